@@ -0,0 +1,122 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for adding, removing and re-classing endpoints in one batch
+ * Usage: npm run endpoints -- <spec.yaml> [ops.yaml] [--add ...] [--remove ...] [--reclass ...]
+ */
+
+import { readFileSync, writeFileSync } from 'fs'
+import * as yaml from 'js-yaml'
+import { applyEndpointBatch } from '../src/utils/bulk-endpoints.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run endpoints -- <spec.yaml> [ops.yaml] [options]
+
+Applies a batch of endpoint operations to a fabric spec. The batch is
+all-or-nothing: if any operation or the final recompute fails, the spec
+is left unchanged.
+
+Arguments:
+  spec.yaml                        Fabric spec with leaf classes (YAML or JSON)
+  ops.yaml                         Optional list of operations, e.g.
+                                   - { op: add, classId: compute, profileName: GPU, count: 200 }
+
+Options:
+  --add <class:profile:count>      Add endpoints (repeatable)
+  --remove <class:profile:count>   Remove endpoints (repeatable)
+  --reclass <from:to:profile:count>
+                                   Move endpoints between classes (repeatable)
+  --output <file>                  Write the updated spec (default: overwrite spec.yaml)
+  --dry-run                        Report changes without writing
+
+Examples:
+  npm run endpoints -- fabric.yaml --add compute:Server:200 --remove storage:Storage:8
+  npm run endpoints -- fabric.yaml migration.yaml --dry-run
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function readYaml(path, what) {
+  try {
+    return yaml.load(readFileSync(path, 'utf8'))
+  } catch (error) {
+    exitWithError(`Cannot read ${what} ${path}: ${error.message}`)
+  }
+}
+
+function parseVerb(op, value) {
+  const parts = value.split(':')
+  const count = Number(parts[parts.length - 1])
+  if (op === 'reclass' && parts.length === 4) {
+    return { op, fromClassId: parts[0], toClassId: parts[1], profileName: parts[2], count }
+  }
+  if (op !== 'reclass' && parts.length === 3) {
+    return { op, classId: parts[0], profileName: parts[1], count }
+  }
+  exitWithError(`--${op} expects ${op === 'reclass' ? 'from:to:profile:count' : 'class:profile:count'}, got '${value}'`)
+}
+
+function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const operations = []
+  const positional = []
+  let output
+  let dryRun = false
+
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i]
+    if (arg === '--dry-run') {
+      dryRun = true
+    } else if (['--add', '--remove', '--reclass', '--output'].includes(arg)) {
+      const value = args[++i]
+      if (!value || value.startsWith('--')) exitWithError(`${arg} requires an argument`)
+      if (arg === '--output') output = value
+      else operations.push(parseVerb(arg.slice(2), value))
+    } else if (arg.startsWith('--')) {
+      exitWithError(`Unknown option ${arg}`)
+    } else {
+      positional.push(arg)
+    }
+  }
+
+  if (positional.length < 1 || positional.length > 2) exitWithError('Expected a spec file and an optional operations file')
+  const [specPath, opsPath] = positional
+
+  const spec = readYaml(specPath, 'fabric spec')
+  if (opsPath) {
+    const fileOps = readYaml(opsPath, 'operations')
+    if (!Array.isArray(fileOps)) exitWithError(`${opsPath} must contain a list of operations`)
+    operations.unshift(...fileOps)
+  }
+  if (operations.length === 0) exitWithError('No operations given')
+
+  const result = applyEndpointBatch(spec, operations)
+  if (!result.applied) {
+    for (const err of result.errors) console.error(`  - ${err}`)
+    exitWithError(`Batch of ${operations.length} operation(s) rejected; spec unchanged`, 2)
+  }
+
+  for (const change of result.changes) {
+    console.log(`  ${change.path}: ${change.before} → ${change.after}`)
+  }
+  console.log(`${operations.length} operation(s) applied; ${result.capacity.totalEndpoints} endpoints in total`)
+
+  if (dryRun) return
+  const target = output || specPath
+  const text = /\.json$/i.test(target) ? JSON.stringify(result.spec, null, 2) + '\n' : yaml.dump(result.spec)
+  writeFileSync(target, text)
+  console.log(`✅ Wrote ${target}`)
+}
+
+main()
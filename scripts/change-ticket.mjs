@@ -0,0 +1,186 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for filing a change ticket for an approved export bundle
+ * Usage: npm run ticket -- <bundle-dir> --wiring <wiring.json> [--config <file>] [--approved-by <name>] [--dry-run]
+ */
+
+import { spawnSync } from 'child_process'
+import { existsSync, mkdtempSync, readFileSync, readdirSync, rmSync, statSync } from 'fs'
+import { tmpdir } from 'os'
+import { basename, dirname, join, relative, resolve } from 'path'
+import { validateWiring } from '../src/domain/wiring.ts'
+import { MANIFEST_FILE, verifyExportManifest } from '../src/io/export-manifest.ts'
+import {
+  TICKET_CONFIG_FILE,
+  attachmentRequest,
+  createTicketRequest,
+  parseCreatedTicket,
+  parseTicketConfig
+} from '../src/io/change-tickets.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run ticket -- [bundle-dir] --wiring <wiring.json> [options]
+
+Files a Jira issue or ServiceNow change request for an approved export
+bundle. The ticket lists the design's validation summary and the checksum
+of every artifact, and the bundle is attached as a .tar.gz. Run it from the
+approval step of your workflow, or as a post hook of npm run manifest.
+
+Arguments:
+  bundle-dir            Export directory sealed by npm run manifest -- write
+                        (default: HNC_EXPORT_DIR, as set for export hooks)
+
+Options:
+  --wiring <file>       Wiring JSON of the design, validated for the summary
+  --config <file>       Ticket settings (default: ${TICKET_CONFIG_FILE} in the
+                        workspace, the directory above bundle-dir)
+  --approved-by <name>  Approver recorded in the ticket
+  --allow-errors        File the ticket even if validation reports errors
+  --dry-run             Print the requests instead of sending them
+
+  ${TICKET_CONFIG_FILE}:
+    { "system": "jira", "url": "https://acme.atlassian.net", "project": "NET",
+      "issueType": "Change", "labels": ["hnc"] }
+    { "system": "servicenow", "url": "https://acme.service-now.com",
+      "assignmentGroup": "Network Engineering" }
+
+Environment Variables:
+  HNC_TICKET_USER       Jira account email or ServiceNow user
+  HNC_TICKET_TOKEN      Jira API token or ServiceNow password
+  HNC_EXPORT_DIR        Default bundle-dir
+
+Exit codes:
+  0  ticket filed (or printed with --dry-run)
+  1  usage, I/O or API error
+  2  the bundle does not match its manifest, or validation failed
+
+Examples:
+  npm run ticket -- fgd/prod-fabric-01 --wiring prod-fabric-01.wiring.json --approved-by "A. Rivera"
+  npm run ticket -- fgd/prod-fabric-01 --wiring prod-fabric-01.wiring.json --dry-run
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function readJson(file, what) {
+  try {
+    return JSON.parse(readFileSync(file, 'utf8'))
+  } catch (error) {
+    exitWithError(`Cannot read ${what} ${file}: ${error.message}`)
+  }
+}
+
+function readTree(dir, root = dir, files = {}) {
+  for (const name of readdirSync(dir).sort()) {
+    const path = join(dir, name)
+    if (statSync(path).isDirectory()) readTree(path, root, files)
+    else files[relative(root, path).split('\\').join('/')] = readFileSync(path, 'utf8')
+  }
+  return files
+}
+
+async function send(request, body) {
+  const response = await fetch(request.url, { method: request.method, headers: request.headers, body })
+  const text = await response.text()
+  if (!response.ok) throw new Error(`${request.method} ${request.url}: HTTP ${response.status} ${text.slice(0, 200)}`)
+  return text ? JSON.parse(text) : {}
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h')) {
+    printUsage()
+    process.exit(0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+  const flag = (name) => {
+    const i = args.indexOf(name)
+    if (i !== -1) args.splice(i, 1)
+    return i !== -1
+  }
+
+  const wiringFile = option('--wiring')
+  const configOption = option('--config')
+  const approvedBy = option('--approved-by')
+  const allowErrors = flag('--allow-errors')
+  const dryRun = flag('--dry-run')
+  if (args.length > 1) exitWithError(`Unexpected arguments: ${args.slice(1).join(' ')}`)
+  const dir = args[0] ?? process.env.HNC_EXPORT_DIR
+  if (!dir) {
+    printUsage()
+    process.exit(1)
+  }
+  if (!wiringFile) exitWithError('--wiring is required')
+  if (!existsSync(dir) || !statSync(dir).isDirectory()) exitWithError(`Not a directory: ${dir}`)
+
+  const manifestPath = join(dir, MANIFEST_FILE)
+  if (!existsSync(manifestPath)) exitWithError(`No ${MANIFEST_FILE} in ${dir}; seal it with npm run manifest -- write first`)
+  const manifest = readJson(manifestPath, 'manifest')
+  const verification = await verifyExportManifest(manifest, readTree(dir))
+  if (!verification.ok) {
+    exitWithError(`${dir} changed since it was sealed (modified: ${verification.modified.join(', ') || 'none'}; ` +
+      `missing: ${verification.missing.join(', ') || 'none'}; unexpected: ${verification.unexpected.join(', ') || 'none'})`, 2)
+  }
+
+  const configFile = configOption ?? join(dirname(resolve(dir)), TICKET_CONFIG_FILE)
+  const { config, errors: configErrors } = parseTicketConfig(readJson(configFile, 'ticket config'))
+  if (!config) exitWithError(`${configFile}: ${configErrors.join('; ')}`)
+
+  const validation = validateWiring(readJson(wiringFile, 'wiring'))
+  if (validation.errors.length > 0 && !allowErrors) {
+    for (const error of validation.errors) console.error(`  ${error}`)
+    exitWithError(`${wiringFile} has ${validation.errors.length} validation error(s); fix them or pass --allow-errors`, 2)
+  }
+
+  const summary = { fabric: manifest.fabric, ...(approvedBy && { approvedBy }), manifest, validation }
+  const user = process.env.HNC_TICKET_USER
+  const token = process.env.HNC_TICKET_TOKEN
+  if (!dryRun && (!user || !token)) exitWithError('HNC_TICKET_USER and HNC_TICKET_TOKEN must be set')
+  const auth = dryRun ? 'Basic <redacted>' : `Basic ${Buffer.from(`${user}:${token}`).toString('base64')}`
+  const bundleName = `${basename(resolve(dir))}.tar.gz`
+  const create = createTicketRequest(config, summary, auth)
+
+  if (dryRun) {
+    const placeholder = { id: '<id>', key: '<key>', url: '' }
+    console.log(JSON.stringify({ create, attach: { ...attachmentRequest(config, placeholder, bundleName, auth), file: bundleName } }, null, 2))
+    return
+  }
+
+  const workDir = mkdtempSync(join(tmpdir(), 'hnc-ticket-'))
+  try {
+    const bundlePath = join(workDir, bundleName)
+    const tar = spawnSync('tar', ['-czf', bundlePath, '-C', dir, '.'], { encoding: 'utf8' })
+    if (tar.error || tar.status !== 0) exitWithError(`Cannot pack ${dir}: ${tar.error?.message ?? tar.stderr}`)
+    const bundle = readFileSync(bundlePath)
+
+    const ticket = parseCreatedTicket(config, await send(create, JSON.stringify(create.body)))
+    console.log(`🎫 Created ${ticket.key}: ${ticket.url}`)
+
+    const attach = attachmentRequest(config, ticket, bundleName, auth)
+    let body = bundle
+    if (config.system === 'jira') {
+      body = new FormData()
+      body.append('file', new Blob([bundle], { type: 'application/gzip' }), bundleName)
+    }
+    await send(attach, body)
+    console.log(`✅ Attached ${bundleName} (${bundle.length} bytes, ${manifest.artifacts.length} checksums) to ${ticket.key}`)
+  } finally {
+    rmSync(workDir, { recursive: true, force: true })
+  }
+}
+
+main().catch(error => exitWithError(error.message))
@@ -0,0 +1,107 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for generating a design comparison report
+ * Usage: npm run compare -- <before-fabric-id> <after-fabric-id> --out report.html
+ */
+
+import { existsSync, readFileSync, writeFileSync } from 'fs'
+import { join } from 'path'
+import { spawnSync } from 'child_process'
+import * as yaml from 'js-yaml'
+import { loadFGD } from '../src/io/fgd.ts'
+import { compareRevisions, renderCompareReportHtml } from '../src/io/compare-report.ts'
+import { REVIEW_COMMENTS_FILE, parseReviewComments } from '../src/domain/review-comments.ts'
+import { comparisonReport, pagerCommand, renderTerminalReport, useColor } from '../src/io/terminal-report.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run compare -- <before-fabric-id> <after-fabric-id> [options]
+
+Renders a standalone HTML report comparing two saved design revisions, or
+a text report for the terminal. Open review comments on the proposed
+revision (npm run review) are listed with the objects they are about.
+
+Arguments:
+  before-fabric-id        Baseline revision under the FGD directory
+  after-fabric-id         Proposed revision under the FGD directory
+
+Options:
+  --out <file>            Write the report here (default: stdout)
+  --format <html|text>    Report format (default: html)
+  --color, --no-color     Force or drop color in text reports (default: on
+                          for terminals, off when piped, in CI or NO_COLOR)
+  --pager                 Page long text reports through $PAGER
+  --title <text>          Report title
+  --base-dir <dir>        FGD directory (default: ./fgd)
+  --addressing <a.yaml>,<b.yaml>
+                          Name -> address maps for each revision
+
+Examples:
+  npm run compare -- prod-fabric-01 prod-fabric-01-rev2 --out cab-review.html
+  npm run compare -- prod-fabric-01 prod-fabric-01-rev2 --format text --pager
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+
+  const flags = args.filter(a => ['--color', '--no-color', '--pager'].includes(a))
+  args.splice(0, args.length, ...args.filter(a => !flags.includes(a)))
+  const outFile = option('--out')
+  const format = option('--format') ?? 'html'
+  if (!['html', 'text'].includes(format)) exitWithError(`--format must be html or text, got ${format}`)
+  const title = option('--title')
+  const baseDir = option('--base-dir')
+  const addressingFiles = option('--addressing')?.split(',')
+  if (args.length !== 2) exitWithError('Expected <before-fabric-id> and <after-fabric-id>')
+  if (addressingFiles && addressingFiles.length !== 2) exitWithError('--addressing expects two comma-separated files')
+
+  const revisions = []
+  for (const [i, fabricId] of args.entries()) {
+    const loaded = await loadFGD({ fabricId, baseDir })
+    if (!loaded.success || !loaded.diagram) {
+      exitWithError(`Cannot load fabric ${fabricId}: ${loaded.error || 'unknown error'}`)
+    }
+    const addressing = addressingFiles ? yaml.load(readFileSync(addressingFiles[i], 'utf8')) : undefined
+    const commentsFile = join(baseDir ?? './fgd', fabricId, REVIEW_COMMENTS_FILE)
+    const review = existsSync(commentsFile) ? parseReviewComments(JSON.parse(readFileSync(commentsFile, 'utf8'))) : undefined
+    for (const error of review?.errors ?? []) console.error(`⚠️  ${commentsFile}: ${error}`)
+    revisions.push({ label: fabricId, diagram: loaded.diagram, addressing, ...(review && { comments: review.comments }) })
+  }
+
+  const comparison = compareRevisions(revisions[0], revisions[1])
+  const term = { argv: flags, env: process.env, isTTY: Boolean(process.stdout.isTTY) && !outFile }
+  const report = format === 'html'
+    ? renderCompareReportHtml(comparison, { title })
+    : renderTerminalReport({ ...comparisonReport(comparison), ...(title && { title }) }, { color: useColor(term) })
+  if (outFile) {
+    writeFileSync(outFile, report)
+    console.log(`✅ Compared ${args[0]} -> ${args[1]}: ${outFile}`)
+  } else {
+    const pager = format === 'text' && pagerCommand(term, report.split('\n').length, process.stdout.rows)
+    if (pager && spawnSync(pager, { input: report, stdio: ['pipe', 'inherit', 'inherit'], shell: true }).status === 0) return
+    process.stdout.write(report)
+  }
+}
+
+main().catch(error => exitWithError(error.message))
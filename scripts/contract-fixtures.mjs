@@ -0,0 +1,56 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for regenerating frontend/Go contract fixtures
+ * Usage: npm run fixtures:contract [-- --check]
+ */
+
+import { existsSync, mkdirSync, readFileSync, writeFileSync } from 'fs'
+import { dirname, join } from 'path'
+import { buildContractFixtures, CONTRACT_FIXTURES_DIR } from '../src/io/contract-fixtures.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run fixtures:contract [-- --check]
+
+Writes the shared fixtures consumed by the vitest and Go test suites to
+${CONTRACT_FIXTURES_DIR}.
+
+Options:
+  --check   Do not write; exit non-zero if any fixture is stale
+`)
+}
+
+function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h')) {
+    printUsage()
+    process.exit(0)
+  }
+
+  const check = args.includes('--check')
+  const stale = []
+
+  for (const [relative, content] of Object.entries(buildContractFixtures())) {
+    const path = join(CONTRACT_FIXTURES_DIR, relative)
+    const current = existsSync(path) ? readFileSync(path, 'utf8') : null
+    if (current === content) continue
+
+    stale.push(path)
+    if (!check) {
+      mkdirSync(dirname(path), { recursive: true })
+      writeFileSync(path, content)
+      console.log(`✅ Wrote ${path}`)
+    }
+  }
+
+  if (check && stale.length > 0) {
+    for (const path of stale) console.error(`  - ${path}`)
+    console.error(`❌ Error: ${stale.length} contract fixture(s) are stale; run npm run fixtures:contract`)
+    process.exit(1)
+  }
+  if (stale.length === 0) console.log('Contract fixtures are up to date')
+}
+
+main()
@@ -0,0 +1,118 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for checking VPC DHCP ranges and exporting them as Kea config
+ * Usage: npm run dhcp -- <vpcs.yaml> [--output kea-dhcp4.json] [--interface eth1] [--no-color]
+ */
+
+import { readFileSync, writeFileSync } from 'fs'
+import { planDhcpScopes, renderKeaDhcp4 } from '../src/io/dhcp-scopes.ts'
+import { deserializeCRDsToVPCConfig } from '../src/io/vpc-yaml.ts'
+import { renderTerminalReport, useColor } from '../src/io/terminal-report.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run dhcp -- <vpcs.yaml> [options]
+
+Checks every DHCP-enabled VPC subnet's range against the subnet size and
+its reserved addresses (network, gateway, broadcast, static route next
+hops), fills in open start/end bounds, and writes Kea DHCPv4 config.
+
+Arguments:
+  vpcs.yaml           VPC objects exported from the designer
+
+Options:
+  --output <file>     Write the Kea Dhcp4 config to <file>
+  --interface <name>  Interface Kea listens on; repeat for several
+                      (default: all interfaces)
+  --lifetime <sec>    Lease lifetime in seconds (default: 3600)
+  --color, --no-color Force or drop color in the report
+
+Exit codes:
+  0  every range fits (warnings allowed)
+  1  usage or I/O error
+  2  a DHCP range does not fit its subnet
+
+Examples:
+  npm run dhcp -- exports/vpcs.yaml
+  npm run dhcp -- exports/vpcs.yaml --output kea-dhcp4.json --interface eth1
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+
+  const repeated = (flag) => {
+    const values = []
+    for (let value = option(flag); value !== undefined; value = option(flag)) values.push(value)
+    return values
+  }
+
+  const output = option('--output')
+  const interfaces = repeated('--interface')
+  const lifetime = option('--lifetime')
+  if (lifetime !== undefined && !/^\d+$/.test(lifetime)) exitWithError('--lifetime must be a whole number of seconds')
+  const positional = args.filter(a => !a.startsWith('--'))
+  if (positional.length !== 1) exitWithError('Expected <vpcs.yaml>')
+
+  let vpcs
+  try {
+    const empty = { vpcAttachments: '', vpcPeerings: '', externals: '', externalAttachments: '', externalPeerings: '' }
+    vpcs = deserializeCRDsToVPCConfig({ vpcs: readFileSync(positional[0], 'utf8'), ...empty }).vpcs
+  } catch (error) {
+    exitWithError(`Cannot read VPCs from ${positional[0]}: ${error.message}`)
+  }
+
+  const plan = planDhcpScopes(vpcs)
+  const findings = [
+    ...plan.errors.map(message => ({ severity: 'error', message })),
+    ...plan.warnings.map(message => ({ severity: 'warning', message }))
+  ]
+  const report = {
+    title: `DHCP scopes: ${positional[0]}`,
+    sections: [
+      {
+        heading: `${plan.scopes.length} scope${plan.scopes.length === 1 ? '' : 's'}`,
+        table: {
+          headers: ['VPC', 'Subnet', 'CIDR', 'Gateway', 'Range', 'Addresses'],
+          rows: plan.scopes.map(s => ({
+            cells: [s.vpc, s.subnet, s.cidr, s.gateway, `${s.start} - ${s.end}${s.derived ? ' (planned)' : ''}`, String(s.size)]
+          }))
+        }
+      },
+      ...(findings.length > 0 ? [{ heading: 'Problems', findings }] : [])
+    ]
+  }
+  const term = { argv: args, env: process.env, isTTY: Boolean(process.stdout.isTTY) }
+  process.stdout.write(renderTerminalReport(report, { color: useColor(term) }))
+
+  if (plan.errors.length > 0) process.exit(2)
+  if (output) {
+    writeFileSync(output, renderKeaDhcp4(plan.scopes, {
+      ...(interfaces.length > 0 && { interfaces }),
+      ...(lifetime !== undefined && { validLifetime: Number(lifetime) })
+    }))
+    console.log(`✅ Wrote ${plan.scopes.length} Kea subnet4 entries -> ${output}`)
+  }
+}
+
+main()
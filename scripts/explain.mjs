@@ -0,0 +1,98 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for explaining allocation decisions
+ * Usage: npm run explain -- <spec.yaml> <target>
+ *
+ * Replays allocation and wiring for the spec with a decision trace and
+ * prints why the target (connection, device or device port) was placed.
+ */
+
+import { readFileSync } from 'fs'
+import * as yaml from 'js-yaml'
+import { loadSwitchProfiles } from '../src/ingest/profileLoader.ts'
+import { allocateMultiClassUplinks } from '../src/domain/allocator.ts'
+import { traceWiring, explainArtifact, formatExplanation } from '../src/domain/explain.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run explain -- <spec.yaml> <target> [--json]
+
+Explains why a connection, device or port ended up where it is.
+
+Arguments:
+  spec.yaml   Fabric spec (YAML or JSON)
+  target      Connection id, device id, or device:port
+
+Options:
+  --json      Print the matching decisions as JSON
+
+Examples:
+  npm run explain -- fabric.yaml srv-default-standardserver-42
+  npm run explain -- fabric.yaml leaf-7:E1/13
+  npm run explain -- fabric.yaml link-leaf-1-spine-2-1
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+/**
+ * Fixture profiles use vendor model ids (celestica-ds2000); specs use the
+ * short names (DS2000), so index both.
+ */
+function indexProfiles(loaded) {
+  const profiles = new Map()
+  for (const [modelId, profile] of loaded) {
+    profiles.set(modelId, profile)
+    profiles.set(modelId.replace(/^[a-z]+-/, '').toUpperCase(), profile)
+  }
+  return profiles
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const asJson = args.includes('--json')
+  const positional = args.filter(a => a !== '--json')
+  if (positional.length !== 2) exitWithError('Expected <spec.yaml> and <target>')
+  const [specFile, target] = positional
+
+  let spec
+  try {
+    spec = yaml.load(readFileSync(specFile, 'utf8'))
+  } catch (error) {
+    exitWithError(`Cannot read spec ${specFile}: ${error.message}`)
+  }
+
+  const { profiles: loaded, errors } = await loadSwitchProfiles()
+  if (errors.length > 0) errors.forEach(err => console.warn(`⚠️  ${err}`))
+  const profiles = indexProfiles(loaded)
+
+  const spineProfile = profiles.get(spec.spineModelId)
+  if (!spineProfile) exitWithError(`Unknown spine model: ${spec.spineModelId}`)
+
+  const multi = allocateMultiClassUplinks(spec, profiles, spineProfile)
+  const allocation = multi.legacy ?? multi
+  const issues = multi.legacy ? multi.legacy.issues : multi.overallIssues
+  if (issues.length > 0) exitWithError(`Allocation failed: ${issues.join('; ')}`, 2)
+
+  const { trace } = traceWiring(spec, profiles, allocation)
+  const explanation = explainArtifact(trace, target)
+
+  if (asJson) {
+    console.log(JSON.stringify(explanation, null, 2))
+  } else {
+    process.stdout.write(formatExplanation(explanation))
+  }
+  if (explanation.decisions.length === 0) process.exit(3)
+}
+
+main().catch(error => exitWithError(error.message))
@@ -0,0 +1,92 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for writing an anonymized copy of an export
+ * Usage: npm run export:anonymize -- <dir> --out <dir>
+ */
+
+import { existsSync, mkdirSync, readdirSync, readFileSync, statSync, writeFileSync } from 'fs'
+import { dirname, join, relative } from 'path'
+import { anonymizeArtifacts } from '../src/io/anonymize.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run export:anonymize -- <dir> --out <dir> [options]
+
+Copies every artifact under <dir> with hostnames, IP and MAC addresses,
+serials and site identifiers replaced by consistent pseudonyms, so the
+design can be shared with vendors or support.
+
+Arguments:
+  dir                 Export directory, e.g. fgd/<fabric-id> or CRD output
+
+Options:
+  --out <dir>         Directory for the anonymized copy (required)
+  --site <name>       Site identifier to replace; repeat for several
+  --host <name>       Extra hostname to replace; repeat for several
+  --mapping <file>    Write original -> pseudonym JSON here; keep it private
+
+Examples:
+  npm run export:anonymize -- fgd/prod-fabric-01 --out /tmp/share --site nyc1
+  npm run export:anonymize -- fgd/prod-fabric-01 --out /tmp/share --mapping ~/prod-fabric-01.map.json
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function readTree(dir, root = dir, files = {}) {
+  for (const name of readdirSync(dir).sort()) {
+    const path = join(dir, name)
+    if (statSync(path).isDirectory()) readTree(path, root, files)
+    else files[relative(root, path).split('\\').join('/')] = readFileSync(path, 'utf8')
+  }
+  return files
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+  const repeated = (flag) => {
+    const values = []
+    for (let value = option(flag); value !== undefined; value = option(flag)) values.push(value)
+    return values
+  }
+
+  const outDir = option('--out')
+  const mappingFile = option('--mapping')
+  const sites = repeated('--site')
+  const hostnames = repeated('--host')
+  if (!outDir) exitWithError('--out is required')
+  if (args.length !== 1) exitWithError('Expected exactly one argument (dir)')
+  const [dir] = args
+  if (!existsSync(dir) || !statSync(dir).isDirectory()) exitWithError(`Not a directory: ${dir}`)
+
+  const { files, mapping } = anonymizeArtifacts(readTree(dir), { sites, hostnames })
+  for (const [path, content] of Object.entries(files)) {
+    const target = join(outDir, path)
+    mkdirSync(dirname(target), { recursive: true })
+    writeFileSync(target, content)
+  }
+  if (mappingFile) writeFileSync(mappingFile, JSON.stringify(mapping, null, 2) + '\n')
+
+  console.log(`✅ Anonymized ${Object.keys(files).length} files (${Object.keys(mapping).length} values replaced) -> ${outDir}`)
+  if (mappingFile) console.log(`   Mapping written to ${mappingFile}; do not share it`)
+}
+
+main().catch(error => exitWithError(error.message))
@@ -0,0 +1,147 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for recording and verifying export checksums
+ * Usage: npm run manifest -- write <dir> [--pre-hook cmd] [--post-hook cmd] | verify <dir>
+ */
+
+import { spawnSync } from 'child_process'
+import { existsSync, readdirSync, readFileSync, statSync, writeFileSync } from 'fs'
+import { basename, join, relative, resolve } from 'path'
+import { MANIFEST_FILE, buildExportManifest, verifyExportManifest } from '../src/io/export-manifest.ts'
+import { parseHook, runExportHooks } from '../src/io/export-hooks.ts'
+import { manifestDiffReport, renderTerminalReport, useColor } from '../src/io/terminal-report.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run manifest -- <command> <dir> [options]
+
+Records SHA-256 checksums for every exported artifact, and for each switch,
+server and connection document inside multi-document YAML, then detects
+edits made after export.
+
+Commands:
+  write <dir>         Hash every file under <dir> into <dir>/${MANIFEST_FILE}
+  verify <dir>        Compare files under <dir> against its manifest
+
+Options:
+  --fabric <name>     Fabric name recorded by write (default: directory name)
+  --pre-hook <cmd>    Run <cmd> in <dir> before hashing; it may edit or add
+                      artifacts. Repeat for several, run in order
+  --post-hook <cmd>   Run <cmd> in <dir> after ${MANIFEST_FILE} is written,
+                      e.g. to push the bundle. Repeat for several
+  --color, --no-color Force or drop color in the verify report (default: on
+                      for terminals, off when piped, in CI or NO_COLOR)
+
+  Hooks get HNC_EXPORT_DIR, HNC_HOOK_STAGE and HNC_FABRIC in the environment.
+  Their exit codes, output and the files they touched are recorded under
+  "hooks" in the manifest; a hook that exits non-zero stops the export.
+
+Exit codes:
+  0  manifest written, or every artifact matches
+  1  usage or I/O error, or a hook failed
+  2  verify found modified, missing or unexpected artifacts
+
+Examples:
+  npm run manifest -- write fgd/prod-fabric-01
+  npm run manifest -- write fgd/prod-fabric-01 --pre-hook "./hooks/add-header.sh --owner netops"
+  npm run manifest -- verify fgd/prod-fabric-01
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function readTree(dir, root = dir, files = {}) {
+  for (const name of readdirSync(dir).sort()) {
+    const path = join(dir, name)
+    if (statSync(path).isDirectory()) readTree(path, root, files)
+    else files[relative(root, path).split('\\').join('/')] = readFileSync(path, 'utf8')
+  }
+  return files
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+
+  const repeated = (flag) => {
+    const values = []
+    for (let value = option(flag); value !== undefined; value = option(flag)) values.push(value)
+    return values
+  }
+
+  const colorFlags = args.filter(a => a === '--color' || a === '--no-color')
+  args.splice(0, args.length, ...args.filter(a => !colorFlags.includes(a)))
+  const fabric = option('--fabric')
+  const hooks = [
+    ...repeated('--pre-hook').map(cmd => parseHook('pre', cmd)),
+    ...repeated('--post-hook').map(cmd => parseHook('post', cmd))
+  ]
+  if (args.length !== 2) exitWithError('Expected a command (write or verify) and a directory')
+  const [command, dir] = args
+  if (!existsSync(dir) || !statSync(dir).isDirectory()) exitWithError(`Not a directory: ${dir}`)
+  const manifestPath = join(dir, MANIFEST_FILE)
+
+  if (command === 'write') {
+    const name = fabric || basename(dir)
+    const artifacts = () => {
+      const { [MANIFEST_FILE]: _manifest, ...files } = readTree(dir)
+      return files
+    }
+    const exec = (hook) => {
+      const result = spawnSync(hook.command, hook.args ?? [], {
+        cwd: dir,
+        encoding: 'utf8',
+        env: { ...process.env, HNC_EXPORT_DIR: resolve(dir), HNC_HOOK_STAGE: hook.stage, HNC_FABRIC: name }
+      })
+      if (result.error) throw result.error
+      return { exitCode: result.status ?? -1, stdout: result.stdout, stderr: result.stderr }
+    }
+    const write = async (records) => {
+      const manifest = await buildExportManifest(name, artifacts(), { hooks: records })
+      writeFileSync(manifestPath, JSON.stringify(manifest, null, 2) + '\n')
+      return manifest
+    }
+
+    const pre = await runExportHooks('pre', hooks, exec, artifacts)
+    if (pre.errors.length > 0) exitWithError(pre.errors.join('; '))
+    let manifest = await write(pre.records)
+    const post = await runExportHooks('post', hooks, exec, artifacts)
+    if (post.records.length > 0) manifest = await write([...pre.records, ...post.records])
+    if (post.errors.length > 0) exitWithError(post.errors.join('; '))
+    for (const record of manifest.hooks ?? []) {
+      console.log(`🪝 ${record.stage} ${record.command}: exit ${record.exitCode}, ${record.added.length} added, ${record.modified.length} modified`)
+    }
+    console.log(`✅ Recorded ${manifest.artifacts.length} checksums -> ${manifestPath}`)
+  } else if (command === 'verify') {
+    if (!existsSync(manifestPath)) exitWithError(`No ${MANIFEST_FILE} in ${dir}; run write first`)
+    const result = await verifyExportManifest(JSON.parse(readFileSync(manifestPath, 'utf8')), readTree(dir))
+    if (result.ok) {
+      console.log(`✅ All artifacts in ${dir} match ${MANIFEST_FILE}`)
+      return
+    }
+    const term = { argv: colorFlags, env: process.env, isTTY: Boolean(process.stdout.isTTY) }
+    process.stdout.write(renderTerminalReport(manifestDiffReport(result, dir), { color: useColor(term) }))
+    process.exit(2)
+  } else {
+    exitWithError(`Unknown command: ${command}`)
+  }
+}
+
+main().catch(error => exitWithError(error.message))
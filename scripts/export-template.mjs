@@ -0,0 +1,107 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for exporting a fabric through a user-supplied template
+ * Usage: npm run export:template -- --template <name> <fabric-id> [--target-release <release>]
+ *
+ * Templates are looked up in HNC_TEMPLATES_DIR (default: ./templates) by
+ * file name, with or without the .tmpl extension, or used as a direct path.
+ */
+
+import { existsSync, readFileSync, writeFileSync } from 'fs'
+import { join } from 'path'
+import { loadFGD } from '../src/io/fgd.ts'
+import { renderExportTemplate } from '../src/io/export-template.ts'
+import { convertWiringDiagramToFabricCRDs } from '../src/io/crd-yaml.ts'
+import { HEDGEHOG_RELEASES, checkReleaseCompatibility } from '../src/io/release-compat.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run export:template -- --template <name> <fabric-id> [--out <file>]
+                                  [--target-release <release>]
+
+Renders a saved fabric through a text/template file.
+
+Arguments:
+  fabric-id           ID of the fabric under ./fgd
+
+Options:
+  --template <name>   Template name in HNC_TEMPLATES_DIR, or a path
+  --out <file>        Write output to a file instead of stdout
+  --target-release <release>
+                      Refuse to export a design that uses features the
+                      Hedgehog release does not support (${HEDGEHOG_RELEASES.join(', ')})
+
+Environment Variables:
+  HNC_TEMPLATES_DIR   Template directory (default: ./templates)
+
+Examples:
+  npm run export:template -- --template cmdb-devices.csv prod-fabric-01
+  npm run export:template -- --template examples/export-templates/cmdb-devices.csv.tmpl test-fabric
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function findTemplate(name) {
+  const dir = process.env.HNC_TEMPLATES_DIR || './templates'
+  const candidates = [name, join(dir, name), join(dir, `${name}.tmpl`)]
+  return candidates.find(path => existsSync(path))
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h')) {
+    printUsage()
+    process.exit(0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+
+  const templateName = option('--template')
+  const outFile = option('--out')
+  const targetRelease = option('--target-release')
+  if (!templateName) exitWithError('--template is required')
+  if (args.length !== 1) exitWithError('Expected exactly one argument (fabric-id)')
+
+  const templatePath = findTemplate(templateName)
+  if (!templatePath) exitWithError(`Template not found: ${templateName}`)
+
+  const loaded = await loadFGD({ fabricId: args[0] })
+  if (!loaded.success || !loaded.diagram) {
+    exitWithError(`Cannot load fabric ${args[0]}: ${loaded.error || 'unknown error'}`)
+  }
+
+  if (targetRelease) {
+    const compatibility = checkReleaseCompatibility(convertWiringDiagramToFabricCRDs(loaded.diagram, {}), targetRelease)
+    for (const warning of compatibility.warnings) console.warn(`⚠️  ${warning}`)
+    if (compatibility.errors.length > 0) exitWithError(`Not compatible with Hedgehog ${targetRelease}: ${compatibility.errors.join('; ')}`, 2)
+  }
+
+  let output
+  try {
+    output = renderExportTemplate(readFileSync(templatePath, 'utf8'), loaded.diagram)
+  } catch (error) {
+    exitWithError(`${templatePath}: ${error.message}`, 2)
+  }
+
+  if (outFile) {
+    writeFileSync(outFile, output)
+    console.log(`✅ Exported ${args[0]} via ${templatePath} -> ${outFile}`)
+  } else {
+    process.stdout.write(output)
+  }
+}
+
+main().catch(error => exitWithError(error.message))
@@ -0,0 +1,123 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for reconciling installed transceivers against a design
+ * Usage: npm run optics -- <wiring.json> [--inventory <agents.json>] [--namespace <ns>] [--json]
+ */
+
+import { spawnSync } from 'child_process'
+import { readFileSync } from 'fs'
+import { wiringToWiringDiagram } from '../src/domain/wiring.ts'
+import { parseAgentTransceivers, reconcileOptics } from '../src/io/optics-inventory.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run optics -- <wiring.json> [options]
+
+Collects installed transceivers (type, serial, DOM) from the fabric's Agent
+resources and reports, per switch port, optics that are missing, of the
+wrong type, or installed where the design has no link.
+
+Arguments:
+  wiring.json         Wiring JSON exported from the designer
+
+Options:
+  --inventory <file>  Read Agent resources from a file (kubectl get agents
+                      -o json) instead of the cluster
+  --namespace <ns>    Namespace of the Agent resources (default: fab)
+  --min-rx-power <dBm>
+                      Also flag optics receiving less than <dBm>
+  --max-temperature <C>
+                      Also flag optics hotter than <C> degrees
+  --json              Print the reconciliation as JSON
+
+Environment Variables:
+  KUBECONFIG          Cluster kubectl reads Agent resources from
+
+Exit codes:
+  0  every expected optic is installed and of the right type
+  1  usage, I/O or kubectl error
+  2  missing or mismatched optics
+
+Examples:
+  npm run optics -- wiring.json
+  npm run optics -- wiring.json --inventory agents.json --min-rx-power -12 --json
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function readJson(file, what) {
+  try {
+    return JSON.parse(readFileSync(file, 'utf8'))
+  } catch (error) {
+    exitWithError(`Cannot read ${what} ${file}: ${error.message}`)
+  }
+}
+
+function kubectlAgents(namespace) {
+  const result = spawnSync('kubectl', ['get', 'agents.agent.githedgehog.com', '-n', namespace, '-o', 'json'], { encoding: 'utf8' })
+  if (result.error) exitWithError(`kubectl: ${result.error.message}`)
+  if (result.status !== 0) exitWithError(result.stderr.trim() || `kubectl exited ${result.status}`)
+  return JSON.parse(result.stdout)
+}
+
+function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+  const number = (flag) => {
+    const value = option(flag)
+    if (value === undefined) return undefined
+    if (!Number.isFinite(Number(value))) exitWithError(`${flag} must be a number`)
+    return Number(value)
+  }
+
+  const inventoryFile = option('--inventory')
+  const namespace = option('--namespace') || 'fab'
+  const minRxPowerDbm = number('--min-rx-power')
+  const maxTemperatureC = number('--max-temperature')
+  const positional = args.filter(a => !a.startsWith('--'))
+  if (positional.length !== 1) exitWithError('Expected <wiring.json>')
+
+  const wiring = readJson(positional[0], 'wiring')
+  wiring.metadata.generatedAt = new Date(wiring.metadata.generatedAt)
+  const agents = inventoryFile ? readJson(inventoryFile, 'inventory') : kubectlAgents(namespace)
+
+  const result = reconcileOptics(wiringToWiringDiagram(wiring), parseAgentTransceivers(agents), {
+    domLimits: {
+      ...(minRxPowerDbm !== undefined && { minRxPowerDbm }),
+      ...(maxTemperatureC !== undefined && { maxTemperatureC })
+    }
+  })
+
+  if (args.includes('--json')) {
+    console.log(JSON.stringify(result, null, 2))
+  } else {
+    for (const port of result.ports) {
+      if (port.status === 'ok' && port.problems.length === 0) continue
+      const serial = port.installed?.serial ? ` (serial ${port.installed.serial})` : ''
+      console.log(`${port.status.padEnd(10)} ${port.device} ${port.port}${serial}: ${port.problems.join('; ')}`)
+    }
+    const { ok, mismatch, missing, unexpected } = result.summary
+    console.log(`\n📦 ${ok} ok, ${mismatch} mismatched, ${missing} missing, ${unexpected} unexpected`)
+  }
+  if (result.summary.mismatch > 0 || result.summary.missing > 0) process.exit(2)
+}
+
+main()
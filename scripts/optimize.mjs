@@ -0,0 +1,126 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for searching hardware mixes and uplink counts
+ * Usage: npm run optimize -- <hardware.yaml> --endpoints <n> [options]
+ */
+
+import { readFileSync } from 'fs'
+import { execFileSync } from 'child_process'
+import * as yaml from 'js-yaml'
+import { optimizeDesign, formatDesignComparison, createIlpStrategy } from '../src/domain/design-optimizer.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run optimize -- <hardware.yaml> --endpoints <n> [options]
+
+Ranks the cheapest designs meeting oversubscription, redundancy and
+growth constraints.
+
+Arguments:
+  hardware.yaml          List of leaf/spine hardware options with prices
+
+Options:
+  --endpoints <n>        Endpoints to place (required)
+  --max-oversub <ratio>  Maximum leaf oversubscription (default: 3)
+  --min-spines <n>       Minimum spine count (default: 2)
+  --headroom <fraction>  Spare endpoint capacity to reserve (default: 0)
+  --link-cost <amount>   Optics/cabling cost per fabric link (default: 0)
+  --top <n>              Designs to report (default: 3)
+  --strategy <name>      exhaustive | greedy | annealing | ilp (default: exhaustive)
+  --ilp-solver <cmd>     Solver command for --strategy=ilp; reads the LP model on
+                         stdin and prints a "name value" line per nonzero
+                         variable, or nothing when the model is infeasible
+  --json                 Print the result as JSON
+
+Examples:
+  npm run optimize -- hardware.yaml --endpoints 480 --max-oversub 2.5
+  npm run optimize -- hardware.yaml --endpoints 4000 --strategy annealing --top 5
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag, fallback) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return fallback
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+  const number = (flag, fallback) => {
+    const raw = option(flag)
+    if (raw === undefined) return fallback
+    const value = Number(raw)
+    if (!Number.isFinite(value)) exitWithError(`${flag} must be a number, got '${raw}'`)
+    return value
+  }
+
+  const endpointCount = number('--endpoints')
+  const constraints = {
+    endpointCount,
+    maxOversubscription: number('--max-oversub', 3),
+    minSpines: number('--min-spines', 2),
+    growthHeadroom: number('--headroom', 0),
+    costPerFabricLink: number('--link-cost', 0)
+  }
+  const topN = number('--top', 3)
+  const strategyName = option('--strategy', 'exhaustive')
+  const solver = option('--ilp-solver')
+  const asJson = args.includes('--json')
+  const positional = args.filter(a => a !== '--json')
+
+  if (endpointCount === undefined) exitWithError('--endpoints is required')
+  if (positional.length !== 1) exitWithError('Expected exactly one hardware file')
+
+  let hardware
+  try {
+    hardware = yaml.load(readFileSync(positional[0], 'utf8'))
+  } catch (error) {
+    exitWithError(`Cannot read hardware options ${positional[0]}: ${error.message}`)
+  }
+  if (!Array.isArray(hardware)) exitWithError(`${positional[0]} must contain a list of hardware options`)
+
+  let strategy = strategyName
+  if (strategyName === 'ilp') {
+    if (!solver) exitWithError('--strategy=ilp requires --ilp-solver')
+    const [cmd, ...cmdArgs] = solver.split(/\s+/)
+    strategy = createIlpStrategy(lp => {
+      const lines = execFileSync(cmd, cmdArgs, { input: lp, encoding: 'utf8' }).split('\n').filter(line => line.trim())
+      if (lines.length === 0) return null
+      return Object.fromEntries(lines.map(line => {
+        const [name, value] = line.trim().split(/\s+/)
+        if (!Number.isFinite(Number(value))) exitWithError(`--ilp-solver printed '${line}', not a variable name and value`)
+        return [name, Number(value)]
+      }))
+    })
+  }
+
+  const result = optimizeDesign(hardware, constraints, topN, strategy)
+  if (result.errors.length > 0) {
+    for (const err of result.errors) console.error(`  - ${err}`)
+    exitWithError('Optimization failed', 2)
+  }
+
+  if (asJson) {
+    console.log(JSON.stringify(result, null, 2))
+  } else {
+    process.stdout.write(formatDesignComparison(result))
+    console.log(`\n${result.evaluated} design point(s) evaluated with ${typeof strategy === 'string' ? strategy : strategy.name}`)
+  }
+  if (result.designs.length === 0) process.exit(3)
+}
+
+main()
@@ -0,0 +1,80 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for rendering a parameterized design template
+ * Usage: npm run render -- <template.yaml> --values <site.yaml> [--out <spec.yaml>]
+ */
+
+import { readFileSync, writeFileSync } from 'fs'
+import * as yaml from 'js-yaml'
+import { parseDesignTemplate, renderDesignTemplate } from '../src/templates/design-template.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run render -- <template.yaml> --values <values.yaml> [--out <file>]
+
+Expands a design template into a concrete fabric spec.
+
+Arguments:
+  template.yaml    Design template declaring variables and a spec body
+
+Options:
+  --values <file>  Values file (YAML or JSON) for the template variables
+  --out <file>     Write the rendered spec here instead of stdout
+
+Examples:
+  npm run render -- templates/pod.yaml --values site-a.yaml
+  npm run render -- templates/pod.yaml --values site-b.yaml --out fgd/site-b.yaml
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires a file argument`)
+    args.splice(i, 2)
+    return value
+  }
+
+  const valuesFile = option('--values')
+  const outFile = option('--out')
+  if (args.length !== 1) exitWithError('Expected exactly one template file')
+
+  let template
+  try {
+    template = parseDesignTemplate(readFileSync(args[0], 'utf8'))
+  } catch (error) {
+    exitWithError(`Cannot read template ${args[0]}: ${error.message}`)
+  }
+
+  const values = valuesFile ? readFileSync(valuesFile, 'utf8') : {}
+  const result = renderDesignTemplate(template, values)
+  if (result.errors.length > 0) {
+    for (const err of result.errors) console.error(`  - ${err}`)
+    exitWithError(`Template ${template.name} did not render (${result.errors.length} error(s))`, 2)
+  }
+
+  const output = yaml.dump(result.spec, { sortKeys: true, indent: 2, lineWidth: 120 })
+  if (outFile) {
+    writeFileSync(outFile, output)
+    console.log(`✅ Rendered ${template.name} -> ${outFile}`)
+  } else {
+    process.stdout.write(output)
+  }
+}
+
+main()
@@ -0,0 +1,120 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for review comments on design objects
+ * Usage: npm run review -- <command> <fabric-id> [options]
+ */
+
+import { existsSync, readFileSync, writeFileSync } from 'fs'
+import { join } from 'path'
+import { userInfo } from 'os'
+import {
+  REVIEW_COMMENTS_FILE,
+  addReviewComment,
+  formatTarget,
+  openComments,
+  parseReviewComments,
+  parseTarget,
+  resolveReviewComment
+} from '../src/domain/review-comments.ts'
+import { canonicalJsonFile } from '../src/domain/canonical-hash.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run review -- <command> <fabric-id> [options]
+
+Attaches review comments to a switch, a connection or a subnet of a saved
+design. Comments are kept in ${REVIEW_COMMENTS_FILE} next to the design and
+show up in npm run compare reports.
+
+Commands:
+  add <fabric-id> <target> <text>   Comment on a design object
+  reply <fabric-id> <id> <text>     Answer a comment
+  list <fabric-id>                  Print open comments (--all for resolved too)
+  resolve <fabric-id> <id>          Resolve a comment and its replies
+
+Targets:
+  device:<id>                       device:leaf-1
+  connection:<from> -> <to>         "connection:leaf-1:E1/49 -> spine-1:E1/1"
+  subnet:<name>                     "subnet:leaf-1 loopback"
+
+Options:
+  --author <name>         Comment author (default: $USER)
+  --base-dir <dir>        FGD directory (default: ./fgd)
+  --all                   List resolved comments as well
+
+Examples:
+  npm run review -- add prod-fabric-01 device:leaf-3 "Why a second leaf model?"
+  npm run review -- reply prod-fabric-01 c1 "Stock; same port map" --author ana
+  npm run review -- resolve prod-fabric-01 c1
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+  const flag = (name) => {
+    const i = args.indexOf(name)
+    if (i !== -1) args.splice(i, 1)
+    return i !== -1
+  }
+
+  const author = option('--author') ?? process.env.USER ?? userInfo().username
+  const baseDir = option('--base-dir') ?? './fgd'
+  const all = flag('--all')
+  const [command, fabricId, ...rest] = args
+  if (!fabricId) exitWithError('Expected a command and a <fabric-id>')
+
+  const dir = join(baseDir, fabricId)
+  if (!existsSync(dir)) exitWithError(`No fabric ${fabricId} under ${baseDir}`)
+  const file = join(dir, REVIEW_COMMENTS_FILE)
+  const { comments, errors } = existsSync(file)
+    ? parseReviewComments(JSON.parse(readFileSync(file, 'utf8')))
+    : { comments: [], errors: [] }
+  if (errors.length > 0) exitWithError(`${file}:\n  ${errors.join('\n  ')}`)
+
+  const save = (next) => writeFileSync(file, canonicalJsonFile({ comments: next }))
+
+  if (command === 'add' || command === 'reply') {
+    if (rest.length !== 2) exitWithError(`${command} expects ${command === 'add' ? 'a target' : 'a comment id'} and the comment text`)
+    const target = command === 'add' ? parseTarget(rest[0]) : undefined
+    if (command === 'add' && !target) exitWithError(`Target ${rest[0]} is not device:<id>, connection:<from> -> <to> or subnet:<name>`)
+    const result = addReviewComment(comments, { target, author, body: rest[1], ...(command === 'reply' && { replyTo: rest[0] }) })
+    if (result.error) exitWithError(result.error)
+    save(result.comments)
+    console.log(`✅ ${result.comment.id} on ${formatTarget(result.comment.target)}`)
+  } else if (command === 'list') {
+    for (const c of all ? comments : openComments(comments)) {
+      const state = c.resolvedAt ? ` [resolved by ${c.resolvedBy}]` : ''
+      console.log(`${c.id}${c.replyTo ? ` (re ${c.replyTo})` : ''} ${formatTarget(c.target)}: ${c.body} (${c.author})${state}`)
+    }
+  } else if (command === 'resolve') {
+    if (rest.length !== 1) exitWithError('resolve expects a comment id')
+    const result = resolveReviewComment(comments, rest[0], author)
+    if (result.error) exitWithError(result.error)
+    save(result.comments)
+    console.log(`✅ Resolved ${rest[0]}`)
+  } else {
+    exitWithError(`Unknown command: ${command}`)
+  }
+}
+
+main().catch(error => exitWithError(error.message))
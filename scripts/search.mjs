@@ -0,0 +1,84 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for searching every design in the workspace
+ * Usage: npm run search -- <query> [--json]
+ */
+
+import { searchWorkspace } from '../src/io/fgd.ts'
+import { parseSearchQuery } from '../src/io/workspace-search.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run search -- <query> [options]
+
+Finds switches, servers and connections across every fabric under ./fgd,
+using the search index written with each save.
+
+Query:
+  <text>              Name, model, serial, asset tag, MAC or label containing <text>
+  name:<text>         Object name containing <text>
+  model:<text>        Switch model containing <text>
+  serial:<text>       Serial number or asset tag containing <text>
+  label:<key>[=<v>]   Label or annotation key (and exact value)
+  ip:<addr|cidr>      Addresses or CIDRs in labels and annotations that
+                      fall inside or overlap <addr|cidr>
+  port:<text>         Connection end (device/port) containing <text>
+
+Options:
+  --json              Print the hits as JSON
+  --base-dir <dir>    Workspace directory (default: ./fgd)
+
+Exit codes:
+  0  at least one hit
+  1  usage or I/O error
+  2  no hits
+
+Examples:
+  npm run search -- model:DS3000
+  npm run search -- ip:10.1.4.0/24
+  npm run search -- label:team=storage --json
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+
+  const baseDir = option('--base-dir') || './fgd'
+  const json = args.includes('--json')
+  const positional = args.filter(a => a !== '--json')
+  if (positional.length !== 1) exitWithError('Expected exactly one query')
+
+  const hits = await searchWorkspace(parseSearchQuery(positional[0]), baseDir)
+  if (json) {
+    console.log(JSON.stringify(hits, null, 2))
+  } else if (hits.length === 0) {
+    console.log(`No objects match ${positional[0]}`)
+  } else {
+    for (const hit of hits) console.log(`${hit.fabricId}  ${hit.kind}  ${hit.name}  (${hit.match})`)
+    const fabrics = new Set(hits.map(h => h.fabricId)).size
+    console.log(`\n🔎 ${hits.length} match${hits.length === 1 ? '' : 'es'} in ${fabrics} fabric${fabrics === 1 ? '' : 's'}`)
+  }
+  if (hits.length === 0) process.exit(2)
+}
+
+main().catch(error => exitWithError(error.message))
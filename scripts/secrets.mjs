@@ -0,0 +1,109 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for listing and resolving secret references in exports
+ * Usage: npm run secrets -- list <file> | resolve <file> [--out <file>]
+ */
+
+import { spawnSync } from 'child_process'
+import { readFileSync, writeFileSync } from 'fs'
+import { findSecretRefs, kubernetesSecretProvider, resolveSecretRefs, vaultSecretProvider } from '../src/io/secret-refs.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run secrets -- <command> <file> [options]
+
+Exports carry secret references (secret://vault/<mount>/<path>#<key>,
+secret://k8s/<namespace>/<name>#<key>) rather than values. List them, or
+substitute the values in a trusted environment just before applying.
+
+Commands:
+  list <file>         Print every reference in <file>, one per line
+  resolve <file>      Print <file> with every reference replaced by its value
+
+Options:
+  --out <file>        Write the resolved output to a file instead of stdout
+
+Environment Variables:
+  VAULT_ADDR          Vault address for secret://vault references
+  VAULT_TOKEN         Vault token for secret://vault references
+  KUBECONFIG          Cluster kubectl reads secret://k8s references from
+
+Exit codes:
+  0  listed, or every reference resolved
+  1  usage or I/O error
+  2  a reference could not be resolved (nothing is written)
+
+Examples:
+  npm run secrets -- list fgd/prod-fabric-01/connections.yaml
+  VAULT_ADDR=https://vault:8200 VAULT_TOKEN=... npm run secrets -- resolve export.cfg --out /run/export.cfg
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+// kubectl keeps cluster credentials out of this script
+async function kubectlSecret(namespace, name) {
+  const result = spawnSync('kubectl', ['get', 'secret', name, '-n', namespace, '-o', 'json'], { encoding: 'utf8' })
+  if (result.error) throw result.error
+  if (result.status !== 0) throw new Error(result.stderr.trim() || `kubectl exited ${result.status}`)
+  return JSON.parse(result.stdout).data ?? {}
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+
+  const outFile = option('--out')
+  if (args.length !== 2) exitWithError('Expected a command (list or resolve) and a file')
+  const [command, file] = args
+
+  let text
+  try {
+    text = readFileSync(file, 'utf8')
+  } catch (error) {
+    exitWithError(`Cannot read ${file}: ${error.message}`)
+  }
+
+  if (command === 'list') {
+    for (const ref of findSecretRefs(text)) console.log(ref.ref)
+  } else if (command === 'resolve') {
+    const providers = {
+      k8s: kubernetesSecretProvider(kubectlSecret),
+      ...(process.env.VAULT_ADDR && process.env.VAULT_TOKEN && {
+        vault: vaultSecretProvider({ address: process.env.VAULT_ADDR, token: process.env.VAULT_TOKEN })
+      })
+    }
+    const result = await resolveSecretRefs(text, providers)
+    if (result.errors.length > 0) {
+      for (const error of result.errors) console.error(`❌ ${error}`)
+      process.exit(2)
+    }
+    if (outFile) {
+      writeFileSync(outFile, result.text, { mode: 0o600 })
+      console.log(`✅ Resolved ${result.resolved.length} secret references -> ${outFile}`)
+    } else {
+      process.stdout.write(result.text)
+    }
+  } else {
+    exitWithError(`Unknown command: ${command}`)
+  }
+}
+
+main().catch(error => exitWithError(error.message))
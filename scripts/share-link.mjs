@@ -0,0 +1,93 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for creating and checking read-only design share links
+ * Usage: npm run share -- <fabric-id> --base-url <url> [options]
+ */
+
+import { createShareLink, verifyShareLink, envShareSecret } from '../src/io/share-links.ts'
+
+const REPORTS = ['design', 'bom', 'cabling', 'compare', 'validation']
+const UNITS = { m: 60, h: 3600, d: 86400 }
+
+function printUsage() {
+  console.log(`
+Usage: npm run share -- <fabric-id> --base-url <url> [options]
+       npm run share -- --verify <link>
+
+Creates an expiring, signed read-only link to a design. The signing secret
+is read from HNC_SHARE_SECRET.
+
+Options:
+  --base-url <url>       URL of the read-only viewer (required when creating)
+  --expires <n><m|h|d>   Lifetime of the link (default: 7d)
+  --reports <list>       Comma-separated: ${REPORTS.join(', ')} (default: design)
+  --revision <token>     Pin the link to one saved revision
+  --verify <link>        Check a link and print the grant it carries
+
+Examples:
+  npm run share -- dc1-fabric --base-url https://hnc.example.com/review --expires 48h --reports design,bom
+  npm run share -- --verify 'https://hnc.example.com/review?share=...'
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+async function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag, fallback) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return fallback
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+
+  const secret = envShareSecret()
+  if (!secret) exitWithError('HNC_SHARE_SECRET is not set')
+
+  const verify = option('--verify')
+  if (verify) {
+    const result = await verifyShareLink(verify, secret)
+    if (!result.valid) exitWithError(result.error, 2)
+    const { fabricId, reports, expiresAt, revision } = result.grant
+    console.log(`✅ Valid link for ${fabricId} (${reports.join(', ')}), expires ${expiresAt.toISOString()}${revision ? `, revision ${revision}` : ''}`)
+    return
+  }
+
+  const baseUrl = option('--base-url')
+  const expires = option('--expires', '7d')
+  const reports = option('--reports', 'design').split(',').map(r => r.trim())
+  const revision = option('--revision')
+  if (args.length !== 1) exitWithError('Expected exactly one fabric id')
+  if (!baseUrl) exitWithError('--base-url is required')
+
+  const match = expires.match(/^(\d+)([mhd])$/)
+  if (!match) exitWithError(`--expires must look like 30m, 48h or 7d, got '${expires}'`)
+  const unknown = reports.filter(r => !REPORTS.includes(r))
+  if (unknown.length > 0) exitWithError(`Unknown report(s): ${unknown.join(', ')}`)
+
+  try {
+    console.log(await createShareLink(args[0], {
+      baseUrl,
+      secret,
+      expiresInSeconds: Number(match[1]) * UNITS[match[2]],
+      reports,
+      revision
+    }))
+  } catch (error) {
+    exitWithError(error.message)
+  }
+}
+
+main()
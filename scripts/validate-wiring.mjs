@@ -0,0 +1,156 @@
+#!/usr/bin/env node
+
+/**
+ * CLI script for validating an exported wiring design
+ * Usage: npm run validate:wiring -- <wiring.json> [--target-release <release>] [--racks <layout.json>] [--catalog <id>] [--json] [--no-color] [--pager]
+ */
+
+import { existsSync, readFileSync, writeFileSync } from 'fs'
+import { spawnSync } from 'child_process'
+import { LINK_REACHES, LINK_REACH_ANNOTATION, annotateLinkReach } from '../src/domain/link-reach.ts'
+import { CATALOGS_FILE, findCatalog, parseCatalogs } from '../src/domain/named-catalogs.ts'
+import { validateWiring, wiringToWiringDiagram } from '../src/domain/wiring.ts'
+import { convertWiringDiagramToFabricCRDs } from '../src/io/crd-yaml.ts'
+import { HEDGEHOG_RELEASES, checkReleaseCompatibility } from '../src/io/release-compat.ts'
+import { embeddedSecrets } from '../src/io/secret-refs.ts'
+import { pagerCommand, renderTerminalReport, useColor, validationReport } from '../src/io/terminal-report.ts'
+
+function printUsage() {
+  console.log(`
+Usage: npm run validate:wiring -- <wiring.json> [options]
+
+Checks a wiring export for duplicate devices, port conflicts, link
+problems and secrets embedded in annotations or labels instead of
+referenced (secret://...), and prints the findings as a report.
+
+Arguments:
+  wiring.json         Wiring JSON exported from the designer
+
+Options:
+  --target-release <release>
+                      Also fail if the design uses features the Hedgehog
+                      release does not support (${HEDGEHOG_RELEASES.join(', ')})
+  --racks <layout.json>
+                      Classify every link as ${LINK_REACHES.join(', ')}
+                      from rack placement, and enforce the reach policies
+                      in the file: { "racks": [{ "id", "row", "hall"?,
+                      "devices": [...] }], "policies"?: [{ "name",
+                      "match": { "type"?, "labels"? }, "allowed": [...] }] }
+  --annotate <file>   With --racks, write the wiring with each link's reach
+                      recorded as a ${LINK_REACH_ANNOTATION} annotation,
+                      which every export carries
+  --catalog <id>      Fail on spine or leaf models outside this named catalog
+                      (default: the catalog the design selected, if any)
+  --catalogs <file>   Catalog definitions (default: ${CATALOGS_FILE}):
+                      { "catalogs": [{ "id", "description"?, "models": [...] }] }
+  --json              Print the raw validation result as JSON
+  --color             Color the report even when piped or in CI
+  --no-color          Never color the report (also: NO_COLOR=1)
+  --pager             Page long reports through $PAGER (default: less -FRX)
+
+Exit codes:
+  0  no errors (warnings allowed)
+  2  validation errors
+
+Examples:
+  npm run validate:wiring -- contracts/fixtures/designs/two-leaf.wiring.json
+  npm run validate:wiring -- wiring.json --pager
+  npm run validate:wiring -- wiring.json --target-release 24.09
+  npm run validate:wiring -- wiring.json --racks racks.json --annotate wiring.reach.json
+  npm run validate:wiring -- wiring.json --catalog prod-approved
+`)
+}
+
+function exitWithError(message, code = 1) {
+  console.error(`❌ Error: ${message}`)
+  process.exit(code)
+}
+
+function printReport(text, term) {
+  const pager = pagerCommand(term, text.split('\n').length, process.stdout.rows)
+  if (pager && spawnSync(pager, { input: text, stdio: ['pipe', 'inherit', 'inherit'], shell: true }).status === 0) return
+  process.stdout.write(text)
+}
+
+function main() {
+  const args = process.argv.slice(2)
+
+  if (args.includes('--help') || args.includes('-h') || args.length === 0) {
+    printUsage()
+    process.exit(args.length === 0 ? 1 : 0)
+  }
+
+  const option = (flag) => {
+    const i = args.indexOf(flag)
+    if (i === -1) return undefined
+    const value = args[i + 1]
+    if (!value || value.startsWith('--')) exitWithError(`${flag} requires an argument`)
+    args.splice(i, 2)
+    return value
+  }
+
+  const targetRelease = option('--target-release')
+  const racksFile = option('--racks')
+  const annotateFile = option('--annotate')
+  if (annotateFile && !racksFile) exitWithError('--annotate requires --racks')
+  const catalogOption = option('--catalog')
+  const catalogsFile = option('--catalogs') ?? CATALOGS_FILE
+  const positional = args.filter(a => !a.startsWith('--'))
+  if (positional.length !== 1) exitWithError('Expected <wiring.json>')
+
+  let wiring
+  try {
+    wiring = JSON.parse(readFileSync(positional[0], 'utf8'))
+  } catch (error) {
+    exitWithError(`Cannot read wiring ${positional[0]}: ${error.message}`)
+  }
+
+  let linkReach
+  if (racksFile) {
+    try {
+      linkReach = JSON.parse(readFileSync(racksFile, 'utf8'))
+    } catch (error) {
+      exitWithError(`Cannot read rack layout ${racksFile}: ${error.message}`)
+    }
+    if (!Array.isArray(linkReach.racks)) exitWithError(`${racksFile} has no "racks" list`)
+  }
+
+  let catalog
+  const catalogId = catalogOption ?? wiring.metadata?.catalog
+  if (catalogId) {
+    if (!existsSync(catalogsFile)) exitWithError(`Design selects catalog ${catalogId} but ${catalogsFile} does not exist`)
+    let raw
+    try {
+      raw = JSON.parse(readFileSync(catalogsFile, 'utf8'))
+    } catch (error) {
+      exitWithError(`Cannot read catalogs ${catalogsFile}: ${error.message}`)
+    }
+    const { catalogs, errors } = parseCatalogs(raw)
+    if (errors.length > 0) exitWithError(`${catalogsFile}: ${errors.join('; ')}`)
+    catalog = findCatalog(catalogs, catalogId)
+    if (!catalog) exitWithError(`No catalog ${catalogId} in ${catalogsFile} (have: ${catalogs.map(c => c.id).join(', ') || 'none'})`)
+  }
+
+  const result = validateWiring(wiring, { ...(linkReach && { linkReach }), ...(catalog && { catalog }) })
+  if (annotateFile) {
+    writeFileSync(annotateFile, JSON.stringify(annotateLinkReach(wiring, linkReach.racks), null, 2) + '\n')
+  }
+  wiring.metadata.generatedAt = new Date(wiring.metadata.generatedAt)
+  const diagram = wiringToWiringDiagram(wiring)
+  result.errors.push(...embeddedSecrets(diagram))
+  if (targetRelease) {
+    const crds = convertWiringDiagramToFabricCRDs(diagram, {})
+    const compatibility = checkReleaseCompatibility(crds, targetRelease)
+    result.errors.push(...compatibility.errors)
+    result.warnings.push(...compatibility.warnings)
+  }
+  if (args.includes('--json')) {
+    console.log(JSON.stringify(result, null, 2))
+  } else {
+    const term = { argv: args, env: process.env, isTTY: Boolean(process.stdout.isTTY) }
+    printReport(renderTerminalReport(validationReport(result, `Wiring validation: ${positional[0]}`), { color: useColor(term) }), term)
+  }
+  if (result.errors.length > 0) process.exit(2)
+}
+
+main()
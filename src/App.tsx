@@ -42,8 +42,8 @@ function FabricDesigner({ fabricId, onBackToList }: { fabricId: string; onBackTo
 function AppContent() {
   const [workspaceState, workspaceSend] = useMachine(workspaceMachine)
 
-  const handleCreateFabric = (name: string) => {
-    workspaceSend({ type: 'CREATE_FABRIC', name })
+  const handleCreateFabric = (name: string, policyPack?: string) => {
+    workspaceSend({ type: 'CREATE_FABRIC', name, policyPack })
   }
 
   const handleSelectFabric = (fabricId: string) => {
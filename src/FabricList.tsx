@@ -8,7 +8,7 @@ import { EmptyState } from './components/EmptyState.js'
 
 interface FabricListProps {
   fabrics: FabricSummary[]
-  onCreateFabric: (name: string) => void
+  onCreateFabric: (name: string, policyPack?: string) => void
   onSelectFabric: (fabricId: string) => void
   onDeleteFabric: (fabricId: string) => void
   onCheckDrift?: (fabricId: string) => void
@@ -29,8 +29,8 @@ export function FabricList({
 }: FabricListProps) {
   const [showCreateForm, setShowCreateForm] = useState(false)
 
-  const handleCreate = (name: string) => {
-    onCreateFabric(name)
+  const handleCreate: FabricListProps['onCreateFabric'] = (...args) => {
+    onCreateFabric(...args)
     setShowCreateForm(false)
   }
 
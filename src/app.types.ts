@@ -46,6 +46,7 @@ export interface EndpointProfile {
   redundancy?: boolean
   esLag?: boolean // ES-LAG intent flag
   nics?: number   // NIC count per endpoint (defaults to 1)
+  labels?: Record<string, string> // Copied onto generated servers and their connections
 }
 
 // Guard types for validation constraints
@@ -85,7 +86,20 @@ export interface DerivedTopology {
 }
 
 // Wiring diagram stub structure
-export interface WiringConnection {
+// User labels/annotations carried through every export (CRD labels, tags, CSV columns)
+export interface Labeled {
+  labels?: Record<string, string>
+  annotations?: Record<string, string>
+}
+
+// Physical asset identity, filled in at deployment time from procurement data
+export interface AssetInfo {
+  serialNumber?: string
+  assetTag?: string
+  macAddress?: string // management MAC, bound from ZTP discovery
+}
+
+export interface WiringConnection extends Labeled {
   from: { device: string; port: string }
   to: { device: string; port: string }
   type: 'uplink' | 'downlink' | 'endpoint'
@@ -93,15 +107,16 @@ export interface WiringConnection {
 
 export interface WiringDiagram {
   devices: {
-    spines: Array<{ id: string; model: string; ports: number }>
-    leaves: Array<{ id: string; model: string; ports: number }>
-    servers: Array<{ id: string; type: string; connections: number }>
+    spines: Array<{ id: string; model: string; ports: number } & Labeled & AssetInfo>
+    leaves: Array<{ id: string; model: string; ports: number } & Labeled & AssetInfo>
+    servers: Array<{ id: string; type: string; connections: number } & Labeled & AssetInfo>
   }
   connections: WiringConnection[]
   metadata: {
     generatedAt: Date
     fabricName: string
     totalDevices: number
+    placementSeed?: number // provenance: the seed placement ties were broken with
   }
 }
 
@@ -161,18 +176,39 @@ export interface SwitchProfile {
     endpointAssignable: string[]
     fabricAssignable: string[]
   }
+  namingScheme?: 'sonic' | 'eth' | 'os10' | 'cumulus' // how the switch's NOS spells ports
+  faceplate?: { blocks: Array<{ ports: string[]; rows: number }> } // front panel, left to right
+  physical?: { typicalPowerWatts: number; maxPowerWatts?: number; heatBtuPerHour?: number; rackUnits?: number; weightKg?: number }
+  cost?: { listPriceUsd: number } // for comparing plans, not quoting
+  capabilities?: { asic: string; tables: { routes?: number; macs?: number; vxlanTunnels?: number }; features: { vxlanRouting?: boolean; roce?: boolean } } // checked against designs
   profiles: {
-    endpoint: { portProfile: string | null; speedGbps: number }
-    uplink: { portProfile: string | null; speedGbps: number }
+    endpoint: { portProfile: string | null; speedGbps: number; breakouts?: BreakoutOption[] }
+    uplink: { portProfile: string | null; speedGbps: number; breakouts?: BreakoutOption[] }
     breakout?: {
       supportsBreakout: boolean
       breakoutType?: string
       capacityMultiplier?: number
     }
   }
-  meta: { source: string; version: string }
+  meta: {
+    source: string
+    version: string
+    airflow?: SwitchAirflow // absent when the vendor data does not say
+  }
 }
 
+// One way to split a port, e.g. a 100G QSFP28 into 4x25G; portPattern names
+// the resulting ports from {port} and {lane}, e.g. '{port}/{lane}' -> E1/1/1
+export interface BreakoutOption {
+  mode: string
+  lanes: number
+  speedGbps: number
+  portPattern: string
+}
+
+// Intake side -> exhaust side, as vendors list fan/PSU options
+export type SwitchAirflow = 'port-to-power' | 'power-to-port'
+
 export type FabricDesignEvent =
   | { type: 'UPDATE_CONFIG'; data: Partial<FabricSpec> }
   | { type: 'COMPUTE_TOPOLOGY' }
@@ -208,6 +244,12 @@ export interface LAGConstraints {
 }
 
 
+// Uplinks of one speed on a leaf, e.g. 2 x 400G during a speed migration
+export interface UplinkSpeedGroup {
+  count: number
+  speedGbps: number
+}
+
 // LeafClass interface for multi-class fabric support
 export interface LeafClass {
   id: string
@@ -215,6 +257,7 @@ export interface LeafClass {
   role: 'standard' | 'border'
   leafModelId?: string // defaults to global leaf model if not specified
   uplinksPerLeaf: number
+  uplinkSpeeds?: UplinkSpeedGroup[] // mixed speeds; counts must add up to uplinksPerLeaf
   endpointProfiles: EndpointProfile[]
   lag?: LAGConstraints
   count?: number // number of leaves in this class
@@ -247,6 +290,16 @@ export interface ExtendedAllocationResult {
   legacy?: AllocationResult // for backwards compatibility
 }
 
+export interface SpareCapacityPolicy {
+  minFreeEndpointPortsPercent?: number // per leaf, 0-100
+  minSpareUplinksPerSpine?: number // free fabric ports per spine
+}
+
+export interface GrowthStep {
+  quarter: string // 'YYYY-Qn'
+  endpoints: number // endpoints added during the quarter
+}
+
 // Fabric specification type (derived from Zod schema)
 export interface FabricSpec {
   name: string
@@ -258,10 +311,29 @@ export interface FabricSpec {
   
   // Backwards compatibility (legacy single-class mode)
   uplinksPerLeaf?: number
+  uplinkSpeeds?: UplinkSpeedGroup[]
   endpointProfile?: EndpointProfile
   endpointCount?: number
   breakoutEnabled?: boolean // Simple breakout toggle for single-class mode
   
+  // Fraction (0-1) of spine fabric ports held back for future pods
+  spineReservation?: number
+
+  // Seed for reproducible tie-breaking in placement; lowest-first when unset
+  placementSeed?: number
+  
+  // Free ports every leaf and spine must keep after allocation
+  spareCapacity?: SpareCapacityPolicy
+  
+  // Expected endpoint additions per quarter, for capacity timelines
+  growthForecast?: GrowthStep[]
+  
+  // Policy pack the design was created from (src/templates/policy-packs.ts)
+  policyPack?: string
+  
+  // Named profile catalog the design selects models from (src/domain/named-catalogs.ts)
+  catalog?: string
+  
   // Common fields
   metadata?: Record<string, any>
   version?: string
@@ -0,0 +1,49 @@
+/**
+ * Parts Catalog Service Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { DEFAULT_PARTS_CATALOG, resolveVendorPart, validatePartsCatalog, type PartsCatalog } from './parts.service'
+import skuCatalog from './sku.json'
+
+const catalog: PartsCatalog = {
+  'GEN-SFP28-25G-SR': [
+    { vendor: 'Beta', partNumber: 'B-25SR', price: 40 },
+    { vendor: 'Alpha', partNumber: 'A-25SR', price: 45 },
+    { vendor: 'Gamma', partNumber: 'G-25SR' }
+  ]
+}
+
+describe('resolveVendorPart', () => {
+  it('picks the first preferred vendor that stocks the SKU', () => {
+    const part = resolveVendorPart(catalog, 'GEN-SFP28-25G-SR', { preferredVendors: ['Delta', 'Alpha', 'Beta'] })
+    expect(part?.partNumber).toBe('A-25SR')
+  })
+
+  it('falls back to the cheapest allowed part', () => {
+    expect(resolveVendorPart(catalog, 'GEN-SFP28-25G-SR')?.partNumber).toBe('B-25SR')
+    expect(resolveVendorPart(catalog, 'GEN-SFP28-25G-SR', { allowedVendors: ['Alpha', 'Gamma'] })?.partNumber).toBe('A-25SR')
+  })
+
+  it('never picks a vendor outside the allowed list', () => {
+    expect(resolveVendorPart(catalog, 'GEN-SFP28-25G-SR', { allowedVendors: ['Gamma'], preferredVendors: ['Beta'] })?.partNumber).toBe('G-25SR')
+    expect(resolveVendorPart(catalog, 'GEN-SFP28-25G-SR', { allowedVendors: ['Delta'] })).toBeUndefined()
+    expect(resolveVendorPart(catalog, 'GEN-UNKNOWN')).toBeUndefined()
+  })
+})
+
+describe('validatePartsCatalog', () => {
+  it('reports empty entries and part numbers reused across SKUs', () => {
+    expect(validatePartsCatalog({
+      'GEN-A': [],
+      'GEN-B': [{ vendor: 'Alpha', partNumber: 'X-1' }],
+      'GEN-C': [{ vendor: 'Alpha', partNumber: 'X-1' }]
+    })).toEqual(['GEN-A: no vendor parts listed', 'Alpha/X-1 is listed for both GEN-B and GEN-C'])
+  })
+
+  it('accepts the bundled catalog, which only maps known generic SKUs', () => {
+    expect(validatePartsCatalog(DEFAULT_PARTS_CATALOG)).toEqual([])
+    const known = { ...skuCatalog.transceivers, ...skuCatalog.breakouts, ...skuCatalog.cables }
+    expect(Object.keys(DEFAULT_PARTS_CATALOG).filter(sku => !(sku in known))).toEqual([])
+  })
+})
@@ -0,0 +1,78 @@
+/**
+ * Parts Catalog Service - HNC v0.6
+ * Maps generic SKUs (GEN-*) to orderable vendor part numbers and picks one
+ * per SKU according to a preferred-vendor policy
+ */
+
+import defaultCatalog from './parts.json'
+
+export interface VendorPart {
+  vendor: string
+  partNumber: string
+  description?: string
+  price?: number
+}
+
+// Generic SKU -> vendor parts that fulfil it
+export type PartsCatalog = Record<string, VendorPart[]>
+
+export interface VendorPolicy {
+  preferredVendors?: string[] // first match wins, in order
+  allowedVendors?: string[] // when set, no other vendor is ever chosen
+}
+
+export const DEFAULT_PARTS_CATALOG: PartsCatalog = defaultCatalog.parts
+
+/**
+ * Picks the vendor part for a generic SKU, or undefined when the catalog
+ * has no allowed part for it. Without a preferred match the cheapest
+ * allowed part is chosen, ties broken by vendor name.
+ */
+export function resolveVendorPart(
+  catalog: PartsCatalog,
+  sku: string,
+  policy: VendorPolicy = {}
+): VendorPart | undefined {
+  const allowed = (catalog[sku] || []).filter(part =>
+    !policy.allowedVendors || policy.allowedVendors.includes(part.vendor)
+  )
+  if (allowed.length === 0) return undefined
+
+  for (const vendor of policy.preferredVendors || []) {
+    const preferred = allowed.find(part => part.vendor === vendor)
+    if (preferred) return preferred
+  }
+
+  return [...allowed].sort((a, b) =>
+    (a.price ?? Infinity) - (b.price ?? Infinity) || a.vendor.localeCompare(b.vendor)
+  )[0]
+}
+
+/**
+ * Checks a catalog for empty entries and duplicate part numbers
+ */
+export function validatePartsCatalog(catalog: PartsCatalog): string[] {
+  const errors: string[] = []
+  const seen = new Map<string, string>()
+
+  for (const [sku, parts] of Object.entries(catalog)) {
+    if (!Array.isArray(parts) || parts.length === 0) {
+      errors.push(`${sku}: no vendor parts listed`)
+      continue
+    }
+    for (const part of parts) {
+      if (!part.vendor || !part.partNumber) {
+        errors.push(`${sku}: every part needs a vendor and partNumber`)
+        continue
+      }
+      const key = `${part.vendor}/${part.partNumber}`
+      const owner = seen.get(key)
+      if (owner && owner !== sku) {
+        errors.push(`${key} is listed for both ${owner} and ${sku}`)
+      }
+      seen.set(key, sku)
+    }
+  }
+
+  return errors
+}
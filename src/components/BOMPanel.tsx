@@ -15,12 +15,15 @@ import { countTransceivers, type TransceiverAnalysis } from '../domain/transceiv
 import { type WiringDiagram } from '../app.types'
 import { type ExternalLink } from '../domain/external-link'
 import { type LeafModel } from '../domain/leaf-capability-filter'
+import { DEFAULT_PARTS_CATALOG, type PartsCatalog, type VendorPolicy } from '../catalog/parts.service'
 
 interface BOMPanelProps {
   wiringDiagram: WiringDiagram
   externalLinks?: ExternalLink[]
   leafModels?: LeafModel[]
   spineModels?: LeafModel[]
+  partsCatalog?: PartsCatalog
+  vendorPolicy?: VendorPolicy
   showPricing?: boolean
   showDetailedBreakdown?: boolean
   onExportCSV?: (bomData: BOMAnalysis) => void
@@ -31,6 +34,8 @@ export function BOMPanel({
   externalLinks = [],
   leafModels = [],
   spineModels = [],
+  partsCatalog = DEFAULT_PARTS_CATALOG,
+  vendorPolicy,
   showPricing = true,
   showDetailedBreakdown = false,
   onExportCSV
@@ -41,12 +46,12 @@ export function BOMPanel({
   // Calculate BOM analysis
   const bomAnalysis = useMemo(() => {
     try {
-      return compileBOM(wiringDiagram, externalLinks, leafModels, spineModels)
+      return compileBOM(wiringDiagram, externalLinks, leafModels, spineModels, { partsCatalog, vendorPolicy })
     } catch (error) {
       console.error('BOM compilation failed:', error)
       return null
     }
-  }, [wiringDiagram, externalLinks, leafModels, spineModels])
+  }, [wiringDiagram, externalLinks, leafModels, spineModels, partsCatalog, vendorPolicy])
 
   // Calculate detailed transceiver analysis
   const transceiverAnalysis = useMemo(() => {
@@ -289,7 +294,8 @@ function BOMCategoryView({
         {items.map((item, index) => (
           <div key={index} className="flex items-center justify-between p-3 border rounded-lg">
             <div className="flex-1">
-              <div className="font-medium">{item.sku}</div>
+              <div className="font-medium">{item.partNumber ? `${item.vendor} ${item.partNumber}` : item.sku}</div>
+              {item.partNumber && <div className="text-xs text-muted-foreground">{item.sku}</div>}
               <div className="text-sm text-muted-foreground">{item.description}</div>
               {item.details && (
                 <div className="text-xs text-muted-foreground mt-1">
@@ -361,7 +367,7 @@ function BOMCategoryView({
 
 // CSV export utilities
 function generateCSV(analysis: BOMAnalysis): string {
-  const headers = ['Category', 'SKU', 'Description', 'Quantity', 'Unit Price', 'Total Price', 'Source']
+  const headers = ['Category', 'SKU', 'Vendor', 'Part Number', 'Description', 'Quantity', 'Unit Price', 'Total Price', 'Source']
   const rows: string[] = [headers.join(',')]
 
   const allItems = [
@@ -375,6 +381,8 @@ function generateCSV(analysis: BOMAnalysis): string {
     const row = [
       item.category,
       item.sku,
+      item.vendor || '',
+      item.partNumber || '',
       `"${item.description}"`,
       item.quantity.toString(),
       (item.unitPrice || 0).toString(),
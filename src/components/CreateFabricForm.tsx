@@ -3,9 +3,10 @@ import { useUserMode } from '../contexts/UserModeContext'
 import { ExplainTooltip, ExplainButton } from './ExplainTooltip'
 import { InlineGuidance } from './GuidedTips'
 import { FieldWithProvenance, ProvenanceEntry } from './ExpertProvenance'
+import { POLICY_PACKS } from '../templates/policy-packs'
 
 interface CreateFabricFormProps {
-  onCreateFabric: (name: string) => void
+  onCreateFabric: (name: string, policyPack?: string) => void
   onCancel: () => void
   isCreating: boolean
   existingFabrics?: string[]
@@ -27,6 +28,7 @@ export function CreateFabricForm({
   validationRules = {}
 }: CreateFabricFormProps) {
   const [newFabricName, setNewFabricName] = useState('')
+  const [policyPack, setPolicyPack] = useState('')
   const [validationError, setValidationError] = useState('')
   const [showSuggestions, setShowSuggestions] = useState(false)
   const { isGuided, isExpert } = useUserMode()
@@ -67,8 +69,10 @@ export function CreateFabricForm({
     const error = validateName(trimmed)
     
     if (!error) {
-      onCreateFabric(trimmed)
+      if (policyPack) onCreateFabric(trimmed, policyPack)
+      else onCreateFabric(trimmed)
       setNewFabricName('')
+      setPolicyPack('')
       setValidationError('')
     } else {
       setValidationError(error)
@@ -286,6 +290,34 @@ export function CreateFabricForm({
         </div>
       </InlineGuidance>
 
+      <div style={fieldGroupStyles}>
+        <label style={labelStyles} htmlFor="policy-pack-select">
+          <span>Policy Pack</span>
+          <ExplainButton
+            explanation="A policy pack pre-fills oversubscription targets, redundancy rules, spare capacity and device naming for a fabric role. Every value can still be changed in the designer."
+            title="What is a policy pack?"
+          />
+        </label>
+        <select
+          id="policy-pack-select"
+          value={policyPack}
+          onChange={(e) => setPolicyPack(e.target.value)}
+          style={inputStyles}
+          disabled={isCreating}
+          data-testid="policy-pack-select"
+        >
+          <option value="">None (blank design)</option>
+          {Object.values(POLICY_PACKS).map(pack => (
+            <option key={pack.id} value={pack.id}>{pack.name}</option>
+          ))}
+        </select>
+        {policyPack && (
+          <div style={{ fontSize: '12px', color: '#6b7280', marginTop: '4px' }} data-testid="policy-pack-description">
+            {POLICY_PACKS[policyPack].description}
+          </div>
+        )}
+      </div>
+
       <div style={buttonGroupStyles}>
         <button
           type="button"
@@ -0,0 +1,75 @@
+/**
+ * Airflow Validation Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { validateAirflow } from './airflow'
+import type { Wiring } from './wiring'
+import type { SwitchAirflow, SwitchProfile } from '../app.types'
+
+const profile = (modelId: string, airflow?: SwitchAirflow): SwitchProfile => ({
+  modelId,
+  roles: [],
+  ports: { endpointAssignable: [], fabricAssignable: [] },
+  profiles: {
+    endpoint: { portProfile: null, speedGbps: 25 },
+    uplink: { portProfile: null, speedGbps: 100 }
+  },
+  meta: { source: 'test', version: '1.0', ...(airflow && { airflow }) }
+})
+
+const profiles = new Map<string, SwitchProfile>([
+  ['DS3000', profile('DS3000', 'port-to-power')],
+  ['DS2000', profile('DS2000', 'power-to-port')],
+  ['DS1000', profile('DS1000')]
+])
+
+const device = (id: string, type: 'spine' | 'leaf', modelId: string) => ({ id, type, modelId, ports: 32 })
+
+const wiring: Wiring = {
+  devices: {
+    spines: [device('spine-1', 'spine', 'DS3000')],
+    leaves: [device('leaf-1', 'leaf', 'DS2000'), device('leaf-2', 'leaf', 'DS2000'), device('leaf-3', 'leaf', 'DS1000')],
+    servers: []
+  },
+  connections: [],
+  metadata: { fabricName: 'air', fabricId: 'air', generatedAt: new Date(0), totalDevices: 4, totalConnections: 0 }
+}
+
+describe('validateAirflow', () => {
+  it('flags switches drawing air from the hot aisle', () => {
+    const result = validateAirflow(wiring, profiles, {
+      racks: [
+        { id: 'r1', frontAisle: 'cold', devices: [{ deviceId: 'spine-1' }, { deviceId: 'leaf-1', portSide: 'rear' }] },
+        { id: 'r2', frontAisle: 'hot', devices: [{ deviceId: 'leaf-2', portSide: 'rear' }, { deviceId: 'leaf-3' }] }
+      ]
+    })
+
+    expect(result.findings.map(f => [f.deviceId, f.intakeAisle, f.reversed])).toEqual([
+      ['spine-1', 'cold', false], // ports front, intake at ports
+      ['leaf-1', 'cold', false], // ports rear, intake at power side = front
+      ['leaf-2', 'hot', true] // rack front faces the hot aisle
+    ])
+    expect(result.errors).toEqual([
+      'Switch leaf-2 in rack r2 takes in air from the hot aisle (power-to-port, ports to the rear); order the port-to-power airflow option or remount it'
+    ])
+    expect(result.warnings).toEqual(['No airflow direction in the DS1000 profile; cannot check leaf-3'])
+  })
+
+  it('reports unplaced, unknown and doubly placed devices', () => {
+    const result = validateAirflow(wiring, profiles, {
+      racks: [
+        { id: 'r1', frontAisle: 'cold', devices: [{ deviceId: 'spine-1' }, { deviceId: 'srv-9' }] },
+        { id: 'r2', frontAisle: 'cold', devices: [{ deviceId: 'spine-1' }] }
+      ]
+    })
+
+    expect(result.errors).toEqual(['Switch spine-1 is placed in more than one rack'])
+    expect(result.warnings).toEqual([
+      'Rack r1 lists srv-9, which is not a switch in this design',
+      'Switch leaf-1 is not placed in any rack',
+      'Switch leaf-2 is not placed in any rack',
+      'Switch leaf-3 is not placed in any rack'
+    ])
+  })
+})
@@ -0,0 +1,88 @@
+/**
+ * Airflow Validation - HNC v0.6
+ * Checks that every switch draws air from the cold aisle, given its
+ * profile's airflow direction, which way it is mounted and which aisle the
+ * rack front faces. Reversed units are cheap to fix before ordering (pick
+ * the other fan/PSU SKU) and expensive after.
+ */
+
+import type { Wiring } from './wiring'
+import type { SwitchProfile } from '../app.types'
+
+export interface RackAisleLayout {
+  racks: Array<{
+    id: string
+    frontAisle: 'cold' | 'hot'
+    devices: Array<{
+      deviceId: string
+      portSide?: 'front' | 'rear' // default: front
+    }>
+  }>
+}
+
+export interface AirflowFinding {
+  deviceId: string
+  rackId: string
+  modelId: string
+  intakeAisle: 'cold' | 'hot'
+  reversed: boolean
+}
+
+export interface AirflowValidationResult {
+  findings: AirflowFinding[]
+  errors: string[]
+  warnings: string[]
+}
+
+/**
+ * Flags switches whose intake faces the hot aisle
+ */
+export function validateAirflow(
+  wiring: Wiring,
+  profiles: Map<string, SwitchProfile>,
+  layout: RackAisleLayout
+): AirflowValidationResult {
+  const findings: AirflowFinding[] = []
+  const errors: string[] = []
+  const warnings: string[] = []
+
+  const switches = new Map([...wiring.devices.spines, ...wiring.devices.leaves].map(d => [d.id, d]))
+  const placed = new Set<string>()
+
+  for (const rack of layout.racks) {
+    for (const { deviceId, portSide = 'front' } of rack.devices) {
+      const device = switches.get(deviceId)
+      if (!device) {
+        warnings.push(`Rack ${rack.id} lists ${deviceId}, which is not a switch in this design`)
+        continue
+      }
+      if (placed.has(deviceId)) {
+        errors.push(`Switch ${deviceId} is placed in more than one rack`)
+        continue
+      }
+      placed.add(deviceId)
+
+      const airflow = profiles.get(device.modelId)?.meta.airflow
+      if (!airflow) {
+        warnings.push(`No airflow direction in the ${device.modelId} profile; cannot check ${deviceId}`)
+        continue
+      }
+
+      const intakeSide = airflow === 'port-to-power' ? portSide : OPPOSITE[portSide]
+      const intakeAisle = intakeSide === 'front' ? rack.frontAisle : OPPOSITE[rack.frontAisle]
+      const reversed = intakeAisle === 'hot'
+      findings.push({ deviceId, rackId: rack.id, modelId: device.modelId, intakeAisle, reversed })
+      if (reversed) {
+        errors.push(`Switch ${deviceId} in rack ${rack.id} takes in air from the hot aisle (${airflow}, ports to the ${portSide}); order the ${airflow === 'port-to-power' ? 'power-to-port' : 'port-to-power'} airflow option or remount it`)
+      }
+    }
+  }
+
+  for (const id of switches.keys()) {
+    if (!placed.has(id)) warnings.push(`Switch ${id} is not placed in any rack`)
+  }
+
+  return { findings, errors, warnings }
+}
+
+const OPPOSITE = { front: 'rear', rear: 'front', cold: 'hot', hot: 'cold' } as const
@@ -4,6 +4,7 @@
  */
 
 import { expandPortRanges, getNextAvailablePort } from './portUtils';
+import { isValidPlacementSeed, spineVisitOrder } from './placement-seed';
 import type { 
   AllocationSpec, 
   AllocationResult, 
@@ -46,9 +47,12 @@ export function allocateUplinks(
     };
   }
   
-  // Parse and expand fabric port ranges
+  // Parse and expand fabric port ranges; reserved spine ports are never allocated
   const leafFabricPorts = expandPortRanges(leafProfile.ports.fabricAssignable);
-  const spineFabricPorts = expandPortRanges(spineProfile.ports.fabricAssignable);
+  const spineFabricPorts = unreservedSpinePorts(
+    expandPortRanges(spineProfile.ports.fabricAssignable),
+    spec.spineReservation
+  );
   
   // Check if we have enough ports
   const totalUplinksNeeded = spec.leavesNeeded * spec.uplinksPerLeaf;
@@ -59,7 +63,9 @@ export function allocateUplinks(
   }
   
   if (spineFabricPorts.length < portsPerSpine) {
-    issues.push(`Spine capacity exceeded: need ${portsPerSpine} ports, spine has ${spineFabricPorts.length} fabricAssignable`);
+    issues.push(spec.spineReservation
+      ? `Spine capacity exceeded: need ${portsPerSpine} ports, spine has ${spineFabricPorts.length} fabricAssignable after reserving ${Math.round(spec.spineReservation * 100)}% for future pods`
+      : `Spine capacity exceeded: need ${portsPerSpine} ports, spine has ${spineFabricPorts.length} fabricAssignable`);
   }
   
   if (issues.length > 0) {
@@ -102,6 +108,14 @@ function validateAllocationConstraints(
     issues.push('Spines needed must be positive');
   }
   
+  if (spec.spineReservation !== undefined && (spec.spineReservation < 0 || spec.spineReservation >= 1)) {
+    issues.push(`Spine reservation (${spec.spineReservation}) must be at least 0 and below 1`);
+  }
+
+  if (spec.placementSeed !== undefined && !isValidPlacementSeed(spec.placementSeed)) {
+    issues.push(`Placement seed (${spec.placementSeed}) must be an integer from 0 to 4294967295`);
+  }
+  
   // Check if profiles have fabric ports
   if (!leafProfile.ports.fabricAssignable || leafProfile.ports.fabricAssignable.length === 0) {
     issues.push('Leaf profile has no fabric ports available');
@@ -114,6 +128,21 @@ function validateAllocationConstraints(
   return issues;
 }
 
+/**
+ * Number of spine fabric ports held back by a reservation fraction
+ */
+export function reservedSpinePortCount(totalPorts: number, spineReservation = 0): number {
+  return Math.floor(totalPorts * Math.max(0, Math.min(1, spineReservation)));
+}
+
+/**
+ * Drops the reserved ports from a spine's fabric port list. The highest
+ * ports are reserved so committed uplinks keep the lowest-first ordering.
+ */
+export function unreservedSpinePorts(spineFabricPorts: string[], spineReservation = 0): string[] {
+  return spineFabricPorts.slice(0, spineFabricPorts.length - reservedSpinePortCount(spineFabricPorts.length, spineReservation));
+}
+
 /**
  * Performs round-robin uplink allocation across spines
  */
@@ -146,7 +175,7 @@ function performRoundRobinAllocation(
     }
     
     // Assign uplinks in round-robin fashion across spines
-    for (let spineId = 0; spineId < spec.spinesNeeded; spineId++) {
+    for (const spineId of spineVisitOrder(spec.spinesNeeded, spec.placementSeed, leafId)) {
       for (let i = 0; i < uplinksPerSpine; i++) {
         // Get next leaf port (each leaf reuses the same port names)
         const leafPort = leafFabricPorts[leafPortIndex];
@@ -268,7 +297,9 @@ export function allocateMultiClassUplinks(
         uplinksPerLeaf: fabricSpec.uplinksPerLeaf,
         leavesNeeded: Math.ceil(fabricSpec.endpointCount / effectiveCapacityPerLeaf),
         spinesNeeded: Math.max(1, Math.ceil((Math.ceil(fabricSpec.endpointCount / effectiveCapacityPerLeaf) * fabricSpec.uplinksPerLeaf) / 32)),
-        endpointCount: fabricSpec.endpointCount
+        endpointCount: fabricSpec.endpointCount,
+        ...(fabricSpec.spineReservation !== undefined && { spineReservation: fabricSpec.spineReservation }),
+        ...(fabricSpec.placementSeed !== undefined && { placementSeed: fabricSpec.placementSeed })
       };
       
       const legacyResult = allocateUplinks(legacySpec, leafProfile, spineProfile);
@@ -318,7 +349,9 @@ export function allocateMultiClassUplinks(
       uplinksPerLeaf: leafClass.uplinksPerLeaf,
       leavesNeeded: classLeavesNeeded,
       spinesNeeded: 0, // Will be calculated globally
-      endpointCount: classEndpoints
+      endpointCount: classEndpoints,
+      ...(fabricSpec.spineReservation !== undefined && { spineReservation: fabricSpec.spineReservation }),
+      ...(fabricSpec.placementSeed !== undefined && { placementSeed: fabricSpec.placementSeed })
     };
     
     classSpecs.push(classSpec);
@@ -422,7 +455,10 @@ function performMultiClassAllocation(
   }
   
   const leafFabricPorts = expandPortRanges(leafProfile.ports.fabricAssignable);
-  const spineFabricPorts = expandPortRanges(spineProfile.ports.fabricAssignable);
+  const spineFabricPorts = unreservedSpinePorts(
+    expandPortRanges(spineProfile.ports.fabricAssignable),
+    spec.spineReservation
+  );
   
   // Check capacity
   if (leafFabricPorts.length < spec.uplinksPerLeaf) {
@@ -441,7 +477,7 @@ function performMultiClassAllocation(
     let leafPortIndex = 0;
     
     // Assign uplinks in round-robin fashion across spines
-    for (let spineId = 0; spineId < spec.spinesNeeded; spineId++) {
+    for (const spineId of spineVisitOrder(spec.spinesNeeded, spec.placementSeed, startingLeafId + leafIdx)) {
       for (let i = 0; i < uplinksPerSpine; i++) {
         const leafPort = leafFabricPorts[leafPortIndex];
         leafPortIndex++;
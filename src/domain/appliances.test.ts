@@ -0,0 +1,76 @@
+/**
+ * L4-7 Appliance Attachment Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { planAppliances, addAppliancesToWiring, appliancesToEndpointProfiles, type Appliance } from './appliances'
+import type { SwitchProfile } from '../app.types'
+import type { Wiring } from './wiring'
+
+const leafProfile: SwitchProfile = {
+  modelId: 'DS2000',
+  roles: ['leaf'],
+  ports: { endpointAssignable: ['E1/1-4'], fabricAssignable: ['E1/49-50'] },
+  profiles: { endpoint: { portProfile: null, speedGbps: 25 }, uplink: { portProfile: null, speedGbps: 100 } },
+  meta: { source: 'test', version: '1' }
+}
+
+const wiring: Wiring = {
+  devices: {
+    spines: [],
+    leaves: [{ id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 4 }, { id: 'leaf-2', type: 'leaf', modelId: 'DS2000', ports: 4 }],
+    servers: [{ id: 'srv-1', type: 'server', modelId: 'server', ports: 1 }]
+  },
+  connections: [{ id: 'link-srv-1', from: { device: 'srv-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' }],
+  metadata: { fabricName: 't', fabricId: 't', generatedAt: new Date(0), totalDevices: 3, totalConnections: 1 }
+}
+
+const firewall: Appliance = {
+  id: 'fw',
+  kind: 'firewall',
+  haMode: 'active-standby',
+  nodes: 2,
+  portsPerNode: 2,
+  speedGbps: 25,
+  handoffs: [{ vrf: 'outside', vlan: 100 }, { vrf: 'inside', vlan: 200 }]
+}
+
+const options = { serviceLeaves: ['leaf-1', 'leaf-2'], profiles: new Map([['DS2000', leafProfile]]) }
+
+describe('appliances', () => {
+  it('places HA nodes on different leaves using free ports', () => {
+    const plan = planAppliances(wiring, [firewall], options)
+
+    expect(plan.errors).toEqual([])
+    expect(plan.placements).toEqual([
+      { node: 'fw-1', applianceId: 'fw', leaf: 'leaf-1', ports: ['E1/2', 'E1/3'] },
+      { node: 'fw-2', applianceId: 'fw', leaf: 'leaf-2', ports: ['E1/1', 'E1/2'] }
+    ])
+  })
+
+  it('allocates transit subnets with VIPs per hand-off', () => {
+    const plan = planAppliances(wiring, [firewall], options)
+
+    expect(plan.addresses[0]).toEqual({
+      applianceId: 'fw', vrf: 'outside', vlan: 100, subnet: '172.16.0.0/29',
+      gateway: '172.16.0.1', vip: '172.16.0.2', nodeAddresses: ['172.16.0.3', '172.16.0.4']
+    })
+    expect(plan.addresses[1].subnet).toBe('172.16.0.8/29')
+  })
+
+  it('rejects conflicting VLAN hand-offs and undersized HA', () => {
+    const lb: Appliance = { ...firewall, id: 'lb', kind: 'load-balancer', nodes: 1, handoffs: [{ vrf: 'dmz', vlan: 100 }] }
+    const plan = planAppliances(wiring, [firewall, lb], options)
+
+    expect(plan.errors).toContain('Appliance lb: VLAN 100 already hands off VRF outside, not dmz')
+    expect(plan.errors).toContain('Appliance lb: active-standby needs at least 2 nodes')
+  })
+
+  it('feeds capacity math and wiring exports', () => {
+    expect(appliancesToEndpointProfiles([firewall])[0]).toMatchObject({ type: 'network', count: 2, portsPerEndpoint: 2, esLag: true })
+
+    const merged = addAppliancesToWiring(wiring, [firewall], planAppliances(wiring, [firewall], options))
+    expect(merged.devices.servers.map(s => s.id)).toEqual(['srv-1', 'fw-1', 'fw-2'])
+    expect(merged.metadata.totalConnections).toBe(5)
+  })
+})
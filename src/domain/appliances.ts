@@ -0,0 +1,205 @@
+/**
+ * L4-7 Appliance Attachment - HNC v0.6
+ * Models firewalls and load balancers as fabric endpoints with HA redundancy
+ * and VLAN/VRF hand-offs, and folds them into port allocation, addressing
+ * and the wiring diagram exports
+ */
+
+import { expandPortRanges } from './portUtils'
+import type { EndpointProfile, SwitchProfile } from '../app.types'
+import type { Wiring, WiringConnection, WiringDevice } from './wiring'
+
+export interface ApplianceHandoff {
+  vrf: string
+  vlan: number
+  prefixLength?: number // transit subnet size, default /29
+}
+
+export interface Appliance {
+  id: string
+  kind: 'firewall' | 'load-balancer'
+  haMode: 'standalone' | 'active-standby' | 'active-active'
+  nodes: number
+  portsPerNode: number
+  speedGbps: number
+  handoffs: ApplianceHandoff[]
+}
+
+export interface ApplianceAddress {
+  applianceId: string
+  vrf: string
+  vlan: number
+  subnet: string
+  gateway: string   // fabric-side anycast gateway
+  vip?: string      // shared address for HA pairs/clusters
+  nodeAddresses: string[]
+}
+
+export interface AppliancePlacement {
+  node: string      // device id for one appliance node
+  applianceId: string
+  leaf: string
+  ports: string[]
+}
+
+export interface AppliancePlan {
+  placements: AppliancePlacement[]
+  addresses: ApplianceAddress[]
+  errors: string[]
+}
+
+/**
+ * Expresses appliances as endpoint profiles so capacity math counts them
+ */
+export function appliancesToEndpointProfiles(appliances: Appliance[]): EndpointProfile[] {
+  return appliances.map(a => ({
+    name: a.id,
+    type: 'network',
+    count: a.nodes,
+    portsPerEndpoint: a.portsPerNode,
+    nics: a.portsPerNode,
+    bandwidth: a.speedGbps,
+    redundancy: a.haMode !== 'standalone',
+    esLag: a.portsPerNode >= 2
+  }))
+}
+
+/**
+ * Places appliance nodes on service leaves and allocates hand-off addressing.
+ * HA nodes of one appliance always land on different leaves.
+ */
+export function planAppliances(
+  wiring: Wiring,
+  appliances: Appliance[],
+  options: { serviceLeaves: string[]; profiles: Map<string, SwitchProfile>; transitPool?: string }
+): AppliancePlan {
+  const { serviceLeaves, profiles, transitPool = '172.16.0.0/16' } = options
+  const errors: string[] = []
+  const placements: AppliancePlacement[] = []
+
+  validateHandoffs(appliances, errors)
+
+  const free = new Map<string, string[]>()
+  for (const leafId of serviceLeaves) {
+    const leaf = wiring.devices.leaves.find(l => l.id === leafId)
+    const profile = leaf && profiles.get(leaf.modelId)
+    if (!leaf || !profile) {
+      errors.push(`Service leaf ${leafId} is not in the wiring or has no profile`)
+      continue
+    }
+    const used = new Set(wiring.connections.flatMap(c => [c.from, c.to]).filter(e => e.device === leafId).map(e => e.port))
+    free.set(leafId, expandPortRanges(profile.ports.endpointAssignable).filter(p => !used.has(p)))
+  }
+  const leaves = serviceLeaves.filter(l => free.has(l))
+
+  for (const appliance of [...appliances].sort((a, b) => a.id.localeCompare(b.id))) {
+    if (appliance.haMode !== 'standalone' && appliance.nodes < 2) {
+      errors.push(`Appliance ${appliance.id}: ${appliance.haMode} needs at least 2 nodes`)
+    }
+    if (appliance.haMode !== 'standalone' && leaves.length < 2) {
+      errors.push(`Appliance ${appliance.id}: HA nodes need at least 2 service leaves`)
+    }
+    for (let n = 0; n < appliance.nodes; n++) {
+      const node = `${appliance.id}-${n + 1}`
+      const leaf = leaves[n % Math.max(1, leaves.length)]
+      const ports = leaf ? free.get(leaf)!.splice(0, appliance.portsPerNode) : []
+      if (!leaf || ports.length < appliance.portsPerNode) {
+        errors.push(`Appliance node ${node}: not enough free ports on service leaf ${leaf ?? '(none)'}`)
+      }
+      placements.push({ node, applianceId: appliance.id, leaf: leaf ?? '', ports })
+    }
+  }
+
+  return { placements, addresses: allocateHandoffAddresses(appliances, transitPool, errors), errors }
+}
+
+/**
+ * Adds placed appliance nodes and their links to a wiring diagram
+ */
+export function addAppliancesToWiring(wiring: Wiring, appliances: Appliance[], plan: AppliancePlan): Wiring {
+  const kinds = new Map(appliances.map(a => [a.id, a.kind]))
+  const devices: WiringDevice[] = plan.placements.map(p => ({
+    id: p.node,
+    type: 'server',
+    modelId: kinds.get(p.applianceId) || 'appliance',
+    ports: p.ports.length,
+    classId: 'appliance'
+  }))
+  const connections: WiringConnection[] = plan.placements.flatMap(p =>
+    p.ports.map((port, i) => ({
+      id: `link-${p.node}-${p.leaf}-${i + 1}`,
+      from: { device: p.node, port: `eth${i}` },
+      to: { device: p.leaf, port },
+      type: 'endpoint' as const
+    }))
+  )
+
+  const allConnections = [...wiring.connections, ...connections].sort((a, b) => a.id.localeCompare(b.id))
+  return {
+    ...wiring,
+    devices: { ...wiring.devices, servers: [...wiring.devices.servers, ...devices] },
+    connections: allConnections,
+    metadata: {
+      ...wiring.metadata,
+      totalDevices: wiring.metadata.totalDevices + devices.length,
+      totalConnections: allConnections.length
+    }
+  }
+}
+
+function validateHandoffs(appliances: Appliance[], errors: string[]) {
+  const vlanOwners = new Map<number, string>()
+  for (const appliance of appliances) {
+    if (appliance.handoffs.length === 0) {
+      errors.push(`Appliance ${appliance.id} has no VLAN/VRF hand-off`)
+    }
+    for (const handoff of appliance.handoffs) {
+      if (handoff.vlan < 2 || handoff.vlan > 4094) {
+        errors.push(`Appliance ${appliance.id}: VLAN ${handoff.vlan} is outside 2-4094`)
+      }
+      const owner = vlanOwners.get(handoff.vlan)
+      if (owner && owner !== handoff.vrf) {
+        errors.push(`Appliance ${appliance.id}: VLAN ${handoff.vlan} already hands off VRF ${owner}, not ${handoff.vrf}`)
+      }
+      vlanOwners.set(handoff.vlan, handoff.vrf)
+    }
+  }
+}
+
+function allocateHandoffAddresses(appliances: Appliance[], pool: string, errors: string[]): ApplianceAddress[] {
+  const [base, bits] = pool.split('/')
+  let cursor = ipToInt(base)
+  const end = cursor + 2 ** (32 - Number(bits))
+  const addresses: ApplianceAddress[] = []
+
+  for (const appliance of [...appliances].sort((a, b) => a.id.localeCompare(b.id))) {
+    for (const handoff of appliance.handoffs) {
+      const prefix = handoff.prefixLength ?? 29
+      const size = 2 ** (32 - prefix)
+      const needed = appliance.nodes + (appliance.haMode === 'standalone' ? 0 : 1) + 1 // nodes + VIP + gateway
+      if (needed > size - 2) {
+        errors.push(`Appliance ${appliance.id} VLAN ${handoff.vlan}: /${prefix} too small for ${needed} addresses`)
+      }
+      cursor = Math.ceil(cursor / size) * size
+      if (cursor + size > end) {
+        errors.push(`Transit pool ${pool} exhausted at appliance ${appliance.id}`)
+        return addresses
+      }
+      addresses.push({
+        applianceId: appliance.id,
+        vrf: handoff.vrf,
+        vlan: handoff.vlan,
+        subnet: `${intToIp(cursor)}/${prefix}`,
+        gateway: intToIp(cursor + 1),
+        vip: appliance.haMode === 'standalone' ? undefined : intToIp(cursor + 2),
+        nodeAddresses: Array.from({ length: appliance.nodes }, (_, i) =>
+          intToIp(cursor + (appliance.haMode === 'standalone' ? 2 : 3) + i))
+      })
+      cursor += size
+    }
+  }
+  return addresses
+}
+
+const ipToInt = (ip: string): number => ip.split('.').reduce((n, o) => n * 256 + Number(o), 0)
+const intToIp = (n: number): string => [24, 16, 8, 0].map(s => Math.floor(n / 2 ** s) % 256).join('.')
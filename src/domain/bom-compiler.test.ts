@@ -305,4 +305,24 @@ describe('BOM Compiler', () => {
       expect(bom.summary.totalTransceivers).toBeGreaterThanOrEqual(expectedMinimum)
     })
   })
-})
\ No newline at end of file
+
+  describe('Vendor Part Numbers', () => {
+    it('lists orderable part numbers when a parts catalog is given', () => {
+      const plain = compileBOM(basicWiring, [], leafModels)
+      const sku = plain.transceivers[0].sku
+      const bom = compileBOM(basicWiring, [], leafModels, [], {
+        partsCatalog: { [sku]: [{ vendor: 'Acme', partNumber: 'AC-1', description: 'Acme optic', price: 5 }] }
+      })
+
+      const item = bom.transceivers.find(t => t.sku === sku)!
+      expect(item).toMatchObject({ vendor: 'Acme', partNumber: 'AC-1', description: 'Acme optic', unitPrice: 5 })
+      expect(item.totalPrice).toBe(5 * item.quantity)
+      expect(bom.switches.every(s => s.partNumber === undefined)).toBe(true) // not in the catalog
+    })
+
+    it('leaves items generic without a catalog', () => {
+      const bom = compileBOM(basicWiring, [], leafModels)
+      expect([...bom.transceivers, ...bom.cables].every(i => i.partNumber === undefined)).toBe(true)
+    })
+  })
+})
@@ -4,8 +4,11 @@
  */
 
 import { SKUService } from '../catalog/sku.service'
+import { resolveVendorPart, type PartsCatalog, type VendorPolicy } from '../catalog/parts.service'
 import { calculateBreakoutFeasibility, type LeafModel } from './leaf-capability-filter'
 import { type ExternalLink, type ExplicitPort } from './external-link'
+import { DEFAULT_OPTIC_SPECS, planLinkOptics, type LinkBudgetOptions, type LinkOpticChoice } from './link-budget'
+import { linkSpeedGbps } from './uplink-speeds'
 import { type WiringDiagram, type WiringDevice, type WiringConnection } from '../app.types'
 
 export interface BOMItem {
@@ -16,11 +19,14 @@ export interface BOMItem {
   unitPrice?: number
   totalPrice?: number
   category: 'switch' | 'transceiver' | 'breakout' | 'cable'
+  vendor?: string // set when a parts catalog resolves the SKU
+  partNumber?: string
   details?: {
     deviceId?: string
     connectionType?: string
     speed?: string
     medium?: string
+    fec?: string // FEC mode the link budget chose for this optic
   }
 }
 
@@ -52,6 +58,12 @@ export interface BOMAnalysis {
   }
 }
 
+export interface BOMOptions {
+  partsCatalog?: PartsCatalog // resolve generic SKUs to orderable vendor parts
+  vendorPolicy?: VendorPolicy
+  linkBudget?: LinkBudgetOptions // pick optics for links with a length annotation
+}
+
 /**
  * Main BOM compilation function
  * Analyzes wiring diagram and external links to produce comprehensive BOM
@@ -60,7 +72,8 @@ export function compileBOM(
   wiringDiagram: WiringDiagram,
   externalLinks: ExternalLink[] = [],
   leafModels: LeafModel[] = [],
-  spineModels: LeafModel[] = [] // Reusing LeafModel interface for consistency
+  spineModels: LeafModel[] = [], // Reusing LeafModel interface for consistency
+  options: BOMOptions = {}
 ): BOMAnalysis {
   try {
     const bomItems = {
@@ -74,7 +87,7 @@ export function compileBOM(
     countSwitches(wiringDiagram, bomItems.switches)
 
     // 2. Count transceivers (per physical link end)
-    countTransceivers(wiringDiagram, externalLinks, bomItems.transceivers)
+    countTransceivers(wiringDiagram, externalLinks, bomItems.transceivers, options.linkBudget)
 
     // 3. Count breakout cables  
     countBreakouts(wiringDiagram, leafModels, bomItems.breakouts)
@@ -83,7 +96,7 @@ export function compileBOM(
     countCables(wiringDiagram, bomItems.cables)
 
     // 5. Calculate pricing for all items
-    addPricingToBOM(bomItems)
+    addPricingToBOM(bomItems, options)
 
     // 6. Generate summary
     const summary = calculateBOMSummary(bomItems, wiringDiagram)
@@ -140,34 +153,71 @@ function countSwitches(wiringDiagram: WiringDiagram, switches: BOMItem[]) {
   }
 }
 
+export interface PortOptic {
+  device: string
+  port: string
+  sku: string
+  fec?: string // FEC mode the link budget chose
+}
+
 /**
- * Count transceivers with per-link-end methodology
- * Critical: Each physical connection requires transceivers at BOTH ends
+ * The optic expected in each switch port of the fabric: both ends of every
+ * uplink and the leaf end of every endpoint link (servers have built-in
+ * NICs). Measured links get the link budget's choice, others the default.
  */
-function countTransceivers(
-  wiringDiagram: WiringDiagram,
-  externalLinks: ExternalLink[],
-  transceivers: BOMItem[]
-) {
-  const transceiverCounts = new Map<string, number>()
+export function expectedPortOptics(wiringDiagram: WiringDiagram, linkBudget?: LinkBudgetOptions): PortOptic[] {
+  // Optics chosen from measured link lengths override the defaults below
+  const planned = new Map<string, LinkOpticChoice>()
+  if (linkBudget) {
+    const prices = Object.fromEntries(
+      (linkBudget.optics ?? DEFAULT_OPTIC_SPECS).map(o => [o.sku, SKUService.getSKUDetails(o.sku).price])
+    )
+    const plan = planLinkOptics(wiringDiagram.connections, { prices, ...linkBudget })
+    if (plan.errors.length > 0) throw new Error(plan.errors.join('; '))
+    for (const link of plan.links) planned.set(`${link.from}>${link.to}`, link)
+  }
+  const plannedOptic = (connection: WiringConnection) =>
+    planned.get(`${connection.from.device}/${connection.from.port}>${connection.to.device}/${connection.to.port}`)
 
-  // 1. Internal fabric connections (leaf-spine)
+  const leaves = new Set(wiringDiagram.devices.leaves.map(l => l.id))
+  const optics: PortOptic[] = []
   for (const connection of wiringDiagram.connections) {
+    const choice = plannedOptic(connection)
+    const fec = choice && { fec: choice.fec }
     if (connection.type === 'uplink') {
       // Each uplink requires 2 transceivers: 1 at leaf, 1 at spine
       const speed = inferConnectionSpeed(connection, wiringDiagram)
-      const sku = SKUService.getTransceiverSKU(speed, '100m', 'dac') // Default to DAC for fabric interconnect
-      
-      // Add 2 transceivers per uplink connection
-      transceiverCounts.set(sku, (transceiverCounts.get(sku) || 0) + 2)
+      const sku = choice?.sku ?? SKUService.getTransceiverSKU(speed, '100m', 'dac') // Default to DAC for fabric interconnect
+      optics.push({ ...connection.from, sku, ...fec }, { ...connection.to, sku, ...fec })
     } else if (connection.type === 'endpoint') {
       // Server connections: 1 transceiver at leaf end (server typically has built-in NIC)
       const speed = inferConnectionSpeed(connection, wiringDiagram)
-      const sku = SKUService.getTransceiverSKU(speed, '3m', 'dac')
-      
-      transceiverCounts.set(sku, (transceiverCounts.get(sku) || 0) + 1)
+      const sku = choice?.sku ?? SKUService.getTransceiverSKU(speed, '3m', 'dac')
+      const leafEnd = leaves.has(connection.from.device) ? connection.from : connection.to
+      optics.push({ ...leafEnd, sku, ...fec })
     }
   }
+  return optics
+}
+
+/**
+ * Count transceivers with per-link-end methodology
+ * Critical: Each physical connection requires transceivers at BOTH ends
+ */
+function countTransceivers(
+  wiringDiagram: WiringDiagram,
+  externalLinks: ExternalLink[],
+  transceivers: BOMItem[],
+  linkBudget?: LinkBudgetOptions
+) {
+  const transceiverCounts = new Map<string, number>()
+  const fecBySku = new Map<string, string>()
+
+  // 1. Internal fabric connections (leaf-spine and server links)
+  for (const optic of expectedPortOptics(wiringDiagram, linkBudget)) {
+    transceiverCounts.set(optic.sku, (transceiverCounts.get(optic.sku) || 0) + 1)
+    if (optic.fec) fecBySku.set(optic.sku, optic.fec)
+  }
 
   // 2. External link connections  
   for (const externalLink of externalLinks) {
@@ -184,12 +234,14 @@ function countTransceivers(
 
   // Convert to BOM items
   for (const [sku, count] of transceiverCounts) {
+    const fec = fecBySku.get(sku)
     transceivers.push({
       sku,
       description: SKUService.getSKUDetails(sku).description,
       quantity: count,
       source: count > 100 ? 'leaf-uplink' : 'server-connection', // Heuristic for source classification
-      category: 'transceiver'
+      category: 'transceiver',
+      ...(fec && { details: { fec } })
     })
   }
 }
@@ -279,15 +331,26 @@ function countCables(wiringDiagram: WiringDiagram, cables: BOMItem[]) {
 }
 
 /**
- * Add pricing information to all BOM items
+ * Add pricing information to all BOM items, using the vendor part's
+ * description and price when a parts catalog resolves the SKU
  */
-function addPricingToBOM(bomItems: { switches: BOMItem[], transceivers: BOMItem[], breakouts: BOMItem[], cables: BOMItem[] }) {
+function addPricingToBOM(
+  bomItems: { switches: BOMItem[], transceivers: BOMItem[], breakouts: BOMItem[], cables: BOMItem[] },
+  options: BOMOptions
+) {
   const allItems = [...bomItems.switches, ...bomItems.transceivers, ...bomItems.breakouts, ...bomItems.cables]
   
   for (const item of allItems) {
     const details = SKUService.getSKUDetails(item.sku)
-    item.unitPrice = details.price
-    item.totalPrice = details.price * item.quantity
+    const part = options.partsCatalog && resolveVendorPart(options.partsCatalog, item.sku, options.vendorPolicy)
+    const price = part?.price ?? details.price
+    if (part) {
+      item.vendor = part.vendor
+      item.partNumber = part.partNumber
+      if (part.description) item.description = part.description
+    }
+    item.unitPrice = price
+    item.totalPrice = price * item.quantity
   }
 }
 
@@ -346,8 +409,8 @@ function inferConnectionSpeed(connection: WiringConnection, wiringDiagram: Wirin
 
   // Default speed inference based on connection type and device types
   if (connection.type === 'uplink') {
-    // Leaf to spine typically 100G in modern fabrics
-    return '100G'
+    // Leaf to spine typically 100G in modern fabrics, unless the link carries its own speed
+    return `${linkSpeedGbps(connection, 100)}G`
   } else if (connection.type === 'endpoint') {
     // Server connections typically 25G
     return '25G' 
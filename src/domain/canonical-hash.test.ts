@@ -0,0 +1,26 @@
+/**
+ * Canonical JSON Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { canonicalJson, canonicalJsonFile } from './canonical-hash'
+
+describe('canonicalJson', () => {
+  it('ignores key order', () => {
+    expect(canonicalJson({ b: 1, a: { d: [2, { f: 1, e: 0 }], c: undefined } }))
+      .toBe('{"a":{"d":[2,{"e":0,"f":1}]},"b":1}')
+  })
+})
+
+describe('canonicalJsonFile', () => {
+  it('writes sorted, indented JSON with a trailing newline', () => {
+    expect(canonicalJsonFile({ zone: 'a<->b', speed: 0.5, ports: [], at: new Date('2024-01-01T00:00:00.000Z'), skip: undefined }))
+      .toBe('{\n  "at": "2024-01-01T00:00:00.000Z",\n  "ports": [],\n  "speed": 0.5,\n  "zone": "a<->b"\n}\n')
+  })
+
+  it('matches the Go emitter for numbers and escapes', () => {
+    // Same cases as tools/hnc-profile-dump/pkg/canonjson
+    expect(canonicalJsonFile([25, 1e21, 1e-7, -0, 'line\r\nbreak\t\u0001']))
+      .toBe('[\n  25,\n  1e+21,\n  1e-7,\n  0,\n  "line\\r\\nbreak\\t\\u0001"\n]\n')
+  })
+})
@@ -0,0 +1,47 @@
+/**
+ * Canonical Hashing - HNC v0.6
+ * Key-order independent JSON and SHA-256 helpers shared by catalog pinning
+ * and design deduplication, and the canonical file layout the contract
+ * fixtures share with the Go tools (tools/hnc-profile-dump/pkg/canonjson).
+ */
+
+/** JSON with object keys sorted recursively, so hashes ignore key order */
+export function canonicalJson(value: unknown): string {
+  if (Array.isArray(value)) return `[${value.map(canonicalJson).join(',')}]`
+  if (value && typeof value === 'object') {
+    const entries = Object.entries(value as Record<string, unknown>)
+      .filter(([, v]) => v !== undefined)
+      .sort(([a], [b]) => (a < b ? -1 : a > b ? 1 : 0))
+    return `{${entries.map(([k, v]) => `${JSON.stringify(k)}:${canonicalJson(v)}`).join(',')}}`
+  }
+  return JSON.stringify(value)
+}
+
+/**
+ * JSON as generated files are written: keys sorted recursively, two-space
+ * indentation and a trailing newline, byte-identical to the Go emitters
+ */
+export function canonicalJsonFile(value: unknown): string {
+  return JSON.stringify(sortKeys(value), null, 2) + '\n'
+}
+
+function sortKeys(value: unknown): unknown {
+  if (value && typeof (value as { toJSON?: unknown }).toJSON === 'function') {
+    value = (value as { toJSON: () => unknown }).toJSON()
+  }
+  if (Array.isArray(value)) return value.map(sortKeys)
+  if (value && typeof value === 'object') {
+    return Object.fromEntries(
+      Object.entries(value as Record<string, unknown>)
+        .filter(([, v]) => v !== undefined)
+        .sort(([a], [b]) => (a < b ? -1 : a > b ? 1 : 0))
+        .map(([k, v]) => [k, sortKeys(v)])
+    )
+  }
+  return value
+}
+
+export async function sha256(text: string): Promise<string> {
+  const digest = await crypto.subtle.digest('SHA-256', new TextEncoder().encode(text))
+  return Array.from(new Uint8Array(digest), b => b.toString(16).padStart(2, '0')).join('')
+}
@@ -0,0 +1,48 @@
+/**
+ * Catalog Pinning Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { pinCatalog, checkCatalogPin, resolveCatalog, describeCatalogDrift } from './catalog-pin'
+import type { SwitchProfile } from '../app.types'
+
+const profile = (modelId: string, speedGbps = 25): SwitchProfile => ({
+  modelId,
+  roles: ['leaf'],
+  ports: { endpointAssignable: ['E1/1-48'], fabricAssignable: ['E1/49-56'] },
+  profiles: {
+    endpoint: { portProfile: 'SFP28-25G', speedGbps },
+    uplink: { portProfile: 'QSFP28-100G', speedGbps: 100 }
+  },
+  meta: { source: 'test', version: 'v0.3.0' }
+})
+
+describe('catalog pinning', () => {
+  it('fingerprints independently of order and key order', async () => {
+    const a = await pinCatalog([profile('ds2000'), profile('ds3000')])
+    const reordered = { ...profile('ds3000'), meta: { version: 'v0.3.0', source: 'test' } }
+    const b = await pinCatalog([reordered, profile('ds2000')])
+
+    expect(a.fingerprint).toMatch(/^[0-9a-f]{64}$/)
+    expect(b.fingerprint).toBe(a.fingerprint)
+    expect(a.versions).toEqual(['v0.3.0'])
+  })
+
+  it('reports per-profile drift against the current catalog', async () => {
+    const pin = await pinCatalog([profile('ds2000'), profile('ds3000')])
+    const drift = await checkCatalogPin(pin, [profile('ds2000', 10), profile('ds4000')])
+
+    expect(drift).toEqual({ matches: false, changed: ['ds2000'], added: ['ds4000'], removed: ['ds3000'] })
+    expect(describeCatalogDrift(drift)[0]).toBe('Profile ds2000 changed since the design was pinned')
+    expect((await checkCatalogPin(pin, [profile('ds3000'), profile('ds2000')])).matches).toBe(true)
+  })
+
+  it('computes against the pinned snapshot in pinned mode', async () => {
+    const pin = await pinCatalog([profile('ds2000')])
+    const current = [profile('ds2000', 10)]
+
+    expect(resolveCatalog(pin, current).get('ds2000')!.profiles.endpoint.speedGbps).toBe(25)
+    expect(resolveCatalog(pin, current, 'current').get('ds2000')!.profiles.endpoint.speedGbps).toBe(10)
+    expect(resolveCatalog(undefined, current).get('ds2000')!.profiles.endpoint.speedGbps).toBe(10)
+  })
+})
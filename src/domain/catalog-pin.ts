@@ -0,0 +1,94 @@
+/**
+ * Per-design Catalog Pinning - HNC v0.6
+ * Records the exact switch profiles a design was computed against so an old
+ * design can be recomputed with its pinned catalog, or checked for drift
+ * against the current one.
+ */
+
+import { canonicalJson, sha256 } from './canonical-hash'
+import type { SwitchProfile } from '../app.types'
+
+export interface CatalogPin {
+  /** SHA-256 over all pinned profiles in canonical form */
+  fingerprint: string
+  /** Distinct profile meta.version values in the catalog */
+  versions: string[]
+  /** Per-model SHA-256, so drift can be reported per profile */
+  models: Record<string, string>
+  /** Snapshot of the profiles, for recomputing against the pinned catalog */
+  profiles: SwitchProfile[]
+  pinnedAt: string
+}
+
+export interface CatalogDrift {
+  matches: boolean
+  changed: string[]
+  added: string[]
+  removed: string[]
+}
+
+/**
+ * Pins a catalog: fingerprints every profile and keeps a snapshot
+ */
+export async function pinCatalog(profiles: SwitchProfile[], pinnedAt: Date = new Date()): Promise<CatalogPin> {
+  const sorted = [...profiles].sort((a, b) => a.modelId.localeCompare(b.modelId))
+  const models: Record<string, string> = {}
+  for (const profile of sorted) {
+    models[profile.modelId] = await sha256(canonicalJson(profile))
+  }
+
+  return {
+    fingerprint: await sha256(Object.entries(models).map(([id, hash]) => `${id}:${hash}`).join('\n')),
+    versions: [...new Set(sorted.map(p => p.meta.version))].sort(),
+    models,
+    profiles: sorted,
+    pinnedAt: pinnedAt.toISOString()
+  }
+}
+
+/**
+ * Compares a pin with the current catalog
+ */
+export async function checkCatalogPin(pin: CatalogPin, current: SwitchProfile[]): Promise<CatalogDrift> {
+  const now = await pinCatalog(current)
+  const changed: string[] = []
+  const removed: string[] = []
+
+  for (const [modelId, hash] of Object.entries(pin.models)) {
+    if (!(modelId in now.models)) removed.push(modelId)
+    else if (now.models[modelId] !== hash) changed.push(modelId)
+  }
+  const added = Object.keys(now.models).filter(modelId => !(modelId in pin.models))
+
+  return {
+    matches: now.fingerprint === pin.fingerprint,
+    changed,
+    added,
+    removed
+  }
+}
+
+/**
+ * Profiles to compute with: the pinned snapshot in pinned mode, otherwise the
+ * current catalog
+ */
+export function resolveCatalog(
+  pin: CatalogPin | undefined,
+  current: SwitchProfile[],
+  mode: 'pinned' | 'current' = 'pinned'
+): Map<string, SwitchProfile> {
+  const profiles = mode === 'pinned' && pin ? pin.profiles : current
+  return new Map(profiles.map(p => [p.modelId, p]))
+}
+
+/**
+ * Formats drift as human-readable warnings
+ */
+export function describeCatalogDrift(drift: CatalogDrift): string[] {
+  if (drift.matches) return []
+  return [
+    ...drift.changed.map(id => `Profile ${id} changed since the design was pinned`),
+    ...drift.removed.map(id => `Profile ${id} was removed from the catalog since the design was pinned`),
+    ...drift.added.map(id => `Profile ${id} was added to the catalog since the design was pinned`)
+  ]
+}
@@ -0,0 +1,51 @@
+/**
+ * Design Content Hashing Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { designHash, groupDuplicateDesigns } from './design-hash'
+import type { WiringDiagram } from '../app.types'
+
+const diagram: WiringDiagram = {
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+    leaves: [
+      { id: 'leaf-1', model: 'DS2000', ports: 48 },
+      { id: 'leaf-2', model: 'DS2000', ports: 48 }
+    ],
+    servers: [{ id: 'srv-1', type: 'server', connections: 1 }]
+  },
+  connections: [
+    { from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' },
+    { from: { device: 'leaf-2', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/2' }, type: 'uplink' }
+  ],
+  metadata: { generatedAt: new Date('2024-01-01'), fabricName: 'a', totalDevices: 4 }
+}
+
+describe('designHash', () => {
+  it('ignores metadata and ordering', async () => {
+    const copy: WiringDiagram = {
+      devices: { ...diagram.devices, leaves: [...diagram.devices.leaves].reverse() },
+      connections: [...diagram.connections].reverse(),
+      metadata: { generatedAt: new Date('2025-06-01'), fabricName: 'b', totalDevices: 4 }
+    }
+
+    expect(await designHash(copy)).toBe(await designHash(diagram))
+  })
+
+  it('changes with content', async () => {
+    const changed = { ...diagram, connections: diagram.connections.slice(1) }
+
+    expect(await designHash(changed)).not.toBe(await designHash(diagram))
+  })
+})
+
+describe('groupDuplicateDesigns', () => {
+  it('returns only groups with more than one fabric', () => {
+    expect(groupDuplicateDesigns([
+      { fabricId: 'f-10', hash: 'x' },
+      { fabricId: 'f-2', hash: 'x' },
+      { fabricId: 'f-3', hash: 'y' }
+    ])).toEqual([['f-2', 'f-10']])
+  })
+})
@@ -0,0 +1,43 @@
+/**
+ * Design Content Hashing - HNC v0.6
+ * Canonical hash of a wiring diagram's content, used to spot accidental
+ * copies in the workspace. Metadata (name, generation time, counts) and
+ * device/connection order do not affect the hash.
+ */
+
+import { canonicalJson, sha256 } from './canonical-hash'
+import type { WiringDiagram, WiringConnection } from '../app.types'
+
+export interface HashedDesign {
+  fabricId: string
+  hash: string
+}
+
+/**
+ * SHA-256 over the diagram's devices and connections in canonical order
+ */
+export async function designHash(diagram: WiringDiagram): Promise<string> {
+  const byId = <T extends { id: string }>(items: T[]) => [...items].sort((a, b) => a.id.localeCompare(b.id))
+  const connectionKey = (c: WiringConnection) => `${c.from.device}|${c.from.port}|${c.to.device}|${c.to.port}|${c.type}`
+
+  return sha256(canonicalJson({
+    spines: byId(diagram.devices.spines),
+    leaves: byId(diagram.devices.leaves),
+    servers: byId(diagram.devices.servers),
+    connections: [...diagram.connections].sort((a, b) => connectionKey(a).localeCompare(connectionKey(b)))
+  }))
+}
+
+/**
+ * Groups designs sharing a hash; only groups of two or more are returned
+ */
+export function groupDuplicateDesigns(designs: HashedDesign[]): string[][] {
+  const groups = new Map<string, string[]>()
+  for (const { fabricId, hash } of designs) {
+    groups.set(hash, [...(groups.get(hash) ?? []), fabricId])
+  }
+  return [...groups.values()]
+    .filter(ids => ids.length > 1)
+    .map(ids => ids.sort((a, b) => a.localeCompare(b, undefined, { numeric: true })))
+    .sort((a, b) => a[0].localeCompare(b[0], undefined, { numeric: true }))
+}
@@ -0,0 +1,199 @@
+/**
+ * Design Optimizer Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import {
+  optimizeDesign,
+  formatDesignComparison,
+  createAnnealingStrategy,
+  createIlpStrategy,
+  designSpaceLp,
+  type DesignSpace,
+  type HardwareOption
+} from './design-optimizer'
+
+const hardware: HardwareOption[] = [
+  { modelId: 'DS2000', role: 'leaf', price: 8500, endpointPorts: 48, endpointSpeedGbps: 25, fabricPorts: 8, fabricSpeedGbps: 100 },
+  { modelId: 'DS1000', role: 'leaf', price: 6000, endpointPorts: 32, endpointSpeedGbps: 25, fabricPorts: 4, fabricSpeedGbps: 100 },
+  { modelId: 'DS3000', role: 'spine', price: 15500, fabricPorts: 32, fabricSpeedGbps: 100 },
+  { modelId: 'DS4000', role: 'spine', price: 45000, fabricPorts: 32, fabricSpeedGbps: 400 }
+]
+
+describe('optimizeDesign', () => {
+  const result = optimizeDesign(hardware, { endpointCount: 96, maxOversubscription: 3, costPerFabricLink: 200 })
+
+  it('ranks feasible designs by total cost', () => {
+    expect(result.errors).toEqual([])
+    expect(result.designs.map(d => `${d.leafModelId}/${d.spineModelId}/${d.uplinksPerLeaf}`))
+      .toEqual(['DS2000/DS3000/4', 'DS2000/DS3000/6', 'DS2000/DS3000/8'])
+    expect(result.designs[0]).toMatchObject({ leaves: 2, spines: 2, oversubscription: 3, cost: { total: 49600 } })
+  })
+
+  it('summarizes trade-offs against the cheapest design', () => {
+    expect(result.designs[1].tradeoffs).toEqual([
+      '+800 (+2%) over the cheapest design',
+      'Better oversubscription (2:1 vs 3:1)',
+      '0 spare endpoint ports, 81% spine ports free for growth'
+    ])
+    expect(formatDesignComparison(result)).toContain('| 1 | DS2000 | DS3000 | 4 | 2 | 2 | 3:1 | 49,600 |')
+  })
+
+  it('applies growth headroom and redundancy constraints', () => {
+    const grown = optimizeDesign(hardware, { endpointCount: 96, maxOversubscription: 3, growthHeadroom: 0.5 }, 1)
+    expect(grown.designs[0].leaves).toBe(3)
+
+    const strict = optimizeDesign(hardware, { endpointCount: 96, maxOversubscription: 10, minSpines: 4, uplinkCounts: [2] })
+    expect(strict.designs).toEqual([])
+    expect(strict.rejected).toEqual({ redundancy: 4 })
+    expect(formatDesignComparison(strict)).toBe('No feasible design (4 evaluated; rejected by redundancy: 4)\n')
+  })
+})
+
+describe('optimization strategies', () => {
+  const constraints = { endpointCount: 96, maxOversubscription: 3, costPerFabricLink: 200 }
+  const exhaustive = optimizeDesign(hardware, constraints, 1)
+
+  it('greedy evaluates fewer points and still finds the cheapest design here', () => {
+    const greedy = optimizeDesign(hardware, constraints, 1, 'greedy')
+    expect(greedy.designs[0].cost.total).toBe(exhaustive.designs[0].cost.total)
+    expect(greedy.evaluated).toBeLessThan(exhaustive.evaluated)
+  })
+
+  it('annealing is reproducible for a given seed', () => {
+    const run = () => optimizeDesign(hardware, constraints, 1, createAnnealingStrategy({ seed: 7, iterations: 200 }))
+    expect(run().designs).toEqual(run().designs)
+    expect(run().designs[0].cost.total).toBe(49600)
+  })
+
+  it('reports unknown strategy names', () => {
+    expect(optimizeDesign(hardware, constraints, 1, 'tabu' as never).errors).toEqual(['Unknown optimization strategy: tabu'])
+  })
+})
+
+/**
+ * Checks values against a CPLEX LP model as designSpaceLp writes it,
+ * returning its cost and the names of the rows and bounds they break
+ */
+function checkLp(lp: string, values: Record<string, number>): { cost: number; broken: string[] } {
+  const sum = (expr: string) => [...expr.matchAll(/([+-]?) ?(\d+(?:\.\d+)?) (\w+)/g)]
+    .reduce((total, [, sign, c, v]) => total + (sign === '-' ? -1 : 1) * Number(c) * (values[v] ?? 0), 0)
+  const lines = lp.split('\n')
+  const section = (name: string) => lines.slice(lines.indexOf(name) + 1, lines.findIndex((l, i) => i > lines.indexOf(name) && !l.startsWith(' ')))
+  const broken: string[] = []
+  for (const line of section('Subject To')) {
+    const [, name, expr, sense, rhs] = line.match(/^ (\w+): (.*) (<=|>=|=) (-?\d+)$/)!
+    const lhs = sum(expr)
+    if (sense === '<=' ? lhs > Number(rhs) : sense === '>=' ? lhs < Number(rhs) : lhs !== Number(rhs)) broken.push(name)
+  }
+  for (const line of section('Bounds')) {
+    const [, lo, v, hi] = line.match(/^ (-?\d+) <= (\w+) <= (-?\d+)$/)!
+    if ((values[v] ?? 0) < Number(lo) || (values[v] ?? 0) > Number(hi)) broken.push(v)
+  }
+  for (const v of section('Binary')[0].trim().split(' ')) {
+    if (![0, 1].includes(values[v] ?? 0)) broken.push(v)
+  }
+  return { cost: sum(section('Minimize')[0].replace(' cost: ', '')), broken }
+}
+
+/**
+ * The values of designSpaceLp's variables for the given leaf, spine,
+ * uplinks and spine count of a space
+ */
+function ilpValues(space: DesignSpace, leaf: string, spine: string, uplinks: number, spineCount: number, bits: number) {
+  const i = space.leaves.findIndex(l => l.modelId === leaf)
+  const j = space.spines.findIndex(s => s.modelId === spine)
+  const speed = [...new Set(space.spines.map(s => s.fabricSpeedGbps))].indexOf(space.spines[j].fabricSpeedGbps)
+  const values: Record<string, number> = {
+    [`leaf${i}`]: 1, [`spine${j}`]: 1, [`spines${j}`]: spineCount, s: spineCount,
+    p: space.spines[j].fabricPorts, [`speed${speed}`]: 1, u: uplinks, [`q${i}`]: uplinks
+  }
+  const k = uplinks / spineCount
+  for (let b = 0; b < bits; b++) {
+    values[`k${b}`] = (k >> b) & 1
+    values[`t${b}`] = values[`k${b}`] * spineCount
+  }
+  return values
+}
+
+describe('ILP strategy', () => {
+  const constraints = { endpointCount: 96, maxOversubscription: 3, costPerFabricLink: 200 }
+  const space: DesignSpace = {
+    leaves: hardware.filter(h => h.role === 'leaf'),
+    spines: hardware.filter(h => h.role === 'spine'),
+    constraints
+  }
+
+  it.each([
+    constraints,
+    { endpointCount: 300, maxOversubscription: 2, minSpines: 3, growthHeadroom: 0.5 }
+  ])('models exactly the designs evaluatePoint accepts, at their cost (%o)', c => {
+    const cSpace = { ...space, constraints: c }
+    const lp = designSpaceLp(cSpace)
+    const designs = new Map(optimizeDesign(hardware, c, 100).designs.map(d => [`${d.leafModelId}/${d.spineModelId}/${d.uplinksPerLeaf}`, d]))
+    expect(designs.size).toBeGreaterThan(0)
+    for (const leaf of space.leaves) {
+      for (const spine of space.spines) {
+        for (let uplinks = 1; uplinks <= 8; uplinks++) {
+          const design = designs.get(`${leaf.modelId}/${spine.modelId}/${uplinks}`)
+          // Every spine count that divides the uplinks, as the model's is free
+          const fits = Array.from({ length: uplinks }, (_, s) => s + 1).filter(s => uplinks % s === 0)
+            .map(s => ({ s, ...checkLp(lp, ilpValues(cSpace, leaf.modelId, spine.modelId, uplinks, s, 4)) }))
+            .filter(r => r.broken.length === 0)
+          if (design) {
+            expect(Math.min(...fits.map(r => r.cost))).toBe(design.cost.total)
+            expect(fits.find(r => r.cost === design.cost.total)!.s).toBe(design.spines)
+          } else {
+            expect(fits, `${leaf.modelId}/${spine.modelId}/${uplinks}`).toEqual([])
+          }
+        }
+      }
+    }
+  })
+
+  it('solves for each next design with the ones found cut out', () => {
+    const models: string[] = []
+    // The second answer falls above the first's cut
+    const answers = [ilpValues(space, 'DS2000', 'DS3000', 4, 2, 4), { ...ilpValues(space, 'DS2000', 'DS3000', 6, 2, 4), d0: 1 }, null]
+    const result = optimizeDesign(hardware, constraints, 3, createIlpStrategy(lp => {
+      models.push(lp)
+      return answers[models.length - 1]
+    }))
+    expect(result.designs.map(d => d.uplinksPerLeaf)).toEqual([4, 6])
+    expect(result.designs[0].cost.total).toBe(49600)
+    expect(result.evaluated).toBe(2)
+    expect(models).toHaveLength(3)
+    // The first answer breaks the second model's cut, the second does not
+    expect(checkLp(models[1], answers[0]!).broken).toEqual(['cutBelow0'])
+    expect(checkLp(models[1], answers[1]!).broken).toEqual([])
+    expect(checkLp(models[2], answers[1]!).broken).toEqual(['cutBelow1'])
+  })
+
+  it('searches a space too large to enumerate', () => {
+    // 2,000 leaves x 64 uplink counts x 2,000 spines: 256 million points
+    const big: HardwareOption[] = [
+      ...Array.from({ length: 2000 }, (_, n): HardwareOption => (
+        { modelId: `L${n}`, role: 'leaf', price: 10000 + n, endpointPorts: 48, endpointSpeedGbps: 25, fabricPorts: 64, fabricSpeedGbps: 100 })),
+      ...Array.from({ length: 2000 }, (_, n): HardwareOption => (
+        { modelId: `S${n}`, role: 'spine', price: 15000 + n, fabricPorts: 64, fabricSpeedGbps: n % 2 ? 400 : 100 }))
+    ]
+    const bigConstraints = { endpointCount: 96, maxOversubscription: 3 }
+    const bigSpace: DesignSpace = { leaves: big.filter(h => h.role === 'leaf'), spines: big.filter(h => h.role === 'spine'), constraints: bigConstraints }
+    // The cheapest leaf and spine, 4 uplinks for 3:1, split over 2 spines
+    const best = ilpValues(bigSpace, 'L0', 'S0', 4, 2, 7)
+    let lines = 0
+    const result = optimizeDesign(big, bigConstraints, 1, createIlpStrategy(lp => {
+      lines = lp.split('\n').length
+      const check = checkLp(lp, best)
+      expect(check).toEqual({ cost: 2 * 10000 + 2 * 15000, broken: [] })
+      // 3 uplinks oversubscribe the leaves, and 3 spines cannot split 4
+      expect(checkLp(lp, ilpValues(bigSpace, 'L0', 'S0', 3, 3, 7)).broken).toContain('oversubscription0_0')
+      expect(checkLp(lp, ilpValues(bigSpace, 'L0', 'S0', 4, 3, 7)).broken).toContain('uplinksPerSpine')
+      return best
+    }))
+    // A few rows per option, not one per point
+    expect(lines).toBeLessThan(5 * big.length)
+    expect(result.evaluated).toBe(1)
+    expect(result.designs).toMatchObject([{ leafModelId: 'L0', spineModelId: 'S0', uplinksPerLeaf: 4, leaves: 2, spines: 2, cost: { total: 50000 } }])
+  })
+})
@@ -0,0 +1,498 @@
+/**
+ * Cost/Constraint Design Optimizer - HNC v0.6
+ * Searches leaf/spine hardware mixes and uplink counts for the cheapest
+ * designs that satisfy oversubscription, redundancy and growth headroom.
+ */
+
+import { seededRandom } from './placement-seed'
+
+export interface HardwareOption {
+  modelId: string
+  role: 'leaf' | 'spine'
+  price: number
+  /** Leaf: server-facing ports. Unused for spines. */
+  endpointPorts?: number
+  endpointSpeedGbps?: number
+  /** Leaf: uplink-capable ports. Spine: leaf-facing ports. */
+  fabricPorts: number
+  fabricSpeedGbps: number
+}
+
+export interface OptimizationConstraints {
+  endpointCount: number
+  /** Maximum leaf oversubscription ratio, e.g. 3 for 3:1 */
+  maxOversubscription: number
+  /** Minimum spine count so a single spine failure keeps the fabric up */
+  minSpines?: number
+  /** Spare endpoint capacity to reserve, as a fraction (0.25 = 25%) */
+  growthHeadroom?: number
+  /** Restrict the uplink counts searched; defaults to 1..leaf fabric ports */
+  uplinkCounts?: number[]
+  /** Cost of optics/cabling per fabric link (both ends) */
+  costPerFabricLink?: number
+}
+
+export interface CandidateDesign {
+  leafModelId: string
+  spineModelId: string
+  uplinksPerLeaf: number
+  leaves: number
+  spines: number
+  oversubscription: number
+  spareEndpointPorts: number
+  spineUtilization: number
+  cost: { switches: number; fabricLinks: number; total: number }
+  tradeoffs: string[]
+}
+
+export interface DesignPoint {
+  leaf: HardwareOption
+  spine: HardwareOption
+  uplinks: number
+}
+
+/**
+ * The design space as its hardware options and constraints, for strategies
+ * that search it without enumerating its points
+ */
+export interface DesignSpace {
+  leaves: HardwareOption[]
+  spines: HardwareOption[]
+  constraints: OptimizationConstraints
+}
+
+/**
+ * Search strategy over the design space. Strategies decide which points to
+ * evaluate; evaluate() is memoized and returns null when infeasible. Most
+ * search the enumerated points; one with searchSpace is handed the space
+ * itself instead, so its points are never enumerated.
+ */
+export interface OptimizationStrategy {
+  name: string
+  search?(space: DesignPoint[], evaluate: (point: DesignPoint) => CandidateDesign | null, topN: number): CandidateDesign[]
+  searchSpace?(space: DesignSpace, evaluate: (point: DesignPoint) => CandidateDesign | null, topN: number): CandidateDesign[]
+}
+
+export type StrategyName = 'exhaustive' | 'greedy' | 'annealing'
+
+export interface OptimizationResult {
+  designs: CandidateDesign[]
+  evaluated: number
+  rejected: Record<string, number>
+  errors: string[]
+}
+
+/**
+ * Searches candidate designs with the given strategy and returns the
+ * top-N by total cost
+ */
+export function optimizeDesign(
+  hardware: HardwareOption[],
+  constraints: OptimizationConstraints,
+  topN = 3,
+  strategy: StrategyName | OptimizationStrategy = 'exhaustive'
+): OptimizationResult {
+  const errors: string[] = []
+  const leaves = hardware.filter(h => h.role === 'leaf')
+  const spines = hardware.filter(h => h.role === 'spine')
+
+  if (constraints.endpointCount <= 0) errors.push('endpointCount must be positive')
+  if (constraints.maxOversubscription <= 0) errors.push('maxOversubscription must be positive')
+  if (leaves.length === 0) errors.push('No leaf hardware options provided')
+  if (spines.length === 0) errors.push('No spine hardware options provided')
+  for (const leaf of leaves) {
+    if (!leaf.endpointPorts || !leaf.endpointSpeedGbps) {
+      errors.push(`Leaf option ${leaf.modelId} needs endpointPorts and endpointSpeedGbps`)
+    }
+  }
+  if (errors.length > 0) return { designs: [], evaluated: 0, rejected: {}, errors }
+
+  const rejected: Record<string, number> = {}
+  const seen = new Map<string, CandidateDesign | null>()
+  const evaluate = (point: DesignPoint): CandidateDesign | null => {
+    const key = pointKey(point)
+    if (!seen.has(key)) {
+      const outcome = evaluatePoint(point, constraints)
+      if (typeof outcome === 'string') rejected[outcome] = (rejected[outcome] || 0) + 1
+      seen.set(key, typeof outcome === 'string' ? null : outcome)
+    }
+    return seen.get(key)!
+  }
+
+  const search = typeof strategy === 'string' ? STRATEGIES[strategy] : strategy
+  if (!search) return { designs: [], evaluated: 0, rejected: {}, errors: [`Unknown optimization strategy: ${strategy}`] }
+
+  const space: DesignSpace = { leaves, spines, constraints }
+  const found = search.searchSpace
+    ? search.searchSpace(space, evaluate, topN)
+    : search.search?.(designPoints(space), evaluate, topN) ?? []
+  const designs = [...found].sort(compareDesigns).slice(0, topN)
+  const best = designs[0]
+  for (const design of designs) {
+    design.tradeoffs = summarizeTradeoffs(design, best)
+  }
+
+  return { designs, evaluated: seen.size, rejected, errors }
+}
+
+/**
+ * Every point of the space: each leaf with each uplink count searched and
+ * each spine
+ */
+function designPoints({ leaves, spines, constraints }: DesignSpace): DesignPoint[] {
+  const points: DesignPoint[] = []
+  for (const leaf of leaves) {
+    for (const uplinks of uplinkCounts(leaf, constraints)) {
+      for (const spine of spines) points.push({ leaf, spine, uplinks })
+    }
+  }
+  return points
+}
+
+const uplinkCounts = (leaf: HardwareOption, constraints: OptimizationConstraints) =>
+  constraints.uplinkCounts ?? Array.from({ length: leaf.fabricPorts }, (_, i) => i + 1)
+
+/**
+ * Scores one design point, returning the rejection reason if infeasible
+ */
+function evaluatePoint(point: DesignPoint, constraints: OptimizationConstraints): CandidateDesign | string {
+  const { leaf, spine, uplinks } = point
+  const minSpines = constraints.minSpines ?? 2
+  const linkCost = constraints.costPerFabricLink ?? 0
+  const requiredPorts = Math.ceil(constraints.endpointCount * (1 + (constraints.growthHeadroom ?? 0)))
+  const endpointPorts = leaf.endpointPorts!
+  const leafCount = Math.ceil(requiredPorts / endpointPorts)
+
+  if (uplinks > leaf.fabricPorts) return 'uplinks exceed leaf fabric ports'
+
+  const linkSpeed = Math.min(leaf.fabricSpeedGbps, spine.fabricSpeedGbps)
+  const oversubscription = (endpointPorts * leaf.endpointSpeedGbps!) / (uplinks * linkSpeed)
+  if (oversubscription > constraints.maxOversubscription) return 'oversubscription'
+
+  // Uplinks must divide evenly across spines (allocator constraint)
+  let spineCount = 0
+  for (let s = minSpines; s <= uplinks; s++) {
+    if (uplinks % s === 0 && (leafCount * uplinks) / s <= spine.fabricPorts) {
+      spineCount = s
+      break
+    }
+  }
+  if (spineCount === 0) return uplinks < minSpines ? 'redundancy' : 'spine capacity'
+
+  const fabricLinks = leafCount * uplinks
+  const switches = leafCount * leaf.price + spineCount * spine.price
+  return {
+    leafModelId: leaf.modelId,
+    spineModelId: spine.modelId,
+    uplinksPerLeaf: uplinks,
+    leaves: leafCount,
+    spines: spineCount,
+    oversubscription: round(oversubscription),
+    spareEndpointPorts: leafCount * endpointPorts - constraints.endpointCount,
+    spineUtilization: round(fabricLinks / (spineCount * spine.fabricPorts)),
+    cost: { switches, fabricLinks: fabricLinks * linkCost, total: switches + fabricLinks * linkCost },
+    tradeoffs: []
+  }
+}
+
+const compareDesigns = (a: CandidateDesign, b: CandidateDesign) =>
+  a.cost.total - b.cost.total ||
+  a.oversubscription - b.oversubscription ||
+  designKey(a).localeCompare(designKey(b), undefined, { numeric: true })
+
+/**
+ * Evaluates every point; exact but linear in the size of the space
+ */
+export const exhaustiveStrategy: OptimizationStrategy = {
+  name: 'exhaustive',
+  search: (space, evaluate) => space.map(evaluate).filter((d): d is CandidateDesign => d !== null)
+}
+
+/**
+ * For each leaf/spine pair, takes the fewest uplinks that are feasible.
+ * Cheap, but skips designs whose extra uplinks would buy fewer spines.
+ */
+export const greedyStrategy: OptimizationStrategy = {
+  name: 'greedy',
+  search: (space, evaluate) => {
+    const pairs = new Map<string, DesignPoint[]>()
+    for (const point of space) {
+      const key = `${point.leaf.modelId}/${point.spine.modelId}`
+      pairs.set(key, [...(pairs.get(key) || []), point])
+    }
+    const found: CandidateDesign[] = []
+    for (const points of pairs.values()) {
+      for (const point of [...points].sort((a, b) => a.uplinks - b.uplinks)) {
+        const design = evaluate(point)
+        if (design) {
+          found.push(design)
+          break
+        }
+      }
+    }
+    return found
+  }
+}
+
+/**
+ * Simulated annealing over the design space with a seeded RNG so runs are
+ * reproducible. Neighbours differ from the current point in one dimension.
+ */
+export function createAnnealingStrategy(options: { iterations?: number; seed?: number; initialTemperature?: number } = {}): OptimizationStrategy {
+  const iterations = options.iterations ?? 500
+  const initialTemperature = options.initialTemperature ?? 1
+
+  return {
+    name: 'annealing',
+    search: (space, evaluate) => {
+      if (space.length === 0) return []
+      const random = seededRandom(options.seed ?? 1)
+      const score = (p: DesignPoint) => evaluate(p)?.cost.total ?? Infinity
+      const found = new Map<string, CandidateDesign>()
+      const record = (p: DesignPoint) => {
+        const design = evaluate(p)
+        if (design) found.set(pointKey(p), design)
+      }
+
+      let current = space[Math.floor(random() * space.length)]
+      record(current)
+      for (let i = 0; i < iterations; i++) {
+        const neighbours = space.filter(p => p !== current && differsInOneDimension(p, current))
+        if (neighbours.length === 0) break
+        const next = neighbours[Math.floor(random() * neighbours.length)]
+        record(next)
+
+        const currentScore = score(current)
+        const nextScore = score(next)
+        const temperature = initialTemperature * (1 - i / iterations)
+        const accept = nextScore <= currentScore ||
+          (Number.isFinite(nextScore) && Number.isFinite(currentScore) &&
+            random() < Math.exp(-(nextScore - currentScore) / (currentScore * temperature + 1e-9)))
+        if (accept || !Number.isFinite(currentScore)) current = next
+      }
+      return [...found.values()]
+    }
+  }
+}
+
+/**
+ * Models the design space as an integer program in CPLEX LP format and hands
+ * it to an external solver (e.g. CBC or HiGHS), so the solver prunes the
+ * space rather than every point being evaluated. The model picks a leaf, a
+ * spine, the uplinks per leaf and the spine count, with the port,
+ * oversubscription, redundancy and spine capacity limits of evaluatePoint as
+ * linear constraints, and minimizes the total cost. Each next design is
+ * solved for with the ones found cut out. The solver callback returns the
+ * values of the model's variables, zero ones optional, or null when it is
+ * infeasible.
+ */
+export function createIlpStrategy(solve: (lp: string) => Record<string, number> | null): OptimizationStrategy {
+  return {
+    name: 'ilp',
+    searchSpace: (space, evaluate, topN) => {
+      const found: CandidateDesign[] = []
+      const cuts: DesignPoint[] = []
+      while (cuts.length < topN) {
+        const values = solve(designSpaceLp(space, cuts))
+        const point = values && ilpPoint(space, values)
+        if (!point) break
+        cuts.push(point)
+        const design = evaluate(point)
+        if (design) found.push(design)
+      }
+      return found
+    }
+  }
+}
+
+/**
+ * The design space as a CPLEX LP model, with the points in cuts excluded.
+ * Variables: binary leafI and spineJ pick the models; integer u is the
+ * uplinks per leaf and spinesJ the count of the picked spine, s in all; p
+ * is the picked spine's ports and binary speedN whether its speed is the
+ * Nth, so that rows per leaf need not name every spine; k, the uplinks from
+ * each leaf to each spine, is written in binary kB, with tB = s x kB so
+ * that u = s x k stays linear; qI is u on the picked leaf, for the fabric
+ * link cost; upC picks a restricted uplink count and dN lets cut N fall
+ * either side of its uplink count. The model grows with the options, not
+ * with the points they make.
+ */
+export function designSpaceLp({ leaves, spines, constraints }: DesignSpace, cuts: DesignPoint[] = []): string {
+  const minSpines = constraints.minSpines ?? 2
+  const linkCost = constraints.costPerFabricLink ?? 0
+  const requiredPorts = Math.ceil(constraints.endpointCount * (1 + (constraints.growthHeadroom ?? 0)))
+  const leafCounts = leaves.map(leaf => Math.ceil(requiredPorts / leaf.endpointPorts!))
+  // Uplinks, and so spines and k, never exceed maxUplinks
+  const maxUplinks = Math.max(...(constraints.uplinkCounts ?? leaves.map(leaf => leaf.fabricPorts)), 1)
+  const bits = Array.from({ length: Math.ceil(Math.log2(maxUplinks + 1)) }, (_, b) => b)
+  const maxSpinePorts = Math.max(...spines.map(spine => spine.fabricPorts))
+  const spineSpeeds = [...new Set(spines.map(spine => spine.fabricSpeedGbps))]
+  const k: Term[] = bits.map(b => [2 ** b, `k${b}`])
+  const rows: string[] = []
+  const row = (name: string, terms: Term[], sense: '<=' | '>=' | '=', rhs: number) =>
+    rows.push(` ${name}: ${linear(terms)} ${sense} ${rhs}`)
+
+  row('oneLeaf', leaves.map((_, i) => [1, `leaf${i}`]), '=', 1)
+  row('oneSpine', spines.map((_, j) => [1, `spine${j}`]), '=', 1)
+  spines.forEach((_, j) => {
+    row(`spineMax${j}`, [[1, `spines${j}`], [-maxUplinks, `spine${j}`]], '<=', 0)
+    row(`spineMin${j}`, [[1, `spines${j}`], [-minSpines, `spine${j}`]], '>=', 0)
+  })
+  row('spineTotal', [[1, 's'], ...spines.map((_, j): Term => [-1, `spines${j}`])], '=', 0)
+  row('spinePorts', [[1, 'p'], ...spines.map((spine, j): Term => [-spine.fabricPorts, `spine${j}`])], '=', 0)
+  spineSpeeds.forEach((speed, n) => row(`spineSpeed${n}`, [[1, `speed${n}`],
+    ...spines.flatMap((spine, j): Term[] => spine.fabricSpeedGbps === speed ? [[-1, `spine${j}`]] : [])], '=', 0))
+  row('uplinkPorts', [[1, 'u'], ...leaves.map((leaf, i): Term => [-leaf.fabricPorts, `leaf${i}`])], '<=', 0)
+  if (constraints.uplinkCounts) {
+    row('uplinkCount', [[1, 'u'], ...constraints.uplinkCounts.map((c, n): Term => [-c, `up${n}`])], '=', 0)
+    row('oneUplinkCount', constraints.uplinkCounts.map((_, n) => [1, `up${n}`]), '=', 1)
+  }
+  // u >= the uplinks leaf I needs at the link speed it runs with the spines
+  // of each speed, when both are picked
+  leaves.forEach((leaf, i) => spineSpeeds.forEach((speed, n) => {
+    const need = minUplinks(leaf, Math.min(leaf.fabricSpeedGbps, speed), constraints.maxOversubscription)
+    row(`oversubscription${i}_${n}`, [[1, 'u'], [-need, `leaf${i}`], [-need, `speed${n}`]], '>=', -need)
+  }))
+  // u = spines x k, with every uplink count a multiple of the spines
+  row('uplinksPerSpine', [[1, 'u'], ...bits.map((b): Term => [-(2 ** b), `t${b}`])], '=', 0)
+  row('kMin', k, '>=', 1)
+  bits.forEach(b => {
+    row(`tOff${b}`, [[1, `t${b}`], [-maxUplinks, `k${b}`]], '<=', 0)
+    row(`tMax${b}`, [[1, `t${b}`], [-1, 's']], '<=', 0)
+    row(`tMin${b}`, [[1, `t${b}`], [-1, 's'], [-maxUplinks, `k${b}`]], '>=', -maxUplinks)
+  })
+  // Each spine takes k links from every leaf: leaves x k <= its ports
+  leaves.forEach((_, i) => {
+    const m = leafCounts[i] * (2 ** bits.length)
+    row(`spineCapacity${i}`, [...k.map(([c, v]): Term => [c * leafCounts[i], v]), [-1, 'p'], [m, `leaf${i}`]], '<=', m)
+  })
+  if (linkCost > 0) {
+    leaves.forEach((_, i) => {
+      row(`qOff${i}`, [[1, `q${i}`], [-maxUplinks, `leaf${i}`]], '<=', 0)
+      row(`qMax${i}`, [[1, `q${i}`], [-1, 'u']], '<=', 0)
+      row(`qMin${i}`, [[1, `q${i}`], [-1, 'u'], [-maxUplinks, `leaf${i}`]], '>=', -maxUplinks)
+    })
+  }
+  // Each cut is excluded: u <= its uplinks - 1 or, with dN, u >= its
+  // uplinks + 1, when its leaf and spine are both picked
+  const big = maxUplinks + 1
+  cuts.forEach((cut, n) => {
+    const leaf = `leaf${leaves.indexOf(cut.leaf)}`
+    const spine = `spine${spines.indexOf(cut.spine)}`
+    row(`cutBelow${n}`, [[1, 'u'], [-big, `d${n}`], [big, leaf], [big, spine]], '<=', cut.uplinks - 1 + 2 * big)
+    row(`cutAbove${n}`, [[1, 'u'], [-big, `d${n}`], [-big, leaf], [-big, spine]], '>=', cut.uplinks + 1 - 3 * big)
+  })
+
+  const cost: Term[] = [
+    ...leaves.map((leaf, i): Term => [leafCounts[i] * leaf.price, `leaf${i}`]),
+    ...spines.map((spine, j): Term => [spine.price, `spines${j}`]),
+    ...(linkCost > 0 ? leaves.map((_, i): Term => [linkCost * leafCounts[i], `q${i}`]) : [])
+  ]
+  const integers = ['u', 's', ...spines.map((_, j) => `spines${j}`), ...bits.map(b => `t${b}`), ...(linkCost > 0 ? leaves.map((_, i) => `q${i}`) : [])]
+  const binaries = [
+    ...leaves.map((_, i) => `leaf${i}`), ...spines.map((_, j) => `spine${j}`), ...spineSpeeds.map((_, n) => `speed${n}`), ...bits.map(b => `k${b}`),
+    ...(constraints.uplinkCounts ?? []).map((_, n) => `up${n}`), ...cuts.map((_, n) => `d${n}`)
+  ]
+  return [
+    'Minimize',
+    ` cost: ${linear(cost)}`,
+    'Subject To',
+    ...rows,
+    'Bounds',
+    ` 1 <= u <= ${maxUplinks}`,
+    ...integers.slice(1).map(v => ` 0 <= ${v} <= ${maxUplinks}`),
+    ` 0 <= p <= ${maxSpinePorts}`,
+    'General',
+    ` ${[...integers, 'p'].join(' ')}`,
+    'Binary',
+    ` ${binaries.join(' ')}`,
+    'End',
+    ''
+  ].join('\n')
+}
+
+/**
+ * The design point a solution of designSpaceLp picks, or null if it picks
+ * none
+ */
+function ilpPoint({ leaves, spines }: DesignSpace, values: Record<string, number>): DesignPoint | null {
+  const leaf = leaves.find((_, i) => Math.round(values[`leaf${i}`] ?? 0) === 1)
+  const spine = spines.find((_, j) => Math.round(values[`spine${j}`] ?? 0) === 1)
+  const uplinks = Math.round(values.u ?? 0)
+  return leaf && spine && uplinks > 0 ? { leaf, spine, uplinks } : null
+}
+
+/**
+ * The fewest uplinks at linkSpeed that keep the leaf within the
+ * oversubscription evaluatePoint allows
+ */
+function minUplinks(leaf: HardwareOption, linkSpeed: number, maxOversubscription: number): number {
+  const endpointGbps = leaf.endpointPorts! * leaf.endpointSpeedGbps!
+  let uplinks = Math.max(1, Math.ceil(endpointGbps / (maxOversubscription * linkSpeed)))
+  while (uplinks > 1 && endpointGbps / ((uplinks - 1) * linkSpeed) <= maxOversubscription) uplinks--
+  while (endpointGbps / (uplinks * linkSpeed) > maxOversubscription) uplinks++
+  return uplinks
+}
+
+/** A coefficient and variable of a linear expression */
+type Term = [number, string]
+
+const linear = (terms: Term[]) =>
+  terms.map(([c, v], i) => i === 0 ? `${c} ${v}` : c < 0 ? `- ${-c} ${v}` : `+ ${c} ${v}`).join(' ')
+
+const STRATEGIES: Record<string, OptimizationStrategy> = {
+  exhaustive: exhaustiveStrategy,
+  greedy: greedyStrategy,
+  annealing: createAnnealingStrategy()
+}
+
+function differsInOneDimension(a: DesignPoint, b: DesignPoint): boolean {
+  const diffs = Number(a.leaf !== b.leaf) + Number(a.spine !== b.spine) + Number(a.uplinks !== b.uplinks)
+  return diffs === 1
+}
+
+function summarizeTradeoffs(design: CandidateDesign, best: CandidateDesign): string[] {
+  const notes: string[] = []
+  if (design === best) {
+    notes.push('Lowest total cost')
+  } else {
+    const delta = design.cost.total - best.cost.total
+    const pct = best.cost.total > 0 ? Math.round((delta / best.cost.total) * 100) : 0
+    notes.push(`+${delta.toLocaleString('en-US')} (+${pct}%) over the cheapest design`)
+  }
+  if (design.oversubscription < best.oversubscription) {
+    notes.push(`Better oversubscription (${design.oversubscription}:1 vs ${best.oversubscription}:1)`)
+  }
+  if (design.spines > best.spines) {
+    notes.push(`Survives more spine failures (${design.spines} spines vs ${best.spines})`)
+  }
+  const spareSpinePct = Math.round((1 - design.spineUtilization) * 100)
+  notes.push(`${design.spareEndpointPorts} spare endpoint ports, ${spareSpinePct}% spine ports free for growth`)
+  return notes
+}
+
+/**
+ * Renders the ranked designs as a Markdown comparison table
+ */
+export function formatDesignComparison(result: OptimizationResult): string {
+  if (result.designs.length === 0) {
+    const reasons = Object.entries(result.rejected).map(([reason, n]) => `${reason}: ${n}`).join(', ')
+    return `No feasible design (${result.evaluated} evaluated${reasons ? `; rejected by ${reasons}` : ''})\n`
+  }
+
+  const lines = [
+    '| # | Leaf | Spine | Uplinks | Leaves | Spines | Oversub | Total cost | Trade-offs |',
+    '|---|------|-------|---------|--------|--------|---------|------------|------------|'
+  ]
+  result.designs.forEach((d, i) => {
+    lines.push(`| ${i + 1} | ${d.leafModelId} | ${d.spineModelId} | ${d.uplinksPerLeaf} | ${d.leaves} | ${d.spines} | ` +
+      `${d.oversubscription}:1 | ${d.cost.total.toLocaleString('en-US')} | ${d.tradeoffs.join('; ')} |`)
+  })
+  return lines.join('\n') + '\n'
+}
+
+const designKey = (d: CandidateDesign) => `${d.leafModelId}/${d.spineModelId}/${d.uplinksPerLeaf}`
+
+const pointKey = (p: DesignPoint) => `${p.leaf.modelId}/${p.spine.modelId}/${p.uplinks}`
+
+const round = (n: number) => Math.round(n * 100) / 100
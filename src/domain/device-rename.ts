@@ -0,0 +1,54 @@
+/**
+ * Device Rename - HNC v0.6
+ * Renames devices across a wiring - device ids, connection ends and the
+ * generated link ids - so naming schemes can be applied after the build.
+ */
+
+import type { Wiring, WiringDevice } from './wiring'
+
+export interface DeviceRenameResult {
+  wiring: Wiring
+  mapping: Record<string, string> // old id -> new id, renamed devices only
+  errors: string[]
+}
+
+/**
+ * Applies a new id to every device the callback names. All-or-nothing: if two
+ * devices would share an id the input wiring is returned unchanged.
+ */
+export function renameDevices(
+  wiring: Wiring,
+  rename: (device: WiringDevice) => string | undefined
+): DeviceRenameResult {
+  const all = [...wiring.devices.spines, ...wiring.devices.leaves, ...wiring.devices.servers]
+  const mapping: Record<string, string> = {}
+  for (const device of all) {
+    const id = rename(device)
+    if (id !== undefined && id !== device.id) mapping[device.id] = id
+  }
+
+  const owners = new Map<string, string>()
+  const errors: string[] = []
+  for (const device of all) {
+    const id = mapping[device.id] ?? device.id
+    const owner = owners.get(id)
+    if (owner !== undefined) errors.push(`Devices ${owner} and ${device.id} would both be named ${id}`)
+    else owners.set(id, device.id)
+  }
+  if (errors.length > 0) return { wiring, mapping: {}, errors }
+
+  const next = structuredClone(wiring)
+  const idOf = (id: string) => mapping[id] ?? id
+  for (const device of [...next.devices.spines, ...next.devices.leaves, ...next.devices.servers]) {
+    device.id = idOf(device.id)
+  }
+  for (const connection of next.connections) {
+    const generated = connection.id.match(/^link-(.+)-(\d+)$/)
+    if (generated && generated[1] === `${connection.from.device}-${connection.to.device}`) {
+      connection.id = `link-${idOf(connection.from.device)}-${idOf(connection.to.device)}-${generated[2]}`
+    }
+    connection.from.device = idOf(connection.from.device)
+    connection.to.device = idOf(connection.to.device)
+  }
+  return { wiring: next, mapping, errors }
+}
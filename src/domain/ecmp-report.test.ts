@@ -0,0 +1,56 @@
+/**
+ * ECMP Path Count & Symmetry Report Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { computeEcmpReport } from './ecmp-report'
+import type { Wiring, WiringConnection } from './wiring'
+
+function fixture(uplinks: Array<[string, string, string]>): Wiring {
+  const connections: WiringConnection[] = uplinks.map(([leaf, spine, port], i) => ({
+    id: `link-${i}`,
+    from: { device: leaf, port },
+    to: { device: spine, port: `E1/${i + 1}` },
+    type: 'uplink'
+  }))
+  const dev = (id: string, type: 'spine' | 'leaf') => ({ id, type, modelId: type, ports: 8 })
+  return {
+    devices: {
+      spines: [dev('spine-1', 'spine'), dev('spine-2', 'spine')],
+      leaves: [dev('leaf-1', 'leaf'), dev('leaf-2', 'leaf'), dev('leaf-3', 'leaf')],
+      servers: []
+    },
+    connections,
+    metadata: { fabricName: 't', fabricId: 't', generatedAt: new Date(0), totalDevices: 5, totalConnections: connections.length }
+  }
+}
+
+const symmetric: Array<[string, string, string]> = [
+  ['leaf-1', 'spine-1', 'E1/49'], ['leaf-1', 'spine-2', 'E1/50'],
+  ['leaf-2', 'spine-1', 'E1/49'], ['leaf-2', 'spine-2', 'E1/50'],
+  ['leaf-3', 'spine-1', 'E1/49'], ['leaf-3', 'spine-2', 'E1/50']
+]
+
+describe('computeEcmpReport', () => {
+  it('reports uniform path counts for a symmetric fabric', () => {
+    const report = computeEcmpReport(fixture(symmetric))
+    expect(report.pairs).toHaveLength(6)
+    expect(report.minPaths).toBe(2)
+    expect(report.maxPaths).toBe(2)
+    expect(report.asymmetries).toHaveLength(0)
+  })
+
+  it('flags uneven uplink counts', () => {
+    const report = computeEcmpReport(fixture([...symmetric, ['leaf-1', 'spine-1', 'E1/51']]))
+    expect(report.asymmetries.some(a => a.kind === 'uneven-uplinks' && a.leaf === 'leaf-1')).toBe(true)
+    expect(report.pairs.find(p => p.source === 'leaf-1' && p.destination === 'leaf-2')!.paths).toBe(3)
+  })
+
+  it('accounts for failed-port exclusions', () => {
+    const report = computeEcmpReport(fixture(symmetric), [{ device: 'leaf-3', port: 'E1/50' }])
+    expect(report.excludedLinks).toEqual(['link-5'])
+    expect(report.minPaths).toBe(1)
+    expect(report.asymmetries.map(a => a.kind)).toContain('missing-spine')
+    expect(report.asymmetries.filter(a => a.kind === 'path-count-mismatch')).toHaveLength(2)
+  })
+})
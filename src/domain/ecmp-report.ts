@@ -0,0 +1,124 @@
+/**
+ * ECMP Path Count & Symmetry Report - HNC v0.6
+ * Counts equal-cost leaf-to-leaf paths through the spine layer and flags
+ * asymmetries caused by uneven uplink counts or excluded (failed) ports
+ */
+
+import type { Wiring, WiringConnection } from './wiring'
+
+export interface ExcludedPort {
+  device: string
+  port: string
+}
+
+export interface LeafPairPaths {
+  source: string
+  destination: string
+  paths: number
+  // Parallel links used on the source side per spine
+  perSpine: Record<string, number>
+}
+
+export interface EcmpAsymmetry {
+  kind: 'uneven-uplinks' | 'missing-spine' | 'path-count-mismatch'
+  leaf: string
+  peer?: string
+  message: string
+}
+
+export interface EcmpReport {
+  pairs: LeafPairPaths[]
+  minPaths: number
+  maxPaths: number
+  asymmetries: EcmpAsymmetry[]
+  excludedLinks: string[]
+}
+
+/**
+ * Builds the ECMP report for every ordered leaf pair.
+ * A path is one (source uplink, spine, destination uplink) combination.
+ */
+export function computeEcmpReport(wiring: Wiring, excluded: ExcludedPort[] = []): EcmpReport {
+  const excludedKeys = new Set(excluded.map(p => `${p.device}:${p.port}`))
+  const isExcluded = (c: WiringConnection) =>
+    excludedKeys.has(`${c.from.device}:${c.from.port}`) || excludedKeys.has(`${c.to.device}:${c.to.port}`)
+
+  const uplinks = wiring.connections.filter(c => c.type === 'uplink')
+  const excludedLinks = uplinks.filter(isExcluded).map(c => c.id).sort()
+  const active = uplinks.filter(c => !isExcluded(c))
+
+  const spines = wiring.devices.spines.map(s => s.id).sort(byId)
+  const leaves = wiring.devices.leaves.map(l => l.id).sort(byId)
+
+  // leaf -> spine -> active link count
+  const counts = new Map<string, Map<string, number>>()
+  for (const leaf of leaves) counts.set(leaf, new Map())
+  for (const link of active) {
+    const bySpine = counts.get(link.from.device)
+    if (!bySpine) continue
+    bySpine.set(link.to.device, (bySpine.get(link.to.device) || 0) + 1)
+  }
+
+  const asymmetries: EcmpAsymmetry[] = []
+  for (const leaf of leaves) {
+    const bySpine = counts.get(leaf)!
+    const missing = spines.filter(s => !bySpine.has(s))
+    if (missing.length > 0 && bySpine.size > 0) {
+      asymmetries.push({
+        kind: 'missing-spine',
+        leaf,
+        message: `Leaf ${leaf} has no active uplink to ${missing.join(', ')}`
+      })
+    }
+    const values = [...bySpine.values()]
+    if (values.length > 1 && Math.max(...values) !== Math.min(...values)) {
+      const detail = [...bySpine.entries()].map(([s, n]) => `${s}=${n}`).join(', ')
+      asymmetries.push({
+        kind: 'uneven-uplinks',
+        leaf,
+        message: `Leaf ${leaf} spreads uplinks unevenly across spines (${detail}); ECMP hashing will overload the wider spines`
+      })
+    }
+  }
+
+  const pairs: LeafPairPaths[] = []
+  for (const source of leaves) {
+    for (const destination of leaves) {
+      if (source === destination) continue
+      const src = counts.get(source)!
+      const dst = counts.get(destination)!
+      const perSpine: Record<string, number> = {}
+      let paths = 0
+      for (const spine of spines) {
+        const a = src.get(spine) || 0
+        const b = dst.get(spine) || 0
+        if (a > 0 && b > 0) {
+          perSpine[spine] = a
+          paths += a * b
+        }
+      }
+      pairs.push({ source, destination, paths, perSpine })
+    }
+  }
+
+  const pathCounts = pairs.map(p => p.paths)
+  const minPaths = pathCounts.length > 0 ? Math.min(...pathCounts) : 0
+  const maxPaths = pathCounts.length > 0 ? Math.max(...pathCounts) : 0
+
+  if (minPaths !== maxPaths) {
+    for (const pair of pairs) {
+      if (pair.paths < maxPaths && pair.source < pair.destination) {
+        asymmetries.push({
+          kind: 'path-count-mismatch',
+          leaf: pair.source,
+          peer: pair.destination,
+          message: `${pair.source} -> ${pair.destination} has ${pair.paths} equal-cost paths; the best-connected leaf pairs have ${maxPaths}`
+        })
+      }
+    }
+  }
+
+  return { pairs, minPaths, maxPaths, asymmetries, excludedLinks }
+}
+
+const byId = (a: string, b: string) => a.localeCompare(b, undefined, { numeric: true })
@@ -0,0 +1,42 @@
+/**
+ * Allocation Explain Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { traceWiring, explainArtifact, formatExplanation } from './explain'
+import { testProfiles, testSpec, testAllocation } from '../fixtures/testFabric'
+
+const profiles = testProfiles()
+
+const spec = testSpec({ name: 'Explain Fabric', endpointCount: 3 })
+const allocation = testAllocation(2)
+
+describe('explainArtifact', () => {
+  const { wiring, trace } = traceWiring(spec, profiles, allocation)
+
+  it('records one decision per connection', () => {
+    expect(trace).toHaveLength(wiring.connections.length)
+  })
+
+  it('explains why a server landed on a leaf port', () => {
+    const explanation = explainArtifact(trace, 'srv-default-server-3')
+
+    expect(explanation.decisions).toHaveLength(1)
+    expect(explanation.decisions[0].target).toEqual({ device: 'leaf-2', port: 'E1/1' })
+    expect(explanation.decisions[0].reasons[1]).toContain('2 endpoint ports per leaf after reserving 2 for uplinks')
+  })
+
+  it('resolves device ports on either end of an uplink', () => {
+    expect(explainArtifact(trace, 'spine-2:E1/2').decisions.map(d => d.connectionId))
+      .toEqual(['link-leaf-2-spine-2-2'])
+    expect(explainArtifact(trace, 'leaf-1 E1/6').decisions.map(d => d.connectionId))
+      .toEqual(['link-leaf-1-spine-2-2'])
+  })
+
+  it('formats explanations and reports unknown targets', () => {
+    expect(formatExplanation(explainArtifact(trace, 'link-leaf-1-spine-1-1'))).toContain(
+      'link-leaf-1-spine-1-1 -> spine-1 E1/1 [uplink-round-robin]'
+    )
+    expect(formatExplanation(explainArtifact(trace, 'leaf-9:E1/1'))).toBe("No allocation decision found for 'leaf-9:E1/1'\n")
+  })
+})
@@ -0,0 +1,87 @@
+/**
+ * Allocation Explain - HNC v0.6
+ * Answers "why did this connection land here?" by replaying the wiring build
+ * with a decision trace and selecting the decisions behind an artifact.
+ *
+ * IP addressing is not assigned by the wiring build, so explanations cover
+ * uplink and endpoint port placement only.
+ */
+
+import { buildWiring, type Wiring, type WiringDecision } from './wiring'
+import type { FabricSpec, SwitchProfile } from '../app.types'
+import type { AllocationResult, MultiClassAllocationResult } from './types'
+
+export interface Explanation {
+  target: string
+  decisions: WiringDecision[]
+}
+
+export interface TracedWiring {
+  wiring: Wiring
+  trace: WiringDecision[]
+}
+
+/**
+ * Rebuilds wiring for a spec and records every placement decision
+ */
+export function traceWiring(
+  spec: FabricSpec,
+  profiles: Map<string, SwitchProfile>,
+  allocation: AllocationResult | MultiClassAllocationResult
+): TracedWiring {
+  const trace: WiringDecision[] = []
+  const wiring = buildWiring(spec, profiles, allocation, trace)
+  return { wiring, trace }
+}
+
+/**
+ * Selects the decisions explaining a target, which may be
+ * - a connection id (link-leaf-1-spine-2-1)
+ * - a device id (srv-default-server-42, leaf-7): decisions placing that device
+ *   or landing on it
+ * - a device port (leaf-7:E1/13 or "leaf-7 E1/13"): the decision that used it
+ */
+export function explainArtifact(trace: WiringDecision[], target: string): Explanation {
+  const query = target.trim()
+  const portMatch = /^([^\s:]+)[\s:]+(\S+)$/.exec(query)
+
+  let decisions: WiringDecision[]
+  if (portMatch) {
+    const [, device, port] = portMatch
+    decisions = trace.filter(d => d.target.device === device && d.target.port === port)
+    if (decisions.length === 0) {
+      // Leaf-side uplink ports are recorded in the reasons, not the target
+      decisions = trace.filter(d => d.subject === device && d.rule === 'uplink-round-robin' &&
+        d.reasons[0].includes(` port ${port} `))
+    }
+  } else {
+    decisions = trace.filter(d => d.connectionId === query || d.subject === query)
+    if (decisions.length === 0) {
+      decisions = trace.filter(d => d.target.device === query)
+    }
+  }
+
+  return {
+    target: query,
+    decisions: [...decisions].sort((a, b) => a.connectionId.localeCompare(b.connectionId, undefined, { numeric: true }))
+  }
+}
+
+/**
+ * Formats an explanation as indented plain text
+ */
+export function formatExplanation(explanation: Explanation): string {
+  if (explanation.decisions.length === 0) {
+    return `No allocation decision found for '${explanation.target}'\n`
+  }
+
+  const lines = [`Explaining ${explanation.target}:`]
+  for (const decision of explanation.decisions) {
+    lines.push('')
+    lines.push(`${decision.connectionId} -> ${decision.target.device} ${decision.target.port} [${decision.rule}]`)
+    for (const reason of decision.reasons) {
+      lines.push(`  - ${reason}`)
+    }
+  }
+  return lines.join('\n') + '\n'
+}
@@ -0,0 +1,80 @@
+/**
+ * Frozen Object Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { FROZEN_ANNOTATION, checkFrozenObjects, freezeObjects, isFrozen, mergeFrozenObjects } from './frozen'
+import { validateWiring, type Wiring, type WiringConnection } from './wiring'
+
+const uplink = (leaf: string, spine: string, leafPort: string, spinePort: string): WiringConnection =>
+  ({ id: `link-${leaf}-${spine}-1`, from: { device: leaf, port: leafPort }, to: { device: spine, port: spinePort }, type: 'uplink' })
+
+const fabric = (connections: WiringConnection[], leaves = ['leaf-1', 'leaf-2']): Wiring => ({
+  devices: {
+    spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32 }],
+    leaves: leaves.map(id => ({ id, type: 'leaf' as const, modelId: 'DS2000', ports: 48 })),
+    servers: []
+  },
+  connections,
+  metadata: { fabricName: 'brownfield', fabricId: 'brownfield', generatedAt: new Date(0), totalDevices: 1 + leaves.length, totalConnections: connections.length }
+})
+
+// Live fabric: leaf-1 is in production, with its loopback recorded as an annotation
+const live = freezeObjects(
+  fabric([uplink('leaf-1', 'spine-1', 'E1/49', 'E1/1'), uplink('leaf-2', 'spine-1', 'E1/49', 'E1/2')]),
+  ['leaf-1']
+)
+live.devices.leaves[0].annotations!['hnc.githedgehog.com/loopback'] = '10.0.0.1/32'
+
+describe('freezeObjects', () => {
+  it('marks the named objects without touching the input', () => {
+    expect(isFrozen(live.devices.leaves[0])).toBe(true)
+    expect(isFrozen(live.devices.leaves[1])).toBe(false)
+    expect(live.devices.leaves[0].annotations?.[FROZEN_ANNOTATION]).toBe('true')
+  })
+})
+
+describe('checkFrozenObjects', () => {
+  it('accepts expansions that leave frozen objects alone', () => {
+    const expanded = structuredClone(live)
+    expanded.devices.leaves.push({ id: 'leaf-3', type: 'leaf', modelId: 'DS2000', ports: 48 })
+    expanded.connections.push(uplink('leaf-3', 'spine-1', 'E1/49', 'E1/3'))
+
+    expect(checkFrozenObjects(live, expanded)).toEqual([])
+  })
+
+  it('reports frozen devices and cables a recompute would change', () => {
+    // Recompute moved leaf-1's uplink and dropped its loopback; leaf-3 took the old spine port
+    const recomputed = fabric([
+      uplink('leaf-1', 'spine-1', 'E1/50', 'E1/4'),
+      uplink('leaf-2', 'spine-1', 'E1/49', 'E1/2'),
+      uplink('leaf-3', 'spine-1', 'E1/49', 'E1/1')
+    ], ['leaf-1', 'leaf-2', 'leaf-3'])
+
+    expect(checkFrozenObjects(live, recomputed)).toEqual([
+      'Frozen device leaf-1 would be modified',
+      'Frozen connection leaf-1/E1/49 - spine-1/E1/1 would be reallocated',
+      'Connection leaf-1/E1/50 - spine-1/E1/4 adds a cable to frozen device leaf-1',
+      'Connection leaf-3/E1/49 - spine-1/E1/1 needs port spine-1/E1/1, held by frozen connection leaf-1/E1/49 - spine-1/E1/1'
+    ])
+    expect(validateWiring(recomputed, { baseline: live }).errors).toContain('Frozen device leaf-1 would be modified')
+  })
+})
+
+describe('mergeFrozenObjects', () => {
+  it('restores frozen objects and drops computed cables on their ports', () => {
+    const recomputed = fabric([
+      uplink('leaf-1', 'spine-1', 'E1/50', 'E1/4'),
+      uplink('leaf-2', 'spine-1', 'E1/49', 'E1/1')
+    ])
+
+    const { wiring, errors } = mergeFrozenObjects(live, recomputed)
+
+    expect(wiring.devices.leaves[0]).toEqual(live.devices.leaves[0])
+    expect(wiring.connections.map(c => `${c.from.device}/${c.from.port}-${c.to.port}`)).toEqual(['leaf-1/E1/49-E1/1'])
+    expect(errors).toEqual([
+      'Computed connection leaf-1/E1/50 - spine-1/E1/4 dropped: adds a cable to frozen device leaf-1',
+      'Computed connection leaf-2/E1/49 - spine-1/E1/1 dropped: needs port spine-1/E1/1, held by frozen connection leaf-1/E1/49 - spine-1/E1/1'
+    ])
+  })
+})
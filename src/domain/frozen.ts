@@ -0,0 +1,133 @@
+/**
+ * Frozen Objects - HNC v0.6
+ * Brownfield devices and cables can be marked frozen: recomputation keeps
+ * them exactly as imported - ports, models and the IP/ASN annotations they
+ * carry - and any change that would need to touch them is reported as an
+ * error instead of being applied to live infrastructure.
+ */
+
+import { canonicalJson } from './canonical-hash'
+import type { Wiring, WiringConnection, WiringDevice } from './wiring'
+import type { Labeled } from '../app.types'
+
+export const FROZEN_ANNOTATION = 'hnc.githedgehog.com/frozen'
+
+export interface FrozenMergeResult {
+  wiring: Wiring
+  errors: string[]
+}
+
+export function isFrozen(obj: Labeled): boolean {
+  return obj.annotations?.[FROZEN_ANNOTATION] === 'true'
+}
+
+/**
+ * Marks the devices and connections with the given ids as frozen
+ */
+export function freezeObjects(wiring: Wiring, ids: Iterable<string>): Wiring {
+  const wanted = new Set(ids)
+  const next = structuredClone(wiring)
+  for (const obj of [...allDevices(next), ...next.connections]) {
+    if (wanted.has(obj.id)) obj.annotations = { ...obj.annotations, [FROZEN_ANNOTATION]: 'true' }
+  }
+  return next
+}
+
+/**
+ * Why the proposed wiring may not replace the baseline: frozen devices
+ * removed or changed, frozen cables moved, or new cables on frozen ports
+ */
+export function checkFrozenObjects(baseline: Wiring, proposed: Wiring): string[] {
+  const errors: string[] = []
+  const proposedDevices = new Map(allDevices(proposed).map(d => [d.id, d]))
+  for (const device of allDevices(baseline).filter(isFrozen)) {
+    const current = proposedDevices.get(device.id)
+    if (!current) errors.push(`Frozen device ${device.id} would be removed`)
+    else if (!sameJson(current, device)) errors.push(`Frozen device ${device.id} would be modified`)
+  }
+
+  const held = frozenConnections(baseline)
+  const byEnds = new Map(proposed.connections.map(c => [ends(c), c]))
+  for (const connection of held) {
+    const current = byEnds.get(ends(connection))
+    if (!current) errors.push(`Frozen connection ${describe(connection)} would be reallocated`)
+    else if (!sameJson(current.annotations, connection.annotations) || !sameJson(current.labels, connection.labels)) {
+      errors.push(`Frozen connection ${describe(connection)} would be modified`)
+    }
+  }
+
+  const conflicts = conflictsWith(baseline, held)
+  for (const connection of proposed.connections) {
+    errors.push(...conflicts(connection).map(reason => `Connection ${describe(connection)} ${reason}`))
+  }
+  return errors
+}
+
+/**
+ * Lays the baseline's frozen objects over a recomputed wiring. Computed
+ * cables that collide with a frozen port are dropped and reported, so the
+ * result never reallocates frozen ports.
+ */
+export function mergeFrozenObjects(baseline: Wiring, computed: Wiring): FrozenMergeResult {
+  const next = structuredClone(computed)
+  const errors: string[] = []
+
+  for (const role of ['spines', 'leaves', 'servers'] as const) {
+    for (const device of baseline.devices[role].filter(isFrozen)) {
+      const index = next.devices[role].findIndex(d => d.id === device.id)
+      if (index === -1) next.devices[role].push(structuredClone(device))
+      else next.devices[role][index] = structuredClone(device)
+    }
+  }
+
+  const held = frozenConnections(baseline)
+  const heldEnds = new Set(held.map(ends))
+  const conflicts = conflictsWith(baseline, held)
+  const kept = next.connections.filter(connection => {
+    if (heldEnds.has(ends(connection))) return false // replaced by the baseline copy below
+    const reasons = conflicts(connection)
+    errors.push(...reasons.map(reason => `Computed connection ${describe(connection)} dropped: ${reason}`))
+    return reasons.length === 0
+  })
+  next.connections = [...held.map(c => structuredClone(c)), ...kept]
+  next.metadata.totalDevices = allDevices(next).length
+  next.metadata.totalConnections = next.connections.length
+  return { wiring: next, errors }
+}
+
+// Frozen cables, plus every cable on a frozen device
+function frozenConnections(wiring: Wiring): WiringConnection[] {
+  const devices = new Set(allDevices(wiring).filter(isFrozen).map(d => d.id))
+  return wiring.connections.filter(c => isFrozen(c) || devices.has(c.from.device) || devices.has(c.to.device))
+}
+
+// Reasons a connection outside the frozen set may not exist: it takes a
+// frozen port, or adds a cable to a frozen device
+function conflictsWith(baseline: Wiring, held: WiringConnection[]): (connection: WiringConnection) => string[] {
+  const heldEnds = new Set(held.map(ends))
+  const owners = new Map<string, WiringConnection>()
+  for (const connection of held) {
+    owners.set(portKey(connection.from), connection)
+    owners.set(portKey(connection.to), connection)
+  }
+  const devices = new Set(allDevices(baseline).filter(isFrozen).map(d => d.id))
+
+  return connection => {
+    if (heldEnds.has(ends(connection))) return []
+    return [connection.from, connection.to].map(end => {
+      const owner = owners.get(portKey(end))
+      if (owner) return `needs port ${portKey(end)}, held by frozen connection ${describe(owner)}`
+      if (devices.has(end.device)) return `adds a cable to frozen device ${end.device}`
+      return undefined
+    }).filter((reason): reason is string => reason !== undefined)
+  }
+}
+
+function allDevices(wiring: Wiring): WiringDevice[] {
+  return [...wiring.devices.spines, ...wiring.devices.leaves, ...wiring.devices.servers]
+}
+
+const portKey = (end: WiringConnection['from']) => `${end.device}/${end.port}`
+const ends = (c: WiringConnection) => `${portKey(c.from)}>${portKey(c.to)}`
+const describe = (c: WiringConnection) => `${portKey(c.from)} - ${portKey(c.to)}`
+const sameJson = (a: unknown, b: unknown) => canonicalJson(a ?? null) === canonicalJson(b ?? null)
@@ -0,0 +1,81 @@
+/**
+ * Growth Forecast Timeline Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { capacityFromWiring, forecastGrowth, formatGrowthTimeline, type GrowthCapacity } from './growth-forecast'
+import type { Wiring } from './wiring'
+import { testProfile } from '../fixtures/testFabric'
+
+// 2 leaves x 8 endpoint ports, 2 uplinks each; 2 spines x 4 fabric ports
+const capacity: GrowthCapacity = {
+  endpoints: 12,
+  leaves: 2,
+  endpointPortsPerLeaf: 8,
+  uplinksPerLeaf: 2,
+  spines: 2,
+  fabricPortsPerSpine: 4
+}
+
+describe('forecastGrowth', () => {
+  const forecast = [
+    { quarter: '2027-Q1', endpoints: 2 },
+    { quarter: '2027-Q2', endpoints: 6 },
+    { quarter: '2027-Q3', endpoints: 8 },
+    { quarter: '2027-Q4', endpoints: 8 }
+  ]
+
+  it('reports when leaves, spines and pools run out', () => {
+    const timeline = forecastGrowth(capacity, forecast, {
+      pools: [{ name: 'servers-v4', size: 32, perEndpoint: 1 }, { name: 'loopbacks', size: 16, perLeaf: 1 }]
+    })
+
+    expect(timeline.errors).toEqual([])
+    expect(timeline.quarters.map(q => [q.quarter, q.endpoints, q.leaves, q.spines])).toEqual([
+      ['2027-Q1', 14, 2, 2],
+      ['2027-Q2', 20, 3, 2],
+      ['2027-Q3', 28, 4, 2],
+      ['2027-Q4', 36, 5, 3]
+    ])
+    expect(timeline.triggers.map(t => [t.resource, t.exhaustedIn, t.orderBy, t.quantity])).toEqual([
+      ['leaves', '2027-Q2', '2027-Q1', 1],
+      ['leaves', '2027-Q3', '2027-Q2', 1],
+      ['leaves', '2027-Q4', '2027-Q3', 1],
+      ['spines', '2027-Q4', '2027-Q3', 1],
+      ['pool:servers-v4', '2027-Q4', '2027-Q3', 4]
+    ])
+  })
+
+  it('flags purchases whose lead time has already passed', () => {
+    const timeline = forecastGrowth(capacity, forecast, { leadTimeQuarters: 2 })
+    expect(timeline.triggers[0]).toMatchObject({ orderBy: '2026-Q4', overdue: true })
+    expect(formatGrowthTimeline(timeline).split('\n')[0]).toBe(
+      '2026-Q4 (overdue): order 1 leaf before 2027-Q2 - 20 endpoints need 3 leaves, 2 installed'
+    )
+  })
+
+  it('rejects malformed or unordered quarters', () => {
+    expect(forecastGrowth(capacity, [{ quarter: 'Q1 2027', endpoints: 1 }]).errors).toEqual(["Quarter 'Q1 2027' must look like 2027-Q1"])
+    expect(forecastGrowth(capacity, [{ quarter: '2027-Q2', endpoints: 1 }, { quarter: '2027-Q1', endpoints: 1 }]).errors)
+      .toEqual(['Forecast quarters must be in order without repeats'])
+  })
+})
+
+describe('capacityFromWiring', () => {
+  it('reads current capacity from the wiring and profiles', () => {
+    const wiring: Wiring = {
+      devices: {
+        spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32 }],
+        leaves: [{ id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 56 }],
+        servers: [{ id: 'srv-1', type: 'server', modelId: 'server', ports: 1 }]
+      },
+      connections: [{ id: 'up-1', from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' }],
+      metadata: { fabricName: 'g', fabricId: 'g', generatedAt: new Date(0), totalDevices: 3, totalConnections: 1 }
+    }
+
+    expect(capacityFromWiring(wiring, new Map([
+      ['DS3000', testProfile('DS3000', [], ['E1/1-32'])],
+      ['DS2000', testProfile('DS2000', ['E1/1-48'], ['E1/49-56'])]
+    ]))).toEqual({ endpoints: 1, leaves: 1, endpointPortsPerLeaf: 48, uplinksPerLeaf: 1, spines: 1, fabricPortsPerSpine: 32 })
+  })
+})
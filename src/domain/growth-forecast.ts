@@ -0,0 +1,174 @@
+/**
+ * Growth Forecast Timeline - HNC v0.6
+ * Projects quarterly endpoint growth onto a design and reports when leaf
+ * ports, spine ports and address pools run out, with the quarter each
+ * purchase has to be ordered by to land before the shortfall.
+ */
+
+import { expandPortRanges } from './portUtils'
+import type { Wiring } from './wiring'
+import type { GrowthStep, SwitchProfile } from '../app.types'
+
+export type { GrowthStep }
+
+export interface GrowthCapacity {
+  endpoints: number // in service today
+  leaves: number
+  endpointPortsPerLeaf: number
+  uplinksPerLeaf: number
+  spines: number
+  fabricPortsPerSpine: number
+  portsPerEndpoint?: number // default: 1
+}
+
+export interface AddressPool {
+  name: string
+  size: number // addresses
+  perEndpoint?: number
+  perLeaf?: number
+}
+
+export interface GrowthOptions {
+  pools?: AddressPool[]
+  leadTimeQuarters?: number // order lead time, default: 1
+}
+
+export type GrowthResource = 'leaves' | 'spines' | `pool:${string}`
+
+export interface GrowthQuarter {
+  quarter: string
+  endpoints: number // cumulative
+  leaves: number // installed after any purchase this quarter
+  spines: number
+  spinePortsUsed: number
+  pools: Record<string, number> // addresses used
+}
+
+export interface PurchaseTrigger {
+  resource: GrowthResource
+  exhaustedIn: string
+  orderBy: string
+  overdue: boolean // orderBy falls before the first forecast quarter
+  quantity: number // switches for leaves/spines, addresses for pools
+  reason: string
+}
+
+export interface GrowthTimeline {
+  quarters: GrowthQuarter[]
+  triggers: PurchaseTrigger[]
+  errors: string[]
+}
+
+/**
+ * Current capacity of a built wiring, taking port counts from the leaf and
+ * spine profiles
+ */
+export function capacityFromWiring(wiring: Wiring, profiles: Map<string, SwitchProfile>): GrowthCapacity {
+  const [leaf] = wiring.devices.leaves
+  const [spine] = wiring.devices.spines
+  const portsOf = (modelId: string | undefined, kind: 'endpointAssignable' | 'fabricAssignable') => {
+    const profile = modelId ? profiles.get(modelId) : undefined
+    return profile ? expandPortRanges(profile.ports[kind]).length : 0
+  }
+  const uplinks = wiring.connections.filter(c => c.type === 'uplink').length
+
+  return {
+    endpoints: wiring.devices.servers.length,
+    leaves: wiring.devices.leaves.length,
+    endpointPortsPerLeaf: portsOf(leaf?.modelId, 'endpointAssignable'),
+    uplinksPerLeaf: wiring.devices.leaves.length > 0 ? Math.round(uplinks / wiring.devices.leaves.length) : 0,
+    spines: wiring.devices.spines.length,
+    fabricPortsPerSpine: portsOf(spine?.modelId, 'fabricAssignable')
+  }
+}
+
+/**
+ * Walks the forecast quarter by quarter. Leaf and spine shortfalls are
+ * assumed bought when they occur, so later triggers build on earlier
+ * purchases; pools are reported once, when they first run out.
+ */
+export function forecastGrowth(capacity: GrowthCapacity, forecast: GrowthStep[], options: GrowthOptions = {}): GrowthTimeline {
+  const { pools = [], leadTimeQuarters = 1 } = options
+  const portsPerEndpoint = capacity.portsPerEndpoint ?? 1
+  const errors: string[] = []
+
+  if (capacity.endpointPortsPerLeaf <= 0) errors.push('Leaves have no endpoint ports; cannot forecast leaf growth')
+  if (capacity.fabricPortsPerSpine <= 0) errors.push('Spines have no fabric ports; cannot forecast spine growth')
+  for (const step of forecast) {
+    if (!parseQuarter(step.quarter)) errors.push(`Quarter '${step.quarter}' must look like 2027-Q1`)
+  }
+  const ordered = forecast.every((step, i) => i === 0 || quarterIndex(step.quarter) > quarterIndex(forecast[i - 1].quarter))
+  if (errors.length === 0 && !ordered) errors.push('Forecast quarters must be in order without repeats')
+  if (errors.length > 0) return { quarters: [], triggers: [], errors }
+
+  const quarters: GrowthQuarter[] = []
+  const triggers: PurchaseTrigger[] = []
+  const exhaustedPools = new Set<string>()
+  const firstQuarter = forecast.length > 0 ? quarterIndex(forecast[0].quarter) : 0
+  let endpoints = capacity.endpoints
+  let leaves = capacity.leaves
+  let spines = capacity.spines
+
+  const trigger = (resource: GrowthResource, quarter: string, quantity: number, reason: string) => {
+    const orderBy = quarterIndex(quarter) - leadTimeQuarters
+    triggers.push({ resource, exhaustedIn: quarter, orderBy: formatQuarter(orderBy), overdue: orderBy < firstQuarter, quantity, reason })
+  }
+
+  for (const step of forecast) {
+    endpoints += step.endpoints
+
+    const leavesNeeded = Math.ceil(endpoints * portsPerEndpoint / capacity.endpointPortsPerLeaf)
+    if (leavesNeeded > leaves) {
+      trigger('leaves', step.quarter, leavesNeeded - leaves, `${endpoints} endpoints need ${leavesNeeded} leaves, ${leaves} installed`)
+      leaves = leavesNeeded
+    }
+
+    const spinePortsUsed = leaves * capacity.uplinksPerLeaf
+    const spinePorts = spines * capacity.fabricPortsPerSpine
+    if (spinePortsUsed > spinePorts) {
+      const more = Math.ceil((spinePortsUsed - spinePorts) / capacity.fabricPortsPerSpine)
+      trigger('spines', step.quarter, more, `${leaves} leaves need ${spinePortsUsed} spine ports, ${spinePorts} installed`)
+      spines += more
+    }
+
+    const used: Record<string, number> = {}
+    for (const pool of pools) {
+      used[pool.name] = endpoints * (pool.perEndpoint ?? 0) + leaves * (pool.perLeaf ?? 0)
+      if (used[pool.name] > pool.size && !exhaustedPools.has(pool.name)) {
+        exhaustedPools.add(pool.name)
+        trigger(`pool:${pool.name}`, step.quarter, used[pool.name] - pool.size, `needs ${used[pool.name]} addresses, pool has ${pool.size}`)
+      }
+    }
+
+    quarters.push({ quarter: step.quarter, endpoints, leaves, spines, spinePortsUsed, pools: used })
+  }
+
+  return { quarters, triggers, errors }
+}
+
+/**
+ * Formats purchase triggers as a plain-text timeline
+ */
+export function formatGrowthTimeline(timeline: GrowthTimeline): string {
+  if (timeline.triggers.length === 0) return 'No capacity runs out within the forecast'
+  return timeline.triggers.map(t => {
+    const what = t.resource.startsWith('pool:')
+      ? `${t.quantity} more addresses for pool ${t.resource.slice(5)}`
+      : `${t.quantity} ${t.resource === 'leaves' ? (t.quantity === 1 ? 'leaf' : 'leaves') : (t.quantity === 1 ? 'spine' : 'spines')}`
+    return `${t.orderBy}${t.overdue ? ' (overdue)' : ''}: order ${what} before ${t.exhaustedIn} - ${t.reason}`
+  }).join('\n')
+}
+
+function parseQuarter(quarter: string): { year: number; q: number } | undefined {
+  const match = quarter.match(/^(\d{4})-Q([1-4])$/)
+  return match ? { year: Number(match[1]), q: Number(match[2]) } : undefined
+}
+
+function quarterIndex(quarter: string): number {
+  const { year, q } = parseQuarter(quarter)!
+  return year * 4 + q - 1
+}
+
+function formatQuarter(index: number): string {
+  return `${Math.floor(index / 4)}-Q${(index % 4) + 1}`
+}
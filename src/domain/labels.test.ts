@@ -0,0 +1,85 @@
+/**
+ * Design Object Labels Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import yaml from 'js-yaml'
+import { validateLabels, mergeLabels, labelTags, labelColumns, labelRow } from './labels'
+import { buildWiring, wiringToWiringDiagram } from './wiring'
+import { serializeWiringDiagramToCRDs, deserializeCRDsToWiringDiagram } from '../io/crd-yaml'
+import { testProfiles, testSpec, testAllocation } from '../fixtures/testFabric'
+
+const profiles = testProfiles()
+
+const spec = testSpec({
+  name: 'Labeled Fabric',
+  endpointProfile: { name: 'Server', portsPerEndpoint: 1, labels: { team: 'ml', 'example.com/cost-center': 'cc-42' } },
+  endpointCount: 2
+})
+const allocation = testAllocation(1)
+
+describe('validateLabels', () => {
+  it('accepts Kubernetes-style labels', () => {
+    expect(validateLabels({ team: 'ml', 'example.com/env': 'prod', empty: '' }, 'srv-1')).toEqual([])
+  })
+
+  it('rejects reserved prefixes and bad syntax', () => {
+    const errors = validateLabels({
+      'hnc.githedgehog.com/role': 'leaf',
+      'bad key': 'x',
+      'Bad_Prefix/env': 'x',
+      env: 'not valid!'
+    }, 'leaf-1')
+
+    expect(errors).toEqual([
+      'leaf-1: label hnc.githedgehog.com/role uses a reserved prefix',
+      'leaf-1: label bad key has an invalid name',
+      'leaf-1: label Bad_Prefix/env has an invalid prefix',
+      'leaf-1: label env has an invalid value "not valid!"'
+    ])
+  })
+})
+
+describe('label helpers', () => {
+  it('lets system labels win over user labels', () => {
+    expect(mergeLabels({ 'hnc.githedgehog.com/role': 'leaf' }, { 'hnc.githedgehog.com/role': 'spine', team: 'ml' }))
+      .toEqual({ team: 'ml', 'hnc.githedgehog.com/role': 'leaf' })
+  })
+
+  it('renders tags and table columns', () => {
+    const objects = [{ labels: { team: 'ml', env: 'prod' } }, { labels: { rack: 'r10' } }, {}]
+
+    expect(labelTags(objects[0].labels)).toEqual(['env:prod', 'team:ml'])
+    expect(labelColumns(objects)).toEqual(['env', 'rack', 'team'])
+    expect(labelRow(objects[1], ['env', 'rack', 'team'])).toEqual(['', 'r10', ''])
+  })
+})
+
+describe('label propagation', () => {
+  const wiring = buildWiring(spec, profiles, allocation)
+  wiring.devices.leaves[0].labels = { rack: 'r10' }
+  wiring.devices.leaves[0].annotations = { 'example.com/owner': 'netops@example.com' }
+  const diagram = wiringToWiringDiagram(wiring)
+
+  it('copies endpoint profile labels onto servers and their connections', () => {
+    expect(diagram.devices.servers.every(s => s.labels?.team === 'ml')).toBe(true)
+    const endpoints = diagram.connections.filter(c => c.type === 'endpoint')
+    expect(endpoints).toHaveLength(2)
+    expect(endpoints.every(c => c.labels?.['example.com/cost-center'] === 'cc-42')).toBe(true)
+    expect(diagram.connections.find(c => c.type === 'uplink')?.labels).toBeUndefined()
+  })
+
+  it('writes labels into CRD metadata and reads them back', () => {
+    const crds = serializeWiringDiagramToCRDs(diagram)
+    const leaf = (yaml.loadAll(crds.switches) as any[]).find(s => s.metadata.name === 'leaf-1')
+
+    expect(leaf.metadata.labels.rack).toBe('r10')
+    expect(leaf.metadata.labels['hnc.githedgehog.com/role']).toBe('leaf')
+    expect(leaf.metadata.annotations).toEqual({ 'example.com/owner': 'netops@example.com' })
+
+    const restored = deserializeCRDsToWiringDiagram(crds)
+    expect(restored.devices.leaves[0].labels).toEqual({ rack: 'r10' })
+    expect(restored.devices.servers[0].labels).toEqual({ team: 'ml', 'example.com/cost-center': 'cc-42' })
+    expect(restored.connections.find(c => c.type === 'endpoint')?.labels?.team).toBe('ml')
+  })
+})
@@ -0,0 +1,94 @@
+/**
+ * Design Object Labels - HNC v0.6
+ * User labels and annotations on servers, switches and connections, and the
+ * helpers exports use to carry them downstream (CRD metadata, tag lists,
+ * cabling map columns) so other systems can filter by team, environment or
+ * cost center.
+ */
+
+import type { Labeled } from '../app.types'
+
+/** Label prefixes owned by HNC and Kubernetes; user labels may not use them */
+export const RESERVED_LABEL_PREFIXES = ['app.kubernetes.io/', 'hnc.githedgehog.com/']
+
+const LABEL_NAME = /^[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$/
+const LABEL_PREFIX = /^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$/
+const LABEL_VALUE = /^([A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?)?$/
+
+/**
+ * Validates labels against Kubernetes label syntax and reserved prefixes
+ */
+export function validateLabels(labels: Record<string, string> | undefined, owner: string): string[] {
+  const errors: string[] = []
+  for (const [key, value] of Object.entries(labels ?? {})) {
+    if (isReservedLabel(key)) {
+      errors.push(`${owner}: label ${key} uses a reserved prefix`)
+      continue
+    }
+    const slash = key.lastIndexOf('/')
+    const prefix = slash >= 0 ? key.slice(0, slash) : undefined
+    const name = slash >= 0 ? key.slice(slash + 1) : key
+    if (prefix !== undefined && (prefix.length > 253 || !LABEL_PREFIX.test(prefix))) {
+      errors.push(`${owner}: label ${key} has an invalid prefix`)
+    } else if (!LABEL_NAME.test(name)) {
+      errors.push(`${owner}: label ${key} has an invalid name`)
+    }
+    if (!LABEL_VALUE.test(value)) {
+      errors.push(`${owner}: label ${key} has an invalid value "${value}"`)
+    }
+  }
+  return errors
+}
+
+export function isReservedLabel(key: string): boolean {
+  return RESERVED_LABEL_PREFIXES.some(prefix => key.startsWith(prefix))
+}
+
+/**
+ * Merges user labels under system labels; system labels always win
+ */
+export function mergeLabels(
+  system: Record<string, string>,
+  user: Record<string, string> | undefined
+): Record<string, string> {
+  const merged: Record<string, string> = {}
+  for (const [key, value] of Object.entries(user ?? {})) {
+    if (!isReservedLabel(key)) merged[key] = value
+  }
+  return { ...merged, ...system }
+}
+
+/**
+ * Recovers user labels from exported metadata by dropping reserved keys
+ */
+export function userLabels(labels: Record<string, string> | undefined): Record<string, string> | undefined {
+  const entries = Object.entries(labels ?? {}).filter(([key]) => !isReservedLabel(key))
+  return entries.length > 0 ? Object.fromEntries(entries) : undefined
+}
+
+/**
+ * Labels as sorted "key:value" tags, for tag-based systems such as NetBox
+ */
+export function labelTags(labels: Record<string, string> | undefined): string[] {
+  return Object.entries(labels ?? {})
+    .map(([key, value]) => (value ? `${key}:${value}` : key))
+    .sort((a, b) => a.localeCompare(b, undefined, { numeric: true }))
+}
+
+/**
+ * Union of label keys across objects, sorted, for use as extra table columns
+ */
+export function labelColumns(objects: Labeled[]): string[] {
+  const keys = new Set<string>()
+  for (const obj of objects) {
+    for (const key of Object.keys(obj.labels ?? {})) keys.add(key)
+  }
+  return [...keys].sort((a, b) => a.localeCompare(b, undefined, { numeric: true }))
+}
+
+/**
+ * Label values for one row, in column order; missing labels are empty
+ */
+export function labelRow(obj: Labeled, columns: string[]): string[] {
+  return columns.map(key => obj.labels?.[key] ?? '')
+}
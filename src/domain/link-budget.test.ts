@@ -0,0 +1,105 @@
+/**
+ * Link Error Budget Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { LINK_LENGTH_ANNOTATION, planLinkOptics, requiredFec } from './link-budget'
+import { LINK_SPEED_ANNOTATION } from './uplink-speeds'
+import { compileBOM } from './bom-compiler'
+import { validateWiring, type Wiring } from './wiring'
+import type { WiringConnection, WiringDiagram } from '../app.types'
+
+const link = (from: string, to: string, type: WiringConnection['type'], lengthM?: number, speed?: string): WiringConnection => ({
+  from: { device: from, port: 'E1/1' },
+  to: { device: to, port: 'E1/1' },
+  type,
+  ...((lengthM !== undefined || speed) && {
+    annotations: {
+      ...(lengthM !== undefined && { [LINK_LENGTH_ANNOTATION]: String(lengthM) }),
+      ...(speed && { [LINK_SPEED_ANNOTATION]: speed })
+    }
+  })
+})
+
+describe('requiredFec', () => {
+  it('picks the weakest mode that meets the post-FEC target', () => {
+    expect(requiredFec(1e-13)).toBe('none')
+    expect(requiredFec(1e-9)).toBe('fc')
+    expect(requiredFec(5e-5)).toBe('rs')
+    expect(requiredFec(1e-3)).toBeUndefined()
+    expect(requiredFec(5e-5, ['none', 'fc'])).toBeUndefined()
+  })
+})
+
+describe('planLinkOptics', () => {
+  it('keeps DAC in rack and upgrades optics as links get longer', () => {
+    const plan = planLinkOptics([
+      link('leaf-1', 'spine-1', 'uplink', 2),
+      link('leaf-2', 'spine-1', 'uplink', 40),
+      link('leaf-3', 'spine-1', 'uplink', 250),
+      link('srv-1', 'leaf-1', 'endpoint', 50),
+      link('leaf-4', 'spine-1', 'uplink')
+    ])
+
+    expect(plan.errors).toEqual([])
+    expect(plan.links.map(l => [l.from, l.sku, l.fec, l.upgraded])).toEqual([
+      ['leaf-1/E1/1', 'GEN-QSFP28-100G-DAC', 'rs', false],
+      ['leaf-2/E1/1', 'GEN-QSFP28-100G-SR4', 'rs', true],
+      ['leaf-3/E1/1', 'GEN-QSFP28-100G-LR4', 'none', true],
+      ['srv-1/E1/1', 'GEN-SFP28-25G-SR', 'rs', true]
+    ])
+    expect(plan.links[1]).toMatchObject({ lossDb: 1.12, marginDb: 0.78 })
+  })
+
+  it('moves to a cleaner optic when the switches cannot run RS-FEC', () => {
+    const plan = planLinkOptics([link('leaf-1', 'spine-1', 'uplink', 40)], { allowedFec: ['none', 'fc'] })
+    expect(plan.links[0]).toMatchObject({ sku: 'GEN-QSFP28-100G-LR4', fec: 'none' })
+  })
+
+  it('respects the loss margin and reports links nothing can serve', () => {
+    const tight = planLinkOptics([link('leaf-1', 'spine-1', 'uplink', 90)], { marginDb: 1 })
+    expect(tight.links[0].sku).toBe('GEN-QSFP28-100G-LR4')
+
+    const plan = planLinkOptics([link('leaf-1', 'spine-1', 'uplink', 20000, '400G')])
+    expect(plan.errors).toEqual([
+      'leaf-1/E1/1 - spine-1/E1/1: no 400G optic reaches 20000 m within its loss budget and allowed FEC modes'
+    ])
+  })
+})
+
+describe('link budget in BOM and validation', () => {
+  const diagram: WiringDiagram = {
+    devices: {
+      spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+      leaves: [{ id: 'leaf-1', model: 'DS2000', ports: 48 }, { id: 'leaf-2', model: 'DS2000', ports: 48 }],
+      servers: []
+    },
+    connections: [link('leaf-1', 'spine-1', 'uplink', 2), { ...link('leaf-2', 'spine-1', 'uplink', 250), to: { device: 'spine-1', port: 'E1/2' } }],
+    metadata: { generatedAt: new Date(0), fabricName: 'budget', totalDevices: 3 }
+  }
+
+  it('orders the optics the budget chose, with their FEC mode', () => {
+    const bom = compileBOM(diagram, [], [], [], { linkBudget: {} })
+    expect(bom.transceivers.map(t => [t.sku, t.quantity, t.details?.fec])).toEqual([
+      ['GEN-QSFP28-100G-DAC', 2, 'rs'],
+      ['GEN-QSFP28-100G-LR4', 2, 'none']
+    ])
+    expect(compileBOM(diagram).transceivers.map(t => t.sku)).toEqual(['GEN-QSFP28-100G-SR4']) // one SR4 default for every uplink
+  })
+
+  it('fails validation for links no optic can serve', () => {
+    const wiring: Wiring = {
+      devices: {
+        spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32 }],
+        leaves: [{ id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 48 }],
+        servers: []
+      },
+      connections: [{ id: 'up-1', ...link('leaf-1', 'spine-1', 'uplink', 20000) }],
+      metadata: { fabricName: 'budget', fabricId: 'budget', generatedAt: new Date(0), totalDevices: 2, totalConnections: 1 }
+    }
+    expect(validateWiring(wiring).errors).toEqual([])
+    expect(validateWiring(wiring, { linkBudget: {} }).errors).toEqual([
+      'leaf-1/E1/1 - spine-1/E1/1: no 100G optic reaches 20000 m within its loss budget and allowed FEC modes'
+    ])
+  })
+})
@@ -0,0 +1,154 @@
+/**
+ * Link Error Budget - HNC v0.6
+ * Picks the optic and FEC mode for each measured link from its length and
+ * the optic's reach, loss budget and pre-FEC bit error rate, so the BOM and
+ * validation carry the choice instead of leaving it to the field.
+ */
+
+import { linkSpeedGbps } from './uplink-speeds'
+import type { Labeled, WiringConnection } from '../app.types'
+
+// Measured or planned cable run, in meters
+export const LINK_LENGTH_ANNOTATION = 'hnc.githedgehog.com/link-length-m'
+
+export type FecMode = 'none' | 'fc' | 'rs' // none, FireCode (BASE-R), Reed-Solomon
+
+export interface OpticSpec {
+  sku: string
+  speedGbps: number
+  medium: 'dac' | 'fiber'
+  maxReachM: number
+  powerBudgetDb?: number // fiber only: allowed channel insertion loss
+  attenuationDbPerKm?: number // fiber only
+  preFecBer: number // worst-case bit error rate within budget
+}
+
+// Highest pre-FEC BER each mode corrects to the post-FEC target
+export const FEC_BER_LIMITS: Record<FecMode, number> = {
+  none: 1e-12,
+  fc: 1e-8,
+  rs: 2.4e-4
+}
+
+const FEC_ORDER: FecMode[] = ['none', 'fc', 'rs']
+
+// Generic catalog optics (src/catalog/sku.json); SR is multimode, LR single-mode
+export const DEFAULT_OPTIC_SPECS: OpticSpec[] = [
+  { sku: 'GEN-SFP28-25G-DAC', speedGbps: 25, medium: 'dac', maxReachM: 3, preFecBer: 1e-12 },
+  { sku: 'GEN-SFP28-25G-SR', speedGbps: 25, medium: 'fiber', maxReachM: 100, powerBudgetDb: 1.8, attenuationDbPerKm: 3.0, preFecBer: 5e-5 },
+  { sku: 'GEN-SFP28-25G-LR', speedGbps: 25, medium: 'fiber', maxReachM: 10000, powerBudgetDb: 6.3, attenuationDbPerKm: 0.4, preFecBer: 1e-12 },
+  { sku: 'GEN-QSFP28-100G-DAC', speedGbps: 100, medium: 'dac', maxReachM: 3, preFecBer: 5e-5 },
+  { sku: 'GEN-QSFP28-100G-SR4', speedGbps: 100, medium: 'fiber', maxReachM: 100, powerBudgetDb: 1.9, attenuationDbPerKm: 3.0, preFecBer: 5e-5 },
+  { sku: 'GEN-QSFP28-100G-LR4', speedGbps: 100, medium: 'fiber', maxReachM: 10000, powerBudgetDb: 6.3, attenuationDbPerKm: 0.4, preFecBer: 1e-12 },
+  { sku: 'GEN-QSFP-DD-400G-DAC', speedGbps: 400, medium: 'dac', maxReachM: 3, preFecBer: 2.4e-4 },
+  { sku: 'GEN-QSFP-DD-400G-SR8', speedGbps: 400, medium: 'fiber', maxReachM: 100, powerBudgetDb: 1.8, attenuationDbPerKm: 3.0, preFecBer: 2.4e-4 },
+  { sku: 'GEN-QSFP-DD-400G-LR8', speedGbps: 400, medium: 'fiber', maxReachM: 10000, powerBudgetDb: 6.3, attenuationDbPerKm: 0.4, preFecBer: 2.4e-4 }
+]
+
+export interface LinkBudgetOptions {
+  optics?: OpticSpec[] // default: DEFAULT_OPTIC_SPECS
+  prices?: Record<string, number> // sku -> unit price, cheapest feasible optic wins
+  connectorLossDb?: number // per mated connector, default: 0.5
+  connectors?: number // per link, default: 2
+  marginDb?: number // loss headroom to keep, default: 0
+  allowedFec?: FecMode[] // modes the switches can run, default: all
+  defaultUplinkGbps?: number // default: 100
+  defaultEndpointGbps?: number // default: 25
+}
+
+export interface LinkOpticChoice {
+  from: string // 'device/port'
+  to: string
+  lengthM: number
+  speedGbps: number
+  sku: string
+  fec: FecMode
+  lossDb?: number // fiber only
+  marginDb?: number
+  upgraded: boolean // the cheapest optic for the speed would not work
+}
+
+export interface LinkBudgetPlan {
+  links: LinkOpticChoice[]
+  errors: string[]
+}
+
+type BudgetLink = Pick<WiringConnection, 'from' | 'to' | 'type'> & Labeled
+
+/**
+ * Weakest FEC mode that brings the optic's BER to the post-FEC target
+ */
+export function requiredFec(preFecBer: number, allowed: FecMode[] = FEC_ORDER): FecMode | undefined {
+  return FEC_ORDER.find(mode => allowed.includes(mode) && preFecBer <= FEC_BER_LIMITS[mode])
+}
+
+export function linkLengthM(connection: Labeled): number | undefined {
+  const length = Number(connection.annotations?.[LINK_LENGTH_ANNOTATION])
+  return connection.annotations?.[LINK_LENGTH_ANNOTATION] !== undefined && length >= 0 ? length : undefined
+}
+
+/**
+ * Chooses an optic and FEC mode for every link with a length annotation;
+ * links without one are left to the default BOM selection
+ */
+export function planLinkOptics(connections: BudgetLink[], options: LinkBudgetOptions = {}): LinkBudgetPlan {
+  const {
+    optics = DEFAULT_OPTIC_SPECS,
+    prices = {},
+    connectorLossDb = 0.5,
+    connectors = 2,
+    marginDb = 0,
+    allowedFec = FEC_ORDER,
+    defaultUplinkGbps = 100,
+    defaultEndpointGbps = 25
+  } = options
+  const links: LinkOpticChoice[] = []
+  const errors: string[] = []
+
+  for (const connection of connections) {
+    const lengthM = linkLengthM(connection)
+    if (lengthM === undefined) continue
+    const from = `${connection.from.device}/${connection.from.port}`
+    const to = `${connection.to.device}/${connection.to.port}`
+    const speedGbps = linkSpeedGbps(connection, connection.type === 'endpoint' ? defaultEndpointGbps : defaultUplinkGbps)
+
+    const candidates = optics
+      .filter(o => o.speedGbps === speedGbps)
+      .sort((a, b) => (prices[a.sku] ?? 0) - (prices[b.sku] ?? 0) || a.maxReachM - b.maxReachM)
+    if (candidates.length === 0) {
+      errors.push(`${from} - ${to}: no ${speedGbps}G optic defined`)
+      continue
+    }
+
+    const choice = candidates.map(optic => {
+      const fec = requiredFec(optic.preFecBer, allowedFec)
+      const lossDb = optic.medium === 'fiber'
+        ? round(lengthM / 1000 * (optic.attenuationDbPerKm ?? 0) + connectors * connectorLossDb)
+        : undefined
+      const margin = lossDb === undefined ? undefined : round((optic.powerBudgetDb ?? 0) - lossDb)
+      const fits = lengthM <= optic.maxReachM && fec !== undefined && (margin === undefined || margin >= marginDb)
+      return { optic, fec, lossDb, margin, fits }
+    }).find(c => c.fits)
+
+    if (!choice) {
+      errors.push(`${from} - ${to}: no ${speedGbps}G optic reaches ${lengthM} m within its loss budget and allowed FEC modes`)
+      continue
+    }
+    links.push({
+      from,
+      to,
+      lengthM,
+      speedGbps,
+      sku: choice.optic.sku,
+      fec: choice.fec!,
+      ...(choice.lossDb !== undefined && { lossDb: choice.lossDb, marginDb: choice.margin }),
+      upgraded: choice.optic !== candidates[0]
+    })
+  }
+
+  return { links, errors }
+}
+
+function round(value: number): number {
+  return Math.round(value * 100) / 100
+}
@@ -0,0 +1,110 @@
+/**
+ * Link Reach Classification Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { LINK_REACH_ANNOTATION, annotateLinkReach, checkReachPolicies, classifyLinks, type RackPlacement, type ReachPolicy } from './link-reach'
+import { validateWiring, type Wiring, type WiringConnection } from './wiring'
+
+const link = (id: string, from: string, to: string, type: WiringConnection['type']): WiringConnection => ({
+  id,
+  from: { device: from, port: 'E1/1' },
+  to: { device: to, port: 'E1/2' },
+  type
+})
+
+const wiring: Wiring = {
+  devices: {
+    spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32 }],
+    leaves: [
+      { id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 56 },
+      { id: 'leaf-2', type: 'leaf', modelId: 'DS2000', ports: 56 }
+    ],
+    servers: [
+      { id: 'srv-1', type: 'server', modelId: 'storage', ports: 2, labels: { network: 'storage-backend' } },
+      { id: 'srv-2', type: 'server', modelId: 'storage', ports: 2, labels: { network: 'storage-backend' } },
+      { id: 'srv-3', type: 'server', modelId: 'compute', ports: 2 }
+    ]
+  },
+  connections: [
+    link('c1', 'srv-1', 'leaf-1', 'endpoint'),
+    link('c2', 'srv-2', 'leaf-1', 'endpoint'),
+    link('c3', 'leaf-1', 'spine-1', 'uplink'),
+    link('c4', 'leaf-2', 'spine-1', 'uplink'),
+    link('c5', 'srv-3', 'leaf-2', 'endpoint')
+  ],
+  metadata: { fabricName: 'reach', fabricId: 'reach', generatedAt: new Date(0), totalDevices: 6, totalConnections: 5 }
+}
+
+const racks: RackPlacement[] = [
+  { id: 'r1', row: 'A', devices: ['leaf-1', 'srv-1'] },
+  { id: 'r2', row: 'A', devices: ['srv-2'] },
+  { id: 'r3', row: 'B', devices: ['spine-1'] },
+  { id: 'r9', row: 'A', hall: 'north', devices: ['leaf-2'] }
+]
+
+const storageBackend: ReachPolicy = { name: 'storage backend', match: { labels: { network: 'storage-backend' } }, allowed: ['in-rack', 'cross-rack'] }
+
+describe('classifyLinks', () => {
+  it('classifies links by rack, row and hall', () => {
+    const result = classifyLinks(wiring, racks)
+    expect(result.links.map(l => [l.connectionId, l.reach])).toEqual([
+      ['c1', 'in-rack'],
+      ['c2', 'cross-rack'],
+      ['c3', 'cross-row'],
+      ['c4', 'cross-hall']
+    ])
+    expect(result.warnings).toEqual(['Device srv-3 is not placed in any rack; its links are not classified'])
+  })
+
+  it('reports devices placed twice and racks listing unknown devices', () => {
+    const result = classifyLinks(wiring, [...racks, { id: 'r4', row: 'C', devices: ['srv-1', 'ghost'] }])
+    expect(result.errors).toEqual(['Device srv-1 is placed in more than one rack'])
+    expect(result.warnings).toContain('Rack r4 lists ghost, which is not a device in this design')
+  })
+
+  it('keeps the annotated reach of links with an unracked end', () => {
+    const annotated = {
+      ...wiring,
+      connections: wiring.connections.map(c => c.id === 'c5' ? { ...c, annotations: { [LINK_REACH_ANNOTATION]: 'in-rack' } } : c)
+    }
+    expect(classifyLinks(annotated, racks).links.find(l => l.connectionId === 'c5')?.reach).toBe('in-rack')
+  })
+})
+
+describe('annotateLinkReach', () => {
+  it('records the reach on each classified connection', () => {
+    const annotated = annotateLinkReach(wiring, racks)
+    expect(annotated.connections.map(c => c.annotations?.[LINK_REACH_ANNOTATION])).toEqual(['in-rack', 'cross-rack', 'cross-row', 'cross-hall', undefined])
+    expect(wiring.connections[0].annotations).toBeUndefined()
+  })
+})
+
+describe('checkReachPolicies', () => {
+  it('flags matched links whose reach the policy does not allow', () => {
+    const farRacks = racks.map(r => r.id === 'r2' ? { ...r, row: 'B' } : r)
+    const result = checkReachPolicies(wiring, { racks: farRacks, policies: [storageBackend] })
+    expect(result.errors).toEqual(['Reach policy "storage backend": srv-2/E1/1 - leaf-1/E1/2 is cross-row; allowed: in-rack, cross-rack'])
+  })
+
+  it('matches on connection type and warns about links it cannot classify', () => {
+    const result = checkReachPolicies(wiring, {
+      racks,
+      policies: [
+        { name: 'endpoints in-rack', match: { type: 'endpoint' }, allowed: ['in-rack'] },
+        { name: 'typo', match: {}, allowed: ['cross-building' as never] }
+      ]
+    })
+    expect(result.errors).toEqual([
+      'Reach policy "endpoints in-rack": srv-2/E1/1 - leaf-1/E1/2 is cross-rack; allowed: in-rack',
+      'Reach policy "typo": unknown reach cross-building (known: in-rack, cross-rack, cross-row, cross-hall)'
+    ])
+    expect(result.warnings).toContain('Reach policy "endpoints in-rack": 1 matching link could not be classified')
+  })
+
+  it('is enforced by validateWiring', () => {
+    const farRacks = racks.map(r => r.id === 'r2' ? { ...r, hall: 'north' } : r)
+    const result = validateWiring(wiring, { linkReach: { racks: farRacks, policies: [storageBackend] } })
+    expect(result.errors).toContain('Reach policy "storage backend": srv-2/E1/1 - leaf-1/E1/2 is cross-hall; allowed: in-rack, cross-rack')
+  })
+})
@@ -0,0 +1,161 @@
+/**
+ * Link Reach Classification - HNC v0.6
+ * Classifies each link as in-rack, cross-rack, cross-row or cross-hall from
+ * where its two ends are racked, records the class on the connection for
+ * exports, and checks reach policies such as "storage backend links stay
+ * in-rack or cross-rack" at validation time.
+ */
+
+import type { Labeled } from '../app.types'
+import type { Wiring, WiringConnection, WiringDevice } from './wiring'
+
+export const LINK_REACH_ANNOTATION = 'hnc.githedgehog.com/link-reach'
+
+// Shortest to longest
+export const LINK_REACHES = ['in-rack', 'cross-rack', 'cross-row', 'cross-hall'] as const
+export type LinkReach = typeof LINK_REACHES[number]
+
+export interface RackPlacement {
+  id: string
+  row: string
+  hall?: string // default: a single hall
+  devices: string[] // switch and server ids
+}
+
+export interface ReachPolicy {
+  name: string
+  match: {
+    type?: WiringConnection['type']
+    labels?: Record<string, string> // on the connection or either end device
+  }
+  allowed: LinkReach[]
+}
+
+export interface LinkReachOptions {
+  racks: RackPlacement[]
+  policies?: ReachPolicy[]
+}
+
+export interface LinkReachInfo {
+  connectionId: string
+  from: string // 'device/port'
+  to: string
+  reach: LinkReach
+}
+
+export interface LinkReachResult {
+  links: LinkReachInfo[]
+  errors: string[]
+  warnings: string[]
+}
+
+export function classifyReach(a: RackPlacement, b: RackPlacement): LinkReach {
+  if ((a.hall ?? '') !== (b.hall ?? '')) return 'cross-hall'
+  if (a.row !== b.row) return 'cross-row'
+  return a.id === b.id ? 'in-rack' : 'cross-rack'
+}
+
+export function linkReach(connection: Labeled): LinkReach | undefined {
+  const value = connection.annotations?.[LINK_REACH_ANNOTATION]
+  return LINK_REACHES.find(reach => reach === value)
+}
+
+/**
+ * Reach of every link whose ends are both racked. Links with an unracked
+ * end keep the reach annotation they already carry, if any.
+ */
+export function classifyLinks(wiring: Wiring, racks: RackPlacement[]): LinkReachResult {
+  const links: LinkReachInfo[] = []
+  const errors: string[] = []
+  const warnings: string[] = []
+
+  const devices = new Set(allDevices(wiring).map(d => d.id))
+  const placement = new Map<string, RackPlacement>()
+  for (const rack of racks) {
+    for (const deviceId of rack.devices) {
+      if (!devices.has(deviceId)) {
+        warnings.push(`Rack ${rack.id} lists ${deviceId}, which is not a device in this design`)
+      } else if (placement.has(deviceId)) {
+        errors.push(`Device ${deviceId} is placed in more than one rack`)
+      } else {
+        placement.set(deviceId, rack)
+      }
+    }
+  }
+
+  const unplaced = new Set<string>()
+  for (const c of wiring.connections) {
+    const a = placement.get(c.from.device)
+    const b = placement.get(c.to.device)
+    for (const [device, rack] of [[c.from.device, a], [c.to.device, b]] as const) {
+      if (!rack) unplaced.add(device)
+    }
+    const reach = a && b ? classifyReach(a, b) : linkReach(c)
+    if (reach) links.push({ connectionId: c.id, from: `${c.from.device}/${c.from.port}`, to: `${c.to.device}/${c.to.port}`, reach })
+  }
+  for (const device of [...unplaced].sort()) {
+    warnings.push(`Device ${device} is not placed in any rack; its links are not classified`)
+  }
+
+  return { links, errors, warnings }
+}
+
+/**
+ * Copy of the wiring with the reach annotation set on every classified
+ * link, so CRD, YAML and template exports carry it
+ */
+export function annotateLinkReach(wiring: Wiring, racks: RackPlacement[]): Wiring {
+  const reaches = new Map(classifyLinks(wiring, racks).links.map(l => [l.connectionId, l.reach]))
+  return {
+    ...wiring,
+    connections: wiring.connections.map(c => {
+      const reach = reaches.get(c.id)
+      return reach ? { ...c, annotations: { ...c.annotations, [LINK_REACH_ANNOTATION]: reach } } : c
+    })
+  }
+}
+
+/**
+ * Links that a policy matches but whose reach it does not allow. A matched
+ * link that cannot be classified is a warning, not a pass.
+ */
+export function checkReachPolicies(wiring: Wiring, options: LinkReachOptions): LinkReachResult {
+  const result = classifyLinks(wiring, options.racks)
+  const errors = [...result.errors]
+  const warnings = [...result.warnings]
+  const reaches = new Map(result.links.map(l => [l.connectionId, l]))
+  const devices = new Map(allDevices(wiring).map(d => [d.id, d]))
+
+  for (const policy of options.policies ?? []) {
+    const unknown = policy.allowed.filter(r => !LINK_REACHES.includes(r))
+    if (unknown.length > 0) {
+      errors.push(`Reach policy "${policy.name}": unknown reach ${unknown.join(', ')} (known: ${LINK_REACHES.join(', ')})`)
+      continue
+    }
+    let unclassified = 0
+    for (const c of wiring.connections) {
+      if (!matches(policy, c, devices)) continue
+      const link = reaches.get(c.id)
+      if (!link) {
+        unclassified++
+      } else if (!policy.allowed.includes(link.reach)) {
+        errors.push(`Reach policy "${policy.name}": ${link.from} - ${link.to} is ${link.reach}; allowed: ${policy.allowed.join(', ')}`)
+      }
+    }
+    if (unclassified > 0) {
+      warnings.push(`Reach policy "${policy.name}": ${unclassified} matching link${unclassified === 1 ? '' : 's'} could not be classified`)
+    }
+  }
+
+  return { links: result.links, errors, warnings }
+}
+
+function matches(policy: ReachPolicy, c: WiringConnection, devices: Map<string, WiringDevice>): boolean {
+  if (policy.match.type && c.type !== policy.match.type) return false
+  const owners: Labeled[] = [c, devices.get(c.from.device) ?? {}, devices.get(c.to.device) ?? {}]
+  return Object.entries(policy.match.labels ?? {}).every(([key, value]) => owners.some(o => o.labels?.[key] === value))
+}
+
+function allDevices(wiring: Wiring): WiringDevice[] {
+  return [...wiring.devices.spines, ...wiring.devices.leaves, ...wiring.devices.servers]
+}
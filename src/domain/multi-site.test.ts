@@ -0,0 +1,56 @@
+/**
+ * Multi-Site Hierarchy & DCI Planning Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { planDci, type MultiSiteDesign, type Site } from './multi-site'
+
+const site = (id: string, leafCount = 2, dciPortsPerLeaf = 4, portSpeedGbps = 100): Site => ({
+  id,
+  name: id.toUpperCase(),
+  pods: [{ id: `${id}-pod1`, name: 'Pod 1', fabric: { name: `${id}-pod1`, spineModelId: 'DS3000', leafModelId: 'DS2000' } }],
+  border: { leafCount, dciPortsPerLeaf, portSpeedGbps }
+})
+
+describe('planDci', () => {
+  it('sizes DCI links and spreads ports across border leaves', () => {
+    const design: MultiSiteDesign = {
+      name: 'global',
+      sites: [site('ams'), site('fra')],
+      dciLinks: [{ id: 'dci-1', from: 'ams', to: 'fra', capacityGbps: 350 }]
+    }
+    const plan = planDci(design)
+
+    expect(plan.errors).toEqual([])
+    expect(plan.links).toEqual([{ id: 'dci-1', from: 'ams', to: 'fra', portsPerEnd: 4, provisionedGbps: 400 }])
+    expect(plan.sites[0]).toMatchObject({ siteId: 'ams', portBudget: 8, portsUsed: 4, portsFree: 4, perLeaf: [2, 2] })
+  })
+
+  it('fails when border port budget is exceeded', () => {
+    const design: MultiSiteDesign = {
+      name: 'global',
+      sites: [site('ams', 1, 2), site('fra'), site('lon')],
+      dciLinks: [
+        { id: 'dci-1', from: 'ams', to: 'fra', capacityGbps: 200 },
+        { id: 'dci-2', from: 'ams', to: 'lon', capacityGbps: 100 }
+      ]
+    }
+    const plan = planDci(design)
+
+    expect(plan.errors).toEqual(['Site ams: DCI needs 4 border ports but only 2 are budgeted'])
+  })
+
+  it('rejects links to unknown sites and warns on speed mismatch', () => {
+    const plan = planDci({
+      name: 'global',
+      sites: [site('ams'), site('fra', 2, 4, 400)],
+      dciLinks: [
+        { id: 'dci-1', from: 'ams', to: 'fra', capacityGbps: 100 },
+        { id: 'dci-2', from: 'ams', to: 'nyc', capacityGbps: 100 }
+      ]
+    })
+
+    expect(plan.errors).toEqual(['DCI link dci-2 references unknown site nyc'])
+    expect(plan.warnings[0]).toContain('port speeds differ')
+  })
+})
@@ -0,0 +1,151 @@
+/**
+ * Multi-Site Hierarchy & DCI Planning - HNC v0.6
+ * A multi-site design groups pods (each an ordinary fabric spec) into sites
+ * and connects sites with data-center-interconnect links terminated on each
+ * site's border leaves
+ */
+
+import type { FabricSpec } from '../app.types'
+
+export interface SitePod {
+  id: string
+  name: string
+  fabric: FabricSpec
+}
+
+export interface SiteBorder {
+  leafCount: number        // border leaves available for DCI
+  dciPortsPerLeaf: number  // ports on each border leaf reserved for DCI
+  portSpeedGbps: number    // speed of a DCI port
+}
+
+export interface Site {
+  id: string
+  name: string
+  region?: string
+  pods: SitePod[]
+  border: SiteBorder
+}
+
+export interface DciLink {
+  id: string
+  from: string // site id
+  to: string   // site id
+  capacityGbps: number
+  minPorts?: number // redundancy floor per site end, default 2
+}
+
+export interface MultiSiteDesign {
+  name: string
+  sites: Site[]
+  dciLinks: DciLink[]
+  version?: string
+}
+
+export interface SiteDciUsage {
+  siteId: string
+  portBudget: number
+  portsUsed: number
+  portsFree: number
+  perLeaf: number[] // DCI ports landed on each border leaf
+}
+
+export interface DciLinkPlan {
+  id: string
+  from: string
+  to: string
+  portsPerEnd: number
+  provisionedGbps: number
+}
+
+export interface DciPlan {
+  sites: SiteDciUsage[]
+  links: DciLinkPlan[]
+  errors: string[]
+  warnings: string[]
+}
+
+/**
+ * Sizes every DCI link and checks it fits each site's border-leaf port budget.
+ * Ports for a link are spread round-robin over a site's border leaves so a
+ * single border leaf failure never takes a DCI link fully down.
+ */
+export function planDci(design: MultiSiteDesign): DciPlan {
+  const errors: string[] = []
+  const warnings: string[] = []
+
+  const sites = new Map<string, Site>()
+  for (const site of design.sites) {
+    if (sites.has(site.id)) errors.push(`Duplicate site id: ${site.id}`)
+    sites.set(site.id, site)
+    const podIds = new Set<string>()
+    for (const pod of site.pods) {
+      if (podIds.has(pod.id)) errors.push(`Site ${site.id}: duplicate pod id ${pod.id}`)
+      podIds.add(pod.id)
+    }
+    if (site.pods.length === 0) warnings.push(`Site ${site.id} has no pods`)
+  }
+
+  const usage = new Map<string, SiteDciUsage>()
+  for (const site of sites.values()) {
+    usage.set(site.id, {
+      siteId: site.id,
+      portBudget: site.border.leafCount * site.border.dciPortsPerLeaf,
+      portsUsed: 0,
+      portsFree: 0,
+      perLeaf: Array.from({ length: site.border.leafCount }, () => 0)
+    })
+  }
+
+  const links: DciLinkPlan[] = []
+  for (const link of [...design.dciLinks].sort((a, b) => a.id.localeCompare(b.id))) {
+    const from = sites.get(link.from)
+    const to = sites.get(link.to)
+    if (!from || !to) {
+      errors.push(`DCI link ${link.id} references unknown site ${!from ? link.from : link.to}`)
+      continue
+    }
+    if (link.from === link.to) {
+      errors.push(`DCI link ${link.id} connects site ${link.from} to itself`)
+      continue
+    }
+
+    // Both ends must run the same port speed; use the slower one
+    const speed = Math.min(from.border.portSpeedGbps, to.border.portSpeedGbps)
+    if (from.border.portSpeedGbps !== to.border.portSpeedGbps) {
+      warnings.push(`DCI link ${link.id}: port speeds differ (${from.border.portSpeedGbps}G vs ${to.border.portSpeedGbps}G); sized at ${speed}G`)
+    }
+    const portsPerEnd = Math.max(link.minPorts ?? 2, Math.ceil(link.capacityGbps / speed))
+    links.push({ id: link.id, from: link.from, to: link.to, portsPerEnd, provisionedGbps: portsPerEnd * speed })
+
+    for (const site of [from, to]) {
+      const siteUsage = usage.get(site.id)!
+      for (let i = 0; i < portsPerEnd; i++) {
+        siteUsage.perLeaf[(siteUsage.portsUsed + i) % Math.max(1, site.border.leafCount)]++
+      }
+      siteUsage.portsUsed += portsPerEnd
+      if (site.border.leafCount > 1 && portsPerEnd < site.border.leafCount) {
+        warnings.push(`DCI link ${link.id} uses ${portsPerEnd} port(s) at site ${site.id}, fewer than its ${site.border.leafCount} border leaves`)
+      }
+    }
+  }
+
+  for (const siteUsage of usage.values()) {
+    siteUsage.portsFree = siteUsage.portBudget - siteUsage.portsUsed
+    if (siteUsage.portsFree < 0) {
+      errors.push(`Site ${siteUsage.siteId}: DCI needs ${siteUsage.portsUsed} border ports but only ${siteUsage.portBudget} are budgeted`)
+    }
+    const site = sites.get(siteUsage.siteId)!
+    const over = siteUsage.perLeaf.findIndex(n => n > site.border.dciPortsPerLeaf)
+    if (over >= 0 && siteUsage.portsFree >= 0) {
+      errors.push(`Site ${siteUsage.siteId}: border leaf ${over + 1} exceeds its ${site.border.dciPortsPerLeaf} DCI ports`)
+    }
+  }
+
+  return {
+    sites: [...usage.values()].sort((a, b) => a.siteId.localeCompare(b.siteId)),
+    links,
+    errors,
+    warnings
+  }
+}
@@ -0,0 +1,81 @@
+/**
+ * Named Profile Catalog Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { catalogAllows, catalogProfiles, checkCatalogModels, findCatalog, parseCatalogs } from './named-catalogs'
+import { validateWiring, type Wiring } from './wiring'
+import type { SwitchProfile } from '../app.types'
+
+const { catalogs } = parseCatalogs({
+  catalogs: [
+    { id: 'prod-approved', description: 'Qualified for production', models: ['celestica-ds2000', 'celestica-ds3000'] },
+    { id: 'lab-experimental', models: ['celestica-ds2000', 'celestica-ds3000', 'celestica-ds5000'] }
+  ]
+})
+const prod = findCatalog(catalogs, 'prod-approved')!
+
+const fabric = (leafModel: string, catalog?: string): Wiring => ({
+  devices: {
+    spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32 }],
+    leaves: [{ id: 'leaf-1', type: 'leaf', modelId: leafModel, ports: 48 }],
+    servers: [{ id: 'server-1', type: 'server', modelId: 'server', ports: 2 }]
+  },
+  connections: [],
+  metadata: { fabricName: 'lab', fabricId: 'lab', generatedAt: new Date(0), totalDevices: 3, totalConnections: 0, ...(catalog && { catalog }) }
+})
+
+describe('parseCatalogs', () => {
+  it('reads catalogs and reports invalid entries', () => {
+    expect(catalogs.map(c => c.id)).toEqual(['prod-approved', 'lab-experimental'])
+    expect(prod.description).toBe('Qualified for production')
+
+    const { catalogs: parsed, errors } = parseCatalogs({
+      catalogs: [{ id: 'prod', models: ['ds2000'] }, { id: 'prod', models: ['ds3000'] }, { id: 'Lab', models: [] }, { id: 'empty', models: [] }]
+    })
+    expect(parsed.map(c => c.id)).toEqual(['prod'])
+    expect(errors).toEqual([
+      'catalog prod: defined more than once',
+      'catalog Lab: id must be lower-case letters, digits and hyphens',
+      'catalog empty: models must be a non-empty list of model ids'
+    ])
+    expect(parseCatalogs({}).errors).toEqual(['catalogs must be a list'])
+  })
+})
+
+describe('catalog membership', () => {
+  it('matches models by profile id or short name', () => {
+    expect(catalogAllows(prod, 'celestica-ds2000')).toBe(true)
+    expect(catalogAllows(prod, 'DS2000')).toBe(true)
+    expect(catalogAllows(prod, 'DS5000')).toBe(false)
+    expect(catalogAllows(prod, 'ds20')).toBe(false)
+  })
+
+  it('filters profiles to the catalog', () => {
+    const profile = (modelId: string) => ({ modelId } as SwitchProfile)
+    expect(catalogProfiles(prod, [profile('celestica-ds2000'), profile('celestica-ds5000')]).map(p => p.modelId))
+      .toEqual(['celestica-ds2000'])
+  })
+})
+
+describe('checkCatalogModels', () => {
+  it('forbids switch models outside the selected catalog', () => {
+    expect(checkCatalogModels(fabric('DS2000'), prod)).toEqual([])
+    expect(checkCatalogModels(fabric('DS5000'), prod)).toEqual([
+      'leaf leaf-1 uses model DS5000, which is not in catalog prod-approved'
+    ])
+    expect(checkCatalogModels(fabric('DS5000'), findCatalog(catalogs, 'lab-experimental')!)).toEqual([])
+  })
+
+  it('reports a design validated against another catalog than it selected', () => {
+    expect(checkCatalogModels(fabric('DS2000', 'lab-experimental'), prod)).toEqual([
+      'Design selects catalog lab-experimental but was validated against prod-approved'
+    ])
+  })
+
+  it('runs as part of wiring validation', () => {
+    expect(validateWiring(fabric('DS5000'), { catalog: prod }).errors)
+      .toContain('leaf leaf-1 uses model DS5000, which is not in catalog prod-approved')
+    expect(validateWiring(fabric('DS5000')).errors).not.toContain('leaf leaf-1 uses model DS5000, which is not in catalog prod-approved')
+  })
+})
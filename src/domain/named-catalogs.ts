@@ -0,0 +1,99 @@
+/**
+ * Named Profile Catalogs - HNC v0.6
+ * Several approved sets of switch models can be registered side by side
+ * (e.g. prod-approved, lab-experimental). A design selects one by id, and
+ * validation rejects spines and leaves whose model is not in it. Until a
+ * server holds them, catalogs are defined per workspace in hnc-catalogs.json.
+ */
+
+import type { SwitchProfile } from '../app.types'
+import type { Wiring } from './wiring'
+
+// Read from the workspace directory, next to hnc-tickets.json
+export const CATALOGS_FILE = 'hnc-catalogs.json'
+
+export interface NamedCatalog {
+  id: string
+  description?: string
+  /** Model ids allowed in designs that select this catalog */
+  models: string[]
+}
+
+/**
+ * Parses { "catalogs": [{ "id", "description"?, "models": [...] }] }
+ */
+export function parseCatalogs(raw: unknown): { catalogs: NamedCatalog[]; errors: string[] } {
+  const errors: string[] = []
+  const list = (raw as { catalogs?: unknown } | undefined)?.catalogs
+  if (!Array.isArray(list)) return { catalogs: [], errors: ['catalogs must be a list'] }
+
+  const catalogs: NamedCatalog[] = []
+  const seen = new Set<string>()
+  list.forEach((entry, i) => {
+    const c = (entry ?? {}) as Record<string, unknown>
+    const where = typeof c.id === 'string' && c.id !== '' ? `catalog ${c.id}` : `catalogs[${i}]`
+    if (typeof c.id !== 'string' || !/^[a-z0-9][a-z0-9-]*$/.test(c.id)) {
+      errors.push(`${where}: id must be lower-case letters, digits and hyphens`)
+      return
+    }
+    if (seen.has(c.id)) {
+      errors.push(`${where}: defined more than once`)
+      return
+    }
+    seen.add(c.id)
+    if (c.description !== undefined && typeof c.description !== 'string') {
+      errors.push(`${where}: description must be a string`)
+    }
+    if (!Array.isArray(c.models) || c.models.length === 0 || !c.models.every(m => typeof m === 'string' && m !== '')) {
+      errors.push(`${where}: models must be a non-empty list of model ids`)
+      return
+    }
+    catalogs.push({
+      id: c.id,
+      ...(typeof c.description === 'string' && { description: c.description }),
+      models: [...new Set(c.models as string[])].sort()
+    })
+  })
+  return { catalogs, errors }
+}
+
+export function findCatalog(catalogs: NamedCatalog[], id: string): NamedCatalog | undefined {
+  return catalogs.find(c => c.id === id)
+}
+
+/**
+ * Whether a model belongs to a catalog. Designs name models both by
+ * profile id (celestica-ds2000) and short name (DS2000), so either matches.
+ */
+export function catalogAllows(catalog: NamedCatalog, modelId: string): boolean {
+  const model = modelId.toLowerCase()
+  return catalog.models.some(m => {
+    const allowed = m.toLowerCase()
+    return allowed === model || allowed.endsWith(`-${model}`) || model.endsWith(`-${allowed}`)
+  })
+}
+
+/**
+ * The profiles of a catalog, for offering only its models in the designer
+ */
+export function catalogProfiles(catalog: NamedCatalog, profiles: SwitchProfile[]): SwitchProfile[] {
+  return profiles.filter(p => catalogAllows(catalog, p.modelId))
+}
+
+/**
+ * Errors for every spine and leaf whose model is outside the catalog, and
+ * for a wiring generated under a different catalog than the one given
+ */
+export function checkCatalogModels(wiring: Wiring, catalog: NamedCatalog): string[] {
+  const errors: string[] = []
+  const selected = wiring.metadata.catalog
+  if (selected !== undefined && selected !== catalog.id) {
+    errors.push(`Design selects catalog ${selected} but was validated against ${catalog.id}`)
+  }
+  for (const device of [...wiring.devices.spines, ...wiring.devices.leaves]) {
+    if (!catalogAllows(catalog, device.modelId)) {
+      errors.push(`${device.type} ${device.id} uses model ${device.modelId}, which is not in catalog ${catalog.id}`)
+    }
+  }
+  return errors
+}
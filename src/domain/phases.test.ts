@@ -0,0 +1,81 @@
+/**
+ * Phased Builds Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { planPhasedBuild } from './phases'
+import { buildWiring } from './wiring'
+import { renderCablingCsv } from '../io/cabling-map'
+import { testProfiles, testSpec, testAllocation } from '../fixtures/testFabric'
+
+const profiles = testProfiles()
+
+const spec = testSpec({ name: 'Phased Fabric', endpointCount: 8 })
+const allocation = testAllocation(4)
+
+const wiring = buildWiring(spec, profiles, allocation)
+
+describe('planPhasedBuild', () => {
+  const plan = planPhasedBuild(wiring, [
+    { id: 'p1', name: 'Racks 1-2', racks: [1, 2] },
+    { id: 'p2', name: 'Racks 3-4', racks: [3, 4] }
+  ])
+
+  it('splits leaves, servers and cables by phase', () => {
+    expect(plan.errors).toEqual([])
+    expect(plan.warnings).toEqual([])
+
+    const [p1, p2] = plan.phases
+    expect(p1.leaves).toEqual(['leaf-1', 'leaf-2'])
+    expect(p2.leaves).toEqual(['leaf-3', 'leaf-4'])
+    expect(p1.added.devices.spines).toHaveLength(2)
+    expect(p2.added.devices.spines).toHaveLength(0)
+    expect(p1.added.devices.servers).toHaveLength(4)
+    expect(p1.cabling).toHaveLength(8) // 4 uplinks + 4 endpoint cables
+    expect(p2.cumulative.connections).toHaveLength(wiring.connections.length)
+  })
+
+  it('computes a BOM for what each phase adds', () => {
+    expect(plan.phases[0].bom.summary.totalSwitches).toBe(4)
+    expect(plan.phases[1].bom.summary.totalSwitches).toBe(2)
+  })
+
+  it('validates the fabric as built after each phase', () => {
+    expect(plan.phases.map(p => p.validation.errors)).toEqual([[], []])
+  })
+
+  it('groups several leaves per rack', () => {
+    const byRack = planPhasedBuild(wiring, [{ id: 'p1', racks: [1] }, { id: 'p2', racks: [2] }], { leavesPerRack: 2 })
+
+    expect(byRack.phases.map(p => p.leaves)).toEqual([['leaf-1', 'leaf-2'], ['leaf-3', 'leaf-4']])
+  })
+
+  it('reports overlapping, missing and unphased leaves', () => {
+    const broken = planPhasedBuild(wiring, [
+      { id: 'p1', racks: [1, 2, 9] },
+      { id: 'p2', leaves: ['leaf-2', 'leaf-7'] }
+    ])
+
+    expect(broken.errors).toEqual([
+      'Phase p1: rack 9 does not exist (design has 4 racks)',
+      'Phase p2: leaf leaf-7 does not exist',
+      'Leaf leaf-2 is assigned to both phase p1 and phase p2'
+    ])
+    expect(broken.warnings).toEqual(['Leaves not assigned to any phase: leaf-3, leaf-4'])
+  })
+})
+
+describe('renderCablingCsv', () => {
+  it('adds label columns after the fixed columns', () => {
+    const csv = renderCablingCsv([
+      { cable: 'c1', type: 'endpoint', fromDevice: 'srv-1', fromPort: 'eth0', toDevice: 'leaf-1', toPort: 'E1/1', labels: { team: 'ml' } },
+      { cable: 'c2', type: 'uplink', fromDevice: 'leaf-1', fromPort: 'E1/5', toDevice: 'spine-1', toPort: 'E1/1', labels: {} }
+    ])
+
+    expect(csv).toBe(
+      'cable,type,from_device,from_port,to_device,to_port,team\n' +
+      'c1,endpoint,srv-1,eth0,leaf-1,E1/1,ml\n' +
+      'c2,uplink,leaf-1,E1/5,spine-1,E1/1,\n'
+    )
+  })
+})
@@ -0,0 +1,162 @@
+/**
+ * Phased Builds - HNC v0.6
+ * Splits one design into deployment phases (phase 1: racks 1-4, phase 2:
+ * racks 5-8, ...) and computes what each phase adds - devices, cables, BOM -
+ * plus validation of the fabric as built after each phase.
+ */
+
+import { validateWiring, wiringToWiringDiagram, type Wiring, type WiringValidationResult } from './wiring'
+import { compileBOM, type BOMAnalysis } from './bom-compiler'
+import { buildCablingMap, type CablingRow } from '../io/cabling-map'
+
+export interface DesignPhase {
+  id: string
+  name?: string
+  racks?: number[] // 1-based; rack N holds the Nth group of leavesPerRack leaves
+  leaves?: string[] // explicit leaf ids, in addition to racks
+}
+
+export interface PhaseOptions {
+  leavesPerRack?: number // default: 1
+}
+
+export interface PhaseBuild {
+  phase: DesignPhase
+  index: number
+  leaves: string[]
+  /** Devices and cables first deployed in this phase */
+  added: Wiring
+  /** Fabric as built once this phase is complete */
+  cumulative: Wiring
+  bom: BOMAnalysis
+  cabling: CablingRow[]
+  validation: WiringValidationResult
+}
+
+export interface PhasedBuildPlan {
+  phases: PhaseBuild[]
+  errors: string[]
+  warnings: string[]
+}
+
+/**
+ * Computes per-phase deltas, BOMs, cabling maps and validation.
+ *
+ * Spines are deployed with the first phase. A server is deployed with the
+ * first phase that includes one of its leaves; its cables to leaves in later
+ * phases are deployed with those phases.
+ */
+export function planPhasedBuild(wiring: Wiring, phases: DesignPhase[], options: PhaseOptions = {}): PhasedBuildPlan {
+  const { leavesPerRack = 1 } = options
+  const errors: string[] = []
+  const warnings: string[] = []
+
+  const sortedLeaves = wiring.devices.leaves
+    .map(l => l.id)
+    .sort((a, b) => a.localeCompare(b, undefined, { numeric: true }))
+  const rackCount = Math.ceil(sortedLeaves.length / leavesPerRack)
+  const leafPhase = new Map<string, number>()
+
+  phases.forEach((phase, index) => {
+    const leaves = [
+      ...(phase.racks ?? []).flatMap(rack => {
+        if (!Number.isInteger(rack) || rack < 1 || rack > rackCount) {
+          errors.push(`Phase ${phase.id}: rack ${rack} does not exist (design has ${rackCount} racks)`)
+          return []
+        }
+        return sortedLeaves.slice((rack - 1) * leavesPerRack, rack * leavesPerRack)
+      }),
+      ...(phase.leaves ?? []).filter(id => {
+        if (sortedLeaves.includes(id)) return true
+        errors.push(`Phase ${phase.id}: leaf ${id} does not exist`)
+        return false
+      })
+    ]
+    for (const leaf of leaves) {
+      const previous = leafPhase.get(leaf)
+      if (previous !== undefined && previous !== index) {
+        errors.push(`Leaf ${leaf} is assigned to both phase ${phases[previous].id} and phase ${phase.id}`)
+      } else {
+        leafPhase.set(leaf, index)
+      }
+    }
+  })
+
+  const unphased = sortedLeaves.filter(id => !leafPhase.has(id))
+  if (unphased.length > 0) {
+    warnings.push(`Leaves not assigned to any phase: ${unphased.join(', ')}`)
+  }
+
+  // Endpoint connections run server -> leaf; a server lands with its earliest leaf
+  const serverPhase = new Map<string, number>()
+  const serverPhases = new Map<string, Set<number>>()
+  for (const c of wiring.connections) {
+    if (c.type !== 'endpoint') continue
+    const phase = leafPhase.get(c.to.device)
+    if (phase === undefined) continue
+    serverPhase.set(c.from.device, Math.min(phase, serverPhase.get(c.from.device) ?? phase))
+    serverPhases.set(c.from.device, (serverPhases.get(c.from.device) ?? new Set()).add(phase))
+  }
+  for (const [server, seen] of serverPhases) {
+    if (seen.size > 1) {
+      const ids = [...seen].sort((a, b) => a - b).map(i => phases[i].id)
+      warnings.push(`Server ${server} is only partially cabled until phase ${ids[ids.length - 1]} (leaves span phases ${ids.join(', ')})`)
+    }
+  }
+
+  const connectionPhase = (c: Wiring['connections'][number]) =>
+    c.type === 'uplink' ? leafPhase.get(c.from.device) : leafPhase.get(c.to.device)
+
+  const built: PhaseBuild[] = phases.map((phase, index) => {
+    const added = subset(wiring, {
+      spines: () => index === 0,
+      leaves: id => leafPhase.get(id) === index,
+      servers: id => serverPhase.get(id) === index,
+      connection: c => connectionPhase(c) === index
+    })
+    const cumulative = subset(wiring, {
+      spines: () => true,
+      leaves: id => (leafPhase.get(id) ?? Infinity) <= index,
+      servers: id => (serverPhase.get(id) ?? Infinity) <= index,
+      connection: c => (connectionPhase(c) ?? Infinity) <= index
+    })
+
+    return {
+      phase,
+      index,
+      leaves: added.devices.leaves.map(l => l.id),
+      added,
+      cumulative,
+      bom: compileBOM(wiringToWiringDiagram(added)),
+      cabling: buildCablingMap(added),
+      validation: validateWiring(cumulative)
+    }
+  })
+
+  return { phases: built, errors, warnings }
+}
+
+interface SubsetFilter {
+  spines: (id: string) => boolean
+  leaves: (id: string) => boolean
+  servers: (id: string) => boolean
+  connection: (c: Wiring['connections'][number]) => boolean
+}
+
+function subset(wiring: Wiring, keep: SubsetFilter): Wiring {
+  const devices = {
+    spines: wiring.devices.spines.filter(d => keep.spines(d.id)),
+    leaves: wiring.devices.leaves.filter(d => keep.leaves(d.id)),
+    servers: wiring.devices.servers.filter(d => keep.servers(d.id))
+  }
+  const connections = wiring.connections.filter(keep.connection)
+  return {
+    devices,
+    connections,
+    metadata: {
+      ...wiring.metadata,
+      totalDevices: devices.spines.length + devices.leaves.length + devices.servers.length,
+      totalConnections: connections.length
+    }
+  }
+}
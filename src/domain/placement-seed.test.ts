@@ -0,0 +1,121 @@
+/**
+ * Placement Seed Tests - HNC v0.6
+ */
+
+import { readFileSync } from 'fs'
+import { describe, it, expect } from 'vitest'
+import { PLACEMENT_SEED_ANNOTATION, createPlacementSeed, isValidPlacementSeed, spineVisitOrder, tieBreakOffset } from './placement-seed'
+import { allocateUplinks } from './allocator'
+import { buildWiring, type WiringDecision } from './wiring'
+import { deserializeCRDsToWiringDiagram, serializeWiringDiagramToCRDs } from '../io/crd-yaml'
+import type { FabricSpec, SwitchProfile, WiringDiagram } from '../app.types'
+import { fixtureFile } from '../ingest/fixtureFiles'
+
+const fixture = (name: string) => JSON.parse(readFileSync(fixtureFile('src/fixtures/switch-profiles', name), 'utf-8')) as SwitchProfile
+
+const profiles = new Map<string, SwitchProfile>([
+  ['DS2000', fixture('ds2000')],
+  ['DS3000', fixture('ds3000')]
+])
+
+const spec = (placementSeed?: number): FabricSpec => ({
+  name: 'seeded',
+  spineModelId: 'DS3000',
+  leafModelId: 'DS2000',
+  uplinksPerLeaf: 4,
+  endpointCount: 8,
+  endpointProfile: { name: 'server', portsPerEndpoint: 1 },
+  ...(placementSeed !== undefined && { placementSeed })
+} as FabricSpec)
+
+const wire = (placementSeed?: number, trace?: WiringDecision[]) => {
+  const allocation = allocateUplinks(
+    { uplinksPerLeaf: 4, leavesNeeded: 6, spinesNeeded: 4, endpointCount: 8, ...(placementSeed !== undefined && { placementSeed }) },
+    profiles.get('DS2000')!,
+    profiles.get('DS3000')!
+  )
+  expect(allocation.issues).toEqual([])
+  return { allocation, wiring: buildWiring(spec(placementSeed), profiles, allocation, trace) }
+}
+
+const firstSpines = (placementSeed?: number) => wire(placementSeed).allocation.leafMaps.map(l => l.uplinks[0].toSpine)
+const uplinkPorts = (placementSeed?: number) =>
+  wire(placementSeed).wiring.connections.filter(c => c.type === 'uplink').map(c => `${c.from.device}/${c.from.port}>${c.to.device}/${c.to.port}`)
+
+describe('placement seed', () => {
+  it('breaks ties lowest-first without a seed', () => {
+    expect(spineVisitOrder(4, undefined, 7)).toEqual([0, 1, 2, 3])
+    expect(firstSpines()).toEqual([0, 0, 0, 0, 0, 0])
+    expect(wire().wiring.metadata).not.toHaveProperty('placementSeed')
+  })
+
+  it('reproduces the same placement for the same seed', () => {
+    expect(uplinkPorts(42)).toEqual(uplinkPorts(42))
+    expect(tieBreakOffset(42, 3, 4)).toBe(tieBreakOffset(42, 3, 4))
+  })
+
+  it('spreads ties differently for different seeds while staying balanced', () => {
+    const seeds = [1, 2, 3, 42, 1234567]
+    const starts = seeds.map(firstSpines)
+    expect(new Set(starts.map(s => s.join())).size).toBeGreaterThan(1)
+    expect(starts.flat().some(spine => spine !== 0)).toBe(true)
+
+    for (const seed of seeds) {
+      const { allocation } = wire(seed)
+      expect(allocation.spineUtilization).toEqual([6, 6, 6, 6])
+      for (const leaf of allocation.leafMaps) {
+        expect(leaf.uplinks.map(u => u.port)).toEqual(['E1/49', 'E1/50', 'E1/51', 'E1/52'])
+        expect([...leaf.uplinks.map(u => u.toSpine)].sort()).toEqual([0, 1, 2, 3])
+      }
+    }
+  })
+
+  it("keeps one leaf's choice stable when the leaf count changes", () => {
+    const six = firstSpines(99)
+    const allocation = allocateUplinks(
+      { uplinksPerLeaf: 4, leavesNeeded: 3, spinesNeeded: 4, endpointCount: 8, placementSeed: 99 },
+      profiles.get('DS2000')!,
+      profiles.get('DS3000')!
+    )
+    expect(allocation.leafMaps.map(l => l.uplinks[0].toSpine)).toEqual(six.slice(0, 3))
+  })
+
+  it('records the seed in wiring metadata and the explain trace', () => {
+    const trace: WiringDecision[] = []
+    const { wiring } = wire(7, trace)
+    expect(wiring.metadata.placementSeed).toBe(7)
+    const first = spineVisitOrder(4, 7, 0)[0]
+    expect(trace.find(d => d.subject === 'leaf-1')!.reasons).toContain(`Placement seed 7 starts leaf-1's round-robin at spine-${first + 1}`)
+  })
+
+  it('round-trips the seed through the Fabric CRD annotation', () => {
+    const diagram: WiringDiagram = {
+      devices: {
+        spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+        leaves: [{ id: 'leaf-1', model: 'DS2000', ports: 48 }],
+        servers: [{ id: 'srv-1', type: 'server', connections: 1 }]
+      },
+      connections: [{ from: { device: 'srv-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' }],
+      metadata: { generatedAt: new Date(0), fabricName: 'seeded', totalDevices: 3, placementSeed: 4000000000 }
+    }
+    const crds = serializeWiringDiagramToCRDs(diagram)
+    expect(crds.fabric).toMatch(new RegExp(`${PLACEMENT_SEED_ANNOTATION}: ["']4000000000["']`))
+    expect(deserializeCRDsToWiringDiagram(crds).metadata.placementSeed).toBe(4000000000)
+
+    const unseeded = serializeWiringDiagramToCRDs({ ...diagram, metadata: { ...diagram.metadata, placementSeed: undefined } })
+    expect(unseeded.fabric).not.toContain(PLACEMENT_SEED_ANNOTATION)
+    expect(deserializeCRDsToWiringDiagram(unseeded).metadata).not.toHaveProperty('placementSeed')
+  })
+
+  it('rejects seeds outside the 32-bit range', () => {
+    const allocation = allocateUplinks(
+      { uplinksPerLeaf: 4, leavesNeeded: 1, spinesNeeded: 4, endpointCount: 8, placementSeed: -1 },
+      profiles.get('DS2000')!,
+      profiles.get('DS3000')!
+    )
+    expect(allocation.issues).toEqual(['Placement seed (-1) must be an integer from 0 to 4294967295'])
+    expect([0, 0xffffffff].every(isValidPlacementSeed)).toBe(true)
+    expect([1.5, 2 ** 32, NaN, '1'].some(isValidPlacementSeed)).toBe(false)
+    expect(isValidPlacementSeed(createPlacementSeed(() => 0.999999999))).toBe(true)
+  })
+})
@@ -0,0 +1,51 @@
+/**
+ * Placement Seed - HNC v0.6
+ * Allocation ties (which equivalent spine a leaf's round-robin starts at)
+ * are broken lowest-first by default. A design can instead carry an explicit
+ * seed: ties are then spread pseudo-randomly, identically on every machine,
+ * and the seed is recorded with the wiring so the result can be reproduced.
+ */
+
+export const PLACEMENT_SEED_ANNOTATION = 'hnc.githedgehog.com/placement-seed'
+
+/**
+ * 32-bit LCG; the same seed yields the same sequence on any JS runtime
+ */
+export function seededRandom(seed: number): () => number {
+  let state = seed >>> 0
+  return () => {
+    state = (state * 1664525 + 1013904223) >>> 0
+    return state / 2 ** 32
+  }
+}
+
+export function isValidPlacementSeed(seed: unknown): seed is number {
+  return typeof seed === 'number' && Number.isInteger(seed) && seed >= 0 && seed <= 0xffffffff
+}
+
+/**
+ * A fresh seed for a design that has none yet
+ */
+export function createPlacementSeed(random: () => number = Math.random): number {
+  return Math.floor(random() * 2 ** 32) >>> 0
+}
+
+/**
+ * Which of `choices` equivalent options item `key` starts at. Depends only
+ * on seed and key, so one leaf's choice does not shift when others change.
+ * Without a seed the lowest option (0) always wins.
+ */
+export function tieBreakOffset(seed: number | undefined, key: number, choices: number): number {
+  if (seed === undefined || choices <= 1) return 0
+  const random = seededRandom((seed ^ Math.imul(key + 1, 0x9e3779b9)) >>> 0)
+  random() // the first draw of a nearby state is correlated; discard it
+  return Math.floor(random() * choices)
+}
+
+/**
+ * Spine indices in the order a leaf's round-robin visits them
+ */
+export function spineVisitOrder(spines: number, seed: number | undefined, leafId: number): number[] {
+  const offset = tieBreakOffset(seed, leafId, spines)
+  return Array.from({ length: spines }, (_, i) => (i + offset) % spines)
+}
@@ -0,0 +1,68 @@
+/**
+ * PTP Timing Distribution Planning Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { planPtpDistribution, type PtpCapability } from './ptp-planning'
+import type { Wiring, WiringConnection } from './wiring'
+
+function fixture(): Wiring {
+  const connections: WiringConnection[] = []
+  for (const leaf of ['leaf-1', 'leaf-2']) {
+    for (const [i, spine] of ['spine-1', 'spine-2'].entries()) {
+      connections.push({ id: `${leaf}-${spine}`, from: { device: leaf, port: `E1/${49 + i}` }, to: { device: spine, port: `E1/${leaf.slice(-1)}` }, type: 'uplink' })
+    }
+  }
+  connections.push({ id: 'srv', from: { device: 'srv-1', port: 'eth0' }, to: { device: 'leaf-2', port: 'E1/1' }, type: 'endpoint' })
+  return {
+    devices: {
+      spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32 }, { id: 'spine-2', type: 'spine', modelId: 'DS3000', ports: 32 }],
+      leaves: [{ id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 48 }, { id: 'leaf-2', type: 'leaf', modelId: 'DS2000', ports: 48 }],
+      servers: [{ id: 'srv-1', type: 'server', modelId: 'server', ports: 1, classId: 'media' }]
+    },
+    connections,
+    metadata: { fabricName: 't', fabricId: 't', generatedAt: new Date(0), totalDevices: 5, totalConnections: connections.length }
+  }
+}
+
+const bc: PtpCapability = { boundaryClock: true, transparentClock: true, profiles: ['g8275.1', 'default-e2e'] }
+
+describe('planPtpDistribution', () => {
+  it('builds a shortest-hop tree from the best grandmaster', () => {
+    const plan = planPtpDistribution(fixture(), {
+      profile: 'g8275.1',
+      grandmasters: [{ id: 'gm-b', attachedTo: 'leaf-2', priority: 200 }, { id: 'gm-a', attachedTo: 'leaf-1', priority: 100 }],
+      capabilities: { DS2000: bc, DS3000: bc }
+    })
+
+    expect(plan.primary).toBe('gm-a')
+    expect(plan.errors).toEqual([])
+    expect(plan.tree.map(n => [n.device, n.parent, n.hops])).toEqual([
+      ['leaf-1', 'gm-a', 1],
+      ['spine-1', 'leaf-1', 2],
+      ['spine-2', 'leaf-1', 2],
+      ['leaf-2', 'spine-1', 3]
+    ])
+    expect(plan.tree[3].parentPort).toBe('E1/49')
+    expect(plan.clients).toEqual([{ server: 'srv-1', leaf: 'leaf-2', hops: 3 }])
+  })
+
+  it('fails switches lacking the required profile', () => {
+    const plan = planPtpDistribution(fixture(), {
+      profile: 'g8275.1',
+      grandmasters: [{ id: 'gm', attachedTo: 'leaf-1' }],
+      capabilities: { DS2000: bc, DS3000: { boundaryClock: true, transparentClock: false, profiles: ['default-e2e'] } }
+    })
+
+    expect(plan.errors).toEqual([
+      'spine-1: DS3000 does not support PTP profile g8275.1',
+      'spine-2: DS3000 does not support PTP profile g8275.1'
+    ])
+    expect(plan.warnings[0]).toContain('Only one grandmaster')
+  })
+
+  it('reports a missing grandmaster', () => {
+    const plan = planPtpDistribution(fixture(), { profile: 'default-e2e', grandmasters: [], capabilities: {} })
+    expect(plan.errors).toEqual(['No grandmaster placed'])
+  })
+})
@@ -0,0 +1,143 @@
+/**
+ * PTP Timing Distribution Planning - HNC v0.6
+ * Places grandmaster clocks, derives the boundary-clock distribution tree over
+ * the fabric and validates PTP profile support on every switch in the tree
+ */
+
+import type { Wiring } from './wiring'
+
+export type PtpProfile = 'default-e2e' | 'default-p2p' | 'g8275.1' | 'g8275.2' | 'smpte-2059'
+
+export interface PtpCapability {
+  boundaryClock: boolean
+  transparentClock: boolean
+  profiles: PtpProfile[]
+}
+
+export interface Grandmaster {
+  id: string
+  attachedTo: string // switch id the GM is cabled to
+  priority?: number  // lower wins (PTP priority1), default 128
+}
+
+export interface PtpPlanOptions {
+  profile: PtpProfile
+  grandmasters: Grandmaster[]
+  capabilities: Record<string, PtpCapability> // by switch modelId
+  clientClasses?: string[] // server classes needing time; default: all servers
+  maxHops?: number         // default: 8 switch hops from the GM
+}
+
+export interface PtpTreeNode {
+  device: string
+  parent: string | null // upstream device (GM id at the root)
+  parentPort?: string   // local port facing the parent (slave port)
+  hops: number
+  clockMode: 'boundary' | 'transparent' | 'none'
+}
+
+export interface PtpPlan {
+  primary: string | null
+  tree: PtpTreeNode[]
+  clients: Array<{ server: string; leaf: string | null; hops: number | null }>
+  errors: string[]
+  warnings: string[]
+}
+
+/**
+ * Computes the shortest-hop timing tree rooted at the best grandmaster.
+ * Servers are leaves of the tree; they never forward time.
+ */
+export function planPtpDistribution(wiring: Wiring, options: PtpPlanOptions): PtpPlan {
+  const { profile, grandmasters, capabilities, clientClasses, maxHops = 8 } = options
+  const errors: string[] = []
+  const warnings: string[] = []
+
+  const switches = new Map([...wiring.devices.spines, ...wiring.devices.leaves].map(d => [d.id, d]))
+  const gms = [...grandmasters]
+    .filter(gm => {
+      if (!switches.has(gm.attachedTo)) {
+        errors.push(`Grandmaster ${gm.id} is attached to unknown switch ${gm.attachedTo}`)
+        return false
+      }
+      return true
+    })
+    .sort((a, b) => (a.priority ?? 128) - (b.priority ?? 128) || a.id.localeCompare(b.id))
+
+  if (gms.length === 0) {
+    errors.push('No grandmaster placed')
+    return { primary: null, tree: [], clients: [], errors, warnings }
+  }
+  if (gms.length === 1) {
+    warnings.push(`Only one grandmaster (${gms[0].id}); timing is lost if it fails`)
+  } else if (new Set(gms.map(g => g.attachedTo)).size === 1) {
+    warnings.push(`All grandmasters attach to ${gms[0].attachedTo}; that switch is a single point of failure`)
+  }
+
+  // Switch-to-switch adjacency with the local port used on each side
+  const adjacency = new Map<string, Array<{ peer: string; localPort: string }>>()
+  for (const conn of wiring.connections) {
+    if (!switches.has(conn.from.device) || !switches.has(conn.to.device)) continue
+    const add = (a: string, b: string, port: string) => {
+      const list = adjacency.get(a) || []
+      list.push({ peer: b, localPort: port })
+      adjacency.set(a, list)
+    }
+    add(conn.from.device, conn.to.device, conn.from.port)
+    add(conn.to.device, conn.from.device, conn.to.port)
+  }
+  for (const list of adjacency.values()) {
+    list.sort((a, b) => a.peer.localeCompare(b.peer, undefined, { numeric: true }) || a.localPort.localeCompare(b.localPort))
+  }
+
+  const primary = gms[0]
+  const nodes = new Map<string, PtpTreeNode>()
+  nodes.set(primary.attachedTo, { device: primary.attachedTo, parent: primary.id, hops: 1, clockMode: 'none' })
+  const queue = [primary.attachedTo]
+  while (queue.length > 0) {
+    const current = queue.shift()!
+    for (const { peer } of adjacency.get(current) || []) {
+      if (nodes.has(peer)) continue
+      const back = (adjacency.get(peer) || []).find(a => a.peer === current)
+      nodes.set(peer, { device: peer, parent: current, parentPort: back?.localPort, hops: nodes.get(current)!.hops + 1, clockMode: 'none' })
+      queue.push(peer)
+    }
+  }
+
+  for (const node of nodes.values()) {
+    const modelId = switches.get(node.device)!.modelId
+    const cap = capabilities[modelId]
+    if (!cap) {
+      errors.push(`${node.device}: no PTP capability data for model ${modelId}`)
+      continue
+    }
+    node.clockMode = cap.boundaryClock ? 'boundary' : cap.transparentClock ? 'transparent' : 'none'
+    if (node.clockMode === 'none') {
+      errors.push(`${node.device}: ${modelId} is neither a boundary nor a transparent clock`)
+    } else if (!cap.profiles.includes(profile)) {
+      errors.push(`${node.device}: ${modelId} does not support PTP profile ${profile}`)
+    }
+    if (node.hops > maxHops) {
+      warnings.push(`${node.device} is ${node.hops} hops from ${primary.id} (limit ${maxHops})`)
+    }
+  }
+  for (const id of switches.keys()) {
+    if (!nodes.has(id)) warnings.push(`${id} is not reachable from grandmaster ${primary.id}`)
+  }
+
+  const wanted = clientClasses ? new Set(clientClasses) : null
+  const clients = wiring.devices.servers
+    .filter(s => !wanted || (s.classId !== undefined && wanted.has(s.classId)))
+    .map(server => {
+      const leaves = wiring.connections
+        .filter(c => c.type === 'endpoint' && c.from.device === server.id && nodes.has(c.to.device))
+        .map(c => nodes.get(c.to.device)!)
+        .sort((a, b) => a.hops - b.hops || a.device.localeCompare(b.device))
+      const best = leaves[0]
+      if (!best) errors.push(`PTP client ${server.id} has no path to grandmaster ${primary.id}`)
+      return { server: server.id, leaf: best?.device ?? null, hops: best ? best.hops : null }
+    })
+
+  const tree = [...nodes.values()].sort((a, b) => a.hops - b.hops || a.device.localeCompare(b.device, undefined, { numeric: true }))
+  return { primary: primary.id, tree, clients, errors, warnings }
+}
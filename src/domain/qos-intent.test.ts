@@ -0,0 +1,93 @@
+/**
+ * Connection-level QoS Intent Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { computeQosIntent, type QosCapability } from './qos-intent'
+import type { Wiring, WiringConnection } from './wiring'
+
+function fixture(): Wiring {
+  const connections: WiringConnection[] = [
+    { id: 'u1', from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' },
+    { id: 'u2', from: { device: 'leaf-2', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/2' }, type: 'uplink' },
+    { id: 'e1', from: { device: 'nas-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' },
+    { id: 'e2', from: { device: 'gpu-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/2' }, type: 'endpoint' },
+    { id: 'e3', from: { device: 'web-1', port: 'eth0' }, to: { device: 'leaf-2', port: 'E1/1' }, type: 'endpoint' }
+  ]
+  return {
+    devices: {
+      spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32 }],
+      leaves: [
+        { id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 48 },
+        { id: 'leaf-2', type: 'leaf', modelId: 'DS1000', ports: 48 }
+      ],
+      servers: [
+        { id: 'nas-1', type: 'server', modelId: 'storage', ports: 1, classId: 'storage' },
+        { id: 'gpu-1', type: 'server', modelId: 'compute', ports: 1, classId: 'gpu' },
+        { id: 'web-1', type: 'server', modelId: 'server', ports: 1, classId: 'web' }
+      ]
+    },
+    connections,
+    metadata: { fabricName: 't', fabricId: 't', generatedAt: new Date(0), totalDevices: 6, totalConnections: 5 }
+  }
+}
+
+const capable: QosCapability = { pfc: true, ecn: true, bufferMB: 32, queues: 8, strictPriority: true }
+const classes = { storage: 'lossless', gpu: 'high-priority' } as const
+
+describe('computeQosIntent', () => {
+  it('derives per-port queue, PFC and ECN intent from server classes', () => {
+    const result = computeQosIntent(fixture(), { classes, capabilities: { DS2000: capable, DS3000: capable } })
+
+    expect(result.errors).toEqual([])
+    expect(result.devices.map(d => d.device)).toEqual(['leaf-1', 'leaf-2', 'spine-1'])
+
+    const leaf1 = result.devices[0]
+    expect(leaf1.queues.map(q => [q.queue, q.intent, q.ports])).toEqual([
+      [0, 'best-effort', ['E1/1', 'E1/2', 'E1/49']],
+      [3, 'lossless', ['E1/1', 'E1/49']],
+      [5, 'high-priority', ['E1/2', 'E1/49']]
+    ])
+    expect(leaf1.pfc).toEqual({ priorities: [3], ports: ['E1/1', 'E1/49'] })
+    expect(leaf1.ecn).toEqual({ minThresholdKB: 150, maxThresholdKB: 1500, ports: ['E1/1', 'E1/49'] })
+
+    // leaf-2 only hosts best-effort servers, so it needs no capability data
+    expect(result.devices[1].queues.map(q => q.intent)).toEqual(['best-effort'])
+    expect(result.devices[1].pfc).toBeUndefined()
+
+    // The spine carries leaf-1's classes on leaf-1's uplink only
+    const spine = result.devices[2]
+    expect(spine.queues.find(q => q.intent === 'lossless')?.ports).toEqual(['E1/1'])
+    expect(spine.queues.find(q => q.intent === 'best-effort')?.ports).toEqual(['E1/1', 'E1/2'])
+  })
+
+  it('rejects intents the hardware cannot honor', () => {
+    const result = computeQosIntent(fixture(), {
+      classes,
+      capabilities: {
+        DS2000: { ...capable, pfc: false, strictPriority: false, queues: 4 },
+        DS3000: { ...capable, bufferMB: 8 }
+      }
+    })
+
+    expect(result.errors).toEqual([
+      'leaf-1: DS2000 does not support PFC, required for lossless traffic',
+      'leaf-1: DS2000 has no strict-priority scheduling, required for high-priority traffic',
+      'leaf-1: DS2000 has 4 queues; high-priority traffic uses queue 5',
+      'spine-1: DS3000 buffer 8MB is below the 16MB lossless minimum'
+    ])
+  })
+
+  it('flags missing capability data, unknown intents and unused classes', () => {
+    const result = computeQosIntent(fixture(), {
+      classes: { storage: 'lossless', gpu: 'turbo' as never, backup: 'best-effort' },
+      capabilities: { DS2000: capable }
+    })
+
+    expect(result.errors).toEqual([
+      'Class gpu: unknown QoS intent turbo',
+      'spine-1: No QoS capability data for model DS3000 (needed for lossless)'
+    ])
+    expect(result.warnings).toEqual(['Class backup has a best-effort QoS intent but no servers'])
+  })
+})
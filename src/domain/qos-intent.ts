@@ -0,0 +1,153 @@
+/**
+ * Connection-level QoS Intent - HNC v0.6
+ * Declares a QoS intent per server class (lossless storage, high-priority
+ * GPU, best-effort everything else), derives the queue, PFC and ECN intent
+ * of every switch port those classes reach, and checks it against each
+ * model's QoS capabilities
+ */
+
+import type { LosslessCapability } from './roce-qualification'
+import type { Wiring } from './wiring'
+
+export const QOS_INTENTS = ['lossless', 'high-priority', 'best-effort'] as const
+export type QosIntent = typeof QOS_INTENTS[number]
+
+export interface QosCapability extends LosslessCapability {
+  queues: number          // egress queues per port
+  strictPriority: boolean // strict-priority scheduling of a queue
+}
+
+export interface QosIntentOptions {
+  // Intent per server class id; servers of other classes are best-effort
+  classes: Record<string, QosIntent>
+  // Capability per switch modelId; models not listed only carry best-effort
+  capabilities: Record<string, QosCapability>
+  minBufferMB?: number // lossless buffer minimum, default: 16
+  ecnMinKB?: number    // default: 150
+  ecnMaxKB?: number    // default: 1500
+}
+
+export interface QosQueueIntent {
+  queue: number
+  intent: QosIntent
+  dscp: number[]
+  scheduling: 'strict' | 'dwrr'
+  weight?: number // dwrr only
+  ports: string[]
+}
+
+export interface QosDeviceIntent {
+  device: string
+  modelId: string
+  queues: QosQueueIntent[]
+  pfc?: { priorities: number[]; ports: string[] }
+  ecn?: { minThresholdKB: number; maxThresholdKB: number; ports: string[] }
+}
+
+export interface QosIntentResult {
+  devices: QosDeviceIntent[]
+  errors: string[]
+  warnings: string[]
+}
+
+// Queue and marking per intent. Lossless uses priority 3, the usual RoCE
+// choice, so the PFC priority and the queue are the same number.
+const QUEUE_PLAN: Record<QosIntent, Omit<QosQueueIntent, 'intent' | 'ports'>> = {
+  'lossless': { queue: 3, dscp: [26], scheduling: 'dwrr', weight: 50 },
+  'high-priority': { queue: 5, dscp: [34, 46], scheduling: 'strict' },
+  'best-effort': { queue: 0, dscp: [0], scheduling: 'dwrr', weight: 50 }
+}
+
+/**
+ * Computes per-device QoS intent for the given wiring.
+ * A leaf port carries the intent of the server cabled to it; with ECMP,
+ * both ends of every uplink of a leaf carry every intent on that leaf.
+ * Every switch port carries best-effort.
+ */
+export function computeQosIntent(wiring: Wiring, options: QosIntentOptions): QosIntentResult {
+  const { classes, capabilities, minBufferMB = 16, ecnMinKB = 150, ecnMaxKB = 1500 } = options
+  const errors: string[] = []
+  const warnings: string[] = []
+
+  const serverClasses = new Set(wiring.devices.servers.map(s => s.classId).filter(Boolean))
+  for (const [classId, intent] of Object.entries(classes)) {
+    if (!QOS_INTENTS.includes(intent)) errors.push(`Class ${classId}: unknown QoS intent ${intent}`)
+    else if (!serverClasses.has(classId)) warnings.push(`Class ${classId} has a ${intent} QoS intent but no servers`)
+  }
+
+  const intentOf = new Map(wiring.devices.servers.map(s => {
+    const intent = s.classId ? classes[s.classId] : undefined
+    return [s.id, intent && QOS_INTENTS.includes(intent) ? intent : 'best-effort' as QosIntent]
+  }))
+  const switches = new Map([...wiring.devices.spines, ...wiring.devices.leaves].map(d => [d.id, d]))
+  // device -> intent -> ports
+  const ports = new Map<string, Map<QosIntent, Set<string>>>()
+  const addPort = (device: string, intent: QosIntent, port: string) => {
+    const byIntent = ports.get(device) || new Map<QosIntent, Set<string>>()
+    byIntent.set(intent, (byIntent.get(intent) || new Set<string>()).add(port))
+    ports.set(device, byIntent)
+  }
+
+  for (const conn of wiring.connections) {
+    if (conn.type === 'endpoint' && switches.has(conn.to.device)) {
+      addPort(conn.to.device, intentOf.get(conn.from.device) || 'best-effort', conn.to.port)
+    }
+  }
+  const leafIntents = new Map([...ports].map(([device, byIntent]) => [device, [...byIntent.keys()]]))
+  for (const conn of wiring.connections) {
+    if (conn.type !== 'uplink') continue
+    for (const intent of new Set<QosIntent>(['best-effort', ...(leafIntents.get(conn.from.device) || [])])) {
+      addPort(conn.from.device, intent, conn.from.port)
+      addPort(conn.to.device, intent, conn.to.port)
+    }
+  }
+
+  const devices = [...ports.keys()].sort().map(device => {
+    const modelId = switches.get(device)!.modelId
+    const byIntent = ports.get(device)!
+    // Best-effort applies to every port of the device
+    byIntent.set('best-effort', new Set([...byIntent.values()].flatMap(set => [...set])))
+
+    const cap = capabilities[modelId]
+    const needed = QOS_INTENTS.filter(intent => intent !== 'best-effort' && byIntent.has(intent))
+    if (needed.length > 0 && !cap) {
+      errors.push(`${device}: No QoS capability data for model ${modelId} (needed for ${needed.join(', ')})`)
+    }
+    if (cap) {
+      if (byIntent.has('lossless')) {
+        if (!cap.pfc) errors.push(`${device}: ${modelId} does not support PFC, required for lossless traffic`)
+        if (!cap.ecn) errors.push(`${device}: ${modelId} does not support ECN, required for lossless traffic`)
+        if (cap.bufferMB < minBufferMB) {
+          errors.push(`${device}: ${modelId} buffer ${cap.bufferMB}MB is below the ${minBufferMB}MB lossless minimum`)
+        }
+      }
+      if (byIntent.has('high-priority') && !cap.strictPriority) {
+        errors.push(`${device}: ${modelId} has no strict-priority scheduling, required for high-priority traffic`)
+      }
+      for (const intent of needed) {
+        if (QUEUE_PLAN[intent].queue >= cap.queues) {
+          errors.push(`${device}: ${modelId} has ${cap.queues} queues; ${intent} traffic uses queue ${QUEUE_PLAN[intent].queue}`)
+        }
+      }
+    }
+
+    const intent: QosDeviceIntent = {
+      device,
+      modelId,
+      queues: QOS_INTENTS.filter(i => byIntent.has(i))
+        .map(i => ({ ...QUEUE_PLAN[i], intent: i, ports: [...byIntent.get(i)!].sort(comparePorts) }))
+        .sort((a, b) => a.queue - b.queue)
+    }
+    const lossless = byIntent.get('lossless')
+    if (lossless) {
+      const losslessPorts = [...lossless].sort(comparePorts)
+      intent.pfc = { priorities: [QUEUE_PLAN.lossless.queue], ports: losslessPorts }
+      intent.ecn = { minThresholdKB: ecnMinKB, maxThresholdKB: ecnMaxKB, ports: losslessPorts }
+    }
+    return intent
+  })
+
+  return { devices, errors, warnings }
+}
+
+const comparePorts = (a: string, b: string) => a.localeCompare(b, undefined, { numeric: true })
@@ -0,0 +1,63 @@
+/**
+ * A/B Redundant Fabric Generation Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { generateRedundantFabrics, applyPlaneNaming } from './redundant-fabrics'
+import type { FabricSpec } from '../app.types'
+import type { Wiring } from './wiring'
+
+const spec: FabricSpec = {
+  name: 'dc1',
+  spineModelId: 'DS3000',
+  leafModelId: 'DS2000',
+  leafClasses: [{
+    id: 'compute',
+    name: 'Compute',
+    role: 'standard',
+    uplinksPerLeaf: 4,
+    endpointProfiles: [{ name: 'server', portsPerEndpoint: 2, nics: 2, count: 40 }]
+  }]
+}
+
+describe('generateRedundantFabrics', () => {
+  it('splits every server NIC set across planes A and B', () => {
+    const design = generateRedundantFabrics(spec)
+    const profileA = design.a.leafClasses![0].endpointProfiles[0]
+
+    expect(design.errors).toEqual([])
+    expect(design.a.name).toBe('dc1-a')
+    expect(design.b.name).toBe('dc1-b')
+    expect(profileA).toMatchObject({ nics: 1, portsPerEndpoint: 1, count: 40 })
+    expect(design.a.metadata?.redundantPlane).toBe('A')
+    expect(design.addressing.a.asnBase).not.toBe(design.addressing.b.asnBase)
+  })
+
+  it('rejects single-NIC profiles and overlapping addressing', () => {
+    const single: FabricSpec = { ...spec, leafClasses: [{ ...spec.leafClasses![0], endpointProfiles: [{ name: 'edge', portsPerEndpoint: 1, count: 4 }] }] }
+    const design = generateRedundantFabrics(single, { addressing: { b: { loopbackPrefix: '10.10.0.0/24' } } })
+
+    expect(design.errors).toContain("Class compute: profile 'edge' has 1 NIC(s); A/B redundancy needs an even count >= 2")
+    expect(design.errors).toContain('Planes A and B share loopbackPrefix 10.10.0.0/24')
+  })
+})
+
+describe('applyPlaneNaming', () => {
+  it('prefixes switch ids but keeps shared server ids', () => {
+    const wiring: Wiring = {
+      devices: {
+        spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32 }],
+        leaves: [{ id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 48 }],
+        servers: [{ id: 'srv-1', type: 'server', modelId: 'server', ports: 1 }]
+      },
+      connections: [{ id: 'link-1', from: { device: 'srv-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' }],
+      metadata: { fabricName: 'dc1', fabricId: 'dc1', generatedAt: new Date(0), totalDevices: 3, totalConnections: 1 }
+    }
+    const named = applyPlaneNaming(wiring, generateRedundantFabrics(spec).addressing.b)
+
+    expect(named.devices.leaves[0].id).toBe('b-leaf-1')
+    expect(named.devices.servers[0].id).toBe('srv-1')
+    expect(named.connections[0]).toMatchObject({ id: 'b-link-1', to: { device: 'b-leaf-1' } })
+    expect(named.metadata.fabricId).toBe('dc1-b')
+  })
+})
@@ -0,0 +1,123 @@
+/**
+ * A/B Redundant Fabric Generation - HNC v0.6
+ *
+ * Generates two physically separate, identical fabrics (A and B) from one
+ * endpoint inventory. Unlike the frontend/backend dual-fabric mode (WP-GPU1),
+ * both planes carry the same traffic: every server splits its NICs evenly
+ * across A and B so losing an entire plane never isolates a server.
+ */
+
+import type { FabricSpec, EndpointProfile, LeafClass } from '../app.types'
+import type { Wiring, WiringDevice } from './wiring'
+
+export type FabricPlane = 'a' | 'b'
+
+export interface PlaneAddressing {
+  plane: FabricPlane
+  namePrefix: string     // prepended to every device id, e.g. "a-"
+  asnBase: number        // first BGP ASN handed out in this plane
+  loopbackPrefix: string // per-switch /32 loopbacks come from here
+  fabricLinkPrefix: string // point-to-point link subnets come from here
+}
+
+export interface RedundantFabricOptions {
+  addressing?: Partial<Record<FabricPlane, Partial<PlaneAddressing>>>
+}
+
+export interface RedundantFabricDesign {
+  a: FabricSpec
+  b: FabricSpec
+  addressing: Record<FabricPlane, PlaneAddressing>
+  errors: string[]
+}
+
+const DEFAULT_ADDRESSING: Record<FabricPlane, PlaneAddressing> = {
+  a: { plane: 'a', namePrefix: 'a-', asnBase: 65100, loopbackPrefix: '10.10.0.0/24', fabricLinkPrefix: '10.11.0.0/16' },
+  b: { plane: 'b', namePrefix: 'b-', asnBase: 65200, loopbackPrefix: '10.20.0.0/24', fabricLinkPrefix: '10.21.0.0/16' }
+}
+
+/**
+ * Splits one fabric spec into independent A and B plane specs.
+ * Each endpoint profile keeps its server count but contributes half of its
+ * NICs to each plane; profiles with an odd or single NIC count are rejected.
+ */
+export function generateRedundantFabrics(spec: FabricSpec, options: RedundantFabricOptions = {}): RedundantFabricDesign {
+  const errors: string[] = []
+  const addressing = {
+    a: { ...DEFAULT_ADDRESSING.a, ...options.addressing?.a, plane: 'a' as const },
+    b: { ...DEFAULT_ADDRESSING.b, ...options.addressing?.b, plane: 'b' as const }
+  }
+
+  if (addressing.a.namePrefix === addressing.b.namePrefix) {
+    errors.push(`Planes A and B must use different name prefixes (both "${addressing.a.namePrefix}")`)
+  }
+  for (const field of ['loopbackPrefix', 'fabricLinkPrefix'] as const) {
+    if (addressing.a[field] === addressing.b[field]) {
+      errors.push(`Planes A and B share ${field} ${addressing.a[field]}`)
+    }
+  }
+
+  const splitProfile = (profile: EndpointProfile, where: string): EndpointProfile => {
+    const nics = profile.nics ?? profile.portsPerEndpoint
+    if (nics < 2 || nics % 2 !== 0) {
+      errors.push(`${where}: profile '${profile.name}' has ${nics} NIC(s); A/B redundancy needs an even count >= 2`)
+    }
+    const perPlane = Math.max(1, Math.floor(nics / 2))
+    return {
+      ...profile,
+      nics: perPlane,
+      portsPerEndpoint: Math.max(1, Math.floor(profile.portsPerEndpoint / 2)),
+      // A single NIC per plane cannot form a LAG
+      esLag: profile.esLag && perPlane >= 2
+    }
+  }
+
+  const planeSpec = (plane: FabricPlane): FabricSpec => {
+    const suffix = plane.toUpperCase()
+    const leafClasses = spec.leafClasses?.map((leafClass: LeafClass) => ({
+      ...leafClass,
+      endpointProfiles: leafClass.endpointProfiles.map(p => splitProfile(p, `Class ${leafClass.id}`))
+    }))
+    return {
+      ...spec,
+      name: `${spec.name}-${plane}`,
+      leafClasses,
+      endpointProfile: spec.endpointProfile ? splitProfile(spec.endpointProfile, 'Fabric') : undefined,
+      metadata: { ...spec.metadata, redundantPlane: suffix, addressing: addressing[plane] }
+    }
+  }
+
+  const a = planeSpec('a')
+  const b = planeSpec('b')
+
+  return { a, b, addressing, errors: [...new Set(errors)] }
+}
+
+/**
+ * Applies a plane's naming prefix to every switch in a built wiring so the
+ * two planes never collide. Servers keep their ids: they exist in both planes.
+ */
+export function applyPlaneNaming(wiring: Wiring, addressing: PlaneAddressing): Wiring {
+  const switchIds = new Set([...wiring.devices.spines, ...wiring.devices.leaves].map(d => d.id))
+  const rename = (id: string) => (switchIds.has(id) ? `${addressing.namePrefix}${id}` : id)
+  const renameDevice = (d: WiringDevice): WiringDevice => ({ ...d, id: rename(d.id) })
+
+  return {
+    ...wiring,
+    devices: {
+      spines: wiring.devices.spines.map(renameDevice),
+      leaves: wiring.devices.leaves.map(renameDevice),
+      servers: wiring.devices.servers
+    },
+    connections: wiring.connections.map(c => ({
+      ...c,
+      id: `${addressing.namePrefix}${c.id}`,
+      from: { ...c.from, device: rename(c.from.device) },
+      to: { ...c.to, device: rename(c.to.device) }
+    })),
+    metadata: {
+      ...wiring.metadata,
+      fabricId: `${wiring.metadata.fabricId}-${addressing.plane}`
+    }
+  }
+}
@@ -0,0 +1,80 @@
+/**
+ * Reference Architecture Conformance Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { checkConformance, formatConformance, type ReferenceArchitecture } from './reference-architecture'
+import type { Wiring, WiringConnection } from './wiring'
+
+const uplink = (leaf: string, spine: string, n: number): WiringConnection =>
+  ({ id: `up-${leaf}-${n}`, from: { device: leaf, port: `E1/${48 + n}` }, to: { device: spine, port: `E1/${n}` }, type: 'uplink' })
+const endpoint = (server: string, leaf: string, n: number): WiringConnection =>
+  ({ id: `ep-${server}-${leaf}`, from: { device: server, port: 'eth0' }, to: { device: leaf, port: `E1/${n}` }, type: 'endpoint' })
+
+// Every server dual-homed; leaf-1 has an uplink to each spine (1:1), leaf-2 only one (2:1)
+const wiring: Wiring = {
+  devices: {
+    spines: [1, 2].map(n => ({ id: `spine-${n}`, type: 'spine' as const, modelId: 'DS3000', ports: 32 })),
+    leaves: [1, 2].map(n => ({ id: `leaf-${n}`, type: 'leaf' as const, modelId: 'DS2000', ports: 56 })),
+    servers: Array.from({ length: 8 }, (_, i) => ({ id: `srv-${i + 1}`, type: 'server' as const, modelId: 'server', ports: 2 }))
+  },
+  connections: [
+    uplink('leaf-1', 'spine-1', 1), uplink('leaf-1', 'spine-2', 2), uplink('leaf-2', 'spine-1', 3),
+    ...Array.from({ length: 8 }, (_, i) => [endpoint(`srv-${i + 1}`, 'leaf-1', i + 1), endpoint(`srv-${i + 1}`, 'leaf-2', i + 1)]).flat()
+  ],
+  metadata: { fabricName: 'ref', fabricId: 'ref', generatedAt: new Date(0), totalDevices: 12, totalConnections: 19 }
+}
+
+const blueprint: ReferenceArchitecture = {
+  name: 'Standard Pod v2',
+  rules: {
+    maxOversubscription: 1.5,
+    spines: { min: 2, max: 4 },
+    maxLeaves: 16,
+    minSpinesPerLeaf: 2,
+    evenSpineLoad: true,
+    minLeavesPerServer: 2
+  },
+  weights: { minLeavesPerServer: 2 },
+  passingScore: 80
+}
+
+describe('checkConformance', () => {
+  it('scores each rule by the share of subjects that pass', () => {
+    const report = checkConformance(wiring, blueprint)
+
+    expect(report.checks.map(c => [c.rule, c.score])).toEqual([
+      ['maxOversubscription', 0.5],
+      ['spines', 1],
+      ['maxLeaves', 1],
+      ['minSpinesPerLeaf', 0.5],
+      ['evenSpineLoad', 1],
+      ['minLeavesPerServer', 1]
+    ])
+    expect(report.checks[0].failures).toEqual(['leaf-2 is 2:1 oversubscribed, limit 1.5:1'])
+    expect(report.checks[3].failures).toEqual(['leaf-2 reaches 1 spine, blueprint requires 2'])
+    // (0.5 + 1 + 1 + 0.5 + 1 + 2 * 1) / 7
+    expect(report.score).toBe(85.7)
+    expect(report.conforms).toBe(true)
+  })
+
+  it('fails designs below the passing score and explains why', () => {
+    const strict = { ...blueprint, rules: { ...blueprint.rules, spines: { min: 4 } }, passingScore: 90 }
+    const report = checkConformance(wiring, strict)
+
+    expect(report.conforms).toBe(false)
+    expect(formatConformance(report).split('\n').slice(0, 5)).toEqual([
+      'Standard Pod v2: 71.4/100 does not conform',
+      '  FAIL maxOversubscription (50%)',
+      '    - leaf-2 is 2:1 oversubscribed, limit 1.5:1',
+      '  FAIL spines (0%)',
+      '    - 2 spines, blueprint allows 4 or more'
+    ])
+  })
+
+  it('scores only the rules the blueprint defines', () => {
+    const report = checkConformance(wiring, { name: 'Minimal', rules: { minUplinksPerLeaf: 1 } })
+    expect(report.checks).toHaveLength(1)
+    expect(report.score).toBe(100)
+  })
+})
@@ -0,0 +1,161 @@
+/**
+ * Reference Architecture Conformance - HNC v0.6
+ * Scores a design against an organization's standard blueprint - ratios,
+ * tiering and redundancy rules - so teams can see how far a design strays
+ * from the approved shape and why.
+ */
+
+import { linkSpeedGbps } from './uplink-speeds'
+import type { Wiring } from './wiring'
+import type { SwitchProfile } from '../app.types'
+
+export interface ReferenceArchitecture {
+  name: string
+  rules: {
+    maxOversubscription?: number // per leaf, endpoint Gbps : uplink Gbps
+    spines?: { min?: number; max?: number }
+    maxLeaves?: number
+    minUplinksPerLeaf?: number
+    minSpinesPerLeaf?: number // distinct spines each leaf uplinks to
+    evenSpineLoad?: boolean // uplinks per spine differ by at most one
+    minLeavesPerServer?: number // server multi-homing
+  }
+  weights?: Partial<Record<ReferenceRule, number>> // default weight: 1
+  passingScore?: number // 0-100, default: 100
+}
+
+export type ReferenceRule = keyof ReferenceArchitecture['rules']
+
+export interface ConformanceCheck {
+  rule: ReferenceRule
+  score: number // 0-1, share of subjects (leaves, servers, fabric) that pass
+  failures: string[]
+}
+
+export interface ConformanceReport {
+  architecture: string
+  checks: ConformanceCheck[]
+  score: number // weighted, 0-100
+  conforms: boolean
+}
+
+export interface ConformanceOptions {
+  profiles?: Map<string, SwitchProfile> // for port speeds in ratio rules
+  defaultEndpointGbps?: number // default: 25
+  defaultUplinkGbps?: number // default: 100
+}
+
+/**
+ * Evaluates every rule the architecture defines; rules it leaves out are
+ * not scored
+ */
+export function checkConformance(
+  wiring: Wiring,
+  architecture: ReferenceArchitecture,
+  options: ConformanceOptions = {}
+): ConformanceReport {
+  const { profiles, defaultEndpointGbps = 25, defaultUplinkGbps = 100 } = options
+  const { rules } = architecture
+  const leaves = wiring.devices.leaves
+  const spines = wiring.devices.spines
+  const uplinks = wiring.connections.filter(c => c.type === 'uplink')
+  const endpoints = wiring.connections.filter(c => c.type === 'endpoint')
+  const checks: ConformanceCheck[] = []
+
+  const perSubject = (rule: ReferenceRule, subjects: string[], failure: (id: string) => string | undefined) => {
+    const failures = subjects.map(failure).filter((f): f is string => f !== undefined)
+    checks.push({ rule, score: subjects.length === 0 ? 1 : (subjects.length - failures.length) / subjects.length, failures })
+  }
+  const global = (rule: ReferenceRule, failure: string | undefined) => {
+    checks.push({ rule, score: failure === undefined ? 1 : 0, failures: failure === undefined ? [] : [failure] })
+  }
+
+  if (rules.maxOversubscription !== undefined) {
+    const max = rules.maxOversubscription
+    perSubject('maxOversubscription', leaves.map(l => l.id), id => {
+      const leaf = leaves.find(l => l.id === id)!
+      const speeds = profiles?.get(leaf.modelId)?.profiles
+      const down = endpoints.filter(c => c.to.device === id).length * (speeds?.endpoint.speedGbps || defaultEndpointGbps)
+      const up = uplinks
+        .filter(c => c.from.device === id)
+        .reduce((sum, c) => sum + linkSpeedGbps(c, speeds?.uplink.speedGbps || defaultUplinkGbps), 0)
+      if (up === 0) return down > 0 ? `${id} has endpoints but no uplink bandwidth` : undefined
+      const ratio = down / up
+      return ratio > max ? `${id} is ${formatRatio(ratio)} oversubscribed, limit ${formatRatio(max)}` : undefined
+    })
+  }
+
+  if (rules.spines) {
+    const { min = 0, max = Infinity } = rules.spines
+    global('spines', spines.length < min || spines.length > max
+      ? `${spines.length} spine${spines.length === 1 ? '' : 's'}, blueprint allows ${min}${max === Infinity ? ' or more' : `-${max}`}`
+      : undefined)
+  }
+
+  if (rules.maxLeaves !== undefined) {
+    global('maxLeaves', leaves.length > rules.maxLeaves
+      ? `${leaves.length} leaves, blueprint allows at most ${rules.maxLeaves}`
+      : undefined)
+  }
+
+  if (rules.minUplinksPerLeaf !== undefined) {
+    const min = rules.minUplinksPerLeaf
+    perSubject('minUplinksPerLeaf', leaves.map(l => l.id), id => {
+      const count = uplinks.filter(c => c.from.device === id).length
+      return count < min ? `${id} has ${count} uplink${count === 1 ? '' : 's'}, blueprint requires ${min}` : undefined
+    })
+  }
+
+  if (rules.minSpinesPerLeaf !== undefined) {
+    const min = rules.minSpinesPerLeaf
+    perSubject('minSpinesPerLeaf', leaves.map(l => l.id), id => {
+      const count = new Set(uplinks.filter(c => c.from.device === id).map(c => c.to.device)).size
+      return count < min ? `${id} reaches ${count} spine${count === 1 ? '' : 's'}, blueprint requires ${min}` : undefined
+    })
+  }
+
+  if (rules.evenSpineLoad) {
+    const load = spines.map(s => uplinks.filter(c => c.to.device === s.id).length)
+    const spread = load.length === 0 ? 0 : Math.max(...load) - Math.min(...load)
+    global('evenSpineLoad', spread > 1
+      ? `Spine uplink counts range from ${Math.min(...load)} to ${Math.max(...load)}`
+      : undefined)
+  }
+
+  if (rules.minLeavesPerServer !== undefined) {
+    const min = rules.minLeavesPerServer
+    perSubject('minLeavesPerServer', wiring.devices.servers.map(s => s.id), id => {
+      const count = new Set(endpoints.filter(c => c.from.device === id).map(c => c.to.device)).size
+      return count < min ? `${id} is homed to ${count} lea${count === 1 ? 'f' : 'ves'}, blueprint requires ${min}` : undefined
+    })
+  }
+
+  const weightOf = (rule: ReferenceRule) => architecture.weights?.[rule] ?? 1
+  const totalWeight = checks.reduce((sum, c) => sum + weightOf(c.rule), 0)
+  const score = totalWeight === 0
+    ? 100
+    : Math.round(checks.reduce((sum, c) => sum + c.score * weightOf(c.rule), 0) / totalWeight * 1000) / 10
+
+  return {
+    architecture: architecture.name,
+    checks,
+    score,
+    conforms: score >= (architecture.passingScore ?? 100)
+  }
+}
+
+/**
+ * Formats the report as plain text, one line per rule plus its failures
+ */
+export function formatConformance(report: ConformanceReport): string {
+  const lines = [`${report.architecture}: ${report.score}/100 ${report.conforms ? 'conforms' : 'does not conform'}`]
+  for (const check of report.checks) {
+    lines.push(`  ${check.score === 1 ? 'PASS' : 'FAIL'} ${check.rule} (${Math.round(check.score * 100)}%)`)
+    for (const failure of check.failures) lines.push(`    - ${failure}`)
+  }
+  return lines.join('\n')
+}
+
+function formatRatio(ratio: number): string {
+  return `${Math.round(ratio * 100) / 100}:1`
+}
@@ -0,0 +1,66 @@
+/**
+ * Report Units Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { REPORT_UNIT_PRESETS, formatBandwidth, formatLength, formatPower, reportUnits } from './report-units'
+import { formatScenarioMatrix, type ScenarioMatrix } from './scenarios'
+import { formatTenantBandwidth } from './tenant-bandwidth'
+import { renderCablingCsv, type CablingRow } from '../io/cabling-map'
+
+const us = REPORT_UNIT_PRESETS.us
+
+describe('report units', () => {
+  it('keeps the historical plain output by default', () => {
+    expect(formatBandwidth(1200)).toBe('1200 Gbps')
+    expect(formatPower(1250)).toBe('1250 W')
+    expect(formatLength(2.25)).toBe('2.3 m')
+  })
+
+  it('converts to the selected units', () => {
+    expect(formatBandwidth(100, { ...us, bandwidth: 'GB/s' })).toBe('12.5 GB/s')
+    expect(formatPower(350, us)).toBe('1,194 BTU/h')
+    expect(formatLength(3, us)).toBe('9.8 ft')
+  })
+
+  it('localizes grouping and decimals', () => {
+    const { units, errors } = reportUnits('metric', { locale: 'de-DE', bandwidth: 'GB/s' })
+    expect(errors).toEqual([])
+    expect(formatBandwidth(12345, units)).toBe('1.543,125 GB/s')
+    expect(formatLength(1.5, units)).toBe('1,5 m')
+  })
+
+  it('reports unknown presets, units and locales', () => {
+    expect(reportUnits('imperial').errors).toEqual(['Unknown unit preset imperial; expected metric or us'])
+    expect(reportUnits('us', { power: 'kW' as never, locale: 'not a locale!' }).errors).toEqual([
+      'Unknown power unit kW; expected W or BTU/h',
+      'Invalid locale not a locale!'
+    ])
+  })
+
+  it('formats each report in the units chosen for that export', () => {
+    const matrix: ScenarioMatrix = {
+      project: 'p',
+      scenarios: [{ id: 'a', name: 'A', cost: 1000, maxOversubscription: 2.5, powerWatts: 1200, endpointHeadroom: 0.5, spineHeadroom: 0.25 }],
+      best: { cost: ['a'], maxOversubscription: ['a'], powerWatts: ['a'], endpointHeadroom: ['a'], spineHeadroom: ['a'] },
+      errors: [],
+      warnings: []
+    }
+    expect(formatScenarioMatrix(matrix)).toContain('| Power | 1200 W * |')
+    expect(formatScenarioMatrix(matrix, us)).toContain('| Power | 4,095 BTU/h * |')
+
+    const tenants = { tenants: [], unassignedGbps: 0, totalGbps: 400, warnings: [] }
+    expect(formatTenantBandwidth(tenants)).toBe('total: 400 Gbps')
+    expect(formatTenantBandwidth(tenants, { ...us, bandwidth: 'GB/s' })).toBe('total: 50 GB/s')
+
+    const rows: CablingRow[] = [
+      { cable: 'c1', type: 'uplink', fromDevice: 'leaf-1', fromPort: 'E1/49', toDevice: 'spine-1', toPort: 'E1/1', lengthM: 1200.5, labels: {} },
+      { cable: 'c2', type: 'uplink', fromDevice: 'leaf-1', fromPort: 'E1/50', toDevice: 'spine-2', toPort: 'E1/1', labels: {} }
+    ]
+    const csv = renderCablingCsv(rows, us).split('\n')
+    expect(csv[0]).toBe('cable,type,from_device,from_port,to_device,to_port,length_ft')
+    expect(csv[1]).toBe('c1,uplink,leaf-1,E1/49,spine-1,E1/1,3938.6') // never grouped, even with a locale
+    expect(csv[2]).toBe('c2,uplink,leaf-1,E1/50,spine-2,E1/1,')
+    expect(renderCablingCsv(rows.slice(1))).toBe('cable,type,from_device,from_port,to_device,to_port\nc2,uplink,leaf-1,E1/50,spine-2,E1/1\n')
+  })
+})
@@ -0,0 +1,76 @@
+/**
+ * Report Units - HNC v0.6
+ * Bandwidth, power and length formatting for reports. Values are always
+ * computed in Gbps, watts and meters; each export picks the units and the
+ * number locale its readers expect.
+ */
+
+export type BandwidthUnit = 'Gbps' | 'GB/s'
+export type PowerUnit = 'W' | 'BTU/h'
+export type LengthUnit = 'm' | 'ft'
+
+export interface ReportUnits {
+  bandwidth: BandwidthUnit
+  power: PowerUnit
+  length: LengthUnit
+  locale?: string // BCP 47 tag, e.g. 'de-DE'; unset prints plain numbers
+}
+
+export const DEFAULT_REPORT_UNITS: ReportUnits = { bandwidth: 'Gbps', power: 'W', length: 'm' }
+
+export const REPORT_UNIT_PRESETS: Record<'metric' | 'us', ReportUnits> = {
+  metric: DEFAULT_REPORT_UNITS,
+  us: { bandwidth: 'Gbps', power: 'BTU/h', length: 'ft', locale: 'en-US' }
+}
+
+const WATTS_TO_BTU_PER_HOUR = 3.412142
+const METERS_PER_FOOT = 0.3048
+
+/**
+ * Units from a preset name with individual overrides, e.g.
+ * reportUnits('metric', { locale: 'fr-FR' }); unknown names are reported
+ */
+export function reportUnits(preset: string = 'metric', overrides: Partial<ReportUnits> = {}): { units: ReportUnits; errors: string[] } {
+  const errors: string[] = []
+  const base = REPORT_UNIT_PRESETS[preset as keyof typeof REPORT_UNIT_PRESETS]
+  if (!base) errors.push(`Unknown unit preset ${preset}; expected ${Object.keys(REPORT_UNIT_PRESETS).join(' or ')}`)
+  const units = { ...(base ?? DEFAULT_REPORT_UNITS), ...overrides }
+
+  if (!['Gbps', 'GB/s'].includes(units.bandwidth)) errors.push(`Unknown bandwidth unit ${units.bandwidth}; expected Gbps or GB/s`)
+  if (!['W', 'BTU/h'].includes(units.power)) errors.push(`Unknown power unit ${units.power}; expected W or BTU/h`)
+  if (!['m', 'ft'].includes(units.length)) errors.push(`Unknown length unit ${units.length}; expected m or ft`)
+  if (units.locale !== undefined) {
+    try {
+      new Intl.NumberFormat(units.locale)
+    } catch {
+      errors.push(`Invalid locale ${units.locale}`)
+    }
+  }
+  return { units, errors }
+}
+
+export const convertBandwidth = (gbps: number, unit: BandwidthUnit): number => unit === 'GB/s' ? gbps / 8 : gbps
+
+export const convertPower = (watts: number, unit: PowerUnit): number => unit === 'BTU/h' ? watts * WATTS_TO_BTU_PER_HOUR : watts
+
+export const convertLength = (meters: number, unit: LengthUnit): number => unit === 'ft' ? meters / METERS_PER_FOOT : meters
+
+export function formatBandwidth(gbps: number, units: ReportUnits = DEFAULT_REPORT_UNITS): string {
+  return `${formatNumber(convertBandwidth(gbps, units.bandwidth), 3, units.locale)} ${units.bandwidth}`
+}
+
+export function formatPower(watts: number, units: ReportUnits = DEFAULT_REPORT_UNITS): string {
+  return `${formatNumber(convertPower(watts, units.power), 0, units.locale)} ${units.power}`
+}
+
+export function formatLength(meters: number, units: ReportUnits = DEFAULT_REPORT_UNITS): string {
+  return `${formatNumber(convertLength(meters, units.length), 1, units.locale)} ${units.length}`
+}
+
+/**
+ * Rounds to `digits` decimals; grouped and localized only when a locale is set
+ */
+export function formatNumber(value: number, digits: number, locale?: string): string {
+  if (locale === undefined) return String(Math.round(value * 10 ** digits) / 10 ** digits)
+  return new Intl.NumberFormat(locale, { maximumFractionDigits: digits }).format(value)
+}
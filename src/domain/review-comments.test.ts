@@ -0,0 +1,93 @@
+/**
+ * Design Review Comments Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import {
+  addReviewComment,
+  commentsOn,
+  connectionTarget,
+  openComments,
+  orphanedComments,
+  parseReviewComments,
+  parseTarget,
+  resolveReviewComment,
+  type ReviewComment,
+  type ReviewTarget
+} from './review-comments'
+import type { WiringDiagram } from '../app.types'
+
+const now = new Date('2026-03-01T12:00:00Z')
+
+const diagram: WiringDiagram = {
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+    leaves: [{ id: 'leaf-1', model: 'DS2000', ports: 56 }],
+    servers: []
+  },
+  connections: [{ from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' }],
+  metadata: { generatedAt: new Date(0), fabricName: 'review', totalDevices: 2 }
+}
+
+describe('review comments', () => {
+  it('reads targets as written on the command line', () => {
+    expect(parseTarget('device:leaf-1')).toEqual({ kind: 'device', id: 'leaf-1' })
+    expect(parseTarget('connection:leaf-1:E1/49 -> spine-1:E1/1')).toEqual({ kind: 'connection', id: connectionTarget(diagram.connections[0]) })
+    expect(parseTarget('subnet:leaf-1 loopback')).toEqual({ kind: 'subnet', id: 'leaf-1 loopback' })
+    expect(parseTarget('rack:r1')).toBeUndefined()
+    expect(parseTarget('device:')).toBeUndefined()
+  })
+
+  it('numbers comments and threads replies onto their target', () => {
+    const first = addReviewComment([], { target: { kind: 'device', id: 'leaf-1' }, author: 'ana', body: ' Why DS2000? ' }, now)
+    const reply = addReviewComment(first.comments, { author: 'bo', body: 'Stock', replyTo: 'c1' }, now)
+
+    expect(first.comment).toEqual({ id: 'c1', target: { kind: 'device', id: 'leaf-1' }, author: 'ana', body: 'Why DS2000?', createdAt: now.toISOString() })
+    expect(reply.comment).toMatchObject({ id: 'c2', target: { kind: 'device', id: 'leaf-1' }, replyTo: 'c1' })
+    expect(first.comments).toHaveLength(1)
+    expect(commentsOn(reply.comments, { kind: 'device', id: 'leaf-1' })).toHaveLength(2)
+    expect(addReviewComment([], { author: 'bo', body: 'x', replyTo: 'c9' }).error).toBe('No comment c9 to reply to')
+    expect(addReviewComment([], { target: { kind: 'device', id: 'leaf-1' }, author: 'bo', body: ' ' }).error).toBe('A comment needs a body')
+  })
+
+  it('resolves a whole thread', () => {
+    let comments = addReviewComment([], { target: { kind: 'subnet', id: 'vrf-a' }, author: 'ana', body: 'Too small' }, now).comments
+    comments = addReviewComment(comments, { author: 'bo', body: 'Widened', replyTo: 'c1' }, now).comments
+    comments = addReviewComment(comments, { target: { kind: 'device', id: 'leaf-1' }, author: 'ana', body: 'OK' }, now).comments
+
+    const resolved = resolveReviewComment(comments, 'c2', 'ana', now).comments
+    expect(openComments(resolved).map(c => c.id)).toEqual(['c3'])
+    expect(resolved[0]).toMatchObject({ resolvedAt: now.toISOString(), resolvedBy: 'ana' })
+    expect(resolveReviewComment(comments, 'c7', 'ana').error).toBe('No comment c7')
+  })
+
+  it('drops malformed comments with a reason', () => {
+    const { comments, errors } = parseReviewComments({
+      comments: [
+        { id: 'c1', target: { kind: 'device', id: 'leaf-1' }, author: 'ana', body: 'ok', createdAt: '2026-01-01' },
+        { id: 'c1', target: { kind: 'rack', id: 'r1' }, author: 'ana', body: 'dup', createdAt: '2026-01-01' },
+        { target: { kind: 'device', id: 'leaf-1' }, body: 'anonymous', createdAt: '2026-01-01', replyTo: 'c5' }
+      ]
+    })
+    expect(comments.map(c => c.id)).toEqual(['c1'])
+    expect(errors).toEqual([
+      'comment c1: defined more than once; target must be a device, connection or subnet with an id',
+      'comments[2]: id is required; author is required; replyTo must name an earlier comment'
+    ])
+    expect(parseReviewComments({}).errors).toEqual(['comments must be a list'])
+  })
+
+  it('finds comments whose object left the design', () => {
+    const targets: ReviewTarget[] = [
+      { kind: 'device', id: 'leaf-1' },
+      { kind: 'device', id: 'leaf-2' },
+      { kind: 'connection', id: 'leaf-1:E1/49 -> spine-1:E1/1' },
+      { kind: 'connection', id: 'leaf-1:E1/50 -> spine-1:E1/1' },
+      { kind: 'subnet', id: 'vrf-a' }
+    ]
+    const comments = targets.reduce<ReviewComment[]>((list, target) => addReviewComment(list, { target, author: 'ana', body: 'x' }, now).comments, [])
+
+    expect(orphanedComments(comments, diagram).map(c => c.id)).toEqual(['c2', 'c4'])
+    expect(orphanedComments(comments, diagram, { 'vrf-b': '10.2.0.0/24' }).map(c => c.id)).toEqual(['c2', 'c4', 'c5'])
+  })
+})
@@ -0,0 +1,168 @@
+/**
+ * Design Review Comments - HNC v0.6
+ * Review feedback attached to the design objects it is about (a switch,
+ * a connection, a subnet), so it travels with the design and shows up in
+ * comparisons and reports instead of living in chat threads. Until a
+ * server holds them, comments are kept next to the design, in
+ * review-comments.json inside its FGD directory.
+ */
+
+import type { WiringConnection, WiringDiagram } from '../app.types'
+
+export const REVIEW_COMMENTS_FILE = 'review-comments.json'
+
+export type ReviewTargetKind = 'device' | 'connection' | 'subnet'
+
+export interface ReviewTarget {
+  kind: ReviewTargetKind
+  /** Device id, connection key (see connectionTarget) or addressing name */
+  id: string
+}
+
+export interface ReviewComment {
+  id: string
+  target: ReviewTarget
+  author: string
+  body: string
+  createdAt: string
+  /** Comment this one answers; replies share their thread's target */
+  replyTo?: string
+  resolvedAt?: string
+  resolvedBy?: string
+}
+
+const KINDS: ReviewTargetKind[] = ['device', 'connection', 'subnet']
+
+/** The target id of a connection: leaf-1:E1/49 -> spine-1:E1/1 */
+export function connectionTarget(c: Pick<WiringConnection, 'from' | 'to'>): string {
+  return `${c.from.device}:${c.from.port} -> ${c.to.device}:${c.to.port}`
+}
+
+/**
+ * Reads "kind:id", as written on the command line, e.g. device:leaf-1
+ */
+export function parseTarget(text: string): ReviewTarget | undefined {
+  const i = text.indexOf(':')
+  const kind = text.slice(0, i) as ReviewTargetKind
+  const id = text.slice(i + 1).trim()
+  return i > 0 && KINDS.includes(kind) && id !== '' ? { kind, id } : undefined
+}
+
+export function formatTarget(target: ReviewTarget): string {
+  return `${target.kind}:${target.id}`
+}
+
+/**
+ * Parses { "comments": [...] }, dropping entries that are malformed
+ */
+export function parseReviewComments(raw: unknown): { comments: ReviewComment[]; errors: string[] } {
+  const errors: string[] = []
+  const list = (raw as { comments?: unknown } | undefined)?.comments
+  if (!Array.isArray(list)) return { comments: [], errors: ['comments must be a list'] }
+
+  const comments: ReviewComment[] = []
+  const ids = new Set<string>()
+  list.forEach((entry, i) => {
+    const c = (entry ?? {}) as Record<string, unknown>
+    const where = typeof c.id === 'string' && c.id !== '' ? `comment ${c.id}` : `comments[${i}]`
+    const target = c.target as Partial<ReviewTarget> | undefined
+    const problems = [
+      ...(typeof c.id !== 'string' || c.id === '' ? ['id is required'] : []),
+      ...(typeof c.id === 'string' && ids.has(c.id) ? ['defined more than once'] : []),
+      ...(!target || !KINDS.includes(target.kind as ReviewTargetKind) || typeof target.id !== 'string' || target.id === ''
+        ? ['target must be a device, connection or subnet with an id'] : []),
+      ...(['author', 'body', 'createdAt'] as const).filter(f => typeof c[f] !== 'string' || c[f] === '').map(f => `${f} is required`),
+      ...(c.replyTo !== undefined && (typeof c.replyTo !== 'string' || !ids.has(c.replyTo)) ? ['replyTo must name an earlier comment'] : [])
+    ]
+    if (problems.length > 0) {
+      errors.push(`${where}: ${problems.join('; ')}`)
+      return
+    }
+    ids.add(c.id as string)
+    comments.push(entry as ReviewComment)
+  })
+  return { comments, errors }
+}
+
+/**
+ * Appends a comment with the next free id (c1, c2, ...); a reply takes the
+ * target of the comment it answers
+ */
+export function addReviewComment(
+  comments: ReviewComment[],
+  input: { target?: ReviewTarget; author: string; body: string; replyTo?: string },
+  now = new Date()
+): { comments: ReviewComment[]; comment?: ReviewComment; error?: string } {
+  const parent = input.replyTo !== undefined ? comments.find(c => c.id === input.replyTo) : undefined
+  if (input.replyTo !== undefined && !parent) return { comments, error: `No comment ${input.replyTo} to reply to` }
+  const target = parent?.target ?? input.target
+  if (!target) return { comments, error: 'A comment needs a target' }
+  if (input.body.trim() === '') return { comments, error: 'A comment needs a body' }
+
+  const next = Math.max(0, ...comments.map(c => Number(/^c(\d+)$/.exec(c.id)?.[1] ?? 0))) + 1
+  const comment: ReviewComment = {
+    id: `c${next}`,
+    target,
+    author: input.author,
+    body: input.body.trim(),
+    createdAt: now.toISOString(),
+    ...(parent && { replyTo: parent.id })
+  }
+  return { comments: [...comments, comment], comment }
+}
+
+/**
+ * Marks a thread resolved: the comment, its thread root and every reply
+ */
+export function resolveReviewComment(
+  comments: ReviewComment[],
+  id: string,
+  by: string,
+  now = new Date()
+): { comments: ReviewComment[]; error?: string } {
+  const comment = comments.find(c => c.id === id)
+  if (!comment) return { comments, error: `No comment ${id}` }
+  const root = threadRoot(comments, comment)
+  return {
+    comments: comments.map(c => threadRoot(comments, c).id === root.id && !c.resolvedAt
+      ? { ...c, resolvedAt: now.toISOString(), resolvedBy: by }
+      : c)
+  }
+}
+
+function threadRoot(comments: ReviewComment[], comment: ReviewComment): ReviewComment {
+  let root = comment
+  const seen = new Set<string>()
+  while (root.replyTo && !seen.has(root.id)) {
+    seen.add(root.id)
+    root = comments.find(c => c.id === root.replyTo) ?? root
+  }
+  return root
+}
+
+export function openComments(comments: ReviewComment[]): ReviewComment[] {
+  return comments.filter(c => !c.resolvedAt)
+}
+
+export function commentsOn(comments: ReviewComment[], target: ReviewTarget): ReviewComment[] {
+  return comments.filter(c => c.target.kind === target.kind && c.target.id === target.id)
+}
+
+/**
+ * Comments whose device or connection is no longer in the design, e.g.
+ * after the switch was removed; subnets are checked only when the
+ * addressing is given
+ */
+export function orphanedComments(
+  comments: ReviewComment[],
+  diagram: WiringDiagram,
+  addressing?: Record<string, string>
+): ReviewComment[] {
+  const devices = new Set([...diagram.devices.spines, ...diagram.devices.leaves, ...diagram.devices.servers].map(d => d.id))
+  const connections = new Set(diagram.connections.map(connectionTarget))
+  return comments.filter(({ target }) => {
+    if (target.kind === 'device') return !devices.has(target.id)
+    if (target.kind === 'connection') return !connections.has(target.id)
+    return addressing !== undefined && !(target.id in addressing)
+  })
+}
@@ -0,0 +1,71 @@
+/**
+ * Lossless / RoCE Path Qualification Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { qualifyLosslessPaths } from './roce-qualification'
+import type { Wiring, WiringConnection } from './wiring'
+
+function fixture(): Wiring {
+  const connections: WiringConnection[] = [
+    { id: 'u1', from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' },
+    { id: 'u2', from: { device: 'leaf-2', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/2' }, type: 'uplink' },
+    { id: 'e1', from: { device: 'gpu-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/2' }, type: 'endpoint' },
+    { id: 'e2', from: { device: 'web-1', port: 'eth0' }, to: { device: 'leaf-2', port: 'E1/1' }, type: 'endpoint' }
+  ]
+  return {
+    devices: {
+      spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32 }],
+      leaves: [
+        { id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 48 },
+        { id: 'leaf-2', type: 'leaf', modelId: 'DS1000', ports: 48 }
+      ],
+      servers: [
+        { id: 'gpu-1', type: 'server', modelId: 'compute', ports: 1, classId: 'gpu' },
+        { id: 'web-1', type: 'server', modelId: 'server', ports: 1, classId: 'web' }
+      ]
+    },
+    connections,
+    metadata: { fabricName: 't', fabricId: 't', generatedAt: new Date(0), totalDevices: 5, totalConnections: 4 }
+  }
+}
+
+const capable = { pfc: true, ecn: true, bufferMB: 32 }
+
+describe('qualifyLosslessPaths', () => {
+  it('qualifies only devices on lossless paths', () => {
+    const result = qualifyLosslessPaths(fixture(), {
+      losslessClasses: ['gpu'],
+      capabilities: { DS2000: capable, DS3000: capable }
+    })
+
+    expect(result.losslessServers).toEqual(['gpu-1'])
+    expect(result.devices.map(d => d.device)).toEqual(['leaf-1', 'spine-1'])
+    expect(result.qualified).toBe(true)
+  })
+
+  it('rejects hardware without PFC or enough buffer', () => {
+    const result = qualifyLosslessPaths(fixture(), {
+      losslessClasses: ['gpu'],
+      capabilities: { DS2000: { pfc: false, ecn: true, bufferMB: 8 }, DS3000: capable }
+    })
+
+    expect(result.qualified).toBe(false)
+    expect(result.errors).toEqual([
+      'leaf-1: DS2000 does not support PFC',
+      'leaf-1: DS2000 buffer 8MB is below the 16MB minimum'
+    ])
+  })
+
+  it('emits PFC/ECN intent per device', () => {
+    const result = qualifyLosslessPaths(fixture(), {
+      losslessClasses: ['gpu'],
+      capabilities: { DS2000: capable, DS3000: capable },
+      pfcPriority: 4
+    })
+    const leaf = result.intents.find(i => i.device === 'leaf-1')!
+
+    expect(leaf.pfc).toEqual({ priorities: [4], ports: ['E1/2', 'E1/49'] })
+    expect(result.intents.find(i => i.device === 'spine-1')!.ecn.ports).toEqual(['E1/1'])
+  })
+})
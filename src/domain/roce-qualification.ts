@@ -0,0 +1,141 @@
+/**
+ * Lossless / RoCE Path Qualification - HNC v0.6
+ * Verifies that every switch on a lossless server class's paths supports
+ * PFC/ECN with enough buffer, and emits per-device PFC/ECN config intent
+ */
+
+import type { Wiring } from './wiring'
+
+export interface LosslessCapability {
+  pfc: boolean
+  ecn: boolean
+  bufferMB: number
+}
+
+export interface RoceQualificationOptions {
+  // Server class ids carrying RoCE/storage traffic
+  losslessClasses: string[]
+  // Capability per switch modelId; models not listed are treated as incapable
+  capabilities: Record<string, LosslessCapability>
+  // Treat servers whose endpoint type is 'storage' as lossless (default: true)
+  includeStorageEndpoints?: boolean
+  minBufferMB?: number   // default: 16
+  pfcPriority?: number   // default: 3
+  ecnMinKB?: number      // default: 150
+  ecnMaxKB?: number      // default: 1500
+}
+
+export interface DeviceQualification {
+  device: string
+  modelId: string
+  role: 'spine' | 'leaf'
+  qualified: boolean
+  reasons: string[]
+}
+
+export interface LosslessDeviceIntent {
+  device: string
+  pfc: { priorities: number[]; ports: string[] }
+  ecn: { minThresholdKB: number; maxThresholdKB: number; ports: string[] }
+}
+
+export interface RoceQualificationResult {
+  losslessServers: string[]
+  devices: DeviceQualification[]
+  qualified: boolean
+  errors: string[]
+  intents: LosslessDeviceIntent[]
+}
+
+/**
+ * Qualifies the lossless paths of the given wiring.
+ * With ECMP every uplink of a lossless leaf may carry the traffic, so all
+ * spines reachable from those leaves are on the path.
+ */
+export function qualifyLosslessPaths(wiring: Wiring, options: RoceQualificationOptions): RoceQualificationResult {
+  const {
+    losslessClasses,
+    capabilities,
+    includeStorageEndpoints = true,
+    minBufferMB = 16,
+    pfcPriority = 3,
+    ecnMinKB = 150,
+    ecnMaxKB = 1500
+  } = options
+
+  const classes = new Set(losslessClasses)
+  const losslessServers = wiring.devices.servers
+    .filter(s => (s.classId && classes.has(s.classId)) || (includeStorageEndpoints && s.modelId === 'storage'))
+    .map(s => s.id)
+    .sort()
+  const serverSet = new Set(losslessServers)
+
+  const leafIds = new Set(wiring.devices.leaves.map(l => l.id))
+  const spineIds = new Set(wiring.devices.spines.map(s => s.id))
+  const ports = new Map<string, Set<string>>()
+  const addPort = (device: string, port: string) => {
+    const set = ports.get(device) || new Set<string>()
+    set.add(port)
+    ports.set(device, set)
+  }
+
+  // Server-facing ports on leaves hosting lossless servers
+  for (const conn of wiring.connections) {
+    if (conn.type === 'endpoint' && serverSet.has(conn.from.device) && leafIds.has(conn.to.device)) {
+      addPort(conn.to.device, conn.to.port)
+    }
+  }
+  const losslessLeaves = new Set(ports.keys())
+
+  // Both ends of every uplink leaving a lossless leaf
+  for (const conn of wiring.connections) {
+    if (conn.type === 'uplink' && losslessLeaves.has(conn.from.device) && spineIds.has(conn.to.device)) {
+      addPort(conn.from.device, conn.from.port)
+      addPort(conn.to.device, conn.to.port)
+    }
+  }
+
+  const models = new Map([...wiring.devices.spines, ...wiring.devices.leaves].map(d => [d.id, d.modelId]))
+  const devices: DeviceQualification[] = [...ports.keys()].sort().map(device => {
+    const modelId = models.get(device) || 'unknown'
+    const cap = capabilities[modelId]
+    const reasons: string[] = []
+    if (!cap) {
+      reasons.push(`No lossless capability data for model ${modelId}`)
+    } else {
+      if (!cap.pfc) reasons.push(`${modelId} does not support PFC`)
+      if (!cap.ecn) reasons.push(`${modelId} does not support ECN`)
+      if (cap.bufferMB < minBufferMB) reasons.push(`${modelId} buffer ${cap.bufferMB}MB is below the ${minBufferMB}MB minimum`)
+    }
+    return {
+      device,
+      modelId,
+      role: spineIds.has(device) ? 'spine' as const : 'leaf' as const,
+      qualified: reasons.length === 0,
+      reasons
+    }
+  })
+
+  const errors = devices
+    .filter(d => !d.qualified)
+    .flatMap(d => d.reasons.map(r => `${d.device}: ${r}`))
+
+  const intents: LosslessDeviceIntent[] = devices.map(d => {
+    const devicePorts = [...ports.get(d.device)!].sort(comparePorts)
+    return {
+      device: d.device,
+      pfc: { priorities: [pfcPriority], ports: devicePorts },
+      ecn: { minThresholdKB: ecnMinKB, maxThresholdKB: ecnMaxKB, ports: devicePorts }
+    }
+  })
+
+  return {
+    losslessServers,
+    devices,
+    qualified: errors.length === 0,
+    errors,
+    intents
+  }
+}
+
+const comparePorts = (a: string, b: string) => a.localeCompare(b, undefined, { numeric: true })
@@ -0,0 +1,59 @@
+/**
+ * Design Scenarios Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { compareScenarios, formatScenarioMatrix } from './scenarios'
+import { buildWiring } from './wiring'
+import { testProfiles, testSpec, testAllocation } from '../fixtures/testFabric'
+
+const profiles = testProfiles(['E1/1-10'], ['E1/11-12'])
+
+const spec = testSpec({ name: 'Scenario Fabric', endpointCount: 8 })
+
+// Two leaves vs. one leaf; every leaf has one uplink to each of the two spines
+const twoLeaves = testAllocation(2, ['E1/11', 'E1/12'])
+const oneLeaf = testAllocation(1, ['E1/11', 'E1/12'])
+
+describe('compareScenarios', () => {
+  const project = {
+    name: 'DC1 refresh',
+    inventory: { server: 8 },
+    scenarios: [
+      { id: 'a', name: 'Two leaves', wiring: buildWiring(spec, profiles, twoLeaves) },
+      { id: 'b', name: 'One leaf', wiring: buildWiring(spec, profiles, oneLeaf) }
+    ]
+  }
+
+  it('computes the comparison matrix across scenarios', () => {
+    const matrix = compareScenarios(project, { profiles })
+    expect(matrix.errors).toEqual([])
+    const [a, b] = matrix.scenarios
+
+    expect(a.powerWatts).toBe(2 * 350 + 2 * 250)
+    expect(b.powerWatts).toBe(2 * 350 + 250)
+    // The wiring fills leaf-1 first, so both worst leaves carry 8 x 25G over 2 x 100G
+    expect(a.maxOversubscription).toBe(1)
+    expect(b.maxOversubscription).toBe(1)
+    expect(a.endpointHeadroom).toBe(0.6) // 12 of 20 ports free
+    expect(b.endpointHeadroom).toBe(0.2)
+    expect(a.cost).toBeGreaterThan(b.cost)
+    expect(matrix.best).toEqual({
+      cost: ['b'],
+      maxOversubscription: ['a', 'b'],
+      powerWatts: ['b'],
+      endpointHeadroom: ['a'],
+      spineHeadroom: ['b']
+    })
+    expect(formatScenarioMatrix(matrix).split('\n')).toContain('| Power | 1200 W | 950 W * |')
+  })
+
+  it('leaves out scenarios that do not serve the shared inventory', () => {
+    const matrix = compareScenarios({ ...project, inventory: { server: 8, storage: 2 } }, { profiles, switchPowerWatts: { DS3000: 350 } })
+    expect(matrix.scenarios).toEqual([])
+    expect(matrix.errors).toEqual([
+      'Scenario a: has 0 storage endpoints, inventory has 2',
+      'Scenario b: has 0 storage endpoints, inventory has 2'
+    ])
+  })
+})
@@ -0,0 +1,160 @@
+/**
+ * Design Scenarios - HNC v0.6
+ * Groups candidate designs for one project that must all serve the same
+ * endpoint inventory, and compares them side by side on cost,
+ * oversubscription, power and port headroom.
+ */
+
+import { compileBOM } from './bom-compiler'
+import { evaluateSpareCapacity } from './spare-capacity'
+import { linkSpeedGbps } from './uplink-speeds'
+import { DEFAULT_REPORT_UNITS, formatNumber, formatPower, type ReportUnits } from './report-units'
+import { wiringToWiringDiagram, type Wiring } from './wiring'
+import type { SwitchProfile } from '../app.types'
+
+export interface ScenarioProject {
+  name: string
+  inventory: Record<string, number> // endpoint type (server modelId) -> count
+  scenarios: Array<{ id: string; name?: string; wiring: Wiring }>
+}
+
+export interface ScenarioOptions {
+  profiles: Map<string, SwitchProfile>
+  switchPowerWatts?: Record<string, number> // modelId -> typical watts
+}
+
+export interface ScenarioMetrics {
+  id: string
+  name: string
+  cost: number
+  maxOversubscription: number // worst leaf, endpoint Gbps : uplink Gbps
+  powerWatts: number
+  endpointHeadroom: number // share of leaf endpoint ports still free, 0-1
+  spineHeadroom: number // share of spine fabric ports still free, 0-1
+}
+
+export type ScenarioMetric = Exclude<keyof ScenarioMetrics, 'id' | 'name'>
+
+export interface ScenarioMatrix {
+  project: string
+  scenarios: ScenarioMetrics[]
+  best: Record<ScenarioMetric, string[]> // scenario ids, ties included
+  errors: string[]
+  warnings: string[]
+}
+
+// Typical draw per switch model, matching the dual-fabric BOM estimates
+export const DEFAULT_SWITCH_POWER_WATTS: Record<string, number> = {
+  DS3000: 350,
+  DS2000: 250
+}
+
+const LOWER_IS_BETTER: Record<ScenarioMetric, boolean> = {
+  cost: true,
+  maxOversubscription: true,
+  powerWatts: true,
+  endpointHeadroom: false,
+  spineHeadroom: false
+}
+
+/**
+ * Checks every scenario serves the shared inventory and computes the
+ * comparison matrix. Scenarios that do not match the inventory are
+ * reported and left out of the matrix.
+ */
+export function compareScenarios(project: ScenarioProject, options: ScenarioOptions): ScenarioMatrix {
+  const { profiles, switchPowerWatts = DEFAULT_SWITCH_POWER_WATTS } = options
+  const errors: string[] = []
+  const warnings: string[] = []
+  const scenarios: ScenarioMetrics[] = []
+
+  for (const scenario of project.scenarios) {
+    const mismatch = inventoryMismatch(project.inventory, scenario.wiring)
+    if (mismatch.length > 0) {
+      errors.push(...mismatch.map(m => `Scenario ${scenario.id}: ${m}`))
+      continue
+    }
+
+    const { wiring } = scenario
+    const switches = [...wiring.devices.spines, ...wiring.devices.leaves]
+    const unknownPower = [...new Set(switches.map(s => s.modelId).filter(m => switchPowerWatts[m] === undefined))]
+    if (unknownPower.length > 0) {
+      warnings.push(`Scenario ${scenario.id}: no power figure for ${unknownPower.join(', ')}; counted as 0 W`)
+    }
+
+    const spare = evaluateSpareCapacity(wiring, profiles, {})
+    errors.push(...spare.errors.map(e => `Scenario ${scenario.id}: ${e}`))
+
+    scenarios.push({
+      id: scenario.id,
+      name: scenario.name ?? scenario.id,
+      cost: compileBOM(wiringToWiringDiagram(wiring)).summary.totalCost,
+      maxOversubscription: maxOversubscription(wiring, profiles),
+      powerWatts: switches.reduce((sum, s) => sum + (switchPowerWatts[s.modelId] ?? 0), 0),
+      endpointHeadroom: headroom(spare.leaves),
+      spineHeadroom: headroom(spare.spines)
+    })
+  }
+
+  const best = Object.fromEntries(
+    (Object.keys(LOWER_IS_BETTER) as ScenarioMetric[]).map(metric => {
+      const values = scenarios.map(s => s[metric])
+      const target = LOWER_IS_BETTER[metric] ? Math.min(...values) : Math.max(...values)
+      return [metric, scenarios.filter(s => s[metric] === target).map(s => s.id)]
+    })
+  ) as Record<ScenarioMetric, string[]>
+
+  return { project: project.name, scenarios, best, errors, warnings }
+}
+
+/**
+ * Formats the matrix as a Markdown table, one column per scenario, with
+ * the best value in each row marked
+ */
+export function formatScenarioMatrix(matrix: ScenarioMatrix, units: ReportUnits = DEFAULT_REPORT_UNITS): string {
+  const rows: Array<[string, ScenarioMetric, (v: number) => string]> = [
+    ['Cost', 'cost', v => `$${v.toLocaleString(units.locale ?? 'en-US')}`],
+    ['Max oversubscription', 'maxOversubscription', v => `${formatNumber(v, 2, units.locale)}:1`],
+    ['Power', 'powerWatts', v => formatPower(v, units)],
+    ['Endpoint port headroom', 'endpointHeadroom', v => `${Math.round(v * 100)}%`],
+    ['Spine port headroom', 'spineHeadroom', v => `${Math.round(v * 100)}%`]
+  ]
+  const header = `| Metric | ${matrix.scenarios.map(s => s.name).join(' | ')} |`
+  const divider = `|---|${matrix.scenarios.map(() => '---').join('|')}|`
+  const body = rows.map(([label, metric, format]) =>
+    `| ${label} | ${matrix.scenarios.map(s => `${format(s[metric])}${matrix.best[metric].includes(s.id) ? ' *' : ''}`).join(' | ')} |`
+  )
+  return [`## ${matrix.project}`, '', header, divider, ...body, '', '\\* best'].join('\n')
+}
+
+function inventoryMismatch(inventory: Record<string, number>, wiring: Wiring): string[] {
+  const counts = new Map<string, number>()
+  for (const server of wiring.devices.servers) {
+    counts.set(server.modelId, (counts.get(server.modelId) || 0) + 1)
+  }
+  const types = [...new Set([...Object.keys(inventory), ...counts.keys()])].sort()
+  return types
+    .filter(type => (inventory[type] || 0) !== (counts.get(type) || 0))
+    .map(type => `has ${counts.get(type) || 0} ${type} endpoints, inventory has ${inventory[type] || 0}`)
+}
+
+/**
+ * Endpoint to uplink bandwidth of the most oversubscribed leaf, 0 when no
+ * leaf has uplinks
+ */
+export function maxOversubscription(wiring: Wiring, profiles: Map<string, SwitchProfile>): number {
+  const ratios = wiring.devices.leaves.map(leaf => {
+    const speeds = profiles.get(leaf.modelId)?.profiles
+    const down = wiring.connections.filter(c => c.type === 'endpoint' && c.to.device === leaf.id).length * (speeds?.endpoint.speedGbps ?? 0)
+    const up = wiring.connections
+      .filter(c => c.type === 'uplink' && c.from.device === leaf.id)
+      .reduce((sum, c) => sum + linkSpeedGbps(c, speeds?.uplink.speedGbps ?? 0), 0)
+    return up === 0 ? 0 : down / up
+  })
+  return Math.round(Math.max(0, ...ratios) * 100) / 100
+}
+
+function headroom(devices: Array<{ total: number; free: number }>): number {
+  const total = devices.reduce((sum, d) => sum + d.total, 0)
+  return total === 0 ? 0 : Math.round(devices.reduce((sum, d) => sum + d.free, 0) / total * 1000) / 1000
+}
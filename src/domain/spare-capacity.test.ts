@@ -0,0 +1,73 @@
+/**
+ * Spare-Capacity Policy Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { evaluateSpareCapacity, spareCapacityTrend, formatSpareCapacityTrend } from './spare-capacity'
+import { buildWiring, validateWiring } from './wiring'
+import { testProfiles, testSpec, testAllocation } from '../fixtures/testFabric'
+
+const profiles = testProfiles()
+
+// 4 leaves with 4 endpoint ports each; 2 spines with 8 fabric ports and 4 uplinks each
+const wiringFor = (endpointCount: number) =>
+  buildWiring(testSpec({ name: 'Spare Fabric', endpointCount }), profiles, testAllocation(4))
+
+describe('evaluateSpareCapacity', () => {
+  const wiring = wiringFor(8)
+
+  it('passes designs that keep exactly the required margin', () => {
+    const report = evaluateSpareCapacity(wiring, profiles, { minFreeEndpointPortsPercent: 50, minSpareUplinksPerSpine: 4 })
+    expect(report.errors).toEqual([])
+    expect(report.leaves[0]).toEqual({ deviceId: 'leaf-1', total: 4, used: 2, free: 2, required: 2, margin: 0 })
+    expect(report.spines.map(s => s.margin)).toEqual([0, 0])
+  })
+
+  it('fails designs that consume the spare margin', () => {
+    const report = evaluateSpareCapacity(wiring, profiles, { minFreeEndpointPortsPercent: 60, minSpareUplinksPerSpine: 5 })
+    expect(report.errors).toEqual([
+      'Leaf leaf-1 has 2 of 4 endpoint ports free; policy requires 3 (60%)',
+      'Leaf leaf-2 has 2 of 4 endpoint ports free; policy requires 3 (60%)',
+      'Leaf leaf-3 has 2 of 4 endpoint ports free; policy requires 3 (60%)',
+      'Leaf leaf-4 has 2 of 4 endpoint ports free; policy requires 3 (60%)',
+      'Spine spine-1 has 4 spare uplink ports; policy requires 5',
+      'Spine spine-2 has 4 spare uplink ports; policy requires 5'
+    ])
+  })
+
+  it('rejects malformed policies', () => {
+    expect(evaluateSpareCapacity(wiring, profiles, { minFreeEndpointPortsPercent: 120, minSpareUplinksPerSpine: -1 }).errors).toEqual([
+      'minFreeEndpointPortsPercent must be between 0 and 100, got 120',
+      'minSpareUplinksPerSpine must be a non-negative integer, got -1'
+    ])
+  })
+
+  it('is enforced by wiring validation when profiles are given', () => {
+    const policy = { minSpareUplinksPerSpine: 5 }
+    expect(validateWiring(wiring, { spareCapacity: policy, profiles }).errors).toHaveLength(2)
+    expect(validateWiring(wiring, { spareCapacity: policy }).warnings).toContain('Spare-capacity policy not checked: no switch profiles given')
+  })
+})
+
+describe('spareCapacityTrend', () => {
+  it('shows margin consumed revision over revision', () => {
+    // 60% of 4 ports = 3 free required; each leaf wires at most 2 endpoints
+    const policy = { minFreeEndpointPortsPercent: 60 }
+    const trend = spareCapacityTrend([
+      { revision: 'r1', report: evaluateSpareCapacity(wiringFor(4), profiles, policy) },
+      { revision: 'r2', report: evaluateSpareCapacity(wiringFor(8), profiles, policy) },
+      { revision: 'r3', report: evaluateSpareCapacity(wiringFor(6), profiles, policy) }
+    ])
+
+    expect(trend.map(p => [p.revision, p.leafMargin, p.consumed, p.violations])).toEqual([
+      ['r1', 0, 0, 2],
+      ['r2', -4, 4, 4],
+      ['r3', -2, -2, 3]
+    ])
+    expect(formatSpareCapacityTrend(trend).split('\n')).toEqual([
+      'r1  leaf margin 0  spine margin 8  tightest leaf-1 -1, spine-1 4  2 violations',
+      'r2  leaf margin -4  spine margin 8  consumed 4  tightest leaf-1 -1, spine-1 4  4 violations',
+      'r3  leaf margin -2  spine margin 8  freed 2  tightest leaf-1 -1, spine-1 4  3 violations'
+    ])
+  })
+})
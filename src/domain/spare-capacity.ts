@@ -0,0 +1,135 @@
+/**
+ * Spare-Capacity Policy - HNC v0.6
+ * Enforces a minimum share of free endpoint ports on every leaf and a
+ * minimum number of spare uplink ports on every spine, and tracks how much
+ * of that margin each saved revision consumes.
+ */
+
+import { expandPortRanges } from './portUtils'
+import type { Wiring } from './wiring'
+import type { SpareCapacityPolicy, SwitchProfile } from '../app.types'
+
+export type { SpareCapacityPolicy }
+
+export interface DeviceMargin {
+  deviceId: string
+  total: number
+  used: number
+  free: number
+  required: number // free ports the policy demands
+  margin: number // free - required; negative means the policy is violated
+}
+
+export interface SpareCapacityReport {
+  leaves: DeviceMargin[]
+  spines: DeviceMargin[]
+  errors: string[]
+}
+
+export interface MarginTrendPoint {
+  revision: string
+  leafMargin: number // summed over leaves
+  spineMargin: number // summed over spines
+  tightestLeaf?: DeviceMargin
+  tightestSpine?: DeviceMargin
+  consumed: number // margin ports lost since the previous revision
+  violations: number
+}
+
+/**
+ * Checks every leaf and spine in the wiring against the policy
+ */
+export function evaluateSpareCapacity(
+  wiring: Wiring,
+  profiles: Map<string, SwitchProfile>,
+  policy: SpareCapacityPolicy
+): SpareCapacityReport {
+  const errors = validatePolicy(policy)
+  if (errors.length > 0) return { leaves: [], spines: [], errors }
+
+  const used = new Map<string, number>()
+  for (const conn of wiring.connections) {
+    if (conn.type === 'endpoint' || conn.type === 'uplink') {
+      used.set(conn.to.device, (used.get(conn.to.device) || 0) + 1)
+    }
+  }
+
+  const margins = (devices: Wiring['devices']['leaves'], portsOf: (p: SwitchProfile) => string[], required: (total: number) => number) =>
+    devices.flatMap(device => {
+      const profile = profiles.get(device.modelId)
+      if (!profile) {
+        errors.push(`No switch profile for ${device.id} (model ${device.modelId})`)
+        return []
+      }
+      const total = expandPortRanges(portsOf(profile)).length
+      const deviceUsed = used.get(device.id) || 0
+      const free = Math.max(0, total - deviceUsed)
+      const need = Math.min(total, required(total))
+      return [{ deviceId: device.id, total, used: deviceUsed, free, required: need, margin: free - need }]
+    })
+
+  const percent = policy.minFreeEndpointPortsPercent ?? 0
+  const leaves = margins(wiring.devices.leaves, p => p.ports.endpointAssignable, total => Math.ceil(total * percent / 100))
+  const spines = margins(wiring.devices.spines, p => p.ports.fabricAssignable, () => policy.minSpareUplinksPerSpine ?? 0)
+
+  for (const leaf of leaves.filter(l => l.margin < 0)) {
+    errors.push(`Leaf ${leaf.deviceId} has ${leaf.free} of ${leaf.total} endpoint ports free; policy requires ${leaf.required} (${percent}%)`)
+  }
+  for (const spine of spines.filter(s => s.margin < 0)) {
+    errors.push(`Spine ${spine.deviceId} has ${spine.free} spare uplink ports; policy requires ${spine.required}`)
+  }
+
+  return { leaves, spines, errors }
+}
+
+/**
+ * Margin per revision, oldest first, with the ports consumed since the
+ * previous revision
+ */
+export function spareCapacityTrend(revisions: Array<{ revision: string; report: SpareCapacityReport }>): MarginTrendPoint[] {
+  let previous: number | undefined
+  return revisions.map(({ revision, report }) => {
+    const leafMargin = sum(report.leaves)
+    const spineMargin = sum(report.spines)
+    const point: MarginTrendPoint = {
+      revision,
+      leafMargin,
+      spineMargin,
+      tightestLeaf: tightest(report.leaves),
+      tightestSpine: tightest(report.spines),
+      consumed: previous === undefined ? 0 : previous - (leafMargin + spineMargin),
+      violations: [...report.leaves, ...report.spines].filter(d => d.margin < 0).length
+    }
+    previous = leafMargin + spineMargin
+    return point
+  })
+}
+
+/**
+ * Formats the trend as a plain-text table
+ */
+export function formatSpareCapacityTrend(trend: MarginTrendPoint[]): string {
+  return trend.map(p => {
+    const consumed = p.consumed === 0 ? '' : `  ${p.consumed > 0 ? 'consumed' : 'freed'} ${Math.abs(p.consumed)}`
+    const tight = [p.tightestLeaf, p.tightestSpine].filter(Boolean).map(d => `${d!.deviceId} ${d!.margin}`).join(', ')
+    return `${p.revision}  leaf margin ${p.leafMargin}  spine margin ${p.spineMargin}${consumed}${tight ? `  tightest ${tight}` : ''}${p.violations ? `  ${p.violations} violation${p.violations === 1 ? '' : 's'}` : ''}`
+  }).join('\n')
+}
+
+function validatePolicy(policy: SpareCapacityPolicy): string[] {
+  const errors: string[] = []
+  const { minFreeEndpointPortsPercent: percent, minSpareUplinksPerSpine: spare } = policy
+  if (percent !== undefined && !(percent >= 0 && percent <= 100)) {
+    errors.push(`minFreeEndpointPortsPercent must be between 0 and 100, got ${percent}`)
+  }
+  if (spare !== undefined && !(Number.isInteger(spare) && spare >= 0)) {
+    errors.push(`minSpareUplinksPerSpine must be a non-negative integer, got ${spare}`)
+  }
+  return errors
+}
+
+const sum = (devices: DeviceMargin[]): number => devices.reduce((acc, d) => acc + d.margin, 0)
+
+function tightest(devices: DeviceMargin[]): DeviceMargin | undefined {
+  return devices.reduce<DeviceMargin | undefined>((min, d) => (!min || d.margin < min.margin ? d : min), undefined)
+}
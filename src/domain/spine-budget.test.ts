@@ -0,0 +1,42 @@
+/**
+ * Spine Port Budget Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { computeSpineBudget, formatSpineBudget } from './spine-budget'
+import { allocateUplinks } from './allocator'
+import { testProfile } from '../fixtures/testFabric'
+
+const leaf = testProfile('DS2000', ['E1/1-48'], ['E1/49-56'])
+const spine = testProfile('DS3000', ['E1/1-48'], ['E1/1-32'])
+
+describe('computeSpineBudget', () => {
+  it('splits spine ports into committed, reserved and free', () => {
+    const allocation = allocateUplinks(
+      { uplinksPerLeaf: 4, leavesNeeded: 4, spinesNeeded: 2, endpointCount: 176, spineReservation: 0.25 },
+      leaf,
+      spine
+    )
+    const report = computeSpineBudget(allocation.spineUtilization, spine, 0.25)
+
+    expect(report.spines).toEqual([
+      { spineId: 0, total: 32, committed: 8, reserved: 8, free: 16 },
+      { spineId: 1, total: 32, committed: 8, reserved: 8, free: 16 }
+    ])
+    expect(report.totals).toEqual({ total: 64, committed: 16, reserved: 16, free: 32 })
+    expect(report.futurePodUplinks).toBe(16)
+  })
+
+  it('reports everything unreserved by default', () => {
+    const report = computeSpineBudget([32], spine)
+
+    expect(report.spines[0]).toEqual({ spineId: 0, total: 32, committed: 32, reserved: 0, free: 0 })
+  })
+
+  it('formats a capacity table', () => {
+    expect(formatSpineBudget(computeSpineBudget([8], spine, 0.5))).toBe(
+      'spine-1  committed 8/32  reserved 16  free 8\n' +
+      'total    committed 8/32  reserved 16  free 8'
+    )
+  })
+})
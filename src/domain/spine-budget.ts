@@ -0,0 +1,68 @@
+/**
+ * Spine Port Budget - HNC v0.6
+ * Capacity report splitting each spine's fabric ports into committed
+ * (allocated uplinks), reserved (held back for future pods) and free.
+ */
+
+import { expandPortRanges } from './portUtils'
+import { reservedSpinePortCount } from './allocator'
+import type { SwitchProfile } from './types'
+
+export interface SpinePortBudget {
+  spineId: number
+  total: number
+  committed: number
+  reserved: number
+  free: number
+}
+
+export interface SpineBudgetReport {
+  spines: SpinePortBudget[]
+  totals: Omit<SpinePortBudget, 'spineId'>
+  /** Additional uplinks the reservation can absorb, summed over spines */
+  futurePodUplinks: number
+}
+
+/**
+ * Builds the committed/reserved/free breakdown from allocated spine utilization
+ */
+export function computeSpineBudget(
+  spineUtilization: number[],
+  spineProfile: SwitchProfile,
+  spineReservation = 0
+): SpineBudgetReport {
+  const total = expandPortRanges(spineProfile.ports.fabricAssignable).length
+  const reserved = reservedSpinePortCount(total, spineReservation)
+
+  const spines = spineUtilization.map((committed, spineId) => ({
+    spineId,
+    total,
+    committed,
+    reserved,
+    free: Math.max(0, total - reserved - committed)
+  }))
+
+  const totals = spines.reduce(
+    (acc, s) => ({
+      total: acc.total + s.total,
+      committed: acc.committed + s.committed,
+      reserved: acc.reserved + s.reserved,
+      free: acc.free + s.free
+    }),
+    { total: 0, committed: 0, reserved: 0, free: 0 }
+  )
+
+  return { spines, totals, futurePodUplinks: totals.reserved }
+}
+
+/**
+ * Formats the budget as a plain-text capacity table
+ */
+export function formatSpineBudget(report: SpineBudgetReport): string {
+  const rows = report.spines.map(s =>
+    `spine-${s.spineId + 1}  committed ${s.committed}/${s.total}  reserved ${s.reserved}  free ${s.free}`
+  )
+  const { total, committed, reserved, free } = report.totals
+  rows.push(`total    committed ${committed}/${total}  reserved ${reserved}  free ${free}`)
+  return rows.join('\n')
+}
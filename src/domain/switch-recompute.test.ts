@@ -0,0 +1,82 @@
+/**
+ * Single-Switch Recompute Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { recomputeSwitch } from './switch-recompute'
+import type { Wiring, WiringConnection } from './wiring'
+import type { SwitchProfile } from '../app.types'
+import { testProfile } from '../fixtures/testFabric'
+
+const profiles = new Map<string, SwitchProfile>([
+  ['DS3000', testProfile('DS3000', [], ['E1/1-4'])],
+  ['DS2000', testProfile('DS2000', ['E1/1-4'], ['E1/49-52'])],
+  ['DS1000', testProfile('DS1000', ['E1/1-2'], ['E1/25-26'])]
+])
+
+const uplink = (leaf: string, spine: string, leafPort: string, spinePort: string): WiringConnection =>
+  ({ id: `link-${leaf}-${spine}-1`, from: { device: leaf, port: leafPort }, to: { device: spine, port: spinePort }, type: 'uplink' })
+const endpoint = (server: string, leaf: string, port: string): WiringConnection =>
+  ({ id: `link-${server}-${leaf}-1`, from: { device: server, port: 'eth0' }, to: { device: leaf, port }, type: 'endpoint' })
+
+const wiring: Wiring = {
+  devices: {
+    spines: ['spine-1', 'spine-2'].map(id => ({ id, type: 'spine' as const, modelId: 'DS3000', ports: 4 })),
+    leaves: ['leaf-1', 'leaf-2'].map(id => ({ id, type: 'leaf' as const, modelId: 'DS2000', ports: 8 })),
+    servers: ['srv-1', 'srv-2'].map(id => ({ id, type: 'server' as const, modelId: 'server', ports: 1 }))
+  },
+  connections: [
+    uplink('leaf-1', 'spine-1', 'E1/49', 'E1/1'), uplink('leaf-1', 'spine-2', 'E1/50', 'E1/1'),
+    uplink('leaf-2', 'spine-1', 'E1/49', 'E1/2'), uplink('leaf-2', 'spine-2', 'E1/50', 'E1/2'),
+    endpoint('srv-1', 'leaf-1', 'E1/1'), endpoint('srv-2', 'leaf-1', 'E1/3')
+  ],
+  metadata: { fabricName: 'panel', fabricId: 'panel', generatedAt: new Date(0), totalDevices: 6, totalConnections: 6 }
+}
+
+describe('recomputeSwitch', () => {
+  it('re-places one leaf on a new model and checks only its neighbours', () => {
+    const result = recomputeSwitch(wiring, 'leaf-1', profiles, { modelId: 'DS1000' })
+
+    expect(result.errors).toEqual([])
+    expect(result.scope).toEqual(['leaf-1', 'spine-1', 'spine-2', 'srv-1', 'srv-2'])
+    const ports = result.wiring.connections.filter(c => c.from.device === 'leaf-1' || c.to.device === 'leaf-1')
+      .map(c => (c.from.device === 'leaf-1' ? c.from.port : c.to.port))
+    expect(ports).toEqual(['E1/25', 'E1/26', 'E1/1', 'E1/2'])
+    expect(result.changed).toEqual(['link-leaf-1-spine-1-1', 'link-leaf-1-spine-2-1', 'link-srv-2-leaf-1-1'])
+    expect(result.wiring.devices.leaves[0]).toMatchObject({ modelId: 'DS1000', ports: 4 })
+    expect(wiring.devices.leaves[0].modelId).toBe('DS2000')
+    expect(result.elapsedMs).toBeGreaterThanOrEqual(0)
+  })
+
+  it('adds uplinks round-robin on the lowest free spine ports', () => {
+    const result = recomputeSwitch(wiring, 'leaf-2', profiles, { uplinks: 4 })
+
+    expect(result.errors).toEqual([])
+    const added = result.wiring.connections.filter(c => result.changed.includes(c.id))
+    expect(added.map(c => [c.from.port, c.to.device, c.to.port])).toEqual([
+      ['E1/51', 'spine-1', 'E1/3'],
+      ['E1/52', 'spine-2', 'E1/3']
+    ])
+    expect(added.map(c => c.id)).toEqual(['link-leaf-2-spine-1-2', 'link-leaf-2-spine-2-2'])
+  })
+
+  it('drops the highest uplinks', () => {
+    const result = recomputeSwitch(wiring, 'leaf-2', profiles, { uplinks: 1 })
+
+    expect(result.changed).toEqual(['link-leaf-2-spine-2-1'])
+    expect(result.wiring.connections).toHaveLength(5)
+    expect(result.wiring.metadata.totalConnections).toBe(5)
+  })
+
+  it('returns the input wiring when the edit does not fit', () => {
+    const crowded = structuredClone(wiring)
+    crowded.connections.push(endpoint('srv-3', 'leaf-1', 'E1/4'))
+
+    const result = recomputeSwitch(crowded, 'leaf-1', profiles, { modelId: 'DS1000' })
+
+    expect(result.errors).toEqual(['leaf-1 needs 3 endpoint ports, model DS1000 has 2'])
+    expect(result.wiring).toBe(crowded)
+    expect(recomputeSwitch(wiring, 'spine-1', profiles, { uplinks: 2 }).errors).toEqual(['spine-1 is a spine; only leaves have an uplink count'])
+    expect(recomputeSwitch(wiring, 'leaf-9', profiles).errors).toEqual(['No switch leaf-9 in the wiring'])
+  })
+})
@@ -0,0 +1,173 @@
+/**
+ * Single-Switch Recompute - HNC v0.6
+ * Re-places the ports of one switch after an edit (model swap, uplink
+ * count) and validates only that switch and its direct neighbours, so the
+ * designer can give per-panel feedback on large fabrics without rebuilding
+ * and revalidating the whole wiring.
+ */
+
+import { expandPortRanges } from './portUtils'
+import type { Wiring, WiringConnection, WiringDevice } from './wiring'
+import type { SwitchProfile } from '../app.types'
+
+export interface SwitchEdit {
+  modelId?: string // swap to another switch profile
+  uplinks?: number // leaves only: new uplink count, spread round-robin over spines
+}
+
+export interface SwitchRecomputeResult {
+  wiring: Wiring
+  scope: string[] // the switch, then the neighbours that were checked
+  changed: string[] // ids of connections added, moved or removed
+  errors: string[]
+  warnings: string[]
+  elapsedMs: number
+}
+
+/**
+ * Recomputes one switch's port allocation. All-or-nothing: on errors the
+ * input wiring is returned unchanged alongside them.
+ */
+export function recomputeSwitch(
+  wiring: Wiring,
+  switchId: string,
+  profiles: Map<string, SwitchProfile>,
+  edit: SwitchEdit = {}
+): SwitchRecomputeResult {
+  const started = performance.now()
+  const done = (result: Omit<SwitchRecomputeResult, 'elapsedMs'>): SwitchRecomputeResult =>
+    ({ ...result, elapsedMs: performance.now() - started })
+
+  const next = structuredClone(wiring)
+  const device = [...next.devices.leaves, ...next.devices.spines].find(d => d.id === switchId)
+  if (!device) return done({ wiring, scope: [], changed: [], errors: [`No switch ${switchId} in the wiring`], warnings: [] })
+  if (edit.uplinks !== undefined && device.type !== 'leaf') {
+    return done({ wiring, scope: [switchId], changed: [], errors: [`${switchId} is a spine; only leaves have an uplink count`], warnings: [] })
+  }
+
+  const modelId = edit.modelId ?? device.modelId
+  const profile = profiles.get(modelId)
+  if (!profile) return done({ wiring, scope: [switchId], changed: [], errors: [`No switch profile for model ${modelId}`], warnings: [] })
+
+  const before = new Map(wiring.connections.map(c => [c.id, canonicalPorts(c)]))
+  const errors: string[] = []
+  device.modelId = modelId
+  if (device.type === 'leaf') {
+    if (edit.uplinks !== undefined) errors.push(...resizeUplinks(next, device, edit.uplinks, profiles))
+    errors.push(...placePorts(next, device, c => c.type === 'uplink', expandPortRanges(profile.ports.fabricAssignable), 'fabric'))
+    errors.push(...placePorts(next, device, c => c.type === 'endpoint', expandPortRanges(profile.ports.endpointAssignable), 'endpoint'))
+  } else {
+    errors.push(...placePorts(next, device, c => c.type === 'uplink', expandPortRanges(profile.ports.fabricAssignable), 'fabric'))
+  }
+  if (errors.length > 0) return done({ wiring, scope: [switchId], changed: [], errors, warnings: [] })
+  device.ports = expandPortRanges([...profile.ports.endpointAssignable, ...profile.ports.fabricAssignable]).length
+
+  const links = next.connections.filter(c => touches(c, switchId))
+  const neighbours = [...new Set(links.map(c => (c.from.device === switchId ? c.to.device : c.from.device)))]
+    .sort((a, b) => a.localeCompare(b, undefined, { numeric: true }))
+  const scope = [switchId, ...neighbours]
+  const warnings: string[] = []
+  errors.push(...validateScope(next, scope, profiles, warnings))
+
+  const after = new Set(next.connections.map(c => c.id))
+  const changed = [
+    ...next.connections.filter(c => before.get(c.id) !== canonicalPorts(c)).map(c => c.id),
+    ...wiring.connections.filter(c => !after.has(c.id)).map(c => c.id)
+  ]
+  if (errors.length > 0) return done({ wiring, scope, changed: [], errors, warnings })
+
+  next.metadata.totalConnections = next.connections.length
+  return done({ wiring: next, scope, changed, errors, warnings })
+}
+
+// Moves the switch side of the selected connections onto the lowest
+// assignable ports, keeping their current order
+function placePorts(
+  wiring: Wiring,
+  device: WiringDevice,
+  select: (c: WiringConnection) => boolean,
+  ports: string[],
+  kind: string
+): string[] {
+  const links = wiring.connections
+    .filter(c => select(c) && touches(c, device.id))
+    .sort((a, b) => comparePorts(side(a, device.id).port, side(b, device.id).port))
+  if (links.length > ports.length) {
+    return [`${device.id} needs ${links.length} ${kind} ports, model ${device.modelId} has ${ports.length}`]
+  }
+  links.forEach((link, i) => { side(link, device.id).port = ports[i] })
+  return []
+}
+
+// Adds uplinks round-robin to the spines with the fewest links from this
+// leaf, on each spine's lowest free fabric port, or drops the last ones
+function resizeUplinks(wiring: Wiring, leaf: WiringDevice, count: number, profiles: Map<string, SwitchProfile>): string[] {
+  if (!Number.isInteger(count) || count < 0) return [`Uplink count for ${leaf.id} must be a non-negative integer, got ${count}`]
+  const uplinks = () => wiring.connections.filter(c => c.type === 'uplink' && c.from.device === leaf.id)
+  const current = uplinks().sort((a, b) => comparePorts(a.from.port, b.from.port))
+
+  for (const dropped of current.slice(count)) {
+    wiring.connections.splice(wiring.connections.indexOf(dropped), 1)
+  }
+  const spines = wiring.devices.spines
+  if (current.length < count && spines.length === 0) return [`${leaf.id} has no spines to uplink to`]
+
+  for (let n = current.length; n < count; n++) {
+    const perSpine = (spine: string) => uplinks().filter(c => c.to.device === spine).length
+    const spine = [...spines].sort((a, b) => perSpine(a.id) - perSpine(b.id))[0]
+    const profile = profiles.get(spine.modelId)
+    if (!profile) return [`No switch profile for model ${spine.modelId}`]
+    const used = new Set(wiring.connections.filter(c => touches(c, spine.id)).map(c => side(c, spine.id).port))
+    const port = expandPortRanges(profile.ports.fabricAssignable).find(p => !used.has(p))
+    if (!port) return [`${spine.id} has no free fabric port for another uplink from ${leaf.id}`]
+
+    let seq = 1
+    while (wiring.connections.some(c => c.id === `link-${leaf.id}-${spine.id}-${seq}`)) seq++
+    wiring.connections.push({
+      id: `link-${leaf.id}-${spine.id}-${seq}`,
+      from: { device: leaf.id, port: `pending-${n}` }, // placed by placePorts
+      to: { device: spine.id, port },
+      type: 'uplink'
+    })
+  }
+  return []
+}
+
+/**
+ * Port checks for the given devices only: every used port exists on the
+ * model and is used once; uneven spine spread on leaves is a warning
+ */
+function validateScope(wiring: Wiring, scope: string[], profiles: Map<string, SwitchProfile>, warnings: string[]): string[] {
+  const errors: string[] = []
+  const devices = new Map([...wiring.devices.spines, ...wiring.devices.leaves, ...wiring.devices.servers].map(d => [d.id, d]))
+
+  for (const id of scope) {
+    const device = devices.get(id)
+    if (!device || device.type === 'server') continue
+    const profile = profiles.get(device.modelId)
+    const valid = profile ? new Set(expandPortRanges([...profile.ports.endpointAssignable, ...profile.ports.fabricAssignable])) : undefined
+    const seen = new Set<string>()
+    for (const connection of wiring.connections.filter(c => touches(c, id))) {
+      const { port } = side(connection, id)
+      if (seen.has(port)) errors.push(`Port overlap: ${id} port ${port} used multiple times`)
+      seen.add(port)
+      if (valid && !valid.has(port)) errors.push(`${id} port ${port} does not exist on model ${device.modelId}`)
+    }
+
+    if (device.type === 'leaf' && wiring.devices.spines.length > 0) {
+      const counts = wiring.devices.spines.map(s => wiring.connections.filter(c => c.type === 'uplink' && c.from.device === id && c.to.device === s.id).length)
+      if (Math.max(...counts) - Math.min(...counts) > 1) warnings.push(`${id} uplinks are uneven across spines: ${counts.join('/')}`)
+    }
+  }
+  return errors
+}
+
+const touches = (c: WiringConnection, id: string) => c.from.device === id || c.to.device === id
+const side = (c: WiringConnection, id: string) => (c.from.device === id ? c.from : c.to)
+const canonicalPorts = (c: WiringConnection) => `${c.from.device}/${c.from.port}>${c.to.device}/${c.to.port}`
+
+// Pending placeholders sort after real ports so new uplinks take the next free ones
+function comparePorts(a: string, b: string): number {
+  const pending = Number(a.startsWith('pending-')) - Number(b.startsWith('pending-'))
+  return pending || a.localeCompare(b, undefined, { numeric: true })
+}
@@ -0,0 +1,80 @@
+/**
+ * Per-Tenant Bandwidth Accounting Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { accountTenantBandwidth, formatTenantBandwidth } from './tenant-bandwidth'
+import type { Wiring, WiringConnection, WiringDevice } from './wiring'
+import type { SwitchProfile } from '../app.types'
+
+const server = (id: string, labels?: Record<string, string>): WiringDevice =>
+  ({ id, type: 'server', modelId: 'server', ports: 2, ...(labels && { labels }) })
+
+const link = (server: string, leaf: string, port: string, labels?: Record<string, string>): WiringConnection =>
+  ({ id: `${server}-${port}`, from: { device: server, port: 'eth0' }, to: { device: leaf, port }, type: 'endpoint', ...(labels && { labels }) })
+
+const wiring: Wiring = {
+  devices: {
+    spines: [],
+    leaves: [
+      { id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 48 },
+      { id: 'leaf-2', type: 'leaf', modelId: 'DS3000', ports: 32 }
+    ],
+    servers: [server('srv-1', { tenant: 'red' }), server('srv-2', { tenant: 'blue' }), server('srv-3')]
+  },
+  connections: [
+    link('srv-1', 'leaf-1', 'E1/1'),
+    link('srv-1', 'leaf-2', 'E1/1'),
+    link('srv-2', 'leaf-1', 'E1/2'),
+    link('srv-2', 'leaf-1', 'E1/3', { tenant: 'red' }),
+    link('srv-3', 'leaf-1', 'E1/4')
+  ],
+  metadata: { fabricName: 't', fabricId: 't', generatedAt: new Date(0), totalDevices: 5, totalConnections: 5 }
+}
+
+const profiles = new Map<string, SwitchProfile>([
+  ['DS3000', {
+    modelId: 'DS3000',
+    roles: [],
+    ports: { endpointAssignable: [], fabricAssignable: [] },
+    profiles: { endpoint: { portProfile: null, speedGbps: 100 }, uplink: { portProfile: null, speedGbps: 400 } },
+    meta: { source: 'test', version: '1.0' }
+  }]
+])
+
+describe('accountTenantBandwidth', () => {
+  it('sums endpoint bandwidth per tenant label and checks quotas', () => {
+    const report = accountTenantBandwidth(wiring, { profiles, quotas: { red: 100, blue: 50, green: 10 } })
+
+    expect(report.tenants).toEqual([
+      { tenant: 'blue', servers: 1, connections: 1, allocatedGbps: 25, quotaGbps: 50, utilization: 0.5, overQuota: false },
+      { tenant: 'red', servers: 2, connections: 3, allocatedGbps: 150, quotaGbps: 100, utilization: 1.5, overQuota: true }
+    ])
+    expect(report.unassignedGbps).toBe(25)
+    expect(report.totalGbps).toBe(200)
+    expect(report.warnings).toEqual([
+      'Quota declared for green, which has no allocated endpoints',
+      'Tenant red is allocated 150 Gbps, over its 100 Gbps quota'
+    ])
+  })
+
+  it('uses explicit VPC membership over labels', () => {
+    const report = accountTenantBandwidth(wiring, { groups: { 'vpc-a': ['srv-1', 'srv-2', 'srv-3'] } })
+
+    expect(report.tenants).toEqual([
+      { tenant: 'vpc-a', servers: 3, connections: 5, allocatedGbps: 125, overQuota: false }
+    ])
+    expect(report.unassignedGbps).toBe(0)
+  })
+
+  it('formats a summary', () => {
+    const text = formatTenantBandwidth(accountTenantBandwidth(wiring, { quotas: { red: 100 } }))
+
+    expect(text).toBe([
+      'blue: 25 Gbps over 1 link, no quota',
+      'red: 75 Gbps over 3 links, quota 100 Gbps (75%)',
+      'unassigned: 25 Gbps',
+      'total: 125 Gbps'
+    ].join('\n'))
+  })
+})
@@ -0,0 +1,126 @@
+/**
+ * Per-Tenant Bandwidth Accounting - HNC v0.6
+ * Aggregates allocated access bandwidth per VPC or tenant label and checks
+ * it against declared quotas, so operators can see who consumes fabric
+ * capacity at design time.
+ */
+
+import { DEFAULT_REPORT_UNITS, formatBandwidth, type ReportUnits } from './report-units'
+import type { Wiring } from './wiring'
+import type { SwitchProfile } from '../app.types'
+
+export interface TenantBandwidthOptions {
+  label?: string                     // tenant label key on servers/connections, default: 'tenant'
+  groups?: Record<string, string[]>  // explicit membership (e.g. VPC -> servers), overrides labels
+  quotas?: Record<string, number>    // tenant -> Gbps
+  profiles?: Map<string, SwitchProfile>
+  defaultEndpointGbps?: number       // used when the leaf profile is unknown, default: 25
+}
+
+export interface TenantUsage {
+  tenant: string
+  servers: number
+  connections: number
+  allocatedGbps: number
+  quotaGbps?: number
+  utilization?: number // allocated / quota
+  overQuota: boolean
+}
+
+export interface TenantBandwidthReport {
+  tenants: TenantUsage[]
+  unassignedGbps: number // endpoint bandwidth with no tenant
+  totalGbps: number
+  warnings: string[]
+}
+
+/**
+ * Sums endpoint connection speed per tenant. A connection's own label wins
+ * over its server's label; explicit groups win over both.
+ */
+export function accountTenantBandwidth(wiring: Wiring, options: TenantBandwidthOptions = {}): TenantBandwidthReport {
+  const { label = 'tenant', groups, quotas = {}, profiles, defaultEndpointGbps = 25 } = options
+  const warnings: string[] = []
+
+  const groupOf = new Map<string, string>()
+  for (const [tenant, servers] of Object.entries(groups ?? {})) {
+    for (const server of servers) {
+      const existing = groupOf.get(server)
+      if (existing && existing !== tenant) {
+        warnings.push(`Server ${server} is in both ${existing} and ${tenant}; counting it under ${existing}`)
+        continue
+      }
+      groupOf.set(server, tenant)
+    }
+  }
+
+  const servers = new Map(wiring.devices.servers.map(s => [s.id, s]))
+  const leafModels = new Map(wiring.devices.leaves.map(l => [l.id, l.modelId]))
+  const usage = new Map<string, { servers: Set<string>; connections: number; gbps: number }>()
+  let unassignedGbps = 0
+  let totalGbps = 0
+
+  for (const conn of wiring.connections) {
+    if (conn.type !== 'endpoint') continue
+    const model = leafModels.get(conn.to.device)
+    const gbps = (model && profiles?.get(model)?.profiles.endpoint.speedGbps) || defaultEndpointGbps
+    totalGbps += gbps
+
+    const server = conn.from.device
+    const tenant = groupOf.get(server) ?? conn.labels?.[label] ?? servers.get(server)?.labels?.[label]
+    if (!tenant) {
+      unassignedGbps += gbps
+      continue
+    }
+
+    const entry = usage.get(tenant) ?? { servers: new Set<string>(), connections: 0, gbps: 0 }
+    entry.servers.add(server)
+    entry.connections++
+    entry.gbps += gbps
+    usage.set(tenant, entry)
+  }
+
+  for (const tenant of Object.keys(quotas)) {
+    if (!usage.has(tenant)) warnings.push(`Quota declared for ${tenant}, which has no allocated endpoints`)
+  }
+
+  const tenants: TenantUsage[] = [...usage.entries()]
+    .sort(([a], [b]) => a.localeCompare(b, undefined, { numeric: true }))
+    .map(([tenant, entry]) => {
+      const quotaGbps = quotas[tenant]
+      const allocatedGbps = round(entry.gbps)
+      return {
+        tenant,
+        servers: entry.servers.size,
+        connections: entry.connections,
+        allocatedGbps,
+        ...(quotaGbps !== undefined && {
+          quotaGbps,
+          utilization: quotaGbps > 0 ? round(allocatedGbps / quotaGbps) : Infinity
+        }),
+        overQuota: quotaGbps !== undefined && allocatedGbps > quotaGbps
+      }
+    })
+
+  for (const t of tenants) {
+    if (t.overQuota) warnings.push(`Tenant ${t.tenant} is allocated ${t.allocatedGbps} Gbps, over its ${t.quotaGbps} Gbps quota`)
+  }
+
+  return { tenants, unassignedGbps: round(unassignedGbps), totalGbps: round(totalGbps), warnings }
+}
+
+/**
+ * Formats the report as a plain-text table
+ */
+export function formatTenantBandwidth(report: TenantBandwidthReport, units: ReportUnits = DEFAULT_REPORT_UNITS): string {
+  const bw = (gbps: number) => formatBandwidth(gbps, units)
+  const rows = report.tenants.map(t => {
+    const quota = t.quotaGbps === undefined ? 'no quota' : `quota ${bw(t.quotaGbps)} (${Math.round((t.utilization ?? 0) * 100)}%)`
+    return `${t.tenant}: ${bw(t.allocatedGbps)} over ${t.connections} link${t.connections === 1 ? '' : 's'}, ${quota}${t.overQuota ? ' OVER' : ''}`
+  })
+  if (report.unassignedGbps > 0) rows.push(`unassigned: ${bw(report.unassignedGbps)}`)
+  rows.push(`total: ${bw(report.totalGbps)}`)
+  return rows.join('\n')
+}
+
+const round = (n: number): number => Math.round(n * 1000) / 1000
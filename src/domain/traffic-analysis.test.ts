@@ -0,0 +1,64 @@
+/**
+ * Traffic Matrix Hotspot Analysis Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { analyzeTraffic } from './traffic-analysis'
+import type { Wiring, WiringConnection, WiringDevice } from './wiring'
+
+const dev = (id: string, type: WiringDevice['type'], classId?: string): WiringDevice =>
+  ({ id, type, modelId: type, ports: 4, classId })
+
+function fixture(): Wiring {
+  const connections: WiringConnection[] = []
+  for (const leaf of ['leaf-1', 'leaf-2']) {
+    for (const spine of ['spine-1', 'spine-2']) {
+      connections.push({ id: `${leaf}-${spine}`, from: { device: leaf, port: 'E1/49' }, to: { device: spine, port: 'E1/1' }, type: 'uplink' })
+    }
+  }
+  connections.push({ id: 'web-1', from: { device: 'web-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' })
+  connections.push({ id: 'web-2', from: { device: 'web-2', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/2' }, type: 'endpoint' })
+  connections.push({ id: 'db-1', from: { device: 'db-1', port: 'eth0' }, to: { device: 'leaf-2', port: 'E1/1' }, type: 'endpoint' })
+
+  return {
+    devices: {
+      spines: [dev('spine-1', 'spine'), dev('spine-2', 'spine')],
+      leaves: [dev('leaf-1', 'leaf'), dev('leaf-2', 'leaf')],
+      servers: [dev('web-1', 'server', 'web'), dev('web-2', 'server', 'web'), dev('db-1', 'server', 'db')]
+    },
+    connections,
+    metadata: { fabricName: 'test', fabricId: 'test', generatedAt: new Date(0), totalDevices: 7, totalConnections: connections.length }
+  }
+}
+
+describe('analyzeTraffic', () => {
+  it('splits cross-leaf demand evenly across uplinks', () => {
+    const result = analyzeTraffic(fixture(), { demands: [{ from: 'web', to: 'db', gbps: 100 }] })
+    const up = result.links.filter(l => l.leaf === 'leaf-1')
+    const down = result.links.filter(l => l.leaf === 'leaf-2')
+
+    expect(up.map(l => l.upstreamGbps)).toEqual([50, 50])
+    expect(down.map(l => l.downstreamGbps)).toEqual([50, 50])
+    expect(result.hotspots).toHaveLength(0)
+  })
+
+  it('ignores traffic that stays on one leaf', () => {
+    const result = analyzeTraffic(fixture(), { demands: [{ from: 'web', to: 'web', gbps: 400 }] })
+    expect(result.links.every(l => l.utilization === 0)).toBe(true)
+  })
+
+  it('flags links above the threshold', () => {
+    const result = analyzeTraffic(fixture(), { demands: [{ from: 'web', to: 'db', gbps: 180 }] }, { threshold: 0.8 })
+    expect(result.hotspots).toHaveLength(4)
+    expect(result.hotspots[0].utilization).toBe(0.9)
+  })
+
+  it('accepts explicit VPC membership', () => {
+    const result = analyzeTraffic(fixture(), {
+      demands: [{ from: 'vpc-a', to: 'vpc-b', gbps: 40 }, { from: 'vpc-a', to: 'missing', gbps: 1 }],
+      groups: { 'vpc-a': ['web-1'], 'vpc-b': ['db-1'] }
+    })
+    expect(result.links.find(l => l.connectionId === 'leaf-1-spine-1')!.upstreamGbps).toBe(20)
+    expect(result.warnings).toHaveLength(1)
+  })
+})
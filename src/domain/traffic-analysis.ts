@@ -0,0 +1,184 @@
+/**
+ * Traffic Matrix Hotspot Analysis - HNC v0.6
+ * Projects group-to-group demand onto fabric links assuming per-hop ECMP
+ * and flags uplinks / spine ports above a utilization threshold
+ */
+
+import type { Wiring, WiringConnection } from './wiring'
+import type { SwitchProfile } from '../app.types'
+
+export interface TrafficDemand {
+  from: string // group name (server class id or VPC)
+  to: string
+  gbps: number
+}
+
+export interface TrafficMatrix {
+  demands: TrafficDemand[]
+  // Optional explicit group membership (e.g. VPC -> servers); defaults to server classId
+  groups?: Record<string, string[]>
+}
+
+export interface LinkUtilization {
+  connectionId: string
+  leaf: string
+  spine: string
+  capacityGbps: number
+  upstreamGbps: number   // leaf -> spine
+  downstreamGbps: number // spine -> leaf
+  utilization: number    // max direction / capacity
+  hotspot: boolean
+}
+
+export interface TrafficAnalysis {
+  links: LinkUtilization[]
+  hotspots: LinkUtilization[]
+  unroutedGbps: number // demand with no path through the fabric
+  warnings: string[]
+}
+
+export interface TrafficAnalysisOptions {
+  threshold?: number          // default: 0.8
+  defaultUplinkGbps?: number  // used when the leaf profile is unknown
+  profiles?: Map<string, SwitchProfile>
+}
+
+/**
+ * Analyzes link utilization for a traffic matrix over the given wiring.
+ * Traffic between servers on the same leaf never reaches the fabric.
+ */
+export function analyzeTraffic(
+  wiring: Wiring,
+  matrix: TrafficMatrix,
+  options: TrafficAnalysisOptions = {}
+): TrafficAnalysis {
+  const { threshold = 0.8, defaultUplinkGbps = 100, profiles } = options
+  const warnings: string[] = []
+
+  const groups = matrix.groups || groupByClass(wiring)
+  const serverLeaves = collectServerLeaves(wiring)
+  const uplinks = wiring.connections.filter(c => c.type === 'uplink')
+
+  // leaf -> spine -> uplink connections
+  const fabric = new Map<string, Map<string, WiringConnection[]>>()
+  for (const link of uplinks) {
+    const bySpine = fabric.get(link.from.device) || new Map<string, WiringConnection[]>()
+    const list = bySpine.get(link.to.device) || []
+    list.push(link)
+    bySpine.set(link.to.device, list)
+    fabric.set(link.from.device, bySpine)
+  }
+
+  const upstream = new Map<string, number>()
+  const downstream = new Map<string, number>()
+  let unroutedGbps = 0
+
+  for (const demand of matrix.demands) {
+    const src = leafWeights(groups[demand.from], serverLeaves)
+    const dst = leafWeights(groups[demand.to], serverLeaves)
+    if (!src || !dst) {
+      warnings.push(`Demand ${demand.from} -> ${demand.to} references an unknown or empty group`)
+      continue
+    }
+
+    for (const [a, wa] of src) {
+      for (const [b, wb] of dst) {
+        if (a === b) continue
+        const gbps = demand.gbps * wa * wb
+        if (gbps <= 0) continue
+
+        // ECMP at the source leaf: spread over uplinks to spines that reach b
+        const aSpines = fabric.get(a) || new Map()
+        const bSpines = fabric.get(b) || new Map()
+        const nextHops = [...aSpines.entries()].filter(([spine]) => bSpines.has(spine))
+        const firstHopLinks = nextHops.reduce((n, [, links]) => n + links.length, 0)
+        if (firstHopLinks === 0) {
+          unroutedGbps += gbps
+          continue
+        }
+
+        for (const [spine, links] of nextHops) {
+          const spineShare = gbps * links.length / firstHopLinks
+          for (const link of links) {
+            upstream.set(link.id, (upstream.get(link.id) || 0) + spineShare / links.length)
+          }
+          // ECMP at the spine: spread over the parallel links down to b
+          const downLinks: WiringConnection[] = bSpines.get(spine)
+          for (const link of downLinks) {
+            downstream.set(link.id, (downstream.get(link.id) || 0) + spineShare / downLinks.length)
+          }
+        }
+      }
+    }
+  }
+
+  const leafModels = new Map(wiring.devices.leaves.map(l => [l.id, l.modelId]))
+  const links: LinkUtilization[] = uplinks.map(link => {
+    const model = leafModels.get(link.from.device)
+    const capacityGbps = (model && profiles?.get(model)?.profiles.uplink.speedGbps) || defaultUplinkGbps
+    const upstreamGbps = round(upstream.get(link.id) || 0)
+    const downstreamGbps = round(downstream.get(link.id) || 0)
+    const utilization = round(Math.max(upstreamGbps, downstreamGbps) / capacityGbps)
+    return {
+      connectionId: link.id,
+      leaf: link.from.device,
+      spine: link.to.device,
+      capacityGbps,
+      upstreamGbps,
+      downstreamGbps,
+      utilization,
+      hotspot: utilization > threshold
+    }
+  })
+
+  if (unroutedGbps > 0) {
+    warnings.push(`${round(unroutedGbps)} Gbps of demand has no path through the fabric`)
+  }
+
+  return {
+    links,
+    hotspots: links.filter(l => l.hotspot).sort((a, b) => b.utilization - a.utilization),
+    unroutedGbps: round(unroutedGbps),
+    warnings
+  }
+}
+
+/**
+ * Fraction of a group's traffic entering the fabric at each leaf
+ */
+function leafWeights(servers: string[] | undefined, serverLeaves: Map<string, string[]>): Map<string, number> | null {
+  const attached = (servers || []).filter(s => (serverLeaves.get(s) || []).length > 0)
+  if (attached.length === 0) return null
+
+  const weights = new Map<string, number>()
+  for (const server of attached) {
+    const leaves = serverLeaves.get(server)!
+    for (const leaf of leaves) {
+      weights.set(leaf, (weights.get(leaf) || 0) + 1 / leaves.length / attached.length)
+    }
+  }
+  return weights
+}
+
+function groupByClass(wiring: Wiring): Record<string, string[]> {
+  const groups: Record<string, string[]> = {}
+  for (const server of wiring.devices.servers) {
+    const key = server.classId || 'default'
+    ;(groups[key] ||= []).push(server.id)
+  }
+  return groups
+}
+
+function collectServerLeaves(wiring: Wiring): Map<string, string[]> {
+  const leafIds = new Set(wiring.devices.leaves.map(l => l.id))
+  const result = new Map<string, string[]>()
+  for (const conn of wiring.connections) {
+    if (conn.type !== 'endpoint' || !leafIds.has(conn.to.device)) continue
+    const leaves = result.get(conn.from.device) || []
+    if (!leaves.includes(conn.to.device)) leaves.push(conn.to.device)
+    result.set(conn.from.device, leaves)
+  }
+  return result
+}
+
+const round = (n: number): number => Math.round(n * 1000) / 1000
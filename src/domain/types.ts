@@ -8,6 +8,8 @@ export interface AllocationSpec {
   leavesNeeded: number;
   spinesNeeded: number;
   endpointCount: number;
+  spineReservation?: number; // Fraction of spine fabric ports held back for future pods (0-1)
+  placementSeed?: number; // Breaks spine-order ties reproducibly; lowest-first when unset
 }
 
 export interface UplinkAssignment {
@@ -0,0 +1,81 @@
+/**
+ * Upgrade Wave Planner Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { planUpgradeWaves, renderUpgradeRunbook } from './upgrade-waves'
+import type { Wiring, WiringConnection } from './wiring'
+
+const device = (id: string, type: 'spine' | 'leaf' | 'server') => ({ id, type, modelId: type, ports: 4 })
+
+function buildFixture(serverLinks: Record<string, string[]>): Wiring {
+  const spines = ['spine-1', 'spine-2']
+  const leaves = ['leaf-1', 'leaf-2', 'leaf-3', 'leaf-4']
+  const connections: WiringConnection[] = []
+
+  for (const leaf of leaves) {
+    for (const spine of spines) {
+      connections.push({ id: `${leaf}-${spine}`, from: { device: leaf, port: 'E1/49' }, to: { device: spine, port: 'E1/1' }, type: 'uplink' })
+    }
+  }
+  for (const [server, serverLeaves] of Object.entries(serverLinks)) {
+    serverLeaves.forEach((leaf, i) => {
+      connections.push({ id: `${server}-${leaf}`, from: { device: server, port: `eth${i}` }, to: { device: leaf, port: 'E1/1' }, type: 'endpoint' })
+    })
+  }
+
+  return {
+    devices: {
+      spines: spines.map(id => device(id, 'spine')),
+      leaves: leaves.map(id => device(id, 'leaf')),
+      servers: Object.keys(serverLinks).map(id => device(id, 'server'))
+    },
+    connections,
+    metadata: { fabricName: 'test', fabricId: 'test', generatedAt: new Date(0), totalDevices: 0, totalConnections: connections.length }
+  }
+}
+
+describe('planUpgradeWaves', () => {
+  it('never upgrades both leaves of a dual-homed server together', () => {
+    const plan = planUpgradeWaves(buildFixture({
+      'srv-1': ['leaf-1', 'leaf-2'],
+      'srv-2': ['leaf-3', 'leaf-4']
+    }))
+
+    for (const wave of plan.waves) {
+      expect(wave.switches.includes('leaf-1') && wave.switches.includes('leaf-2')).toBe(false)
+      expect(wave.switches.includes('leaf-3') && wave.switches.includes('leaf-4')).toBe(false)
+    }
+    const leafWaves = plan.waves.filter(w => w.role === 'leaf')
+    expect(leafWaves).toHaveLength(2)
+    expect(leafWaves[0].switches).toEqual(['leaf-1', 'leaf-3'])
+  })
+
+  it('keeps at least one spine up for every leaf', () => {
+    const plan = planUpgradeWaves(buildFixture({}))
+    const spineWaves = plan.waves.filter(w => w.role === 'spine')
+
+    expect(spineWaves.map(w => w.switches)).toEqual([['spine-1'], ['spine-2']])
+    expect(plan.waves[0].role).toBe('spine')
+  })
+
+  it('reports single-homed servers impacted by a wave', () => {
+    const plan = planUpgradeWaves(buildFixture({ 'srv-1': ['leaf-2'] }))
+    const wave = plan.waves.find(w => w.switches.includes('leaf-2'))!
+
+    expect(wave.impactedServers).toEqual(['srv-1'])
+    expect(plan.warnings.some(w => w.includes('single-homed'))).toBe(true)
+  })
+
+  it('honors the per-wave switch limit', () => {
+    const plan = planUpgradeWaves(buildFixture({}), { maxSwitchesPerWave: 1 })
+    expect(plan.waves.every(w => w.switches.length === 1)).toBe(true)
+    expect(plan.waves).toHaveLength(6)
+  })
+
+  it('renders an ordered runbook', () => {
+    const runbook = renderUpgradeRunbook(planUpgradeWaves(buildFixture({ 'srv-1': ['leaf-1', 'leaf-2'] })))
+    expect(runbook).toContain('# Upgrade runbook: test')
+    expect(runbook.indexOf('## Wave 1 (spine)')).toBeLessThan(runbook.indexOf('## Wave 3 (leaf)'))
+  })
+})
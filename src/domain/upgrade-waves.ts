@@ -0,0 +1,200 @@
+/**
+ * Rolling Upgrade Wave Planner - HNC v0.6
+ * Groups fabric switches into upgrade waves that can be taken down together
+ * without isolating dual-homed servers or cutting a leaf off from every spine
+ */
+
+import type { Wiring, WiringDevice } from './wiring'
+
+export interface UpgradeWave {
+  index: number
+  role: 'spine' | 'leaf'
+  switches: string[]
+  // Single-homed servers that lose connectivity while this wave is down
+  impactedServers: string[]
+}
+
+export interface UpgradePlan {
+  fabricName: string
+  waves: UpgradeWave[]
+  warnings: string[]
+}
+
+export interface UpgradePlanOptions {
+  maxSwitchesPerWave?: number // 0 or undefined = unlimited
+  spinesFirst?: boolean       // default: true
+}
+
+/**
+ * Computes ordered upgrade waves for every switch in the wiring.
+ *
+ * A redundancy group is a set of switches that must never be down at the same
+ * time: the leaves serving one dual-homed server, or the spines serving one leaf.
+ * Switches are placed greedily (deterministic id order) into the earliest wave
+ * that would not take a whole redundancy group down.
+ */
+export function planUpgradeWaves(wiring: Wiring, options: UpgradePlanOptions = {}): UpgradePlan {
+  const { maxSwitchesPerWave = 0, spinesFirst = true } = options
+  const warnings: string[] = []
+
+  const serverLeaves = collectServerLeaves(wiring)
+  const leafSpines = collectLeafSpines(wiring)
+
+  const groups: string[][] = []
+  const singleHomed = new Map<string, string[]>() // leaf -> servers
+
+  for (const [server, leaves] of serverLeaves) {
+    if (leaves.length >= 2) {
+      groups.push(leaves)
+    } else if (leaves.length === 1) {
+      const list = singleHomed.get(leaves[0]) || []
+      list.push(server)
+      singleHomed.set(leaves[0], list)
+    }
+  }
+
+  for (const [leaf, spines] of leafSpines) {
+    if (spines.length >= 2) {
+      groups.push(spines)
+    } else if (spines.length === 1) {
+      warnings.push(`Leaf ${leaf} has a single spine (${spines[0]}); it is isolated while that spine upgrades`)
+    }
+  }
+
+  const spineIds = sortIds(wiring.devices.spines)
+  const leafIds = sortIds(wiring.devices.leaves)
+
+  const spineWaves = assignWaves(spineIds, groups, maxSwitchesPerWave)
+  const leafWaves = assignWaves(leafIds, groups, maxSwitchesPerWave)
+  const ordered = spinesFirst
+    ? [...spineWaves.map(w => ({ role: 'spine' as const, switches: w })), ...leafWaves.map(w => ({ role: 'leaf' as const, switches: w }))]
+    : [...leafWaves.map(w => ({ role: 'leaf' as const, switches: w })), ...spineWaves.map(w => ({ role: 'spine' as const, switches: w }))]
+
+  const waves: UpgradeWave[] = ordered.map((wave, i) => ({
+    index: i + 1,
+    role: wave.role,
+    switches: wave.switches,
+    impactedServers: wave.switches
+      .flatMap(id => singleHomed.get(id) || [])
+      .sort((a, b) => a.localeCompare(b))
+  }))
+
+  for (const wave of waves) {
+    if (wave.impactedServers.length > 0) {
+      warnings.push(`Wave ${wave.index} interrupts ${wave.impactedServers.length} single-homed server(s)`)
+    }
+  }
+
+  return { fabricName: wiring.metadata.fabricName, waves, warnings }
+}
+
+/**
+ * Renders an upgrade plan as an ordered Markdown runbook
+ */
+export function renderUpgradeRunbook(plan: UpgradePlan): string {
+  const lines: string[] = [`# Upgrade runbook: ${plan.fabricName}`, '']
+
+  for (const wave of plan.waves) {
+    lines.push(`## Wave ${wave.index} (${wave.role})`)
+    lines.push('')
+    for (const id of wave.switches) {
+      lines.push(`- [ ] Drain and upgrade ${id}`)
+    }
+    lines.push(`- [ ] Verify BGP sessions and uplinks restored on ${wave.switches.join(', ')}`)
+    if (wave.impactedServers.length > 0) {
+      lines.push(`- [ ] Notify owners: single-homed servers offline during this wave: ${wave.impactedServers.join(', ')}`)
+    }
+    lines.push('')
+  }
+
+  if (plan.warnings.length > 0) {
+    lines.push('## Warnings', '')
+    for (const warning of plan.warnings) {
+      lines.push(`- ${warning}`)
+    }
+    lines.push('')
+  }
+
+  return lines.join('\n')
+}
+
+/**
+ * Places each switch into the earliest wave that keeps every group partially up
+ */
+function assignWaves(ids: string[], groups: string[][], maxPerWave: number): string[][] {
+  const waves: Set<string>[] = []
+  const relevant = new Map<string, string[][]>()
+  for (const id of ids) {
+    relevant.set(id, groups.filter(g => g.includes(id)))
+  }
+
+  for (const id of ids) {
+    let placed = false
+    for (const wave of waves) {
+      if (maxPerWave > 0 && wave.size >= maxPerWave) continue
+      const conflicts = relevant.get(id)!.some(group =>
+        group.every(member => member === id || wave.has(member))
+      )
+      if (!conflicts) {
+        wave.add(id)
+        placed = true
+        break
+      }
+    }
+    if (!placed) {
+      waves.push(new Set([id]))
+    }
+  }
+
+  return waves.map(wave => [...wave])
+}
+
+function collectServerLeaves(wiring: Wiring): Map<string, string[]> {
+  const leafIds = new Set(wiring.devices.leaves.map(l => l.id))
+  const result = new Map<string, string[]>()
+
+  for (const server of wiring.devices.servers) {
+    result.set(server.id, [])
+  }
+  for (const conn of wiring.connections) {
+    if (conn.type !== 'endpoint') continue
+    const [server, leaf] = leafIds.has(conn.to.device)
+      ? [conn.from.device, conn.to.device]
+      : [conn.to.device, conn.from.device]
+    const leaves = result.get(server)
+    if (leaves && leafIds.has(leaf) && !leaves.includes(leaf)) {
+      leaves.push(leaf)
+    }
+  }
+  for (const leaves of result.values()) {
+    leaves.sort((a, b) => a.localeCompare(b))
+  }
+  return result
+}
+
+function collectLeafSpines(wiring: Wiring): Map<string, string[]> {
+  const spineIds = new Set(wiring.devices.spines.map(s => s.id))
+  const result = new Map<string, string[]>()
+
+  for (const leaf of wiring.devices.leaves) {
+    result.set(leaf.id, [])
+  }
+  for (const conn of wiring.connections) {
+    if (conn.type !== 'uplink') continue
+    const [leaf, spine] = spineIds.has(conn.to.device)
+      ? [conn.from.device, conn.to.device]
+      : [conn.to.device, conn.from.device]
+    const spines = result.get(leaf)
+    if (spines && spineIds.has(spine) && !spines.includes(spine)) {
+      spines.push(spine)
+    }
+  }
+  for (const spines of result.values()) {
+    spines.sort((a, b) => a.localeCompare(b))
+  }
+  return result
+}
+
+function sortIds(devices: WiringDevice[]): string[] {
+  return devices.map(d => d.id).sort((a, b) => a.localeCompare(b, undefined, { numeric: true }))
+}
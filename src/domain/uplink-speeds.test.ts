@@ -0,0 +1,78 @@
+/**
+ * Mixed Uplink Speeds Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { LINK_SPEED_ANNOTATION, analyzeUplinkMix, expandUplinkSpeeds, validateUplinkSpeeds } from './uplink-speeds'
+import { buildWiring, validateWiring, wiringToWiringDiagram } from './wiring'
+import { checkConformance } from './reference-architecture'
+import { convertWiringDiagramToFabricCRDs } from '../io/crd-yaml'
+import { testProfiles, testSpec, testAllocation } from '../fixtures/testFabric'
+
+const profiles = testProfiles(['E1/1-10'], ['E1/11-16'])
+
+const spec = testSpec({
+  name: 'Migration Fabric',
+  uplinksPerLeaf: 3,
+  uplinkSpeeds: [{ count: 2, speedGbps: 100 }, { count: 1, speedGbps: 400 }],
+  endpointCount: 4
+})
+const allocation = testAllocation(1, ['E1/11', 'E1/12', 'E1/13'])
+
+describe('uplink speed groups', () => {
+  it('expands groups fastest first and checks counts', () => {
+    expect(expandUplinkSpeeds(spec.uplinkSpeeds!)).toEqual([400, 100, 100])
+    expect(validateUplinkSpeeds(spec.uplinkSpeeds!, 3)).toEqual([])
+    expect(validateUplinkSpeeds([{ count: 0, speedGbps: 400 }, { count: 4, speedGbps: 100 }], 3)).toEqual([
+      'Uplink group 400G needs a positive whole count',
+      'Uplink speed groups add up to 4 links, uplinksPerLeaf is 3'
+    ])
+  })
+
+  it('computes nominal and ECMP-limited oversubscription', () => {
+    const mix = analyzeUplinkMix([400, 400, 100, 100, 100, 100], 1200, 'leaf-1')
+    expect(mix).toMatchObject({ nominalGbps: 1200, ecmpGbps: 600, oversubscription: 1, ecmpOversubscription: 2, weights: { 400: 4, 100: 1 } })
+    expect(mix.warnings).toEqual([
+      'leaf-1 mixes uplink speeds (2x400G + 4x100G): equal-weight ECMP saturates the 100G links at 600 of 1200 Gbps; use weighted ECMP 4:1'
+    ])
+    expect(analyzeUplinkMix([100, 100], 100).warnings).toEqual([])
+  })
+})
+
+describe('mixed uplinks in wiring and exports', () => {
+  const wiring = buildWiring(spec, profiles, allocation)
+
+  it('annotates each uplink with its speed', () => {
+    const uplinks = wiring.connections.filter(c => c.type === 'uplink')
+    expect(uplinks.map(c => [c.from.port, c.annotations?.[LINK_SPEED_ANNOTATION]]).sort()).toEqual([
+      ['E1/11', '400G'],
+      ['E1/12', '100G'],
+      ['E1/13', '100G']
+    ])
+  })
+
+  it('warns about ECMP weights during validation', () => {
+    expect(validateWiring(wiring).warnings).toContain(
+      'leaf-1 mixes uplink speeds (1x400G + 2x100G): equal-weight ECMP saturates the 100G links at 300 of 600 Gbps; use weighted ECMP 4:1'
+    )
+  })
+
+  it('uses link speeds in oversubscription checks', () => {
+    // 4 x 25G down over 600G up; profile speed alone would give 300G up
+    const report = checkConformance(wiring, { name: 'ratio', rules: { maxOversubscription: 0.2 } }, { profiles })
+    expect(report.conforms).toBe(true)
+  })
+
+  it('writes port speeds on both ends in switch CRDs', () => {
+    const crds = convertWiringDiagramToFabricCRDs(wiringToWiringDiagram(wiring), {})
+    const leaf = crds.switches.find(s => s.metadata.name === 'leaf-1')!
+    expect(leaf.spec.portSpeeds).toEqual({ 'E1/11': '400G', 'E1/12': '100G', 'E1/13': '100G' })
+    expect(crds.switches.find(s => s.metadata.name === 'spine-2')!.spec.portSpeeds).toEqual({ 'E1/1': '100G' })
+  })
+
+  it('rejects groups that do not add up to uplinksPerLeaf', () => {
+    expect(() => buildWiring({ ...spec, uplinksPerLeaf: 2 }, profiles, allocation)).toThrow(
+      'Invalid uplink speeds for fabric: Uplink speed groups add up to 3 links, uplinksPerLeaf is 2'
+    )
+  })
+})
@@ -0,0 +1,109 @@
+/**
+ * Mixed Uplink Speeds - HNC v0.6
+ * Leaves migrating between uplink generations (e.g. 2x400G + 4x100G) carry
+ * a per-link speed. Plain ECMP hashes flows evenly across members, so the
+ * slowest links saturate first unless weights follow link speed.
+ */
+
+import type { Wiring, WiringConnection } from './wiring'
+import type { UplinkSpeedGroup } from '../app.types'
+
+export type { UplinkSpeedGroup }
+
+// Per-link speed on uplink connections, in upstream notation ('400G')
+export const LINK_SPEED_ANNOTATION = 'hnc.githedgehog.com/link-speed'
+
+export interface UplinkMixAnalysis {
+  nominalGbps: number // sum of link speeds
+  ecmpGbps: number // usable with equal-weight ECMP: slowest link x link count
+  oversubscription: number // endpoint Gbps : nominal uplink Gbps
+  ecmpOversubscription: number // endpoint Gbps : ECMP-usable uplink Gbps
+  weights: Record<string, number> // suggested ECMP weight per speed, e.g. { 400: 4, 100: 1 }
+  warnings: string[]
+}
+
+export function validateUplinkSpeeds(groups: UplinkSpeedGroup[], uplinksPerLeaf: number): string[] {
+  const errors: string[] = []
+  for (const group of groups) {
+    if (!Number.isInteger(group.count) || group.count <= 0) errors.push(`Uplink group ${group.speedGbps}G needs a positive whole count`)
+    if (!(group.speedGbps > 0)) errors.push(`Uplink group speed must be positive, got ${group.speedGbps}`)
+  }
+  const total = groups.reduce((sum, g) => sum + g.count, 0)
+  if (total !== uplinksPerLeaf) errors.push(`Uplink speed groups add up to ${total} links, uplinksPerLeaf is ${uplinksPerLeaf}`)
+  return errors
+}
+
+/**
+ * Speed of each uplink in order, fastest first, so the lowest fabric ports
+ * get the new-generation optics
+ */
+export function expandUplinkSpeeds(groups: UplinkSpeedGroup[]): number[] {
+  return [...groups]
+    .sort((a, b) => b.speedGbps - a.speedGbps)
+    .flatMap(g => Array<number>(g.count).fill(g.speedGbps))
+}
+
+export function formatLinkSpeed(speedGbps: number): string {
+  return `${speedGbps}G`
+}
+
+/**
+ * Link speed from the connection annotation, else the fallback
+ */
+export function linkSpeedGbps(connection: Pick<WiringConnection, 'annotations'>, fallbackGbps: number): number {
+  const speed = parseInt(connection.annotations?.[LINK_SPEED_ANNOTATION] ?? '', 10)
+  return speed > 0 ? speed : fallbackGbps
+}
+
+/**
+ * Bandwidth and ECMP analysis for one leaf's uplink speeds
+ */
+export function analyzeUplinkMix(speeds: number[], endpointGbps: number, leafId = 'leaf'): UplinkMixAnalysis {
+  const nominalGbps = speeds.reduce((sum, s) => sum + s, 0)
+  const ecmpGbps = speeds.length === 0 ? 0 : Math.min(...speeds) * speeds.length
+  const distinct = [...new Set(speeds)].sort((a, b) => b - a)
+  const unit = distinct.reduce(gcd, 0)
+  const weights = Object.fromEntries(distinct.map(s => [String(s), s / unit]))
+  const warnings: string[] = []
+
+  if (distinct.length > 1) {
+    const mix = distinct.map(s => `${speeds.filter(x => x === s).length}x${formatLinkSpeed(s)}`).join(' + ')
+    warnings.push(
+      `${leafId} mixes uplink speeds (${mix}): equal-weight ECMP saturates the ${formatLinkSpeed(distinct[distinct.length - 1])} links ` +
+      `at ${ecmpGbps} of ${nominalGbps} Gbps; use weighted ECMP ${distinct.map(s => weights[String(s)]).join(':')}`
+    )
+  }
+
+  return {
+    nominalGbps,
+    ecmpGbps,
+    oversubscription: ratio(endpointGbps, nominalGbps),
+    ecmpOversubscription: ratio(endpointGbps, ecmpGbps),
+    weights,
+    warnings
+  }
+}
+
+/**
+ * ECMP warnings for every leaf whose uplinks carry mixed speed annotations
+ */
+export function mixedUplinkWarnings(wiring: Wiring): string[] {
+  return wiring.devices.leaves.flatMap(leaf => {
+    const uplinks = wiring.connections.filter(c => c.type === 'uplink' && c.from.device === leaf.id)
+    const speeds = uplinks.map(c => linkSpeedGbps(c, 0))
+    if (speeds.every(s => s === 0)) return []
+    const unknown = speeds.filter(s => s === 0).length
+    return [
+      ...(unknown > 0 ? [`${leaf.id} has ${unknown} uplink${unknown === 1 ? '' : 's'} without a link speed`] : []),
+      ...analyzeUplinkMix(speeds.filter(s => s > 0), 0, leaf.id).warnings
+    ]
+  })
+}
+
+function gcd(a: number, b: number): number {
+  return b === 0 ? a : gcd(b, a % b)
+}
+
+function ratio(down: number, up: number): number {
+  return up === 0 ? 0 : Math.round(down / up * 100) / 100
+}
@@ -632,7 +632,7 @@ describe('FGD Integration', () => {
 
     expect(result.success).toBe(true);
     expect(result.fabricPath).toBe('./fgd/test-fabric');
-    expect(result.filesWritten).toHaveLength(3);
+    expect(result.filesWritten).toHaveLength(4);
     expect(result.filesWritten.some(f => f.includes('servers.yaml'))).toBe(true);
     expect(result.filesWritten.some(f => f.includes('switches.yaml'))).toBe(true);
     expect(result.filesWritten.some(f => f.includes('connections.yaml'))).toBe(true);
@@ -12,17 +12,25 @@ import type {
   AllocationResult,
   LeafAllocation,
   UplinkAssignment,
-  SwitchProfile
+  SwitchProfile,
+  UplinkSpeedGroup
 } from '../app.types';
 import type {
   MultiClassAllocationResult
 } from './types';
 import * as yaml from 'js-yaml';
 import { saveFGD, type FGDSaveOptions, type FGDSaveResult } from '../io/fgd';
-import type { WiringDiagram } from '../app.types';
+import type { WiringDiagram, Labeled, AssetInfo, SpareCapacityPolicy } from '../app.types';
+import { evaluateSpareCapacity } from './spare-capacity';
+import { planLinkOptics, type LinkBudgetOptions } from './link-budget';
+import { checkReachPolicies, type LinkReachOptions } from './link-reach';
+import { checkFrozenObjects } from './frozen';
+import { checkCatalogModels, type NamedCatalog } from './named-catalogs';
+import { spineVisitOrder } from './placement-seed';
+import { LINK_SPEED_ANNOTATION, expandUplinkSpeeds, formatLinkSpeed, mixedUplinkWarnings, validateUplinkSpeeds } from './uplink-speeds';
 
 // Core Wiring Types
-export interface WiringDevice {
+export interface WiringDevice extends Labeled, AssetInfo {
   id: string;
   type: 'spine' | 'leaf' | 'server';
   modelId: string;
@@ -30,7 +38,7 @@ export interface WiringDevice {
   classId?: string; // For multi-class fabrics
 }
 
-export interface WiringConnection {
+export interface WiringConnection extends Labeled {
   id: string;
   from: { device: string; port: string };
   to: { device: string; port: string };
@@ -50,9 +58,23 @@ export interface Wiring {
     generatedAt: Date;
     totalDevices: number;
     totalConnections: number;
+    placementSeed?: number; // provenance: seed allocation ties were broken with
+    catalog?: string; // named catalog the design selected its models from
   };
 }
 
+/**
+ * One placement decision recorded while building wiring, used to explain
+ * why a connection landed on a given device and port
+ */
+export interface WiringDecision {
+  connectionId: string;
+  rule: 'uplink-round-robin' | 'endpoint-fill';
+  subject: string; // device being placed (leaf for uplinks, server for endpoints)
+  target: { device: string; port: string };
+  reasons: string[];
+}
+
 export interface WiringValidationResult {
   errors: string[];
   warnings: string[];
@@ -70,12 +92,14 @@ export interface WiringYamlResult {
  * @param spec - Fabric specification
  * @param profiles - Switch profiles map  
  * @param allocation - Allocation result (single or multi-class)
+ * @param trace - Optional sink receiving one decision per connection
  * @returns Complete Wiring with devices and connections
  */
 export function buildWiring(
   spec: FabricSpec,
   profiles: Map<string, SwitchProfile>,
-  allocation: AllocationResult | MultiClassAllocationResult
+  allocation: AllocationResult | MultiClassAllocationResult,
+  trace?: WiringDecision[]
 ): Wiring {
   const spineProfile = profiles.get(spec.spineModelId);
   if (!spineProfile) {
@@ -89,9 +113,9 @@ export function buildWiring(
   const isMultiClass = 'classAllocations' in allocation;
   
   if (isMultiClass) {
-    return buildMultiClassWiring(spec, profiles, allocation, fabricId, spinePorts);
+    return buildMultiClassWiring(spec, profiles, allocation, fabricId, spinePorts, trace);
   } else {
-    return buildSingleClassWiring(spec, profiles, allocation, fabricId, spinePorts);
+    return buildSingleClassWiring(spec, profiles, allocation, fabricId, spinePorts, trace);
   }
 }
 
@@ -103,7 +127,8 @@ function buildSingleClassWiring(
   profiles: Map<string, SwitchProfile>,
   allocation: AllocationResult,
   fabricId: string,
-  spinePorts: string[]
+  spinePorts: string[],
+  trace?: WiringDecision[]
 ): Wiring {
   const leafProfile = profiles.get(spec.leafModelId);
   if (!leafProfile) {
@@ -133,6 +158,7 @@ function buildSingleClassWiring(
 
   // Create leaf devices and uplink connections
   const leafPorts = expandPortRanges(leafProfile.ports.fabricAssignable);
+  const uplinkSpeeds = uplinkSpeedPlan(spec.uplinkSpeeds, spec.uplinksPerLeaf || 0, 'fabric');
   for (const leafAlloc of allocation.leafMaps) {
     const leafId = `leaf-${leafAlloc.leafId + 1}`;
     devices.leaves.push({
@@ -151,11 +177,25 @@ function buildSingleClassWiring(
       const spinePort = spinePorts[spinePortIndex % spinePorts.length];
       spinePortUsage[uplink.toSpine]++;
       
+      const connectionId = `link-${leafId}-${spineId}-${linkSeq}`;
       connections.push({
-        id: `link-${leafId}-${spineId}-${linkSeq}`,
+        id: connectionId,
         from: { device: leafId, port: uplink.port },
         to: { device: spineId, port: spinePort },
-        type: 'uplink'
+        type: 'uplink',
+        ...linkSpeed(uplinkSpeeds, linkSeq)
+      });
+      trace?.push({
+        connectionId,
+        rule: 'uplink-round-robin',
+        subject: leafId,
+        target: { device: spineId, port: spinePort },
+        reasons: [
+          `${leafId} uplink ${linkSeq} of ${leafAlloc.uplinks.length} uses fabric port ${uplink.port} (lowest free fabricAssignable port first)`,
+          `Round-robin places ${leafAlloc.uplinks.length / spinesNeeded} uplink(s) per spine across ${spinesNeeded} spine(s), so this uplink goes to ${spineId}`,
+          `${spinePort} is the next free fabric port on ${spineId} (port ${spinePortIndex + 1} of ${spinePorts.length})`,
+          ...seedReason(spec, leafId, leafAlloc.leafId, spinesNeeded)
+        ]
       });
       linkSeq++;
     }
@@ -171,7 +211,8 @@ function buildSingleClassWiring(
       'default',
       leafProfile,
       spec.uplinksPerLeaf || 0,
-      spec.breakoutEnabled || false
+      spec.breakoutEnabled || false,
+      trace
     );
   }
 
@@ -183,7 +224,9 @@ function buildSingleClassWiring(
       fabricId,
       generatedAt: new Date(),
       totalDevices: devices.spines.length + devices.leaves.length + devices.servers.length,
-      totalConnections: connections.length
+      totalConnections: connections.length,
+      ...(spec.placementSeed !== undefined && { placementSeed: spec.placementSeed }),
+      ...(spec.catalog && { catalog: spec.catalog })
     }
   };
 }
@@ -196,7 +239,8 @@ function buildMultiClassWiring(
   profiles: Map<string, SwitchProfile>,
   allocation: MultiClassAllocationResult,
   fabricId: string,
-  spinePorts: string[]
+  spinePorts: string[],
+  trace?: WiringDecision[]
 ): Wiring {
   const devices = {
     spines: [] as WiringDevice[],
@@ -229,6 +273,7 @@ function buildMultiClassWiring(
     }
 
     const leafPorts = expandPortRanges(leafProfile.ports.fabricAssignable);
+    const uplinkSpeeds = uplinkSpeedPlan(leafClass.uplinkSpeeds, leafClass.uplinksPerLeaf, `class ${leafClass.id}`);
 
     // Create leaf devices for this class
     for (const leafAlloc of classAllocation.leafMaps) {
@@ -249,11 +294,25 @@ function buildMultiClassWiring(
         const spinePortIndex = uplink.toSpine; // Simplified for deterministic behavior
         const spinePort = spinePorts[spinePortIndex % spinePorts.length];
         
+        const connectionId = `link-${leafId}-${spineId}-${linkSeq}`;
         connections.push({
-          id: `link-${leafId}-${spineId}-${linkSeq}`,
+          id: connectionId,
           from: { device: leafId, port: uplink.port },
           to: { device: spineId, port: spinePort },
-          type: 'uplink'
+          type: 'uplink',
+          ...linkSpeed(uplinkSpeeds, linkSeq)
+        });
+        trace?.push({
+          connectionId,
+          rule: 'uplink-round-robin',
+          subject: leafId,
+          target: { device: spineId, port: spinePort },
+          reasons: [
+            `${leafId} (class ${leafClass.id}) uplink ${linkSeq} of ${leafAlloc.uplinks.length} uses fabric port ${uplink.port} (lowest free fabricAssignable port first)`,
+            `Round-robin across ${allocation.spineUtilization.length} spine(s) sends this uplink to ${spineId}`,
+            `Multi-class wiring uses spine fabric port index ${spinePortIndex + 1} for ${spineId}, giving ${spinePort}`,
+            ...seedReason(spec, leafId, leafAlloc.leafId, allocation.spineUtilization.length)
+          ]
         });
         linkSeq++;
       }
@@ -270,7 +329,8 @@ function buildMultiClassWiring(
           leafClass.id,
           leafProfile,
           leafClass.uplinksPerLeaf,
-          leafClass.breakoutEnabled || false
+          leafClass.breakoutEnabled || false,
+          trace
         );
       }
     }
@@ -284,11 +344,30 @@ function buildMultiClassWiring(
       fabricId,
       generatedAt: new Date(),
       totalDevices: devices.spines.length + devices.leaves.length + devices.servers.length,
-      totalConnections: connections.length
+      totalConnections: connections.length,
+      ...(spec.placementSeed !== undefined && { placementSeed: spec.placementSeed }),
+      ...(spec.catalog && { catalog: spec.catalog })
     }
   };
 }
 
+/**
+ * Per-uplink speeds for a leaf, or undefined when the leaf uses one speed
+ */
+function uplinkSpeedPlan(groups: UplinkSpeedGroup[] | undefined, uplinksPerLeaf: number, scope: string): number[] | undefined {
+  if (!groups || groups.length === 0) return undefined;
+  const errors = validateUplinkSpeeds(groups, uplinksPerLeaf);
+  if (errors.length > 0) {
+    throw new Error(`Invalid uplink speeds for ${scope}: ${errors.join('; ')}`);
+  }
+  return expandUplinkSpeeds(groups);
+}
+
+function linkSpeed(speeds: number[] | undefined, linkSeq: number): Labeled {
+  const speed = speeds?.[(linkSeq - 1) % speeds.length];
+  return speed ? { annotations: { [LINK_SPEED_ANNOTATION]: formatLinkSpeed(speed) } } : {};
+}
+
 /**
  * Creates server devices and endpoint connections with breakout support
  */
@@ -300,7 +379,8 @@ function createServersAndConnections(
   classId: string,
   leafProfile: SwitchProfile,
   uplinksPerLeaf: number,
-  breakoutEnabled?: boolean
+  breakoutEnabled?: boolean,
+  trace?: WiringDecision[]
 ) {
   const endpointPorts = expandPortRanges(leafProfile.ports.endpointAssignable);
   const baseDownlinksPerLeaf = endpointPorts.length - uplinksPerLeaf;
@@ -308,11 +388,13 @@ function createServersAndConnections(
   // Calculate effective capacity with breakout multiplier
   let availablePorts: string[] = [];
   let effectivePortsPerLeaf = baseDownlinksPerLeaf;
+  let capacityReason = `${baseDownlinksPerLeaf} endpoint ports per leaf after reserving ${uplinksPerLeaf} for uplinks`;
   
   if (breakoutEnabled && leafProfile.profiles.breakout?.supportsBreakout) {
     // Use breakout allocation - allocate ports in whole groups
     const multiplier = leafProfile.profiles.breakout.capacityMultiplier || 4;
     effectivePortsPerLeaf = baseDownlinksPerLeaf * multiplier;
+    capacityReason += `, x${multiplier} breakout = ${effectivePortsPerLeaf} endpoints per leaf`;
     
     // Generate breakout port allocation for each leaf
     const requiredGroups = Math.ceil(endpointCount / 4); // 4 endpoints per breakout group
@@ -342,7 +424,8 @@ function createServersAndConnections(
       type: 'server',
       modelId: endpointProfile.type || 'server',
       ports: endpointProfile.portsPerEndpoint,
-      classId
+      classId,
+      ...(endpointProfile.labels && { labels: { ...endpointProfile.labels } })
     });
 
     // Connect to leaf using appropriate port naming
@@ -353,11 +436,24 @@ function createServersAndConnections(
     // Use breakout child ports or regular ports
     const leafPort = availablePorts[portIndex % availablePorts.length];
     
+    const connectionId = `link-${serverId}-${targetLeafId}-1`;
     connections.push({
-      id: `link-${serverId}-${targetLeafId}-1`,
+      id: connectionId,
       from: { device: serverId, port: 'eth0' },
       to: { device: targetLeafId, port: leafPort },
-      type: 'endpoint'
+      type: 'endpoint',
+      ...(endpointProfile.labels && { labels: { ...endpointProfile.labels } })
+    });
+    trace?.push({
+      connectionId,
+      rule: 'endpoint-fill',
+      subject: serverId,
+      target: { device: targetLeafId, port: leafPort },
+      reasons: [
+        `${serverId} is endpoint ${i + 1} of ${endpointCount} for profile '${endpointProfile.name}' in class ${classId}`,
+        `Leaves are filled in order: ${capacityReason}`,
+        `Endpoint ${i + 1} takes slot ${portIndex + 1} on ${targetLeafId}, which maps to port ${leafPort}`
+      ]
     });
 
     serverIndex++;
@@ -371,10 +467,19 @@ function createServersAndConnections(
   }
 }
 
+export interface WiringValidationOptions {
+  spareCapacity?: SpareCapacityPolicy;
+  profiles?: Map<string, SwitchProfile>; // required to check spareCapacity
+  linkBudget?: LinkBudgetOptions; // optic reach and FEC for links with a length annotation
+  linkReach?: LinkReachOptions; // rack placement and the reach each kind of link may span
+  baseline?: Wiring; // imported fabric whose frozen objects must survive unchanged
+  catalog?: NamedCatalog; // the design's catalog; spine and leaf models must be in it
+}
+
 /**
  * Validates a wiring configuration for correctness
  */
-export function validateWiring(wiring: Wiring): WiringValidationResult {
+export function validateWiring(wiring: Wiring, options: WiringValidationOptions = {}): WiringValidationResult {
   const errors: string[] = [];
   const warnings: string[] = [];
 
@@ -446,6 +551,36 @@ export function validateWiring(wiring: Wiring): WiringValidationResult {
     warnings.push(`Uneven spine utilization: min=${minUtil}, max=${maxUtil}`);
   }
 
+  // Mixed uplink speeds need weighted ECMP to use the faster links
+  warnings.push(...mixedUplinkWarnings(wiring));
+
+  // Spare-capacity policy: designs may not eat into the required margin
+  if (options.spareCapacity) {
+    if (options.profiles) {
+      errors.push(...evaluateSpareCapacity(wiring, options.profiles, options.spareCapacity).errors);
+    } else {
+      warnings.push('Spare-capacity policy not checked: no switch profiles given');
+    }
+  }
+
+  if (options.linkBudget) {
+    errors.push(...planLinkOptics(wiring.connections, options.linkBudget).errors);
+  }
+
+  if (options.linkReach) {
+    const reach = checkReachPolicies(wiring, options.linkReach);
+    errors.push(...reach.errors);
+    warnings.push(...reach.warnings);
+  }
+
+  if (options.baseline) {
+    errors.push(...checkFrozenObjects(options.baseline, wiring));
+  }
+
+  if (options.catalog) {
+    errors.push(...checkCatalogModels(wiring, options.catalog));
+  }
+
   return { errors, warnings };
 }
 
@@ -468,7 +603,8 @@ export function emitYaml(wiring: Wiring): WiringYamlResult {
     metadata: {
       totalSwitches: sortedSpines.length + sortedLeaves.length,
       fabricName: wiring.metadata.fabricName,
-      generatedAt: wiring.metadata.generatedAt.toISOString()
+      generatedAt: wiring.metadata.generatedAt.toISOString(),
+      ...(wiring.metadata.placementSeed !== undefined && { placementSeed: wiring.metadata.placementSeed })
     }
   };
 
@@ -509,6 +645,13 @@ export function emitYaml(wiring: Wiring): WiringYamlResult {
 
 // Helper functions
 
+// Explain trace note for leaves whose spine order a placement seed rotated
+function seedReason(spec: FabricSpec, leafId: string, allocLeafId: number, spines: number): string[] {
+  if (spec.placementSeed === undefined) return [];
+  const first = spineVisitOrder(spines, spec.placementSeed, allocLeafId)[0];
+  return [`Placement seed ${spec.placementSeed} starts ${leafId}'s round-robin at spine-${first + 1}`];
+}
+
 /**
  * Generates a deterministic fabric ID from fabric name
  */
@@ -554,32 +697,58 @@ export function wiringToWiringDiagram(wiring: Wiring): WiringDiagram {
       spines: wiring.devices.spines.map(s => ({
         id: s.id,
         model: s.modelId,
-        ports: s.ports
+        ports: s.ports,
+        ...copyLabels(s),
+        ...copyAsset(s)
       })),
       leaves: wiring.devices.leaves.map(l => ({
         id: l.id,
         model: l.modelId,
-        ports: l.ports
+        ports: l.ports,
+        ...copyLabels(l),
+        ...copyAsset(l)
       })),
       servers: wiring.devices.servers.map(s => ({
         id: s.id,
         type: s.modelId,
-        connections: s.ports
+        connections: s.ports,
+        ...copyLabels(s),
+        ...copyAsset(s)
       }))
     },
     connections: wiring.connections.map(c => ({
       from: { device: c.from.device, port: c.from.port },
       to: { device: c.to.device, port: c.to.port },
-      type: c.type as 'uplink' | 'downlink' | 'endpoint'
+      type: c.type as 'uplink' | 'downlink' | 'endpoint',
+      ...copyLabels(c)
     })),
     metadata: {
       generatedAt: wiring.metadata.generatedAt,
       fabricName: wiring.metadata.fabricName,
-      totalDevices: wiring.metadata.totalDevices
+      totalDevices: wiring.metadata.totalDevices,
+      ...(wiring.metadata.placementSeed !== undefined && { placementSeed: wiring.metadata.placementSeed })
     }
   };
 }
 
+/**
+ * Copies only the label fields that are set, keeping output free of empty keys
+ */
+function copyLabels(obj: Labeled): Labeled {
+  return {
+    ...(obj.labels && { labels: { ...obj.labels } }),
+    ...(obj.annotations && { annotations: { ...obj.annotations } })
+  };
+}
+
+function copyAsset(obj: AssetInfo): AssetInfo {
+  return {
+    ...(obj.serialNumber && { serialNumber: obj.serialNumber }),
+    ...(obj.assetTag && { assetTag: obj.assetTag }),
+    ...(obj.macAddress && { macAddress: obj.macAddress })
+  };
+}
+
 /**
  * Writes wiring YAML files to FGD directory structure: ./fgd/<fabric-id>/
  * Creates: switches.yaml, servers.yaml, connections.yaml
@@ -25,6 +25,7 @@ export interface FabricSummary {
   status: 'draft' | 'computed' | 'saved'
   createdAt: Date
   lastModified: Date
+  policyPack?: string // policy pack chosen at creation
   driftStatus?: DriftStatus | null
   gitStatus?: GitStatus | null
 }
@@ -37,7 +38,7 @@ export interface WorkspaceContext {
 }
 
 export type WorkspaceEvent =
-  | { type: 'CREATE_FABRIC'; name: string }
+  | { type: 'CREATE_FABRIC'; name: string; policyPack?: string }
   | { type: 'SELECT_FABRIC'; fabricId: string }
   | { type: 'DELETE_FABRIC'; fabricId: string }
   | { type: 'LIST_FABRICS' }
@@ -0,0 +1,45 @@
+import type { FabricSpec, AllocationResult, SwitchProfile } from '../app.types'
+
+// Small fabric for wiring tests: DS3000 spines with fabric ports E1/1-8,
+// and DS2000 leaves with endpoint ports E1/1-4 and uplinks on E1/5-6
+
+export const testProfile = (modelId: string, endpoint: string[], fabric: string[]): SwitchProfile => ({
+  modelId,
+  roles: [],
+  ports: { endpointAssignable: endpoint, fabricAssignable: fabric },
+  profiles: {
+    endpoint: { portProfile: null, speedGbps: 25 },
+    uplink: { portProfile: null, speedGbps: 100 }
+  },
+  meta: { source: 'test', version: '1.0' }
+})
+
+// Profiles by model ID, with the leaf's ports overridable
+export const testProfiles = (leafEndpoint = ['E1/1-4'], leafFabric = ['E1/5-6']) => new Map<string, SwitchProfile>([
+  ['DS3000', testProfile('DS3000', [], ['E1/1-8'])],
+  ['DS2000', testProfile('DS2000', leafEndpoint, leafFabric)]
+])
+
+export const testSpec = (spec: Partial<FabricSpec> = {}): FabricSpec => ({
+  name: 'Test Fabric',
+  spineModelId: 'DS3000',
+  leafModelId: 'DS2000',
+  uplinksPerLeaf: 2,
+  endpointProfile: { name: 'Server', portsPerEndpoint: 1 },
+  endpointCount: 1,
+  ...spec
+})
+
+// Allocation giving each leaf an uplink on every port, to the spines in turn
+export const testAllocation = (leaves: number, ports = ['E1/5', 'E1/6'], spines = 2): AllocationResult => {
+  const spineUtilization = new Array<number>(spines).fill(0)
+  ports.forEach((_, i) => { spineUtilization[i % spines] += leaves })
+  return {
+    leafMaps: Array.from({ length: leaves }, (_, leafId) => ({
+      leafId,
+      uplinks: ports.map((port, i) => ({ port, toSpine: i % spines }))
+    })),
+    spineUtilization,
+    issues: []
+  }
+}
@@ -0,0 +1,206 @@
+/**
+ * Profile Catalog Fallback Chain - HNC v0.6
+ * Resolves the switch profile catalog from a remote registry, falling back to
+ * the last registry response cached on disk and then to the profiles bundled
+ * with HNC, so compute and validation keep working through registry outages.
+ * The result says which catalog was used and why the ones before it were not.
+ */
+
+import { fixtureProfiles } from './fixtureLayout.js';
+import { validateSwitchProfile } from './profileLoader.js';
+import type { SwitchProfile } from './types.js';
+
+export const DEFAULT_CATALOG_CACHE = '.hnc/profile-catalog.json';
+const DEFAULT_TIMEOUT_MS = 5000;
+
+/**
+ * hnc serve API version this client speaks, sent in API_VERSION_HEADER.
+ * A registry that answers with an older version than
+ * MIN_SERVER_API_VERSION is refused; one that answers with none is a plain
+ * file server and is read as it is.
+ */
+export const API_VERSION = 1;
+export const MIN_SERVER_API_VERSION = 1;
+export const API_VERSION_HEADER = 'HNC-API-Version';
+
+// The bundled fixtures, in either layout: the index and manifest of a
+// tree are picked up with the profiles and sorted out by fixtureProfiles
+const FIXTURES_DIR = '../fixtures/switch-profiles/';
+const fixtureModules = import.meta.glob(['../fixtures/switch-profiles/*.json', '../fixtures/switch-profiles/*/*/*.json'], {
+  eager: true,
+  import: 'default'
+});
+
+/** The bundled profiles, in model ID order as the Go registry lists them */
+export const EMBEDDED_PROFILES = (fixtureProfiles(
+  Object.fromEntries(Object.entries(fixtureModules).map(([path, profile]) => [path.slice(FIXTURES_DIR.length), profile]))
+) as SwitchProfile[]).sort((a, b) => a.modelId.localeCompare(b.modelId));
+
+export type CatalogSource = 'remote' | 'cache' | 'embedded';
+
+export interface CatalogAttempt {
+  source: CatalogSource;
+  location: string;
+  error: string;
+}
+
+export interface CatalogProvenance {
+  source: CatalogSource;
+  /** Registry URL, cache file, or "embedded" */
+  location: string;
+  /** When the registry served this catalog; absent for embedded profiles */
+  fetchedAt?: string;
+  /** True when a fallback was used because the registry failed */
+  degraded: boolean;
+  /** Sources tried before the one used, with why each failed */
+  attempts: CatalogAttempt[];
+}
+
+export interface CatalogChainConfig {
+  /** Registry serving a JSON array of profiles (default: HNC_PROFILE_REGISTRY_URL) */
+  registryUrl?: string;
+  /** Where the last registry response is kept (default: HNC_PROFILE_CACHE or DEFAULT_CATALOG_CACHE) */
+  cachePath?: string;
+  timeoutMs?: number;
+  fetch?: typeof fetch;
+  now?: () => Date;
+}
+
+export interface CatalogChainResult {
+  profiles: Map<string, SwitchProfile>;
+  provenance: CatalogProvenance;
+}
+
+interface CachedCatalog {
+  url: string;
+  fetchedAt: string;
+  profiles: SwitchProfile[];
+}
+
+/**
+ * Registry, then cache, then embedded profiles. A good registry response
+ * refreshes the cache; failing to write it is reported but not fatal.
+ */
+export async function resolveProfileCatalog(config: CatalogChainConfig = {}): Promise<CatalogChainResult> {
+  const registryUrl = config.registryUrl ?? process.env.HNC_PROFILE_REGISTRY_URL;
+  const cachePath = config.cachePath ?? process.env.HNC_PROFILE_CACHE ?? DEFAULT_CATALOG_CACHE;
+  const now = config.now ?? (() => new Date());
+  const attempts: CatalogAttempt[] = [];
+
+  if (registryUrl) {
+    try {
+      const { body, warning } = await fetchRegistry(registryUrl, config);
+      const profiles = parseCatalog(body);
+      if (warning) attempts.push({ source: 'remote', location: registryUrl, error: `warning: ${warning}` });
+      const fetchedAt = now().toISOString();
+      try {
+        await writeCache(cachePath, { url: registryUrl, fetchedAt, profiles });
+      } catch (error) {
+        attempts.push({ source: 'cache', location: cachePath, error: `not updated: ${message(error)}` });
+      }
+      return {
+        profiles: byModelId(profiles),
+        provenance: { source: 'remote', location: registryUrl, fetchedAt, degraded: false, attempts }
+      };
+    } catch (error) {
+      attempts.push({ source: 'remote', location: registryUrl, error: message(error) });
+    }
+  }
+
+  try {
+    const fs = await import('fs/promises');
+    const cached = JSON.parse(await fs.readFile(cachePath, 'utf-8')) as CachedCatalog;
+    const profiles = parseCatalog(cached.profiles);
+    return {
+      profiles: byModelId(profiles),
+      provenance: {
+        source: 'cache',
+        location: cachePath,
+        ...(typeof cached.fetchedAt === 'string' && { fetchedAt: cached.fetchedAt }),
+        degraded: registryUrl !== undefined,
+        attempts
+      }
+    };
+  } catch (error) {
+    attempts.push({ source: 'cache', location: cachePath, error: message(error) });
+  }
+
+  return {
+    profiles: byModelId(EMBEDDED_PROFILES),
+    provenance: { source: 'embedded', location: 'embedded', degraded: registryUrl !== undefined, attempts }
+  };
+}
+
+/**
+ * Human-readable notes on a degraded or partly failed resolution, for the
+ * loader's errors list
+ */
+export function describeProvenance(provenance: CatalogProvenance): string[] {
+  const notes = provenance.attempts.map(a => `Profile catalog ${a.source} ${a.location}: ${a.error}`);
+  if (provenance.degraded) {
+    const from = provenance.source === 'cache'
+      ? `cached catalog from ${provenance.fetchedAt ?? 'an unknown time'}`
+      : 'embedded default profiles';
+    notes.push(`Profile registry unavailable; using ${from}`);
+  }
+  return notes;
+}
+
+/**
+ * Accepts a JSON array of profiles, as written by hnc profiles dump -output -
+ */
+function parseCatalog(raw: unknown): SwitchProfile[] {
+  if (!Array.isArray(raw) || raw.length === 0) {
+    throw new Error('catalog must be a non-empty array of profiles');
+  }
+  for (const profile of raw) {
+    validateSwitchProfile(profile, profile?.modelId);
+  }
+  return raw as SwitchProfile[];
+}
+
+/**
+ * The registry's response, with the Warning an hnc serve registry sends a
+ * client it has deprecated
+ */
+async function fetchRegistry(url: string, config: CatalogChainConfig): Promise<{ body: unknown; warning: string | null }> {
+  const fetchImpl = config.fetch ?? fetch;
+  const response = await fetchImpl(url, {
+    headers: { Accept: 'application/json', [API_VERSION_HEADER]: String(API_VERSION) },
+    signal: AbortSignal.timeout(config.timeoutMs ?? DEFAULT_TIMEOUT_MS)
+  });
+  if (!response.ok) throw new Error(`HTTP ${response.status}`);
+  checkServerVersion(response.headers.get(API_VERSION_HEADER));
+  return { body: await response.json(), warning: response.headers.get('Warning') };
+}
+
+/**
+ * Refuses an hnc serve registry too old for this client. A newer one
+ * decides for itself whether it still serves API_VERSION, and warns when
+ * it is about to stop.
+ */
+function checkServerVersion(served: string | null): void {
+  if (served === null) return;
+  const version = Number(served);
+  if (!Number.isInteger(version)) {
+    throw new Error(`registry sent ${API_VERSION_HEADER} "${served}", which is not a version number`);
+  }
+  if (version < MIN_SERVER_API_VERSION) {
+    throw new Error(`registry speaks API version ${version}, older than ${MIN_SERVER_API_VERSION}, the oldest this HNC reads; upgrade hnc serve`);
+  }
+}
+
+async function writeCache(cachePath: string, cached: CachedCatalog): Promise<void> {
+  const fs = await import('fs/promises');
+  const path = await import('path');
+  await fs.mkdir(path.dirname(cachePath), { recursive: true });
+  await fs.writeFile(cachePath, JSON.stringify(cached, null, 2) + '\n');
+}
+
+function byModelId(profiles: SwitchProfile[]): Map<string, SwitchProfile> {
+  return new Map(profiles.map(p => [p.modelId, p]));
+}
+
+function message(error: unknown): string {
+  return error instanceof Error ? error.message : String(error);
+}
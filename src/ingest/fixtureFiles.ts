@@ -0,0 +1,38 @@
+/**
+ * Fixture Files - HNC v0.6
+ * Node.js reads of a switch profile fixtures directory in either layout
+ * (see fixtureLayout.ts), for the loaders and scripts that run outside the
+ * browser
+ */
+
+import { existsSync, readFileSync, readdirSync } from 'fs';
+import { basename, join } from 'path';
+import { FIXTURE_INDEX, flatPaths, indexPaths, type FixtureIndex } from './fixtureLayout.js';
+
+/**
+ * Paths of the profile files of a fixtures directory, relative to it, in
+ * path order: those its index lists, or the files at the top
+ */
+export function fixtureFiles(dir: string): string[] {
+  const indexPath = join(dir, FIXTURE_INDEX);
+  if (existsSync(indexPath)) {
+    return indexPaths(JSON.parse(readFileSync(indexPath, 'utf-8')) as FixtureIndex);
+  }
+  return flatPaths(readdirSync(dir));
+}
+
+/**
+ * Path of the profile file named name.json (e.g. ds2000) in a fixtures
+ * directory of either layout; at the top when it has none
+ */
+export function fixtureFile(dir: string, name: string): string {
+  const file = existsSync(dir) ? fixtureFiles(dir).find(path => basename(path) === `${name}.json`) : undefined;
+  return `${dir}/${file ?? `${name}.json`}`;
+}
+
+/**
+ * Every profile of a fixtures directory, in path order
+ */
+export function readFixtureProfiles(dir: string): unknown[] {
+  return fixtureFiles(dir).map(path => JSON.parse(readFileSync(join(dir, path), 'utf-8')));
+}
@@ -0,0 +1,63 @@
+/**
+ * Fixture Layouts - HNC v0.6
+ * The two layouts hnc profiles dump writes a switch profile fixtures
+ * directory in, as the Go loaders read them (pkg/profiles/layout.go): flat,
+ * every profile at the top, or tree, each under <vendor>/<role>/ and listed
+ * in an index.json at the top. Neither the index nor the manifest.json
+ * beside the profiles is a profile.
+ */
+
+export const FIXTURE_INDEX = 'index.json';
+export const FIXTURE_MANIFEST = 'manifest.json';
+
+export interface FixtureIndexEntry {
+  modelId: string;
+  vendor: string;
+  role: string;
+  /** Slash-separated, relative to the index */
+  path: string;
+}
+
+export interface FixtureIndex {
+  layout: string;
+  profiles: FixtureIndexEntry[];
+}
+
+/**
+ * The profile paths a tree layout index lists, in path order, refusing
+ * any outside the directory
+ */
+export function indexPaths(index: FixtureIndex): string[] {
+  if (!index || !Array.isArray(index.profiles)) {
+    throw new Error(`${FIXTURE_INDEX}: profiles must be an array`);
+  }
+  return index.profiles.map(({ path }) => {
+    if (typeof path !== 'string' || path.startsWith('/') || path.split('/').some(part => part === '' || part === '..')) {
+      throw new Error(`${FIXTURE_INDEX}: ${path} is outside the fixtures directory`);
+    }
+    return path;
+  }).sort();
+}
+
+/**
+ * The profile files at the top of a flat directory, in name order, from
+ * the names of its files
+ */
+export function flatPaths(names: string[]): string[] {
+  return names.filter(name => name.endsWith('.json') && !name.includes('/') && name !== FIXTURE_MANIFEST && name !== FIXTURE_INDEX).sort();
+}
+
+/**
+ * The profiles of a fixtures directory in either layout, from the parsed
+ * contents of its files keyed by path relative to it
+ */
+export function fixtureProfiles(files: Record<string, unknown>): unknown[] {
+  const index = files[FIXTURE_INDEX] as FixtureIndex | undefined;
+  const paths = index === undefined ? flatPaths(Object.keys(files)) : indexPaths(index);
+  return paths.map(path => {
+    if (!(path in files)) {
+      throw new Error(`${FIXTURE_INDEX} lists ${path}, which is missing`);
+    }
+    return files[path];
+  });
+}
@@ -1,6 +1,6 @@
 /**
  * Switch Profile Loader - HNC v0.3
- * Loads switch profiles with fixture mode (default) and optional Go generation
+ * Loads switch profiles in fixture mode (default), with optional Go generation, or through the registry fallback chain
  */
 
 import { SwitchProfile, ProfileIngestMode, ProfileLoaderConfig, ProfileLoaderResult } from './types.js';
@@ -11,7 +11,7 @@ const DEFAULT_FIXTURES = ['ds2000', 'ds3000'];
 /**
  * Validates that a loaded object conforms to SwitchProfile schema
  */
-function validateSwitchProfile(obj: any, modelId: string): obj is SwitchProfile {
+export function validateSwitchProfile(obj: any, modelId: string): obj is SwitchProfile {
   if (!obj || typeof obj !== 'object') {
     throw new Error(`Invalid profile for ${modelId}: not an object`);
   }
@@ -79,6 +79,17 @@ function validateSwitchProfile(obj: any, modelId: string): obj is SwitchProfile
     if (typeof profile.speedGbps !== 'number') {
       throw new Error(`Invalid profile for ${modelId}: profiles.${profileType}.speedGbps must be a number`);
     }
+    if (profile.breakouts !== undefined) {
+      if (!Array.isArray(profile.breakouts)) {
+        throw new Error(`Invalid profile for ${modelId}: profiles.${profileType}.breakouts must be an array`);
+      }
+      profile.breakouts.forEach((option: any, i: number) => {
+        if (typeof option?.mode !== 'string' || typeof option.lanes !== 'number' ||
+            typeof option.speedGbps !== 'number' || typeof option.portPattern !== 'string') {
+          throw new Error(`Invalid profile for ${modelId}: profiles.${profileType}.breakouts[${i}] needs mode, lanes, speedGbps and portPattern`);
+        }
+      });
+    }
   });
 
   // Validate meta structure
@@ -91,13 +102,14 @@ function validateSwitchProfile(obj: any, modelId: string): obj is SwitchProfile
 }
 
 /**
- * Loads a single fixture profile from JSON file
+ * Loads a single fixture profile from JSON file, in a fixtures directory
+ * of either layout
  */
 async function loadFixtureProfile(modelId: string, fixturesPath: string): Promise<SwitchProfile> {
-  const fixturePath = `${fixturesPath}/${modelId}.json`;
-  
   try {
     // Use dynamic import for Node.js file system operations
+    const { fixtureFile } = await import('./fixtureFiles.js');
+    const fixturePath = fixtureFile(fixturesPath, modelId);
     const fs = await import('fs/promises');
     const content = await fs.readFile(fixturePath, 'utf-8');
     const profile = JSON.parse(content);
@@ -177,7 +189,8 @@ async function loadWithGoGeneration(config: ProfileLoaderConfig): Promise<Profil
 
 /**
  * Main profile loader function
- * Supports both fixture mode (default) and optional Go generation
+ * Supports fixture mode (default), optional Go generation, and registry mode
+ * (remote registry, then local cache, then embedded profiles)
  */
 export async function loadSwitchProfiles(config: ProfileLoaderConfig = {}): Promise<ProfileLoaderResult> {
   const mode = config.mode || (process.env.PROFILE_INGEST_MODE as ProfileIngestMode) || 'fixture';
@@ -187,6 +200,21 @@ export async function loadSwitchProfiles(config: ProfileLoaderConfig = {}): Prom
     return loadWithGoGeneration(config);
   }
 
+  if (mode === 'registry') {
+    const { resolveProfileCatalog, describeProvenance } = await import('./catalogChain.js');
+    const { profiles, provenance } = await resolveProfileCatalog({
+      registryUrl: config.registryUrl,
+      cachePath: config.cachePath
+    });
+    return {
+      profiles,
+      mode: 'registry',
+      loadedAt: new Date(),
+      errors: describeProvenance(provenance),
+      provenance
+    };
+  }
+
   // Default fixture mode
   const { profiles, errors } = await loadFixtureProfiles(fixturesPath);
   
@@ -0,0 +1,147 @@
+// Generated by `hnc gen ts-types` from the Go profile structs, schema version v0.5.0; do not edit by hand.
+
+export interface Ports {
+  /** Port ranges servers may connect to, e.g. E1/1-48 */
+  endpointAssignable: string[];
+  /** Port ranges for leaf-spine links, e.g. E1/49-56 */
+  fabricAssignable: string[];
+}
+
+export interface PortBlock {
+  /** Port ranges in the block, e.g. E1/1-48 */
+  ports: string[];
+  /** Cage rows; ports fill each column top to bottom */
+  rows: number;
+}
+
+export interface Faceplate {
+  /** Cage blocks, left to right */
+  blocks: PortBlock[];
+}
+
+export interface Physical {
+  /** Typical power draw in watts, with optics fitted */
+  typicalPowerWatts: number;
+  /** Maximum power draw in watts, for sizing feeds */
+  maxPowerWatts?: number;
+  /** Maximum heat output in BTU/h (default: maximum, else typical, power x 3.412) */
+  heatBtuPerHour?: number;
+  /** Height in rack units */
+  rackUnits?: number;
+  /** Weight in kilograms, with power supplies and fans fitted */
+  weightKg?: number;
+}
+
+export interface Cost {
+  /** List price in US dollars, for comparing plans rather than quoting */
+  listPriceUsd: number;
+}
+
+export interface Tables {
+  /** IPv4 routes the switch holds */
+  routes?: number;
+  /** MAC addresses the switch learns */
+  macs?: number;
+  /** Remote VTEPs the switch keeps VXLAN tunnels to */
+  vxlanTunnels?: number;
+}
+
+export interface Features {
+  /** Routes between VXLAN segments, which leaves need to carry VPCs */
+  vxlanRouting?: boolean;
+  /** Lossless RoCE transport: PFC and ECN */
+  roce?: boolean;
+}
+
+export interface Capabilities {
+  /** ASIC family, e.g. trident3 or tomahawk4 */
+  asic: string;
+  /** Forwarding table sizes; 0 or absent where the datasheet gives none */
+  tables: Tables;
+  /** Forwarding features the ASIC and NOS support; absent means unsupported */
+  features: Features;
+}
+
+export interface Lifecycle {
+  /** active, deprecated (still sold, kept out of new designs) or end-of-sale */
+  status: string;
+  /** Model ID to design with instead, e.g. celestica-ds3000 */
+  replacement?: string;
+  /** Date support ends, as YYYY-MM-DD */
+  endOfLife?: string;
+}
+
+export interface BreakoutOption {
+  /** Breakout mode, e.g. 4x25G */
+  mode: string;
+  /** Resulting ports per physical port */
+  lanes: number;
+  /** Speed of each resulting port in Gbps */
+  speedGbps: number;
+  /** Resulting port names from {port} and {lane}, e.g. {port}/{lane} */
+  portPattern: string;
+}
+
+export interface PortProfile {
+  /** Hedgehog port profile name, e.g. SFP28-25G; null for ports with none */
+  portProfile: string | null;
+  /** Port speed in Gbps; 0 for ports with none */
+  speedGbps: number;
+  /** Ways the port can split into lower-speed ports */
+  breakouts?: BreakoutOption[];
+}
+
+export interface BreakoutCapability {
+  /** Whether endpoint ports can break out */
+  supportsBreakout: boolean;
+  /** Default breakout mode, e.g. 4x25G */
+  breakoutType?: string;
+  /** Endpoint ports per physical port when broken out */
+  capacityMultiplier?: number;
+}
+
+export interface RoleProfiles {
+  /** Endpoint-facing ports */
+  endpoint: PortProfile;
+  /** Fabric-facing ports */
+  uplink: PortProfile;
+  /** Switch-level breakout summary for the wiring builder */
+  breakout?: BreakoutCapability | null;
+}
+
+export interface Profiles extends RoleProfiles {
+  /** Port profiles of roles after the first, keyed by role, where they differ from the inline ones */
+  roles?: Record<string, RoleProfiles>;
+}
+
+export interface Meta {
+  /** File or generator the profile was written from */
+  source: string;
+  /** Profile schema version, e.g. v0.4.0 */
+  version: string;
+}
+
+export interface SwitchProfile {
+  /** Hedgehog model ID, vendor and model joined by a hyphen, e.g. celestica-ds2000 */
+  modelId: string;
+  /** Fabric roles the switch can take: leaf, spine or both */
+  roles: string[];
+  /** Which front-panel ports may carry endpoint and fabric links */
+  ports: Ports;
+  /** How the switch's network OS spells port names, so names imported from it map to the E1/1 ones used here: sonic (the default), eth, os10 or cumulus */
+  namingScheme?: string;
+  /** Physical front-panel layout, for commissioning sheets */
+  faceplate?: Faceplate | null;
+  /** Power, heat, height and weight from the datasheet, for plan optimization and power reports */
+  physical?: Physical | null;
+  /** List price, for plan optimization */
+  cost?: Cost | null;
+  /** ASIC family, table sizes and features, for checking designs against */
+  capabilities?: Capabilities | null;
+  /** Whether the model is still sold, and what replaces it, for keeping retired models out of new designs */
+  lifecycle?: Lifecycle | null;
+  /** Port profile and speed of endpoint and uplink ports, per role */
+  profiles: Profiles;
+  /** Where the profile came from and the schema version it follows */
+  meta: Meta;
+}
@@ -3,9 +3,24 @@
  * Defines TypeScript interfaces matching the exact JSON schema contract
  */
 
+import type { CatalogProvenance } from './catalogChain.js';
+
 export interface PortProfile {
   portProfile: string | null;
   speedGbps: number;
+  /** Ways to split the port, e.g. 4x25G on a 100G QSFP28 */
+  breakouts?: BreakoutOption[];
+}
+
+export interface BreakoutOption {
+  /** Mode name, e.g. "4x25G" */
+  mode: string;
+  /** Resulting ports per physical port */
+  lanes: number;
+  /** Speed of each resulting port */
+  speedGbps: number;
+  /** Resulting port names from {port} and {lane}, e.g. "{port}/{lane}" */
+  portPattern: string;
 }
 
 export interface ProfilePorts {
@@ -13,6 +28,45 @@ export interface ProfilePorts {
   fabricAssignable: string[];
 }
 
+export interface PortBlock {
+  /** Port ranges in the block, e.g. "E1/1-48" */
+  ports: string[];
+  /** Cage rows; ports fill each column top to bottom */
+  rows: number;
+}
+
+export interface ProfileFaceplate {
+  /** Port blocks from left to right on the front panel */
+  blocks: PortBlock[];
+}
+
+export interface ProfilePhysical {
+  /** Typical power draw in watts, with optics fitted */
+  typicalPowerWatts: number;
+  /** Maximum power draw in watts, for sizing feeds */
+  maxPowerWatts?: number;
+  /** Maximum heat output in BTU/h (default: maximum, else typical, power x 3.412) */
+  heatBtuPerHour?: number;
+  /** Height in rack units */
+  rackUnits?: number;
+  /** Weight in kilograms, with power supplies and fans fitted */
+  weightKg?: number;
+}
+
+export interface ProfileCost {
+  /** List price in US dollars, for comparing plans rather than quoting */
+  listPriceUsd: number;
+}
+
+export interface ProfileCapabilities {
+  /** ASIC family, e.g. trident3 or tomahawk4 */
+  asic: string;
+  /** Forwarding table sizes; 0 or absent where the datasheet gives none */
+  tables: { routes?: number; macs?: number; vxlanTunnels?: number };
+  /** Forwarding features the ASIC and NOS support; absent means unsupported */
+  features: { vxlanRouting?: boolean; roce?: boolean };
+}
+
 export interface BreakoutCapability {
   /** Read-only flag indicating if port supports breakouts */
   readonly supportsBreakout: boolean;
@@ -38,16 +92,26 @@ export interface SwitchProfile {
   modelId: string;
   roles: string[];
   ports: ProfilePorts;
+  /** How the switch's NOS spells port names: sonic (default), eth, os10 or cumulus */
+  namingScheme?: 'sonic' | 'eth' | 'os10' | 'cumulus';
+  faceplate?: ProfileFaceplate;
+  physical?: ProfilePhysical;
+  cost?: ProfileCost;
+  capabilities?: ProfileCapabilities;
   profiles: ProfileProfiles;
   meta: ProfileMeta;
 }
 
-export type ProfileIngestMode = 'fixture' | 'go';
+export type ProfileIngestMode = 'fixture' | 'go' | 'registry';
 
 export interface ProfileLoaderConfig {
   mode?: ProfileIngestMode;
   fixturesPath?: string;
   goToolPath?: string;
+  /** Registry mode: catalog URL, tried before the cache and embedded profiles */
+  registryUrl?: string;
+  /** Registry mode: where the last registry response is cached */
+  cachePath?: string;
 }
 
 export interface ProfileLoaderResult {
@@ -55,6 +119,8 @@ export interface ProfileLoaderResult {
   mode: ProfileIngestMode;
   loadedAt: Date;
   errors: string[];
+  /** Registry mode: which catalog in the fallback chain was used */
+  provenance?: CatalogProvenance;
 }
 
 /** Helper type for breakout calculations */
@@ -0,0 +1,175 @@
+/**
+ * Export Anonymization - HNC v0.6
+ * Replaces hostnames, IP and MAC addresses, serials and site identifiers
+ * with pseudonyms that stay consistent across every artifact of an export,
+ * so a design can be shared with vendors or support without leaking
+ * internal data. The mapping stays with the sender to translate answers back.
+ */
+
+import * as yaml from 'js-yaml'
+import { renameDevices } from '../domain/device-rename'
+import type { Wiring } from '../domain/wiring'
+
+export type PseudonymKind = 'spine' | 'leaf' | 'switch' | 'server' | 'fabric' | 'site' | 'serial' | 'mac' | 'ipv4' | 'ipv6'
+
+export interface AnonymizeOptions {
+  sites?: string[] // site identifiers to replace wherever they appear, e.g. 'nyc1'
+  hostnames?: string[] // names not discoverable from the artifacts
+}
+
+export interface AnonymizeResult {
+  files: Record<string, string>
+  mapping: Record<string, string> // original -> pseudonym; keep private
+}
+
+/**
+ * Hands out one stable pseudonym per original value, numbered per kind
+ */
+export class Pseudonyms {
+  private readonly byValue = new Map<string, string>()
+  private readonly counts = new Map<string, number>()
+  private readonly networks = new Map<string, string>() // IPv4 /24 -> pseudonym /24
+
+  get(kind: PseudonymKind, value: string): string {
+    const existing = this.byValue.get(value)
+    if (existing !== undefined) return existing
+    const pseudonym = kind === 'ipv4' ? this.ipv4(value) : this.format(kind, this.next(kind))
+    this.byValue.set(value, pseudonym)
+    return pseudonym
+  }
+
+  mapping(): Record<string, string> {
+    return Object.fromEntries(this.byValue)
+  }
+
+  private next(kind: string): number {
+    const n = (this.counts.get(kind) ?? 0) + 1
+    this.counts.set(kind, n)
+    return n
+  }
+
+  private format(kind: PseudonymKind, n: number): string {
+    switch (kind) {
+      case 'serial': return `SN${String(n).padStart(6, '0')}`
+      case 'mac': return `02:00:00:${[16, 8, 0].map(s => ((n >> s) & 0xff).toString(16).padStart(2, '0')).join(':')}`
+      case 'ipv6': return `2001:db8::${n.toString(16)}`
+      default: return `${kind}-${n}`
+    }
+  }
+
+  // Keeps the host octet and maps each /24 to its own 10.x.y so subnet
+  // membership survives; the prefix length is carried over by the caller
+  private ipv4(address: string): string {
+    const octets = address.split('.')
+    const network = octets.slice(0, 3).join('.')
+    let mapped = this.networks.get(network)
+    if (mapped === undefined) {
+      const n = this.networks.size
+      mapped = `10.${(n >> 8) & 0xff}.${n & 0xff}`
+      this.networks.set(network, mapped)
+    }
+    return `${mapped}.${octets[3]}`
+  }
+}
+
+const SERIAL_KEY = /(?<![A-Za-z0-9])((?:serial(?:Number|-number)?|asset(?:Tag|-tag))["']?\s*[:=]\s*)(["']?)([^"'\s,#}]+)\2/g
+const MAC = /(?<![0-9A-Fa-f:.-])(?:(?:[0-9A-Fa-f]{2}[:-]){5}[0-9A-Fa-f]{2}|[0-9A-Fa-f]{4}\.[0-9A-Fa-f]{4}\.[0-9A-Fa-f]{4})(?![0-9A-Fa-f:.-])/g
+const IPV6 = /(?<![0-9A-Za-z:])(?:[0-9A-Fa-f]{1,4}(?::[0-9A-Fa-f]{1,4}){7}|(?:[0-9A-Fa-f]{1,4}(?::[0-9A-Fa-f]{1,4})*)?::(?:[0-9A-Fa-f]{1,4}(?::[0-9A-Fa-f]{1,4})*)?)(?![0-9A-Za-z:])/g
+const IPV4 = /(?<![0-9.])((?:25[0-5]|2[0-4]\d|1?\d?\d)(?:\.(?:25[0-5]|2[0-4]\d|1?\d?\d)){3})(?![0-9.])/g
+
+/**
+ * Anonymizes a set of export files with one shared pseudonym table
+ */
+export function anonymizeArtifacts(files: Record<string, string>, options: AnonymizeOptions = {}): AnonymizeResult {
+  const pseudonyms = new Pseudonyms()
+  for (const site of options.sites ?? []) pseudonyms.get('site', site)
+  const discovered = Object.entries(files)
+    .sort(([a], [b]) => a.localeCompare(b))
+    .flatMap(([path, content]) => discoverNames(path, content))
+  // Names with a known role first, so a host seen only in a config keeps it
+  for (const { kind, name } of [...discovered.filter(d => d.kind !== 'switch'), ...discovered.filter(d => d.kind === 'switch')]) {
+    pseudonyms.get(kind, name)
+  }
+  for (const name of options.hostnames ?? []) pseudonyms.get('switch', name)
+
+  const names = Object.keys(pseudonyms.mapping()).sort((a, b) => b.length - a.length)
+  const namePattern = names.length === 0
+    ? undefined
+    : new RegExp(`(?<![A-Za-z0-9])(?:${names.map(escapeRegExp).join('|')})(?![A-Za-z0-9])`, 'g')
+
+  const out: Record<string, string> = {}
+  for (const [path, content] of Object.entries(files)) {
+    out[path] = scrubText(content, pseudonyms, namePattern)
+  }
+  return { files: out, mapping: pseudonyms.mapping() }
+}
+
+/**
+ * Anonymizes a wiring before export: devices are renamed by role, asset
+ * data and the fabric name replaced
+ */
+export function anonymizeWiring(wiring: Wiring, pseudonyms = new Pseudonyms()): Wiring {
+  const { wiring: renamed } = renameDevices(wiring, device => pseudonyms.get(device.type, device.id))
+  for (const device of [...renamed.devices.spines, ...renamed.devices.leaves, ...renamed.devices.servers]) {
+    if (device.serialNumber) device.serialNumber = pseudonyms.get('serial', device.serialNumber)
+    if (device.assetTag) device.assetTag = pseudonyms.get('serial', device.assetTag)
+    if (device.macAddress) device.macAddress = pseudonyms.get('mac', device.macAddress.toLowerCase())
+  }
+  renamed.metadata.fabricName = pseudonyms.get('fabric', wiring.metadata.fabricName)
+  renamed.metadata.fabricId = pseudonyms.get('fabric', wiring.metadata.fabricId)
+  return renamed
+}
+
+function scrubText(content: string, pseudonyms: Pseudonyms, namePattern: RegExp | undefined): string {
+  let text = content.replace(SERIAL_KEY, (_, key: string, quote: string, value: string) =>
+    `${key}${quote}${pseudonyms.get('serial', value)}${quote}`)
+  text = text.replace(MAC, mac => pseudonyms.get('mac', mac.toLowerCase()))
+  text = text.replace(IPV6, address => address.length < 3 ? address : pseudonyms.get('ipv6', address.toLowerCase()))
+  text = text.replace(IPV4, address => isPublicScope(address) ? pseudonyms.get('ipv4', address) : address)
+  return namePattern ? text.replace(namePattern, name => pseudonyms.get('switch', name)) : text
+}
+
+// Masks, wildcards, loopback and multicast carry no internal data
+function isPublicScope(address: string): boolean {
+  const first = Number(address.split('.')[0])
+  return first !== 0 && first !== 127 && first < 224
+}
+
+// Device, fabric and host names the artifacts define, with their role
+function discoverNames(path: string, content: string): Array<{ kind: PseudonymKind; name: string }> {
+  const found: Array<{ kind: PseudonymKind; name: string }> = []
+  for (const match of content.matchAll(/^hostname (\S+)/gm)) found.push({ kind: 'switch', name: match[1] })
+  if (!/\.(ya?ml|json)$/.test(path)) return found
+
+  let docs: unknown[]
+  try {
+    docs = yaml.loadAll(content)
+  } catch {
+    return found
+  }
+  for (const doc of docs as Array<Record<string, any> | null>) {
+    if (!doc || typeof doc !== 'object') continue
+    const name = doc.metadata?.name
+    if (typeof name === 'string') {
+      if (doc.kind === 'Switch') found.push({ kind: switchKind(doc.spec?.role), name })
+      else if (doc.kind === 'Server') found.push({ kind: 'server', name })
+      else if (doc.kind === 'Fabric') found.push({ kind: 'fabric', name })
+    }
+    for (const sw of Array.isArray(doc.switches) ? doc.switches : []) {
+      if (typeof sw?.id === 'string') found.push({ kind: switchKind(sw.type), name: sw.id })
+    }
+    for (const server of Array.isArray(doc.servers) ? doc.servers : []) {
+      if (typeof server?.id === 'string') found.push({ kind: 'server', name: server.id })
+    }
+    if (typeof doc.metadata?.fabricName === 'string') found.push({ kind: 'fabric', name: doc.metadata.fabricName })
+  }
+  return found
+}
+
+function switchKind(role: unknown): PseudonymKind {
+  return role === 'spine' || role === 'leaf' ? role : 'switch'
+}
+
+function escapeRegExp(value: string): string {
+  return value.replace(/[.*+?^${}()|[\]\\]/g, '\\$&')
+}
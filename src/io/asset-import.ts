@@ -0,0 +1,168 @@
+/**
+ * Procurement Asset Import - HNC v0.6
+ * Reads serial numbers and asset tags from a procurement CSV and attaches
+ * them to the matching design devices, so exports can name the physical
+ * box behind each switch and server.
+ */
+
+import type { AssetInfo } from '../app.types'
+import type { Wiring, WiringDevice } from '../domain/wiring'
+
+export interface AssetRecord extends AssetInfo {
+  deviceId: string
+  line: number // 1-based CSV line, for error messages
+}
+
+export interface AssetImportResult {
+  wiring: Wiring // the input wiring when there are errors
+  applied: number
+  errors: string[]
+  warnings: string[]
+}
+
+// Header names used by common procurement exports, compared case- and
+// punctuation-insensitively
+const DEVICE_COLUMNS = ['device', 'hostname', 'name', 'deviceid']
+const SERIAL_COLUMNS = ['serial', 'serialnumber', 'sn']
+const ASSET_TAG_COLUMNS = ['assettag', 'asset', 'tag']
+
+/**
+ * Parses a procurement CSV with a device column and serial and/or asset
+ * tag columns
+ */
+export function parseProcurementCsv(text: string): { records: AssetRecord[]; errors: string[] } {
+  const errors: string[] = []
+  const [header, ...rows] = parseCsv(text, errors)
+  if (errors.length > 0) return { records: [], errors }
+  if (!header) return { records: [], errors: ['Procurement CSV is empty'] }
+
+  const normalized = header.map(h => h.toLowerCase().replace(/[^a-z0-9]/g, ''))
+  const column = (names: string[]) => normalized.findIndex(h => names.includes(h))
+  const device = column(DEVICE_COLUMNS)
+  const serial = column(SERIAL_COLUMNS)
+  const assetTag = column(ASSET_TAG_COLUMNS)
+
+  if (device === -1) errors.push(`Procurement CSV needs a device column (${DEVICE_COLUMNS.join(', ')})`)
+  if (serial === -1 && assetTag === -1) errors.push('Procurement CSV needs a serial or asset tag column')
+  if (errors.length > 0) return { records: [], errors }
+
+  const records: AssetRecord[] = []
+  rows.forEach((cells, i) => {
+    if (cells.every(c => c.trim() === '')) return
+    const deviceId = cells[device]?.trim()
+    if (!deviceId) {
+      errors.push(`Line ${i + 2}: no device name`)
+      return
+    }
+    const record: AssetRecord = { deviceId, line: i + 2 }
+    const serialNumber = serial === -1 ? '' : cells[serial]?.trim()
+    const tag = assetTag === -1 ? '' : cells[assetTag]?.trim()
+    if (serialNumber) record.serialNumber = serialNumber
+    if (tag) record.assetTag = tag
+    records.push(record)
+  })
+
+  return { records, errors }
+}
+
+/**
+ * Attaches asset records to devices. Nothing is applied if any record
+ * names an unknown device, reuses a serial, or would overwrite a different
+ * value without `overwrite`.
+ */
+export function applyAssetRecords(
+  wiring: Wiring,
+  records: AssetRecord[],
+  options: { overwrite?: boolean } = {}
+): AssetImportResult {
+  const working: Wiring = structuredClone(wiring)
+  const devices = new Map<string, WiringDevice>(
+    [...working.devices.spines, ...working.devices.leaves, ...working.devices.servers].map(d => [d.id, d])
+  )
+  const errors: string[] = []
+  const warnings: string[] = []
+  const serialOwner = new Map<string, string>()
+  let applied = 0
+
+  for (const device of devices.values()) {
+    if (device.serialNumber) serialOwner.set(device.serialNumber, device.id)
+  }
+
+  for (const record of records) {
+    const device = devices.get(record.deviceId)
+    if (!device) {
+      errors.push(`Line ${record.line}: ${record.deviceId} is not a device in this design`)
+      continue
+    }
+
+    for (const field of ['serialNumber', 'assetTag'] as const) {
+      const value = record[field]
+      if (!value || device[field] === value) continue
+      if (device[field] && !options.overwrite) {
+        errors.push(`Line ${record.line}: ${device.id} already has ${field} ${device[field]}, not replacing it with ${value}`)
+        continue
+      }
+      if (field === 'serialNumber') {
+        const owner = serialOwner.get(value)
+        if (owner && owner !== device.id) {
+          errors.push(`Line ${record.line}: serial ${value} is already assigned to ${owner}`)
+          continue
+        }
+        if (device.serialNumber) serialOwner.delete(device.serialNumber)
+        serialOwner.set(value, device.id)
+      }
+      device[field] = value
+      applied++
+    }
+  }
+
+  const missing = [...devices.values()].filter(d => d.type !== 'server' && !d.serialNumber)
+  if (missing.length > 0) warnings.push(`${missing.length} switch${missing.length === 1 ? ' has' : 'es have'} no serial number: ${missing.map(d => d.id).join(', ')}`)
+
+  return errors.length > 0
+    ? { wiring, applied: 0, errors, warnings: [] }
+    : { wiring: working, applied, errors, warnings }
+}
+
+/**
+ * Minimal RFC 4180 reader: quoted fields, doubled quotes, CRLF or LF. A
+ * quoted field left open runs to the end of the text; it is reported in
+ * `errors` when given.
+ */
+export function parseCsv(text: string, errors: string[] = []): string[][] {
+  const rows: string[][] = []
+  let row: string[] = []
+  let cell = ''
+  let quoted = false
+  let quotedAt = 0
+
+  for (let i = 0; i < text.length; i++) {
+    const ch = text[i]
+    if (quoted) {
+      if (ch === '"' && text[i + 1] === '"') {
+        cell += '"'
+        i++
+      } else if (ch === '"') {
+        quoted = false
+      } else {
+        cell += ch
+      }
+    } else if (ch === '"') {
+      quoted = true
+      quotedAt = i
+    } else if (ch === ',') {
+      row.push(cell)
+      cell = ''
+    } else if (ch === '\n' || ch === '\r') {
+      if (ch === '\r' && text[i + 1] === '\n') i++
+      rows.push([...row, cell])
+      row = []
+      cell = ''
+    } else {
+      cell += ch
+    }
+  }
+  if (quoted) errors.push(`Line ${text.slice(0, quotedAt).split(/\r\n|\r|\n/).length}: quoted field is never closed`)
+  if (cell !== '' || row.length > 0) rows.push([...row, cell])
+  return rows
+}
@@ -0,0 +1,102 @@
+/**
+ * Cable Label Export - HNC v0.6
+ * Printable label text for both ends of every cable, derived from the
+ * cabling map. Each label is a row of fixed text lines so label-printer
+ * software can bind one field per line.
+ */
+
+import type { CablingRow } from './cabling-map'
+
+export type CableEnd = 'A' | 'B' // A = from side, B = to side
+
+export interface CableLabel {
+  cable: string
+  end: CableEnd
+  device: string
+  port: string
+  lines: string[]
+}
+
+export interface CableLabelOptions {
+  // One template per printed line. Placeholders: {cable} {type} {end}
+  // {localDevice} {localPort} {remoteDevice} {remotePort} {label.<key>}
+  template?: string[]
+  maxLineLength?: number // lines longer than this are reported, not truncated
+}
+
+export const DEFAULT_LABEL_TEMPLATE = ['{cable}', '{localDevice} {localPort}', 'to {remoteDevice} {remotePort}']
+
+const PLACEHOLDER = /\{([\w.-]+)\}/g
+const FIELDS = ['cable', 'type', 'end', 'localDevice', 'localPort', 'remoteDevice', 'remotePort']
+
+/**
+ * Builds two labels per cable (end A, then end B) in cabling map order
+ */
+export function buildCableLabels(
+  rows: CablingRow[],
+  options: CableLabelOptions = {}
+): { labels: CableLabel[]; errors: string[] } {
+  const { template = DEFAULT_LABEL_TEMPLATE, maxLineLength } = options
+  const errors = validateTemplate(template)
+  if (errors.length > 0) return { labels: [], errors }
+
+  const labels: CableLabel[] = []
+  for (const row of rows) {
+    const ends: Array<[CableEnd, string, string, string, string]> = [
+      ['A', row.fromDevice, row.fromPort, row.toDevice, row.toPort],
+      ['B', row.toDevice, row.toPort, row.fromDevice, row.fromPort]
+    ]
+    for (const [end, device, port, remoteDevice, remotePort] of ends) {
+      const values: Record<string, string> = {
+        cable: row.cable,
+        type: row.type,
+        end,
+        localDevice: device,
+        localPort: port,
+        remoteDevice,
+        remotePort
+      }
+      const lines = template.map(line => line.replace(PLACEHOLDER, (_, key: string) =>
+        key.startsWith('label.') ? row.labels[key.slice(6)] ?? '' : values[key]
+      ).trim())
+      if (maxLineLength) {
+        lines.forEach((line, i) => {
+          if (line.length > maxLineLength) {
+            errors.push(`${row.cable} end ${end} line ${i + 1} is ${line.length} characters, limit is ${maxLineLength}`)
+          }
+        })
+      }
+      labels.push({ cable: row.cable, end, device, port, lines })
+    }
+  }
+
+  return { labels, errors }
+}
+
+/**
+ * Renders labels as CSV with one column per template line (line1, line2, ...)
+ * and CRLF line endings, which label printer import tools expect
+ */
+export function renderCableLabelsCsv(labels: CableLabel[]): string {
+  const lineCount = Math.max(0, ...labels.map(l => l.lines.length))
+  const header = ['cable', 'end', 'device', 'port', ...Array.from({ length: lineCount }, (_, i) => `line${i + 1}`)]
+  const rows = labels.map(l => [l.cable, l.end, l.device, l.port, ...Array.from({ length: lineCount }, (_, i) => l.lines[i] ?? '')])
+  return [header, ...rows].map(cells => cells.map(csvCell).join(',')).join('\r\n') + '\r\n'
+}
+
+function validateTemplate(template: string[]): string[] {
+  if (template.length === 0) return ['Label template needs at least one line']
+  const errors: string[] = []
+  for (const line of template) {
+    for (const [, key] of line.matchAll(PLACEHOLDER)) {
+      if (!FIELDS.includes(key) && !(key.startsWith('label.') && key.length > 6)) {
+        errors.push(`Unknown label placeholder {${key}}`)
+      }
+    }
+  }
+  return errors
+}
+
+function csvCell(value: string): string {
+  return /[",\r\n]/.test(value) ? `"${value.replace(/"/g, '""')}"` : value
+}
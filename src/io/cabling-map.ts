@@ -0,0 +1,63 @@
+/**
+ * Cabling Map Export - HNC v0.6
+ * One row per physical cable, with user labels as extra columns so
+ * installers and downstream systems can filter by team or environment.
+ */
+
+import { labelColumns, labelRow } from '../domain/labels'
+import { linkLengthM } from '../domain/link-budget'
+import { DEFAULT_REPORT_UNITS, convertLength, formatNumber, type ReportUnits } from '../domain/report-units'
+import type { Wiring } from '../domain/wiring'
+
+export interface CablingRow {
+  cable: string
+  type: string
+  fromDevice: string
+  fromPort: string
+  toDevice: string
+  toPort: string
+  lengthM?: number // from the link-length annotation, when measured
+  labels: Record<string, string>
+}
+
+/**
+ * Builds cabling rows in deterministic order (by cable id)
+ */
+export function buildCablingMap(wiring: Wiring): CablingRow[] {
+  return [...wiring.connections]
+    .sort((a, b) => a.id.localeCompare(b.id, undefined, { numeric: true }))
+    .map(c => {
+      const lengthM = linkLengthM(c)
+      return {
+        cable: c.id,
+        type: c.type,
+        fromDevice: c.from.device,
+        fromPort: c.from.port,
+        toDevice: c.to.device,
+        toPort: c.to.port,
+        ...(lengthM !== undefined && { lengthM }),
+        labels: { ...c.labels }
+      }
+    })
+}
+
+/**
+ * Renders cabling rows as CSV; label keys become trailing columns. When any
+ * cable is measured, a length column in the chosen unit (length_m or
+ * length_ft) follows the ports. CSV numbers are never localized, so a
+ * decimal comma cannot split a cell.
+ */
+export function renderCablingCsv(rows: CablingRow[], units: ReportUnits = DEFAULT_REPORT_UNITS): string {
+  const columns = labelColumns(rows)
+  const measured = rows.some(r => r.lengthM !== undefined)
+  const length = (r: CablingRow) => r.lengthM === undefined ? '' : formatNumber(convertLength(r.lengthM, units.length), 1)
+  const header = ['cable', 'type', 'from_device', 'from_port', 'to_device', 'to_port', ...(measured ? [`length_${units.length}`] : []), ...columns]
+  const lines = rows.map(r => [
+    r.cable, r.type, r.fromDevice, r.fromPort, r.toDevice, r.toPort, ...(measured ? [length(r)] : []), ...labelRow(r, columns)
+  ])
+  return [header, ...lines].map(cells => cells.map(csvCell).join(',')).join('\n') + '\n'
+}
+
+function csvCell(value: string): string {
+  return /[",\n]/.test(value) ? `"${value.replace(/"/g, '""')}"` : value
+}
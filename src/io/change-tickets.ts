@@ -0,0 +1,174 @@
+/**
+ * Change Tickets - HNC v0.6
+ * Turns an approved, sealed export bundle into a Jira issue or ServiceNow
+ * change request: the ticket carries the validation summary and artifact
+ * checksums, and the bundle itself is attached, so the change board
+ * reviews exactly what will be deployed. Which system, project and group
+ * to file under is configured per workspace.
+ */
+
+import type { ExportManifest } from './export-manifest.js'
+
+// Read from the workspace directory (the parent of each fgd/<fabric-id>)
+export const TICKET_CONFIG_FILE = 'hnc-tickets.json'
+
+export const TICKET_SYSTEMS = ['jira', 'servicenow'] as const
+export type TicketSystem = typeof TICKET_SYSTEMS[number]
+
+export interface TicketConfig {
+  system: TicketSystem
+  url: string // base URL, e.g. https://acme.atlassian.net
+  project?: string // Jira project key (required for Jira)
+  issueType?: string // Jira issue type (default: Change)
+  assignmentGroup?: string // ServiceNow assignment group
+  labels?: string[] // Jira labels
+}
+
+export interface ChangeSummary {
+  fabric: string
+  approvedBy?: string
+  manifest: ExportManifest
+  validation: { errors: string[]; warnings: string[] }
+}
+
+export interface TicketRequest {
+  method: 'POST'
+  url: string
+  headers: Record<string, string>
+  body?: unknown // JSON; attachment uploads send the file instead
+}
+
+export interface CreatedTicket {
+  id: string // Jira issue id or ServiceNow sys_id
+  key: string // Jira issue key or ServiceNow change number
+  url: string // where a person opens it
+}
+
+// Validation messages listed in the ticket before the rest are counted
+const MAX_LISTED = 20
+
+export function parseTicketConfig(raw: unknown): { config?: TicketConfig; errors: string[] } {
+  const errors: string[] = []
+  const c = (raw ?? {}) as Record<string, unknown>
+  if (!TICKET_SYSTEMS.includes(c.system as TicketSystem)) {
+    errors.push(`system must be one of ${TICKET_SYSTEMS.join(', ')}`)
+  }
+  if (typeof c.url !== 'string' || !/^https?:\/\//.test(c.url)) {
+    errors.push('url must be an http(s) URL')
+  }
+  if (c.system === 'jira' && (typeof c.project !== 'string' || c.project === '')) {
+    errors.push('project is required for jira')
+  }
+  for (const field of ['project', 'issueType', 'assignmentGroup'] as const) {
+    if (c[field] !== undefined && typeof c[field] !== 'string') errors.push(`${field} must be a string`)
+  }
+  if (c.labels !== undefined && !(Array.isArray(c.labels) && c.labels.every(l => typeof l === 'string'))) {
+    errors.push('labels must be a list of strings')
+  }
+  if (errors.length > 0) return { errors }
+  return {
+    config: {
+      system: c.system as TicketSystem,
+      url: (c.url as string).replace(/\/+$/, ''),
+      ...(typeof c.project === 'string' && { project: c.project }),
+      ...(typeof c.issueType === 'string' && { issueType: c.issueType }),
+      ...(typeof c.assignmentGroup === 'string' && { assignmentGroup: c.assignmentGroup }),
+      ...(Array.isArray(c.labels) && { labels: c.labels as string[] })
+    },
+    errors
+  }
+}
+
+export function ticketTitle(summary: ChangeSummary): string {
+  return `Deploy fabric ${summary.fabric} (${summary.manifest.generatedAt})`
+}
+
+/**
+ * Plain-text ticket body: who approved, validation outcome, and the
+ * checksum of every artifact file in the attached bundle
+ */
+export function ticketDescription(summary: ChangeSummary): string {
+  const { errors, warnings } = summary.validation
+  const files = summary.manifest.artifacts.filter(a => a.device === undefined)
+  const lines = [
+    `HNC design ${summary.fabric} was approved for deployment${summary.approvedBy ? ` by ${summary.approvedBy}` : ''}.`,
+    '',
+    `Validation: ${errors.length === 0 ? 'passed' : 'FAILED'} (${errors.length} error(s), ${warnings.length} warning(s))`,
+    ...listed('Error', errors),
+    ...listed('Warning', warnings),
+    '',
+    `Artifacts (${summary.manifest.algorithm}, bundle generated ${summary.manifest.generatedAt}):`,
+    ...files.map(a => `  ${a.sha256}  ${a.path}`)
+  ]
+  return lines.join('\n') + '\n'
+}
+
+export function createTicketRequest(config: TicketConfig, summary: ChangeSummary, auth: string): TicketRequest {
+  const headers = { Authorization: auth, 'Content-Type': 'application/json', Accept: 'application/json' }
+  if (config.system === 'jira') {
+    return {
+      method: 'POST',
+      url: `${config.url}/rest/api/2/issue`,
+      headers,
+      body: {
+        fields: {
+          project: { key: config.project },
+          issuetype: { name: config.issueType ?? 'Change' },
+          summary: ticketTitle(summary),
+          description: ticketDescription(summary),
+          ...(config.labels && config.labels.length > 0 && { labels: config.labels })
+        }
+      }
+    }
+  }
+  return {
+    method: 'POST',
+    url: `${config.url}/api/now/table/change_request`,
+    headers,
+    body: {
+      type: 'normal',
+      short_description: ticketTitle(summary),
+      description: ticketDescription(summary),
+      ...(config.assignmentGroup && { assignment_group: config.assignmentGroup })
+    }
+  }
+}
+
+/**
+ * Upload of the bundle to a created ticket. Jira takes multipart form data
+ * under "file"; ServiceNow takes the raw bytes with the name in the query.
+ */
+export function attachmentRequest(config: TicketConfig, ticket: CreatedTicket, fileName: string, auth: string): TicketRequest {
+  if (config.system === 'jira') {
+    return {
+      method: 'POST',
+      url: `${config.url}/rest/api/2/issue/${encodeURIComponent(ticket.key)}/attachments`,
+      headers: { Authorization: auth, 'X-Atlassian-Token': 'no-check', Accept: 'application/json' }
+    }
+  }
+  const query = new URLSearchParams({ table_name: 'change_request', table_sys_id: ticket.id, file_name: fileName })
+  return {
+    method: 'POST',
+    url: `${config.url}/api/now/attachment/file?${query}`,
+    headers: { Authorization: auth, 'Content-Type': 'application/gzip', Accept: 'application/json' }
+  }
+}
+
+export function parseCreatedTicket(config: TicketConfig, response: unknown): CreatedTicket {
+  const r = (response ?? {}) as Record<string, any>
+  if (config.system === 'jira') {
+    if (typeof r.id !== 'string' || typeof r.key !== 'string') throw new Error('Jira response has no issue id and key')
+    return { id: r.id, key: r.key, url: `${config.url}/browse/${r.key}` }
+  }
+  const result = r.result ?? {}
+  if (typeof result.sys_id !== 'string' || typeof result.number !== 'string') {
+    throw new Error('ServiceNow response has no sys_id and number')
+  }
+  return { id: result.sys_id, key: result.number, url: `${config.url}/nav_to.do?uri=change_request.do?sys_id=${result.sys_id}` }
+}
+
+function listed(label: string, messages: string[]): string[] {
+  const lines = messages.slice(0, MAX_LISTED).map(m => `  ${label}: ${m}`)
+  if (messages.length > MAX_LISTED) lines.push(`  ... and ${messages.length - MAX_LISTED} more`)
+  return lines
+}
@@ -0,0 +1,256 @@
+/**
+ * Snapshot-and-compare HTML report
+ *
+ * Compares two design revisions (topology, BOM, addressing) and renders a
+ * standalone HTML page - no external CSS or JS - suitable for attaching to
+ * change-advisory-board review packets.
+ */
+
+import { compileBOM, type BOMAnalysis } from '../domain/bom-compiler'
+import { formatTarget, openComments, type ReviewComment, type ReviewTarget } from '../domain/review-comments'
+import type { WiringDiagram, WiringConnection } from '../app.types'
+
+export interface DesignRevision {
+  label: string
+  diagram: WiringDiagram
+  /** Named addresses/prefixes, e.g. { 'leaf-1 loopback': '10.0.0.1/32' } */
+  addressing?: Record<string, string>
+  /** Precompiled BOM; compiled from the diagram when omitted */
+  bom?: BOMAnalysis
+  /** Review comments on the revision's design objects */
+  comments?: ReviewComment[]
+}
+
+export interface DeviceChange {
+  id: string
+  role: 'spine' | 'leaf' | 'server'
+  change: 'added' | 'removed' | 'modified'
+  before?: string
+  after?: string
+}
+
+export interface BomLineDelta {
+  sku: string
+  description: string
+  before: number
+  after: number
+  delta: number
+}
+
+export interface AddressChange {
+  name: string
+  change: 'added' | 'removed' | 'modified'
+  before?: string
+  after?: string
+}
+
+export interface DesignComparison {
+  before: string
+  after: string
+  devices: DeviceChange[]
+  connections: { added: string[]; removed: string[] }
+  bom: { lines: BomLineDelta[]; costBefore: number; costAfter: number }
+  addressing: AddressChange[]
+  /** Open review comments of the later revision, when it has any */
+  comments?: CommentOnChange[]
+}
+
+export interface CommentOnChange {
+  comment: ReviewComment
+  /** Whether the comparison touches the commented object */
+  changed: boolean
+}
+
+/**
+ * Computes topology, BOM and addressing deltas between two revisions
+ */
+export function compareRevisions(before: DesignRevision, after: DesignRevision): DesignComparison {
+  const comparison: DesignComparison = {
+    before: before.label,
+    after: after.label,
+    devices: diffDevices(before.diagram, after.diagram),
+    connections: diffConnections(before.diagram, after.diagram),
+    bom: diffBom(before.bom ?? compileBOM(before.diagram), after.bom ?? compileBOM(after.diagram)),
+    addressing: diffAddressing(before.addressing ?? {}, after.addressing ?? {})
+  }
+  const comments = openComments(after.comments ?? [])
+  return {
+    ...comparison,
+    ...(comments.length > 0 && { comments: comments.map(comment => ({ comment, changed: touches(comparison, comment.target) })) })
+  }
+}
+
+function touches(c: DesignComparison, target: ReviewTarget): boolean {
+  if (target.kind === 'device') return c.devices.some(d => d.id === target.id)
+  if (target.kind === 'subnet') return c.addressing.some(a => a.name === target.id)
+  // Connection lines carry their type: "a:1 -> b:1 (uplink)"
+  return [...c.connections.added, ...c.connections.removed].some(line => line === target.id || line.startsWith(`${target.id} (`))
+}
+
+function diffDevices(before: WiringDiagram, after: WiringDiagram): DeviceChange[] {
+  const describe = (d: WiringDiagram) => {
+    const map = new Map<string, { role: DeviceChange['role']; detail: string }>()
+    for (const s of d.devices.spines) map.set(s.id, { role: 'spine', detail: `${s.model}, ${s.ports} ports` })
+    for (const l of d.devices.leaves) map.set(l.id, { role: 'leaf', detail: `${l.model}, ${l.ports} ports` })
+    for (const s of d.devices.servers) map.set(s.id, { role: 'server', detail: `${s.type}, ${s.connections} connections` })
+    return map
+  }
+  const a = describe(before)
+  const b = describe(after)
+  const changes: DeviceChange[] = []
+
+  for (const id of new Set([...a.keys(), ...b.keys()])) {
+    const was = a.get(id)
+    const now = b.get(id)
+    if (!was) changes.push({ id, role: now!.role, change: 'added', after: now!.detail })
+    else if (!now) changes.push({ id, role: was.role, change: 'removed', before: was.detail })
+    else if (was.detail !== now.detail || was.role !== now.role) {
+      changes.push({ id, role: now.role, change: 'modified', before: was.detail, after: now.detail })
+    }
+  }
+  return changes.sort((x, y) => x.id.localeCompare(y.id, undefined, { numeric: true }))
+}
+
+function diffConnections(before: WiringDiagram, after: WiringDiagram): DesignComparison['connections'] {
+  const key = (c: WiringConnection) => `${c.from.device}:${c.from.port} -> ${c.to.device}:${c.to.port} (${c.type})`
+  const a = new Set(before.connections.map(key))
+  const b = new Set(after.connections.map(key))
+  const sort = (xs: string[]) => xs.sort((x, y) => x.localeCompare(y, undefined, { numeric: true }))
+  return {
+    added: sort([...b].filter(k => !a.has(k))),
+    removed: sort([...a].filter(k => !b.has(k)))
+  }
+}
+
+function diffBom(before: BOMAnalysis, after: BOMAnalysis): DesignComparison['bom'] {
+  const collect = (bom: BOMAnalysis) => {
+    const lines = new Map<string, { description: string; quantity: number }>()
+    for (const item of [...bom.switches, ...bom.transceivers, ...bom.breakouts, ...bom.cables]) {
+      const line = lines.get(item.sku) ?? { description: item.description, quantity: 0 }
+      line.quantity += item.quantity
+      lines.set(item.sku, line)
+    }
+    return lines
+  }
+  const a = collect(before)
+  const b = collect(after)
+  const lines: BomLineDelta[] = []
+  for (const sku of new Set([...a.keys(), ...b.keys()])) {
+    const was = a.get(sku)?.quantity ?? 0
+    const now = b.get(sku)?.quantity ?? 0
+    if (was !== now) {
+      lines.push({ sku, description: (b.get(sku) ?? a.get(sku))!.description, before: was, after: now, delta: now - was })
+    }
+  }
+  return {
+    lines: lines.sort((x, y) => x.sku.localeCompare(y.sku)),
+    costBefore: before.summary.totalCost,
+    costAfter: after.summary.totalCost
+  }
+}
+
+function diffAddressing(before: Record<string, string>, after: Record<string, string>): AddressChange[] {
+  const changes: AddressChange[] = []
+  for (const name of new Set([...Object.keys(before), ...Object.keys(after)])) {
+    const was = before[name]
+    const now = after[name]
+    if (was === undefined) changes.push({ name, change: 'added', after: now })
+    else if (now === undefined) changes.push({ name, change: 'removed', before: was })
+    else if (was !== now) changes.push({ name, change: 'modified', before: was, after: now })
+  }
+  return changes.sort((x, y) => x.name.localeCompare(y.name, undefined, { numeric: true }))
+}
+
+/**
+ * Renders a comparison as a self-contained HTML document
+ */
+export function renderCompareReportHtml(comparison: DesignComparison, options: { title?: string; generatedAt?: Date } = {}): string {
+  const title = options.title ?? `Design comparison: ${comparison.before} → ${comparison.after}`
+  const generatedAt = (options.generatedAt ?? new Date()).toISOString()
+  const { devices, connections, bom, addressing, comments } = comparison
+  const costDelta = bom.costAfter - bom.costBefore
+
+  const sections = [
+    section('Summary', table(['Area', 'Changes'], [
+      ['Devices', String(devices.length)],
+      ['Connections', `+${connections.added.length} / -${connections.removed.length}`],
+      ['BOM lines', String(bom.lines.length)],
+      ['Cost', `${money(bom.costBefore)} → ${money(bom.costAfter)} (${costDelta >= 0 ? '+' : ''}${money(costDelta)})`],
+      ['Addressing', String(addressing.length)],
+      ...(comments ? [['Open comments', String(comments.length)]] : [])
+    ])),
+    section('Topology', (devices.length === 0 ? empty() : table(
+      ['Device', 'Role', 'Change', comparison.before, comparison.after],
+      devices.map(d => [d.id, d.role, badge(d.change), d.before ?? '', d.after ?? ''])
+    )) + '\n' + sideBySide(comparison, connections)),
+    section('Bill of materials', bom.lines.length === 0 ? empty() : table(
+      ['SKU', 'Description', comparison.before, comparison.after, 'Delta'],
+      bom.lines.map(l => [l.sku, l.description, String(l.before), String(l.after), `${l.delta > 0 ? '+' : ''}${l.delta}`])
+    )),
+    section('Addressing', addressing.length === 0 ? empty() : table(
+      ['Name', 'Change', comparison.before, comparison.after],
+      addressing.map(a => [a.name, badge(a.change), a.before ?? '', a.after ?? ''])
+    )),
+    ...(comments ? [section('Review comments', table(
+      ['Object', 'Comment', 'Author', 'Changed'],
+      comments.map(({ comment: c, changed }) => [
+        formatTarget(c.target),
+        `${c.replyTo ? `↳ ${c.replyTo}: ` : ''}${c.body}`,
+        c.author,
+        changed ? { html: '<span class="modified">changed</span>' } : ''
+      ])
+    ))] : [])
+  ]
+
+  return `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>${escapeHtml(title)}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2933; }
+table { border-collapse: collapse; margin: 0.5rem 0 1.5rem; width: 100%; }
+th, td { border: 1px solid #cbd2d9; padding: 0.3rem 0.6rem; text-align: left; font-size: 0.9rem; }
+th { background: #f0f4f8; }
+.grid { display: grid; grid-template-columns: 1fr 1fr; gap: 1rem; }
+.added { color: #1b7f3b; } .removed { color: #b42318; } .modified { color: #b54708; }
+pre { background: #f8fafc; padding: 0.5rem; overflow-x: auto; font-size: 0.8rem; }
+footer { color: #7b8794; font-size: 0.8rem; }
+</style>
+</head>
+<body>
+<h1>${escapeHtml(title)}</h1>
+${sections.join('\n')}
+<footer>Generated ${escapeHtml(generatedAt)}</footer>
+</body>
+</html>
+`
+}
+
+function sideBySide(comparison: DesignComparison, connections: DesignComparison['connections']): string {
+  const list = (items: string[], cls: string) =>
+    items.length === 0 ? '<p>None</p>' : `<pre class="${cls}">${items.map(escapeHtml).join('\n')}</pre>`
+  return `<div class="grid">
+<div><h3>Removed connections (${escapeHtml(comparison.before)})</h3>${list(connections.removed, 'removed')}</div>
+<div><h3>Added connections (${escapeHtml(comparison.after)})</h3>${list(connections.added, 'added')}</div>
+</div>`
+}
+
+const section = (heading: string, body: string) => `<section>\n<h2>${escapeHtml(heading)}</h2>\n${body}\n</section>`
+
+/** Table cells are escaped text unless already rendered markup */
+type Cell = string | { html: string }
+
+const table = (headers: string[], rows: Cell[][]) =>
+  `<table>\n<tr>${headers.map(h => `<th>${escapeHtml(h)}</th>`).join('')}</tr>\n` +
+  rows.map(r => `<tr>${r.map(c => `<td>${typeof c === 'string' ? escapeHtml(c) : c.html}</td>`).join('')}</tr>`).join('\n') +
+  '\n</table>'
+
+const badge = (change: DeviceChange['change']): Cell => ({ html: `<span class="${change}">${change}</span>` })
+
+const empty = () => '<p>No changes.</p>'
+
+const money = (n: number) => n.toLocaleString('en-US', { style: 'currency', currency: 'USD' })
+
+const escapeHtml = (s: string) =>
+  s.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;')
@@ -0,0 +1,72 @@
+/**
+ * Compressed design files - HNC v0.6
+ *
+ * gzip runs through the Compression Streams API, so the same code runs in
+ * Node and the browser. zstd uses node:zlib, which has it from Node 22.15;
+ * elsewhere reading or writing a .zst file fails with a readable error.
+ * Compressed files carry the codec's extension after their own, e.g.
+ * connections.yaml.gz.
+ */
+
+export type Compression = 'gzip' | 'zstd'
+
+export const COMPRESSION_EXTENSIONS: Record<Compression, string> = {
+  gzip: '.gz',
+  zstd: '.zst'
+}
+
+/**
+ * The codec a file name's extension says it was written with, if any
+ */
+export function compressionOf(fileName: string): Compression | undefined {
+  return (Object.keys(COMPRESSION_EXTENSIONS) as Compression[]).find(c => fileName.endsWith(COMPRESSION_EXTENSIONS[c]))
+}
+
+export async function compressString(text: string, compression: Compression): Promise<Uint8Array> {
+  const data = new TextEncoder().encode(text)
+  if (compression === 'gzip') return pipe(data, new CompressionStream('gzip'))
+  return (await zstd()).zstdCompressSync(data)
+}
+
+export async function decompressString(data: Uint8Array, compression: Compression): Promise<string> {
+  const raw = compression === 'gzip'
+    ? await pipe(data, new DecompressionStream('gzip'))
+    : (await zstd()).zstdDecompressSync(data)
+  return new TextDecoder().decode(raw)
+}
+
+async function pipe(data: Uint8Array, transform: CompressionStream | DecompressionStream): Promise<Uint8Array> {
+  const writer = transform.writable.getWriter()
+  const written = writer.write(data as Uint8Array<ArrayBuffer>).then(() => writer.close())
+  const chunks: Uint8Array[] = []
+  const reader = transform.readable.getReader()
+  for (;;) {
+    const { done, value } = await reader.read()
+    if (done) break
+    chunks.push(value)
+  }
+  await written
+  const out = new Uint8Array(chunks.reduce((n, c) => n + c.length, 0))
+  let offset = 0
+  for (const chunk of chunks) {
+    out.set(chunk, offset)
+    offset += chunk.length
+  }
+  return out
+}
+
+interface ZstdCodec {
+  zstdCompressSync(data: Uint8Array): Uint8Array
+  zstdDecompressSync(data: Uint8Array): Uint8Array
+}
+
+async function zstd(): Promise<ZstdCodec> {
+  let zlib: Partial<ZstdCodec> | undefined
+  if (typeof process !== 'undefined' && process.versions?.node) {
+    zlib = await import('zlib') as Partial<ZstdCodec>
+  }
+  if (!zlib?.zstdCompressSync || !zlib.zstdDecompressSync) {
+    throw new Error('zstd needs Node 22.15 or later; use gzip compression instead')
+  }
+  return zlib as ZstdCodec
+}
@@ -0,0 +1,189 @@
+/**
+ * Connection-Type Registry - HNC v0.6
+ * Maps each cable to a Hedgehog Connection kind (unbundled, bundled, ESLAG,
+ * fabric, ...) and owns that kind's spec shape, so exporters only ask the
+ * registry. Supporting a new upstream kind means registering one handler.
+ */
+
+import type { WiringConnection, WiringDiagram } from '../app.types.js'
+
+// Forces a kind for one connection, e.g. 'mclag' or 'management'
+export const CONNECTION_KIND_ANNOTATION = 'hnc.githedgehog.com/connection-kind'
+
+export type DeviceRole = 'spine' | 'leaf' | 'server'
+
+export interface ConnectionContext {
+  roleOf(deviceId: string): DeviceRole | undefined // undefined: not in the design (external)
+  linksOf(deviceId: string): WiringConnection[] // connections at either end
+}
+
+export interface ConnectionTypeHandler {
+  kind: string // key under Connection spec, e.g. 'unbundled'
+  matches(connection: WiringConnection, context: ConnectionContext): boolean
+  toSpec(connection: WiringConnection, context: ConnectionContext): Record<string, any>
+  fromSpec(spec: Record<string, any>): Array<Pick<WiringConnection, 'from' | 'to' | 'type'>>
+}
+
+const handlers: ConnectionTypeHandler[] = []
+
+/**
+ * Registers a handler; it replaces any handler of the same kind and is
+ * tried before the ones registered earlier
+ */
+export function registerConnectionType(handler: ConnectionTypeHandler): void {
+  const existing = handlers.findIndex(h => h.kind === handler.kind)
+  if (existing !== -1) handlers.splice(existing, 1)
+  handlers.unshift(handler)
+}
+
+export function unregisterConnectionType(kind: string): boolean {
+  const existing = handlers.findIndex(h => h.kind === kind)
+  if (existing !== -1) handlers.splice(existing, 1)
+  return existing !== -1
+}
+
+export function getConnectionType(kind: string): ConnectionTypeHandler | undefined {
+  return handlers.find(h => h.kind === kind)
+}
+
+export function connectionKinds(): string[] {
+  return handlers.map(h => h.kind)
+}
+
+/**
+ * Picks the handler for a connection: the annotated kind when set, else the
+ * most recently registered handler that matches
+ */
+export function resolveConnectionType(connection: WiringConnection, context: ConnectionContext): ConnectionTypeHandler {
+  const forced = connection.annotations?.[CONNECTION_KIND_ANNOTATION]
+  if (forced) {
+    const handler = getConnectionType(forced)
+    if (!handler) throw new Error(`Unknown connection kind '${forced}' on ${connection.from.device}-${connection.to.device}`)
+    return handler
+  }
+  const handler = handlers.find(h => h.matches(connection, context))
+  if (!handler) throw new Error(`No connection kind matches ${connection.from.device}-${connection.to.device}`)
+  return handler
+}
+
+export function connectionContext(diagram: WiringDiagram): ConnectionContext {
+  const roles = new Map<string, DeviceRole>([
+    ...diagram.devices.spines.map(d => [d.id, 'spine'] as const),
+    ...diagram.devices.leaves.map(d => [d.id, 'leaf'] as const),
+    ...diagram.devices.servers.map(d => [d.id, 'server'] as const)
+  ])
+  const links = new Map<string, WiringConnection[]>()
+  for (const connection of diagram.connections) {
+    for (const device of new Set([connection.from.device, connection.to.device])) {
+      links.set(device, [...(links.get(device) ?? []), connection])
+    }
+  }
+  return { roleOf: id => roles.get(id), linksOf: id => links.get(id) ?? [] }
+}
+
+// Upstream ports are written "<device>/<port>"
+export function portRef(device: string, port: string): string {
+  return `${device}/${port}`
+}
+
+export function parsePortRef(ref: string): { device: string; port: string } {
+  const slash = ref.indexOf('/')
+  return slash === -1 ? { device: ref, port: '' } : { device: ref.slice(0, slash), port: ref.slice(slash + 1) }
+}
+
+// Server end first, whichever way round the connection was recorded
+function serverSide(connection: WiringConnection, context: ConnectionContext) {
+  return context.roleOf(connection.from.device) === 'server'
+    ? { server: connection.from, other: connection.to }
+    : { server: connection.to, other: connection.from }
+}
+
+function serverLinks(connection: WiringConnection, context: ConnectionContext): WiringConnection[] {
+  return context.linksOf(serverSide(connection, context).server.device)
+}
+
+function isEndpoint(connection: WiringConnection, context: ConnectionContext): boolean {
+  const roles = [context.roleOf(connection.from.device), context.roleOf(connection.to.device)]
+  return roles.includes('server') && (roles.includes('leaf') || roles.includes('spine'))
+}
+
+const switchCount = (links: WiringConnection[], context: ConnectionContext) =>
+  new Set(links.map(l => serverSide(l, context).other.device)).size
+
+const serverLink = (connection: WiringConnection, context: ConnectionContext) => {
+  const { server, other } = serverSide(connection, context)
+  return { server: { port: portRef(server.device, server.port) }, switch: { port: portRef(other.device, other.port) } }
+}
+
+const endpointFromLink = (link: any): Pick<WiringConnection, 'from' | 'to' | 'type'> => ({
+  from: parsePortRef(link.server?.port ?? ''),
+  to: parsePortRef(link.switch?.port ?? ''),
+  type: 'endpoint'
+})
+
+// Multi-link kinds (bundled, MCLAG, ESLAG) are exported one cable per
+// Connection, each listing its own link
+const multiLinkEndpoint = (kind: string, matches: ConnectionTypeHandler['matches']): ConnectionTypeHandler => ({
+  kind,
+  matches,
+  toSpec: (connection, context) => ({ links: [serverLink(connection, context)] }),
+  fromSpec: spec => (spec.links ?? []).map(endpointFromLink)
+})
+
+// Switch end first for switch-to-outside kinds
+const switchSide = (connection: WiringConnection, context: ConnectionContext) =>
+  context.roleOf(connection.from.device) === undefined ? { sw: connection.to, peer: connection.from } : { sw: connection.from, peer: connection.to }
+
+export const BUILTIN_CONNECTION_TYPES: ConnectionTypeHandler[] = [
+  {
+    kind: 'management',
+    matches: () => false, // annotation only
+    toSpec: (connection, context) => {
+      const { sw, peer } = switchSide(connection, context)
+      return { link: { switch: { port: portRef(sw.device, sw.port) }, server: { port: portRef(peer.device, peer.port) } } }
+    },
+    fromSpec: spec => [{ from: parsePortRef(spec.link?.switch?.port ?? ''), to: parsePortRef(spec.link?.server?.port ?? ''), type: 'endpoint' }]
+  },
+  {
+    kind: 'external',
+    matches: (c, context) => [c.from.device, c.to.device].filter(d => context.roleOf(d) === undefined).length === 1,
+    toSpec: (connection, context) => {
+      const { sw } = switchSide(connection, context)
+      return { link: { switch: { port: portRef(sw.device, sw.port) } } }
+    },
+    fromSpec: spec => [{ from: parsePortRef(spec.link?.switch?.port ?? ''), to: { device: 'external', port: '' }, type: 'uplink' }]
+  },
+  {
+    kind: 'fabric',
+    matches: (c, context) => {
+      const roles = [context.roleOf(c.from.device), context.roleOf(c.to.device)]
+      return roles.includes('leaf') && roles.includes('spine')
+    },
+    toSpec: (connection, context) => {
+      const [leaf, spine] = context.roleOf(connection.from.device) === 'leaf'
+        ? [connection.from, connection.to]
+        : [connection.to, connection.from]
+      return { links: [{ spine: { port: portRef(spine.device, spine.port) }, leaf: { port: portRef(leaf.device, leaf.port) } }] }
+    },
+    fromSpec: spec => (spec.links ?? []).map((link: any) => ({
+      from: parsePortRef(link.leaf?.port ?? ''),
+      to: parsePortRef(link.spine?.port ?? ''),
+      type: 'uplink' as const
+    }))
+  },
+  multiLinkEndpoint('mclag', () => false), // annotation only; ESLAG is the default for multi-homing
+  multiLinkEndpoint('eslag', (c, context) => isEndpoint(c, context) && switchCount(serverLinks(c, context), context) > 1),
+  multiLinkEndpoint('bundled', (c, context) => {
+    const links = serverLinks(c, context)
+    return isEndpoint(c, context) && links.length > 1 && switchCount(links, context) === 1
+  }),
+  {
+    kind: 'unbundled',
+    matches: isEndpoint,
+    toSpec: (connection, context) => ({ link: serverLink(connection, context) }),
+    fromSpec: spec => spec.link ? [endpointFromLink(spec.link)] : []
+  }
+]
+
+// Registered last-to-first so the list above reads in match order
+for (const handler of [...BUILTIN_CONNECTION_TYPES].reverse()) registerConnectionType(handler)
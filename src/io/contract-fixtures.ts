@@ -0,0 +1,75 @@
+/**
+ * Frontend/Go contract fixtures
+ *
+ * Builds the shared JSON fixtures under contracts/fixtures that both the
+ * vitest suite and the Go tests under tools/ decode. Regenerate with
+ * `npm run fixtures:contract`; tests fail when the checked-in files drift
+ * from what this module produces.
+ */
+
+import { readFixtureProfiles } from '../ingest/fixtureFiles'
+import { allocateUplinks } from '../domain/allocator'
+import { buildWiring, validateWiring, type Wiring } from '../domain/wiring'
+import { canonicalJsonFile } from '../domain/canonical-hash'
+import type { FabricSpec, SwitchProfile } from '../app.types'
+
+export const CONTRACT_FIXTURES_DIR = 'contracts/fixtures'
+
+const FIXED_DATE = new Date('2024-01-01T00:00:00.000Z')
+
+// The switch profile fixtures, in whichever layout they were dumped
+const PROFILES_DIR = 'src/fixtures/switch-profiles'
+
+/**
+ * Returns fixture file contents keyed by path relative to CONTRACT_FIXTURES_DIR
+ */
+export function buildContractFixtures(): Record<string, string> {
+  const profiles = readFixtureProfiles(PROFILES_DIR) as SwitchProfile[]
+  const model = (modelId: string) => {
+    const profile = profiles.find(p => p.modelId === modelId)
+    if (!profile) throw new Error(`no ${modelId} fixture in ${PROFILES_DIR}`)
+    return profile
+  }
+  const ds2000 = model('celestica-ds2000')
+  const ds3000 = model('celestica-ds3000')
+  const byShortName = new Map<string, SwitchProfile>([
+    ['DS2000', ds2000],
+    ['DS3000', ds3000]
+  ])
+
+  const spec: FabricSpec = {
+    name: 'contract-fabric',
+    spineModelId: 'DS3000',
+    leafModelId: 'DS2000',
+    uplinksPerLeaf: 4,
+    endpointProfile: { name: 'Server', portsPerEndpoint: 1 },
+    endpointCount: 48
+  }
+  const allocation = allocateUplinks(
+    { uplinksPerLeaf: 4, leavesNeeded: 2, spinesNeeded: 2, endpointCount: 48 },
+    ds2000,
+    ds3000
+  )
+  const wiring = pinDate(buildWiring(spec, byShortName, allocation))
+
+  // Same design with a duplicated leaf, to pin the error format
+  const broken: Wiring = {
+    ...wiring,
+    devices: { ...wiring.devices, leaves: [...wiring.devices.leaves, wiring.devices.leaves[0]] }
+  }
+
+  const files: Record<string, string> = {
+    ...Object.fromEntries(profiles.map(p => [`profiles/${p.modelId}.json`, json(p)])),
+    'designs/two-leaf.spec.json': json(spec),
+    'designs/two-leaf.wiring.json': json(wiring),
+    'validation/two-leaf.json': json(validateWiring(wiring)),
+    'validation/duplicate-leaf.json': json(validateWiring(broken))
+  }
+  return files
+}
+
+function pinDate(wiring: Wiring): Wiring {
+  return { ...wiring, metadata: { ...wiring.metadata, generatedAt: FIXED_DATE } }
+}
+
+const json = canonicalJsonFile
@@ -1,5 +1,9 @@
 import * as yaml from 'js-yaml'
-import type { WiringDiagram } from '../app.types.js'
+import type { WiringDiagram, AssetInfo } from '../app.types.js'
+import { mergeLabels, userLabels } from '../domain/labels.js'
+import { LINK_SPEED_ANNOTATION } from '../domain/uplink-speeds.js'
+import { PLACEMENT_SEED_ANNOTATION, isValidPlacementSeed } from '../domain/placement-seed.js'
+import { connectionContext, getConnectionType, connectionKinds, resolveConnectionType, type ConnectionContext } from './connection-types.js'
 import type { 
   FabricDeploymentCRDs, 
   FabricCRD, 
@@ -14,6 +18,11 @@ import type {
  * Converts HNC WiringDiagram to upstream CRD format
  */
 
+// Asset identity is carried in annotations; switch serials and bound MACs
+// also go in spec.boot so the fabric can identify the box at boot (ZTP)
+export const SERIAL_NUMBER_ANNOTATION = 'hnc.githedgehog.com/serial-number'
+export const ASSET_TAG_ANNOTATION = 'hnc.githedgehog.com/asset-tag'
+
 export interface CRDYAMLs {
   fabric: string
   switches: string
@@ -81,7 +90,8 @@ export function convertWiringDiagramToFabricCRDs(
       },
       annotations: {
         'hnc.githedgehog.com/generated-at': diagram.metadata.generatedAt.toISOString(),
-        'hnc.githedgehog.com/total-devices': diagram.metadata.totalDevices.toString()
+        'hnc.githedgehog.com/total-devices': diagram.metadata.totalDevices.toString(),
+        ...(diagram.metadata.placementSeed !== undefined && { [PLACEMENT_SEED_ANNOTATION]: String(diagram.metadata.placementSeed) })
       }
     } : { name: sanitizeK8sName(diagram.metadata.fabricName), namespace },
     spec: {
@@ -114,9 +124,10 @@ export function convertWiringDiagramToFabricCRDs(
   }
 
   // Generate switch CRDs
+  const portSpeeds = linkPortSpeeds(diagram)
   const switchCRDs: HNCSwitchCRD[] = [
-    ...diagram.devices.spines.map(spine => createSwitchCRD(spine, 'spine', options)),
-    ...diagram.devices.leaves.map(leaf => createSwitchCRD(leaf, 'leaf', options))
+    ...diagram.devices.spines.map(spine => createSwitchCRD(spine, 'spine', options, portSpeeds.get(spine.id))),
+    ...diagram.devices.leaves.map(leaf => createSwitchCRD(leaf, 'leaf', options, portSpeeds.get(leaf.id)))
   ]
 
   // Generate server CRDs
@@ -125,8 +136,9 @@ export function convertWiringDiagramToFabricCRDs(
   )
 
   // Generate connection CRDs
+  const context = connectionContext(diagram)
   const connectionCRDs: HNCConnectionCRD[] = diagram.connections.map(connection =>
-    createConnectionCRD(connection, diagram, options)
+    createConnectionCRD(connection, diagram, context, options)
   )
 
   return {
@@ -143,7 +155,8 @@ export function convertWiringDiagramToFabricCRDs(
 function createSwitchCRD(
   switchData: any,
   role: 'spine' | 'leaf',
-  options: CRDSerializationOptions
+  options: CRDSerializationOptions,
+  portSpeeds?: Record<string, string>
 ): HNCSwitchCRD {
   const { namespace = 'default', generateK8sMetadata = true, preserveHNCExtensions = true } = options
 
@@ -153,16 +166,24 @@ function createSwitchCRD(
     metadata: generateK8sMetadata ? {
       name: switchData.id,
       namespace,
-      labels: {
+      labels: mergeLabels({
         'app.kubernetes.io/name': 'hnc-switch',
         'app.kubernetes.io/component': 'network-switch',
         'hnc.githedgehog.com/role': role,
         'hnc.githedgehog.com/model': switchData.model
-      }
+      }, switchData.labels),
+      ...annotationsWithAsset(switchData)
     } : { name: switchData.id, namespace },
     spec: {
       role,
       profile: `${switchData.model.toLowerCase()}-profile`,
+      ...(portSpeeds && { portSpeeds }),
+      ...((switchData.serialNumber || switchData.macAddress) && {
+        boot: {
+          ...(switchData.serialNumber && { serial: switchData.serialNumber }),
+          ...(switchData.macAddress && { mac: switchData.macAddress })
+        }
+      }),
       ...(preserveHNCExtensions && {
         hncMetadata: {
           fabricRole: role,
@@ -190,11 +211,12 @@ function createServerCRD(
     metadata: generateK8sMetadata ? {
       name: serverData.id,
       namespace,
-      labels: {
+      labels: mergeLabels({
         'app.kubernetes.io/name': 'hnc-server',
         'app.kubernetes.io/component': 'endpoint-server',
         'hnc.githedgehog.com/type': serverData.type || 'server'
-      }
+      }, serverData.labels),
+      ...annotationsWithAsset(serverData)
     } : { name: serverData.id, namespace },
     spec: {
       description: `Server ${serverData.id} (${serverData.type || 'server'})`,
@@ -216,6 +238,7 @@ function createServerCRD(
 function createConnectionCRD(
   connectionData: any,
   diagram: WiringDiagram,
+  context: ConnectionContext,
   options: CRDSerializationOptions
 ): HNCConnectionCRD {
   const { namespace = 'default', generateK8sMetadata = true, preserveHNCExtensions = true } = options
@@ -243,6 +266,7 @@ function createConnectionCRD(
   }
 
   const connectionName = `${connectionData.from.device}-${connectionData.to.device}-${connectionData.from.port}-${connectionData.to.port}`
+  const handler = resolveConnectionType(connectionData, context)
 
   return {
     apiVersion: (options.apiVersionOverride?.connection || 'wiring.githedgehog.com/v1beta1') as 'wiring.githedgehog.com/v1beta1',
@@ -250,22 +274,18 @@ function createConnectionCRD(
     metadata: generateK8sMetadata ? {
       name: sanitizeK8sName(connectionName),
       namespace,
-      labels: {
+      labels: mergeLabels({
         'app.kubernetes.io/name': 'hnc-connection',
         'app.kubernetes.io/component': 'network-connection',
         'hnc.githedgehog.com/type': connectionType,
+        'hnc.githedgehog.com/connection-kind': handler.kind,
         'hnc.githedgehog.com/from-device': connectionData.from.device,
         'hnc.githedgehog.com/to-device': connectionData.to.device
-      }
+      }, connectionData.labels),
+      ...(connectionData.annotations && { annotations: { ...connectionData.annotations } })
     } : { name: sanitizeK8sName(connectionName), namespace },
     spec: {
-      // Use unbundled connection type as the base CRD structure
-      unbundled: {
-        link: {
-          server: connectionData.from.device.includes('server') ? connectionData.from.device : connectionData.to.device,
-          switch: connectionData.from.device.includes('server') ? connectionData.to.device : connectionData.from.device
-        }
-      },
+      [handler.kind]: handler.toSpec(connectionData, context),
       ...(preserveHNCExtensions && {
         hncMetadata: {
           connectionType,
@@ -308,7 +328,9 @@ export function deserializeCRDsToWiringDiagram(yamls: CRDYAMLs): WiringDiagram {
       .map(s => ({
         id: s.metadata.name || '',
         model: s.spec.hncMetadata?.fabricRole === 'spine' ? 'DS3000' : 'DS3000',
-        ports: s.spec.hncMetadata?.downlinkPorts || 64
+        ports: s.spec.hncMetadata?.downlinkPorts || 64,
+        ...labelsFromMetadata(s.metadata),
+        ...assetFromCRD(s.metadata, s.spec.boot)
       }))
 
     const leaves = switchesData
@@ -316,14 +338,18 @@ export function deserializeCRDsToWiringDiagram(yamls: CRDYAMLs): WiringDiagram {
       .map(s => ({
         id: s.metadata.name || '',
         model: s.spec.hncMetadata?.fabricRole === 'leaf' ? 'DS2000' : 'DS2000', 
-        ports: (s.spec.hncMetadata?.endpointPorts || 44) + (s.spec.hncMetadata?.uplinkPorts || 4)
+        ports: (s.spec.hncMetadata?.endpointPorts || 44) + (s.spec.hncMetadata?.uplinkPorts || 4),
+        ...labelsFromMetadata(s.metadata),
+        ...assetFromCRD(s.metadata, s.spec.boot)
       }))
 
     // Extract servers
     const servers = serversData.map(s => ({
       id: s.metadata.name || '',
       type: s.spec.hncMetadata?.endpointType || 'server',
-      connections: s.spec.hncMetadata?.connectionCount || 1
+      connections: s.spec.hncMetadata?.connectionCount || 1,
+      ...labelsFromMetadata(s.metadata),
+      ...assetFromCRD(s.metadata)
     }))
 
     // Extract connections and convert back to HNC format
@@ -339,18 +365,16 @@ export function deserializeCRDsToWiringDiagram(yamls: CRDYAMLs): WiringDiagram {
             device: c.metadata.labels?.['hnc.githedgehog.com/to-device'] || '',
             port: hncMeta.portBinding.targetPort
           },
-          type: hncMeta.connectionType as 'uplink' | 'downlink' | 'endpoint'
+          type: hncMeta.connectionType as 'uplink' | 'downlink' | 'endpoint',
+          ...labelsFromMetadata(c.metadata)
         }
       }
 
-      // Fallback: try to infer from connection spec
-      const unbundled = c.spec.unbundled
-      if (unbundled && unbundled.link) {
-        return {
-          from: { device: unbundled.link.server || '', port: 'eth0' },
-          to: { device: unbundled.link.switch || '', port: '1/1' },
-          type: 'endpoint' as const
-        }
+      // Fallback: read the link from whichever registered kind the spec uses
+      const kind = connectionKinds().find(k => (c.spec as Record<string, any>)[k])
+      const [link] = kind ? getConnectionType(kind)!.fromSpec((c.spec as Record<string, any>)[kind]) : []
+      if (link) {
+        return { ...link, ...labelsFromMetadata(c.metadata) }
       }
 
       throw new Error(`Unable to parse connection: ${c.metadata.name}`)
@@ -361,13 +385,17 @@ export function deserializeCRDsToWiringDiagram(yamls: CRDYAMLs): WiringDiagram {
       ? new Date(fabricData.metadata.annotations['hnc.githedgehog.com/generated-at'])
       : new Date()
 
+    const seedAnnotation = fabricData.metadata.annotations?.[PLACEMENT_SEED_ANNOTATION]
+    const placementSeed = seedAnnotation === undefined ? undefined : Number(seedAnnotation)
+
     const diagram: WiringDiagram = {
       devices: { spines, leaves, servers },
       connections,
       metadata: {
         generatedAt,
         fabricName: fabricData.metadata.name || 'unnamed-fabric',
-        totalDevices: spines.length + leaves.length + servers.length
+        totalDevices: spines.length + leaves.length + servers.length,
+        ...(isValidPlacementSeed(placementSeed) && { placementSeed })
       }
     }
 
@@ -432,6 +460,51 @@ function sanitizeK8sName(name: string): string {
     .substring(0, 63)
 }
 
+// Switch port speeds from per-link speed annotations (mixed uplink speeds),
+// set on both ends of each link
+function linkPortSpeeds(diagram: WiringDiagram): Map<string, Record<string, string>> {
+  const speeds = new Map<string, Record<string, string>>()
+  for (const connection of diagram.connections) {
+    const speed = connection.annotations?.[LINK_SPEED_ANNOTATION]
+    if (!speed) continue
+    for (const end of [connection.from, connection.to]) {
+      speeds.set(end.device, { ...speeds.get(end.device), [end.port]: speed })
+    }
+  }
+  return speeds
+}
+
+// User labels and annotations from CRD metadata, omitting empty fields
+function labelsFromMetadata(metadata: { labels?: Record<string, string>; annotations?: Record<string, string> }) {
+  const labels = userLabels(metadata.labels)
+  const annotations = Object.entries(metadata.annotations || {})
+    .filter(([key]) => key !== SERIAL_NUMBER_ANNOTATION && key !== ASSET_TAG_ANNOTATION)
+  return {
+    ...(labels && { labels }),
+    ...(annotations.length > 0 && { annotations: Object.fromEntries(annotations) })
+  }
+}
+
+// User annotations plus asset annotations, omitted when both are empty
+function annotationsWithAsset(device: AssetInfo & { annotations?: Record<string, string> }) {
+  const annotations = {
+    ...device.annotations,
+    ...(device.serialNumber && { [SERIAL_NUMBER_ANNOTATION]: device.serialNumber }),
+    ...(device.assetTag && { [ASSET_TAG_ANNOTATION]: device.assetTag })
+  }
+  return Object.keys(annotations).length > 0 ? { annotations } : {}
+}
+
+function assetFromCRD(metadata: { annotations?: Record<string, string> }, boot?: Record<string, any>): AssetInfo {
+  const serialNumber = boot?.serial || metadata.annotations?.[SERIAL_NUMBER_ANNOTATION]
+  const assetTag = metadata.annotations?.[ASSET_TAG_ANNOTATION]
+  return {
+    ...(serialNumber && { serialNumber }),
+    ...(assetTag && { assetTag }),
+    ...(boot?.mac && { macAddress: boot.mac })
+  }
+}
+
 /**
  * Semantic validation for round-trip testing
  */
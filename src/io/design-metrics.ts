@@ -0,0 +1,119 @@
+/**
+ * Design KPI Metrics - HNC v0.6
+ * Per-design KPIs (port utilization, oversubscription, cost, power and
+ * validation findings) in the Prometheus text exposition format for
+ * scraping, and as a time series over saved revisions (JSON) for
+ * dashboards that track design health across the fleet.
+ */
+
+import { compileBOM } from '../domain/bom-compiler'
+import { DEFAULT_SWITCH_POWER_WATTS, maxOversubscription } from '../domain/scenarios'
+import { evaluateSpareCapacity } from '../domain/spare-capacity'
+import { validateWiring, wiringToWiringDiagram, type Wiring } from '../domain/wiring'
+import type { SwitchProfile } from '../app.types'
+
+export interface DesignKpiOptions {
+  profiles: Map<string, SwitchProfile>
+  switchPowerWatts?: Record<string, number> // modelId -> typical watts
+}
+
+export interface DesignKpis {
+  leafPortUtilization: number // share of leaf endpoint ports in use, 0-1
+  spinePortUtilization: number // share of spine fabric ports in use, 0-1
+  maxOversubscription: number // worst leaf, endpoint Gbps : uplink Gbps
+  cost: number
+  powerWatts: number
+  validationErrors: number
+  validationWarnings: number
+}
+
+export interface DesignKpiPoint extends DesignKpis {
+  revision: string
+  savedAt?: string // ISO 8601
+}
+
+export interface DesignKpiSeries {
+  design: string
+  points: DesignKpiPoint[]
+  changes: Array<{ revision: string; metric: keyof DesignKpis; before: number; after: number }>
+}
+
+// Metric name and help text for each KPI, all gauges; names follow the
+// Prometheus conventions of base units and a _ratio suffix for shares
+const METRICS: Array<{ kpi: keyof DesignKpis; name: string; help: string }> = [
+  { kpi: 'leafPortUtilization', name: 'hnc_design_leaf_port_utilization_ratio', help: 'Share of leaf endpoint ports in use' },
+  { kpi: 'spinePortUtilization', name: 'hnc_design_spine_port_utilization_ratio', help: 'Share of spine fabric ports in use' },
+  { kpi: 'maxOversubscription', name: 'hnc_design_max_oversubscription_ratio', help: 'Endpoint to uplink bandwidth of the most oversubscribed leaf' },
+  { kpi: 'cost', name: 'hnc_design_cost_dollars', help: 'Bill of materials list price' },
+  { kpi: 'powerWatts', name: 'hnc_design_power_watts', help: 'Typical switch power draw' },
+  { kpi: 'validationErrors', name: 'hnc_design_validation_errors', help: 'Wiring validation errors' },
+  { kpi: 'validationWarnings', name: 'hnc_design_validation_warnings', help: 'Wiring validation warnings' }
+]
+
+/**
+ * Computes the KPIs of one design. Switch models without a power figure
+ * count as 0 W, as in scenario comparisons.
+ */
+export function designKpis(wiring: Wiring, options: DesignKpiOptions): DesignKpis {
+  const { profiles, switchPowerWatts = DEFAULT_SWITCH_POWER_WATTS } = options
+  const spare = evaluateSpareCapacity(wiring, profiles, {})
+  const validation = validateWiring(wiring, { profiles })
+  const switches = [...wiring.devices.spines, ...wiring.devices.leaves]
+  return {
+    leafPortUtilization: utilization(spare.leaves),
+    spinePortUtilization: utilization(spare.spines),
+    maxOversubscription: maxOversubscription(wiring, profiles),
+    cost: compileBOM(wiringToWiringDiagram(wiring)).summary.totalCost,
+    powerWatts: switches.reduce((sum, s) => sum + (switchPowerWatts[s.modelId] ?? 0), 0),
+    validationErrors: validation.errors.length + spare.errors.length,
+    validationWarnings: validation.warnings.length
+  }
+}
+
+/**
+ * KPIs per revision, oldest first, with every KPI change between
+ * consecutive revisions listed
+ */
+export function designKpiSeries(
+  design: string,
+  revisions: Array<{ revision: string; savedAt?: string; wiring: Wiring }>,
+  options: DesignKpiOptions
+): DesignKpiSeries {
+  const points = revisions.map(({ revision, savedAt, wiring }) => ({
+    revision,
+    ...(savedAt ? { savedAt } : {}),
+    ...designKpis(wiring, options)
+  }))
+  const changes = points.slice(1).flatMap((point, i) =>
+    METRICS.filter(({ kpi }) => point[kpi] !== points[i][kpi])
+      .map(({ kpi }) => ({ revision: point.revision, metric: kpi, before: points[i][kpi], after: point[kpi] }))
+  )
+  return { design, points, changes }
+}
+
+/**
+ * Formats designs as Prometheus text exposition, one gauge family per KPI
+ * labeled by design and, when known, the revision measured
+ */
+export function formatPrometheus(samples: Array<{ design: string; revision?: string; kpis: DesignKpis }>): string {
+  const lines: string[] = []
+  for (const { kpi, name, help } of METRICS) {
+    lines.push(`# HELP ${name} ${help}`, `# TYPE ${name} gauge`)
+    for (const sample of samples) {
+      const labels = [`design="${escapeLabel(sample.design)}"`]
+      if (sample.revision) labels.push(`revision="${escapeLabel(sample.revision)}"`)
+      lines.push(`${name}{${labels.join(',')}} ${sample.kpis[kpi]}`)
+    }
+  }
+  return lines.join('\n') + '\n'
+}
+
+function utilization(devices: Array<{ total: number; used: number }>): number {
+  const total = devices.reduce((sum, d) => sum + d.total, 0)
+  const used = devices.reduce((sum, d) => sum + Math.min(d.used, d.total), 0)
+  return total === 0 ? 0 : Math.round(used / total * 1000) / 1000
+}
+
+function escapeLabel(value: string): string {
+  return value.replace(/\\/g, '\\\\').replace(/"/g, '\\"').replace(/\n/g, '\\n')
+}
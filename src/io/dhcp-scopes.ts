@@ -0,0 +1,151 @@
+/**
+ * DHCP Scope Planning - HNC v0.6
+ * Checks the DHCP ranges VPC subnets declare against the subnet size and
+ * the addresses the fabric keeps for itself (network, gateway, broadcast,
+ * static route next hops), and renders the scopes as Kea DHCPv4 config so
+ * the DHCP service matches the design.
+ */
+
+import type { VPCYAMLConfig } from './vpc-yaml'
+
+export interface DhcpScope {
+  vpc: string
+  subnet: string
+  cidr: string
+  gateway: string
+  start: string
+  end: string
+  size: number // addresses in the pool
+  vlan?: number
+  derived: boolean // start or end filled in from the subnet
+}
+
+export interface DhcpScopePlan {
+  scopes: DhcpScope[]
+  errors: string[]
+  warnings: string[]
+}
+
+export interface KeaOptions {
+  interfaces?: string[] // default: every interface
+  validLifetime?: number // seconds, default 3600
+}
+
+/**
+ * Scopes for every subnet with DHCP enabled. A missing start or end
+ * defaults to the first or last usable host that is not the gateway;
+ * subnets without a valid CIDR are left to validateVPCConfiguration.
+ */
+export function planDhcpScopes(vpcs: VPCYAMLConfig[]): DhcpScopePlan {
+  const scopes: DhcpScope[] = []
+  const errors: string[] = []
+  const warnings: string[] = []
+
+  for (const vpc of vpcs) {
+    const nextHops = (vpc.spec.staticRoutes ?? []).map(r => r.nextHop)
+    for (const [name, subnet] of Object.entries(vpc.spec.subnets ?? {})) {
+      const label = `VPC ${vpc.metadata.name}, subnet ${name}`
+      const dhcp = subnet.dhcp
+      if (!dhcp) continue
+      if (!dhcp.enable) {
+        if (dhcp.start || dhcp.end) warnings.push(`${label}: DHCP range is set but DHCP is disabled; the range is ignored`)
+        continue
+      }
+
+      const net = parseCidr(subnet.cidr)
+      if (!net) continue
+      if (net.prefix > 30) {
+        errors.push(`${label}: /${net.prefix} is too small for DHCP; it needs at least a /30`)
+        continue
+      }
+      const first = net.network + 1
+      const last = net.broadcast - 1
+      const inside = (ip: number) => ip >= first && ip <= last
+
+      const gateway = subnet.gateway ? parseIp(subnet.gateway) : first
+      if (gateway === undefined || !inside(gateway)) {
+        errors.push(`${label}: gateway ${subnet.gateway} is not a usable address in ${subnet.cidr}`)
+        continue
+      }
+
+      const bound = (value: string | undefined, which: 'start' | 'end', fallback: number): number | undefined => {
+        if (!value) return fallback
+        const ip = parseIp(value)
+        if (ip === undefined) errors.push(`${label}: DHCP ${which} ${value} is not an IPv4 address`)
+        else if (ip === net.network) errors.push(`${label}: DHCP ${which} ${value} is the network address`)
+        else if (ip === net.broadcast) errors.push(`${label}: DHCP ${which} ${value} is the broadcast address`)
+        else if (!inside(ip)) errors.push(`${label}: DHCP ${which} ${value} is outside ${subnet.cidr}`)
+        else return ip
+        return undefined
+      }
+      const start = bound(dhcp.start, 'start', gateway === first ? first + 1 : first)
+      const end = bound(dhcp.end, 'end', gateway === last ? last - 1 : last)
+      if (start === undefined || end === undefined) continue
+
+      if (start > end) {
+        errors.push(`${label}: DHCP start ${intToIp(start)} is after end ${intToIp(end)}`)
+        continue
+      }
+      const reserved = [
+        { ip: gateway, what: 'gateway' },
+        ...nextHops.map(hop => ({ ip: parseIp(hop), what: 'static route next hop' }))
+      ].filter(r => r.ip !== undefined && r.ip >= start && r.ip <= end)
+      if (reserved.length > 0) {
+        errors.push(`${label}: DHCP range ${intToIp(start)}-${intToIp(end)} includes the ${reserved.map(r => `${r.what} ${intToIp(r.ip!)}`).join(', ')}`)
+        continue
+      }
+
+      scopes.push({
+        vpc: vpc.metadata.name,
+        subnet: name,
+        cidr: `${intToIp(net.network)}/${net.prefix}`,
+        gateway: intToIp(gateway),
+        start: intToIp(start),
+        end: intToIp(end),
+        size: end - start + 1,
+        ...(subnet.vlan !== undefined && { vlan: subnet.vlan }),
+        derived: !dhcp.start || !dhcp.end
+      })
+    }
+  }
+  return { scopes, errors, warnings }
+}
+
+/**
+ * Kea DHCPv4 configuration with one subnet4 entry per scope; the VPC and
+ * subnet names travel in user-context so leases can be traced back
+ */
+export function renderKeaDhcp4(scopes: DhcpScope[], options: KeaOptions = {}): string {
+  const config = {
+    Dhcp4: {
+      'interfaces-config': { interfaces: options.interfaces ?? ['*'] },
+      'valid-lifetime': options.validLifetime ?? 3600,
+      subnet4: scopes.map((scope, i) => ({
+        id: i + 1,
+        subnet: scope.cidr,
+        pools: [{ pool: `${scope.start} - ${scope.end}` }],
+        'option-data': [{ name: 'routers', data: scope.gateway }],
+        'user-context': { vpc: scope.vpc, subnet: scope.subnet, ...(scope.vlan !== undefined && { vlan: scope.vlan }) }
+      }))
+    }
+  }
+  return JSON.stringify(config, null, 2) + '\n'
+}
+
+function parseCidr(cidr: string): { network: number; broadcast: number; prefix: number } | undefined {
+  const [ip, bits, ...rest] = (cidr ?? '').split('/')
+  const addr = parseIp(ip)
+  const prefix = Number(bits)
+  if (addr === undefined || rest.length > 0 || !/^\d{1,2}$/.test(bits ?? '') || prefix > 32) return undefined
+  const size = 2 ** (32 - prefix)
+  const network = Math.floor(addr / size) * size
+  return { network, broadcast: network + size - 1, prefix }
+}
+
+function parseIp(ip: string): number | undefined {
+  const octets = (ip ?? '').split('.')
+  if (octets.length !== 4 || octets.some(o => !/^\d{1,3}$/.test(o) || Number(o) > 255)) return undefined
+  return octets.reduce((n, o) => n * 256 + Number(o), 0)
+}
+
+const intToIp = (n: number): string => [24, 16, 8, 0].map(s => Math.floor(n / 2 ** s) % 256).join('.')
@@ -0,0 +1,98 @@
+/**
+ * Encryption at rest for persisted designs - HNC v0.6
+ *
+ * AES-256-GCM via Web Crypto, so the same code runs in Node and the browser.
+ * Encrypted files are stored as a single-line envelope:
+ *
+ *   hnc-enc:v1:<base64 iv>:<base64 ciphertext+tag>
+ *
+ * Keys come from HNC_ENCRYPTION_KEY (base64, 32 bytes) or from a KMS-backed
+ * provider that returns the data key.
+ */
+
+export const ENVELOPE_PREFIX = 'hnc-enc:v1:'
+
+export interface EncryptionKeyProvider {
+  /** Raw 256-bit data key */
+  getKey(): Promise<Uint8Array>
+}
+
+/**
+ * Key provider reading a base64 key from HNC_ENCRYPTION_KEY.
+ * Returns undefined when the variable is unset, i.e. encryption is off.
+ */
+export function envKeyProvider(env: Record<string, string | undefined> = typeof process !== 'undefined' ? process.env : {}): EncryptionKeyProvider | undefined {
+  const encoded = env.HNC_ENCRYPTION_KEY
+  if (!encoded) return undefined
+  return {
+    getKey: async () => checkKey(fromBase64(encoded.trim()), 'HNC_ENCRYPTION_KEY')
+  }
+}
+
+/**
+ * Key provider for KMS-backed keys. fetchDataKey should return the plaintext
+ * data key (e.g. from a KMS Decrypt call on a wrapped key); it is called once
+ * and cached for the lifetime of the provider.
+ */
+export function kmsKeyProvider(fetchDataKey: () => Promise<Uint8Array>): EncryptionKeyProvider {
+  let cached: Promise<Uint8Array> | undefined
+  return {
+    getKey: () => {
+      cached ??= fetchDataKey().then(key => checkKey(key, 'KMS data key'))
+      return cached
+    }
+  }
+}
+
+export function isEncrypted(data: string): boolean {
+  return data.startsWith(ENVELOPE_PREFIX)
+}
+
+/**
+ * Encrypts a UTF-8 string into an envelope
+ */
+export async function encryptString(plaintext: string, provider: EncryptionKeyProvider): Promise<string> {
+  const key = await importKey(await provider.getKey())
+  const iv = crypto.getRandomValues(new Uint8Array(12))
+  const ciphertext = await crypto.subtle.encrypt({ name: 'AES-GCM', iv }, key, new TextEncoder().encode(plaintext))
+  return `${ENVELOPE_PREFIX}${toBase64(iv)}:${toBase64(new Uint8Array(ciphertext))}\n`
+}
+
+/**
+ * Decrypts an envelope produced by encryptString
+ */
+export async function decryptString(envelope: string, provider: EncryptionKeyProvider): Promise<string> {
+  const parts = envelope.trim().slice(ENVELOPE_PREFIX.length).split(':')
+  if (!isEncrypted(envelope) || parts.length !== 2) {
+    throw new Error('Not an HNC encryption envelope')
+  }
+  const key = await importKey(await provider.getKey())
+  try {
+    const plaintext = await crypto.subtle.decrypt({ name: 'AES-GCM', iv: fromBase64(parts[0]) }, key, fromBase64(parts[1]))
+    return new TextDecoder().decode(plaintext)
+  } catch {
+    throw new Error('Decryption failed: wrong key or corrupted data')
+  }
+}
+
+function importKey(raw: Uint8Array): Promise<CryptoKey> {
+  return crypto.subtle.importKey('raw', raw as Uint8Array<ArrayBuffer>, { name: 'AES-GCM' }, false, ['encrypt', 'decrypt'])
+}
+
+function checkKey(key: Uint8Array, source: string): Uint8Array {
+  if (key.length !== 32) {
+    throw new Error(`${source} must be 32 bytes for AES-256-GCM, got ${key.length}`)
+  }
+  return key
+}
+
+export function toBase64(bytes: Uint8Array): string {
+  let binary = ''
+  // Chunked to stay under argument limits for large designs
+  for (let i = 0; i < bytes.length; i += 0x8000) {
+    binary += String.fromCharCode(...bytes.subarray(i, i + 0x8000))
+  }
+  return btoa(binary)
+}
+
+export const fromBase64 = (encoded: string): Uint8Array<ArrayBuffer> => Uint8Array.from(atob(encoded), c => c.charCodeAt(0))
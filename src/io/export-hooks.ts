@@ -0,0 +1,113 @@
+/**
+ * Export Hooks - HNC v0.6
+ * Runs user-provided executables before and after an export bundle is
+ * sealed: pre hooks may rewrite or add artifacts (company headers, extra
+ * files), post hooks see the final bundle (push to internal systems). What
+ * each hook printed, its exit code and the files it touched are recorded in
+ * the bundle manifest.
+ */
+
+export type HookStage = 'pre' | 'post'
+
+export interface ExportHook {
+  stage: HookStage
+  command: string
+  args?: string[]
+}
+
+export interface HookExecution {
+  exitCode: number
+  stdout: string
+  stderr: string
+}
+
+export interface HookRecord {
+  stage: HookStage
+  command: string
+  args: string[]
+  exitCode: number
+  stdout: string
+  stderr: string
+  truncated?: boolean // stdout or stderr was cut to MAX_HOOK_OUTPUT
+  durationMs: number
+  added: string[]
+  modified: string[]
+  removed: string[]
+}
+
+export interface HookRunResult {
+  records: HookRecord[]
+  errors: string[]
+}
+
+// Per stream, so a chatty hook cannot bloat the manifest
+export const MAX_HOOK_OUTPUT = 4096
+
+/**
+ * Runs the hooks of one stage in order. exec runs a hook against the
+ * bundle; snapshot reads the bundle's files so changes can be attributed
+ * to the hook that made them. Stops at the first hook that exits non-zero.
+ */
+export async function runExportHooks(
+  stage: HookStage,
+  hooks: ExportHook[],
+  exec: (hook: ExportHook) => Promise<HookExecution> | HookExecution,
+  snapshot: () => Record<string, string>
+): Promise<HookRunResult> {
+  const records: HookRecord[] = []
+  for (const hook of hooks.filter(h => h.stage === stage)) {
+    const before = snapshot()
+    const started = Date.now()
+    let result: HookExecution
+    try {
+      result = await exec(hook)
+    } catch (error) {
+      result = { exitCode: -1, stdout: '', stderr: error instanceof Error ? error.message : String(error) }
+    }
+    const stdout = clip(result.stdout)
+    const stderr = clip(result.stderr)
+    records.push({
+      stage,
+      command: hook.command,
+      args: hook.args ?? [],
+      exitCode: result.exitCode,
+      stdout: stdout.text,
+      stderr: stderr.text,
+      ...((stdout.truncated || stderr.truncated) && { truncated: true }),
+      durationMs: Date.now() - started,
+      ...diffFiles(before, snapshot())
+    })
+    if (result.exitCode !== 0) {
+      return { records, errors: [`${stage} hook ${hook.command} exited with code ${result.exitCode}`] }
+    }
+  }
+  return { records, errors: [] }
+}
+
+/**
+ * Parses repeated --pre-hook/--post-hook values: a command line split on
+ * whitespace, e.g. "./add-header.sh --owner netops"
+ */
+export function parseHook(stage: HookStage, commandLine: string): ExportHook {
+  const [command, ...args] = commandLine.trim().split(/\s+/)
+  if (!command) throw new Error(`Empty ${stage} hook`)
+  return { stage, command, ...(args.length > 0 && { args }) }
+}
+
+/**
+ * Paths added, modified and removed between two snapshots, each sorted
+ */
+export function diffFiles(before: Record<string, string>, after: Record<string, string>): Pick<HookRecord, 'added' | 'modified' | 'removed'> {
+  const paths = (files: Record<string, string>) => Object.keys(files).sort()
+  return {
+    added: paths(after).filter(p => !(p in before)),
+    modified: paths(after).filter(p => p in before && before[p] !== after[p]),
+    removed: paths(before).filter(p => !(p in after))
+  }
+}
+
+function clip(text: string): { text: string; truncated: boolean } {
+  return text.length > MAX_HOOK_OUTPUT
+    ? { text: text.slice(0, MAX_HOOK_OUTPUT), truncated: true }
+    : { text, truncated: false }
+}
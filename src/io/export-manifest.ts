@@ -0,0 +1,114 @@
+/**
+ * Export Manifest - HNC v0.6
+ * Records a SHA-256 for every exported artifact, and for each device
+ * document inside multi-document YAML, so a later verify pinpoints which
+ * file or switch was edited after export. Export hooks that ran on the
+ * bundle are recorded alongside.
+ */
+
+import * as yaml from 'js-yaml'
+import { sha256 } from '../domain/canonical-hash.js'
+import type { HookRecord } from './export-hooks.js'
+
+export const MANIFEST_FILE = 'manifest.json'
+
+export interface ManifestEntry {
+  path: string
+  device?: string // metadata.name of one document in the file
+  sha256: string
+  bytes: number
+}
+
+export interface ExportManifest {
+  version: 1
+  fabric: string
+  generatedAt: string
+  algorithm: 'sha256'
+  artifacts: ManifestEntry[]
+  hooks?: HookRecord[]
+}
+
+export interface ManifestVerification {
+  ok: boolean
+  modified: string[] // 'path' or 'path#device'
+  missing: string[]
+  unexpected: string[] // files not in the manifest
+}
+
+/**
+ * Hashes every file, plus each named document of YAML files, in path order
+ */
+export async function buildExportManifest(
+  fabric: string,
+  files: Record<string, string>,
+  options: { generatedAt?: Date; hooks?: HookRecord[] } = {}
+): Promise<ExportManifest> {
+  const artifacts: ManifestEntry[] = []
+  for (const path of Object.keys(files).filter(p => p !== MANIFEST_FILE).sort()) {
+    artifacts.push(await entry(path, files[path]))
+    for (const doc of deviceDocuments(path, files[path])) {
+      artifacts.push({ ...(await entry(path, doc.text)), device: doc.device })
+    }
+  }
+  return {
+    version: 1,
+    fabric,
+    generatedAt: (options.generatedAt ?? new Date()).toISOString(),
+    algorithm: 'sha256',
+    artifacts,
+    ...(options.hooks && options.hooks.length > 0 && { hooks: options.hooks })
+  }
+}
+
+/**
+ * Compares files against the manifest. Device entries are checked only
+ * when their file changed, to name the edited documents.
+ */
+export async function verifyExportManifest(manifest: ExportManifest, files: Record<string, string>): Promise<ManifestVerification> {
+  const modified: string[] = []
+  const missing: string[] = []
+  const recorded = new Set(manifest.artifacts.map(a => a.path))
+
+  for (const artifact of manifest.artifacts.filter(a => a.device === undefined)) {
+    const content = files[artifact.path]
+    if (content === undefined) {
+      missing.push(artifact.path)
+      continue
+    }
+    if ((await sha256(content)) === artifact.sha256) continue
+
+    const devices = manifest.artifacts.filter(a => a.path === artifact.path && a.device !== undefined)
+    const current = new Map(deviceDocuments(artifact.path, content).map(d => [d.device, d.text]))
+    const changed: string[] = []
+    for (const device of devices) {
+      const text = current.get(device.device!)
+      const label = `${artifact.path}#${device.device}`
+      if (text === undefined) missing.push(label)
+      else if ((await sha256(text)) !== device.sha256) changed.push(label)
+    }
+    modified.push(...(changed.length > 0 ? changed : [artifact.path]))
+  }
+
+  const unexpected = Object.keys(files).filter(p => p !== MANIFEST_FILE && !recorded.has(p)).sort()
+  return { ok: modified.length + missing.length + unexpected.length === 0, modified, missing, unexpected }
+}
+
+async function entry(path: string, content: string): Promise<ManifestEntry> {
+  return { path, sha256: await sha256(content), bytes: new TextEncoder().encode(content).length }
+}
+
+// Named documents of a multi-document YAML file; other files have none
+function deviceDocuments(path: string, content: string): Array<{ device: string; text: string }> {
+  if (!/\.ya?ml$/.test(path)) return []
+  const docs = content.split(/^---\s*$/m).filter(text => text.trim() !== '')
+  if (docs.length < 2) return []
+  return docs.flatMap(text => {
+    let name: unknown
+    try {
+      name = (yaml.load(text) as { metadata?: { name?: unknown } } | undefined)?.metadata?.name
+    } catch {
+      return []
+    }
+    return typeof name === 'string' ? [{ device: name, text }] : []
+  })
+}
@@ -0,0 +1,213 @@
+/**
+ * User-supplied export templates
+ *
+ * Renders custom export formats (CMDB CSVs, vendor configs, ...) from
+ * template files in a small subset of the Go text/template action syntax:
+ *
+ *   {{ .field.path }}  {{ . }}  {{ $.root }}  {{ .x | upper }}
+ *   {{ range .list }}...{{ else }}...{{ end }}
+ *   {{ if .x }}...{{ else }}...{{ end }}   {{ with .x }}...{{ end }}
+ *   {{- trims preceding whitespace, -}} trims following whitespace
+ *
+ * Functions: len, upper, lower, join <sep>, quote, csv, default <value>.
+ * These are this renderer's own, not Go builtins, and take the piped value
+ * first, so templates using them do not run under Go. Anything else Go
+ * accepts (else if, variables, define/template/block, function calls
+ * without a pipe) is a TemplateError rather than being misrendered. Maps
+ * range in sorted key order, as in Go. For real text/template rendering
+ * use `hnc export -template`.
+ */
+
+type Node =
+  | { kind: 'text'; text: string }
+  | { kind: 'value'; expr: string; line: number }
+  | { kind: 'range' | 'if' | 'with'; expr: string; body: Node[]; alt: Node[]; line: number }
+
+export class TemplateError extends Error {
+  constructor(message: string, public line: number) {
+    super(`line ${line}: ${message}`)
+    this.name = 'TemplateError'
+  }
+}
+
+const FUNCS: Record<string, (value: unknown, arg?: string) => unknown> = {
+  len: v => (Array.isArray(v) || typeof v === 'string' ? v.length : v && typeof v === 'object' ? Object.keys(v).length : 0),
+  upper: v => String(v ?? '').toUpperCase(),
+  lower: v => String(v ?? '').toLowerCase(),
+  join: (v, sep = ',') => (Array.isArray(v) ? v.map(String).join(sep) : String(v ?? '')),
+  quote: v => JSON.stringify(String(v ?? '')),
+  csv: v => {
+    const s = String(v ?? '')
+    return /[",\n]/.test(s) ? `"${s.replace(/"/g, '""')}"` : s
+  },
+  default: (v, arg) => (v === undefined || v === null || v === '' ? arg ?? '' : v)
+}
+
+/**
+ * Renders a template against the export context (usually the design)
+ */
+export function renderExportTemplate(source: string, data: unknown): string {
+  const nodes = parse(tokenize(source))
+  return execute(nodes, data, data)
+}
+
+/** Go actions the renderer does not implement */
+const UNSUPPORTED = ['define', 'template', 'block', 'break', 'continue']
+
+interface Token {
+  kind: 'text' | 'action'
+  value: string
+  line: number
+}
+
+function tokenize(source: string): Token[] {
+  const tokens: Token[] = []
+  const re = /\{\{(-?)\s*([\s\S]*?)\s*(-?)\}\}/g
+  let last = 0
+  let trimNext = false
+  let match: RegExpExecArray | null
+
+  while ((match = re.exec(source)) !== null) {
+    let text = source.slice(last, match.index)
+    if (trimNext) text = text.replace(/^\s+/, '')
+    if (match[1] === '-') text = text.replace(/\s+$/, '')
+    if (text) tokens.push({ kind: 'text', value: text, line: lineAt(source, last) })
+    tokens.push({ kind: 'action', value: match[2], line: lineAt(source, match.index) })
+    trimNext = match[3] === '-'
+    last = re.lastIndex
+  }
+  let tail = source.slice(last)
+  if (trimNext) tail = tail.replace(/^\s+/, '')
+  if (tail) tokens.push({ kind: 'text', value: tail, line: lineAt(source, last) })
+  return tokens
+}
+
+function parse(tokens: Token[]): Node[] {
+  let pos = 0
+
+  const parseList = (terminators: string[]): { nodes: Node[]; end: string; line: number } => {
+    const nodes: Node[] = []
+    while (pos < tokens.length) {
+      const token = tokens[pos++]
+      if (token.kind === 'text') {
+        nodes.push({ kind: 'text', text: token.value })
+        continue
+      }
+      if (token.value.startsWith('/*')) continue // comment
+      const [keyword, ...rest] = token.value.split(/\s+/)
+      const unquoted = token.value.replace(/"(?:[^"\\]|\\.)*"/g, '""')
+      if ((keyword === 'else' && rest.length > 0) || UNSUPPORTED.includes(keyword) ||
+        /^\$\w/.test(keyword) || /(^|\s):?=(\s|$)/.test(unquoted)) {
+        throw new TemplateError(`unsupported action {{ ${token.value} }}`, token.line)
+      }
+      if (terminators.includes(keyword)) {
+        return { nodes, end: keyword, line: token.line }
+      }
+      if (keyword === 'range' || keyword === 'if' || keyword === 'with') {
+        const body = parseList(['else', 'end'])
+        const alt = body.end === 'else' ? parseList(['end']) : { nodes: [] as Node[], end: 'end', line: body.line }
+        if (alt.end !== 'end') throw new TemplateError(`unterminated ${keyword}`, token.line)
+        nodes.push({ kind: keyword, expr: rest.join(' '), body: body.nodes, alt: alt.nodes, line: token.line })
+        continue
+      }
+      if (keyword === 'end' || keyword === 'else') {
+        throw new TemplateError(`unexpected {{ ${keyword} }}`, token.line)
+      }
+      nodes.push({ kind: 'value', expr: token.value, line: token.line })
+    }
+    if (terminators.length > 0) {
+      throw new TemplateError(`missing {{ ${terminators[terminators.length - 1]} }}`, tokens[tokens.length - 1]?.line ?? 1)
+    }
+    return { nodes, end: '', line: 0 }
+  }
+
+  return parseList([]).nodes
+}
+
+function execute(nodes: Node[], dot: unknown, root: unknown): string {
+  let out = ''
+  for (const node of nodes) {
+    switch (node.kind) {
+      case 'text':
+        out += node.text
+        break
+      case 'value': {
+        const value = evaluate(node.expr, dot, root, node.line)
+        out += format(value)
+        break
+      }
+      case 'if': {
+        const value = evaluate(node.expr, dot, root, node.line)
+        out += execute(truthy(value) ? node.body : node.alt, dot, root)
+        break
+      }
+      case 'with': {
+        const value = evaluate(node.expr, dot, root, node.line)
+        out += truthy(value) ? execute(node.body, value, root) : execute(node.alt, dot, root)
+        break
+      }
+      case 'range': {
+        const value = evaluate(node.expr, dot, root, node.line)
+        const items = Array.isArray(value) ? value
+          : value && typeof value === 'object'
+            ? Object.keys(value).sort().map(key => (value as Record<string, unknown>)[key])
+            : []
+        out += items.length > 0 ? items.map(item => execute(node.body, item, root)).join('') : execute(node.alt, dot, root)
+        break
+      }
+    }
+  }
+  return out
+}
+
+function evaluate(expr: string, dot: unknown, root: unknown, line: number): unknown {
+  const [head, ...pipes] = splitPipeline(expr)
+  let value = resolve(head, dot, root, line)
+  for (const pipe of pipes) {
+    const [name, ...args] = pipe.split(/\s+/)
+    const fn = FUNCS[name]
+    if (!fn) throw new TemplateError(`function "${name}" not defined`, line)
+    value = fn(value, args.length > 0 ? unquote(args.join(' ')) : undefined)
+  }
+  return value
+}
+
+/** Splits a pipeline on the | outside quoted strings */
+function splitPipeline(expr: string): string[] {
+  return (expr.match(/(?:"(?:[^"\\]|\\.)*"|[^|"])+/g) ?? ['']).map(s => s.trim())
+}
+
+function resolve(path: string, dot: unknown, root: unknown, line: number): unknown {
+  if (/^".*"$/.test(path)) return unquote(path)
+  if (/^-?\d+(\.\d+)?$/.test(path)) return Number(path)
+  if (path === '.') return dot
+  if (path === '$') return root
+
+  let base: unknown
+  let rest: string
+  if (/\s/.test(path)) {
+    throw new TemplateError(`cannot evaluate "${path}"`, line)
+  } else if (path.startsWith('$.')) {
+    base = root
+    rest = path.slice(2)
+  } else if (path.startsWith('.')) {
+    base = dot
+    rest = path.slice(1)
+  } else {
+    throw new TemplateError(`cannot evaluate "${path}"`, line)
+  }
+  return rest.split('.').reduce<unknown>((obj, key) =>
+    obj && typeof obj === 'object' ? (obj as Record<string, unknown>)[key] : undefined, base)
+}
+
+const format = (v: unknown): string =>
+  v === undefined || v === null ? ''
+    : v instanceof Date ? v.toISOString()
+      : typeof v === 'object' ? JSON.stringify(v) : String(v)
+
+const truthy = (v: unknown): boolean =>
+  !(v === undefined || v === null || v === false || v === 0 || v === '' || (Array.isArray(v) && v.length === 0))
+
+const unquote = (s: string): string => (/^".*"$/.test(s) ? JSON.parse(s) : s)
+
+const lineAt = (source: string, index: number): number => source.slice(0, index).split('\n').length
@@ -1,20 +1,34 @@
-import type { WiringDiagram } from '../app.types.js'
-import { serializeWiringDiagram, deserializeWiringDiagram } from './yaml.js'
-import { serializeWiringDiagramToCRDs, deserializeCRDsToWiringDiagram } from './crd-yaml.js'
+import type { WiringDiagram, SwitchProfile } from '../app.types.js'
+import { serializeWiringDiagram, serializeWiringDiagramChunks, deserializeWiringDiagramChunks, type DocumentName } from './yaml.js'
+import { serializeWiringDiagramToCRDs, deserializeCRDsToWiringDiagram, convertWiringDiagramToFabricCRDs } from './crd-yaml.js'
 import type { CRDYAMLs, CRDSerializationOptions } from './crd-yaml.js'
 import { gitService, generateCommitMessage } from '../features/git.service.js'
+import { checkCatalogPin, type CatalogPin, type CatalogDrift } from '../domain/catalog-pin.js'
+import { designHash, groupDuplicateDesigns, type HashedDesign } from '../domain/design-hash.js'
+import { sha256 } from '../domain/canonical-hash.js'
+import { compareRevisions, type DesignComparison } from './compare-report.js'
+import { checkReleaseCompatibility, type ReleaseCompatibility } from './release-compat.js'
+import { SEARCH_INDEX_FILE, buildSearchIndex, searchIndex, type SearchHit, type SearchIndex, type SearchQuery } from './workspace-search.js'
+import { envKeyProvider, encryptString, decryptString, isEncrypted, toBase64, fromBase64, type EncryptionKeyProvider } from './encryption.js'
+import { COMPRESSION_EXTENSIONS, compressionOf, compressString, decompressString, type Compression } from './compression.js'
 
 // Platform-specific implementations
 interface FGDPlatform {
   mkdir(path: string, options?: { recursive?: boolean }): Promise<void>
   writeFile(path: string, data: string, encoding?: string): Promise<void>
   readFile(path: string, encoding?: string): Promise<string>
+  writeBytes(path: string, data: Uint8Array): Promise<void>
+  readBytes(path: string): Promise<Uint8Array>
   access(path: string): Promise<void>
   readdir(path: string, options?: { withFileTypes?: boolean }): Promise<any[]>
   rm(path: string, options?: { recursive?: boolean, force?: boolean }): Promise<void>
+  rename(from: string, to: string): Promise<void>
   join(...paths: string[]): string
 }
 
+// Marks binary files in the browser's string storage
+const BINARY_PREFIX = 'hnc-bin:'
+
 // Browser-safe in-memory implementation
 class BrowserFGD implements FGDPlatform {
   private storage = new Map<string, string>()
@@ -63,6 +77,15 @@ class BrowserFGD implements FGDPlatform {
     throw error
   }
 
+  async writeBytes(path: string, data: Uint8Array): Promise<void> {
+    await this.writeFile(path, BINARY_PREFIX + toBase64(data))
+  }
+
+  async readBytes(path: string): Promise<Uint8Array> {
+    const data = await this.readFile(path)
+    return data.startsWith(BINARY_PREFIX) ? fromBase64(data.slice(BINARY_PREFIX.length)) : new TextEncoder().encode(data)
+  }
+
   async access(path: string): Promise<void> {
     const exists = this.storage.has(path) || 
       (typeof window !== 'undefined' && window.localStorage?.getItem(`fgd:${path}`) !== null)
@@ -135,6 +158,19 @@ class BrowserFGD implements FGDPlatform {
       }
     }
   }
+
+  async rename(from: string, to: string): Promise<void> {
+    const moved = Array.from(this.storage.entries()).filter(([key]) => key === from || key.startsWith(from + '/'))
+    if (moved.length === 0) {
+      const error = new Error(`ENOENT: no such file or directory, rename '${from}'`)
+      ;(error as any).code = 'ENOENT'
+      throw error
+    }
+    await this.rm(from, { recursive: true })
+    for (const [key, data] of moved) {
+      await this.writeFile(to + key.slice(from.length), data)
+    }
+  }
 }
 
 // Node.js implementation
@@ -155,6 +191,16 @@ class NodeFGD implements FGDPlatform {
     return result.toString()
   }
 
+  async writeBytes(path: string, data: Uint8Array): Promise<void> {
+    const fs = await import('fs')
+    await fs.promises.writeFile(path, data)
+  }
+
+  async readBytes(path: string): Promise<Uint8Array> {
+    const fs = await import('fs')
+    return new Uint8Array(await fs.promises.readFile(path))
+  }
+
   async access(path: string): Promise<void> {
     const fs = await import('fs')
     await fs.promises.access(path)
@@ -173,6 +219,11 @@ class NodeFGD implements FGDPlatform {
     await fs.promises.rm(path, options)
   }
 
+  async rename(from: string, to: string): Promise<void> {
+    const fs = await import('fs')
+    await fs.promises.rename(from, to)
+  }
+
   join(...paths: string[]): string {
     // ES module compatible path joining for Node.js
     // Use posix-style joining that works across platforms
@@ -201,6 +252,17 @@ export interface FGDSaveOptions {
   // CRD output options
   outputFormat?: 'legacy' | 'crd' | 'both' // Default: 'legacy' for backwards compatibility
   crdOptions?: CRDSerializationOptions // CRD-specific serialization options
+  encryption?: EncryptionKeyProvider | false // Default: HNC_ENCRYPTION_KEY if set, false disables
+  catalogPin?: CatalogPin // Written to catalog-pin.json alongside the design
+  detectDuplicates?: boolean // Default: false - compare with the previous save and other fabrics
+  // Revision token from the last load/save; the save is rejected with a conflict
+  // if the stored design changed since. null means the fabric must not exist yet.
+  expectedRevision?: string | null
+  targetRelease?: string // Hedgehog release the export must run on; the save is rejected if the design needs a later one
+  // Large designs (legacy format only): compress each document, e.g. servers.yaml.gz,
+  // and/or split it into a directory of chunks of at most chunkSize objects, e.g. servers/part-0001.yaml
+  compression?: Compression // Not combinable with encryption
+  chunkSize?: number
 }
 
 export interface FGDLoadOptions {
@@ -209,6 +271,8 @@ export interface FGDLoadOptions {
   // CRD input options
   inputFormat?: 'auto' | 'legacy' | 'crd' // Default: 'auto' - detect format automatically
   preferCRD?: boolean // Default: false - prefer CRD format if both exist
+  encryption?: EncryptionKeyProvider | false // Key for encrypted files; default: HNC_ENCRYPTION_KEY
+  catalog?: SwitchProfile[] // Current catalog to check the design's pin against
 }
 
 export interface FGDSaveResult {
@@ -218,10 +282,26 @@ export interface FGDSaveResult {
   filesWritten: string[]
   outputFormat: 'legacy' | 'crd' | 'both'
   crdCompliant?: boolean // Whether CRD files were generated
+  encrypted?: boolean // Whether files were written encrypted
+  compression?: Compression // Codec the design documents were written with
+  chunked?: boolean // Whether the design documents were split into chunks
   gitCommit?: string // Git commit hash if Git enabled
+  designHash?: string // Canonical content hash, when detectDuplicates is set
+  duplicateOf?: string[] // Other fabrics with identical content
+  unchanged?: boolean // Content is identical to this fabric's previous save
+  revision?: string // Token to pass as expectedRevision on the next save
+  conflict?: SaveConflict // Set when expectedRevision did not match
+  compatibility?: ReleaseCompatibility // Set when targetRelease was given
   error?: string
 }
 
+export interface SaveConflict {
+  expectedRevision: string | null
+  currentRevision: string | null
+  // Semantic diff from the stored design (the competing change) to the rejected one
+  diff?: DesignComparison
+}
+
 export interface FGDLoadResult {
   success: boolean
   diagram?: WiringDiagram
@@ -229,6 +309,9 @@ export interface FGDLoadResult {
   filesRead: string[]
   detectedFormat?: 'legacy' | 'crd'
   crdCompliant?: boolean // Whether loaded from CRD format
+  catalogPin?: CatalogPin // Catalog the design was built against, if pinned
+  catalogDrift?: CatalogDrift // Set when options.catalog was given and a pin exists
+  revision?: string // Token to pass as expectedRevision when saving edits
   error?: string
 }
 
@@ -243,8 +326,34 @@ export async function saveFGD(diagram: WiringDiagram, options: FGDSaveOptions):
   const fabricPath = platform.join(baseDir, options.fabricId)
   const fgdId = `fgd-${options.fabricId}-${Date.now()}`
   const outputFormat = options.outputFormat || 'legacy'
+  const encryption = resolveEncryption(options.encryption)
+  const writeFile = (path: string, data: string) => writeStored(path, data, encryption)
   
   try {
+    if ((options.compression || options.chunkSize !== undefined) && outputFormat !== 'legacy') {
+      throw new Error('Compression and chunking are only supported for the legacy output format')
+    }
+    if (options.compression && encryption) {
+      throw new Error('Compressed files cannot be encrypted; save without compression or pass encryption: false')
+    }
+
+    // Check the target release before anything is written
+    const compatibility = options.targetRelease
+      ? checkReleaseCompatibility(convertWiringDiagramToFabricCRDs(diagram, options.crdOptions ?? {}), options.targetRelease)
+      : undefined
+    if (compatibility && compatibility.errors.length > 0) {
+      return {
+        success: false,
+        fgdId,
+        fabricPath,
+        filesWritten: [],
+        outputFormat,
+        crdCompliant: false,
+        compatibility,
+        error: `Design is not compatible with Hedgehog ${options.targetRelease}: ${compatibility.errors.join('; ')}`
+      }
+    }
+
     // Create directories if needed
     if (options.createDirs !== false) {
       await platform.mkdir(fabricPath, { recursive: true })
@@ -253,22 +362,46 @@ export async function saveFGD(diagram: WiringDiagram, options: FGDSaveOptions):
     let filesWritten: string[] = []
     let crdCompliant = false
 
+    if (options.expectedRevision !== undefined) {
+      const currentRevision = (await storedRevision(fabricPath)) ?? null
+      if (currentRevision !== options.expectedRevision) {
+        const stored = currentRevision ? await loadFGD({ fabricId: options.fabricId, baseDir, encryption: encryption ?? false }) : undefined
+        return {
+          success: false,
+          fgdId,
+          fabricPath,
+          filesWritten: [],
+          outputFormat,
+          crdCompliant: false,
+          conflict: {
+            expectedRevision: options.expectedRevision,
+            currentRevision,
+            ...(stored?.diagram && {
+              diff: compareRevisions({ label: 'stored', diagram: stored.diagram }, { label: 'rejected', diagram })
+            })
+          },
+          error: options.expectedRevision === null
+            ? `Fabric ${options.fabricId} already exists`
+            : currentRevision
+              ? `Fabric ${options.fabricId} was modified since revision ${options.expectedRevision}`
+              : `Fabric ${options.fabricId} no longer exists`
+        }
+      }
+    }
+
+    // Compare before writing, while the previous save's hash is still on disk
+    const hash = options.detectDuplicates ? await designHash(diagram) : undefined
+    const previousHash = hash ? await readDesignHash(fabricPath, encryption) : undefined
+    const duplicateOf = hash
+      ? (await workspaceDesignHashes(baseDir, encryption))
+        .filter(d => d.fabricId !== options.fabricId && d.hash === hash)
+        .map(d => d.fabricId)
+      : undefined
+
     // Handle different output formats
     if (outputFormat === 'legacy' || outputFormat === 'both') {
       // Legacy HNC format
-      const yamls = serializeWiringDiagram(diagram)
-      
-      const legacyServerPath = platform.join(fabricPath, 'servers.yaml')
-      const legacySwitchPath = platform.join(fabricPath, 'switches.yaml') 
-      const legacyConnectionPath = platform.join(fabricPath, 'connections.yaml')
-
-      await Promise.all([
-        platform.writeFile(legacyServerPath, yamls.servers, 'utf8'),
-        platform.writeFile(legacySwitchPath, yamls.switches, 'utf8'),
-        platform.writeFile(legacyConnectionPath, yamls.connections, 'utf8')
-      ])
-
-      filesWritten.push(legacyServerPath, legacySwitchPath, legacyConnectionPath)
+      filesWritten.push(...await writeLegacyDocuments(diagram, fabricPath, options, writeFile))
     }
 
     if (outputFormat === 'crd' || outputFormat === 'both') {
@@ -284,26 +417,50 @@ export async function saveFGD(diagram: WiringDiagram, options: FGDSaveOptions):
       const connectionPath_crd = platform.join(fabricPath, `connections${crdSuffix}.yaml`)
 
       await Promise.all([
-        platform.writeFile(fabricPath_crd, crdYamls.fabric, 'utf8'),
-        platform.writeFile(serverPath_crd, crdYamls.servers, 'utf8'),
-        platform.writeFile(switchPath_crd, crdYamls.switches, 'utf8'),
-        platform.writeFile(connectionPath_crd, crdYamls.connections, 'utf8')
+        writeFile(fabricPath_crd, crdYamls.fabric),
+        writeFile(serverPath_crd, crdYamls.servers),
+        writeFile(switchPath_crd, crdYamls.switches),
+        writeFile(connectionPath_crd, crdYamls.connections)
       ])
 
       filesWritten.push(fabricPath_crd, serverPath_crd, switchPath_crd, connectionPath_crd)
     }
 
+    if (options.catalogPin) {
+      const pinPath = platform.join(fabricPath, CATALOG_PIN_FILE)
+      await writeFile(pinPath, JSON.stringify(options.catalogPin, null, 2) + '\n')
+      filesWritten.push(pinPath)
+    }
+
+    // Keep the workspace search index in step with the design
+    const indexPath = platform.join(fabricPath, SEARCH_INDEX_FILE)
+    await writeFile(indexPath, JSON.stringify(buildSearchIndex(diagram)) + '\n')
+    filesWritten.push(indexPath)
+
+    if (hash) {
+      const hashPath = platform.join(fabricPath, DESIGN_HASH_FILE)
+      await writeFile(hashPath, hash + '\n')
+      filesWritten.push(hashPath)
+    }
+
     const result: FGDSaveResult = {
       success: true,
       fgdId,
       fabricPath,
       filesWritten,
       outputFormat,
-      crdCompliant
+      crdCompliant,
+      encrypted: Boolean(encryption),
+      ...(options.compression && { compression: options.compression }),
+      ...(options.chunkSize !== undefined && { chunked: true }),
+      revision: await storedRevision(fabricPath),
+      ...(compatibility && { compatibility }),
+      ...(hash && { designHash: hash, duplicateOf, unchanged: previousHash === hash })
     }
 
     // Git integration: Write to Git and commit if enabled
-    if (gitService.isEnabled()) {
+    // Skipped for encrypted saves, since the Git mirror stores plaintext
+    if (gitService.isEnabled() && !encryption) {
       try {
         const gitWriteSuccess = await gitService.writeFabric(options.fabricId, diagram)
         if (gitWriteSuccess) {
@@ -335,6 +492,93 @@ export async function saveFGD(diagram: WiringDiagram, options: FGDSaveOptions):
   }
 }
 
+const LEGACY_DOCUMENTS: DocumentName[] = ['servers', 'switches', 'connections']
+
+// A stored document: plain or compressed YAML, in the fabric directory or as a chunk
+const DOCUMENT_FILE = /\.yaml(\.gz|\.zst)?$/
+const CHUNK_FILE = /^part-(\d+)\.yaml(\.gz|\.zst)?$/
+
+const chunkFileName = (n: number) => `part-${String(n).padStart(4, '0')}.yaml`
+
+/**
+ * Writes the legacy documents, compressed and/or chunked as the options ask,
+ * after removing whatever layout an earlier save left them in
+ */
+async function writeLegacyDocuments(
+  diagram: WiringDiagram,
+  fabricPath: string,
+  options: FGDSaveOptions,
+  writeFile: (path: string, data: string) => Promise<void>
+): Promise<string[]> {
+  const compression = options.compression
+  const extension = compression ? COMPRESSION_EXTENSIONS[compression] : ''
+  const files: [path: string, data: string][] = []
+  if (options.chunkSize !== undefined) {
+    const chunks = serializeWiringDiagramChunks(diagram, options.chunkSize)
+    for (const name of LEGACY_DOCUMENTS) {
+      await removeDocument(fabricPath, name)
+      await platform.mkdir(platform.join(fabricPath, name), { recursive: true })
+      chunks[name].forEach((chunk, i) => files.push([platform.join(fabricPath, name, chunkFileName(i + 1) + extension), chunk]))
+    }
+  } else {
+    const yamls = serializeWiringDiagram(diagram)
+    for (const name of LEGACY_DOCUMENTS) {
+      await removeDocument(fabricPath, name)
+      files.push([platform.join(fabricPath, `${name}.yaml${extension}`), yamls[name]])
+    }
+  }
+  await Promise.all(files.map(async ([path, data]) =>
+    compression ? platform.writeBytes(path, await compressString(data, compression)) : writeFile(path, data)))
+  return files.map(([path]) => path)
+}
+
+async function removeDocument(fabricPath: string, name: DocumentName): Promise<void> {
+  for (const extension of ['', ...Object.values(COMPRESSION_EXTENSIONS)]) {
+    await platform.rm(platform.join(fabricPath, `${name}.yaml${extension}`), { force: true })
+  }
+  await platform.rm(platform.join(fabricPath, name), { recursive: true, force: true })
+}
+
+/**
+ * Chunk file names in a chunk directory, in chunk order; none if it does not exist
+ */
+async function chunkFiles(dir: string): Promise<string[]> {
+  try {
+    return (await platform.readdir(dir)).map(String)
+      .filter(name => CHUNK_FILE.test(name))
+      .sort((a, b) => Number(CHUNK_FILE.exec(a)![1]) - Number(CHUNK_FILE.exec(b)![1]))
+  } catch {
+    return []
+  }
+}
+
+/**
+ * The files holding a legacy document, in whichever layout it was saved:
+ * name.yaml, name.yaml.gz, name.yaml.zst or name/part-NNNN.yaml[.gz|.zst].
+ * Undefined if the document is not stored.
+ */
+async function documentFiles(fabricPath: string, name: DocumentName): Promise<string[] | undefined> {
+  for (const extension of ['', ...Object.values(COMPRESSION_EXTENSIONS)]) {
+    const path = platform.join(fabricPath, `${name}.yaml${extension}`)
+    try {
+      await platform.access(path)
+      return [path]
+    } catch {
+      // Try the next layout
+    }
+  }
+  const chunks = await chunkFiles(platform.join(fabricPath, name))
+  return chunks.length > 0 ? chunks.map(chunk => platform.join(fabricPath, name, chunk)) : undefined
+}
+
+/**
+ * Reads a stored document file, decompressing it by its extension
+ */
+async function readDocument(path: string, encryption?: EncryptionKeyProvider): Promise<string> {
+  const compression = compressionOf(path)
+  return compression ? decompressString(await platform.readBytes(path), compression) : readStored(path, encryption)
+}
+
 /**
  * Loads a WiringDiagram from local FGD directory structure
  * Reads: ./fgd/{fabric-id}/servers.yaml, switches.yaml, connections.yaml
@@ -346,9 +590,12 @@ export async function loadFGD(options: FGDLoadOptions): Promise<FGDLoadResult> {
   const fabricPath = platform.join(baseDir, options.fabricId)
   const inputFormat = options.inputFormat || 'auto'
   const preferCRD = options.preferCRD || false
+  const encryption = resolveEncryption(options.encryption)
   
-  // Try Git first if enabled (only for legacy format for now)
-  if (gitService.isEnabled() && (inputFormat === 'auto' || inputFormat === 'legacy')) {
+  // Try Git first if enabled (only for legacy format for now). Skipped when
+  // encryption is on: encrypted saves skip the Git mirror, so its plaintext
+  // copy is stale
+  if (gitService.isEnabled() && !encryption && (inputFormat === 'auto' || inputFormat === 'legacy')) {
     try {
       const gitDiagram = await gitService.readFabric(options.fabricId)
       if (gitDiagram) {
@@ -358,7 +605,8 @@ export async function loadFGD(options: FGDLoadOptions): Promise<FGDLoadResult> {
           fabricPath,
           filesRead: [`git:${fabricPath}/servers.yaml`, `git:${fabricPath}/switches.yaml`, `git:${fabricPath}/connections.yaml`],
           detectedFormat: 'legacy',
-          crdCompliant: false
+          crdCompliant: false,
+          revision: await storedRevision(fabricPath)
         }
       }
     } catch (error) {
@@ -369,31 +617,32 @@ export async function loadFGD(options: FGDLoadOptions): Promise<FGDLoadResult> {
   
   // Determine which format to try based on options and file availability
   const formatPriority = determineFormatPriority(inputFormat, preferCRD)
+  const failures: string[] = []
   
   for (const format of formatPriority) {
     try {
       if (format === 'crd') {
-        const result = await loadCRDFormat(fabricPath)
+        const result = await loadCRDFormat(fabricPath, encryption)
         if (result.success) {
-          return {
+          return attachCatalogPin({
             ...result,
             detectedFormat: 'crd',
             crdCompliant: true
-          }
+          }, options.catalog, encryption)
         }
       } else {
-        const result = await loadLegacyFormat(fabricPath)
+        const result = await loadLegacyFormat(fabricPath, encryption)
         if (result.success) {
-          return {
+          return attachCatalogPin({
             ...result,
             detectedFormat: 'legacy',
             crdCompliant: false
-          }
+          }, options.catalog, encryption)
         }
       }
     } catch (error) {
-      // Continue to next format
-      console.warn(`Failed to load ${format} format:`, error)
+      // Continue to next format, keeping why this one failed
+      failures.push(`${format}: ${error instanceof Error ? error.message : String(error)}`)
     }
   }
   
@@ -402,7 +651,7 @@ export async function loadFGD(options: FGDLoadOptions): Promise<FGDLoadResult> {
     success: false,
     fabricPath,
     filesRead: [],
-    error: `No valid FGD files found at ${fabricPath} in any supported format (tried: ${formatPriority.join(', ')})`
+    error: `No valid FGD files found at ${fabricPath} in any supported format (${failures.join('; ')})`
   }
 }
 
@@ -417,10 +666,216 @@ function determineFormatPriority(inputFormat: string, preferCRD: boolean): ('leg
   return preferCRD ? ['crd', 'legacy'] : ['legacy', 'crd']
 }
 
+const CATALOG_PIN_FILE = 'catalog-pin.json'
+
+/**
+ * Adds the design's catalog pin (and drift against the given catalog) to a
+ * load result. Designs saved before pinning simply have no pin.
+ */
+async function attachCatalogPin(
+  loaded: FGDLoadResult,
+  catalog: SwitchProfile[] | undefined,
+  encryption?: EncryptionKeyProvider
+): Promise<FGDLoadResult> {
+  const result = { ...loaded, revision: await storedRevision(loaded.fabricPath) }
+  const pinPath = platform.join(result.fabricPath, CATALOG_PIN_FILE)
+  try {
+    await platform.access(pinPath)
+  } catch {
+    return result
+  }
+  const catalogPin: CatalogPin = JSON.parse(await readStored(pinPath, encryption))
+  return {
+    ...result,
+    filesRead: [...result.filesRead, pinPath],
+    catalogPin,
+    catalogDrift: catalog ? await checkCatalogPin(catalogPin, catalog) : undefined
+  }
+}
+
+const DESIGN_HASH_FILE = 'design.sha256'
+
+/**
+ * Revision token for a stored fabric: SHA-256 over its YAML files (compressed
+ * and chunked ones included) as stored.
+ * Every save changes it (generation time is part of the files), so it
+ * identifies one save rather than the content. Undefined if nothing is stored.
+ */
+async function storedRevision(fabricPath: string): Promise<string | undefined> {
+  let names: string[]
+  try {
+    names = (await platform.readdir(fabricPath)).map(String).filter(name => DOCUMENT_FILE.test(name))
+  } catch {
+    return undefined
+  }
+  for (const document of LEGACY_DOCUMENTS) {
+    names.push(...(await chunkFiles(platform.join(fabricPath, document))).map(chunk => `${document}/${chunk}`))
+  }
+  if (names.length === 0) return undefined
+  const parts = await Promise.all(names.sort().map(async name => {
+    const path = platform.join(fabricPath, name)
+    const data = compressionOf(name) ? toBase64(await platform.readBytes(path)) : await platform.readFile(path, 'utf8')
+    return `${name}\n${data}`
+  }))
+  return sha256(parts.join('\n'))
+}
+
+async function readDesignHash(fabricPath: string, encryption?: EncryptionKeyProvider): Promise<string | undefined> {
+  try {
+    return (await readStored(platform.join(fabricPath, DESIGN_HASH_FILE), encryption)).trim()
+  } catch {
+    return undefined
+  }
+}
+
+/**
+ * Content hashes of every fabric in the workspace. Fabrics saved without
+ * duplicate detection are loaded and hashed; unreadable ones are skipped.
+ */
+async function workspaceDesignHashes(baseDir: string, encryption?: EncryptionKeyProvider): Promise<HashedDesign[]> {
+  const designs: HashedDesign[] = []
+  for (const fabricId of await listFabrics(baseDir)) {
+    let hash = await readDesignHash(platform.join(baseDir, fabricId), encryption)
+    if (!hash) {
+      const loaded = await loadFGD({ fabricId, baseDir, encryption: encryption ?? false })
+      if (loaded.diagram) hash = await designHash(loaded.diagram)
+    }
+    if (hash) designs.push({ fabricId, hash })
+  }
+  return designs
+}
+
+/**
+ * Finds objects across every fabric in the workspace, from the index each
+ * save writes. Fabrics saved before indexing are loaded and indexed on the
+ * fly; unreadable ones are skipped.
+ */
+export async function searchWorkspace(
+  query: SearchQuery,
+  baseDir = './fgd',
+  encryption?: EncryptionKeyProvider | false
+): Promise<SearchHit[]> {
+  const key = resolveEncryption(encryption)
+  const hits: SearchHit[] = []
+  for (const fabricId of await listFabrics(baseDir)) {
+    let index: SearchIndex | undefined
+    try {
+      index = JSON.parse(await readStored(platform.join(baseDir, fabricId, SEARCH_INDEX_FILE), key))
+    } catch {
+      const loaded = await loadFGD({ fabricId, baseDir, encryption: key ?? false })
+      if (loaded.diagram) index = buildSearchIndex(loaded.diagram)
+    }
+    if (index) hits.push(...searchIndex(fabricId, index, query))
+  }
+  return hits
+}
+
+const EXPORTS_DIR = 'exports'
+const EXPORTS_INDEX = 'index.json'
+
+export interface ExportSaveOptions {
+  baseDir?: string // Default: './fgd'
+  encryption?: EncryptionKeyProvider | false // Default: HNC_ENCRYPTION_KEY if set, false disables
+}
+
+export interface ExportSaveResult {
+  success: boolean
+  exportPath: string
+  filesWritten: string[]
+  encrypted: boolean
+  error?: string
+}
+
+/**
+ * Saves a fabric's export artifacts (relative path -> content, e.g. wiring
+ * YAML or a CMDB CSV) to ./fgd/{fabric-id}/exports, replacing the previous
+ * set. With an encryption key every file, the index of paths included, is
+ * written as an envelope, as the design is.
+ */
+export async function saveExports(
+  fabricId: string,
+  files: Record<string, string>,
+  options: ExportSaveOptions = {}
+): Promise<ExportSaveResult> {
+  const exportPath = platform.join(options.baseDir || './fgd', fabricId, EXPORTS_DIR)
+  const encryption = resolveEncryption(options.encryption)
+  const paths = Object.keys(files).sort()
+  const bad = paths.find(path => path === EXPORTS_INDEX || path.startsWith('/') || path.split('/').some(part => part === '' || part === '.' || part === '..'))
+  if (bad !== undefined) {
+    return { success: false, exportPath, filesWritten: [], encrypted: false, error: `Export path ${JSON.stringify(bad)} must be relative, inside the exports directory and not ${EXPORTS_INDEX}` }
+  }
+  try {
+    await platform.rm(exportPath, { recursive: true, force: true })
+    await platform.mkdir(exportPath, { recursive: true })
+    const filesWritten: string[] = []
+    for (const path of paths) {
+      const target = platform.join(exportPath, path)
+      if (path.includes('/')) {
+        await platform.mkdir(target.slice(0, target.lastIndexOf('/')), { recursive: true })
+      }
+      await writeStored(target, files[path], encryption)
+      filesWritten.push(target)
+    }
+    const indexPath = platform.join(exportPath, EXPORTS_INDEX)
+    await writeStored(indexPath, JSON.stringify(paths, null, 2) + '\n', encryption)
+    return { success: true, exportPath, filesWritten: [...filesWritten, indexPath], encrypted: Boolean(encryption) }
+  } catch (error) {
+    return { success: false, exportPath, filesWritten: [], encrypted: Boolean(encryption), error: error instanceof Error ? error.message : String(error) }
+  }
+}
+
+/**
+ * Loads the export artifacts saveExports stored for a fabric, decrypting
+ * them with the key; a fabric without exports has none. Throws when they
+ * are encrypted and no key is configured.
+ */
+export async function loadExports(
+  fabricId: string,
+  options: ExportSaveOptions = {}
+): Promise<Record<string, string>> {
+  const exportPath = platform.join(options.baseDir || './fgd', fabricId, EXPORTS_DIR)
+  const encryption = resolveEncryption(options.encryption)
+  let paths: string[]
+  try {
+    paths = JSON.parse(await readStored(platform.join(exportPath, EXPORTS_INDEX), encryption))
+  } catch (error) {
+    if ((error as any).code === 'ENOENT') return {}
+    throw error
+  }
+  const files: Record<string, string> = {}
+  for (const path of paths) {
+    files[path] = await readStored(platform.join(exportPath, path), encryption)
+  }
+  return files
+}
+
+function resolveEncryption(option: EncryptionKeyProvider | false | undefined): EncryptionKeyProvider | undefined {
+  return option === false ? undefined : option ?? envKeyProvider()
+}
+
+/**
+ * Writes a stored file, as an encryption envelope when a key is given
+ */
+async function writeStored(path: string, data: string, encryption?: EncryptionKeyProvider): Promise<void> {
+  await platform.writeFile(path, encryption ? await encryptString(data, encryption) : data, 'utf8')
+}
+
+/**
+ * Reads a stored file, decrypting it if it is an encryption envelope
+ */
+async function readStored(path: string, encryption?: EncryptionKeyProvider): Promise<string> {
+  const data = await platform.readFile(path, 'utf8')
+  if (!isEncrypted(data)) return data
+  if (!encryption) {
+    throw new Error(`${path} is encrypted; set HNC_ENCRYPTION_KEY or pass an encryption key provider`)
+  }
+  return decryptString(data, encryption)
+}
+
 /**
  * Load CRD format files
  */
-async function loadCRDFormat(fabricPath: string): Promise<Omit<FGDLoadResult, 'detectedFormat' | 'crdCompliant'>> {
+async function loadCRDFormat(fabricPath: string, encryption?: EncryptionKeyProvider): Promise<Omit<FGDLoadResult, 'detectedFormat' | 'crdCompliant'>> {
   // CRD format file paths
   const fabricCRDPath = platform.join(fabricPath, 'fabric.yaml')
   const serversCRDPath = platform.join(fabricPath, 'servers.yaml')
@@ -438,7 +893,9 @@ async function loadCRDFormat(fabricPath: string): Promise<Omit<FGDLoadResult, 'd
     ]
   ]
   
+  let invalid: string | undefined
   for (const [fabricPath_crd, serversPath_crd, switchesPath_crd, connectionsPath_crd] of crdPaths) {
+    let found = false
     try {
       // Check if all required files exist
       await Promise.all([
@@ -447,13 +904,14 @@ async function loadCRDFormat(fabricPath: string): Promise<Omit<FGDLoadResult, 'd
         platform.access(switchesPath_crd),
         platform.access(connectionsPath_crd)
       ])
+      found = true
 
       // Read all CRD YAML files
       const [fabric, servers, switches, connections] = await Promise.all([
-        platform.readFile(fabricPath_crd, 'utf8'),
-        platform.readFile(serversPath_crd, 'utf8'),
-        platform.readFile(switchesPath_crd, 'utf8'),
-        platform.readFile(connectionsPath_crd, 'utf8')
+        readStored(fabricPath_crd, encryption),
+        readStored(serversPath_crd, encryption),
+        readStored(switchesPath_crd, encryption),
+        readStored(connectionsPath_crd, encryption)
       ])
 
       // Deserialize CRDs back to WiringDiagram
@@ -466,38 +924,33 @@ async function loadCRDFormat(fabricPath: string): Promise<Omit<FGDLoadResult, 'd
         filesRead: [fabricPath_crd, serversPath_crd, switchesPath_crd, connectionsPath_crd]
       }
     } catch (error) {
-      // Try next set of paths
+      // Try next set of paths, keeping why files that were there failed
+      if (found) invalid = `${fabricPath_crd}: ${error instanceof Error ? error.message : String(error)}`
       continue
     }
   }
   
-  throw new Error('CRD format files not found or invalid')
+  throw new Error(invalid ? `CRD format files invalid: ${invalid}` : 'CRD format files not found')
 }
 
 /**
  * Load legacy format files
  */
-async function loadLegacyFormat(fabricPath: string): Promise<Omit<FGDLoadResult, 'detectedFormat' | 'crdCompliant'>> {
-  const serverPath = platform.join(fabricPath, 'servers.yaml')
-  const switchPath = platform.join(fabricPath, 'switches.yaml')
-  const connectionPath = platform.join(fabricPath, 'connections.yaml')
-
-  // Check if all required files exist
-  await Promise.all([
-    platform.access(serverPath),
-    platform.access(switchPath), 
-    platform.access(connectionPath)
-  ])
+async function loadLegacyFormat(fabricPath: string, encryption?: EncryptionKeyProvider): Promise<Omit<FGDLoadResult, 'detectedFormat' | 'crdCompliant'>> {
+  // Find each document in whichever layout it was saved
+  const files = await Promise.all(LEGACY_DOCUMENTS.map(name => documentFiles(fabricPath, name)))
+  const missing = LEGACY_DOCUMENTS.filter((_, i) => !files[i])
+  if (missing.length > 0) {
+    throw new Error(`Legacy format files not found: ${missing.map(name => `${name}.yaml`).join(', ')}`)
+  }
 
   // Read all YAML files
-  const [servers, switches, connections] = await Promise.all([
-    platform.readFile(serverPath, 'utf8'),
-    platform.readFile(switchPath, 'utf8'),
-    platform.readFile(connectionPath, 'utf8')
-  ])
+  const [servers, switches, connections] = await Promise.all(
+    files.map(paths => Promise.all(paths!.map(path => readDocument(path, encryption))))
+  )
 
   // Deserialize back to WiringDiagram
-  const diagram = deserializeWiringDiagram({
+  const diagram = deserializeWiringDiagramChunks({
     servers,
     switches,
     connections
@@ -507,7 +960,7 @@ async function loadLegacyFormat(fabricPath: string): Promise<Omit<FGDLoadResult,
     success: true,
     diagram,
     fabricPath,
-    filesRead: [serverPath, switchPath, connectionPath]
+    filesRead: files.flatMap(paths => paths!)
   }
 }
 
@@ -520,7 +973,7 @@ export async function listFabrics(baseDir = './fgd'): Promise<string[]> {
     await new Promise(resolve => setTimeout(resolve, 10))
     const entries = await platform.readdir(baseDir, { withFileTypes: true })
     return entries
-      .filter(entry => entry.isDirectory())
+      .filter(entry => entry.isDirectory() && !entry.name.startsWith('.')) // skip TRASH_DIR
       .map(entry => entry.name)
       .sort()
   } catch (error) {
@@ -531,23 +984,26 @@ export async function listFabrics(baseDir = './fgd'): Promise<string[]> {
   }
 }
 
+/**
+ * Groups fabrics in the workspace whose content is identical
+ */
+export async function findDuplicateFabrics(
+  baseDir = './fgd',
+  encryption?: EncryptionKeyProvider | false
+): Promise<string[][]> {
+  return groupDuplicateDesigns(await workspaceDesignHashes(baseDir, resolveEncryption(encryption)))
+}
+
 /**
  * Checks if a fabric exists in the FGD directory (supports both legacy and CRD formats)
  */
 export async function fabricExists(fabricId: string, baseDir = './fgd'): Promise<boolean> {
   const fabricPath = platform.join(baseDir, fabricId)
   
-  // Check for legacy format
-  const legacyFiles = ['servers.yaml', 'switches.yaml', 'connections.yaml']
-  try {
-    await Promise.all(
-      legacyFiles.map(filename => 
-        platform.access(platform.join(fabricPath, filename))
-      )
-    )
+  // Check for legacy format, in any layout
+  const legacyFiles = await Promise.all(LEGACY_DOCUMENTS.map(name => documentFiles(fabricPath, name)))
+  if (legacyFiles.every(Boolean)) {
     return true
-  } catch {
-    // Legacy format doesn't exist, try CRD format
   }
   
   // Check for CRD format
@@ -589,4 +1045,100 @@ export async function deleteFabric(fabricId: string, baseDir = './fgd'): Promise
   } catch {
     return false
   }
-}
\ No newline at end of file
+}
+
+export const TRASH_DIR = '.trash'
+export const DEFAULT_TRASH_RETENTION_DAYS = 30
+
+export interface TrashedFabric {
+  trashId: string // <fabric-id>@<deleted-at ms>, the directory name under TRASH_DIR
+  fabricId: string
+  deletedAt: Date
+  expiresAt: Date
+}
+
+export interface RestoreResult {
+  success: boolean
+  fabricId: string
+  error?: string
+}
+
+/**
+ * Soft-deletes a fabric by moving it into the workspace trash, where it can
+ * be restored until purgeTrash removes it after the retention window
+ */
+export async function trashFabric(fabricId: string, baseDir = './fgd', now = new Date()): Promise<TrashedFabric | null> {
+  const trashId = `${fabricId}@${now.getTime()}`
+  try {
+    await platform.mkdir(platform.join(baseDir, TRASH_DIR), { recursive: true })
+    await platform.rename(platform.join(baseDir, fabricId), platform.join(baseDir, TRASH_DIR, trashId))
+  } catch {
+    return null
+  }
+  return toTrashedFabric(trashId, DEFAULT_TRASH_RETENTION_DAYS)!
+}
+
+/**
+ * Lists trashed fabrics, most recently deleted first
+ */
+export async function listTrash(baseDir = './fgd', retentionDays = DEFAULT_TRASH_RETENTION_DAYS): Promise<TrashedFabric[]> {
+  let entries: any[]
+  try {
+    entries = await platform.readdir(platform.join(baseDir, TRASH_DIR), { withFileTypes: true })
+  } catch (error) {
+    if ((error as any).code === 'ENOENT') return []
+    throw error
+  }
+  return entries
+    .map(entry => toTrashedFabric(entry.name, retentionDays))
+    .filter((item): item is TrashedFabric => item !== null)
+    .sort((a, b) => b.deletedAt.getTime() - a.deletedAt.getTime())
+}
+
+/**
+ * Restores a trashed fabric under its original id, or under `as` when given.
+ * Never overwrites an existing fabric.
+ */
+export async function restoreFabric(trashId: string, baseDir = './fgd', as?: string): Promise<RestoreResult> {
+  const trashed = toTrashedFabric(trashId, DEFAULT_TRASH_RETENTION_DAYS)
+  const fabricId = as || trashed?.fabricId || trashId
+  if (!trashed) {
+    return { success: false, fabricId, error: `Not a trash entry: ${trashId}` }
+  }
+  if ((await listFabrics(baseDir)).includes(fabricId)) {
+    return { success: false, fabricId, error: `Fabric ${fabricId} already exists; restore it under another id` }
+  }
+  try {
+    await platform.rename(platform.join(baseDir, TRASH_DIR, trashId), platform.join(baseDir, fabricId))
+    return { success: true, fabricId }
+  } catch (error) {
+    return { success: false, fabricId, error: error instanceof Error ? error.message : 'Unknown error during restore' }
+  }
+}
+
+/**
+ * Permanently deletes trash entries older than the retention window
+ */
+export async function purgeTrash(
+  baseDir = './fgd',
+  retentionDays = DEFAULT_TRASH_RETENTION_DAYS,
+  now = new Date()
+): Promise<string[]> {
+  const expired = (await listTrash(baseDir, retentionDays)).filter(item => item.expiresAt <= now)
+  for (const item of expired) {
+    await platform.rm(platform.join(baseDir, TRASH_DIR, item.trashId), { recursive: true, force: true })
+  }
+  return expired.map(item => item.trashId)
+}
+
+function toTrashedFabric(trashId: string, retentionDays: number): TrashedFabric | null {
+  const match = trashId.match(/^(.+)@(\d+)$/)
+  if (!match) return null
+  const deletedAt = new Date(Number(match[2]))
+  return {
+    trashId,
+    fabricId: match[1],
+    deletedAt,
+    expiresAt: new Date(deletedAt.getTime() + retentionDays * 24 * 60 * 60 * 1000)
+  }
+}
@@ -0,0 +1,65 @@
+/**
+ * NetBox Device Export - HNC v0.6
+ * Device rows in NetBox's bulk import CSV format (DCIM > Devices > Import),
+ * carrying serials, asset tags and user labels as tags.
+ */
+
+import { labelTags } from '../domain/labels'
+import type { Wiring } from '../domain/wiring'
+
+export interface NetBoxExportOptions {
+  site: string
+  manufacturer?: string // default: 'Celestica'
+  status?: 'planned' | 'staged' | 'active' // default: 'planned'
+  includeServers?: boolean // default: true
+}
+
+export interface NetBoxDeviceRow {
+  name: string
+  role: string
+  manufacturer: string
+  device_type: string
+  site: string
+  status: string
+  serial: string
+  asset_tag: string
+  tags: string
+}
+
+const COLUMNS: Array<keyof NetBoxDeviceRow> = ['name', 'role', 'manufacturer', 'device_type', 'site', 'status', 'serial', 'asset_tag', 'tags']
+
+/**
+ * Builds one NetBox device row per design device, switches first
+ */
+export function buildNetBoxDevices(wiring: Wiring, options: NetBoxExportOptions): NetBoxDeviceRow[] {
+  const { site, manufacturer = 'Celestica', status = 'planned', includeServers = true } = options
+  const devices = [
+    ...wiring.devices.spines,
+    ...wiring.devices.leaves,
+    ...(includeServers ? wiring.devices.servers : [])
+  ]
+
+  return devices.map(d => ({
+    name: d.id,
+    role: d.type,
+    manufacturer: d.type === 'server' ? 'Generic' : manufacturer,
+    device_type: d.modelId,
+    site,
+    status,
+    serial: d.serialNumber ?? '',
+    asset_tag: d.assetTag ?? '',
+    tags: labelTags(d.labels).join(',')
+  }))
+}
+
+/**
+ * Renders rows as CSV with NetBox's import field names as the header
+ */
+export function renderNetBoxCsv(rows: NetBoxDeviceRow[]): string {
+  const lines = rows.map(r => COLUMNS.map(c => r[c]))
+  return [COLUMNS, ...lines].map(cells => cells.map(csvCell).join(',')).join('\n') + '\n'
+}
+
+function csvCell(value: string): string {
+  return /[",\n]/.test(value) ? `"${value.replace(/"/g, '""')}"` : value
+}
@@ -0,0 +1,183 @@
+/**
+ * Optics Inventory Reconciliation - HNC v0.6
+ * Compares the transceivers switches report as installed (type, serial,
+ * DOM readings) with the optics the design expects in each port, and
+ * reports per port what is missing, wrong or unplanned, so a bad batch or
+ * a DAC-for-SR swap is caught before links are brought up.
+ */
+
+import { SKUService } from '../catalog/sku.service'
+import { expectedPortOptics } from '../domain/bom-compiler'
+import type { LinkBudgetOptions } from '../domain/link-budget'
+import type { WiringDiagram } from '../app.types'
+
+export interface InstalledOptic {
+  device: string
+  port: string
+  description?: string // compliance or type as reported, e.g. 100GBASE-SR4
+  formFactor?: string // e.g. QSFP28
+  cableType?: string // e.g. DAC, AOC
+  vendor?: string
+  vendorPart?: string
+  serial?: string
+  dom?: {
+    temperatureC?: number
+    voltage?: number
+    rxPowerDbm?: number
+    txPowerDbm?: number
+  }
+}
+
+export type OpticStatus = 'ok' | 'mismatch' | 'missing' | 'unexpected'
+
+export interface PortReconciliation {
+  device: string
+  port: string
+  status: OpticStatus
+  expectedSku?: string
+  installed?: InstalledOptic
+  problems: string[] // why a mismatch, or DOM readings out of range
+}
+
+export interface DomLimits {
+  minRxPowerDbm?: number
+  maxTemperatureC?: number
+}
+
+export interface OpticsReconciliation {
+  ports: PortReconciliation[] // by device, then port
+  summary: Record<OpticStatus, number>
+}
+
+// What a transceiver is, as far as matching goes
+interface OpticKind {
+  speed?: string // '100G'
+  form?: string // 'QSFP28'
+  medium?: 'dac' | 'fiber'
+  reach?: 'SR' | 'LR'
+}
+
+/**
+ * Installed optics from Hedgehog Agent resources (kubectl get agents -o
+ * json): status.state.transceivers, keyed by port. Ports reporting no
+ * module (present: false) are left out.
+ */
+export function parseAgentTransceivers(agents: { items?: unknown[] }): InstalledOptic[] {
+  const optics: InstalledOptic[] = []
+  for (const agent of (agents.items ?? []) as Array<Record<string, any>>) {
+    const device = agent?.metadata?.name
+    const transceivers = agent?.status?.state?.transceivers ?? {}
+    if (typeof device !== 'string') continue
+    for (const [port, t] of Object.entries(transceivers as Record<string, Record<string, any>>)) {
+      if (t?.present === false) continue
+      const dom = {
+        ...(typeof t.temperature === 'number' && { temperatureC: t.temperature }),
+        ...(typeof t.voltage === 'number' && { voltage: t.voltage }),
+        ...(typeof t.rxPower === 'number' && { rxPowerDbm: t.rxPower }),
+        ...(typeof t.txPower === 'number' && { txPowerDbm: t.txPower })
+      }
+      optics.push({
+        device,
+        port,
+        ...(t.description && { description: String(t.description) }),
+        ...(t.formFactor && { formFactor: String(t.formFactor) }),
+        ...(t.cableType && { cableType: String(t.cableType) }),
+        ...(t.vendor && { vendor: String(t.vendor) }),
+        ...(t.vendorPart && { vendorPart: String(t.vendorPart) }),
+        ...(t.serialNumber && { serial: String(t.serialNumber) }),
+        ...(Object.keys(dom).length > 0 && { dom })
+      })
+    }
+  }
+  return optics
+}
+
+/**
+ * Reconciles installed optics against the design, port by port
+ */
+export function reconcileOptics(
+  diagram: WiringDiagram,
+  installed: InstalledOptic[],
+  options: { linkBudget?: LinkBudgetOptions; domLimits?: DomLimits } = {}
+): OpticsReconciliation {
+  const key = (o: { device: string; port: string }) => `${o.device}\u0000${o.port}`
+  const expected = new Map(expectedPortOptics(diagram, options.linkBudget).map(o => [key(o), o]))
+  const reported = new Map(installed.map(o => [key(o), o]))
+  const ports: PortReconciliation[] = []
+
+  for (const [k, want] of expected) {
+    const have = reported.get(k)
+    if (!have) {
+      ports.push({ device: want.device, port: want.port, status: 'missing', expectedSku: want.sku, problems: [`no transceiver; expected ${want.sku}`] })
+      continue
+    }
+    const problems = kindMismatches(skuKind(want.sku), installedKind(have))
+    const dom = domProblems(have, options.domLimits)
+    ports.push({
+      device: want.device,
+      port: want.port,
+      status: problems.length > 0 ? 'mismatch' : 'ok',
+      expectedSku: want.sku,
+      installed: have,
+      problems: [...problems, ...dom]
+    })
+  }
+  for (const [k, have] of reported) {
+    if (!expected.has(k)) {
+      ports.push({ device: have.device, port: have.port, status: 'unexpected', installed: have, problems: ['transceiver in a port the design does not use'] })
+    }
+  }
+
+  ports.sort((a, b) => a.device.localeCompare(b.device, undefined, { numeric: true }) || a.port.localeCompare(b.port, undefined, { numeric: true }))
+  const summary: Record<OpticStatus, number> = { ok: 0, mismatch: 0, missing: 0, unexpected: 0 }
+  for (const p of ports) summary[p.status]++
+  return { ports, summary }
+}
+
+function skuKind(sku: string): OpticKind {
+  const spec = SKUService.getSKUDetails(sku).specifications ?? {}
+  const reach = /-(SR|LR)\d*$/.exec(sku)?.[1] as OpticKind['reach']
+  return {
+    ...(spec.speed && { speed: spec.speed }),
+    ...(spec.form && { form: String(spec.form).replace(/-DAC$/, '') }),
+    ...(spec.medium && { medium: spec.medium === 'dac' ? 'dac' : 'fiber' }),
+    ...(reach && { reach })
+  }
+}
+
+// Reads what it can from the reported type; unknown fields are not compared
+function installedKind(optic: InstalledOptic): OpticKind {
+  const text = `${optic.description ?? ''} ${optic.cableType ?? ''}`.toUpperCase()
+  const speed = /(\d+)GBASE/.exec(text)?.[1]
+  const copper = /\bDAC\b|BASE-CR|COPPER|PASSIVE/.test(text)
+  const fiber = /BASE-(SR|LR|DR|FR|ER)|\bAOC\b|OPTICAL/.test(text)
+  const reach = /BASE-(SR|LR)/.exec(text)?.[1] as OpticKind['reach']
+  return {
+    ...(speed && { speed: `${speed}G` }),
+    ...(optic.formFactor && { form: optic.formFactor.toUpperCase().replace(/\s+/g, '') }),
+    ...((copper || fiber) && { medium: copper ? 'dac' : 'fiber' }),
+    ...(reach && { reach })
+  }
+}
+
+function kindMismatches(want: OpticKind, have: OpticKind): string[] {
+  const problems: string[] = []
+  for (const field of ['speed', 'form', 'medium', 'reach'] as const) {
+    if (want[field] && have[field] && want[field] !== have[field]) {
+      problems.push(`${field} is ${have[field]}, expected ${want[field]}`)
+    }
+  }
+  return problems
+}
+
+function domProblems(optic: InstalledOptic, limits: DomLimits = {}): string[] {
+  const problems: string[] = []
+  const { rxPowerDbm, temperatureC } = optic.dom ?? {}
+  if (limits.minRxPowerDbm !== undefined && rxPowerDbm !== undefined && rxPowerDbm < limits.minRxPowerDbm) {
+    problems.push(`rx power ${rxPowerDbm} dBm is below ${limits.minRxPowerDbm} dBm`)
+  }
+  if (limits.maxTemperatureC !== undefined && temperatureC !== undefined && temperatureC > limits.maxTemperatureC) {
+    problems.push(`temperature ${temperatureC} °C is above ${limits.maxTemperatureC} °C`)
+  }
+  return problems
+}
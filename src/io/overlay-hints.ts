@@ -0,0 +1,129 @@
+import * as yaml from 'js-yaml'
+import type { Wiring } from '../domain/wiring'
+
+/**
+ * Overlay controller integration hints (NSX / OVN)
+ * Derives the per-host data an overlay controller needs from the fabric
+ * design: transport VLAN, MTU budget and an uplink/teaming profile per host
+ */
+
+export type OverlayController = 'nsx' | 'ovn'
+
+export interface OverlayHintOptions {
+  controller: OverlayController
+  transportVlan: number
+  fabricMtu?: number      // switch port MTU, default 9216
+  hostMtu?: number        // host NIC MTU, default 9000
+  physicalNetwork?: string // OVN bridge mapping name, default 'physnet1'
+  hosts?: string[]        // restrict to these server ids
+}
+
+export interface HostUplink {
+  nic: string
+  leaf: string
+  port: string
+}
+
+export interface HostOverlayHint {
+  host: string
+  uplinks: HostUplink[]
+  teaming: 'lacp' | 'load-balance-source' | 'failover-order' | 'none'
+  transportVlan: number
+  mtu: number
+  // Controller-specific settings keyed the way the controller names them
+  settings: Record<string, string | number | string[]>
+}
+
+export interface OverlayHints {
+  controller: OverlayController
+  fabricName: string
+  transportVlan: number
+  overlayMtu: number
+  hosts: HostOverlayHint[]
+  warnings: string[]
+}
+
+// Geneve encapsulation overhead budget (outer IPv4 + UDP + Geneve + options)
+const GENEVE_OVERHEAD = 100
+const NSX_MIN_MTU = 1600
+
+export function buildOverlayHints(wiring: Wiring, options: OverlayHintOptions): OverlayHints {
+  const { controller, transportVlan, fabricMtu = 9216, hostMtu = 9000, physicalNetwork = 'physnet1' } = options
+  const warnings: string[] = []
+
+  if (transportVlan < 2 || transportVlan > 4094) {
+    warnings.push(`Transport VLAN ${transportVlan} is outside 2-4094`)
+  }
+  const mtu = Math.min(hostMtu, fabricMtu)
+  const overlayMtu = mtu - GENEVE_OVERHEAD
+  if (controller === 'nsx' && mtu < NSX_MIN_MTU) {
+    warnings.push(`Host MTU ${mtu} is below the ${NSX_MIN_MTU} NSX requires for Geneve transport`)
+  }
+  if (hostMtu > fabricMtu) {
+    warnings.push(`Host MTU ${hostMtu} exceeds fabric MTU ${fabricMtu}; using ${fabricMtu}`)
+  }
+
+  const wanted = options.hosts ? new Set(options.hosts) : null
+  const leafIds = new Set(wiring.devices.leaves.map(l => l.id))
+  const hosts: HostOverlayHint[] = []
+
+  for (const server of [...wiring.devices.servers].sort((a, b) => a.id.localeCompare(b.id))) {
+    if (wanted && !wanted.has(server.id)) continue
+    const uplinks = wiring.connections
+      .filter(c => c.type === 'endpoint' && c.from.device === server.id && leafIds.has(c.to.device))
+      .map(c => ({ nic: c.from.port, leaf: c.to.device, port: c.to.port }))
+      .sort((a, b) => a.nic.localeCompare(b.nic))
+
+    if (uplinks.length === 0) {
+      warnings.push(`Host ${server.id} has no fabric uplinks`)
+      continue
+    }
+
+    const distinctLeaves = new Set(uplinks.map(u => u.leaf)).size
+    const teaming: HostOverlayHint['teaming'] =
+      uplinks.length === 1 ? 'none'
+        : distinctLeaves === 1 ? 'lacp'
+          : controller === 'nsx' ? 'load-balance-source' : 'failover-order'
+
+    hosts.push({
+      host: server.id,
+      uplinks,
+      teaming,
+      transportVlan,
+      mtu,
+      settings: controller === 'nsx'
+        ? nsxSettings(uplinks, teaming, transportVlan, mtu)
+        : ovnSettings(uplinks, physicalNetwork, mtu)
+    })
+  }
+
+  return { controller, fabricName: wiring.metadata.fabricName, transportVlan, overlayMtu, hosts, warnings }
+}
+
+function nsxSettings(uplinks: HostUplink[], teaming: HostOverlayHint['teaming'], vlan: number, mtu: number) {
+  const policy = teaming === 'lacp' ? 'LACP' : teaming === 'load-balance-source' ? 'LOADBALANCE_SRCID' : 'FAILOVER_ORDER'
+  return {
+    uplinkProfile: `hnc-${policy.toLowerCase()}-${uplinks.length}x`,
+    teamingPolicy: policy,
+    activeUplinks: uplinks.map((_, i) => `uplink-${i + 1}`),
+    transportVlan: vlan,
+    mtu
+  }
+}
+
+function ovnSettings(uplinks: HostUplink[], physnet: string, mtu: number) {
+  return {
+    'ovn-bridge-mappings': `${physnet}:br-ex`,
+    'ovn-encap-type': 'geneve',
+    bondMode: uplinks.length > 1 ? 'active-backup' : 'none',
+    bondMembers: uplinks.map(u => u.nic),
+    mtu
+  }
+}
+
+/**
+ * Serializes overlay hints as YAML with stable key order
+ */
+export function serializeOverlayHints(hints: OverlayHints): string {
+  return yaml.dump(hints, { sortKeys: true, indent: 2, lineWidth: 120 })
+}
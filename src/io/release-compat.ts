@@ -0,0 +1,120 @@
+/**
+ * Release Compatibility - HNC v0.6
+ * Maps the wiring and VPC schema features an export can use to the
+ * Hedgehog fabric release that first accepts them, so a design aimed at
+ * an older fabric is rejected before its YAML is written rather than by
+ * the fabric controller.
+ */
+
+import type { FabricDeploymentCRDs } from '../fabric.types.js'
+
+// Oldest first; a release supports every feature introduced at or before it
+export const HEDGEHOG_RELEASES = ['24.09', '25.01', '25.02', '25.03'] as const
+
+export type HedgehogRelease = typeof HEDGEHOG_RELEASES[number]
+
+export interface ReleaseFeature {
+  id: string // e.g. 'connection.eslag'
+  description: string
+  since: HedgehogRelease
+}
+
+// Keep in step with the fabric release notes when a new release ships
+export const RELEASE_FEATURES: ReleaseFeature[] = [
+  { id: 'connection.unbundled', description: 'unbundled server connections', since: '24.09' },
+  { id: 'connection.bundled', description: 'bundled server connections', since: '24.09' },
+  { id: 'connection.mclag', description: 'MCLAG server connections', since: '24.09' },
+  { id: 'connection.fabric', description: 'leaf-spine fabric connections', since: '24.09' },
+  { id: 'connection.management', description: 'management connections', since: '24.09' },
+  { id: 'connection.external', description: 'external connections', since: '24.09' },
+  { id: 'connection.mclagDomain', description: 'MCLAG domain connections', since: '24.09' },
+  { id: 'connection.vpcLoopback', description: 'VPC loopback connections', since: '24.09' },
+  { id: 'connection.staticExternal', description: 'static external connections', since: '24.09' },
+  { id: 'connection.eslag', description: 'ESLAG server connections', since: '25.01' },
+  { id: 'connection.mesh', description: 'leaf mesh connections', since: '25.03' },
+  { id: 'connection.gateway', description: 'gateway connections', since: '25.03' },
+  { id: 'switch.portSpeeds', description: 'per-port speeds and breakouts on switches', since: '24.09' },
+  { id: 'switch.boot', description: 'switch boot identities (serial, MAC) for ZTP', since: '25.02' },
+  { id: 'vpc', description: 'VPCs and VPC attachments', since: '24.09' },
+  { id: 'vpc.peering', description: 'VPC peerings', since: '24.09' },
+  { id: 'vpc.staticRoutes', description: 'VPC static routes', since: '25.01' },
+  { id: 'external', description: 'External objects', since: '24.09' },
+  { id: 'vpc.dhcpRange', description: 'DHCP ranges on VPC subnets', since: '25.01' }
+]
+
+export interface DesignFeatureUse {
+  feature: string
+  resources: string[] // metadata.name of each resource using it
+}
+
+export interface ReleaseCompatibility {
+  release: string
+  features: DesignFeatureUse[]
+  errors: string[]
+  warnings: string[]
+}
+
+/**
+ * Features the exported resources use, in first-use order
+ */
+export function designFeatures(crds: FabricDeploymentCRDs): DesignFeatureUse[] {
+  const uses = new Map<string, string[]>()
+  const use = (feature: string, name: string) => {
+    const names = uses.get(feature) ?? []
+    if (!names.includes(name)) names.push(name)
+    uses.set(feature, names)
+  }
+
+  for (const sw of crds.switches) {
+    if (sw.spec.portSpeeds && Object.keys(sw.spec.portSpeeds).length > 0) use('switch.portSpeeds', sw.metadata.name)
+    if (sw.spec.boot) use('switch.boot', sw.metadata.name)
+  }
+  for (const connection of crds.connections) {
+    for (const kind of Object.keys(connection.spec).filter(k => k !== 'hncMetadata')) {
+      use(`connection.${kind}`, connection.metadata.name)
+    }
+  }
+  for (const vpc of crds.vpcs ?? []) {
+    use('vpc', vpc.metadata.name)
+    if ((vpc.spec.staticRoutes ?? []).length > 0) use('vpc.staticRoutes', vpc.metadata.name)
+    for (const subnet of Object.values(vpc.spec.subnets ?? {})) {
+      if (subnet.dhcp?.start || subnet.dhcp?.end) use('vpc.dhcpRange', vpc.metadata.name)
+    }
+  }
+  for (const attachment of crds.vpcAttachments ?? []) use('vpc', attachment.metadata.name)
+  for (const peering of crds.vpcPeerings ?? []) use('vpc.peering', peering.metadata.name)
+  for (const external of crds.externals ?? []) use('external', external.metadata.name)
+
+  return [...uses].map(([feature, resources]) => ({ feature, resources }))
+}
+
+/**
+ * Checks that every feature the export uses is supported by the target
+ * release. Features missing from RELEASE_FEATURES (e.g. a custom
+ * connection kind) are warned about, since their support is unknown.
+ */
+export function checkReleaseCompatibility(crds: FabricDeploymentCRDs, release: string): ReleaseCompatibility {
+  const target = HEDGEHOG_RELEASES.indexOf(release as HedgehogRelease)
+  if (target === -1) {
+    return {
+      release,
+      features: [],
+      errors: [`Unknown Hedgehog release ${release} (known: ${HEDGEHOG_RELEASES.join(', ')})`],
+      warnings: []
+    }
+  }
+
+  const features = designFeatures(crds)
+  const errors: string[] = []
+  const warnings: string[] = []
+  for (const { feature, resources } of features) {
+    const known = RELEASE_FEATURES.find(f => f.id === feature)
+    const where = resources.length > 3 ? `${resources.slice(0, 3).join(', ')} and ${resources.length - 3} more` : resources.join(', ')
+    if (!known) {
+      warnings.push(`${feature} (${where}) is not in the release compatibility matrix; check ${release} supports it`)
+    } else if (HEDGEHOG_RELEASES.indexOf(known.since) > target) {
+      errors.push(`${known.description} need Hedgehog ${known.since} or later, but the target is ${release}: ${where}`)
+    }
+  }
+  return { release, features, errors, warnings }
+}
@@ -0,0 +1,144 @@
+/**
+ * Running-Config Interface Import - HNC v0.6
+ * Extracts interface usage and speeds from Arista EOS and Cisco NX-OS/IOS
+ * running-configs, to seed brownfield designs when no structured source
+ * (NetBox, Kubernetes) is available.
+ */
+
+export type ConfigVendor = 'arista-eos' | 'cisco-nxos' | 'cisco-ios'
+
+export interface ConfigInterface {
+  name: string
+  description?: string
+  speedGbps?: number // explicit speed, or implied by the IOS interface name
+  shutdown: boolean
+  mode?: 'access' | 'trunk' | 'routed'
+  channelGroup?: number
+  mtu?: number
+}
+
+export interface ParsedRunningConfig {
+  hostname?: string
+  vendor: ConfigVendor
+  interfaces: ConfigInterface[] // physical Ethernet ports only
+  warnings: string[]
+}
+
+export interface PortUsageSummary {
+  device: string
+  totalPorts: number
+  usedPorts: number
+  shutdownPorts: number
+  bySpeedGbps: Record<string, number> // used ports per speed, 'unknown' when unset
+  portChannels: number[]
+}
+
+// IOS names carry the port speed; EOS and NX-OS use plain "Ethernet"
+const NAME_SPEEDS: Array<[RegExp, number]> = [
+  [/^HundredGig(abit)?E/i, 100],
+  [/^FortyGig(abit)?E/i, 40],
+  [/^TwentyFiveGig(abit)?E/i, 25],
+  [/^TenGig(abit)?E/i, 10],
+  [/^Gig(abit)?E/i, 1]
+]
+const PHYSICAL = /^(Ethernet|Eth|[A-Za-z]*Gig[A-Za-z]*E)/i
+
+/**
+ * Guesses the vendor from the config header and syntax
+ */
+export function detectConfigVendor(text: string): ConfigVendor {
+  if (/^feature |^version \d+\.\d+\(/m.test(text)) return 'cisco-nxos'
+  if (/^interface [A-Za-z]*Gig[A-Za-z]*E/m.test(text)) return 'cisco-ios'
+  return 'arista-eos'
+}
+
+/**
+ * Parses the hostname and physical interface sections of a running-config
+ */
+export function parseRunningConfig(text: string, vendor: ConfigVendor = detectConfigVendor(text)): ParsedRunningConfig {
+  const interfaces: ConfigInterface[] = []
+  const warnings: string[] = []
+  let hostname: string | undefined
+  let current: ConfigInterface | undefined
+
+  for (const raw of text.split(/\r?\n/)) {
+    const line = raw.replace(/\s+$/, '')
+    if (line === '' || /^\s*!/.test(line)) continue
+
+    const indented = /^\s/.test(line)
+    if (!indented) {
+      current = undefined
+      const host = line.match(/^hostname (\S+)/)
+      if (host) hostname = host[1]
+      const iface = line.match(/^interface (\S+)$/)
+      if (iface && PHYSICAL.test(iface[1])) {
+        current = { name: iface[1], shutdown: false }
+        const implied = NAME_SPEEDS.find(([pattern]) => pattern.test(iface[1]))
+        if (implied) current.speedGbps = implied[1]
+        interfaces.push(current)
+      }
+      continue
+    }
+    if (!current) continue
+
+    const command = line.trim()
+    let match: RegExpMatchArray | null
+    if ((match = command.match(/^description (.+)$/))) {
+      current.description = match[1]
+    } else if (command === 'shutdown') {
+      current.shutdown = true
+    } else if (command === 'no shutdown') {
+      current.shutdown = false
+    } else if ((match = command.match(/^speed (?:forced )?(\S+)/))) {
+      const speed = parseSpeed(match[1])
+      if (speed === undefined && match[1] !== 'auto') warnings.push(`${current.name}: unrecognized speed '${match[1]}'`)
+      if (speed !== undefined) current.speedGbps = speed
+    } else if ((match = command.match(/^switchport mode (access|trunk)/))) {
+      current.mode = match[1] as 'access' | 'trunk'
+    } else if (command === 'no switchport') {
+      current.mode = 'routed'
+    } else if (/^ip(v6)? address /.test(command) && current.mode === undefined) {
+      current.mode = 'routed'
+    } else if ((match = command.match(/^channel-group (\d+)/))) {
+      current.channelGroup = Number(match[1])
+    } else if ((match = command.match(/^mtu (\d+)/))) {
+      current.mtu = Number(match[1])
+    }
+  }
+
+  if (interfaces.length === 0) warnings.push('No physical interfaces found')
+  return { hostname, vendor, interfaces, warnings }
+}
+
+/**
+ * Port usage per device. A port counts as used when it is not shut down
+ * and carries a description, a switchport/routed mode or a channel-group.
+ */
+export function summarizePortUsage(config: ParsedRunningConfig, device = config.hostname ?? 'unknown'): PortUsageSummary {
+  const used = config.interfaces.filter(i =>
+    !i.shutdown && (i.description !== undefined || i.mode !== undefined || i.channelGroup !== undefined)
+  )
+  const bySpeedGbps: Record<string, number> = {}
+  for (const iface of used) {
+    const key = iface.speedGbps === undefined ? 'unknown' : String(iface.speedGbps)
+    bySpeedGbps[key] = (bySpeedGbps[key] || 0) + 1
+  }
+
+  return {
+    device,
+    totalPorts: config.interfaces.length,
+    usedPorts: used.length,
+    shutdownPorts: config.interfaces.filter(i => i.shutdown).length,
+    bySpeedGbps,
+    portChannels: [...new Set(used.flatMap(i => i.channelGroup === undefined ? [] : [i.channelGroup]))].sort((a, b) => a - b)
+  }
+}
+
+// EOS: 25g, 100gfull, 100g-4; NX-OS/IOS: Mbps (25000, 1000)
+function parseSpeed(value: string): number | undefined {
+  const gig = value.match(/^(\d+)g/i)
+  if (gig) return Number(gig[1])
+  const mbps = value.match(/^(\d+)(full|half)?$/i)
+  if (mbps && Number(mbps[1]) >= 10) return Number(mbps[1]) / 1000
+  return undefined
+}
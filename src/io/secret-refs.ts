@@ -0,0 +1,152 @@
+/**
+ * Secret References - HNC v0.6
+ * Designs name secrets (BGP passwords, SNMP communities, API tokens)
+ * instead of embedding them:
+ *
+ *   secret://vault/<mount>/<path>#<key>      KV v2, e.g. secret://vault/kv/fabric/bgp#password
+ *   secret://k8s/<namespace>/<name>#<key>    e.g. secret://k8s/fab/snmp#community
+ *
+ * Exporters copy references through verbatim, so exported YAML and
+ * templates never hold the values. A trusted environment can resolve them
+ * in the final text with resolveSecretRefs and a provider per backend.
+ */
+
+import type { WiringDiagram } from '../app.types.js'
+
+export type SecretBackend = 'vault' | 'k8s'
+
+export interface SecretRef {
+  ref: string // the reference as written
+  backend: SecretBackend
+  path: string // vault: mount/path, k8s: namespace/name
+  key: string
+}
+
+export interface SecretProvider {
+  /** Value of one key of a referenced secret */
+  resolve(ref: SecretRef): Promise<string>
+}
+
+export interface SecretResolution {
+  text: string
+  resolved: string[] // references substituted, in first-use order
+  errors: string[]
+}
+
+const SECRET_REF = /secret:\/\/(vault|k8s)\/([A-Za-z0-9._-]+(?:\/[A-Za-z0-9._-]+)+)#([A-Za-z0-9._-]+)/g
+
+// Annotation and label keys that name a secret; their values must be references
+const SECRET_KEY = /password|passwd|community|token|secret|psk/i
+
+export function parseSecretRef(value: string): SecretRef | undefined {
+  const match = new RegExp(`^${SECRET_REF.source}$`).exec(value)
+  if (!match) return undefined
+  const ref = { ref: value, backend: match[1] as SecretBackend, path: match[2], key: match[3] }
+  return ref.backend === 'k8s' && ref.path.split('/').length !== 2 ? undefined : ref
+}
+
+export function formatSecretRef(backend: SecretBackend, path: string, key: string): string {
+  return `secret://${backend}/${path}#${key}`
+}
+
+/**
+ * Every well-formed reference in the text, once each, in first-use order
+ */
+export function findSecretRefs(text: string): SecretRef[] {
+  const refs: SecretRef[] = []
+  for (const match of text.matchAll(SECRET_REF)) {
+    const ref = parseSecretRef(match[0])
+    if (ref && !refs.some(r => r.ref === ref.ref)) refs.push(ref)
+  }
+  return refs
+}
+
+/**
+ * Annotations and labels whose key names a secret but whose value is not
+ * a reference, i.e. a secret that would be exported in plain text
+ */
+export function embeddedSecrets(diagram: WiringDiagram): string[] {
+  const problems: string[] = []
+  const check = (owner: string, meta: { labels?: Record<string, string>; annotations?: Record<string, string> }) => {
+    for (const [kind, entries] of [['annotation', meta.annotations], ['label', meta.labels]] as const) {
+      for (const [key, value] of Object.entries(entries ?? {})) {
+        if (SECRET_KEY.test(key) && value && !parseSecretRef(value)) {
+          problems.push(`${owner}: ${kind} ${key} holds a plain-text secret; use a reference such as ${formatSecretRef('vault', 'kv/fabric', key.split('/').pop()!)}`)
+        }
+      }
+    }
+  }
+  for (const device of [...diagram.devices.spines, ...diagram.devices.leaves, ...diagram.devices.servers]) check(device.id, device)
+  for (const c of diagram.connections) check(`${c.from.device}/${c.from.port} -> ${c.to.device}/${c.to.port}`, c)
+  return problems
+}
+
+/**
+ * Replaces every reference in the text with its value. Each reference is
+ * resolved once; a backend without a provider, or a lookup that fails,
+ * is reported and leaves the reference in place.
+ */
+export async function resolveSecretRefs(
+  text: string,
+  providers: Partial<Record<SecretBackend, SecretProvider>>
+): Promise<SecretResolution> {
+  const values = new Map<string, string>()
+  const errors: string[] = []
+  for (const ref of findSecretRefs(text)) {
+    const provider = providers[ref.backend]
+    if (!provider) {
+      errors.push(`${ref.ref}: no ${ref.backend} provider configured`)
+      continue
+    }
+    try {
+      values.set(ref.ref, await provider.resolve(ref))
+    } catch (error) {
+      errors.push(`${ref.ref}: ${error instanceof Error ? error.message : String(error)}`)
+    }
+  }
+  return {
+    text: text.replace(SECRET_REF, ref => values.get(ref) ?? ref),
+    resolved: [...values.keys()],
+    errors
+  }
+}
+
+/**
+ * Provider reading Vault KV v2 secrets over HTTP. The mount is the first
+ * path segment: secret://vault/kv/fabric/bgp#password reads
+ * <address>/v1/kv/data/fabric/bgp.
+ */
+export function vaultSecretProvider(options: { address: string; token: string; fetch?: typeof fetch }): SecretProvider {
+  const get = options.fetch ?? fetch
+  const cache = new Map<string, Promise<Record<string, string>>>()
+  return {
+    resolve: async (ref) => {
+      const [mount, ...rest] = ref.path.split('/')
+      const url = `${options.address.replace(/\/+$/, '')}/v1/${mount}/data/${rest.join('/')}`
+      if (!cache.has(url)) {
+        cache.set(url, get(url, { headers: { 'X-Vault-Token': options.token } }).then(async response => {
+          if (!response.ok) throw new Error(`Vault returned ${response.status} for ${mount}/${rest.join('/')}`)
+          return (await response.json())?.data?.data ?? {}
+        }))
+      }
+      const data = await cache.get(url)!
+      if (typeof data[ref.key] !== 'string') throw new Error(`key ${ref.key} not found`)
+      return data[ref.key]
+    }
+  }
+}
+
+/**
+ * Provider reading Kubernetes Secrets. readSecret returns the Secret's
+ * data map (base64 values), e.g. from the API client or kubectl.
+ */
+export function kubernetesSecretProvider(readSecret: (namespace: string, name: string) => Promise<Record<string, string>>): SecretProvider {
+  return {
+    resolve: async (ref) => {
+      const [namespace, name] = ref.path.split('/')
+      const data = await readSecret(namespace, name)
+      if (typeof data?.[ref.key] !== 'string') throw new Error(`key ${ref.key} not found in secret ${namespace}/${name}`)
+      return new TextDecoder().decode(Uint8Array.from(atob(data[ref.key]), c => c.charCodeAt(0)))
+    }
+  }
+}
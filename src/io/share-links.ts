@@ -0,0 +1,129 @@
+/**
+ * Read-only share links - HNC v0.6
+ *
+ * Expiring, HMAC-signed links granting read-only access to one design and
+ * a chosen set of its reports, for reviewers outside the workspace. A link
+ * carries its own grant:
+ *
+ *   <base-url>?share=<base64url payload>.<base64url HMAC-SHA256>
+ *
+ * so whatever serves designs can check it with verifyShareLink and the
+ * signing secret alone (HNC_SHARE_SECRET), no session or user lookup needed.
+ */
+
+export const SHARE_PARAM = 'share'
+
+export type ShareableReport = 'design' | 'bom' | 'cabling' | 'compare' | 'validation'
+
+export interface ShareGrant {
+  fabricId: string
+  reports: ShareableReport[]
+  expiresAt: Date
+  revision?: string // pin the link to one saved revision
+}
+
+export interface ShareLinkOptions {
+  baseUrl: string
+  secret: string
+  expiresInSeconds?: number // default: 7 days
+  reports?: ShareableReport[] // default: ['design']
+  revision?: string
+  now?: Date
+}
+
+export type ShareVerification =
+  | { valid: true; grant: ShareGrant }
+  | { valid: false; error: string }
+
+const DEFAULT_EXPIRY_SECONDS = 7 * 24 * 60 * 60
+
+/**
+ * Signing secret from HNC_SHARE_SECRET, undefined when unset
+ */
+export function envShareSecret(env: Record<string, string | undefined> = typeof process !== 'undefined' ? process.env : {}): string | undefined {
+  return env.HNC_SHARE_SECRET || undefined
+}
+
+/**
+ * Creates a signed read-only link for a design
+ */
+export async function createShareLink(fabricId: string, options: ShareLinkOptions): Promise<string> {
+  const { baseUrl, secret, expiresInSeconds = DEFAULT_EXPIRY_SECONDS, reports = ['design'], revision, now = new Date() } = options
+  if (!secret) throw new Error('A signing secret is required to create share links')
+  if (expiresInSeconds <= 0) throw new Error('Share links must expire in the future')
+
+  const payload = {
+    f: fabricId,
+    r: reports,
+    exp: Math.floor(now.getTime() / 1000) + Math.floor(expiresInSeconds),
+    ...(revision && { v: revision })
+  }
+  const body = toBase64Url(new TextEncoder().encode(JSON.stringify(payload)))
+  const url = new URL(baseUrl)
+  url.searchParams.set(SHARE_PARAM, `${body}.${await sign(body, secret)}`)
+  return url.toString()
+}
+
+/**
+ * Checks a share link (or bare token) and returns the grant it carries
+ */
+export async function verifyShareLink(linkOrToken: string, secret: string, now: Date = new Date()): Promise<ShareVerification> {
+  const token = linkOrToken.includes('?') ? new URL(linkOrToken).searchParams.get(SHARE_PARAM) : linkOrToken
+  const [body, signature, extra] = (token ?? '').split('.')
+  if (!body || !signature || extra !== undefined) {
+    return { valid: false, error: 'Malformed share token' }
+  }
+  if (!(await timingSafeEqual(signature, await sign(body, secret)))) {
+    return { valid: false, error: 'Invalid share link signature' }
+  }
+
+  let payload: { f: string; r: ShareableReport[]; exp: number; v?: string }
+  try {
+    payload = JSON.parse(new TextDecoder().decode(fromBase64Url(body)))
+  } catch {
+    return { valid: false, error: 'Malformed share token' }
+  }
+
+  const expiresAt = new Date(payload.exp * 1000)
+  if (expiresAt <= now) {
+    return { valid: false, error: `Share link expired at ${expiresAt.toISOString()}` }
+  }
+  return {
+    valid: true,
+    grant: { fabricId: payload.f, reports: payload.r, expiresAt, ...(payload.v && { revision: payload.v }) }
+  }
+}
+
+/**
+ * Whether a verified grant allows reading the given report
+ */
+export function grantAllows(grant: ShareGrant, fabricId: string, report: ShareableReport): boolean {
+  return grant.fabricId === fabricId && grant.reports.includes(report)
+}
+
+async function sign(body: string, secret: string): Promise<string> {
+  const key = await crypto.subtle.importKey('raw', new TextEncoder().encode(secret), { name: 'HMAC', hash: 'SHA-256' }, false, ['sign'])
+  return toBase64Url(new Uint8Array(await crypto.subtle.sign('HMAC', key, new TextEncoder().encode(body))))
+}
+
+// Compares digests of both strings so timing does not depend on where they differ
+async function timingSafeEqual(a: string, b: string): Promise<boolean> {
+  const digest = async (s: string) => new Uint8Array(await crypto.subtle.digest('SHA-256', new TextEncoder().encode(s)))
+  const [da, db] = await Promise.all([digest(a), digest(b)])
+  let diff = 0
+  for (let i = 0; i < da.length; i++) diff |= da[i] ^ db[i]
+  return diff === 0
+}
+
+function toBase64Url(bytes: Uint8Array): string {
+  let binary = ''
+  for (let i = 0; i < bytes.length; i += 0x8000) {
+    binary += String.fromCharCode(...bytes.subarray(i, i + 0x8000))
+  }
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '')
+}
+
+function fromBase64Url(text: string): Uint8Array {
+  const binary = atob(text.replace(/-/g, '+').replace(/_/g, '/'))
+  return Uint8Array.from(binary, c => c.charCodeAt(0))
+}
@@ -0,0 +1,180 @@
+/**
+ * Terminal Report Renderer - HNC v0.6
+ * Renders validation and diff results for a terminal: aligned tables,
+ * findings colored by severity, and a pager for long output. Color is
+ * dropped for pipes, CI logs and NO_COLOR so captured output stays plain.
+ */
+
+import type { WiringValidationResult } from '../domain/wiring'
+import type { DesignComparison } from './compare-report'
+import { formatTarget } from '../domain/review-comments'
+import type { ManifestVerification } from './export-manifest'
+
+export type Severity = 'error' | 'warning' | 'info' | 'ok'
+
+export interface ReportRow {
+  cells: string[]
+  severity?: Severity
+}
+
+export interface ReportSection {
+  heading: string
+  table?: { headers: string[]; rows: ReportRow[] }
+  findings?: Array<{ severity: Severity; message: string }>
+}
+
+export interface TerminalReport {
+  title: string
+  sections: ReportSection[]
+}
+
+export interface TerminalEnv {
+  argv: string[]
+  env: Record<string, string | undefined>
+  isTTY: boolean
+}
+
+const ANSI: Record<Severity | 'bold' | 'dim', string> = {
+  error: '\x1b[31m',
+  warning: '\x1b[33m',
+  info: '\x1b[36m',
+  ok: '\x1b[32m',
+  bold: '\x1b[1m',
+  dim: '\x1b[2m'
+}
+const RESET = '\x1b[0m'
+
+const MARKS: Record<Severity, string> = { error: '✖', warning: '⚠', info: 'ℹ', ok: '✔' }
+
+/**
+ * --color forces and --no-color drops color; otherwise NO_COLOR, CI,
+ * TERM=dumb and non-TTY output are plain, and FORCE_COLOR overrides them
+ */
+export function useColor({ argv, env, isTTY }: TerminalEnv): boolean {
+  if (argv.includes('--no-color')) return false
+  if (argv.includes('--color')) return true
+  if (env.NO_COLOR !== undefined && env.NO_COLOR !== '') return false
+  if (env.FORCE_COLOR !== undefined && env.FORCE_COLOR !== '0') return true
+  if (env.CI !== undefined && env.CI !== 'false') return false
+  return isTTY && env.TERM !== 'dumb'
+}
+
+/**
+ * Pager command when --pager is given, output is a TTY outside CI and the
+ * text is taller than the terminal; undefined prints directly
+ */
+export function pagerCommand({ argv, env, isTTY }: TerminalEnv, lines: number, rows = Number(env.LINES) || 24): string | undefined {
+  if (!argv.includes('--pager') || argv.includes('--no-pager') || !isTTY) return undefined
+  if (env.CI !== undefined && env.CI !== 'false') return undefined
+  if (lines < rows) return undefined
+  return env.HNC_PAGER || env.PAGER || 'less -FRX'
+}
+
+export function renderTerminalReport(report: TerminalReport, options: { color?: boolean } = {}): string {
+  const paint = (style: keyof typeof ANSI, text: string) => options.color ? `${ANSI[style]}${text}${RESET}` : text
+  const out: string[] = [paint('bold', report.title), '']
+
+  for (const section of report.sections) {
+    out.push(paint('bold', section.heading))
+    if (section.table && section.table.rows.length > 0) {
+      const { headers, rows } = section.table
+      const widths = headers.map((h, i) => Math.max(width(h), ...rows.map(r => width(r.cells[i] ?? ''))))
+      const line = (cells: string[]) => cells.map((c, i) => i === cells.length - 1 ? c : pad(c, widths[i])).join('  ').trimEnd()
+      out.push('  ' + paint('dim', line(headers)))
+      for (const row of rows) {
+        const text = line(row.cells)
+        out.push('  ' + (row.severity ? paint(row.severity, text) : text))
+      }
+    }
+    for (const f of section.findings ?? []) {
+      out.push(`  ${paint(f.severity, `${MARKS[f.severity]} ${f.message}`)}`)
+    }
+    if ((section.table?.rows.length ?? 0) === 0 && (section.findings?.length ?? 0) === 0) {
+      out.push('  ' + paint('dim', 'None'))
+    }
+    out.push('')
+  }
+  return out.join('\n')
+}
+
+export function validationReport(result: WiringValidationResult, title = 'Wiring validation'): TerminalReport {
+  const findings: NonNullable<ReportSection['findings']> = [
+    ...result.errors.map(message => ({ severity: 'error' as const, message })),
+    ...result.warnings.map(message => ({ severity: 'warning' as const, message }))
+  ]
+  if (findings.length === 0) findings.push({ severity: 'ok', message: 'No problems found' })
+  return {
+    title,
+    sections: [{ heading: `${count(result.errors.length, 'error')}, ${count(result.warnings.length, 'warning')}`, findings }]
+  }
+}
+
+export function comparisonReport(c: DesignComparison): TerminalReport {
+  const changeSeverity = { added: 'ok', removed: 'error', modified: 'warning' } as const
+  return {
+    title: `Design comparison: ${c.before} → ${c.after}`,
+    sections: [
+      {
+        heading: 'Topology',
+        table: {
+          headers: ['Device', 'Role', 'Change', c.before, c.after],
+          rows: c.devices.map(d => ({ cells: [d.id, d.role, d.change, d.before ?? '', d.after ?? ''], severity: changeSeverity[d.change] }))
+        }
+      },
+      {
+        heading: `Connections (+${c.connections.added.length} / -${c.connections.removed.length})`,
+        findings: [
+          ...c.connections.removed.map(message => ({ severity: 'error' as const, message: `- ${message}` })),
+          ...c.connections.added.map(message => ({ severity: 'ok' as const, message: `+ ${message}` }))
+        ]
+      },
+      {
+        heading: 'Bill of materials',
+        table: {
+          headers: ['SKU', 'Description', c.before, c.after, 'Delta'],
+          rows: c.bom.lines.map(l => ({
+            cells: [l.sku, l.description, String(l.before), String(l.after), `${l.delta > 0 ? '+' : ''}${l.delta}`],
+            severity: l.delta > 0 ? 'ok' : 'error'
+          }))
+        }
+      },
+      {
+        heading: 'Addressing',
+        table: {
+          headers: ['Name', 'Change', c.before, c.after],
+          rows: c.addressing.map(a => ({ cells: [a.name, a.change, a.before ?? '', a.after ?? ''], severity: changeSeverity[a.change] }))
+        }
+      },
+      ...(c.comments ? [{
+        heading: 'Review comments',
+        findings: c.comments.map(({ comment, changed }) => ({
+          severity: changed ? 'warning' as const : 'info' as const,
+          message: `${formatTarget(comment.target)}: ${comment.body} (${comment.author}${changed ? ', changed' : ''})`
+        }))
+      }] : [])
+    ]
+  }
+}
+
+export function manifestDiffReport(result: ManifestVerification, dir: string): TerminalReport {
+  const rows: ReportRow[] = [
+    ...result.modified.map(path => ({ cells: ['modified', path], severity: 'warning' as const })),
+    ...result.missing.map(path => ({ cells: ['missing', path], severity: 'error' as const })),
+    ...result.unexpected.map(path => ({ cells: ['unexpected', path], severity: 'info' as const }))
+  ]
+  return {
+    title: `Export manifest check: ${dir}`,
+    sections: [{
+      heading: result.ok ? 'All artifacts match' : `${count(rows.length, 'artifact')} differ`,
+      table: { headers: ['Status', 'Path'], rows },
+      ...(result.ok && { findings: [{ severity: 'ok' as const, message: 'No differences' }] })
+    }]
+  }
+}
+
+const count = (n: number, noun: string) => `${n} ${noun}${n === 1 ? '' : 's'}`
+
+// Code points, so arrows and accented names do not skew the columns
+const width = (s: string) => [...s].length
+
+const pad = (s: string, n: number) => s + ' '.repeat(Math.max(0, n - width(s)))
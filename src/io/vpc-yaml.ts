@@ -1,5 +1,6 @@
 import * as yaml from 'js-yaml'
 import type { VPC, VPCAttachment, VPCPeering, External, ExternalAttachment, ExternalPeering } from '../upstream/types/generated'
+import { planDhcpScopes, type DhcpScope } from './dhcp-scopes'
 
 export interface VPCYAMLs {
   vpcs: string
@@ -198,7 +199,7 @@ function convertToVPCCRD(vpc: VPCYAMLConfig, options: VPCSerializationOptions):
       ipv4Namespace: vpc.spec.ipv4Namespace,
       vlanNamespace: vpc.spec.vlanNamespace,
       mode: vpc.spec.mode,
-      subnets: convertSubnetsToK8sFormat(vpc.spec.subnets),
+      subnets: convertSubnetsToK8sFormat(vpc.spec.subnets, planDhcpScopes([vpc]).scopes),
       permit: vpc.spec.permit,
       staticRoutes: vpc.spec.staticRoutes?.map(route => ({
         destination: route.destination,
@@ -374,10 +375,15 @@ function convertToExternalPeeringCRD(peering: VPCDeploymentConfig['externalPeeri
   }
 }
 
-function convertSubnetsToK8sFormat(subnets: VPCYAMLConfig['spec']['subnets']): Record<string, any> {
+// Enabled DHCP ranges left open in the design are exported as planned, so
+// the VPC object and the DHCP server config hand out the same pool
+function convertSubnetsToK8sFormat(subnets: VPCYAMLConfig['spec']['subnets'], scopes: DhcpScope[] = []): Record<string, any> {
   const result: Record<string, any> = {}
   
   Object.entries(subnets).forEach(([name, subnet]) => {
+    const scope = scopes.find(s => s.subnet === name)
+    const start = subnet.dhcp?.start || scope?.start
+    const end = subnet.dhcp?.end || scope?.end
     result[name] = {
       cidr: subnet.cidr,
       ...(subnet.gateway && { gateway: subnet.gateway }),
@@ -387,8 +393,8 @@ function convertSubnetsToK8sFormat(subnets: VPCYAMLConfig['spec']['subnets']): R
       ...(subnet.dhcp && {
         dhcp: {
           enable: subnet.dhcp.enable,
-          ...(subnet.dhcp.start && { start: subnet.dhcp.start }),
-          ...(subnet.dhcp.end && { end: subnet.dhcp.end })
+          ...(start && { start }),
+          ...(end && { end })
         }
       })
     }
@@ -620,6 +626,11 @@ export function validateVPCConfiguration(config: VPCDeploymentConfig): {
     })
   })
 
+  // Validate DHCP ranges
+  const dhcp = planDhcpScopes(config.vpcs)
+  errors.push(...dhcp.errors)
+  warnings.push(...dhcp.warnings)
+
   // Validate VPC Attachments
   config.vpcAttachments.forEach((attachment, index) => {
     if (!attachment.spec.connection) {
@@ -0,0 +1,150 @@
+/**
+ * Workspace Search - HNC v0.6
+ * Indexes the objects of a design (switches, servers, connections) by name,
+ * model, serial, labels, addresses and ports, so questions like "which
+ * designs use spine model DS3000" or "where is 10.1.4.0/24 allocated" are
+ * answered from small per-design indexes written on save, without loading
+ * every design.
+ */
+
+import type { WiringDiagram } from '../app.types.js'
+
+export const SEARCH_INDEX_FILE = 'search-index.json'
+
+export type SearchField = 'name' | 'model' | 'label' | 'serial' | 'ip' | 'port'
+
+export interface IndexedObject {
+  kind: 'switch' | 'server' | 'connection'
+  name: string
+  role?: string // spine, leaf, or the server type
+  model?: string
+  serial?: string
+  assetTag?: string
+  mac?: string
+  labels?: Record<string, string> // labels and annotations
+  ips?: string[] // addresses and CIDRs found in label and annotation values
+  ports?: string[] // device/port for each end of a connection
+}
+
+export interface SearchIndex {
+  version: 1
+  fabricName: string
+  objects: IndexedObject[]
+}
+
+export interface SearchQuery {
+  field?: SearchField // default: name, model, serial, asset tag, MAC and labels
+  value: string
+}
+
+export interface SearchHit {
+  fabricId: string
+  kind: IndexedObject['kind']
+  name: string
+  match: string // what matched, e.g. 'model=DS3000'
+}
+
+const IP_OR_CIDR = /\b\d{1,3}(?:\.\d{1,3}){3}(?:\/\d{1,2})?\b/g
+
+/**
+ * Builds the search index for one design
+ */
+export function buildSearchIndex(diagram: WiringDiagram): SearchIndex {
+  const objects: IndexedObject[] = []
+  const metadata = (o: { labels?: Record<string, string>; annotations?: Record<string, string> }) => {
+    const labels = { ...o.annotations, ...o.labels }
+    const ips = [...new Set(Object.values(labels).flatMap(v => v.match(IP_OR_CIDR) ?? []))]
+    return {
+      ...(Object.keys(labels).length > 0 && { labels }),
+      ...(ips.length > 0 && { ips })
+    }
+  }
+  const asset = (d: { serialNumber?: string; assetTag?: string; macAddress?: string }) => ({
+    ...(d.serialNumber && { serial: d.serialNumber }),
+    ...(d.assetTag && { assetTag: d.assetTag }),
+    ...(d.macAddress && { mac: d.macAddress })
+  })
+
+  for (const [role, switches] of [['spine', diagram.devices.spines], ['leaf', diagram.devices.leaves]] as const) {
+    for (const sw of switches) {
+      objects.push({ kind: 'switch', name: sw.id, role, model: sw.model, ...asset(sw), ...metadata(sw) })
+    }
+  }
+  for (const server of diagram.devices.servers) {
+    objects.push({ kind: 'server', name: server.id, role: server.type, ...asset(server), ...metadata(server) })
+  }
+  for (const c of diagram.connections) {
+    const ports = [`${c.from.device}/${c.from.port}`, `${c.to.device}/${c.to.port}`]
+    objects.push({ kind: 'connection', name: ports.join(' <-> '), role: c.type, ports, ...metadata(c) })
+  }
+  return { version: 1, fabricName: diagram.metadata.fabricName, objects }
+}
+
+/**
+ * Parses "field:value" (e.g. model:DS3000, ip:10.1.4.0/24, label:team=storage)
+ * or a bare value searched across the default fields
+ */
+export function parseSearchQuery(text: string): SearchQuery {
+  const match = text.match(/^(name|model|label|serial|ip|port):(.*)$/)
+  return match ? { field: match[1] as SearchField, value: match[2] } : { value: text }
+}
+
+/**
+ * Objects of one index that match the query. Text matches are
+ * case-insensitive substrings; ip matches addresses inside a queried CIDR
+ * and CIDRs that overlap it; label matches key or key=value.
+ */
+export function searchIndex(fabricId: string, index: SearchIndex, query: SearchQuery): SearchHit[] {
+  const needle = query.value.toLowerCase()
+  const contains = (value: string | undefined) => value !== undefined && value.toLowerCase().includes(needle)
+  const hits: SearchHit[] = []
+
+  for (const o of index.objects) {
+    const matches: string[] = []
+    const check = (field: string, value: string | undefined) => {
+      if (contains(value)) matches.push(`${field}=${value}`)
+    }
+    const labelMatches = () => {
+      const [key, value] = query.value.split('=', 2)
+      for (const [k, v] of Object.entries(o.labels ?? {})) {
+        if (value === undefined ? contains(k) || contains(v) : k === key && v === value) matches.push(`${k}=${v}`)
+      }
+    }
+
+    switch (query.field) {
+      case 'name': check('name', o.name); break
+      case 'model': check('model', o.model); break
+      case 'serial': check('serial', o.serial); check('assetTag', o.assetTag); break
+      case 'port': for (const port of o.ports ?? []) check('port', port); break
+      case 'label': labelMatches(); break
+      case 'ip': for (const ip of o.ips ?? []) if (overlaps(ip, query.value)) matches.push(`ip=${ip}`); break
+      default:
+        check('name', o.name)
+        check('model', o.model)
+        check('serial', o.serial)
+        check('assetTag', o.assetTag)
+        check('mac', o.mac)
+        labelMatches()
+    }
+    if (matches.length > 0) hits.push({ fabricId, kind: o.kind, name: o.name, match: matches.join(', ') })
+  }
+  return hits
+}
+
+function overlaps(a: string, b: string): boolean {
+  const ra = range(a)
+  const rb = range(b)
+  return ra !== undefined && rb !== undefined && ra[0] <= rb[1] && rb[0] <= ra[1]
+}
+
+// First and last address of an address or CIDR
+function range(value: string): [number, number] | undefined {
+  const [ip, bits = '32'] = value.split('/')
+  const octets = ip.split('.').map(Number)
+  const prefix = Number(bits)
+  if (octets.length !== 4 || octets.some(o => !Number.isInteger(o) || o < 0 || o > 255) || !(prefix >= 0 && prefix <= 32)) return undefined
+  const addr = octets.reduce((n, o) => n * 256 + o, 0)
+  const size = 2 ** (32 - prefix)
+  const first = Math.floor(addr / size) * size
+  return [first, first + size - 1]
+}
@@ -10,22 +10,39 @@ interface SerializedYAMLs {
 }
 
 // Zod schema for validation during deserialization
+const LabelsSchema = {
+  labels: z.record(z.string()).optional(),
+  annotations: z.record(z.string()).optional()
+}
+
+const AssetSchema = {
+  serialNumber: z.string().optional(),
+  assetTag: z.string().optional(),
+  macAddress: z.string().optional()
+}
+
 const SerializedWiringDiagramSchema = z.object({
   devices: z.object({
     spines: z.array(z.object({
       id: z.string(),
       model: z.string(), 
-      ports: z.number()
+      ports: z.number(),
+      ...LabelsSchema,
+      ...AssetSchema
     })),
     leaves: z.array(z.object({
       id: z.string(),
       model: z.string(),
-      ports: z.number()
+      ports: z.number(),
+      ...LabelsSchema,
+      ...AssetSchema
     })),
     servers: z.array(z.object({
       id: z.string(),
       type: z.string(),
-      connections: z.number()
+      connections: z.number(),
+      ...LabelsSchema,
+      ...AssetSchema
     }))
   }),
   connections: z.array(z.object({
@@ -37,7 +54,8 @@ const SerializedWiringDiagramSchema = z.object({
       device: z.string(),
       port: z.string()
     }),
-    type: z.enum(['uplink', 'downlink', 'endpoint'])
+    type: z.enum(['uplink', 'downlink', 'endpoint']),
+    ...LabelsSchema
   })),
   metadata: z.object({
     generatedAt: z.date(),
@@ -46,11 +64,26 @@ const SerializedWiringDiagramSchema = z.object({
   })
 })
 
+const DUMP_OPTIONS: yaml.DumpOptions = {
+  sortKeys: true,
+  indent: 2,
+  lineWidth: 120,
+  quotingType: '"'
+}
+
+// Document name -> the key holding its object list
+const DOCUMENT_LISTS = {
+  servers: 'servers',
+  switches: 'switches',
+  connections: 'connections'
+} as const
+
+export type DocumentName = keyof SerializedYAMLs
+
 /**
- * Serializes a WiringDiagram to separate YAML strings for servers, switches, and connections
- * Uses deterministic sorting for consistent output
+ * The three documents as plain data, before dumping to YAML
  */
-export function serializeWiringDiagram(diagram: WiringDiagram): SerializedYAMLs {
+function buildDocuments(diagram: WiringDiagram): Record<DocumentName, any> {
   // Servers YAML - includes both spines and leaves, plus endpoint servers
   const allSwitches = [
     ...diagram.devices.spines.map(s => ({ ...s, type: 'spine' as const })),
@@ -85,37 +118,65 @@ export function serializeWiringDiagram(diagram: WiringDiagram): SerializedYAMLs
     }
   }
 
+  return { servers: serversData, switches: switchesData, connections: connectionsData }
+}
+
+/**
+ * Serializes a WiringDiagram to separate YAML strings for servers, switches, and connections
+ * Uses deterministic sorting for consistent output
+ */
+export function serializeWiringDiagram(diagram: WiringDiagram): SerializedYAMLs {
+  const documents = buildDocuments(diagram)
   return {
-    servers: yaml.dump(serversData, { 
-      sortKeys: true, 
-      indent: 2,
-      lineWidth: 120,
-      quotingType: '"'
-    }),
-    switches: yaml.dump(switchesData, { 
-      sortKeys: true, 
-      indent: 2,
-      lineWidth: 120,
-      quotingType: '"'
-    }),
-    connections: yaml.dump(connectionsData, { 
-      sortKeys: true, 
-      indent: 2,
-      lineWidth: 120,
-      quotingType: '"'
-    })
+    servers: yaml.dump(documents.servers, DUMP_OPTIONS),
+    switches: yaml.dump(documents.switches, DUMP_OPTIONS),
+    connections: yaml.dump(documents.connections, DUMP_OPTIONS)
   }
 }
 
+/**
+ * Serializes a WiringDiagram with each document split into chunks of at
+ * most chunkSize objects. Every chunk is a complete document of the same
+ * shape, its metadata extended with chunk (1-based) and chunks.
+ */
+export function serializeWiringDiagramChunks(diagram: WiringDiagram, chunkSize: number): Record<DocumentName, string[]> {
+  if (!Number.isInteger(chunkSize) || chunkSize < 1) {
+    throw new Error(`Chunk size must be a positive integer, got ${chunkSize}`)
+  }
+  const documents = buildDocuments(diagram)
+  const chunk = (name: DocumentName): string[] => {
+    const list: unknown[] = documents[name][DOCUMENT_LISTS[name]]
+    const chunks = Math.max(1, Math.ceil(list.length / chunkSize))
+    return Array.from({ length: chunks }, (_, i) => yaml.dump({
+      [DOCUMENT_LISTS[name]]: list.slice(i * chunkSize, (i + 1) * chunkSize),
+      metadata: { ...documents[name].metadata, chunk: i + 1, chunks }
+    }, DUMP_OPTIONS))
+  }
+  return { servers: chunk('servers'), switches: chunk('switches'), connections: chunk('connections') }
+}
+
 /**
  * Deserializes YAML strings back to a WiringDiagram
  * Validates structure and reconstructs the complete diagram
  */
 export function deserializeWiringDiagram(yamls: SerializedYAMLs): WiringDiagram {
+  return deserializeWiringDiagramChunks({
+    servers: [yamls.servers],
+    switches: [yamls.switches],
+    connections: [yamls.connections]
+  })
+}
+
+/**
+ * Deserializes documents written by serializeWiringDiagramChunks (a single
+ * unchunked document per name works too). Chunks must be in order and
+ * complete.
+ */
+export function deserializeWiringDiagramChunks(chunks: Record<DocumentName, string[]>): WiringDiagram {
   try {
-    const serversData = yaml.load(yamls.servers) as any
-    const switchesData = yaml.load(yamls.switches) as any
-    const connectionsData = yaml.load(yamls.connections) as any
+    const serversData = mergeChunks('servers', chunks.servers)
+    const switchesData = mergeChunks('switches', chunks.switches)
+    const connectionsData = mergeChunks('connections', chunks.connections)
 
     // Validate basic structure
     if (!serversData || !Array.isArray(serversData.servers)) {
@@ -169,6 +230,29 @@ export function deserializeWiringDiagram(yamls: SerializedYAMLs): WiringDiagram
   }
 }
 
+/**
+ * Joins a document's chunks back into one document. Unchunked documents
+ * pass through as they are.
+ */
+function mergeChunks(name: DocumentName, chunks: string[]): any {
+  const parsed = chunks.map(chunk => yaml.load(chunk) as any)
+  if (parsed.length === 1 && parsed[0]?.metadata?.chunks === undefined) return parsed[0]
+
+  const key = DOCUMENT_LISTS[name]
+  const list: unknown[] = []
+  parsed.forEach((data, i) => {
+    if (!data || !Array.isArray(data[key])) {
+      throw new Error(`Invalid ${name} data: chunk ${i + 1} is missing or has an invalid ${key} array`)
+    }
+    if (data.metadata?.chunk !== i + 1 || data.metadata?.chunks !== parsed.length) {
+      throw new Error(`Invalid ${name} data: expected chunk ${i + 1} of ${parsed.length}, found chunk ${data.metadata?.chunk} of ${data.metadata?.chunks}`)
+    }
+    list.push(...data[key])
+  })
+  const { chunk, chunks: total, ...metadata } = parsed[0].metadata
+  return { [key]: list, metadata }
+}
+
 /**
  * Validates that a YAML string is well-formed
  */
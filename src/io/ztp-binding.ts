@@ -0,0 +1,164 @@
+/**
+ * ZTP MAC Binding - HNC v0.6
+ * Binds switches discovered on the management network (from DHCP server
+ * logs or a CSV) to design switch identities, so zero-touch provisioning
+ * hands each physical box the config of the switch it was cabled as.
+ *
+ * A discovered box is bound by, in order: an explicit device column in the
+ * CSV, or a serial number matching one imported from procurement.
+ */
+
+import { parseCsv } from './asset-import'
+import type { Wiring, WiringDevice } from '../domain/wiring'
+
+export interface DiscoveredSwitch {
+  mac: string // normalized lower-case aa:bb:cc:dd:ee:ff
+  serial?: string
+  deviceId?: string // operator-supplied binding
+  source: string // log line or CSV line, for error messages
+}
+
+export interface ZtpBinding {
+  deviceId: string
+  mac: string
+  via: 'explicit' | 'serial'
+}
+
+export interface ZtpBindingResult {
+  wiring: Wiring // the input wiring when there are errors
+  bindings: ZtpBinding[]
+  unbound: DiscoveredSwitch[] // seen on the network, not matched to a design switch
+  pending: string[] // design switches with no MAC yet
+  errors: string[]
+}
+
+const MAC = /\b([0-9a-f]{2}(?:[:-][0-9a-f]{2}){5})\b/i
+
+/**
+ * Extracts client MACs from ISC dhcpd or dnsmasq DISCOVER/REQUEST log lines,
+ * first sighting wins
+ */
+export function parseDhcpLog(text: string): DiscoveredSwitch[] {
+  const seen = new Map<string, DiscoveredSwitch>()
+  text.split(/\r?\n/).forEach((line, i) => {
+    if (!/DHCP(DISCOVER|REQUEST)/.test(line)) return
+    const match = line.match(MAC)
+    if (!match) return
+    const mac = normalizeMac(match[1])
+    if (!seen.has(mac)) seen.set(mac, { mac, source: `log line ${i + 1}` })
+  })
+  return [...seen.values()]
+}
+
+/**
+ * Parses a discovery CSV with a mac column and optional serial and device
+ * columns
+ */
+export function parseDiscoveryCsv(text: string): { discovered: DiscoveredSwitch[]; errors: string[] } {
+  const [header, ...rows] = parseCsv(text)
+  if (!header) return { discovered: [], errors: ['Discovery CSV is empty'] }
+
+  const normalized = header.map(h => h.toLowerCase().replace(/[^a-z0-9]/g, ''))
+  const column = (names: string[]) => normalized.findIndex(h => names.includes(h))
+  const mac = column(['mac', 'macaddress', 'hwaddress'])
+  const serial = column(['serial', 'serialnumber', 'sn'])
+  const device = column(['device', 'hostname', 'name', 'deviceid'])
+  if (mac === -1) return { discovered: [], errors: ['Discovery CSV needs a mac column'] }
+
+  const discovered: DiscoveredSwitch[] = []
+  const errors: string[] = []
+  rows.forEach((cells, i) => {
+    if (cells.every(c => c.trim() === '')) return
+    const raw = cells[mac]?.trim() ?? ''
+    if (!MAC.test(raw)) {
+      errors.push(`Line ${i + 2}: '${raw}' is not a MAC address`)
+      return
+    }
+    const entry: DiscoveredSwitch = { mac: normalizeMac(raw), source: `line ${i + 2}` }
+    const serialValue = serial === -1 ? '' : cells[serial]?.trim()
+    const deviceValue = device === -1 ? '' : cells[device]?.trim()
+    if (serialValue) entry.serial = serialValue
+    if (deviceValue) entry.deviceId = deviceValue
+    discovered.push(entry)
+  })
+
+  return { discovered, errors }
+}
+
+/**
+ * Binds discovered MACs to design switches. Nothing is applied if a
+ * binding names an unknown switch, or a MAC or switch would be bound twice.
+ */
+export function bindDiscoveredSwitches(wiring: Wiring, discovered: DiscoveredSwitch[]): ZtpBindingResult {
+  const working: Wiring = structuredClone(wiring)
+  const switches = [...working.devices.spines, ...working.devices.leaves]
+  const byId = new Map(switches.map(s => [s.id, s]))
+  const bySerial = new Map(switches.filter(s => s.serialNumber).map(s => [s.serialNumber!, s]))
+  const macOwner = new Map(switches.filter(s => s.macAddress).map(s => [s.macAddress!, s.id]))
+
+  const bindings: ZtpBinding[] = []
+  const unbound: DiscoveredSwitch[] = []
+  const errors: string[] = []
+
+  for (const box of discovered) {
+    let target: WiringDevice | undefined
+    let via: ZtpBinding['via'] = 'explicit'
+    if (box.deviceId) {
+      target = byId.get(box.deviceId)
+      if (!target) {
+        errors.push(`${box.source}: ${box.deviceId} is not a switch in this design`)
+        continue
+      }
+      if (box.serial && target.serialNumber && box.serial !== target.serialNumber) {
+        errors.push(`${box.source}: ${box.deviceId} has serial ${target.serialNumber}, but the box reports ${box.serial}`)
+        continue
+      }
+    } else if (box.serial && bySerial.has(box.serial)) {
+      target = bySerial.get(box.serial)
+      via = 'serial'
+    }
+    if (!target) {
+      unbound.push(box)
+      continue
+    }
+
+    const owner = macOwner.get(box.mac)
+    if (owner && owner !== target.id) {
+      errors.push(`${box.source}: MAC ${box.mac} is already bound to ${owner}`)
+      continue
+    }
+    if (target.macAddress && target.macAddress !== box.mac) {
+      errors.push(`${box.source}: ${target.id} is already bound to MAC ${target.macAddress}`)
+      continue
+    }
+
+    target.macAddress = box.mac
+    if (box.serial && !target.serialNumber) target.serialNumber = box.serial
+    macOwner.set(box.mac, target.id)
+    bindings.push({ deviceId: target.id, mac: box.mac, via })
+  }
+
+  const result = errors.length > 0 ? wiring : working
+  return {
+    wiring: result,
+    bindings: errors.length > 0 ? [] : bindings,
+    unbound,
+    pending: [...result.devices.spines, ...result.devices.leaves].filter(s => !s.macAddress).map(s => s.id),
+    errors
+  }
+}
+
+/**
+ * ISC dhcpd host reservations for every bound switch, so the DHCP server
+ * hands each box its design hostname
+ */
+export function renderDhcpdHosts(wiring: Wiring): string {
+  return [...wiring.devices.spines, ...wiring.devices.leaves]
+    .filter(s => s.macAddress)
+    .map(s => `host ${s.id} {\n  hardware ethernet ${s.macAddress};\n  option host-name "${s.id}";\n}\n`)
+    .join('')
+}
+
+function normalizeMac(mac: string): string {
+  return mac.toLowerCase().replace(/-/g, ':')
+}
@@ -31,6 +31,12 @@ export const EndpointProfileSchema = z.object({
   nics: z.number().int().min(1).max(8).default(1).optional(), // NIC count per endpoint
 });
 
+// Mixed uplink speeds, e.g. 2 x 400G + 2 x 100G during a migration
+const UplinkSpeedsSchema = z.array(z.object({
+  count: z.number().int().min(1, 'Uplink speed group needs at least 1 link'),
+  speedGbps: z.number().positive('Uplink speed must be positive'),
+})).optional();
+
 // LeafClass schema for multi-class fabric support
 export const LeafClassSchema = z.object({
   id: z.string()
@@ -53,6 +59,8 @@ export const LeafClassSchema = z.object({
     .min(1, 'Minimum 1 uplink per leaf')
     .max(4, 'Maximum 4 uplinks per leaf'),
   
+  uplinkSpeeds: UplinkSpeedsSchema,
+  
   endpointProfiles: z.array(EndpointProfileSchema)
     .min(1, 'At least one endpoint profile is required per leaf class')
     .max(10, 'Maximum 10 endpoint profiles per leaf class'),
@@ -93,9 +101,15 @@ export const FabricSpecSchema = z.object({
     .max(4, 'Maximum 4 uplinks per leaf')
     .optional(),
   
+  uplinkSpeeds: UplinkSpeedsSchema,
+  
   endpointProfile: EndpointProfileSchema.optional(),
   endpointCount: z.number().int().min(1).max(10000).optional(),
   
+  policyPack: z.string().optional(), // Policy pack the design was created from
+  catalog: z.string().optional(), // Named profile catalog the design selects models from
+  placementSeed: z.number().int().min(0).max(0xffffffff).optional(), // Reproducible placement tie-breaks
+  
   // Common fields
   breakoutEnabled: z.boolean().optional(), // Global port breakout support
   metadata: z.record(z.string(), z.any()).optional(),
@@ -0,0 +1,46 @@
+import { describe, it, expect } from 'vitest'
+import { parseDesignTemplate, renderDesignTemplate } from './design-template'
+
+const TEMPLATE = `
+name: standard-pod
+variables:
+  siteCode: { type: string, pattern: '[a-z]{3}[0-9]' }
+  servers: { type: number, min: 1, max: 2000 }
+  uplinks: { type: number, default: 4 }
+spec:
+  name: '{{ siteCode }}-pod1'
+  spineModelId: DS3000
+  leafModelId: DS2000
+  uplinksPerLeaf: '{{ uplinks }}'
+  endpointCount: '{{servers}}'
+  endpointProfile: { name: server, portsPerEndpoint: 1 }
+`
+
+describe('design templates', () => {
+  it('renders typed values into a concrete spec', () => {
+    const result = renderDesignTemplate(parseDesignTemplate(TEMPLATE), 'siteCode: ams1\nservers: 96\n')
+
+    expect(result.errors).toEqual([])
+    expect(result.spec).toMatchObject({ name: 'ams1-pod1', uplinksPerLeaf: 4, endpointCount: 96 })
+  })
+
+  it('reports missing, unknown and out-of-range values', () => {
+    const result = renderDesignTemplate(parseDesignTemplate(TEMPLATE), { servers: 5000, extra: 1 })
+
+    expect(result.spec).toBeNull()
+    expect(result.errors).toEqual([
+      "Unknown value 'extra' (not declared by template standard-pod)",
+      "Missing required value 'siteCode'",
+      "Value 'servers' (5000) is above maximum 2000"
+    ])
+  })
+
+  it('rejects values that do not match a pattern', () => {
+    const result = renderDesignTemplate(parseDesignTemplate(TEMPLATE), { siteCode: 'AMS', servers: 4 })
+    expect(result.errors).toEqual(["Value 'siteCode' ('AMS') does not match [a-z]{3}[0-9]"])
+  })
+
+  it('requires a spec body', () => {
+    expect(() => parseDesignTemplate('name: empty')).toThrow('Design template must define a spec')
+  })
+})
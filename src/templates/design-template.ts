@@ -0,0 +1,137 @@
+/**
+ * Parameterized Design Templates
+ * A design template declares typed variables and a fabric spec body that
+ * references them; rendering with a values file yields a concrete spec
+ * (Helm-style reuse for repeatable site builds)
+ */
+
+import * as yaml from 'js-yaml'
+import type { FabricSpec } from '../app.types'
+
+export interface TemplateVariable {
+  type: 'number' | 'string' | 'boolean'
+  description?: string
+  default?: number | string | boolean
+  required?: boolean
+  min?: number
+  max?: number
+  pattern?: string // regex for string values
+}
+
+export interface DesignTemplate {
+  name: string
+  description?: string
+  variables: Record<string, TemplateVariable>
+  // Any string may contain {{ var }}; a string that is only {{ var }} takes the variable's type
+  spec: unknown
+}
+
+export interface RenderResult {
+  spec: FabricSpec | null
+  values: Record<string, number | string | boolean>
+  errors: string[]
+}
+
+const WHOLE = /^\{\{\s*([A-Za-z_][\w]*)\s*\}\}$/
+const INLINE = /\{\{\s*([A-Za-z_][\w]*)\s*\}\}/g
+
+/**
+ * Parses a template document (YAML or JSON)
+ */
+export function parseDesignTemplate(source: string): DesignTemplate {
+  const doc = yaml.load(source) as Partial<DesignTemplate> | null
+  if (!doc || typeof doc !== 'object' || !doc.spec) {
+    throw new Error('Design template must define a spec')
+  }
+  return {
+    name: doc.name || 'template',
+    description: doc.description,
+    variables: doc.variables || {},
+    spec: doc.spec
+  }
+}
+
+/**
+ * Renders a template with the given values (already parsed or as YAML text)
+ */
+export function renderDesignTemplate(
+  template: DesignTemplate,
+  input: Record<string, unknown> | string = {}
+): RenderResult {
+  const errors: string[] = []
+  const raw = typeof input === 'string' ? ((yaml.load(input) as Record<string, unknown>) || {}) : input
+  const values: Record<string, number | string | boolean> = {}
+
+  for (const key of Object.keys(raw)) {
+    if (!(key in template.variables)) errors.push(`Unknown value '${key}' (not declared by template ${template.name})`)
+  }
+
+  for (const [name, variable] of Object.entries(template.variables)) {
+    const value = name in raw ? raw[name] : variable.default
+    if (value === undefined) {
+      if (variable.required !== false) errors.push(`Missing required value '${name}'`)
+      continue
+    }
+    const checked = checkValue(name, variable, value)
+    if ('error' in checked) {
+      errors.push(checked.error)
+      continue
+    }
+    values[name] = checked.value
+  }
+
+  if (errors.length > 0) return { spec: null, values, errors }
+
+  const substitute = (node: unknown, path: string): unknown => {
+    if (typeof node === 'string') {
+      const whole = node.match(WHOLE)
+      if (whole) {
+        if (!(whole[1] in values)) errors.push(`${path}: undefined variable '${whole[1]}'`)
+        return values[whole[1]]
+      }
+      return node.replace(INLINE, (_, name: string) => {
+        if (!(name in values)) errors.push(`${path}: undefined variable '${name}'`)
+        return String(values[name] ?? '')
+      })
+    }
+    if (Array.isArray(node)) return node.map((item, i) => substitute(item, `${path}[${i}]`))
+    if (node && typeof node === 'object') {
+      return Object.fromEntries(Object.entries(node).map(([k, v]) => [k, substitute(v, `${path}.${k}`)]))
+    }
+    return node
+  }
+
+  const spec = substitute(template.spec, 'spec') as FabricSpec
+  if (!spec.name || !spec.spineModelId || !spec.leafModelId) {
+    errors.push('Rendered spec must define name, spineModelId and leafModelId')
+  }
+
+  return { spec: errors.length === 0 ? spec : null, values, errors }
+}
+
+type Checked = { value: number | string | boolean } | { error: string }
+
+/**
+ * Coerces a raw value to the variable's type and applies its constraints
+ */
+function checkValue(name: string, variable: TemplateVariable, value: unknown): Checked {
+  switch (variable.type) {
+    case 'number': {
+      const n = typeof value === 'string' ? Number(value) : value
+      if (typeof n !== 'number' || Number.isNaN(n)) return { error: `Value '${name}' must be a number` }
+      if (variable.min !== undefined && n < variable.min) return { error: `Value '${name}' (${n}) is below minimum ${variable.min}` }
+      if (variable.max !== undefined && n > variable.max) return { error: `Value '${name}' (${n}) is above maximum ${variable.max}` }
+      return { value: n }
+    }
+    case 'boolean':
+      if (typeof value !== 'boolean') return { error: `Value '${name}' must be true or false` }
+      return { value }
+    default: {
+      const s = String(value)
+      if (variable.pattern && !new RegExp(`^(?:${variable.pattern})$`).test(s)) {
+        return { error: `Value '${name}' ('${s}') does not match ${variable.pattern}` }
+      }
+      return { value: s }
+    }
+  }
+}
@@ -0,0 +1,92 @@
+/**
+ * Policy Pack Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest'
+import { POLICY_PACKS, applyNamingTemplates, createSpecFromPolicyPack, evaluatePolicyPack } from './policy-packs'
+import type { Wiring, WiringConnection } from '../domain/wiring'
+import type { SwitchProfile } from '../app.types'
+import { testProfile } from '../fixtures/testFabric'
+
+const profiles = new Map<string, SwitchProfile>([
+  ['DS3000', testProfile('DS3000', [], ['E1/1-4'])],
+  ['DS2000', testProfile('DS2000', ['E1/1-4'], ['E1/49-52'])]
+])
+
+const uplink = (leaf: string, spine: string, n: number): WiringConnection =>
+  ({ id: `link-${leaf}-${spine}-${n}`, from: { device: leaf, port: `E1/${48 + n}` }, to: { device: spine, port: `E1/${n}` }, type: 'uplink' })
+const endpoint = (server: string, leaf: string, n: number): WiringConnection =>
+  ({ id: `link-${server}-${leaf}-1`, from: { device: server, port: 'eth0' }, to: { device: leaf, port: `E1/${n}` }, type: 'endpoint' })
+
+// One leaf, two spines, 2x100G up and 4x25G down (1:1), every endpoint port used
+const wiring: Wiring = {
+  devices: {
+    spines: [1, 2].map(n => ({ id: `spine-${n}`, type: 'spine' as const, modelId: 'DS3000', ports: 4 })),
+    leaves: [{ id: 'leaf-1', type: 'leaf' as const, modelId: 'DS2000', ports: 8 }],
+    servers: [1, 2, 3, 4].map(n => ({ id: `srv-${n}`, type: 'server' as const, modelId: 'server', ports: 1 }))
+  },
+  connections: [
+    uplink('leaf-1', 'spine-1', 1), uplink('leaf-1', 'spine-2', 2),
+    ...[1, 2, 3, 4].map(n => endpoint(`srv-${n}`, 'leaf-1', n))
+  ],
+  metadata: { fabricName: 'pod1', fabricId: 'pod1', generatedAt: new Date(0), totalDevices: 7, totalConnections: 6 }
+}
+
+describe('createSpecFromPolicyPack', () => {
+  it('starts from the pack defaults and lets the caller override them', () => {
+    const spec = createSpecFromPolicyPack('ai-hpc', 'gpu-pod', { endpointCount: 64, uplinksPerLeaf: 2 })
+
+    expect(spec).toMatchObject({
+      name: 'gpu-pod',
+      policyPack: 'ai-hpc',
+      spineModelId: 'DS3000',
+      leafModelId: 'DS2000',
+      uplinksPerLeaf: 2,
+      endpointCount: 64,
+      spareCapacity: POLICY_PACKS['ai-hpc'].spareCapacity
+    })
+    expect(createSpecFromPolicyPack('ai-hpc', 'gpu-pod').uplinksPerLeaf).toBe(4)
+    expect(() => createSpecFromPolicyPack('campus', 'x')).toThrow("Unknown policy pack 'campus'")
+  })
+})
+
+describe('applyNamingTemplates', () => {
+  it('renames devices and their connection ends', () => {
+    const { wiring: named, mapping, errors } = applyNamingTemplates(wiring, POLICY_PACKS.enterprise.naming)
+
+    expect(errors).toEqual([])
+    expect(named.devices.spines.map(d => d.id)).toEqual(['pod1-spine-01', 'pod1-spine-02'])
+    expect(named.devices.servers.map(d => d.id)).toEqual(['srv-1', 'srv-2', 'srv-3', 'srv-4'])
+    expect(mapping['leaf-1']).toBe('pod1-leaf-01')
+    expect(named.connections[0]).toMatchObject({
+      id: 'link-pod1-leaf-01-pod1-spine-01-1',
+      from: { device: 'pod1-leaf-01' },
+      to: { device: 'pod1-spine-01' }
+    })
+    expect(named.connections[2].to.device).toBe('pod1-leaf-01')
+    expect(wiring.devices.leaves[0].id).toBe('leaf-1')
+  })
+
+  it('refuses templates that give two devices the same name', () => {
+    const result = applyNamingTemplates(wiring, { spine: '{fabric}-spine' })
+
+    expect(result.errors).toEqual(['Devices spine-1 and spine-2 would both be named pod1-spine'])
+    expect(result.wiring).toBe(wiring)
+  })
+})
+
+describe('evaluatePolicyPack', () => {
+  it('checks the reference rules and spare capacity of the selected pack', () => {
+    const ai = evaluatePolicyPack(wiring, POLICY_PACKS['ai-hpc'], profiles)
+    const enterprise = evaluatePolicyPack(wiring, POLICY_PACKS.enterprise, profiles)
+
+    expect(ai.conformance.checks.find(c => c.rule === 'maxOversubscription')?.score).toBe(1)
+    expect(ai.conformance.checks.find(c => c.rule === 'minUplinksPerLeaf')?.failures).toHaveLength(1)
+    expect(ai.errors).toEqual([])
+    expect(enterprise.errors).toEqual([
+      'Leaf leaf-1 has 0 of 4 endpoint ports free; policy requires 1 (20%)',
+      'Spine spine-1 has 3 spare uplink ports; policy requires 4',
+      'Spine spine-2 has 3 spare uplink ports; policy requires 4'
+    ])
+  })
+})
@@ -0,0 +1,130 @@
+/**
+ * Role-Aware Policy Packs - HNC v0.6
+ * Default oversubscription targets, redundancy rules, spare capacity and
+ * device naming for the common fabric roles, picked when a design is
+ * created so it starts from sane values instead of blank fields.
+ */
+
+import { checkConformance, type ConformanceReport, type ReferenceArchitecture } from '../domain/reference-architecture'
+import { evaluateSpareCapacity } from '../domain/spare-capacity'
+import { renameDevices, type DeviceRenameResult } from '../domain/device-rename'
+import type { Wiring } from '../domain/wiring'
+import type { FabricSpec, SpareCapacityPolicy, SwitchProfile } from '../app.types'
+
+/**
+ * Device id patterns. Placeholders: {fabric}, {class} (leaf class id, empty
+ * for single-class designs) and {n} - the 1-based index within the role,
+ * zero-padded with {n:3}.
+ */
+export interface NamingTemplates {
+  spine?: string
+  leaf?: string
+  server?: string
+}
+
+export interface PolicyPack {
+  id: string
+  name: string
+  description: string
+  reference: ReferenceArchitecture
+  spareCapacity: SpareCapacityPolicy
+  defaults: Partial<FabricSpec> // applied before the caller's values
+  naming: NamingTemplates
+}
+
+export interface PolicyPackReport {
+  conformance: ConformanceReport
+  errors: string[] // spare-capacity violations
+}
+
+export const POLICY_PACKS: Record<string, PolicyPack> = {
+  enterprise: {
+    id: 'enterprise',
+    name: 'Enterprise Data Center',
+    description: 'General-purpose workloads: 3:1 oversubscription, dual-homed servers, room to grow',
+    reference: {
+      name: 'enterprise',
+      rules: { maxOversubscription: 3, spines: { min: 2 }, minUplinksPerLeaf: 2, minSpinesPerLeaf: 2, evenSpineLoad: true, minLeavesPerServer: 2 }
+    },
+    spareCapacity: { minFreeEndpointPortsPercent: 20, minSpareUplinksPerSpine: 4 },
+    defaults: { uplinksPerLeaf: 2 },
+    naming: { spine: '{fabric}-spine-{n:2}', leaf: '{fabric}-leaf-{n:2}' }
+  },
+  'ai-hpc': {
+    id: 'ai-hpc',
+    name: 'AI / HPC',
+    description: 'GPU and HPC clusters: non-blocking fabric, wide uplinks, tight spare margin',
+    reference: {
+      name: 'ai-hpc',
+      rules: { maxOversubscription: 1, spines: { min: 2 }, minUplinksPerLeaf: 4, minSpinesPerLeaf: 2, evenSpineLoad: true }
+    },
+    spareCapacity: { minFreeEndpointPortsPercent: 0, minSpareUplinksPerSpine: 2 },
+    defaults: { uplinksPerLeaf: 4 },
+    naming: { spine: '{fabric}-sp{n:2}', leaf: '{fabric}-rail{n:2}' }
+  },
+  edge: {
+    id: 'edge',
+    name: 'Edge Site',
+    description: 'Small remote sites: few switches, higher oversubscription, single spine allowed',
+    reference: {
+      name: 'edge',
+      rules: { maxOversubscription: 6, spines: { min: 1, max: 2 }, maxLeaves: 4, minUplinksPerLeaf: 1 }
+    },
+    spareCapacity: { minFreeEndpointPortsPercent: 10 },
+    defaults: { uplinksPerLeaf: 2 },
+    naming: { spine: '{fabric}-agg{n}', leaf: '{fabric}-tor{n}' }
+  }
+}
+
+export function getPolicyPack(id: string): PolicyPack | undefined {
+  return POLICY_PACKS[id]
+}
+
+/**
+ * Starting spec for a new design: pack defaults, then the caller's overrides
+ */
+export function createSpecFromPolicyPack(packId: string, name: string, overrides: Partial<FabricSpec> = {}): FabricSpec {
+  const pack = getPolicyPack(packId)
+  if (!pack) throw new Error(`Unknown policy pack '${packId}'`)
+  return {
+    spineModelId: 'DS3000',
+    leafModelId: 'DS2000',
+    ...pack.defaults,
+    spareCapacity: { ...pack.spareCapacity },
+    ...overrides,
+    name,
+    policyPack: pack.id
+  }
+}
+
+/**
+ * Renames spines, leaves and servers by the pack's templates; roles without
+ * a template keep their generated ids
+ */
+export function applyNamingTemplates(wiring: Wiring, naming: NamingTemplates, fabricName = wiring.metadata.fabricName): DeviceRenameResult {
+  const indexes = new Map<string, number>()
+  for (const [role, devices] of Object.entries(wiring.devices)) {
+    devices.forEach((device, i) => indexes.set(`${role}:${device.id}`, i + 1))
+  }
+  const roles = { spine: 'spines', leaf: 'leaves', server: 'servers' } as const
+  return renameDevices(wiring, device => {
+    const template = naming[device.type]
+    if (!template) return undefined
+    const n = indexes.get(`${roles[device.type]}:${device.id}`) ?? 0
+    return template
+      .replace(/\{fabric\}/g, fabricName)
+      .replace(/\{class\}/g, device.classId ?? '')
+      .replace(/\{n(?::(\d+))?\}/g, (_, width?: string) => String(n).padStart(Number(width ?? 0), '0'))
+  })
+}
+
+/**
+ * Checks a generated wiring against the pack's reference rules and spare
+ * capacity policy
+ */
+export function evaluatePolicyPack(wiring: Wiring, pack: PolicyPack, profiles: Map<string, SwitchProfile>): PolicyPackReport {
+  return {
+    conformance: checkConformance(wiring, pack.reference, { profiles }),
+    errors: evaluateSpareCapacity(wiring, profiles, pack.spareCapacity).errors
+  }
+}
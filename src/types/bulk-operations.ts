@@ -1,7 +1,7 @@
 // Bulk Operations Types for WP-BULK1
 // Enables bulk renaming and class reassignment with preview and validation
 
-import type { LeafClass, EndpointProfile, FabricGuard } from '../app.types'
+import type { LeafClass, EndpointProfile, FabricGuard, FabricSpec } from '../app.types'
 
 // Pattern-based renaming configuration
 export interface RenamingPattern {
@@ -187,3 +187,17 @@ export interface BulkOperationSelection {
   filters: SelectionFilter
   excludeIds: string[]
 }
+
+// Batch endpoint operations: applied all-or-nothing with a single recompute
+export type EndpointBatchOperation =
+  | { op: 'add'; classId: string; profileName: string; count: number; profile?: Omit<EndpointProfile, 'name' | 'count'> }
+  | { op: 'remove'; classId: string; profileName: string; count: number }
+  | { op: 'reclass'; fromClassId: string; toClassId: string; profileName: string; count: number }
+
+export interface EndpointBatchResult {
+  applied: boolean
+  spec: FabricSpec // the updated spec, or the original when not applied
+  changes: ChangeRecord[]
+  errors: string[]
+  capacity: CapacitySnapshot
+}
@@ -0,0 +1,83 @@
+// Batch Endpoint Operations Tests
+import { describe, it, expect, vi } from 'vitest'
+import { applyEndpointBatch } from './bulk-endpoints'
+import type { FabricSpec } from '../app.types'
+
+const spec: FabricSpec = {
+  name: 'bulk-fabric',
+  spineModelId: 'DS3000',
+  leafModelId: 'DS2000',
+  leafClasses: [
+    {
+      id: 'compute',
+      name: 'Compute',
+      role: 'standard',
+      uplinksPerLeaf: 4,
+      endpointProfiles: [{ name: 'Server', portsPerEndpoint: 1, count: 300 }]
+    },
+    {
+      id: 'storage',
+      name: 'Storage',
+      role: 'standard',
+      uplinksPerLeaf: 4,
+      count: 2,
+      endpointProfiles: [{ name: 'Storage', portsPerEndpoint: 1, count: 40 }]
+    }
+  ]
+}
+
+describe('applyEndpointBatch', () => {
+  it('applies adds, removes and re-classes with one recompute', () => {
+    const recompute = vi.fn(() => [])
+    const result = applyEndpointBatch(spec, [
+      { op: 'add', classId: 'compute', profileName: 'GPU', count: 200, profile: { portsPerEndpoint: 2 } },
+      { op: 'remove', classId: 'compute', profileName: 'Server', count: 100 },
+      { op: 'reclass', fromClassId: 'compute', toClassId: 'storage', profileName: 'Server', count: 20 }
+    ], { recompute })
+
+    expect(result.errors).toEqual([])
+    expect(result.applied).toBe(true)
+    expect(recompute).toHaveBeenCalledTimes(1)
+
+    const [compute, storage] = result.spec.leafClasses!
+    expect(compute.endpointProfiles).toEqual([
+      { name: 'Server', portsPerEndpoint: 1, count: 180 },
+      { name: 'GPU', portsPerEndpoint: 2, count: 200 }
+    ])
+    expect(storage.endpointProfiles[1]).toEqual({ name: 'Server', portsPerEndpoint: 1, count: 20 })
+    expect(result.changes.map(c => [c.path, c.before, c.after])).toEqual([
+      ['leafClasses.compute.endpointProfiles.GPU.count', 0, 200],
+      ['leafClasses.compute.endpointProfiles.Server.count', 300, 200],
+      ['leafClasses.compute.endpointProfiles.Server.count', 200, 180],
+      ['leafClasses.storage.endpointProfiles.Server.count', 0, 20]
+    ])
+    expect(spec.leafClasses![0].endpointProfiles).toHaveLength(1) // input untouched
+  })
+
+  it('applies nothing when any operation fails', () => {
+    const result = applyEndpointBatch(spec, [
+      { op: 'add', classId: 'compute', profileName: 'Server', count: 10 },
+      { op: 'remove', classId: 'storage', profileName: 'Storage', count: 50 },
+      { op: 'reclass', fromClassId: 'compute', toClassId: 'edge', profileName: 'Server', count: 1 }
+    ])
+
+    expect(result.applied).toBe(false)
+    expect(result.spec).toBe(spec)
+    expect(result.changes).toEqual([])
+    expect(result.errors).toEqual([
+      'Operation 2 (remove): class storage has 40 Storage endpoints, cannot remove 50',
+      'Operation 3 (reclass): leaf class edge does not exist'
+    ])
+  })
+
+  it('rejects the batch when fixed-size classes overflow on recompute', () => {
+    // storage has 2 leaves x 44 endpoint ports = 88
+    const result = applyEndpointBatch(spec, [
+      { op: 'reclass', fromClassId: 'compute', toClassId: 'storage', profileName: 'Server', count: 49 }
+    ])
+
+    expect(result.applied).toBe(false)
+    expect(result.errors).toEqual(['Class storage needs 89 endpoint ports but its 2 leaves provide 88'])
+    expect(result.capacity.byClass.storage.endpointCount).toBe(40)
+  })
+})
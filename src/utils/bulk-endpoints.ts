@@ -0,0 +1,172 @@
+// Batch Endpoint Operations
+// Adds, removes or re-classes many endpoints in one transaction: every
+// operation is checked against a working copy, the fabric is recomputed once,
+// and nothing is applied unless the whole batch succeeds.
+
+import type { FabricSpec, LeafClass } from '../app.types'
+import type { ChangeRecord, EndpointBatchOperation, EndpointBatchResult } from '../types/bulk-operations'
+import { calculateCapacitySnapshot } from './bulk-operations'
+
+export interface EndpointBatchOptions {
+  // Single recompute over the final spec; any errors abort the batch.
+  // Default: capacity check for classes with a fixed leaf count.
+  recompute?: (spec: FabricSpec) => string[]
+}
+
+/**
+ * Applies a batch of endpoint operations with all-or-nothing semantics
+ */
+export function applyEndpointBatch(
+  spec: FabricSpec,
+  operations: EndpointBatchOperation[],
+  options: EndpointBatchOptions = {}
+): EndpointBatchResult {
+  const { recompute = checkFixedClassCapacity } = options
+  const working: FabricSpec = JSON.parse(JSON.stringify(spec)) // deep clone
+  const changes: ChangeRecord[] = []
+  const errors: string[] = []
+
+  operations.forEach((operation, index) => {
+    const opErrors = applyOperation(working, operation, changes, index)
+    errors.push(...opErrors.map(e => `Operation ${index + 1} (${operation.op}): ${e}`))
+  })
+
+  if (errors.length === 0) {
+    errors.push(...recompute(working))
+  }
+
+  const applied = errors.length === 0
+  const result = applied ? working : spec
+  return {
+    applied,
+    spec: result,
+    changes: applied ? changes : [],
+    errors,
+    capacity: calculateCapacitySnapshot(result)
+  }
+}
+
+function applyOperation(
+  spec: FabricSpec,
+  operation: EndpointBatchOperation,
+  changes: ChangeRecord[],
+  index: number
+): string[] {
+  if (!Number.isInteger(operation.count) || operation.count <= 0) {
+    return [`count must be a positive integer, got ${operation.count}`]
+  }
+
+  switch (operation.op) {
+    case 'add': {
+      const leafClass = findClass(spec, operation.classId)
+      if (!leafClass) return [`leaf class ${operation.classId} does not exist`]
+      const before = countOf(leafClass, operation.profileName)
+      const existing = leafClass.endpointProfiles.find(p => p.name === operation.profileName)
+      if (existing) {
+        existing.count = (existing.count || 0) + operation.count
+      } else {
+        leafClass.endpointProfiles.push({
+          portsPerEndpoint: 1,
+          ...operation.profile,
+          name: operation.profileName,
+          count: operation.count
+        })
+      }
+      changes.push(countChange(index, 'add', leafClass.id, operation.profileName, before, before + operation.count))
+      return []
+    }
+
+    case 'remove': {
+      const leafClass = findClass(spec, operation.classId)
+      if (!leafClass) return [`leaf class ${operation.classId} does not exist`]
+      const before = countOf(leafClass, operation.profileName)
+      if (before < operation.count) {
+        return [`class ${leafClass.id} has ${before} ${operation.profileName} endpoints, cannot remove ${operation.count}`]
+      }
+      setCount(leafClass, operation.profileName, before - operation.count)
+      changes.push(countChange(index, 'remove', leafClass.id, operation.profileName, before, before - operation.count))
+      return []
+    }
+
+    case 'reclass': {
+      const source = findClass(spec, operation.fromClassId)
+      const target = findClass(spec, operation.toClassId)
+      const missing = [
+        ...(source ? [] : [`leaf class ${operation.fromClassId} does not exist`]),
+        ...(target ? [] : [`leaf class ${operation.toClassId} does not exist`])
+      ]
+      if (!source || !target) return missing
+      if (source === target) return [`source and target class are both ${source.id}`]
+
+      const sourceBefore = countOf(source, operation.profileName)
+      if (sourceBefore < operation.count) {
+        return [`class ${source.id} has ${sourceBefore} ${operation.profileName} endpoints, cannot move ${operation.count}`]
+      }
+      const template = source.endpointProfiles.find(p => p.name === operation.profileName)!
+      const targetBefore = countOf(target, operation.profileName)
+
+      setCount(source, operation.profileName, sourceBefore - operation.count)
+      const existing = target.endpointProfiles.find(p => p.name === operation.profileName)
+      if (existing) {
+        existing.count = targetBefore + operation.count
+      } else {
+        target.endpointProfiles.push({ ...template, count: operation.count })
+      }
+      changes.push(
+        countChange(index, 'reassign', source.id, operation.profileName, sourceBefore, sourceBefore - operation.count),
+        countChange(index, 'reassign', target.id, operation.profileName, targetBefore, targetBefore + operation.count)
+      )
+      return []
+    }
+
+    default:
+      return [`unknown operation ${(operation as { op: string }).op}`]
+  }
+}
+
+/**
+ * Default recompute: classes with an explicit leaf count cannot grow, so
+ * their endpoints must fit the ports they already have
+ */
+function checkFixedClassCapacity(spec: FabricSpec): string[] {
+  const snapshot = calculateCapacitySnapshot(spec)
+  return (spec.leafClasses || [])
+    .filter(c => c.count !== undefined && snapshot.byClass[c.id].endpointCount > snapshot.byClass[c.id].capacity)
+    .map(c => `Class ${c.id} needs ${snapshot.byClass[c.id].endpointCount} endpoint ports but its ${c.count} ${c.count === 1 ? 'leaf provides' : 'leaves provide'} ${snapshot.byClass[c.id].capacity}`)
+}
+
+function findClass(spec: FabricSpec, classId: string): LeafClass | undefined {
+  return spec.leafClasses?.find(c => c.id === classId)
+}
+
+function countOf(leafClass: LeafClass, profileName: string): number {
+  return leafClass.endpointProfiles.find(p => p.name === profileName)?.count || 0
+}
+
+function setCount(leafClass: LeafClass, profileName: string, count: number) {
+  if (count === 0) {
+    leafClass.endpointProfiles = leafClass.endpointProfiles.filter(p => p.name !== profileName)
+    return
+  }
+  const profile = leafClass.endpointProfiles.find(p => p.name === profileName)
+  if (profile) profile.count = count
+}
+
+function countChange(
+  index: number,
+  type: ChangeRecord['type'],
+  classId: string,
+  profileName: string,
+  before: number,
+  after: number
+): ChangeRecord {
+  return {
+    id: `batch-${index}-${classId}-${profileName}`,
+    type,
+    target: 'profile',
+    path: `leafClasses.${classId}.endpointProfiles.${profileName}.count`,
+    before,
+    after,
+    impact: Math.abs(after - before) >= 100 ? 'high' : Math.abs(after - before) >= 10 ? 'medium' : 'low'
+  }
+}
@@ -66,7 +66,8 @@ export const workspaceMachine = createMachine({
                   name: event.name.trim(),
                   status: 'draft',
                   createdAt: new Date(),
-                  lastModified: new Date()
+                  lastModified: new Date(),
+                  ...(event.policyPack && { policyPack: event.policyPack })
                 }
                 return [...context.fabrics, newFabric]
               },
@@ -166,7 +167,8 @@ export const workspaceMachine = createMachine({
                   name: event.name.trim(),
                   status: 'draft',
                   createdAt: new Date(),
-                  lastModified: new Date()
+                  lastModified: new Date(),
+                  ...(event.policyPack && { policyPack: event.policyPack })
                 }
                 return [...context.fabrics, newFabric]
               },
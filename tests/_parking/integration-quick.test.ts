@@ -92,7 +92,7 @@ describe('Quick Integration Test: Compute → Save → Reload', () => {
     })
     
     expect(saveResult.success).toBe(true)
-    expect(saveResult.filesWritten).toHaveLength(3)
+    expect(saveResult.filesWritten).toHaveLength(4) // 3 YAML files + search index
     console.log('✓ FGD save successful:', saveResult.filesWritten)
 
     // STEP 5: Test FGD load
@@ -133,7 +133,7 @@ describe('CRD Round-trip Validation', () => {
       expect(saveResult.success).toBe(true)
       expect(saveResult.outputFormat).toBe('both')
       expect(saveResult.crdCompliant).toBe(true)
-      expect(saveResult.filesWritten).toHaveLength(7) // 3 legacy + 4 CRD files
+      expect(saveResult.filesWritten).toHaveLength(8) // 3 legacy + 4 CRD files + search index
 
       // Load from legacy format
       const legacyLoadResult = await loadFGD({
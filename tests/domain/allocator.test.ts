@@ -292,6 +292,54 @@ describe('Uplink Allocator', () => {
     });
   });
 
+  describe('Spine Port Reservation', () => {
+    it('should allocate within the unreserved spine ports', () => {
+      // 16 leaves x 4 uplinks over 2 spines = 32 ports per spine, all of DS3000
+      const fullSpec = { ...basicSpec, leavesNeeded: 16 };
+      expect(allocateUplinks(fullSpec, ds2000Profile, ds3000Profile).issues).toEqual([]);
+
+      const result = allocateUplinks({ ...fullSpec, spineReservation: 0.25 }, ds2000Profile, ds3000Profile);
+      expect(result.leafMaps).toEqual([]);
+      expect(result.issues).toEqual([
+        'Spine capacity exceeded: need 32 ports, spine has 24 fabricAssignable after reserving 25% for future pods'
+      ]);
+    });
+
+    it('should leave allocations unchanged when the reservation fits', () => {
+      const result = allocateUplinks({ ...basicSpec, spineReservation: 0.5 }, ds2000Profile, ds3000Profile);
+
+      expect(result.issues).toEqual([]);
+      expect(result.spineUtilization).toEqual([4, 4]);
+    });
+
+    it('should reject reservations outside [0, 1)', () => {
+      const result = allocateUplinks({ ...basicSpec, spineReservation: 1 }, ds2000Profile, ds3000Profile);
+
+      expect(result.issues).toContain('Spine reservation (1) must be at least 0 and below 1');
+    });
+
+    it('should honor the fabric-level reservation in multi-class mode', () => {
+      const fabricSpec: FabricSpec = {
+        name: 'reserved-fabric',
+        spineModelId: 'DS3000',
+        leafModelId: 'DS2000',
+        spineReservation: 0.9,
+        leafClasses: [{
+          id: 'compute',
+          name: 'Compute',
+          role: 'standard',
+          uplinksPerLeaf: 2,
+          endpointProfiles: [{ name: 'Server', portsPerEndpoint: 1, count: 460 }]
+        }]
+      };
+      const profiles = new Map([['DS2000', ds2000Profile]]);
+
+      // 10 leaves x 2 uplinks on one spine; only 4 of 32 ports remain unreserved
+      const result = allocateMultiClassUplinks(fabricSpec, profiles, ds3000Profile);
+      expect(result.overallIssues).toEqual(['Ran out of spine fabric ports for spine 0']);
+    });
+  });
+
   describe('Allocation Validation', () => {
     it('should validate correct allocations', () => {
       const result = allocateUplinks(basicSpec, ds2000Profile, ds3000Profile);
@@ -0,0 +1,108 @@
+/**
+ * Profile Catalog Fallback Chain Tests - HNC v0.6
+ */
+
+import { describe, it, expect } from 'vitest';
+import { mkdtempSync, readFileSync, writeFileSync } from 'fs';
+import { tmpdir } from 'os';
+import { join } from 'path';
+import {
+  API_VERSION, API_VERSION_HEADER, EMBEDDED_PROFILES, describeProvenance, resolveProfileCatalog
+} from '../../src/ingest/catalogChain.js';
+import { loadSwitchProfiles } from '../../src/ingest/profileLoader.js';
+
+const REGISTRY = 'https://profiles.example.com/catalog.json';
+const NOW = () => new Date('2026-03-01T12:00:00.000Z');
+
+const remoteProfile = { ...EMBEDDED_PROFILES[0], meta: { source: 'registry', version: 'v0.5.0' } };
+
+function respond(status: number, body: unknown, headers: Record<string, string> = {}): typeof fetch {
+  return (async () => new Response(JSON.stringify(body), { status, headers })) as typeof fetch;
+}
+
+const offline: typeof fetch = async () => {
+  throw new Error('getaddrinfo ENOTFOUND profiles.example.com');
+};
+
+describe('resolveProfileCatalog', () => {
+  it('uses the registry and refreshes the cache', async () => {
+    const cachePath = join(mkdtempSync(join(tmpdir(), 'hnc-catalog-')), 'cache', 'catalog.json');
+    const result = await resolveProfileCatalog({ registryUrl: REGISTRY, cachePath, fetch: respond(200, [remoteProfile]), now: NOW });
+
+    expect(result.provenance).toEqual({
+      source: 'remote', location: REGISTRY, fetchedAt: '2026-03-01T12:00:00.000Z', degraded: false, attempts: []
+    });
+    expect([...result.profiles.keys()]).toEqual(['celestica-ds2000']);
+    expect(JSON.parse(readFileSync(cachePath, 'utf-8'))).toMatchObject({ url: REGISTRY, profiles: [remoteProfile] });
+  });
+
+  it('falls back to the cache when the registry is down', async () => {
+    const cachePath = join(mkdtempSync(join(tmpdir(), 'hnc-catalog-')), 'catalog.json');
+    await resolveProfileCatalog({ registryUrl: REGISTRY, cachePath, fetch: respond(200, [remoteProfile]), now: NOW });
+
+    const result = await resolveProfileCatalog({ registryUrl: REGISTRY, cachePath, fetch: respond(503, {}) });
+    expect(result.provenance.source).toBe('cache');
+    expect(result.provenance.degraded).toBe(true);
+    expect(result.provenance.attempts).toEqual([{ source: 'remote', location: REGISTRY, error: 'HTTP 503' }]);
+    expect(result.profiles.get('celestica-ds2000')?.meta.source).toBe('registry');
+    expect(describeProvenance(result.provenance)).toEqual([
+      `Profile catalog remote ${REGISTRY}: HTTP 503`,
+      'Profile registry unavailable; using cached catalog from 2026-03-01T12:00:00.000Z'
+    ]);
+  });
+
+  it('falls back to the embedded profiles when the cache is missing or invalid', async () => {
+    const dir = mkdtempSync(join(tmpdir(), 'hnc-catalog-'));
+    const cachePath = join(dir, 'catalog.json');
+    writeFileSync(cachePath, JSON.stringify({ profiles: [{ modelId: 'broken' }] }));
+
+    const result = await resolveProfileCatalog({ registryUrl: REGISTRY, cachePath, fetch: offline });
+    expect(result.provenance.source).toBe('embedded');
+    expect(result.provenance.degraded).toBe(true);
+    expect(result.provenance.attempts.map(a => a.source)).toEqual(['remote', 'cache']);
+    expect(result.provenance.attempts[1].error).toContain('Invalid profile for broken');
+    expect(result.profiles.size).toBe(EMBEDDED_PROFILES.length);
+  });
+
+  it('rejects a registry response that is not a profile list', async () => {
+    const cachePath = join(mkdtempSync(join(tmpdir(), 'hnc-catalog-')), 'catalog.json');
+    const result = await resolveProfileCatalog({ registryUrl: REGISTRY, cachePath, fetch: respond(200, { profiles: [] }) });
+    expect(result.provenance.source).toBe('embedded');
+    expect(result.provenance.attempts[0].error).toBe('catalog must be a non-empty array of profiles');
+  });
+
+  it('sends its API version and refuses an hnc serve registry too old for it', async () => {
+    const cachePath = join(mkdtempSync(join(tmpdir(), 'hnc-catalog-')), 'catalog.json');
+    let sent: string | null = null;
+    const current: typeof fetch = async (_url, init) => {
+      sent = new Headers(init?.headers).get(API_VERSION_HEADER);
+      return new Response(JSON.stringify([remoteProfile]), { status: 200, headers: { [API_VERSION_HEADER]: String(API_VERSION) } });
+    };
+    expect((await resolveProfileCatalog({ registryUrl: REGISTRY, cachePath, fetch: current })).provenance.source).toBe('remote');
+    expect(sent).toBe(String(API_VERSION));
+
+    const old = await resolveProfileCatalog({
+      registryUrl: REGISTRY, cachePath: join(cachePath, 'none'), fetch: respond(200, [remoteProfile], { [API_VERSION_HEADER]: '0' })
+    });
+    expect(old.provenance.source).toBe('embedded');
+    expect(old.provenance.attempts[0].error).toBe('registry speaks API version 0, older than 1, the oldest this HNC reads; upgrade hnc serve');
+  });
+
+  it('reports the deprecation warning of an hnc serve registry', async () => {
+    const cachePath = join(mkdtempSync(join(tmpdir(), 'hnc-catalog-')), 'catalog.json');
+    const warning = '299 - "API version 1 is deprecated; this server speaks 2 to 3, so upgrade the client"';
+    const result = await resolveProfileCatalog({
+      registryUrl: REGISTRY, cachePath, fetch: respond(200, [remoteProfile], { [API_VERSION_HEADER]: '3', Warning: warning })
+    });
+    expect(result.provenance.source).toBe('remote');
+    expect(describeProvenance(result.provenance)).toEqual([`Profile catalog remote ${REGISTRY}: warning: ${warning}`]);
+  });
+
+  it('is not degraded without a registry configured', async () => {
+    const cachePath = join(mkdtempSync(join(tmpdir(), 'hnc-catalog-')), 'catalog.json');
+    const result = await loadSwitchProfiles({ mode: 'registry', cachePath });
+    expect(result.mode).toBe('registry');
+    expect(result.provenance?.source).toBe('embedded');
+    expect(result.provenance?.degraded).toBe(false);
+  });
+});
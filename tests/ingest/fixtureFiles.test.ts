@@ -0,0 +1,70 @@
+/**
+ * Fixture Layout Tests - HNC v0.6
+ * Loads switch profile fixtures dumped in the tree layout as well as flat
+ */
+
+import { describe, it, expect } from 'vitest';
+import { mkdirSync, mkdtempSync, readFileSync, writeFileSync } from 'fs';
+import { tmpdir } from 'os';
+import { dirname, join } from 'path';
+import { fixtureFile, fixtureFiles, readFixtureProfiles } from '../../src/ingest/fixtureFiles.js';
+import { fixtureProfiles } from '../../src/ingest/fixtureLayout.js';
+import { loadSwitchProfiles } from '../../src/ingest/profileLoader.js';
+
+const FIXTURES = 'src/fixtures/switch-profiles';
+
+const fixture = (name: string) => readFileSync(fixtureFile(FIXTURES, name), 'utf-8');
+
+// A fixtures directory holding files, by path in it
+function fixturesDir(files: Record<string, string>): string {
+  const dir = mkdtempSync(join(tmpdir(), 'hnc-fixtures-'));
+  for (const [path, content] of Object.entries(files)) {
+    mkdirSync(dirname(join(dir, path)), { recursive: true });
+    writeFileSync(join(dir, path), content);
+  }
+  return dir;
+}
+
+// A fixtures directory as hnc profiles dump -layout tree writes it
+function treeFixtures(): string {
+  return fixturesDir({
+    'celestica/leaf/ds2000.json': fixture('ds2000'),
+    'celestica/spine/ds3000.json': fixture('ds3000'),
+    'index.json': JSON.stringify({
+      layout: 'tree',
+      profiles: [
+        { modelId: 'celestica-ds2000', path: 'celestica/leaf/ds2000.json', role: 'leaf', vendor: 'celestica' },
+        { modelId: 'celestica-ds3000', path: 'celestica/spine/ds3000.json', role: 'spine', vendor: 'celestica' }
+      ]
+    }),
+    'manifest.json': JSON.stringify({ files: [] })
+  });
+}
+
+describe('fixture layouts', () => {
+  it('lists the profiles of a tree as its index does', () => {
+    const dir = treeFixtures();
+    expect(fixtureFiles(dir)).toEqual(['celestica/leaf/ds2000.json', 'celestica/spine/ds3000.json']);
+    expect(fixtureFile(dir, 'ds3000')).toBe(`${dir}/celestica/spine/ds3000.json`);
+    expect(readFixtureProfiles(dir).map(p => (p as { modelId: string }).modelId)).toEqual(['celestica-ds2000', 'celestica-ds3000']);
+  });
+
+  it('lists the profiles at the top of a flat directory, without its manifest', () => {
+    const dir = fixturesDir({ 'ds3000.json': fixture('ds3000'), 'ds2000.json': fixture('ds2000'), 'manifest.json': '{"files": []}', 'upstream/x.json': '{}' });
+    expect(fixtureFiles(dir)).toEqual(['ds2000.json', 'ds3000.json']);
+    expect(fixtureFile(dir, 'ds2000')).toBe(`${dir}/ds2000.json`);
+    expect(fixtureFile(join(dir, 'none'), 'ds2000')).toBe(`${join(dir, 'none')}/ds2000.json`);
+  });
+
+  it('loads a tree in fixture mode', async () => {
+    const result = await loadSwitchProfiles({ mode: 'fixture', fixturesPath: treeFixtures() });
+    expect(result.errors).toEqual([]);
+    expect([...result.profiles.keys()]).toEqual(['celestica-ds2000', 'celestica-ds3000']);
+  });
+
+  it('refuses an index that lists a missing file or one outside the directory', () => {
+    const index = (path: string) => ({ layout: 'tree', profiles: [{ modelId: 'x', vendor: 'x', role: 'leaf', path }] });
+    expect(() => fixtureProfiles({ 'index.json': index('x/leaf/x.json') })).toThrow('index.json lists x/leaf/x.json, which is missing');
+    expect(() => fixtureProfiles({ 'index.json': index('../x.json'), '../x.json': {} })).toThrow('outside the fixtures directory');
+  });
+});
@@ -366,5 +366,28 @@ describe('ProfileLoader', () => {
 
       expect(result.errors.some(error => error.includes('speedGbps must be a number'))).toBe(true);
     });
+
+    it('should validate breakout options', async () => {
+      const invalidBreakouts = {
+        modelId: "celestica-ds2000",
+        roles: ["leaf"],
+        ports: { endpointAssignable: [], fabricAssignable: [] },
+        profiles: {
+          endpoint: { portProfile: "SFP28-25G", speedGbps: 25 },
+          uplink: { portProfile: "QSFP28-100G", speedGbps: 100, breakouts: [{ mode: "4x25G", lanes: "4" }] }
+        },
+        meta: { source: "test", version: "v1.0.0" }
+      };
+
+      mockReadFile.mockImplementation((path: string) =>
+        path.includes('ds2000.json')
+          ? Promise.resolve(JSON.stringify(invalidBreakouts))
+          : Promise.reject(new Error('File not found'))
+      );
+
+      const result = await loadSwitchProfiles();
+
+      expect(result.errors.some(error => error.includes('profiles.uplink.breakouts[0] needs mode, lanes, speedGbps and portPattern'))).toBe(true);
+    });
   });
 });
\ No newline at end of file
@@ -0,0 +1,101 @@
+import { describe, it, expect } from 'vitest'
+import * as yaml from 'js-yaml'
+import { anonymizeArtifacts, anonymizeWiring } from '../../src/io/anonymize'
+import { serializeWiringDiagramToCRDs } from '../../src/io/crd-yaml'
+import type { WiringDiagram } from '../../src/app.types'
+import type { Wiring } from '../../src/domain/wiring'
+
+const diagram: WiringDiagram = {
+  devices: {
+    spines: [{ id: 'nyc1-sp01', model: 'DS3000', ports: 32, serialNumber: 'FDO2231X0AB' }],
+    leaves: [{ id: 'nyc1-lf01', model: 'DS2000', ports: 48, macAddress: '0C:29:EF:12:34:56' }],
+    servers: [{ id: 'nyc1-db01', type: 'server', connections: 1 }]
+  },
+  connections: [
+    { from: { device: 'nyc1-lf01', port: 'E1/49' }, to: { device: 'nyc1-sp01', port: 'E1/1' }, type: 'uplink' },
+    { from: { device: 'nyc1-db01', port: 'eth0' }, to: { device: 'nyc1-lf01', port: 'E1/1' }, type: 'endpoint' }
+  ],
+  metadata: { generatedAt: new Date(0), fabricName: 'nyc1-prod', totalDevices: 3 }
+}
+
+const runningConfig = `hostname nyc1-lf01
+!
+interface Ethernet1
+   description to nyc1-db01
+   ip address 172.16.10.5/24
+!
+interface Management1
+   ip address 172.16.10.9/24
+   ipv6 address 2620:0:2d0:200::7/64
+!
+ip route 0.0.0.0/0 172.16.20.1
+`
+
+describe('anonymizeArtifacts', () => {
+  const crds = serializeWiringDiagramToCRDs(diagram)
+  const { files, mapping } = anonymizeArtifacts(
+    {
+      'fabric.yaml': crds.fabric,
+      'switches.yaml': crds.switches,
+      'servers.yaml': crds.servers,
+      'connections.yaml': crds.connections,
+      'configs/nyc1-lf01.cfg': runningConfig
+    },
+    { sites: ['nyc1'] }
+  )
+  const all = Object.values(files).join('\n')
+
+  it('leaves no hostname, serial, MAC, address or site in any artifact', () => {
+    for (const secret of ['nyc1', 'FDO2231X0AB', '0C:29:EF:12:34:56', '172.16.', '2620:0:2d0']) {
+      expect(all).not.toContain(secret)
+    }
+  })
+
+  it('uses the same pseudonym for a value in every file', () => {
+    const leaf = mapping['nyc1-lf01']
+    expect(leaf).toBe('leaf-1')
+    expect(mapping['nyc1-sp01']).toBe('spine-1')
+    expect(mapping['nyc1-db01']).toBe('server-1')
+    expect(files['configs/nyc1-lf01.cfg']).toContain(`hostname ${leaf}`)
+    expect(files['configs/nyc1-lf01.cfg']).toContain('description to server-1')
+
+    const switches = yaml.loadAll(files['switches.yaml']).filter((d: any) => d?.kind === 'Switch') as any[]
+    expect(switches.map(s => s.metadata.name).sort()).toEqual(['leaf-1', 'spine-1'])
+    expect(switches.find(s => s.metadata.name === 'spine-1').spec.boot.serial).toBe('SN000001')
+    expect(switches.find(s => s.metadata.name === 'leaf-1').spec.boot.mac).toBe(mapping['0c:29:ef:12:34:56'])
+  })
+
+  it('keeps subnet membership and leaves default routes alone', () => {
+    expect(files['configs/nyc1-lf01.cfg']).toContain('ip address 10.0.0.5/24')
+    expect(files['configs/nyc1-lf01.cfg']).toContain('ip address 10.0.0.9/24')
+    expect(files['configs/nyc1-lf01.cfg']).toContain('ip route 0.0.0.0/0 10.0.1.1')
+    expect(files['configs/nyc1-lf01.cfg']).toContain('ipv6 address 2001:db8::1/64')
+  })
+
+  it('replaces site identifiers embedded in other names', () => {
+    expect(mapping['nyc1']).toBe('site-1')
+    expect(mapping['nyc1-prod']).toBe('fabric-1')
+  })
+})
+
+describe('anonymizeWiring', () => {
+  it('renames devices by role and scrubs asset data', () => {
+    const wiring: Wiring = {
+      devices: {
+        spines: [{ id: 'nyc1-sp01', type: 'spine', modelId: 'DS3000', ports: 32, serialNumber: 'FDO2231X0AB' }],
+        leaves: [{ id: 'nyc1-lf01', type: 'leaf', modelId: 'DS2000', ports: 48, assetTag: 'IT-00417' }],
+        servers: []
+      },
+      connections: [{ id: 'link-nyc1-lf01-nyc1-sp01-1', from: { device: 'nyc1-lf01', port: 'E1/49' }, to: { device: 'nyc1-sp01', port: 'E1/1' }, type: 'uplink' }],
+      metadata: { fabricName: 'nyc1-prod', fabricId: 'nyc1-prod', generatedAt: new Date(0), totalDevices: 2, totalConnections: 1 }
+    }
+
+    const anonymized = anonymizeWiring(wiring)
+
+    expect(anonymized.devices.spines[0]).toMatchObject({ id: 'spine-1', serialNumber: 'SN000001' })
+    expect(anonymized.devices.leaves[0]).toMatchObject({ id: 'leaf-1', assetTag: 'SN000002' })
+    expect(anonymized.connections[0].id).toBe('link-leaf-1-spine-1-1')
+    expect(anonymized.metadata.fabricName).toBe('fabric-1')
+    expect(wiring.devices.spines[0].id).toBe('nyc1-sp01')
+  })
+})
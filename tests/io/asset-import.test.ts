@@ -0,0 +1,93 @@
+import { describe, it, expect } from 'vitest'
+import yaml from 'js-yaml'
+import { parseProcurementCsv, applyAssetRecords } from '../../src/io/asset-import'
+import { buildNetBoxDevices, renderNetBoxCsv } from '../../src/io/netbox-export'
+import { serializeWiringDiagramToCRDs, deserializeCRDsToWiringDiagram, SERIAL_NUMBER_ANNOTATION, ASSET_TAG_ANNOTATION } from '../../src/io/crd-yaml'
+import { buildWiring, wiringToWiringDiagram } from '../../src/domain/wiring'
+import { testProfiles, testSpec, testAllocation } from '../../src/fixtures/testFabric'
+
+const profiles = testProfiles()
+
+const wiring = buildWiring(testSpec({ name: 'Asset Fabric' }), profiles, testAllocation(1))
+
+const csv = [
+  'Hostname,Serial Number,Asset Tag,PO',
+  'spine-1,SN-S1,AT-001,PO-7',
+  'spine-2,SN-S2,,PO-7',
+  '"leaf-1","SN-L1","AT-003, rack 2",PO-8',
+  ''
+].join('\r\n')
+
+describe('parseProcurementCsv', () => {
+  it('maps common header names and quoted cells', () => {
+    const { records, errors } = parseProcurementCsv(csv)
+    expect(errors).toEqual([])
+    expect(records).toEqual([
+      { deviceId: 'spine-1', line: 2, serialNumber: 'SN-S1', assetTag: 'AT-001' },
+      { deviceId: 'spine-2', line: 3, serialNumber: 'SN-S2' },
+      { deviceId: 'leaf-1', line: 4, serialNumber: 'SN-L1', assetTag: 'AT-003, rack 2' }
+    ])
+  })
+
+  it('requires a device column and an asset column', () => {
+    expect(parseProcurementCsv('sku,qty\nX,1\n').errors).toEqual([
+      'Procurement CSV needs a device column (device, hostname, name, deviceid)',
+      'Procurement CSV needs a serial or asset tag column'
+    ])
+  })
+})
+
+describe('applyAssetRecords', () => {
+  it('attaches serials and asset tags to devices', () => {
+    const result = applyAssetRecords(wiring, parseProcurementCsv(csv).records)
+    expect(result.errors).toEqual([])
+    expect(result.applied).toBe(5)
+    expect(result.wiring.devices.spines.map(s => [s.serialNumber, s.assetTag])).toEqual([['SN-S1', 'AT-001'], ['SN-S2', undefined]])
+    expect(result.warnings).toEqual([])
+    expect(wiring.devices.spines[0].serialNumber).toBeUndefined() // input untouched
+  })
+
+  it('applies nothing on unknown devices, reused serials or silent overwrites', () => {
+    const first = applyAssetRecords(wiring, parseProcurementCsv(csv).records).wiring
+    const result = applyAssetRecords(first, [
+      { deviceId: 'leaf-9', line: 2, serialNumber: 'SN-X' },
+      { deviceId: 'spine-2', line: 3, serialNumber: 'SN-S1' },
+      { deviceId: 'leaf-1', line: 4, assetTag: 'AT-999' }
+    ])
+
+    expect(result.wiring).toBe(first)
+    expect(result.errors).toEqual([
+      'Line 2: leaf-9 is not a device in this design',
+      'Line 3: spine-2 already has serialNumber SN-S2, not replacing it with SN-S1',
+      'Line 4: leaf-1 already has assetTag AT-003, rack 2, not replacing it with AT-999'
+    ])
+    expect(applyAssetRecords(first, [{ deviceId: 'spine-2', line: 3, serialNumber: 'SN-S1' }], { overwrite: true }).errors)
+      .toEqual(['Line 3: serial SN-S1 is already assigned to spine-1'])
+  })
+})
+
+describe('asset propagation', () => {
+  const tracked = applyAssetRecords(wiring, parseProcurementCsv(csv).records).wiring
+
+  it('writes switch serials to spec.boot and asset tags to annotations, and reads them back', () => {
+    const crds = serializeWiringDiagramToCRDs(wiringToWiringDiagram(tracked))
+    const spine = (yaml.loadAll(crds.switches) as any[]).find(s => s.metadata.name === 'spine-1')
+
+    expect(spine.spec.boot).toEqual({ serial: 'SN-S1' })
+    expect(spine.metadata.annotations).toEqual({ [SERIAL_NUMBER_ANNOTATION]: 'SN-S1', [ASSET_TAG_ANNOTATION]: 'AT-001' })
+
+    const restored = deserializeCRDsToWiringDiagram(crds)
+    expect(restored.devices.spines[0]).toMatchObject({ serialNumber: 'SN-S1', assetTag: 'AT-001' })
+    expect(restored.devices.spines[0].annotations).toBeUndefined()
+  })
+
+  it('exports serials and asset tags to NetBox device import CSV', () => {
+    const rows = buildNetBoxDevices(tracked, { site: 'dc1', includeServers: false })
+    expect(renderNetBoxCsv(rows).split('\n').slice(0, 4)).toEqual([
+      'name,role,manufacturer,device_type,site,status,serial,asset_tag,tags',
+      'spine-1,spine,Celestica,DS3000,dc1,planned,SN-S1,AT-001,',
+      'spine-2,spine,Celestica,DS3000,dc1,planned,SN-S2,,',
+      'leaf-1,leaf,Celestica,DS2000,dc1,planned,SN-L1,"AT-003, rack 2",'
+    ])
+  })
+})
@@ -0,0 +1,50 @@
+import { describe, it, expect } from 'vitest'
+import { buildCableLabels, renderCableLabelsCsv } from '../../src/io/cable-labels'
+import type { CablingRow } from '../../src/io/cabling-map'
+
+const rows: CablingRow[] = [
+  {
+    cable: 'uplink-1',
+    type: 'uplink',
+    fromDevice: 'leaf-1',
+    fromPort: 'E1/49',
+    toDevice: 'spine-1',
+    toPort: 'E1/1',
+    labels: { team: 'net' }
+  }
+]
+
+describe('buildCableLabels', () => {
+  it('labels both ends with the local side first', () => {
+    const { labels, errors } = buildCableLabels(rows)
+    expect(errors).toEqual([])
+    expect(labels).toEqual([
+      { cable: 'uplink-1', end: 'A', device: 'leaf-1', port: 'E1/49', lines: ['uplink-1', 'leaf-1 E1/49', 'to spine-1 E1/1'] },
+      { cable: 'uplink-1', end: 'B', device: 'spine-1', port: 'E1/1', lines: ['uplink-1', 'spine-1 E1/1', 'to leaf-1 E1/49'] }
+    ])
+  })
+
+  it('fills custom templates, including user labels', () => {
+    const { labels } = buildCableLabels(rows, { template: ['{cable}-{end} [{label.team}] {label.missing}'] })
+    expect(labels.map(l => l.lines)).toEqual([['uplink-1-A [net]'], ['uplink-1-B [net]']])
+  })
+
+  it('rejects unknown placeholders and reports overlong lines', () => {
+    expect(buildCableLabels(rows, { template: ['{rack}'] })).toEqual({ labels: [], errors: ['Unknown label placeholder {rack}'] })
+    expect(buildCableLabels(rows, { maxLineLength: 12 }).errors).toEqual([
+      'uplink-1 end A line 3 is 15 characters, limit is 12',
+      'uplink-1 end B line 3 is 15 characters, limit is 12'
+    ])
+  })
+})
+
+describe('renderCableLabelsCsv', () => {
+  it('writes one column per line with CRLF endings', () => {
+    const { labels } = buildCableLabels(rows, { template: ['{cable}', '{localPort}, {remoteDevice}'] })
+    expect(renderCableLabelsCsv(labels)).toBe(
+      'cable,end,device,port,line1,line2\r\n' +
+      'uplink-1,A,leaf-1,E1/49,uplink-1,"E1/49, spine-1"\r\n' +
+      'uplink-1,B,spine-1,E1/1,uplink-1,"E1/1, leaf-1"\r\n'
+    )
+  })
+})
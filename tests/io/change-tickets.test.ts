@@ -0,0 +1,77 @@
+import { describe, it, expect } from 'vitest'
+import {
+  attachmentRequest,
+  createTicketRequest,
+  parseCreatedTicket,
+  parseTicketConfig,
+  ticketDescription,
+  type ChangeSummary
+} from '../../src/io/change-tickets'
+
+const summary: ChangeSummary = {
+  fabric: 'prod-fabric-01',
+  approvedBy: 'A. Rivera',
+  manifest: {
+    version: 1,
+    fabric: 'prod-fabric-01',
+    generatedAt: '2026-01-05T10:00:00.000Z',
+    algorithm: 'sha256',
+    artifacts: [
+      { path: 'switches.yaml', sha256: 'aaa', bytes: 10 },
+      { path: 'switches.yaml', device: 'leaf-1', sha256: 'bbb', bytes: 5 }
+    ]
+  },
+  validation: { errors: [], warnings: ['Device srv-3 is not placed in any rack'] }
+}
+
+const jira = parseTicketConfig({ system: 'jira', url: 'https://acme.atlassian.net/', project: 'NET', labels: ['hnc'] }).config!
+const servicenow = parseTicketConfig({ system: 'servicenow', url: 'https://acme.service-now.com', assignmentGroup: 'Network Engineering' }).config!
+
+describe('change tickets', () => {
+  it('validates the workspace ticket config', () => {
+    expect(jira.url).toBe('https://acme.atlassian.net')
+    expect(parseTicketConfig({ system: 'jira', url: 'acme' }).errors).toEqual(['url must be an http(s) URL', 'project is required for jira'])
+    expect(parseTicketConfig({ system: 'remedy', url: 'https://x', labels: 'hnc' }).errors).toEqual([
+      'system must be one of jira, servicenow',
+      'labels must be a list of strings'
+    ])
+  })
+
+  it('summarizes approval, validation and artifact checksums', () => {
+    expect(ticketDescription(summary)).toBe([
+      'HNC design prod-fabric-01 was approved for deployment by A. Rivera.',
+      '',
+      'Validation: passed (0 error(s), 1 warning(s))',
+      '  Warning: Device srv-3 is not placed in any rack',
+      '',
+      'Artifacts (sha256, bundle generated 2026-01-05T10:00:00.000Z):',
+      '  aaa  switches.yaml',
+      ''
+    ].join('\n'))
+  })
+
+  it('builds Jira issue and attachment requests', () => {
+    const create = createTicketRequest(jira, summary, 'Basic x')
+    expect(create.url).toBe('https://acme.atlassian.net/rest/api/2/issue')
+    expect(create.body).toMatchObject({
+      fields: { project: { key: 'NET' }, issuetype: { name: 'Change' }, summary: 'Deploy fabric prod-fabric-01 (2026-01-05T10:00:00.000Z)', labels: ['hnc'] }
+    })
+    const ticket = parseCreatedTicket(jira, { id: '10042', key: 'NET-7', self: 'https://acme.atlassian.net/rest/api/2/issue/10042' })
+    expect(ticket).toEqual({ id: '10042', key: 'NET-7', url: 'https://acme.atlassian.net/browse/NET-7' })
+    const attach = attachmentRequest(jira, ticket, 'prod-fabric-01.tar.gz', 'Basic x')
+    expect(attach.url).toBe('https://acme.atlassian.net/rest/api/2/issue/NET-7/attachments')
+    expect(attach.headers['X-Atlassian-Token']).toBe('no-check')
+  })
+
+  it('builds ServiceNow change request and attachment requests', () => {
+    const create = createTicketRequest(servicenow, summary, 'Basic x')
+    expect(create.url).toBe('https://acme.service-now.com/api/now/table/change_request')
+    expect(create.body).toMatchObject({ type: 'normal', assignment_group: 'Network Engineering' })
+    const ticket = parseCreatedTicket(servicenow, { result: { sys_id: 'abc123', number: 'CHG0010001' } })
+    expect(ticket.key).toBe('CHG0010001')
+    expect(attachmentRequest(servicenow, ticket, 'prod-fabric-01.tar.gz', 'Basic x').url).toBe(
+      'https://acme.service-now.com/api/now/attachment/file?table_name=change_request&table_sys_id=abc123&file_name=prod-fabric-01.tar.gz'
+    )
+    expect(() => parseCreatedTicket(servicenow, { error: 'nope' })).toThrow('no sys_id and number')
+  })
+})
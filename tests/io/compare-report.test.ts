@@ -0,0 +1,68 @@
+import { describe, it, expect } from 'vitest'
+import { compareRevisions, renderCompareReportHtml } from '../../src/io/compare-report'
+import type { ReviewComment } from '../../src/domain/review-comments'
+import type { WiringDiagram } from '../../src/app.types'
+
+const revision = (leaves: string[]): WiringDiagram => ({
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+    leaves: leaves.map(id => ({ id, model: 'DS2000', ports: 56 })),
+    servers: [{ id: 'server-1', type: 'server', connections: 1 }]
+  },
+  connections: [
+    ...leaves.map((id, i) => ({ from: { device: id, port: 'eth1/49' }, to: { device: 'spine-1', port: `eth1/${i + 1}` }, type: 'uplink' as const })),
+    { from: { device: 'server-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'eth1/1' }, type: 'endpoint' as const }
+  ],
+  metadata: { generatedAt: new Date(0), fabricName: 'cab', totalDevices: leaves.length + 2 }
+})
+
+describe('compare report', () => {
+  const comparison = compareRevisions(
+    { label: 'r1', diagram: revision(['leaf-1']), addressing: { 'leaf-1 loopback': '10.0.0.1/32', 'vrf-a': '10.1.0.0/24' } },
+    { label: 'r2', diagram: revision(['leaf-1', 'leaf-2']), addressing: { 'leaf-1 loopback': '10.0.0.1/32', 'vrf-a': '10.1.0.0/23' } }
+  )
+
+  it('computes topology, BOM and addressing deltas', () => {
+    expect(comparison.devices).toEqual([{ id: 'leaf-2', role: 'leaf', change: 'added', after: 'DS2000, 56 ports' }])
+    expect(comparison.connections).toEqual({ added: ['leaf-2:eth1/49 -> spine-1:eth1/2 (uplink)'], removed: [] })
+    expect(comparison.bom.costAfter).toBeGreaterThan(comparison.bom.costBefore)
+    expect(comparison.bom.lines.some(l => l.delta === 1)).toBe(true)
+    expect(comparison.addressing).toEqual([{ name: 'vrf-a', change: 'modified', before: '10.1.0.0/24', after: '10.1.0.0/23' }])
+  })
+
+  it('renders a standalone HTML document with escaped content', () => {
+    const html = renderCompareReportHtml(comparison, { title: 'CAB <review>', generatedAt: new Date(0) })
+
+    expect(html).toMatch(/^<!DOCTYPE html>/)
+    expect(html).toContain('<title>CAB &lt;review&gt;</title>')
+    expect(html).toContain('<td>leaf-2</td><td>leaf</td><td><span class="added">added</span></td>')
+    expect(html).toContain('leaf-2:eth1/49 -&gt; spine-1:eth1/2 (uplink)')
+    expect(html).not.toMatch(/<(script|link)\b/)
+    expect(html).not.toContain('Review comments')
+  })
+
+  it('lists open review comments, flagging those on changed objects', () => {
+    const comment = (id: string, target: ReviewComment['target'], resolvedAt?: string): ReviewComment =>
+      ({ id, target, author: 'ana', body: `on ${target.id}`, createdAt: '2026-01-01T00:00:00.000Z', ...(resolvedAt && { resolvedAt }) })
+    const reviewed = compareRevisions(
+      { label: 'r1', diagram: revision(['leaf-1']), addressing: { 'vrf-a': '10.1.0.0/24' } },
+      {
+        label: 'r2',
+        diagram: revision(['leaf-1', 'leaf-2']),
+        addressing: { 'vrf-a': '10.1.0.0/24' },
+        comments: [
+          comment('c1', { kind: 'connection', id: 'leaf-2:eth1/49 -> spine-1:eth1/2' }),
+          comment('c2', { kind: 'device', id: 'leaf-1' }),
+          comment('c3', { kind: 'subnet', id: 'vrf-a' }),
+          comment('c4', { kind: 'device', id: 'leaf-2' }, '2026-01-02T00:00:00.000Z')
+        ]
+      }
+    )
+
+    expect(reviewed.comments?.map(c => [c.comment.id, c.changed])).toEqual([['c1', true], ['c2', false], ['c3', false]])
+    const html = renderCompareReportHtml(reviewed, { generatedAt: new Date(0) })
+    expect(html).toContain('<td>Open comments</td><td>3</td>')
+    expect(html).toContain('<td>connection:leaf-2:eth1/49 -&gt; spine-1:eth1/2</td><td>on leaf-2:eth1/49 -&gt; spine-1:eth1/2</td><td>ana</td><td><span class="modified">changed</span></td>')
+    expect(comparison.comments).toBeUndefined()
+  })
+})
@@ -0,0 +1,104 @@
+import { describe, it, expect, afterEach } from 'vitest'
+import yaml from 'js-yaml'
+import {
+  CONNECTION_KIND_ANNOTATION,
+  connectionContext,
+  connectionKinds,
+  registerConnectionType,
+  unregisterConnectionType,
+  resolveConnectionType,
+  parsePortRef
+} from '../../src/io/connection-types'
+import { convertWiringDiagramToFabricCRDs, deserializeCRDsToWiringDiagram, serializeWiringDiagramToCRDs } from '../../src/io/crd-yaml'
+import type { WiringDiagram } from '../../src/app.types'
+
+const diagram: WiringDiagram = {
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+    leaves: [
+      { id: 'leaf-1', model: 'DS2000', ports: 48 },
+      { id: 'leaf-2', model: 'DS2000', ports: 48 }
+    ],
+    servers: [
+      { id: 'srv-single', type: 'server', connections: 1 },
+      { id: 'srv-bond', type: 'server', connections: 2 },
+      { id: 'srv-multi', type: 'server', connections: 2 }
+    ]
+  },
+  connections: [
+    { from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' },
+    { from: { device: 'srv-single', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' },
+    { from: { device: 'srv-bond', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/2' }, type: 'endpoint' },
+    { from: { device: 'srv-bond', port: 'eth1' }, to: { device: 'leaf-1', port: 'E1/3' }, type: 'endpoint' },
+    { from: { device: 'srv-multi', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/4' }, type: 'endpoint' },
+    { from: { device: 'srv-multi', port: 'eth1' }, to: { device: 'leaf-2', port: 'E1/4' }, type: 'endpoint' },
+    { from: { device: 'leaf-2', port: 'E1/48' }, to: { device: 'border-rtr', port: 'xe-0/0/0' }, type: 'uplink' }
+  ],
+  metadata: { generatedAt: new Date(0), fabricName: 'kinds', totalDevices: 6 }
+}
+
+const kindsOf = (d: WiringDiagram) => {
+  const context = connectionContext(d)
+  return d.connections.map(c => resolveConnectionType(c, context).kind)
+}
+
+describe('connection-type registry', () => {
+  afterEach(() => {
+    unregisterConnectionType('mesh')
+  })
+
+  it('classifies cables from device roles and server homing', () => {
+    expect(kindsOf(diagram)).toEqual(['fabric', 'unbundled', 'bundled', 'bundled', 'eslag', 'eslag', 'external'])
+  })
+
+  it('lets an annotation force a kind', () => {
+    const forced = structuredClone(diagram)
+    forced.connections[4].annotations = { [CONNECTION_KIND_ANNOTATION]: 'mclag' }
+    expect(kindsOf(forced)[4]).toBe('mclag')
+
+    forced.connections[4].annotations = { [CONNECTION_KIND_ANNOTATION]: 'bogus' }
+    expect(() => kindsOf(forced)).toThrow("Unknown connection kind 'bogus'")
+  })
+
+  it('writes each kind under its own spec key with upstream port refs', () => {
+    const crds = convertWiringDiagramToFabricCRDs(diagram, {})
+    expect(crds.connections[0].spec.fabric).toEqual({
+      links: [{ spine: { port: 'spine-1/E1/1' }, leaf: { port: 'leaf-1/E1/49' } }]
+    })
+    expect(crds.connections[1].spec.unbundled).toEqual({
+      link: { server: { port: 'srv-single/eth0' }, switch: { port: 'leaf-1/E1/1' } }
+    })
+    expect(crds.connections[4].spec.eslag).toEqual({
+      links: [{ server: { port: 'srv-multi/eth0' }, switch: { port: 'leaf-1/E1/4' } }]
+    })
+    expect(crds.connections[6].spec.external).toEqual({ link: { switch: { port: 'leaf-2/E1/48' } } })
+    expect(crds.connections.map(c => c.metadata.labels?.['hnc.githedgehog.com/connection-kind'])).toEqual(kindsOf(diagram))
+  })
+
+  it('exports a newly registered kind without touching the exporter', () => {
+    registerConnectionType({
+      kind: 'mesh',
+      matches: (c, context) => context.roleOf(c.from.device) === 'leaf' && context.roleOf(c.to.device) === 'leaf',
+      toSpec: c => ({ links: [{ leaf1: { port: `${c.from.device}/${c.from.port}` }, leaf2: { port: `${c.to.device}/${c.to.port}` } }] }),
+      fromSpec: spec => spec.links.map((l: any) => ({ from: parsePortRef(l.leaf1.port), to: parsePortRef(l.leaf2.port), type: 'uplink' }))
+    })
+    const meshed = structuredClone(diagram)
+    meshed.connections = [{ from: { device: 'leaf-1', port: 'E1/50' }, to: { device: 'leaf-2', port: 'E1/50' }, type: 'uplink' }]
+
+    const [crd] = convertWiringDiagramToFabricCRDs(meshed, {}).connections
+    expect(crd.spec.mesh).toEqual({ links: [{ leaf1: { port: 'leaf-1/E1/50' }, leaf2: { port: 'leaf-2/E1/50' } }] })
+    expect(connectionKinds()[0]).toBe('mesh')
+    expect(unregisterConnectionType('mesh')).toBe(true)
+    expect(connectionKinds()).not.toContain('mesh')
+  })
+
+  it('reads links back from the spec when HNC metadata is absent', () => {
+    const yamls = serializeWiringDiagramToCRDs(diagram, { preserveHNCExtensions: false })
+    const docs = yaml.loadAll(yamls.connections) as any[]
+    docs.forEach(d => { delete d.metadata.labels })
+    const restored = deserializeCRDsToWiringDiagram({ ...yamls, connections: docs.map(d => yaml.dump(d)).join('---\n') })
+
+    expect(restored.connections[0]).toMatchObject({ from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' })
+    expect(restored.connections[3]).toMatchObject({ from: { device: 'srv-bond', port: 'eth1' }, to: { device: 'leaf-1', port: 'E1/3' }, type: 'endpoint' })
+  })
+})
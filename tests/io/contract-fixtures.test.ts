@@ -0,0 +1,17 @@
+import { describe, it, expect } from 'vitest'
+import { readFileSync } from 'fs'
+import { join } from 'path'
+import { buildContractFixtures, CONTRACT_FIXTURES_DIR } from '../../src/io/contract-fixtures'
+
+describe('contract fixtures', () => {
+  const fixtures = buildContractFixtures()
+
+  it.each(Object.keys(fixtures))('%s is up to date (run npm run fixtures:contract)', relative => {
+    expect(readFileSync(join(CONTRACT_FIXTURES_DIR, relative), 'utf8')).toBe(fixtures[relative])
+  })
+
+  it('pins a clean design and a failing one', () => {
+    expect(JSON.parse(fixtures['validation/two-leaf.json']).errors).toEqual([])
+    expect(JSON.parse(fixtures['validation/duplicate-leaf.json']).errors).toEqual(['Duplicate device IDs: leaf-1'])
+  })
+})
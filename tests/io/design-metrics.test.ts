@@ -0,0 +1,53 @@
+import { describe, it, expect } from 'vitest'
+import { designKpis, designKpiSeries, formatPrometheus } from '../../src/io/design-metrics'
+import { buildWiring } from '../../src/domain/wiring'
+import { testProfiles, testSpec, testAllocation } from '../../src/fixtures/testFabric'
+
+const profiles = testProfiles(['E1/1-10'], ['E1/11-12'])
+
+const spec = (endpointCount: number) => testSpec({ name: 'KPI Fabric', endpointCount })
+const allocation = testAllocation(1, ['E1/11', 'E1/12'])
+
+describe('design metrics', () => {
+  it('computes the KPIs of a design', () => {
+    const kpis = designKpis(buildWiring(spec(8), profiles, allocation), { profiles })
+    expect(kpis.leafPortUtilization).toBe(0.8) // 8 of 10 endpoint ports
+    expect(kpis.spinePortUtilization).toBe(0.125) // 1 of 8 fabric ports on each spine
+    expect(kpis.maxOversubscription).toBe(1) // 8 x 25G over 2 x 100G
+    expect(kpis.powerWatts).toBe(2 * 350 + 250)
+    expect(kpis.cost).toBeGreaterThan(0)
+    expect(kpis.validationErrors).toBe(0)
+  })
+
+  it('tracks KPI changes across revisions', () => {
+    const series = designKpiSeries('dc1', [
+      { revision: 'r1', savedAt: '2026-01-01T00:00:00Z', wiring: buildWiring(spec(4), profiles, allocation) },
+      { revision: 'r2', wiring: buildWiring(spec(4), profiles, allocation) },
+      { revision: 'r3', wiring: buildWiring(spec(8), profiles, allocation) }
+    ], { profiles })
+
+    expect(series.points.map(p => p.revision)).toEqual(['r1', 'r2', 'r3'])
+    expect(series.points[0].savedAt).toBe('2026-01-01T00:00:00Z')
+    expect(series.points[1]).not.toHaveProperty('savedAt')
+    expect(series.changes.filter(c => c.revision === 'r2')).toEqual([])
+    expect(series.changes).toContainEqual({ revision: 'r3', metric: 'leafPortUtilization', before: 0.4, after: 0.8 })
+  })
+
+  it('formats Prometheus exposition with escaped labels', () => {
+    const kpis = designKpis(buildWiring(spec(8), profiles, allocation), { profiles })
+    const text = formatPrometheus([
+      { design: 'dc1', revision: 'r3', kpis },
+      { design: 'lab "b"', kpis }
+    ])
+    const lines = text.split('\n')
+    expect(lines.slice(0, 4)).toEqual([
+      '# HELP hnc_design_leaf_port_utilization_ratio Share of leaf endpoint ports in use',
+      '# TYPE hnc_design_leaf_port_utilization_ratio gauge',
+      'hnc_design_leaf_port_utilization_ratio{design="dc1",revision="r3"} 0.8',
+      'hnc_design_leaf_port_utilization_ratio{design="lab \\"b\\""} 0.8'
+    ])
+    expect(lines).toContain('hnc_design_power_watts{design="dc1",revision="r3"} 950')
+    expect(lines).toContain('# TYPE hnc_design_validation_errors gauge')
+    expect(text.endsWith('\n')).toBe(true)
+  })
+})
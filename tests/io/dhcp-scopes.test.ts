@@ -0,0 +1,91 @@
+import { describe, it, expect } from 'vitest'
+import { planDhcpScopes, renderKeaDhcp4 } from '../../src/io/dhcp-scopes'
+import { serializeVPCConfigToCRDs, validateVPCConfiguration, type VPCYAMLConfig } from '../../src/io/vpc-yaml'
+
+const vpc = (subnets: VPCYAMLConfig['spec']['subnets'], staticRoutes?: VPCYAMLConfig['spec']['staticRoutes']): VPCYAMLConfig => ({
+  metadata: { name: 'prod' },
+  spec: { subnets, ...(staticRoutes && { staticRoutes }) }
+})
+
+describe('planDhcpScopes', () => {
+  it('keeps declared ranges and derives missing bounds around the gateway', () => {
+    const plan = planDhcpScopes([vpc({
+      web: { cidr: '10.1.1.0/24', gateway: '10.1.1.1', vlan: 1001, dhcp: { enable: true, start: '10.1.1.10', end: '10.1.1.99' } },
+      app: { cidr: '10.1.2.0/24', dhcp: { enable: true } },
+      db: { cidr: '10.1.3.0/24', gateway: '10.1.3.254', dhcp: { enable: true, start: '10.1.3.100' } },
+      mgmt: { cidr: '10.1.4.0/24' }
+    })])
+
+    expect(plan.errors).toEqual([])
+    expect(plan.scopes.map(s => [s.subnet, s.start, s.end, s.size, s.derived])).toEqual([
+      ['web', '10.1.1.10', '10.1.1.99', 90, false],
+      ['app', '10.1.2.2', '10.1.2.254', 253, true],
+      ['db', '10.1.3.100', '10.1.3.253', 154, true]
+    ])
+    expect(plan.scopes[0].vlan).toBe(1001)
+    expect(plan.scopes[1].gateway).toBe('10.1.2.1')
+  })
+
+  it('rejects ranges outside the subnet or over reserved addresses', () => {
+    const plan = planDhcpScopes([vpc({
+      a: { cidr: '10.2.0.0/24', dhcp: { enable: true, start: '10.2.0.0', end: '10.2.0.50' } },
+      b: { cidr: '10.2.1.0/24', dhcp: { enable: true, start: '10.2.1.10', end: '10.2.2.10' } },
+      c: { cidr: '10.2.2.0/24', gateway: '10.2.2.1', dhcp: { enable: true, start: '10.2.2.1', end: '10.2.2.20' } },
+      d: { cidr: '10.2.3.0/24', dhcp: { enable: true, start: '10.2.3.20', end: '10.2.3.10' } },
+      e: { cidr: '10.2.4.0/31', dhcp: { enable: true } },
+      f: { cidr: '10.2.5.0/24', dhcp: { enable: true, start: '10.2.5.2', end: '10.2.5.255' } },
+      g: { cidr: '10.2.6.0/24', dhcp: { enable: true } }
+    }, [{ destination: '0.0.0.0/0', nextHop: '10.2.6.5' }])])
+
+    expect(plan.scopes).toEqual([])
+    expect(plan.errors).toEqual([
+      'VPC prod, subnet a: DHCP start 10.2.0.0 is the network address',
+      'VPC prod, subnet b: DHCP end 10.2.2.10 is outside 10.2.1.0/24',
+      'VPC prod, subnet c: DHCP range 10.2.2.1-10.2.2.20 includes the gateway 10.2.2.1',
+      'VPC prod, subnet d: DHCP start 10.2.3.20 is after end 10.2.3.10',
+      'VPC prod, subnet e: /31 is too small for DHCP; it needs at least a /30',
+      'VPC prod, subnet f: DHCP end 10.2.5.255 is the broadcast address',
+      'VPC prod, subnet g: DHCP range 10.2.6.2-10.2.6.254 includes the static route next hop 10.2.6.5'
+    ])
+  })
+
+  it('warns about ranges on subnets with DHCP disabled', () => {
+    const plan = planDhcpScopes([vpc({ web: { cidr: '10.1.1.0/24', dhcp: { enable: false, start: '10.1.1.10' } } })])
+    expect(plan.scopes).toEqual([])
+    expect(plan.warnings).toEqual(['VPC prod, subnet web: DHCP range is set but DHCP is disabled; the range is ignored'])
+  })
+})
+
+describe('DHCP scopes in exports', () => {
+  const config = {
+    vpcs: [vpc({ app: { cidr: '10.1.2.0/24', vlan: 1002, dhcp: { enable: true } } })],
+    vpcAttachments: [],
+    vpcPeerings: [],
+    externals: [],
+    externalAttachments: [],
+    externalPeerings: []
+  }
+
+  it('renders Kea subnet4 entries', () => {
+    const kea = JSON.parse(renderKeaDhcp4(planDhcpScopes(config.vpcs).scopes, { interfaces: ['eth1'] }))
+    expect(kea.Dhcp4['interfaces-config']).toEqual({ interfaces: ['eth1'] })
+    expect(kea.Dhcp4.subnet4).toEqual([{
+      id: 1,
+      subnet: '10.1.2.0/24',
+      pools: [{ pool: '10.1.2.2 - 10.1.2.254' }],
+      'option-data': [{ name: 'routers', data: '10.1.2.1' }],
+      'user-context': { vpc: 'prod', subnet: 'app', vlan: 1002 }
+    }])
+  })
+
+  it('writes planned ranges into the VPC object', () => {
+    const yaml = serializeVPCConfigToCRDs(config, { generateK8sMetadata: false }).vpcs
+    expect(yaml).toMatch(/start: "?10\.1\.2\.2"?\n/)
+    expect(yaml).toMatch(/end: "?10\.1\.2\.254"?\n/)
+  })
+
+  it('reports range errors from validateVPCConfiguration', () => {
+    const broken = { ...config, vpcs: [vpc({ app: { cidr: '10.1.2.0/24', dhcp: { enable: true, start: '10.9.9.9' } } })] }
+    expect(validateVPCConfiguration(broken).errors).toContain('VPC prod, subnet app: DHCP start 10.9.9.9 is outside 10.1.2.0/24')
+  })
+})
@@ -0,0 +1,33 @@
+import { describe, it, expect } from 'vitest'
+import { encryptString, decryptString, envKeyProvider, kmsKeyProvider, isEncrypted } from '../../src/io/encryption'
+
+const KEY_B64 = btoa(String.fromCharCode(...new Uint8Array(32).fill(1)))
+
+describe('encryption at rest', () => {
+  it('round-trips through an AES-GCM envelope', async () => {
+    const provider = envKeyProvider({ HNC_ENCRYPTION_KEY: KEY_B64 })!
+    const envelope = await encryptString('switches:\n  - leaf-1\n', provider)
+
+    expect(isEncrypted(envelope)).toBe(true)
+    expect(envelope).not.toContain('leaf-1')
+    expect(await decryptString(envelope, provider)).toBe('switches:\n  - leaf-1\n')
+  })
+
+  it('uses a fresh IV per encryption', async () => {
+    const provider = envKeyProvider({ HNC_ENCRYPTION_KEY: KEY_B64 })!
+    expect(await encryptString('x', provider)).not.toBe(await encryptString('x', provider))
+  })
+
+  it('is disabled when no key is configured', () => {
+    expect(envKeyProvider({})).toBeUndefined()
+  })
+
+  it('rejects wrong keys and wrong key sizes', async () => {
+    const envelope = await encryptString('secret', envKeyProvider({ HNC_ENCRYPTION_KEY: KEY_B64 })!)
+    const other = kmsKeyProvider(async () => new Uint8Array(32).fill(2))
+
+    await expect(decryptString(envelope, other)).rejects.toThrow('Decryption failed: wrong key or corrupted data')
+    await expect(encryptString('x', kmsKeyProvider(async () => new Uint8Array(16))))
+      .rejects.toThrow('KMS data key must be 32 bytes for AES-256-GCM, got 16')
+  })
+})
@@ -0,0 +1,76 @@
+import { describe, it, expect } from 'vitest'
+import { MAX_HOOK_OUTPUT, diffFiles, parseHook, runExportHooks, type ExportHook } from '../../src/io/export-hooks'
+import { buildExportManifest, verifyExportManifest } from '../../src/io/export-manifest'
+
+const bundle = () => ({ 'fabric.yaml': 'kind: Fabric\n', 'switches.yaml': 'kind: Switch\n' })
+
+describe('export hooks', () => {
+  it('parses hook command lines', () => {
+    expect(parseHook('pre', ' ./add-header.sh  --owner netops ')).toEqual({ stage: 'pre', command: './add-header.sh', args: ['--owner', 'netops'] })
+    expect(parseHook('post', 'push-bundle')).toEqual({ stage: 'post', command: 'push-bundle' })
+    expect(() => parseHook('pre', '  ')).toThrow('Empty pre hook')
+  })
+
+  it('runs only the requested stage, in order, and attributes file changes', async () => {
+    const files: Record<string, string> = bundle()
+    const hooks: ExportHook[] = [
+      { stage: 'pre', command: 'header' },
+      { stage: 'post', command: 'push' },
+      { stage: 'pre', command: 'notes' }
+    ]
+    const ran: string[] = []
+    const exec = (hook: ExportHook) => {
+      ran.push(hook.command)
+      if (hook.command === 'header') files['fabric.yaml'] = '# Acme Corp\n' + files['fabric.yaml']
+      if (hook.command === 'notes') files['NOTES.txt'] = 'reviewed\n'
+      return { exitCode: 0, stdout: `${hook.command} ok\n`, stderr: '' }
+    }
+
+    const { records, errors } = await runExportHooks('pre', hooks, exec, () => ({ ...files }))
+    expect(errors).toEqual([])
+    expect(ran).toEqual(['header', 'notes'])
+    expect(records.map(({ durationMs: _d, ...r }) => r)).toEqual([
+      { stage: 'pre', command: 'header', args: [], exitCode: 0, stdout: 'header ok\n', stderr: '', added: [], modified: ['fabric.yaml'], removed: [] },
+      { stage: 'pre', command: 'notes', args: [], exitCode: 0, stdout: 'notes ok\n', stderr: '', added: ['NOTES.txt'], modified: [], removed: [] }
+    ])
+  })
+
+  it('stops at the first failing hook and records it', async () => {
+    const hooks: ExportHook[] = [{ stage: 'post', command: 'push' }, { stage: 'post', command: 'notify' }]
+    const { records, errors } = await runExportHooks('post', hooks, () => ({ exitCode: 3, stdout: '', stderr: 'unauthorized' }), bundle)
+
+    expect(errors).toEqual(['post hook push exited with code 3'])
+    expect(records).toHaveLength(1)
+    expect(records[0]).toMatchObject({ command: 'push', exitCode: 3, stderr: 'unauthorized' })
+  })
+
+  it('records hooks that cannot be started as failures', async () => {
+    const exec = () => { throw new Error('spawn ./missing.sh ENOENT') }
+    const { records, errors } = await runExportHooks('pre', [{ stage: 'pre', command: './missing.sh' }], exec, bundle)
+
+    expect(errors).toEqual(['pre hook ./missing.sh exited with code -1'])
+    expect(records[0]).toMatchObject({ exitCode: -1, stderr: 'spawn ./missing.sh ENOENT' })
+  })
+
+  it('truncates long output', async () => {
+    const exec = () => ({ exitCode: 0, stdout: 'x'.repeat(MAX_HOOK_OUTPUT + 10), stderr: '' })
+    const { records } = await runExportHooks('post', [{ stage: 'post', command: 'chatty' }], exec, bundle)
+
+    expect(records[0].stdout).toHaveLength(MAX_HOOK_OUTPUT)
+    expect(records[0].truncated).toBe(true)
+  })
+
+  it('diffs snapshots', () => {
+    expect(diffFiles({ a: '1', b: '2', c: '3' }, { a: '1', b: '22', d: '4' })).toEqual({ added: ['d'], modified: ['b'], removed: ['c'] })
+  })
+
+  it('records hooks in the manifest without affecting verification', async () => {
+    const files = bundle()
+    const { records } = await runExportHooks('pre', [{ stage: 'pre', command: 'noop' }], () => ({ exitCode: 0, stdout: 'done', stderr: '' }), () => files)
+    const manifest = await buildExportManifest('hooks', files, { hooks: records })
+
+    expect(manifest.hooks).toEqual(records)
+    expect((await verifyExportManifest(manifest, files)).ok).toBe(true)
+    expect(await buildExportManifest('hooks', files)).not.toHaveProperty('hooks')
+  })
+})
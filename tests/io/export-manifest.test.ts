@@ -0,0 +1,69 @@
+import { describe, it, expect } from 'vitest'
+import { MANIFEST_FILE, buildExportManifest, verifyExportManifest } from '../../src/io/export-manifest'
+import { serializeWiringDiagramToCRDs } from '../../src/io/crd-yaml'
+import type { WiringDiagram } from '../../src/app.types'
+
+const diagram: WiringDiagram = {
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+    leaves: [{ id: 'leaf-1', model: 'DS2000', ports: 48 }, { id: 'leaf-2', model: 'DS2000', ports: 48 }],
+    servers: [{ id: 'srv-1', type: 'server', connections: 1 }]
+  },
+  connections: [{ from: { device: 'srv-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' }],
+  metadata: { generatedAt: new Date(0), fabricName: 'manifest', totalDevices: 4 }
+}
+
+const exported = () => {
+  const crds = serializeWiringDiagramToCRDs(diagram)
+  return {
+    'fabric.yaml': crds.fabric,
+    'switches.yaml': crds.switches,
+    'servers.yaml': crds.servers,
+    'connections.yaml': crds.connections,
+    'notes/README.txt': 'exported for review\n'
+  }
+}
+
+describe('export manifest', () => {
+  it('hashes every file and each switch document', async () => {
+    const manifest = await buildExportManifest('manifest', exported(), { generatedAt: new Date(0) })
+    expect(manifest).toMatchObject({ version: 1, fabric: 'manifest', generatedAt: '1970-01-01T00:00:00.000Z', algorithm: 'sha256' })
+    expect(manifest.artifacts.map(a => a.device ? `${a.path}#${a.device}` : a.path)).toEqual([
+      'connections.yaml',
+      'fabric.yaml',
+      'notes/README.txt',
+      'servers.yaml',
+      'switches.yaml',
+      'switches.yaml#spine-1',
+      'switches.yaml#leaf-1',
+      'switches.yaml#leaf-2'
+    ])
+    expect(manifest.artifacts.every(a => /^[0-9a-f]{64}$/.test(a.sha256))).toBe(true)
+    expect(manifest.artifacts.find(a => a.path === 'notes/README.txt')!.bytes).toBe(20)
+  })
+
+  it('passes untouched exports and ignores the manifest file itself', async () => {
+    const files = exported()
+    const manifest = await buildExportManifest('manifest', files)
+    const result = await verifyExportManifest(manifest, { ...files, [MANIFEST_FILE]: JSON.stringify(manifest) })
+    expect(result).toEqual({ ok: true, modified: [], missing: [], unexpected: [] })
+  })
+
+  it('names the switch whose document was edited after export', async () => {
+    const files = exported()
+    const manifest = await buildExportManifest('manifest', files)
+    const tampered = { ...files, 'switches.yaml': files['switches.yaml'].replace('name: leaf-2', 'name: leaf-2\n  annotations:\n    edited: by-hand') }
+
+    expect(tampered['switches.yaml']).not.toBe(files['switches.yaml'])
+    expect(await verifyExportManifest(manifest, tampered)).toMatchObject({ ok: false, modified: ['switches.yaml#leaf-2'] })
+  })
+
+  it('reports modified, missing and unexpected files', async () => {
+    const files = exported()
+    const manifest = await buildExportManifest('manifest', files)
+    const { ['servers.yaml']: _removed, ...rest } = files
+    const result = await verifyExportManifest(manifest, { ...rest, 'fabric.yaml': files['fabric.yaml'] + '# edit\n', 'extra.yaml': 'x: 1\n' })
+
+    expect(result).toEqual({ ok: false, modified: ['fabric.yaml'], missing: ['servers.yaml'], unexpected: ['extra.yaml'] })
+  })
+})
@@ -0,0 +1,61 @@
+import { describe, it, expect } from 'vitest'
+import { readFileSync } from 'fs'
+import { renderExportTemplate, TemplateError } from '../../src/io/export-template'
+
+const design = {
+  metadata: { fabricName: 'dc1' },
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000' }],
+    leaves: [{ id: 'leaf-1', model: 'DS2000' }, { id: 'leaf-2', model: 'DS2000, rev B' }]
+  },
+  tags: ['prod', 'ams']
+}
+
+describe('renderExportTemplate', () => {
+  it('supports fields, root access, range and trim markers', () => {
+    const out = renderExportTemplate('{{ range .devices.leaves -}}\n{{ .id }}@{{ $.metadata.fabricName }}\n{{ end -}}', design)
+    expect(out).toBe('leaf-1@dc1\nleaf-2@dc1\n')
+  })
+
+  it('supports if/else, with and pipeline functions', () => {
+    expect(renderExportTemplate('{{ if .devices.servers }}yes{{ else }}no{{ end }}', design)).toBe('no')
+    expect(renderExportTemplate('{{ with .metadata }}{{ .fabricName | upper }}{{ end }}', design)).toBe('DC1')
+    expect(renderExportTemplate('{{ .tags | join ";" }} {{ .devices.leaves | len }}', design)).toBe('prod;ams 2')
+    expect(renderExportTemplate('{{ .missing | default "n/a" }}', design)).toBe('n/a')
+  })
+
+  it('renders the shipped CMDB example', () => {
+    const source = readFileSync('examples/export-templates/cmdb-devices.csv.tmpl', 'utf8')
+    expect(renderExportTemplate(source, design)).toBe(
+      'hostname,role,model,fabric\nspine-1,spine,DS3000,dc1\nleaf-1,leaf,DS2000,dc1\nleaf-2,leaf,"DS2000, rev B",dc1\n'
+    )
+  })
+
+  it('reports template errors with line numbers', () => {
+    expect(() => renderExportTemplate('ok\n{{ range .x }}', design)).toThrow(TemplateError)
+    expect(() => renderExportTemplate('{{ .x | nope }}', design)).toThrow('line 1: function "nope" not defined')
+  })
+
+  it('splits pipelines only outside quoted strings', () => {
+    expect(renderExportTemplate('{{ .tags | join " | " }}', design)).toBe('prod | ams')
+    expect(renderExportTemplate('{{ "a|b" | upper }}', design)).toBe('A|B')
+  })
+
+  it('ranges over maps in sorted key order, as Go does', () => {
+    expect(renderExportTemplate('{{ range . }}{{ . }},{{ end }}', { b: 2, c: 3, a: 1 })).toBe('1,2,3,')
+  })
+
+  it('rejects Go syntax it does not implement instead of misrendering it', () => {
+    for (const source of [
+      '{{ if .a }}a{{ else if .b }}b{{ end }}',
+      '{{ range $i, $d := .devices.leaves }}{{ end }}',
+      '{{ $x := .tags }}',
+      '{{ define "row" }}{{ end }}',
+      '{{ template "row" . }}',
+      '{{ len .tags }}',
+      '{{ .tags .metadata }}'
+    ]) {
+      expect(() => renderExportTemplate(source, design), source).toThrow(TemplateError)
+    }
+  })
+})
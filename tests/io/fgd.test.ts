@@ -1,7 +1,14 @@
-import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { describe, it, expect, beforeEach, afterEach, vi } from 'vitest'
 import { promises as fs } from 'fs'
+import * as zlib from 'zlib'
 import { join } from 'path'
-import { saveFGD, loadFGD, listFabrics, fabricExists, deleteFabric } from '../../src/io/fgd'
+import {
+  saveFGD, loadFGD, listFabrics, fabricExists, deleteFabric, findDuplicateFabrics,
+  trashFabric, listTrash, restoreFabric, purgeTrash, searchWorkspace, saveExports, loadExports
+} from '../../src/io/fgd'
+import { gitService } from '../../src/features/git.service'
+import { kmsKeyProvider } from '../../src/io/encryption'
+import { pinCatalog } from '../../src/domain/catalog-pin'
 import type { WiringDiagram } from '../../src/app.types'
 
 const TEST_BASE_DIR = './test-fgd'
@@ -77,11 +84,11 @@ describe('FGD File Operations', () => {
       expect(result.success).toBe(true)
       expect(result.fgdId).toMatch(/^fgd-test-fabric-123-\d+$/)
       expect(result.fabricPath).toBe('./' + join(TEST_BASE_DIR, TEST_FABRIC_ID))
-      expect(result.filesWritten).toHaveLength(3)
+      expect(result.filesWritten).toHaveLength(4)
       expect(result.error).toBeUndefined()
 
       // Verify files were actually created
-      const expectedFiles = ['servers.yaml', 'switches.yaml', 'connections.yaml']
+      const expectedFiles = ['servers.yaml', 'switches.yaml', 'connections.yaml', 'search-index.json']
       for (const filename of expectedFiles) {
         const filepath = join(TEST_BASE_DIR, TEST_FABRIC_ID, filename)
         expect(result.filesWritten).toContain('./' + filepath)
@@ -266,6 +273,214 @@ describe('FGD File Operations', () => {
     })
   })
 
+  describe('Encryption at rest', () => {
+    const key = kmsKeyProvider(async () => new Uint8Array(32).fill(7))
+
+    it('should write encrypted files and load them with the key', async () => {
+      const saved = await saveFGD(mockWiringDiagram, {
+        fabricId: TEST_FABRIC_ID,
+        baseDir: TEST_BASE_DIR,
+        encryption: key
+      })
+      expect(saved.encrypted).toBe(true)
+
+      const raw = await fs.readFile(join(TEST_BASE_DIR, TEST_FABRIC_ID, 'servers.yaml'), 'utf8')
+      expect(raw).toMatch(/^hnc-enc:v1:/)
+      expect(raw).not.toContain('server-1')
+
+      const loaded = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, encryption: key })
+      expect(loaded.success).toBe(true)
+      expect(loaded.diagram!.devices.servers).toHaveLength(3)
+    })
+
+    it('should refuse to load encrypted files without a key', async () => {
+      await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, encryption: key })
+
+      const result = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, encryption: false })
+      expect(result.success).toBe(false)
+    })
+
+    it('should not load the plaintext Git copy of an encrypted design', async () => {
+      const stale: WiringDiagram = { ...mockWiringDiagram, devices: { ...mockWiringDiagram.devices, servers: [] } }
+      vi.spyOn(gitService, 'isEnabled').mockReturnValue(true)
+      const readFabric = vi.spyOn(gitService, 'readFabric').mockResolvedValue(stale)
+      try {
+        await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, encryption: key })
+        const loaded = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, encryption: key })
+
+        expect(readFabric).not.toHaveBeenCalled()
+        expect(loaded.diagram!.devices.servers).toHaveLength(3)
+      } finally {
+        vi.restoreAllMocks()
+      }
+    })
+
+    it('should encrypt saved exports and load them with the key', async () => {
+      const files = { 'cmdb.csv': 'name,role\nleaf-1,leaf\n', 'wiring/leaf-1.yaml': 'name: leaf-1\n' }
+      const saved = await saveExports(TEST_FABRIC_ID, files, { baseDir: TEST_BASE_DIR, encryption: key })
+      expect(saved.success).toBe(true)
+      expect(saved.encrypted).toBe(true)
+
+      for (const path of ['cmdb.csv', 'wiring/leaf-1.yaml', 'index.json']) {
+        const raw = await fs.readFile(join(TEST_BASE_DIR, TEST_FABRIC_ID, 'exports', path), 'utf8')
+        expect(raw).toMatch(/^hnc-enc:v1:/)
+        expect(raw).not.toContain('leaf-1')
+      }
+      expect(await loadExports(TEST_FABRIC_ID, { baseDir: TEST_BASE_DIR, encryption: key })).toEqual(files)
+      await expect(loadExports(TEST_FABRIC_ID, { baseDir: TEST_BASE_DIR, encryption: false })).rejects.toThrow('is encrypted')
+      expect((await saveExports(TEST_FABRIC_ID, { '../escape.csv': '' }, { baseDir: TEST_BASE_DIR })).success).toBe(false)
+    })
+  })
+
+  describe('Compressed and chunked designs', () => {
+    const fabricDir = `${TEST_BASE_DIR}/${TEST_FABRIC_ID}`
+    const hasZstd = typeof (zlib as any).zstdCompressSync === 'function'
+
+    it('should write gzip-compressed documents and load them', async () => {
+      const saved = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, compression: 'gzip' })
+      expect(saved.success).toBe(true)
+      expect(saved.compression).toBe('gzip')
+      expect(saved.filesWritten).toContain(`${fabricDir}/servers.yaml.gz`)
+      await expect(fs.access(join(fabricDir, 'servers.yaml'))).rejects.toThrow()
+
+      const raw = zlib.gunzipSync(await fs.readFile(join(fabricDir, 'connections.yaml.gz'))).toString()
+      expect(raw).toContain('fabricName: test-fabric-integration')
+
+      const loaded = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+      expect(loaded.success).toBe(true)
+      expect(loaded.revision).toBe(saved.revision)
+      expect(loaded.diagram!.connections).toEqual(mockWiringDiagram.connections)
+      expect(await fabricExists(TEST_FABRIC_ID, TEST_BASE_DIR)).toBe(true)
+    })
+
+    it('should split documents into chunk directories', async () => {
+      const saved = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, chunkSize: 2, compression: 'gzip' })
+      expect(saved.chunked).toBe(true)
+      expect((await fs.readdir(join(fabricDir, 'servers'))).sort()).toEqual(['part-0001.yaml.gz', 'part-0002.yaml.gz'])
+
+      const loaded = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+      expect(loaded.success).toBe(true)
+      expect(loaded.filesRead).toHaveLength(2 + 2 + 2)
+      expect(loaded.diagram!.devices.servers.map(s => s.id)).toEqual(['server-1', 'server-2', 'server-3'])
+      expect(loaded.diagram!.devices.spines).toHaveLength(2)
+    })
+
+    it('should remove the previous layout when re-saving', async () => {
+      await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, chunkSize: 1 })
+      const first = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+      const saved = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, expectedRevision: first.revision })
+      expect(saved.success).toBe(true)
+      await expect(fs.access(join(fabricDir, 'servers'))).rejects.toThrow()
+
+      const loaded = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+      expect(loaded.filesRead).toEqual(['servers', 'switches', 'connections'].map(name => `${fabricDir}/${name}.yaml`))
+    })
+
+    it('should reject compression with encryption or the CRD format', async () => {
+      const key = kmsKeyProvider(async () => new Uint8Array(32).fill(7))
+      const encrypted = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, compression: 'gzip', encryption: key })
+      expect(encrypted.success).toBe(false)
+      expect(encrypted.error).toContain('cannot be encrypted')
+
+      const crd = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, chunkSize: 10, outputFormat: 'crd' })
+      expect(crd.success).toBe(false)
+      expect(crd.error).toContain('legacy output format')
+    })
+
+    it.runIf(hasZstd)('should write zstd-compressed documents and load them', async () => {
+      await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, compression: 'zstd' })
+      await fs.access(join(fabricDir, 'switches.yaml.zst'))
+      const loaded = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+      expect(loaded.diagram!.devices.leaves).toHaveLength(2)
+    })
+
+    it.skipIf(hasZstd)('should explain that zstd needs a newer Node', async () => {
+      const saved = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, compression: 'zstd' })
+      expect(saved.success).toBe(false)
+      expect(saved.error).toContain('use gzip')
+    })
+  })
+
+  describe('Catalog pinning', () => {
+    it('should store the catalog pin and report drift on load', async () => {
+      const profile = {
+        modelId: 'DS2000',
+        roles: ['leaf'],
+        ports: { endpointAssignable: ['E1/1-48'], fabricAssignable: ['E1/49-56'] },
+        profiles: { endpoint: { portProfile: null, speedGbps: 25 }, uplink: { portProfile: null, speedGbps: 100 } },
+        meta: { source: 'test', version: '1.0' }
+      }
+      const catalogPin = await pinCatalog([profile])
+      const saved = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, catalogPin })
+      expect(saved.filesWritten.some(f => f.endsWith('catalog-pin.json'))).toBe(true)
+
+      const changed = { ...profile, meta: { source: 'test', version: '1.1' } }
+      const loaded = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, catalog: [changed] })
+      expect(loaded.catalogPin?.fingerprint).toBe(catalogPin.fingerprint)
+      expect(loaded.catalogDrift).toEqual({ matches: false, changed: ['DS2000'], added: [], removed: [] })
+    })
+  })
+
+  describe('Target release', () => {
+    it('should refuse to write a design the target release cannot run', async () => {
+      const withSerial: WiringDiagram = {
+        ...mockWiringDiagram,
+        devices: { ...mockWiringDiagram.devices, leaves: [{ id: 'leaf-1', model: 'DS2000', ports: 48, serialNumber: 'CN0001' }] }
+      }
+      const rejected = await saveFGD(withSerial, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, targetRelease: '24.09' })
+      expect(rejected.success).toBe(false)
+      expect(rejected.error).toContain('not compatible with Hedgehog 24.09')
+      expect(await fabricExists(TEST_FABRIC_ID, TEST_BASE_DIR)).toBe(false)
+
+      const saved = await saveFGD(withSerial, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, targetRelease: '25.02' })
+      expect(saved.success).toBe(true)
+      expect(saved.compatibility?.errors).toEqual([])
+    })
+  })
+
+  describe('Duplicate detection', () => {
+    it('should flag copies of an existing fabric and unchanged re-saves', async () => {
+      await saveFGD(mockWiringDiagram, { fabricId: 'fabric-a', baseDir: TEST_BASE_DIR })
+      const reordered: WiringDiagram = {
+        ...mockWiringDiagram,
+        devices: { ...mockWiringDiagram.devices, leaves: [...mockWiringDiagram.devices.leaves].reverse() },
+        metadata: { ...mockWiringDiagram.metadata, fabricName: 'Copy', generatedAt: new Date(0) }
+      }
+
+      const copy = await saveFGD(reordered, { fabricId: 'fabric-b', baseDir: TEST_BASE_DIR, detectDuplicates: true })
+      expect(copy.duplicateOf).toEqual(['fabric-a'])
+      expect(copy.unchanged).toBe(false)
+      expect(copy.filesWritten.some(f => f.endsWith('design.sha256'))).toBe(true)
+
+      const again = await saveFGD(reordered, { fabricId: 'fabric-b', baseDir: TEST_BASE_DIR, detectDuplicates: true })
+      expect(again.unchanged).toBe(true)
+      expect(again.designHash).toBe(copy.designHash)
+
+      await saveFGD({ ...mockWiringDiagram, connections: [] }, { fabricId: 'fabric-c', baseDir: TEST_BASE_DIR })
+      expect(await findDuplicateFabrics(TEST_BASE_DIR)).toEqual([['fabric-a', 'fabric-b']])
+    })
+  })
+
+  describe('Workspace search', () => {
+    it('should find objects across fabrics from the index written on save', async () => {
+      await saveFGD(mockWiringDiagram, { fabricId: 'fabric-a', baseDir: TEST_BASE_DIR })
+      const other: WiringDiagram = {
+        ...mockWiringDiagram,
+        devices: { ...mockWiringDiagram.devices, spines: [{ id: 'spine-9', model: 'DS4000', ports: 32 }] }
+      }
+      await saveFGD(other, { fabricId: 'fabric-b', baseDir: TEST_BASE_DIR })
+
+      const hits = await searchWorkspace({ field: 'model', value: 'DS3000' }, TEST_BASE_DIR)
+      expect(hits.map(h => `${h.fabricId}:${h.name}`)).toEqual(['fabric-a:spine-1', 'fabric-a:spine-2'])
+
+      // A fabric saved before indexing is indexed from its YAML
+      await fs.rm(join(TEST_BASE_DIR, 'fabric-b', 'search-index.json'))
+      expect(await searchWorkspace({ field: 'model', value: 'DS4000' }, TEST_BASE_DIR)).toEqual([
+        { fabricId: 'fabric-b', kind: 'switch', name: 'spine-9', match: 'model=DS4000' }
+      ])
+    })
+  })
+
   describe('Utility Functions', () => {
     it('should list available fabrics', async () => {
       // Create multiple fabrics
@@ -321,6 +536,83 @@ describe('FGD File Operations', () => {
     })
   })
 
+  describe('Concurrent edits', () => {
+    it('should reject a save based on a stale revision with a semantic diff', async () => {
+      const created = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, expectedRevision: null })
+      expect(created.success).toBe(true)
+
+      // Two designers load the same revision
+      const alice = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+      const bob = await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+      expect(alice.revision).toBe(created.revision)
+
+      const aliceEdit: WiringDiagram = {
+        ...mockWiringDiagram,
+        devices: { ...mockWiringDiagram.devices, leaves: [...mockWiringDiagram.devices.leaves, { id: 'leaf-3', model: 'DS2000', ports: 48 }] }
+      }
+      const saved = await saveFGD(aliceEdit, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, expectedRevision: alice.revision })
+      expect(saved.success).toBe(true)
+      expect(saved.revision).not.toBe(alice.revision)
+
+      const rejected = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, expectedRevision: bob.revision })
+      expect(rejected.success).toBe(false)
+      expect(rejected.conflict?.currentRevision).toBe(saved.revision)
+      expect(rejected.conflict?.diff?.devices).toEqual([
+        { id: 'leaf-3', role: 'leaf', change: 'removed', before: 'DS2000, 48 ports' }
+      ])
+      expect((await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })).diagram?.devices.leaves).toHaveLength(3)
+    })
+
+    it('should reject creating a fabric that already exists', async () => {
+      await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+      const result = await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR, expectedRevision: null })
+
+      expect(result.success).toBe(false)
+      expect(result.error).toBe(`Fabric ${TEST_FABRIC_ID} already exists`)
+    })
+  })
+
+  describe('Trash', () => {
+    it('should soft-delete, list and restore a fabric', async () => {
+      await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+
+      const trashed = await trashFabric(TEST_FABRIC_ID, TEST_BASE_DIR, new Date('2024-01-01T00:00:00Z'))
+      expect(trashed?.fabricId).toBe(TEST_FABRIC_ID)
+      expect(trashed?.expiresAt.toISOString()).toBe('2024-01-31T00:00:00.000Z')
+      expect(await listFabrics(TEST_BASE_DIR)).toEqual([])
+      expect((await listTrash(TEST_BASE_DIR)).map(t => t.trashId)).toEqual([trashed!.trashId])
+
+      const restored = await restoreFabric(trashed!.trashId, TEST_BASE_DIR)
+      expect(restored).toEqual({ success: true, fabricId: TEST_FABRIC_ID })
+      expect((await loadFGD({ fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })).success).toBe(true)
+      expect(await listTrash(TEST_BASE_DIR)).toEqual([])
+    })
+
+    it('should not restore over an existing fabric', async () => {
+      await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+      const trashed = await trashFabric(TEST_FABRIC_ID, TEST_BASE_DIR)
+      await saveFGD(mockWiringDiagram, { fabricId: TEST_FABRIC_ID, baseDir: TEST_BASE_DIR })
+
+      const clash = await restoreFabric(trashed!.trashId, TEST_BASE_DIR)
+      expect(clash.success).toBe(false)
+      expect(clash.error).toContain('already exists')
+
+      const renamed = await restoreFabric(trashed!.trashId, TEST_BASE_DIR, 'recovered')
+      expect(renamed).toEqual({ success: true, fabricId: 'recovered' })
+    })
+
+    it('should purge entries past the retention window', async () => {
+      for (const [fabricId, deletedAt] of [['old', '2024-01-01'], ['recent', '2024-02-20']]) {
+        await saveFGD(mockWiringDiagram, { fabricId, baseDir: TEST_BASE_DIR })
+        await trashFabric(fabricId, TEST_BASE_DIR, new Date(deletedAt))
+      }
+
+      const purged = await purgeTrash(TEST_BASE_DIR, 30, new Date('2024-03-01'))
+      expect(purged).toEqual([`old@${new Date('2024-01-01').getTime()}`])
+      expect((await listTrash(TEST_BASE_DIR)).map(t => t.fabricId)).toEqual(['recent'])
+    })
+  })
+
   describe('Golden Path Integration', () => {
     it('should handle complete save-load-modify-save cycle', async () => {
       // 1. Save initial diagram
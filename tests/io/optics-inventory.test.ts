@@ -0,0 +1,73 @@
+import { describe, it, expect } from 'vitest'
+import { parseAgentTransceivers, reconcileOptics, type InstalledOptic } from '../../src/io/optics-inventory'
+import { LINK_LENGTH_ANNOTATION } from '../../src/domain/link-budget'
+import type { WiringDiagram } from '../../src/app.types'
+
+const diagram: WiringDiagram = {
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+    leaves: [{ id: 'leaf-1', model: 'DS2000', ports: 56 }],
+    servers: [{ id: 'srv-1', type: 'compute', connections: 1 }]
+  },
+  connections: [
+    { from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' },
+    { from: { device: 'leaf-1', port: 'E1/50' }, to: { device: 'spine-1', port: 'E1/2' }, type: 'uplink', annotations: { [LINK_LENGTH_ANNOTATION]: '40' } },
+    { from: { device: 'srv-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' }
+  ],
+  metadata: { generatedAt: new Date(0), fabricName: 'optics', totalDevices: 3 }
+}
+
+const sr4 = { description: '100GBASE-SR4', formFactor: 'QSFP28' }
+const dac100 = { description: '100GBASE-CR4', formFactor: 'QSFP28', cableType: 'DAC' }
+
+describe('optics inventory', () => {
+  it('reads installed transceivers from Agent status', () => {
+    const optics = parseAgentTransceivers({
+      items: [{
+        metadata: { name: 'leaf-1' },
+        status: {
+          state: {
+            transceivers: {
+              'E1/1': { description: '25GBASE-CR', formFactor: 'SFP28', vendor: 'Acme', vendorPart: 'DAC-25', serialNumber: 'SN1', temperature: 41.5 },
+              'E1/2': { present: false }
+            }
+          }
+        }
+      }]
+    })
+    expect(optics).toEqual([{
+      device: 'leaf-1', port: 'E1/1', description: '25GBASE-CR', formFactor: 'SFP28', vendor: 'Acme', vendorPart: 'DAC-25', serial: 'SN1', dom: { temperatureC: 41.5 }
+    }])
+  })
+
+  it('reports matching, wrong, missing and unplanned optics per port', () => {
+    const installed: InstalledOptic[] = [
+      { device: 'leaf-1', port: 'E1/49', ...sr4, serial: 'A1' },
+      { device: 'spine-1', port: 'E1/1', ...sr4, serial: 'A2' },
+      // The measured 40 m link is beyond DAC reach
+      { device: 'leaf-1', port: 'E1/50', ...dac100, serial: 'A3' },
+      { device: 'spine-1', port: 'E1/2', description: '100GBASE-LR4', formFactor: 'QSFP28', serial: 'A4' },
+      { device: 'leaf-1', port: 'E1/2', description: '25GBASE-SR', formFactor: 'SFP28', serial: 'A5' }
+    ]
+    const result = reconcileOptics(diagram, installed, { linkBudget: {} })
+    expect(result.ports.map(p => [p.device, p.port, p.status, p.problems])).toEqual([
+      ['leaf-1', 'E1/1', 'missing', ['no transceiver; expected GEN-SFP28-25G-DAC']],
+      ['leaf-1', 'E1/2', 'unexpected', ['transceiver in a port the design does not use']],
+      ['leaf-1', 'E1/49', 'ok', []],
+      ['leaf-1', 'E1/50', 'mismatch', ['medium is dac, expected fiber']],
+      ['spine-1', 'E1/1', 'ok', []],
+      ['spine-1', 'E1/2', 'mismatch', ['reach is LR, expected SR']]
+    ])
+    expect(result.summary).toEqual({ ok: 2, mismatch: 2, missing: 1, unexpected: 1 })
+  })
+
+  it('flags DOM readings outside the limits', () => {
+    const installed: InstalledOptic[] = [
+      { device: 'leaf-1', port: 'E1/49', ...sr4, dom: { rxPowerDbm: -15.2, temperatureC: 72 } }
+    ]
+    const port = reconcileOptics(diagram, installed, { domLimits: { minRxPowerDbm: -12, maxTemperatureC: 70 } }).ports
+      .find(p => p.port === 'E1/49')
+    expect(port?.status).toBe('ok')
+    expect(port?.problems).toEqual(['rx power -15.2 dBm is below -12 dBm', 'temperature 72 °C is above 70 °C'])
+  })
+})
@@ -0,0 +1,50 @@
+import { describe, it, expect } from 'vitest'
+import { buildOverlayHints, serializeOverlayHints } from '../../src/io/overlay-hints'
+import type { Wiring } from '../../src/domain/wiring'
+
+const wiring: Wiring = {
+  devices: {
+    spines: [],
+    leaves: [
+      { id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 48 },
+      { id: 'leaf-2', type: 'leaf', modelId: 'DS2000', ports: 48 }
+    ],
+    servers: [
+      { id: 'esx-1', type: 'server', modelId: 'server', ports: 2 },
+      { id: 'esx-2', type: 'server', modelId: 'server', ports: 2 }
+    ]
+  },
+  connections: [
+    { id: 'c1', from: { device: 'esx-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' },
+    { id: 'c2', from: { device: 'esx-1', port: 'eth1' }, to: { device: 'leaf-2', port: 'E1/1' }, type: 'endpoint' },
+    { id: 'c3', from: { device: 'esx-2', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/2' }, type: 'endpoint' },
+    { id: 'c4', from: { device: 'esx-2', port: 'eth1' }, to: { device: 'leaf-1', port: 'E1/3' }, type: 'endpoint' }
+  ],
+  metadata: { fabricName: 'dc1', fabricId: 'dc1', generatedAt: new Date(0), totalDevices: 4, totalConnections: 4 }
+}
+
+describe('Overlay controller hints', () => {
+  it('derives NSX uplink profiles from host attachments', () => {
+    const hints = buildOverlayHints(wiring, { controller: 'nsx', transportVlan: 300 })
+
+    expect(hints.overlayMtu).toBe(8900)
+    expect(hints.hosts.map(h => [h.host, h.teaming])).toEqual([
+      ['esx-1', 'load-balance-source'],
+      ['esx-2', 'lacp']
+    ])
+    expect(hints.hosts[0].settings).toMatchObject({ teamingPolicy: 'LOADBALANCE_SRCID', transportVlan: 300, mtu: 9000 })
+  })
+
+  it('emits OVN bridge mappings and warns on low MTU', () => {
+    const hints = buildOverlayHints(wiring, { controller: 'ovn', transportVlan: 300, hostMtu: 1500, hosts: ['esx-1'] })
+
+    expect(hints.hosts).toHaveLength(1)
+    expect(hints.hosts[0].settings['ovn-bridge-mappings']).toBe('physnet1:br-ex')
+    expect(serializeOverlayHints(hints)).toContain('controller: ovn')
+  })
+
+  it('enforces the NSX Geneve MTU floor', () => {
+    const hints = buildOverlayHints(wiring, { controller: 'nsx', transportVlan: 300, hostMtu: 1500 })
+    expect(hints.warnings).toContain('Host MTU 1500 is below the 1600 NSX requires for Geneve transport')
+  })
+})
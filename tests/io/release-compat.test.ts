@@ -0,0 +1,69 @@
+import { describe, it, expect } from 'vitest'
+import { checkReleaseCompatibility, designFeatures } from '../../src/io/release-compat'
+import { convertWiringDiagramToFabricCRDs } from '../../src/io/crd-yaml'
+import type { FabricDeploymentCRDs } from '../../src/fabric.types'
+import type { WiringDiagram } from '../../src/app.types'
+
+const diagram: WiringDiagram = {
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+    leaves: [
+      { id: 'leaf-1', model: 'DS2000', ports: 48, serialNumber: 'CN0001' },
+      { id: 'leaf-2', model: 'DS2000', ports: 48 }
+    ],
+    servers: [
+      { id: 'srv-single', type: 'server', connections: 1 },
+      { id: 'srv-multi', type: 'server', connections: 2 }
+    ]
+  },
+  connections: [
+    { from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' },
+    { from: { device: 'srv-single', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' },
+    { from: { device: 'srv-multi', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/2' }, type: 'endpoint' },
+    { from: { device: 'srv-multi', port: 'eth1' }, to: { device: 'leaf-2', port: 'E1/2' }, type: 'endpoint' }
+  ],
+  metadata: { generatedAt: new Date(0), fabricName: 'compat', totalDevices: 5 }
+}
+
+const crds = convertWiringDiagramToFabricCRDs(diagram, {})
+
+describe('release compatibility', () => {
+  it('lists the features an export uses and where', () => {
+    expect(designFeatures(crds)).toEqual([
+      { feature: 'switch.boot', resources: ['leaf-1'] },
+      { feature: 'connection.fabric', resources: ['leaf-1-spine-1-e1-49-e1-1'] },
+      { feature: 'connection.unbundled', resources: ['srv-single-leaf-1-eth0-e1-1'] },
+      { feature: 'connection.eslag', resources: ['srv-multi-leaf-1-eth0-e1-2', 'srv-multi-leaf-2-eth1-e1-2'] }
+    ])
+  })
+
+  it('rejects features newer than the target release', () => {
+    expect(checkReleaseCompatibility(crds, '25.02').errors).toEqual([])
+    expect(checkReleaseCompatibility(crds, '24.09').errors).toEqual([
+      'switch boot identities (serial, MAC) for ZTP need Hedgehog 25.02 or later, but the target is 24.09: leaf-1',
+      'ESLAG server connections need Hedgehog 25.01 or later, but the target is 24.09: srv-multi-leaf-1-eth0-e1-2, srv-multi-leaf-2-eth1-e1-2'
+    ])
+  })
+
+  it('checks VPC objects and warns about features it does not know', () => {
+    const withVpc: FabricDeploymentCRDs = {
+      ...crds,
+      connections: [{ ...crds.connections[0], spec: { ...crds.connections[0].spec, bespoke: {} } as any }],
+      vpcs: [{
+        apiVersion: 'vpc.githedgehog.com/v1beta1',
+        kind: 'VPC',
+        metadata: { name: 'vpc-1' },
+        spec: { subnets: { default: { cidr: '10.0.0.0/24', dhcp: { enable: true, start: '10.0.0.10' } } } }
+      }]
+    }
+    const result = checkReleaseCompatibility(withVpc, '24.09')
+    expect(result.errors).toContain('DHCP ranges on VPC subnets need Hedgehog 25.01 or later, but the target is 24.09: vpc-1')
+    expect(result.warnings).toEqual([
+      'connection.bespoke (leaf-1-spine-1-e1-49-e1-1) is not in the release compatibility matrix; check 24.09 supports it'
+    ])
+  })
+
+  it('rejects unknown releases', () => {
+    expect(checkReleaseCompatibility(crds, '23.01').errors).toEqual(['Unknown Hedgehog release 23.01 (known: 24.09, 25.01, 25.02, 25.03)'])
+  })
+})
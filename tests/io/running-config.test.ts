@@ -0,0 +1,120 @@
+import { describe, it, expect } from 'vitest'
+import { detectConfigVendor, parseRunningConfig, summarizePortUsage } from '../../src/io/running-config'
+
+const eos = [
+  '! Command: show running-config',
+  '! device: leaf-a (DCS-7050SX3-48YC8, EOS-4.28.3M)',
+  '!',
+  'hostname leaf-a',
+  '!',
+  'interface Ethernet1',
+  '   description srv-01 eth0',
+  '   speed forced 25gfull',
+  '   switchport mode trunk',
+  '   channel-group 10 mode active',
+  '!',
+  'interface Ethernet2',
+  '   shutdown',
+  '!',
+  'interface Ethernet49/1',
+  '   description spine-1',
+  '   speed 100g-4',
+  '   no switchport',
+  '   ip address 10.0.0.1/31',
+  '!',
+  'interface Management1',
+  '   ip address 192.168.0.10/24',
+  '!',
+  'interface Port-Channel10',
+  '   switchport mode trunk'
+].join('\n')
+
+const nxos = [
+  '!Command: show running-config',
+  'version 9.3(8) Bios:version 05.44',
+  'hostname leaf-b',
+  'feature lacp',
+  '',
+  'interface Ethernet1/1',
+  '  description srv-02',
+  '  speed 25000',
+  '  switchport mode access',
+  '  mtu 9216',
+  '  no shutdown',
+  '',
+  'interface Ethernet1/2',
+  '  speed auto',
+  '  ip address 10.1.0.1/31',
+  '',
+  'interface Ethernet1/3',
+  '  speed fast'
+].join('\n')
+
+const ios = [
+  'version 15.2',
+  'hostname access-1',
+  'interface GigabitEthernet1/0/1',
+  ' description printer',
+  ' switchport mode access',
+  'interface TenGigabitEthernet1/1/1',
+  ' description uplink',
+  ' speed 1000',
+  ' channel-group 5 mode on',
+  'interface Vlan10',
+  ' ip address 10.2.0.1 255.255.255.0'
+].join('\n')
+
+describe('running-config import', () => {
+  it('detects the vendor from the config syntax', () => {
+    expect(detectConfigVendor(eos)).toBe('arista-eos')
+    expect(detectConfigVendor(nxos)).toBe('cisco-nxos')
+    expect(detectConfigVendor(ios)).toBe('cisco-ios')
+  })
+
+  it('parses Arista EOS interfaces, skipping logical ones', () => {
+    const config = parseRunningConfig(eos)
+    expect(config.hostname).toBe('leaf-a')
+    expect(config.interfaces.map(i => i.name)).toEqual(['Ethernet1', 'Ethernet2', 'Ethernet49/1'])
+    expect(config.interfaces[0]).toEqual({
+      name: 'Ethernet1', description: 'srv-01 eth0', speedGbps: 25, shutdown: false, mode: 'trunk', channelGroup: 10
+    })
+    expect(config.interfaces[1].shutdown).toBe(true)
+    expect(config.interfaces[2]).toMatchObject({ speedGbps: 100, mode: 'routed' })
+    expect(config.warnings).toEqual([])
+  })
+
+  it('reads NX-OS speeds in Mbps and warns on unknown speeds', () => {
+    const config = parseRunningConfig(nxos)
+    expect(config.interfaces[0]).toMatchObject({ speedGbps: 25, mode: 'access', mtu: 9216, shutdown: false })
+    expect(config.interfaces[1].speedGbps).toBeUndefined()
+    expect(config.interfaces[1].mode).toBe('routed')
+    expect(config.warnings).toEqual(["Ethernet1/3: unrecognized speed 'fast'"])
+  })
+
+  it('takes IOS speeds from the interface name unless overridden', () => {
+    const config = parseRunningConfig(ios)
+    expect(config.interfaces.map(i => [i.name, i.speedGbps])).toEqual([
+      ['GigabitEthernet1/0/1', 1],
+      ['TenGigabitEthernet1/1/1', 1]
+    ])
+  })
+
+  it('summarizes port usage for seeding a brownfield design', () => {
+    expect(summarizePortUsage(parseRunningConfig(eos))).toEqual({
+      device: 'leaf-a',
+      totalPorts: 3,
+      usedPorts: 2,
+      shutdownPorts: 1,
+      bySpeedGbps: { 25: 1, 100: 1 },
+      portChannels: [10]
+    })
+    const nx = summarizePortUsage(parseRunningConfig(nxos), 'leaf-b-renamed')
+    expect(nx.device).toBe('leaf-b-renamed')
+    expect(nx.usedPorts).toBe(2)
+    expect(nx.bySpeedGbps).toEqual({ 25: 1, unknown: 1 })
+  })
+
+  it('warns when a config has no physical interfaces', () => {
+    expect(parseRunningConfig('hostname empty\n').warnings).toEqual(['No physical interfaces found'])
+  })
+})
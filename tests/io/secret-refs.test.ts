@@ -0,0 +1,84 @@
+import { describe, it, expect, vi } from 'vitest'
+import {
+  embeddedSecrets,
+  findSecretRefs,
+  kubernetesSecretProvider,
+  parseSecretRef,
+  resolveSecretRefs,
+  vaultSecretProvider
+} from '../../src/io/secret-refs'
+import type { WiringDiagram } from '../../src/app.types'
+
+const exported = `metadata:
+  annotations:
+    hnc.githedgehog.com/bgp-password: secret://vault/kv/fabric/bgp#password
+    hnc.githedgehog.com/snmp-community: secret://k8s/fabric/snmp#community
+    hnc.githedgehog.com/bgp-password-peer: secret://vault/kv/fabric/bgp#password
+`
+
+describe('secret references', () => {
+  it('parses vault and k8s references', () => {
+    expect(parseSecretRef('secret://vault/kv/fabric/bgp#password')).toEqual({
+      ref: 'secret://vault/kv/fabric/bgp#password', backend: 'vault', path: 'kv/fabric/bgp', key: 'password'
+    })
+    expect(parseSecretRef('secret://k8s/fabric/snmp#community')?.path).toBe('fabric/snmp')
+    expect(parseSecretRef('secret://k8s/fabric/snmp/extra#community')).toBeUndefined()
+    expect(parseSecretRef('secret://vault/kv#password')).toBeUndefined()
+    expect(parseSecretRef('hunter2')).toBeUndefined()
+  })
+
+  it('finds each reference once in exported text', () => {
+    expect(findSecretRefs(exported).map(r => r.ref)).toEqual([
+      'secret://vault/kv/fabric/bgp#password',
+      'secret://k8s/fabric/snmp#community'
+    ])
+  })
+
+  it('resolves references through the configured providers', async () => {
+    const fetch = vi.fn(async () => new Response(JSON.stringify({ data: { data: { password: 's3cret' } } })))
+    const vault = vaultSecretProvider({ address: 'https://vault:8200/', token: 't', fetch: fetch as any })
+    const k8s = kubernetesSecretProvider(async (namespace, name) => {
+      expect([namespace, name]).toEqual(['fabric', 'snmp'])
+      return { community: btoa('public-ro') }
+    })
+
+    const result = await resolveSecretRefs(exported, { vault, k8s })
+    expect(result.errors).toEqual([])
+    expect(result.resolved).toHaveLength(2)
+    expect(result.text).toContain('bgp-password: s3cret\n')
+    expect(result.text).toContain('snmp-community: public-ro\n')
+    expect(result.text).not.toContain('secret://')
+    expect(fetch).toHaveBeenCalledTimes(1)
+    expect(fetch).toHaveBeenCalledWith('https://vault:8200/v1/kv/data/fabric/bgp', { headers: { 'X-Vault-Token': 't' } })
+  })
+
+  it('leaves unresolved references in place and reports them', async () => {
+    const fetch = vi.fn(async () => new Response('denied', { status: 403 }))
+    const result = await resolveSecretRefs(exported, { vault: vaultSecretProvider({ address: 'https://vault', token: 't', fetch: fetch as any }) })
+    expect(result.errors).toEqual([
+      'secret://vault/kv/fabric/bgp#password: Vault returned 403 for kv/fabric/bgp',
+      'secret://k8s/fabric/snmp#community: no k8s provider configured'
+    ])
+    expect(result.text).toBe(exported)
+  })
+
+  it('flags secrets embedded as plain values', () => {
+    const diagram: WiringDiagram = {
+      devices: {
+        spines: [],
+        leaves: [{ id: 'leaf-1', model: 'DS2000', ports: 48, annotations: { 'hnc.githedgehog.com/bgp-password': 'hunter2' } }],
+        servers: []
+      },
+      connections: [{
+        from: { device: 'leaf-1', port: 'E1/48' },
+        to: { device: 'border', port: 'xe-0/0/0' },
+        type: 'uplink',
+        annotations: { 'hnc.githedgehog.com/bgp-password': 'secret://vault/kv/fabric/bgp#password' }
+      }],
+      metadata: { generatedAt: new Date(0), fabricName: 'secrets', totalDevices: 1 }
+    }
+    expect(embeddedSecrets(diagram)).toEqual([
+      'leaf-1: annotation hnc.githedgehog.com/bgp-password holds a plain-text secret; use a reference such as secret://vault/kv/fabric#bgp-password'
+    ])
+  })
+})
@@ -0,0 +1,53 @@
+import { describe, it, expect } from 'vitest'
+import { createShareLink, verifyShareLink, grantAllows, envShareSecret } from '../../src/io/share-links'
+
+const SECRET = 'test-share-secret'
+const NOW = new Date('2024-05-01T12:00:00Z')
+
+describe('read-only share links', () => {
+  it('round-trips a signed grant', async () => {
+    const link = await createShareLink('fabric-1', {
+      baseUrl: 'https://hnc.example.com/review',
+      secret: SECRET,
+      expiresInSeconds: 3600,
+      reports: ['design', 'bom'],
+      now: NOW
+    })
+    expect(link.startsWith('https://hnc.example.com/review?share=')).toBe(true)
+
+    const result = await verifyShareLink(link, SECRET, NOW)
+    expect(result).toEqual({
+      valid: true,
+      grant: { fabricId: 'fabric-1', reports: ['design', 'bom'], expiresAt: new Date('2024-05-01T13:00:00Z') }
+    })
+    if (!result.valid) return
+    expect(grantAllows(result.grant, 'fabric-1', 'bom')).toBe(true)
+    expect(grantAllows(result.grant, 'fabric-1', 'cabling')).toBe(false)
+    expect(grantAllows(result.grant, 'fabric-2', 'design')).toBe(false)
+  })
+
+  it('rejects expired links', async () => {
+    const link = await createShareLink('fabric-1', { baseUrl: 'https://x.test/', secret: SECRET, expiresInSeconds: 60, now: NOW })
+
+    expect(await verifyShareLink(link, SECRET, new Date('2024-05-01T12:01:00Z'))).toEqual({
+      valid: false,
+      error: 'Share link expired at 2024-05-01T12:01:00.000Z'
+    })
+  })
+
+  it('rejects tampered payloads and other secrets', async () => {
+    const link = await createShareLink('fabric-1', { baseUrl: 'https://x.test/', secret: SECRET, now: NOW, revision: 'abc' })
+    const token = new URL(link).searchParams.get('share')!
+    const [, signature] = token.split('.')
+    const forged = btoa(JSON.stringify({ f: 'fabric-2', r: ['design'], exp: 9999999999 })).replace(/=+$/, '')
+
+    expect(await verifyShareLink(`${forged}.${signature}`, SECRET, NOW)).toEqual({ valid: false, error: 'Invalid share link signature' })
+    expect(await verifyShareLink(link, 'other-secret', NOW)).toEqual({ valid: false, error: 'Invalid share link signature' })
+    expect(await verifyShareLink('garbage', SECRET, NOW)).toEqual({ valid: false, error: 'Malformed share token' })
+  })
+
+  it('reads the secret from HNC_SHARE_SECRET', () => {
+    expect(envShareSecret({ HNC_SHARE_SECRET: 's' })).toBe('s')
+    expect(envShareSecret({})).toBeUndefined()
+  })
+})
@@ -0,0 +1,89 @@
+import { describe, it, expect } from 'vitest'
+import {
+  comparisonReport,
+  manifestDiffReport,
+  pagerCommand,
+  renderTerminalReport,
+  useColor,
+  validationReport
+} from '../../src/io/terminal-report'
+import type { DesignComparison } from '../../src/io/compare-report'
+
+const tty = (argv: string[] = [], env: Record<string, string> = {}) => ({ argv, env, isTTY: true })
+
+describe('terminal report', () => {
+  it('detects when to color', () => {
+    expect(useColor(tty())).toBe(true)
+    expect(useColor({ ...tty(), isTTY: false })).toBe(false)
+    expect(useColor(tty(['--no-color']))).toBe(false)
+    expect(useColor(tty([], { NO_COLOR: '1' }))).toBe(false)
+    expect(useColor(tty([], { CI: 'true' }))).toBe(false)
+    expect(useColor(tty([], { TERM: 'dumb' }))).toBe(false)
+    expect(useColor(tty([], { CI: 'true', FORCE_COLOR: '1' }))).toBe(true)
+    expect(useColor({ argv: ['--color'], env: { NO_COLOR: '1' }, isTTY: false })).toBe(true)
+  })
+
+  it('pages only when asked, on a terminal, outside CI, for tall output', () => {
+    expect(pagerCommand(tty(['--pager']), 100)).toBe('less -FRX')
+    expect(pagerCommand(tty(['--pager'], { PAGER: 'more' }), 100)).toBe('more')
+    expect(pagerCommand(tty(['--pager']), 5)).toBeUndefined()
+    expect(pagerCommand(tty([]), 100)).toBeUndefined()
+    expect(pagerCommand(tty(['--pager'], { CI: '1' }), 100)).toBeUndefined()
+    expect(pagerCommand({ argv: ['--pager'], env: {}, isTTY: false }, 100)).toBeUndefined()
+  })
+
+  it('renders validation findings by severity, plain or colored', () => {
+    const report = validationReport({ errors: ['Duplicate device IDs: leaf-1'], warnings: ['Spine spine-2 has no uplinks'] })
+    expect(renderTerminalReport(report)).toBe([
+      'Wiring validation',
+      '',
+      '1 error, 1 warning',
+      '  ✖ Duplicate device IDs: leaf-1',
+      '  ⚠ Spine spine-2 has no uplinks',
+      ''
+    ].join('\n'))
+
+    const colored = renderTerminalReport(report, { color: true })
+    expect(colored).toContain('\x1b[31m✖ Duplicate device IDs: leaf-1\x1b[0m')
+    expect(colored).toContain('\x1b[33m⚠ Spine spine-2 has no uplinks\x1b[0m')
+    expect(renderTerminalReport(validationReport({ errors: [], warnings: [] }))).toContain('✔ No problems found')
+  })
+
+  it('aligns comparison tables and marks empty sections', () => {
+    const comparison: DesignComparison = {
+      before: 'rev1',
+      after: 'rev2',
+      devices: [
+        { id: 'leaf-3', role: 'leaf', change: 'added', after: 'DS2000, 56 ports' },
+        { id: 'spine-10', role: 'spine', change: 'removed', before: 'DS3000, 32 ports' }
+      ],
+      connections: { added: ['leaf-3:E1/49 -> spine-1:E1/3 (uplink)'], removed: [] },
+      bom: { lines: [], costBefore: 0, costAfter: 0 },
+      addressing: []
+    }
+    const text = renderTerminalReport(comparisonReport(comparison))
+    expect(text).toContain([
+      'Topology',
+      '  Device    Role   Change   rev1              rev2',
+      '  leaf-3    leaf   added                      DS2000, 56 ports',
+      '  spine-10  spine  removed  DS3000, 32 ports'
+    ].join('\n'))
+    expect(text).toContain('Connections (+1 / -0)\n  ✔ + leaf-3:E1/49 -> spine-1:E1/3 (uplink)')
+    expect(text).toContain('Bill of materials\n  None')
+    expect(text).not.toContain('Review comments')
+
+    const reviewed = renderTerminalReport(comparisonReport({
+      ...comparison,
+      comments: [
+        { comment: { id: 'c1', target: { kind: 'device', id: 'leaf-3' }, author: 'ana', body: 'Why DS2000?', createdAt: '2026-01-01T00:00:00.000Z' }, changed: true },
+        { comment: { id: 'c2', target: { kind: 'subnet', id: 'vrf-a' }, author: 'bo', body: 'Too small', createdAt: '2026-01-01T00:00:00.000Z' }, changed: false }
+      ]
+    }))
+    expect(reviewed).toContain('Review comments\n  ⚠ device:leaf-3: Why DS2000? (ana, changed)\n  ℹ subnet:vrf-a: Too small (bo)')
+  })
+
+  it('lists manifest differences', () => {
+    const text = renderTerminalReport(manifestDiffReport({ ok: false, modified: ['wiring.yaml'], missing: ['bom.csv'], unexpected: [] }, 'out'))
+    expect(text).toContain('2 artifacts differ\n  Status    Path\n  modified  wiring.yaml\n  missing   bom.csv')
+  })
+})
@@ -0,0 +1,51 @@
+import { describe, it, expect } from 'vitest'
+import { buildSearchIndex, parseSearchQuery, searchIndex } from '../../src/io/workspace-search'
+import type { WiringDiagram } from '../../src/app.types'
+
+const diagram: WiringDiagram = {
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000', ports: 32, serialNumber: 'CN3000A1' }],
+    leaves: [{
+      id: 'leaf-1',
+      model: 'DS2000',
+      ports: 48,
+      labels: { team: 'storage' },
+      annotations: { 'hnc.githedgehog.com/mgmt-ip': '10.1.4.17/24' }
+    }],
+    servers: [{ id: 'srv-1', type: 'storage', connections: 1, labels: { subnet: '10.2.0.0/16' } }]
+  },
+  connections: [
+    { from: { device: 'leaf-1', port: 'E1/49' }, to: { device: 'spine-1', port: 'E1/1' }, type: 'uplink' }
+  ],
+  metadata: { generatedAt: new Date(0), fabricName: 'search', totalDevices: 3 }
+}
+
+const index = buildSearchIndex(diagram)
+const find = (query: string) => searchIndex('fab', index, parseSearchQuery(query)).map(h => `${h.name} (${h.match})`)
+
+describe('workspace search', () => {
+  it('indexes switches, servers and connections', () => {
+    expect(index.objects.map(o => `${o.kind}:${o.name}`)).toEqual([
+      'switch:spine-1', 'switch:leaf-1', 'server:srv-1', 'connection:leaf-1/E1/49 <-> spine-1/E1/1'
+    ])
+    expect(index.objects[1].ips).toEqual(['10.1.4.17/24'])
+  })
+
+  it('matches by field', () => {
+    expect(find('model:ds3000')).toEqual(['spine-1 (model=DS3000)'])
+    expect(find('serial:CN3000')).toEqual(['spine-1 (serial=CN3000A1)'])
+    expect(find('label:team=storage')).toEqual(['leaf-1 (team=storage)'])
+    expect(find('port:spine-1/E1/1')).toEqual(['leaf-1/E1/49 <-> spine-1/E1/1 (port=spine-1/E1/1)'])
+  })
+
+  it('matches addresses inside or overlapping a CIDR', () => {
+    expect(find('ip:10.1.4.0/24')).toEqual(['leaf-1 (ip=10.1.4.17/24)'])
+    expect(find('ip:10.2.3.4')).toEqual(['srv-1 (ip=10.2.0.0/16)'])
+    expect(find('ip:10.3.0.0/16')).toEqual([])
+  })
+
+  it('searches names, models and labels for bare terms', () => {
+    expect(find('storage')).toEqual(['leaf-1 (team=storage)'])
+    expect(find('leaf')).toEqual(['leaf-1 (name=leaf-1)', 'leaf-1/E1/49 <-> spine-1/E1/1 (name=leaf-1/E1/49 <-> spine-1/E1/1)'])
+  })
+})
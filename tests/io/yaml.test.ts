@@ -1,5 +1,7 @@
 import { describe, it, expect } from 'vitest'
-import { serializeWiringDiagram, deserializeWiringDiagram, validateYAML } from '../../src/io/yaml'
+import {
+  serializeWiringDiagram, deserializeWiringDiagram, serializeWiringDiagramChunks, deserializeWiringDiagramChunks, validateYAML
+} from '../../src/io/yaml'
 import type { WiringDiagram } from '../../src/app.types'
 
 // Test fixture: minimal wiring diagram
@@ -269,4 +271,30 @@ describe('Roundtrip Testing', () => {
     expect(current.metadata.fabricName).toBe(mockWiringDiagram.metadata.fabricName)
     expect(current.metadata.totalDevices).toBe(mockWiringDiagram.metadata.totalDevices)
   })
-})
\ No newline at end of file
+})
+describe('Chunked documents', () => {
+  it('should split documents into chunks and join them back', () => {
+    const chunks = serializeWiringDiagramChunks(mockWiringDiagram, 1)
+    expect(chunks.servers).toHaveLength(2)
+    expect(chunks.switches).toHaveLength(4)
+    expect(chunks.servers[1]).toContain('chunk: 2')
+    expect(chunks.servers[1]).toContain('chunks: 2')
+
+    const diagram = deserializeWiringDiagramChunks(chunks)
+    expect(diagram).toEqual(deserializeWiringDiagram(serializeWiringDiagram(mockWiringDiagram)))
+  })
+
+  it('should write one chunk for an empty document', () => {
+    const empty = { ...mockWiringDiagram, connections: [] }
+    const chunks = serializeWiringDiagramChunks(empty, 10)
+    expect(chunks.connections).toHaveLength(1)
+    expect(deserializeWiringDiagramChunks(chunks).connections).toEqual([])
+  })
+
+  it('should reject missing or out-of-order chunks', () => {
+    const chunks = serializeWiringDiagramChunks(mockWiringDiagram, 1)
+    expect(() => deserializeWiringDiagramChunks({ ...chunks, switches: chunks.switches.slice(1) }))
+      .toThrow('expected chunk 1 of 3, found chunk 2 of 4')
+    expect(() => serializeWiringDiagramChunks(mockWiringDiagram, 0)).toThrow('positive integer')
+  })
+})
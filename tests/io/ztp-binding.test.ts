@@ -0,0 +1,97 @@
+import { describe, it, expect } from 'vitest'
+import yaml from 'js-yaml'
+import { parseDhcpLog, parseDiscoveryCsv, bindDiscoveredSwitches, renderDhcpdHosts } from '../../src/io/ztp-binding'
+import { serializeWiringDiagramToCRDs, deserializeCRDsToWiringDiagram } from '../../src/io/crd-yaml'
+import { wiringToWiringDiagram, type Wiring } from '../../src/domain/wiring'
+
+const wiring: Wiring = {
+  devices: {
+    spines: [{ id: 'spine-1', type: 'spine', modelId: 'DS3000', ports: 32, serialNumber: 'SN-S1' }],
+    leaves: [
+      { id: 'leaf-1', type: 'leaf', modelId: 'DS2000', ports: 48 },
+      { id: 'leaf-2', type: 'leaf', modelId: 'DS2000', ports: 48 }
+    ],
+    servers: [{ id: 'srv-1', type: 'server', modelId: 'server', ports: 1 }]
+  },
+  connections: [{ id: 'conn-1', from: { device: 'srv-1', port: 'eth0' }, to: { device: 'leaf-1', port: 'E1/1' }, type: 'endpoint' }],
+  metadata: { fabricName: 'ztp', fabricId: 'ztp', generatedAt: new Date(0), totalDevices: 4, totalConnections: 1 }
+}
+
+describe('discovery parsing', () => {
+  it('reads client MACs from dhcpd and dnsmasq logs', () => {
+    const log = [
+      'Jan 10 10:00:01 mgmt dhcpd[812]: DHCPDISCOVER from 0C:29:EF:00:00:01 via eth1',
+      'Jan 10 10:00:01 mgmt dhcpd[812]: DHCPOFFER on 10.0.0.11 to 0c:29:ef:00:00:01 via eth1',
+      'Jan 10 10:00:02 mgmt dnsmasq-dhcp[90]: DHCPREQUEST(eth1) 10.0.0.12 0c-29-ef-00-00-02',
+      'Jan 10 10:00:03 mgmt dhcpd[812]: DHCPREQUEST for 10.0.0.11 from 0c:29:ef:00:00:01 via eth1'
+    ].join('\n')
+
+    expect(parseDhcpLog(log)).toEqual([
+      { mac: '0c:29:ef:00:00:01', source: 'log line 1' },
+      { mac: '0c:29:ef:00:00:02', source: 'log line 3' }
+    ])
+  })
+
+  it('reads MACs, serials and explicit bindings from CSV', () => {
+    const { discovered, errors } = parseDiscoveryCsv('MAC Address,Serial,Hostname\n0C:29:EF:00:00:01,SN-S1,\nnot-a-mac,,\n0c:29:ef:00:00:02,,leaf-2\n')
+    expect(errors).toEqual(["Line 3: 'not-a-mac' is not a MAC address"])
+    expect(discovered).toEqual([
+      { mac: '0c:29:ef:00:00:01', serial: 'SN-S1', source: 'line 2' },
+      { mac: '0c:29:ef:00:00:02', deviceId: 'leaf-2', source: 'line 4' }
+    ])
+  })
+})
+
+describe('bindDiscoveredSwitches', () => {
+  it('binds by serial or explicit device and reports what is left', () => {
+    const result = bindDiscoveredSwitches(wiring, [
+      { mac: '0c:29:ef:00:00:01', serial: 'SN-S1', source: 'line 2' },
+      { mac: '0c:29:ef:00:00:02', deviceId: 'leaf-2', serial: 'SN-L2', source: 'line 3' },
+      { mac: '0c:29:ef:00:00:03', source: 'log line 9' }
+    ])
+
+    expect(result.errors).toEqual([])
+    expect(result.bindings).toEqual([
+      { deviceId: 'spine-1', mac: '0c:29:ef:00:00:01', via: 'serial' },
+      { deviceId: 'leaf-2', mac: '0c:29:ef:00:00:02', via: 'explicit' }
+    ])
+    expect(result.unbound.map(u => u.mac)).toEqual(['0c:29:ef:00:00:03'])
+    expect(result.pending).toEqual(['leaf-1'])
+    expect(result.wiring.devices.leaves[1]).toMatchObject({ macAddress: '0c:29:ef:00:00:02', serialNumber: 'SN-L2' })
+    expect(wiring.devices.leaves[1].macAddress).toBeUndefined() // input untouched
+  })
+
+  it('applies nothing on unknown switches, serial mismatches or double bindings', () => {
+    const result = bindDiscoveredSwitches(wiring, [
+      { mac: '0c:29:ef:00:00:01', deviceId: 'leaf-9', source: 'line 2' },
+      { mac: '0c:29:ef:00:00:02', deviceId: 'spine-1', serial: 'SN-X', source: 'line 3' },
+      { mac: '0c:29:ef:00:00:04', deviceId: 'leaf-1', source: 'line 4' },
+      { mac: '0c:29:ef:00:00:04', deviceId: 'leaf-2', source: 'line 5' }
+    ])
+
+    expect(result.wiring).toBe(wiring)
+    expect(result.bindings).toEqual([])
+    expect(result.errors).toEqual([
+      'line 2: leaf-9 is not a switch in this design',
+      'line 3: spine-1 has serial SN-S1, but the box reports SN-X',
+      'line 5: MAC 0c:29:ef:00:00:04 is already bound to leaf-1'
+    ])
+  })
+})
+
+describe('ZTP exports', () => {
+  const bound = bindDiscoveredSwitches(wiring, [
+    { mac: '0c:29:ef:00:00:01', serial: 'SN-S1', source: 'line 2' }
+  ]).wiring
+
+  it('writes dhcpd host reservations for bound switches', () => {
+    expect(renderDhcpdHosts(bound)).toBe('host spine-1 {\n  hardware ethernet 0c:29:ef:00:00:01;\n  option host-name "spine-1";\n}\n')
+  })
+
+  it('carries the MAC in the Switch spec.boot and reads it back', () => {
+    const crds = serializeWiringDiagramToCRDs(wiringToWiringDiagram(bound))
+    const spine = (yaml.loadAll(crds.switches) as any[]).find(s => s.metadata.name === 'spine-1')
+    expect(spine.spec.boot).toEqual({ serial: 'SN-S1', mac: '0c:29:ef:00:00:01' })
+    expect(deserializeCRDsToWiringDiagram(crds).devices.spines[0].macAddress).toBe('0c:29:ef:00:00:01')
+  })
+})
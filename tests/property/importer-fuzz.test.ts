@@ -0,0 +1,116 @@
+/**
+ * Property tests for the FGD loader and the procurement CSV importer:
+ * malformed input comes back as a structured error naming what is wrong,
+ * never as a throw
+ */
+
+import { describe, it, expect, afterAll } from 'vitest'
+import * as fc from 'fast-check'
+import { promises as fs } from 'fs'
+import { join } from 'path'
+import { saveFGD, loadFGD } from '../../src/io/fgd.js'
+import { parseCsv, parseProcurementCsv, applyAssetRecords } from '../../src/io/asset-import.js'
+import type { Wiring } from '../../src/domain/wiring.js'
+import type { WiringDiagram } from '../../src/app.types.js'
+
+const BASE_DIR = './test-fgd-fuzz'
+
+afterAll(() => fs.rm(BASE_DIR, { recursive: true, force: true }))
+
+// Text that is YAML-ish often enough to get past the parser
+const document = fc.oneof(
+  fc.string(),
+  fc.string({ unit: 'binary' }),
+  fc.json(),
+  fc.constantFrom('servers: 1', 'switches: [null]', 'connections: [{}]', 'metadata: {generatedAt: x}\nservers: []', '- a\n- : b', '&a [*a]')
+)
+
+const diagram: WiringDiagram = {
+  devices: {
+    spines: [{ id: 'spine-1', model: 'DS3000', ports: 32 }],
+    leaves: [{ id: 'leaf-1', model: 'DS2000', ports: 48 }],
+    servers: [{ id: 'server-1', type: 'compute', connections: 1 }]
+  },
+  connections: [{ from: { device: 'leaf-1', port: 'uplink-1' }, to: { device: 'spine-1', port: 'downlink-1' }, type: 'uplink' }],
+  metadata: { generatedAt: new Date('2024-01-01T12:00:00Z'), fabricName: 'fuzz', totalDevices: 3 }
+}
+
+describe('loadFGD on malformed documents', () => {
+  let n = 0
+  const fabric = () => `fuzz-${n++}`
+
+  it('resolves with an error naming why each format failed', async () => {
+    await fc.assert(fc.asyncProperty(document, document, document, async (servers, switches, connections) => {
+      const fabricId = fabric()
+      const dir = join(BASE_DIR, fabricId)
+      await fs.mkdir(dir, { recursive: true })
+      await fs.writeFile(join(dir, 'servers.yaml'), servers)
+      await fs.writeFile(join(dir, 'switches.yaml'), switches)
+      await fs.writeFile(join(dir, 'connections.yaml'), connections)
+
+      const result = await loadFGD({ fabricId, baseDir: BASE_DIR, encryption: false })
+      if (!result.success) {
+        expect(result.error).toMatch(/legacy: [\s\S]+; crd: /)
+      }
+    }), { numRuns: 100 })
+  })
+
+  it('resolves on a saved design with one document cut short', async () => {
+    await fc.assert(fc.asyncProperty(fc.constantFrom('servers', 'switches', 'connections'), fc.integer({ min: 0, max: 400 }), async (name, length) => {
+      const fabricId = fabric()
+      await saveFGD(diagram, { fabricId, baseDir: BASE_DIR, encryption: false })
+      const path = join(BASE_DIR, fabricId, `${name}.yaml`)
+      await fs.writeFile(path, (await fs.readFile(path, 'utf8')).slice(0, length))
+
+      const result = await loadFGD({ fabricId, baseDir: BASE_DIR, encryption: false })
+      expect(result.success || result.error).toBeTruthy()
+    }), { numRuns: 50 })
+  })
+})
+
+describe('procurement CSV import on arbitrary text', () => {
+  const quote = (cell: string) => (/[",\r\n]/.test(cell) ? `"${cell.replace(/"/g, '""')}"` : cell)
+
+  it('parseCsv reads back the rows it was written from', () => {
+    fc.assert(fc.property(fc.array(fc.array(fc.string(), { minLength: 1 }), { minLength: 1 }), rows => {
+      const errors: string[] = []
+      expect(parseCsv(rows.map(row => row.map(quote).join(',') + '\n').join(''), errors)).toEqual(rows)
+      expect(errors).toEqual([])
+    }))
+  })
+
+  it('reports a quoted field that is never closed', () => {
+    fc.assert(fc.property(fc.array(fc.string()), fc.string(), (lines, tail) => {
+      const text = ['device,serial', ...lines.map(quote)].join('\n') + `\nleaf-1,"${tail.replace(/"/g, '""')}`
+      const result = parseProcurementCsv(text)
+      expect(result.records).toEqual([])
+      expect(result.errors).toEqual([expect.stringContaining('quoted field is never closed')])
+    }))
+  })
+
+  it('returns errors instead of throwing, and applies nothing when there are any', () => {
+    const wiring = {
+      devices: {
+        spines: [{ id: 'spine-1', type: 'spine', serialNumber: 'SN1' }],
+        leaves: [{ id: 'leaf-1', type: 'leaf' }],
+        servers: []
+      },
+      connections: []
+    } as unknown as Wiring
+    const cell = fc.oneof(fc.string(), fc.constantFrom('device', 'serial', 'asset tag', 'leaf-1', 'spine-1', 'SN1', '__proto__', 'constructor'))
+    const csv = fc.oneof(fc.string(), fc.array(fc.array(cell), { minLength: 1 }).map(rows => rows.map(row => row.join(',')).join('\n')))
+
+    fc.assert(fc.property(csv, fc.boolean(), (text, overwrite) => {
+      const parsed = parseProcurementCsv(text)
+      for (const record of parsed.records) {
+        expect(record.deviceId).toBe(record.deviceId.trim())
+        expect(record.deviceId).not.toBe('')
+      }
+      const result = applyAssetRecords(wiring, parsed.records, { overwrite })
+      if (result.errors.length > 0) {
+        expect(result.wiring).toBe(wiring)
+        expect(result.applied).toBe(0)
+      }
+    }), { numRuns: 500 })
+  })
+})
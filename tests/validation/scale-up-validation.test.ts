@@ -252,7 +252,7 @@ describe('Scale-Up Validation: 8→32 Servers', () => {
       })
 
       expect(saveResult.success).toBe(true)
-      expect(saveResult.filesWritten).toHaveLength(3)
+      expect(saveResult.filesWritten).toHaveLength(4) // 3 YAML files + search index
       
       // Track file size
       const yamls = emitYaml(baselineWiring)
@@ -337,7 +337,7 @@ describe('Scale-Up Validation: 8→32 Servers', () => {
       })
 
       expect(saveResult.success).toBe(true)
-      expect(saveResult.filesWritten).toHaveLength(3)
+      expect(saveResult.filesWritten).toHaveLength(4) // 3 YAML files + search index
       
       // Track file size
       const yamls = emitYaml(scaledWiring)
@@ -0,0 +1,3 @@
+module github.com/hnc/bench
+
+go 1.21
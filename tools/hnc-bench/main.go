@@ -0,0 +1,228 @@
+// hnc-bench tracks planner performance across hnc versions. It runs a
+// fixed set of scenarios, each a chain of hnc commands over the built-in
+// profiles, several times with -stats-out, and writes the median time and
+// the largest allocation of every step as JSON. Given the results of an
+// earlier build with -baseline, it reports the steps that got slower or
+// allocate more by over -threshold, or that generate a different fabric,
+// and exits 1 when there are any, so CI can hold a release on them:
+//
+//	go build -o /tmp/hnc-old ./cmd/hnc   # in tools/hnc-profile-dump, at the last release
+//	go run . -hnc /tmp/hnc-old -output baseline.json
+//	go run . -hnc /tmp/hnc -baseline baseline.json
+//
+// It reads hnc's stats as JSON rather than importing the hnc packages, so
+// it benchmarks any build, including ones from before a refactor.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// statsVersion is the version of hnc's -stats-out format this reads
+const statsVersion = 1
+
+// scenarios are the fabrics benchmarked, from a single pod to multi-pod,
+// each a chain of commands run in one directory, later ones reading what
+// earlier ones wrote
+var scenarios = []struct {
+	name  string
+	steps [][]string
+}{
+	{"small", [][]string{{"plan", "-endpoints", "96"}, {"cabling"}, {"vpcs", "-vpcs", "10"}}},
+	{"mclag", [][]string{{"plan", "-endpoints", "480", "-redundancy", "mclag"}, {"cabling"}, {"vpcs", "-vpcs", "100"}}},
+	{"multi-pod", [][]string{{"plan", "-endpoints", "4000", "-pods", "4"}, {"cabling"}, {"vpcs", "-vpcs", "500", "-ipv4-pool", "10.0.0.0/8"}}},
+	{"optimize", [][]string{{"plan", "-endpoints", "960", "-optimize", "ports"}}},
+}
+
+// stats is the part of hnc's -stats-out summary compared
+type stats struct {
+	StatsVersion int     `json:"statsVersion"`
+	Version      string  `json:"version"`
+	Switches     int     `json:"switches"`
+	Ports        int     `json:"ports"`
+	Cables       int     `json:"cables"`
+	ElapsedMs    float64 `json:"elapsedMs"`
+	Memory       struct {
+		TotalAllocBytes uint64 `json:"totalAllocBytes"`
+	} `json:"memory"`
+}
+
+// Step is the result of one command of a scenario over every run
+type Step struct {
+	Name            string  `json:"name"` // scenario/command, e.g. small/plan
+	Command         string  `json:"command"`
+	MedianMs        float64 `json:"medianMs"`
+	MaxMs           float64 `json:"maxMs"`
+	TotalAllocBytes uint64  `json:"totalAllocBytes"` // the most of any run
+	Switches        int     `json:"switches"`
+	Ports           int     `json:"ports"`
+	Cables          int     `json:"cables"`
+}
+
+// Results is one benchmark of one hnc build
+type Results struct {
+	HNCVersion string `json:"hncVersion"`
+	Runs       int    `json:"runs"`
+	Steps      []Step `json:"steps"`
+}
+
+func main() {
+	hnc := flag.String("hnc", "hnc", "hnc binary to benchmark")
+	runs := flag.Int("runs", 5, "Times to run each scenario; the median time is kept")
+	output := flag.String("output", "", "Write the results as JSON to this file (default: stdout)")
+	baseline := flag.String("baseline", "", "Results of an earlier build to compare against (default: none)")
+	threshold := flag.Float64("threshold", 0.2, "Fraction a step's median time or allocation may grow by before it is a regression")
+	flag.Parse()
+	if *runs < 1 {
+		fmt.Fprintln(os.Stderr, "Error: -runs must be at least 1")
+		os.Exit(2)
+	}
+
+	res, err := bench(*hnc, *runs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding results: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		os.Stdout.Write(data)
+	} else if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	if *baseline == "" {
+		return
+	}
+
+	var base Results
+	data, err = os.ReadFile(*baseline)
+	if err == nil {
+		err = json.Unmarshal(data, &base)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading baseline %s: %v\n", *baseline, err)
+		os.Exit(1)
+	}
+	regressions := compare(base, res, *threshold)
+	for _, r := range regressions {
+		fmt.Fprintln(os.Stderr, r)
+	}
+	if len(regressions) > 0 {
+		fmt.Fprintf(os.Stderr, "%d regression(s) from %s to %s\n", len(regressions), base.HNCVersion, res.HNCVersion)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "No regressions from %s to %s over %d step(s)\n", base.HNCVersion, res.HNCVersion, len(res.Steps))
+}
+
+// bench runs every scenario runs times, each run in a fresh directory
+func bench(hnc string, runs int) (Results, error) {
+	res := Results{Runs: runs}
+	byName := map[string]int{}
+	times := map[string][]float64{}
+	for run := 0; run < runs; run++ {
+		for _, s := range scenarios {
+			dir, err := os.MkdirTemp("", "hnc-bench-")
+			if err != nil {
+				return res, err
+			}
+			for _, args := range s.steps {
+				name := s.name + "/" + args[0]
+				st, err := runStep(hnc, dir, args)
+				if err != nil {
+					os.RemoveAll(dir)
+					return res, fmt.Errorf("%s: %w", name, err)
+				}
+				res.HNCVersion = st.Version
+				i, ok := byName[name]
+				if !ok {
+					i = len(res.Steps)
+					byName[name] = i
+					res.Steps = append(res.Steps, Step{Name: name, Command: "hnc " + strings.Join(args, " ")})
+				}
+				step := &res.Steps[i]
+				step.Switches, step.Ports, step.Cables = st.Switches, st.Ports, st.Cables
+				step.TotalAllocBytes = max(step.TotalAllocBytes, st.Memory.TotalAllocBytes)
+				step.MaxMs = max(step.MaxMs, st.ElapsedMs)
+				times[name] = append(times[name], st.ElapsedMs)
+			}
+			os.RemoveAll(dir)
+		}
+	}
+	for i := range res.Steps {
+		res.Steps[i].MedianMs = median(times[res.Steps[i].Name])
+	}
+	return res, nil
+}
+
+// runStep runs one hnc command in dir and reads its stats
+func runStep(hnc, dir string, args []string) (stats, error) {
+	var st stats
+	statsFile := filepath.Join(dir, "stats.json")
+	cmd := exec.Command(hnc, append(append([]string{}, args...), "-q", "-stats-out", statsFile)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return st, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	data, err := os.ReadFile(statsFile)
+	if err != nil {
+		return st, fmt.Errorf("no stats; is %s older than -stats-out? %w", hnc, err)
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, err
+	}
+	if st.StatsVersion != statsVersion {
+		return st, fmt.Errorf("stats format %d, want %d", st.StatsVersion, statsVersion)
+	}
+	return st, os.Remove(statsFile)
+}
+
+func median(xs []float64) float64 {
+	sorted := append([]float64{}, xs...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// compare lists the steps of res that regressed from base: slower or
+// allocating more by over threshold, or generating a fabric of another
+// size. Steps only one side has are skipped, so scenarios can be added.
+func compare(base, res Results, threshold float64) []string {
+	old := map[string]Step{}
+	for _, s := range base.Steps {
+		old[s.Name] = s
+	}
+	var out []string
+	for _, s := range res.Steps {
+		b, ok := old[s.Name]
+		if !ok {
+			continue
+		}
+		if b.MedianMs > 0 && s.MedianMs > b.MedianMs*(1+threshold) {
+			out = append(out, fmt.Sprintf("%s: median %.2fms, was %.2fms (+%.0f%%)", s.Name, s.MedianMs, b.MedianMs, 100*(s.MedianMs/b.MedianMs-1)))
+		}
+		if b.TotalAllocBytes > 0 && float64(s.TotalAllocBytes) > float64(b.TotalAllocBytes)*(1+threshold) {
+			out = append(out, fmt.Sprintf("%s: allocates %d bytes, was %d (+%.0f%%)", s.Name, s.TotalAllocBytes, b.TotalAllocBytes,
+				100*(float64(s.TotalAllocBytes)/float64(b.TotalAllocBytes)-1)))
+		}
+		if s.Switches != b.Switches || s.Ports != b.Ports || s.Cables != b.Cables {
+			out = append(out, fmt.Sprintf("%s: generates %d switches, %d ports and %d cables, was %d, %d and %d",
+				s.Name, s.Switches, s.Ports, s.Cables, b.Switches, b.Ports, b.Cables))
+		}
+	}
+	return out
+}
@@ -0,0 +1,12 @@
+// hnc-bom is hnc bom, kept for existing scripts
+package main
+
+import (
+	"os"
+
+	"github.com/hnc/profile-dump/pkg/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(cli.Std("hnc-bom"), cli.Command{Run: cli.BOM, Mutates: true}, os.Args[1:]))
+}
@@ -0,0 +1,12 @@
+// hnc-cabling is hnc cabling, kept for existing scripts
+package main
+
+import (
+	"os"
+
+	"github.com/hnc/profile-dump/pkg/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(cli.Std("hnc-cabling"), cli.Command{Run: cli.Cabling, Mutates: true}, os.Args[1:]))
+}
@@ -0,0 +1,12 @@
+// hnc-fabric-plan is hnc plan, kept for existing scripts
+package main
+
+import (
+	"os"
+
+	"github.com/hnc/profile-dump/pkg/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(cli.Std("hnc-fabric-plan"), cli.Command{Run: cli.Plan, Mutates: true}, os.Args[1:]))
+}
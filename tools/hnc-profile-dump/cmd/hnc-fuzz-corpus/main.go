@@ -0,0 +1,163 @@
+// hnc-fuzz-corpus seeds the Go fuzz targets from the repository's golden
+// files: contract and fixture switch profiles, the contract wiring design
+// and the documents of every FGD under fgd/. Seeds are written in the
+// "go test fuzz v1" format to each target's testdata/fuzz directory, so
+// plain `go test` replays them and `go test -fuzz` starts from them.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/logging"
+)
+
+// maxDocBytes skips the large scale-test documents, which slow fuzzing
+// without covering anything the smaller ones do not
+const maxDocBytes = 2048
+
+// seed is one corpus entry: the target it feeds and its encoded arguments
+type seed struct {
+	dir    string // package directory, relative to the module
+	target string
+	values []string // Go literals, e.g. []byte("...") or bool(false)
+}
+
+func main() {
+	var repoRoot, moduleDir string
+	flag.StringVar(&repoRoot, "root", "../..", "Repository root holding contracts/, src/fixtures/ and fgd/")
+	flag.StringVar(&moduleDir, "module", ".", "Go module directory to write testdata/fuzz into")
+	var logOpts logging.Options
+	logging.Flags(flag.CommandLine, &logOpts)
+	flag.Parse()
+	log := logging.New(os.Stderr, logOpts)
+
+	seeds, err := collect(repoRoot)
+	if err != nil {
+		log.Error(fmt.Sprintf("Error reading golden files: %v", err))
+		os.Exit(1)
+	}
+	counts := map[string]int{}
+	for _, s := range seeds {
+		path, err := write(moduleDir, s)
+		if err != nil {
+			log.Error(fmt.Sprintf("Error writing seed: %v", err))
+			os.Exit(1)
+		}
+		counts[filepath.Dir(path)]++
+	}
+
+	dirs := make([]string, 0, len(counts))
+	for dir := range counts {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		log.Info(fmt.Sprintf("Seeded %d inputs: %s", counts[dir], dir), "dir", dir, "seeds", counts[dir])
+	}
+}
+
+func collect(root string) ([]seed, error) {
+	var seeds []seed
+
+	profileFiles, err := globAll(root, "contracts/fixtures/profiles/*.json", "src/fixtures/switch-profiles/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range profileFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, seed{"pkg/profiles", "FuzzDecode", []string{bytesLit(data), "bool(false)"}})
+
+		var p struct {
+			Ports struct {
+				EndpointAssignable []string `json:"endpointAssignable"`
+				FabricAssignable   []string `json:"fabricAssignable"`
+			} `json:"ports"`
+		}
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse profile %s: %w", file, err)
+		}
+		ranges := append(append([]string{}, p.Ports.EndpointAssignable...), p.Ports.FabricAssignable...)
+		seeds = append(seeds, seed{"pkg/ports", "FuzzExpand", []string{stringLit(strings.Join(ranges, "\n"))}})
+	}
+
+	wiringFiles, err := globAll(root, "contracts/fixtures/designs/*.wiring.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range wiringFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, seed{"pkg/cli", "FuzzRenderWiring", []string{bytesLit(data)}})
+	}
+
+	// One seed per YAML document: the profile parser reads single documents
+	fgdFiles, err := globAll(root, "fgd/*/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range fgdFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range strings.Split(string(data), "\n---") {
+			if strings.TrimSpace(doc) != "" && len(doc) <= maxDocBytes {
+				seeds = append(seeds, seed{"pkg/profiles", "FuzzParseYAML", []string{stringLit(doc)}})
+			}
+		}
+	}
+	return seeds, nil
+}
+
+func globAll(root string, patterns ...string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no golden files match %s under %s", strings.Join(patterns, ", "), root)
+	}
+	return files, nil
+}
+
+// write stores a seed under a name derived from its content, as go test
+// does, so regenerating is idempotent
+func write(moduleDir string, s seed) (string, error) {
+	content := "go test fuzz v1\n" + strings.Join(s.values, "\n") + "\n"
+	sum := sha256.Sum256([]byte(content))
+	dir := filepath.Join(moduleDir, s.dir, "testdata", "fuzz", s.target)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+	path := filepath.Join(dir, "golden-"+hex.EncodeToString(sum[:8]))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func bytesLit(b []byte) string {
+	return "[]byte(" + strconv.Quote(string(b)) + ")"
+}
+
+func stringLit(s string) string {
+	return "string(" + strconv.Quote(s) + ")"
+}
@@ -0,0 +1,12 @@
+// hnc-portmap is hnc portmap, kept for existing scripts
+package main
+
+import (
+	"os"
+
+	"github.com/hnc/profile-dump/pkg/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(cli.Std("hnc-portmap"), cli.Command{Run: cli.Portmap, Mutates: true}, os.Args[1:]))
+}
@@ -0,0 +1,20 @@
+// hnc-profile-schema is hnc profiles schema and hnc profiles validate,
+// kept for existing scripts: it emits the JSON Schema of the switch
+// profile fixtures and checks fixture files against it.
+//
+//	hnc-profile-schema schema [-output switch-profile.schema.json]
+//	hnc-profile-schema validate [-dir ../../src/fixtures/switch-profiles]
+package main
+
+import (
+	"os"
+
+	"github.com/hnc/profile-dump/pkg/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(cli.Std("hnc-profile-schema"), cli.Command{Commands: []cli.Command{
+		{Name: "schema", Summary: "Write the SwitchProfile JSON Schema (default: stdout)", Run: cli.Schema, Mutates: true},
+		{Name: "validate", Summary: "Check every *.json profile in -dir against the schema", Run: cli.Validate},
+	}}, os.Args[1:]))
+}
@@ -0,0 +1,20 @@
+// hnc is the HNC command line: the profile, planning, BOM, cabling and
+// port map tools as subcommands of one binary, sharing their flag parsing
+// and exit codes (0 success, 1 failure, 2 usage).
+//
+//	hnc profiles dump -check
+//	hnc profiles lint -json ../../src/fixtures/switch-profiles
+//	hnc plan -endpoints 96 -oversubscription 3
+//	hnc bom -plan fabric-plan.json
+//	hnc cabling -plan fabric-plan.json -csv cabling.csv
+package main
+
+import (
+	"os"
+
+	"github.com/hnc/profile-dump/pkg/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(cli.Std("hnc"), cli.Root, os.Args[1:]))
+}
@@ -0,0 +1,328 @@
+// Package addressing numbers the underlay of a leaf-spine fabric: a /31
+// point-to-point subnet for every leaf-spine link (or none, for
+// unnumbered links that borrow the loopback), a loopback address for every
+// switch, and BGP ASNs, one shared by the spines and one per leaf. Values
+// are taken in switch and link order from the configured pools, so an
+// unchanged cabling map always gets the same addresses.
+package addressing
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Link numbering modes
+const (
+	// P2P gives every link a /31, the spine the lower address
+	P2P = "p2p"
+	// Unnumbered gives links no subnet; both ends borrow their loopback
+	Unnumbered = "unnumbered"
+)
+
+// Modes lists the supported modes, default first
+var Modes = []string{P2P, Unnumbered}
+
+// ASNRange is an inclusive range of BGP AS numbers
+type ASNRange struct {
+	First uint32 `json:"first"`
+	Last  uint32 `json:"last"`
+}
+
+func (r ASNRange) String() string {
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// ParseASNRange reads FIRST-LAST
+func ParseASNRange(s string) (ASNRange, error) {
+	first, last, ok := strings.Cut(s, "-")
+	a, errA := strconv.ParseUint(strings.TrimSpace(first), 10, 32)
+	b, errB := strconv.ParseUint(strings.TrimSpace(last), 10, 32)
+	if !ok || errA != nil || errB != nil || a == 0 || a > b {
+		return ASNRange{}, fmt.Errorf("bad ASN range %q (want FIRST-LAST, e.g. 65101-65199)", s)
+	}
+	return ASNRange{First: uint32(a), Last: uint32(b)}, nil
+}
+
+// Defaults follow the Hedgehog fabric's: spines in AS 65100, leaves from
+// 65101, loopbacks and fabric links in 172.30.0.0/16
+var (
+	DefaultLinkPool     = "172.30.128.0/17"
+	DefaultLoopbackPool = "172.30.8.0/22"
+	DefaultSpineASN     = uint32(65100)
+	DefaultLeafASNs     = ASNRange{First: 65101, Last: 65199}
+)
+
+// Options are the pools addresses and ASNs come from
+type Options struct {
+	Mode         string   `json:"mode"`
+	LinkPool     string   `json:"linkPool,omitempty"` // CIDR the /31s are carved from; unused when unnumbered
+	LoopbackPool string   `json:"loopbackPool"`
+	SpineASN     uint32   `json:"spineASN"`
+	LeafASNs     ASNRange `json:"leafASNs"`
+}
+
+// Link is one leaf-spine link to number, as a cabling map lists it
+type Link struct {
+	Name  string // e.g. leaf1:E1/49 <-> spine1:E1/1
+	Leaf  string
+	Spine string
+}
+
+// Plan is the numbering, written into cabling.json under addressing
+type Plan struct {
+	Options  Options       `json:"options"`
+	Switches []Switch      `json:"switches"`
+	Links    []LinkAddress `json:"links"`
+}
+
+// Switch is one switch's BGP identity
+type Switch struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"` // spine or leaf
+	ASN      uint32 `json:"asn"`
+	Loopback string `json:"loopback"` // /32, also the BGP router ID
+}
+
+// LinkAddress is the numbering of one link; the addresses are empty for
+// unnumbered links
+type LinkAddress struct {
+	Link         string `json:"link"`
+	Subnet       string `json:"subnet,omitempty"`
+	SpineAddress string `json:"spineAddress,omitempty"`
+	LeafAddress  string `json:"leafAddress,omitempty"`
+}
+
+// Assign numbers spines spine1..spineN and leaves leaf1..leafN, in that
+// order, from the first address of the loopback pool, and links in the
+// order given from the start of the link pool
+func Assign(spines, leaves int, links []Link, opts Options) (Plan, error) {
+	if opts.Mode == "" {
+		opts.Mode = P2P
+	}
+	if opts.LoopbackPool == "" {
+		opts.LoopbackPool = DefaultLoopbackPool
+	}
+	if opts.SpineASN == 0 {
+		opts.SpineASN = DefaultSpineASN
+	}
+	if opts.LeafASNs == (ASNRange{}) {
+		opts.LeafASNs = DefaultLeafASNs
+	}
+	switch opts.Mode {
+	case P2P:
+		if opts.LinkPool == "" {
+			opts.LinkPool = DefaultLinkPool
+		}
+	case Unnumbered:
+		opts.LinkPool = ""
+	default:
+		return Plan{}, fmt.Errorf("unknown addressing mode %q (want %s)", opts.Mode, strings.Join(Modes, " or "))
+	}
+	if opts.SpineASN >= opts.LeafASNs.First && opts.SpineASN <= opts.LeafASNs.Last {
+		return Plan{}, fmt.Errorf("spine ASN %d is inside the leaf ASN range %s", opts.SpineASN, opts.LeafASNs)
+	}
+	return Extend(Plan{Options: opts}, spines, leaves, links)
+}
+
+// Extend numbers a grown fabric from an existing plan's pools. Switches
+// and links the plan already numbers keep their ASNs and addresses; new
+// ones, in the order Assign would take them, get the lowest ASN and
+// addresses the existing plan does not hold, even for links since
+// removed. Extending an empty plan is Assign.
+func Extend(prev Plan, spines, leaves int, links []Link) (Plan, error) {
+	opts := prev.Options
+	if uint64(opts.LeafASNs.Last-opts.LeafASNs.First)+1 < uint64(leaves) {
+		return Plan{}, fmt.Errorf("%d leaves need %d ASNs, range %s has %d", leaves, leaves, opts.LeafASNs, opts.LeafASNs.Last-opts.LeafASNs.First+1)
+	}
+	loopbacks, err := parsePool("loopback", opts.LoopbackPool)
+	if err != nil {
+		return Plan{}, err
+	}
+	if size := loopbacks.size(); size < uint64(spines+leaves) {
+		return Plan{}, fmt.Errorf("loopback pool %s has %d addresses, the fabric needs %d", opts.LoopbackPool, size, spines+leaves)
+	}
+
+	numbered := map[string]Switch{}
+	asns, addrs := map[uint32]bool{}, map[string]bool{}
+	for _, sw := range prev.Switches {
+		numbered[sw.Name] = sw
+		asns[sw.ASN] = true
+		addrs[sw.Loopback] = true
+	}
+	nextASN, nextLoopback := opts.LeafASNs.First, uint64(0)
+	plan := Plan{Options: opts}
+	for i := 0; i < spines+leaves; i++ {
+		sw := Switch{Name: "spine" + strconv.Itoa(i+1), Role: "spine", ASN: opts.SpineASN}
+		if i >= spines {
+			sw = Switch{Name: "leaf" + strconv.Itoa(i-spines+1), Role: "leaf"}
+		}
+		if old, ok := numbered[sw.Name]; ok {
+			plan.Switches = append(plan.Switches, old)
+			continue
+		}
+		if sw.Role == "leaf" {
+			for asns[nextASN] {
+				nextASN++
+			}
+			if nextASN > opts.LeafASNs.Last {
+				return Plan{}, fmt.Errorf("leaf ASN range %s is used up", opts.LeafASNs)
+			}
+			sw.ASN = nextASN
+			asns[nextASN] = true
+		}
+		for ; ; nextLoopback++ {
+			if nextLoopback >= loopbacks.size() {
+				return Plan{}, fmt.Errorf("loopback pool %s is used up", opts.LoopbackPool)
+			}
+			if sw.Loopback = netip.PrefixFrom(loopbacks.addr(nextLoopback), 32).String(); !addrs[sw.Loopback] {
+				break
+			}
+		}
+		addrs[sw.Loopback] = true
+		plan.Switches = append(plan.Switches, sw)
+	}
+
+	if opts.Mode == Unnumbered {
+		for _, l := range links {
+			plan.Links = append(plan.Links, LinkAddress{Link: l.Name})
+		}
+		return plan, nil
+	}
+	pool, err := parsePool("link", opts.LinkPool)
+	if err != nil {
+		return Plan{}, err
+	}
+	if size := pool.size() / 2; size < uint64(len(links)) {
+		return Plan{}, fmt.Errorf("link pool %s holds %d /31s, the fabric has %d links", opts.LinkPool, size, len(links))
+	}
+	numberedLinks := map[string]LinkAddress{}
+	subnets := map[string]bool{}
+	for _, l := range prev.Links {
+		numberedLinks[l.Link] = l
+		subnets[l.Subnet] = true
+	}
+	next := uint64(0)
+	for _, l := range links {
+		if old, ok := numberedLinks[l.Name]; ok {
+			plan.Links = append(plan.Links, old)
+			continue
+		}
+		var spine, leaf netip.Addr
+		for ; ; next++ {
+			if next >= pool.size()/2 {
+				return Plan{}, fmt.Errorf("link pool %s is used up", opts.LinkPool)
+			}
+			spine, leaf = pool.addr(2*next), pool.addr(2*next+1)
+			if !subnets[netip.PrefixFrom(spine, 31).String()] {
+				break
+			}
+		}
+		next++
+		plan.Links = append(plan.Links, LinkAddress{
+			Link:         l.Name,
+			Subnet:       netip.PrefixFrom(spine, 31).String(),
+			SpineAddress: netip.PrefixFrom(spine, 31).String(),
+			LeafAddress:  netip.PrefixFrom(leaf, 31).String(),
+		})
+	}
+	return plan, nil
+}
+
+// pool is an IPv4 network addresses are handed out from
+type pool struct {
+	prefix netip.Prefix
+	base   uint64
+}
+
+func parsePool(name, cidr string) (pool, error) {
+	p, err := netip.ParsePrefix(cidr)
+	if err != nil || !p.Addr().Is4() || p.Masked() != p {
+		return pool{}, fmt.Errorf("%s pool %q is not an IPv4 network address, e.g. %s", name, cidr, DefaultLinkPool)
+	}
+	b := p.Addr().As4()
+	return pool{prefix: p, base: uint64(b[0])<<24 | uint64(b[1])<<16 | uint64(b[2])<<8 | uint64(b[3])}, nil
+}
+
+func (p pool) size() uint64 {
+	return 1 << (32 - p.prefix.Bits())
+}
+
+// addr is the pool's i-th address
+func (p pool) addr(i uint64) netip.Addr {
+	v := p.base + i
+	return netip.AddrFrom4([4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+// Check checks next, a renumbering of prev as an allocation policy
+// returns it: the same mode, switches and links in the same order, every
+// switch its own IPv4 /32 loopback, the spines one ASN and every leaf an
+// ASN of its own, and in p2p mode every link its own /31, the spine and
+// leaf each taking one of its two addresses
+func Check(prev, next Plan) error {
+	if next.Options.Mode != prev.Options.Mode {
+		return fmt.Errorf("mode is %q, want %s", next.Options.Mode, prev.Options.Mode)
+	}
+	if len(next.Switches) != len(prev.Switches) || len(next.Links) != len(prev.Links) {
+		return fmt.Errorf("numbers %d switches and %d links, want %d and %d", len(next.Switches), len(next.Links), len(prev.Switches), len(prev.Links))
+	}
+	loopbacks := map[netip.Addr]string{}
+	owners := map[uint32]Switch{}
+	for i, sw := range next.Switches {
+		if old := prev.Switches[i]; sw.Name != old.Name || sw.Role != old.Role {
+			return fmt.Errorf("switch %d is %s %s, want %s %s", i+1, sw.Role, sw.Name, old.Role, old.Name)
+		}
+		p, err := netip.ParsePrefix(sw.Loopback)
+		if err != nil || !p.Addr().Is4() || p.Bits() != 32 {
+			return fmt.Errorf("%s loopback %q is not an IPv4 /32", sw.Name, sw.Loopback)
+		}
+		if other, ok := loopbacks[p.Addr()]; ok {
+			return fmt.Errorf("%s and %s share loopback %s", other, sw.Name, p)
+		}
+		loopbacks[p.Addr()] = sw.Name
+		if sw.ASN == 0 {
+			return fmt.Errorf("%s has no ASN", sw.Name)
+		}
+		if sw.Role == "spine" && sw.ASN != next.Switches[0].ASN {
+			return fmt.Errorf("%s has ASN %d, %s %d; the spines share one", sw.Name, sw.ASN, next.Switches[0].Name, next.Switches[0].ASN)
+		}
+		if o, ok := owners[sw.ASN]; ok && (o.Role == "leaf" || sw.Role == "leaf") {
+			return fmt.Errorf("%s and %s share ASN %d", o.Name, sw.Name, sw.ASN)
+		}
+		owners[sw.ASN] = sw
+	}
+
+	subnets := map[netip.Prefix]string{}
+	for i, l := range next.Links {
+		if l.Link != prev.Links[i].Link {
+			return fmt.Errorf("link %d is %s, want %s", i+1, l.Link, prev.Links[i].Link)
+		}
+		if next.Options.Mode == Unnumbered {
+			if l.Subnet != "" || l.SpineAddress != "" || l.LeafAddress != "" {
+				return fmt.Errorf("%s has addresses, but the links are unnumbered", l.Link)
+			}
+			continue
+		}
+		subnet, err := netip.ParsePrefix(l.Subnet)
+		if err != nil || !subnet.Addr().Is4() || subnet.Bits() != 31 || subnet.Masked() != subnet {
+			return fmt.Errorf("%s subnet %q is not an IPv4 /31", l.Link, l.Subnet)
+		}
+		if other, ok := subnets[subnet]; ok {
+			return fmt.Errorf("%s and %s share subnet %s", other, l.Link, subnet)
+		}
+		subnets[subnet] = l.Link
+		spine, err1 := netip.ParsePrefix(l.SpineAddress)
+		leaf, err2 := netip.ParsePrefix(l.LeafAddress)
+		if err1 != nil || err2 != nil || spine.Bits() != 31 || leaf.Bits() != 31 ||
+			!subnet.Contains(spine.Addr()) || !subnet.Contains(leaf.Addr()) || spine.Addr() == leaf.Addr() {
+			return fmt.Errorf("%s addresses %q and %q are not the two of %s", l.Link, l.SpineAddress, l.LeafAddress, subnet)
+		}
+		for _, a := range []netip.Addr{spine.Addr(), leaf.Addr()} {
+			if sw, ok := loopbacks[a]; ok {
+				return fmt.Errorf("%s address %s is %s's loopback", l.Link, a, sw)
+			}
+		}
+	}
+	return nil
+}
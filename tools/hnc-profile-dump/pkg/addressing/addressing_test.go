@@ -0,0 +1,152 @@
+package addressing
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var links = []Link{
+	{Name: "leaf1:E1/49 <-> spine1:E1/1", Leaf: "leaf1", Spine: "spine1"},
+	{Name: "leaf1:E1/50 <-> spine2:E1/1", Leaf: "leaf1", Spine: "spine2"},
+	{Name: "leaf2:E1/49 <-> spine1:E1/2", Leaf: "leaf2", Spine: "spine1"},
+}
+
+func TestAssign(t *testing.T) {
+	plan, err := Assign(2, 2, links, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSwitches := []Switch{
+		{Name: "spine1", Role: "spine", ASN: 65100, Loopback: "172.30.8.0/32"},
+		{Name: "spine2", Role: "spine", ASN: 65100, Loopback: "172.30.8.1/32"},
+		{Name: "leaf1", Role: "leaf", ASN: 65101, Loopback: "172.30.8.2/32"},
+		{Name: "leaf2", Role: "leaf", ASN: 65102, Loopback: "172.30.8.3/32"},
+	}
+	if !reflect.DeepEqual(plan.Switches, wantSwitches) {
+		t.Errorf("switches =\n%+v\nwant\n%+v", plan.Switches, wantSwitches)
+	}
+	want := LinkAddress{Link: links[2].Name, Subnet: "172.30.128.4/31", SpineAddress: "172.30.128.4/31", LeafAddress: "172.30.128.5/31"}
+	if len(plan.Links) != 3 || plan.Links[2] != want {
+		t.Errorf("links = %+v", plan.Links)
+	}
+	if plan.Options.Mode != P2P || plan.Options.LinkPool != DefaultLinkPool {
+		t.Errorf("options = %+v, want the defaults recorded", plan.Options)
+	}
+
+	again, _ := Assign(2, 2, links, Options{})
+	if !reflect.DeepEqual(plan, again) {
+		t.Error("same inputs numbered differently")
+	}
+}
+
+func TestUnnumbered(t *testing.T) {
+	plan, err := Assign(2, 2, links, Options{Mode: Unnumbered, LinkPool: "10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Options.LinkPool != "" || plan.Links[0] != (LinkAddress{Link: links[0].Name}) || plan.Switches[3].Loopback != "172.30.8.3/32" {
+		t.Errorf("unnumbered plan = %+v", plan)
+	}
+}
+
+func TestAssignErrors(t *testing.T) {
+	for _, tc := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{Mode: "ospf"}, "unknown addressing mode"},
+		{Options{LinkPool: "10.0.0.0/30"}, "holds 2 /31s, the fabric has 3 links"},
+		{Options{LoopbackPool: "10.0.0.0/31"}, "has 2 addresses, the fabric needs 4"},
+		{Options{LinkPool: "10.0.0.1/24"}, "not an IPv4 network address"},
+		{Options{LeafASNs: ASNRange{65001, 65001}}, "2 leaves need 2 ASNs"},
+		{Options{SpineASN: 65101}, "inside the leaf ASN range"},
+	} {
+		if _, err := Assign(2, 2, links, tc.opts); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Assign(%+v) = %v, want %q", tc.opts, err, tc.want)
+		}
+	}
+}
+
+func TestParseASNRange(t *testing.T) {
+	if r, err := ParseASNRange("65101-65199"); err != nil || r != DefaultLeafASNs {
+		t.Errorf("ParseASNRange = %v, %v", r, err)
+	}
+	for _, s := range []string{"65101", "0-10", "20-10", "1-4294967296"} {
+		if _, err := ParseASNRange(s); err == nil {
+			t.Errorf("ParseASNRange(%q) succeeded", s)
+		}
+	}
+}
+
+func TestExtendKeepsNumbering(t *testing.T) {
+	plan, err := Assign(2, 2, links, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// leaf1's first link is gone and leaf3 joins
+	grown := []Link{links[1], links[2], {Name: "leaf3:E1/49 <-> spine1:E1/3", Leaf: "leaf3", Spine: "spine1"}}
+	again, err := Extend(plan, 2, 3, grown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Switches[:4], plan.Switches) || !reflect.DeepEqual(again.Links[:2], plan.Links[1:]) {
+		t.Fatalf("extended plan renumbered existing switches or links: %+v", again)
+	}
+	if want := (Switch{Name: "leaf3", Role: "leaf", ASN: 65103, Loopback: "172.30.8.4/32"}); again.Switches[4] != want {
+		t.Errorf("leaf3 = %+v, want %+v", again.Switches[4], want)
+	}
+	// the /31 of leaf1's removed link is not handed out again
+	if again.Links[2].Subnet != "172.30.128.6/31" {
+		t.Errorf("new link = %+v", again.Links[2])
+	}
+	if _, err := Extend(plan, 2, 100, grown); err == nil || !strings.Contains(err.Error(), "100 leaves need 100 ASNs") {
+		t.Errorf("Extend past the ASN range = %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	prev, err := Assign(2, 2, links, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// renumber copies prev and lets each case change the copy
+	renumber := func(change func(*Plan)) Plan {
+		next := Plan{Options: prev.Options, Switches: append([]Switch{}, prev.Switches...), Links: append([]LinkAddress{}, prev.Links...)}
+		change(&next)
+		return next
+	}
+	site := renumber(func(p *Plan) {
+		for i := range p.Switches {
+			p.Switches[i].Loopback = "10.255.0." + strconv.Itoa(i+1) + "/32"
+		}
+		p.Switches[2].ASN, p.Switches[3].ASN = 64601, 64602
+		p.Links[0] = LinkAddress{Link: links[0].Name, Subnet: "10.254.0.0/31", SpineAddress: "10.254.0.1/31", LeafAddress: "10.254.0.0/31"}
+	})
+	if err := Check(prev, site); err != nil {
+		t.Errorf("Check(site) = %v", err)
+	}
+	for _, tc := range []struct {
+		change func(*Plan)
+		want   string
+	}{
+		{func(p *Plan) { p.Options.Mode = Unnumbered }, `mode is "unnumbered", want p2p`},
+		{func(p *Plan) { p.Switches = p.Switches[1:] }, "numbers 3 switches and 3 links, want 4 and 3"},
+		{func(p *Plan) { p.Switches[0].Name = "core1" }, "switch 1 is spine core1, want spine spine1"},
+		{func(p *Plan) { p.Switches[1].Loopback = "172.30.8.0/32" }, "spine1 and spine2 share loopback 172.30.8.0/32"},
+		{func(p *Plan) { p.Switches[2].Loopback = "10.0.0.0/24" }, `leaf1 loopback "10.0.0.0/24" is not an IPv4 /32`},
+		{func(p *Plan) { p.Switches[1].ASN = 65000 }, "spine2 has ASN 65000, spine1 65100; the spines share one"},
+		{func(p *Plan) { p.Switches[3].ASN = 65101 }, "leaf1 and leaf2 share ASN 65101"},
+		{func(p *Plan) { p.Switches[2].ASN = 65100 }, "spine2 and leaf1 share ASN 65100"},
+		{func(p *Plan) { p.Links[1].Subnet = p.Links[0].Subnet }, "share subnet 172.30.128.0/31"},
+		{func(p *Plan) { p.Links[0].LeafAddress = "172.30.128.9/31" }, "are not the two of 172.30.128.0/31"},
+		{func(p *Plan) {
+			p.Links[0] = LinkAddress{Link: links[0].Name, Subnet: "172.30.8.0/31", SpineAddress: "172.30.8.0/31", LeafAddress: "172.30.8.1/31"}
+		}, "address 172.30.8.0 is spine1's loopback"},
+	} {
+		if err := Check(prev, renumber(tc.change)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Check() = %v, want %q", err, tc.want)
+		}
+	}
+}
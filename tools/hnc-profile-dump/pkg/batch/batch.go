@@ -0,0 +1,81 @@
+// Package batch runs one function over many items on a bounded pool of
+// goroutines, for generating and validating large profile sets. Results
+// come back in item order whatever order the workers finish in, and every
+// item's error is kept rather than the first, so one run reports all the
+// broken inputs and its output does not depend on scheduling. MapContext
+// stops handing out items once its context is done and reports each item
+// finished to the context's progress.Reporter.
+package batch
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hnc/profile-dump/pkg/progress"
+)
+
+// Jobs is the worker count for a -j flag's value: n, or one per CPU
+// when n is 0 or less
+func Jobs(n int) int {
+	if n <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return n
+}
+
+// Map calls fn on every item, at most Jobs(jobs) at a time, and returns
+// the results in item order. The error joins those of every item that
+// failed, in item order; the results of those items are zero.
+func Map[T, R any](jobs int, items []T, fn func(T) (R, error)) ([]R, error) {
+	return MapContext(context.Background(), "", jobs, items, fn)
+}
+
+// MapContext is Map that starts no more items once ctx is done, and
+// reports every item fn finishes as a step of stage. The items it did not
+// start have zero results, and ctx's error is joined after the items'.
+// An item already started runs to the end.
+func MapContext[T, R any](ctx context.Context, stage string, jobs int, items []T, fn func(T) (R, error)) ([]R, error) {
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+	var done atomic.Int64
+	run := func(i int) {
+		results[i], errs[i] = fn(items[i])
+		progress.Report(ctx, stage, int(done.Add(1)), len(items))
+	}
+	workers := min(Jobs(jobs), len(items))
+	if workers <= 1 {
+		for i := range items {
+			if ctx.Err() != nil {
+				break
+			}
+			run(i)
+		}
+		return results, errors.Join(append(errs, ctx.Err())...)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				run(i)
+			}
+		}()
+	}
+dispatch:
+	for i := range items {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(next)
+	wg.Wait()
+	return results, errors.Join(append(errs, ctx.Err())...)
+}
@@ -0,0 +1,90 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/progress"
+)
+
+func TestMap(t *testing.T) {
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+	var running, peak atomic.Int32
+	got, err := Map(4, items, func(n int) (string, error) {
+		if r := running.Add(1); r > peak.Load() {
+			peak.Store(r)
+		}
+		defer running.Add(-1)
+		// Later items finish first, so order comes from the index alone
+		time.Sleep(time.Duration(50-n) * 20 * time.Microsecond)
+		if n%20 == 7 {
+			return "", fmt.Errorf("item %d", n)
+		}
+		return fmt.Sprint(n), nil
+	})
+	for i, s := range got {
+		want := fmt.Sprint(i)
+		if i%20 == 7 {
+			want = ""
+		}
+		if s != want {
+			t.Fatalf("Map()[%d] = %q, want %q", i, s, want)
+		}
+	}
+	if err == nil || err.Error() != "item 7\nitem 27\nitem 47" {
+		t.Errorf("Map() error = %v, want items 7, 27 and 47 in order", err)
+	}
+	if p := peak.Load(); p > 4 {
+		t.Errorf("Map(4) ran %d at once", p)
+	}
+}
+
+func TestMapSerial(t *testing.T) {
+	var order []int
+	_, err := Map(1, []int{3, 1, 2}, func(n int) (int, error) {
+		order = append(order, n)
+		return n, nil
+	})
+	if err != nil || fmt.Sprint(order) != "[3 1 2]" {
+		t.Errorf("Map(1) order = %v, %v", order, err)
+	}
+	if _, err := Map(0, []int{}, func(int) (int, error) { return 0, errors.New("never") }); err != nil {
+		t.Errorf("Map of no items = %v", err)
+	}
+	if Jobs(0) < 1 || Jobs(3) != 3 {
+		t.Errorf("Jobs(0) = %d, Jobs(3) = %d", Jobs(0), Jobs(3))
+	}
+}
+
+func TestMapContext(t *testing.T) {
+	for _, jobs := range []int{1, 4} {
+		var reports atomic.Int32
+		ctx, cancel := context.WithCancel(progress.WithReporter(context.Background(), progress.Func(func(e progress.Event) {
+			if e.Stage != "items" || e.Total != 100 {
+				t.Errorf("event %+v", e)
+			}
+			reports.Add(1)
+		})))
+		var ran atomic.Int32
+		got, err := MapContext(ctx, "items", jobs, make([]int, 100), func(int) (int, error) {
+			if ran.Add(1) == 10 {
+				cancel()
+			}
+			return 1, nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("MapContext(%d) cancelled = %v", jobs, err)
+		}
+		// Items already handed out finish; none starts after
+		if n := ran.Load(); n < 10 || n > 10+int32(jobs) || got[99] != 0 || reports.Load() != n {
+			t.Errorf("MapContext(%d) ran %d items, reported %d, last result %d", jobs, n, reports.Load(), got[99])
+		}
+	}
+}
@@ -0,0 +1,409 @@
+// Package bom turns a fabric plan into a bill of materials: the switches
+// to order, an optic SKU for every cage the plan lights, picked from the
+// optics matrix for the reach of its run, and the cables between them
+// grouped by length class.
+package bom
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/layout"
+	"github.com/hnc/profile-dump/pkg/optics"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Categories, in the order lines are listed
+const (
+	Switch = "switch"
+	Optic  = "optic"
+	Cable  = "cable"
+)
+
+// Options sets the cable length class for each kind of run
+type Options struct {
+	EndpointLength string // leaf to endpoint, default "3m" (in rack)
+	FabricLength   string // leaf to spine, default "10m" (across the row)
+	PeerLength     string // MCLAG peer links between a leaf pair, default "3m" (in rack)
+	PodLength      string // spine to super-spine, default "30m" (between pod rows)
+	ExternalLength string // border leaf to external router or firewall, default "10m"
+	// SuperSpine is the super-spine profile of a multi-pod plan
+	SuperSpine *profiles.SwitchProfile
+	// DirectAttach uses a DAC or AOC cable instead of two optics and a
+	// fiber wherever one reaches and both ends share a port profile
+	DirectAttach bool
+	// Runs, when measured from a layout, replaces the three length
+	// classes with the length of every run
+	Runs *Runs
+}
+
+// Runs counts a plan's cable runs by length class, e.g. {"3m": 40}.
+// Fabric runs are per leaf cage, so a breakout cable is one run at the
+// length of its longest lane.
+type Runs struct {
+	Endpoint map[string]int `json:"endpoint"`
+	Fabric   map[string]int `json:"fabric"`
+	Peer     map[string]int `json:"peer"`
+}
+
+// Line is one orderable item
+type Line struct {
+	Category    string `json:"category"`
+	Item        string `json:"item"` // switch SKU, optic SKU, cable length class or DAC/AOC SKU
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	PortProfile string `json:"portProfile,omitempty"` // of optics and DAC/AOC cables, from v2
+}
+
+// BOM is the bill of materials for one plan, written as bom.json
+type BOM struct {
+	FormatVersion string `json:"formatVersion,omitempty"` // empty in v1
+	LeafModel     string `json:"leafModel"`
+	SpineModel    string `json:"spineModel"`
+	// SuperSpineModel is set for multi-pod plans
+	SuperSpineModel string `json:"superSpineModel,omitempty"`
+	Lines           []Line `json:"lines"`
+}
+
+// Compute lists what the plan needs. Every endpoint takes an optic at the
+// leaf and a cable; every leaf uplink takes an optic at both ends and a
+// cable. With a breakout, optics are counted per physical cage and each
+// leaf cage takes one breakout cable whose lanes fan out to the spines.
+// Dual-homed endpoints take an optic and a cable to each leaf of their
+// pair, and MCLAG peer links an optic at both ends and a cable. Optics
+// are the shortest-reach SKU that covers the run's length class; with
+// DirectAttach a run a DAC or AOC covers takes that cable alone. With
+// measured Runs, each run is bought for its own length class, DAC and
+// AOC cables in the stock length that covers it. Port profiles the
+// optics matrix does not list are ordered by name. A multi-pod plan's
+// spine uplinks take an optic at both ends and a cable of the PodLength
+// class, which a layout does not measure. External uplinks take an optic
+// at the border leaf and a cable of the ExternalLength class, also
+// unmeasured; the far end's optic comes with the router or firewall.
+func Compute(plan fabricplan.Plan, leaf, spine profiles.SwitchProfile, opts Options) (BOM, error) {
+	if leaf.ModelID != plan.LeafModel || spine.ModelID != plan.SpineModel {
+		return BOM{}, fmt.Errorf("plan is for leaf %s and spine %s, not %s and %s",
+			plan.LeafModel, plan.SpineModel, leaf.ModelID, spine.ModelID)
+	}
+	if opts.EndpointLength == "" {
+		opts.EndpointLength = "3m"
+	}
+	if opts.FabricLength == "" {
+		opts.FabricLength = "10m"
+	}
+	if opts.PeerLength == "" {
+		opts.PeerLength = "3m"
+	}
+	if opts.PodLength == "" {
+		opts.PodLength = "30m"
+	}
+	if opts.ExternalLength == "" {
+		opts.ExternalLength = "10m"
+	}
+	var superSpine profiles.SwitchProfile
+	if plan.Pods > 1 {
+		if opts.SuperSpine == nil || opts.SuperSpine.ModelID != plan.SuperSpineModel {
+			return BOM{}, fmt.Errorf("plan is for super-spine %s, which the options do not give", plan.SuperSpineModel)
+		}
+		superSpine = *opts.SuperSpine
+	}
+	endpointProfile, err := portProfile(leaf, "endpoint", leaf.Profiles.Endpoint)
+	if err != nil {
+		return BOM{}, err
+	}
+	leafProfile, err := portProfile(leaf, "uplink", leaf.Profiles.Uplink)
+	if err != nil {
+		return BOM{}, err
+	}
+	// A plan without spines, such as a collapsed core, buys no spine optics
+	var spineProfile string
+	if plan.Spines > 0 {
+		if spineProfile, err = portProfile(spine, "uplink", spine.Profiles.Uplink); err != nil {
+			return BOM{}, err
+		}
+	}
+	mode := plan.Request.Breakout
+	fabricDirect := opts.DirectAttach && mode == "" && leafProfile == spineProfile
+
+	leafCages, spineCages := plan.UplinksPerLeaf, plan.SpinePortsUsed
+	fabricCable := fmt.Sprintf("%dG leaf to spine", leaf.Profiles.Uplink.SpeedGbps)
+	if mode != "" {
+		leafSplit, ok := leaf.Profiles.Uplink.Breakout(mode)
+		if !ok {
+			return BOM{}, fmt.Errorf("leaf %s uplinks do not support breakout %s", leaf.ModelID, mode)
+		}
+		spineSplit, ok := spine.Profiles.Uplink.Breakout(mode)
+		if !ok {
+			return BOM{}, fmt.Errorf("spine %s fabric ports do not support breakout %s", spine.ModelID, mode)
+		}
+		leafCages = ceilDiv(leafCages, leafSplit.Lanes)
+		spineCages = ceilDiv(spineCages, spineSplit.Lanes)
+		fabricCable = fmt.Sprintf("%s breakout, leaf to spine", leafSplit.Mode)
+	}
+
+	endpointCable := fmt.Sprintf("%dG leaf to endpoint", plan.Request.EndpointSpeedGbps)
+	if len(plan.Request.Classes) > 0 {
+		endpointCable = "mixed-speed leaf to endpoint"
+	}
+
+	runs := Runs{
+		Endpoint: map[string]int{opts.EndpointLength: plan.EndpointPorts()},
+		Fabric:   map[string]int{opts.FabricLength: plan.Leaves * leafCages},
+		Peer:     map[string]int{opts.PeerLength: plan.LeafPairs * plan.PeerLinksPerLeaf},
+	}
+	if opts.Runs != nil {
+		for _, check := range []struct {
+			kind      string
+			got, want map[string]int
+		}{{"endpoint", opts.Runs.Endpoint, runs.Endpoint}, {"fabric", opts.Runs.Fabric, runs.Fabric}, {"peer link", opts.Runs.Peer, runs.Peer}} {
+			if got, want := total(check.got), total(check.want); got != want {
+				return BOM{}, fmt.Errorf("measured %d %s runs, the plan has %d", got, check.kind, want)
+			}
+		}
+		runs = *opts.Runs
+	}
+
+	b := BOM{FormatVersion: FormatVersion, LeafModel: leaf.ModelID, SpineModel: spine.ModelID, SuperSpineModel: plan.SuperSpineModel}
+	b.add(Line{Category: Switch, Item: SKU(leaf), Description: leaf.ModelID + " leaf", Quantity: plan.Leaves})
+	b.add(Line{Category: Switch, Item: SKU(spine), Description: spine.ModelID + " spine", Quantity: plan.Spines})
+	if plan.Pods > 1 {
+		b.add(Line{Category: Switch, Item: SKU(superSpine), Description: superSpine.ModelID + " super-spine", Quantity: plan.SuperSpines})
+	}
+	external := map[string]int{}
+	if n := plan.ExternalPorts(); n > 0 {
+		external[opts.ExternalLength] = n
+	}
+	var cables []Line
+	for _, run := range []struct {
+		profile     string
+		lengths     map[string]int
+		direct      bool
+		optics      string
+		opticsPer   int // leaf optics per run: peer links end on two leaves
+		description string
+	}{
+		{endpointProfile, runs.Endpoint, opts.DirectAttach, "leaf endpoint ports", 1, endpointCable},
+		{leafProfile, runs.Fabric, fabricDirect, "leaf uplink ports", 1, fabricCable},
+		{leafProfile, runs.Peer, opts.DirectAttach, "leaf peer link ports", 2, fmt.Sprintf("%dG leaf peer link", leaf.Profiles.Uplink.SpeedGbps)},
+		{endpointProfile, external, opts.DirectAttach, "border leaf external ports", 1, fmt.Sprintf("%dG border leaf to external", leaf.Profiles.Endpoint.SpeedGbps)},
+	} {
+		for _, length := range lengthsOf(run.lengths) {
+			o, err := pick(run.profile, length, run.direct)
+			if err != nil {
+				return BOM{}, err
+			}
+			b.addOptic(o, run.optics, run.opticsPer*run.lengths[length])
+			if opts.Runs != nil {
+				meters, _ := optics.ParseLength(length)
+				o = o.Cut(meters)
+			}
+			cables = append(cables, cableLine(o, length, run.description, run.lengths[length]))
+		}
+	}
+	// A breakout cage's lanes run to leaves at different lengths, so its
+	// optic covers the longest fabric run
+	fabricLengths := lengthsOf(runs.Fabric)
+	for i, length := range fabricLengths {
+		if plan.Spines == 0 {
+			break
+		}
+		quantity := runs.Fabric[length]
+		if mode != "" {
+			if i < len(fabricLengths)-1 {
+				continue
+			}
+			quantity = plan.Spines * spineCages
+		}
+		o, err := pick(spineProfile, length, fabricDirect)
+		if err != nil {
+			return BOM{}, err
+		}
+		b.addOptic(o, "spine fabric ports", quantity)
+	}
+	if plan.Pods > 1 {
+		superProfile, err := portProfile(superSpine, "uplink", superSpine.Profiles.Uplink)
+		if err != nil {
+			return BOM{}, err
+		}
+		direct := opts.DirectAttach && spineProfile == superProfile
+		links := plan.Spines * plan.SpineUplinks
+		o, err := pick(spineProfile, opts.PodLength, direct)
+		if err != nil {
+			return BOM{}, err
+		}
+		b.addOptic(o, "spine uplink ports", links)
+		far, err := pick(superProfile, opts.PodLength, direct)
+		if err != nil {
+			return BOM{}, err
+		}
+		b.addOptic(far, "super-spine fabric ports", links)
+		cables = append(cables, cableLine(o, opts.PodLength, fmt.Sprintf("%dG spine to super-spine", spine.Profiles.Uplink.SpeedGbps), links))
+	}
+	for _, line := range cables {
+		b.add(line)
+	}
+	return b, nil
+}
+
+// Measure counts the runs of a plan cabled as m and laid out as l: each
+// rack's servers to their leaves, every leaf cage to its spines and every
+// peer link, at the length class of the racks between their ends
+func Measure(plan fabricplan.Plan, m cabling.Map, l layout.Layout) (Runs, error) {
+	runs := Runs{Endpoint: map[string]int{}, Fabric: map[string]int{}, Peer: map[string]int{}}
+	homes := 1
+	if plan.LeafPairs > 0 {
+		homes = 2
+	}
+	for _, r := range l.Racks {
+		if r.Servers == 0 {
+			continue
+		}
+		if r.ServerLength == "" {
+			return Runs{}, fmt.Errorf("rack %s has servers but no server cable length", r.Name)
+		}
+		runs.Endpoint[r.ServerLength] += r.Servers * homes
+	}
+	cages := map[string]float64{} // leaf cage -> longest lane run
+	for _, c := range m.Cables {
+		length, err := l.Cable(c.Leaf, c.Spine)
+		if err != nil {
+			return Runs{}, err
+		}
+		meters, _ := optics.ParseLength(length)
+		cage := c.Leaf + ":" + c.LeafPort
+		if plan.Request.Breakout != "" {
+			cage = c.Leaf + ":" + c.LeafPort[:strings.LastIndex(c.LeafPort, "/")]
+		}
+		cages[cage] = max(cages[cage], meters)
+	}
+	for _, meters := range cages {
+		runs.Fabric[layout.LengthClass(meters)]++
+	}
+	for _, p := range m.PeerLinks {
+		length, err := l.Cable(p.Leaf, p.Peer)
+		if err != nil {
+			return Runs{}, err
+		}
+		runs.Peer[length]++
+	}
+	return runs, nil
+}
+
+// pick chooses the part for runs of a port profile at a length class. A
+// port profile the matrix does not list is ordered as a transceiver by
+// its name.
+func pick(portProfile, length string, directAttach bool) (optics.Option, error) {
+	meters, err := optics.ParseLength(length)
+	if err != nil {
+		return optics.Option{}, err
+	}
+	if !optics.Known(portProfile) {
+		return optics.Option{SKU: portProfile, PortProfile: portProfile, Media: optics.Transceiver}, nil
+	}
+	return optics.Pick(portProfile, meters, directAttach)
+}
+
+// addOptic lists the optics for ports whose runs use a transceiver
+func (b *BOM) addOptic(o optics.Option, description string, quantity int) {
+	if !o.Assembly() {
+		b.add(Line{Category: Optic, Item: o.SKU, Description: description, Quantity: quantity, PortProfile: o.PortProfile})
+	}
+}
+
+// cableLine is a fiber of the length class for runs lit by transceivers,
+// or the DAC or AOC itself
+func cableLine(o optics.Option, length, description string, quantity int) Line {
+	line := Line{Category: Cable, Item: length, Description: description, Quantity: quantity}
+	if o.Assembly() {
+		line.Item, line.PortProfile = o.SKU, o.PortProfile
+	}
+	return line
+}
+
+// lengthsOf lists the length classes of runs, shortest first
+func lengthsOf(runs map[string]int) []string {
+	lengths := make([]string, 0, len(runs))
+	for length := range runs {
+		lengths = append(lengths, length)
+	}
+	sort.Slice(lengths, func(i, j int) bool {
+		a, _ := optics.ParseLength(lengths[i])
+		b, _ := optics.ParseLength(lengths[j])
+		return a < b
+	})
+	return lengths
+}
+
+func total(runs map[string]int) int {
+	n := 0
+	for _, count := range runs {
+		n += count
+	}
+	return n
+}
+
+// SKU is the order code for a switch model: celestica-ds2000 is
+// CELESTICA-DS2000
+func SKU(p profiles.SwitchProfile) string {
+	return strings.ToUpper(p.ModelID)
+}
+
+// add merges quantities for the same item and description, so leaf and
+// spine optics of one port profile stay separate lines
+func (b *BOM) add(line Line) {
+	if line.Quantity == 0 {
+		return
+	}
+	for i := range b.Lines {
+		l := &b.Lines[i]
+		if l.Category == line.Category && l.Item == line.Item && l.Description == line.Description {
+			l.Quantity += line.Quantity
+			return
+		}
+	}
+	b.Lines = append(b.Lines, line)
+}
+
+// CSVHeader is the first row of RenderCSV at FormatVersion
+var CSVHeader = []string{"category", "item", "description", "quantity", "port_profile"}
+
+// csvHeaderV1 is the first row of a v1 CSV, which has no port profiles
+var csvHeaderV1 = CSVHeader[:4]
+
+// RenderCSV writes one row per line, in the layout of the BOM's
+// format version
+func RenderCSV(bom BOM) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	v1 := bom.FormatVersion == ""
+	if v1 {
+		w.Write(csvHeaderV1)
+	} else {
+		w.Write(CSVHeader)
+	}
+	for _, l := range bom.Lines {
+		row := []string{l.Category, l.Item, l.Description, strconv.Itoa(l.Quantity)}
+		if !v1 {
+			row = append(row, l.PortProfile)
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return b.String()
+}
+
+func portProfile(p profiles.SwitchProfile, which string, pp profiles.PortProfile) (string, error) {
+	if pp.PortProfile == nil || *pp.PortProfile == "" {
+		return "", fmt.Errorf("%s has no %s port profile, so its optics are unknown", p.ModelID, which)
+	}
+	return *pp.PortProfile, nil
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
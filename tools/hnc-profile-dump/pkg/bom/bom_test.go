@@ -0,0 +1,167 @@
+package bom
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/layout"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func plan(t *testing.T, req fabricplan.Request) fabricplan.Plan {
+	t.Helper()
+	p, err := fabricplan.Compute(req, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestComputeCountsBothEnds(t *testing.T) {
+	// 5 leaves x 4 uplinks over 2 spines, 200 endpoints
+	b, err := Compute(plan(t, fabricplan.Request{Endpoints: 200, Oversubscription: 3}), profiles.DS2000(), profiles.DS3000(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Line{
+		{Switch, "CELESTICA-DS2000", "celestica-ds2000 leaf", 5, ""},
+		{Switch, "CELESTICA-DS3000", "celestica-ds3000 spine", 2, ""},
+		{Optic, "GEN-SFP28-25G-SR", "leaf endpoint ports", 200, "SFP28-25G"},
+		{Optic, "GEN-QSFP28-100G-SR4", "leaf uplink ports", 20, "QSFP28-100G"},
+		{Optic, "GEN-QSFP28-100G-SR4", "spine fabric ports", 20, "QSFP28-100G"},
+		{Cable, "3m", "25G leaf to endpoint", 200, ""},
+		{Cable, "10m", "100G leaf to spine", 20, ""},
+	}
+	if !reflect.DeepEqual(b.Lines, want) {
+		t.Fatalf("lines = %+v\nwant    %+v", b.Lines, want)
+	}
+}
+
+func TestComputeCountsBreakoutCages(t *testing.T) {
+	// 40 leaves x 16 x 25G uplinks = 4 cages each; 8 spines x 80 lanes = 20 cages each
+	b, err := Compute(plan(t, fabricplan.Request{Endpoints: 40 * 48, Oversubscription: 3, Breakout: "4x25G"}),
+		profiles.DS2000(), profiles.DS3000(), Options{FabricLength: "30m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{}
+	for _, l := range b.Lines {
+		got[l.Description] = l.Quantity
+	}
+	for desc, n := range map[string]int{"leaf uplink ports": 160, "spine fabric ports": 160, "4x25G breakout, leaf to spine": 160} {
+		if got[desc] != n {
+			t.Errorf("%s = %d, want %d", desc, got[desc], n)
+		}
+	}
+	if last := b.Lines[len(b.Lines)-1]; last.Item != "30m" {
+		t.Errorf("fabric cable length = %s, want 30m", last.Item)
+	}
+}
+
+func TestComputePicksOpticsForReach(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 200, Oversubscription: 3})
+	b, err := Compute(p, profiles.DS2000(), profiles.DS3000(), Options{FabricLength: "2km", DirectAttach: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Endpoints are close enough for a DAC; the spines are a campus away
+	want := []Line{
+		{Switch, "CELESTICA-DS2000", "celestica-ds2000 leaf", 5, ""},
+		{Switch, "CELESTICA-DS3000", "celestica-ds3000 spine", 2, ""},
+		{Optic, "GEN-QSFP28-100G-LR4", "leaf uplink ports", 20, "QSFP28-100G"},
+		{Optic, "GEN-QSFP28-100G-LR4", "spine fabric ports", 20, "QSFP28-100G"},
+		{Cable, "GEN-SFP28-25G-DAC", "25G leaf to endpoint", 200, "SFP28-25G"},
+		{Cable, "2km", "100G leaf to spine", 20, ""},
+	}
+	if !reflect.DeepEqual(b.Lines, want) {
+		t.Fatalf("lines = %+v\nwant    %+v", b.Lines, want)
+	}
+
+	if _, err := Compute(p, profiles.DS2000(), profiles.DS3000(), Options{FabricLength: "40km"}); err == nil || !strings.Contains(err.Error(), "no QSFP28-100G optic reaches 40000m") {
+		t.Errorf("error = %v, want no optic in reach", err)
+	}
+	if _, err := Compute(p, profiles.DS2000(), profiles.DS3000(), Options{EndpointLength: "short"}); err == nil || !strings.Contains(err.Error(), `cable length "short"`) {
+		t.Errorf("error = %v, want a bad length class", err)
+	}
+}
+
+func TestComputeRejectsMismatchedProfiles(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 48, Oversubscription: 3})
+	if _, err := Compute(p, profiles.DS3000(), profiles.DS3000(), Options{}); err == nil || !strings.Contains(err.Error(), "plan is for leaf celestica-ds2000") {
+		t.Fatalf("error = %v, want a model mismatch", err)
+	}
+	spine := profiles.DS3000()
+	spine.Profiles.Uplink.PortProfile = nil
+	if _, err := Compute(p, profiles.DS2000(), spine, Options{}); err == nil || !strings.Contains(err.Error(), "no uplink port profile") {
+		t.Fatalf("error = %v, want a missing port profile", err)
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	got := RenderCSV(BOM{Lines: []Line{{Optic, "SFP28-25G", "leaf endpoint ports", 48, ""}, {Cable, "3m", "25G, leaf to endpoint", 48, ""}}})
+	want := "category,item,description,quantity\noptic,SFP28-25G,leaf endpoint ports,48\ncable,3m,\"25G, leaf to endpoint\",48\n"
+	if got != want {
+		t.Fatalf("RenderCSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestComputeCountsDualHomingAndPeerLinks(t *testing.T) {
+	b, err := Compute(plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3, Redundancy: fabricplan.MCLAG}), profiles.DS2000(), profiles.DS3000(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{}
+	for _, l := range b.Lines {
+		got[l.Description] = l.Quantity
+	}
+	// 96 endpoints on both leaves of 2 pairs, 2 peer links per pair
+	for desc, want := range map[string]int{"leaf endpoint ports": 192, "25G leaf to endpoint": 192, "leaf peer link ports": 8, "100G leaf peer link": 4} {
+		if got[desc] != want {
+			t.Errorf("%s = %d, want %d", desc, got[desc], want)
+		}
+	}
+}
+
+func TestComputeBucketsMeasuredRuns(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3, Redundancy: fabricplan.MCLAG})
+	m, err := cabling.Assign(p, profiles.DS2000(), profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := layout.Plan(p, profiles.DS2000(), profiles.DS3000(), layout.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs, err := Measure(p, m, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a pair's 40 servers beside it and 8 the next rack over, twice
+	want := Runs{Endpoint: map[string]int{"3m": 160, "5m": 32}, Fabric: map[string]int{"5m": 16}, Peer: map[string]int{"3m": 4}}
+	if !reflect.DeepEqual(runs, want) {
+		t.Fatalf("runs = %+v, want %+v", runs, want)
+	}
+
+	b, err := Compute(p, profiles.DS2000(), profiles.DS3000(), Options{DirectAttach: true, Runs: &runs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cables []string
+	for _, line := range b.Lines {
+		if line.Category == Cable {
+			cables = append(cables, fmt.Sprintf("%s x%d", line.Item, line.Quantity))
+		}
+	}
+	if got, want := strings.Join(cables, ", "), "GEN-SFP28-25G-DAC x160, GEN-SFP28-25G-AOC-5M x32, GEN-QSFP28-100G-AOC-5M x16, GEN-QSFP28-100G-DAC x4"; got != want {
+		t.Errorf("cables = %s, want %s", got, want)
+	}
+
+	runs.Fabric["5m"]--
+	if _, err := Compute(p, profiles.DS2000(), profiles.DS3000(), Options{Runs: &runs}); err == nil || !strings.Contains(err.Error(), "measured 15 fabric runs, the plan has 16") {
+		t.Errorf("error = %v, want a run count mismatch", err)
+	}
+}
@@ -0,0 +1,96 @@
+package bom
+
+import (
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/optics"
+)
+
+// FormatVersion is the layout of bom.json and bom.csv Compute returns; it
+// is recorded in BOM.FormatVersion
+const FormatVersion = "v2"
+
+// Format versions AtVersion can write, oldest first
+//
+//	v1  optics listed by port profile, every cable by length class
+//	v2  optics and DAC/AOC cables by SKU from the optics matrix, with the
+//	    port profile of each in portProfile (port_profile in the CSV)
+var FormatVersions = []string{"v1", FormatVersion}
+
+// Version is the format version of b; v1 files don't record one
+func (b BOM) Version() string {
+	if b.FormatVersion == "" {
+		return "v1"
+	}
+	return b.FormatVersion
+}
+
+// AtVersion returns the BOM as the given format version writes it. Going
+// back to v1 lists optics by port profile and a DAC or AOC as a cable of
+// its length; going forward to v2 orders each port profile's
+// shortest-reach transceiver, as v1 did not record the run lengths.
+func AtVersion(b BOM, version string) (BOM, error) {
+	if !slices.Contains(FormatVersions, version) {
+		return BOM{}, fmt.Errorf("cannot write BOM format %s (supported: %s)", version, strings.Join(FormatVersions, ", "))
+	}
+	if b.Version() == version {
+		return b, nil
+	}
+	out := BOM{LeafModel: b.LeafModel, SpineModel: b.SpineModel, SuperSpineModel: b.SuperSpineModel}
+	if version != "v1" {
+		out.FormatVersion = version
+	}
+	for _, l := range b.Lines {
+		switch {
+		case version == "v1" && l.PortProfile != "":
+			if l.Category == Optic {
+				l.Item = l.PortProfile
+			} else if o, ok := optics.BySKU(l.Item); ok {
+				l.Item = o.Reach()
+			}
+			l.PortProfile = ""
+		case version != "v1" && l.Category == Optic:
+			if o, err := optics.Pick(l.Item, 0, false); err == nil {
+				l.Item, l.PortProfile = o.SKU, o.PortProfile
+			}
+		}
+		out.add(l)
+	}
+	return out, nil
+}
+
+// ParseCSV reads a BOM written by RenderCSV at any format version, telling
+// the version from the header
+func ParseCSV(data string) (BOM, error) {
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return BOM{}, err
+	}
+	if len(rows) == 0 {
+		return BOM{}, fmt.Errorf("empty BOM CSV")
+	}
+	var b BOM
+	switch header := strings.Join(rows[0], ","); header {
+	case strings.Join(csvHeaderV1, ","):
+	case strings.Join(CSVHeader, ","):
+		b.FormatVersion = FormatVersion
+	default:
+		return BOM{}, fmt.Errorf("unknown BOM CSV header %q", header)
+	}
+	for i, row := range rows[1:] {
+		quantity, err := strconv.Atoi(row[3])
+		if err != nil {
+			return BOM{}, fmt.Errorf("row %d: quantity %q is not a number", i+2, row[3])
+		}
+		l := Line{Category: row[0], Item: row[1], Description: row[2], Quantity: quantity}
+		if len(row) > 4 {
+			l.PortProfile = row[4]
+		}
+		b.Lines = append(b.Lines, l)
+	}
+	return b, nil
+}
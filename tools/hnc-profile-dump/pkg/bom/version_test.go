@@ -0,0 +1,75 @@
+package bom
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func TestAtVersion(t *testing.T) {
+	b, err := Compute(plan(t, fabricplan.Request{Endpoints: 200, Oversubscription: 3}), profiles.DS2000(), profiles.DS3000(), Options{DirectAttach: true, FabricLength: "2km"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Version() != FormatVersion {
+		t.Fatalf("Compute() is at %s, want %s", b.Version(), FormatVersion)
+	}
+
+	v1, err := AtVersion(b, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Line{
+		{Switch, "CELESTICA-DS2000", "celestica-ds2000 leaf", 5, ""},
+		{Switch, "CELESTICA-DS3000", "celestica-ds3000 spine", 2, ""},
+		{Optic, "QSFP28-100G", "leaf uplink ports", 20, ""},
+		{Optic, "QSFP28-100G", "spine fabric ports", 20, ""},
+		{Cable, "3m", "25G leaf to endpoint", 200, ""},
+		{Cable, "2km", "100G leaf to spine", 20, ""},
+	}
+	if v1.FormatVersion != "" || !reflect.DeepEqual(v1.Lines, want) {
+		t.Fatalf("v1 = %+v\nwant lines %+v", v1, want)
+	}
+	if csv := RenderCSV(v1); !strings.HasPrefix(csv, "category,item,description,quantity\n") {
+		t.Errorf("v1 CSV header:\n%s", csv)
+	}
+
+	// v1 kept no run lengths, so upgrading orders the shortest-reach optic
+	v2, err := AtVersion(v1, "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := v2.Lines[2]; v2.FormatVersion != "v2" || l.Item != "GEN-QSFP28-100G-SR4" || l.PortProfile != "QSFP28-100G" {
+		t.Errorf("v2 = %+v", v2)
+	}
+
+	if _, err := AtVersion(b, "v3"); err == nil || !strings.Contains(err.Error(), "supported: v1, v2") {
+		t.Errorf("AtVersion(v3) error = %v", err)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	b, err := Compute(plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3}), profiles.DS2000(), profiles.DS3000(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range FormatVersions {
+		at, _ := AtVersion(b, version)
+		csv := RenderCSV(at)
+		parsed, err := ParseCSV(csv)
+		if err != nil {
+			t.Fatalf("%s: %v", version, err)
+		}
+		if parsed.Version() != version || !reflect.DeepEqual(parsed.Lines, at.Lines) {
+			t.Errorf("%s: ParseCSV(RenderCSV()) = %+v, want %+v", version, parsed, at)
+		}
+	}
+	for _, bad := range []string{"", "sku,count\n", "category,item,description,quantity\noptic,x,y,many\n"} {
+		if _, err := ParseCSV(bad); err == nil {
+			t.Errorf("ParseCSV(%q) succeeded, want an error", bad)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package bundle packs what an air-gapped install needs to run the HNC
+// toolchain (the hnc binaries, the switch profile catalog, the JSON
+// Schemas and the export templates) into one gzipped tar, with a
+// manifest.json of every file's SHA-256 checksum, and checks a bundle
+// against that manifest without network access:
+//
+//	bin/hnc
+//	catalog/celestica-ds2000.json
+//	schemas/switch-profile.schema.json
+//	templates/cmdb.csv.tmpl
+//	manifest.json
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/manifest"
+)
+
+// Directories of the bundle, by what they hold
+const (
+	BinDir      = "bin"
+	CatalogDir  = "catalog"
+	SchemaDir   = "schemas"
+	TemplateDir = "templates"
+)
+
+// Write writes files, by slash-separated name, and their manifest as a
+// gzipped tar, in name order with the manifest last. Files in BinDir are
+// executable. Every entry is dated now, so the same files and time give
+// the same archive.
+func Write(w io.Writer, files map[string][]byte, m manifest.Manifest, now time.Time) error {
+	data, err := canonjson.Marshal(m)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		mode := int64(0o644)
+		if path.Dir(name) == BinDir {
+			mode = 0o755
+		}
+		hdr := &tar.Header{Name: name, Mode: mode, Size: int64(len(data)), ModTime: now.UTC().Truncate(time.Second), Typeflag: tar.TypeReg, Format: tar.FormatPAX}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	for _, name := range names {
+		if err := add(name, files[name]); err != nil {
+			return err
+		}
+	}
+	if err := add(manifest.File, data); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Read reads every file of a bundle, its manifest.json among them, by
+// slash-separated name. Directory entries are skipped; links, absolute
+// names and names climbing out of the bundle are errors, so a bundle
+// cannot write outside the directory it is unpacked in.
+func Read(r io.Reader) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzipped bundle: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		switch {
+		case hdr.Typeflag == tar.TypeDir:
+			continue
+		case hdr.Typeflag != tar.TypeReg:
+			return nil, fmt.Errorf("%s: not a regular file", hdr.Name)
+		case path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../"):
+			return nil, fmt.Errorf("%s: outside the bundle", hdr.Name)
+		}
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("%s: in the bundle twice", hdr.Name)
+		}
+		if files[name], err = io.ReadAll(tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Verify checks files read from a bundle against the manifest.json among
+// them, returning the manifest, the problems and how many files matched
+func Verify(files map[string][]byte) (manifest.Manifest, []manifest.Problem, int, error) {
+	var m manifest.Manifest
+	data, ok := files[manifest.File]
+	if !ok {
+		return m, nil, 0, fmt.Errorf("no %s in the bundle", manifest.File)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, nil, 0, fmt.Errorf("parsing %s: %w", manifest.File, err)
+	}
+	problems, matched := manifest.Check(files, m)
+	return m, problems, matched, nil
+}
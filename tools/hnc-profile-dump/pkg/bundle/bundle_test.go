@@ -0,0 +1,96 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/manifest"
+)
+
+func TestWriteRead(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := map[string][]byte{"bin/hnc": []byte("elf"), "catalog/a.json": []byte("{}"), "templates/cmdb.tmpl": []byte("{{.Plan.Leaves}}")}
+	var out bytes.Buffer
+	if err := Write(&out, files, manifest.New("hnc bundle create", "(devel)", files, manifest.Manifest{}, now), now); err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	Write(&again, files, manifest.New("hnc bundle create", "(devel)", files, manifest.Manifest{}, now), now)
+	if !bytes.Equal(out.Bytes(), again.Bytes()) {
+		t.Error("the same files and time gave different archives")
+	}
+
+	read, err := Read(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, problems, matched, err := Verify(read)
+	if err != nil || len(problems) != 0 || matched != 3 || m.Generator != "hnc bundle create" {
+		t.Fatalf("Verify() = %+v, %v, %d, %v", m, problems, matched, err)
+	}
+	delete(read, manifest.File)
+	if !reflect.DeepEqual(read, files) {
+		t.Errorf("Read() = %q, want %q", read, files)
+	}
+
+	modes := map[string]int64{}
+	tr := tar.NewReader(mustGunzip(t, out.Bytes()))
+	for hdr, err := tr.Next(); err == nil; hdr, err = tr.Next() {
+		modes[hdr.Name] = hdr.Mode
+	}
+	if modes["bin/hnc"] != 0o755 || modes["catalog/a.json"] != 0o644 {
+		t.Errorf("modes = %v", modes)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	files := map[string][]byte{"bin/hnc": []byte("elf")}
+	m := manifest.New("hnc bundle create", "(devel)", files, manifest.Manifest{}, time.Now())
+	var out bytes.Buffer
+	Write(&out, files, m, time.Now())
+	read, _ := Read(&out)
+	read["bin/hnc"] = []byte("patched")
+	if _, problems, _, err := Verify(read); err != nil || len(problems) != 1 || problems[0].Name != "bin/hnc" {
+		t.Errorf("Verify() of a patched binary = %v, %v", problems, err)
+	}
+	delete(read, manifest.File)
+	if _, _, _, err := Verify(read); err == nil {
+		t.Error("Verify() without a manifest succeeded")
+	}
+}
+
+// A bundle cannot name files outside the directory it is unpacked in
+func TestReadRejectsUnsafeEntries(t *testing.T) {
+	for _, hdr := range []tar.Header{
+		{Name: "../evil", Typeflag: tar.TypeReg},
+		{Name: "/etc/passwd", Typeflag: tar.TypeReg},
+		{Name: "bin/link", Typeflag: tar.TypeSymlink, Linkname: "/etc"},
+	} {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		tw.WriteHeader(&hdr)
+		tw.Close()
+		gz.Close()
+		if _, err := Read(&buf); err == nil || !strings.Contains(err.Error(), hdr.Name) {
+			t.Errorf("Read() of %s = %v", hdr.Name, err)
+		}
+	}
+	if _, err := Read(strings.NewReader("not gzip")); err == nil {
+		t.Error("Read() of a plain file succeeded")
+	}
+}
+
+func mustGunzip(t *testing.T, data []byte) *gzip.Reader {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gz
+}
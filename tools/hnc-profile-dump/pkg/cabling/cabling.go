@@ -0,0 +1,537 @@
+// Package cabling assigns every leaf uplink in a fabric plan to a spine
+// port, and in a multi-pod plan every spine uplink to a super-spine port.
+// The assignment depends only on the plan, the profiles and the seed, so
+// regenerating the map for an unchanged plan reproduces it exactly.
+package cabling
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/addressing"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/layout"
+	"github.com/hnc/profile-dump/pkg/ports"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/provenance"
+)
+
+// Strategies for spreading a leaf's uplinks over the spines
+const (
+	// RoundRobin sends uplink 1 to spine 1, uplink 2 to spine 2 and so on,
+	// so the lanes of a breakout cage land on different spines
+	RoundRobin = "round-robin"
+	// Striped sends each spine a contiguous block of uplinks: with 4
+	// uplinks and 2 spines, uplinks 1-2 go to spine 1
+	Striped = "striped"
+)
+
+// Strategies lists the supported strategies, default first
+var Strategies = []string{RoundRobin, Striped}
+
+// Cable is one leaf-spine link
+type Cable struct {
+	Link      string `json:"link"` // String(), for reading the map at a glance
+	Leaf      string `json:"leaf"`
+	LeafPort  string `json:"leafPort"`
+	Spine     string `json:"spine"`
+	SpinePort string `json:"spinePort"`
+	Length    string `json:"length,omitempty"` // length class estimated from a layout, e.g. 5m
+	Pod       int    `json:"pod,omitempty"`    // of a multi-pod plan, from 1
+}
+
+// String is the cable as installers read it: leaf1:E1/49 <-> spine1:E1/1
+func (c Cable) String() string {
+	return fmt.Sprintf("%s:%s <-> %s:%s", c.Leaf, c.LeafPort, c.Spine, c.SpinePort)
+}
+
+// PeerLink is one MCLAG peer link between the two leaves of a pair
+type PeerLink struct {
+	Link     string `json:"link"` // String()
+	Leaf     string `json:"leaf"`
+	LeafPort string `json:"leafPort"`
+	Peer     string `json:"peer"`
+	PeerPort string `json:"peerPort"`
+	Length   string `json:"length,omitempty"`
+}
+
+// String is the link as installers read it: leaf1:E1/55 <-> leaf2:E1/55
+func (p PeerLink) String() string {
+	return fmt.Sprintf("%s:%s <-> %s:%s", p.Leaf, p.LeafPort, p.Peer, p.PeerPort)
+}
+
+// SpineLink is one uplink from a pod's spine to a super-spine
+type SpineLink struct {
+	Link           string `json:"link"` // String()
+	Pod            int    `json:"pod"`
+	Spine          string `json:"spine"`
+	SpinePort      string `json:"spinePort"`
+	SuperSpine     string `json:"superSpine"`
+	SuperSpinePort string `json:"superSpinePort"`
+}
+
+// String is the link as installers read it: spine1:E1/31 <-> superspine1:E1/1
+func (l SpineLink) String() string {
+	return fmt.Sprintf("%s:%s <-> %s:%s", l.Spine, l.SpinePort, l.SuperSpine, l.SuperSpinePort)
+}
+
+// ExternalLink is one uplink from a border leaf to a router or firewall
+// outside the fabric, whose ports the profiles do not know
+type ExternalLink struct {
+	Link     string `json:"link"` // String()
+	Leaf     string `json:"leaf"`
+	LeafPort string `json:"leafPort"`
+	Peer     string `json:"peer"`
+}
+
+// String is the link as installers read it: leaf4:E1/48 <-> external1
+func (e ExternalLink) String() string {
+	return fmt.Sprintf("%s:%s <-> %s", e.Leaf, e.LeafPort, e.Peer)
+}
+
+// ServerLink is one NIC of an inventoried server cabled to a leaf
+// endpoint port
+type ServerLink struct {
+	Link     string `json:"link"` // String()
+	Server   string `json:"server"`
+	NIC      string `json:"nic"`
+	Leaf     string `json:"leaf"`
+	LeafPort string `json:"leafPort"`
+}
+
+// String is the link as installers read it: leaf1:E1/3 <-> web01:eth0
+func (s ServerLink) String() string {
+	return fmt.Sprintf("%s:%s <-> %s:%s", s.Leaf, s.LeafPort, s.Server, s.NIC)
+}
+
+// Map is the cabling for one plan, written as cabling.json
+type Map struct {
+	Strategy string `json:"strategy"`
+	// Seed, when not 0, shuffles which spine each leaf's uplinks go to
+	// and, in a multi-pod map, which super-spine each spine's do
+	Seed       int64      `json:"seed,omitempty"`
+	LeafModel  string     `json:"leafModel"`
+	SpineModel string     `json:"spineModel"`
+	Cables     []Cable    `json:"cables"`
+	PeerLinks  []PeerLink `json:"peerLinks,omitempty"`
+	// A multi-pod map's cables carry their pod, and SpineLinks join the
+	// pods' spines to the super-spines
+	SuperSpineModel string      `json:"superSpineModel,omitempty"`
+	SpineLinks      []SpineLink `json:"spineLinks,omitempty"`
+	// ExternalLinks uplink the border leaves out of the fabric
+	ExternalLinks []ExternalLink `json:"externalLinks,omitempty"`
+	// ServerLinks cable the servers of an inventory, when one is given
+	ServerLinks []ServerLink `json:"serverLinks,omitempty"`
+	// Addressing numbers the cables and switches, when asked for
+	Addressing *addressing.Plan `json:"addressing,omitempty"`
+	// Hostnames are the site names a naming policy gave the switches,
+	// keyed by the names hnc gave them, which the map keeps using
+	Hostnames map[string]string `json:"hostnames,omitempty"`
+	// Generator wrote the map, when it was written by hnc
+	Generator *provenance.Generator `json:"generator,omitempty"`
+}
+
+// Measure estimates the length class of every cable and peer link from
+// the racks a layout puts their ends in
+func (m *Map) Measure(l layout.Layout) error {
+	for i, c := range m.Cables {
+		length, err := l.Cable(c.Leaf, c.Spine)
+		if err != nil {
+			return err
+		}
+		m.Cables[i].Length = length
+	}
+	for i, p := range m.PeerLinks {
+		length, err := l.Cable(p.Leaf, p.Peer)
+		if err != nil {
+			return err
+		}
+		m.PeerLinks[i].Length = length
+	}
+	return nil
+}
+
+// Lengths counts the map's cables and peer links by length class
+func (m Map) Lengths() map[string]int {
+	counts := map[string]int{}
+	for _, c := range m.Cables {
+		if c.Length != "" {
+			counts[c.Length]++
+		}
+	}
+	for _, p := range m.PeerLinks {
+		if p.Length != "" {
+			counts[p.Length]++
+		}
+	}
+	return counts
+}
+
+// Address numbers the map's cables, in map order, and the plan's switches
+func (m *Map) Address(plan fabricplan.Plan, opts addressing.Options) error {
+	if plan.Pods > 1 {
+		return fmt.Errorf("addressing numbers two-tier fabrics; the plan has %d pods and super-spines", plan.Pods)
+	}
+	a, err := addressing.Assign(plan.Spines, plan.Leaves, m.links(), opts)
+	if err != nil {
+		return err
+	}
+	m.Addressing = &a
+	return nil
+}
+
+// links lists the map's cables for numbering
+func (m Map) links() []addressing.Link {
+	links := make([]addressing.Link, len(m.Cables))
+	for i, c := range m.Cables {
+		links[i] = addressing.Link{Name: c.Link, Leaf: c.Leaf, Spine: c.Spine}
+	}
+	return links
+}
+
+// Assign cables the plan leaf by leaf. Each leaf uses its first
+// UplinksPerLeaf fabric ports in profile order; each spine gives leaf N
+// the Nth block of its fabric ports, so spine ports follow leaf order.
+// With a breakout, ports are the lanes the profiles split them into. MCLAG
+// plans also cable leaf 2N-1 to leaf 2N over the last PeerLinksPerLeaf
+// fabric ports of each, unsplit. Border leaves uplink their last
+// ExternalPortsPerLeaf endpoint ports to external1, external2 and so on
+// in turn. A plan without spines, such as a
+// collapsed core, has only its peer links. Multi-pod plans are cabled
+// with AssignPods. A nonzero seed keeps each spine's share of every leaf's
+// uplinks and shuffles, leaf by leaf, which spine takes which share.
+func Assign(plan fabricplan.Plan, leaf, spine profiles.SwitchProfile, strategy string, seed int64) (Map, error) {
+	if plan.Pods > 1 {
+		return Map{}, fmt.Errorf("the plan has %d pods, whose spines are cabled to super-spine %s too", plan.Pods, plan.SuperSpineModel)
+	}
+	if strategy == "" {
+		strategy = RoundRobin
+	}
+	return Extend(Map{Strategy: strategy, Seed: seed, LeafModel: plan.LeafModel, SpineModel: plan.SpineModel}, plan, leaf, spine)
+}
+
+// AssignPods cables a multi-pod plan: each pod's leaves to its own
+// spines as Assign cables a fabric, pod after pod, then every spine's
+// last SpineUplinks fabric ports to the super-spines, spread over them
+// with the same strategy. Each super-spine gives spine N the Nth block
+// of its fabric ports, so super-spine ports follow spine order. A nonzero
+// seed shuffles the super-spines spine by spine as Assign does the spines.
+func AssignPods(plan fabricplan.Plan, leaf, spine, superSpine profiles.SwitchProfile, strategy string, seed int64) (Map, error) {
+	if plan.Pods < 2 {
+		return Map{}, fmt.Errorf("the plan has no pods; cable it with Assign")
+	}
+	if superSpine.ModelID != plan.SuperSpineModel {
+		return Map{}, fmt.Errorf("plan is for super-spine %s, not %s", plan.SuperSpineModel, superSpine.ModelID)
+	}
+	if plan.SuperSpines <= 0 || plan.SpineUplinks%plan.SuperSpines != 0 {
+		return Map{}, fmt.Errorf("%d uplinks per spine do not split evenly over %d super-spines", plan.SpineUplinks, plan.SuperSpines)
+	}
+	if strategy == "" {
+		strategy = RoundRobin
+	}
+	m, err := Extend(Map{Strategy: strategy, Seed: seed, LeafModel: plan.LeafModel, SpineModel: plan.SpineModel, SuperSpineModel: plan.SuperSpineModel}, plan, leaf, spine)
+	if err != nil {
+		return Map{}, err
+	}
+	spinePorts, err := fabricPorts(spine, "")
+	if err != nil {
+		return Map{}, err
+	}
+	superPorts, err := fabricPorts(superSpine, "")
+	if err != nil {
+		return Map{}, err
+	}
+	perSuper := plan.SpineUplinks / plan.SuperSpines
+	if len(superPorts) < plan.Spines*perSuper {
+		return Map{}, fmt.Errorf("super-spine %s has %d fabric ports, the plan needs %d", superSpine.ModelID, len(superPorts), plan.Spines*perSuper)
+	}
+	uplinks := spinePorts[len(spinePorts)-plan.SpineUplinks:]
+	_, spinesPerPod := plan.PodSize()
+	for sp := 0; sp < plan.Spines; sp++ {
+		order := provenance.Perm(seed, "spine"+strconv.Itoa(sp+1), plan.SuperSpines)
+		for u, port := range uplinks {
+			s, slot := u%plan.SuperSpines, u/plan.SuperSpines
+			if strategy == Striped {
+				s, slot = u/perSuper, u%perSuper
+			}
+			s = order[s]
+			l := SpineLink{
+				Pod:            sp/spinesPerPod + 1,
+				Spine:          "spine" + strconv.Itoa(sp+1),
+				SpinePort:      port,
+				SuperSpine:     "superspine" + strconv.Itoa(s+1),
+				SuperSpinePort: superPorts[sp*perSuper+slot],
+			}
+			l.Link = l.String()
+			m.SpineLinks = append(m.SpineLinks, l)
+		}
+	}
+	return m, nil
+}
+
+// Extend cables the leaves a grown plan adds to an existing map, which
+// keeps every cable, peer link and address it has. New leaves are cabled
+// as Assign cables them, each taking the lowest spine ports no cable
+// uses, so extending the map Assign wrote for a plan gives the map it
+// writes for the grown one, seed and all. Spines and leaves keep the
+// map's names; new leaves are numbered on from the existing ones. An
+// imported map's new leaves are cabled round-robin.
+func Extend(existing Map, plan fabricplan.Plan, leaf, spine profiles.SwitchProfile) (Map, error) {
+	if leaf.ModelID != plan.LeafModel || spine.ModelID != plan.SpineModel {
+		return Map{}, fmt.Errorf("plan is for leaf %s and spine %s, not %s and %s",
+			plan.LeafModel, plan.SpineModel, leaf.ModelID, spine.ModelID)
+	}
+	if existing.LeafModel != plan.LeafModel || existing.SpineModel != plan.SpineModel {
+		return Map{}, fmt.Errorf("cabling map is for leaf %s and spine %s, the plan for %s and %s",
+			existing.LeafModel, existing.SpineModel, plan.LeafModel, plan.SpineModel)
+	}
+	if existing.SuperSpineModel != plan.SuperSpineModel {
+		return Map{}, fmt.Errorf("cabling map is for super-spine %q, the plan for %q", existing.SuperSpineModel, plan.SuperSpineModel)
+	}
+	strategy := existing.Strategy
+	if strategy != RoundRobin && strategy != Striped {
+		if len(existing.Cables) == 0 {
+			return Map{}, fmt.Errorf("unknown strategy %q (want %s)", strategy, strings.Join(Strategies, " or "))
+		}
+		strategy = RoundRobin
+	}
+	if _, spines := plan.PodSize(); plan.UplinksPerLeaf > 0 && (spines <= 0 || plan.UplinksPerLeaf%spines != 0) {
+		return Map{}, fmt.Errorf("%d uplinks per leaf do not split evenly over %d spines", plan.UplinksPerLeaf, spines)
+	}
+	if plan.UplinksPerLeaf == 0 && plan.Spines > 0 {
+		return Map{}, fmt.Errorf("the plan has %d spines but no uplinks", plan.Spines)
+	}
+
+	leafPorts, err := fabricPorts(leaf, plan.Request.Breakout)
+	if err != nil {
+		return Map{}, err
+	}
+	spinePorts, err := fabricPorts(spine, plan.Request.Breakout)
+	if err != nil {
+		return Map{}, err
+	}
+	if plan.SpineUplinks > 0 {
+		// Spine uplinks to the super-spines take the last ports
+		if plan.SpineUplinks >= len(spinePorts) {
+			return Map{}, fmt.Errorf("spine %s has %d fabric ports, too few for %d super-spine uplinks and leaf uplinks", spine.ModelID, len(spinePorts), plan.SpineUplinks)
+		}
+		spinePorts = spinePorts[:len(spinePorts)-plan.SpineUplinks]
+	}
+	leavesPerPod, spinesPerPod := plan.PodSize()
+	if plan.Pods > 1 && (leavesPerPod*plan.Pods != plan.Leaves || spinesPerPod*plan.Pods != plan.Spines) {
+		return Map{}, fmt.Errorf("%d leaves and %d spines do not split evenly over %d pods", plan.Leaves, plan.Spines, plan.Pods)
+	}
+	var peerPorts []string
+	if plan.PeerLinksPerLeaf > 0 {
+		cages, err := fabricPorts(leaf, "")
+		if err != nil {
+			return Map{}, err
+		}
+		if plan.PeerLinksPerLeaf >= len(cages) {
+			return Map{}, fmt.Errorf("leaf %s has %d fabric ports, too few for %d peer links and uplinks", leaf.ModelID, len(cages), plan.PeerLinksPerLeaf)
+		}
+		// Peer links take whole cages from the end, and with them their lanes
+		leafPorts = leafPorts[:len(leafPorts)-plan.PeerLinksPerLeaf*len(leafPorts)/len(cages)]
+		peerPorts = cages[len(cages)-plan.PeerLinksPerLeaf:]
+	}
+	perSpine := 0
+	if spinesPerPod > 0 {
+		perSpine = plan.UplinksPerLeaf / spinesPerPod
+	}
+	if len(leafPorts) < plan.UplinksPerLeaf {
+		return Map{}, fmt.Errorf("leaf %s has %d fabric ports, the plan needs %d", leaf.ModelID, len(leafPorts), plan.UplinksPerLeaf)
+	}
+
+	// The map's switches, in the order it first names them
+	var leaves, spines []string
+	named := map[string]bool{}
+	used := map[string]map[string]bool{}
+	for _, c := range existing.Cables {
+		if !named[c.Leaf] {
+			named[c.Leaf] = true
+			leaves = append(leaves, c.Leaf)
+		}
+		if !named[c.Spine] {
+			named[c.Spine] = true
+			spines = append(spines, c.Spine)
+			used[c.Spine] = map[string]bool{}
+		}
+		used[c.Spine][c.SpinePort] = true
+	}
+	if existing.Seed != 0 {
+		// A seeded map can name spine2 before spine1; its spines are hnc's,
+		// numbered in plan order
+		sort.SliceStable(spines, func(i, j int) bool { return switchNumber(spines[i]) < switchNumber(spines[j]) })
+	}
+	if len(leaves) > plan.Leaves || len(spines) > plan.Spines {
+		return Map{}, fmt.Errorf("cabling map has %d leaves and %d spines, more than the plan's %d and %d",
+			len(leaves), len(spines), plan.Leaves, plan.Spines)
+	}
+	newLeaves := plan.Leaves - len(leaves)
+	if plan.LeafPairs > 0 && len(leaves)%2 != 0 {
+		return Map{}, fmt.Errorf("cabling map has %d leaves, which do not pair", len(leaves))
+	}
+	// New switches are numbered on, past any name the map already uses
+	number := func(list []string, role string, want int) []string {
+		for n := 1; len(list) < want; n++ {
+			if name := role + strconv.Itoa(n); !named[name] {
+				named[name] = true
+				list = append(list, name)
+			}
+		}
+		return list
+	}
+	leaves = number(leaves, "leaf", plan.Leaves)
+	spines = number(spines, "spine", plan.Spines)
+	// A leaf is cabled to the spines of its pod: all of them without pods
+	first := plan.Leaves - newLeaves
+	podSpine := func(leafIndex, s int) int {
+		if plan.Pods > 1 {
+			return leafIndex/leavesPerPod*spinesPerPod + s
+		}
+		return s
+	}
+	need := make([]int, plan.Spines)
+	for l := first; l < plan.Leaves; l++ {
+		for s := 0; s < spinesPerPod; s++ {
+			need[podSpine(l, s)] += perSpine
+		}
+	}
+	free := make([][]string, plan.Spines)
+	for s, sp := range spines {
+		for _, port := range spinePorts {
+			if !used[sp][port] {
+				free[s] = append(free[s], port)
+			}
+		}
+		if len(free[s]) < need[s] {
+			return Map{}, fmt.Errorf("spine %s has %d free fabric ports, the plan needs %d", spine.ModelID, len(free[s]), need[s])
+		}
+	}
+
+	m := existing
+	m.Cables = append([]Cable{}, existing.Cables...)
+	m.PeerLinks = append([]PeerLink(nil), existing.PeerLinks...)
+	m.ExternalLinks = append([]ExternalLink(nil), existing.ExternalLinks...)
+	next := make([]int, plan.Spines)
+	for l := first; l < plan.Leaves; l++ {
+		order := provenance.Perm(existing.Seed, leaves[l], spinesPerPod)
+		for u := 0; u < plan.UplinksPerLeaf; u++ {
+			s := u % spinesPerPod
+			if strategy == Striped {
+				s = u / perSpine
+			}
+			s = podSpine(l, order[s])
+			c := Cable{
+				Leaf:      leaves[l],
+				LeafPort:  leafPorts[u],
+				Spine:     spines[s],
+				SpinePort: free[s][next[s]],
+			}
+			if plan.Pods > 1 {
+				c.Pod = l/leavesPerPod + 1
+			}
+			next[s]++
+			c.Link = c.String()
+			m.Cables = append(m.Cables, c)
+		}
+	}
+	for pair := first / 2; len(peerPorts) > 0 && pair < plan.LeafPairs; pair++ {
+		for _, port := range peerPorts {
+			p := PeerLink{Leaf: leaves[2*pair], LeafPort: port, Peer: leaves[2*pair+1], PeerPort: port}
+			p.Link = p.String()
+			m.PeerLinks = append(m.PeerLinks, p)
+		}
+	}
+	if plan.ExternalPortsPerLeaf > 0 {
+		if plan.ExternalPeers <= 0 {
+			return Map{}, fmt.Errorf("the plan's %d external uplinks have no peers", plan.ExternalPorts())
+		}
+		names, err := ports.Expand(leaf.Ports.EndpointAssignable)
+		if err != nil {
+			return Map{}, fmt.Errorf("%s: %w", leaf.ModelID, err)
+		}
+		if plan.ExternalPortsPerLeaf > len(names) {
+			return Map{}, fmt.Errorf("leaf %s has %d endpoint ports, too few for %d external uplinks", leaf.ModelID, len(names), plan.ExternalPortsPerLeaf)
+		}
+		external := names[len(names)-plan.ExternalPortsPerLeaf:]
+		for l := max(first, plan.Leaves-plan.BorderLeaves); l < plan.Leaves; l++ {
+			for u, port := range external {
+				k := (l-plan.Leaves+plan.BorderLeaves)*len(external) + u
+				e := ExternalLink{Leaf: leaves[l], LeafPort: port, Peer: "external" + strconv.Itoa(k%plan.ExternalPeers+1)}
+				e.Link = e.String()
+				m.ExternalLinks = append(m.ExternalLinks, e)
+			}
+		}
+	}
+	if existing.Addressing != nil {
+		a, err := addressing.Extend(*existing.Addressing, plan.Spines, plan.Leaves, m.links())
+		if err != nil {
+			return Map{}, err
+		}
+		m.Addressing = &a
+	}
+	return m, nil
+}
+
+// fabricPorts names a switch's fabric ports in profile order, split into
+// lanes when the plan uses a breakout
+func fabricPorts(p profiles.SwitchProfile, breakout string) ([]string, error) {
+	names, err := ports.Expand(p.Ports.FabricAssignable)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.ModelID, err)
+	}
+	if breakout == "" {
+		return names, nil
+	}
+	split, ok := p.Profiles.Uplink.Breakout(breakout)
+	if !ok {
+		return nil, fmt.Errorf("%s fabric ports do not support breakout %s", p.ModelID, breakout)
+	}
+	var lanes []string
+	for _, name := range names {
+		lanes = append(lanes, split.PortNames(name)...)
+	}
+	return lanes, nil
+}
+
+// CSVHeader is the first row of RenderCSV
+var CSVHeader = []string{"cable", "leaf", "leaf_port", "spine", "spine_port", "link"}
+
+// RenderCSV writes one row per cable, numbered from 1 in map order, then
+// one per peer link with the peer leaf in the spine columns, then one per
+// spine link with the spine in the leaf columns and the super-spine in
+// the spine columns, then one per external link with its peer in the
+// spine column, then one per server link with the server and its NIC in
+// the spine columns
+func RenderCSV(m Map) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(CSVHeader)
+	for i, c := range m.Cables {
+		w.Write([]string{strconv.Itoa(i + 1), c.Leaf, c.LeafPort, c.Spine, c.SpinePort, c.Link})
+	}
+	for i, p := range m.PeerLinks {
+		w.Write([]string{strconv.Itoa(len(m.Cables) + i + 1), p.Leaf, p.LeafPort, p.Peer, p.PeerPort, p.Link})
+	}
+	for i, l := range m.SpineLinks {
+		w.Write([]string{strconv.Itoa(len(m.Cables) + len(m.PeerLinks) + i + 1), l.Spine, l.SpinePort, l.SuperSpine, l.SuperSpinePort, l.Link})
+	}
+	for i, e := range m.ExternalLinks {
+		w.Write([]string{strconv.Itoa(len(m.Cables) + len(m.PeerLinks) + len(m.SpineLinks) + i + 1), e.Leaf, e.LeafPort, e.Peer, "", e.Link})
+	}
+	for i, l := range m.ServerLinks {
+		w.Write([]string{strconv.Itoa(len(m.Cables) + len(m.PeerLinks) + len(m.SpineLinks) + len(m.ExternalLinks) + i + 1), l.Leaf, l.LeafPort, l.Server, l.NIC, l.Link})
+	}
+	w.Flush()
+	return b.String()
+}
+
+// switchNumber is the number hnc named a switch with, 2 for spine2
+func switchNumber(name string) int {
+	n, _ := strconv.Atoi(name[len(strings.TrimRight(name, "0123456789")):])
+	return n
+}
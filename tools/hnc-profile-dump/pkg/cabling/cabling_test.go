@@ -0,0 +1,326 @@
+package cabling
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/addressing"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/layout"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func plan(t *testing.T, req fabricplan.Request) fabricplan.Plan {
+	t.Helper()
+	p, err := fabricplan.Compute(req, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func links(m Map) []string {
+	var out []string
+	for _, c := range m.Cables {
+		out = append(out, c.String())
+	}
+	return out
+}
+
+func TestAssignStrategies(t *testing.T) {
+	// 2 leaves x 4 uplinks over 2 spines: 2 ports per spine per leaf
+	p := plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3})
+	rr, err := Assign(p, profiles.DS2000(), profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"leaf1:E1/49 <-> spine1:E1/1", "leaf1:E1/50 <-> spine2:E1/1", "leaf1:E1/51 <-> spine1:E1/2", "leaf1:E1/52 <-> spine2:E1/2",
+		"leaf2:E1/49 <-> spine1:E1/3", "leaf2:E1/50 <-> spine2:E1/3", "leaf2:E1/51 <-> spine1:E1/4", "leaf2:E1/52 <-> spine2:E1/4",
+	}
+	if rr.Strategy != RoundRobin || !reflect.DeepEqual(links(rr), want) {
+		t.Fatalf("round-robin = %q\nwant          %q", links(rr), want)
+	}
+
+	striped, err := Assign(p, profiles.DS2000(), profiles.DS3000(), Striped, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"leaf1:E1/49 <-> spine1:E1/1", "leaf1:E1/50 <-> spine1:E1/2", "leaf1:E1/51 <-> spine2:E1/1", "leaf1:E1/52 <-> spine2:E1/2",
+		"leaf2:E1/49 <-> spine1:E1/3", "leaf2:E1/50 <-> spine1:E1/4", "leaf2:E1/51 <-> spine2:E1/3", "leaf2:E1/52 <-> spine2:E1/4",
+	}
+	if !reflect.DeepEqual(links(striped), want) {
+		t.Fatalf("striped = %q\nwant      %q", links(striped), want)
+	}
+
+	again, _ := Assign(p, profiles.DS2000(), profiles.DS3000(), RoundRobin, 0)
+	if !reflect.DeepEqual(again, rr) {
+		t.Fatal("re-running the assignment changed the map")
+	}
+}
+
+func TestAssignSeed(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 480, Oversubscription: 3})
+	canonical, _ := Assign(p, profiles.DS2000(), profiles.DS3000(), RoundRobin, 0)
+	m, err := Assign(p, profiles.DS2000(), profiles.DS3000(), RoundRobin, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := Assign(p, profiles.DS2000(), profiles.DS3000(), RoundRobin, 7)
+	if !reflect.DeepEqual(again, m) {
+		t.Fatal("re-running the assignment with the same seed changed the map")
+	}
+	if m.Seed != 7 || reflect.DeepEqual(links(m), links(canonical)) {
+		t.Fatalf("seed 7 = %q, as unseeded", links(m))
+	}
+	// Every leaf still sends each spine its share, on the spine ports
+	// the unseeded map gives it
+	perLeafSpine := map[string]int{}
+	spinePorts := map[string][]string{}
+	for _, c := range m.Cables {
+		perLeafSpine[c.Leaf+" "+c.Spine]++
+		spinePorts[c.Spine] = append(spinePorts[c.Spine], c.SpinePort)
+	}
+	for key, n := range perLeafSpine {
+		if n != p.UplinksPerLeaf/p.Spines {
+			t.Errorf("%s has %d cables, want %d", key, n, p.UplinksPerLeaf/p.Spines)
+		}
+	}
+	for _, c := range canonical.Cables {
+		if ports := spinePorts[c.Spine]; len(ports) == 0 || ports[0] != c.SpinePort {
+			t.Fatalf("%s ports = %q, not in leaf order", c.Spine, spinePorts[c.Spine])
+		}
+		spinePorts[c.Spine] = spinePorts[c.Spine][1:]
+	}
+}
+
+func TestAssignBreakoutLanes(t *testing.T) {
+	// 16 x 25G uplinks per leaf over 8 spines: each cage's lanes fan out to 4 spines
+	p := plan(t, fabricplan.Request{Endpoints: 40 * 48, Oversubscription: 3, Breakout: "4x25G"})
+	m, err := Assign(p, profiles.DS2000(), profiles.DS3000(), RoundRobin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Cables) != 40*16 {
+		t.Fatalf("%d cables, want %d", len(m.Cables), 40*16)
+	}
+	got := links(m)[:5]
+	want := []string{
+		"leaf1:E1/49/1 <-> spine1:E1/1/1", "leaf1:E1/49/2 <-> spine2:E1/1/1", "leaf1:E1/49/3 <-> spine3:E1/1/1",
+		"leaf1:E1/49/4 <-> spine4:E1/1/1", "leaf1:E1/50/1 <-> spine5:E1/1/1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("first cables = %q\nwant           %q", got, want)
+	}
+	if last := m.Cables[len(m.Cables)-1].String(); last != "leaf40:E1/52/4 <-> spine8:E1/20/4" {
+		t.Fatalf("last cable = %s", last)
+	}
+}
+
+func TestAssignRejectsBadInput(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3})
+	if _, err := Assign(p, profiles.DS2000(), profiles.DS3000(), "random", 0); err == nil || !strings.Contains(err.Error(), `unknown strategy "random"`) {
+		t.Fatalf("error = %v, want unknown strategy", err)
+	}
+	if _, err := Assign(p, profiles.DS3000(), profiles.DS3000(), "", 0); err == nil || !strings.Contains(err.Error(), "plan is for leaf celestica-ds2000") {
+		t.Fatalf("error = %v, want a model mismatch", err)
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	c := Cable{Leaf: "leaf1", LeafPort: "E1/49", Spine: "spine1", SpinePort: "E1/1"}
+	c.Link = c.String()
+	want := "cable,leaf,leaf_port,spine,spine_port,link\n1,leaf1,E1/49,spine1,E1/1,leaf1:E1/49 <-> spine1:E1/1\n"
+	if got := RenderCSV(Map{Cables: []Cable{c}}); got != want {
+		t.Fatalf("RenderCSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAssignPeerLinks(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3, Redundancy: fabricplan.MCLAG})
+	m, err := Assign(p, profiles.DS2000(), profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var peers []string
+	for _, l := range m.PeerLinks {
+		peers = append(peers, l.Link)
+	}
+	want := []string{
+		"leaf1:E1/55 <-> leaf2:E1/55", "leaf1:E1/56 <-> leaf2:E1/56",
+		"leaf3:E1/55 <-> leaf4:E1/55", "leaf3:E1/56 <-> leaf4:E1/56",
+	}
+	if !reflect.DeepEqual(peers, want) {
+		t.Fatalf("peer links = %q, want %q", peers, want)
+	}
+	if len(m.Cables) != p.Leaves*p.UplinksPerLeaf || !strings.HasSuffix(RenderCSV(m), "\n20,leaf3,E1/56,leaf4,E1/56,leaf3:E1/56 <-> leaf4:E1/56\n") {
+		t.Errorf("cables = %d, CSV:\n%s", len(m.Cables), RenderCSV(m))
+	}
+
+	// With a breakout the peer cages' lanes are not used as uplinks
+	p = plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3, Redundancy: fabricplan.MCLAG, Breakout: "4x25G"})
+	m, err = Assign(p, profiles.DS2000(), profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range m.Cables {
+		if strings.HasPrefix(c.LeafPort, "E1/55/") || strings.HasPrefix(c.LeafPort, "E1/56/") {
+			t.Fatalf("uplink %s uses a peer link cage", c.Link)
+		}
+	}
+}
+
+// Extending the map of a plan gives the map of the grown plan, addresses
+// and all, for either strategy and with peer links
+func TestExtendMatchesAssign(t *testing.T) {
+	leaf, spine := profiles.DS2000(), profiles.DS3000()
+	for _, tc := range []struct {
+		req      fabricplan.Request
+		strategy string
+		seed     int64
+	}{
+		{fabricplan.Request{Endpoints: 96, Oversubscription: 3}, RoundRobin, 0},
+		{fabricplan.Request{Endpoints: 96, Oversubscription: 3}, Striped, 0},
+		{fabricplan.Request{Endpoints: 96, Oversubscription: 3}, Striped, 7},
+		{fabricplan.Request{Endpoints: 40, Oversubscription: 3, Redundancy: fabricplan.MCLAG}, RoundRobin, 0},
+	} {
+		p := plan(t, tc.req)
+		grown, err := fabricplan.Expand(p, 3*tc.req.Endpoints, leaf, spine)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, _ := Assign(p, leaf, spine, tc.strategy, tc.seed)
+		want, _ := Assign(grown, leaf, spine, tc.strategy, tc.seed)
+		if err := m.Address(p, addressing.Options{}); err != nil {
+			t.Fatal(err)
+		}
+		want.Address(grown, addressing.Options{})
+		got, err := Extend(m, grown, leaf, spine)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%+v %s: Extend =\n%q\nwant\n%q", tc.req, tc.strategy, links(got), links(want))
+		}
+	}
+}
+
+func TestExtendImportedMap(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 48, Oversubscription: 3})
+	m := Map{Strategy: "imported", LeafModel: p.LeafModel, SpineModel: p.SpineModel, Cables: []Cable{
+		{Leaf: "leaf-01", LeafPort: "E1/49", Spine: "spine-01", SpinePort: "E1/7"},
+		{Leaf: "leaf-01", LeafPort: "E1/50", Spine: "spine-02", SpinePort: "E1/1"},
+	}}
+	p.UplinksPerLeaf = 2
+	p.UplinkGbpsPerLeaf = 200
+	grown, err := fabricplan.Expand(p, 96, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Extend(m, grown, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"leaf-01:E1/49 <-> spine-01:E1/7", "leaf-01:E1/50 <-> spine-02:E1/1",
+		"leaf1:E1/49 <-> spine-01:E1/1", "leaf1:E1/50 <-> spine-02:E1/2",
+	}
+	if got.Strategy != "imported" || !reflect.DeepEqual(links(got), want) {
+		t.Errorf("extended imported map = %q\nwant %q", links(got), want)
+	}
+	m.SpineModel = "celestica-ds4000"
+	if _, err := Extend(m, grown, profiles.DS2000(), profiles.DS3000()); err == nil || !strings.Contains(err.Error(), "cabling map is for") {
+		t.Errorf("Extend of a map for another spine = %v", err)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3, Redundancy: fabricplan.MCLAG})
+	m, err := Assign(p, profiles.DS2000(), profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := layout.Plan(p, profiles.DS2000(), profiles.DS3000(), layout.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Measure(l); err != nil {
+		t.Fatal(err)
+	}
+	// spines in rack 1, leaf pairs in racks 2 and 3 (3.6m and 4.2m runs);
+	// peer links stay in the rack
+	if got, want := m.Lengths(), map[string]int{"3m": 4, "5m": 16}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lengths = %v, want %v", got, want)
+	}
+	if m.Cables[0].Length != "5m" || m.PeerLinks[0].Length != "3m" {
+		t.Errorf("first cable %s, first peer link %s", m.Cables[0].Length, m.PeerLinks[0].Length)
+	}
+	if err := m.Measure(layout.Layout{}); err == nil || !strings.Contains(err.Error(), "is not in the layout") {
+		t.Errorf("Measure(empty layout) = %v", err)
+	}
+}
+
+func TestAssignCollapsedCore(t *testing.T) {
+	plan, err := fabricplan.Compute(fabricplan.Request{Endpoints: 40, Topology: fabricplan.CollapsedCore}, profiles.DS2000(), profiles.SwitchProfile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Assign(plan, profiles.DS2000(), profiles.SwitchProfile{}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Cables) != 0 || m.Cables == nil || len(m.PeerLinks) != 2 || m.PeerLinks[0].Link != "leaf1:E1/55 <-> leaf2:E1/55" {
+		t.Fatalf("Assign() = %+v", m)
+	}
+}
+
+func TestAssignPods(t *testing.T) {
+	plan, err := fabricplan.ComputePods(fabricplan.Request{Endpoints: 2000, Oversubscription: 3, Pods: 4}, profiles.DS2000(), profiles.DS3000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := AssignPods(plan, profiles.DS2000(), profiles.DS3000(), profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Cables) != plan.Leaves*plan.UplinksPerLeaf || len(m.SpineLinks) != plan.Spines*plan.SpineUplinks || m.SuperSpineModel != plan.SuperSpineModel {
+		t.Fatalf("AssignPods() has %d cables and %d spine links", len(m.Cables), len(m.SpineLinks))
+	}
+	// leaf12 opens pod 2, cabled to its own spines
+	for _, c := range m.Cables {
+		if c.Leaf == "leaf12" && (c.Pod != 2 || (c.Spine != "spine3" && c.Spine != "spine4")) {
+			t.Errorf("leaf12 cable %s in pod %d", c.Link, c.Pod)
+		}
+	}
+	first, last := m.SpineLinks[0], m.SpineLinks[len(m.SpineLinks)-1]
+	if first.Link != "spine1:E1/25 <-> superspine1:E1/1" || last.Link != "spine8:E1/32 <-> superspine2:E1/32" || last.Pod != 4 {
+		t.Errorf("spine links run %+v to %+v", first, last)
+	}
+	if _, err := Assign(plan, profiles.DS2000(), profiles.DS3000(), "", 0); err == nil {
+		t.Error("Assign(pods) succeeded")
+	}
+}
+
+func TestAssignExternalLinks(t *testing.T) {
+	plan, err := fabricplan.Compute(fabricplan.Request{Endpoints: 96, Oversubscription: 3, External: &fabricplan.External{PortsPerLeaf: 2, Peers: 3}}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Assign(plan, profiles.DS2000(), profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range m.ExternalLinks {
+		got = append(got, e.Link)
+	}
+	want := []string{"leaf2:E1/47 <-> external1", "leaf2:E1/48 <-> external2", "leaf3:E1/47 <-> external3", "leaf3:E1/48 <-> external1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("external links = %v, want %v", got, want)
+	}
+	if csv := RenderCSV(m); !strings.Contains(csv, ",leaf3,E1/48,external1,,leaf3:E1/48 <-> external1\n") {
+		t.Errorf("RenderCSV() =\n%s", csv)
+	}
+}
@@ -0,0 +1,160 @@
+package cabling
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/hnc/profile-dump/pkg/addressing"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/ports"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// An allocation policy revises one decision hnc made in a map: where the
+// servers are placed, what the switches are called at the site, or how
+// the underlay is numbered. The policy sees the whole map but may change
+// only its own part, and hnc checks the change before taking it, so a
+// policy can choose differently but cannot break the map.
+
+// hostnamePattern is what a site hostname may be
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]{0,61}[A-Za-z0-9])?$`)
+
+// Place takes a placement policy's server links in place of the map's.
+// They must cable the same server NICs, each to an endpoint port, or a
+// breakout lane of one, of a leaf of the plan; no port may be taken
+// twice, by a lane of another breakout or by an external uplink.
+func (m *Map) Place(links []ServerLink, plan fabricplan.Plan, leaf profiles.SwitchProfile) error {
+	cages, err := laneCages(leaf)
+	if err != nil {
+		return err
+	}
+	want := map[string]int{}
+	for _, l := range m.ServerLinks {
+		want[l.Server+":"+l.NIC]++
+	}
+	taken := map[string]string{} // leaf:port -> link
+	modes := map[string]string{} // leaf:cage -> breakout mode taken in it
+	take := func(leafName, port, link string) error {
+		n := switchNumber(leafName)
+		if leafName != "leaf"+strconv.Itoa(n) || n < 1 || n > plan.Leaves {
+			return fmt.Errorf("%s: %s is not a leaf of the plan", link, leafName)
+		}
+		c, ok := cages[port]
+		if !ok {
+			return fmt.Errorf("%s: %s is not an endpoint port of %s", link, port, leaf.ModelID)
+		}
+		if other, ok := taken[leafName+":"+port]; ok {
+			return fmt.Errorf("%s: %s:%s is taken by %s", link, leafName, port, other)
+		}
+		if mode, ok := modes[leafName+":"+c.port]; ok && mode != c.mode {
+			return fmt.Errorf("%s: %s:%s is split differently from the rest of %s", link, leafName, port, c.port)
+		}
+		taken[leafName+":"+port], modes[leafName+":"+c.port] = link, c.mode
+		return nil
+	}
+	for _, e := range m.ExternalLinks {
+		if err := take(e.Leaf, e.LeafPort, e.Link); err != nil {
+			return err
+		}
+	}
+	placed := make([]ServerLink, len(links))
+	for i, l := range links {
+		l.Link = l.String()
+		if want[l.Server+":"+l.NIC] == 0 {
+			return fmt.Errorf("%s: %s:%s is not a server NIC of the map, or is cabled twice", l.Link, l.Server, l.NIC)
+		}
+		want[l.Server+":"+l.NIC]--
+		if err := take(l.Leaf, l.LeafPort, l.Link); err != nil {
+			return err
+		}
+		placed[i] = l
+	}
+	var missing []string
+	for nic, n := range want {
+		if n > 0 {
+			missing = append(missing, nic)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%s is left uncabled", missing[0])
+	}
+	m.ServerLinks = placed
+	return nil
+}
+
+// cage is the endpoint port a port name belongs to, and the breakout
+// mode that names it, "" for the port unsplit
+type cage struct{ port, mode string }
+
+// laneCages maps every name an endpoint port of leaf goes by, unsplit or
+// as a breakout lane, to its cage
+func laneCages(leaf profiles.SwitchProfile) (map[string]cage, error) {
+	names, err := ports.Expand(leaf.Ports.EndpointAssignable)
+	if err != nil {
+		return nil, fmt.Errorf("leaf %s endpoint ports: %w", leaf.ModelID, err)
+	}
+	cages := map[string]cage{}
+	for _, port := range names {
+		cages[port] = cage{port: port}
+		for _, b := range leaf.Profiles.Endpoint.Breakouts {
+			for _, lane := range b.PortNames(port) {
+				if _, ok := cages[lane]; !ok {
+					cages[lane] = cage{port: port, mode: b.Mode}
+				}
+			}
+		}
+	}
+	return cages, nil
+}
+
+// Name records a naming policy's site hostnames for the map's switches,
+// keyed by the name hnc gave each. Every key must be a switch of the
+// plan, and every hostname valid and unlike any other.
+func (m *Map) Name(hostnames map[string]string, plan fabricplan.Plan) error {
+	switches := map[string]bool{}
+	for _, role := range []struct {
+		prefix string
+		count  int
+	}{{"spine", plan.Spines}, {"leaf", plan.Leaves}, {"superspine", plan.SuperSpines}} {
+		for i := 1; i <= role.count; i++ {
+			switches[role.prefix+strconv.Itoa(i)] = true
+		}
+	}
+	names := make([]string, 0, len(hostnames))
+	for name := range hostnames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	owners := map[string]string{}
+	for _, name := range names {
+		host := hostnames[name]
+		switch {
+		case !switches[name]:
+			return fmt.Errorf("%s is not a switch of the plan", name)
+		case !hostnamePattern.MatchString(host):
+			return fmt.Errorf("%s hostname %q is not a valid hostname", name, host)
+		case owners[host] != "":
+			return fmt.Errorf("%s and %s are both named %s", owners[host], name, host)
+		}
+		owners[host] = name
+	}
+	m.Hostnames = hostnames
+	return nil
+}
+
+// Renumber takes an addressing policy's numbering in place of the map's,
+// once addressing.Check passes it. The pools stay the map's own, so
+// numbering a grown fabric takes what the policy left free in them.
+func (m *Map) Renumber(a addressing.Plan) error {
+	if m.Addressing == nil {
+		return fmt.Errorf("the map is not numbered")
+	}
+	if err := addressing.Check(*m.Addressing, a); err != nil {
+		return err
+	}
+	m.Addressing.Switches, m.Addressing.Links = a.Switches, a.Links
+	return nil
+}
@@ -0,0 +1,105 @@
+package cabling
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/addressing"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func TestPlace(t *testing.T) {
+	// 3 leaves, leaf2 and leaf3 uplinking out of E1/47-48
+	p := plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3, External: &fabricplan.External{PortsPerLeaf: 2, Peers: 3}})
+	leaf := profiles.DS2000()
+	leaf.Profiles.Endpoint.Breakouts = []profiles.BreakoutOption{{Mode: "2x10G", Lanes: 2, SpeedGbps: 10, PortPattern: "{port}/{lane}"}}
+	m, err := Assign(p, leaf, profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.ServerLinks = []ServerLink{{Server: "web01", NIC: "eth0", Leaf: "leaf1", LeafPort: "E1/1"}, {Server: "web01", NIC: "eth1", Leaf: "leaf2", LeafPort: "E1/1"}}
+
+	moved := []ServerLink{{Server: "web01", NIC: "eth1", Leaf: "leaf3", LeafPort: "E1/10/1"}, {Server: "web01", NIC: "eth0", Leaf: "leaf3", LeafPort: "E1/10/2"}}
+	if err := m.Place(moved, p, leaf); err != nil {
+		t.Fatal(err)
+	}
+	if m.ServerLinks[0].Link != "leaf3:E1/10/1 <-> web01:eth1" || len(m.ServerLinks) != 2 {
+		t.Errorf("placed = %+v", m.ServerLinks)
+	}
+
+	for _, tc := range []struct {
+		links []ServerLink
+		want  string
+	}{
+		{moved[:1], "web01:eth0 is left uncabled"},
+		{append(moved, ServerLink{Server: "web01", NIC: "eth0", Leaf: "leaf1", LeafPort: "E1/2"}), "web01:eth0 is not a server NIC of the map, or is cabled twice"},
+		{[]ServerLink{moved[0], {Server: "db01", NIC: "eth0", Leaf: "leaf1", LeafPort: "E1/2"}}, "db01:eth0 is not a server NIC"},
+		{[]ServerLink{moved[0], {Server: "web01", NIC: "eth0", Leaf: "leaf4", LeafPort: "E1/2"}}, "leaf4 is not a leaf of the plan"},
+		{[]ServerLink{moved[0], {Server: "web01", NIC: "eth0", Leaf: "leaf1", LeafPort: "E1/49"}}, "E1/49 is not an endpoint port of celestica-ds2000"},
+		{[]ServerLink{moved[0], {Server: "web01", NIC: "eth0", Leaf: "leaf3", LeafPort: "E1/10/1"}}, "leaf3:E1/10/1 is taken by leaf3:E1/10/1 <-> web01:eth1"},
+		{[]ServerLink{moved[0], {Server: "web01", NIC: "eth0", Leaf: "leaf3", LeafPort: "E1/10"}}, "leaf3:E1/10 is split differently from the rest of E1/10"},
+		{[]ServerLink{moved[0], {Server: "web01", NIC: "eth0", Leaf: "leaf2", LeafPort: "E1/48"}}, "leaf2:E1/48 is taken by leaf2:E1/48 <-> external2"},
+	} {
+		again := m
+		if err := again.Place(tc.links, p, leaf); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Place() = %v, want %q", err, tc.want)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3})
+	m, err := Assign(p, profiles.DS2000(), profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		hostnames map[string]string
+		want      string
+	}{
+		{map[string]string{"leaf3": "dc1-r3-leaf"}, "leaf3 is not a switch of the plan"},
+		{map[string]string{"leaf1": "dc1 r1"}, `leaf1 hostname "dc1 r1" is not a valid hostname`},
+		{map[string]string{"leaf1": "dc1-r1", "spine1": "dc1-r1"}, "leaf1 and spine1 are both named dc1-r1"},
+	} {
+		if err := m.Name(tc.hostnames, p); err == nil || err.Error() != tc.want {
+			t.Errorf("Name(%v) = %v, want %q", tc.hostnames, err, tc.want)
+		}
+	}
+	if m.Hostnames != nil {
+		t.Errorf("a failed Name() kept hostnames %v", m.Hostnames)
+	}
+	site := map[string]string{"spine1": "dc1-sp1", "spine2": "dc1-sp2", "leaf1": "dc1-r1-tor", "leaf2": "dc1-r2-tor"}
+	if err := m.Name(site, p); err != nil || m.Hostnames["leaf2"] != "dc1-r2-tor" {
+		t.Errorf("Name() = %v, hostnames %v", err, m.Hostnames)
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	p := plan(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3})
+	m, err := Assign(p, profiles.DS2000(), profiles.DS3000(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Renumber(addressing.Plan{}); err == nil || err.Error() != "the map is not numbered" {
+		t.Errorf("Renumber(unnumbered map) = %v", err)
+	}
+	if err := m.Address(p, addressing.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	next := *m.Addressing
+	next.Options.LoopbackPool = "10.0.0.0/8"
+	next.Switches = append([]addressing.Switch{}, next.Switches...)
+	next.Switches[2].ASN = 64512
+	if err := m.Renumber(next); err != nil {
+		t.Fatal(err)
+	}
+	if m.Addressing.Switches[2].ASN != 64512 || m.Addressing.Options.LoopbackPool != addressing.DefaultLoopbackPool {
+		t.Errorf("renumbered = %+v, want leaf1 at 64512 in the map's pools", m.Addressing)
+	}
+	next.Switches = append([]addressing.Switch{}, next.Switches...)
+	next.Switches[3].ASN = 64512
+	if err := m.Renumber(next); err == nil || err.Error() != "leaf1 and leaf2 share ASN 64512" {
+		t.Errorf("Renumber(shared ASN) = %v", err)
+	}
+}
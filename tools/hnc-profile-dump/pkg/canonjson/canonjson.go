@@ -0,0 +1,192 @@
+// Package canonjson writes canonical JSON, the one byte layout every hnc
+// emitter uses so generated files are identical whatever the platform,
+// Go version or struct field order: object keys sorted, two-space
+// indentation, numbers formatted as JavaScript's JSON.stringify does, only
+// LF line endings and a trailing newline. The frontend writes its contract
+// fixtures the same way, so files from either side compare byte for byte.
+package canonjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Marshal renders v as canonical, indented JSON ending in a newline
+func Marshal(v any) ([]byte, error) {
+	return encode(v, "  ")
+}
+
+// Compact renders v as canonical JSON on a single line ending in a
+// newline, for NDJSON streams
+func Compact(v any) ([]byte, error) {
+	return encode(v, "")
+}
+
+// FromJSON re-renders a JSON document canonically, as Marshal would have
+// written it
+func FromJSON(data []byte) ([]byte, error) {
+	return canonical(data, "  ")
+}
+
+func encode(v any, indent string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return canonical(data, indent)
+}
+
+func canonical(data []byte, indent string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var root any
+	if err := dec.Decode(&root); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, fmt.Errorf("unexpected data after JSON document")
+	}
+	var b strings.Builder
+	if err := write(&b, root, indent, 0); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return []byte(b.String()), nil
+}
+
+func write(b *strings.Builder, v any, indent string, depth int) error {
+	newline := func(depth int) {
+		if indent != "" {
+			b.WriteByte('\n')
+			b.WriteString(strings.Repeat(indent, depth))
+		}
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		// Byte order of UTF-8 matches code point order; JavaScript's
+		// default sort compares UTF-16 units, which only differs for keys
+		// outside the BMP
+		sort.Strings(keys)
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			newline(depth + 1)
+			writeString(b, k)
+			b.WriteByte(':')
+			if indent != "" {
+				b.WriteByte(' ')
+			}
+			if err := write(b, v[k], indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		b.WriteByte('}')
+	case []any:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := write(b, e, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		b.WriteByte(']')
+	case string:
+		writeString(b, v)
+	case json.Number:
+		n, err := Number(v)
+		if err != nil {
+			return err
+		}
+		b.WriteString(n)
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case nil:
+		b.WriteString("null")
+	default:
+		return fmt.Errorf("canonjson: unexpected %T", v)
+	}
+	return nil
+}
+
+// Number formats a JSON number canonically. Integers are kept as written,
+// so 64-bit counters and IDs survive; other numbers are formatted as
+// JavaScript does: plain decimals from 1e-6 up to 1e21, the shortest
+// exponent form (1e-7, 1.5e+21) outside that range, and -0 as 0.
+func Number(n json.Number) (string, error) {
+	s := string(n)
+	if !strings.ContainsAny(s, ".eE") {
+		if s == "-0" {
+			return "0", nil
+		}
+		return s, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) {
+		return "", fmt.Errorf("canonjson: invalid number %s", s)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	sign := exp[:1]
+	exp = strings.TrimLeft(exp[1:], "0")
+	return mantissa + "e" + sign + exp, nil
+}
+
+// writeString quotes s as JSON.stringify does: only quotes, backslashes
+// and control characters are escaped, so <, > and & stay readable
+func writeString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
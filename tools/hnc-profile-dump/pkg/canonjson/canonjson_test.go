@@ -0,0 +1,152 @@
+package canonjson
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	type port struct {
+		Name  string  `json:"name"`
+		Speed float64 `json:"speed"`
+		Label string  `json:"label,omitempty"`
+	}
+	v := struct {
+		Zone  string          `json:"zone"`
+		Ports []port          `json:"ports"`
+		Tags  map[string]int  `json:"tags"`
+		Empty map[string]bool `json:"empty"`
+		None  []int           `json:"none"`
+		Count uint64          `json:"count"`
+	}{
+		Zone:  "a<->b & c",
+		Ports: []port{{Name: "E1/1", Speed: 25, Label: "line\r\nbreak\t\x01"}, {Name: "E1/2", Speed: 0.5}},
+		Tags:  map[string]int{"z": 1, "a": 2, "M": 3},
+		Empty: map[string]bool{},
+		Count: 18446744073709551615,
+	}
+	want := `{
+  "count": 18446744073709551615,
+  "empty": {},
+  "none": null,
+  "ports": [
+    {
+      "label": "line\r\nbreak\t\u0001",
+      "name": "E1/1",
+      "speed": 25
+    },
+    {
+      "name": "E1/2",
+      "speed": 0.5
+    }
+  ],
+  "tags": {
+    "M": 3,
+    "a": 2,
+    "z": 1
+  },
+  "zone": "a<->b & c"
+}
+`
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(string(got), "\r") {
+		t.Error("Marshal() wrote a carriage return")
+	}
+
+	compact, err := Compact(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"count":18446744073709551615,"empty":{},"none":null,"ports":[{"label":"line\r\nbreak\t\u0001","name":"E1/1","speed":25},{"name":"E1/2","speed":0.5}],"tags":{"M":3,"a":2,"z":1},"zone":"a<->b & c"}` + "\n"; string(compact) != want {
+		t.Errorf("Compact() = %s, want %s", compact, want)
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	// Key order, whitespace and CRLF line endings of the input don't matter
+	in := "{\r\n\t\"b\" : [ 1 , 2 ],\r\n\t\"a\" : { }\r\n}\r\n"
+	got, err := FromJSON([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": {},\n  \"b\": [\n    1,\n    2\n  ]\n}\n"; string(got) != want {
+		t.Errorf("FromJSON() = %q, want %q", got, want)
+	}
+	for _, in := range []string{``, `{"a":`, `{"a":1} {"b":2}`} {
+		if _, err := FromJSON([]byte(in)); err == nil {
+			t.Errorf("FromJSON(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+// The expected values are what JavaScript's String(Number(in)) gives
+func TestNumber(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"25", "25"},
+		{"-0", "0"},
+		{"0.0", "0"},
+		{"-0.0", "0"},
+		{"25.0", "25"},
+		{"1.50", "1.5"},
+		{"0.1", "0.1"},
+		{"1e2", "100"},
+		{"123456789012345678901234", "123456789012345678901234"},
+		{"1.2e20", "120000000000000000000"},
+		{"1e21", "1e+21"},
+		{"1.5E+21", "1.5e+21"},
+		{"0.000001", "0.000001"},
+		{"1e-7", "1e-7"},
+		{"-2.5e-10", "-2.5e-10"},
+		{"0.30000000000000004", "0.30000000000000004"},
+	}
+	for _, tt := range tests {
+		got, err := Number(json.Number(tt.in))
+		if err != nil {
+			t.Errorf("Number(%s): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Number(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	if _, err := Number("1e400"); err == nil {
+		t.Error("Number(1e400) succeeded, want an error")
+	}
+}
+
+// The frontend writes the contract fixtures; they must already be in the
+// layout this package writes, or the two emitters would disagree
+func TestContractFixturesAreCanonical(t *testing.T) {
+	dir := "../../../../contracts/fixtures"
+	checked := 0
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		canonical, err := FromJSON(data)
+		if err != nil {
+			return err
+		}
+		if string(canonical) != string(data) {
+			t.Errorf("%s is not canonical JSON", path)
+		}
+		checked++
+		return nil
+	})
+	if err != nil || checked == 0 {
+		t.Fatalf("checked %d fixtures: %v", checked, err)
+	}
+}
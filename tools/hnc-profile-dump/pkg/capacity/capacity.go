@@ -0,0 +1,116 @@
+// Package capacity is the per-switch port and bandwidth math shared by the
+// planning tools: how many endpoints a leaf holds, what its uplinks carry
+// and the oversubscription that results.
+package capacity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/ports"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// MaxEndpoints is how many endpoints a switch can connect: its
+// endpoint-assignable ports, multiplied out when they run in breakoutMode
+// (e.g. "4x25G"; "" for none). The mode is looked up in the endpoint
+// breakouts, then in the switch-level breakout summary the frontend
+// multiplies capacity by.
+func MaxEndpoints(p profiles.SwitchProfile, breakoutMode string) (int, error) {
+	n, err := countPorts(p.Ports.EndpointAssignable)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", p.ModelID, err)
+	}
+	if breakoutMode == "" {
+		return n, nil
+	}
+	if b, ok := p.Profiles.Endpoint.Breakout(breakoutMode); ok {
+		return n * b.Lanes, nil
+	}
+	if s := p.Profiles.Breakout; s != nil && s.SupportsBreakout && strings.EqualFold(s.BreakoutType, breakoutMode) && s.CapacityMultiplier > 0 {
+		return n * s.CapacityMultiplier, nil
+	}
+	return 0, fmt.Errorf("%s endpoint ports do not support breakout %s", p.ModelID, breakoutMode)
+}
+
+// Fit is how endpoints of one speed use a switch's endpoint ports
+type Fit struct {
+	Breakout      string // endpoint breakout mode the ports run, "" for whole ports
+	PortSpeedGbps int    // speed of each port or lane, at least the endpoints'
+	PerPort       int    // endpoints one physical port takes
+}
+
+// EndpointFit is the densest way endpoints of speedGbps use a switch's
+// endpoint ports: the endpoint breakout with the most lanes at least that
+// fast, or whole ports. Ports and lanes faster than the endpoints run at
+// the endpoints' speed; between breakouts of as many lanes, the slowest
+// lanes fit best.
+func EndpointFit(p profiles.SwitchProfile, speedGbps int) (Fit, error) {
+	port := p.Profiles.Endpoint
+	if speedGbps <= 0 {
+		return Fit{}, fmt.Errorf("endpoint speed must be positive, got %dG", speedGbps)
+	}
+	fit := Fit{PortSpeedGbps: port.SpeedGbps, PerPort: 1}
+	for _, b := range port.Breakouts {
+		if b.SpeedGbps >= speedGbps && (b.Lanes > fit.PerPort || b.Lanes == fit.PerPort && b.SpeedGbps < fit.PortSpeedGbps) {
+			fit = Fit{Breakout: b.Mode, PortSpeedGbps: b.SpeedGbps, PerPort: b.Lanes}
+		}
+	}
+	if fit.PortSpeedGbps < speedGbps {
+		return Fit{}, fmt.Errorf("%s endpoint ports run at %dG, too slow for %dG endpoints", p.ModelID, port.SpeedGbps, speedGbps)
+	}
+	return fit, nil
+}
+
+// FabricPorts is how many fabric links a switch can terminate and the
+// speed of each, after splitting its fabric ports by breakoutMode ("" for
+// none)
+func FabricPorts(p profiles.SwitchProfile, breakoutMode string) (count, speedGbps int, err error) {
+	n, err := countPorts(p.Ports.FabricAssignable)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", p.ModelID, err)
+	}
+	if breakoutMode == "" {
+		return n, p.Profiles.Uplink.SpeedGbps, nil
+	}
+	b, ok := p.Profiles.Uplink.Breakout(breakoutMode)
+	if !ok {
+		return 0, 0, fmt.Errorf("%s fabric ports do not support breakout %s", p.ModelID, breakoutMode)
+	}
+	return n * b.Lanes, b.SpeedGbps, nil
+}
+
+// UplinkBandwidthGbps is what uplinks of the profile's uplink speed carry
+// together; more uplinks than the switch has fabric ports is an error
+func UplinkBandwidthGbps(p profiles.SwitchProfile, uplinks int) (int, error) {
+	n, speed, err := FabricPorts(p, "")
+	if err != nil {
+		return 0, err
+	}
+	if uplinks < 0 || uplinks > n {
+		return 0, fmt.Errorf("%s has %d fabric ports, cannot carry %d uplinks", p.ModelID, n, uplinks)
+	}
+	return uplinks * speed, nil
+}
+
+// Oversubscription is the endpoint to uplink bandwidth ratio, e.g. 3 for
+// 3:1
+func Oversubscription(endpointGbps, uplinkGbps int) (float64, error) {
+	if uplinkGbps <= 0 {
+		return 0, fmt.Errorf("uplink bandwidth must be positive, got %dG", uplinkGbps)
+	}
+	return float64(endpointGbps) / float64(uplinkGbps), nil
+}
+
+// countPorts counts the ports in entries like "E1/1-48" or "E1/55"
+func countPorts(ranges []string) (int, error) {
+	n := 0
+	for _, r := range ranges {
+		c, err := ports.Count(r)
+		if err != nil {
+			return 0, err
+		}
+		n += c
+	}
+	return n, nil
+}
@@ -0,0 +1,157 @@
+package capacity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/ports"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/profiles/profiletest"
+)
+
+func TestMaxEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		profile profiles.SwitchProfile
+		mode    string
+		want    int
+	}{
+		{profiles.DS2000(), "", 48},
+		// From the switch-level summary: 4x25G multiplies capacity by 4
+		{profiles.DS2000(), "4x25G", 192},
+		{profiles.DS3000(), "", 0},
+	} {
+		got, err := MaxEndpoints(tc.profile, tc.mode)
+		if err != nil || got != tc.want {
+			t.Errorf("MaxEndpoints(%s, %q) = %d, %v; want %d", tc.profile.ModelID, tc.mode, got, err, tc.want)
+		}
+	}
+
+	p := profiles.DS2000()
+	p.Profiles.Endpoint.Breakouts = []profiles.BreakoutOption{{Mode: "2x10G", Lanes: 2, SpeedGbps: 10, PortPattern: "{port}/{lane}"}}
+	if got, err := MaxEndpoints(p, "2x10G"); err != nil || got != 96 {
+		t.Errorf("MaxEndpoints(2x10G endpoint breakout) = %d, %v; want 96", got, err)
+	}
+	if _, err := MaxEndpoints(profiles.DS2000(), "2x50G"); err == nil || !strings.Contains(err.Error(), "do not support breakout 2x50G") {
+		t.Errorf("unknown breakout error = %v", err)
+	}
+}
+
+func TestEndpointFit(t *testing.T) {
+	p := profiles.DS2000()
+	p.Profiles.Endpoint = profiles.PortProfile{SpeedGbps: 100, Breakouts: []profiles.BreakoutOption{
+		{Mode: "2x50G", Lanes: 2, SpeedGbps: 50},
+		{Mode: "4x25G", Lanes: 4, SpeedGbps: 25},
+		{Mode: "4x40G", Lanes: 4, SpeedGbps: 40},
+	}}
+	for _, tc := range []struct {
+		speed int
+		want  Fit
+	}{
+		{100, Fit{PortSpeedGbps: 100, PerPort: 1}},
+		{50, Fit{Breakout: "2x50G", PortSpeedGbps: 50, PerPort: 2}},
+		{40, Fit{Breakout: "4x40G", PortSpeedGbps: 40, PerPort: 4}},
+		// 10G runs on the slowest of the four-lane breakouts
+		{10, Fit{Breakout: "4x25G", PortSpeedGbps: 25, PerPort: 4}},
+	} {
+		if got, err := EndpointFit(p, tc.speed); err != nil || got != tc.want {
+			t.Errorf("EndpointFit(%dG) = %+v, %v; want %+v", tc.speed, got, err, tc.want)
+		}
+	}
+	// 10G servers run slower on whole SFP28 ports; 100G do not fit them
+	if got, err := EndpointFit(profiles.DS2000(), 10); err != nil || got != (Fit{PortSpeedGbps: 25, PerPort: 1}) {
+		t.Errorf("EndpointFit(DS2000, 10G) = %+v, %v", got, err)
+	}
+	if _, err := EndpointFit(profiles.DS2000(), 100); err == nil || !strings.Contains(err.Error(), "run at 25G, too slow for 100G endpoints") {
+		t.Errorf("EndpointFit(DS2000, 100G) error = %v", err)
+	}
+}
+
+func TestFabricPorts(t *testing.T) {
+	if n, speed, err := FabricPorts(profiles.DS3000(), ""); err != nil || n != 32 || speed != 100 {
+		t.Errorf("FabricPorts(DS3000) = %d x %dG, %v; want 32 x 100G", n, speed, err)
+	}
+	if n, speed, err := FabricPorts(profiles.DS2000(), "4x25G"); err != nil || n != 32 || speed != 25 {
+		t.Errorf("FabricPorts(DS2000, 4x25G) = %d x %dG, %v; want 32 x 25G", n, speed, err)
+	}
+}
+
+func TestUplinkBandwidthAndOversubscription(t *testing.T) {
+	up, err := UplinkBandwidthGbps(profiles.DS2000(), 4)
+	if err != nil || up != 400 {
+		t.Fatalf("UplinkBandwidthGbps(DS2000, 4) = %d, %v; want 400", up, err)
+	}
+	if _, err := UplinkBandwidthGbps(profiles.DS2000(), 9); err == nil {
+		t.Error("9 uplinks on 8 fabric ports succeeded")
+	}
+
+	// 48 x 25G over 4 x 100G
+	if ratio, err := Oversubscription(48*25, up); err != nil || ratio != 3 {
+		t.Errorf("Oversubscription(1200, 400) = %g, %v; want 3", ratio, err)
+	}
+	if _, err := Oversubscription(1200, 0); err == nil {
+		t.Error("Oversubscription with no uplink bandwidth succeeded")
+	}
+}
+
+// Counting without expanding agrees with the expanded port lists, for
+// every breakout a random profile offers
+func TestCountsMatchExpandedPorts(t *testing.T) {
+	g := profiletest.New(1)
+	for i := 0; i < 300; i++ {
+		p := g.Profile([]string{profiles.RoleLeaf, profiles.RoleSpine}[i%2])
+		endpoints, _ := ports.Expand(p.Ports.EndpointAssignable)
+		fabric, _ := ports.Expand(p.Ports.FabricAssignable)
+		if n, err := MaxEndpoints(p, ""); err != nil || n != len(endpoints) {
+			t.Fatalf("MaxEndpoints(%v) = %d, %v; want %d", p.Ports.EndpointAssignable, n, err, len(endpoints))
+		}
+		for _, b := range p.Profiles.Endpoint.Breakouts {
+			if n, err := MaxEndpoints(p, b.Mode); err != nil || n != len(endpoints)*b.Lanes {
+				t.Fatalf("MaxEndpoints(%s) = %d, %v; want %d", b.Mode, n, err, len(endpoints)*b.Lanes)
+			}
+		}
+		if n, speed, err := FabricPorts(p, ""); err != nil || n != len(fabric) || speed != p.Profiles.Uplink.SpeedGbps {
+			t.Fatalf("FabricPorts(%v) = %d x %dG, %v", p.Ports.FabricAssignable, n, speed, err)
+		}
+		for _, b := range p.Profiles.Uplink.Breakouts {
+			if n, speed, err := FabricPorts(p, b.Mode); err != nil || n != len(fabric)*b.Lanes || speed != b.SpeedGbps {
+				t.Fatalf("FabricPorts(%s) = %d x %dG, %v", b.Mode, n, speed, err)
+			}
+		}
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	leaf := profiles.DS2000()
+	ds4000, _ := profiles.Default().Get("celestica-ds4000")
+	if err := CheckLink(leaf, profiles.RoleLeaf, profiles.DS3000(), profiles.RoleSpine, ""); err != nil {
+		t.Errorf("DS2000 to DS3000 = %v", err)
+	}
+	if err := CheckLink(leaf, profiles.RoleLeaf, profiles.DS3000(), profiles.RoleSpine, "4x25G"); err != nil {
+		t.Errorf("DS2000 to DS3000 at 4x25G = %v", err)
+	}
+	err := CheckLink(leaf, profiles.RoleLeaf, ds4000, profiles.RoleSpine, "")
+	if want := "leaf celestica-ds2000 fabric ports E1/49-56 (QSFP28-100G, 100G) cannot link to spine celestica-ds4000 fabric ports E1/1-32 (QSFP-DD-400G, 400G): 100G against 400G; " +
+		"no breakout both support runs them at the same speed"; err == nil || err.Error() != want {
+		t.Errorf("DS2000 to DS4000 =\n%v\nwant\n%s", err, want)
+	}
+
+	// A breakout the two share is suggested
+	spine, _ := profiles.Default().Get("celestica-ds4000")
+	spine.Profiles.Uplink.Breakouts = append(spine.Profiles.Uplink.Breakouts, profiles.BreakoutOption{Mode: "4x25G", Lanes: 4, SpeedGbps: 25, PortPattern: "{port}/{lane}"})
+	if err := CheckLink(leaf, profiles.RoleLeaf, spine, profiles.RoleSpine, ""); err == nil || !strings.Contains(err.Error(), "plan with breakout 4x25G") {
+		t.Errorf("DS2000 to a DS4000 with 4x25G = %v", err)
+	}
+
+	// The same speed over cages no cable joins
+	osfp := profiles.DS3000()
+	name := "OSFP-100G"
+	osfp.Profiles.Uplink.PortProfile = &name
+	if err := CheckLink(leaf, profiles.RoleLeaf, osfp, profiles.RoleSpine, ""); err == nil || !strings.Contains(err.Error(), "no cable joins QSFP28 and OSFP cages") {
+		t.Errorf("QSFP28 to OSFP = %v", err)
+	}
+	// A QSFP-DD cage takes QSFP28 modules
+	name = "QSFP-DD-100G"
+	if err := CheckLink(leaf, profiles.RoleLeaf, osfp, profiles.RoleSpine, ""); err != nil {
+		t.Errorf("QSFP28 to QSFP-DD = %v", err)
+	}
+}
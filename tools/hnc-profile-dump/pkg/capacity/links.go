@@ -0,0 +1,133 @@
+package capacity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// linkEnd is one end of a kind of fabric link: the fabric ports of a
+// switch in a role, as a breakout mode runs them
+type linkEnd struct {
+	Role        string // e.g. leaf, spine or super-spine
+	ModelID     string
+	Ports       string // the fabric-assignable port ranges, e.g. E1/49-56
+	PortProfile string // of the unsplit port, e.g. QSFP28-100G; "" when the profile names none
+	Breakout    string // "" for unsplit
+	SpeedGbps   int    // of each port or breakout lane
+}
+
+// fabricEnd is the end a switch's fabric ports make in role, split by
+// breakoutMode ("" for none)
+func fabricEnd(p profiles.SwitchProfile, role, breakoutMode string) (linkEnd, error) {
+	_, speed, err := FabricPorts(p, breakoutMode)
+	if err != nil {
+		return linkEnd{}, err
+	}
+	end := linkEnd{Role: role, ModelID: p.ModelID, Ports: strings.Join(p.Ports.FabricAssignable, ","), Breakout: breakoutMode, SpeedGbps: speed}
+	if pp := p.Profiles.Uplink.PortProfile; pp != nil {
+		end.PortProfile = *pp
+	}
+	return end, nil
+}
+
+// formFactor is the cage a port profile names, e.g. QSFP28 for
+// QSFP28-100G or QSFP-DD for QSFP-DD-400G
+func (e linkEnd) formFactor() string {
+	i := strings.LastIndex(e.PortProfile, "-")
+	if i < 0 {
+		return e.PortProfile
+	}
+	if speed := strings.TrimSuffix(e.PortProfile[i+1:], "G"); speed != e.PortProfile[i+1:] {
+		if _, err := strconv.Atoi(speed); err == nil {
+			return e.PortProfile[:i]
+		}
+	}
+	return e.PortProfile
+}
+
+func (e linkEnd) String() string {
+	s := fmt.Sprintf("%s %s fabric ports %s (", e.Role, e.ModelID, e.Ports)
+	if e.PortProfile != "" {
+		s += e.PortProfile + ", "
+	}
+	if e.Breakout != "" {
+		s += "broken out " + e.Breakout + ", "
+	}
+	return s + strconv.Itoa(e.SpeedGbps) + "G)"
+}
+
+// accepts lists, for each cage, the narrower modules it also takes, so a
+// link may join the two when both run at the same speed
+var accepts = map[string][]string{
+	"QSFP-DD": {"QSFP56", "QSFP28", "QSFP+"},
+	"QSFP112": {"QSFP56", "QSFP28", "QSFP+"},
+	"QSFP56":  {"QSFP28", "QSFP+"},
+	"QSFP28":  {"QSFP+"},
+	"SFP-DD":  {"SFP56", "SFP28", "SFP+"},
+	"SFP56":   {"SFP28", "SFP+"},
+	"SFP28":   {"SFP+"},
+}
+
+// mates reports whether cages a and b can be joined by one cable or pair
+// of optics: the same cage, or one taking the other's modules. Unknown
+// cages are not checked.
+func mates(a, b string) bool {
+	if a == "" || b == "" || strings.EqualFold(a, b) {
+		return true
+	}
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		for _, narrower := range accepts[strings.ToUpper(pair[0])] {
+			if strings.EqualFold(narrower, pair[1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckLink reports why fabric links from switch pa in roleA to switch pb
+// in roleB, both split by breakoutMode ("" for none), cannot come up: the
+// ports or lanes at each end running at different speeds, or cages no
+// cable joins. The error names the ports at both ends and, for a speed
+// mismatch, the breakout that would match them if both switches have one.
+func CheckLink(pa profiles.SwitchProfile, roleA string, pb profiles.SwitchProfile, roleB, breakoutMode string) error {
+	a, err := fabricEnd(pa, roleA, breakoutMode)
+	if err != nil {
+		return fmt.Errorf("%s %w", roleA, err)
+	}
+	b, err := fabricEnd(pb, roleB, breakoutMode)
+	if err != nil {
+		return fmt.Errorf("%s %w", roleB, err)
+	}
+	if a.SpeedGbps != b.SpeedGbps {
+		hint := ""
+		if mode := MatchingBreakout(pa, pb); mode != "" && mode != a.Breakout {
+			hint = "; plan with breakout " + mode + " to run both at the same speed"
+		} else if a.Breakout == "" {
+			hint = "; no breakout both support runs them at the same speed"
+		}
+		return fmt.Errorf("%s cannot link to %s: %dG against %dG%s", a, b, a.SpeedGbps, b.SpeedGbps, hint)
+	}
+	if fa, fb := a.formFactor(), b.formFactor(); !mates(fa, fb) {
+		return fmt.Errorf("%s cannot link to %s: no cable joins %s and %s cages", a, b, fa, fb)
+	}
+	return nil
+}
+
+// MatchingBreakout is the first fabric port breakout mode both switches
+// support at the same lane speed, or "" when they run at the same speed
+// unsplit or share no such mode
+func MatchingBreakout(a, b profiles.SwitchProfile) string {
+	if a.Profiles.Uplink.SpeedGbps == b.Profiles.Uplink.SpeedGbps {
+		return ""
+	}
+	for _, ba := range a.Profiles.Uplink.Breakouts {
+		if bb, ok := b.Profiles.Uplink.Breakout(ba.Mode); ok && bb.SpeedGbps == ba.SpeedGbps {
+			return ba.Mode
+		}
+	}
+	return ""
+}
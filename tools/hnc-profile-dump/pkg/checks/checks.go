@@ -0,0 +1,298 @@
+// Package checks gathers the results of HNC's validators (profile lint
+// rules, fixture drift and schema checks, plan feasibility) into one
+// report, and writes it as SARIF for code review annotations and as
+// JUnit XML for CI test tabs. A report is suites of cases: a suite per
+// validator, a case per thing it checked (a profile, a fixture check, a
+// plan), each with the findings against it. Only error findings fail a
+// case.
+package checks
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/doctor"
+	"github.com/hnc/profile-dump/pkg/lint"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Levels of a finding, as SARIF names them; only errors fail
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+// Finding is one problem a validator found
+type Finding struct {
+	Rule    string `json:"rule"` // suite/rule, e.g. lint/port-overlap
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"` // the file it is in, when known
+}
+
+// Case is one thing a validator checked
+type Case struct {
+	Name     string    `json:"name"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Failed reports whether any of the case's findings is an error
+func (c Case) Failed() bool {
+	for _, f := range c.Findings {
+		if f.Level == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Suite is the cases one validator checked
+type Suite struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Cases       []Case `json:"cases"`
+}
+
+// Report is the suites of one check run
+type Report struct {
+	Tool    string  `json:"tool"`
+	Version string  `json:"version"`
+	Suites  []Suite `json:"suites"`
+}
+
+// Count is how many findings the report has at level
+func (r Report) Count(level string) int {
+	n := 0
+	for _, s := range r.Suites {
+		for _, c := range s.Cases {
+			for _, f := range c.Findings {
+				if f.Level == level {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// Lint is a case per profile with its lint findings, errors and warnings
+// as the rules rate them
+func Lint(names []string, findings []lint.Finding) Suite {
+	s := Suite{Name: "lint", Description: "Switch profile lint rules"}
+	index := map[string]int{}
+	for _, name := range names {
+		index[name] = len(s.Cases)
+		s.Cases = append(s.Cases, Case{Name: name})
+	}
+	for _, f := range findings {
+		i, ok := index[f.ModelID]
+		if !ok {
+			i = len(s.Cases)
+			index[f.ModelID] = i
+			s.Cases = append(s.Cases, Case{Name: f.ModelID})
+		}
+		level := Warning
+		if f.Severity == lint.Error {
+			level = Error
+		}
+		s.Cases[i].Findings = append(s.Cases[i].Findings, Finding{Rule: "lint/" + f.Rule, Level: level, Message: f.Message})
+	}
+	return s
+}
+
+// Doctor is a case per doctor check of the files in dir, named suite: a
+// finding per detail of a failed or warning check, located in the file
+// the detail starts with, if it names one in dir. The check's fix is
+// added to its message.
+func Doctor(name, description, dir string, checks []doctor.Check) Suite {
+	s := Suite{Name: name, Description: description}
+	for _, c := range checks {
+		k := Case{Name: c.Name}
+		level := ""
+		switch c.Status {
+		case doctor.Fail:
+			level = Error
+		case doctor.Warn:
+			level = Warning
+		}
+		details := c.Details
+		if level != "" && len(details) == 0 {
+			details = []string{c.Summary}
+		}
+		for _, d := range details {
+			if level == "" {
+				break
+			}
+			f := Finding{Rule: name + "/" + c.Name, Level: level, Message: d}
+			if file, _, ok := strings.Cut(d, ": "); ok && !strings.ContainsAny(file, " /") && strings.Contains(file, ".") {
+				f.File = filepath.Join(dir, file)
+			}
+			if c.Fix != "" {
+				f.Message += "; fix: " + c.Fix
+			}
+			k.Findings = append(k.Findings, f)
+		}
+		s.Cases = append(s.Cases, k)
+	}
+	return s
+}
+
+// Schema is a case per profile fixture file, with an error finding per
+// way it breaks the JSON Schema
+func Schema(files []string) Suite {
+	s := Suite{Name: "schema", Description: "Switch profile fixtures against the JSON Schema"}
+	for _, file := range files {
+		k := Case{Name: filepath.Base(file)}
+		data, err := os.ReadFile(file)
+		problems := profiles.CheckSchema(data)
+		if err != nil {
+			problems = []string{err.Error()}
+		}
+		for _, p := range problems {
+			k.Findings = append(k.Findings, Finding{Rule: "schema/json-schema", Level: Error, Message: p, File: file})
+		}
+		s.Cases = append(s.Cases, k)
+	}
+	return s
+}
+
+// Plan is the one case of a plan's feasibility: a finding per problem,
+// all errors, located in the plan file
+func Plan(file string, problems []string) Suite {
+	k := Case{Name: filepath.Base(file)}
+	for _, p := range problems {
+		k.Findings = append(k.Findings, Finding{Rule: "plan/feasibility", Level: Error, Message: p, File: file})
+	}
+	return Suite{Name: "plan", Description: "Fabric plan feasibility on its switch models", Cases: []Case{k}}
+}
+
+// SARIFVersion is the SARIF version SARIF writes
+const SARIFVersion = "2.1.0"
+
+// SARIF writes the report as a SARIF log of one run, a rule per suite and
+// rule that found anything, a result per finding. Passing cases leave no
+// result, as SARIF records problems only.
+func SARIF(r Report) ([]byte, error) {
+	type text struct {
+		Text string `json:"text"`
+	}
+	type rule struct {
+		ID               string `json:"id"`
+		ShortDescription text   `json:"shortDescription"`
+	}
+	type location struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+		} `json:"physicalLocation"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   text       `json:"message"`
+		Locations []location `json:"locations,omitempty"`
+	}
+	rules := []rule{}
+	seen := map[string]bool{}
+	results := []result{}
+	for _, s := range r.Suites {
+		for _, c := range s.Cases {
+			for _, f := range c.Findings {
+				if !seen[f.Rule] {
+					seen[f.Rule] = true
+					rules = append(rules, rule{ID: f.Rule, ShortDescription: text{s.Description + ": " + strings.TrimPrefix(f.Rule, s.Name+"/")}})
+				}
+				res := result{RuleID: f.Rule, Level: f.Level, Message: text{c.Name + ": " + f.Message}}
+				if f.File != "" {
+					var l location
+					l.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(f.File)
+					res.Locations = []location{l}
+				}
+				results = append(results, res)
+			}
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return canonjson.Marshal(map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": SARIFVersion,
+		"runs": []any{map[string]any{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":           r.Tool,
+				"version":        r.Version,
+				"informationUri": "https://github.com/afewell-hh/hnc",
+				"rules":          rules,
+			}},
+			"results": results,
+		}},
+	})
+}
+
+// JUnit writes the report as JUnit XML: a testsuite per suite, a testcase
+// per case, failing with its errors; warnings go to the case's
+// system-out, so they show without failing it
+func JUnit(r Report) ([]byte, error) {
+	type failure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+	type testcase struct {
+		Name      string   `xml:"name,attr"`
+		ClassName string   `xml:"classname,attr"`
+		Failure   *failure `xml:"failure,omitempty"`
+		SystemOut string   `xml:"system-out,omitempty"`
+	}
+	type testsuite struct {
+		Name     string     `xml:"name,attr"`
+		Tests    int        `xml:"tests,attr"`
+		Failures int        `xml:"failures,attr"`
+		Cases    []testcase `xml:"testcase"`
+	}
+	type testsuites struct {
+		XMLName  xml.Name    `xml:"testsuites"`
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Suites   []testsuite `xml:"testsuite"`
+	}
+	out := testsuites{Name: r.Tool + " check"}
+	for _, s := range r.Suites {
+		ts := testsuite{Name: s.Name, Tests: len(s.Cases)}
+		for _, c := range s.Cases {
+			tc := testcase{Name: c.Name, ClassName: r.Tool + "." + s.Name}
+			var errors, warnings []string
+			for _, f := range c.Findings {
+				line := fmt.Sprintf("%s: %s", f.Rule, f.Message)
+				if f.File != "" {
+					line = f.File + ": " + line
+				}
+				if f.Level == Error {
+					errors = append(errors, line)
+				} else {
+					warnings = append(warnings, line)
+				}
+			}
+			if len(errors) > 0 {
+				tc.Failure = &failure{Message: fmt.Sprintf("%d error(s)", len(errors)), Type: s.Name, Text: strings.Join(errors, "\n")}
+				ts.Failures++
+			}
+			tc.SystemOut = strings.Join(warnings, "\n")
+			ts.Cases = append(ts.Cases, tc)
+		}
+		out.Tests += ts.Tests
+		out.Failures += ts.Failures
+		out.Suites = append(out.Suites, ts)
+	}
+	data, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
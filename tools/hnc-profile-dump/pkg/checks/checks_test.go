@@ -0,0 +1,95 @@
+package checks
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/doctor"
+	"github.com/hnc/profile-dump/pkg/lint"
+)
+
+func testReport() Report {
+	return Report{Tool: "hnc", Version: "v1.2.3", Suites: []Suite{
+		Lint([]string{"celestica-ds2000", "celestica-ds3000"}, []lint.Finding{
+			{Rule: "port-overlap", ModelID: "celestica-ds3000", Severity: lint.Error, Message: "E1/1 is in two groups"},
+			{Rule: "no-breakouts", ModelID: "celestica-ds3000", Severity: lint.Warning, Message: "no breakout modes"},
+		}),
+		Doctor("fixtures", "Fixtures", "fixtures", []doctor.Check{
+			{Name: "files", Status: doctor.OK},
+			{Name: "content", Status: doctor.Fail, Summary: "1 stale", Details: []string{"ds2000.json: differs from the generator"}, Fix: "hnc profiles dump"},
+		}),
+		Plan("plan.json", nil),
+	}}
+}
+
+func TestReport(t *testing.T) {
+	r := testReport()
+	if r.Count(Error) != 2 || r.Count(Warning) != 1 {
+		t.Errorf("%d errors, %d warnings, want 2 and 1", r.Count(Error), r.Count(Warning))
+	}
+	lint := r.Suites[0]
+	if len(lint.Cases) != 2 || lint.Cases[0].Failed() || !lint.Cases[1].Failed() {
+		t.Errorf("lint cases = %+v", lint.Cases)
+	}
+	want := Finding{Rule: "fixtures/content", Level: Error, Message: "ds2000.json: differs from the generator; fix: hnc profiles dump", File: "fixtures/ds2000.json"}
+	if got := r.Suites[1].Cases[1].Findings; len(got) != 1 || got[0] != want {
+		t.Errorf("fixture findings = %+v, want %+v", got, want)
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	data, err := SARIF(testReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string          `json:"ruleId"`
+				Level     string          `json:"level"`
+				Locations json.RawMessage `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	run := log.Runs[0]
+	if log.Version != SARIFVersion || run.Tool.Driver.Name != "hnc" || len(run.Tool.Driver.Rules) != 3 || run.Tool.Driver.Rules[0].ID != "fixtures/content" {
+		t.Errorf("SARIF log = %s", data)
+	}
+	// Passing cases leave no result
+	if len(run.Results) != 3 || run.Results[1].Level != Warning || !strings.Contains(string(run.Results[2].Locations), `"uri": "fixtures/ds2000.json"`) {
+		t.Errorf("results = %+v", run.Results)
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	data, err := JUnit(testReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml := string(data)
+	for _, want := range []string{
+		`<testsuites name="hnc check" tests="5" failures="2">`,
+		`<testsuite name="lint" tests="2" failures="1">`,
+		`<testcase name="celestica-ds2000" classname="hnc.lint"></testcase>`,
+		`<failure message="1 error(s)" type="lint">lint/port-overlap: E1/1 is in two groups</failure>`,
+		`<system-out>lint/no-breakouts: no breakout modes</system-out>`,
+		`<testcase name="plan.json" classname="hnc.plan"></testcase>`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("JUnit missing %q:\n%s", want, xml)
+		}
+	}
+}
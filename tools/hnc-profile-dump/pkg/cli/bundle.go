@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/bundle"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/manifest"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/templates"
+)
+
+// BundleCreate packs the hnc binary and any others given, the profile
+// catalog, the switch profile JSON Schema and the export templates into
+// one gzipped tar with a manifest of their checksums, for installs without
+// network access. Unpacked, catalog/ serves as a -profiles directory and
+// templates/ as a -templates one.
+func BundleCreate(env Env, args []string) int {
+	flags := newFlags(env, "[-bin FILE,...] [-profiles DIR] [-templates DIR] [-output FILE]")
+	bins := flags.String("bin", "", "Comma-separated binaries to pack into bin/, e.g. hnc builds for other platforms (default: this hnc binary)")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	templatesDir := flags.String("templates", "", "Directory of export templates to pack (default: $"+templates.DirEnv+", else "+templates.Dir+" when it exists)")
+	outputFile := flags.String("output", "hnc-bundle.tar.gz", "Bundle file")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	files := map[string][]byte{}
+	binaries := splitList(*bins)
+	if len(binaries) == 0 {
+		self, err := os.Executable()
+		if err != nil {
+			return env.fail(ExitIO, "Error: finding this binary: %v; name the binaries with -bin", err)
+		}
+		binaries = []string{self}
+	}
+	for _, bin := range binaries {
+		name := path.Join(bundle.BinDir, filepath.Base(bin))
+		if _, ok := files[name]; ok {
+			return env.fail(ExitUsage, "Error: -bin names two binaries called %s", filepath.Base(bin))
+		}
+		data, err := os.ReadFile(bin)
+		if err != nil {
+			return env.failAt(bin, ExitIO, "Error %v", err)
+		}
+		files[name] = data
+	}
+
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	catalog, err := registry.Render()
+	if err != nil {
+		return env.fail(ExitFailure, "Error generating profiles: %v", err)
+	}
+	for _, f := range catalog {
+		files[path.Join(bundle.CatalogDir, f.Name)] = f.Data
+	}
+	schema, err := canonjson.Marshal(profiles.Schema())
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding schema: %v", err)
+	}
+	files[path.Join(bundle.SchemaDir, "switch-profile.schema.json")] = schema
+
+	dir, required := *templatesDir, true
+	if dir == "" {
+		dir = env.getenv(templates.DirEnv)
+	}
+	if dir == "" {
+		dir, required = templates.Dir, false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && (required || !errors.Is(err, fs.ErrNotExist)) {
+		return env.failAt(dir, ExitIO, "Error reading templates: %v", err)
+	}
+	packed := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return env.failAt(filepath.Join(dir, entry.Name()), ExitIO, "Error %v", err)
+		}
+		files[path.Join(bundle.TemplateDir, entry.Name())] = data
+		packed++
+	}
+
+	now := time.Now()
+	var out bytes.Buffer
+	if err := bundle.Write(&out, files, manifest.New(env.Prog, buildVersion(), files, manifest.Manifest{}, now), now); err != nil {
+		return env.fail(ExitFailure, "Error writing bundle: %v", err)
+	}
+	env.info("Packed %d binaries, %d profiles, 1 schema and %d templates", len(binaries), len(catalog), packed)
+	return env.writeFile(*outputFile, out.Bytes())
+}
+
+// BundleVerify checks a bundle written by hnc bundle create against the
+// checksums in its manifest, offline: every listed file must be there
+// unchanged, and nothing else. It exits 3 when a file does not match.
+func BundleVerify(env Env, args []string) int {
+	flags := newFlags(env, "BUNDLE")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if flags.NArg() != 1 {
+		env.fail(ExitUsage, "Error: name one bundle to verify")
+		flags.Usage()
+		return ExitUsage
+	}
+
+	file := flags.Arg(0)
+	f, err := os.Open(file)
+	if err != nil {
+		return env.failAt(file, ExitIO, "Error %v", err)
+	}
+	defer f.Close()
+	files, err := bundle.Read(f)
+	if err != nil {
+		return env.failAt(file, inputExit(err), "Error reading bundle: %v", err)
+	}
+	m, problems, matched, err := bundle.Verify(files)
+	if err != nil {
+		return env.failAt(file, ExitValidation, "Error: %v", err)
+	}
+	for _, p := range problems {
+		env.failAt(file, ExitValidation, "%s: %s", p.Name, p.Message)
+	}
+	if len(problems) > 0 {
+		return env.failAt(file, ExitValidation, "%d file(s) in %s do not match %s", len(problems), file, manifest.File)
+	}
+	env.info("%d file(s) in %s match %s, written by %s %s", matched, file, manifest.File, m.Generator, m.GeneratorVersion)
+	return ExitOK
+}
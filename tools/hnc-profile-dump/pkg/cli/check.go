@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/hnc/profile-dump/pkg/checks"
+	"github.com/hnc/profile-dump/pkg/constraints"
+	"github.com/hnc/profile-dump/pkg/doctor"
+	"github.com/hnc/profile-dump/pkg/lint"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+// Check runs the validators CI gates on in one pass (the lint rules over
+// the profiles, the fixtures against the generator and the JSON Schema,
+// and, with -plan, the plan's feasibility on its switch models) and writes
+// every result as SARIF and JUnit XML. Every validator runs even when an
+// earlier one fails. It exits 1 when any finds an error.
+func Check(env Env, args []string) int {
+	flags := newFlags(env, "[flags]")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	dir := flags.String("dir", "../../src/fixtures/switch-profiles", "Directory of switch profile JSON fixtures to check for drift and against the JSON Schema (\"\" to skip)")
+	planFile := flags.String("plan", "", "Fabric plan to check the feasibility of (default: none)")
+	vpcsFile := flags.String("vpcs", "", "With -plan, VPC plan whose demands the switch models must also meet (default: none)")
+	sarifFile := flags.String("sarif", "hnc-check.sarif", "Output file for the SARIF log (empty to skip)")
+	junitFile := flags.String("junit", "hnc-check.xml", "Output file for the JUnit XML report (empty to skip)")
+	jobs := jobsFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if *vpcsFile != "" && *planFile == "" {
+		return env.fail(ExitUsage, "Error: -vpcs needs -plan")
+	}
+
+	registry, err := loadRegistryJobs(env, *profilesDir, *jobs)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	report := checks.Report{Tool: "hnc", Version: buildVersion()}
+	var names []string
+	for _, p := range registry.List() {
+		names = append(names, p.ModelID)
+	}
+	report.Suites = append(report.Suites, checks.Lint(names, lint.Run(registry.List(), env.lintRules(lint.Rules))))
+
+	if *dir != "" {
+		generated, regenerate, err := generatedFixtures(registry, *dir, *profilesDir, *jobs)
+		if err != nil {
+			return env.fail(ExitFailure, "Error generating profiles: %v", err)
+		}
+		// The schema suite checks every fixture, so drift leaves it out
+		drift := slices.DeleteFunc(doctor.Fixtures(*dir, generated, regenerate), func(c doctor.Check) bool { return c.Name == "schemas" })
+		report.Suites = append(report.Suites,
+			checks.Doctor("fixtures", "Switch profile fixtures against the generator", *dir, drift),
+			checks.Schema(profileFiles(*dir, "*.json")))
+	}
+
+	if *planFile != "" {
+		plan, err := readPlan(*planFile)
+		if err != nil {
+			return env.failAt(*planFile, inputExit(err), "Error %v", err)
+		}
+		var vpcs *vpcplan.Plan
+		if *vpcsFile != "" {
+			if vpcs, err = readVPCPlan(*vpcsFile); err != nil {
+				return env.failAt(*vpcsFile, inputExit(err), "Error %v", err)
+			}
+		}
+		var problems []string
+		leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+		if err != nil {
+			problems = []string{err.Error()}
+		} else {
+			problems = constraints.Check(plan, vpcs, leaf, spine)
+		}
+		report.Suites = append(report.Suites, checks.Plan(*planFile, problems))
+	}
+
+	for _, o := range []struct {
+		file   string
+		render func(checks.Report) ([]byte, error)
+	}{{*sarifFile, checks.SARIF}, {*junitFile, checks.JUnit}} {
+		if o.file == "" {
+			continue
+		}
+		data, err := o.render(report)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding %s: %v", o.file, err)
+		}
+		if code := env.writeFile(o.file, data); code != ExitOK {
+			return code
+		}
+	}
+	printReport(env.Stdout, report)
+
+	if n := report.Count(checks.Error); n > 0 {
+		return env.fail(ExitFailure, "hnc check found %d error(s) and %d warning(s)", n, report.Count(checks.Warning))
+	}
+	env.info("%d suite(s) passed with %d warning(s)", len(report.Suites), report.Count(checks.Warning))
+	return ExitOK
+}
+
+// printReport writes a report as a table of cases, each case's findings
+// indented under it
+func printReport(out io.Writer, r checks.Report) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SUITE\tCASE\tSTATUS")
+	for _, s := range r.Suites {
+		for _, c := range s.Cases {
+			status := "ok"
+			if c.Failed() {
+				status = "fail"
+			} else if len(c.Findings) > 0 {
+				status = "warn"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, c.Name, status)
+			for _, f := range c.Findings {
+				fmt.Fprintf(w, "\t  %s: %s\n", f.Rule, f.Message)
+			}
+		}
+	}
+	w.Flush()
+}
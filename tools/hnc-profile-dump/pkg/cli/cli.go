@@ -0,0 +1,597 @@
+// Package cli implements the hnc subcommands and the conventions they
+// share, so the single hnc binary and the older per-tool binaries behave
+// the same: flags parse with ContinueOnError and print usage to stderr,
+// problems are reported on stderr, every command exits with one of the
+// Exit codes below, every command takes -v, -q and -log-format for its
+// status messages and -errors for its problems, and every command that
+// writes files takes -dry-run to report them instead and -sink (or
+// $HNC_SINK) to publish them to object storage or a GitHub pull request.
+//
+// With -errors=json each problem is one JSON object on a line of stderr,
+// and nothing else is written there:
+//
+//	{"code":"io","exitCode":4,"path":"plan.json","message":"reading plan: open plan.json: no such file or directory"}
+//
+// code names the exit code (failure, usage, validation or io) and path,
+// when present, is the file the problem is in. Scripts should test the
+// exit code or these objects rather than match message text.
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/logging"
+	"github.com/hnc/profile-dump/pkg/progress"
+	"github.com/hnc/profile-dump/pkg/runstats"
+	"github.com/hnc/profile-dump/pkg/sink"
+)
+
+// Exit codes shared by every command
+const (
+	ExitOK         = 0
+	ExitFailure    = 1 // the command could not do what was asked, or a check it ran failed
+	ExitUsage      = 2 // bad flags or arguments
+	ExitValidation = 3 // an input was read but is malformed or breaks the schema or lint rules
+	ExitIO         = 4 // a file could not be read or written
+)
+
+// exitNames are the codes of -errors=json objects
+var exitNames = map[int]string{
+	ExitFailure:    "failure",
+	ExitUsage:      "usage",
+	ExitValidation: "validation",
+	ExitIO:         "io",
+}
+
+// Error formats
+var errorFormats = []string{"text", "json"}
+
+// Env is what a command reads from and writes to. Prog is the command
+// line that reached it (e.g. "hnc profiles dump"), for usage messages.
+type Env struct {
+	Prog   string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Getenv func(key string) string // nil for an empty environment
+
+	dryRun    *dryRun          // set for commands that mutate
+	sink      *outputSink      // set for commands that mutate
+	log       *logging.Options // set by the command's -v, -q and -log-format
+	errFormat *string          // set by -errors
+	stats     *runStats        // set for commands that mutate
+}
+
+// Std is the process environment for a command invoked as prog
+func Std(prog string) Env {
+	return Env{Prog: prog, Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr, Getenv: os.Getenv, log: &logging.Options{}, errFormat: new(string)}
+}
+
+func (env Env) getenv(key string) string {
+	if env.Getenv == nil {
+		return ""
+	}
+	return env.Getenv(key)
+}
+
+// Command is a runnable command, or a group of subcommands when Run is nil.
+// A runnable command may have subcommands too (hnc plan diff), named by
+// its first argument. Mutates marks commands that write files, which then
+// take -dry-run.
+type Command struct {
+	Name     string
+	Summary  string
+	Run      func(env Env, args []string) int
+	Mutates  bool
+	Commands []Command
+}
+
+// Main runs cmd, descending into subcommand groups by name. A group given
+// no subcommand prints its help and exits 2; -h prints it and exits 0.
+// -errors=json anywhere on the command line applies from the start, so
+// an unknown command or flag before it is reported as JSON too, and it
+// may come before the command name.
+func Main(env Env, cmd Command, args []string) int {
+	if env.log == nil {
+		env.log = &logging.Options{}
+	}
+	if env.errFormat == nil {
+		env.errFormat = new(string)
+	}
+	if errorsFlag(args) == "json" {
+		*env.errFormat = "json"
+	}
+	if cmd.Run != nil && len(args) > 0 {
+		for _, sub := range cmd.Commands {
+			if sub.Name == args[0] {
+				env.Prog += " " + sub.Name
+				return Main(env, sub, args[1:])
+			}
+		}
+	}
+	if cmd.Run != nil && cmd.Mutates {
+		return runDryRunnable(env, cmd.Run, args)
+	}
+	if cmd.Run != nil {
+		return cmd.Run(env, args)
+	}
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") && errorsFlag(args[:1]) != "" {
+		args = args[1:] // hnc -errors=json plan ...
+	}
+	if len(args) == 0 {
+		if env.errorsJSON() {
+			return env.fail(ExitUsage, "Error: %s needs a command", env.Prog)
+		}
+		printHelp(env.Stderr, env.Prog, cmd)
+		return ExitUsage
+	}
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		printHelp(env.Stdout, env.Prog, cmd)
+		return ExitOK
+	}
+	for _, sub := range cmd.Commands {
+		if sub.Name == args[0] {
+			env.Prog += " " + sub.Name
+			return Main(env, sub, args[1:])
+		}
+	}
+	env.fail(ExitUsage, "Error: unknown command %q", env.Prog+" "+args[0])
+	if !env.errorsJSON() {
+		printHelp(env.Stderr, env.Prog, cmd)
+	}
+	return ExitUsage
+}
+
+// errorsFlag is the value of the last -errors flag in args, before any --
+func errorsFlag(args []string) string {
+	value := ""
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, v, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-"), "=")
+		if name != "errors" {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			v = args[i+1]
+		}
+		value = v
+	}
+	return value
+}
+
+func printHelp(w io.Writer, prog string, cmd Command) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", prog)
+	for _, sub := range cmd.Commands {
+		fmt.Fprintf(w, "  %-10s %s\n", sub.Name, sub.Summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for a command's flags.\n", prog)
+}
+
+// newFlags is a flag set that reports to stderr instead of exiting, with
+// usage headed by the command line and synopsis. Under -errors=json flag
+// errors and usage are left to parseExit.
+func newFlags(env Env, synopsis string) *flag.FlagSet {
+	flags := flag.NewFlagSet(env.Prog, flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	if env.errorsJSON() {
+		flags.SetOutput(io.Discard)
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s\n\n", env.Prog, synopsis)
+		flags.PrintDefaults()
+	}
+	if env.log != nil {
+		logging.Flags(flags, env.log)
+	}
+	if env.errFormat != nil {
+		flags.Func("errors", "Problem report `format`: "+strings.Join(errorFormats, " or ")+" (default: text)", func(value string) error {
+			if value != "text" && value != "json" {
+				return fmt.Errorf("want %s", strings.Join(errorFormats, " or "))
+			}
+			*env.errFormat = value
+			return nil
+		})
+	}
+	if env.dryRun != nil {
+		flags.Var(env.dryRun, "dry-run", "Report the files the command would write and what it would allocate, without writing; -dry-run=json prints the report as JSON")
+	}
+	if env.stats != nil {
+		flags.StringVar(&env.stats.file, "stats-out", "", "Write a JSON summary of the run (switches, ports and cables generated, time per stage, memory) to `FILE`, "+
+			"for tracking performance across versions; written under -dry-run too (default: none)")
+	}
+	if env.sink != nil {
+		flags.Var(env.sink, "sink", "Publish the files the command writes to `URL`: file: for local files, s3://BUCKET/PREFIX for object storage, "+
+			"or github://OWNER/REPO/PREFIX to commit them to a new branch and open a pull request (default: $HNC_SINK, else file:)")
+	}
+	return flags
+}
+
+// parseExit is the exit code for a flag parse error: -h is not a failure.
+// Under -errors=json, -h still prints usage and other errors are
+// reported as objects.
+func (env Env) parseExit(flags *flag.FlagSet, err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		if env.errorsJSON() {
+			flags.SetOutput(env.Stderr)
+			flags.Usage()
+		}
+		return ExitOK
+	}
+	if env.errorsJSON() {
+		return env.fail(ExitUsage, "Error: %v", err)
+	}
+	return ExitUsage
+}
+
+func (env Env) errorsJSON() bool {
+	return env.errFormat != nil && *env.errFormat == "json"
+}
+
+// logger logs status messages and problems to stderr, as the command's
+// flags ask
+func (env Env) logger() *slog.Logger {
+	opts := logging.Options{}
+	if env.log != nil {
+		opts = *env.log
+	}
+	return logging.New(env.Stderr, opts)
+}
+
+// info logs a status message
+func (env Env) info(format string, args ...any) {
+	env.logger().Info(fmt.Sprintf(format, args...))
+}
+
+// debug logs a message shown only with -v
+func (env Env) debug(format string, args ...any) {
+	env.logger().Debug(fmt.Sprintf(format, args...))
+}
+
+// interruptible is the context for long-running library calls: done on
+// an interrupt, so they stop rather than the process dying mid-write, and
+// logging their progress at debug level. stop restores signal handling.
+func (env Env) interruptible() (ctx context.Context, stop context.CancelFunc) {
+	ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	return progress.WithReporter(ctx, progress.Func(func(e progress.Event) {
+		env.debug("%s: %d of %d", e.Stage, e.Done, e.Total)
+	})), stop
+}
+
+// warn logs a problem that does not stop the command
+func (env Env) warn(format string, args ...any) {
+	env.logger().Warn(fmt.Sprintf(format, args...))
+}
+
+// fail logs a problem and returns code
+func (env Env) fail(code int, format string, args ...any) int {
+	return env.failAt("", code, format, args...)
+}
+
+// failAt logs a problem in the file at path and returns code. An empty
+// path is taken from a file error among args, if there is one.
+func (env Env) failAt(path string, code int, format string, args ...any) int {
+	message := fmt.Sprintf(format, args...)
+	if path == "" {
+		for _, arg := range args {
+			var pathErr *fs.PathError
+			if err, ok := arg.(error); ok && errors.As(err, &pathErr) {
+				path = pathErr.Path
+				break
+			}
+		}
+	}
+	if env.errorsJSON() {
+		report := struct {
+			Code     string `json:"code"`
+			ExitCode int    `json:"exitCode"`
+			Path     string `json:"path,omitempty"`
+			Message  string `json:"message"`
+		}{exitNames[code], code, path, bareMessage(message)}
+		data, _ := json.Marshal(report)
+		env.Stderr.Write(append(data, '\n'))
+		return code
+	}
+	if path != "" {
+		env.logger().Error(message, "exitCode", code, "path", path)
+	} else {
+		env.logger().Error(message, "exitCode", code)
+	}
+	return code
+}
+
+// bareMessage is a problem without its "Error: " lead, which the object's
+// code makes redundant
+func bareMessage(message string) string {
+	for _, lead := range []string{"Error: ", "Error "} {
+		if rest, ok := strings.CutPrefix(message, lead); ok {
+			return rest
+		}
+	}
+	return message
+}
+
+// inputExit is the exit code for an error reading an input: ExitIO when
+// the file could not be read, ExitValidation when what it holds is wrong
+func inputExit(err error) int {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return ExitIO
+	}
+	return ExitValidation
+}
+
+// writeFile writes a generated file and logs it
+func (env Env) writeFile(file string, data []byte) int {
+	env.recorder().File(len(data))
+	done := env.recorder().Stage("write")
+	written, err := env.write(file, data)
+	done()
+	if err != nil {
+		return env.failAt(file, ExitIO, "Error writing %s: %v", file, err)
+	}
+	if written {
+		location := env.output().Location(file)
+		env.logger().Info("Generated "+location, "file", location, "bytes", len(data))
+	}
+	return ExitOK
+}
+
+// write writes a generated file, or to stdout for "-", or under -dry-run
+// records what writing it would do; written reports whether a file was
+// really written
+func (env Env) write(file string, data []byte) (written bool, err error) {
+	if !env.dryRunning() {
+		if file == "-" {
+			_, err := env.Stdout.Write(data)
+			return false, err
+		}
+		ctx, stop := env.interruptible()
+		defer stop()
+		return true, env.output().Write(ctx, file, data)
+	}
+	action := "create"
+	if current, err := os.ReadFile(file); err == nil {
+		action = "update"
+		if bytes.Equal(current, data) {
+			action = "unchanged"
+		}
+	}
+	env.dryRun.changes = append(env.dryRun.changes, change{Action: action, Path: file, Bytes: len(data)})
+	return false, nil
+}
+
+// mkdirAll creates an output directory, except under -dry-run or for a
+// sink that does not write local files
+func (env Env) mkdirAll(dir string) error {
+	if _, local := env.output().(sink.File); env.dryRunning() || !local {
+		return nil
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// output is the sink the command writes files to: local files unless
+// -sink names another
+func (env Env) output() sink.Sink {
+	if env.sink == nil || env.sink.sink == nil {
+		return sink.File{}
+	}
+	return env.sink.sink
+}
+
+// outputSink is the -sink flag and the sink it opens
+type outputSink struct {
+	url    string
+	sink   sink.Sink
+	getenv func(string) string
+}
+
+func (o *outputSink) String() string {
+	if o == nil {
+		return ""
+	}
+	return o.url
+}
+
+func (o *outputSink) Set(value string) error {
+	s, err := sink.Open(value, o.getenv)
+	if err != nil {
+		return err
+	}
+	o.url, o.sink = value, s
+	return nil
+}
+
+// record notes a change other than a file, e.g. the switches a plan
+// allocates, for the -dry-run report
+func (env Env) record(action, format string, args ...any) {
+	if env.dryRunning() {
+		env.dryRun.changes = append(env.dryRun.changes, change{Action: action, Detail: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (env Env) dryRunning() bool {
+	return env.dryRun != nil && env.dryRun.format != ""
+}
+
+// dryRun is the -dry-run flag and the changes recorded under it. Its
+// format is "" when the flag is not given, else text or json.
+type dryRun struct {
+	format  string
+	changes []change
+}
+
+// change is one effect a command would have
+type change struct {
+	Action string `json:"action"` // create, update, unchanged or allocate
+	Path   string `json:"path,omitempty"`
+	Bytes  int    `json:"bytes,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (d *dryRun) String() string {
+	if d == nil {
+		return ""
+	}
+	return d.format
+}
+
+func (d *dryRun) Set(value string) error {
+	switch value {
+	case "true", "text":
+		d.format = "text"
+	case "json":
+		d.format = "json"
+	case "false":
+		d.format = ""
+	default:
+		return fmt.Errorf("want -dry-run or -dry-run=json")
+	}
+	return nil
+}
+
+func (d *dryRun) IsBoolFlag() bool { return true }
+
+// runDryRunnable runs a command that may take -dry-run and then prints its
+// report. With -dry-run=json the report is the only output on stdout; what
+// the command prints goes to stderr.
+func runDryRunnable(env Env, run func(env Env, args []string) int, args []string) (code int) {
+	env.stats = &runStats{rec: runstats.New(env.Prog, buildVersion())}
+	defer func() {
+		if c := env.writeStats(code); code == ExitOK {
+			code = c
+		}
+	}()
+	d := &dryRun{}
+	stdout := env.Stdout
+	env.sink = &outputSink{getenv: env.getenv}
+	if url := env.getenv("HNC_SINK"); url != "" {
+		if err := env.sink.Set(url); err != nil {
+			return env.fail(ExitUsage, "Error: HNC_SINK: %v", err)
+		}
+	}
+	env.dryRun = d
+	env.Stdout = dryRunOutput{d, stdout, env.Stderr}
+	code = run(env, args)
+	if code != ExitOK {
+		return code
+	}
+	if d.format == "" {
+		return env.publish()
+	}
+	if d.format == "json" {
+		report := struct {
+			DryRun  bool     `json:"dryRun"`
+			Command string   `json:"command"`
+			Changes []change `json:"changes"`
+		}{true, env.Prog, append([]change{}, d.changes...)}
+		data, _ := canonjson.Marshal(report)
+		stdout.Write(data)
+		return code
+	}
+	if len(d.changes) == 0 {
+		fmt.Fprintln(stdout, "Dry run: nothing would be written")
+		return code
+	}
+	fmt.Fprintln(stdout, "Dry run; nothing was written:")
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, c := range d.changes {
+		if c.Path != "" {
+			fmt.Fprintf(tw, "  %s\t%s (%d bytes)\n", c.Action, c.Path, c.Bytes)
+		} else {
+			fmt.Fprintf(tw, "  %s\t%s\n", c.Action, c.Detail)
+		}
+	}
+	tw.Flush()
+	return code
+}
+
+// runStats is -stats-out: the file, and the recorder of the run
+type runStats struct {
+	file string
+	rec  *runstats.Recorder
+}
+
+// recorder records the stats of a command that mutates; it is nil, and
+// records nothing, for other commands
+func (env Env) recorder() *runstats.Recorder {
+	if env.stats == nil {
+		return nil
+	}
+	return env.stats.rec
+}
+
+// writeStats writes the run's stats to the -stats-out file, if one is
+// given. It is the run's own record rather than one of its outputs, so it
+// goes to a local file even under -dry-run or -sink.
+func (env Env) writeStats(code int) int {
+	if env.stats == nil || env.stats.file == "" {
+		return ExitOK
+	}
+	data, err := canonjson.Marshal(env.stats.rec.Finish(code))
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding stats: %v", err)
+	}
+	if err := os.WriteFile(env.stats.file, data, 0644); err != nil {
+		return env.failAt(env.stats.file, ExitIO, "Error writing %s: %v", env.stats.file, err)
+	}
+	env.debug("Wrote run stats to %s", env.stats.file)
+	return ExitOK
+}
+
+// publish finishes writing to the command's sink, such as opening the
+// pull request of a GitHub sink
+func (env Env) publish() int {
+	ctx, stop := env.interruptible()
+	defer stop()
+	link, err := env.output().Publish(ctx, "Publish "+env.Prog+" output")
+	if err != nil {
+		return env.fail(ExitIO, "Error publishing to %s: %v", env.sink.url, err)
+	}
+	if link != "" {
+		env.info("Published %s", link)
+	}
+	return ExitOK
+}
+
+// dryRunOutput is a command's stdout, moved to stderr under -dry-run=json
+type dryRunOutput struct {
+	dryRun         *dryRun
+	stdout, stderr io.Writer
+}
+
+func (w dryRunOutput) Write(p []byte) (int, error) {
+	if w.dryRun.format == "json" {
+		return w.stderr.Write(p)
+	}
+	return w.stdout.Write(p)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
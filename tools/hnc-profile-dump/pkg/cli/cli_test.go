@@ -0,0 +1,2216 @@
+package cli
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/bundle"
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/doctor"
+	"github.com/hnc/profile-dump/pkg/drift"
+	"github.com/hnc/profile-dump/pkg/fabricapi"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/facilities"
+	"github.com/hnc/profile-dump/pkg/manifest"
+	"github.com/hnc/profile-dump/pkg/optics"
+	"github.com/hnc/profile-dump/pkg/plandiff"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/resilience"
+	"github.com/hnc/profile-dump/pkg/runstats"
+	"github.com/hnc/profile-dump/pkg/server"
+	"github.com/hnc/profile-dump/pkg/templates"
+	"github.com/hnc/profile-dump/pkg/utilization"
+)
+
+// contractDir holds the fixtures shared with the frontend test suite
+// (regenerated by `npm run fixtures:contract`)
+const contractDir = "../../../../contracts/fixtures"
+
+func testEnv(stdin io.Reader, stdout, stderr io.Writer) Env {
+	return Env{Prog: "hnc", Stdin: stdin, Stdout: stdout, Stderr: stderr}
+}
+
+func TestMainDispatch(t *testing.T) {
+	for _, tc := range []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{args: nil, code: ExitUsage, stderr: "  profiles   Generate, lint"},
+		{args: []string{"-h"}, code: ExitOK, stdout: "  plan       Size a leaf-spine fabric"},
+		{args: []string{"profiles"}, code: ExitUsage, stderr: "Usage: hnc profiles <command>"},
+		{args: []string{"profiles", "nope"}, code: ExitUsage, stderr: `Error: unknown command "hnc profiles nope"`},
+		{args: []string{"profiles", "schema"}, code: ExitOK, stdout: `"$schema"`},
+		{args: []string{"plan", "-h"}, code: ExitOK, stderr: "Usage: hnc plan -endpoints N [flags]"},
+		{args: []string{"plan"}, code: ExitUsage, stderr: "Error: -endpoints is required"},
+		{args: []string{"plan", "-endpoints", "-5"}, code: ExitUsage, stderr: "Error: -endpoints must be positive, got -5"},
+		{args: []string{"plan", "-endpoints", "96", "-min-spines", "-1"}, code: ExitUsage, stderr: "Error: -min-spines must be positive, got -1"},
+		{args: []string{"plan", "-endpoints", "96", "-min-spines", "40"}, code: ExitFailure, stderr: "a minimum of 40 spines needs 40 uplinks on each of 2 leaves"},
+		{args: []string{"plan", "-endpoints", "96", "-optimize", "speed"}, code: ExitUsage, stderr: `Error: unknown -optimize "speed"`},
+		{args: []string{"bom", "-nope"}, code: ExitUsage, stderr: "flag provided but not defined: -nope"},
+		{args: []string{"serve", "-h"}, code: ExitOK, stderr: "Usage: hnc serve [-addr HOST:PORT]"},
+		{args: []string{"serve", "-max-concurrent", "0"}, code: ExitUsage, stderr: "must be positive"},
+		{args: []string{"serve", "-registry", "http://127.0.0.1:1/profiles", "-profiles", "x"}, code: ExitUsage, stderr: "-registry and -profiles are exclusive"},
+		{args: []string{"doctor", "-h"}, code: ExitOK, stderr: "-offline"},
+		{args: []string{"export", "-h"}, code: ExitOK, stderr: "-format"},
+		{args: []string{"import", "wiring"}, code: ExitUsage, stderr: "name one wiring file"},
+		{args: []string{"report", "utilization", "-h"}, code: ExitOK, stderr: "-format"},
+		{args: []string{"optics", "list", "-h"}, code: ExitOK, stderr: "-log-format format"},
+		{args: []string{"plan", "-endpoints", "96", "-log-format", "xml"}, code: ExitUsage, stderr: `invalid value "xml" for flag -log-format`},
+		{args: []string{"layout", "-h"}, code: ExitOK, stderr: "-racks-per-pod"},
+		{args: []string{"report", "power", "-h"}, code: ExitOK, stderr: "-leaves-per-rack"},
+		{args: []string{"vpcs"}, code: ExitUsage, stderr: "Error: -vpcs is required"},
+		{args: []string{"vpcs", "-vpcs", "2", "-vlans", "10"}, code: ExitUsage, stderr: "Error: -vlans: bad range"},
+		{args: []string{"bom", "-nope", "-errors=json"}, code: ExitUsage, stderr: `{"code":"usage","exitCode":2,"message":"flag provided but not defined: -nope"}`},
+		{args: []string{"-errors=json", "profiles", "nope"}, code: ExitUsage, stderr: `"message":"unknown command \"hnc profiles nope\""}`},
+		{args: []string{"plan", "-errors", "yaml"}, code: ExitUsage, stderr: `invalid value "yaml" for flag -errors`},
+		{args: []string{"plan", "diff"}, code: ExitUsage, stderr: "Error: name the old and the new plan"},
+		{args: []string{"plan", "diff", "-h"}, code: ExitOK, stderr: "Usage: hnc plan diff [flags] <old.json> <new.json>"},
+		{args: []string{"plan", "expand"}, code: ExitUsage, stderr: "Error: -endpoints is required"},
+		{args: []string{"plan", "expand", "-endpoints", "-3"}, code: ExitUsage, stderr: "Error: -endpoints must be positive, got -3"},
+		{args: []string{"plan", "-endpoint-classes", "40x25G,8"}, code: ExitUsage, stderr: `Error: -endpoint-classes: bad endpoint class "8"`},
+	} {
+		var stdout, stderr strings.Builder
+		code := Main(testEnv(nil, &stdout, &stderr), Root, tc.args)
+		if code != tc.code || !strings.Contains(stdout.String(), tc.stdout) || !strings.Contains(stderr.String(), tc.stderr) {
+			t.Errorf("hnc %s = %d\nstdout: %s\nstderr: %s", strings.Join(tc.args, " "), code, stdout.String(), stderr.String())
+		}
+	}
+}
+
+// plan, bom, cabling and vpcs chain through the plan file as the
+// separate binaries did
+func TestPlanBOMCabling(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	steps := [][]string{
+		{"plan", "-endpoints", "96", "-output", planFile},
+		{"bom", "-plan", planFile, "-json", filepath.Join(dir, "bom.json"), "-csv", ""},
+		{"cabling", "-plan", planFile, "-output", filepath.Join(dir, "cabling.json")},
+		{"vpcs", "-plan", planFile, "-vpcs", "4", "-subnets", "/25", "-output", filepath.Join(dir, "vpc-plan.json")},
+	}
+	for _, args := range steps {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+
+	data, err := os.ReadFile(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plan fabricplan.Plan
+	if err := json.Unmarshal(data, &plan); err != nil || plan.Leaves == 0 {
+		t.Fatalf("plan = %+v, %v", plan, err)
+	}
+	for _, file := range []string{"bom.json", "cabling.json", "vpc-plan.json"} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Error(err)
+		}
+	}
+	if strings.Contains(stdout.String(), "bom.csv") {
+		t.Errorf("-csv \"\" still wrote a CSV:\n%s", stdout.String())
+	}
+}
+
+// cabling -addressing numbers the map it writes, and only then
+func TestCablingAddressing(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	cablingFile := filepath.Join(dir, "cabling.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", planFile},
+		{"cabling", "-plan", planFile, "-output", cablingFile, "-addressing", "p2p", "-link-pool", "10.255.0.0/24", "-leaf-asns", "64601-64699"},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+	data, err := os.ReadFile(cablingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m cabling.Map
+	if err := json.Unmarshal(data, &m); err != nil || m.Addressing == nil {
+		t.Fatalf("cabling map = %s, %v", data, err)
+	}
+	if l := m.Addressing.Links[1]; l.Link != m.Cables[1].Link || l.SpineAddress != "10.255.0.2/31" || l.LeafAddress != "10.255.0.3/31" {
+		t.Errorf("second link = %+v", l)
+	}
+	if sw := m.Addressing.Switches[len(m.Addressing.Switches)-1]; sw.Role != "leaf" || sw.ASN < 64601 {
+		t.Errorf("last switch = %+v", sw)
+	}
+	if !strings.Contains(stderr.String(), "Numbered ") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+
+	if code := Main(env, Root, []string{"cabling", "-plan", planFile, "-output", cablingFile}); code != ExitOK {
+		t.Fatalf("cabling = %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(cablingFile); strings.Contains(string(data), "addressing") {
+		t.Error("cabling without -addressing numbered the map")
+	}
+	for _, args := range [][]string{
+		{"cabling", "-plan", planFile, "-addressing", "p2p", "-leaf-asns", "65200"},
+		{"cabling", "-plan", planFile, "-addressing", "p2p", "-spine-asn", "0"},
+	} {
+		if code := Main(env, Root, args); code != ExitUsage {
+			t.Errorf("hnc %s = %d, want %d", strings.Join(args, " "), code, ExitUsage)
+		}
+	}
+	if code := Main(env, Root, []string{"cabling", "-plan", planFile, "-output", cablingFile, "-addressing", "p2p", "-link-pool", "10.255.0.0/30"}); code != ExitFailure {
+		t.Errorf("cabling with a /30 link pool = %d, want %d", code, ExitFailure)
+	}
+}
+
+// cabling -seed and vpcs -seed record the seed and the generator, and
+// rerunning with the seed writes the same files
+func TestSeed(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "480", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan = %d: %s", code, stderr.String())
+	}
+	write := func(args ...string) string {
+		t.Helper()
+		out := filepath.Join(dir, args[0]+".json")
+		if code := Main(env, Root, append(args, "-plan", planFile, "-output", out)); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	for _, args := range [][]string{{"cabling", "-seed", "7"}, {"vpcs", "-vpcs", "8", "-seed", "7"}} {
+		first := write(args...)
+		if !strings.Contains(first, `"seed": 7`) || !strings.Contains(first, `"name": "hnc `+args[0]+`"`) || !strings.Contains(first, `"version": "`) {
+			t.Errorf("hnc %s wrote no seed or generator:\n%s", strings.Join(args, " "), first)
+		}
+		if again := write(args...); again != first {
+			t.Errorf("hnc %s wrote different files from the same seed", strings.Join(args, " "))
+		}
+		if unseeded := write(args[:len(args)-2]...); unseeded == first || strings.Contains(unseeded, `"seed"`) {
+			t.Errorf("hnc %s without -seed wrote the seeded file", strings.Join(args, " "))
+		}
+	}
+	if !strings.Contains(stderr.String(), "rerun with -seed 7") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+}
+
+// cabling -layout and bom -layout estimate cable lengths from the racks
+// hnc layout placed the switches in
+func TestLayoutCabling(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	layoutFile := filepath.Join(dir, "layout.json")
+	cablingFile := filepath.Join(dir, "cabling.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", planFile},
+		{"layout", "-plan", planFile, "-output", layoutFile},
+		{"cabling", "-plan", planFile, "-layout", layoutFile, "-output", cablingFile},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+	if !strings.Contains(stderr.String(), "Placed 4 switches and 96 servers in 5 racks across 1 pod(s)") ||
+		!strings.Contains(stderr.String(), "Estimated cable lengths: 5m x 8") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+	m, err := readCabling(cablingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Cables[0].Length != "5m" {
+		t.Errorf("first cable %+v", m.Cables[0])
+	}
+	stderr.Reset()
+	bomFile := filepath.Join(dir, "bom.json")
+	if code := Main(env, Root, []string{"bom", "-plan", planFile, "-layout", layoutFile, "-cabling", cablingFile, "-direct-attach", "-json", bomFile, "-csv", ""}); code != ExitOK {
+		t.Fatalf("bom -layout = %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(bomFile); !strings.Contains(stderr.String(), "Measured runs from") || !strings.Contains(string(data), "GEN-QSFP28-100G-AOC-5M") {
+		t.Errorf("stderr:\n%s\nBOM: %s", stderr.String(), data)
+	}
+	if code := Main(env, Root, []string{"layout", "-plan", planFile, "-output", layoutFile, "-rack-units", "1", "-leaves-per-rack", "2"}); code != ExitFailure {
+		t.Errorf("layout with 1U racks = %d, want %d", code, ExitFailure)
+	}
+}
+
+// plan -optimize picks models from the profiles, and needs their prices
+// to optimize for cost
+func TestPlanOptimize(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	// Of the built-in models only the DS2000 and DS3000 are priced: 2
+	// leaves at 9500 and 2 spines at 14000
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-optimize", "cost", "-output", planFile}); code != ExitOK ||
+		!strings.Contains(stderr.String(), "Optimized for cost: celestica-ds2000 leaves and celestica-ds3000 spines score 47000") {
+		t.Fatalf("plan -optimize cost on the built-in models = %d: %s", code, stderr.String())
+	}
+
+	unpricedDir := filepath.Join(dir, "unpriced")
+	for _, p := range []profiles.SwitchProfile{profiles.DS2000(), profiles.DS3000()} {
+		p.Cost = nil
+		if _, err := profiles.WriteFile(p, unpricedDir, profiles.FileName(p.ModelID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-optimize", "cost", "-profiles", unpricedDir, "-output", planFile}); code != ExitFailure ||
+		!strings.Contains(stderr.String(), "the catalog has no cost data: none of its 1 leaf model(s) sets cost.listPriceUsd") {
+		t.Fatalf("plan -optimize cost without prices = %d: %s", code, stderr.String())
+	}
+
+	profilesDir := filepath.Join(dir, "profiles")
+	for _, p := range []struct {
+		profile profiles.SwitchProfile
+		usd     float64
+	}{{profiles.DS2000(), 15000}, {profiles.DS3000(), 30000}} {
+		p.profile.Cost = &profiles.Cost{ListPriceUSD: p.usd}
+		if _, err := profiles.WriteFile(p.profile, profilesDir, profiles.FileName(p.profile.ModelID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stdout.Reset()
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-optimize", "cost", "-profiles", profilesDir, "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan -optimize cost = %d: %s", code, stderr.String())
+	}
+	plan, err := readPlan(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 leaves and 2 spines
+	if !strings.Contains(stderr.String(), "Optimized for cost: celestica-ds2000 leaves and celestica-ds3000 spines score 90000") || plan.Leaves != 2 {
+		t.Errorf("plan %d leaves, %d spines:\n%s", plan.Leaves, plan.Spines, stderr.String())
+	}
+}
+
+// plan and vpcs refuse designs the switch models' capabilities cannot
+// carry, reporting every shortfall
+func TestPlanCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	profilesDir := filepath.Join(dir, "profiles")
+	leaf := profiles.DS2000()
+	leaf.Capabilities = &profiles.Capabilities{ASIC: "trident3", Tables: profiles.Tables{MACs: 64}}
+	for _, p := range []profiles.SwitchProfile{leaf, profiles.DS3000()} {
+		if _, err := profiles.WriteFile(p, profilesDir, profiles.FileName(p.ModelID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-profiles", profilesDir, "-output", planFile}); code != ExitValidation {
+		t.Fatalf("plan beyond capabilities = %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{
+		"leaf celestica-ds2000 (trident3) holds 64 MAC addresses, the design needs 96",
+		"(1 problem(s)); nothing was written",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr missing %q:\n%s", want, stderr.String())
+		}
+	}
+	if _, err := os.Stat(planFile); err == nil {
+		t.Error("plan written despite the problems")
+	}
+
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "48", "-profiles", profilesDir, "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan within capabilities = %d: %s", code, stderr.String())
+	}
+	if code := Main(env, Root, []string{"vpcs", "-vpcs", "2", "-plan", planFile, "-profiles", profilesDir, "-output", filepath.Join(dir, "vpc-plan.json")}); code != ExitValidation ||
+		!strings.Contains(stderr.String(), "does not support VXLAN routing, for VPCs") {
+		t.Errorf("vpcs without VXLAN routing = %d: %s", code, stderr.String())
+	}
+}
+
+// plan warns of deprecated models, or with -deprecated refuse rejects the
+// design and leaves them out of -optimize, while import wiring takes them
+// as deployed
+func TestPlanDeprecated(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	profilesDir := filepath.Join(dir, "profiles")
+	leaf, successor := profiles.DS2000(), profiles.DS2000()
+	leaf.Lifecycle = &profiles.Lifecycle{Status: profiles.LifecycleEndOfSale, EndOfLife: "2027-06-30", Replacement: "celestica-ds2100"}
+	successor.ModelID = "celestica-ds2100"
+	for _, p := range []profiles.SwitchProfile{leaf, successor, profiles.DS3000()} {
+		p.Cost = &profiles.Cost{ListPriceUSD: 15000}
+		if _, err := profiles.WriteFile(p, profilesDir, profiles.FileName(p.ModelID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	const retired = "celestica-ds2000 is end-of-sale, end of life 2027-06-30; use celestica-ds2100 instead"
+	if code := Main(env, Root, []string{"plan", "-endpoints", "48", "-profiles", profilesDir, "-output", planFile}); code != ExitOK ||
+		!strings.Contains(stderr.String(), "Warning: "+retired) {
+		t.Fatalf("plan with a deprecated leaf = %d: %s", code, stderr.String())
+	}
+
+	stderr.Reset()
+	os.Remove(planFile)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "48", "-deprecated", "refuse", "-profiles", profilesDir, "-output", planFile}); code != ExitValidation ||
+		!strings.Contains(stderr.String(), "Error: "+retired) || !strings.Contains(stderr.String(), "places 1 retired model(s)") {
+		t.Fatalf("plan -deprecated refuse = %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(planFile); err == nil {
+		t.Error("plan written despite the retired leaf")
+	}
+
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "48", "-deprecated", "refuse", "-optimize", "cost", "-profiles", profilesDir, "-output", planFile}); code != ExitOK ||
+		!strings.Contains(stderr.String(), "celestica-ds2100 leaves and celestica-ds3000 spines") {
+		t.Errorf("plan -deprecated refuse -optimize = %d: %s", code, stderr.String())
+	}
+	// The built-in models are all active
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "48", "-deprecated", "refuse", "-output", planFile}); code != ExitOK || strings.Contains(stderr.String(), "retired") {
+		t.Errorf("plan -deprecated refuse on the built-in models = %d: %s", code, stderr.String())
+	}
+	if code := Main(env, Root, []string{"plan", "-endpoints", "48", "-deprecated", "never", "-output", planFile}); code != ExitUsage {
+		t.Errorf("plan -deprecated never = %d, want %d", code, ExitUsage)
+	}
+
+	stderr.Reset()
+	args := []string{"import", "wiring", "-profiles", profilesDir, "-output", planFile, "-cabling", filepath.Join(dir, "cabling.json"), "../wiringyaml/testdata/wiring.yaml"}
+	if code := Main(env, Root, args); code != ExitOK || strings.Contains(stderr.String(), "end-of-sale") {
+		t.Errorf("import wiring of deprecated leaves = %d: %s", code, stderr.String())
+	}
+}
+
+// plan -watch replans when the profiles change, keeps watching through
+// a failed replan, and reports the outputs that changed
+func TestPlanWatch(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	profilesDir := filepath.Join(dir, "profiles")
+	writeLeaf := func(macs int) {
+		leaf := profiles.DS2000()
+		leaf.Capabilities = &profiles.Capabilities{ASIC: "trident3", Tables: profiles.Tables{MACs: macs}}
+		if _, err := profiles.WriteFile(leaf, profilesDir, profiles.FileName(leaf.ModelID)); err != nil {
+			t.Error(err)
+		}
+	}
+	writeLeaf(64)
+	if _, err := profiles.WriteFile(profiles.DS3000(), profilesDir, profiles.FileName(profiles.DS3000().ModelID)); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "48", "-watch"},
+		{"plan", "-endpoints", "48", "-profiles", profilesDir, "-watch", "-interactive"},
+		{"profiles", "dump", "-watch"},
+		{"profiles", "dump", "-input", profilesDir, "-output", "-", "-watch"},
+	} {
+		if code := Main(env, Root, args); code != ExitUsage {
+			t.Errorf("hnc %s = %d, want %d", strings.Join(args, " "), code, ExitUsage)
+		}
+	}
+
+	// Each run makes the next change: too small a MAC table, then back,
+	// with the plan removed so the last run creates it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	runs := 0
+	run := func() int {
+		runs++
+		code := Main(env, Root, []string{"plan", "-endpoints", "48", "-profiles", profilesDir, "-output", planFile})
+		switch runs {
+		case 1:
+			time.AfterFunc(20*time.Millisecond, func() { writeLeaf(8) })
+		case 2:
+			time.AfterFunc(20*time.Millisecond, func() { os.Remove(planFile); writeLeaf(64) })
+		default:
+			cancel()
+		}
+		return code
+	}
+	stderr.Reset()
+	env.watchLoop(ctx, []string{profilesDir}, []string{planFile}, 5*time.Millisecond, 50*time.Millisecond, run)
+	if runs != 3 {
+		t.Fatalf("%d runs, want 3: %s", runs, stderr.String())
+	}
+	for _, want := range []string{"Watching " + profilesDir, "holds 8 MAC addresses", "Regenerating failed (exit 3)", "Created " + planFile + " (+"} {
+		if !strings.Contains(stderr.String()+stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s%s", want, stdout.String(), stderr.String())
+		}
+	}
+}
+
+// plan -workload roce plans only lossless switches, within 2:1, and
+// writes their QoS settings into the plan
+func TestPlanRoCE(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	// The built-in DS2000 and DS3000 are lossless
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-workload", "roce", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan -workload roce = %d: %s", code, stderr.String())
+	}
+	plan, err := readPlan(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Request.Oversubscription != 2 || plan.Lossless == nil || len(plan.Lossless.Ports) != 3 || plan.UplinksPerLeaf%plan.Spines != 0 {
+		t.Errorf("plan = %+v", plan)
+	}
+	if !strings.Contains(stderr.String(), "leaf endpoint ports, SFP28-25G 25G: 25 KB headroom, ECN from 37 KB to 375 KB") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-workload", "roce", "-oversubscription", "3", "-output", planFile}); code != ExitFailure ||
+		!strings.Contains(stderr.String(), "oversubscribed at most 2:1, not 3:1") {
+		t.Fatalf("plan -workload roce at 3:1 = %d: %s", code, stderr.String())
+	}
+
+	profilesDir := filepath.Join(dir, "profiles")
+	for _, p := range []profiles.SwitchProfile{profiles.DS2000(), profiles.DS3000()} {
+		p.Capabilities.Features.RoCE = false
+		if _, err := profiles.WriteFile(p, profilesDir, profiles.FileName(p.ModelID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-workload", "roce", "-profiles", profilesDir, "-output", planFile}); code != ExitFailure ||
+		!strings.Contains(stderr.String(), "leaf celestica-ds2000 is not lossless") {
+		t.Fatalf("plan -workload roce on lossy profiles = %d: %s", code, stderr.String())
+	}
+}
+
+// plan -endpoint-classes places each class, and report utilization
+// lists them per leaf
+func TestPlanEndpointClasses(t *testing.T) {
+	planFile := filepath.Join(t.TempDir(), "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoint-classes", "60x25G,19x10G", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan -endpoint-classes = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Placed 19x10G: up to 10 per leaf on 10 25G ports") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"report", "utilization", "-plan", planFile}); code != ExitOK ||
+		!strings.Contains(stdout.String(), "30x25G on 30 ports, 9x10G on 9 ports") {
+		t.Errorf("report utilization = %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if code := Main(env, Root, []string{"plan", "-endpoint-classes", "16x100G", "-output", planFile}); code != ExitFailure ||
+		!strings.Contains(stderr.String(), "too slow for 100G endpoints") {
+		t.Errorf("plan with 100G endpoints on 25G leaves = %d: %s", code, stderr.String())
+	}
+}
+
+// A collapsed core needs no spine profile down the pipeline
+func TestPlanCollapsedCore(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "40", "-topology", "collapsed-core", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan -topology collapsed-core = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Planned a collapsed-core fabric: 2 leaves, no spines, 40 endpoints per leaf") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+	for _, args := range [][]string{
+		{"cabling", "-plan", planFile, "-output", filepath.Join(dir, "cabling.json"), "-csv", ""},
+		{"bom", "-plan", planFile, "-json", filepath.Join(dir, "bom.json"), "-csv", ""},
+		{"report", "utilization", "-plan", planFile},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Errorf("%v = %d: %s", args, code, stderr.String())
+		}
+	}
+	for args, want := range map[string]string{
+		"-spine DS3000":  "has no spines; drop -spine",
+		"-optimize cost": "-optimize pairs leaves with spines",
+	} {
+		stderr.Reset()
+		argv := append([]string{"plan", "-endpoints", "40", "-topology", "single-switch", "-output", planFile}, strings.Fields(args)...)
+		if code := Main(env, Root, argv); code != ExitUsage || !strings.Contains(stderr.String(), want) {
+			t.Errorf("plan %s = %d: %s", args, code, stderr.String())
+		}
+	}
+}
+
+// A multi-pod plan carries its super-spines down the pipeline, but has
+// no Hedgehog wiring to export to
+func TestPlanPods(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "2000", "-pods", "4", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan -pods 4 = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Split into 4 pods of 11 leaves and 2 spines; 2 super-spines (celestica-ds3000) take 8 uplinks per spine (2.75:1 between pods)") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"cabling", "-output", filepath.Join(dir, "cabling.json"), "-csv", ""}, "Assigned 64 spine uplinks for 4 pods and 2 super-spines"},
+		{[]string{"bom", "-json", filepath.Join(dir, "bom.json"), "-csv", ""}, "44 leaves, 8 spines and 2 super-spines"},
+		{[]string{"report", "utilization"}, "superspine2  super-spine"},
+		{[]string{"diagram"}, `"4 pods, 44 leaves, 8 spines, 2 super-spines"`},
+	} {
+		stdout.Reset()
+		stderr.Reset()
+		argv := append(tt.args, "-plan", planFile)
+		if code := Main(env, Root, argv); code != ExitOK || !strings.Contains(stdout.String()+stderr.String(), tt.want) {
+			t.Errorf("%v = %d, want %q:\n%s%s", tt.args, code, tt.want, stdout.String(), stderr.String())
+		}
+	}
+	for args, want := range map[string]string{
+		"export -plan " + planFile:                    "no super-spine role, so the 4-pod plan cannot be exported",
+		"plan -endpoints 200 -min-super-spines 2":     "need -pods",
+		"plan -endpoints 2000 -pods 4 -optimize cost": "-optimize",
+	} {
+		stderr.Reset()
+		argv := append(strings.Fields(args), "-output", filepath.Join(dir, "out"))
+		if code := Main(env, Root, argv); code == ExitOK || !strings.Contains(stderr.String(), want) {
+			t.Errorf("%s = %d: %s", args, code, stderr.String())
+		}
+	}
+}
+
+// Border leaf uplinks run from the plan to the cabling map, the BOM and
+// the utilization report
+func TestPlanBorderLeaves(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-external-ports", "4", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan -external-ports 4 = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Reserved 4 external ports on each of 2 border leaves (leaf2-leaf3) for 2 external peers") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"cabling", "-output", filepath.Join(dir, "cabling.json")}, "Assigned 8 external uplinks from 2 border leaves to 2 external peers"},
+		{[]string{"report", "utilization"}, "leaf3   leaf   celestica-ds2000  36/48 (75.0%)"},
+	} {
+		stdout.Reset()
+		stderr.Reset()
+		if code := Main(env, Root, append(tt.args, "-plan", planFile)); code != ExitOK || !strings.Contains(stdout.String()+stderr.String(), tt.want) {
+			t.Errorf("%v = %d, want %q:\n%s%s", tt.args, code, tt.want, stdout.String(), stderr.String())
+		}
+	}
+	bomFile := filepath.Join(dir, "bom.csv")
+	if code := Main(env, Root, []string{"bom", "-plan", planFile, "-json", "", "-csv", bomFile, "-external-length", "30m"}); code != ExitOK {
+		t.Fatalf("bom = %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(bomFile); !strings.Contains(string(data), "cable,30m,25G border leaf to external,8,") {
+		t.Errorf("bom.csv:\n%s", data)
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-border-leaves", "2", "-output", planFile}); code != ExitUsage || !strings.Contains(stderr.String(), "need -external-ports") {
+		t.Errorf("plan -border-leaves without -external-ports = %d: %s", code, stderr.String())
+	}
+}
+
+// -xlsx and -format xlsx write workbooks with a sheet per artifact
+func TestWorkbooks(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	planCSV := filepath.Join(dir, "plan.csv")
+	if code := Main(env, Root, []string{"plan", "-endpoints", "100", "-output", planFile, "-csv", planCSV, "-xlsx", filepath.Join(dir, "plan.xlsx")}); code != ExitOK {
+		t.Fatalf("plan -csv -xlsx = %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(planCSV); !strings.HasPrefix(string(data), "field,value\nrequest.endpoints,100\n") || !strings.Contains(string(data), "\nleaves,3\n") {
+		t.Errorf("plan.csv:\n%s", data)
+	}
+	for _, tt := range []struct {
+		args   []string
+		file   string
+		sheets []string
+	}{
+		{[]string{"plan", "-endpoints", "100", "-output", planFile}, "plan.xlsx", []string{"Plan"}},
+		{[]string{"bom", "-plan", planFile, "-json", "", "-csv", ""}, "bom.xlsx", []string{"Plan", "BOM"}},
+		{[]string{"cabling", "-plan", planFile, "-output", filepath.Join(dir, "cabling.json")}, "cabling.xlsx", []string{"Plan", "Cabling"}},
+		{[]string{"report", "power", "-plan", planFile, "-format", "xlsx", "-output"}, "power.xlsx", []string{"Power"}},
+	} {
+		file := filepath.Join(dir, tt.file)
+		args := append(tt.args, file)
+		if tt.args[0] != "report" {
+			args = append(tt.args, "-xlsx", file)
+		}
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("%v = %d: %s", args, code, stderr.String())
+		}
+		z, err := zip.OpenReader(file)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		var names []string
+		for _, f := range z.File {
+			if f.Name == "xl/workbook.xml" {
+				r, _ := f.Open()
+				data, _ := io.ReadAll(r)
+				for _, m := range regexp.MustCompile(`<sheet name="([^"]+)"`).FindAllStringSubmatch(string(data), -1) {
+					names = append(names, m[1])
+				}
+			}
+		}
+		z.Close()
+		if !slices.Equal(names, tt.sheets) {
+			t.Errorf("%s sheets = %v, want %v", tt.file, names, tt.sheets)
+		}
+	}
+}
+
+// plan -output - writes the plan to stdout rather than a file named -
+func TestPlanOutputStdout(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+
+	var stdout, stderr strings.Builder
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"plan", "-endpoints", "96", "-output", "-"}); code != ExitOK {
+		t.Fatalf("plan -output - = %d: %s", code, stderr.String())
+	}
+	var plan fabricplan.Plan
+	if err := json.Unmarshal([]byte(stdout.String()), &plan); err != nil || plan.Leaves != 2 {
+		t.Errorf("stdout = %s, %v", stdout.String(), err)
+	}
+	if _, err := os.Stat("-"); err == nil || strings.Contains(stderr.String(), "Generated") {
+		t.Errorf("plan -output - wrote a file named -: %s", stderr.String())
+	}
+}
+
+// -sink puts what a command writes in object storage, and $HNC_SINK
+// names the sink when the flag does not
+func TestPlanSink(t *testing.T) {
+	objects := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		objects[r.Method+" "+r.URL.Path] = string(data)
+	}))
+	defer srv.Close()
+	vars := map[string]string{"AWS_ACCESS_KEY_ID": "AK", "AWS_SECRET_ACCESS_KEY": "SK", "AWS_ENDPOINT_URL": srv.URL}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	env.Getenv = func(key string) string { return vars[key] }
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-sink", "s3://fabrics/ci"}); code != ExitOK {
+		t.Fatalf("plan -sink = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Generated s3://fabrics/ci/fabric-plan.json") || !strings.Contains(objects["PUT /fabrics/ci/fabric-plan.json"], `"leafModel"`) {
+		t.Errorf("objects %v after:\n%s", objects, stderr.String())
+	}
+	if _, err := os.Stat("fabric-plan.json"); err == nil {
+		t.Error("plan -sink also wrote fabric-plan.json locally")
+	}
+
+	vars["HNC_SINK"] = "s3://fabrics/env"
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-output", "plans/fabric-plan.json"}); code != ExitOK || objects["PUT /fabrics/env/plans/fabric-plan.json"] == "" {
+		t.Errorf("plan with $HNC_SINK = %d: %v", code, objects)
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-output", filepath.Join(t.TempDir(), "plan.json")}); code != ExitIO || !strings.Contains(stderr.String(), "outside the working directory") {
+		t.Errorf("plan -output outside the working directory = %d: %s", code, stderr.String())
+	}
+	vars["HNC_SINK"] = "s3://fabrics"
+	delete(vars, "AWS_SECRET_ACCESS_KEY")
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96"}); code != ExitUsage || !strings.Contains(stderr.String(), "HNC_SINK: s3 sink needs AWS_ACCESS_KEY_ID") {
+		t.Errorf("plan with a bad $HNC_SINK = %d: %s", code, stderr.String())
+	}
+}
+
+// plan diff compares an expansion against the plan it grows
+// plan -interactive asks again after a bad answer, previews the fabric
+// and writes the plan the answers make, with the flags as defaults
+func TestPlanInteractive(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fabric-plan.json")
+	answers := strings.Join([]string{"", "160", "", "mclagg", "mclag", "", "", "", "3", "y"}, "\n") + "\n"
+	var stdout, stderr strings.Builder
+	if code := Main(testEnv(strings.NewReader(answers), &stdout, &stderr), Root, []string{"plan", "-interactive", "-endpoints", "96", "-output", file}); code != ExitOK {
+		t.Fatalf("plan -interactive = %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{"Endpoints, a port count", "[96]: ", "want none, mclag, eslag", "preview: "} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("plan -interactive asked without %q:\n%s", want, stderr.String())
+		}
+	}
+	plan, err := readPlan(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Request.Endpoints != 160 || plan.Request.Redundancy != "mclag" || plan.Request.MinSpines != 3 || plan.Spines < 3 {
+		t.Errorf("plan -interactive planned %+v", plan.Request)
+	}
+
+	stderr.Reset()
+	declined := filepath.Join(t.TempDir(), "fabric-plan.json")
+	if code := Main(testEnv(strings.NewReader("\n\n\n\n\n\n\n\nn\n"), &stdout, &stderr), Root, []string{"plan", "-interactive", "-endpoints", "96", "-output", declined}); code != ExitFailure {
+		t.Errorf("plan -interactive declined = %d, want %d", code, ExitFailure)
+	}
+	if _, err := os.Stat(declined); err == nil {
+		t.Error("plan -interactive wrote a declined plan")
+	}
+	if code := Main(testEnv(strings.NewReader(""), &stdout, &stderr), Root, []string{"plan", "-interactive"}); code != ExitFailure {
+		t.Errorf("plan -interactive without answers = %d, want %d", code, ExitFailure)
+	}
+}
+
+// hnc tui edits a plan with the wizard's questions and checks and writes
+// it through hnc plan, reporting to the session
+func TestTUI(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"plan", "-endpoints", "96", "-output", file}); code != ExitOK {
+		t.Fatalf("plan = %d: %s", code, stderr.String())
+	}
+	session := strings.Join([]string{"profiles spine", "edit endpoints redundancy", "160", "mclag", "check", "write", "edit endpoints", "100000", "write", "bogus", "quit"}, "\n") + "\n"
+	stdout.Reset()
+	if code := Main(testEnv(strings.NewReader(session), &stdout, &stderr), Root, []string{"tui", "-plan", file}); code != ExitOK {
+		t.Fatalf("tui = %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	for _, want := range []string{"endpoints         96", "celestica-ds3000  spine", "Endpoints, a port count", "Dry run; nothing was written", "Generated " + file, "Error: ", `Unknown command "bogus"`} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("tui session lacks %q:\n%s", want, stdout.String())
+		}
+	}
+	plan, err := readPlan(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Request.Endpoints != 160 || plan.Request.Redundancy != "mclag" {
+		t.Errorf("tui wrote %+v, want the 160 mclag endpoints and not the plan that failed", plan.Request)
+	}
+
+	if code := Main(testEnv(strings.NewReader(""), &stdout, &stderr), Root, []string{"tui", "-profiles", "-"}); code != ExitUsage {
+		t.Errorf("tui -profiles - = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestPlanDiff(t *testing.T) {
+	dir := t.TempDir()
+	oldFile, newFile := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", oldFile},
+		{"plan", "-endpoints", "160", "-output", newFile},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("%v = %d: %s", args, code, stderr.String())
+		}
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"plan", "diff", "-json", oldFile, newFile}); code != ExitOK {
+		t.Fatalf("plan diff = %d: %s", code, stderr.String())
+	}
+	var diff plandiff.Diff
+	if err := json.Unmarshal([]byte(stdout.String()), &diff); err != nil {
+		t.Fatalf("%v:\n%s", err, stdout.String())
+	}
+	var added []string
+	for _, c := range diff.Switches {
+		if c.Kind == plandiff.Added {
+			added = append(added, c.Object)
+		}
+	}
+	if want := []string{"switch/leaf3", "switch/leaf4"}; !reflect.DeepEqual(added, want) || len(diff.Capacity) == 0 {
+		t.Errorf("added %q, want %q; capacity %+v", added, want, diff.Capacity)
+	}
+
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "diff", oldFile, oldFile}); code != ExitOK || !strings.HasPrefix(stderr.String(), "No differences") {
+		t.Errorf("plan diff of a plan with itself = %d:\n%s", code, stderr.String())
+	}
+	if code := Main(env, Root, []string{"plan", "diff", "-old-vpcs", "v.json", oldFile, newFile}); code != ExitUsage {
+		t.Errorf("plan diff -old-vpcs alone = %d, want %d", code, ExitUsage)
+	}
+}
+
+// A price list estimates the cost of a BOM, of each saved scenario and of
+// the change between two plans
+func TestPricing(t *testing.T) {
+	dir := t.TempDir()
+	oldFile, newFile := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	pricesFile, costFile := filepath.Join(dir, "prices.csv"), filepath.Join(dir, "bom-cost.json")
+	// No price for the 3m endpoint cables
+	prices := "sku,unit_price,min_quantity,discount_percent\n" +
+		"CELESTICA-DS2000,10000,,\nCELESTICA-DS3000,20000,,\n" +
+		"GEN-QSFP28-100G-SR4,100,40,10\nGEN-SFP28-25G-SR,20,,\n10m,10,,\n"
+	if err := os.WriteFile(pricesFile, []byte(prices), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "200", "-output", oldFile},
+		{"plan", "-endpoints", "400", "-output", newFile},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("%v = %d: %s", args, code, stderr.String())
+		}
+	}
+
+	stderr.Reset()
+	argv := []string{"bom", "-plan", oldFile, "-json", "", "-csv", "", "-pricing", pricesFile, "-cost", costFile}
+	if code := Main(env, Root, argv); code != ExitOK {
+		t.Fatalf("bom -pricing = %d: %s", code, stderr.String())
+	}
+	// 5 leaves and 2 spines, 40 uplink optics at 10% off, 200 endpoint
+	// optics and 20 fabric fibers
+	if want := "Estimated cost: 97800.00 (switches 90000.00, optics 7600.00, cables 200.00)"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("stderr missing %q:\n%s", want, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "prices.csv prices no 3m; the estimate leaves them out") {
+		t.Errorf("stderr = %s", stderr.String())
+	}
+	var estimate struct{ Total float64 }
+	if data, err := os.ReadFile(costFile); err != nil || json.Unmarshal(data, &estimate) != nil || estimate.Total != 97800 {
+		t.Errorf("%s = %+v, %v", costFile, estimate, err)
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"plan", "diff", "-pricing", pricesFile, oldFile, newFile}); code != ExitOK {
+		t.Fatalf("plan diff -pricing = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Cost:\nCATEGORY  OLD") || !regexp.MustCompile(`\ntotal +97800\.00 +\d+\.00 +\+\d+\.00\n`).MatchString(stdout.String()) {
+		t.Errorf("plan diff -pricing:\n%s", stdout.String())
+	}
+
+	store := filepath.Join(dir, "scenarios")
+	if code := Main(env, Root, []string{"scenario", "save", "-store", store, "-plan", oldFile, "small"}); code != ExitOK {
+		t.Fatalf("scenario save = %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"scenario", "list", "-store", store, "-pricing", pricesFile}); code != ExitOK ||
+		!strings.Contains(stdout.String(), "COST") || !strings.Contains(stdout.String(), "97800.00") {
+		t.Errorf("scenario list -pricing = %d:\n%s", code, stdout.String())
+	}
+	if code := Main(env, Root, []string{"bom", "-plan", oldFile, "-pricing", filepath.Join(dir, "none.csv")}); code != ExitIO {
+		t.Errorf("bom -pricing of a missing file = %d, want %d", code, ExitIO)
+	}
+}
+
+// Scenarios save plans with their cabling maps, list and load them back,
+// and compare with plan diff
+func TestScenarios(t *testing.T) {
+	dir := t.TempDir()
+	store := filepath.Join(dir, "scenarios")
+	plan, cablingFile := filepath.Join(dir, "fabric-plan.json"), filepath.Join(dir, "cabling.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", plan},
+		{"cabling", "-plan", plan, "-output", cablingFile},
+		{"scenario", "save", "-store", store, "-plan", plan, "-cabling", cablingFile, "-note", "today", "small"},
+		{"plan", "-endpoints", "160", "-output", plan},
+		{"scenario", "save", "-store", store, "-plan", plan, "large"},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("%v = %d: %s", args, code, stderr.String())
+		}
+	}
+	if code := Main(env, Root, []string{"scenario", "save", "-store", store, "-plan", plan, "large"}); code != ExitFailure {
+		t.Errorf("scenario save over a saved scenario = %d, want %d", code, ExitFailure)
+	}
+	if code := Main(env, Root, []string{"scenario", "save", "-store", store, "-plan", plan, "../large"}); code != ExitUsage {
+		t.Errorf("scenario save of a bad name = %d, want %d", code, ExitUsage)
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"scenario", "list", "-store", store, "-json"}); code != ExitOK {
+		t.Fatalf("scenario list = %d: %s", code, stderr.String())
+	}
+	var list []struct {
+		Name      string   `json:"name"`
+		Note      string   `json:"note"`
+		Files     []string `json:"files"`
+		Endpoints int      `json:"endpoints"`
+		Leaves    int      `json:"leaves"`
+	}
+	if err := json.Unmarshal([]byte(stdout.String()), &list); err != nil {
+		t.Fatalf("%v:\n%s", err, stdout.String())
+	}
+	if len(list) != 2 || list[0].Name != "large" || list[0].Endpoints != 160 || list[1].Note != "today" ||
+		!reflect.DeepEqual(list[1].Files, []string{"fabric-plan.json", "cabling.json"}) {
+		t.Errorf("scenario list = %+v", list)
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"scenario", "diff", "-store", store, "-json", "small", "large"}); code != ExitOK {
+		t.Fatalf("scenario diff = %d: %s", code, stderr.String())
+	}
+	var diff plandiff.Diff
+	if err := json.Unmarshal([]byte(stdout.String()), &diff); err != nil {
+		t.Fatalf("%v:\n%s", err, stdout.String())
+	}
+	if len(diff.Switches) == 0 {
+		t.Errorf("scenario diff found no switch changes:\n%s", stdout.String())
+	}
+
+	out := filepath.Join(dir, "restored")
+	if code := Main(env, Root, []string{"scenario", "load", "-store", store, "-output", out, "small"}); code != ExitOK {
+		t.Fatalf("scenario load = %d: %s", code, stderr.String())
+	}
+	for _, name := range []string{"fabric-plan.json", "cabling.json"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("scenario load did not write %s: %v", name, err)
+		}
+	}
+	if code := Main(env, Root, []string{"scenario", "delete", "-store", store, "small"}); code != ExitOK {
+		t.Fatalf("scenario delete = %d: %s", code, stderr.String())
+	}
+	if code := Main(env, Root, []string{"scenario", "load", "-store", store, "small"}); code != ExitIO {
+		t.Errorf("scenario load of a deleted scenario = %d, want %d", code, ExitIO)
+	}
+}
+
+// plan expand adds leaves and cables and keeps the existing ones, with
+// their addresses
+func TestPlanExpand(t *testing.T) {
+	dir := t.TempDir()
+	planFile, cablingFile := filepath.Join(dir, "fabric-plan.json"), filepath.Join(dir, "cabling.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", planFile},
+		{"cabling", "-plan", planFile, "-addressing", "p2p", "-output", cablingFile},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("%v = %d: %s", args, code, stderr.String())
+		}
+	}
+	before, err := readCabling(cablingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	args := []string{"plan", "expand", "-endpoints", "200", "-plan", planFile, "-cabling", cablingFile, "-output", planFile, "-cabling-output", cablingFile}
+	if code := Main(env, Root, args); code != ExitOK {
+		t.Fatalf("plan expand = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "3 new leaves with 12 cables; the 2 existing leaves keep their cables and addresses") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+	plan, err := readPlan(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := readCabling(cablingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Leaves != 5 || len(after.Cables) != 20 || !reflect.DeepEqual(after.Cables[:8], before.Cables) ||
+		!reflect.DeepEqual(after.Addressing.Links[:8], before.Addressing.Links) {
+		t.Errorf("grown plan has %d leaves, cabling %d cables; existing cables or addresses changed", plan.Leaves, len(after.Cables))
+	}
+	args[3] = "100"
+	if code := Main(env, Root, args); code != ExitFailure || !strings.Contains(stderr.String(), "cannot shrink it to 100") {
+		t.Errorf("plan expand to fewer endpoints = %d: %s", code, stderr.String())
+	}
+}
+
+// import platform writes a profile definition hnc plan loads
+func TestImportPlatform(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	testdata := filepath.Join("..", "sonicplatform", "testdata")
+	args := []string{"import", "platform", "-model-id", "example-leaf56", "-hwsku", filepath.Join(testdata, "hwsku.json"),
+		"-format", "json", "-output", dir, filepath.Join(testdata, "platform.json")}
+	if code := Main(env, Root, args); code != ExitOK {
+		t.Fatalf("import platform = %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{"Warning: skipped breakout mode 1x50G(2)+2x25G(2)", "Imported example-leaf56 as a leaf with 48 endpoint and 8 fabric ports"} {
+		if !strings.Contains(stderr.String()+stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s%s", want, stdout.String(), stderr.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "leaf56.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := profiles.WriteFile(profiles.DS3000(), dir, "ds3000.json"); err != nil {
+		t.Fatal(err)
+	}
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-leaf", "example-leaf56", "-profiles", dir, "-output", filepath.Join(dir, "plan.json")}); code != ExitOK {
+		t.Fatalf("plan with the imported leaf = %d: %s", code, stderr.String())
+	}
+
+	for _, args := range [][]string{
+		{"import", "platform", filepath.Join(testdata, "platform.json")},
+		{"import", "platform", "-model-id", "example-leaf56", "-format", "xml", filepath.Join(testdata, "platform.json")},
+	} {
+		if code := Main(env, Root, args); code != ExitUsage {
+			t.Errorf("hnc %s = %d, want %d", strings.Join(args, " "), code, ExitUsage)
+		}
+	}
+	if code := Main(env, Root, []string{"import", "platform", "-model-id", "example-leaf56", "-fabric-ports", "99", filepath.Join(testdata, "platform.json")}); code != ExitValidation {
+		t.Errorf("import platform -fabric-ports 99 = %d, want %d", code, ExitValidation)
+	}
+}
+
+// import endpoints writes an inventory hnc plan sizes from and hnc
+// cabling and vpcs cable and attach server by server
+func TestImportEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	inventoryFile := filepath.Join(dir, "endpoints.json")
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	cablingFile := filepath.Join(dir, "cabling.json")
+	vpcsFile := filepath.Join(dir, "vpc-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(strings.NewReader("hostname,nics,nic_speed,rack\nweb01,2,25G,r1\nweb02,1,25G,r2\ndb01,1,10G,r1\n"), &stdout, &stderr)
+	for _, args := range [][]string{
+		{"import", "endpoints", "-output", inventoryFile, "-"},
+		{"plan", "-inventory", inventoryFile, "-output", planFile},
+		{"cabling", "-plan", planFile, "-inventory", inventoryFile, "-output", cablingFile},
+		{"vpcs", "-plan", planFile, "-vpcs", "2", "-inventory", inventoryFile, "-output", vpcsFile},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+	for _, want := range []string{"Imported 3 servers with 4 NICs from -: 3x25G, 1x10G endpoints, single-homed", "Cabled 4 server NICs"} {
+		if !strings.Contains(stderr.String()+stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s%s", want, stdout.String(), stderr.String())
+		}
+	}
+	plan, err := readPlan(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []fabricplan.EndpointClass{{Count: 3, SpeedGbps: 25}, {Count: 1, SpeedGbps: 10}}; !reflect.DeepEqual(plan.Request.Classes, want) {
+		t.Errorf("plan classes = %v, want %v", plan.Request.Classes, want)
+	}
+	m, err := readCabling(cablingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.ServerLinks) != 4 {
+		t.Errorf("server links = %+v", m.ServerLinks)
+	}
+	vpcs, err := readVPCPlan(vpcsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var servers []string
+	for _, a := range vpcs.Attachments {
+		servers = append(servers, a.Endpoint)
+	}
+	slices.Sort(servers)
+	if want := []string{"db01", "web01-eth0", "web01-eth1", "web02"}; !reflect.DeepEqual(servers, want) {
+		t.Errorf("attached %v, want %v", servers, want)
+	}
+
+	if code := Main(env, Root, []string{"plan", "-inventory", inventoryFile, "-endpoints", "96", "-output", planFile}); code != ExitUsage {
+		t.Errorf("plan -inventory -endpoints = %d, want %d", code, ExitUsage)
+	}
+	if code := Main(env, Root, []string{"plan", "-inventory", inventoryFile, "-redundancy", "mclag", "-output", planFile}); code != ExitValidation {
+		t.Errorf("plan -inventory of single-homed servers -redundancy mclag = %d, want %d", code, ExitValidation)
+	}
+}
+
+// import wiring writes a plan and cabling map the other commands read
+func TestImportWiring(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	args := []string{"import", "wiring", "-output", planFile, "-cabling", filepath.Join(dir, "cabling.json"), "../wiringyaml/testdata/wiring.yaml"}
+	if code := Main(env, Root, args); code != ExitOK {
+		t.Fatalf("import wiring = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Imported 2 leaves (celestica-ds2000), 2 spines") || !strings.Contains(stdout.String(), "2/48 (4.2%)") {
+		t.Errorf("output:\n%s%s", stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Warning: connection border--external--leaf-02") {
+		t.Errorf("stderr:\n%s", stderr.String())
+	}
+	if code := Main(env, Root, []string{"bom", "-plan", planFile, "-json", filepath.Join(dir, "bom.json"), "-csv", ""}); code != ExitOK {
+		t.Errorf("bom from the imported plan = %d: %s", code, stderr.String())
+	}
+	if code := Main(env, Root, []string{"import", "wiring", "-output", planFile, "-", "extra"}); code != ExitUsage {
+		t.Errorf("import wiring with two files = %d, want %d", code, ExitUsage)
+	}
+}
+
+// report utilization reads a written cabling map, or assigns one itself
+func TestReportUtilization(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	cablingFile := filepath.Join(dir, "cabling.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", planFile},
+		{"cabling", "-plan", planFile, "-output", cablingFile},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"report", "utilization", "-plan", planFile, "-cabling", cablingFile}); code != ExitOK {
+		t.Fatalf("report utilization = %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "SWITCH") || !strings.Contains(stdout.String(), "48/48 (100.0%)") {
+		t.Errorf("table:\n%s", stdout.String())
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"report", "utilization", "-plan", planFile, "-format", "json"}); code != ExitOK {
+		t.Fatalf("report utilization -format json = %d: %s", code, stderr.String())
+	}
+	var switches []utilization.Switch
+	if err := json.Unmarshal([]byte(stdout.String()), &switches); err != nil || len(switches) != 4 || switches[2].Fabric.Used != 4 {
+		t.Errorf("JSON = %+v, %v", switches, err)
+	}
+	csvFile := filepath.Join(dir, "utilization.csv")
+	if code := Main(env, Root, []string{"report", "utilization", "-plan", planFile, "-format", "csv", "-output", csvFile}); code != ExitOK {
+		t.Fatalf("report utilization -format csv = %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(csvFile); !strings.HasPrefix(string(data), "switch,role,model,") {
+		t.Errorf("CSV = %s", data)
+	}
+	if code := Main(env, Root, []string{"report", "utilization", "-plan", planFile, "-format", "pdf"}); code != ExitUsage {
+		t.Errorf("report utilization -format pdf = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestReportResilience(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "200", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan = %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"report", "resilience", "-plan", planFile}); code != ExitOK {
+		t.Fatalf("report resilience = %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{"Fabric: 200 endpoints, 2000G of leaf uplinks, 2.50:1", "spine  spine1", "1000G (50.0%)  5.00:1", "leaf   leaf1"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("table missing %q:\n%s", want, stdout.String())
+		}
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"report", "resilience", "-plan", planFile, "-all", "-format", "json"}); code != ExitOK {
+		t.Fatalf("report resilience -all -format json = %d: %s", code, stderr.String())
+	}
+	var report resilience.Report
+	if err := json.Unmarshal([]byte(stdout.String()), &report); err != nil || len(report.Failures) != 2+5+20 || report.Failures[2].EndpointsDown != 40 {
+		t.Errorf("JSON = %+v, %v", report, err)
+	}
+}
+
+// report power sums the profiles' physical figures per rack, and shows
+// and warns about the figures they lack
+func TestReportPower(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	profilesDir := filepath.Join(dir, "profiles")
+	leaf, spine := profiles.DS2000(), profiles.DS3000()
+	leaf.Physical = &profiles.Physical{TypicalPowerWatts: 350, MaxPowerWatts: 550, RackUnits: 1, WeightKg: 9.5}
+	spine.Physical = &profiles.Physical{TypicalPowerWatts: 420}
+	for _, p := range []profiles.SwitchProfile{leaf, spine} {
+		if _, err := profiles.WriteFile(p, profilesDir, profiles.FileName(p.ModelID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "200", "-redundancy", "mclag", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan = %d: %s", code, stderr.String())
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"report", "power", "-plan", planFile, "-profiles", profilesDir}); code != ExitOK {
+		t.Fatalf("report power = %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "RACK") || !strings.Contains(stdout.String(), "leaf1,leaf2") {
+		t.Errorf("table:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Warning: celestica-ds3000 has no physical.maxPowerWatts") {
+		t.Errorf("stderr = %s", stderr.String())
+	}
+	// -quiet keeps the fabric's totals; a -width too narrow for the table
+	// writes each rack as a block
+	for _, tt := range []struct {
+		flags, want string
+	}{
+		{"-quiet", "Fabric: 12 switches in 6 racks, rack units missing, weight missing, 4340W typical, 6340W max, 21632 BTU/h\n"},
+		{"-width 40", "RACK          spine-rack1\nSWITCHES      spine1,spine2\n"},
+	} {
+		stdout.Reset()
+		argv := append([]string{"report", "power", "-plan", planFile, "-profiles", profilesDir}, strings.Fields(tt.flags)...)
+		if code := Main(env, Root, argv); code != ExitOK || !strings.HasPrefix(stdout.String(), tt.want) {
+			t.Errorf("report power %s = %d:\n%s", tt.flags, code, stdout.String())
+		}
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"report", "power", "-plan", planFile, "-profiles", profilesDir, "-format", "json"}); code != ExitOK {
+		t.Fatalf("report power -format json = %d: %s", code, stderr.String())
+	}
+	var report facilities.Report
+	if err := json.Unmarshal([]byte(stdout.String()), &report); err != nil {
+		t.Fatal(err)
+	}
+	// 10 leaves in 5 racks and 2 spines in one
+	if len(report.Racks) != 6 || report.Fabric.TypicalPowerWatts != 10*350+2*420 || report.Racks[1].Budget.RackUnits != 2 ||
+		!reflect.DeepEqual(report.Fabric.Missing, []string{"rackUnits", "weightKg"}) {
+		t.Errorf("report = %+v", report)
+	}
+	// The built-in DS2000 and DS3000 have every figure
+	stdout.Reset()
+	stderr.Reset()
+	if code := Main(env, Root, []string{"report", "power", "-plan", planFile, "-quiet"}); code != ExitOK ||
+		stdout.String() != "Fabric: 12 switches in 6 racks, 12 RU, 113 kg, 3100W typical, 4900W max, 16718.8 BTU/h\n" || stderr.String() != "" {
+		t.Errorf("report power on the built-in models = %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if code := Main(env, Root, []string{"report", "power", "-plan", planFile, "-leaves-per-rack", "3"}); code != ExitUsage {
+		t.Errorf("report power -leaves-per-rack 3 on a paired plan = %d, want %d", code, ExitUsage)
+	}
+}
+
+// The built-in DS2000 and DS3000 carry every kind of planning data, so a
+// lossless fabric of them is optimized for cost, checked against their
+// capabilities and lifecycle, and reported on without a figure missing
+func TestPlanAndReportBuiltIns(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	args := []string{"plan", "-endpoints", "200", "-workload", "roce", "-optimize", "cost", "-deprecated", "refuse", "-output", planFile}
+	if code := Main(env, Root, args); code != ExitOK ||
+		!strings.Contains(stderr.String(), "Optimized for cost: celestica-ds2000 leaves and celestica-ds3000 spines") {
+		t.Fatalf("plan = %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stderr.String(), "Skipped celestica-ds2000 + celestica-ds3000") || strings.Contains(stderr.String(), "retired") {
+		t.Errorf("plan stderr:\n%s", stderr.String())
+	}
+	plan, err := readPlan(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Lossless == nil {
+		t.Errorf("plan has no lossless settings: %+v", plan)
+	}
+
+	for _, report := range []string{"power", "utilization", "resilience"} {
+		stdout.Reset()
+		stderr.Reset()
+		if code := Main(env, Root, []string{"report", report, "-plan", planFile, "-format", "json"}); code != ExitOK || stderr.String() != "" {
+			t.Errorf("report %s = %d: %s", report, code, stderr.String())
+		}
+		if report != "power" {
+			continue
+		}
+		var r facilities.Report
+		if err := json.Unmarshal([]byte(stdout.String()), &r); err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Fabric.Missing) > 0 || r.Fabric.Switches != plan.Leaves+plan.Spines || r.Fabric.TypicalPowerWatts != float64(plan.Leaves*250+plan.Spines*300) {
+			t.Errorf("report power = %+v", r.Fabric)
+		}
+	}
+}
+
+// diagram draws a written cabling map, or assigns one itself
+func TestDiagram(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	cablingFile := filepath.Join(dir, "cabling.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", planFile},
+		{"cabling", "-plan", planFile, "-output", cablingFile},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"diagram", "-plan", planFile, "-cabling", cablingFile, "-format", "mermaid", "-bundle"}); code != ExitOK {
+		t.Fatalf("diagram = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `spine1 ---|"2x100G`) {
+		t.Errorf("diagram -format mermaid -bundle:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"diagram", "-plan", planFile}); code != ExitOK || !strings.HasPrefix(stdout.String(), "graph ") {
+		t.Errorf("diagram without -cabling = %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if code := Main(env, Root, []string{"diagram", "-plan", planFile, "-format", "svg"}); code != ExitUsage {
+		t.Errorf("diagram -format svg = %d, want %d", code, ExitUsage)
+	}
+}
+
+// export renders a written cabling map, or assigns one itself
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	cablingFile := filepath.Join(dir, "cabling.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", planFile},
+		{"cabling", "-plan", planFile, "-output", cablingFile, "-addressing", "p2p"},
+		{"export", "-plan", planFile, "-cabling", cablingFile, "-output", filepath.Join(dir, "main.tf")},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "main.tf")); err != nil || !strings.Contains(string(data), `ip = "172.30.128.1/31"`) {
+		t.Errorf("main.tf = %s, %v", data, err)
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"export", "-plan", planFile, "-format", "pulumi"}); code != ExitOK || !strings.Contains(stdout.String(), "runtime: \"yaml\"") {
+		t.Errorf("export -format pulumi without -cabling = %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if code := Main(env, Root, []string{"export", "-plan", planFile, "-format", "cdk"}); code != ExitUsage {
+		t.Errorf("export -format cdk = %d, want %d", code, ExitUsage)
+	}
+
+	templatesDir := filepath.Join(dir, "templates")
+	if err := os.Mkdir(templatesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := "{{range .Cabling.Cables}}{{.Leaf}} {{.LeafPort}} {{.Spine}} {{.SpinePort}}\n{{end}}"
+	if err := os.WriteFile(filepath.Join(templatesDir, "cmdb.tmpl"), []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env.Getenv = func(key string) string { return map[string]string{templates.DirEnv: templatesDir}[key] }
+	stdout.Reset()
+	if code := Main(env, Root, []string{"export", "-plan", planFile, "-cabling", cablingFile, "-template", "cmdb.tmpl"}); code != ExitOK || !strings.HasPrefix(stdout.String(), "leaf1 E1/") {
+		t.Errorf("export -template = %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+	for _, tc := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"-template", "cmdb.tmpl", "-format", "pulumi"}, ExitUsage},
+		{[]string{"-template", "missing.tmpl"}, ExitIO},
+		{[]string{"-template", "cmdb.tmpl", "-templates", dir}, ExitIO},
+	} {
+		if code := Main(env, Root, append([]string{"export", "-plan", planFile}, tc.args...)); code != tc.want {
+			t.Errorf("export %s = %d, want %d", strings.Join(tc.args, " "), code, tc.want)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(templatesDir, "bad.tmpl"), []byte("{{upper .Plan.LeafModel}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := Main(env, Root, []string{"export", "-plan", planFile, "-template", "bad.tmpl"}); code != ExitValidation {
+		t.Errorf("export -template with an unknown function = %d, want %d", code, ExitValidation)
+	}
+}
+
+// bundle create packs the binaries, catalog, schema and templates, and
+// bundle verify catches any file changed after packing
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	bin, templatesDir, bundleFile := filepath.Join(dir, "hnc"), filepath.Join(dir, "templates"), filepath.Join(dir, "hnc-bundle.tar.gz")
+	os.WriteFile(bin, []byte("elf"), 0o755)
+	os.Mkdir(templatesDir, 0o755)
+	os.WriteFile(filepath.Join(templatesDir, "cmdb.tmpl"), []byte("{{.Plan.Leaves}}"), 0o644)
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	env.Getenv = func(key string) string { return map[string]string{templates.DirEnv: templatesDir}[key] }
+	if code := Main(env, Root, []string{"bundle", "create", "-bin", bin, "-output", bundleFile}); code != ExitOK {
+		t.Fatalf("bundle create = %d: %s", code, stderr.String())
+	}
+	if code := Main(env, Root, []string{"bundle", "verify", bundleFile}); code != ExitOK || !strings.Contains(stderr.String(), "match manifest.json, written by hnc bundle create (devel)") {
+		t.Fatalf("bundle verify = %d: %s", code, stderr.String())
+	}
+
+	f, _ := os.Open(bundleFile)
+	files, err := bundle.Read(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"bin/hnc", "catalog/ds2000.json", "schemas/switch-profile.schema.json", "templates/cmdb.tmpl"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("bundle lacks %s", name)
+		}
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(files[manifest.File], &m); err != nil {
+		t.Fatal(err)
+	}
+	delete(files, manifest.File)
+	files["bin/hnc"] = []byte("patched")
+	var tampered bytes.Buffer
+	if err := bundle.Write(&tampered, files, m, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(bundleFile, tampered.Bytes(), 0o644)
+	stderr.Reset()
+	if code := Main(env, Root, []string{"bundle", "verify", bundleFile}); code != ExitValidation || !strings.Contains(stderr.String(), "bin/hnc: changed") {
+		t.Errorf("bundle verify of a patched binary = %d: %s", code, stderr.String())
+	}
+	if code := Main(env, Root, []string{"bundle", "create", "-bin", bin + "," + bin, "-output", bundleFile}); code != ExitUsage {
+		t.Errorf("bundle create with two binaries called hnc = %d, want %d", code, ExitUsage)
+	}
+}
+
+// export wiring writes a wiring diagram that import wiring reads back as
+// the same switches and cables
+func TestExportWiring(t *testing.T) {
+	dir := t.TempDir()
+	planFile, cablingFile, vpcsFile := filepath.Join(dir, "fabric-plan.json"), filepath.Join(dir, "cabling.json"), filepath.Join(dir, "vpc-plan.json")
+	wiring := filepath.Join(dir, "wiring.yaml")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "40", "-redundancy", "mclag", "-output", planFile},
+		{"cabling", "-plan", planFile, "-output", cablingFile, "-addressing", "p2p"},
+		{"vpcs", "-plan", planFile, "-vpcs", "2", "-vlans", "2000-2099", "-output", vpcsFile},
+		{"export", "wiring", "-plan", planFile, "-cabling", cablingFile, "-vpcs", vpcsFile, "-output", wiring},
+		{"import", "wiring", "-output", filepath.Join(dir, "imported-plan.json"), "-cabling", filepath.Join(dir, "imported-cabling.json"), wiring},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+	data, err := os.ReadFile(wiring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"kind: \"VLANNamespace\"", "from: 2000\n      to: 2099", "kind: \"SwitchGroup\"", "asn: 65101", "name: \"leaf1--mclag-domain--leaf2\""} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("wiring.yaml lacks %q:\n%s", want, data)
+		}
+	}
+	plan, _ := readPlan(planFile)
+	imported, err := readPlan(filepath.Join(dir, "imported-plan.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Leaves != plan.Leaves || imported.Spines != plan.Spines || imported.UplinksPerLeaf != plan.UplinksPerLeaf || imported.LeafPairs != plan.LeafPairs {
+		t.Errorf("imported %+v, exported %+v", imported, plan)
+	}
+
+	stderr.Reset()
+	if code := Main(env, Root, []string{"export", "wiring", "-plan", planFile}); code != ExitOK || !strings.Contains(stderr.String(), "not numbered") {
+		t.Errorf("export wiring without -cabling = %d:\n%s", code, stderr.String())
+	}
+}
+
+// export netbox writes YAML import netbox reads back as the plan, and
+// with -url records what it would create under -dry-run
+func TestExportNetBox(t *testing.T) {
+	dir := t.TempDir()
+	planFile, netboxFile := filepath.Join(dir, "fabric-plan.json"), filepath.Join(dir, "netbox.yaml")
+	importedFile := filepath.Join(dir, "imported-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-redundancy", "mclag", "-output", planFile},
+		{"export", "netbox", "-plan", planFile, "-site", "dc1", "-output", netboxFile},
+		{"import", "netbox", "-output", importedFile, "-cabling", "", netboxFile},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+	plan, _ := readPlan(planFile)
+	imported, err := readPlan(importedFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Leaves != plan.Leaves || imported.Spines != plan.Spines || imported.UplinksPerLeaf != plan.UplinksPerLeaf || imported.LeafPairs != plan.LeafPairs {
+		t.Errorf("imported %+v, exported %+v", imported, plan)
+	}
+
+	stdout.Reset()
+	env.Getenv = func(key string) string { return map[string]string{"NETBOX_URL": "https://netbox.invalid"}[key] }
+	if code := Main(env, Root, []string{"export", "netbox", "-plan", planFile, "-site", "dc1", "-dry-run"}); code != ExitOK {
+		t.Fatalf("export netbox -dry-run = %d: %s", code, stderr.String())
+	}
+	if want := "create  6 devices, 40 interfaces and 20 cables at NetBox site dc1"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("export netbox -dry-run lacks %q:\n%s", want, stdout.String())
+	}
+	for _, args := range [][]string{
+		{"export", "netbox", "-plan", planFile},
+		{"import", "netbox", "-url", "https://netbox.invalid"},
+		{"import", "netbox", "-site", "dc1", netboxFile, netboxFile},
+	} {
+		if code := Main(env, Root, args); code != ExitUsage {
+			t.Errorf("hnc %s = %d, want %d", strings.Join(args, " "), code, ExitUsage)
+		}
+	}
+}
+
+func TestOpticsList(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"optics", "list", "-port-profile", "QSFP28-100G"}); code != ExitOK {
+		t.Fatalf("optics list = %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{"PORT PROFILE", "GEN-QSFP28-100G-SR4   transceiver  100GBASE-SR4  100m", "GEN-QSFP28-4X25G-DAC  dac          -             3m     4x25G"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("optics list lacks %q:\n%s", want, stdout.String())
+		}
+	}
+	if strings.Contains(stdout.String(), "SFP28-25G ") {
+		t.Errorf("optics list -port-profile QSFP28-100G lists other port profiles:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"optics", "list", "-json"}); code != ExitOK {
+		t.Fatal(stderr.String())
+	}
+	var list []optics.Option
+	if err := json.Unmarshal([]byte(stdout.String()), &list); err != nil || len(list) != len(optics.All()) {
+		t.Fatalf("optics list -json = %d options, %v", len(list), err)
+	}
+
+	stderr.Reset()
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"optics", "list", "-port-profile", "CFP2"}); code != ExitFailure || !strings.Contains(stderr.String(), "known: OSFP-800G") {
+		t.Errorf("unknown port profile = %d: %s", code, stderr.String())
+	}
+}
+
+// bom writes a pinned format version, and formats convert moves a file
+// between versions
+func TestFormatVersions(t *testing.T) {
+	dir := t.TempDir()
+	planFile, csvFile := filepath.Join(dir, "fabric-plan.json"), filepath.Join(dir, "bom.csv")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", planFile, "-format-version", "v1"},
+		{"bom", "-plan", planFile, "-json", "", "-csv", csvFile, "-format-version", "v1"},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Fatalf("hnc %s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+	v1, _ := os.ReadFile(csvFile)
+	if !strings.HasPrefix(string(v1), "category,item,description,quantity\n") {
+		t.Fatalf("bom -format-version v1 wrote:\n%s", v1)
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"formats", "convert", "-format", "bom-csv", csvFile}); code != ExitOK {
+		t.Fatalf("formats convert = %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "optic,GEN-SFP28-25G-SR,leaf endpoint ports,96,SFP28-25G\n") {
+		t.Errorf("formats convert to current:\n%s", stdout.String())
+	}
+
+	for _, args := range [][]string{
+		{"cabling", "-plan", planFile, "-format-version", "v9"},
+		{"formats", "convert", "-format", "nope", csvFile},
+	} {
+		stderr.Reset()
+		if code := Main(env, Root, args); code != ExitUsage {
+			t.Errorf("hnc %s = %d, want %d: %s", strings.Join(args, " "), code, ExitUsage, stderr.String())
+		}
+	}
+	if !strings.Contains(stderr.String(), "want profile-json, plan-json") {
+		t.Errorf("unknown -format error lists no formats: %s", stderr.String())
+	}
+}
+
+// Every mutating command takes -dry-run, writes nothing under it and
+// reports the same changes as text or JSON
+// -q, -v and -log-format change how status messages are logged, not
+// where command output goes
+func TestLogging(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-output", planFile, "-q"}); code != ExitOK || stdout.Len() > 0 || stderr.Len() > 0 {
+		t.Fatalf("plan -q = %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-output", planFile, "-v"}); code != ExitOK || !strings.Contains(stderr.String(), "Using 6 built-in profiles\n") || stdout.Len() > 0 {
+		t.Fatalf("plan -v = %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+
+	stderr.Reset()
+	if code := Main(env, Root, []string{"report", "utilization", "-plan", planFile, "-q"}); code != ExitOK || !strings.HasPrefix(stdout.String(), "SWITCH") {
+		t.Errorf("report utilization -q = %d:\n%s", code, stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-output", planFile, "-log-format", "json"}); code != ExitOK || stdout.Len() > 0 {
+		t.Fatalf("plan -log-format json = %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	var generated struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+		File  string `json:"file"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &generated); err != nil || generated.Level != "INFO" || generated.File != planFile || len(lines) != 2 {
+		t.Errorf("records %q: %+v, %v", lines, generated, err)
+	}
+
+	stderr.Reset()
+	if code := Main(env, Root, []string{"bom", "-plan", filepath.Join(dir, "none.json"), "-log-format", "json", "-q"}); code != ExitIO ||
+		!strings.Contains(stderr.String(), `"level":"ERROR"`) || !strings.Contains(stderr.String(), `"exitCode":4`) {
+		t.Errorf("bom of a missing plan = %d: %s", code, stderr.String())
+	}
+}
+
+// -errors=json reports each problem as one object on stderr, and only that
+func TestErrorsJSON(t *testing.T) {
+	type problem struct {
+		Code     string `json:"code"`
+		ExitCode int    `json:"exitCode"`
+		Path     string `json:"path"`
+		Message  string `json:"message"`
+	}
+	decode := func(stderr string) []problem {
+		var out []problem
+		for _, line := range strings.Split(strings.TrimSpace(stderr), "\n") {
+			var p problem
+			if err := json.Unmarshal([]byte(line), &p); err != nil {
+				t.Fatalf("stderr line %q: %v", line, err)
+			}
+			out = append(out, p)
+		}
+		return out
+	}
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "none.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+
+	if code := Main(env, Root, []string{"bom", "-errors=json", "-plan", missing}); code != ExitIO {
+		t.Errorf("bom of a missing plan = %d, want %d", code, ExitIO)
+	}
+	if got := decode(stderr.String()); len(got) != 1 || got[0].Code != "io" || got[0].ExitCode != ExitIO || got[0].Path != missing ||
+		!strings.HasPrefix(got[0].Message, "reading plan: ") {
+		t.Errorf("problems = %+v", got)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"cabling", "-plan", bad, "-errors=json"}); code != ExitValidation {
+		t.Errorf("cabling of a malformed plan = %d, want %d", code, ExitValidation)
+	}
+	if got := decode(stderr.String()); len(got) != 1 || got[0].Code != "validation" || got[0].Path != bad {
+		t.Errorf("problems = %+v", got)
+	}
+
+	fixtures := t.TempDir()
+	if err := os.WriteFile(filepath.Join(fixtures, "ds2000.json"), []byte(`{"modelId": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"profiles", "validate", "-dir", fixtures, "-errors=json"}); code != ExitValidation {
+		t.Errorf("profiles validate = %d, want %d", code, ExitValidation)
+	}
+	got := decode(stderr.String())
+	if last := got[len(got)-1]; len(got) < 2 || got[0].Path != filepath.Join(fixtures, "ds2000.json") || last.Path != fixtures {
+		t.Errorf("problems = %+v", got)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"plan", "-endpoints", "96", "-output", planFile, "-dry-run"}); code != ExitOK {
+		t.Fatalf("plan -dry-run = %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(planFile); !os.IsNotExist(err) {
+		t.Fatalf("plan -dry-run wrote %s", planFile)
+	}
+	for _, want := range []string{"Dry run; nothing was written:", "allocate  2 leaves (celestica-ds2000), 2 spines", "create    " + planFile} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("plan -dry-run output lacks %q:\n%s", want, stdout.String())
+		}
+	}
+
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"plan", "-endpoints", "96", "-output", planFile}); code != ExitOK {
+		t.Fatal(stderr.String())
+	}
+	for _, args := range [][]string{
+		{"plan", "-endpoints", "96", "-output", planFile},
+		{"cabling", "-plan", planFile, "-output", filepath.Join(dir, "cabling.json")},
+		{"bom", "-plan", planFile, "-json", filepath.Join(dir, "bom.json"), "-csv", ""},
+		{"portmap", "-wiring", filepath.Join(contractDir, "designs", "two-leaf.wiring.json"), "-profiles", filepath.Join(contractDir, "profiles"), "-output", filepath.Join(dir, "portmaps")},
+		{"profiles", "dump", "-output", filepath.Join(dir, "profiles")},
+		{"profiles", "schema", "-output", filepath.Join(dir, "schema.json")},
+		{"docs", "-output", filepath.Join(dir, "reference.md")},
+	} {
+		stdout.Reset()
+		stderr.Reset()
+		code := Main(testEnv(nil, &stdout, &stderr), Root, append(args, "-dry-run=json"))
+		var report struct {
+			DryRun  bool
+			Command string
+			Changes []change
+		}
+		if err := json.Unmarshal([]byte(stdout.String()), &report); code != ExitOK || err != nil {
+			t.Fatalf("hnc %s -dry-run=json = %d, %v\nstdout: %s\nstderr: %s", strings.Join(args, " "), code, err, stdout.String(), stderr.String())
+		}
+		if !report.DryRun || !strings.HasPrefix(report.Command, "hnc "+args[0]) || len(report.Changes) == 0 {
+			t.Errorf("hnc %s -dry-run=json = %+v", strings.Join(args, " "), report)
+		}
+		for _, c := range report.Changes {
+			if c.Path == planFile && c.Action != "unchanged" {
+				t.Errorf("replanning the same fabric would %s %s", c.Action, c.Path)
+			}
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dry runs left %d files in %s, want only the plan", len(entries), dir)
+	}
+
+	// Read-only commands have no -dry-run
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"profiles", "lint", "-dry-run"}); code != ExitUsage {
+		t.Errorf("profiles lint -dry-run = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestStatsOut(t *testing.T) {
+	dir := t.TempDir()
+	planFile, statsFile := filepath.Join(dir, "fabric-plan.json"), filepath.Join(dir, "stats.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan = %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(statsFile); !os.IsNotExist(err) {
+		t.Fatal("stats written without -stats-out")
+	}
+	// Under -dry-run too, as the cabling is still computed
+	if code := Main(env, Root, []string{"cabling", "-plan", planFile, "-output", filepath.Join(dir, "cabling.json"), "-stats-out", statsFile, "-dry-run"}); code != ExitOK {
+		t.Fatalf("cabling -stats-out = %d: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(statsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats runstats.Stats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatal(err)
+	}
+	var stages []string
+	for _, s := range stats.Stages {
+		stages = append(stages, s.Name)
+	}
+	if stats.Command != "hnc cabling" || stats.Switches != 4 || stats.Cables != 8 || stats.Files != 1 || strings.Join(stages, ",") != "load,cabling,write" {
+		t.Errorf("stats = %s", data)
+	}
+
+	// A failed run records its exit code
+	if code := Main(env, Root, []string{"cabling", "-plan", filepath.Join(dir, "none.json"), "-stats-out", statsFile}); code != ExitIO {
+		t.Fatalf("cabling of a missing plan = %d, want %d", code, ExitIO)
+	}
+	data, _ = os.ReadFile(statsFile)
+	if err := json.Unmarshal(data, &stats); err != nil || stats.ExitCode != ExitIO {
+		t.Errorf("stats of a failed run = %s, %v", data, err)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "fabric-plan.json")
+	var stdout, stderr strings.Builder
+	if code := Plan(testEnv(nil, &stdout, &stderr), []string{"-endpoints", "96", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan = %d: %s", code, stderr.String())
+	}
+	plan, err := readPlan(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cluster serves every built-in profile and the planned switches,
+	// but one fabric link is missing
+	var crds []profiles.SwitchProfileCRD
+	for _, p := range profiles.Default().List() {
+		crd, err := profiles.ToCRD(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		crds = append(crds, crd)
+	}
+	var switches []map[string]any
+	for i := 0; i < plan.Leaves; i++ {
+		switches = append(switches, map[string]any{"metadata": map[string]string{"name": fmt.Sprintf("leaf-%d", i+1)},
+			"spec": map[string]string{"role": "server-leaf", "profile": "celestica-ds2000"}})
+	}
+	for i := 0; i < plan.Spines; i++ {
+		switches = append(switches, map[string]any{"metadata": map[string]string{"name": fmt.Sprintf("spine-%d", i+1)},
+			"spec": map[string]string{"role": "spine", "profile": "celestica-ds3000"}})
+	}
+	links := make([]struct{}, plan.Leaves*plan.UplinksPerLeaf-1)
+	defer func(saved fabricapi.Kubectl) { kubectl = saved }(kubectl)
+	kubectl = func(_ context.Context, args ...string) ([]byte, error) {
+		switch args[1] {
+		case fabricapi.Resource:
+			return json.Marshal(map[string]any{"items": crds})
+		case fabricapi.SwitchResource:
+			return json.Marshal(map[string]any{"items": switches})
+		case fabricapi.ConnectionResource:
+			return json.Marshal(map[string]any{"items": []any{map[string]any{"spec": map[string]any{"fabric": map[string]any{"links": links}}}}})
+		}
+		return []byte("lab"), nil
+	}
+
+	stderr.Reset()
+	if code := Drift(testEnv(nil, &stdout, &stderr), nil); code != ExitOK || stderr.String() != "No drift in lab\n" {
+		t.Fatalf("drift without -plan = %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	stdout.Reset()
+	if code := Drift(testEnv(nil, &stdout, &stderr), []string{"-plan", planFile, "-json"}); code != ExitFailure {
+		t.Fatalf("drift -plan = %d, want 1: %s", code, stderr.String())
+	}
+	var report drift.Report
+	if err := json.Unmarshal([]byte(stdout.String()), &report); err != nil || len(report.Changes) != 1 || report.Changes[0].Path != "fabricLinks" {
+		t.Fatalf("report = %s, %v", stdout.String(), err)
+	}
+	if code := Drift(testEnv(nil, &stdout, &stderr), []string{"-plan", planFile, "-fail-on", "critical"}); code != ExitOK {
+		t.Errorf("drift -fail-on critical = %d, want 0 for a warning", code)
+	}
+}
+
+// doctor runs every check even when one fails, and the bundle records them
+func TestProfilesDoctor(t *testing.T) {
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"profiles", "doctor", "-dir", "../../../../src/fixtures/switch-profiles"}); code != ExitOK {
+		t.Fatalf("profiles doctor on the checked-in fixtures = %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+	dir := t.TempDir()
+	stdout.Reset()
+	if code := Main(env, Root, []string{"profiles", "doctor", "-dir", dir}); code != ExitFailure {
+		t.Fatalf("profiles doctor on an empty directory = %d, want 1", code)
+	}
+	for _, want := range []string{"files     fail    0 of 6 expected fixture(s), 6 missing, 0 unknown", "fix: hnc profiles dump -output " + dir + "\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("profiles doctor output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	dir := t.TempDir()
+	bundleFile := filepath.Join(dir, "bundle.json")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"doctor", "-offline", "-fgd", dir, "-bundle", bundleFile}); code != ExitOK {
+		t.Fatalf("doctor -offline = %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "cluster    skip") || !strings.Contains(stderr.String(), "Generated "+bundleFile) {
+		t.Errorf("doctor output:\n%s%s", stdout.String(), stderr.String())
+	}
+	data, err := os.ReadFile(bundleFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundle doctor.Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil || len(bundle.Checks) != 5 || bundle.Environment.Inputs["fgd"] != dir {
+		t.Fatalf("bundle = %s, %v", data, err)
+	}
+
+	defer func(saved fabricapi.Kubectl) { kubectl = saved }(kubectl)
+	kubectl = func(_ context.Context, args ...string) ([]byte, error) {
+		return nil, fmt.Errorf("kubectl: connection refused")
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"doctor", "-fgd", dir, "-profiles", filepath.Join(dir, "none"), "-json"}); code != ExitFailure {
+		t.Fatalf("doctor with no cluster and no profiles = %d, want 1", code)
+	}
+	if err := json.Unmarshal([]byte(stdout.String()), &bundle); err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[string]doctor.Status{}
+	for _, c := range bundle.Checks {
+		statuses[c.Name] = c.Status
+	}
+	if statuses["catalog"] != doctor.Fail || statuses["cluster"] != doctor.Fail || statuses["storage"] != doctor.OK {
+		t.Errorf("statuses = %v", statuses)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	fixtures := filepath.Join(dir, "fixtures")
+	sarifFile, junitFile := filepath.Join(dir, "check.sarif"), filepath.Join(dir, "check.xml")
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"profiles", "dump", "-output", fixtures}); code != ExitOK {
+		t.Fatalf("profiles dump = %d: %s", code, stderr.String())
+	}
+	args := []string{"check", "-dir", fixtures, "-sarif", sarifFile, "-junit", junitFile}
+	if code := Main(env, Root, args); code != ExitOK {
+		t.Fatalf("check = %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "schema    ds2000.json       ok") {
+		t.Errorf("check output:\n%s", stdout.String())
+	}
+
+	// A hand-edited fixture drifts and breaks the schema
+	if err := os.WriteFile(filepath.Join(fixtures, "ds2000.json"), []byte(`{"model_id": "celestica-ds2000"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := Main(env, Root, args); code != ExitFailure {
+		t.Fatalf("check of an edited fixture = %d, want 1", code)
+	}
+	sarif, err := os.ReadFile(sarifFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(sarif, &log); err != nil || len(log.Runs) != 1 {
+		t.Fatalf("SARIF = %s, %v", sarif, err)
+	}
+	rules := map[string]bool{}
+	for _, r := range log.Runs[0].Results {
+		rules[r.RuleID] = true
+		if r.RuleID == "schema/json-schema" && (r.Level != "error" || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != filepath.ToSlash(filepath.Join(fixtures, "ds2000.json"))) {
+			t.Errorf("schema result = %+v", r)
+		}
+	}
+	if !rules["fixtures/content"] || !rules["schema/json-schema"] {
+		t.Errorf("SARIF rules = %v", rules)
+	}
+	junit, err := os.ReadFile(junitFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(junit), `<testcase name="ds2000.json" classname="hnc.schema">`) || !strings.Contains(string(junit), "<failure ") {
+		t.Errorf("JUnit:\n%s", junit)
+	}
+
+	// An infeasible plan fails its own suite
+	planFile := filepath.Join(dir, "plan.json")
+	if err := os.WriteFile(planFile, []byte(`{"leafModel": "celestica-ds9999", "spineModel": "celestica-ds3000"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"check", "-dir", "", "-plan", planFile, "-sarif", "", "-junit", ""}); code != ExitFailure {
+		t.Fatalf("check of an unknown leaf = %d, want 1", code)
+	}
+	if !strings.Contains(stdout.String(), "plan   plan.json         fail") || !strings.Contains(stdout.String(), "plan/feasibility") {
+		t.Errorf("check -plan output:\n%s", stdout.String())
+	}
+	if code := Main(env, Root, []string{"check", "-vpcs", planFile}); code != ExitUsage {
+		t.Errorf("check -vpcs without -plan = %d, want 2", code)
+	}
+}
+
+// The checked-in reference is what release notes link to
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugin is a shell script")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+req=$(cat)
+case "$req" in
+*'"method":"describe"'*)
+	echo '{"protocol":1,"version":"0.1.0","description":"Acme","capabilities":[
+		{"kind":"lint-rule","name":"vendor","description":"models are Acme models","severity":"error"},
+		{"kind":"pricing","name":"list"},{"kind":"exporter","name":"cmdb"},
+		{"kind":"naming-policy","name":"site"},{"kind":"addressing-policy","name":"flat"}]}' ;;
+*'"method":"lint"'*'"modelId":"celestica-'*) echo '{"protocol":1,"messages":["not an Acme model"]}' ;;
+*'"method":"lint"'*) echo '{"protocol":1}' ;;
+*'"method":"price"'*) echo '{"protocol":1,"estimate":{"currency":"USD","lines":[],"byCategory":{"switch":1000},"total":1000}}' ;;
+*'"method":"export"'*) echo '{"protocol":1,"output":"cmdb export"}' ;;
+*'"method":"name"'*) echo '{"protocol":1,"hostnames":{"leaf1":"dc1-r1-tor","spine1":"dc1-sp1"}}' ;;
+*'"method":"address"'*) echo '{"protocol":1,"addressing":{"options":{"mode":"p2p"},"switches":[],"links":[]}}' ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "hnc-plugin-acme"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"plugins", "list"}); code != ExitOK || !strings.Contains(stderr.String(), "$HNC_PLUGIN_PATH is not set") {
+		t.Errorf("plugins list without a path = %d: %s", code, stderr.String())
+	}
+	env.Getenv = func(key string) string { return map[string]string{"HNC_PLUGIN_PATH": dir}[key] }
+	stdout.Reset()
+	if code := Main(env, Root, []string{"plugins", "list"}); code != ExitOK ||
+		!strings.Contains(stdout.String(), "acme    0.1.0") || !strings.Contains(stdout.String(), "lint-rule          vendor  models are Acme models") {
+		t.Errorf("plugins list = %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	if code := Main(env, Root, []string{"profiles", "lint", "-enable", "acme/vendor"}); code != ExitValidation ||
+		!strings.Contains(stdout.String(), "acme/vendor") || !strings.Contains(stdout.String(), "not an Acme model") {
+		t.Errorf("lint with the plugin rule = %d:\n%s", code, stdout.String())
+	}
+
+	work := t.TempDir()
+	planFile := filepath.Join(work, "fabric-plan.json")
+	if code := Main(env, Root, []string{"plan", "-endpoints", "96", "-output", planFile}); code != ExitOK {
+		t.Fatalf("plan = %d: %s", code, stderr.String())
+	}
+	stderr.Reset()
+	costFile := filepath.Join(work, "cost.json")
+	if code := Main(env, Root, []string{"bom", "-plan", planFile, "-json", "", "-csv", "", "-pricing", "plugin:acme", "-cost", costFile}); code != ExitOK ||
+		!strings.Contains(stderr.String(), "Estimated cost: USD 1000.00") {
+		t.Errorf("bom -pricing plugin:acme = %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	stdout.Reset()
+	if code := Main(env, Root, []string{"export", "-plan", planFile, "-format", "plugin:acme/cmdb"}); code != ExitOK || stdout.String() != "cmdb export" {
+		t.Errorf("export -format plugin:acme/cmdb = %d: %q %s", code, stdout.String(), stderr.String())
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"export", "-plan", planFile, "-format", "plugin:other"}); code != ExitUsage || !strings.Contains(stderr.String(), `no plugin "other"`) {
+		t.Errorf("export -format plugin:other = %d: %s", code, stderr.String())
+	}
+
+	cablingFile := filepath.Join(work, "cabling.json")
+	stderr.Reset()
+	if code := Main(env, Root, []string{"cabling", "-plan", planFile, "-output", cablingFile, "-naming-policy", "plugin:acme"}); code != ExitOK ||
+		!strings.Contains(stderr.String(), "Applied policy plugin:acme") {
+		t.Errorf("cabling -naming-policy plugin:acme = %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(cablingFile); !strings.Contains(string(data), `"leaf1": "dc1-r1-tor"`) {
+		t.Errorf("cabling.json has no hostnames:\n%s", data)
+	}
+	for _, tc := range []struct {
+		args []string
+		code int
+		want string
+	}{
+		{[]string{"-addressing", "p2p", "-addressing-policy", "plugin:acme"}, ExitFailure, "plugin acme address: numbers 0 switches and 0 links, want 4 and 8"},
+		{[]string{"-addressing-policy", "plugin:acme"}, ExitUsage, "-addressing-policy renumbers what -addressing numbers"},
+		{[]string{"-placement-policy", "plugin:acme"}, ExitUsage, "-placement-policy places the servers of an -inventory"},
+		{[]string{"-naming-policy", "acme"}, ExitUsage, `-naming-policy "acme" does not name a plugin`},
+		{[]string{"-naming-policy", "plugin:acme/flat"}, ExitUsage, `plugin acme has no naming-policy "flat"`},
+	} {
+		stderr.Reset()
+		args := append([]string{"cabling", "-plan", planFile, "-output", cablingFile}, tc.args...)
+		if code := Main(env, Root, args); code != tc.code || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v = %d: %s, want %d: %q", args, code, stderr.String(), tc.code, tc.want)
+		}
+	}
+}
+
+// hnc serve -registry loads and reloads the catalog another hnc serve
+// serves, and rechecks -designs against it
+func TestServeRegistry(t *testing.T) {
+	registry := httptest.NewServer(server.Handler(profiles.Default(), server.Options{}))
+	defer registry.Close()
+	got, err := fetchRegistry(registry.URL + "/profiles")
+	if err != nil || len(got.List()) != len(profiles.Default().List()) {
+		t.Fatalf("fetchRegistry = %v", err)
+	}
+	if _, err := fetchRegistry(registry.URL + "/nowhere"); err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("fetchRegistry of a missing path = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "rack-a.json"), []byte(`{"leafModel": "DS2000", "spineModel": "DS3000", "leaves": 2, "spines": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	designs, err := readDesigns(dir)
+	if err != nil || designs["rack-a"].SpineModel != "DS3000" {
+		t.Fatalf("readDesigns = %+v, %v", designs, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readDesigns(dir); err == nil || !strings.Contains(err.Error(), "broken.json") {
+		t.Errorf("readDesigns with a broken plan = %v", err)
+	}
+}
+
+func TestDocsAreUpToDate(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if code := Docs(testEnv(nil, &stdout, &stderr), []string{"-check", "-output", "../../../../docs/schema-reference.md"}); code != ExitOK {
+		t.Fatalf("docs -check = %d; regenerate with go run ./cmd/hnc docs -output ../../docs/schema-reference.md\n%s%s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestTSTypesAreUpToDate(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"gen", "ts-types", "-check", "-output", "../../../../src/ingest/switchProfile.generated.d.ts"}); code != ExitOK {
+		t.Fatalf("gen ts-types -check = %d; regenerate with go run ./cmd/hnc gen ts-types -output ../../src/ingest/switchProfile.generated.d.ts\n%s%s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestGenTSTypesZod(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if code := Main(testEnv(nil, &stdout, &stderr), Root, []string{"gen", "ts-types", "-zod"}); code != ExitOK {
+		t.Fatalf("gen ts-types -zod = %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{
+		"import { z } from 'zod';\n",
+		"export const ProfilesSchema = RoleProfilesSchema.extend({\n",
+		"  faceplate: FaceplateSchema.nullable().optional(),\n",
+		"export type SwitchProfile = z.infer<typeof SwitchProfileSchema>;\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("gen ts-types -zod missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
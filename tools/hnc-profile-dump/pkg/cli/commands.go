@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/constraints"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+// Root is the hnc command tree
+var Root = Command{Name: "hnc", Commands: []Command{
+	{Name: "profiles", Summary: "Generate, lint, migrate and schema-check switch profiles", Commands: []Command{
+		{Name: "dump", Summary: "Write switch profiles as frontend fixtures, YAML or CRDs", Run: Dump, Mutates: true},
+		{Name: "lint", Summary: "Run lint rules over switch profiles", Run: Lint},
+		{Name: "migrate", Summary: "Upgrade JSON profiles to the current schema", Run: Migrate, Mutates: true},
+		{Name: "schema", Summary: "Write the SwitchProfile JSON Schema", Run: Schema, Mutates: true},
+		{Name: "validate", Summary: "Check profile fixtures against the JSON Schema", Run: Validate},
+		{Name: "verify", Summary: "Check generated fixtures against the checksums in their manifest.json", Run: VerifyManifest},
+		{Name: "doctor", Summary: "Find unknown, missing, invalid and stale fixtures and say how to fix them", Run: ProfilesDoctor},
+	}},
+	{Name: "plan", Summary: "Size a leaf-spine fabric for an endpoint count", Run: Plan, Mutates: true, Commands: []Command{
+		{Name: "diff", Summary: "Compare two fabric plans: switches, links, capacity, VLANs and VNIs", Run: PlanDiff},
+		{Name: "expand", Summary: "Grow a plan and its cabling to more endpoints without recabling existing leaves", Run: PlanExpand, Mutates: true},
+	}},
+	{Name: "scenario", Summary: "Keep named what-if fabric plans and compare them", Commands: []Command{
+		{Name: "save", Summary: "Save a fabric plan and the files it was planned from as a named scenario", Run: ScenarioSave},
+		{Name: "list", Summary: "List the saved scenarios and the fabric each plans", Run: ScenarioList},
+		{Name: "load", Summary: "Write a saved scenario's plan and files back out", Run: ScenarioLoad, Mutates: true},
+		{Name: "delete", Summary: "Remove a saved scenario", Run: ScenarioDelete},
+		{Name: "diff", Summary: "Compare two saved scenarios with hnc plan diff", Run: ScenarioDiff},
+	}},
+	{Name: "bom", Summary: "List the bill of materials for a fabric plan", Run: BOM, Mutates: true},
+	{Name: "optics", Summary: "List the transceivers, DAC and AOC cables for each port profile", Commands: []Command{
+		{Name: "list", Summary: "List the optics that fit a port profile and how far they reach", Run: OpticsList},
+	}},
+	{Name: "vpcs", Summary: "Allocate VLANs, VNIs and subnets for tenant VPCs and attach the endpoints", Run: VPCs, Mutates: true},
+	{Name: "layout", Summary: "Place a fabric plan's switches and servers in racks and pods", Run: Layout, Mutates: true},
+	{Name: "cabling", Summary: "Assign leaf-spine cables for a fabric plan", Run: Cabling, Mutates: true},
+	{Name: "diagram", Summary: "Draw a fabric plan's cabling as GraphViz DOT or Mermaid", Run: Diagram, Mutates: true},
+	{Name: "export", Summary: "Render a fabric plan's wiring as Terraform or Pulumi resources", Run: Export, Mutates: true, Commands: []Command{
+		{Name: "wiring", Summary: "Write a fabric plan and its cabling as Hedgehog wiring diagram YAML for hhfab", Run: ExportWiring, Mutates: true},
+		{Name: "netbox", Summary: "Write a fabric plan's devices, interfaces and cables as NetBox import YAML or create them through its API", Run: ExportNetBox, Mutates: true},
+	}},
+	{Name: "formats", Summary: "List export format versions and convert files between them", Commands: []Command{
+		{Name: "list", Summary: "List every export format and the versions hnc can write", Run: FormatsList},
+		{Name: "convert", Summary: "Rewrite an exported file at another format version", Run: FormatsConvert, Mutates: true},
+	}},
+	{Name: "import", Summary: "Load existing fabrics, switch platforms and server inventories into HNC designs", Commands: []Command{
+		{Name: "wiring", Summary: "Rebuild a fabric plan and cabling map from a Hedgehog wiring.yaml", Run: ImportWiring, Mutates: true},
+		{Name: "platform", Summary: "Convert a SONiC platform.json and hwsku.json into a switch profile", Run: ImportPlatform, Mutates: true},
+		{Name: "netbox", Summary: "Rebuild a fabric plan and cabling map from the devices and cables of a NetBox site", Run: ImportNetBox, Mutates: true},
+		{Name: "endpoints", Summary: "Read a CSV or JSON server inventory for hnc plan, cabling and vpcs -inventory", Run: ImportEndpoints, Mutates: true},
+	}},
+	{Name: "report", Summary: "Report on a fabric plan for capacity and facilities reviews", Commands: []Command{
+		{Name: "utilization", Summary: "List the used and free endpoint and fabric ports of every switch", Run: ReportUtilization, Mutates: true},
+		{Name: "power", Summary: "Roll up rack units, weight, power and heat per rack and for the fabric", Run: ReportPower, Mutates: true},
+		{Name: "resilience", Summary: "Report the endpoints and bandwidth lost to the worst single spine, leaf and link failures", Run: ReportResilience, Mutates: true},
+	}},
+	{Name: "portmap", Summary: "Draw faceplate port maps or commissioning sheets for a wiring", Run: Portmap, Mutates: true},
+	{Name: "drift", Summary: "Compare a running fabric with the local profiles and plan", Run: Drift},
+	{Name: "plugins", Summary: "List the lint rule, pricing and exporter plugins on $HNC_PLUGIN_PATH", Commands: []Command{
+		{Name: "list", Summary: "Run the handshake with every plugin and list what each provides", Run: PluginsList},
+	}},
+	{Name: "check", Summary: "Run the lint, fixture, schema and plan checks at once and report them as SARIF and JUnit XML", Run: Check, Mutates: true},
+	{Name: "doctor", Summary: "Check the catalog, storage, cluster, templates and schemas and bundle the results for support", Run: Doctor, Mutates: true},
+	{Name: "bundle", Summary: "Pack the toolchain, catalog, schemas and templates for air-gapped installs", Commands: []Command{
+		{Name: "create", Summary: "Write the binaries, catalog, schemas and templates as one archive with their checksums", Run: BundleCreate, Mutates: true},
+		{Name: "verify", Summary: "Check a bundle against the checksums in its manifest, offline", Run: BundleVerify},
+	}},
+	{Name: "tui", Summary: "Browse profiles, edit a design and see its validation in a terminal session, e.g. over SSH", Run: TUI},
+	{Name: "serve", Summary: "Serve profiles and fabric planning over HTTP for the frontend", Run: Serve},
+	{Name: "docs", Summary: "Write the switch profile and FGD format reference", Run: Docs, Mutates: true},
+	{Name: "gen", Summary: "Generate code from the Go types for other HNC components", Commands: []Command{
+		{Name: "ts-types", Summary: "Write TypeScript declarations or zod schemas for the switch profile", Run: GenTSTypes, Mutates: true},
+	}},
+}}
+
+// jobsFlag adds -j, how many profiles or files a command works on at once
+func jobsFlag(flags *flag.FlagSet) *int {
+	return flags.Int("j", 0, "Profiles to process at once (default: one per CPU); output order does not depend on it")
+}
+
+const profilesUsage = "Directory of YAML or JSON profile definitions, or - for a stream on stdin as written by hnc profiles dump -output - (default: the built-in profiles embedded in the binary)"
+
+// buildVersion is the module version hnc was built at, (devel) for a
+// local build
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// loadRegistry is the built-in profiles, or those in dir (- for stdin)
+func loadRegistry(env Env, dir string) (*profiles.Registry, error) {
+	return loadRegistryJobs(env, dir, 0)
+}
+
+// loadRegistryJobs is loadRegistry parsing jobs files at a time
+func loadRegistryJobs(env Env, dir string, jobs int) (*profiles.Registry, error) {
+	defer env.recorder().Stage("load")()
+	if dir == "" {
+		registry := profiles.Default()
+		env.debug("Using %d built-in profiles", len(registry.List()))
+		return registry, nil
+	}
+	registry, err := profiles.LoadJobs(dir, env.Stdin, jobs)
+	if err == nil {
+		env.debug("Loaded %d profiles from %s", len(registry.List()), dir)
+	}
+	return registry, err
+}
+
+// findModels looks up the leaf and spine profiles a plan is built from,
+// each with the port profiles of the role it plays. A plan without spines
+// names no spine model, and gets the zero profile for one.
+func findModels(registry *profiles.Registry, leafModel, spineModel string) (leaf, spine profiles.SwitchProfile, err error) {
+	leaf, ok := registry.Find(leafModel)
+	if !ok {
+		return leaf, spine, fmt.Errorf("no profile for leaf model %s", leafModel)
+	}
+	if spineModel == "" {
+		return leaf.InRole(profiles.RoleLeaf), spine, nil
+	}
+	if spine, ok = registry.Find(spineModel); !ok {
+		return leaf, spine, fmt.Errorf("no profile for spine model %s", spineModel)
+	}
+	return leaf.InRole(profiles.RoleLeaf), spine.InRole(profiles.RoleSpine), nil
+}
+
+// checkConstraints reports, one line each, every way the plan's leaf and
+// spine models fall short of what the plan and its VPC plan, if any, ask
+// of them, as far as their profiles' capabilities say
+func checkConstraints(env Env, registry *profiles.Registry, plan fabricplan.Plan, vpcs *vpcplan.Plan) int {
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	problems := constraints.Check(plan, vpcs, leaf, spine)
+	for _, p := range problems {
+		env.fail(ExitValidation, "%s", p)
+	}
+	if len(problems) > 0 {
+		return env.fail(ExitValidation, "Error: the design asks more than its switch models can do (%d problem(s)); nothing was written", len(problems))
+	}
+	return ExitOK
+}
+
+// deprecatedPolicies are what hnc plan -deprecated does with a model its
+// profile marks deprecated or end-of-sale
+var deprecatedPolicies = []string{"warn", "refuse", "allow"}
+
+// checkLifecycle warns of the retired models a new design places or, with
+// policy refuse, rejects the design. Imports skip it: a deployed fabric
+// keeps the models it has.
+func checkLifecycle(env Env, registry *profiles.Registry, plan fabricplan.Plan, policy string) int {
+	if policy == "allow" {
+		return ExitOK
+	}
+	var retired []string
+	seen := map[string]bool{}
+	for _, model := range []string{plan.LeafModel, plan.SpineModel, plan.SuperSpineModel} {
+		p, ok := registry.Find(model)
+		if !ok || seen[p.ModelID] || !p.Retired() {
+			continue
+		}
+		seen[p.ModelID] = true
+		retired = append(retired, p.Retirement())
+	}
+	for _, r := range retired {
+		if policy == "refuse" {
+			env.fail(ExitValidation, "Error: %s", r)
+		} else {
+			env.warn("Warning: %s", r)
+		}
+	}
+	if policy == "refuse" && len(retired) > 0 {
+		return env.fail(ExitValidation, "Error: the design places %d retired model(s), which -deprecated refuse keeps out of new designs; nothing was written", len(retired))
+	}
+	return ExitOK
+}
+
+// findSuperSpine looks up the super-spine profile of a multi-pod plan,
+// with the port profiles of the spine role it plays; a plan without pods
+// gets the zero profile
+func findSuperSpine(registry *profiles.Registry, plan fabricplan.Plan) (profiles.SwitchProfile, error) {
+	if plan.Pods <= 1 {
+		return profiles.SwitchProfile{}, nil
+	}
+	p, ok := registry.Find(plan.SuperSpineModel)
+	if !ok {
+		return p, fmt.Errorf("no profile for super-spine model %s", plan.SuperSpineModel)
+	}
+	return p.InRole(profiles.RoleSpine), nil
+}
+
+// assignCabling cables a plan as hnc cabling does, a multi-pod plan's
+// spines to its super-spines too
+func assignCabling(registry *profiles.Registry, plan fabricplan.Plan, leaf, spine profiles.SwitchProfile, strategy string, seed int64) (cabling.Map, error) {
+	if plan.Pods <= 1 {
+		return cabling.Assign(plan, leaf, spine, strategy, seed)
+	}
+	superSpine, err := findSuperSpine(registry, plan)
+	if err != nil {
+		return cabling.Map{}, err
+	}
+	return cabling.AssignPods(plan, leaf, spine, superSpine, strategy, seed)
+}
+
+// readPlan reads a plan written by hnc plan
+func readPlan(file string) (fabricplan.Plan, error) {
+	var plan fabricplan.Plan
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return plan, fmt.Errorf("reading plan: %w", err)
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return plan, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return plan, nil
+}
+
+// readCabling reads a cabling map written by hnc cabling
+func readCabling(file string) (cabling.Map, error) {
+	var m cabling.Map
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return m, fmt.Errorf("reading cabling map: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return m, nil
+}
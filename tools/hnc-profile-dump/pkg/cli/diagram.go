@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/diagram"
+)
+
+// Diagram draws a plan's cabling as GraphViz DOT or a Mermaid flowchart,
+// from a cabling map or, without one, the cabling hnc cabling would assign
+func Diagram(env Env, args []string) int {
+	flags := newFlags(env, "[-format dot|mermaid] [-cabling FILE] [-output FILE]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	cablingFile := flags.String("cabling", "", "Cabling map written by hnc cabling (default: assign one with -strategy)")
+	strategy := flags.String("strategy", cabling.RoundRobin, "Without -cabling, how leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	format := flags.String("format", "dot", "Output format: "+strings.Join(diagram.Formats, " or "))
+	bundle := flags.Bool("bundle", false, "Draw the cables between two switches as one edge")
+	outputFile := flags.String("output", "", "Output file for the diagram (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+
+	var m cabling.Map
+	if *cablingFile != "" {
+		if m, err = readCabling(*cablingFile); err != nil {
+			return env.failAt(*cablingFile, inputExit(err), "Error %v", err)
+		}
+	} else if m, err = assignCabling(registry, plan, leaf, spine, *strategy, 0); err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+
+	g, err := diagram.FromCabling(plan, m, leaf, spine)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	if *bundle {
+		g = diagram.Bundle(g)
+	}
+	out, err := diagram.Render(g, *format)
+	if err != nil {
+		return env.fail(ExitUsage, "Error: %v", err)
+	}
+	if *outputFile == "" {
+		fmt.Fprint(env.Stdout, out)
+		return ExitOK
+	}
+	return env.writeFile(*outputFile, []byte(out))
+}
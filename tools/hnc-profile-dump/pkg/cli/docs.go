@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/hnc/profile-dump/pkg/fgd"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/schemadoc"
+	"github.com/hnc/profile-dump/pkg/textdiff"
+)
+
+const docsTitle = "HNC File Format Reference"
+
+// schemaDocs documents the switch profile and each FGD file from their Go types
+func schemaDocs() []schemadoc.Doc {
+	docs := []schemadoc.Doc{schemadoc.Describe("Switch profile",
+		fmt.Sprintf("One JSON (or YAML) file per switch model, as written by `hnc profiles dump` to src/fixtures/switch-profiles/. Schema version %s; unknown fields are rejected.", profiles.SchemaVersion),
+		profiles.SwitchProfile{})}
+	for _, f := range fgd.Files {
+		docs = append(docs, schemadoc.Describe("FGD "+f.Name,
+			fmt.Sprintf("Written by the frontend to fgd/<fabric-id>/%s in the legacy FGD format.", f.Name), f.Doc))
+	}
+	return docs
+}
+
+// Docs writes the format reference for switch profiles and FGD files as
+// Markdown or HTML, or with -check diffs it against the file already there
+func Docs(env Env, args []string) int {
+	flags := newFlags(env, "[-format markdown|html] [-output FILE] [-check]")
+	format := flags.String("format", "markdown", "Output format: markdown or html")
+	outputFile := flags.String("output", "", "Output file for the reference (default: stdout)")
+	check := flags.Bool("check", false, "Compare the regenerated reference with -output and print a unified diff instead of writing; exits 1 on drift")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	const intro = "Generated by `hnc docs` from the Go types the HNC tools read and write; do not edit by hand."
+	var out string
+	switch *format {
+	case "markdown":
+		out = schemadoc.Markdown(docsTitle, intro, schemaDocs())
+	case "html":
+		out = schemadoc.HTML(docsTitle, intro, schemaDocs())
+	default:
+		return env.fail(ExitUsage, "Error: unknown -format %q (want markdown or html)", *format)
+	}
+
+	if *check {
+		return env.checkGenerated(*outputFile, out)
+	}
+	if *outputFile == "" {
+		fmt.Fprint(env.Stdout, out)
+		return ExitOK
+	}
+	return env.writeFile(*outputFile, []byte(out))
+}
+
+// checkGenerated diffs a regenerated file, out, against outputFile, for
+// the -check of commands that write files generated from the Go types
+func (env Env) checkGenerated(outputFile, out string) int {
+	if outputFile == "" {
+		return env.fail(ExitUsage, "Error: -check needs -output")
+	}
+	current, err := os.ReadFile(outputFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return env.failAt(outputFile, ExitIO, "Error: %v", err)
+	}
+	if diff := textdiff.Unified(outputFile, outputFile+" (regenerated)", string(current), out); diff != "" {
+		fmt.Fprint(env.Stdout, diff)
+		return env.fail(ExitFailure, "%s is out of date; rerun without -check to regenerate", outputFile)
+	}
+	env.info("%s is up to date", outputFile)
+	return ExitOK
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/doctor"
+	"github.com/hnc/profile-dump/pkg/fabricapi"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Doctor runs every self-test and prints the results, and with -bundle
+// writes them with the tool's environment as a diagnostics bundle for
+// support. Checks run even when earlier ones fail, so one run shows
+// everything that is wrong. It exits 1 when a check fails.
+func Doctor(env Env, args []string) int {
+	flags := newFlags(env, "[flags]")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	fgdDir := flags.String("fgd", "./fgd", "FGD directory the frontend saves fabrics to")
+	templatesDir := flags.String("templates", "", "Directory of *.tmpl export templates to parse (default: skip the check)")
+	fixturesDir := flags.String("fixtures", "", "Directory of switch profile JSON fixtures to check against the JSON Schema (default: skip them)")
+	kubeconfig := flags.String("kubeconfig", "", "Kubeconfig file of the fabric controller (default: kubectl's)")
+	kubeContext := flags.String("context", "", "Kubeconfig context (default: the current one)")
+	offline := flags.Bool("offline", false, "Skip the cluster check")
+	bundleFile := flags.String("bundle", "", "Write the diagnostics bundle as JSON to this file")
+	asJSON := flags.Bool("json", false, "Print the bundle as JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	inputs := map[string]string{}
+	flags.VisitAll(func(f *flag.Flag) { inputs[f.Name] = f.Value.String() })
+	bundle := doctor.NewBundle(time.Now(), inputs)
+
+	if registry, err := loadRegistry(env, *profilesDir); err != nil {
+		bundle.Checks = append(bundle.Checks,
+			doctor.Check{Name: "catalog", Status: doctor.Fail, Summary: fmt.Sprintf("loading profiles: %v", err)},
+			doctor.Check{Name: "schemas", Status: doctor.Skip, Summary: "no profiles loaded"})
+	} else {
+		bundle.Checks = append(bundle.Checks, doctor.Catalog(registry.List()), doctor.Schemas(registry.List(), *fixturesDir))
+	}
+	bundle.Checks = append(bundle.Checks, doctor.Storage(*fgdDir))
+	if *offline {
+		bundle.Checks = append(bundle.Checks, doctor.Check{Name: "cluster", Status: doctor.Skip, Summary: "-offline given"})
+	} else {
+		ctx, stop := env.interruptible()
+		defer stop()
+		bundle.Checks = append(bundle.Checks, doctor.Cluster(ctx, kubectl, fabricapi.Cluster{Kubeconfig: *kubeconfig, Context: *kubeContext}))
+	}
+	if *templatesDir == "" {
+		bundle.Checks = append(bundle.Checks, doctor.Check{Name: "templates", Status: doctor.Skip, Summary: "no -templates directory given"})
+	} else {
+		bundle.Checks = append(bundle.Checks, doctor.Templates(*templatesDir))
+	}
+
+	data, err := canonjson.Marshal(bundle)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding bundle: %v", err)
+	}
+	if *bundleFile != "" {
+		if code := env.writeFile(*bundleFile, data); code != ExitOK {
+			return code
+		}
+	}
+	if *asJSON {
+		env.Stdout.Write(data)
+	} else {
+		printChecks(env.Stdout, bundle.Checks)
+	}
+
+	if bundle.Failed() {
+		return env.fail(ExitFailure, "hnc doctor found problems; attach the output of hnc doctor -bundle FILE to support requests")
+	}
+	return ExitOK
+}
+
+// printChecks writes checks as a table, each check's problems and fix
+// indented under it
+func printChecks(out io.Writer, checks []doctor.Check) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tSUMMARY")
+	for _, c := range checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, c.Status, c.Summary)
+		for _, d := range c.Details {
+			fmt.Fprintf(w, "\t\t  %s\n", d)
+		}
+		if c.Fix != "" {
+			fmt.Fprintf(w, "\t\t  fix: %s\n", c.Fix)
+		}
+	}
+	w.Flush()
+}
+
+// ProfilesDoctor checks a fixtures directory, in the layout it is in,
+// against the profiles the generator writes now (unknown and missing
+// files, schema violations, older schema versions, hand edits and the
+// manifest) and prints what to run about each problem. It exits 1 when a
+// check fails.
+func ProfilesDoctor(env Env, args []string) int {
+	flags := newFlags(env, "[-dir DIR] [-input DIR] [-j N] [-json]")
+	dir := flags.String("dir", "../../src/fixtures/switch-profiles", "Directory of switch profile JSON fixtures")
+	inputDir := flags.String("input", "", "Directory of YAML or JSON profile definitions the fixtures are generated from, as for hnc profiles dump (default: the built-in profiles)")
+	asJSON := flags.Bool("json", false, "Print the checks as JSON instead of a table")
+	jobs := jobsFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	registry, err := loadRegistryJobs(env, *inputDir, *jobs)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	generated, regenerate, err := generatedFixtures(registry, *dir, *inputDir, *jobs)
+	if err != nil {
+		return env.fail(ExitFailure, "Error generating profiles: %v", err)
+	}
+	checks := doctor.Fixtures(*dir, generated, regenerate)
+
+	if *asJSON {
+		data, err := canonjson.Marshal(checks)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding checks: %v", err)
+		}
+		env.Stdout.Write(data)
+	} else {
+		printChecks(env.Stdout, checks)
+	}
+	for _, c := range checks {
+		if c.Status == doctor.Fail {
+			return env.failAt(*dir, ExitFailure, "%s is broken; run the fixes above", *dir)
+		}
+	}
+	env.info("%s matches the generator", *dir)
+	return ExitOK
+}
+
+// generatedFixtures renders the registry's fixtures as hnc profiles dump
+// writes them to dir, in the layout dir is in, and returns them with the
+// command that regenerates them from inputDir
+func generatedFixtures(registry *profiles.Registry, dir, inputDir string, jobs int) ([]profiles.File, string, error) {
+	layout := profiles.DirLayout(dir)
+	files, err := registry.RenderWith(profiles.JSONFile, jobs)
+	if err == nil {
+		files, err = profiles.Partition(layout, registry.List(), files)
+	}
+	regenerate := "hnc profiles dump -output " + dir
+	if inputDir != "" {
+		regenerate += " -input " + inputDir
+	}
+	if layout != profiles.LayoutFlat {
+		regenerate += " -layout " + layout
+	}
+	return files, regenerate, err
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/drift"
+	"github.com/hnc/profile-dump/pkg/fabricapi"
+)
+
+// Drift compares the profiles and wiring a fabric controller serves with
+// the local profiles and, given -plan, the fabric plan. It exits 1 when a
+// difference reaches -fail-on, so it can run as a periodic job.
+func Drift(env Env, args []string) int {
+	flags := newFlags(env, "[flags]")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	planFile := flags.String("plan", "", "Fabric plan written by hnc plan to check the cluster's switches and fabric links against (default: profiles only)")
+	kubeconfig := flags.String("kubeconfig", "", "Kubeconfig file of the fabric controller (default: kubectl's)")
+	kubeContext := flags.String("context", "", "Kubeconfig context (default: the current one)")
+	failOn := flags.String("fail-on", string(drift.Warning), "Lowest severity that fails the run: info, warning or critical")
+	asJSON := flags.Bool("json", false, "Print the report as JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if !slices.Contains(drift.Severities, drift.Severity(*failOn)) {
+		return env.fail(ExitUsage, "Error: unknown -fail-on %q (want info, warning or critical)", *failOn)
+	}
+
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	ctx, stop := env.interruptible()
+	defer stop()
+	cluster := fabricapi.Cluster{Kubeconfig: *kubeconfig, Context: *kubeContext}
+	name, err := fabricapi.ClusterName(ctx, kubectl, cluster)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	live, err := fabricapi.SwitchProfiles(ctx, kubectl, cluster)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+
+	report := drift.Report{Cluster: name}
+	if *planFile == "" {
+		report.Changes = drift.Profiles(registry.List(), live)
+	} else {
+		plan, err := readPlan(*planFile)
+		if err != nil {
+			return env.failAt(*planFile, inputExit(err), "Error %v", err)
+		}
+		leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+		if err != nil {
+			return env.fail(ExitValidation, "Error: %v", err)
+		}
+		switches, err := fabricapi.Switches(ctx, kubectl, cluster)
+		if err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+		links, err := fabricapi.FabricLinks(ctx, kubectl, cluster)
+		if err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+		report.Changes = append(drift.Profiles(registry.List(), live, leaf.ModelID, spine.ModelID),
+			drift.Wiring(plan, leaf.ModelID, spine.ModelID, switches, links)...)
+	}
+	report.Sort()
+
+	if *asJSON {
+		if report.Changes == nil {
+			report.Changes = []drift.Change{}
+		}
+		data, err := canonjson.Marshal(report)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding report: %v", err)
+		}
+		env.Stdout.Write(data)
+	} else if len(report.Changes) == 0 {
+		env.info("No drift in %s", name)
+	} else {
+		w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SEVERITY\tKIND\tOBJECT\tPATH\tLOCAL\tLIVE")
+		for _, c := range report.Changes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Severity, c.Kind, c.Object, c.Path, value(c.Local), value(c.Live))
+		}
+		w.Flush()
+	}
+
+	if max := report.Max(); max != "" && max.AtLeast(drift.Severity(*failOn)) {
+		return env.fail(ExitFailure, "%d difference(s) between %s and the design, up to %s", len(report.Changes), name, max)
+	}
+	return ExitOK
+}
+
+// value prints a report value compactly, - for none
+func value(v any) string {
+	if v == nil {
+		return "-"
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
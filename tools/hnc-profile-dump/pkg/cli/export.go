@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/iac"
+	"github.com/hnc/profile-dump/pkg/netbox"
+	"github.com/hnc/profile-dump/pkg/plugins"
+	"github.com/hnc/profile-dump/pkg/templates"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+// Export renders a plan and its cabling as Terraform or Pulumi resources
+// for the Hedgehog wiring API, with an exporter plugin or through a Go
+// text/template, from a cabling
+// map or, without one, the cabling hnc cabling would assign
+func Export(env Env, args []string) int {
+	flags := newFlags(env, "[-format terraform|pulumi | -template NAME] [-cabling FILE] [-output FILE]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	cablingFile := flags.String("cabling", "", "Cabling map written by hnc cabling, numbered with -addressing for ASNs and link IPs (default: assign one with -strategy)")
+	strategy := flags.String("strategy", cabling.RoundRobin, "Without -cabling, how leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	format := flags.String("format", "terraform", "Output format: "+strings.Join(iac.Formats, " (HCL) or ")+" (Pulumi YAML), or "+pluginRef+"PLUGIN[/NAME] for a plugin's exporter")
+	templateName := flags.String("template", "", "Render this Go text/template file, or the one of this name in -templates, with the design as .Plan and .Cabling, in place of -format")
+	templatesDir := flags.String("templates", "", "Directory of templates for -template (default: $"+templates.DirEnv+", else "+templates.Dir+")")
+	outputFile := flags.String("output", "", "Output file, e.g. main.tf or Pulumi.yaml (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	var templateFile string
+	if *templateName != "" {
+		set := map[string]bool{}
+		flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if set["format"] {
+			return env.fail(ExitUsage, "Error: -template renders in place of -format; drop one")
+		}
+		dir := *templatesDir
+		if dir == "" {
+			dir = fallback(env.getenv(templates.DirEnv), templates.Dir)
+		}
+		var err error
+		if templateFile, err = templates.Find(*templateName, dir); err != nil {
+			return env.fail(ExitIO, "Error: -template: %v", err)
+		}
+	}
+	plugin, exporter, isPlugin, err := env.resolvePlugin(plugins.Exporter, *format)
+	if err != nil {
+		return env.fail(ExitUsage, "Error: -format: %v", err)
+	}
+	plan, m, code := readDesign(env, *planFile, *cablingFile, *profilesDir, *strategy)
+	if code != ExitOK {
+		return code
+	}
+	var out string
+	if templateFile != "" {
+		if out, err = templates.Render(templateFile, templates.Context{Plan: plan, Cabling: m}); err != nil {
+			return env.failAt(templateFile, inputExit(err), "Error: %v", err)
+		}
+	} else if isPlugin {
+		if out, err = plugin.Export(context.Background(), exporter, plan, m); err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+	} else {
+		if len(m.ExternalLinks) > 0 {
+			env.warn("Skipped %d external uplinks, which Hedgehog attaches to externals outside the wiring", len(m.ExternalLinks))
+		}
+		if out, err = iac.Render(iac.Build(plan, m), *format); err != nil {
+			return env.fail(ExitUsage, "Error: %v", err)
+		}
+	}
+	if *outputFile == "" {
+		fmt.Fprint(env.Stdout, out)
+		return ExitOK
+	}
+	return env.writeFile(*outputFile, []byte(out))
+}
+
+// readDesign reads the plan and cabling map hnc export renders: the
+// cabling map in cablingFile or, without one, the one hnc cabling would
+// assign with strategy
+func readDesign(env Env, planFile, cablingFile, profilesDir, strategy string) (fabricplan.Plan, cabling.Map, int) {
+	var m cabling.Map
+	plan, err := readPlan(planFile)
+	if err != nil {
+		return plan, m, env.failAt(planFile, inputExit(err), "Error %v", err)
+	}
+	if plan.Pods > 1 {
+		return plan, m, env.failAt(planFile, ExitValidation, "Error: Hedgehog wiring has no super-spine role, so the %d-pod plan cannot be exported", plan.Pods)
+	}
+	if cablingFile != "" {
+		if m, err = readCabling(cablingFile); err != nil {
+			return plan, m, env.failAt(cablingFile, inputExit(err), "Error %v", err)
+		}
+	} else {
+		registry, err := loadRegistry(env, profilesDir)
+		if err != nil {
+			return plan, m, env.fail(inputExit(err), "Error loading profiles: %v", err)
+		}
+		leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+		if err != nil {
+			return plan, m, env.fail(ExitValidation, "Error: %v", err)
+		}
+		if m, err = assignCabling(registry, plan, leaf, spine, strategy, 0); err != nil {
+			return plan, m, env.fail(ExitFailure, "Error: %v", err)
+		}
+	}
+	if m.LeafModel != plan.LeafModel || m.SpineModel != plan.SpineModel {
+		return plan, m, env.failAt(cablingFile, ExitValidation, "Error: cabling map is for leaf %s and spine %s, the plan for %s and %s",
+			m.LeafModel, m.SpineModel, plan.LeafModel, plan.SpineModel)
+	}
+	return plan, m, ExitOK
+}
+
+// ExportWiring writes a plan and its cabling as the Hedgehog wiring
+// diagram YAML hhfab takes: SwitchGroups, Switches with the ASNs and
+// loopbacks of a numbered cabling map, Connections, and the VLANNamespace
+// the VPC plan allocates from
+func ExportWiring(env Env, args []string) int {
+	flags := newFlags(env, "[-cabling FILE] [-vpcs FILE] [-output FILE]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	cablingFile := flags.String("cabling", "", "Cabling map written by hnc cabling, numbered with -addressing for ASNs and link IPs (default: assign one with -strategy)")
+	strategy := flags.String("strategy", cabling.RoundRobin, "Without -cabling, how leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	vpcsFile := flags.String("vpcs", "", "VPC plan written by hnc vpcs, whose VLAN pool the VLANNamespace holds (default: "+vpcplan.DefaultVLANs.String()+")")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	outputFile := flags.String("output", "", "Output file, e.g. wiring.yaml (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	plan, m, code := readDesign(env, *planFile, *cablingFile, *profilesDir, *strategy)
+	if code != ExitOK {
+		return code
+	}
+	vlans := vpcplan.DefaultVLANs
+	if *vpcsFile != "" {
+		vpcs, err := readVPCPlan(*vpcsFile)
+		if err != nil {
+			return env.failAt(*vpcsFile, inputExit(err), "Error %v", err)
+		}
+		vlans = vpcs.Request.VLANs
+	}
+	if m.Addressing == nil {
+		env.warn("The cabling map is not numbered, so switches have no ASN or protocol IP; number it with hnc cabling -addressing")
+	}
+	if len(m.ExternalLinks) > 0 {
+		env.warn("Skipped %d external uplinks, which Hedgehog attaches to externals outside the wiring", len(m.ExternalLinks))
+	}
+	out, err := iac.Wiring(append([]iac.Object{iac.VLANNamespace(vlans)}, iac.Build(plan, m)...))
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding wiring: %v", err)
+	}
+	if *outputFile == "" {
+		fmt.Fprint(env.Stdout, out)
+		return ExitOK
+	}
+	return env.writeFile(*outputFile, []byte(out))
+}
+
+// ExportNetBox writes a plan and its cabling as the devices, interfaces
+// and cables NetBox records: as YAML for its bulk import pages or, with
+// -url, created at a site through its REST API
+func ExportNetBox(env Env, args []string) int {
+	flags := newFlags(env, "-site SLUG [-url URL] [-output FILE]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	cablingFile := flags.String("cabling", "", "Cabling map written by hnc cabling, with -inventory for server cables (default: assign one with -strategy)")
+	strategy := flags.String("strategy", cabling.RoundRobin, "Without -cabling, how leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	site := flags.String("site", "", "Slug of the NetBox site the devices are at (required)")
+	apiURL := flags.String("url", "", "NetBox to create the devices and cables in through its REST API, authenticated by NETBOX_TOKEN (default: $NETBOX_URL, else write YAML)")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	outputFile := flags.String("output", "", "Output file for the YAML, e.g. netbox.yaml (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if *site == "" {
+		env.fail(ExitUsage, "Error: -site is required")
+		flags.Usage()
+		return ExitUsage
+	}
+	if *apiURL == "" {
+		*apiURL = env.getenv("NETBOX_URL")
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	superSpine, err := findSuperSpine(registry, plan)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	var m cabling.Map
+	if *cablingFile != "" {
+		if m, err = readCabling(*cablingFile); err != nil {
+			return env.failAt(*cablingFile, inputExit(err), "Error %v", err)
+		}
+	} else if m, err = assignCabling(registry, plan, leaf, spine, *strategy, 0); err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	if len(m.ExternalLinks) > 0 {
+		env.warn("Skipped %d external uplinks, whose peers HNC does not name as NetBox devices", len(m.ExternalLinks))
+	}
+	e, err := netbox.Build(plan, m, leaf, spine, superSpine, *site)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+
+	if *apiURL == "" {
+		out, err := netbox.Render(e)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding NetBox YAML: %v", err)
+		}
+		if *outputFile == "" {
+			fmt.Fprint(env.Stdout, out)
+			return ExitOK
+		}
+		return env.writeFile(*outputFile, []byte(out))
+	}
+	if *outputFile != "" {
+		return env.fail(ExitUsage, "Error: -output writes YAML; drop it to push to %s", *apiURL)
+	}
+	env.record("create", "%d devices, %d interfaces and %d cables at NetBox site %s", len(e.Devices), len(e.Interfaces), len(e.Cables), *site)
+	if env.dryRunning() {
+		return ExitOK
+	}
+	client := netbox.Client{URL: *apiURL, Token: env.getenv("NETBOX_TOKEN")}
+	ctx, stop := env.interruptible()
+	defer stop()
+	res, err := client.PushContext(ctx, e)
+	if err != nil {
+		if res.Devices+res.Interfaces+res.Cables > 0 {
+			env.info("Created %d devices, %d interfaces and %d cables before stopping", res.Devices, res.Interfaces, res.Cables)
+		}
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	env.info("Created %d devices, %d interfaces and %d cables at %s site %s; %d were already there",
+		res.Devices, res.Interfaces, res.Cables, *apiURL, *site, res.Existing)
+	if res.Roles > 0 {
+		env.info("Created %d device roles", res.Roles)
+	}
+	return ExitOK
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/exports"
+)
+
+// formatVersionFlag adds -format-version, the version of the export
+// formats a command writes
+func formatVersionFlag(flags *flag.FlagSet, format string) *string {
+	f, _ := exports.Find(format)
+	return flags.String("format-version", f.Current(), "Version of the "+strings.TrimSuffix(format, "-json")+" files to write: "+
+		strings.Join(f.Versions, " or ")+"; pin it so automation keeps reading what it was written against")
+}
+
+// checkFormatVersion is ExitOK if the format can be written at version
+func (env Env) checkFormatVersion(format, version string) int {
+	f, _ := exports.Find(format)
+	if err := f.Check(version); err != nil {
+		return env.fail(ExitUsage, "Error: %v", err)
+	}
+	return ExitOK
+}
+
+// FormatsList prints every export format and the versions hnc can write
+func FormatsList(env Env, args []string) int {
+	flags := newFlags(env, "[-json]")
+	asJSON := flags.Bool("json", false, "Print the formats as JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if *asJSON {
+		data, err := canonjson.Marshal(exports.Formats)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding formats: %v", err)
+		}
+		env.Stdout.Write(data)
+		return ExitOK
+	}
+	w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FORMAT\tWRITTEN BY\tCURRENT\tVERSIONS")
+	for _, f := range exports.Formats {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Name, f.WrittenBy, f.Current(), strings.Join(f.Versions, ", "))
+	}
+	w.Flush()
+	return ExitOK
+}
+
+// FormatsConvert rewrites an exported file at another version of its
+// format, for automation pinned to an older layout
+func FormatsConvert(env Env, args []string) int {
+	flags := newFlags(env, "-format NAME -to VERSION [-output FILE] FILE|-")
+	format := flags.String("format", "", "Format of the file: "+strings.Join(exports.Names(), ", "))
+	to := flags.String("to", "", "Version to convert to (default: the current one)")
+	outputFile := flags.String("output", "", "Output file (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if flags.NArg() != 1 {
+		env.fail(ExitUsage, "Error: name one file to convert, or - for stdin")
+		flags.Usage()
+		return ExitUsage
+	}
+	f, ok := exports.Find(*format)
+	if !ok {
+		return env.fail(ExitUsage, "Error: unknown -format %q (want %s)", *format, strings.Join(exports.Names(), ", "))
+	}
+	if *to == "" {
+		*to = f.Current()
+	}
+
+	file := flags.Arg(0)
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(env.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return env.fail(ExitIO, "Error: %v", err)
+	}
+	out, from, err := f.Convert(data, *to)
+	if err != nil {
+		return env.failAt(file, ExitValidation, "Error converting %s: %v", file, err)
+	}
+	if *outputFile == "" {
+		env.Stdout.Write(out)
+		return ExitOK
+	}
+	if code := env.writeFile(*outputFile, out); code != ExitOK {
+		return code
+	}
+	env.info("Converted %s from %s %s to %s", file, f.Name, from, *to)
+	return ExitOK
+}
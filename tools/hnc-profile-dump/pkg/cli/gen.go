@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/tsgen"
+)
+
+// tsTypes are the Go types the frontend reads, under their TypeScript names
+var tsTypes = []tsgen.Type{{Name: "SwitchProfile", Value: profiles.SwitchProfile{}}}
+
+// GenTSTypes writes TypeScript declarations for the switch profile, or
+// with -zod zod schemas and the types inferred from them, or with -check
+// diffs them against the file already there
+func GenTSTypes(env Env, args []string) int {
+	flags := newFlags(env, "[-zod] [-output FILE] [-check]")
+	zod := flags.Bool("zod", false, "Write zod schemas, and the types inferred from them, as a .ts module instead of .d.ts declarations")
+	outputFile := flags.String("output", "", "Output file for the declarations (default: stdout)")
+	check := flags.Bool("check", false, "Compare the regenerated declarations with -output and print a unified diff instead of writing; exits 1 on drift")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	out := tsgen.Generate(tsgen.Options{
+		Header: fmt.Sprintf("Generated by `hnc gen ts-types` from the Go profile structs, schema version %s; do not edit by hand.", profiles.SchemaVersion),
+		Zod:    *zod,
+		Strict: true, // profiles are decoded with unknown fields rejected
+	}, tsTypes...)
+	if *check {
+		return env.checkGenerated(*outputFile, out)
+	}
+	if *outputFile == "" {
+		fmt.Fprint(env.Stdout, out)
+		return ExitOK
+	}
+	return env.writeFile(*outputFile, []byte(out))
+}
@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/inventory"
+	"github.com/hnc/profile-dump/pkg/netbox"
+	"github.com/hnc/profile-dump/pkg/ports"
+	"github.com/hnc/profile-dump/pkg/present"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/sonicplatform"
+	"github.com/hnc/profile-dump/pkg/wiringyaml"
+)
+
+// ImportWiring reads a Hedgehog wiring.yaml and writes the fabric plan and
+// cabling map it amounts to, for loading a brownfield fabric into the
+// designer, and prints every switch's port utilization
+func ImportWiring(env Env, args []string) int {
+	flags := newFlags(env, "[flags] FILE|-")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	planFile := flags.String("output", "fabric-plan.json", "Output file for the fabric plan")
+	cablingFile := flags.String("cabling", "cabling.json", "Output file for the cabling map (\"\" for none)")
+	utilizationFile := flags.String("utilization", "", "Also write the port utilization as JSON to this file (default: none)")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if flags.NArg() != 1 {
+		env.fail(ExitUsage, "Error: name one wiring file to import, or - for stdin")
+		flags.Usage()
+		return ExitUsage
+	}
+
+	file := flags.Arg(0)
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(env.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return env.fail(ExitIO, "Error: %v", err)
+	}
+	w, err := wiringyaml.Parse(data)
+	if err != nil {
+		return env.failAt(file, ExitValidation, "Error parsing %s: %v", file, err)
+	}
+	return env.reconstruct(w, file, *profilesDir, *planFile, *cablingFile, *utilizationFile)
+}
+
+// reconstruct rebuilds the design a wiring from source amounts to, writes
+// the plan, cabling map and utilization to the files given ("" for none),
+// and prints every switch's port utilization
+func (env Env) reconstruct(w wiringyaml.Wiring, source, profilesDir, planFile, cablingFile, utilizationFile string) int {
+	registry, err := loadRegistry(env, profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	imported, err := wiringyaml.Reconstruct(w, registry)
+	if err != nil {
+		return env.failAt(source, ExitValidation, "Error importing %s: %v", source, err)
+	}
+	for _, warning := range imported.Warnings {
+		env.warn("Warning: %s", warning)
+	}
+
+	outputs := []struct {
+		file, what string
+		value      any
+	}{
+		{planFile, "plan", imported.Plan},
+		{cablingFile, "cabling map", imported.Cabling},
+		{utilizationFile, "utilization", imported.Utilization},
+	}
+	for _, o := range outputs {
+		if o.file == "" {
+			continue
+		}
+		data, err := canonjson.Marshal(o.value)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding %s: %v", o.what, err)
+		}
+		if code := env.writeFile(o.file, data); code != ExitOK {
+			return code
+		}
+	}
+	plan := imported.Plan
+	env.info("Imported %d leaves (%s), %d spines (%s), %d cables and %d endpoints from %s",
+		plan.Leaves, plan.LeafModel, plan.Spines, plan.SpineModel, len(imported.Cabling.Cables), plan.Request.Endpoints, source)
+
+	present.Detect(env.Stdout, env.getenv).Render(env.Stdout, utilizationTable(imported.Utilization))
+	return ExitOK
+}
+
+// ImportNetBox reads the devices and cables of a NetBox site, from its
+// REST API or a file written by hnc export netbox, and writes the fabric
+// plan and cabling map they amount to, as hnc import wiring does
+func ImportNetBox(env Env, args []string) int {
+	flags := newFlags(env, "-site SLUG [-url URL] [flags] | [flags] FILE|-")
+	site := flags.String("site", "", "Slug of the NetBox site to read the devices and cables of, with -url")
+	apiURL := flags.String("url", "", "NetBox to read through its REST API, authenticated by NETBOX_TOKEN (default: $NETBOX_URL, unless a FILE is named)")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	planFile := flags.String("output", "fabric-plan.json", "Output file for the fabric plan")
+	cablingFile := flags.String("cabling", "cabling.json", "Output file for the cabling map (\"\" for none)")
+	utilizationFile := flags.String("utilization", "", "Also write the port utilization as JSON to this file (default: none)")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if *apiURL == "" && flags.NArg() == 0 {
+		*apiURL = env.getenv("NETBOX_URL")
+	}
+	if (*apiURL == "") == (flags.NArg() == 0) || flags.NArg() > 1 || (*apiURL != "" && *site == "") {
+		env.fail(ExitUsage, "Error: name one file written by hnc export netbox, or - for stdin, or a -site to read from -url")
+		flags.Usage()
+		return ExitUsage
+	}
+
+	var e netbox.Export
+	var err error
+	source := *apiURL
+	if *apiURL != "" {
+		client := netbox.Client{URL: *apiURL, Token: env.getenv("NETBOX_TOKEN")}
+		ctx, stop := env.interruptible()
+		defer stop()
+		if e, err = client.FetchContext(ctx, *site); err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+	} else {
+		source = flags.Arg(0)
+		var data []byte
+		if source == "-" {
+			data, err = io.ReadAll(env.Stdin)
+		} else {
+			data, err = os.ReadFile(source)
+		}
+		if err != nil {
+			return env.fail(ExitIO, "Error: %v", err)
+		}
+		if e, err = netbox.Parse(data); err != nil {
+			return env.failAt(source, ExitValidation, "Error parsing %s: %v", source, err)
+		}
+	}
+	w, err := e.Wiring()
+	if err != nil {
+		return env.failAt(source, ExitValidation, "Error importing %s: %v", source, err)
+	}
+	return env.reconstruct(w, source, *profilesDir, *planFile, *cablingFile, *utilizationFile)
+}
+
+// ImportPlatform converts the port map of a SONiC platform, its
+// platform.json and optionally an hwsku.json, into a switch profile
+// definition, for onboarding a hardware model without writing its
+// profile by hand
+func ImportPlatform(env Env, args []string) int {
+	flags := newFlags(env, "-model-id ID [flags] PLATFORM.JSON|-")
+	var opts sonicplatform.Options
+	flags.StringVar(&opts.ModelID, "model-id", "", "Hedgehog model ID of the switch, vendor and model joined by a hyphen, e.g. celestica-ds2000 (required)")
+	hwskuFile := flags.String("hwsku", "", "hwsku.json of the SKU, for the breakout mode the ports come up in (default: none)")
+	flags.IntVar(&opts.FabricPorts, "fabric-ports", 0, "Make the last N ports the fabric ports of a leaf (default: the fastest ports of a mixed-speed platform, else every port of a spine)")
+	outputDir := flags.String("output", ".", "Directory to write the profile definition to, named for the model")
+	format := flags.String("format", "yaml", "Definition format: yaml or json")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if flags.NArg() != 1 || opts.ModelID == "" {
+		env.fail(ExitUsage, "Error: name one platform.json to import, or - for stdin, and its -model-id")
+		flags.Usage()
+		return ExitUsage
+	}
+	renderer := profiles.YAMLFile
+	switch *format {
+	case "yaml":
+	case "json":
+		renderer = profiles.JSONFile
+	default:
+		return env.fail(ExitUsage, "Error: unknown -format %q (want yaml or json)", *format)
+	}
+
+	file := flags.Arg(0)
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(env.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return env.fail(ExitIO, "Error: %v", err)
+	}
+	platform, err := sonicplatform.ParsePlatform(data)
+	if err != nil {
+		return env.failAt(file, ExitValidation, "Error parsing %s: %v", file, err)
+	}
+	var hwsku *sonicplatform.HWSKU
+	if *hwskuFile != "" {
+		data, err := os.ReadFile(*hwskuFile)
+		if err != nil {
+			return env.fail(ExitIO, "Error: %v", err)
+		}
+		h, err := sonicplatform.ParseHWSKU(data)
+		if err != nil {
+			return env.failAt(*hwskuFile, ExitValidation, "Error parsing %s: %v", *hwskuFile, err)
+		}
+		hwsku = &h
+	}
+	opts.Source = filepath.Base(file)
+	r, err := sonicplatform.Convert(platform, hwsku, opts)
+	if err != nil {
+		return env.failAt(file, ExitValidation, "Error importing %s: %v", file, err)
+	}
+	for _, warning := range r.Warnings {
+		env.warn("Warning: %s", warning)
+	}
+
+	out, err := renderer(r.Profile)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding profile: %v", err)
+	}
+	if err := env.mkdirAll(*outputDir); err != nil {
+		return env.failAt(*outputDir, ExitIO, "Error: failed to create output directory: %v", err)
+	}
+	path := filepath.Join(*outputDir, out.Name)
+	if code := env.writeFile(path, out.Data); code != ExitOK {
+		return code
+	}
+	p := r.Profile
+	endpoint, _ := ports.Expand(p.Ports.EndpointAssignable)
+	fabric, _ := ports.Expand(p.Ports.FabricAssignable)
+	env.info("Imported %s as a %s with %d endpoint and %d fabric ports from %s; review it with hnc profiles lint %s",
+		p.ModelID, p.Roles[0], len(endpoint), len(fabric), file, path)
+	return ExitOK
+}
+
+// ImportEndpoints reads a server inventory, CSV or JSON, and writes it as
+// endpoints.json for hnc plan, cabling and vpcs -inventory, which size,
+// cable and attach the fabric server by server
+func ImportEndpoints(env Env, args []string) int {
+	flags := newFlags(env, "[flags] FILE|-")
+	outputFile := flags.String("output", "endpoints.json", "Output file for the inventory")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if flags.NArg() != 1 {
+		env.fail(ExitUsage, "Error: name one inventory file to import, or - for stdin")
+		flags.Usage()
+		return ExitUsage
+	}
+
+	file := flags.Arg(0)
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(env.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return env.fail(ExitIO, "Error: %v", err)
+	}
+	inv, err := inventory.Parse(data)
+	if err != nil {
+		return env.failAt(file, ExitValidation, "Error parsing %s: %v", file, err)
+	}
+	out, err := canonjson.Marshal(inv)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding inventory: %v", err)
+	}
+	if code := env.writeFile(*outputFile, out); code != ExitOK {
+		return code
+	}
+	nics := 0
+	for _, s := range inv.Servers {
+		nics += s.NICs
+	}
+	var classes []string
+	for _, c := range inv.Classes() {
+		classes = append(classes, c.String())
+	}
+	env.info("Imported %d servers with %d NICs from %s: %s endpoints, %s-homed", len(inv.Servers), nics, file,
+		strings.Join(classes, ", "), inv.Servers[0].Redundancy)
+	return ExitOK
+}
+
+// inventoryUsage describes an -inventory flag
+const inventoryUsage = "Server inventory written by hnc import endpoints"
+
+// readInventory reads an inventory written by hnc import endpoints
+func readInventory(file string) (inventory.Inventory, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return inventory.Inventory{}, fmt.Errorf("reading inventory: %w", err)
+	}
+	inv, err := inventory.Parse(data)
+	if err != nil {
+		return inv, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return inv, nil
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/layout"
+	"github.com/hnc/profile-dump/pkg/optics"
+)
+
+// Layout places a plan's switches and servers in racks and pods and
+// writes the layout as JSON for cabling
+func Layout(env Env, args []string) int {
+	var opts layout.Options
+	flags := newFlags(env, "[flags]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	flags.IntVar(&opts.RackUnits, "rack-units", layout.DefaultRackUnits, "Usable rack units per rack")
+	flags.IntVar(&opts.LeavesPerRack, "leaves-per-rack", 0, "Leaves top of rack in each rack (default: 1, or the leaf pair with -redundancy)")
+	flags.IntVar(&opts.RacksPerPod, "racks-per-pod", layout.DefaultRacksPerPod, "Racks in each pod's row")
+	flags.IntVar(&opts.ServerUnits, "server-units", layout.DefaultServerUnits, "Rack units per server")
+	flags.Float64Var(&opts.RackWidthM, "rack-width", layout.DefaultRackWidthM, "Rack width in meters, for cable runs along a row")
+	flags.Float64Var(&opts.PodPitchM, "pod-pitch", layout.DefaultPodPitchM, "Distance in meters from one pod's row to the next")
+	flags.Float64Var(&opts.DropM, "drop", layout.DefaultDropM, "Meters of cable at each end from the port to the tray, with slack")
+	outputFile := flags.String("output", "layout.json", "Output file for the layout")
+	formatVersion := formatVersionFlag(flags, "layout-json")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if code := env.checkFormatVersion("layout-json", *formatVersion); code != ExitOK {
+		return code
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	l, err := layout.Plan(plan, leaf, spine, opts)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	data, err := canonjson.Marshal(l)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding layout: %v", err)
+	}
+	pods, servers := 0, 0
+	for _, r := range l.Racks {
+		pods = max(pods, r.Pod)
+		servers += r.Servers
+	}
+	env.record("allocate", "%d racks in %d pod(s)", len(l.Racks), pods)
+	if code := env.writeFile(*outputFile, data); code != ExitOK {
+		return code
+	}
+	env.info("Placed %d switches and %d servers in %d racks across %d pod(s)", plan.Leaves+plan.Spines, servers, len(l.Racks), pods)
+	return ExitOK
+}
+
+func readLayout(file string) (layout.Layout, error) {
+	var l layout.Layout
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return l, fmt.Errorf("reading layout: %w", err)
+	}
+	if err := json.Unmarshal(data, &l); err != nil {
+		return l, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return l, nil
+}
+
+// lengthCounts prints cables by length class, shortest first: "3m x 8,
+// 5m x 4"
+func lengthCounts(counts map[string]int) string {
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		a, _ := optics.ParseLength(classes[i])
+		b, _ := optics.ParseLength(classes[j])
+		return a < b
+	})
+	parts := make([]string, len(classes))
+	for i, class := range classes {
+		parts[i] = fmt.Sprintf("%s x %d", class, counts[class])
+	}
+	return strings.Join(parts, ", ")
+}
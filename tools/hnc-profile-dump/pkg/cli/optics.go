@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/optics"
+)
+
+// OpticsList prints the optics matrix: the transceivers, DAC and AOC
+// cables for every port profile, or for the one given
+func OpticsList(env Env, args []string) int {
+	flags := newFlags(env, "[-port-profile NAME] [-json]")
+	portProfile := flags.String("port-profile", "", "Port profile to list, e.g. QSFP28-100G (default: all)")
+	asJSON := flags.Bool("json", false, "Print the options as JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	list := optics.All()
+	if *portProfile != "" {
+		if list = optics.For(*portProfile); len(list) == 0 {
+			return env.fail(ExitFailure, "Error: no optics for port profile %s (known: %s)", *portProfile, strings.Join(optics.PortProfiles(), ", "))
+		}
+	}
+	if *asJSON {
+		data, err := canonjson.Marshal(list)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding optics: %v", err)
+		}
+		env.Stdout.Write(data)
+		return ExitOK
+	}
+	w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PORT PROFILE\tSKU\tMEDIA\tSTANDARD\tREACH\tBREAKOUT")
+	for _, o := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", o.PortProfile, o.SKU, o.Media, dash(o.Standard), o.Reach(), dash(o.Breakout))
+	}
+	w.Flush()
+	return ExitOK
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,791 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/addressing"
+	"github.com/hnc/profile-dump/pkg/bom"
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/inventory"
+	"github.com/hnc/profile-dump/pkg/optimize"
+	"github.com/hnc/profile-dump/pkg/plugins"
+	"github.com/hnc/profile-dump/pkg/pricing"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/provenance"
+)
+
+// objectivesUsage lists the -optimize objectives: "cost (list price of
+// the switches), ..."
+func objectivesUsage() string {
+	var parts []string
+	for _, o := range optimize.Objectives {
+		parts = append(parts, o.Name+" ("+o.Summary+")")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Plan sizes a fabric for an endpoint count and oversubscription target
+// and writes the plan as JSON for bom and cabling
+func Plan(env Env, args []string) int {
+	var req fabricplan.Request
+	flags := newFlags(env, "-endpoints N [flags] | -interactive [flags]")
+	flags.IntVar(&req.Endpoints, "endpoints", 0, "Number of endpoint ports to carry (required)")
+	flags.Float64Var(&req.Oversubscription, "oversubscription", 3, "Target endpoint:uplink bandwidth ratio, e.g. 3 for 3:1; with -workload roce the default is the 2:1 that workload allows")
+	flags.IntVar(&req.MinSpines, "min-spines", 2, "Fewest spines to plan for")
+	flags.IntVar(&req.EndpointSpeedGbps, "endpoint-speed", 0, "Endpoint port speed in Gbps (default: leaf profile speed)")
+	classes := flags.String("endpoint-classes", "", "Mixed-speed endpoints as COUNTxSPEED, comma-separated, e.g. 40x25G,16x100G,8x10G; in place of -endpoints and -endpoint-speed")
+	inventoryFile := flags.String("inventory", "", inventoryUsage+"; in place of -endpoints, -endpoint-speed and -endpoint-classes")
+	flags.StringVar(&req.Breakout, "breakout", "", "Breakout mode for leaf uplinks and spine fabric ports, e.g. 4x25G (default: none)")
+	flags.StringVar(&req.Redundancy, "redundancy", fabricplan.RedundancyNone, "Leaf redundancy: "+strings.Join(fabricplan.Redundancies, ", ")+"; mclag and eslag pair leaves and dual-home every endpoint")
+	flags.IntVar(&req.PeerLinks, "peer-links", 0, "With -redundancy mclag, peer links per leaf pair (default: 2)")
+	flags.StringVar(&req.Topology, "topology", fabricplan.LeafSpine, "Fabric topology: "+strings.Join(fabricplan.Topologies, ", ")+"; collapsed-core is one MCLAG or ESLAG leaf pair and single-switch one leaf, neither with spines")
+	flags.StringVar(&req.Workload, "workload", fabricplan.WorkloadGeneral, "What the endpoints run: "+strings.Join(fabricplan.Workloads, ", ")+fmt.Sprintf("; roce plans only lossless switches, whose profiles set capabilities.features.roce (of the built-ins, the DS2000 and DS3000), at most %d:1 oversubscribed, and adds their PFC and ECN settings to the plan", fabricplan.MaxRoCEOversubscription))
+	flags.IntVar(&req.Pods, "pods", 1, "Split the endpoints over this many leaf/spine pods joined by super-spines")
+	superSpineModel := flags.String("super-spine", "", "With -pods, super-spine model ID or short name (default: the spine model)")
+	flags.Float64Var(&req.PodOversubscription, "pod-oversubscription", 0, "With -pods, target spine leaf-facing:super-spine bandwidth ratio (default: -oversubscription)")
+	flags.IntVar(&req.MinSuperSpines, "min-super-spines", 0, "With -pods, fewest super-spines to plan for (default: 2)")
+	var external fabricplan.External
+	flags.IntVar(&external.PortsPerLeaf, "external-ports", 0, "Endpoint ports each border leaf gives to uplinks out of the fabric, to routers or firewalls (default: none)")
+	flags.IntVar(&external.BorderLeaves, "border-leaves", 0, "With -external-ports, how many of the last leaves are border leaves (default: 2, or 1 on a single switch)")
+	flags.IntVar(&external.Peers, "external-peers", 0, "With -external-ports, external routers or firewalls the uplinks spread over (default: 2)")
+	leafModel := flags.String("leaf", "DS2000", "Leaf model ID or short name; with -optimize, only consider this leaf")
+	spineModel := flags.String("spine", "DS3000", "Spine model ID or short name; with -optimize, only consider this spine")
+	objective := flags.String("optimize", "", "Choose the leaf and spine models from the profiles that minimize "+objectivesUsage()+"; models whose profiles lack the figures are left out, and it fails when no leaf or no spine has them (default: use -leaf and -spine)")
+	deprecated := flags.String("deprecated", "warn", "What to do with a model whose profile marks it deprecated or end-of-sale: "+strings.Join(deprecatedPolicies, ", ")+"; with refuse, -optimize leaves such models out. The built-in profiles are all active and carry no end-of-life dates")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	outputFile := flags.String("output", "fabric-plan.json", "Output file for the plan, or - for stdout")
+	csvFile := flags.String("csv", "", "Also write the plan as CSV, one field,value row per value, to this file (default: none)")
+	xlsxFile := flags.String("xlsx", "", "Also write the plan as an Excel workbook to this file (default: none)")
+	interactive := flags.Bool("interactive", false, "Ask for the topology, endpoints, speeds, redundancy, oversubscription and models one at a time, previewing the fabric after each answer; the flags give the defaults")
+	formatVersion := formatVersionFlag(flags, "plan-json")
+	watch := watchFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if code := env.checkFormatVersion("plan-json", *formatVersion); code != ExitOK {
+		return code
+	}
+	if !slices.Contains(deprecatedPolicies, *deprecated) {
+		return env.fail(ExitUsage, "Error: unknown -deprecated %q (want %s)", *deprecated, strings.Join(deprecatedPolicies, ", "))
+	}
+	if *watch {
+		switch {
+		case *profilesDir == "" || *profilesDir == "-":
+			return env.fail(ExitUsage, "Error: -watch needs -profiles DIR, the definitions to watch")
+		case *interactive:
+			return env.fail(ExitUsage, "Error: -watch and -interactive cannot be combined")
+		}
+		outputs := []string{*outputFile}
+		for _, f := range []string{*csvFile, *xlsxFile} {
+			if f != "" {
+				outputs = append(outputs, f)
+			}
+		}
+		inputs := []string{*profilesDir}
+		if *inventoryFile != "" {
+			inputs = append(inputs, *inventoryFile)
+		}
+		return env.watch(inputs, outputs, Plan, args)
+	}
+	if *classes != "" {
+		var err error
+		if req.Classes, err = fabricplan.ParseClasses(*classes); err != nil {
+			return env.fail(ExitUsage, "Error: -endpoint-classes: %v", err)
+		}
+	}
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["endpoints"] && req.Endpoints <= 0 {
+		return env.fail(ExitUsage, "Error: -endpoints must be positive, got %d", req.Endpoints)
+	}
+	if set["min-spines"] && req.MinSpines <= 0 {
+		return env.fail(ExitUsage, "Error: -min-spines must be positive, got %d", req.MinSpines)
+	}
+	if *inventoryFile != "" {
+		if set["endpoints"] || set["endpoint-speed"] || set["endpoint-classes"] || *interactive {
+			return env.fail(ExitUsage, "Error: -inventory gives the endpoints; drop -endpoints, -endpoint-speed, -endpoint-classes and -interactive")
+		}
+		inv, err := readInventory(*inventoryFile)
+		if err != nil {
+			return env.failAt(*inventoryFile, inputExit(err), "Error %v", err)
+		}
+		if err := inv.Apply(&req); err != nil {
+			return env.failAt(*inventoryFile, ExitValidation, "Error: %s: %v", *inventoryFile, err)
+		}
+	}
+	if req.Pods == 1 {
+		req.Pods = 0
+	}
+	if req.Workload == fabricplan.RoCE && !set["oversubscription"] {
+		req.Oversubscription = fabricplan.MaxRoCEOversubscription
+	}
+	var registry *profiles.Registry
+	if *interactive {
+		if *objective != "" {
+			return env.fail(ExitUsage, "Error: -interactive asks for the leaf and spine models; drop -optimize")
+		}
+		var err error
+		if registry, err = loadRegistry(env, *profilesDir); err != nil {
+			return env.fail(inputExit(err), "Error loading profiles: %v", err)
+		}
+		w := planWizard{in: bufio.NewScanner(env.Stdin), out: env.Stderr, registry: registry,
+			req: &req, leafModel: leafModel, spineModel: spineModel, output: *outputFile, superSpineModel: *superSpineModel}
+		ok, err := w.run()
+		if err != nil {
+			return env.fail(ExitIO, "Error reading answers: %v", err)
+		}
+		if !ok {
+			return env.fail(ExitFailure, "Error: no plan confirmed; nothing was written")
+		}
+		// The answers stand in for -spine and -min-spines
+		delete(set, "spine")
+		delete(set, "min-spines")
+	}
+	if req.Endpoints <= 0 && len(req.Classes) == 0 {
+		env.fail(ExitUsage, "Error: -endpoints is required")
+		flags.Usage()
+		return ExitUsage
+	}
+
+	if req.Pods < 0 {
+		return env.fail(ExitUsage, "Error: -pods must be positive, got %d", req.Pods)
+	}
+	if req.Pods == 0 && (set["super-spine"] || set["pod-oversubscription"] || set["min-super-spines"]) {
+		return env.fail(ExitUsage, "Error: -super-spine, -pod-oversubscription and -min-super-spines need -pods")
+	}
+	if external.PortsPerLeaf != 0 {
+		req.External = &external
+	} else if set["border-leaves"] || set["external-peers"] {
+		return env.fail(ExitUsage, "Error: -border-leaves and -external-peers need -external-ports")
+	}
+	if req.Pods > 1 && *objective != "" {
+		return env.fail(ExitUsage, "Error: -optimize pairs leaves with spines, not pods of them with super-spines")
+	}
+	spineless := req.Topology != fabricplan.LeafSpine && req.Topology != ""
+	if spineless {
+		if *objective != "" {
+			return env.fail(ExitUsage, "Error: -optimize pairs leaves with spines, which a %s fabric does not have", req.Topology)
+		}
+		if set["spine"] || set["min-spines"] {
+			return env.fail(ExitUsage, "Error: a %s fabric has no spines; drop -spine and -min-spines", req.Topology)
+		}
+		*spineModel = ""
+	}
+
+	var err error
+	if registry == nil {
+		if registry, err = loadRegistry(env, *profilesDir); err != nil {
+			return env.fail(inputExit(err), "Error loading profiles: %v", err)
+		}
+	}
+	done := env.recorder().Stage("plan")
+	var plan fabricplan.Plan
+	if *objective != "" {
+		if _, ok := optimize.Find(*objective); !ok {
+			return env.fail(ExitUsage, "Error: unknown -optimize %q (want %s)", *objective, strings.Join(optimize.Names(), ", "))
+		}
+		candidates := registry.List()
+		if *deprecated == "refuse" {
+			candidates = slices.DeleteFunc(candidates, profiles.SwitchProfile.Retired)
+		}
+		leaves, spines := optimize.Choices(candidates)
+		if set["leaf"] || set["spine"] {
+			leaf, spine, err := findModels(registry, *leafModel, *spineModel)
+			if err != nil {
+				return env.fail(ExitValidation, "Error: %v", err)
+			}
+			if set["leaf"] {
+				leaves = []profiles.SwitchProfile{leaf}
+			}
+			if set["spine"] {
+				spines = []profiles.SwitchProfile{spine}
+			}
+		}
+		ctx, stop := env.interruptible()
+		defer stop()
+		res, err := optimize.PlanContext(ctx, req, leaves, spines, *objective)
+		for _, skipped := range res.Skipped {
+			env.warn("Skipped %s", skipped)
+		}
+		if err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+		plan = res.Best.Plan
+		env.info("Optimized for %s: %s leaves and %s spines score %g, best of %d pairing(s)",
+			res.Objective, plan.LeafModel, plan.SpineModel, res.Best.Score, len(res.Candidates))
+	} else {
+		leaf, spine, err := findModels(registry, *leafModel, *spineModel)
+		if err != nil {
+			return env.fail(ExitValidation, "Error: %v", err)
+		}
+		if req.Pods > 1 {
+			if *superSpineModel == "" {
+				*superSpineModel = *spineModel
+			}
+			superSpine, ok := registry.Find(*superSpineModel)
+			if !ok {
+				return env.fail(ExitValidation, "Error: no profile for super-spine model %s", *superSpineModel)
+			}
+			if plan, err = fabricplan.ComputePods(req, leaf, spine, superSpine.InRole(profiles.RoleSpine)); err != nil {
+				return env.fail(ExitFailure, "Error: %v", err)
+			}
+		} else if plan, err = fabricplan.Compute(req, leaf, spine); err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+	}
+	done()
+	env.recorder().Plan(plan)
+	if code := checkConstraints(env, registry, plan, nil); code != ExitOK {
+		return code
+	}
+	if code := checkLifecycle(env, registry, plan, *deprecated); code != ExitOK {
+		return code
+	}
+	data, err := canonjson.Marshal(plan)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding plan: %v", err)
+	}
+	if spineless {
+		env.record("allocate", "%d leaves (%s) as a %s fabric, no spines", plan.Leaves, plan.LeafModel, plan.Topology())
+	} else {
+		env.record("allocate", "%d leaves (%s), %d spines (%s), %d uplinks per leaf",
+			plan.Leaves, plan.LeafModel, plan.Spines, plan.SpineModel, plan.UplinksPerLeaf)
+	}
+	if code := env.writeFile(*outputFile, data); code != ExitOK {
+		return code
+	}
+	if *csvFile != "" || *xlsxFile != "" {
+		text, err := fabricplan.RenderCSV(plan)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding plan: %v", err)
+		}
+		if *csvFile != "" {
+			if code := env.writeFile(*csvFile, []byte(text)); code != ExitOK {
+				return code
+			}
+		}
+		if *xlsxFile != "" {
+			if code := env.writeWorkbook(*xlsxFile, csvSheet{"Plan", text}); code != ExitOK {
+				return code
+			}
+		}
+	}
+	if spineless {
+		env.info("Planned a %s fabric: %d leaves, no spines, %d endpoints per leaf", plan.Topology(), plan.Leaves, plan.EndpointsPerLeaf)
+	} else {
+		env.info("Planned %d leaves, %d spines, %d uplinks per leaf (%.2f:1)",
+			plan.Leaves, plan.Spines, plan.UplinksPerLeaf, plan.AchievedOversubscription)
+	}
+	if plan.Pods > 1 {
+		leaves, spines := plan.PodSize()
+		env.info("Split into %d pods of %d leaves and %d spines; %d super-spines (%s) take %d uplinks per spine (%.2f:1 between pods)",
+			plan.Pods, leaves, spines, plan.SuperSpines, plan.SuperSpineModel, plan.SpineUplinks, plan.AchievedPodOversubscription)
+	}
+	if plan.BorderLeaves > 0 {
+		border := fmt.Sprintf("leaf%d-leaf%d", plan.Leaves-plan.BorderLeaves+1, plan.Leaves)
+		if plan.BorderLeaves == 1 {
+			border = fmt.Sprintf("leaf%d", plan.Leaves)
+		}
+		env.info("Reserved %d external ports on each of %d border leaves (%s) for %d external peers",
+			plan.ExternalPortsPerLeaf, plan.BorderLeaves, border, plan.ExternalPeers)
+	}
+	if plan.LeafPairs > 0 {
+		env.info("Paired leaves for %s: %d pairs, %d peer links per leaf", plan.Request.Redundancy, plan.LeafPairs, plan.PeerLinksPerLeaf)
+	}
+	for _, p := range plan.Placement {
+		ports := fmt.Sprintf("%dG ports", p.PortSpeedGbps)
+		if p.Breakout != "" {
+			ports = fmt.Sprintf("ports in %s", p.Breakout)
+		}
+		env.info("Placed %s: up to %d per leaf on %d %s", p.EndpointClass, p.PerLeaf, p.PortsPerLeaf, ports)
+	}
+	if l := plan.Lossless; l != nil {
+		env.info("Lossless for RoCE: PFC on priority %d (DSCP %d), CNPs on priority %d (DSCP %d), MTU %d", l.Priority, l.DSCP, l.CNPPriority, l.CNPDSCP, l.MTU)
+		for _, q := range l.Ports {
+			env.info("  %s %s ports, %s %dG: %d KB headroom, ECN from %d KB to %d KB", q.Role, q.Ports, fallback(q.PortProfile, "no port profile"), q.SpeedGbps, q.HeadroomKB, q.ECNMinKB, q.ECNMaxKB)
+		}
+	}
+	return ExitOK
+}
+
+// PlanExpand grows an existing plan and its cabling map to carry more
+// endpoints. Only leaves, their cables and peer links are added; every
+// existing cable, port and address stays as it is, so nothing deployed is
+// recabled or renumbered.
+func PlanExpand(env Env, args []string) int {
+	flags := newFlags(env, "-endpoints N [flags]")
+	endpoints := flags.Int("endpoints", 0, "Number of endpoint ports the grown fabric carries, existing ones included (required)")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan or hnc import wiring")
+	cablingFile := flags.String("cabling", "", "Cabling map of the existing fabric (default: assign the plan's with -strategy)")
+	strategy := flags.String("strategy", cabling.RoundRobin, "Without -cabling, how the existing leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	seed := flags.Int64("seed", 0, "Without -cabling, the seed the existing cabling was assigned with (default: unseeded)")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	outputFile := flags.String("output", "fabric-plan.json", "Output file for the grown plan")
+	cablingOutput := flags.String("cabling-output", "cabling.json", "Output file for the grown cabling map")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if *endpoints < 0 {
+		return env.fail(ExitUsage, "Error: -endpoints must be positive, got %d", *endpoints)
+	}
+	if *endpoints == 0 {
+		env.fail(ExitUsage, "Error: -endpoints is required")
+		flags.Usage()
+		return ExitUsage
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	var m cabling.Map
+	if *cablingFile != "" {
+		if m, err = readCabling(*cablingFile); err != nil {
+			return env.failAt(*cablingFile, inputExit(err), "Error %v", err)
+		}
+	} else if m, err = assignCabling(registry, plan, leaf, spine, *strategy, *seed); err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+
+	grown, err := fabricplan.Expand(plan, *endpoints, leaf, spine)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	extended, err := cabling.Extend(m, grown, leaf, spine)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	for _, c := range extended.Cables[len(m.Cables):] {
+		env.debug("Adding cable %s", c.Link)
+	}
+	for _, p := range extended.PeerLinks[len(m.PeerLinks):] {
+		env.debug("Adding peer link %s", p.Link)
+	}
+	extended.Generator = provenance.New(env.Prog, buildVersion())
+
+	planData, err := canonjson.Marshal(grown)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding plan: %v", err)
+	}
+	cablingData, err := canonjson.Marshal(extended)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding cabling map: %v", err)
+	}
+	added := grown.Leaves - plan.Leaves
+	env.record("allocate", "%d leaves, %d leaf-spine cables, %d peer links added", added,
+		len(extended.Cables)-len(m.Cables), len(extended.PeerLinks)-len(m.PeerLinks))
+	if code := env.writeFile(*outputFile, planData); code != ExitOK {
+		return code
+	}
+	if code := env.writeFile(*cablingOutput, cablingData); code != ExitOK {
+		return code
+	}
+	if added == 0 {
+		env.info("The %d existing leaves carry %d endpoints (%.2f:1); nothing to add", grown.Leaves, grown.Request.Endpoints, grown.AchievedOversubscription)
+		return ExitOK
+	}
+	newLinks := fmt.Sprintf("%d cables", len(extended.Cables)-len(m.Cables))
+	if n := len(extended.PeerLinks) - len(m.PeerLinks); n > 0 {
+		newLinks += fmt.Sprintf(" and %d peer links", n)
+	}
+	kept := "cables"
+	if m.Addressing != nil {
+		kept = "cables and addresses"
+	}
+	env.info("Expanded to %d endpoints (%.2f:1): %d new leaves with %s; the %d existing leaves keep their %s",
+		grown.Request.Endpoints, grown.AchievedOversubscription, added, newLinks, plan.Leaves, kept)
+	return ExitOK
+}
+
+// BOM lists the switches, optics and cables a plan needs, as JSON, CSV
+// and, with -xlsx, an Excel workbook, and with -pricing estimates what
+// they cost from a price list or a pricing plugin
+func BOM(env Env, args []string) int {
+	var opts bom.Options
+	flags := newFlags(env, "[flags]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	flags.StringVar(&opts.EndpointLength, "endpoint-length", "3m", "Length class of leaf to endpoint cables")
+	flags.StringVar(&opts.FabricLength, "fabric-length", "10m", "Length class of leaf to spine cables")
+	flags.StringVar(&opts.PeerLength, "peer-length", "3m", "Length class of MCLAG peer link cables")
+	flags.StringVar(&opts.PodLength, "pod-length", "30m", "Length class of spine to super-spine cables in a multi-pod plan")
+	flags.StringVar(&opts.ExternalLength, "external-length", "10m", "Length class of border leaf to external router or firewall cables")
+	flags.BoolVar(&opts.DirectAttach, "direct-attach", false, "Use DAC or AOC cables instead of optics and fiber where they reach")
+	layoutFile := flags.String("layout", "", "Layout written by hnc layout, to buy each run at its own length instead of the -*-length classes (default: none)")
+	cablingFile := flags.String("cabling", "", "With -layout, the cabling map written by hnc cabling (default: assign one with -strategy)")
+	strategy := flags.String("strategy", cabling.RoundRobin, "With -layout and no -cabling, how leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	jsonFile := flags.String("json", "bom.json", "Output file for the JSON BOM (empty to skip)")
+	csvFile := flags.String("csv", "bom.csv", "Output file for the CSV BOM (empty to skip)")
+	xlsxFile := flags.String("xlsx", "", "Also write the plan and BOM as an Excel workbook, a sheet each, to this file (default: none)")
+	pricingFile := flags.String("pricing", "", pricingUsage+"; or "+pluginRef+"PLUGIN[/NAME] to have a plugin price it")
+	costFile := flags.String("cost", "bom-cost.json", "With -pricing, output file for the cost estimate as JSON (empty to skip)")
+	formatVersion := formatVersionFlag(flags, "bom-json")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if code := env.checkFormatVersion("bom-json", *formatVersion); code != ExitOK {
+		return code
+	}
+	var prices func(bom.BOM) (pricing.Estimate, error)
+	if plugin, c, ok, err := env.resolvePlugin(plugins.Pricing, *pricingFile); ok {
+		if err != nil {
+			return env.fail(ExitUsage, "Error: -pricing: %v", err)
+		}
+		prices = func(b bom.BOM) (pricing.Estimate, error) { return plugin.Price(context.Background(), c, b) }
+	} else if *pricingFile != "" {
+		l, err := readPriceList(*pricingFile)
+		if err != nil {
+			return env.failAt(*pricingFile, inputExit(err), "Error %v", err)
+		}
+		prices = func(b bom.BOM) (pricing.Estimate, error) { return l.Estimate(b), nil }
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	if plan.Pods > 1 {
+		superSpine, err := findSuperSpine(registry, plan)
+		if err != nil {
+			return env.fail(ExitValidation, "Error: %v", err)
+		}
+		opts.SuperSpine = &superSpine
+	}
+
+	if *layoutFile != "" {
+		l, err := readLayout(*layoutFile)
+		if err != nil {
+			return env.failAt(*layoutFile, inputExit(err), "Error %v", err)
+		}
+		var m cabling.Map
+		if *cablingFile != "" {
+			if m, err = readCabling(*cablingFile); err != nil {
+				return env.failAt(*cablingFile, inputExit(err), "Error %v", err)
+			}
+		} else if m, err = assignCabling(registry, plan, leaf, spine, *strategy, 0); err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+		runs, err := bom.Measure(plan, m, l)
+		if err != nil {
+			return env.failAt(*layoutFile, ExitValidation, "Error: %s: %v", *layoutFile, err)
+		}
+		opts.Runs = &runs
+		measured := fmt.Sprintf("endpoint %s; fabric %s", lengthCounts(runs.Endpoint), lengthCounts(runs.Fabric))
+		if len(runs.Peer) > 0 {
+			measured += "; peer link " + lengthCounts(runs.Peer)
+		}
+		env.info("Measured runs from %s: %s", *layoutFile, measured)
+	}
+	b, err := bom.Compute(plan, leaf, spine, opts)
+	if err == nil {
+		b, err = bom.AtVersion(b, *formatVersion)
+	}
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	if *jsonFile != "" {
+		data, err := canonjson.Marshal(b)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding BOM: %v", err)
+		}
+		if code := env.writeFile(*jsonFile, data); code != ExitOK {
+			return code
+		}
+	}
+	if *csvFile != "" {
+		if code := env.writeFile(*csvFile, []byte(bom.RenderCSV(b))); code != ExitOK {
+			return code
+		}
+	}
+	var estimate pricing.Estimate
+	if prices != nil {
+		if estimate, err = prices(b); err != nil {
+			return env.fail(ExitFailure, "Error pricing the BOM: %v", err)
+		}
+		if *costFile != "" {
+			data, err := canonjson.Marshal(estimate)
+			if err != nil {
+				return env.fail(ExitFailure, "Error encoding cost estimate: %v", err)
+			}
+			if code := env.writeFile(*costFile, data); code != ExitOK {
+				return code
+			}
+		}
+	}
+	if *xlsxFile != "" {
+		planCSV, err := fabricplan.RenderCSV(plan)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding plan: %v", err)
+		}
+		sheets := []csvSheet{{"Plan", planCSV}, {"BOM", bom.RenderCSV(b)}}
+		if prices != nil {
+			sheets = append(sheets, csvSheet{"Cost", pricing.RenderCSV(estimate)})
+		}
+		if code := env.writeWorkbook(*xlsxFile, sheets...); code != ExitOK {
+			return code
+		}
+	}
+	if plan.Pods > 1 {
+		env.info("Listed %d BOM lines for %d leaves, %d spines and %d super-spines", len(b.Lines), plan.Leaves, plan.Spines, plan.SuperSpines)
+	} else {
+		env.info("Listed %d BOM lines for %d leaves and %d spines", len(b.Lines), plan.Leaves, plan.Spines)
+	}
+	if prices != nil {
+		env.reportCost(*pricingFile, estimate)
+	}
+	return ExitOK
+}
+
+// pricingUsage describes a -pricing flag
+const pricingUsage = "Price list of SKU unit prices and discount tiers (CSV or JSON) to estimate costs from; hnc ships no prices (default: none)"
+
+// readPriceList reads a price list, as CSV when it ends in .csv
+func readPriceList(file string) (pricing.List, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return pricing.List{}, fmt.Errorf("reading price list: %w", err)
+	}
+	l, err := pricing.Parse(data, file)
+	if err != nil {
+		return l, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return l, nil
+}
+
+// planCost estimates the cost of the BOM hnc bom lists for a plan at its
+// default cable lengths
+func planCost(registry *profiles.Registry, plan fabricplan.Plan, prices pricing.List) (pricing.Estimate, error) {
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return pricing.Estimate{}, err
+	}
+	var opts bom.Options
+	if plan.Pods > 1 {
+		superSpine, err := findSuperSpine(registry, plan)
+		if err != nil {
+			return pricing.Estimate{}, err
+		}
+		opts.SuperSpine = &superSpine
+	}
+	b, err := bom.Compute(plan, leaf, spine, opts)
+	if err != nil {
+		return pricing.Estimate{}, err
+	}
+	return prices.Estimate(b), nil
+}
+
+// reportCost prints an estimate's total by category, and warns of the
+// items the price list left out of it
+func (env Env) reportCost(pricingFile string, e pricing.Estimate) {
+	env.info("Estimated cost: %s (switches %s, optics %s, cables %s)", e.Format(e.Total),
+		e.Format(e.ByCategory[bom.Switch]), e.Format(e.ByCategory[bom.Optic]), e.Format(e.ByCategory[bom.Cable]))
+	if len(e.Unpriced) > 0 {
+		env.warn("Warning: %s prices no %s; the estimate leaves them out", pricingFile, strings.Join(e.Unpriced, ", "))
+	}
+}
+
+// Cabling assigns every leaf uplink in a plan to a spine port, and every
+// spine uplink of a multi-pod plan to a super-spine port
+func Cabling(env Env, args []string) int {
+	flags := newFlags(env, "[flags]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	strategy := flags.String("strategy", cabling.RoundRobin, "How leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	seed := flags.Int64("seed", 0, "Shuffle which spine takes each leaf's uplinks with this seed; the same seed reproduces the map (default: unseeded)")
+	jsonFile := flags.String("output", "cabling.json", "Output file for the cabling map")
+	csvFile := flags.String("csv", "", "Also write the cabling map as CSV to this file (default: none)")
+	xlsxFile := flags.String("xlsx", "", "Also write the plan and cabling map as an Excel workbook, a sheet each, to this file (default: none)")
+	layoutFile := flags.String("layout", "", "Layout written by hnc layout, to estimate each cable's length class (default: none)")
+	inventoryFile := flags.String("inventory", "", inventoryUsage+", to also cable each server's NICs to leaf endpoint ports; plan it with hnc plan -inventory")
+	var numbering addressing.Options
+	flags.StringVar(&numbering.Mode, "addressing", "", "Also number links and switches: "+strings.Join(addressing.Modes, " (/31 per link) or ")+" (default: none)")
+	flags.StringVar(&numbering.LinkPool, "link-pool", addressing.DefaultLinkPool, "IPv4 CIDR the /31 link subnets are carved from")
+	flags.StringVar(&numbering.LoopbackPool, "loopback-pool", addressing.DefaultLoopbackPool, "IPv4 CIDR the switch loopbacks are taken from")
+	spineASN := flags.Uint("spine-asn", uint(addressing.DefaultSpineASN), "BGP ASN shared by the spines")
+	leafASNs := flags.String("leaf-asns", addressing.DefaultLeafASNs.String(), "BGP ASN pool for the leaves, one each, FIRST-LAST")
+	placementPolicy := flags.String("placement-policy", "", "Placement policy that recables the -inventory servers, as "+pluginRef+"PLUGIN[/NAME] (default: none)")
+	namingPolicy := flags.String("naming-policy", "", "Naming policy that gives the switches site hostnames, as "+pluginRef+"PLUGIN[/NAME] (default: none)")
+	addressingPolicy := flags.String("addressing-policy", "", "Addressing policy that renumbers what -addressing numbered, as "+pluginRef+"PLUGIN[/NAME] (default: none)")
+	formatVersion := formatVersionFlag(flags, "cabling-json")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if code := env.checkFormatVersion("cabling-json", *formatVersion); code != ExitOK {
+		return code
+	}
+	if *placementPolicy != "" && *inventoryFile == "" {
+		return env.fail(ExitUsage, "Error: -placement-policy places the servers of an -inventory; give one")
+	}
+	if *addressingPolicy != "" && numbering.Mode == "" {
+		return env.fail(ExitUsage, "Error: -addressing-policy renumbers what -addressing numbers; give a mode")
+	}
+	placer, placement, placing, code := env.resolvePolicy("placement-policy", plugins.Placement, *placementPolicy)
+	if code != ExitOK {
+		return code
+	}
+	namer, naming, renaming, code := env.resolvePolicy("naming-policy", plugins.Naming, *namingPolicy)
+	if code != ExitOK {
+		return code
+	}
+	numberer, renumbering, renumber, code := env.resolvePolicy("addressing-policy", plugins.Addressing, *addressingPolicy)
+	if code != ExitOK {
+		return code
+	}
+	if numbering.Mode != "" {
+		var err error
+		if numbering.LeafASNs, err = addressing.ParseASNRange(*leafASNs); err != nil {
+			return env.fail(ExitUsage, "Error: -leaf-asns: %v", err)
+		}
+		if *spineASN == 0 || *spineASN > 1<<32-1 {
+			return env.fail(ExitUsage, "Error: -spine-asn %d is not a 32-bit ASN", *spineASN)
+		}
+		numbering.SpineASN = uint32(*spineASN)
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+
+	done := env.recorder().Stage("cabling")
+	m, err := assignCabling(registry, plan, leaf, spine, *strategy, *seed)
+	done()
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	m.Generator = provenance.New(env.Prog, buildVersion())
+	if *inventoryFile != "" {
+		inv, err := readInventory(*inventoryFile)
+		if err != nil {
+			return env.failAt(*inventoryFile, inputExit(err), "Error %v", err)
+		}
+		if m.ServerLinks, _, err = inventory.Assign(inv, plan, leaf); err != nil {
+			return env.failAt(*inventoryFile, ExitValidation, "Error: %s: %v", *inventoryFile, err)
+		}
+	}
+	if placing {
+		if err := placer.Place(context.Background(), placement, plan, leaf, &m); err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+	}
+	if numbering.Mode != "" {
+		if err := m.Address(plan, numbering); err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+	}
+	if renumber {
+		if err := numberer.Address(context.Background(), renumbering, plan, &m); err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+	}
+	if renaming {
+		if err := namer.Hostnames(context.Background(), naming, plan, &m); err != nil {
+			return env.fail(ExitFailure, "Error: %v", err)
+		}
+	}
+	if *layoutFile != "" {
+		l, err := readLayout(*layoutFile)
+		if err != nil {
+			return env.failAt(*layoutFile, inputExit(err), "Error %v", err)
+		}
+		if err := m.Measure(l); err != nil {
+			return env.failAt(*layoutFile, ExitValidation, "Error: %s: %v", *layoutFile, err)
+		}
+	}
+	data, err := canonjson.Marshal(m)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding cabling map: %v", err)
+	}
+	env.recorder().Plan(plan)
+	env.recorder().Cabling(m)
+	env.record("allocate", "%d leaf-spine cables, %d peer links", len(m.Cables), len(m.PeerLinks))
+	if len(m.SpineLinks) > 0 {
+		env.record("allocate", "%d spine-super-spine cables", len(m.SpineLinks))
+	}
+	if len(m.ExternalLinks) > 0 {
+		env.record("allocate", "%d external uplinks", len(m.ExternalLinks))
+	}
+	if len(m.ServerLinks) > 0 {
+		env.record("allocate", "%d server links", len(m.ServerLinks))
+	}
+	if code := env.writeFile(*jsonFile, data); code != ExitOK {
+		return code
+	}
+	if *csvFile != "" {
+		if code := env.writeFile(*csvFile, []byte(cabling.RenderCSV(m))); code != ExitOK {
+			return code
+		}
+	}
+	if *xlsxFile != "" {
+		planCSV, err := fabricplan.RenderCSV(plan)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding plan: %v", err)
+		}
+		if code := env.writeWorkbook(*xlsxFile, csvSheet{"Plan", planCSV}, csvSheet{"Cabling", cabling.RenderCSV(m)}); code != ExitOK {
+			return code
+		}
+	}
+	env.info("Assigned %d cables (%s) for %d leaves and %d spines", len(m.Cables), m.Strategy, plan.Leaves, plan.Spines)
+	if m.Seed != 0 {
+		env.info("Shuffled spines with seed %d; rerun with -seed %d to reproduce the map", m.Seed, m.Seed)
+	}
+	if len(m.PeerLinks) > 0 {
+		env.info("Assigned %d peer links for %d leaf pairs", len(m.PeerLinks), plan.LeafPairs)
+	}
+	if len(m.SpineLinks) > 0 {
+		env.info("Assigned %d spine uplinks for %d pods and %d super-spines", len(m.SpineLinks), plan.Pods, plan.SuperSpines)
+	}
+	if len(m.ExternalLinks) > 0 {
+		env.info("Assigned %d external uplinks from %d border leaves to %d external peers", len(m.ExternalLinks), plan.BorderLeaves, plan.ExternalPeers)
+	}
+	if len(m.ServerLinks) > 0 {
+		env.info("Cabled %d server NICs from %s to leaf endpoint ports", len(m.ServerLinks), *inventoryFile)
+	}
+	for _, p := range []struct {
+		applied bool
+		flag    string
+	}{{placing, *placementPolicy}, {renumber, *addressingPolicy}, {renaming, *namingPolicy}} {
+		if p.applied {
+			env.info("Applied policy %s", p.flag)
+		}
+	}
+	if a := m.Addressing; a != nil {
+		env.info("Numbered %d links (%s) and %d switches from %s", len(a.Links), a.Options.Mode, len(a.Switches), a.Options.LoopbackPool)
+	}
+	if *layoutFile != "" {
+		env.info("Estimated cable lengths: %s", lengthCounts(m.Lengths()))
+	}
+	return ExitOK
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/plandiff"
+	"github.com/hnc/profile-dump/pkg/pricing"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+// PlanDiff compares two fabric plans for change review: switches, links,
+// capacity, given both VPC plans, VLAN and VNI assignments, and, given a
+// price list, what each plan's BOM costs. Without a
+// cabling map for a plan its cables are assigned with -strategy, as hnc
+// cabling would.
+func PlanDiff(env Env, args []string) int {
+	flags := newFlags(env, "[flags] <old.json> <new.json>")
+	oldCabling := flags.String("old-cabling", "", "Cabling map of the old plan (default: assign one with -strategy)")
+	newCabling := flags.String("new-cabling", "", "Cabling map of the new plan (default: assign one with -strategy)")
+	strategy := flags.String("strategy", cabling.RoundRobin, "Without a cabling map, how leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	oldVPCs := flags.String("old-vpcs", "", "VPC plan written by hnc vpcs for the old plan; with -new-vpcs, compares VLANs and VNIs")
+	newVPCs := flags.String("new-vpcs", "", "VPC plan written by hnc vpcs for the new plan")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	pricingFile := flags.String("pricing", "", pricingUsage)
+	asJSON := flags.Bool("json", false, "Print the diff as JSON instead of tables")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if flags.NArg() != 2 {
+		env.fail(ExitUsage, "Error: name the old and the new plan")
+		flags.Usage()
+		return ExitUsage
+	}
+	if (*oldVPCs == "") != (*newVPCs == "") {
+		return env.fail(ExitUsage, "Error: -old-vpcs and -new-vpcs go together")
+	}
+
+	var prices pricing.List
+	if *pricingFile != "" {
+		var err error
+		if prices, err = readPriceList(*pricingFile); err != nil {
+			return env.failAt(*pricingFile, inputExit(err), "Error %v", err)
+		}
+	}
+
+	var designs [2]plandiff.Design
+	for i, side := range []struct{ planFile, cablingFile, vpcsFile string }{
+		{flags.Arg(0), *oldCabling, *oldVPCs},
+		{flags.Arg(1), *newCabling, *newVPCs},
+	} {
+		d := &designs[i]
+		var err error
+		if d.Plan, err = readPlan(side.planFile); err != nil {
+			return env.failAt(side.planFile, inputExit(err), "Error %v", err)
+		}
+		var registry *profiles.Registry
+		if side.cablingFile == "" || *pricingFile != "" {
+			if registry, err = loadRegistry(env, *profilesDir); err != nil {
+				return env.fail(inputExit(err), "Error loading profiles: %v", err)
+			}
+		}
+		if side.cablingFile != "" {
+			if d.Cabling, err = readCabling(side.cablingFile); err != nil {
+				return env.failAt(side.cablingFile, inputExit(err), "Error %v", err)
+			}
+		} else {
+			leaf, spine, err := findModels(registry, d.Plan.LeafModel, d.Plan.SpineModel)
+			if err != nil {
+				return env.failAt(side.planFile, ExitValidation, "Error: %s: %v", side.planFile, err)
+			}
+			if d.Cabling, err = assignCabling(registry, d.Plan, leaf, spine, *strategy, 0); err != nil {
+				return env.fail(ExitFailure, "Error: %s: %v", side.planFile, err)
+			}
+		}
+		if side.vpcsFile != "" {
+			if d.VPCs, err = readVPCPlan(side.vpcsFile); err != nil {
+				return env.failAt(side.vpcsFile, inputExit(err), "Error %v", err)
+			}
+		}
+		if *pricingFile != "" {
+			estimate, err := planCost(registry, d.Plan, prices)
+			if err != nil {
+				return env.fail(ExitFailure, "Error: %s: %v", side.planFile, err)
+			}
+			if len(estimate.Unpriced) > 0 {
+				env.warn("Warning: %s prices no %s of %s; its estimate leaves them out", *pricingFile, strings.Join(estimate.Unpriced, ", "), side.planFile)
+			}
+			d.Cost = &estimate
+		}
+	}
+
+	diff := plandiff.Compare(designs[0], designs[1])
+	if *asJSON {
+		data, err := canonjson.Marshal(diff)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding diff: %v", err)
+		}
+		env.Stdout.Write(data)
+		return ExitOK
+	}
+	if diff.Empty() {
+		env.info("No differences between %s and %s", flags.Arg(0), flags.Arg(1))
+		return ExitOK
+	}
+	printPlanDiff(env, diff, prices.Currency)
+	return ExitOK
+}
+
+// printPlanDiff prints each section of a diff that has changes as a
+// table, in the order of the JSON, its costs in currency
+func printPlanDiff(env Env, diff plandiff.Diff, currency string) {
+	first := true
+	section := func(title string) *tabwriter.Writer {
+		if !first {
+			fmt.Fprintln(env.Stdout)
+		}
+		first = false
+		fmt.Fprintf(env.Stdout, "%s:\n", title)
+		return tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	}
+	changes := func(title string, changes []plandiff.Change) {
+		if len(changes) == 0 {
+			return
+		}
+		w := section(title)
+		fmt.Fprintln(w, "KIND\tOBJECT\tFIELD\tOLD\tNEW")
+		for _, c := range changes {
+			field := c.Field
+			if field == "" {
+				field = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Kind, c.Object, field, diffValue(c.Old), diffValue(c.New))
+		}
+		w.Flush()
+	}
+
+	changes("Switches", diff.Switches)
+	changes("Links", diff.Links)
+	if len(diff.Capacity) > 0 {
+		w := section("Capacity")
+		fmt.Fprintln(w, "METRIC\tOLD\tNEW\tCHANGE")
+		for _, d := range diff.Capacity {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%+g\n", d.Metric, strconv.FormatFloat(d.Old, 'f', -1, 64), strconv.FormatFloat(d.New, 'f', -1, 64), d.Change)
+		}
+		w.Flush()
+	}
+	changes("VPCs", diff.VPCs)
+	if len(diff.Cost) > 0 {
+		title := "Cost"
+		if currency != "" {
+			title += " (" + currency + ")"
+		}
+		w := section(title)
+		fmt.Fprintln(w, "CATEGORY\tOLD\tNEW\tCHANGE")
+		for _, d := range diff.Cost {
+			fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%+.2f\n", d.Metric, d.Old, d.New, d.Change)
+		}
+		w.Flush()
+	}
+}
+
+// diffValue prints a changed value as it reads, - for none
+func diffValue(v any) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprint(v)
+}
+
+// readVPCPlan reads a VPC plan written by hnc vpcs
+func readVPCPlan(file string) (*vpcplan.Plan, error) {
+	var p vpcplan.Plan
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading VPC plan: %w", err)
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return &p, nil
+}
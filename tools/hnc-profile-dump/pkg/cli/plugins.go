@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/lint"
+	"github.com/hnc/profile-dump/pkg/plugins"
+)
+
+// pluginRef is how a flag that takes a plugin capability names one:
+// plugin:PLUGIN or plugin:PLUGIN/NAME
+const pluginRef = "plugin:"
+
+// PluginsList runs the handshake with every plugin on $HNC_PLUGIN_PATH
+// and lists what each provides. It exits 1 when any fails it.
+func PluginsList(env Env, args []string) int {
+	flags := newFlags(env, "[-json]")
+	asJSON := flags.Bool("json", false, "Print the plugins as JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	path := env.getenv(plugins.PathEnv)
+	loaded, loadErr := plugins.Load(context.Background(), path)
+	if *asJSON {
+		data, err := canonjson.Marshal(append([]plugins.Plugin{}, loaded...))
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding plugins: %v", err)
+		}
+		env.Stdout.Write(data)
+	} else if len(loaded) > 0 {
+		w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PLUGIN\tVERSION\tKIND\tNAME\tDESCRIPTION")
+		for _, p := range loaded {
+			version := p.Version
+			if version == "" {
+				version = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t\t\t%s\n", p.Name, version, p.Description)
+			for _, c := range p.Capabilities {
+				fmt.Fprintf(w, "\t\t%s\t%s\t%s\n", c.Kind, c.Name, c.Description)
+			}
+		}
+		w.Flush()
+	}
+	if loadErr != nil {
+		for _, err := range strings.Split(loadErr.Error(), "\n") {
+			env.fail(ExitFailure, "Error: %s", err)
+		}
+		return ExitFailure
+	}
+	if path == "" {
+		env.info("No plugins: $%s is not set", plugins.PathEnv)
+		return ExitOK
+	}
+	env.info("%d plugin(s) on $%s", len(loaded), plugins.PathEnv)
+	return ExitOK
+}
+
+// plugins loads the plugins on $HNC_PLUGIN_PATH, warning of those that
+// fail the handshake, which are left out
+func (env Env) plugins() []plugins.Plugin {
+	path := env.getenv(plugins.PathEnv)
+	if path == "" {
+		return nil
+	}
+	loaded, err := plugins.Load(context.Background(), path)
+	if err != nil {
+		env.warn("Warning: skipping plugins: %v", err)
+	}
+	return loaded
+}
+
+// lintRules are the built-in lint rules and the plugins' ones
+func (env Env) lintRules(rules []lint.Rule) []lint.Rule {
+	return append(append([]lint.Rule{}, rules...), plugins.LintRules(env.plugins())...)
+}
+
+// resolvePlugin finds the capability of kind a flag names as
+// plugin:PLUGIN[/NAME]; ok is false when the value does not name one
+func (env Env) resolvePlugin(kind, value string) (p plugins.Plugin, c plugins.Capability, ok bool, err error) {
+	ref, ok := strings.CutPrefix(value, pluginRef)
+	if !ok {
+		return p, c, false, nil
+	}
+	p, c, err = plugins.Resolve(env.plugins(), kind, ref)
+	return p, c, true, err
+}
+
+// resolvePolicy finds the allocation policy a flag names, which only a
+// plugin provides; set is false when the flag is empty
+func (env Env) resolvePolicy(flag, kind, value string) (p plugins.Plugin, c plugins.Capability, set bool, code int) {
+	if value == "" {
+		return p, c, false, ExitOK
+	}
+	p, c, ok, err := env.resolvePlugin(kind, value)
+	if !ok {
+		return p, c, false, env.fail(ExitUsage, "Error: -%s %q does not name a plugin; want %sPLUGIN or %sPLUGIN/NAME", flag, value, pluginRef, pluginRef)
+	}
+	if err != nil {
+		return p, c, false, env.fail(ExitUsage, "Error: -%s: %v", flag, err)
+	}
+	return p, c, true, ExitOK
+}
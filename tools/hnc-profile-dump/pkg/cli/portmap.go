@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/portmap"
+	"github.com/hnc/profile-dump/pkg/ports"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// wiring mirrors the frontend Wiring JSON (src/domain/wiring.ts)
+type wiring struct {
+	Devices struct {
+		Spines []device `json:"spines"`
+		Leaves []device `json:"leaves"`
+	} `json:"devices"`
+	Connections []connection `json:"connections"`
+}
+
+type device struct {
+	ID      string `json:"id"`
+	ModelID string `json:"modelId"`
+}
+
+type connection struct {
+	From endpoint `json:"from"`
+	To   endpoint `json:"to"`
+	Type string   `json:"type"`
+}
+
+type endpoint struct {
+	Device string `json:"device"`
+	Port   string `json:"port"`
+}
+
+// indexProfiles indexes profiles by model ID and by short name
+// (celestica-ds2000 is also reachable as DS2000)
+func indexProfiles(registry *profiles.Registry) map[string]profiles.SwitchProfile {
+	index := map[string]profiles.SwitchProfile{}
+	for _, p := range registry.List() {
+		index[p.ModelID] = p
+		if i := strings.Index(p.ModelID, "-"); i >= 0 {
+			index[strings.ToUpper(p.ModelID[i+1:])] = p
+		}
+	}
+	return index
+}
+
+// faceplatePositions locates each port from the profile's faceplate
+// layout; profiles without one get blank positions
+func faceplatePositions(p profiles.SwitchProfile) (map[string]profiles.Position, error) {
+	if p.Faceplate == nil {
+		return nil, nil
+	}
+	return p.Faceplate.Positions()
+}
+
+// portUsage indexes every connected switch port by switch, then port
+func portUsage(w wiring) map[string]map[string]portmap.Usage {
+	usage := map[string]map[string]portmap.Usage{}
+	mark := func(sw, port string, state portmap.State, peer endpoint) {
+		if usage[sw] == nil {
+			usage[sw] = map[string]portmap.Usage{}
+		}
+		usage[sw][port] = portmap.Usage{State: state, Peer: peer.Device + ":" + peer.Port}
+	}
+	for _, c := range w.Connections {
+		switch c.Type {
+		case "uplink":
+			mark(c.From.Device, c.From.Port, portmap.StateUplink, c.To)
+			mark(c.To.Device, c.To.Port, portmap.StateUplink, c.From)
+		case "endpoint":
+			mark(c.To.Device, c.To.Port, portmap.StateEndpoint, c.From)
+		}
+	}
+	return usage
+}
+
+// Portmap draws a faceplate SVG, or writes a commissioning sheet, for every
+// switch in a wiring exported from the frontend
+func Portmap(env Env, args []string) int {
+	flags := newFlags(env, "-wiring FILE [flags]")
+	wiringFile := flags.String("wiring", "", "Wiring JSON exported from the frontend (required)")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	outputDir := flags.String("output", "portmaps", "Output directory for SVG faceplates")
+	reservedList := flags.String("reserved", "", "Comma-separated switch:port list to mark reserved (e.g. leaf-1:E1/48)")
+	format := flags.String("format", "svg", "Output format: svg (faceplate drawing) or sheet (commissioning CSV with faceplate row/column per port)")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if *wiringFile == "" {
+		env.fail(ExitUsage, "Error: -wiring is required")
+		flags.Usage()
+		return ExitUsage
+	}
+	if *format != "svg" && *format != "sheet" {
+		return env.fail(ExitUsage, "Error: unknown -format %q (want svg or sheet)", *format)
+	}
+
+	data, err := os.ReadFile(*wiringFile)
+	if err != nil {
+		return env.failAt(*wiringFile, ExitIO, "Error reading wiring: %v", err)
+	}
+	var w wiring
+	if err := json.Unmarshal(data, &w); err != nil {
+		return env.failAt(*wiringFile, ExitValidation, "Error parsing wiring %s: %v", *wiringFile, err)
+	}
+
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	profiles := indexProfiles(registry)
+
+	usage := portUsage(w)
+
+	reserved := map[string][]string{}
+	for _, item := range strings.Split(*reservedList, ",") {
+		if sw, port, ok := strings.Cut(strings.TrimSpace(item), ":"); ok {
+			reserved[sw] = append(reserved[sw], port)
+		}
+	}
+
+	if err := env.mkdirAll(*outputDir); err != nil {
+		return env.failAt(*outputDir, ExitIO, "Error creating output directory: %v", err)
+	}
+
+	switches := append(append([]device{}, w.Devices.Spines...), w.Devices.Leaves...)
+	for _, sw := range switches {
+		p, ok := profiles[sw.ModelID]
+		if !ok {
+			return env.failAt(*wiringFile, ExitValidation, "Error: no profile for model %s (switch %s)", sw.ModelID, sw.ID)
+		}
+		names, err := ports.Expand(append(append([]string{}, p.Ports.EndpointAssignable...), p.Ports.FabricAssignable...))
+		if err != nil {
+			return env.fail(ExitValidation, "Error in profile %s: %v", p.ModelID, err)
+		}
+
+		fp := portmap.Build(sw.ID, sw.ModelID, names, usage[sw.ID], reserved[sw.ID])
+		out, path, kind := portmap.RenderSVG(fp), filepath.Join(*outputDir, sw.ID+".svg"), "port map"
+		if *format == "sheet" {
+			positions, err := faceplatePositions(p)
+			if err != nil {
+				return env.fail(ExitValidation, "Error in profile %s: %v", p.ModelID, err)
+			}
+			out, path, kind = portmap.RenderSheet(fp, positions), filepath.Join(*outputDir, sw.ID+".commissioning.csv"), "commissioning sheet"
+		}
+		written, err := env.write(path, []byte(out))
+		if err != nil {
+			return env.failAt(path, ExitIO, "Error writing %s: %v", path, err)
+		}
+		if written {
+			env.info("Generated %s: %s", kind, path)
+		}
+	}
+	return ExitOK
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/portmap"
+)
+
+// The contract wiring fixture (and hnc-fuzz-corpus seeds) decode, and any
+// wiring that decodes renders a faceplate for each switch
+func FuzzRenderWiring(f *testing.F) {
+	if data, err := os.ReadFile(filepath.Join(contractDir, "designs", "two-leaf.wiring.json")); err == nil {
+		f.Add(data)
+	}
+	f.Add([]byte(`{"devices":{"leaves":[{"id":"leaf-1"}]},"connections":[{"from":{"device":"s","port":"eth0"},"to":{"device":"leaf-1","port":"E1/1/2"},"type":"endpoint"}]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var w wiring
+		if err := json.Unmarshal(data, &w); err != nil {
+			return
+		}
+		usage := portUsage(w)
+		ports := []string{"E1/1", "E1/2", "E1/49"}
+		for _, sw := range append(append([]device{}, w.Devices.Spines...), w.Devices.Leaves...) {
+			svg := portmap.RenderSVG(portmap.Build(sw.ID, sw.ModelID, ports, usage[sw.ID], nil))
+			if !strings.HasSuffix(svg, "</svg>\n") {
+				t.Fatalf("truncated SVG for %s", sw.ID)
+			}
+		}
+	})
+}
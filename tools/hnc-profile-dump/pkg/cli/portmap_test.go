@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/ports"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// The frontend wiring fixture must decode and only reference ports that
+// the Go range expansion produces for each switch profile.
+func TestContractWiringUsesProfilePorts(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(contractDir, "designs", "two-leaf.wiring.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w wiring
+	if err := json.Unmarshal(data, &w); err != nil {
+		t.Fatalf("wiring fixture does not decode: %v", err)
+	}
+	registry, err := profiles.Load(filepath.Join(contractDir, "profiles"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	models := indexProfiles(registry)
+
+	switchPorts := map[string]map[string]bool{}
+	for _, sw := range append(append([]device{}, w.Devices.Spines...), w.Devices.Leaves...) {
+		p, ok := models[sw.ModelID]
+		if !ok {
+			t.Fatalf("no contract profile for model %s", sw.ModelID)
+		}
+		names, err := ports.Expand(append(append([]string{}, p.Ports.EndpointAssignable...), p.Ports.FabricAssignable...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		switchPorts[sw.ID] = map[string]bool{}
+		for _, n := range names {
+			switchPorts[sw.ID][n] = true
+		}
+	}
+
+	for _, c := range w.Connections {
+		for _, end := range []endpoint{c.From, c.To} {
+			if known, isSwitch := switchPorts[end.Device]; isSwitch && !known[end.Port] {
+				t.Errorf("connection uses %s:%s, which is not in the switch profile", end.Device, end.Port)
+			}
+		}
+	}
+}
+
+// Without -profiles the embedded profiles are used, so portmap needs no
+// fixture checkout beside it
+func TestPortmapBuiltinProfiles(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr strings.Builder
+	args := []string{"-wiring", filepath.Join(contractDir, "designs", "two-leaf.wiring.json"), "-format", "sheet", "-output", dir}
+	if code := Portmap(testEnv(nil, &stdout, &stderr), args); code != ExitOK {
+		t.Fatalf("portmap = %d: %s", code, stderr.String())
+	}
+	sheets, _ := filepath.Glob(filepath.Join(dir, "*.commissioning.csv"))
+	if len(sheets) == 0 {
+		t.Errorf("no commissioning sheets in %s:\n%s", dir, stdout.String())
+	}
+}
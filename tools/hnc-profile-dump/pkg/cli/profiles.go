@@ -0,0 +1,545 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/batch"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/fabricapi"
+	"github.com/hnc/profile-dump/pkg/lint"
+	"github.com/hnc/profile-dump/pkg/manifest"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/textdiff"
+)
+
+// kubectl queries the fabric controller for dump -source fabric-api and
+// for drift; tests replace it
+var kubectl fabricapi.Kubectl = fabricapi.Exec
+
+// Dump writes the switch profiles as frontend fixtures, YAML or CRD
+// manifests, to a directory or stdout, or with -check diffs them against
+// the files already there. A directory also gets a manifest.json of the
+// files' checksums for hnc profiles verify, and with -layout tree holds
+// them as <vendor>/<role>/<file> under an index.json.
+func Dump(env Env, args []string) int {
+	flags := newFlags(env, "[flags]")
+	outputDir := flags.String("output", "../../src/fixtures/switch-profiles", "Output directory for generated profiles, or - to stream them to stdout")
+	inputDir := flags.String("input", "", "Directory of YAML or JSON profile definitions, or - for a JSON array or NDJSON on stdin (default: built-in DS2000 and DS3000)")
+	format := flags.String("format", "json", "Output format: json (frontend fixtures), yaml (the same profiles as YAML) or crd (Hedgehog SwitchProfile manifests)")
+	layout := flags.String("layout", profiles.LayoutFlat, "Output directory layout: flat (every file at the top) or tree (<vendor>/<role>/<file>, listed in "+profiles.IndexFile+")")
+	profileVersion := flags.String("profile-version", profiles.SchemaVersion, "Schema version to write: "+strings.Join(profiles.SchemaVersions, " or ")+"; older versions drop the fields they lack")
+	check := flags.Bool("check", false, "Compare regenerated profiles with the files in -output and print a unified diff instead of writing; exits 1 on drift")
+	ndjson := flags.Bool("ndjson", false, "With -output - and -format json, stream one profile per line instead of a JSON array")
+	source := flags.String("source", "", "Set to fabric-api to read the SwitchProfile objects of a running fabric controller instead of -input")
+	kubeconfig := flags.String("kubeconfig", "", "With -source fabric-api, the kubeconfig file (default: kubectl's)")
+	kubeContext := flags.String("context", "", "With -source fabric-api, the kubeconfig context (default: the current one)")
+	jobs := jobsFlag(flags)
+	watch := watchFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	if *watch {
+		switch {
+		case *inputDir == "" || *inputDir == "-" || *source != "":
+			return env.fail(ExitUsage, "Error: -watch needs -input DIR, the definitions to watch")
+		case *check || *outputDir == "-":
+			return env.fail(ExitUsage, "Error: -watch writes -output; it cannot be used with -check or -output -")
+		}
+		return env.watch([]string{*inputDir}, []string{*outputDir}, Dump, args)
+	}
+	if *ndjson && (*outputDir != "-" || *format != "json") {
+		return env.fail(ExitUsage, "Error: -ndjson needs -output - and -format json")
+	}
+	if *check && *outputDir == "-" {
+		return env.fail(ExitUsage, "Error: -check compares against a directory; it cannot be used with -output -")
+	}
+	if !slices.Contains(profiles.Layouts, *layout) {
+		return env.fail(ExitUsage, "Error: unknown -layout %q (want %s)", *layout, strings.Join(profiles.Layouts, " or "))
+	}
+	if *layout != profiles.LayoutFlat && *outputDir == "-" {
+		return env.fail(ExitUsage, "Error: -layout %s lays out a directory; it cannot be used with -output -", *layout)
+	}
+	switch {
+	case *source != "" && *source != "fabric-api":
+		return env.fail(ExitUsage, "Error: unknown -source %q (want fabric-api)", *source)
+	case *source != "" && *inputDir != "":
+		return env.fail(ExitUsage, "Error: -source fabric-api and -input cannot be combined")
+	case *source == "" && (*kubeconfig != "" || *kubeContext != ""):
+		return env.fail(ExitUsage, "Error: -kubeconfig and -context need -source fabric-api")
+	}
+
+	renderer := profiles.JSONFile
+	switch *format {
+	case "json":
+	case "yaml":
+		renderer = profiles.YAMLFile
+	case "crd":
+		renderer = profiles.CRDFile
+	default:
+		return env.fail(ExitUsage, "Error: unknown -format %q (want json, yaml or crd)", *format)
+	}
+	render := func(r *profiles.Registry) ([]profiles.File, error) { return r.RenderWith(renderer, *jobs) }
+	renderDir := func(r *profiles.Registry) ([]profiles.File, error) {
+		files, err := render(r)
+		if err != nil {
+			return nil, err
+		}
+		return profiles.Partition(*layout, r.List(), files)
+	}
+
+	var registry *profiles.Registry
+	var err error
+	if *source == "fabric-api" {
+		var list []profiles.SwitchProfile
+		ctx, stop := env.interruptible()
+		defer stop()
+		if list, err = fabricapi.SwitchProfiles(ctx, kubectl, fabricapi.Cluster{Kubeconfig: *kubeconfig, Context: *kubeContext}); err == nil {
+			registry, err = profiles.NewRegistry(list...)
+		}
+	} else {
+		registry, err = loadRegistryJobs(env, *inputDir, *jobs)
+	}
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	invalid, _ := batch.Map(*jobs, registry.List(), func(p profiles.SwitchProfile) (string, error) {
+		if errs := profiles.Validate(p); len(errs) > 0 {
+			return fmt.Sprintf("Error in profile %s: %s", p.ModelID, strings.Join(errs, "; ")), nil
+		}
+		return "", nil
+	})
+	if invalid = slices.DeleteFunc(invalid, func(e string) bool { return e == "" }); len(invalid) > 0 {
+		for _, e := range invalid {
+			env.fail(ExitValidation, "%s", e)
+		}
+		return env.fail(ExitValidation, "%d invalid profile(s); nothing was written", len(invalid))
+	}
+	if registry, err = registry.AtVersion(*profileVersion); err != nil {
+		return env.fail(ExitUsage, "Error: %v", err)
+	}
+
+	if *check {
+		files, err := renderDir(registry)
+		if err != nil {
+			return env.fail(ExitFailure, "Error generating profiles: %v", err)
+		}
+		stale, err := checkDir(env.Stdout, *outputDir, files)
+		if err != nil {
+			return env.fail(ExitIO, "Error checking profiles: %v", err)
+		}
+		if stale > 0 {
+			return env.fail(ExitFailure, "%d profile file(s) in %s are out of date; rerun without -check to regenerate", stale, *outputDir)
+		}
+		env.info("Profiles in %s are up to date", *outputDir)
+		return ExitOK
+	}
+
+	if *outputDir == "-" {
+		if err := stream(env.Stdout, registry, *format, *ndjson, render); err != nil {
+			return env.fail(ExitFailure, "Error generating profiles: %v", err)
+		}
+		return ExitOK
+	}
+
+	files, err := renderDir(registry)
+	if err != nil {
+		return env.fail(ExitFailure, "Error generating profiles: %v", err)
+	}
+	dryRun := env.dryRunning()
+	if !dryRun {
+		env.info("HNC Profile Dump - Generating switch profiles...")
+	}
+	if err := env.mkdirAll(*outputDir); err != nil {
+		return env.failAt(*outputDir, ExitIO, "Error generating profiles: failed to create output directory: %v", err)
+	}
+	contents := map[string][]byte{}
+	for _, f := range files {
+		path := filepath.Join(*outputDir, filepath.FromSlash(f.Name))
+		if err := env.mkdirAll(filepath.Dir(path)); err != nil {
+			return env.failAt(path, ExitIO, "Error generating profiles: failed to create directory for %s: %v", path, err)
+		}
+		written, err := env.write(path, f.Data)
+		if err != nil {
+			return env.failAt(path, ExitIO, "Error generating profiles: failed to write file %s: %v", path, err)
+		}
+		if written {
+			env.info("Generated profile: %s", path)
+		}
+		contents[f.Name] = f.Data
+	}
+	if code := env.writeManifest(*outputDir, contents); code != ExitOK {
+		return code
+	}
+	if !dryRun {
+		env.info("Profile generation completed successfully!")
+	}
+	return ExitOK
+}
+
+// writeManifest records the checksums of the files just generated in dir,
+// keeping the time of any that did not change
+func (env Env) writeManifest(dir string, files map[string][]byte) int {
+	path := filepath.Join(dir, manifest.File)
+	previous, err := manifest.Read(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return env.failAt(path, inputExit(err), "Error: %v", err)
+	}
+	data, err := canonjson.Marshal(manifest.New(env.Prog, buildVersion(), files, previous, time.Now()))
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding manifest: %v", err)
+	}
+	written, err := env.write(path, data)
+	if err != nil {
+		return env.failAt(path, ExitIO, "Error generating profiles: failed to write file %s: %v", path, err)
+	}
+	if written {
+		env.info("Generated manifest: %s", path)
+	}
+	return ExitOK
+}
+
+// stream writes profiles to w instead of a directory: JSON as an array or
+// NDJSON, YAML and CRD manifests as one multi-document stream
+func stream(w io.Writer, registry *profiles.Registry, format string, ndjson bool, render func(*profiles.Registry) ([]profiles.File, error)) error {
+	if format == "json" {
+		return registry.Stream(w, ndjson)
+	}
+	files, err := render(registry)
+	if err != nil {
+		return err
+	}
+	for i, f := range files {
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+		}
+		if _, err := w.Write(f.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDir writes a unified diff to w for every file whose copy in dir
+// differs from the regenerated one, diffing missing files against empty,
+// and returns how many differ
+func checkDir(w io.Writer, dir string, files []profiles.File) (int, error) {
+	stale := 0
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.Name))
+		current, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return stale, err
+		}
+		if diff := textdiff.Unified(path, path+" (regenerated)", string(current), string(f.Data)); diff != "" {
+			fmt.Fprint(w, diff)
+			stale++
+		}
+	}
+	return stale, nil
+}
+
+// Migrate upgrades the JSON profiles named on the command line, or every
+// *.json in named directories, to the current schema. It prints a diff of
+// each upgrade and exits 1 if any file is out of date, unless -write
+// rewrites them in place.
+func Migrate(env Env, args []string) int {
+	flags := newFlags(env, "[-write] [-j N] <file.json|dir>...")
+	write := flags.Bool("write", false, "Rewrite files in place instead of printing what would change")
+	jobs := jobsFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [-write] [-j N] <file.json|dir>...\n\nUpgrades profiles from v0.2.x and later to %s.\n\n", env.Prog, profiles.SchemaVersion)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	var files []string
+	for _, arg := range flags.Args() {
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			files = append(files, profileFiles(arg, "*.json")...)
+		} else {
+			files = append(files, arg)
+		}
+	}
+
+	type migration struct {
+		data, migrated []byte
+		from           string
+		code           int
+		err            string
+	}
+	results, _ := batch.Map(*jobs, files, func(file string) (migration, error) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return migration{code: ExitIO, err: fmt.Sprintf("Error: %v", err)}, nil
+		}
+		p, from, err := profiles.Migrate(data)
+		if err != nil {
+			return migration{code: ExitValidation, err: fmt.Sprintf("Error migrating %s: %v", file, err)}, nil
+		}
+		migrated, err := profiles.Marshal(p)
+		if err != nil {
+			return migration{code: ExitFailure, err: fmt.Sprintf("Error migrating %s: %v", file, err)}, nil
+		}
+		return migration{data: data, migrated: migrated, from: from}, nil
+	})
+
+	// Report every file that does not migrate, and write the others
+	stale, failed, code := 0, 0, ExitOK
+	for i, file := range files {
+		r := results[i]
+		if r.err != "" {
+			failed++
+			code = max(code, r.code)
+			env.failAt(file, r.code, "%s", r.err)
+			continue
+		}
+		data, migrated, from := r.data, r.migrated, r.from
+		if string(migrated) == string(data) {
+			continue
+		}
+		stale++
+		if !*write && !env.dryRunning() {
+			fmt.Fprint(env.Stdout, textdiff.Unified(file, file+" (migrated)", string(data), string(migrated)))
+			continue
+		}
+		written, err := env.write(file, migrated)
+		if err != nil {
+			return env.failAt(file, ExitIO, "Error: %v", err)
+		}
+		if written {
+			env.info("Migrated %s from %s to %s", file, from, profiles.SchemaVersion)
+		}
+	}
+	if failed > 0 {
+		return env.fail(code, "%d of %d profile file(s) could not be migrated", failed, len(files))
+	}
+	if stale > 0 && !*write && !env.dryRunning() {
+		return env.fail(ExitFailure, "%d profile file(s) need migrating to %s; rerun with -write", stale, profiles.SchemaVersion)
+	}
+	return ExitOK
+}
+
+// Lint runs the lint rules, and those of the plugins on $HNC_PLUGIN_PATH,
+// over the profiles named on the command line (files, every profile in
+// named directories, or - for a stream on stdin), or over the built-ins
+// when none are named. It exits 3 if any rule reports an error.
+func Lint(env Env, args []string) int {
+	flags := newFlags(env, "[flags] [file|dir|-]...")
+	enable := flags.String("enable", "", "Comma-separated rules to run instead of all of them")
+	disable := flags.String("disable", "", "Comma-separated rules to skip")
+	modelPattern := flags.String("model-pattern", lint.ModelIDPattern.String(), "Regular expression modelId must match (model-id rule)")
+	asJSON := flags.Bool("json", false, "Print findings as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] [file|dir|-]...\n\nRules:\n", env.Prog)
+		for _, r := range lint.Rules {
+			fmt.Fprintf(flags.Output(), "  %-22s %s (%s)\n", r.Name, r.Description, r.Severity)
+		}
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	pattern, err := regexp.Compile(*modelPattern)
+	if err != nil {
+		return env.fail(ExitUsage, "Error: bad -model-pattern: %v", err)
+	}
+	rules := env.lintRules(lint.Rules)
+	for i, r := range rules {
+		if r.Name == "model-id" {
+			rules[i] = lint.ModelIDRule(pattern)
+		}
+	}
+	if rules, err = lint.Select(rules, splitList(*enable), splitList(*disable)); err != nil {
+		return env.fail(ExitUsage, "Error: %v", err)
+	}
+
+	ps := profiles.Default().List()
+	if flags.NArg() > 0 {
+		ps = nil
+		for _, arg := range flags.Args() {
+			if arg == "-" {
+				streamed, err := profiles.ParseStream(env.Stdin)
+				if err != nil {
+					return env.fail(inputExit(err), "Error parsing stdin: %v", err)
+				}
+				ps = append(ps, streamed...)
+				continue
+			}
+			files := []string{arg}
+			if info, err := os.Stat(arg); err == nil && info.IsDir() {
+				files = nil
+				files = profileFiles(arg, "*.json", "*.yaml", "*.yml")
+			}
+			for _, file := range files {
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return env.fail(ExitIO, "Error: %v", err)
+				}
+				if profiles.IsFamily(file) {
+					family, err := profiles.ParseFamily(data)
+					if err != nil {
+						return env.failAt(file, ExitValidation, "Error parsing %s: %v", file, err)
+					}
+					ps = append(ps, family...)
+					continue
+				}
+				p, err := profiles.Parse(data, filepath.Ext(file))
+				if err != nil {
+					return env.failAt(file, ExitValidation, "Error parsing %s: %v", file, err)
+				}
+				ps = append(ps, p)
+			}
+		}
+	}
+
+	findings := lint.Run(ps, rules)
+	if *asJSON {
+		data, _ := canonjson.Marshal(findings)
+		env.Stdout.Write(data)
+	} else {
+		fmt.Fprint(env.Stdout, lint.RenderText(findings))
+		env.info("%d profile(s), %d rule(s): %d error(s), %d warning(s)",
+			len(ps), len(rules), lint.Errors(findings), len(findings)-lint.Errors(findings))
+	}
+	if n := lint.Errors(findings); n > 0 {
+		return env.fail(ExitValidation, "%d lint error(s)", n)
+	}
+	return ExitOK
+}
+
+// Schema writes the JSON Schema of the switch profile fixtures, derived
+// from the Go SwitchProfile struct
+func Schema(env Env, args []string) int {
+	flags := newFlags(env, "[-output FILE]")
+	outputFile := flags.String("output", "", "Output file for the schema (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	data, err := canonjson.Marshal(profiles.Schema())
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding schema: %v", err)
+	}
+	if *outputFile == "" {
+		env.Stdout.Write(data)
+		return ExitOK
+	}
+	written, err := env.write(*outputFile, data)
+	if err != nil {
+		return env.failAt(*outputFile, ExitIO, "Error writing %s: %v", *outputFile, err)
+	}
+	if written {
+		env.info("Generated schema: %s", *outputFile)
+	}
+	return ExitOK
+}
+
+// Validate checks every profile fixture in a directory against the
+// schema, so hand edits that would break the frontend fail fast. Each
+// problem is reported on its own, against its file.
+func Validate(env Env, args []string) int {
+	flags := newFlags(env, "[-dir DIR] [-j N]")
+	dir := flags.String("dir", "../../src/fixtures/switch-profiles", "Directory of switch profile JSON fixtures")
+	jobs := jobsFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	problems, checked, err := validateDir(*dir, *jobs)
+	if err != nil {
+		return env.failAt(*dir, inputExit(err), "Error: %v", err)
+	}
+	for _, p := range problems {
+		file, _, _ := strings.Cut(p, ": ")
+		env.failAt(filepath.Join(*dir, file), ExitValidation, "%s", p)
+	}
+	if len(problems) > 0 {
+		return env.failAt(*dir, ExitValidation, "%d problem(s) in %s", len(problems), *dir)
+	}
+	env.info("%d profile(s) in %s match the schema", checked, *dir)
+	return ExitOK
+}
+
+// validateDir checks every *.json profile in dir, jobs at a time, and
+// returns one line per problem, prefixed with the file name, in file
+// order, and the number of files checked
+func validateDir(dir string, jobs int) ([]string, int, error) {
+	files := profileFiles(dir, "*.json")
+	if len(files) == 0 {
+		return nil, 0, fmt.Errorf("no profile fixtures in %s", dir)
+	}
+	found, err := batch.Map(jobs, files, func(file string) ([]string, error) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read profile %s: %w", file, err)
+		}
+		var problems []string
+		name, _ := filepath.Rel(dir, file)
+		for _, e := range profiles.CheckSchema(data) {
+			problems = append(problems, name+": "+e)
+		}
+		return problems, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	var problems []string
+	for _, p := range found {
+		problems = append(problems, p...)
+	}
+	return problems, len(files), nil
+}
+
+// profileFiles are the profile files in dir, in either layout, matching
+// patterns, in order, leaving out the manifest hnc profiles dump writes
+// beside them
+func profileFiles(dir string, patterns ...string) []string {
+	files, _ := profiles.FixtureFiles(dir, patterns...)
+	return files
+}
+
+// VerifyManifest checks the files in a directory against the manifest.json
+// hnc profiles dump wrote with them, so a fixture edited by hand since it
+// was generated, or one that was never generated, fails
+func VerifyManifest(env Env, args []string) int {
+	flags := newFlags(env, "[-dir DIR]")
+	dir := flags.String("dir", "../../src/fixtures/switch-profiles", "Directory of generated files and their "+manifest.File)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	m, err := manifest.Read(*dir)
+	if err != nil {
+		return env.failAt(filepath.Join(*dir, manifest.File), inputExit(err), "Error: %v", err)
+	}
+	problems, matched, err := manifest.Verify(*dir, m)
+	if err != nil {
+		return env.fail(ExitIO, "Error: %v", err)
+	}
+	for _, p := range problems {
+		env.failAt(filepath.Join(*dir, p.Name), ExitValidation, "%s: %s", p.Name, p.Message)
+	}
+	if len(problems) > 0 {
+		return env.failAt(*dir, ExitValidation, "%d file(s) in %s do not match %s", len(problems), *dir, manifest.File)
+	}
+	env.info("%d file(s) in %s match %s, written by %s %s", matched, *dir, manifest.File, m.Generator, m.GeneratorVersion)
+	return ExitOK
+}
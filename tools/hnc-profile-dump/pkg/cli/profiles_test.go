@@ -0,0 +1,349 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricapi"
+	"github.com/hnc/profile-dump/pkg/lint"
+	"github.com/hnc/profile-dump/pkg/manifest"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/textdiff"
+)
+
+func TestGeneratedProfilesMatchContract(t *testing.T) {
+	for _, generated := range profiles.Default().List() {
+		data, err := os.ReadFile(filepath.Join(contractDir, "profiles", generated.ModelID+".json"))
+		if err != nil {
+			t.Fatalf("missing contract fixture for %s: %v", generated.ModelID, err)
+		}
+		var contract profiles.SwitchProfile
+		if err := json.Unmarshal(data, &contract); err != nil {
+			t.Fatalf("contract fixture for %s does not decode: %v", generated.ModelID, err)
+		}
+		if !reflect.DeepEqual(contract, generated) {
+			t.Errorf("%s drifted from contract fixture:\n got  %+v\n want %+v", generated.ModelID, generated, contract)
+		}
+		// Both sides write canonical JSON, so the bytes match too
+		if rendered, _ := profiles.Marshal(generated); string(rendered) != string(data) {
+			t.Errorf("%s: Go and the frontend render the profile differently:\n%s", generated.ModelID,
+				textdiff.Unified("contract", "generated", string(data), string(rendered)))
+		}
+	}
+}
+
+func TestCheckDir(t *testing.T) {
+	files, err := profiles.Default().Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if stale, err := checkDir(&out, "../../../../src/fixtures/switch-profiles", files); err != nil || stale != 0 {
+		t.Fatalf("checkDir(fixtures) = %d, %v; want 0 stale\n%s", stale, err, out.String())
+	}
+
+	dir := t.TempDir()
+	if _, err := profiles.Default().WriteAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(files[0].Data), `"speedGbps": 25`, `"speedGbps": 10`, 1)
+	if err := os.WriteFile(filepath.Join(dir, files[0].Name), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, files[1].Name)); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	stale, err := checkDir(&out, dir, files)
+	if err != nil || stale != 2 {
+		t.Fatalf("checkDir() = %d, %v; want 2 stale", stale, err)
+	}
+	for _, want := range []string{`-      "speedGbps": 10`, `+      "speedGbps": 25`, "@@ -0,0 +1,"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("diff missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDumpFromFabricAPI(t *testing.T) {
+	crd, err := profiles.ToCRD(profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	crd.Metadata.ResourceVersion = "7"
+	defer func(saved fabricapi.Kubectl) { kubectl = saved }(kubectl)
+	kubectl = func(_ context.Context, args ...string) ([]byte, error) {
+		if args[len(args)-1] == "jsonpath={.clusters[0].name}" {
+			return []byte("lab"), nil
+		}
+		return json.Marshal(map[string]any{"items": []profiles.SwitchProfileCRD{crd}})
+	}
+
+	var stdout, stderr strings.Builder
+	if code := Dump(testEnv(nil, &stdout, &stderr), []string{"-source", "fabric-api", "-output", "-", "-ndjson"}); code != 0 {
+		t.Fatalf("dump -source fabric-api = %d: %s", code, stderr.String())
+	}
+	p, err := profiles.Decode([]byte(stdout.String()), ".json")
+	if err != nil || p.ModelID != "celestica-ds3000" || p.Meta.Source != "fabric-api:lab@7" {
+		t.Fatalf("dumped %s, %v", stdout.String(), err)
+	}
+	if code := Dump(testEnv(nil, &stdout, &stderr), []string{"-context", "lab"}); code != 2 {
+		t.Errorf("dump -context without -source = %d, want 2", code)
+	}
+}
+
+func TestRunMigrate(t *testing.T) {
+	dir := t.TempDir()
+	old := `{"modelId":"celestica-ds3000","role":"spine","ports":{"endpoint":[],"fabric":["E1/1-32"]},` +
+		`"profiles":{"endpoint":{"portProfile":null,"speedGbps":0},"uplink":{"portProfile":"QSFP28-100G","speedGbps":100}},` +
+		`"meta":{"source":"switch_profile.go","version":"v0.2.1"}}` + "\n"
+	path := filepath.Join(dir, "ds3000.json")
+	if err := os.WriteFile(path, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	if code := Migrate(testEnv(nil, &stdout, &stderr), []string{dir}); code != 1 || !strings.Contains(stdout.String(), `+  "roles": [`) {
+		t.Fatalf("migrate without -write = %d\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != old {
+		t.Fatal("migrate without -write changed the file")
+	}
+
+	stdout.Reset()
+	if code := Migrate(testEnv(nil, &stdout, &stderr), []string{"-write", path}); code != 0 {
+		t.Fatalf("migrate -write = %d: %s", code, stderr.String())
+	}
+	data, _ := os.ReadFile(path)
+	p, err := profiles.Decode(data, ".json")
+	if err != nil || p.Meta.Version != profiles.SchemaVersion || !strings.HasSuffix(string(data), "}\n") {
+		t.Fatalf("migrated file = %s, %v", data, err)
+	}
+	if code := Migrate(testEnv(nil, &stdout, &stderr), []string{dir}); code != 0 {
+		t.Errorf("migrate after -write = %d, want 0", code)
+	}
+}
+
+// -j changes how many profiles are worked on at once, not what is written,
+// and every bad input is reported, not only the first
+func TestProfilesJobs(t *testing.T) {
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	var dumps []map[string]string
+	for _, jobs := range []string{"1", "8"} {
+		dir := t.TempDir()
+		if code := Main(env, Root, []string{"profiles", "dump", "-j", jobs, "-format", "yaml", "-output", dir}); code != ExitOK {
+			t.Fatalf("dump -j %s = %d: %s", jobs, code, stderr.String())
+		}
+		files := map[string]string{}
+		for _, name := range profileFiles(dir, "*.yaml") {
+			data, _ := os.ReadFile(name)
+			files[filepath.Base(name)] = string(data)
+		}
+		dumps = append(dumps, files)
+	}
+	if len(dumps[0]) == 0 || !reflect.DeepEqual(dumps[0], dumps[1]) {
+		t.Errorf("dump -j 1 and -j 8 differ")
+	}
+
+	dir := t.TempDir()
+	valid, _ := os.ReadFile("../../../../src/fixtures/switch-profiles/ds2000.json")
+	for name, data := range map[string]string{"a.json": "{", "b.json": string(valid), "c.json": `{"modelId": 1}`} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"profiles", "migrate", "-j", "3", dir}); code != ExitValidation {
+		t.Errorf("migrate of two broken files = %d, want %d", code, ExitValidation)
+	}
+	a, c := strings.Index(stderr.String(), "a.json"), strings.Index(stderr.String(), "c.json")
+	if a < 0 || c < a || !strings.Contains(stderr.String(), "2 of 3 profile file(s) could not be migrated") {
+		t.Errorf("migrate stderr:\n%s", stderr.String())
+	}
+}
+
+func TestRunLint(t *testing.T) {
+	dir := t.TempDir()
+	bad := profiles.DS3000()
+	bad.Ports.EndpointAssignable = []string{"E1/1"}
+	data, _ := profiles.Marshal(bad)
+	if err := os.WriteFile(filepath.Join(dir, "ds3000.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	if code := Lint(testEnv(nil, &stdout, &stderr), []string{"-json", dir}); code != ExitValidation {
+		t.Fatalf("lint = %d, want 3\n%s%s", code, stdout.String(), stderr.String())
+	}
+	var findings []lint.Finding
+	if err := json.Unmarshal([]byte(stdout.String()), &findings); err != nil {
+		t.Fatal(err)
+	}
+	// The endpoint port also overlaps the fabric range and has no speed
+	var rules []string
+	for _, f := range findings {
+		rules = append(rules, f.Rule)
+	}
+	if want := []string{"port-overlap", "spine-endpoint-ports", "ethernet-speeds"}; !reflect.DeepEqual(rules, want) {
+		t.Fatalf("findings = %+v", findings)
+	}
+
+	stdout.Reset()
+	if code := Lint(testEnv(nil, &stdout, &stderr), []string{"-disable", "port-overlap,spine-endpoint-ports,ethernet-speeds", dir}); code != 0 {
+		t.Errorf("lint with the failing rules disabled = %d\n%s", code, stdout.String())
+	}
+	if code := Lint(testEnv(nil, &stdout, &stderr), []string{"-enable", "nope"}); code != 2 {
+		t.Errorf("lint -enable nope = %d, want 2", code)
+	}
+}
+
+func TestRunLintReadsStdin(t *testing.T) {
+	var in strings.Builder
+	if err := profiles.Default().Stream(&in, true); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	if code := Lint(testEnv(strings.NewReader(in.String()), &stdout, &stderr), []string{"-"}); code != 0 {
+		t.Fatalf("lint - = %d\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.HasPrefix(stderr.String(), "6 profile(s)") {
+		t.Errorf("lint - output = %q", stderr.String())
+	}
+}
+
+// dump writes a manifest that verify accepts until a file is edited, and
+// regenerating unchanged files leaves it as it was
+func TestDumpManifestVerify(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"profiles", "dump", "-output", dir}); code != ExitOK {
+		t.Fatalf("dump = %d: %s", code, stderr.String())
+	}
+	first, err := os.ReadFile(filepath.Join(dir, manifest.File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := Main(env, Root, []string{"profiles", "dump", "-output", dir}); code != ExitOK {
+		t.Fatalf("dump again = %d: %s", code, stderr.String())
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, manifest.File)); string(again) != string(first) {
+		t.Errorf("regenerating changed the manifest:\n%s", textdiff.Unified("first", "again", string(first), string(again)))
+	}
+	if code := Main(env, Root, []string{"profiles", "verify", "-dir", dir}); code != ExitOK {
+		t.Fatalf("verify = %d: %s", code, stderr.String())
+	}
+	if code := Validate(env, []string{"-dir", dir}); code != ExitOK {
+		t.Errorf("validate read the manifest as a profile: %s", stderr.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "ds2000.json"), []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if code := Main(env, Root, []string{"profiles", "verify", "-dir", dir}); code != ExitValidation || !strings.Contains(stderr.String(), "ds2000.json: changed since it was generated") {
+		t.Errorf("verify of an edited fixture = %d: %s", code, stderr.String())
+	}
+}
+
+// The checked-in frontend fixtures are the files hand edits keep breaking
+func TestDumpTreeLayout(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr strings.Builder
+	env := testEnv(nil, &stdout, &stderr)
+	if code := Main(env, Root, []string{"profiles", "dump", "-output", dir, "-layout", "tree"}); code != ExitOK {
+		t.Fatalf("dump -layout tree = %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "edgecore", "spine", "dcs204.json")); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"profiles", "dump", "-output", dir, "-layout", "tree", "-check"},
+		{"profiles", "verify", "-dir", dir},
+		{"profiles", "validate", "-dir", dir},
+		{"profiles", "doctor", "-dir", dir},
+		{"profiles", "dump", "-input", dir, "-output", "-"},
+	} {
+		if code := Main(env, Root, args); code != ExitOK {
+			t.Errorf("%s = %d: %s", strings.Join(args, " "), code, stderr.String())
+		}
+	}
+
+	// A flat dump beside it leaves files the tree does not have
+	stdout.Reset()
+	if code := Main(env, Root, []string{"profiles", "dump", "-output", dir}); code != ExitOK {
+		t.Fatalf("dump = %d: %s", code, stderr.String())
+	}
+	if code := Main(env, Root, []string{"profiles", "doctor", "-dir", dir}); code != ExitFailure ||
+		!strings.Contains(stdout.String(), "ds2000.json: not a profile the generator writes") ||
+		!strings.Contains(stdout.String(), "-layout tree, then delete the unknown files") {
+		t.Errorf("doctor of mixed layouts = %d:\n%s", code, stdout.String())
+	}
+
+	stderr.Reset()
+	if code := Main(env, Root, []string{"profiles", "dump", "-output", "-", "-layout", "tree"}); code != ExitUsage || !strings.Contains(stderr.String(), "cannot be used with -output -") {
+		t.Errorf("dump -layout tree -output - = %d: %s", code, stderr.String())
+	}
+	if code := Main(env, Root, []string{"profiles", "dump", "-output", dir, "-layout", "nested"}); code != ExitUsage {
+		t.Errorf("dump -layout nested = %d", code)
+	}
+}
+
+func TestFixturesMatchSchema(t *testing.T) {
+	for _, dir := range []string{"../../../../src/fixtures/switch-profiles", filepath.Join(contractDir, "profiles")} {
+		problems, checked, err := validateDir(dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(problems) > 0 || checked == 0 {
+			t.Errorf("%s: %d checked, problems:\n%s", dir, checked, strings.Join(problems, "\n"))
+		}
+	}
+	dir := "../../../../src/fixtures/switch-profiles"
+	m, err := manifest.Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems, _, err := manifest.Verify(dir, m); err != nil || len(problems) > 0 {
+		t.Errorf("%s was edited since hnc profiles dump wrote it: %+v, %v", dir, problems, err)
+	}
+}
+
+func TestValidateDirReportsDrift(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("../../../../src/fixtures/switch-profiles/ds2000.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	uplink := doc["profiles"].(map[string]any)["uplink"].(map[string]any)
+	uplink["breakouts"].([]any)[0].(map[string]any)["speedGbps"] = "25"
+	doc["role"] = doc["roles"]
+	delete(doc, "roles")
+	drifted, _ := json.Marshal(doc)
+	if err := os.WriteFile(filepath.Join(dir, "ds2000.json"), drifted, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, _, err := validateDir(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`ds2000.json: $: missing required property "roles"`,
+		"ds2000.json: $.profiles.uplink.breakouts[0].speedGbps: expected integer, got string",
+		`ds2000.json: $: unknown property "role"`,
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("problems = %q, want %q", problems, want)
+	}
+}
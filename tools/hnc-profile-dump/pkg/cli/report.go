@@ -0,0 +1,358 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/facilities"
+	"github.com/hnc/profile-dump/pkg/present"
+	"github.com/hnc/profile-dump/pkg/resilience"
+	"github.com/hnc/profile-dump/pkg/utilization"
+	"github.com/hnc/profile-dump/pkg/xlsx"
+)
+
+// reportFormats are the -format values of the report commands
+var reportFormats = []string{"table", "json", "csv", "xlsx"}
+
+// ReportUtilization prints the used and free endpoint and fabric ports of
+// every switch in a plan, from a cabling map or, without one, the cabling
+// hnc cabling would assign
+func ReportUtilization(env Env, args []string) int {
+	flags := newFlags(env, "[-format table|json|csv|xlsx] [-cabling FILE] [-output FILE]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	cablingFile := flags.String("cabling", "", "Cabling map written by hnc cabling (default: assign one with -strategy)")
+	strategy := flags.String("strategy", cabling.RoundRobin, "Without -cabling, how leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	format := flags.String("format", "table", "Output format: "+strings.Join(reportFormats, ", "))
+	outputFile := flags.String("output", "", "Output file for the report (default: stdout)")
+	opts := presentFlags(env, flags)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	var m cabling.Map
+	if *cablingFile != "" {
+		if m, err = readCabling(*cablingFile); err != nil {
+			return env.failAt(*cablingFile, inputExit(err), "Error %v", err)
+		}
+	} else if m, err = assignCabling(registry, plan, leaf, spine, *strategy, 0); err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+
+	superSpine, err := findSuperSpine(registry, plan)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	switches, err := utilization.FromPlan(plan, m, leaf, spine, superSpine)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	return env.writeReport(*format, *outputFile, "Utilization", switches, *opts, func() present.Table { return utilizationTable(switches) },
+		func() string { return utilization.RenderCSV(switches) })
+}
+
+// ReportResilience fails every spine, leaf and leaf-spine link of a plan,
+// and every super-spine of a multi-pod one, in turn, and reports what the
+// worst failure of each kind costs: endpoints cut off or left on one leaf
+// of their pair, fabric bandwidth lost and the oversubscription left
+func ReportResilience(env Env, args []string) int {
+	flags := newFlags(env, "[-format table|json|csv|xlsx] [-cabling FILE] [-all] [-output FILE]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	cablingFile := flags.String("cabling", "", "Cabling map written by hnc cabling (default: assign one with -strategy)")
+	strategy := flags.String("strategy", cabling.RoundRobin, "Without -cabling, how leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	all := flags.Bool("all", false, "Report every failure, not only the worst of each kind")
+	format := flags.String("format", "table", "Output format: "+strings.Join(reportFormats, ", "))
+	outputFile := flags.String("output", "", "Output file for the report (default: stdout)")
+	opts := presentFlags(env, flags)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	var m cabling.Map
+	if *cablingFile != "" {
+		if m, err = readCabling(*cablingFile); err != nil {
+			return env.failAt(*cablingFile, inputExit(err), "Error %v", err)
+		}
+	} else if m, err = assignCabling(registry, plan, leaf, spine, *strategy, 0); err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	ctx, stop := env.interruptible()
+	defer stop()
+	report, err := resilience.AnalyzeContext(ctx, plan, m, spine)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	if !*all {
+		report.Failures = resilience.Worst(report.Failures)
+	}
+	return env.writeReport(*format, *outputFile, "Resilience", report, *opts, func() present.Table { return resilienceTable(report, !*all) },
+		func() string { return resilience.RenderCSV(report) })
+}
+
+// presentFlags adds the flags of how a report's table is presented; its
+// defaults are what the terminal, or the lack of one, suits
+func presentFlags(env Env, flags *flag.FlagSet) *present.Options {
+	opts := present.Detect(env.Stdout, env.getenv)
+	flags.BoolFunc("no-color", "Do not bold the table header (default: bold on a terminal without NO_COLOR)", func(string) error {
+		opts.Color = false
+		return nil
+	})
+	flags.IntVar(&opts.Width, "width", opts.Width, "Columns the table must fit in, or each row is written as a block of lines; 0 for any (default: $COLUMNS on a terminal)")
+	flags.BoolVar(&opts.Quiet, "quiet", false, "Print only the table's summary lines, not its rows")
+	return &opts
+}
+
+// writeReport renders a report in one of reportFormats: value as JSON,
+// the table presented by opts, or the CSV rendering, or the CSV as the one
+// sheet of a workbook, to stdout or outputFile; a table written to a file
+// is plain and any width
+func (env Env) writeReport(format, outputFile, sheet string, value any, opts present.Options, table func() present.Table, csv func() string) int {
+	var out strings.Builder
+	switch format {
+	case "table":
+		if outputFile != "" {
+			opts.Color, opts.Width = false, 0
+		}
+		opts.Render(&out, table())
+	case "json":
+		data, err := canonjson.Marshal(value)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding report: %v", err)
+		}
+		out.Write(data)
+	case "csv":
+		out.WriteString(csv())
+	case "xlsx":
+		data, err := workbook(csvSheet{sheet, csv()})
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding report: %v", err)
+		}
+		out.Write(data)
+	default:
+		return env.fail(ExitUsage, "Error: unknown -format %q (want %s)", format, strings.Join(reportFormats, ", "))
+	}
+	if outputFile == "" {
+		fmt.Fprint(env.Stdout, out.String())
+		return ExitOK
+	}
+	return env.writeFile(outputFile, []byte(out.String()))
+}
+
+// ReportPower rolls the rack units, weight, power and heat of a plan's
+// switches up per rack and for the fabric, for facilities planning
+func ReportPower(env Env, args []string) int {
+	var layout facilities.Layout
+	flags := newFlags(env, "[-format table|json|csv|xlsx] [-leaves-per-rack N] [-output FILE]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	flags.IntVar(&layout.LeavesPerRack, "leaves-per-rack", 0, "Leaves that share a rack (default: 1, or the leaf pair with -redundancy)")
+	flags.IntVar(&layout.SpinesPerRack, "spines-per-rack", 0, "Spines that share a rack (default: all of them)")
+	flags.IntVar(&layout.SuperSpinesPerRack, "super-spines-per-rack", 0, "Super-spines of a multi-pod plan that share a rack (default: all of them)")
+	format := flags.String("format", "table", "Output format: "+strings.Join(reportFormats, ", "))
+	outputFile := flags.String("output", "", "Output file for the report (default: stdout)")
+	opts := presentFlags(env, flags)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	leaf, spine, err := findModels(registry, plan.LeafModel, plan.SpineModel)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	superSpine, err := findSuperSpine(registry, plan)
+	if err != nil {
+		return env.fail(ExitValidation, "Error: %v", err)
+	}
+	report, err := facilities.FromPlan(plan, leaf, spine, superSpine, layout)
+	if err != nil {
+		return env.fail(ExitUsage, "Error: %v", err)
+	}
+	for _, warning := range report.Warnings {
+		env.warn("Warning: %s", warning)
+	}
+	return env.writeReport(*format, *outputFile, "Power", report, *opts, func() present.Table { return powerTable(report) },
+		func() string { return facilities.RenderCSV(report) })
+}
+
+// powerTable is a row per rack, summed up for the fabric; a figure some
+// of the switches lack reads missing
+func powerTable(r facilities.Report) present.Table {
+	t := present.Table{Header: []string{"RACK", "SWITCHES", "RU", "WEIGHT (KG)", "TYPICAL (W)", "MAX (W)", "HEAT (BTU/H)"}}
+	for _, rack := range r.Racks {
+		row := []string{rack.Name, strings.Join(rack.Switches, ",")}
+		for _, f := range facilities.Figures {
+			row = append(row, rack.Budget.Figure(f))
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	f := r.Fabric
+	summary := []string{fmt.Sprintf("Fabric: %d switches in %d racks", f.Switches, len(r.Racks))}
+	for i, unit := range []struct{ format, missing string }{
+		{"%s RU", "rack units"}, {"%s kg", "weight"}, {"%sW typical", "typical power"}, {"%sW max", "max power"}, {"%s BTU/h", "heat"},
+	} {
+		if v := f.Figure(facilities.Figures[i]); v != "missing" {
+			summary = append(summary, fmt.Sprintf(unit.format, v))
+		} else {
+			summary = append(summary, unit.missing+" missing")
+		}
+	}
+	t.Summary = []string{strings.Join(summary, ", ")}
+	return t
+}
+
+// resilienceTable is a row per failure, the worst of each kind and how
+// many there were when worst, summed up by the fabric whole and its worst
+// single failure
+func resilienceTable(r resilience.Report, worst bool) present.Table {
+	pods := r.PodOversubscription > 0
+	spineless := r.FabricGbps == 0
+	var t present.Table
+	t.Header = []string{"KIND", "FAILED"}
+	if worst {
+		t.Header = []string{"KIND", "WORST", "OF"}
+	}
+	t.Header = append(t.Header, "ENDPOINTS DOWN", "DEGRADED", "UPLINKS LEFT", "OVERSUBSCRIPTION")
+	if pods {
+		t.Header = append(t.Header, "BETWEEN PODS")
+	}
+	var worstDown *resilience.Failure
+	for i, f := range r.Failures {
+		row := []string{f.Kind, f.Component}
+		if worst {
+			row = append(row, fmt.Sprint(f.Components))
+		}
+		row = append(row, fmt.Sprint(f.EndpointsDown), fmt.Sprint(f.EndpointsDegraded))
+		if spineless {
+			row = append(row, "-", "-")
+		} else {
+			row = append(row, fmt.Sprintf("%dG (%.1f%%)", f.FabricGbps, f.FabricPercent), fmt.Sprintf("%.2f:1", f.Oversubscription))
+		}
+		if pods {
+			row = append(row, fmt.Sprintf("%.2f:1", f.PodOversubscription))
+		}
+		t.Rows = append(t.Rows, row)
+		if worstDown == nil || f.EndpointsDown > worstDown.EndpointsDown ||
+			f.EndpointsDown == worstDown.EndpointsDown && f.FabricGbps < worstDown.FabricGbps {
+			worstDown = &r.Failures[i]
+		}
+	}
+
+	fabric := fmt.Sprintf("Fabric: %d endpoints, no spines", r.Endpoints)
+	if !spineless {
+		fabric = fmt.Sprintf("Fabric: %d endpoints, %dG of leaf uplinks, %.2f:1 on the most loaded leaf", r.Endpoints, r.FabricGbps, r.Oversubscription)
+	}
+	if pods {
+		fabric += fmt.Sprintf(", %.2f:1 between pods", r.PodOversubscription)
+	}
+	t.Summary = []string{fabric}
+	if f := worstDown; f != nil {
+		line := fmt.Sprintf("Worst single failure: %s %s, %d endpoints down and %d degraded", f.Kind, f.Component, f.EndpointsDown, f.EndpointsDegraded)
+		if !spineless {
+			line += fmt.Sprintf(", %dG (%.1f%%) of uplinks left", f.FabricGbps, f.FabricPercent)
+		}
+		t.Summary = append(t.Summary, line)
+	}
+	return t
+}
+
+// utilizationTable is a row per switch, each port class as used/total and
+// a percentage, and a leaf's endpoint classes when the plan has them,
+// summed up for the fabric
+func utilizationTable(switches []utilization.Switch) present.Table {
+	classes := false
+	for _, s := range switches {
+		classes = classes || len(s.Classes) > 0
+	}
+	t := present.Table{Header: []string{"SWITCH", "ROLE", "MODEL", "ENDPOINT PORTS", "FREE", "FABRIC PORTS", "FREE"}}
+	if classes {
+		t.Header = append(t.Header, "ENDPOINT CLASSES")
+	}
+	var endpoints, fabric utilization.Ports
+	for _, s := range switches {
+		row := []string{s.Name, s.Role, s.Model, usage(s.Endpoints), fmt.Sprint(s.Endpoints.Free), usage(s.Fabric), fmt.Sprint(s.Fabric.Free)}
+		if classes {
+			row = append(row, s.ClassList(", "))
+		}
+		t.Rows = append(t.Rows, row)
+		endpoints.Used, endpoints.Total = endpoints.Used+s.Endpoints.Used, endpoints.Total+s.Endpoints.Total
+		fabric.Used, fabric.Total = fabric.Used+s.Fabric.Used, fabric.Total+s.Fabric.Total
+	}
+	for _, p := range []*utilization.Ports{&endpoints, &fabric} {
+		if p.Total > 0 {
+			p.Percent = 100 * float64(p.Used) / float64(p.Total)
+		}
+	}
+	t.Summary = []string{fmt.Sprintf("Fabric: %d switches, %s endpoint ports and %s fabric ports used", len(switches), usage(endpoints), usage(fabric))}
+	return t
+}
+
+// usage prints ports as 12/48 (25.0%), - for a switch with none
+func usage(p utilization.Ports) string {
+	if p.Total == 0 && p.Used == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d (%.1f%%)", p.Used, p.Total, p.Percent)
+}
+
+// csvSheet is a sheet of a workbook, from a CSV rendering
+type csvSheet struct {
+	name, csv string
+}
+
+// workbook is an Excel workbook of the sheets, in order
+func workbook(sheets ...csvSheet) ([]byte, error) {
+	var parsed []xlsx.Sheet
+	for _, s := range sheets {
+		sheet, err := xlsx.FromCSV(s.name, s.csv)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, sheet)
+	}
+	return xlsx.Write(parsed...)
+}
+
+// writeWorkbook writes the sheets as an Excel workbook to file
+func (env Env) writeWorkbook(file string, sheets ...csvSheet) int {
+	data, err := workbook(sheets...)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding %s: %v", file, err)
+	}
+	return env.writeFile(file, data)
+}
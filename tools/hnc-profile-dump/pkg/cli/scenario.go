@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/pricing"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/scenario"
+)
+
+// storeFlag adds -store, the scenario store directory
+func storeFlag(flags *flag.FlagSet) *string {
+	return flags.String("store", "scenarios", "Directory of saved scenarios")
+}
+
+// scenarioName is a command's one argument, a valid scenario name
+func scenarioName(env Env, flags *flag.FlagSet) (string, int) {
+	if flags.NArg() != 1 {
+		env.fail(ExitUsage, "Error: name one scenario")
+		flags.Usage()
+		return "", ExitUsage
+	}
+	name := flags.Arg(0)
+	if err := scenario.CheckName(name); err != nil {
+		return "", env.fail(ExitUsage, "Error: %v", err)
+	}
+	return name, ExitOK
+}
+
+// scenarioExit is the exit code for a store error: a scenario the store
+// does not have is a missing input
+func scenarioExit(err error) int {
+	if errors.Is(err, scenario.ErrNotFound) {
+		return ExitIO
+	}
+	return ExitFailure
+}
+
+// ScenarioSave saves a fabric plan, with its cabling map, VPC plan and any
+// other files it was planned from, as a named scenario
+func ScenarioSave(env Env, args []string) int {
+	flags := newFlags(env, "[flags] <name>")
+	store := storeFlag(flags)
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	cablingFile := flags.String("cabling", "", "Cabling map written by hnc cabling, used by hnc scenario diff")
+	vpcsFile := flags.String("vpcs", "", "VPC plan written by hnc vpcs, used by hnc scenario diff")
+	inputs := flags.String("files", "", "Comma-separated other files to keep with the plan, e.g. a layout or the endpoint classes it was planned from")
+	note := flags.String("note", "", "What the scenario tries out")
+	force := flags.Bool("force", false, "Replace a scenario of the same name")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	name, code := scenarioName(env, flags)
+	if code != ExitOK {
+		return code
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	if *cablingFile != "" {
+		if _, err := readCabling(*cablingFile); err != nil {
+			return env.failAt(*cablingFile, inputExit(err), "Error %v", err)
+		}
+	}
+	if *vpcsFile != "" {
+		if _, err := readVPCPlan(*vpcsFile); err != nil {
+			return env.failAt(*vpcsFile, inputExit(err), "Error %v", err)
+		}
+	}
+	files := map[string][]byte{}
+	sources := map[string]string{}
+	for _, in := range []struct{ file, as string }{{*planFile, scenario.PlanFile}, {*cablingFile, scenario.CablingFile}, {*vpcsFile, scenario.VPCsFile}} {
+		if in.file != "" {
+			sources[in.as] = in.file
+		}
+	}
+	for _, file := range splitList(*inputs) {
+		as := filepath.Base(file)
+		if other, ok := sources[as]; ok || as == scenario.MetaFile {
+			if !ok {
+				other = "the scenario's own"
+			}
+			return env.fail(ExitUsage, "Error: -files %s would be saved as %s, as %s is", file, as, other)
+		}
+		sources[as] = file
+	}
+	for as, file := range sources {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return env.failAt(file, ExitIO, "Error reading %s: %v", file, err)
+		}
+		files[as] = data
+	}
+
+	st := scenario.Store{Dir: *store}
+	s, err := st.Save(scenario.Scenario{Name: name, Note: *note, SavedAt: time.Now()}, files, *force)
+	if err != nil {
+		return env.fail(ExitFailure, "Error saving scenario %s: %v", name, err)
+	}
+	env.info("Saved scenario %s (%d endpoints on %d leaves and %d spines) with %s to %s",
+		name, plan.Request.Endpoints, plan.Leaves, plan.Spines, strings.Join(s.Files, ", "), filepath.Join(st.Dir, name))
+	return ExitOK
+}
+
+// scenarioSummary is a scenario as hnc scenario list shows it
+type scenarioSummary struct {
+	scenario.Scenario
+	Endpoints  int    `json:"endpoints"`
+	LeafModel  string `json:"leafModel"`
+	Leaves     int    `json:"leaves"`
+	SpineModel string `json:"spineModel,omitempty"`
+	Spines     int    `json:"spines"`
+	// Cost is, given a price list, the estimated cost of the plan's BOM
+	Cost *float64 `json:"cost,omitempty"`
+}
+
+// ScenarioList lists the saved scenarios and the fabric each plans, and
+// with -pricing what each would cost
+func ScenarioList(env Env, args []string) int {
+	flags := newFlags(env, "[flags]")
+	store := storeFlag(flags)
+	pricingFile := flags.String("pricing", "", pricingUsage)
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	asJSON := flags.Bool("json", false, "Print the scenarios as JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	var prices pricing.List
+	var registry *profiles.Registry
+	if *pricingFile != "" {
+		var err error
+		if prices, err = readPriceList(*pricingFile); err != nil {
+			return env.failAt(*pricingFile, inputExit(err), "Error %v", err)
+		}
+		if registry, err = loadRegistry(env, *profilesDir); err != nil {
+			return env.fail(inputExit(err), "Error loading profiles: %v", err)
+		}
+	}
+	st := scenario.Store{Dir: *store}
+	list, err := st.List()
+	if err != nil {
+		return env.fail(ExitIO, "Error listing scenarios: %v", err)
+	}
+	summaries := []scenarioSummary{}
+	var unpriced []string
+	for _, s := range list {
+		file := st.Path(s.Name, scenario.PlanFile)
+		plan, err := readPlan(file)
+		if err != nil {
+			return env.failAt(file, inputExit(err), "Error: scenario %s: %v", s.Name, err)
+		}
+		summary := scenarioSummary{Scenario: s, Endpoints: plan.Request.Endpoints,
+			LeafModel: plan.LeafModel, Leaves: plan.Leaves, SpineModel: plan.SpineModel, Spines: plan.Spines}
+		if registry != nil {
+			estimate, err := planCost(registry, plan, prices)
+			if err != nil {
+				return env.fail(ExitFailure, "Error: scenario %s: %v", s.Name, err)
+			}
+			summary.Cost = &estimate.Total
+			for _, item := range estimate.Unpriced {
+				if !slices.Contains(unpriced, item) {
+					unpriced = append(unpriced, item)
+				}
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	if len(unpriced) > 0 {
+		slices.Sort(unpriced)
+		env.warn("Warning: %s prices no %s; the estimates leave them out", *pricingFile, strings.Join(unpriced, ", "))
+	}
+
+	if *asJSON {
+		data, err := canonjson.Marshal(summaries)
+		if err != nil {
+			return env.fail(ExitFailure, "Error encoding scenarios: %v", err)
+		}
+		env.Stdout.Write(data)
+		return ExitOK
+	}
+	if len(summaries) == 0 {
+		env.info("No scenarios in %s; save one with hnc scenario save", st.Dir)
+		return ExitOK
+	}
+	w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	header := "NAME\tSAVED\tENDPOINTS\tLEAVES\tSPINES"
+	if registry != nil {
+		header += "\tCOST"
+	}
+	fmt.Fprintln(w, header+"\tNOTE")
+	for _, s := range summaries {
+		spines := fmt.Sprintf("%d x %s", s.Spines, s.SpineModel)
+		if s.Spines == 0 {
+			spines = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d x %s\t%s", s.Name, s.SavedAt.Format(time.RFC3339),
+			s.Endpoints, s.Leaves, s.LeafModel, spines)
+		if s.Cost != nil {
+			fmt.Fprintf(w, "\t%s", pricing.Estimate{Currency: prices.Currency}.Format(*s.Cost))
+		}
+		fmt.Fprintf(w, "\t%s\n", s.Note)
+	}
+	w.Flush()
+	return ExitOK
+}
+
+// ScenarioLoad writes a saved scenario's plan and files back out, for the
+// other commands to work on or to save again under another name
+func ScenarioLoad(env Env, args []string) int {
+	flags := newFlags(env, "[flags] <name>")
+	store := storeFlag(flags)
+	outputDir := flags.String("output", ".", "Directory to write the scenario's files to, under the names hnc gives them")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	name, code := scenarioName(env, flags)
+	if code != ExitOK {
+		return code
+	}
+	st := scenario.Store{Dir: *store}
+	s, err := st.Get(name)
+	if err != nil {
+		return env.fail(scenarioExit(err), "Error: %v", err)
+	}
+	if err := env.mkdirAll(*outputDir); err != nil {
+		return env.failAt(*outputDir, ExitIO, "Error creating output directory: %v", err)
+	}
+	for _, file := range s.Files {
+		data, err := st.Read(name, file)
+		if err != nil {
+			return env.failAt(st.Path(name, file), ExitIO, "Error reading scenario %s: %v", name, err)
+		}
+		if code := env.writeFile(filepath.Join(*outputDir, file), data); code != ExitOK {
+			return code
+		}
+	}
+	env.info("Loaded scenario %s, saved %s", name, s.SavedAt.Format(time.RFC3339))
+	return ExitOK
+}
+
+// ScenarioDelete removes a saved scenario and its files
+func ScenarioDelete(env Env, args []string) int {
+	flags := newFlags(env, "[flags] <name>")
+	store := storeFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	name, code := scenarioName(env, flags)
+	if code != ExitOK {
+		return code
+	}
+	if err := (scenario.Store{Dir: *store}).Delete(name); err != nil {
+		return env.fail(scenarioExit(err), "Error: %v", err)
+	}
+	env.info("Deleted scenario %s", name)
+	return ExitOK
+}
+
+// ScenarioDiff compares two saved scenarios with hnc plan diff, using the
+// cabling maps they saved and, when both saved one, their VPC plans
+func ScenarioDiff(env Env, args []string) int {
+	flags := newFlags(env, "[flags] <old> <new>")
+	store := storeFlag(flags)
+	strategy := flags.String("strategy", cabling.RoundRobin, "For a scenario saved without a cabling map, how leaf uplinks spread over spines: "+strings.Join(cabling.Strategies, " or "))
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	pricingFile := flags.String("pricing", "", pricingUsage)
+	asJSON := flags.Bool("json", false, "Print the diff as JSON instead of tables")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if flags.NArg() != 2 {
+		env.fail(ExitUsage, "Error: name the old and the new scenario")
+		flags.Usage()
+		return ExitUsage
+	}
+	st := scenario.Store{Dir: *store}
+	var sides [2]scenario.Scenario
+	for i := range sides {
+		var err error
+		if sides[i], err = st.Get(flags.Arg(i)); err != nil {
+			return env.fail(scenarioExit(err), "Error: %v", err)
+		}
+	}
+
+	diffArgs := []string{"-strategy", *strategy, "-profiles", *profilesDir, "-pricing", *pricingFile, "-json=" + fmt.Sprint(*asJSON)}
+	for i, side := range []string{"old", "new"} {
+		if sides[i].Has(scenario.CablingFile) {
+			diffArgs = append(diffArgs, "-"+side+"-cabling", st.Path(sides[i].Name, scenario.CablingFile))
+		}
+		if sides[0].Has(scenario.VPCsFile) && sides[1].Has(scenario.VPCsFile) {
+			diffArgs = append(diffArgs, "-"+side+"-vpcs", st.Path(sides[i].Name, scenario.VPCsFile))
+		}
+	}
+	diffArgs = append(diffArgs, st.Path(sides[0].Name, scenario.PlanFile), st.Path(sides[1].Name, scenario.PlanFile))
+	return PlanDiff(env, diffArgs)
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/server"
+)
+
+// Serve answers profile and plan requests over HTTP until interrupted,
+// letting requests in flight finish. Plans are bounded in body size,
+// endpoints and, per client, concurrency, so one client cannot take the
+// server down for everyone else. SIGHUP or POST /admin/reload loads the
+// catalog again, from the built-in profiles, the -profiles directory or
+// the -registry, and swaps it in once it validates; a catalog that does
+// not leaves the one being served in place. The reload then checks again
+// each -designs plan that uses a model it removed or changed. POST
+// /admin/reload takes $HNC_ADMIN_TOKEN as a bearer token, or, when that
+// is unset, is answered to this host only.
+func Serve(env Env, args []string) int {
+	flags := newFlags(env, "[-addr HOST:PORT] [flags]")
+	addr := flags.String("addr", "127.0.0.1:8080", "Address to listen on")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	registryURL := flags.String("registry", "", "URL of a profile registry to load the profiles from, answering the JSON array GET /profiles of hnc serve does (default: -profiles)")
+	designsDir := flags.String("designs", "", "Directory of plans, as written by hnc plan, to check again on each reload that removes or changes one of their models (default: none)")
+	allowOrigin := flags.String("allow-origin", "http://localhost:5173", "Origin the frontend is served from, for CORS; empty to send no CORS headers")
+	maxBody := flags.Int64("max-body", server.MaxRequestBytes, "Largest request body to accept, in bytes")
+	maxEndpoints := flags.Int("max-endpoints", server.MaxEndpoints, "Most endpoints a planned design may have")
+	maxConcurrent := flags.Int("max-concurrent", server.MaxConcurrent, "Most plans one client IP address may have computing at once")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if *maxBody <= 0 || *maxEndpoints <= 0 || *maxConcurrent <= 0 {
+		return env.fail(ExitUsage, "Error: -max-body, -max-endpoints and -max-concurrent must be positive")
+	}
+	if *registryURL != "" && *profilesDir != "" {
+		return env.fail(ExitUsage, "Error: -registry and -profiles are exclusive")
+	}
+
+	source, load := "the built-in profiles", func() (*profiles.Registry, error) { return loadRegistry(env, *profilesDir) }
+	switch {
+	case *registryURL != "":
+		source, load = *registryURL, func() (*profiles.Registry, error) { return fetchRegistry(*registryURL) }
+	case *profilesDir != "":
+		source = *profilesDir
+	}
+	registry, err := load()
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	opts := server.Options{
+		Build: buildVersion(), AllowOrigin: *allowOrigin, AdminToken: env.getenv("HNC_ADMIN_TOKEN"),
+		MaxRequestBytes: *maxBody, MaxEndpoints: *maxEndpoints, MaxConcurrent: *maxConcurrent,
+	}
+	// A stream on stdin is read once
+	if *profilesDir != "-" {
+		opts.Reload = load
+	}
+	if *designsDir != "" {
+		opts.Designs = func() (map[string]fabricplan.Plan, error) { return readDesigns(*designsDir) }
+		if _, err := opts.Designs(); err != nil {
+			return env.fail(inputExit(err), "Error %v", err)
+		}
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	handler := server.New(registry, opts)
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if opts.Reload != nil {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-hup:
+				}
+				reloaded, err := handler.Reload()
+				if err != nil {
+					env.warn("%v", err)
+					continue
+				}
+				env.info("Reloaded %d profiles from %s: %d added, %d removed, %d changed",
+					reloaded.Profiles, source, len(reloaded.Added), len(reloaded.Removed), len(reloaded.Changed))
+				for _, d := range reloaded.Designs {
+					for _, p := range d.Problems {
+						env.warn("Design %s no longer fits: %s", d.Design, p)
+					}
+				}
+			}
+		}()
+	}
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	env.info("Serving %d profiles on http://%s", len(registry.List()), listener.Addr())
+	if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	<-drained
+	return ExitOK
+}
+
+// registryTimeout bounds fetching the catalog from a -registry
+const registryTimeout = 30 * time.Second
+
+// fetchRegistry loads the catalog a profile registry serves at url
+func fetchRegistry(url string) (*profiles.Registry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(server.VersionHeader, strconv.Itoa(server.APIVersion))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	registry, err := profiles.ReadStream(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	return registry, nil
+}
+
+// readDesigns reads every plan in dir, by file name without .json
+func readDesigns(dir string) (map[string]fabricplan.Plan, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	designs := map[string]fabricplan.Plan{}
+	for _, file := range files {
+		plan, err := readPlan(file)
+		if err != nil {
+			return nil, err
+		}
+		designs[strings.TrimSuffix(filepath.Base(file), ".json")] = plan
+	}
+	return designs, nil
+}
@@ -0,0 +1,267 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// tuiHelp lists the hnc tui commands
+const tuiHelp = `Commands:
+  profiles [ROLE]  List the switch profiles, or those that play ROLE
+  profile MODEL    Show a switch profile
+  show             Show the design's parameters and preview its fabric
+  edit [PARAM]     Answer the hnc plan -interactive questions again, or only PARAM's
+  check            Run hnc plan's checks on the design without writing it
+  write [FILE]     Write the plan, as hnc plan -output FILE does
+  help             List these commands
+  quit             Leave; unwritten changes are lost
+`
+
+// TUI is a terminal session over a fabric plan for users working over SSH
+// without the web frontend: browse the profiles, edit the design's
+// high-level parameters with the hnc plan -interactive questions, and see
+// the validation results hnc plan gives, from hnc plan itself. Commands
+// are read a line at a time, so any terminal, or a script, can drive it.
+func TUI(env Env, args []string) int {
+	flags := newFlags(env, "[-plan FILE] [-profiles DIR]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan to edit and write; a new design when the file does not exist")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if *profilesDir == "-" {
+		return env.fail(ExitUsage, "Error: stdin carries the session's commands; give -profiles a directory")
+	}
+
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	// A new design starts from hnc plan's defaults
+	req := fabricplan.Request{Oversubscription: 3, MinSpines: 2, Redundancy: fabricplan.RedundancyNone, Topology: fabricplan.LeafSpine}
+	leafModel, spineModel, superSpineModel := "DS2000", "DS3000", ""
+	if plan, err := readPlan(*planFile); err == nil {
+		req, leafModel, spineModel, superSpineModel = plan.Request, plan.LeafModel, plan.SpineModel, plan.SuperSpineModel
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+
+	s := tuiSession{env: env, file: *planFile, profilesDir: *profilesDir, planWizard: planWizard{
+		in: bufio.NewScanner(env.Stdin), out: env.Stdout, registry: registry,
+		req: &req, leafModel: &leafModel, spineModel: &spineModel, output: *planFile, superSpineModel: superSpineModel,
+	}}
+	fmt.Fprintf(s.out, "Editing %s; type help for the commands.\n", s.file)
+	s.show()
+	for {
+		fmt.Fprint(s.out, "hnc> ")
+		if !s.in.Scan() {
+			fmt.Fprintln(s.out)
+			break
+		}
+		fields := strings.Fields(s.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		ok, err := s.run(fields[0], fields[1:])
+		if err != nil {
+			return env.fail(ExitIO, "Error reading commands: %v", err)
+		}
+		if !ok {
+			return ExitOK
+		}
+	}
+	if err := s.in.Err(); err != nil {
+		return env.fail(ExitIO, "Error reading commands: %v", err)
+	}
+	return ExitOK
+}
+
+// tuiSession is the design hnc tui edits, with the wizard that asks for
+// its parameters
+type tuiSession struct {
+	planWizard
+	env         Env
+	file        string
+	profilesDir string
+}
+
+// run runs one command; ok is false once the session is over
+func (s *tuiSession) run(cmd string, args []string) (ok bool, err error) {
+	switch cmd {
+	case "profiles":
+		s.listProfiles(args)
+	case "profile":
+		s.showProfile(args)
+	case "show":
+		s.show()
+	case "edit":
+		return s.edit(args)
+	case "check":
+		s.runPlan(s.file, "-dry-run")
+	case "write":
+		file := s.file
+		if len(args) > 0 {
+			file = args[0]
+		}
+		if s.runPlan(file) == ExitOK {
+			s.file = file
+		}
+	case "help", "?":
+		fmt.Fprint(s.out, tuiHelp)
+	case "quit", "exit":
+		return false, nil
+	default:
+		fmt.Fprintf(s.out, "Unknown command %q; type help for the commands.\n", cmd)
+	}
+	return true, nil
+}
+
+// listProfiles lists the profiles, those playing args[0] when given
+func (s *tuiSession) listProfiles(args []string) {
+	w := tabwriter.NewWriter(s.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tROLES\tSTATUS")
+	for _, p := range s.registry.List() {
+		if len(args) > 0 && !slices.Contains(p.Roles, args[0]) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.ModelID, strings.Join(p.Roles, ","), p.Status())
+	}
+	w.Flush()
+}
+
+// showProfile prints a profile as hnc profiles dump writes it
+func (s *tuiSession) showProfile(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(s.out, "Name one model, e.g. profile DS2000.")
+		return
+	}
+	p, ok := s.registry.Find(args[0])
+	if !ok {
+		fmt.Fprintf(s.out, "No profile for %s.\n", args[0])
+		return
+	}
+	data, err := profiles.Marshal(p)
+	if err != nil {
+		fmt.Fprintf(s.out, "Cannot show %s: %v\n", p.ModelID, err)
+		return
+	}
+	s.out.Write(data)
+	if r := p.Retirement(); r != "" {
+		fmt.Fprintf(s.out, "Note: %s\n", r)
+	}
+}
+
+// show prints the parameters the wizard asks for and the fabric they plan
+func (s *tuiSession) show() {
+	endpoints := strconv.Itoa(s.req.Endpoints)
+	if len(s.req.Classes) > 0 {
+		endpoints = classList(s.req.Classes)
+	}
+	speed := "leaf speed"
+	if s.req.EndpointSpeedGbps > 0 {
+		speed = strconv.Itoa(s.req.EndpointSpeedGbps)
+	}
+	w := tabwriter.NewWriter(s.out, 0, 4, 2, ' ', 0)
+	for _, row := range [][2]string{
+		{"topology", fallback(s.req.Topology, fabricplan.LeafSpine)}, {"endpoints", endpoints}, {"speed", speed},
+		{"redundancy", fallback(s.req.Redundancy, fabricplan.RedundancyNone)},
+		{"oversubscription", strconv.FormatFloat(s.req.Oversubscription, 'g', -1, 64)},
+		{"leaf", *s.leafModel}, {"spine", fallback(*s.spineModel, "none")}, {"min-spines", strconv.Itoa(s.req.MinSpines)},
+	} {
+		fmt.Fprintf(w, "  %s\t%s\n", row[0], row[1])
+	}
+	w.Flush()
+	s.preview()
+}
+
+// edit asks the wizard's questions, or only those for the parameters
+// named in args; ok is false when the input ended
+func (s *tuiSession) edit(args []string) (ok bool, err error) {
+	var params []string
+	for _, step := range s.steps() {
+		params = append(params, step.param)
+	}
+	for _, arg := range args {
+		if !slices.Contains(params, arg) {
+			fmt.Fprintf(s.out, "No parameter %q; want %s.\n", arg, strings.Join(params, ", "))
+			return true, nil
+		}
+	}
+	fmt.Fprintln(s.out, "Press Enter to keep the value in brackets.")
+	for _, step := range s.steps() {
+		if len(args) > 0 && !slices.Contains(args, step.param) {
+			continue
+		}
+		if ok, err := step.ask(); !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// runPlan runs hnc plan on the design with extra flags, reporting to the
+// session as it does on the command line
+func (s *tuiSession) runPlan(file string, extra ...string) int {
+	env := s.env
+	env.Prog, env.Stderr, env.log, env.errFormat = "hnc plan", s.out, nil, nil
+	args := append(planArgs(s.planWizard, s.profilesDir), "-output", file)
+	return Main(env, Command{Name: "plan", Run: Plan, Mutates: true}, append(args, extra...))
+}
+
+// planArgs are the hnc plan flags that plan what the wizard holds
+func planArgs(w planWizard, profilesDir string) []string {
+	req := w.req
+	var args []string
+	if len(req.Classes) > 0 {
+		args = append(args, "-endpoint-classes", classList(req.Classes))
+	} else {
+		args = append(args, "-endpoints", strconv.Itoa(req.Endpoints))
+		if req.EndpointSpeedGbps > 0 {
+			args = append(args, "-endpoint-speed", strconv.Itoa(req.EndpointSpeedGbps))
+		}
+	}
+	args = append(args, "-topology", fallback(req.Topology, fabricplan.LeafSpine), "-leaf", *w.leafModel,
+		"-oversubscription", strconv.FormatFloat(req.Oversubscription, 'g', -1, 64))
+	if !w.spineless() {
+		args = append(args, "-spine", *w.spineModel, "-min-spines", strconv.Itoa(req.MinSpines))
+	}
+	for _, f := range []struct{ name, value string }{
+		{"redundancy", req.Redundancy}, {"breakout", req.Breakout}, {"workload", req.Workload}, {"profiles", profilesDir},
+	} {
+		if f.value != "" {
+			args = append(args, "-"+f.name, f.value)
+		}
+	}
+	for _, f := range []struct {
+		name  string
+		value int
+	}{{"peer-links", req.PeerLinks}, {"pods", req.Pods}} {
+		if f.value != 0 {
+			args = append(args, "-"+f.name, strconv.Itoa(f.value))
+		}
+	}
+	if req.Pods > 1 {
+		if w.superSpineModel != "" {
+			args = append(args, "-super-spine", w.superSpineModel)
+		}
+		if req.MinSuperSpines != 0 {
+			args = append(args, "-min-super-spines", strconv.Itoa(req.MinSuperSpines))
+		}
+		if req.PodOversubscription != 0 {
+			args = append(args, "-pod-oversubscription", strconv.FormatFloat(req.PodOversubscription, 'g', -1, 64))
+		}
+	}
+	if e := req.External; e != nil {
+		args = append(args, "-external-ports", strconv.Itoa(e.PortsPerLeaf), "-border-leaves", strconv.Itoa(e.BorderLeaves), "-external-peers", strconv.Itoa(e.Peers))
+	}
+	return args
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/inventory"
+	"github.com/hnc/profile-dump/pkg/provenance"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+// VPCs allocates VLANs, VNIs and subnets for tenant VPCs and attaches the
+// endpoints of a fabric plan to them, writing vpc-plan.json once the
+// plan's switch models are known to route VXLAN and hold the subnets
+func VPCs(env Env, args []string) int {
+	var req vpcplan.Request
+	flags := newFlags(env, "-vpcs N [flags]")
+	planFile := flags.String("plan", "fabric-plan.json", "Fabric plan written by hnc plan")
+	flags.IntVar(&req.VPCs, "vpcs", 0, "Number of tenant VPCs (required)")
+	subnets := flags.String("subnets", "24", "Comma-separated prefix lengths of the subnets each VPC gets, e.g. 24,26")
+	vlans := flags.String("vlans", vpcplan.DefaultVLANs.String(), "VLAN ID pool, FIRST-LAST")
+	vnis := flags.String("vnis", vpcplan.DefaultVNIs.String(), "VNI pool, FIRST-LAST")
+	flags.StringVar(&req.IPv4Pool, "ipv4-pool", vpcplan.DefaultIPv4Pool, "IPv4 CIDR the subnets are carved from")
+	flags.Int64Var(&req.Seed, "seed", 0, "Shuffle the order VPCs take pool values in with this seed; the same seed reproduces the plan (default: unseeded)")
+	inventoryFile := flags.String("inventory", "", inventoryUsage+", to attach each server in place of the plan's numbered endpoints; plan it with hnc plan -inventory")
+	profilesDir := flags.String("profiles", "", profilesUsage)
+	outputFile := flags.String("output", "vpc-plan.json", "Output file for the VPC plan")
+	formatVersion := formatVersionFlag(flags, "vpc-plan-json")
+	if err := flags.Parse(args); err != nil {
+		return env.parseExit(flags, err)
+	}
+	if code := env.checkFormatVersion("vpc-plan-json", *formatVersion); code != ExitOK {
+		return code
+	}
+	if req.VPCs <= 0 {
+		env.fail(ExitUsage, "Error: -vpcs is required")
+		flags.Usage()
+		return ExitUsage
+	}
+	for _, s := range splitList(*subnets) {
+		prefix, err := strconv.Atoi(strings.TrimPrefix(s, "/"))
+		if err != nil {
+			return env.fail(ExitUsage, "Error: bad -subnets prefix length %q", s)
+		}
+		req.SubnetPrefixes = append(req.SubnetPrefixes, prefix)
+	}
+	var err error
+	if req.VLANs, err = vpcplan.ParseRange(*vlans); err != nil {
+		return env.fail(ExitUsage, "Error: -vlans: %v", err)
+	}
+	if req.VNIs, err = vpcplan.ParseRange(*vnis); err != nil {
+		return env.fail(ExitUsage, "Error: -vnis: %v", err)
+	}
+
+	plan, err := readPlan(*planFile)
+	if err != nil {
+		return env.failAt(*planFile, inputExit(err), "Error %v", err)
+	}
+	registry, err := loadRegistry(env, *profilesDir)
+	if err != nil {
+		return env.fail(inputExit(err), "Error loading profiles: %v", err)
+	}
+	done := env.recorder().Stage("vpcs")
+	var vpcs vpcplan.Plan
+	if *inventoryFile != "" {
+		inv, err := readInventory(*inventoryFile)
+		if err != nil {
+			return env.failAt(*inventoryFile, inputExit(err), "Error %v", err)
+		}
+		leaf, _, err := findModels(registry, plan.LeafModel, "")
+		if err != nil {
+			return env.fail(ExitValidation, "Error: %v", err)
+		}
+		_, endpoints, err := inventory.Assign(inv, plan, leaf)
+		if err != nil {
+			return env.failAt(*inventoryFile, ExitValidation, "Error: %s: %v", *inventoryFile, err)
+		}
+		vpcs, err = vpcplan.ComputeEndpoints(req, plan, endpoints)
+	} else {
+		vpcs, err = vpcplan.Compute(req, plan)
+	}
+	done()
+	env.recorder().Plan(plan)
+	if err != nil {
+		return env.fail(ExitFailure, "Error: %v", err)
+	}
+	if code := checkConstraints(env, registry, plan, &vpcs); code != ExitOK {
+		return code
+	}
+	vpcs.Generator = provenance.New(env.Prog, buildVersion())
+	data, err := canonjson.Marshal(vpcs)
+	if err != nil {
+		return env.fail(ExitFailure, "Error encoding VPC plan: %v", err)
+	}
+	env.record("allocate", "%d VPCs, %d subnets, %d attachments", len(vpcs.VPCs), len(vpcs.VPCs)*len(vpcs.Request.SubnetPrefixes), len(vpcs.Attachments))
+	if code := env.writeFile(*outputFile, data); code != ExitOK {
+		return code
+	}
+	env.info("Allocated %d VPCs with %d subnet(s) each and attached %d endpoints",
+		len(vpcs.VPCs), len(vpcs.Request.SubnetPrefixes), len(vpcs.Attachments))
+	return ExitOK
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io/fs"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hnc/profile-dump/pkg/textdiff"
+)
+
+// watchInterval is how often -watch looks at its inputs. watchDebounce is
+// how long they must then stay unchanged before the outputs are
+// regenerated, so an editor saving a file in several writes, or a
+// checkout touching many, regenerates once.
+const (
+	watchInterval = 250 * time.Millisecond
+	watchDebounce = 500 * time.Millisecond
+)
+
+// watchFlag adds -watch to a command that regenerates files
+func watchFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("watch", false, "Keep running, and regenerate the outputs whenever an input file changes, printing which outputs changed")
+}
+
+// watch runs the command again, without -watch, each time a file under
+// inputs changes, until interrupted, and reports how the files under
+// outputs changed
+func (env Env) watch(inputs, outputs []string, run func(Env, []string) int, args []string) int {
+	if env.dryRunning() {
+		return env.fail(ExitUsage, "Error: -watch writes files as they change; it cannot be used with -dry-run")
+	}
+	var rest []string
+	for _, a := range args {
+		if name := strings.TrimLeft(a, "-"); name != "watch" && !strings.HasPrefix(name, "watch=") {
+			rest = append(rest, a)
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return env.watchLoop(ctx, inputs, outputs, watchInterval, watchDebounce, func() int { return run(env, rest) })
+}
+
+// watchLoop runs run, then again once a change to inputs has settled for
+// debounce, until ctx is done. A run that fails leaves the loop waiting
+// for the next change.
+func (env Env) watchLoop(ctx context.Context, inputs, outputs []string, interval, debounce time.Duration, run func() int) int {
+	run()
+	env.info("Watching %s for changes; interrupt to stop", strings.Join(inputs, ", "))
+	last := fileStates(inputs)
+	var changed time.Time
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ExitOK
+		case <-ticker.C:
+		}
+		if now := fileStates(inputs); !maps.Equal(now, last) {
+			last, changed = now, time.Now()
+			continue
+		}
+		if changed.IsZero() || time.Since(changed) < debounce {
+			continue
+		}
+		changed = time.Time{}
+		before := fileContents(outputs)
+		if code := run(); code != ExitOK {
+			env.warn("Regenerating failed (exit %d); waiting for the next change", code)
+			continue
+		}
+		env.reportChanges(before, fileContents(outputs))
+	}
+}
+
+// fileState is what watch compares to notice a changed file
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// fileStates is the state of every file under paths; missing paths are
+// left out, so creating one is a change
+func fileStates(paths []string) map[string]fileState {
+	states := map[string]fileState{}
+	for _, root := range paths {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				states[path] = fileState{info.Size(), info.ModTime()}
+			}
+			return nil
+		})
+	}
+	return states
+}
+
+// fileContents reads every file under paths
+func fileContents(paths []string) map[string]string {
+	contents := map[string]string{}
+	for _, root := range paths {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if data, err := os.ReadFile(path); err == nil {
+				contents[path] = string(data)
+			}
+			return nil
+		})
+	}
+	return contents
+}
+
+// reportChanges prints one line per output that a rerun created, changed
+// or removed, with the lines added and removed from text files
+func (env Env) reportChanges(before, after map[string]string) {
+	var paths []string
+	for path := range before {
+		paths = append(paths, path)
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	n := 0
+	for _, path := range paths {
+		a, wasThere := before[path]
+		b, isThere := after[path]
+		if a == b && wasThere == isThere {
+			continue
+		}
+		n++
+		action := "Changed"
+		switch {
+		case !wasThere:
+			action = "Created"
+		case !isThere:
+			action = "Removed"
+		}
+		if !utf8.ValidString(a) || !utf8.ValidString(b) {
+			env.info("%s %s", action, path)
+			continue
+		}
+		added, removed := textdiff.Stat(a, b)
+		env.info("%s %s (+%d -%d lines)", action, path, added, removed)
+	}
+	if n == 0 {
+		env.info("Regenerated; no output changed")
+	}
+}
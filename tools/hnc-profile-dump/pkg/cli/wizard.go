@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// planWizard is hnc plan -interactive: it asks for the choices the plan
+// flags make, one at a time with the flag's value as the default, and
+// previews the fabric after every answer. Questions go to stderr so a
+// plan written to stdout stays clean.
+type planWizard struct {
+	in       *bufio.Scanner
+	out      io.Writer
+	registry *profiles.Registry
+
+	req                   *fabricplan.Request
+	leafModel, spineModel *string
+	output                string
+	superSpineModel       string
+}
+
+// run asks every question and for confirmation; ok is false when the
+// user declined to write the plan or the input ended first. A plan the
+// answers cannot make is hnc plan's to report, as for flags.
+func (w *planWizard) run() (ok bool, err error) {
+	fmt.Fprintln(w.out, "Sizing a fabric; press Enter to keep the value in brackets.")
+	for _, step := range w.steps() {
+		if ok, err := step.ask(); !ok || err != nil {
+			return false, err
+		}
+	}
+	for {
+		answer, ok := w.ask("Write the plan to "+w.output+"? (y, n)", "y")
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		if !ok {
+			return false, w.in.Err()
+		}
+	}
+}
+
+// wizardStep is one question, by the parameter its answer sets
+type wizardStep struct {
+	param string
+	ask   func() (bool, error)
+}
+
+// steps are the questions in the order run asks them
+func (w *planWizard) steps() []wizardStep {
+	return []wizardStep{
+		{"topology", w.askTopology}, {"endpoints", w.askEndpoints}, {"speed", w.askSpeed}, {"redundancy", w.askRedundancy},
+		{"oversubscription", w.askOversubscription}, {"leaf", w.askLeaf}, {"spine", w.askSpine}, {"min-spines", w.askMinSpines},
+	}
+}
+
+// ask prompts for one answer, def when the user just presses Enter; ok
+// is false at the end of the input
+func (w *planWizard) ask(prompt, def string) (answer string, ok bool) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", prompt, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", prompt)
+	}
+	if !w.in.Scan() {
+		fmt.Fprintln(w.out)
+		return "", false
+	}
+	if answer = strings.TrimSpace(w.in.Text()); answer == "" {
+		answer = def
+	}
+	return answer, true
+}
+
+// askUntil asks until set accepts the answer, then previews the plan
+func (w *planWizard) askUntil(prompt, def string, set func(string) error) (bool, error) {
+	for {
+		answer, ok := w.ask(prompt, def)
+		if !ok {
+			return false, w.in.Err()
+		}
+		if err := set(answer); err != nil {
+			fmt.Fprintf(w.out, "  %v\n", err)
+			continue
+		}
+		w.preview()
+		return true, nil
+	}
+}
+
+func (w *planWizard) spineless() bool {
+	return w.req.Topology != fabricplan.LeafSpine && w.req.Topology != ""
+}
+
+func (w *planWizard) askTopology() (bool, error) {
+	return w.askUntil("Topology ("+strings.Join(fabricplan.Topologies, ", ")+")", fallback(w.req.Topology, fabricplan.LeafSpine), func(s string) error {
+		if !slices.Contains(fabricplan.Topologies, s) {
+			return fmt.Errorf("want %s", strings.Join(fabricplan.Topologies, ", "))
+		}
+		w.req.Topology = s
+		return nil
+	})
+}
+
+func (w *planWizard) askEndpoints() (bool, error) {
+	def := ""
+	if len(w.req.Classes) > 0 {
+		def = classList(w.req.Classes)
+	} else if w.req.Endpoints > 0 {
+		def = strconv.Itoa(w.req.Endpoints)
+	}
+	return w.askUntil("Endpoints, a port count or mixed speeds as COUNTxSPEED,... (e.g. 96 or 40x25G,16x100G)", def, func(s string) error {
+		if s == "" {
+			return fmt.Errorf("want an endpoint count")
+		}
+		if n, err := strconv.Atoi(s); err == nil {
+			if n <= 0 {
+				return fmt.Errorf("want a positive endpoint count")
+			}
+			w.req.Endpoints, w.req.Classes = n, nil
+			return nil
+		}
+		classes, err := fabricplan.ParseClasses(s)
+		if err != nil {
+			return err
+		}
+		w.req.Endpoints, w.req.Classes = 0, classes
+		return nil
+	})
+}
+
+func (w *planWizard) askSpeed() (bool, error) {
+	if len(w.req.Classes) > 0 {
+		return true, nil
+	}
+	def := "leaf speed"
+	if w.req.EndpointSpeedGbps > 0 {
+		def = strconv.Itoa(w.req.EndpointSpeedGbps)
+	}
+	return w.askUntil("Endpoint speed in Gbps", def, func(s string) error {
+		if s == "leaf speed" {
+			w.req.EndpointSpeedGbps = 0
+			return nil
+		}
+		g, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(s, "G"), "g"))
+		if err != nil || g <= 0 {
+			return fmt.Errorf("want a speed in Gbps, e.g. 25")
+		}
+		w.req.EndpointSpeedGbps = g
+		return nil
+	})
+}
+
+func (w *planWizard) askRedundancy() (bool, error) {
+	if w.req.Topology == fabricplan.SingleSwitch {
+		return true, nil
+	}
+	return w.askUntil("Leaf redundancy ("+strings.Join(fabricplan.Redundancies, ", ")+")", fallback(w.req.Redundancy, fabricplan.RedundancyNone), func(s string) error {
+		if !slices.Contains(fabricplan.Redundancies, s) {
+			return fmt.Errorf("want %s", strings.Join(fabricplan.Redundancies, ", "))
+		}
+		w.req.Redundancy = s
+		return nil
+	})
+}
+
+func (w *planWizard) askOversubscription() (bool, error) {
+	if w.spineless() {
+		return true, nil
+	}
+	return w.askUntil("Oversubscription, endpoint:uplink bandwidth (e.g. 3 for 3:1)", strconv.FormatFloat(w.req.Oversubscription, 'g', -1, 64), func(s string) error {
+		r, err := strconv.ParseFloat(strings.TrimSuffix(s, ":1"), 64)
+		if err != nil || r <= 0 {
+			return fmt.Errorf("want a positive ratio, e.g. 3 or 1.5")
+		}
+		w.req.Oversubscription = r
+		return nil
+	})
+}
+
+// askModel asks for a switch model the profiles have, listing those that
+// play role
+func (w *planWizard) askModel(prompt, role string, model *string) (bool, error) {
+	var names []string
+	for _, p := range w.registry.List() {
+		if slices.Contains(p.Roles, role) {
+			names = append(names, p.ModelID)
+		}
+	}
+	return w.askUntil(prompt+" ("+strings.Join(names, ", ")+")", *model, func(s string) error {
+		p, ok := w.registry.Find(s)
+		if !ok {
+			return fmt.Errorf("no profile for %s", s)
+		}
+		if !slices.Contains(p.Roles, role) {
+			return fmt.Errorf("%s does not list the %s role", p.ModelID, role)
+		}
+		*model = s
+		return nil
+	})
+}
+
+func (w *planWizard) askLeaf() (bool, error) {
+	return w.askModel("Leaf model", profiles.RoleLeaf, w.leafModel)
+}
+
+func (w *planWizard) askSpine() (bool, error) {
+	if w.spineless() {
+		*w.spineModel = ""
+		return true, nil
+	}
+	return w.askModel("Spine model", profiles.RoleSpine, w.spineModel)
+}
+
+func (w *planWizard) askMinSpines() (bool, error) {
+	if w.spineless() {
+		return true, nil
+	}
+	return w.askUntil("Fewest spines", strconv.Itoa(w.req.MinSpines), func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return fmt.Errorf("want a spine count from 1")
+		}
+		w.req.MinSpines = n
+		return nil
+	})
+}
+
+// plan computes the fabric for the answers so far
+func (w *planWizard) plan() (fabricplan.Plan, error) {
+	leaf, spine, err := findModels(w.registry, *w.leafModel, *w.spineModel)
+	if err != nil {
+		return fabricplan.Plan{}, err
+	}
+	if w.req.Pods <= 1 {
+		return fabricplan.Compute(*w.req, leaf, spine)
+	}
+	superSpine, ok := w.registry.Find(fallback(w.superSpineModel, *w.spineModel))
+	if !ok {
+		return fabricplan.Plan{}, fmt.Errorf("no profile for super-spine model %s", fallback(w.superSpineModel, *w.spineModel))
+	}
+	return fabricplan.ComputePods(*w.req, leaf, spine, superSpine.InRole(profiles.RoleSpine))
+}
+
+// preview shows the fabric the answers so far plan, once there are
+// endpoints to plan for
+func (w *planWizard) preview() {
+	if w.req.Endpoints <= 0 && len(w.req.Classes) == 0 {
+		return
+	}
+	if w.spineless() {
+		*w.spineModel = ""
+	}
+	plan, err := w.plan()
+	if err != nil {
+		fmt.Fprintf(w.out, "  preview: cannot plan this: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w.out, "  preview: %s\n", describePlan(plan))
+}
+
+// describePlan is a plan's topology in a line
+func describePlan(plan fabricplan.Plan) string {
+	if plan.Spines == 0 {
+		return fmt.Sprintf("%s: %d x %s, %d endpoints per leaf", plan.Topology(), plan.Leaves, plan.LeafModel, plan.EndpointsPerLeaf)
+	}
+	s := fmt.Sprintf("%d x %s leaves, %d x %s spines, %d uplinks per leaf (%.2f:1)",
+		plan.Leaves, plan.LeafModel, plan.Spines, plan.SpineModel, plan.UplinksPerLeaf, plan.AchievedOversubscription)
+	if plan.LeafPairs > 0 {
+		s += fmt.Sprintf(", %d %s pairs", plan.LeafPairs, plan.Request.Redundancy)
+	}
+	if plan.Pods > 1 {
+		s += fmt.Sprintf(", %d pods under %d x %s super-spines", plan.Pods, plan.SuperSpines, plan.SuperSpineModel)
+	}
+	return s
+}
+
+// classList is endpoint classes as -endpoint-classes takes them, e.g.
+// 40x25G,16x100G
+func classList(classes []fabricplan.EndpointClass) string {
+	var parts []string
+	for _, c := range classes {
+		parts = append(parts, c.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// fallback is s, or def when s is empty
+func fallback(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+// Package constraints checks a design against what its switch models can
+// do: the routes, MAC addresses and VXLAN tunnels each switch has to hold,
+// and the features it has to support, against the capabilities in their
+// profiles. Demand is estimated for the worst case the design allows, so
+// a design that passes fits however its VPCs are spread over the leaves.
+// Profiles without capabilities are not checked. The fabric links between
+// leaves and spines are checked too, so a plan written before its models
+// were found not to link is caught.
+package constraints
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/capacity"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+// Demand is what a design asks of every switch in a role
+type Demand struct {
+	Role         string `json:"role"`
+	Routes       int    `json:"routes"`       // loopbacks, fabric links and, on leaves, VPC subnets
+	MACs         int    `json:"macs"`         // every endpoint, as a VPC can stretch over every leaf
+	VXLANTunnels int    `json:"vxlanTunnels"` // one to every other VTEP
+	VXLANRouting bool   `json:"vxlanRouting"` // leaves routing between VPC subnets
+	RoCE         bool   `json:"roce"`
+}
+
+// Demands is what the plan, with its VPC plan when there is one, asks of
+// each leaf and, when it has them, each spine. Every switch carries the
+// underlay routes: the loopback of every switch and every fabric link.
+func Demands(plan fabricplan.Plan, vpcs *vpcplan.Plan) []Demand {
+	switches := plan.Leaves + plan.Spines + plan.SuperSpines
+	links := plan.Leaves*plan.UplinksPerLeaf + plan.Spines*plan.SpineUplinks
+	subnets := 0
+	if vpcs != nil {
+		for _, vpc := range vpcs.VPCs {
+			subnets += len(vpc.Subnets)
+		}
+	}
+	// An MCLAG pair shares one VTEP address
+	vteps := plan.Leaves
+	if plan.Request.Redundancy == fabricplan.MCLAG {
+		vteps -= plan.LeafPairs
+	}
+	roce := plan.Request.Workload == fabricplan.RoCE
+	leaf := Demand{
+		Role:         profiles.RoleLeaf,
+		Routes:       switches + links + subnets,
+		MACs:         plan.Request.Endpoints,
+		VXLANTunnels: max(vteps-1, 0),
+		VXLANRouting: vpcs != nil && len(vpcs.VPCs) > 0,
+		RoCE:         roce,
+	}
+	if plan.Spines == 0 {
+		return []Demand{leaf}
+	}
+	return []Demand{leaf, {Role: profiles.RoleSpine, Routes: switches + links, RoCE: roce}}
+}
+
+// Check reports every way the leaf and spine models fall short of what
+// the plan asks of them, their fabric ports not linking among them; nil
+// means they fit or have no capabilities to check
+func Check(plan fabricplan.Plan, vpcs *vpcplan.Plan, leaf, spine profiles.SwitchProfile) []string {
+	var problems []string
+	if plan.Spines > 0 {
+		if err := capacity.CheckLink(leaf, profiles.RoleLeaf, spine, profiles.RoleSpine, plan.Request.Breakout); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for _, d := range Demands(plan, vpcs) {
+		p := leaf
+		if d.Role == profiles.RoleSpine {
+			p = spine
+		}
+		c := p.Capabilities
+		if c == nil {
+			continue
+		}
+		who := fmt.Sprintf("%s %s (%s)", d.Role, p.ModelID, c.ASIC)
+		for _, t := range []struct {
+			what       string
+			need, have int
+		}{
+			{"routes", d.Routes, c.Tables.Routes},
+			{"MAC addresses", d.MACs, c.Tables.MACs},
+			{"VXLAN tunnels", d.VXLANTunnels, c.Tables.VXLANTunnels},
+		} {
+			if t.have > 0 && t.need > t.have {
+				problems = append(problems, fmt.Sprintf("%s holds %d %s, the design needs %d", who, t.have, t.what, t.need))
+			}
+		}
+		var missing []string
+		if d.VXLANRouting && !c.Features.VXLANRouting {
+			missing = append(missing, "VXLAN routing, for VPCs")
+		}
+		if d.RoCE && !c.Features.RoCE {
+			missing = append(missing, "lossless RoCE transport")
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("%s does not support %s", who, strings.Join(missing, " or ")))
+		}
+	}
+	return problems
+}
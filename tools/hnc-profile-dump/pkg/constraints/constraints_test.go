@@ -0,0 +1,81 @@
+package constraints
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+func fabric(t *testing.T, req fabricplan.Request) fabricplan.Plan {
+	t.Helper()
+	plan, err := fabricplan.Compute(req, profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestDemands(t *testing.T) {
+	// 3 leaves with 3 uplinks each to 3 spines
+	plan := fabric(t, fabricplan.Request{Endpoints: 100, Oversubscription: 3})
+	vpcs, err := vpcplan.Compute(vpcplan.Request{VPCs: 2, SubnetPrefixes: []int{24, 27}}, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Demand{
+		{Role: profiles.RoleLeaf, Routes: 6 + 9 + 4, MACs: 100, VXLANTunnels: 2, VXLANRouting: true},
+		{Role: profiles.RoleSpine, Routes: 6 + 9},
+	}
+	if got := Demands(plan, &vpcs); !reflect.DeepEqual(got, want) {
+		t.Errorf("Demands() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// 10 MCLAG leaves are 5 VTEPs
+	mclag := fabric(t, fabricplan.Request{Endpoints: 200, Oversubscription: 3, Redundancy: fabricplan.MCLAG})
+	mclag.Request.Workload = fabricplan.RoCE
+	if d := Demands(mclag, nil); d[0].VXLANTunnels != 4 || d[0].VXLANRouting || !d[0].RoCE || !d[1].RoCE {
+		t.Errorf("MCLAG Demands() = %+v", d)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// A roce plan of switches later found not to be lossless
+	plan := fabric(t, fabricplan.Request{Endpoints: 100, Oversubscription: 3})
+	plan.Request.Workload = fabricplan.RoCE
+	vpcs, err := vpcplan.Compute(vpcplan.Request{VPCs: 2, SubnetPrefixes: []int{24}}, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, spine := profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine)
+	if problems := Check(plan, &vpcs, leaf, spine); problems != nil {
+		t.Errorf("profiles without capabilities: %q", problems)
+	}
+
+	leaf.Capabilities = &profiles.Capabilities{ASIC: "trident3", Tables: profiles.Tables{Routes: 16, MACs: 64}, Features: profiles.Features{RoCE: true}}
+	spine.Capabilities = &profiles.Capabilities{ASIC: "trident3", Tables: profiles.Tables{Routes: 16000}, Features: profiles.Features{RoCE: true}}
+	want := []string{
+		"leaf celestica-ds2000 (trident3) holds 16 routes, the design needs 17",
+		"leaf celestica-ds2000 (trident3) holds 64 MAC addresses, the design needs 100",
+		"leaf celestica-ds2000 (trident3) does not support VXLAN routing, for VPCs",
+	}
+	if got := Check(plan, &vpcs, leaf, spine); !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() =\n%q\nwant\n%q", got, want)
+	}
+
+	leaf.Capabilities = &profiles.Capabilities{ASIC: "trident3", Features: profiles.Features{VXLANRouting: true, RoCE: true}}
+	spine.Capabilities.Features.RoCE = false
+	want = []string{"spine celestica-ds3000 (trident3) does not support lossless RoCE transport"}
+	if got := Check(plan, &vpcs, leaf, spine); !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() =\n%q\nwant\n%q", got, want)
+	}
+
+	// A plan whose spine model cannot take the leaf uplinks
+	ds4000, _ := profiles.Default().Get("celestica-ds4000")
+	if got := Check(plan, nil, profiles.DS2000(), ds4000); len(got) != 1 || !strings.Contains(got[0], "cannot link to spine celestica-ds4000") {
+		t.Errorf("Check() of DS4000 spines = %q", got)
+	}
+}
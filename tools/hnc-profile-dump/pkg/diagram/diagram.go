@@ -0,0 +1,220 @@
+// Package diagram draws a fabric's cabling as GraphViz DOT or Mermaid
+// flowchart text for design documents: super-spines, spines and leaves
+// grouped in rows,
+// each link labeled with its speed and the ports at either end.
+package diagram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Formats lists the supported output formats, default first
+var Formats = []string{"dot", "mermaid"}
+
+// Link kinds
+const (
+	Uplink = "uplink" // leaf to spine
+	Pod    = "pod"    // spine to super-spine, in a multi-pod plan
+	Peer   = "peer"   // MCLAG peer link between the leaves of a pair
+)
+
+// Switch is one node of the diagram
+type Switch struct {
+	Name  string
+	Model string
+}
+
+// Link is an edge of the diagram: one cable or, bundled, every cable
+// between the same two switches. From is drawn above To, so uplinks run
+// from the spine down to the leaf.
+type Link struct {
+	Kind      string
+	From      string
+	FromPorts []string
+	To        string
+	ToPorts   []string
+	SpeedGbps int // of each cable
+}
+
+// Graph is a fabric as the diagram draws it
+type Graph struct {
+	Title       string
+	SuperSpines []Switch
+	Spines      []Switch
+	Leaves      []Switch
+	Links       []Link
+}
+
+// FromCabling builds the graph of a plan's cabling map. Link speeds come
+// from the profiles: the uplink port speed, or the lane speed of the
+// plan's breakout; peer links and spine uplinks use whole ports.
+func FromCabling(plan fabricplan.Plan, m cabling.Map, leaf, spine profiles.SwitchProfile) (Graph, error) {
+	if m.LeafModel != plan.LeafModel || m.SpineModel != plan.SpineModel {
+		return Graph{}, fmt.Errorf("cabling map is for leaf %s and spine %s, the plan for %s and %s",
+			m.LeafModel, m.SpineModel, plan.LeafModel, plan.SpineModel)
+	}
+	speed := leaf.Profiles.Uplink.SpeedGbps
+	if plan.Request.Breakout != "" {
+		split, ok := leaf.Profiles.Uplink.Breakout(plan.Request.Breakout)
+		if !ok {
+			return Graph{}, fmt.Errorf("%s fabric ports do not support breakout %s", leaf.ModelID, plan.Request.Breakout)
+		}
+		speed = split.SpeedGbps
+	}
+
+	g := Graph{Title: fmt.Sprintf("%d leaves, %d spines", plan.Leaves, plan.Spines)}
+	if plan.Spines == 0 {
+		g.Title = fmt.Sprintf("%d leaves, %s", plan.Leaves, plan.Topology())
+	}
+	if plan.Pods > 1 {
+		g.Title = fmt.Sprintf("%d pods, %d leaves, %d spines, %d super-spines", plan.Pods, plan.Leaves, plan.Spines, plan.SuperSpines)
+	}
+	for i := 1; i <= plan.SuperSpines; i++ {
+		g.SuperSpines = append(g.SuperSpines, Switch{Name: "superspine" + strconv.Itoa(i), Model: plan.SuperSpineModel})
+	}
+	for i := 1; i <= plan.Spines; i++ {
+		g.Spines = append(g.Spines, Switch{Name: "spine" + strconv.Itoa(i), Model: spine.ModelID})
+	}
+	for i := 1; i <= plan.Leaves; i++ {
+		g.Leaves = append(g.Leaves, Switch{Name: "leaf" + strconv.Itoa(i), Model: leaf.ModelID})
+	}
+	for _, c := range m.Cables {
+		g.Links = append(g.Links, Link{Kind: Uplink, From: c.Spine, FromPorts: []string{c.SpinePort}, To: c.Leaf, ToPorts: []string{c.LeafPort}, SpeedGbps: speed})
+	}
+	for _, l := range m.SpineLinks {
+		g.Links = append(g.Links, Link{Kind: Pod, From: l.SuperSpine, FromPorts: []string{l.SuperSpinePort}, To: l.Spine, ToPorts: []string{l.SpinePort}, SpeedGbps: spine.Profiles.Uplink.SpeedGbps})
+	}
+	for _, p := range m.PeerLinks {
+		g.Links = append(g.Links, Link{Kind: Peer, From: p.Leaf, FromPorts: []string{p.LeafPort}, To: p.Peer, ToPorts: []string{p.PeerPort}, SpeedGbps: leaf.Profiles.Uplink.SpeedGbps})
+	}
+	return g, nil
+}
+
+// Bundle merges the links between each pair of switches into one edge,
+// keeping their ports in order, so large fabrics stay readable
+func Bundle(g Graph) Graph {
+	type pair struct {
+		kind, from, to string
+		speed          int
+	}
+	index := map[pair]int{}
+	var links []Link
+	for _, l := range g.Links {
+		k := pair{l.Kind, l.From, l.To, l.SpeedGbps}
+		i, ok := index[k]
+		if !ok {
+			i = len(links)
+			index[k] = i
+			links = append(links, Link{Kind: l.Kind, From: l.From, To: l.To, SpeedGbps: l.SpeedGbps})
+		}
+		links[i].FromPorts = append(links[i].FromPorts, l.FromPorts...)
+		links[i].ToPorts = append(links[i].ToPorts, l.ToPorts...)
+	}
+	g.Links = links
+	return g
+}
+
+// Speed is the link's label: 100G, or 2x100G for a bundle
+func (l Link) Speed() string {
+	if n := len(l.FromPorts); n > 1 {
+		return fmt.Sprintf("%dx%dG", n, l.SpeedGbps)
+	}
+	return strconv.Itoa(l.SpeedGbps) + "G"
+}
+
+// Render draws the graph in one of Formats
+func Render(g Graph, format string) (string, error) {
+	switch format {
+	case "dot":
+		return DOT(g), nil
+	case "mermaid":
+		return Mermaid(g), nil
+	}
+	return "", fmt.Errorf("unknown format %q (want %s)", format, strings.Join(Formats, " or "))
+}
+
+// DOT renders the graph for GraphViz: each tier of switches as ranked
+// clusters, each edge labeled with its speed and its ports at either end
+func DOT(g Graph) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "graph %s {\n", dotQuote(g.Title))
+	b.WriteString("  rankdir=TB;\n  node [shape=box, style=rounded];\n  edge [fontsize=10];\n")
+	for _, group := range []struct {
+		id, label string
+		switches  []Switch
+	}{{"superspines", "Super-spines", g.SuperSpines}, {"spines", "Spines", g.Spines}, {"leaves", "Leaves", g.Leaves}} {
+		if len(group.switches) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  subgraph cluster_%s {\n    label=%s;\n    rank=same;\n", group.id, dotQuote(group.label))
+		for _, s := range group.switches {
+			fmt.Fprintf(&b, "    %s [label=%s];\n", dotQuote(s.Name), dotQuote(s.Name+"\n"+s.Model))
+		}
+		b.WriteString("  }\n")
+	}
+	for _, l := range g.Links {
+		attrs := fmt.Sprintf("label=%s, taillabel=%s, headlabel=%s",
+			dotQuote(l.Speed()), dotQuote(strings.Join(l.FromPorts, "\n")), dotQuote(strings.Join(l.ToPorts, "\n")))
+		if l.Kind == Peer {
+			attrs += ", style=dashed, constraint=false"
+		}
+		fmt.Fprintf(&b, "  %s -- %s [%s];\n", dotQuote(l.From), dotQuote(l.To), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart, each tier above the
+// next,
+// each edge labeled with its speed and ports; peer links are dotted
+func Mermaid(g Graph) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\ntitle: %s\n---\nflowchart TB\n", g.Title)
+	for _, group := range []struct {
+		id, label string
+		switches  []Switch
+	}{{"superspines", "Super-spines", g.SuperSpines}, {"spines", "Spines", g.Spines}, {"leaves", "Leaves", g.Leaves}} {
+		if len(group.switches) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  subgraph %s [%s]\n    direction LR\n", group.id, mermaidQuote(group.label))
+		for _, s := range group.switches {
+			fmt.Fprintf(&b, "    %s[%s]\n", mermaidID(s.Name), mermaidQuote(s.Name+"<br>"+s.Model))
+		}
+		b.WriteString("  end\n")
+	}
+	for _, l := range g.Links {
+		arrow := "---"
+		if l.Kind == Peer {
+			arrow = "-.-"
+		}
+		label := fmt.Sprintf("%s %s : %s", l.Speed(), strings.Join(l.FromPorts, ","), strings.Join(l.ToPorts, ","))
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", mermaidID(l.From), arrow, mermaidQuote(label), mermaidID(l.To))
+	}
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidID keeps node IDs to letters, digits and underscores, which
+// every Mermaid version accepts
+func mermaidID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
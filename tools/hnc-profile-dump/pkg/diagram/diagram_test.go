@@ -0,0 +1,121 @@
+package diagram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func graph(t *testing.T, req fabricplan.Request) Graph {
+	t.Helper()
+	leaf, spine := profiles.DS2000(), profiles.DS3000()
+	p, err := fabricplan.Compute(req, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := cabling.Assign(p, leaf, spine, cabling.RoundRobin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := FromCabling(p, m, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestFromCabling(t *testing.T) {
+	// 2 leaves x 4 uplinks over 2 spines
+	g := graph(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3})
+	if len(g.Spines) != 2 || len(g.Leaves) != 2 || len(g.Links) != 8 {
+		t.Fatalf("%d spines, %d leaves, %d links; want 2, 2, 8", len(g.Spines), len(g.Leaves), len(g.Links))
+	}
+	first := g.Links[0]
+	if first.From != "spine1" || first.FromPorts[0] != "E1/1" || first.To != "leaf1" || first.ToPorts[0] != "E1/49" || first.Speed() != "100G" {
+		t.Fatalf("first link = %+v (%s)", first, first.Speed())
+	}
+
+	bundled := Bundle(g)
+	if len(bundled.Links) != 4 {
+		t.Fatalf("%d bundled links, want one per leaf-spine pair", len(bundled.Links))
+	}
+	if l := bundled.Links[0]; l.Speed() != "2x100G" || strings.Join(l.FromPorts, ",") != "E1/1,E1/2" || strings.Join(l.ToPorts, ",") != "E1/49,E1/51" {
+		t.Fatalf("first bundle = %+v (%s)", l, l.Speed())
+	}
+}
+
+func TestFromCablingBreakout(t *testing.T) {
+	g := graph(t, fabricplan.Request{Endpoints: 40 * 48, Oversubscription: 3, Breakout: "4x25G"})
+	if s := g.Links[0].Speed(); s != "25G" {
+		t.Fatalf("breakout lane speed = %s, want 25G", s)
+	}
+}
+
+func TestFromCablingModelMismatch(t *testing.T) {
+	leaf, spine := profiles.DS2000(), profiles.DS3000()
+	p, err := fabricplan.Compute(fabricplan.Request{Endpoints: 96, Oversubscription: 3}, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromCabling(p, cabling.Map{LeafModel: "other", SpineModel: p.SpineModel}, leaf, spine); err == nil {
+		t.Fatal("a cabling map for another leaf model was accepted")
+	}
+}
+
+func TestRender(t *testing.T) {
+	g := Bundle(graph(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3}))
+
+	dot, err := Render(g, "dot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`graph "2 leaves, 2 spines" {`,
+		"subgraph cluster_spines {",
+		`"leaf1" [label="leaf1\n` + profiles.DS2000().ModelID + `"];`,
+		`"spine1" -- "leaf1" [label="2x100G", taillabel="E1/1\nE1/2", headlabel="E1/49\nE1/51"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT lacks %s\n%s", want, dot)
+		}
+	}
+
+	mermaid, err := Render(g, "mermaid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"flowchart TB\n",
+		`  subgraph leaves ["Leaves"]`,
+		`  spine1 ---|"2x100G E1/1,E1/2 : E1/49,E1/51"| leaf1`,
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid lacks %s\n%s", want, mermaid)
+		}
+	}
+
+	if _, err := Render(g, "svg"); err == nil {
+		t.Error("unknown format was accepted")
+	}
+}
+
+func TestPeerLinksAreDashed(t *testing.T) {
+	g := Graph{Title: "pair", Leaves: []Switch{{Name: "leaf1"}, {Name: "leaf2"}}, Links: []Link{
+		{Kind: Peer, From: "leaf1", FromPorts: []string{"E1/55"}, To: "leaf2", ToPorts: []string{"E1/55"}, SpeedGbps: 100},
+	}}
+	if dot := DOT(g); !strings.Contains(dot, "style=dashed, constraint=false") {
+		t.Errorf("DOT peer link not dashed:\n%s", dot)
+	}
+	if m := Mermaid(g); !strings.Contains(m, `leaf1 -.-|"100G E1/55 : E1/55"| leaf2`) {
+		t.Errorf("Mermaid peer link not dotted:\n%s", m)
+	}
+}
+
+func TestMermaidID(t *testing.T) {
+	if id := mermaidID("leaf-1.a"); id != "leaf_1_a" {
+		t.Fatalf("mermaidID = %s", id)
+	}
+}
@@ -0,0 +1,287 @@
+// Package doctor runs the self-tests behind hnc doctor. Each check looks
+// at one thing a misconfigured environment gets wrong (the profile
+// catalog, FGD storage, the fabric controller, export templates, schema
+// versions) and reports it in one place, and the results together with
+// the tool's environment make up the diagnostics bundle attached to
+// support requests.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/exports"
+	"github.com/hnc/profile-dump/pkg/fabricapi"
+	"github.com/hnc/profile-dump/pkg/fgd"
+	"github.com/hnc/profile-dump/pkg/lint"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Status is the outcome of a check; only Fail fails a doctor run
+type Status string
+
+const (
+	OK   Status = "ok"
+	Warn Status = "warn"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// Check is the result of one self-test
+type Check struct {
+	Name    string   `json:"name"`
+	Status  Status   `json:"status"`
+	Summary string   `json:"summary"`
+	Details []string `json:"details,omitempty"` // one line per problem found
+	Fix     string   `json:"fix,omitempty"`     // what to run or do about them
+}
+
+// Bundle is the diagnostics bundle: every check and what it ran against
+type Bundle struct {
+	GeneratedAt time.Time   `json:"generatedAt"`
+	Environment Environment `json:"environment"`
+	Checks      []Check     `json:"checks"`
+}
+
+// Environment is the tool's side of a support request
+type Environment struct {
+	GoVersion     string            `json:"goVersion"`
+	Platform      string            `json:"platform"`
+	ProfileSchema string            `json:"profileSchema"`
+	ExportFormats map[string]string `json:"exportFormats"` // format name -> version written
+	Inputs        map[string]string `json:"inputs"`        // flag -> value the checks ran with
+}
+
+// NewBundle is an empty bundle describing this build, with the inputs
+// the checks are about to run with
+func NewBundle(now time.Time, inputs map[string]string) Bundle {
+	formats := map[string]string{}
+	for _, f := range exports.Formats {
+		formats[f.Name] = f.Current()
+	}
+	return Bundle{
+		GeneratedAt: now.UTC(),
+		Environment: Environment{
+			GoVersion:     runtime.Version(),
+			Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+			ProfileSchema: profiles.SchemaVersion,
+			ExportFormats: formats,
+			Inputs:        inputs,
+		},
+	}
+}
+
+// Failed reports whether any check failed
+func (b Bundle) Failed() bool {
+	for _, c := range b.Checks {
+		if c.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// Catalog checks every profile against the profile rules and the lint
+// rules; lint warnings only warn
+func Catalog(ps []profiles.SwitchProfile) Check {
+	c := Check{Name: "catalog", Status: OK}
+	invalid := 0
+	for _, p := range ps {
+		errs := profiles.Validate(p)
+		if len(errs) > 0 {
+			invalid++
+		}
+		for _, e := range errs {
+			c.Details = append(c.Details, p.ModelID+": "+e)
+		}
+	}
+	findings := lint.Run(ps, lint.Rules)
+	for _, f := range findings {
+		c.Details = append(c.Details, fmt.Sprintf("%s: %s (%s %s)", f.ModelID, f.Message, f.Rule, f.Severity))
+	}
+	errors := lint.Errors(findings)
+	switch {
+	case len(ps) == 0:
+		c.Status, c.Summary = Fail, "no switch profiles"
+		return c
+	case invalid > 0 || errors > 0:
+		c.Status = Fail
+	case len(findings) > 0:
+		c.Status = Warn
+	}
+	c.Summary = fmt.Sprintf("%d profile(s), %d invalid, %d lint error(s), %d lint warning(s)", len(ps), invalid, errors, len(findings)-errors)
+	return c
+}
+
+// Storage checks that the FGD directory is writable and that every fabric
+// in it has its three files, in any layout the frontend saves. A missing
+// directory only warns, since the first save creates it.
+func Storage(dir string) Check {
+	c := Check{Name: "storage", Status: OK}
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		c.Status, c.Summary = Warn, fmt.Sprintf("%s does not exist yet; the first save creates it", dir)
+		return c
+	case err != nil:
+		c.Status, c.Summary = Fail, err.Error()
+		return c
+	case !info.IsDir():
+		c.Status, c.Summary = Fail, fmt.Sprintf("%s is not a directory", dir)
+		return c
+	}
+
+	probe, err := os.CreateTemp(dir, ".hnc-doctor-*")
+	if err != nil {
+		c.Status, c.Summary = Fail, fmt.Sprintf("%s is not writable: %v", dir, err)
+		return c
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		c.Status, c.Summary = Fail, fmt.Sprintf("%s is not readable: %v", dir, err)
+		return c
+	}
+	fabrics := 0
+	for _, e := range entries {
+		// Dot directories hold the trash, not fabrics
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		fabrics++
+		for _, f := range fgd.Files {
+			if fgd.Stored(filepath.Join(dir, e.Name()), f.Name) == nil {
+				c.Details = append(c.Details, fmt.Sprintf("%s: %s is missing", e.Name(), f.Name))
+			}
+		}
+	}
+	if len(c.Details) > 0 {
+		c.Status = Warn
+	}
+	c.Summary = fmt.Sprintf("%s is writable, %d fabric(s), %d problem(s)", dir, fabrics, len(c.Details))
+	return c
+}
+
+// Cluster checks that kubectl reaches the fabric controller and that it
+// serves SwitchProfiles
+func Cluster(ctx context.Context, kubectl fabricapi.Kubectl, cluster fabricapi.Cluster) Check {
+	c := Check{Name: "cluster", Status: Fail}
+	name, err := fabricapi.ClusterName(ctx, kubectl, cluster)
+	if err != nil {
+		c.Summary = err.Error()
+		return c
+	}
+	live, err := fabricapi.SwitchProfiles(ctx, kubectl, cluster)
+	if err != nil {
+		c.Summary = fmt.Sprintf("%s: %v", name, err)
+		return c
+	}
+	c.Status, c.Summary = OK, fmt.Sprintf("%s serves %d switch profile(s)", name, len(live))
+	if len(live) == 0 {
+		c.Status = Warn
+	}
+	return c
+}
+
+// templateFuncs are the functions src/io/export-template.ts provides; len
+// is built into text/template. Only their names matter for parsing.
+var templateFuncs = template.FuncMap{}
+
+func init() {
+	for _, name := range []string{"upper", "lower", "join", "quote", "csv", "default"} {
+		templateFuncs[name] = func(args ...any) string { return "" }
+	}
+}
+
+// Templates parses every *.tmpl export template in dir with Go's
+// text/template, whose syntax the frontend's templates share
+func Templates(dir string) Check {
+	c := Check{Name: "templates", Status: OK}
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		c.Status, c.Summary = Fail, err.Error()
+		return c
+	}
+	if len(files) == 0 {
+		c.Status, c.Summary = Warn, fmt.Sprintf("no *.tmpl templates in %s", dir)
+		return c
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			_, err = template.New(filepath.Base(file)).Funcs(templateFuncs).Parse(string(data))
+		}
+		if err != nil {
+			c.Details = append(c.Details, err.Error())
+		}
+	}
+	if len(c.Details) > 0 {
+		c.Status = Fail
+	}
+	c.Summary = fmt.Sprintf("%d template(s), %d invalid", len(files), len(c.Details))
+	return c
+}
+
+// Schemas checks the schema version of every profile, and of every JSON
+// fixture in fixturesDir unless it is empty: older versions warn, since
+// they still load, and fixtures that do not match the JSON Schema fail
+func Schemas(ps []profiles.SwitchProfile, fixturesDir string) Check {
+	c := Check{Name: "schemas", Status: OK}
+	versions := map[string]int{}
+	for _, p := range ps {
+		versions[p.Meta.Version]++
+		if p.Meta.Version != profiles.SchemaVersion {
+			c.Details = append(c.Details, fmt.Sprintf("%s: schema %s; run hnc profiles migrate to upgrade it to %s", p.ModelID, p.Meta.Version, profiles.SchemaVersion))
+		}
+	}
+	if len(c.Details) > 0 {
+		c.Status = Warn
+	}
+	var seen []string
+	for v, n := range versions {
+		seen = append(seen, fmt.Sprintf("%d at %s", n, v))
+	}
+	sort.Strings(seen)
+	c.Summary = "profiles: " + strings.Join(seen, ", ")
+
+	if fixturesDir == "" {
+		return c
+	}
+	files, err := profiles.FixtureFiles(fixturesDir, "*.json")
+	if err == nil && len(files) == 0 {
+		err = fmt.Errorf("no profile fixtures in %s", fixturesDir)
+	}
+	if err != nil {
+		c.Status, c.Details = Fail, append(c.Details, err.Error())
+		return c
+	}
+	mismatched := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		problems := profiles.CheckSchema(data)
+		if err != nil {
+			problems = []string{err.Error()}
+		}
+		if len(problems) > 0 {
+			mismatched++
+		}
+		name, _ := filepath.Rel(fixturesDir, file)
+		for _, p := range problems {
+			c.Details = append(c.Details, filepath.ToSlash(name)+": "+p)
+		}
+	}
+	if mismatched > 0 {
+		c.Status = Fail
+	}
+	c.Summary += fmt.Sprintf("; %d fixture(s), %d not matching the JSON Schema", len(files), mismatched)
+	return c
+}
@@ -0,0 +1,196 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/fabricapi"
+	"github.com/hnc/profile-dump/pkg/manifest"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	if c := Catalog(profiles.Default().List()); c.Status != OK {
+		t.Fatalf("built-in catalog = %+v", c)
+	}
+	bad := profiles.DS2000()
+	bad.ModelID = "DS2000"
+	if c := Catalog([]profiles.SwitchProfile{bad}); c.Status != Warn || !strings.Contains(c.Details[0], "model-id") {
+		t.Errorf("misnamed profile = %+v, want a model-id warning", c)
+	}
+	bad.Profiles.Endpoint.PortProfile = nil
+	if c := Catalog([]profiles.SwitchProfile{bad}); c.Status != Fail {
+		t.Errorf("invalid profile = %+v, want fail", c)
+	}
+	if c := Catalog(nil); c.Status != Fail {
+		t.Errorf("empty catalog = %+v, want fail", c)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	dir := t.TempDir()
+	if c := Storage(filepath.Join(dir, "fgd")); c.Status != Warn || !strings.Contains(c.Summary, "first save") {
+		t.Errorf("missing dir = %+v", c)
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"plain/servers.yaml": "", "plain/switches.yaml": "", "plain/connections.yaml": "",
+		"packed/servers.yaml.gz": "", "packed/switches/part-0001.yaml": "", "packed/connections/part-0001.yaml.zst": "",
+		"partial/servers.yaml":    "",
+		".trash/old/servers.yaml": "",
+	})
+	c := Storage(dir)
+	if c.Status != Warn || len(c.Details) != 2 || !strings.Contains(c.Summary, "3 fabric(s)") {
+		t.Fatalf("storage = %+v, want partial's two missing files", c)
+	}
+	if c.Details[0] != "partial: switches.yaml is missing" {
+		t.Errorf("details = %q", c.Details)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 4 {
+		t.Errorf("write probe left %d entries behind", len(entries)-4)
+	}
+
+	if c := Storage(filepath.Join(dir, "plain", "servers.yaml")); c.Status != Fail {
+		t.Errorf("file as dir = %+v, want fail", c)
+	}
+}
+
+func TestCluster(t *testing.T) {
+	crd, err := profiles.ToCRD(profiles.DS2000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubectl := func(_ context.Context, args ...string) ([]byte, error) {
+		if args[0] == "config" {
+			return []byte("lab"), nil
+		}
+		return json.Marshal(map[string]any{"items": []any{crd}})
+	}
+	if c := Cluster(context.Background(), kubectl, fabricapi.Cluster{}); c.Status != OK || c.Summary != "lab serves 1 switch profile(s)" {
+		t.Errorf("cluster = %+v", c)
+	}
+	unreachable := func(context.Context, ...string) ([]byte, error) { return nil, errors.New("connection refused") }
+	if c := Cluster(context.Background(), unreachable, fabricapi.Cluster{}); c.Status != Fail || c.Summary != "connection refused" {
+		t.Errorf("unreachable cluster = %+v", c)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	if c := Templates("../../../../examples/export-templates"); c.Status != OK {
+		t.Fatalf("example templates = %+v", c)
+	}
+	dir := t.TempDir()
+	if c := Templates(dir); c.Status != Warn {
+		t.Errorf("empty dir = %+v, want warn", c)
+	}
+	writeFiles(t, dir, map[string]string{
+		"ok.tmpl":     `{{ range .devices.leaves }}{{ .id | upper | csv }}{{ end }}`,
+		"broken.tmpl": "{{ range .devices.leaves }}\n{{ .id }}",
+		"nofunc.tmpl": `{{ .id | shout }}`,
+	})
+	c := Templates(dir)
+	if c.Status != Fail || len(c.Details) != 2 || !strings.Contains(c.Summary, "3 template(s), 2 invalid") {
+		t.Fatalf("templates = %+v", c)
+	}
+}
+
+func TestSchemas(t *testing.T) {
+	old := profiles.DS2000()
+	old.Meta.Version = "v0.4.0"
+	c := Schemas([]profiles.SwitchProfile{old, profiles.DS3000()}, "")
+	if c.Status != Warn || c.Summary != "profiles: 1 at v0.4.0, 1 at v0.5.0" || !strings.Contains(c.Details[0], "migrate") {
+		t.Errorf("schemas = %+v", c)
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"bad.json": `{"modelId": 1}`})
+	if c := Schemas(profiles.Default().List(), dir); c.Status != Fail || !strings.Contains(c.Summary, "1 not matching") {
+		t.Errorf("bad fixture = %+v", c)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	b := NewBundle(time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("x", 3600)), map[string]string{"fgd": "./fgd"})
+	if b.GeneratedAt.Location() != time.UTC || b.Environment.ProfileSchema != profiles.SchemaVersion || b.Environment.ExportFormats["plan-json"] != "v1" {
+		t.Errorf("bundle = %+v", b)
+	}
+	b.Checks = []Check{{Status: OK}, {Status: Skip}, {Status: Warn}}
+	if b.Failed() {
+		t.Error("warnings failed the bundle")
+	}
+	if b.Checks = append(b.Checks, Check{Status: Fail}); !b.Failed() {
+		t.Error("a failed check did not fail the bundle")
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	generated, err := profiles.Default().Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := map[string][]byte{}
+	for _, f := range generated {
+		files[f.Name] = f.Data
+		writeFiles(t, dir, map[string]string{f.Name: string(f.Data)})
+	}
+	m, _ := json.Marshal(manifest.New("hnc profiles dump", "(devel)", files, manifest.Manifest{}, time.Now()))
+	writeFiles(t, dir, map[string]string{manifest.File: string(m), ".gitkeep": ""})
+	status := func(checks []Check) map[string]Status {
+		got := map[string]Status{}
+		for _, c := range checks {
+			got[c.Name] = c.Status
+		}
+		return got
+	}
+	for name, s := range status(Fixtures(dir, generated, "regen")) {
+		if s != OK {
+			t.Errorf("fresh fixtures: %s = %s", name, s)
+		}
+	}
+
+	first, second := generated[0].Name, generated[1].Name
+	os.Remove(filepath.Join(dir, first))
+	old := strings.Replace(string(files[second]), `"`+profiles.SchemaVersion+`"`, `"v0.4.0"`, 1)
+	writeFiles(t, dir, map[string]string{second: old, "notes.txt": "todo"})
+	checks := Fixtures(dir, generated, "regen")
+	want := map[string]Status{"files": Fail, "schemas": OK, "versions": Warn, "content": Fail, "manifest": Fail}
+	if got := status(checks); !reflect.DeepEqual(got, want) {
+		t.Errorf("broken fixtures = %v, want %v", got, want)
+	}
+	if c := checks[0]; len(c.Details) != 2 || !strings.HasPrefix(c.Details[0], "notes.txt: not a profile") ||
+		!strings.HasPrefix(c.Details[1], first+": missing") || c.Fix != "regen, then delete the unknown files" {
+		t.Errorf("files = %+v", c)
+	}
+	if c := checks[2]; len(c.Details) != 1 || !strings.Contains(c.Details[0], "schema v0.4.0") || c.Fix != "regen" {
+		t.Errorf("versions = %+v", c)
+	}
+
+	writeFiles(t, dir, map[string]string{second: `{"modelId": "x"}`})
+	if c := Fixtures(dir, generated, "regen")[1]; c.Status != Fail || !strings.HasPrefix(c.Details[0], second+": ") {
+		t.Errorf("invalid fixture: schemas = %+v", c)
+	}
+	if c := Fixtures(filepath.Join(dir, "none"), generated, "regen")[0]; c.Status != Fail || c.Fix != "regen" {
+		t.Errorf("missing dir: files = %+v", c)
+	}
+}
@@ -0,0 +1,207 @@
+package doctor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/manifest"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Fixtures checks a directory of switch profile fixtures, in either layout
+// (see profiles.Partition), against the files the generator writes now,
+// generated, behind hnc profiles doctor: files it does not write, ones it
+// writes that are missing, fixtures that break the JSON Schema or are at an
+// older schema version, ones that differ from what it writes, and the
+// manifest. regenerate is the command that writes generated to dir, the
+// fix for most of them.
+func Fixtures(dir string, generated []profiles.File, regenerate string) []Check {
+	files := Check{Name: "files", Status: OK}
+	want := map[string][]byte{}
+	dirs := map[string]bool{}
+	for _, f := range generated {
+		want[f.Name] = f.Data
+		for d := path.Dir(f.Name); d != "."; d = path.Dir(d) {
+			dirs[d] = true
+		}
+	}
+	// The files in dir, and in the directories of a tree layout, by
+	// slash-separated path; other directories are unknown entries
+	type entry struct {
+		name string
+		dir  bool
+	}
+	var entries []entry
+	err := filepath.WalkDir(dir, func(file string, e fs.DirEntry, err error) error {
+		if err != nil || file == dir {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, file)
+		name := filepath.ToSlash(rel)
+		// Dot files are editor and VCS droppings, not fixtures
+		if strings.HasPrefix(e.Name(), ".") && e.IsDir() {
+			return fs.SkipDir
+		}
+		if strings.HasPrefix(e.Name(), ".") || name == manifest.File || e.IsDir() && dirs[name] {
+			return nil
+		}
+		entries = append(entries, entry{name, e.IsDir()})
+		if e.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		files.Status, files.Summary = Fail, err.Error()
+		if errors.Is(err, fs.ErrNotExist) {
+			files.Summary, files.Fix = fmt.Sprintf("%s does not exist", dir), regenerate
+		}
+		skipped := func(name string) Check {
+			return Check{Name: name, Status: Skip, Summary: "no fixtures directory"}
+		}
+		return []Check{files, skipped("schemas"), skipped("versions"), skipped("content"), skipped("manifest")}
+	}
+
+	present := map[string][]byte{}
+	var unknown, missing int
+	for _, e := range entries {
+		name := e.name
+		if _, ok := want[name]; !ok || e.dir {
+			unknown++
+			files.Details = append(files.Details, fmt.Sprintf("%s: not a profile the generator writes; delete it, or add its model to the profile definitions and regenerate", name))
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			files.Details = append(files.Details, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		present[name] = data
+	}
+	for _, f := range generated {
+		if _, ok := present[f.Name]; !ok && !exists(dir, f.Name) {
+			missing++
+			files.Details = append(files.Details, fmt.Sprintf("%s: missing; the generator writes it for %s", f.Name, modelID(f.Data)))
+		}
+	}
+	if len(files.Details) > 0 {
+		files.Status, files.Fix = Fail, regenerate
+		if unknown > 0 {
+			files.Fix += ", then delete the unknown files"
+		}
+	}
+	files.Summary = fmt.Sprintf("%d of %d expected fixture(s), %d missing, %d unknown", len(present), len(generated), missing, unknown)
+
+	schemas := Check{Name: "schemas", Status: OK}
+	versions := Check{Name: "versions", Status: OK}
+	content := Check{Name: "content", Status: OK}
+	invalid, old, stale := 0, 0, 0
+	for _, f := range generated {
+		data, ok := present[f.Name]
+		if !ok {
+			continue
+		}
+		if f.Name == profiles.IndexFile {
+			if string(data) != string(f.Data) {
+				stale++
+				content.Details = append(content.Details, fmt.Sprintf("%s: differs from what the generator writes", f.Name))
+			}
+			continue
+		}
+		if problems := profiles.CheckSchema(data); len(problems) > 0 {
+			invalid++
+			for _, p := range problems {
+				schemas.Details = append(schemas.Details, f.Name+": "+p)
+			}
+		}
+		if v := schemaVersion(data); v != profiles.SchemaVersion {
+			old++
+			if v == "" {
+				v = "unknown"
+			}
+			versions.Details = append(versions.Details, fmt.Sprintf("%s: schema %s, the generator writes %s", f.Name, v, profiles.SchemaVersion))
+		}
+		if string(data) != string(f.Data) {
+			stale++
+			content.Details = append(content.Details, fmt.Sprintf("%s: differs from what the generator writes; edit the profile definitions, not the fixture", f.Name))
+		}
+	}
+	fixtures := len(present)
+	if _, ok := present[profiles.IndexFile]; ok {
+		fixtures--
+	}
+	schemas.Summary = fmt.Sprintf("%d fixture(s), %d not matching the JSON Schema", fixtures, invalid)
+	versions.Summary = fmt.Sprintf("%d fixture(s) at schema %s, %d older", fixtures-old, profiles.SchemaVersion, old)
+	content.Summary = fmt.Sprintf("%d fixture(s), %d out of date", len(present), stale)
+	if invalid > 0 {
+		schemas.Status, schemas.Fix = Fail, regenerate
+	}
+	if old > 0 {
+		versions.Status, versions.Fix = Warn, regenerate
+	}
+	if stale > 0 {
+		content.Status, content.Fix = Fail, regenerate
+	}
+
+	return []Check{files, schemas, versions, content, manifestCheck(dir, want, regenerate)}
+}
+
+// manifestCheck verifies the checksums in dir's manifest of the files the
+// generator writes; the others are the files check's to report
+func manifestCheck(dir string, want map[string][]byte, regenerate string) Check {
+	c := Check{Name: "manifest", Status: OK}
+	m, err := manifest.Read(dir)
+	if err != nil {
+		c.Status, c.Summary, c.Fix = Fail, err.Error(), regenerate
+		if errors.Is(err, fs.ErrNotExist) {
+			c.Summary = fmt.Sprintf("no %s in %s", manifest.File, dir)
+		}
+		return c
+	}
+	problems, matched, err := manifest.Verify(dir, m)
+	if err != nil {
+		c.Status, c.Summary = Fail, err.Error()
+		return c
+	}
+	for _, p := range problems {
+		if _, ok := want[p.Name]; ok {
+			c.Details = append(c.Details, p.Name+": "+p.Message)
+		}
+	}
+	if len(c.Details) > 0 {
+		c.Status, c.Fix = Fail, regenerate
+	}
+	c.Summary = fmt.Sprintf("%d file(s) match %s, written by %s %s", matched, manifest.File, m.Generator, m.GeneratorVersion)
+	return c
+}
+
+func exists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+	return err == nil
+}
+
+// schemaVersion is meta.version of a fixture, empty when it has none
+func schemaVersion(data []byte) string {
+	var p struct {
+		Meta struct {
+			Version string `json:"version"`
+		} `json:"meta"`
+	}
+	json.Unmarshal(data, &p)
+	return p.Meta.Version
+}
+
+// modelID is the modelId of a generated fixture
+func modelID(data []byte) string {
+	var p struct {
+		ModelID string `json:"modelId"`
+	}
+	json.Unmarshal(data, &p)
+	return p.ModelID
+}
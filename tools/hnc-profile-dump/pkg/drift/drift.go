@@ -0,0 +1,261 @@
+// Package drift compares what a fabric controller serves with the HNC
+// design it was deployed from: switch profiles field by field, and the
+// switches and fabric links of the wiring against the plan. Every
+// difference carries a severity, so a periodic job can alert only on the
+// ones that break the design.
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/fabricapi"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Severity ranks a difference. Critical ones invalidate the design (a
+// port range or speed the plan relies on), warnings are worth a look,
+// info is bookkeeping.
+type Severity string
+
+const (
+	Info     Severity = "info"
+	Warning  Severity = "warning"
+	Critical Severity = "critical"
+)
+
+// Severities lists the severities from lowest to highest
+var Severities = []Severity{Info, Warning, Critical}
+
+// AtLeast reports whether s is as severe as min
+func (s Severity) AtLeast(min Severity) bool {
+	return rank(s) >= rank(min)
+}
+
+func rank(s Severity) int {
+	for i, v := range Severities {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Kinds of difference, seen from the local design: a field or object the
+// cluster has and the design lacks is added
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is one difference between the design and the cluster
+type Change struct {
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`
+	Object   string   `json:"object"`         // e.g. switchprofile/celestica-ds2000
+	Path     string   `json:"path,omitempty"` // field within the object, e.g. ports.fabricAssignable
+	Local    any      `json:"local,omitempty"`
+	Live     any      `json:"live,omitempty"`
+}
+
+// Report is every difference found in one cluster, most severe first
+type Report struct {
+	Cluster string   `json:"cluster"`
+	Changes []Change `json:"changes"`
+}
+
+// Max is the highest severity in the report, or "" when nothing drifted
+func (r Report) Max() Severity {
+	var max Severity
+	for _, c := range r.Changes {
+		if rank(c.Severity) > rank(max) {
+			max = c.Severity
+		}
+	}
+	return max
+}
+
+// Sort orders the changes by severity, then object and path
+func (r *Report) Sort() {
+	sort.SliceStable(r.Changes, func(i, j int) bool {
+		a, b := r.Changes[i], r.Changes[j]
+		if a.Severity != b.Severity {
+			return rank(a.Severity) > rank(b.Severity)
+		}
+		if a.Object != b.Object {
+			return a.Object < b.Object
+		}
+		return a.Path < b.Path
+	})
+}
+
+// profileSeverity grades a changed profile field by how much of a design
+// depends on it
+func profileSeverity(path string) Severity {
+	switch {
+	case strings.HasPrefix(path, "ports."),
+		strings.HasSuffix(path, ".portProfile"),
+		strings.HasSuffix(path, ".speedGbps") && !strings.Contains(path, "breakout"):
+		return Critical
+	case path == "roles", strings.Contains(path, "breakout"):
+		return Warning
+	}
+	return Info
+}
+
+// Profiles compares the local profiles with those the cluster serves.
+// Faceplates, per-role port profiles and HNC's planning data (physical,
+// cost, capabilities and lifecycle) are not part of the resource and
+// sources always differ, so none of them is compared. A local model the
+// cluster lacks is critical when it is one of used, the models a plan is
+// built from, and a warning otherwise.
+func Profiles(local, live []profiles.SwitchProfile, used ...string) []Change {
+	liveByID := map[string]profiles.SwitchProfile{}
+	for _, p := range live {
+		liveByID[p.ModelID] = p
+	}
+	var changes []Change
+	for _, p := range local {
+		object := "switchprofile/" + p.ModelID
+		l, ok := liveByID[p.ModelID]
+		if !ok {
+			severity := Warning
+			for _, name := range used {
+				if name == p.ModelID {
+					severity = Critical
+				}
+			}
+			changes = append(changes, Change{Severity: severity, Kind: Removed, Object: object})
+			continue
+		}
+		delete(liveByID, p.ModelID)
+		changes = append(changes, Fields(object, designFields(p), designFields(l), profileSeverity)...)
+	}
+	for id := range liveByID {
+		changes = append(changes, Change{Severity: Info, Kind: Added, Object: "switchprofile/" + id})
+	}
+	return changes
+}
+
+// designFields drops what a profile read back from a cluster cannot match
+func designFields(p profiles.SwitchProfile) profiles.SwitchProfile {
+	p.Faceplate, p.Profiles.Roles, p.Meta.Source = nil, nil, ""
+	p.Physical, p.Cost, p.Capabilities, p.Lifecycle = nil, nil, nil, nil
+	return p
+}
+
+// Fields diffs the JSON encodings of local and live object by object
+// field; lists are compared whole
+func Fields(object string, local, live any, severity func(path string) Severity) []Change {
+	a, b := flatten(local), flatten(live)
+	var changes []Change
+	for _, path := range union(a, b) {
+		la, inLocal := a[path]
+		lb, inLive := b[path]
+		change := Change{Severity: severity(path), Object: object, Path: path, Local: la, Live: lb}
+		switch {
+		case !inLive:
+			change.Kind = Removed
+		case !inLocal:
+			change.Kind = Added
+		case !reflect.DeepEqual(la, lb):
+			change.Kind = Changed
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// flatten maps the dotted path of every non-object JSON value in v to it
+func flatten(v any) map[string]any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("drift: %v", err))
+	}
+	var decoded any
+	_ = json.Unmarshal(data, &decoded)
+	out := map[string]any{}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		if m, ok := v.(map[string]any); ok {
+			for k, child := range m {
+				walk(prefix+k+".", child)
+			}
+			return
+		}
+		out[strings.TrimSuffix(prefix, ".")] = v
+	}
+	walk("", decoded)
+	return out
+}
+
+func union(a, b map[string]any) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Wiring compares the cluster's switches and fabric links with a plan:
+// switch counts per role and the profile each uses are critical, the
+// number of leaf-spine links a warning (links may be down for repair).
+// leafModel and spineModel are the plan's models resolved to model IDs.
+func Wiring(plan fabricplan.Plan, leafModel, spineModel string, switches []fabricapi.Switch, fabricLinks int) []Change {
+	var changes []Change
+	for _, role := range []struct {
+		name  string
+		count int
+		model string
+	}{{"leaf", plan.Leaves, leafModel}, {"spine", plan.Spines, spineModel}} {
+		var names []string
+		for _, sw := range switches {
+			if roleOf(sw.Role) != role.name {
+				continue
+			}
+			names = append(names, sw.Name)
+			if sw.Profile != role.model {
+				changes = append(changes, Change{Severity: Critical, Kind: Changed, Object: "switch/" + sw.Name, Path: "profile", Local: role.model, Live: sw.Profile})
+			}
+		}
+		if len(names) != role.count {
+			changes = append(changes, Change{
+				Severity: Critical, Kind: countKind(role.count, len(names)), Object: "wiring", Path: role.name + "s",
+				Local: role.count, Live: len(names),
+			})
+		}
+	}
+	if want := plan.Leaves * plan.UplinksPerLeaf; fabricLinks != want {
+		changes = append(changes, Change{Severity: Warning, Kind: countKind(want, fabricLinks), Object: "wiring", Path: "fabricLinks", Local: want, Live: fabricLinks})
+	}
+	return changes
+}
+
+// roleOf folds the controller's leaf roles (server-leaf, border-leaf,
+// mixed-leaf) into HNC's leaf
+func roleOf(role string) string {
+	if strings.HasSuffix(role, "leaf") {
+		return "leaf"
+	}
+	return role
+}
+
+func countKind(local, live int) string {
+	if live > local {
+		return Added
+	}
+	return Removed
+}
@@ -0,0 +1,59 @@
+package drift
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricapi"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func TestProfiles(t *testing.T) {
+	live := profiles.DS2000()
+	live.Faceplate, live.Meta.Source = nil, "fabric-api:lab@1"
+	live.Ports.FabricAssignable = []string{"E1/49-54"}
+	live.Profiles.Uplink.Breakouts = nil
+	ds4000, _ := profiles.Default().Get("celestica-ds4000")
+	extra := profiles.DS3000()
+	extra.ModelID = "celestica-ds3000-b"
+
+	got := Profiles([]profiles.SwitchProfile{profiles.DS2000(), profiles.DS3000(), ds4000}, []profiles.SwitchProfile{live, extra}, "celestica-ds3000")
+	want := []Change{
+		{Severity: Critical, Kind: Changed, Object: "switchprofile/celestica-ds2000", Path: "ports.fabricAssignable", Local: []any{"E1/49-56"}, Live: []any{"E1/49-54"}},
+		{Severity: Warning, Kind: Removed, Object: "switchprofile/celestica-ds2000", Path: "profiles.uplink.breakouts",
+			Local: []any{map[string]any{"mode": "4x25G", "lanes": 4.0, "speedGbps": 25.0, "portPattern": "{port}/{lane}"}}},
+		{Severity: Critical, Kind: Removed, Object: "switchprofile/celestica-ds3000"},
+		{Severity: Warning, Kind: Removed, Object: "switchprofile/celestica-ds4000"},
+		{Severity: Info, Kind: Added, Object: "switchprofile/celestica-ds3000-b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Profiles() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := Profiles([]profiles.SwitchProfile{profiles.DS2000()}, []profiles.SwitchProfile{profiles.DS2000()}); len(got) != 0 {
+		t.Errorf("identical profiles drifted: %+v", got)
+	}
+}
+
+func TestWiring(t *testing.T) {
+	plan := fabricplan.Plan{Leaves: 2, Spines: 2, UplinksPerLeaf: 4}
+	switches := []fabricapi.Switch{
+		{Name: "leaf-1", Role: "server-leaf", Profile: "celestica-ds2000"},
+		{Name: "leaf-2", Role: "border-leaf", Profile: "celestica-ds2000"},
+		{Name: "spine-1", Role: "spine", Profile: "celestica-ds4000"},
+	}
+	report := Report{Changes: Wiring(plan, "celestica-ds2000", "celestica-ds3000", switches, 6)}
+	report.Sort()
+	want := []Change{
+		{Severity: Critical, Kind: Changed, Object: "switch/spine-1", Path: "profile", Local: "celestica-ds3000", Live: "celestica-ds4000"},
+		{Severity: Critical, Kind: Removed, Object: "wiring", Path: "spines", Local: 2, Live: 1},
+		{Severity: Warning, Kind: Removed, Object: "wiring", Path: "fabricLinks", Local: 8, Live: 6},
+	}
+	if !reflect.DeepEqual(report.Changes, want) {
+		t.Errorf("Wiring() =\n%+v\nwant\n%+v", report.Changes, want)
+	}
+	if report.Max() != Critical || !Warning.AtLeast(Info) || Info.AtLeast(Warning) {
+		t.Errorf("Max() = %s", report.Max())
+	}
+}
@@ -0,0 +1,119 @@
+// Package exports lists every file format hnc writes along with the
+// versions of it hnc can still write, so automation can pin the version
+// it was built against, and converts files between those versions.
+package exports
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/bom"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Format is one file layout hnc writes
+type Format struct {
+	Name      string   `json:"name"`
+	WrittenBy string   `json:"writtenBy"`
+	Versions  []string `json:"versions"` // oldest first; hnc writes the last by default
+	// convert rewrites a file at another version and returns the version
+	// it was at; nil while the format has a single version
+	convert func(data []byte, to string) ([]byte, string, error)
+}
+
+// Formats is every export format, in the order the commands run
+var Formats = []Format{
+	{Name: "profile-json", WrittenBy: "hnc profiles dump", Versions: profiles.SchemaVersions, convert: convertProfile},
+	{Name: "plan-json", WrittenBy: "hnc plan", Versions: []string{"v1"}},
+	{Name: "bom-json", WrittenBy: "hnc bom -json", Versions: bom.FormatVersions, convert: convertBOMJSON},
+	{Name: "bom-csv", WrittenBy: "hnc bom -csv", Versions: bom.FormatVersions, convert: convertBOMCSV},
+	{Name: "layout-json", WrittenBy: "hnc layout", Versions: []string{"v1"}},
+	{Name: "cabling-json", WrittenBy: "hnc cabling", Versions: []string{"v1"}},
+	{Name: "cabling-csv", WrittenBy: "hnc cabling -csv", Versions: []string{"v1"}},
+	{Name: "vpc-plan-json", WrittenBy: "hnc vpcs", Versions: []string{"v1"}},
+}
+
+// Names lists the format names
+func Names() []string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Find looks up a format by name
+func Find(name string) (Format, bool) {
+	for _, f := range Formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Current is the version hnc writes unless asked for another
+func (f Format) Current() string {
+	return f.Versions[len(f.Versions)-1]
+}
+
+// Check reports whether hnc can write the format at version
+func (f Format) Check(version string) error {
+	if !slices.Contains(f.Versions, version) {
+		return fmt.Errorf("%s has no version %s (supported: %s)", f.Name, version, strings.Join(f.Versions, ", "))
+	}
+	return nil
+}
+
+// Convert rewrites a file of this format at version to, and returns the
+// version it was written at
+func (f Format) Convert(data []byte, to string) ([]byte, string, error) {
+	if err := f.Check(to); err != nil {
+		return nil, "", err
+	}
+	if f.convert == nil {
+		return data, f.Current(), nil
+	}
+	return f.convert(data, to)
+}
+
+func convertProfile(data []byte, to string) ([]byte, string, error) {
+	p, from, err := profiles.Migrate(data)
+	if err != nil {
+		return nil, from, err
+	}
+	if p, err = profiles.AtVersion(p, to); err != nil {
+		return nil, from, err
+	}
+	out, err := profiles.Marshal(p)
+	return out, from, err
+}
+
+func convertBOMJSON(data []byte, to string) ([]byte, string, error) {
+	var b bom.BOM
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, "", err
+	}
+	from := b.Version()
+	b, err := bom.AtVersion(b, to)
+	if err != nil {
+		return nil, from, err
+	}
+	out, err := canonjson.Marshal(b)
+	return out, from, err
+}
+
+func convertBOMCSV(data []byte, to string) ([]byte, string, error) {
+	b, err := bom.ParseCSV(string(data))
+	if err != nil {
+		return nil, "", err
+	}
+	from := b.Version()
+	if b, err = bom.AtVersion(b, to); err != nil {
+		return nil, from, err
+	}
+	return []byte(bom.RenderCSV(b)), from, nil
+}
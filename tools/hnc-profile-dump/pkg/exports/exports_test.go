@@ -0,0 +1,79 @@
+package exports
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/bom"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func find(t *testing.T, name string) Format {
+	t.Helper()
+	f, ok := Find(name)
+	if !ok {
+		t.Fatalf("no format %s", name)
+	}
+	return f
+}
+
+func TestConvertBOM(t *testing.T) {
+	p, err := fabricplan.Compute(fabricplan.Request{Endpoints: 96, Oversubscription: 3}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := bom.Compute(p, profiles.DS2000(), profiles.DS3000(), bom.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csv := []byte(bom.RenderCSV(b))
+	v1, from, err := find(t, "bom-csv").Convert(csv, "v1")
+	if err != nil || from != "v2" {
+		t.Fatalf("Convert(bom-csv, v1) from %s: %v", from, err)
+	}
+	if !strings.HasPrefix(string(v1), "category,item,description,quantity\n") || !strings.Contains(string(v1), "optic,SFP28-25G,leaf endpoint ports,96\n") {
+		t.Errorf("v1 CSV:\n%s", v1)
+	}
+	back, from, err := find(t, "bom-csv").Convert(v1, "v2")
+	if err != nil || from != "v1" || string(back) != string(csv) {
+		t.Errorf("v1 -> v2 from %s, %v:\n%s\nwant\n%s", from, err, back, csv)
+	}
+
+	data, _ := canonjson.Marshal(b)
+	v1, _, err = find(t, "bom-json").Convert(data, "v1")
+	if err != nil || strings.Contains(string(v1), "formatVersion") || strings.Contains(string(v1), "GEN-") {
+		t.Errorf("v1 JSON, %v:\n%s", err, v1)
+	}
+	if same, _, _ := find(t, "bom-json").Convert(data, "v2"); string(same) != string(data) {
+		t.Errorf("converting to the same version changed the file:\n%s", same)
+	}
+}
+
+func TestConvertProfile(t *testing.T) {
+	data, _ := profiles.Marshal(profiles.DS2000())
+	old, from, err := find(t, "profile-json").Convert(data, "v0.3.0")
+	if err != nil || from != profiles.SchemaVersion {
+		t.Fatalf("Convert(v0.3.0) from %s: %v", from, err)
+	}
+	if !strings.Contains(string(old), `"version": "v0.3.0"`) || strings.Contains(string(old), "breakouts") {
+		t.Errorf("v0.3.0 profile:\n%s", old)
+	}
+}
+
+func TestSingleVersionFormats(t *testing.T) {
+	f := find(t, "plan-json")
+	if out, from, err := f.Convert([]byte(`{"leaves":2}`), "v1"); err != nil || from != "v1" || string(out) != `{"leaves":2}` {
+		t.Errorf("Convert(plan-json, v1) = %s, %s, %v", out, from, err)
+	}
+	if _, _, err := f.Convert(nil, "v2"); err == nil || err.Error() != "plan-json has no version v2 (supported: v1)" {
+		t.Errorf("Convert(plan-json, v2) error = %v", err)
+	}
+	for _, f := range Formats {
+		if len(f.Versions) > 1 && f.convert == nil {
+			t.Errorf("%s has %d versions but no converter", f.Name, len(f.Versions))
+		}
+	}
+}
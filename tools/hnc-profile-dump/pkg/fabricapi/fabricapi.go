@@ -0,0 +1,170 @@
+// Package fabricapi reads the SwitchProfile and wiring objects of a
+// running Hedgehog fabric controller through kubectl, so HNC can design
+// against the profiles a cluster actually serves instead of the built-in
+// ones, and check a deployed fabric against its design.
+package fabricapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// kubectl names of the wiring custom resources read from a cluster
+const (
+	Resource           = "switchprofiles.wiring.githedgehog.com"
+	SwitchResource     = "switches.wiring.githedgehog.com"
+	ConnectionResource = "connections.wiring.githedgehog.com"
+)
+
+// SourcePrefix starts Meta.Source of every profile read from a cluster
+const SourcePrefix = "fabric-api:"
+
+// Kubectl runs kubectl with args and returns its stdout, stopping it once
+// ctx is done
+type Kubectl func(ctx context.Context, args ...string) ([]byte, error)
+
+// Exec runs the kubectl on PATH, reporting its stderr on failure. It is
+// killed once ctx is done.
+func Exec(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("kubectl %s: %s", strings.Join(args, " "), msg)
+		}
+		return nil, fmt.Errorf("kubectl %s: %w", strings.Join(args, " "), err)
+	}
+	return out, nil
+}
+
+// Cluster selects the controller to query; empty fields leave the choice
+// to kubectl's defaults (KUBECONFIG, the current context)
+type Cluster struct {
+	Kubeconfig string
+	Context    string
+}
+
+func (c Cluster) args(args ...string) []string {
+	if c.Context != "" {
+		args = append([]string{"--context", c.Context}, args...)
+	}
+	if c.Kubeconfig != "" {
+		args = append([]string{"--kubeconfig", c.Kubeconfig}, args...)
+	}
+	return args
+}
+
+// ClusterName is the name of the cluster the kubeconfig context selects
+func ClusterName(ctx context.Context, kubectl Kubectl, c Cluster) (string, error) {
+	out, err := kubectl(ctx, c.args("config", "view", "--minify", "-o", "jsonpath={.clusters[0].name}")...)
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(string(out))
+	if name == "" {
+		return "", fmt.Errorf("kubeconfig selects no cluster")
+	}
+	return name, nil
+}
+
+// list decodes the items of every object of resource into items, a
+// pointer to a slice
+func list(ctx context.Context, kubectl Kubectl, c Cluster, resource string, items any) error {
+	out, err := kubectl(ctx, c.args("get", resource, "-o", "json")...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(out, &struct {
+		Items any `json:"items"`
+	}{items}); err != nil {
+		return fmt.Errorf("parsing %s: %w", resource, err)
+	}
+	return nil
+}
+
+// SwitchProfiles lists the cluster's SwitchProfile objects and converts
+// each with profiles.FromCRD. Meta.Source records the cluster and the
+// object's resource version, e.g. "fabric-api:prod-east@48213", so a
+// design can be traced to the exact profile revision it was built from.
+func SwitchProfiles(ctx context.Context, kubectl Kubectl, c Cluster) ([]profiles.SwitchProfile, error) {
+	cluster, err := ClusterName(ctx, kubectl, c)
+	if err != nil {
+		return nil, err
+	}
+	var items []profiles.SwitchProfileCRD
+	if err := list(ctx, kubectl, c, Resource, &items); err != nil {
+		return nil, fmt.Errorf("cluster %s: %w", cluster, err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("cluster %s has no %s objects", cluster, Resource)
+	}
+
+	result := make([]profiles.SwitchProfile, 0, len(items))
+	for _, crd := range items {
+		p, err := profiles.FromCRD(crd, SourcePrefix+cluster+"@"+crd.Metadata.ResourceVersion)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", cluster, err)
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// Switch is the part of a wiring Switch object HNC designs cover
+type Switch struct {
+	Name    string
+	Role    string // spine, server-leaf, border-leaf or mixed-leaf
+	Profile string // SwitchProfile name, e.g. celestica-ds2000
+}
+
+// Switches lists the cluster's wiring Switch objects, sorted by name
+func Switches(ctx context.Context, kubectl Kubectl, c Cluster) ([]Switch, error) {
+	var items []struct {
+		Metadata profiles.CRDMetadata `json:"metadata"`
+		Spec     struct {
+			Role    string `json:"role"`
+			Profile string `json:"profile"`
+		} `json:"spec"`
+	}
+	if err := list(ctx, kubectl, c, SwitchResource, &items); err != nil {
+		return nil, err
+	}
+	switches := make([]Switch, len(items))
+	for i, item := range items {
+		switches[i] = Switch{Name: item.Metadata.Name, Role: item.Spec.Role, Profile: item.Spec.Profile}
+	}
+	sort.Slice(switches, func(i, j int) bool { return switches[i].Name < switches[j].Name })
+	return switches, nil
+}
+
+// FabricLinks counts the leaf-spine links of the cluster's fabric
+// Connection objects; each object holds every link between one leaf and
+// one spine
+func FabricLinks(ctx context.Context, kubectl Kubectl, c Cluster) (int, error) {
+	var items []struct {
+		Spec struct {
+			Fabric *struct {
+				Links []json.RawMessage `json:"links"`
+			} `json:"fabric"`
+		} `json:"spec"`
+	}
+	if err := list(ctx, kubectl, c, ConnectionResource, &items); err != nil {
+		return 0, err
+	}
+	links := 0
+	for _, item := range items {
+		if item.Spec.Fabric != nil {
+			links += len(item.Spec.Fabric.Links)
+		}
+	}
+	return links, nil
+}
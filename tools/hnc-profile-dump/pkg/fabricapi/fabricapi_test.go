@@ -0,0 +1,62 @@
+package fabricapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// fakeKubectl answers the two kubectl calls SwitchProfiles makes, and
+// records the arguments of each
+func fakeKubectl(t *testing.T, calls *[][]string, items ...profiles.SwitchProfileCRD) Kubectl {
+	return func(_ context.Context, args ...string) ([]byte, error) {
+		*calls = append(*calls, args)
+		switch {
+		case slices.Contains(args, "config"):
+			return []byte("lab-east"), nil
+		case slices.Contains(args, Resource):
+			data, err := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": items})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return data, nil
+		}
+		return nil, errors.New("unexpected kubectl call")
+	}
+}
+
+func TestSwitchProfiles(t *testing.T) {
+	crd, err := profiles.ToCRD(profiles.DS2000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	crd.Metadata.ResourceVersion = "48213"
+	var calls [][]string
+	got, err := SwitchProfiles(context.Background(), fakeKubectl(t, &calls, crd), Cluster{Kubeconfig: "/etc/hnc/kubeconfig", Context: "lab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ModelID != "celestica-ds2000" || got[0].Meta.Source != "fabric-api:lab-east@48213" {
+		t.Fatalf("SwitchProfiles() = %+v", got)
+	}
+	want := []string{"--kubeconfig", "/etc/hnc/kubeconfig", "--context", "lab", "get", Resource, "-o", "json"}
+	if len(calls) != 2 || !reflect.DeepEqual(calls[1], want) {
+		t.Errorf("kubectl calls = %q, want the second to be %q", calls, want)
+	}
+}
+
+func TestSwitchProfilesReportsEmptyCluster(t *testing.T) {
+	var calls [][]string
+	if _, err := SwitchProfiles(context.Background(), fakeKubectl(t, &calls), Cluster{}); err == nil || !strings.Contains(err.Error(), "lab-east has no") {
+		t.Fatalf("SwitchProfiles() error = %v, want no objects in lab-east", err)
+	}
+	if want := []string{"config", "view", "--minify", "-o", "jsonpath={.clusters[0].name}"}; !reflect.DeepEqual(calls[0], want) {
+		t.Errorf("kubectl call = %q, want %q", calls[0], want)
+	}
+}
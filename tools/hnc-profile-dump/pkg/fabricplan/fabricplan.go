@@ -0,0 +1,857 @@
+// Package fabricplan sizes a two-tier leaf/spine fabric from switch
+// profiles: how many leaves carry the endpoints, how many uplinks each leaf
+// needs to meet an oversubscription target, and how many spines terminate
+// them. Smaller deployments plan a collapsed core, one leaf pair with no
+// spines, or a single switch; larger ones a three-tier Clos of leaf/spine
+// pods joined by super-spines.
+package fabricplan
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/capacity"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Request is what the fabric has to carry
+type Request struct {
+	Endpoints         int     `json:"endpoints"`
+	Oversubscription  float64 `json:"oversubscription"`            // target endpoint : uplink bandwidth, e.g. 3 for 3:1
+	MinSpines         int     `json:"minSpines"`                   // default: 2
+	EndpointSpeedGbps int     `json:"endpointSpeedGbps,omitempty"` // default: the leaf profile's endpoint speed
+	Breakout          string  `json:"breakout,omitempty"`          // split leaf uplinks and spine fabric ports, e.g. "4x25G"
+	Redundancy        string  `json:"redundancy,omitempty"`        // MCLAG or ESLAG leaf pairs; "" for single-homed endpoints
+	PeerLinks         int     `json:"peerLinks,omitempty"`         // MCLAG peer links per leaf pair, default: 2
+	Topology          string  `json:"topology,omitempty"`          // collapsed-core or single-switch; "" for leaf-spine
+	Workload          string  `json:"workload,omitempty"`          // roce for lossless RDMA; "" for general
+	// Pods, from 2, splits the endpoints over leaf/spine pods whose spines
+	// uplink to super-spines; see ComputePods
+	Pods                int     `json:"pods,omitempty"`
+	PodOversubscription float64 `json:"podOversubscription,omitempty"` // target spine leaf-facing : super-spine bandwidth, default: Oversubscription
+	MinSuperSpines      int     `json:"minSuperSpines,omitempty"`      // default: 2
+	// Classes are mixed-speed endpoints, in place of one EndpointSpeedGbps;
+	// Endpoints is their total
+	Classes []EndpointClass `json:"endpointClasses,omitempty"`
+	// External reserves border leaf ports for uplinks out of the fabric
+	External *External `json:"external,omitempty"`
+}
+
+// External is how the fabric reaches routers and firewalls outside it:
+// its last BorderLeaves leaves each give their last PortsPerLeaf endpoint
+// ports, at the endpoint port speed, to uplinks spread over the Peers
+type External struct {
+	BorderLeaves int `json:"borderLeaves"` // default: 2, or 1 on a single switch; whole pairs with redundancy
+	PortsPerLeaf int `json:"portsPerLeaf"`
+	Peers        int `json:"peers"` // default: 2, or 1 for a single external port
+}
+
+// EndpointClass is a group of endpoints at one speed, e.g. 16 100G servers
+type EndpointClass struct {
+	Count     int `json:"count"`
+	SpeedGbps int `json:"speedGbps"`
+}
+
+// String is the class as COUNTxSPEED, e.g. 16x100G
+func (c EndpointClass) String() string {
+	return fmt.Sprintf("%dx%dG", c.Count, c.SpeedGbps)
+}
+
+// ParseClasses reads comma-separated COUNTxSPEED classes, e.g.
+// 40x25G,16x100G,8x10G
+func ParseClasses(s string) ([]EndpointClass, error) {
+	var classes []EndpointClass
+	for _, part := range strings.Split(s, ",") {
+		count, speed, ok := strings.Cut(strings.TrimSpace(part), "x")
+		n, errN := strconv.Atoi(count)
+		g, errG := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(speed, "G"), "g"))
+		if !ok || errN != nil || errG != nil || n <= 0 || g <= 0 {
+			return nil, fmt.Errorf("bad endpoint class %q (want COUNTxSPEED, e.g. 40x25G)", part)
+		}
+		classes = append(classes, EndpointClass{Count: n, SpeedGbps: g})
+	}
+	return classes, nil
+}
+
+// Leaf redundancy modes. Both pair leaves and dual-home every endpoint to
+// the two leaves of its pair; MCLAG also cables peer links between them,
+// ESLAG (EVPN multihoming) needs none.
+const (
+	RedundancyNone = "none"
+	MCLAG          = "mclag"
+	ESLAG          = "eslag"
+)
+
+// Redundancies lists the accepted -redundancy values, default first
+var Redundancies = []string{RedundancyNone, MCLAG, ESLAG}
+
+// Topologies. A leaf-spine fabric uplinks every leaf to the spines; a
+// collapsed core is one leaf pair that carries the endpoints itself, with
+// no spines or uplinks; a single switch is one leaf on its own.
+const (
+	LeafSpine     = "leaf-spine"
+	CollapsedCore = "collapsed-core"
+	SingleSwitch  = "single-switch"
+)
+
+// Topologies lists the accepted -topology values, default first
+var Topologies = []string{LeafSpine, CollapsedCore, SingleSwitch}
+
+// Plan is the computed topology, written as fabric-plan.json
+type Plan struct {
+	Request                  Request `json:"request"`
+	LeafModel                string  `json:"leafModel"`
+	SpineModel               string  `json:"spineModel"`
+	Leaves                   int     `json:"leaves"`
+	Spines                   int     `json:"spines"`
+	UplinksPerLeaf           int     `json:"uplinksPerLeaf"`
+	EndpointsPerLeaf         int     `json:"endpointsPerLeaf"` // on the fullest leaf
+	DownlinkGbpsPerLeaf      int     `json:"downlinkGbpsPerLeaf"`
+	UplinkGbpsPerLeaf        int     `json:"uplinkGbpsPerLeaf"`
+	AchievedOversubscription float64 `json:"achievedOversubscription"`
+	SpinePortsUsed           int     `json:"spinePortsUsed"` // per spine, by leaf uplinks
+	SpinePortsFree           int     `json:"spinePortsFree"` // per spine, past leaf uplinks and SpineUplinks
+	LeafPairs                int     `json:"leafPairs,omitempty"`
+	PeerLinksPerLeaf         int     `json:"peerLinksPerLeaf,omitempty"` // fabric ports each leaf gives its MCLAG peer
+	// Placement is where each of the request's endpoint classes lands
+	Placement []Placement `json:"endpointPlacement,omitempty"`
+	// The last BorderLeaves leaves hold fewer endpoints to uplink out of
+	// the fabric; see External
+	BorderLeaves         int `json:"borderLeaves,omitempty"`
+	ExternalPortsPerLeaf int `json:"externalPortsPerLeaf,omitempty"`
+	ExternalPeers        int `json:"externalPeers,omitempty"`
+	// Lossless is the QoS a RoCE workload needs on every switch
+	Lossless *Lossless `json:"lossless,omitempty"`
+	// A multi-pod plan's Leaves, Spines and LeafPairs are fabric totals,
+	// split evenly over its Pods; every spine takes SpineUplinks of its
+	// last fabric ports to the super-spines
+	Pods                        int     `json:"pods,omitempty"`
+	SuperSpineModel             string  `json:"superSpineModel,omitempty"`
+	SuperSpines                 int     `json:"superSpines,omitempty"`
+	SpineUplinks                int     `json:"spineUplinks,omitempty"` // per spine
+	AchievedPodOversubscription float64 `json:"achievedPodOversubscription,omitempty"`
+	SuperSpinePortsUsed         int     `json:"superSpinePortsUsed,omitempty"` // per super-spine
+	SuperSpinePortsFree         int     `json:"superSpinePortsFree,omitempty"` // per super-spine
+}
+
+// Placement is how one endpoint class uses the leaves' endpoint ports.
+// A class spreads evenly over the leaves (or leaf pairs), the first ones
+// taking one more when it does not divide.
+type Placement struct {
+	EndpointClass
+	Breakout      string `json:"breakout,omitempty"` // endpoint port breakout the class runs on, e.g. 4x25G
+	PortSpeedGbps int    `json:"portSpeedGbps"`      // of each port or lane; above the class speed when it runs slower
+	PerPort       int    `json:"perPort"`            // endpoints per physical port
+	PerLeaf       int    `json:"perLeaf"`            // on the fullest leaf
+	PortsPerLeaf  int    `json:"portsPerLeaf"`       // physical endpoint ports on the fullest leaf
+}
+
+// OnLeaf is how many of the class's endpoints the slot-th of slots leaves
+// (or leaf pairs) holds, and the physical ports they take
+func (p Placement) OnLeaf(slot, slots int) (endpoints, ports int) {
+	endpoints = p.Count / slots
+	if slot < p.Count%slots {
+		endpoints++
+	}
+	return endpoints, ceilDiv(endpoints, p.PerPort)
+}
+
+// Topology is the plan's topology, LeafSpine for plans that name none
+func (p Plan) Topology() string {
+	if p.Request.Topology == "" {
+		return LeafSpine
+	}
+	return p.Request.Topology
+}
+
+// PodSize is how many leaves and spines each pod has: all of them in a
+// plan without pods
+func (p Plan) PodSize() (leaves, spines int) {
+	if p.Pods <= 1 {
+		return p.Leaves, p.Spines
+	}
+	return p.Leaves / p.Pods, p.Spines / p.Pods
+}
+
+// ExternalPorts is how many border leaf ports uplink out of the fabric
+func (p Plan) ExternalPorts() int {
+	return p.BorderLeaves * p.ExternalPortsPerLeaf
+}
+
+// EndpointPorts is how many leaf ports the endpoints take: one each, or
+// one on each leaf of a pair when they are dual-homed
+func (p Plan) EndpointPorts() int {
+	if p.LeafPairs > 0 {
+		return 2 * p.Request.Endpoints
+	}
+	return p.Request.Endpoints
+}
+
+// Compute picks the fewest leaves that hold the endpoints, the fewest
+// uplinks per leaf that meet the oversubscription target, and the fewest
+// spines (at least MinSpines) that split those uplinks evenly within each
+// spine's fabric ports. Uplinks are raised when no spine count fits. With
+// a Breakout, fabric ports are counted as the ports they split into. With
+// a Redundancy, leaves come in pairs that each hold a port of every
+// endpoint of the pair, and MCLAG peer links take each leaf's last fabric
+// ports, whole, before uplinks are counted. With endpoint Classes, each
+// class runs on the leaf ports or breakout lanes capacity.EndpointFit
+// picks and spreads evenly over the leaves, as few as the fullest one's
+// endpoint ports allow. With External uplinks, the border leaves give up
+// endpoint ports to them, which count toward their downlink bandwidth;
+// the leaves before them take more endpoints, and then more leaves are
+// added, until the rest fit. Endpoint classes spread over every leaf, so
+// they are an error with External uplinks. A RoCE Workload takes only
+// lossless leaves and spines and an oversubscription target of at most
+// MaxRoCEOversubscription, and the plan gains the QoS they need; uplinks
+// already split evenly, so every leaf has as many links to every spine
+// and ECMP spreads RoCE flows evenly. A collapsed-core or single-switch
+// Topology plans no spines, and spine is ignored; see computeSpineless.
+// Requests for pods are planned with ComputePods.
+func Compute(req Request, leaf, spine profiles.SwitchProfile) (Plan, error) {
+	return ComputeContext(context.Background(), req, leaf, spine)
+}
+
+// ComputeContext is Compute that gives up once ctx is done, with an error
+// wrapping ctx's, checking between the uplink counts it tries
+func ComputeContext(ctx context.Context, req Request, leaf, spine profiles.SwitchProfile) (Plan, error) {
+	if req.Pods > 1 || req.PodOversubscription != 0 || req.MinSuperSpines != 0 {
+		return Plan{}, fmt.Errorf("pods need super-spines; plan them with a super-spine model")
+	}
+	return compute(ctx, req, leaf, spine, 0)
+}
+
+// compute is Compute with the last reserved fabric ports of every spine
+// kept from leaf uplinks
+func compute(ctx context.Context, req Request, leaf, spine profiles.SwitchProfile, reserved int) (Plan, error) {
+	if err := ctx.Err(); err != nil {
+		return Plan{}, fmt.Errorf("planning stopped: %w", err)
+	}
+	if req.Endpoints < 0 || req.Endpoints == 0 && len(req.Classes) == 0 {
+		return Plan{}, fmt.Errorf("endpoints must be positive, got %d", req.Endpoints)
+	}
+	if req.MinSpines < 0 {
+		return Plan{}, fmt.Errorf("min spines must be positive, got %d", req.MinSpines)
+	}
+	switch req.Topology {
+	case LeafSpine:
+		req.Topology = ""
+	case "":
+	case CollapsedCore:
+		if req.Redundancy == "" || req.Redundancy == RedundancyNone {
+			req.Redundancy = MCLAG
+		}
+	case SingleSwitch:
+		if req.Redundancy != "" && req.Redundancy != RedundancyNone {
+			return Plan{}, fmt.Errorf("a single-switch fabric has no leaf pairs for %s redundancy", req.Redundancy)
+		}
+	default:
+		return Plan{}, fmt.Errorf("unknown topology %q (want %s)", req.Topology, strings.Join(Topologies, ", "))
+	}
+	if !slices.Contains(leaf.Roles, profiles.RoleLeaf) {
+		return Plan{}, fmt.Errorf("leaf %s does not list the leaf role", leaf.ModelID)
+	}
+	if req.Topology == "" {
+		if req.Oversubscription <= 0 {
+			return Plan{}, fmt.Errorf("oversubscription must be positive, got %g", req.Oversubscription)
+		}
+		if !slices.Contains(spine.Roles, profiles.RoleSpine) {
+			return Plan{}, fmt.Errorf("spine %s does not list the spine role", spine.ModelID)
+		}
+		if req.MinSpines == 0 {
+			req.MinSpines = 2
+		}
+	}
+	if err := checkWorkload(&req, leaf, spine); err != nil {
+		return Plan{}, err
+	}
+	if len(req.Classes) > 0 {
+		if err := checkClasses(&req); err != nil {
+			return Plan{}, err
+		}
+	} else if req.EndpointSpeedGbps == 0 {
+		req.EndpointSpeedGbps = leaf.Profiles.Endpoint.SpeedGbps
+	}
+	switch req.Redundancy {
+	case RedundancyNone:
+		req.Redundancy = ""
+		fallthrough
+	case "", ESLAG:
+		if req.PeerLinks != 0 {
+			return Plan{}, fmt.Errorf("peer links need mclag redundancy")
+		}
+	case MCLAG:
+		if req.PeerLinks == 0 {
+			req.PeerLinks = 2
+		}
+		if req.PeerLinks < 0 {
+			return Plan{}, fmt.Errorf("peer links must be positive, got %d", req.PeerLinks)
+		}
+	default:
+		return Plan{}, fmt.Errorf("unknown redundancy %q (want none, mclag or eslag)", req.Redundancy)
+	}
+	if err := checkExternal(&req); err != nil {
+		return Plan{}, err
+	}
+	var border External
+	if req.External != nil {
+		border = *req.External
+	}
+
+	endpointPorts, err := capacity.MaxEndpoints(leaf, "")
+	if err != nil {
+		return Plan{}, fmt.Errorf("leaf %w", err)
+	}
+	if endpointPorts == 0 || req.EndpointSpeedGbps <= 0 && len(req.Classes) == 0 {
+		return Plan{}, fmt.Errorf("leaf %s has no endpoint ports", leaf.ModelID)
+	}
+	if border.PortsPerLeaf > endpointPorts {
+		return Plan{}, fmt.Errorf("leaf %s has %d endpoint ports, too few for %d external uplinks", leaf.ModelID, endpointPorts, border.PortsPerLeaf)
+	}
+	externalGbps := border.PortsPerLeaf * leaf.Profiles.Endpoint.SpeedGbps
+	if req.Topology != "" {
+		return computeSpineless(req, leaf, endpointPorts, externalGbps)
+	}
+	leafFabric, uplinkGbps, err := capacity.FabricPorts(leaf, req.Breakout)
+	if err != nil {
+		return Plan{}, fmt.Errorf("leaf %w", err)
+	}
+	spineFabric, spineGbps, err := capacity.FabricPorts(spine, req.Breakout)
+	if err != nil {
+		return Plan{}, fmt.Errorf("spine %w", err)
+	}
+	if err := capacity.CheckLink(leaf, profiles.RoleLeaf, spine, profiles.RoleSpine, req.Breakout); err != nil {
+		return Plan{}, err
+	}
+	spineFabric -= reserved
+	if leafFabric == 0 || uplinkGbps <= 0 || spineFabric <= 0 {
+		return Plan{}, fmt.Errorf("leaf %s or spine %s has no fabric ports", leaf.ModelID, spine.ModelID)
+	}
+	if req.PeerLinks > 0 {
+		cages, _, _ := capacity.FabricPorts(leaf, "")
+		if req.PeerLinks >= cages {
+			return Plan{}, fmt.Errorf("leaf %s has %d fabric ports, too few for %d peer links and uplinks", leaf.ModelID, cages, req.PeerLinks)
+		}
+		leafFabric -= req.PeerLinks * leafFabric / cages
+	}
+
+	// Leaves, or leaf pairs with redundancy, to hold the endpoints
+	slots := ceilDiv(req.Endpoints, endpointPorts)
+	var placement []Placement
+	if len(req.Classes) > 0 {
+		if slots, placement, err = place(req.Classes, leaf, endpointPorts); err != nil {
+			return Plan{}, err
+		}
+	}
+	borderSlots := border.BorderLeaves
+	if req.Redundancy != "" {
+		borderSlots /= 2
+	}
+	perLeaf := ceilDiv(req.Endpoints, slots)
+	if borderSlots > 0 {
+		slots, perLeaf = withBorder(req.Endpoints, endpointPorts, slots, borderSlots, border.PortsPerLeaf)
+	}
+	leaves, pairs := slots, 0
+	if req.Redundancy != "" {
+		pairs = slots
+		leaves *= 2
+	}
+	downGbps := perLeaf * req.EndpointSpeedGbps
+	if borderSlots > 0 {
+		downGbps = max(downGbps, onSlot(req.Endpoints, perLeaf, slots-borderSlots)*req.EndpointSpeedGbps+externalGbps)
+	}
+	if placement != nil {
+		perLeaf = 0
+		for _, p := range placement {
+			perLeaf += p.PerLeaf
+			downGbps += p.PerLeaf * p.SpeedGbps
+		}
+	}
+	needed := int(math.Ceil(float64(downGbps) / (req.Oversubscription * float64(uplinkGbps))))
+	var lossless *Lossless
+	if req.Workload == RoCE {
+		lossless = newLossless(append(endpointQoS(req, leaf, placement),
+			portQoS(profiles.RoleLeaf, "fabric", leaf.Profiles.Uplink.PortProfile, uplinkGbps),
+			portQoS(profiles.RoleSpine, "fabric", spine.Profiles.Uplink.PortProfile, spineGbps))...)
+	}
+
+	for uplinks := max(needed, req.MinSpines); uplinks <= leafFabric; uplinks++ {
+		if err := ctx.Err(); err != nil {
+			return Plan{}, fmt.Errorf("planning stopped at %d uplinks per leaf: %w", uplinks, err)
+		}
+		for spines := req.MinSpines; spines <= uplinks; spines++ {
+			if uplinks%spines != 0 || leaves*uplinks/spines > spineFabric {
+				continue
+			}
+			used := leaves * uplinks / spines
+			ratio, _ := capacity.Oversubscription(downGbps, uplinks*uplinkGbps)
+			return Plan{
+				Request:                  req,
+				LeafModel:                leaf.ModelID,
+				SpineModel:               spine.ModelID,
+				Leaves:                   leaves,
+				Spines:                   spines,
+				UplinksPerLeaf:           uplinks,
+				EndpointsPerLeaf:         perLeaf,
+				DownlinkGbpsPerLeaf:      downGbps,
+				UplinkGbpsPerLeaf:        uplinks * uplinkGbps,
+				AchievedOversubscription: math.Round(ratio*100) / 100,
+				SpinePortsUsed:           used,
+				SpinePortsFree:           spineFabric - used,
+				LeafPairs:                pairs,
+				PeerLinksPerLeaf:         req.PeerLinks,
+				Placement:                placement,
+				BorderLeaves:             border.BorderLeaves,
+				ExternalPortsPerLeaf:     border.PortsPerLeaf,
+				ExternalPeers:            border.Peers,
+				Lossless:                 lossless,
+			}, nil
+		}
+	}
+	if req.MinSpines > needed {
+		return Plan{}, fmt.Errorf("no topology fits: a minimum of %d spines needs %d uplinks on each of %d leaves, one per spine, but leaf %s has %d fabric ports and spine %s has %d",
+			req.MinSpines, req.MinSpines, leaves, leaf.ModelID, leafFabric, spine.ModelID, spineFabric)
+	}
+	return Plan{}, fmt.Errorf("no topology fits: %d leaves need %d uplinks each at %g:1, but leaf %s has %d fabric ports and spine %s has %d",
+		leaves, needed, req.Oversubscription, leaf.ModelID, leafFabric, spine.ModelID, spineFabric)
+}
+
+// ComputePods plans a three-tier Clos: the endpoints split evenly over
+// req.Pods leaf/spine pods, each sized as Compute sizes a fabric, and
+// every spine uplinked to the super-spines over its last fabric ports,
+// enough of them to meet PodOversubscription between the leaf-facing
+// bandwidth of the spine and its uplinks. As at the leaves, the fewest
+// super-spines (at least MinSuperSpines) that split each spine's uplinks
+// evenly within their fabric ports are used, and spine uplinks are
+// raised when none fit. Pods run their fabric ports unsplit, so a
+// Breakout is an error, and endpoint classes and External uplinks are
+// planned per fabric, so they are too.
+func ComputePods(req Request, leaf, spine, superSpine profiles.SwitchProfile) (Plan, error) {
+	if req.Pods < 2 {
+		return Plan{}, fmt.Errorf("a multi-pod fabric needs at least 2 pods, got %d", req.Pods)
+	}
+	if req.Topology != "" && req.Topology != LeafSpine {
+		return Plan{}, fmt.Errorf("pods are leaf-spine fabrics, not %s", req.Topology)
+	}
+	if req.Breakout != "" {
+		return Plan{}, fmt.Errorf("pods run their fabric ports unsplit; drop breakout %s", req.Breakout)
+	}
+	if len(req.Classes) > 0 {
+		return Plan{}, fmt.Errorf("endpoint classes are placed over one fabric, not pods")
+	}
+	if req.External != nil {
+		return Plan{}, fmt.Errorf("border leaves uplink one two-tier fabric out, not pods")
+	}
+	if req.Endpoints < req.Pods {
+		return Plan{}, fmt.Errorf("%d endpoints do not fill %d pods", req.Endpoints, req.Pods)
+	}
+	if req.PodOversubscription == 0 {
+		req.PodOversubscription = req.Oversubscription
+	}
+	if req.PodOversubscription <= 0 {
+		return Plan{}, fmt.Errorf("pod oversubscription must be positive, got %g", req.PodOversubscription)
+	}
+	if req.MinSuperSpines == 0 {
+		req.MinSuperSpines = 2
+	}
+	if !slices.Contains(superSpine.Roles, profiles.RoleSpine) {
+		return Plan{}, fmt.Errorf("super-spine %s does not list the spine role", superSpine.ModelID)
+	}
+	if req.Workload == RoCE {
+		if err := checkLossless("super-spine", superSpine); err != nil {
+			return Plan{}, err
+		}
+		if req.PodOversubscription > MaxRoCEOversubscription {
+			return Plan{}, fmt.Errorf("a roce fabric is oversubscribed at most %d:1, not %g:1 between pods", MaxRoCEOversubscription, req.PodOversubscription)
+		}
+	}
+	spineFabric, spineGbps, err := capacity.FabricPorts(spine, "")
+	if err != nil {
+		return Plan{}, fmt.Errorf("spine %w", err)
+	}
+	superFabric, superGbps, err := capacity.FabricPorts(superSpine, "")
+	if err != nil {
+		return Plan{}, fmt.Errorf("super-spine %w", err)
+	}
+	if superFabric == 0 {
+		return Plan{}, fmt.Errorf("super-spine %s has no fabric ports", superSpine.ModelID)
+	}
+	if err := capacity.CheckLink(spine, profiles.RoleSpine, superSpine, "super-spine", ""); err != nil {
+		return Plan{}, err
+	}
+
+	podReq := req
+	podReq.Pods, podReq.PodOversubscription, podReq.MinSuperSpines = 0, 0, 0
+	podReq.Endpoints = ceilDiv(req.Endpoints, req.Pods)
+	err = fmt.Errorf("spine %s has %d fabric ports, too few for leaf uplinks and super-spine uplinks", spine.ModelID, spineFabric)
+	for reserved := req.MinSuperSpines; reserved < spineFabric; reserved++ {
+		pod, podErr := compute(context.Background(), podReq, leaf, spine, reserved)
+		if podErr != nil {
+			err = podErr
+			continue
+		}
+		downGbps := pod.SpinePortsUsed * pod.UplinkGbpsPerLeaf / pod.UplinksPerLeaf
+		needed := int(math.Ceil(float64(downGbps) / (req.PodOversubscription * float64(spineGbps))))
+		spines := pod.Spines * req.Pods
+		for uplinks := max(needed, req.MinSuperSpines); uplinks <= reserved; uplinks++ {
+			for supers := req.MinSuperSpines; supers <= uplinks; supers++ {
+				if uplinks%supers != 0 || spines*uplinks/supers > superFabric {
+					continue
+				}
+				used := spines * uplinks / supers
+				ratio, _ := capacity.Oversubscription(downGbps, uplinks*spineGbps)
+				p := pod
+				p.Request.Endpoints = req.Endpoints
+				p.Request.Pods, p.Request.PodOversubscription, p.Request.MinSuperSpines = req.Pods, req.PodOversubscription, req.MinSuperSpines
+				p.Leaves *= req.Pods
+				p.LeafPairs *= req.Pods
+				p.Spines = spines
+				p.SpinePortsFree += reserved - uplinks
+				p.Pods = req.Pods
+				p.SuperSpineModel = superSpine.ModelID
+				p.SuperSpines = supers
+				p.SpineUplinks = uplinks
+				p.AchievedPodOversubscription = math.Round(ratio*100) / 100
+				p.SuperSpinePortsUsed = used
+				p.SuperSpinePortsFree = superFabric - used
+				if p.Lossless != nil {
+					p.Lossless = newLossless(append(p.Lossless.Ports, portQoS("super-spine", "fabric", superSpine.Profiles.Uplink.PortProfile, superGbps))...)
+				}
+				return p, nil
+			}
+		}
+		err = fmt.Errorf("no topology fits: %d spines need %d uplinks each at %g:1, but super-spine %s has %d fabric ports",
+			spines, needed, req.PodOversubscription, superSpine.ModelID, superFabric)
+	}
+	return Plan{}, err
+}
+
+// computeSpineless plans a collapsed core or a single switch: one leaf,
+// or one leaf pair, that holds every endpoint on its endpoint ports. There
+// are no uplinks to count, so the oversubscription target, spines and
+// uplink breakout do not apply; a collapsed core's MCLAG peer links take
+// the last fabric ports, as a leaf-spine plan's do.
+func computeSpineless(req Request, leaf profiles.SwitchProfile, endpointPorts, externalGbps int) (Plan, error) {
+	if req.Breakout != "" {
+		return Plan{}, fmt.Errorf("breakout %s splits spine uplinks, which a %s fabric does not have", req.Breakout, req.Topology)
+	}
+	req.Oversubscription, req.MinSpines = 0, 0
+	if req.PeerLinks > 0 {
+		cages, _, _ := capacity.FabricPorts(leaf, "")
+		if req.PeerLinks > cages {
+			return Plan{}, fmt.Errorf("leaf %s has %d fabric ports, too few for %d peer links", leaf.ModelID, cages, req.PeerLinks)
+		}
+	}
+
+	perLeaf := req.Endpoints
+	downGbps := perLeaf * req.EndpointSpeedGbps
+	var placement []Placement
+	if len(req.Classes) > 0 {
+		slots, p, err := place(req.Classes, leaf, endpointPorts)
+		if err != nil {
+			return Plan{}, err
+		}
+		if slots > 1 {
+			return Plan{}, fmt.Errorf("leaf %s has %d endpoint ports, too few for the endpoint classes of a %s fabric", leaf.ModelID, endpointPorts, req.Topology)
+		}
+		placement, downGbps = p, 0
+		for _, p := range placement {
+			downGbps += p.PerLeaf * p.SpeedGbps
+		}
+	} else if perLeaf > endpointPorts {
+		return Plan{}, fmt.Errorf("leaf %s has %d endpoint ports, too few for %d endpoints on a %s fabric", leaf.ModelID, endpointPorts, perLeaf, req.Topology)
+	}
+	leaves := 1
+	if req.Redundancy != "" {
+		leaves = 2
+	}
+	var border External
+	if req.External != nil {
+		border = *req.External
+		if border.BorderLeaves != leaves {
+			return Plan{}, fmt.Errorf("a %s fabric has %d leaves, all of them border leaves, not %d", req.Topology, leaves, border.BorderLeaves)
+		}
+		if perLeaf+border.PortsPerLeaf > endpointPorts {
+			return Plan{}, fmt.Errorf("leaf %s has %d endpoint ports, too few for %d endpoints and %d external uplinks", leaf.ModelID, endpointPorts, perLeaf, border.PortsPerLeaf)
+		}
+		downGbps += externalGbps
+	}
+	var lossless *Lossless
+	if req.Workload == RoCE {
+		ports := endpointQoS(req, leaf, placement)
+		if req.PeerLinks > 0 {
+			_, gbps, _ := capacity.FabricPorts(leaf, "")
+			ports = append(ports, portQoS(profiles.RoleLeaf, "fabric", leaf.Profiles.Uplink.PortProfile, gbps))
+		}
+		lossless = newLossless(ports...)
+	}
+	plan := Plan{
+		Request:              req,
+		LeafModel:            leaf.ModelID,
+		Leaves:               1,
+		EndpointsPerLeaf:     perLeaf,
+		DownlinkGbpsPerLeaf:  downGbps,
+		PeerLinksPerLeaf:     req.PeerLinks,
+		Placement:            placement,
+		BorderLeaves:         border.BorderLeaves,
+		ExternalPortsPerLeaf: border.PortsPerLeaf,
+		ExternalPeers:        border.Peers,
+		Lossless:             lossless,
+	}
+	if req.Redundancy != "" {
+		plan.Leaves, plan.LeafPairs = 2, 1
+	}
+	return plan, nil
+}
+
+// checkExternal fills in the defaults of the request's external uplinks,
+// on a copy
+func checkExternal(req *Request) error {
+	if req.External == nil {
+		return nil
+	}
+	e := *req.External
+	if len(req.Classes) > 0 {
+		return fmt.Errorf("endpoint classes spread over every leaf, so border leaves cannot give up ports to external uplinks")
+	}
+	if e.PortsPerLeaf <= 0 {
+		return fmt.Errorf("external uplinks need a positive port count per border leaf, got %d", e.PortsPerLeaf)
+	}
+	if e.BorderLeaves == 0 {
+		e.BorderLeaves = 2
+		if req.Topology == SingleSwitch {
+			e.BorderLeaves = 1
+		}
+	}
+	if e.Peers == 0 {
+		e.Peers = min(2, e.BorderLeaves*e.PortsPerLeaf)
+	}
+	if e.BorderLeaves < 0 || e.Peers < 0 {
+		return fmt.Errorf("border leaves and external peers must be positive, got %d and %d", e.BorderLeaves, e.Peers)
+	}
+	if req.Redundancy != "" && e.BorderLeaves%2 != 0 {
+		return fmt.Errorf("%s pairs leaves, so border leaves come in pairs too, not %d", req.Redundancy, e.BorderLeaves)
+	}
+	req.External = &e
+	return nil
+}
+
+// withBorder is the fewest leaves (or leaf pairs), from slots, that hold
+// the endpoints when the last border of them each keep external of their
+// endpoint ports for uplinks, and the endpoints they each hold. Endpoints
+// fill the leaves in order, perLeaf at a time, so leaves before the
+// border ones hold more than an even share when that leaves the border
+// leaves room.
+func withBorder(endpoints, endpointPorts, slots, border, external int) (int, int) {
+	for slots = max(slots, border); ; slots++ {
+		perLeaf := ceilDiv(endpoints, slots)
+		if perLeaf > endpointPorts-external && slots > border {
+			perLeaf = max(perLeaf, ceilDiv(endpoints-endpointPorts+external, slots-border))
+		}
+		if perLeaf <= endpointPorts && onSlot(endpoints, perLeaf, slots-border) <= endpointPorts-external {
+			return slots, perLeaf
+		}
+	}
+}
+
+// onSlot is how many endpoints the slot-th leaf (or leaf pair) holds when
+// they fill the leaves in order, perLeaf at a time
+func onSlot(endpoints, perLeaf, slot int) int {
+	return min(max(endpoints-slot*perLeaf, 0), perLeaf)
+}
+
+// checkClasses totals the request's endpoint classes into Endpoints
+func checkClasses(req *Request) error {
+	if req.EndpointSpeedGbps != 0 {
+		return fmt.Errorf("endpoint classes each have their speed; drop endpoint speed %dG", req.EndpointSpeedGbps)
+	}
+	total := 0
+	for _, c := range req.Classes {
+		if c.Count <= 0 || c.SpeedGbps <= 0 {
+			return fmt.Errorf("endpoint class %s needs a positive count and speed", c)
+		}
+		total += c.Count
+	}
+	if req.Endpoints != 0 && req.Endpoints != total {
+		return fmt.Errorf("%d endpoints do not match the %d of the endpoint classes", req.Endpoints, total)
+	}
+	req.Endpoints = total
+	return nil
+}
+
+// place fits every class to the leaf's endpoint ports and finds the
+// fewest leaves (or pairs) whose fullest one holds its share of each
+func place(classes []EndpointClass, leaf profiles.SwitchProfile, endpointPorts int) (int, []Placement, error) {
+	placement := make([]Placement, len(classes))
+	total := 0
+	for i, c := range classes {
+		fit, err := capacity.EndpointFit(leaf, c.SpeedGbps)
+		if err != nil {
+			return 0, nil, fmt.Errorf("leaf %w", err)
+		}
+		placement[i] = Placement{EndpointClass: c, Breakout: fit.Breakout, PortSpeedGbps: fit.PortSpeedGbps, PerPort: fit.PerPort}
+		total += c.Count
+	}
+	for slots := 1; slots <= total; slots++ {
+		ports := 0
+		for i := range placement {
+			placement[i].PerLeaf, placement[i].PortsPerLeaf = placement[i].OnLeaf(0, slots)
+			ports += placement[i].PortsPerLeaf
+		}
+		if ports <= endpointPorts {
+			return slots, placement, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("leaf %s has %d endpoint ports, too few for one endpoint of each of %d classes", leaf.ModelID, endpointPorts, len(classes))
+}
+
+// Expand grows a plan to carry more endpoints without recabling or
+// renumbering what is deployed: the models, spines, uplinks per leaf and
+// redundancy stay as they are, and the plan gains the fewest leaves (or
+// leaf pairs) that hold the endpoints with every leaf inside the plan's
+// oversubscription on the uplinks it has. Growing past the spines' fabric
+// ports needs more spines, and with them more uplinks on every leaf, so
+// that is an error; such a fabric is replanned with Compute.
+func Expand(existing Plan, endpoints int, leaf, spine profiles.SwitchProfile) (Plan, error) {
+	if leaf.ModelID != existing.LeafModel || spine.ModelID != existing.SpineModel {
+		return Plan{}, fmt.Errorf("plan is for leaf %s and spine %s, not %s and %s",
+			existing.LeafModel, existing.SpineModel, leaf.ModelID, spine.ModelID)
+	}
+	if existing.Pods > 1 {
+		return Plan{}, fmt.Errorf("the plan has %d pods, which grow by replanning the fabric", existing.Pods)
+	}
+	if existing.Request.Topology != "" {
+		return Plan{}, fmt.Errorf("a %s fabric has no spines to add leaves to; replan it as a leaf-spine fabric", existing.Request.Topology)
+	}
+	if existing.BorderLeaves > 0 {
+		return Plan{}, fmt.Errorf("the plan's %d border leaves are its last, which new leaves would follow; replan the fabric", existing.BorderLeaves)
+	}
+	if len(existing.Request.Classes) > 0 {
+		return Plan{}, fmt.Errorf("the plan places endpoint classes, which a single endpoint count cannot grow; replan the fabric")
+	}
+	if endpoints < existing.Request.Endpoints {
+		return Plan{}, fmt.Errorf("the plan already carries %d endpoints; expanding cannot shrink it to %d", existing.Request.Endpoints, endpoints)
+	}
+	if existing.Leaves <= 0 || existing.Spines <= 0 || existing.UplinksPerLeaf <= 0 {
+		return Plan{}, fmt.Errorf("the plan has %d leaves, %d spines and %d uplinks per leaf; there is nothing to expand",
+			existing.Leaves, existing.Spines, existing.UplinksPerLeaf)
+	}
+	req := existing.Request
+	if req.EndpointSpeedGbps == 0 {
+		req.EndpointSpeedGbps = leaf.Profiles.Endpoint.SpeedGbps
+	}
+	endpointPorts, err := capacity.MaxEndpoints(leaf, "")
+	if err != nil {
+		return Plan{}, fmt.Errorf("leaf %w", err)
+	}
+	spineFabric, _, err := capacity.FabricPorts(spine, req.Breakout)
+	if err != nil {
+		return Plan{}, fmt.Errorf("spine %w", err)
+	}
+	if endpointPorts == 0 || req.EndpointSpeedGbps <= 0 {
+		return Plan{}, fmt.Errorf("leaf %s has no endpoint ports", leaf.ModelID)
+	}
+
+	// A leaf holds what its ports take and its uplinks carry within the
+	// target, and never less than the existing leaves already hold
+	perLeafMax := endpointPorts
+	if req.Oversubscription > 0 {
+		carried := int(math.Floor(req.Oversubscription*float64(existing.UplinkGbpsPerLeaf)/float64(req.EndpointSpeedGbps) + 1e-9))
+		perLeafMax = min(perLeafMax, carried)
+	}
+	perLeafMax = max(perLeafMax, existing.EndpointsPerLeaf)
+	if perLeafMax <= 0 {
+		return Plan{}, fmt.Errorf("leaf %s carries no endpoints on %dG of uplinks", leaf.ModelID, existing.UplinkGbpsPerLeaf)
+	}
+	req.Endpoints = endpoints
+	leaves, pairs := max(existing.Leaves, ceilDiv(endpoints, perLeafMax)), 0
+	perLeaf := ceilDiv(endpoints, leaves)
+	if existing.LeafPairs > 0 {
+		pairs = max(existing.LeafPairs, ceilDiv(endpoints, perLeafMax))
+		leaves, perLeaf = 2*pairs, ceilDiv(endpoints, pairs)
+	}
+	used := leaves * existing.UplinksPerLeaf / existing.Spines
+	if used > spineFabric {
+		return Plan{}, fmt.Errorf("%d leaves need %d fabric ports on each spine, spine %s has %d; more spines would recable every leaf, so replan the fabric",
+			leaves, used, spine.ModelID, spineFabric)
+	}
+
+	p := existing
+	p.Request = req
+	p.Leaves = leaves
+	p.LeafPairs = pairs
+	p.EndpointsPerLeaf = perLeaf
+	p.DownlinkGbpsPerLeaf = perLeaf * req.EndpointSpeedGbps
+	ratio, _ := capacity.Oversubscription(p.DownlinkGbpsPerLeaf, p.UplinkGbpsPerLeaf)
+	p.AchievedOversubscription = math.Round(ratio*100) / 100
+	p.SpinePortsUsed = used
+	p.SpinePortsFree = spineFabric - used
+	return p, nil
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// CSVHeader is the first row of RenderCSV
+var CSVHeader = []string{"field", "value"}
+
+// RenderCSV writes one row per value of the plan's JSON, in document
+// order, named by its path, e.g. request.endpoints or
+// request.endpointClasses[0].speedGbps
+func RenderCSV(p Plan) (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(CSVHeader)
+	if err := flatten(dec, "", w); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return b.String(), nil
+}
+
+// flatten writes the next JSON value of dec, at path, as rows
+func flatten(dec *json.Decoder, path string, w *csv.Writer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		for i := 0; dec.More(); i++ {
+			elem := fmt.Sprintf("%s[%d]", path, i)
+			if tok == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				elem = key.(string)
+				if path != "" {
+					elem = path + "." + elem
+				}
+			}
+			if err := flatten(dec, elem, w); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token() // the closing delimiter
+		return err
+	case nil:
+		return w.Write([]string{path, ""})
+	default:
+		return w.Write([]string{path, fmt.Sprint(tok)})
+	}
+}
@@ -0,0 +1,427 @@
+package fabricplan
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/capacity"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/profiles/profiletest"
+)
+
+func TestComputeSizesFabric(t *testing.T) {
+	// 200 x 25G on 48-port leaves: 5 leaves of 40 endpoints = 1000G down;
+	// 3:1 needs 334G up, so 4 x 100G uplinks over 2 spines
+	plan, err := Compute(Request{Endpoints: 200, Oversubscription: 3}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [...]int{plan.Leaves, plan.Spines, plan.UplinksPerLeaf, plan.EndpointsPerLeaf, plan.SpinePortsUsed, plan.SpinePortsFree}
+	if want := [...]int{5, 2, 4, 40, 10, 22}; got != want {
+		t.Fatalf("leaves/spines/uplinks/perLeaf/used/free = %v, want %v", got, want)
+	}
+	if plan.AchievedOversubscription != 2.5 || plan.Request.MinSpines != 2 || plan.Request.EndpointSpeedGbps != 25 {
+		t.Fatalf("plan = %+v", plan)
+	}
+}
+
+func TestComputeSplitsUplinksEvenly(t *testing.T) {
+	// 48 x 25G at 2:1 needs 6 uplinks; with at least 4 spines only 6 divides them
+	plan, err := Compute(Request{Endpoints: 48, Oversubscription: 2, MinSpines: 4}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.UplinksPerLeaf != 6 || plan.Spines != 6 {
+		t.Fatalf("uplinks/spines = %d/%d, want 6/6", plan.UplinksPerLeaf, plan.Spines)
+	}
+}
+
+func TestComputeAddsSpinesWhenPortsRunOut(t *testing.T) {
+	// 20 leaves x 4 uplinks = 80 spine ports: two 32-port spines are not enough
+	plan, err := Compute(Request{Endpoints: 20 * 48, Oversubscription: 3}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Leaves != 20 || plan.Spines != 4 || plan.UplinksPerLeaf != 4 || plan.SpinePortsUsed != 20 {
+		t.Fatalf("plan = %+v", plan)
+	}
+}
+
+func TestComputeCountsBreakoutPorts(t *testing.T) {
+	// 40 leaves do not fit 32 x 100G spine ports, but do fit 128 x 25G:
+	// 1200G down at 3:1 needs 16 x 25G uplinks, split over 8 spines
+	plan, err := Compute(Request{Endpoints: 40 * 48, Oversubscription: 3, Breakout: "4x25G"}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [...]int{plan.Leaves, plan.Spines, plan.UplinksPerLeaf, plan.UplinkGbpsPerLeaf, plan.SpinePortsUsed, plan.SpinePortsFree}
+	if want := [...]int{40, 8, 16, 400, 80, 48}; got != want {
+		t.Fatalf("leaves/spines/uplinks/uplinkGbps/used/free = %v, want %v", got, want)
+	}
+
+	if _, err := Compute(Request{Endpoints: 48, Oversubscription: 3, Breakout: "2x50G"}, profiles.DS2000(), profiles.DS3000()); err == nil ||
+		!strings.Contains(err.Error(), "do not support breakout 2x50G") {
+		t.Errorf("unknown breakout error = %v", err)
+	}
+}
+
+func TestComputeReportsWhenNothingFits(t *testing.T) {
+	cases := []Request{
+		{Endpoints: 48, Oversubscription: 1},      // 12 uplinks, a DS2000 has 8 fabric ports
+		{Endpoints: 40 * 48, Oversubscription: 3}, // 40 leaves, a DS3000 has 32 ports
+	}
+	for _, req := range cases {
+		if _, err := Compute(req, profiles.DS2000(), profiles.DS3000()); err == nil || !strings.Contains(err.Error(), "no topology fits") {
+			t.Errorf("Compute(%+v) error = %v, want no topology fits", req, err)
+		}
+	}
+	// The spine minimum, not the oversubscription, is what does not fit
+	if _, err := Compute(Request{Endpoints: 96, Oversubscription: 3, MinSpines: 40}, profiles.DS2000(), profiles.DS3000()); err == nil ||
+		!strings.Contains(err.Error(), "a minimum of 40 spines needs 40 uplinks on each of 2 leaves") {
+		t.Errorf("Compute(40 spines) error = %v", err)
+	}
+	for _, req := range []Request{{Endpoints: -5, Oversubscription: 3, Classes: []EndpointClass{{Count: 8, SpeedGbps: 25}}}, {Endpoints: 96, Oversubscription: 3, MinSpines: -1}} {
+		if _, err := Compute(req, profiles.DS2000(), profiles.DS3000()); err == nil || !strings.Contains(err.Error(), "must be positive, got -") {
+			t.Errorf("Compute(%+v) error = %v, want must be positive", req, err)
+		}
+	}
+}
+
+func TestComputeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := Request{Endpoints: 96, Oversubscription: 3}
+	if _, err := ComputeContext(ctx, req, profiles.DS2000(), profiles.DS3000()); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err := ComputeContext(ctx, req, profiles.DS2000(), profiles.DS3000()); !errors.Is(err, context.Canceled) {
+		t.Errorf("ComputeContext(cancelled) error = %v", err)
+	}
+}
+
+// Spines whose fabric ports cannot take the leaf uplinks are refused,
+// whatever the port counts
+func TestComputeChecksLinks(t *testing.T) {
+	ds4000, _ := profiles.Default().Get("celestica-ds4000")
+	if _, err := Compute(Request{Endpoints: 96, Oversubscription: 3}, profiles.DS2000(), ds4000); err == nil ||
+		!strings.Contains(err.Error(), "cannot link to spine celestica-ds4000") {
+		t.Errorf("Compute(DS4000 spines) error = %v", err)
+	}
+	req := Request{Endpoints: 2000, Oversubscription: 3, Pods: 4}
+	if _, err := ComputePods(req, profiles.DS2000(), profiles.DS3000(), ds4000); err == nil ||
+		!strings.Contains(err.Error(), "cannot link to super-spine celestica-ds4000") {
+		t.Errorf("ComputePods(DS4000 super-spines) error = %v", err)
+	}
+}
+
+func TestComputeRejectsBadRequests(t *testing.T) {
+	for _, req := range []Request{{Endpoints: 0, Oversubscription: 3}, {Endpoints: 10, Oversubscription: 0}} {
+		if _, err := Compute(req, profiles.DS2000(), profiles.DS3000()); err == nil {
+			t.Errorf("Compute(%+v) succeeded", req)
+		}
+	}
+}
+
+func TestComputePairsLeaves(t *testing.T) {
+	// 96 dual-homed endpoints fill both leaves of 2 pairs; MCLAG gives 2 of
+	// each leaf's 8 fabric ports to its peer, leaving 6 for the 6 uplinks
+	// 2:1 needs, while ESLAG keeps all 8
+	for _, tc := range []struct {
+		redundancy string
+		peerLinks  int
+	}{{MCLAG, 2}, {ESLAG, 0}} {
+		plan, err := Compute(Request{Endpoints: 96, Oversubscription: 2, Redundancy: tc.redundancy}, profiles.DS2000(), profiles.DS3000())
+		if err != nil {
+			t.Fatalf("%s: %v", tc.redundancy, err)
+		}
+		got := [...]int{plan.LeafPairs, plan.Leaves, plan.EndpointsPerLeaf, plan.UplinksPerLeaf, plan.PeerLinksPerLeaf, plan.EndpointPorts()}
+		if want := [...]int{2, 4, 48, 6, tc.peerLinks, 192}; got != want {
+			t.Errorf("%s: pairs/leaves/perLeaf/uplinks/peerLinks/endpointPorts = %v, want %v", tc.redundancy, got, want)
+		}
+	}
+
+	// 1.5:1 needs all 8 fabric ports as uplinks, which only ESLAG leaves free
+	if _, err := Compute(Request{Endpoints: 96, Oversubscription: 1.5, Redundancy: MCLAG}, profiles.DS2000(), profiles.DS3000()); err == nil {
+		t.Error("MCLAG plan used the peer link ports as uplinks")
+	}
+	if _, err := Compute(Request{Endpoints: 96, Oversubscription: 1.5, Redundancy: ESLAG}, profiles.DS2000(), profiles.DS3000()); err != nil {
+		t.Errorf("ESLAG: %v", err)
+	}
+
+	for _, req := range []Request{
+		{Endpoints: 96, Oversubscription: 3, Redundancy: "vpc"},
+		{Endpoints: 96, Oversubscription: 3, Redundancy: ESLAG, PeerLinks: 2},
+		{Endpoints: 96, Oversubscription: 3, Redundancy: MCLAG, PeerLinks: 8},
+	} {
+		if _, err := Compute(req, profiles.DS2000(), profiles.DS3000()); err == nil {
+			t.Errorf("Compute(%+v) succeeded", req)
+		}
+	}
+}
+
+func TestComputeProperties(t *testing.T) {
+	g, r := profiletest.New(1), rand.New(rand.NewSource(1))
+	planned := 0
+	for i := 0; i < 500; i++ {
+		leaf, spine := g.Fabric()
+		req := Request{
+			Endpoints:        1 + r.Intn(2000),
+			Oversubscription: []float64{1, 1.5, 2, 3, 4}[r.Intn(5)],
+			MinSpines:        1 + r.Intn(4),
+		}
+		if r.Intn(3) == 0 {
+			req.Breakout = profiletest.Breakout(leaf, spine)
+		}
+		plan, err := Compute(req, leaf, spine)
+		if err != nil {
+			continue // nothing fits; the error case is covered above
+		}
+		planned++
+		leafFabric, _, _ := capacity.FabricPorts(leaf, req.Breakout)
+		spineFabric, _, _ := capacity.FabricPorts(spine, req.Breakout)
+		switch {
+		case plan.Leaves*plan.EndpointsPerLeaf < req.Endpoints:
+			t.Fatalf("%d leaves of %d endpoints do not hold %d", plan.Leaves, plan.EndpointsPerLeaf, req.Endpoints)
+		case plan.Spines < req.MinSpines || plan.UplinksPerLeaf%plan.Spines != 0:
+			t.Fatalf("%d uplinks over %d spines (min %d)", plan.UplinksPerLeaf, plan.Spines, req.MinSpines)
+		case plan.UplinksPerLeaf > leafFabric || plan.SpinePortsUsed > spineFabric || plan.SpinePortsUsed+plan.SpinePortsFree != spineFabric:
+			t.Fatalf("plan overruns the fabric ports (leaf %d, spine %d): %+v", leafFabric, spineFabric, plan)
+		case plan.AchievedOversubscription > req.Oversubscription+0.005:
+			t.Fatalf("achieved %g:1, target %g:1", plan.AchievedOversubscription, req.Oversubscription)
+		}
+	}
+	if planned < 100 {
+		t.Fatalf("only %d of 500 random fabrics planned", planned)
+	}
+}
+
+func TestExpandAddsLeaves(t *testing.T) {
+	plan, err := Compute(Request{Endpoints: 200, Oversubscription: 3}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 400 endpoints on the same 4 x 100G uplinks: 9 leaves of at most 48
+	grown, err := Expand(plan, 400, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [...]int{grown.Leaves, grown.Spines, grown.UplinksPerLeaf, grown.EndpointsPerLeaf, grown.SpinePortsUsed, grown.SpinePortsFree}
+	if want := [...]int{9, 2, 4, 45, 18, 14}; got != want {
+		t.Fatalf("leaves/spines/uplinks/perLeaf/used/free = %v, want %v", got, want)
+	}
+	if grown.AchievedOversubscription != 2.81 || grown.Request.Endpoints != 400 || grown.Request.Oversubscription != 3 {
+		t.Fatalf("grown = %+v", grown)
+	}
+
+	paired, err := Compute(Request{Endpoints: 40, Oversubscription: 3, Redundancy: MCLAG}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grown, err := Expand(paired, 90, profiles.DS2000(), profiles.DS3000()); err != nil || grown.LeafPairs != 2 || grown.Leaves != 4 {
+		t.Fatalf("Expand(mclag) = %+v, %v", grown, err)
+	}
+
+	for _, tc := range []struct {
+		endpoints int
+		want      string
+	}{
+		{100, "cannot shrink it to 100"},
+		{1000, "more spines would recable every leaf"},
+	} {
+		if _, err := Expand(plan, tc.endpoints, profiles.DS2000(), profiles.DS3000()); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Expand(%d) = %v, want %q", tc.endpoints, err, tc.want)
+		}
+	}
+}
+
+func TestComputePlacesEndpointClasses(t *testing.T) {
+	classes, err := ParseClasses("160x25G, 16x100G,8x10G")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A leaf of 48 100G endpoint ports that break out to 4x25G: 25G and
+	// 10G endpoints take a lane each, 100G a whole port. One leaf would
+	// need 40+16+2 ports; two need 20+8+1.
+	leaf := profiles.DS2000()
+	leaf.Profiles.Endpoint = profiles.PortProfile{SpeedGbps: 100, Breakouts: []profiles.BreakoutOption{{Mode: "4x25G", Lanes: 4, SpeedGbps: 25, PortPattern: "{port}/{lane}"}}}
+	plan, err := Compute(Request{Oversubscription: 4, Classes: classes}, leaf, profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Leaves != 2 || plan.Request.Endpoints != 184 || plan.EndpointsPerLeaf != 92 || plan.DownlinkGbpsPerLeaf != 2840 || plan.UplinksPerLeaf != 8 {
+		t.Fatalf("plan = %+v", plan)
+	}
+	want := []Placement{
+		{EndpointClass: classes[0], Breakout: "4x25G", PortSpeedGbps: 25, PerPort: 4, PerLeaf: 80, PortsPerLeaf: 20},
+		{EndpointClass: classes[1], PortSpeedGbps: 100, PerPort: 1, PerLeaf: 8, PortsPerLeaf: 8},
+		{EndpointClass: classes[2], Breakout: "4x25G", PortSpeedGbps: 25, PerPort: 4, PerLeaf: 4, PortsPerLeaf: 1},
+	}
+	for i, p := range plan.Placement {
+		if p != want[i] {
+			t.Errorf("placement %d = %+v, want %+v", i, p, want[i])
+		}
+	}
+	if n, ports := plan.Placement[2].OnLeaf(1, 2); n != 4 || ports != 1 {
+		t.Errorf("10G class on leaf 2 = %d endpoints on %d ports", n, ports)
+	}
+
+	for _, tc := range []struct {
+		req  Request
+		want string
+	}{
+		{Request{Oversubscription: 3, Classes: classes}, "run at 25G, too slow for 100G endpoints"},
+		{Request{Oversubscription: 3, Classes: classes[:1], EndpointSpeedGbps: 25}, "drop endpoint speed"},
+		{Request{Oversubscription: 3, Classes: classes[:1], Endpoints: 10}, "10 endpoints do not match the 160"},
+	} {
+		if _, err := Compute(tc.req, profiles.DS2000(), profiles.DS3000()); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Compute(%+v) = %v, want %q", tc.req, err, tc.want)
+		}
+	}
+	if _, err := ParseClasses("40x25G,x10G"); err == nil || !strings.Contains(err.Error(), `bad endpoint class "x10G"`) {
+		t.Errorf("ParseClasses error = %v", err)
+	}
+}
+
+func TestComputeSpinelessTopologies(t *testing.T) {
+	core, err := Compute(Request{Endpoints: 40, Oversubscription: 3, Topology: CollapsedCore}, profiles.DS2000(), profiles.SwitchProfile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [...]int{core.Leaves, core.LeafPairs, core.Spines, core.UplinksPerLeaf, core.EndpointsPerLeaf, core.PeerLinksPerLeaf}
+	if want := [...]int{2, 1, 0, 0, 40, 2}; got != want || core.Request.Redundancy != MCLAG || core.SpineModel != "" || core.Topology() != CollapsedCore {
+		t.Fatalf("collapsed core leaves/pairs/spines/uplinks/perLeaf/peerLinks = %v, want %v: %+v", got, want, core)
+	}
+
+	single, err := Compute(Request{Endpoints: 48, Topology: SingleSwitch}, profiles.DS2000(), profiles.SwitchProfile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if single.Leaves != 1 || single.LeafPairs != 0 || single.Spines != 0 || single.DownlinkGbpsPerLeaf != 48*25 {
+		t.Fatalf("single switch = %+v", single)
+	}
+	if plan, _ := Compute(Request{Endpoints: 48, Oversubscription: 3, Topology: LeafSpine}, profiles.DS2000(), profiles.DS3000()); plan.Request.Topology != "" || plan.Topology() != LeafSpine {
+		t.Errorf("leaf-spine plan records topology %q", plan.Request.Topology)
+	}
+
+	for _, tt := range []struct {
+		req   Request
+		spine profiles.SwitchProfile
+		want  string
+	}{
+		{Request{Endpoints: 49, Topology: SingleSwitch}, profiles.SwitchProfile{}, "too few for 49 endpoints on a single-switch fabric"},
+		{Request{Endpoints: 10, Topology: SingleSwitch, Redundancy: ESLAG}, profiles.SwitchProfile{}, "no leaf pairs for eslag"},
+		{Request{Endpoints: 10, Topology: CollapsedCore, Breakout: "4x25G"}, profiles.SwitchProfile{}, "which a collapsed-core fabric does not have"},
+		{Request{Endpoints: 10, Topology: "ring"}, profiles.SwitchProfile{}, `unknown topology "ring"`},
+		{Request{Endpoints: 10, Oversubscription: 3}, profiles.DS2000(), "spine celestica-ds2000 does not list the spine role"},
+	} {
+		if _, err := Compute(tt.req, profiles.DS2000(), tt.spine); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compute(%+v) error = %v, want %q", tt.req, err, tt.want)
+		}
+	}
+	if _, err := Expand(core, 60, profiles.DS2000(), profiles.SwitchProfile{}); err == nil || !strings.Contains(err.Error(), "no spines to add leaves to") {
+		t.Errorf("Expand(collapsed core) error = %v", err)
+	}
+}
+
+func TestComputePods(t *testing.T) {
+	req := Request{Endpoints: 2000, Oversubscription: 3, Pods: 4}
+	plan, err := ComputePods(req, profiles.DS2000(), profiles.DS3000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [...]int{plan.Pods, plan.Leaves, plan.Spines, plan.SuperSpines, plan.SpineUplinks, plan.SuperSpinePortsFree}
+	if want := [...]int{4, 44, 8, 2, 8, 0}; got != want || plan.AchievedPodOversubscription != 2.75 || plan.Request.Endpoints != 2000 {
+		t.Fatalf("pods/leaves/spines/superSpines/spineUplinks/superFree = %v, want %v: %+v", got, want, plan)
+	}
+	if leaves, spines := plan.PodSize(); leaves != 11 || spines != 2 {
+		t.Errorf("PodSize() = %d, %d, want 11, 2", leaves, spines)
+	}
+	if plan.SpinePortsUsed+plan.SpineUplinks+plan.SpinePortsFree != 32 {
+		t.Errorf("spine ports used %d + uplinks %d + free %d, want 32", plan.SpinePortsUsed, plan.SpineUplinks, plan.SpinePortsFree)
+	}
+
+	for _, tt := range []struct {
+		req  Request
+		want string
+	}{
+		{Request{Endpoints: 2000, Pods: 1}, "at least 2 pods"},
+		{Request{Endpoints: 2000, Pods: 4, Breakout: "4x25G"}, "pods run their fabric ports unsplit"},
+		{Request{Endpoints: 3, Pods: 4}, "do not fill 4 pods"},
+		{Request{Endpoints: 2000, Pods: 4, Topology: CollapsedCore}, "pods are leaf-spine fabrics"},
+	} {
+		if _, err := ComputePods(tt.req, profiles.DS2000(), profiles.DS3000(), profiles.DS3000()); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ComputePods(%+v) error = %v, want %q", tt.req, err, tt.want)
+		}
+	}
+	if _, err := Compute(req, profiles.DS2000(), profiles.DS3000()); err == nil || !strings.Contains(err.Error(), "pods need super-spines") {
+		t.Errorf("Compute(pods) error = %v", err)
+	}
+	if _, err := Expand(plan, 2400, profiles.DS2000(), profiles.DS3000()); err == nil {
+		t.Error("Expand(pods) succeeded")
+	}
+}
+
+func TestComputeReservesBorderLeafPorts(t *testing.T) {
+	// Two 48-port leaves would hold 96 endpoints, but not with 4 ports
+	// of each border leaf uplinked out
+	plan, err := Compute(Request{Endpoints: 96, Oversubscription: 3, External: &External{PortsPerLeaf: 4}}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [...]int{plan.Leaves, plan.EndpointsPerLeaf, plan.BorderLeaves, plan.ExternalPortsPerLeaf, plan.ExternalPeers, plan.ExternalPorts(), plan.DownlinkGbpsPerLeaf}
+	if want := [...]int{3, 32, 2, 4, 2, 8, 36 * 25}; got != want {
+		t.Fatalf("leaves/perLeaf/border/ports/peers/external/downGbps = %v, want %v", got, want)
+	}
+
+	// A dedicated border pair gives all its endpoint ports to uplinks
+	plan, err = Compute(Request{Endpoints: 96, Oversubscription: 3, Redundancy: MCLAG, External: &External{PortsPerLeaf: 48, Peers: 4}}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.LeafPairs != 3 || plan.BorderLeaves != 2 || plan.EndpointsPerLeaf != 48 || plan.ExternalPeers != 4 {
+		t.Errorf("dedicated border pair = %+v", plan)
+	}
+
+	for _, tt := range []struct {
+		req  Request
+		want string
+	}{
+		{Request{Endpoints: 96, Oversubscription: 3, External: &External{PortsPerLeaf: 49}}, "too few for 49 external uplinks"},
+		{Request{Endpoints: 96, Oversubscription: 3, Redundancy: ESLAG, External: &External{PortsPerLeaf: 2, BorderLeaves: 1}}, "border leaves come in pairs"},
+		{Request{Classes: []EndpointClass{{Count: 8, SpeedGbps: 25}}, Oversubscription: 3, External: &External{PortsPerLeaf: 2}}, "endpoint classes spread over every leaf"},
+		{Request{Endpoints: 40, Topology: SingleSwitch, External: &External{PortsPerLeaf: 2, BorderLeaves: 2}}, "all of them border leaves, not 2"},
+		{Request{Endpoints: 47, Topology: SingleSwitch, External: &External{PortsPerLeaf: 2}}, "too few for 47 endpoints and 2 external uplinks"},
+	} {
+		if _, err := Compute(tt.req, profiles.DS2000(), profiles.DS3000()); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compute(%+v) error = %v, want %q", tt.req, err, tt.want)
+		}
+	}
+	if _, err := Expand(plan, 200, profiles.DS2000(), profiles.DS3000()); err == nil || !strings.Contains(err.Error(), "border leaves are its last") {
+		t.Errorf("Expand(border leaves) error = %v", err)
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	p := Plan{
+		Request:   Request{Endpoints: 80, Classes: []EndpointClass{{Count: 40, SpeedGbps: 25}, {Count: 40, SpeedGbps: 100}}},
+		LeafModel: "celestica-ds2000",
+		Leaves:    2,
+	}
+	got, err := RenderCSV(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"field,value\nrequest.endpoints,80\n",
+		"request.endpointClasses[0].count,40\nrequest.endpointClasses[0].speedGbps,25\nrequest.endpointClasses[1].count,40\n",
+		"\nleafModel,celestica-ds2000\n",
+		"\nleaves,2\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderCSV missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package fabricplan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Workloads. A general fabric carries whatever its endpoints send; a RoCE
+// fabric carries RDMA over Converged Ethernet, which needs every switch
+// lossless and the fabric lightly loaded.
+const (
+	WorkloadGeneral = "general"
+	RoCE            = "roce"
+)
+
+// Workloads lists the accepted -workload values, default first
+var Workloads = []string{WorkloadGeneral, RoCE}
+
+// MaxRoCEOversubscription is the most a RoCE fabric's leaves and spines
+// may be oversubscribed: past it, PFC pauses spread congestion across the
+// fabric faster than ECN can slow the senders
+const MaxRoCEOversubscription = 2
+
+// Lossless is the QoS every switch of a RoCE plan needs: RoCE traffic in
+// one lossless priority with PFC, congestion notification packets (CNPs)
+// in a strict priority, and per port profile the PFC headroom and ECN
+// thresholds for its speed.
+type Lossless struct {
+	Priority    int       `json:"priority"`    // 802.1p priority and traffic class PFC pauses
+	DSCP        int       `json:"dscp"`        // RoCE packets' marking, trusted and mapped to Priority
+	CNPPriority int       `json:"cnpPriority"` // strict priority for CNPs
+	CNPDSCP     int       `json:"cnpDscp"`
+	MTU         int       `json:"mtu"`
+	Ports       []PortQoS `json:"ports"`
+}
+
+// PortQoS is the buffer settings of one kind of port
+type PortQoS struct {
+	Role        string `json:"role"`  // leaf, spine or super-spine
+	Ports       string `json:"ports"` // endpoint or fabric
+	PortProfile string `json:"portProfile,omitempty"`
+	SpeedGbps   int    `json:"speedGbps"`
+	HeadroomKB  int    `json:"headroomKb"` // PFC headroom per port, for what arrives after a pause is sent
+	ECNMinKB    int    `json:"ecnMinKb"`   // lossless queue depth ECN marking starts at
+	ECNMaxKB    int    `json:"ecnMaxKb"`   // and marks every packet by
+}
+
+// RoCE QoS, as NVIDIA's RoCE configurations set it
+const (
+	rocePriority         = 3
+	roceDSCP             = 26
+	cnpPriority          = 6
+	cnpDSCP              = 48
+	losslessMTU          = 9216
+	headroomBytesPerGbps = 250 // 2µs at 1G: round trip on 100 m of cable, then the pause response
+)
+
+// portQoS sizes a port's PFC headroom, two jumbo frames in flight plus
+// what arrives at speed before the pause takes effect, and its ECN
+// thresholds, scaled from the 150 KB and 1.5 MB used at 100G
+func portQoS(role, ports string, profile *string, speedGbps int) PortQoS {
+	q := PortQoS{Role: role, Ports: ports, SpeedGbps: speedGbps,
+		HeadroomKB: ceilDiv(2*losslessMTU+speedGbps*headroomBytesPerGbps, 1024),
+		ECNMinKB:   speedGbps * 3 / 2,
+		ECNMaxKB:   speedGbps * 15,
+	}
+	if profile != nil {
+		q.PortProfile = *profile
+	}
+	return q
+}
+
+// newLossless is the QoS for ports, each kind once
+func newLossless(ports ...PortQoS) *Lossless {
+	l := &Lossless{Priority: rocePriority, DSCP: roceDSCP, CNPPriority: cnpPriority, CNPDSCP: cnpDSCP, MTU: losslessMTU}
+	for _, p := range ports {
+		dup := false
+		for _, q := range l.Ports {
+			dup = dup || q == p
+		}
+		if !dup {
+			l.Ports = append(l.Ports, p)
+		}
+	}
+	return l
+}
+
+// endpointQoS is the QoS of a leaf's endpoint ports at every speed they
+// run at
+func endpointQoS(req Request, leaf profiles.SwitchProfile, placement []Placement) []PortQoS {
+	if len(placement) == 0 {
+		return []PortQoS{portQoS(profiles.RoleLeaf, "endpoint", leaf.Profiles.Endpoint.PortProfile, req.EndpointSpeedGbps)}
+	}
+	var ports []PortQoS
+	for _, p := range placement {
+		ports = append(ports, portQoS(profiles.RoleLeaf, "endpoint", leaf.Profiles.Endpoint.PortProfile, p.PortSpeedGbps))
+	}
+	return ports
+}
+
+// checkWorkload normalizes the request's workload and, for RoCE, checks
+// the switches are lossless and the oversubscription target within
+// MaxRoCEOversubscription; spine is ignored when the topology has none
+func checkWorkload(req *Request, leaf, spine profiles.SwitchProfile) error {
+	switch req.Workload {
+	case WorkloadGeneral:
+		req.Workload = ""
+		return nil
+	case "":
+		return nil
+	case RoCE:
+	default:
+		return fmt.Errorf("unknown workload %q (want %s)", req.Workload, strings.Join(Workloads, ", "))
+	}
+	if err := checkLossless("leaf", leaf); err != nil {
+		return err
+	}
+	if req.Topology != "" {
+		return nil
+	}
+	if err := checkLossless("spine", spine); err != nil {
+		return err
+	}
+	if req.Oversubscription > MaxRoCEOversubscription {
+		return fmt.Errorf("a roce fabric is oversubscribed at most %d:1, not %g:1", MaxRoCEOversubscription, req.Oversubscription)
+	}
+	return nil
+}
+
+// checkLossless reports a switch whose profile does not say it runs RoCE
+// lossless; a profile without capabilities is not known to
+func checkLossless(role string, p profiles.SwitchProfile) error {
+	if p.Capabilities == nil || !p.Capabilities.Features.RoCE {
+		return fmt.Errorf("%s %s is not lossless: a roce workload needs capabilities.features.roce in its profile", role, p.ModelID)
+	}
+	return nil
+}
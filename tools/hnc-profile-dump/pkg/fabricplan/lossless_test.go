@@ -0,0 +1,72 @@
+package fabricplan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// lossless gives a copy of p the RoCE capability
+func lossless(p profiles.SwitchProfile) profiles.SwitchProfile {
+	p.Capabilities = &profiles.Capabilities{ASIC: "trident3", Features: profiles.Features{RoCE: true}}
+	return p
+}
+
+// lossy gives a copy of p capabilities without RoCE
+func lossy(p profiles.SwitchProfile) profiles.SwitchProfile {
+	p.Capabilities = &profiles.Capabilities{ASIC: "trident3"}
+	return p
+}
+
+func TestComputeRoCE(t *testing.T) {
+	leaf, spine := lossless(profiles.DS2000()), lossless(profiles.DS3000())
+	// 48 x 25G at 2:1 needs 6 x 100G uplinks, 3 to each spine
+	plan, err := Compute(Request{Endpoints: 96, Oversubscription: 2, Workload: RoCE}, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.UplinksPerLeaf != 6 || plan.Spines != 2 || plan.Lossless == nil {
+		t.Fatalf("uplinks/spines = %d/%d, lossless %v", plan.UplinksPerLeaf, plan.Spines, plan.Lossless)
+	}
+	want := Lossless{Priority: 3, DSCP: 26, CNPPriority: 6, CNPDSCP: 48, MTU: 9216, Ports: []PortQoS{
+		{Role: "leaf", Ports: "endpoint", PortProfile: "SFP28-25G", SpeedGbps: 25, HeadroomKB: 25, ECNMinKB: 37, ECNMaxKB: 375},
+		{Role: "leaf", Ports: "fabric", PortProfile: "QSFP28-100G", SpeedGbps: 100, HeadroomKB: 43, ECNMinKB: 150, ECNMaxKB: 1500},
+		{Role: "spine", Ports: "fabric", PortProfile: "QSFP28-100G", SpeedGbps: 100, HeadroomKB: 43, ECNMinKB: 150, ECNMaxKB: 1500},
+	}}
+	if !reflect.DeepEqual(*plan.Lossless, want) {
+		t.Errorf("lossless =\n%+v\nwant\n%+v", *plan.Lossless, want)
+	}
+
+	general, err := Compute(Request{Endpoints: 96, Oversubscription: 2, Workload: WorkloadGeneral}, profiles.DS2000(), profiles.DS3000())
+	if err != nil || general.Lossless != nil || general.Request.Workload != "" {
+		t.Errorf("general workload = %+v, %v", general, err)
+	}
+	core, err := Compute(Request{Endpoints: 40, Topology: CollapsedCore, Workload: RoCE}, leaf, profiles.SwitchProfile{})
+	if err != nil || core.Lossless == nil || len(core.Lossless.Ports) != 2 {
+		t.Errorf("collapsed core = %+v, %v; want endpoint and peer link QoS", core.Lossless, err)
+	}
+}
+
+func TestComputeRoCERejects(t *testing.T) {
+	leaf, spine := lossless(profiles.DS2000()), lossless(profiles.DS3000())
+	for _, tc := range []struct {
+		req         Request
+		leaf, spine profiles.SwitchProfile
+		want        string
+	}{
+		{Request{Endpoints: 96, Oversubscription: 2, Workload: RoCE}, lossy(profiles.DS2000()), spine, "leaf celestica-ds2000 is not lossless"},
+		{Request{Endpoints: 96, Oversubscription: 2, Workload: RoCE}, leaf, lossy(profiles.DS3000()), "spine celestica-ds3000 is not lossless"},
+		{Request{Endpoints: 96, Oversubscription: 3, Workload: RoCE}, leaf, spine, "oversubscribed at most 2:1, not 3:1"},
+		{Request{Endpoints: 96, Oversubscription: 2, Workload: "hpc"}, leaf, spine, `unknown workload "hpc"`},
+	} {
+		if _, err := Compute(tc.req, tc.leaf, tc.spine); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Compute(%+v) = %v, want %q", tc.req, err, tc.want)
+		}
+	}
+	if _, err := ComputePods(Request{Endpoints: 400, Oversubscription: 2, Pods: 2, Workload: RoCE}, leaf, spine, lossy(profiles.DS3000())); err == nil ||
+		!strings.Contains(err.Error(), "super-spine celestica-ds3000 is not lossless") {
+		t.Errorf("ComputePods() with a lossy super-spine = %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+// Package facilities rolls a fabric plan's switches up into what the data
+// center has to provide: rack units, weight, typical and maximum power
+// and heat, per rack and for the whole fabric, from the physical figures
+// in the switch profiles. Leaves (leaf pairs stay together), spines and
+// super-spines are placed in racks of their own, in plan order.
+package facilities
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Layout is how many switches share a rack; 0 takes the default
+type Layout struct {
+	LeavesPerRack int // default 1, or the leaf pair for redundant plans
+	SpinesPerRack int // default all the spines in one rack
+	// SuperSpinesPerRack is for multi-pod plans, default all in one rack
+	SuperSpinesPerRack int
+}
+
+// Budget is what a set of switches takes from the facility. A figure a
+// switch's profile lacks is listed in Missing, so reports show it missing
+// rather than a total that reads low, and in Report.Warnings. A lacking
+// maximum power counts as the typical draw instead.
+type Budget struct {
+	Switches          int      `json:"switches"`
+	RackUnits         int      `json:"rackUnits"`
+	WeightKg          float64  `json:"weightKg"`
+	TypicalPowerWatts float64  `json:"typicalPowerWatts"`
+	MaxPowerWatts     float64  `json:"maxPowerWatts"`
+	HeatBTUPerHour    float64  `json:"heatBtuPerHour"`
+	Missing           []string `json:"missing,omitempty"` // figures, by JSON name, some of the switches lack
+}
+
+// Figures lists the figures of a budget by JSON name, in report order
+var Figures = []string{"rackUnits", "weightKg", "typicalPowerWatts", "maxPowerWatts", "heatBtuPerHour"}
+
+func (b *Budget) add(p *profiles.Physical) {
+	b.Switches++
+	if p == nil {
+		b.lack(Figures...)
+		return
+	}
+	if p.RackUnits == 0 {
+		b.lack("rackUnits")
+	}
+	if p.WeightKg == 0 {
+		b.lack("weightKg")
+	}
+	b.RackUnits += p.RackUnits
+	b.WeightKg = round(b.WeightKg + p.WeightKg)
+	b.TypicalPowerWatts = round(b.TypicalPowerWatts + p.TypicalPowerWatts)
+	b.MaxPowerWatts = round(b.MaxPowerWatts + max(p.MaxPowerWatts, p.TypicalPowerWatts))
+	b.HeatBTUPerHour = round(b.HeatBTUPerHour + p.Heat())
+}
+
+// lack adds figures to Missing, keeping it in report order
+func (b *Budget) lack(figures ...string) {
+	for _, f := range figures {
+		if !slices.Contains(b.Missing, f) {
+			b.Missing = append(b.Missing, f)
+		}
+	}
+	slices.SortFunc(b.Missing, func(x, y string) int { return slices.Index(Figures, x) - slices.Index(Figures, y) })
+}
+
+// Figure is the total of a figure, by JSON name, formatted, or "missing"
+// when some of the switches lack it
+func (b Budget) Figure(name string) string {
+	if slices.Contains(b.Missing, name) {
+		return "missing"
+	}
+	switch name {
+	case "rackUnits":
+		return strconv.Itoa(b.RackUnits)
+	case "weightKg":
+		return format(b.WeightKg)
+	case "typicalPowerWatts":
+		return format(b.TypicalPowerWatts)
+	case "maxPowerWatts":
+		return format(b.MaxPowerWatts)
+	case "heatBtuPerHour":
+		return format(b.HeatBTUPerHour)
+	}
+	return ""
+}
+
+// round keeps sums to 0.1, so float error does not show in reports
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// Rack is one rack's switches and their budget
+type Rack struct {
+	Name     string   `json:"name"`
+	Switches []string `json:"switches"`
+	Budget   Budget   `json:"budget"`
+}
+
+// Report is the rollup of a plan
+type Report struct {
+	Racks    []Rack   `json:"racks"`
+	Fabric   Budget   `json:"fabric"`
+	Warnings []string `json:"warnings,omitempty"` // models missing figures
+}
+
+// FromPlan places the plan's leaves in racks leaf-rack1, leaf-rack2, ...,
+// its spines in spine-rack1, ... and a multi-pod plan's super-spines in
+// superspine-rack1, ..., and sums each rack's switches. superSpine is
+// ignored for plans without pods.
+func FromPlan(plan fabricplan.Plan, leaf, spine, superSpine profiles.SwitchProfile, layout Layout) (Report, error) {
+	if layout.LeavesPerRack == 0 {
+		layout.LeavesPerRack = 1
+		if plan.LeafPairs > 0 {
+			layout.LeavesPerRack = 2
+		}
+	}
+	if layout.SpinesPerRack == 0 {
+		layout.SpinesPerRack = max(plan.Spines, 1)
+	}
+	if layout.SuperSpinesPerRack == 0 {
+		layout.SuperSpinesPerRack = max(plan.SuperSpines, 1)
+	}
+	if layout.LeavesPerRack < 0 || layout.SpinesPerRack < 0 || layout.SuperSpinesPerRack < 0 {
+		return Report{}, fmt.Errorf("switches per rack must be positive")
+	}
+	if plan.LeafPairs > 0 && layout.LeavesPerRack%2 != 0 {
+		return Report{}, fmt.Errorf("%s leaf pairs share a rack, so leaves per rack must be even, got %d", plan.Request.Redundancy, layout.LeavesPerRack)
+	}
+
+	var r Report
+	for _, tier := range []struct {
+		role    string
+		count   int
+		perRack int
+		profile profiles.SwitchProfile
+	}{
+		{"superspine", plan.SuperSpines, layout.SuperSpinesPerRack, superSpine},
+		{profiles.RoleSpine, plan.Spines, layout.SpinesPerRack, spine},
+		{profiles.RoleLeaf, plan.Leaves, layout.LeavesPerRack, leaf},
+	} {
+		if tier.count == 0 {
+			continue
+		}
+		for _, w := range missing(tier.profile) {
+			// a super-spine is often the spine model
+			if !slices.Contains(r.Warnings, w) {
+				r.Warnings = append(r.Warnings, w)
+			}
+		}
+		for i := 0; i < tier.count; i++ {
+			if i%tier.perRack == 0 {
+				r.Racks = append(r.Racks, Rack{Name: tier.role + "-rack" + strconv.Itoa(i/tier.perRack+1)})
+			}
+			rack := &r.Racks[len(r.Racks)-1]
+			rack.Switches = append(rack.Switches, tier.role+strconv.Itoa(i+1))
+			rack.Budget.add(tier.profile.Physical)
+			r.Fabric.add(tier.profile.Physical)
+		}
+	}
+	return r, nil
+}
+
+// missing lists the figures p's profile lacks
+func missing(p profiles.SwitchProfile) []string {
+	ph := p.Physical
+	if ph == nil {
+		return []string{p.ModelID + " has no physical figures; the report shows them missing"}
+	}
+	var fields []string
+	if ph.MaxPowerWatts == 0 {
+		fields = append(fields, "maxPowerWatts (counted as typical)")
+	}
+	if ph.RackUnits == 0 {
+		fields = append(fields, "rackUnits")
+	}
+	if ph.WeightKg == 0 {
+		fields = append(fields, "weightKg")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return []string{p.ModelID + " has no physical." + strings.Join(fields, ", physical.")}
+}
+
+// CSVHeader is the first row of RenderCSV
+var CSVHeader = []string{"rack", "switches", "rack_units", "weight_kg", "typical_power_w", "max_power_w", "heat_btu_per_hour"}
+
+// RenderCSV writes one row per rack, then a fabric row with the totals;
+// a figure some of the switches lack reads missing
+func RenderCSV(r Report) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(CSVHeader)
+	row := func(name string, budget Budget) {
+		fields := []string{name, strconv.Itoa(budget.Switches)}
+		for _, f := range Figures {
+			fields = append(fields, budget.Figure(f))
+		}
+		w.Write(fields)
+	}
+	for _, rack := range r.Racks {
+		row(rack.Name, rack.Budget)
+	}
+	row("fabric", r.Fabric)
+	w.Flush()
+	return b.String()
+}
+
+func format(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
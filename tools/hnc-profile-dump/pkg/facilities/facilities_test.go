@@ -0,0 +1,79 @@
+package facilities
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func TestFromPlanRollsUpRacks(t *testing.T) {
+	leaf, spine := profiles.DS2000(), profiles.DS3000()
+	leaf.Physical = &profiles.Physical{TypicalPowerWatts: 300, MaxPowerWatts: 500, HeatBTUPerHour: 1800, RackUnits: 1, WeightKg: 10}
+	spine.Physical = &profiles.Physical{TypicalPowerWatts: 400, MaxPowerWatts: 700, RackUnits: 2, WeightKg: 15.5}
+	plan := fabricplan.Plan{Leaves: 5, Spines: 2}
+
+	r, err := FromPlan(plan, leaf, spine, profiles.SwitchProfile{}, Layout{LeavesPerRack: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, rack := range r.Racks {
+		names = append(names, rack.Name+"="+strings.Join(rack.Switches, ","))
+	}
+	if got, want := strings.Join(names, " "), "spine-rack1=spine1,spine2 leaf-rack1=leaf1,leaf2 leaf-rack2=leaf3,leaf4 leaf-rack3=leaf5"; got != want {
+		t.Errorf("racks = %s, want %s", got, want)
+	}
+	if got, want := r.Racks[0].Budget, (Budget{Switches: 2, RackUnits: 4, WeightKg: 31, TypicalPowerWatts: 800, MaxPowerWatts: 1400, HeatBTUPerHour: 4776.8}); !reflect.DeepEqual(got, want) {
+		t.Errorf("spine rack = %+v, want %+v", got, want)
+	}
+	if got, want := r.Fabric, (Budget{Switches: 7, RackUnits: 9, WeightKg: 81, TypicalPowerWatts: 2300, MaxPowerWatts: 3900, HeatBTUPerHour: 13776.8}); !reflect.DeepEqual(got, want) {
+		t.Errorf("fabric = %+v, want %+v", got, want)
+	}
+	if len(r.Warnings) > 0 {
+		t.Errorf("warnings = %v", r.Warnings)
+	}
+	if csv := RenderCSV(r); !strings.HasSuffix(csv, "fabric,7,9,81,2300,3900,13776.8\n") {
+		t.Errorf("CSV = %s", csv)
+	}
+}
+
+func TestFromPlanWarnsAboutMissingFigures(t *testing.T) {
+	leaf := profiles.DS2000()
+	leaf.Physical = &profiles.Physical{TypicalPowerWatts: 300}
+	plan := fabricplan.Plan{Leaves: 4, Spines: 2, LeafPairs: 2, Request: fabricplan.Request{Redundancy: fabricplan.MCLAG}}
+
+	spine := profiles.DS3000()
+	spine.Physical = nil
+	r, err := FromPlan(plan, leaf, spine, profiles.SwitchProfile{}, Layout{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Racks) != 3 || len(r.Racks[1].Switches) != 2 {
+		t.Errorf("racks = %+v, want pairs sharing racks", r.Racks)
+	}
+	if r.Fabric.MaxPowerWatts != 1200 {
+		t.Errorf("max power = %g, want the leaves' typical 1200", r.Fabric.MaxPowerWatts)
+	}
+	if got, want := r.Racks[1].Budget.Missing, []string{"rackUnits", "weightKg"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("leaf rack missing %v, want %v", got, want)
+	}
+	if got := r.Fabric.Missing; !reflect.DeepEqual(got, Figures) {
+		t.Errorf("fabric missing %v, want every figure", got)
+	}
+	if csv := RenderCSV(r); !strings.Contains(csv, "leaf-rack1,2,missing,missing,600,600,2047.2\n") ||
+		!strings.HasSuffix(csv, "fabric,6,missing,missing,missing,missing,missing\n") {
+		t.Errorf("CSV = %s", csv)
+	}
+	warnings := strings.Join(r.Warnings, "; ")
+	for _, want := range []string{"celestica-ds3000 has no physical figures", "celestica-ds2000 has no physical.maxPowerWatts (counted as typical), physical.rackUnits"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("warnings = %s, want %q", warnings, want)
+		}
+	}
+	if _, err := FromPlan(plan, leaf, spine, profiles.SwitchProfile{}, Layout{LeavesPerRack: 3}); err == nil {
+		t.Error("FromPlan() split a leaf pair across racks")
+	}
+}
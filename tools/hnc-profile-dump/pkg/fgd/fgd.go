@@ -0,0 +1,82 @@
+// Package fgd describes the Fabric Generation Document: the three YAML
+// files (servers.yaml, switches.yaml, connections.yaml) the frontend
+// writes under fgd/<fabric-id>/ in its legacy output format. The types
+// mirror src/io/yaml.ts and carry doc tags for the schema reference.
+package fgd
+
+// Files maps each FGD file name to the document it holds
+var Files = []struct {
+	Name string
+	Doc  any
+}{
+	{"servers.yaml", ServersFile{}},
+	{"switches.yaml", SwitchesFile{}},
+	{"connections.yaml", ConnectionsFile{}},
+}
+
+// Labeled is the labels and annotations any device or connection may carry
+type Labeled struct {
+	Labels      map[string]string `json:"labels,omitempty" doc:"Free-form labels, e.g. rack or network; used by policies and exports"`
+	Annotations map[string]string `json:"annotations,omitempty" doc:"Tool-written metadata under hnc.githedgehog.com/, e.g. link-length or link-reach"`
+}
+
+// Asset is a device's physical identity, filled in at deployment time
+type Asset struct {
+	SerialNumber string `json:"serialNumber,omitempty" doc:"Chassis serial number from procurement data"`
+	AssetTag     string `json:"assetTag,omitempty" doc:"Site asset tag"`
+	MACAddress   string `json:"macAddress,omitempty" doc:"Management MAC address, bound from ZTP discovery"`
+}
+
+type ServersFile struct {
+	Servers  []Server     `json:"servers" doc:"Endpoint servers, sorted by id"`
+	Metadata FileMetadata `json:"metadata" doc:"Counts and generation time"`
+}
+
+type Server struct {
+	ID          string `json:"id" doc:"Unique server id, e.g. server-1"`
+	Type        string `json:"type" doc:"Server class, e.g. compute-standard"`
+	Connections int    `json:"connections" doc:"Number of NICs cabled to the fabric"`
+	Labeled
+	Asset
+}
+
+type SwitchesFile struct {
+	Switches []Switch     `json:"switches" doc:"Spines and leaves, sorted by id"`
+	Metadata FileMetadata `json:"metadata" doc:"Counts and generation time"`
+}
+
+type Switch struct {
+	ID    string `json:"id" doc:"Unique switch id, e.g. leaf-1"`
+	Type  string `json:"type" doc:"Fabric role: spine or leaf"`
+	Model string `json:"model" doc:"Switch model, a profile model ID or short name such as DS2000"`
+	Ports int    `json:"ports" doc:"Front-panel port count"`
+	Labeled
+	Asset
+}
+
+type ConnectionsFile struct {
+	Connections []Connection `json:"connections" doc:"Every cable, sorted by from.device then from.port"`
+	Metadata    FileMetadata `json:"metadata" doc:"Counts, fabric name and generation time"`
+}
+
+type Connection struct {
+	From Endpoint `json:"from" doc:"Near end; the server for endpoint links, the leaf for uplinks"`
+	To   Endpoint `json:"to" doc:"Far end; the leaf for endpoint links, the spine for uplinks"`
+	Type string   `json:"type" doc:"Link type: uplink, downlink or endpoint"`
+	Labeled
+}
+
+type Endpoint struct {
+	Device string `json:"device" doc:"Device id"`
+	Port   string `json:"port" doc:"Port name on that device, e.g. E1/1 or eth0"`
+}
+
+// FileMetadata is the metadata block each file ends with; each file
+// carries only its own count
+type FileMetadata struct {
+	GeneratedAt      string `json:"generatedAt" doc:"RFC 3339 time the design was generated"`
+	FabricName       string `json:"fabricName,omitempty" doc:"Fabric name (connections.yaml)"`
+	TotalServers     int    `json:"totalServers,omitempty" doc:"Number of servers (servers.yaml)"`
+	TotalSwitches    int    `json:"totalSwitches,omitempty" doc:"Number of switches (switches.yaml)"`
+	TotalConnections int    `json:"totalConnections,omitempty" doc:"Number of connections (connections.yaml)"`
+}
@@ -0,0 +1,51 @@
+package fgd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CompressedExtensions are the suffixes of compressed FGD files, e.g.
+// servers.yaml.gz, as src/io/compression.ts writes them
+var CompressedExtensions = []string{".gz", ".zst"}
+
+// chunkFile names one chunk of a chunked file, e.g. servers/part-0001.yaml
+var chunkFile = regexp.MustCompile(`^part-(\d+)\.yaml(\.gz|\.zst)?$`)
+
+// Stored returns the paths holding FGD file name (e.g. servers.yaml) in
+// fabricDir, in whichever layout the frontend saved it: the plain file, a
+// compressed one, or a directory of chunks in chunk order. It returns nil
+// if the file is not stored.
+func Stored(fabricDir, name string) []string {
+	for _, ext := range append([]string{""}, CompressedExtensions...) {
+		path := filepath.Join(fabricDir, name+ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return []string{path}
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(fabricDir, strings.TrimSuffix(name, ".yaml")))
+	if err != nil {
+		return nil
+	}
+	var chunks []string
+	for _, e := range entries {
+		if chunkFile.MatchString(e.Name()) && !e.IsDir() {
+			chunks = append(chunks, e.Name())
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunkNumber(chunks[i]) < chunkNumber(chunks[j]) })
+	var paths []string
+	for _, c := range chunks {
+		paths = append(paths, filepath.Join(fabricDir, strings.TrimSuffix(name, ".yaml"), c))
+	}
+	return paths
+}
+
+func chunkNumber(name string) int {
+	n, _ := strconv.Atoi(chunkFile.FindStringSubmatch(name)[1])
+	return n
+}
@@ -0,0 +1,305 @@
+// Package iac renders a fabric plan and its cabling map as infrastructure
+// as code: the Hedgehog wiring objects the fabric controller reads (one
+// Switch per switch, one fabric Connection per leaf-spine pair, one
+// mclag-domain Connection per MCLAG pair), as Terraform kubernetes_manifest
+// resources, a Pulumi YAML program or the wiring diagram YAML hhfab reads,
+// so a design is applied rather than transcribed. Objects come out in plan and cable order, so an unchanged
+// design renders byte for byte the same.
+package iac
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+	"github.com/hnc/profile-dump/pkg/yamlenc"
+)
+
+// Formats lists the supported output formats, default first
+var Formats = []string{"terraform", "pulumi"}
+
+// APIVersion of the Hedgehog wiring objects
+const APIVersion = "wiring.githedgehog.com/v1beta1"
+
+// Namespace the wiring objects are created in
+const Namespace = "default"
+
+// Object is one wiring object to create
+type Object struct {
+	Kind      string
+	Name      string
+	Spec      map[string]any
+	DependsOn []string // IDs of the objects the controller needs first
+}
+
+// ID names the object across a rendering, e.g. switch-leaf1
+func (o Object) ID() string {
+	return strings.ToLower(o.Kind) + "-" + o.Name
+}
+
+// manifest is the object as kubectl would apply it
+func (o Object) manifest() map[string]any {
+	return map[string]any{
+		"apiVersion": APIVersion,
+		"kind":       o.Kind,
+		"metadata":   map[string]any{"name": o.Name, "namespace": Namespace},
+		"spec":       o.Spec,
+	}
+}
+
+// Build lists the wiring objects of a plan cabled as m: SwitchGroups for
+// redundant leaf pairs, spines, leaves, fabric Connections in the order
+// their first cable appears in m, then MCLAG domains. With a numbered
+// map, switches carry their ASN and loopback and fabric links their /31s.
+func Build(plan fabricplan.Plan, m cabling.Map) []Object {
+	switches := map[string]map[string]any{}
+	var objects []Object
+	for i := 0; i < plan.LeafPairs; i++ {
+		objects = append(objects, Object{Kind: "SwitchGroup", Name: groupName(plan, i), Spec: map[string]any{}})
+	}
+	for i := 0; i < plan.Spines+plan.Leaves; i++ {
+		name, spec := "spine"+strconv.Itoa(i+1), map[string]any{"role": "spine", "profile": plan.SpineModel}
+		var deps []string
+		if i >= plan.Spines {
+			leaf := i - plan.Spines
+			name, spec = "leaf"+strconv.Itoa(leaf+1), map[string]any{"role": "server-leaf", "profile": plan.LeafModel}
+			if leaf < 2*plan.LeafPairs {
+				group := groupName(plan, leaf/2)
+				spec["redundancy"] = map[string]any{"group": group, "type": plan.Request.Redundancy}
+				deps = []string{Object{Kind: "SwitchGroup", Name: group}.ID()}
+			}
+		}
+		spec["description"] = fmt.Sprintf("%s %s", spec["role"], spec["profile"])
+		if host := m.Hostnames[name]; host != "" {
+			spec["description"] = fmt.Sprintf("%s, %s", host, spec["description"])
+		}
+		switches[name] = spec
+		objects = append(objects, Object{Kind: "Switch", Name: name, Spec: spec, DependsOn: deps})
+	}
+	addresses := map[string][2]string{} // link -> spine, leaf address
+	if a := m.Addressing; a != nil {
+		for _, sw := range a.Switches {
+			if spec, ok := switches[sw.Name]; ok {
+				spec["asn"] = sw.ASN
+				spec["protocolIP"] = sw.Loopback
+			}
+		}
+		for _, l := range a.Links {
+			if l.Subnet != "" {
+				addresses[l.Link] = [2]string{l.SpineAddress, l.LeafAddress}
+			}
+		}
+	}
+
+	fabric := map[string]*Object{}
+	var order []string
+	for _, c := range m.Cables {
+		name := c.Spine + "--fabric--" + c.Leaf
+		conn, ok := fabric[name]
+		if !ok {
+			conn = &Object{Kind: "Connection", Name: name, Spec: map[string]any{"fabric": map[string]any{"links": []any{}}},
+				DependsOn: []string{switchID(c.Spine), switchID(c.Leaf)}}
+			fabric[name] = conn
+			order = append(order, name)
+		}
+		spine, leaf := map[string]any{"port": c.Spine + "/" + c.SpinePort}, map[string]any{"port": c.Leaf + "/" + c.LeafPort}
+		if ip, ok := addresses[c.Link]; ok {
+			spine["ip"], leaf["ip"] = ip[0], ip[1]
+		}
+		f := conn.Spec["fabric"].(map[string]any)
+		f["links"] = append(f["links"].([]any), map[string]any{"spine": spine, "leaf": leaf})
+	}
+	for _, name := range order {
+		objects = append(objects, *fabric[name])
+	}
+
+	domains := map[string]*Object{}
+	order = order[:0]
+	for _, p := range m.PeerLinks {
+		name := p.Leaf + "--mclag-domain--" + p.Peer
+		conn, ok := domains[name]
+		if !ok {
+			conn = &Object{Kind: "Connection", Name: name, Spec: map[string]any{"mclagDomain": map[string]any{"peerLinks": []any{}}},
+				DependsOn: []string{switchID(p.Leaf), switchID(p.Peer)}}
+			domains[name] = conn
+			order = append(order, name)
+		}
+		d := conn.Spec["mclagDomain"].(map[string]any)
+		d["peerLinks"] = append(d["peerLinks"].([]any), map[string]any{
+			"switch1": map[string]any{"port": p.Leaf + "/" + p.LeafPort},
+			"switch2": map[string]any{"port": p.Peer + "/" + p.PeerPort},
+		})
+	}
+	for _, name := range order {
+		objects = append(objects, *domains[name])
+	}
+	return objects
+}
+
+// VLANNamespace is the default VLANNamespace, holding the VLANs VPCs are
+// given from
+func VLANNamespace(vlans vpcplan.Range) Object {
+	return Object{Kind: "VLANNamespace", Name: "default", Spec: map[string]any{
+		"ranges": []any{map[string]any{"from": vlans.First, "to": vlans.Last}},
+	}}
+}
+
+// groupName names leaf pair i's SwitchGroup after its redundancy, e.g.
+// mclag-1
+func groupName(plan fabricplan.Plan, i int) string {
+	return plan.Request.Redundancy + "-" + strconv.Itoa(i+1)
+}
+
+func switchID(name string) string {
+	return Object{Kind: "Switch", Name: name}.ID()
+}
+
+// Render writes the objects in one of Formats
+func Render(objects []Object, format string) (string, error) {
+	switch format {
+	case "terraform":
+		return Terraform(objects), nil
+	case "pulumi":
+		return Pulumi(objects)
+	}
+	return "", fmt.Errorf("unknown format %q (want %s)", format, strings.Join(Formats, " or "))
+}
+
+// Terraform writes a kubernetes_manifest resource per object for the
+// hashicorp/kubernetes provider, configured as usual through its provider
+// block or KUBE_CONFIG_PATH
+func Terraform(objects []Object) string {
+	var b strings.Builder
+	b.WriteString("# Generated by hnc export; regenerate rather than edit\n\n")
+	b.WriteString("terraform {\n  required_providers {\n    kubernetes = {\n      source = \"hashicorp/kubernetes\"\n    }\n  }\n}\n")
+	for _, o := range objects {
+		fmt.Fprintf(&b, "\nresource \"kubernetes_manifest\" %q {\n", tfName(o.ID()))
+		b.WriteString("  manifest = ")
+		writeHCL(&b, o.manifest(), 2)
+		b.WriteString("\n")
+		if len(o.DependsOn) > 0 {
+			deps := make([]string, len(o.DependsOn))
+			for i, id := range o.DependsOn {
+				deps[i] = "kubernetes_manifest." + tfName(id)
+			}
+			fmt.Fprintf(&b, "\n  depends_on = [%s]\n", strings.Join(deps, ", "))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+var tfInvalid = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// tfName makes an object ID a Terraform resource name: switch-leaf1 ->
+// switch_leaf1, connection-spine1--fabric--leaf1 ->
+// connection_spine1_fabric_leaf1
+func tfName(id string) string {
+	return tfInvalid.ReplaceAllString(id, "_")
+}
+
+var hclIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// writeHCL writes a value built of maps, slices, strings and integers as
+// an HCL expression, object keys sorted
+func writeHCL(b *strings.Builder, v any, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("{\n")
+		for _, k := range keys {
+			if !hclIdent.MatchString(k) {
+				k = hclString(k)
+			}
+			b.WriteString(pad + "  " + k + " = ")
+			writeHCL(b, v[k], indent+2)
+			b.WriteString("\n")
+		}
+		b.WriteString(pad + "}")
+	case []any:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[\n")
+		for _, item := range v {
+			b.WriteString(pad + "  ")
+			writeHCL(b, item, indent+2)
+			b.WriteString(",\n")
+		}
+		b.WriteString(pad + "]")
+	case string:
+		b.WriteString(hclString(v))
+	default:
+		fmt.Fprint(b, v)
+	}
+}
+
+// hclString quotes s, escaping the template sequences HCL would expand
+func hclString(s string) string {
+	s = strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+	return strconv.Quote(s)
+}
+
+// Wiring writes the objects as a Hedgehog wiring diagram, one YAML
+// document per object in the order given, as hhfab init --wiring reads it
+func Wiring(objects []Object) (string, error) {
+	var b strings.Builder
+	b.WriteString("# Generated by hnc export wiring; regenerate rather than edit\n")
+	for i, o := range objects {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		data, err := yamlenc.Marshal(o.manifest())
+		if err != nil {
+			return "", err
+		}
+		b.Write(data)
+	}
+	return b.String(), nil
+}
+
+// Pulumi writes a Pulumi YAML program with a kubernetes resource per
+// object, the wiring types addressed as kubernetes:<apiVersion>:<kind>
+func Pulumi(objects []Object) (string, error) {
+	resources := map[string]any{}
+	for _, o := range objects {
+		manifest := o.manifest()
+		res := map[string]any{
+			"type":       "kubernetes:" + APIVersion + ":" + o.Kind,
+			"properties": map[string]any{"metadata": manifest["metadata"], "spec": manifest["spec"]},
+		}
+		if len(o.DependsOn) > 0 {
+			deps := make([]string, len(o.DependsOn))
+			for i, id := range o.DependsOn {
+				deps[i] = "${" + id + "}"
+			}
+			res["options"] = map[string]any{"dependsOn": deps}
+		}
+		resources[o.ID()] = res
+	}
+	data, err := yamlenc.Marshal(map[string]any{
+		"name":        "hnc-fabric",
+		"runtime":     "yaml",
+		"description": "Generated by hnc export; regenerate rather than edit",
+		"resources":   resources,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,137 @@
+package iac
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/addressing"
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+func design(t *testing.T, req fabricplan.Request) (fabricplan.Plan, cabling.Map) {
+	t.Helper()
+	leaf, spine := profiles.DS2000(), profiles.DS3000()
+	p, err := fabricplan.Compute(req, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := cabling.Assign(p, leaf, spine, cabling.RoundRobin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, m
+}
+
+func TestBuild(t *testing.T) {
+	// 2 leaves x 4 uplinks over 2 spines, one MCLAG pair
+	p, m := design(t, fabricplan.Request{Endpoints: 40, Oversubscription: 3, Redundancy: fabricplan.MCLAG})
+	objects := Build(p, m)
+	var ids []string
+	for _, o := range objects {
+		ids = append(ids, o.ID())
+	}
+	want := "switchgroup-mclag-1 switch-spine1 switch-spine2 switch-leaf1 switch-leaf2 " +
+		"connection-spine1--fabric--leaf1 connection-spine2--fabric--leaf1 connection-spine1--fabric--leaf2 connection-spine2--fabric--leaf2 " +
+		"connection-leaf1--mclag-domain--leaf2"
+	if got := strings.Join(ids, " "); got != want {
+		t.Fatalf("objects =\n%s\nwant\n%s", got, want)
+	}
+	leaf1 := objects[3].Spec
+	if leaf1["role"] != "server-leaf" || leaf1["redundancy"].(map[string]any)["group"] != "mclag-1" || objects[3].DependsOn[0] != "switchgroup-mclag-1" {
+		t.Errorf("leaf1 = %+v", objects[3])
+	}
+	links := objects[5].Spec["fabric"].(map[string]any)["links"].([]any)
+	if len(links) != 2 || links[0].(map[string]any)["leaf"].(map[string]any)["port"] != "leaf1/E1/49" {
+		t.Errorf("spine1--fabric--leaf1 links = %+v", links)
+	}
+	if _, ok := leaf1["asn"]; ok {
+		t.Error("unnumbered map gave leaf1 an ASN")
+	}
+}
+
+func TestBuildNumbered(t *testing.T) {
+	p, m := design(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3})
+	if err := m.Address(p, addressing.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	objects := Build(p, m)
+	if leaf2 := objects[3].Spec; leaf2["asn"] != uint32(65102) || leaf2["protocolIP"] != "172.30.8.3/32" {
+		t.Errorf("leaf2 = %+v", leaf2)
+	}
+	link := objects[4].Spec["fabric"].(map[string]any)["links"].([]any)[0].(map[string]any)
+	if link["spine"].(map[string]any)["ip"] != "172.30.128.0/31" || link["leaf"].(map[string]any)["ip"] != "172.30.128.1/31" {
+		t.Errorf("first link = %+v", link)
+	}
+}
+
+func TestRender(t *testing.T) {
+	p, m := design(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3})
+	objects := Build(p, m)
+
+	tf, err := Render(objects, "terraform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`source = "hashicorp/kubernetes"`,
+		"resource \"kubernetes_manifest\" \"connection_spine1_fabric_leaf1\" {\n  manifest = {\n    apiVersion = \"wiring.githedgehog.com/v1beta1\"\n",
+		`port = "spine1/E1/1"`,
+		"depends_on = [kubernetes_manifest.switch_spine1, kubernetes_manifest.switch_leaf1]",
+	} {
+		if !strings.Contains(tf, want) {
+			t.Errorf("terraform output lacks %q:\n%s", want, tf)
+		}
+	}
+	if again, _ := Render(Build(p, m), "terraform"); again != tf {
+		t.Error("same design rendered differently")
+	}
+
+	pulumi, err := Render(objects, "pulumi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"runtime: \"yaml\"\n",
+		"  switch-leaf1:\n    properties:\n",
+		`type: "kubernetes:wiring.githedgehog.com/v1beta1:Switch"`,
+		`- "${switch-spine1}"`,
+	} {
+		if !strings.Contains(pulumi, want) {
+			t.Errorf("pulumi output lacks %q:\n%s", want, pulumi)
+		}
+	}
+
+	if _, err := Render(objects, "cdk"); err == nil {
+		t.Error("Render accepted an unknown format")
+	}
+}
+
+func TestHCLString(t *testing.T) {
+	if got := hclString(`a "${b}" %{c}`); got != `"a \"$${b}\" %%{c}"` {
+		t.Errorf("hclString = %s", got)
+	}
+}
+
+func TestWiring(t *testing.T) {
+	p, m := design(t, fabricplan.Request{Endpoints: 40, Oversubscription: 3, Redundancy: fabricplan.MCLAG})
+	out, err := Wiring(append([]Object{VLANNamespace(vpcplan.DefaultVLANs)}, Build(p, m)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs := strings.Count(out, "\n---\n") + 1; docs != 11 {
+		t.Errorf("Wiring wrote %d documents, want 11:\n%s", docs, out)
+	}
+	for _, want := range []string{
+		"apiVersion: \"wiring.githedgehog.com/v1beta1\"\nkind: \"VLANNamespace\"\nmetadata:\n  name: \"default\"",
+		"ranges:\n    - from: 1000\n      to: 2999\n",
+		"name: \"spine2--fabric--leaf1\"",
+		"- leaf:\n          port: \"leaf1/E1/49\"",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Wiring lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Package inventory reads a server inventory, the hosts a fabric is built
+// for and their NICs, as CSV or JSON, so the planner sizes the fabric from
+// the actual servers and cables and attaches them server by server rather
+// than as numbered endpoints.
+//
+// A server's NICs are its endpoints: each NIC of a single-homed server is
+// one, cabled to one leaf, and each pair of a dual-homed server's NICs is
+// one, cabled to both leaves of a leaf pair. Servers are placed in
+// inventory order with those of a rack hint kept together, the racks in
+// the order they first appear, so a rack's servers fill the same leaves
+// where they fit.
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/ports"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+// Redundancy requirements of a server
+const (
+	Single = "single" // each NIC to one leaf
+	Dual   = "dual"   // NICs in pairs, one to each leaf of a pair
+)
+
+// Server is one inventoried host
+type Server struct {
+	Hostname     string `json:"hostname"`
+	NICs         int    `json:"nics"`
+	NICSpeedGbps int    `json:"nicSpeedGbps"`
+	Rack         string `json:"rack,omitempty"` // hint to keep the rack's servers on the same leaves
+	Redundancy   string `json:"redundancy"`     // Single or Dual
+}
+
+// Inventory is the servers of a fabric, written as endpoints.json by hnc
+// import endpoints
+type Inventory struct {
+	Servers []Server `json:"servers"`
+}
+
+// CSVHeader is the columns of a CSV inventory; rack and redundancy may be
+// left out, and columns may come in any order
+var CSVHeader = []string{"hostname", "nics", "nic_speed", "rack", "redundancy"}
+
+// hostnamePattern is what a hostname may be, as it names connections
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]{0,61}[A-Za-z0-9])?$`)
+
+// Parse reads an inventory, as JSON when it is a JSON object and as CSV
+// otherwise, and checks it
+func Parse(data []byte) (Inventory, error) {
+	var inv Inventory
+	var err error
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		inv, err = parseCSV(data)
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&inv)
+	}
+	if err != nil {
+		return Inventory{}, err
+	}
+	for i := range inv.Servers {
+		s := &inv.Servers[i]
+		if s.Redundancy == "" {
+			s.Redundancy = Single
+		}
+		s.Redundancy = strings.ToLower(s.Redundancy)
+	}
+	return inv, inv.Validate()
+}
+
+// parseCSV reads a CSV inventory under CSVHeader. A NIC speed may be
+// written with its unit, 25G, or without, 25.
+func parseCSV(data []byte) (Inventory, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	header, err := r.Read()
+	if err != nil {
+		return Inventory{}, fmt.Errorf("reading header: %w", err)
+	}
+	column := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		known := false
+		for _, c := range CSVHeader {
+			known = known || c == name
+		}
+		if !known {
+			return Inventory{}, fmt.Errorf("unknown column %q; the columns are %s", name, strings.Join(CSVHeader, ", "))
+		}
+		column[name] = i
+	}
+	for _, required := range CSVHeader[:3] {
+		if _, ok := column[required]; !ok {
+			return Inventory{}, fmt.Errorf("no %s column", required)
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := column[name]; ok {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	var inv Inventory
+	for line := 2; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Inventory{}, err
+		}
+		s := Server{Hostname: field(row, "hostname"), Rack: field(row, "rack"), Redundancy: field(row, "redundancy")}
+		if s.NICs, err = strconv.Atoi(field(row, "nics")); err != nil {
+			return Inventory{}, fmt.Errorf("line %d: nics %q is not a whole number", line, field(row, "nics"))
+		}
+		speed := strings.TrimSuffix(strings.ToUpper(field(row, "nic_speed")), "G")
+		if s.NICSpeedGbps, err = strconv.Atoi(speed); err != nil {
+			return Inventory{}, fmt.Errorf("line %d: nic_speed %q is not a speed such as 25G", line, field(row, "nic_speed"))
+		}
+		inv.Servers = append(inv.Servers, s)
+	}
+	return inv, nil
+}
+
+// Validate checks every server has a unique hostname, NICs and a speed,
+// that dual-homed servers have their NICs in pairs, and that the servers
+// share one redundancy, as a fabric is planned with one
+func (inv Inventory) Validate() error {
+	if len(inv.Servers) == 0 {
+		return fmt.Errorf("no servers")
+	}
+	seen := map[string]bool{}
+	for _, s := range inv.Servers {
+		if !hostnamePattern.MatchString(s.Hostname) {
+			return fmt.Errorf("hostname %q is not 1 to 63 letters, digits, dots and hyphens", s.Hostname)
+		}
+		if seen[s.Hostname] {
+			return fmt.Errorf("%s is listed twice", s.Hostname)
+		}
+		seen[s.Hostname] = true
+		if s.NICs < 1 {
+			return fmt.Errorf("%s: %d NICs; a server needs at least one", s.Hostname, s.NICs)
+		}
+		if s.NICSpeedGbps <= 0 {
+			return fmt.Errorf("%s: NIC speed must be positive, got %dG", s.Hostname, s.NICSpeedGbps)
+		}
+		switch s.Redundancy {
+		case Single:
+		case Dual:
+			if s.NICs%2 != 0 {
+				return fmt.Errorf("%s is dual-homed with %d NICs; its NICs go to the leaves of a pair two at a time", s.Hostname, s.NICs)
+			}
+		default:
+			return fmt.Errorf("%s: redundancy %q is not %s or %s", s.Hostname, s.Redundancy, Single, Dual)
+		}
+		if s.Redundancy != inv.Servers[0].Redundancy {
+			return fmt.Errorf("%s is %s-homed and %s %s-homed; a fabric is planned with one redundancy, so plan them as separate fabrics",
+				s.Hostname, s.Redundancy, inv.Servers[0].Hostname, inv.Servers[0].Redundancy)
+		}
+	}
+	return nil
+}
+
+// Dual reports whether the servers are dual-homed
+func (inv Inventory) Dual() bool {
+	return inv.Servers[0].Redundancy == Dual
+}
+
+// Endpoint is one server connection: a NIC, or a pair of NICs for a
+// dual-homed server
+type Endpoint struct {
+	Name      string   // the hostname, with the first NIC when the server has several endpoints: web01-eth2
+	Server    string   // hostname
+	NICs      []string // eth0, eth1, ...
+	SpeedGbps int
+}
+
+// Endpoints lists the servers' endpoints in placement order
+func (inv Inventory) Endpoints() []Endpoint {
+	var racks []string
+	byRack := map[string][]Server{}
+	for _, s := range inv.Servers {
+		if _, ok := byRack[s.Rack]; !ok && s.Rack != "" {
+			racks = append(racks, s.Rack)
+		}
+		byRack[s.Rack] = append(byRack[s.Rack], s)
+	}
+	var out []Endpoint
+	for _, rack := range append(racks, "") {
+		for _, s := range byRack[rack] {
+			per := 1
+			if s.Redundancy == Dual {
+				per = 2
+			}
+			for n := 0; n < s.NICs; n += per {
+				e := Endpoint{Name: s.Hostname, Server: s.Hostname, SpeedGbps: s.NICSpeedGbps}
+				for i := n; i < n+per; i++ {
+					e.NICs = append(e.NICs, "eth"+strconv.Itoa(i))
+				}
+				if s.NICs > per {
+					e.Name += "-" + e.NICs[0]
+				}
+				out = append(out, e)
+			}
+		}
+	}
+	return out
+}
+
+// Classes is the endpoints by speed, in the order the speeds first appear
+func (inv Inventory) Classes() []fabricplan.EndpointClass {
+	var classes []fabricplan.EndpointClass
+	index := map[int]int{}
+	for _, e := range inv.Endpoints() {
+		i, ok := index[e.SpeedGbps]
+		if !ok {
+			i = len(classes)
+			index[e.SpeedGbps] = i
+			classes = append(classes, fabricplan.EndpointClass{SpeedGbps: e.SpeedGbps})
+		}
+		classes[i].Count++
+	}
+	return classes
+}
+
+// Apply sets the endpoints of req to the inventory's: a count and speed
+// when the NICs share one, endpoint classes when they do not. The
+// request's redundancy must match the servers'.
+func (inv Inventory) Apply(req *fabricplan.Request) error {
+	paired := req.Redundancy != "" && req.Redundancy != fabricplan.RedundancyNone
+	if inv.Dual() && !paired {
+		return fmt.Errorf("the inventory's servers are dual-homed; plan them with a redundancy, mclag or eslag")
+	}
+	if !inv.Dual() && paired {
+		return fmt.Errorf("the inventory's servers are single-homed, so a %s plan would give each endpoint a second leaf it has no NIC for", req.Redundancy)
+	}
+	classes := inv.Classes()
+	req.Endpoints, req.EndpointSpeedGbps, req.Classes = 0, 0, nil
+	if len(classes) == 1 {
+		req.Endpoints, req.EndpointSpeedGbps = classes[0].Count, classes[0].SpeedGbps
+		return nil
+	}
+	req.Classes = classes
+	return nil
+}
+
+// Assign cables every endpoint to leaf endpoint ports as the plan places
+// endpoints: filling the leaves (or leaf pairs) in order, EndpointsPerLeaf
+// at a time, or with endpoint classes spreading each class evenly, on the
+// breakout lanes the plan runs it on. A leaf's endpoint ports are taken in
+// profile order, class after class; a dual-homed endpoint takes the same
+// port on both leaves of its pair. The plan must be for the inventory's
+// endpoints. It returns the server links and the endpoints to attach to
+// VPCs.
+func Assign(inv Inventory, plan fabricplan.Plan, leaf profiles.SwitchProfile) ([]cabling.ServerLink, []vpcplan.Endpoint, error) {
+	if leaf.ModelID != plan.LeafModel {
+		return nil, nil, fmt.Errorf("plan is for leaf %s, not %s", plan.LeafModel, leaf.ModelID)
+	}
+	if inv.Dual() != (plan.LeafPairs > 0) {
+		return nil, nil, fmt.Errorf("the inventory's servers are %s-homed and the plan's endpoints are not; replan it from the inventory", inv.Servers[0].Redundancy)
+	}
+	classed := len(plan.Placement) > 0
+	placement := plan.Placement
+	if !classed {
+		speed := plan.Request.EndpointSpeedGbps
+		if speed == 0 {
+			speed = leaf.Profiles.Endpoint.SpeedGbps
+		}
+		placement = []fabricplan.Placement{{EndpointClass: fabricplan.EndpointClass{Count: plan.Request.Endpoints, SpeedGbps: speed}, PerPort: 1}}
+	}
+	planned := make([]fabricplan.EndpointClass, len(placement))
+	for i, p := range placement {
+		planned[i] = p.EndpointClass
+	}
+	if classes := inv.Classes(); !slices.Equal(planned, classes) {
+		return nil, nil, fmt.Errorf("the plan is for %s endpoints and the inventory has %s; replan it from the inventory", classList(planned), classList(classes))
+	}
+	queues := map[int][]Endpoint{}
+	for _, e := range inv.Endpoints() {
+		queues[e.SpeedGbps] = append(queues[e.SpeedGbps], e)
+	}
+
+	names, err := ports.Expand(leaf.Ports.EndpointAssignable)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", leaf.ModelID, err)
+	}
+	slots, perLeaf := plan.Leaves, 1
+	if plan.LeafPairs > 0 {
+		slots, perLeaf = plan.LeafPairs, 2
+	}
+	var links []cabling.ServerLink
+	var attach []vpcplan.Endpoint
+	for slot := 0; slot < slots; slot++ {
+		leaves := make([]string, perLeaf)
+		for i := range leaves {
+			leaves[i] = "leaf" + strconv.Itoa(slot*perLeaf+i+1)
+		}
+		free := names
+		if slot*perLeaf >= plan.Leaves-plan.BorderLeaves {
+			free = names[:max(len(names)-plan.ExternalPortsPerLeaf, 0)]
+		}
+		next := 0
+		for _, p := range placement {
+			n, _ := p.OnLeaf(slot, slots)
+			if !classed {
+				n = min(max(plan.Request.Endpoints-slot*plan.EndpointsPerLeaf, 0), plan.EndpointsPerLeaf)
+			}
+			lanes, err := laneNames(leaf, p.Breakout)
+			if err != nil {
+				return nil, nil, err
+			}
+			for i := 0; i < n; i++ {
+				port := next + i/p.PerPort
+				if port >= len(free) {
+					return nil, nil, fmt.Errorf("%s has %d endpoint ports, too few for its share of the inventory", leaves[0], len(free))
+				}
+				queue := queues[p.SpeedGbps]
+				if len(queue) == 0 {
+					return nil, nil, fmt.Errorf("the plan places more %dG endpoints than the inventory has", p.SpeedGbps)
+				}
+				e := queue[0]
+				queues[p.SpeedGbps] = queue[1:]
+				portName := lanes(free[port], i%p.PerPort)
+				for k, nic := range e.NICs {
+					l := cabling.ServerLink{Server: e.Server, NIC: nic, Leaf: leaves[k], LeafPort: portName}
+					l.Link = l.String()
+					links = append(links, l)
+				}
+				attach = append(attach, vpcplan.Endpoint{Server: e.Name, Leaves: leaves})
+			}
+			next += (n + p.PerPort - 1) / p.PerPort
+		}
+	}
+	return links, attach, nil
+}
+
+// laneNames names lane i of an endpoint port under a breakout, or the
+// port itself without one
+func laneNames(leaf profiles.SwitchProfile, breakout string) (func(port string, lane int) string, error) {
+	if breakout == "" {
+		return func(port string, _ int) string { return port }, nil
+	}
+	split, ok := leaf.Profiles.Endpoint.Breakout(breakout)
+	if !ok {
+		return nil, fmt.Errorf("leaf %s endpoint ports do not support breakout %s", leaf.ModelID, breakout)
+	}
+	return func(port string, lane int) string { return split.PortNames(port)[lane] }, nil
+}
+
+// classList writes classes as 40x25G, 8x100G
+func classList(classes []fabricplan.EndpointClass) string {
+	parts := make([]string, len(classes))
+	for i, c := range classes {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, ", ")
+}
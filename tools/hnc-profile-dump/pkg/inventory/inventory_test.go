@@ -0,0 +1,107 @@
+package inventory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+func TestParse(t *testing.T) {
+	inv, err := Parse([]byte(`Hostname,NICs,NIC_Speed,Rack
+web01,2,25G,r1
+web02,1,25,r2
+db01,1,25G,r1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Servers) != 3 || inv.Servers[1] != (Server{Hostname: "web02", NICs: 1, NICSpeedGbps: 25, Rack: "r2", Redundancy: Single}) {
+		t.Errorf("servers = %+v", inv.Servers)
+	}
+	// The racks keep their servers together, in the order they first appear
+	var names []string
+	for _, e := range inv.Endpoints() {
+		names = append(names, e.Name)
+	}
+	if want := []string{"web01-eth0", "web01-eth1", "db01", "web02"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("endpoints = %v, want %v", names, want)
+	}
+
+	// JSON reads the same
+	j, err := Parse([]byte(`{"servers": [{"hostname": "web01", "nics": 2, "nicSpeedGbps": 25, "rack": "r1"},
+		{"hostname": "web02", "nics": 1, "nicSpeedGbps": 25, "rack": "r2"}, {"hostname": "db01", "nics": 1, "nicSpeedGbps": 25, "rack": "r1"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(j, inv) {
+		t.Errorf("JSON inventory = %+v, want %+v", j, inv)
+	}
+
+	for _, tc := range []struct{ data, want string }{
+		{"hostname,nics\nweb01,2\n", "no nic_speed column"},
+		{"hostname,nics,nic_speed,owner\n", `unknown column "owner"`},
+		{"hostname,nics,nic_speed\nweb01,two,25G\n", `line 2: nics "two"`},
+		{"hostname,nics,nic_speed\n", "no servers"},
+		{"hostname,nics,nic_speed\nweb01,1,25G\nweb01,1,25G\n", "web01 is listed twice"},
+		{"hostname,nics,nic_speed\nweb_01,1,25G\n", `hostname "web_01"`},
+		{"hostname,nics,nic_speed,redundancy\nweb01,3,25G,dual\n", "dual-homed with 3 NICs"},
+		{"hostname,nics,nic_speed,redundancy\nweb01,2,25G,dual\nweb02,1,25G,single\n", "plan them as separate fabrics"},
+		{`{"servers": [], "owner": "x"}`, `unknown field "owner"`},
+	} {
+		if _, err := Parse([]byte(tc.data)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Parse(%q) = %v, want %q", tc.data, err, tc.want)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	inv, err := Parse([]byte(`hostname,nics,nic_speed,redundancy
+web01,2,25G,dual
+db01,4,25G,dual
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := fabricplan.Request{Oversubscription: 3, Redundancy: fabricplan.RedundancyNone}
+	if err := inv.Apply(&req); err == nil || !strings.Contains(err.Error(), "dual-homed") {
+		t.Errorf("Apply without redundancy = %v", err)
+	}
+	req.Redundancy = fabricplan.MCLAG
+	if err := inv.Apply(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Endpoints != 3 || req.EndpointSpeedGbps != 25 {
+		t.Errorf("request = %+v, want 3 25G endpoints", req)
+	}
+	leaf := profiles.DS2000().InRole(profiles.RoleLeaf)
+	plan, err := fabricplan.Compute(req, leaf, profiles.DS3000().InRole(profiles.RoleSpine))
+	if err != nil {
+		t.Fatal(err)
+	}
+	links, endpoints, err := Assign(inv, plan, leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each pair of NICs takes the same port on both leaves of the pair
+	if len(links) != 6 || links[0].Link != "leaf1:E1/1 <-> web01:eth0" || links[1].Link != "leaf2:E1/1 <-> web01:eth1" ||
+		links[5].Link != "leaf2:E1/3 <-> db01:eth3" {
+		t.Errorf("links = %+v", links)
+	}
+	want := vpcplan.Endpoint{Server: "db01-eth2", Leaves: []string{"leaf1", "leaf2"}}
+	if len(endpoints) != 3 || !reflect.DeepEqual(endpoints[2], want) {
+		t.Errorf("endpoints = %+v, want the last %+v", endpoints, want)
+	}
+
+	// A plan for other endpoints is refused
+	other, err := fabricplan.Compute(fabricplan.Request{Endpoints: 4, Oversubscription: 3, Redundancy: fabricplan.MCLAG}, leaf, profiles.DS3000().InRole(profiles.RoleSpine))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Assign(inv, other, leaf); err == nil || !strings.Contains(err.Error(), "the plan is for 4x25G endpoints and the inventory has 3x25G") {
+		t.Errorf("Assign to another plan = %v", err)
+	}
+}
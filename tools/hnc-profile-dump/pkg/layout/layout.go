@@ -0,0 +1,220 @@
+// Package layout places a fabric plan's switches and servers in racks
+// and pods: spines first, then each group of leaves top of rack with the
+// servers that hang off them, spilling servers into the next racks when
+// a rack runs out of units. Pods are rows of racks, so how far apart two
+// racks stand, and the length class of a cable between them, follows
+// from their pod and position. hnc cabling reads the layout to estimate
+// the length of every cable it assigns.
+package layout
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Options are the rack dimensions and placement rules; 0 takes the
+// default
+type Options struct {
+	RackUnits     int     `json:"rackUnits"`     // usable units per rack, default 42
+	LeavesPerRack int     `json:"leavesPerRack"` // default 1, or the leaf pair for redundant plans
+	RacksPerPod   int     `json:"racksPerPod"`   // racks in a row, default 10
+	ServerUnits   int     `json:"serverUnits"`   // units per server, default 1
+	RackWidthM    float64 `json:"rackWidthMeters"`
+	PodPitchM     float64 `json:"podPitchMeters"` // row to row, through the aisle
+	DropM         float64 `json:"dropMeters"`     // each cable end's run from the port to the tray, with slack
+}
+
+// Defaults for the zero Options fields
+const (
+	DefaultRackUnits   = 42
+	DefaultRacksPerPod = 10
+	DefaultServerUnits = 1
+	DefaultRackWidthM  = 0.6
+	DefaultPodPitchM   = 3
+	DefaultDropM       = 1.5
+)
+
+// Layout is the placement, written to layout.json
+type Layout struct {
+	Options    Options `json:"options"`
+	LeafModel  string  `json:"leafModel"`
+	SpineModel string  `json:"spineModel"`
+	Racks      []Rack  `json:"racks"`
+}
+
+// Rack is one rack and what it holds, switches top down
+type Rack struct {
+	Name      string   `json:"name"` // e.g. pod1-rack3
+	Pod       int      `json:"pod"`
+	Position  int      `json:"position"` // from the start of the row, 1 first
+	Switches  []string `json:"switches,omitempty"`
+	Servers   int      `json:"servers,omitempty"`
+	UnitsUsed int      `json:"unitsUsed"`
+	// ServerLeaves are the leaves the rack's servers cable to, and
+	// ServerLength the length class of those cables
+	ServerLeaves []string `json:"serverLeaves,omitempty"`
+	ServerLength string   `json:"serverLength,omitempty"`
+}
+
+// Plan lays out a plan's switches; a model's height comes from its
+// physical.rackUnits, else 1
+func Plan(plan fabricplan.Plan, leaf, spine profiles.SwitchProfile, opts Options) (Layout, error) {
+	opts = withDefaults(opts, plan)
+	if opts.RackUnits < 0 || opts.LeavesPerRack < 0 || opts.RacksPerPod < 0 || opts.ServerUnits < 0 ||
+		opts.RackWidthM < 0 || opts.PodPitchM < 0 || opts.DropM < 0 {
+		return Layout{}, fmt.Errorf("rack dimensions must be positive")
+	}
+	if plan.LeafPairs > 0 && opts.LeavesPerRack%2 != 0 {
+		return Layout{}, fmt.Errorf("%s leaf pairs share a rack, so leaves per rack must be even, got %d", plan.Request.Redundancy, opts.LeavesPerRack)
+	}
+	leafUnits, spineUnits := height(leaf), height(spine)
+	if spineUnits > opts.RackUnits {
+		return Layout{}, fmt.Errorf("a %dU spine does not fit a %dU rack", spineUnits, opts.RackUnits)
+	}
+	if need := opts.LeavesPerRack * leafUnits; need > opts.RackUnits {
+		return Layout{}, fmt.Errorf("%d leaves of %dU do not fit a %dU rack", opts.LeavesPerRack, leafUnits, opts.RackUnits)
+	}
+
+	l := Layout{Options: opts, LeafModel: leaf.ModelID, SpineModel: spine.ModelID}
+	next := func() *Rack {
+		i := len(l.Racks)
+		pod, position := i/opts.RacksPerPod+1, i%opts.RacksPerPod+1
+		l.Racks = append(l.Racks, Rack{Name: "pod" + strconv.Itoa(pod) + "-rack" + strconv.Itoa(position), Pod: pod, Position: position})
+		return &l.Racks[i]
+	}
+
+	var rack *Rack
+	for i := 0; i < plan.Spines; i++ {
+		if rack == nil || rack.UnitsUsed+spineUnits > opts.RackUnits {
+			rack = next()
+		}
+		rack.Switches = append(rack.Switches, "spine"+strconv.Itoa(i+1))
+		rack.UnitsUsed += spineUnits
+	}
+	for first := 0; first < plan.Leaves; first += opts.LeavesPerRack {
+		rack = next()
+		home := len(l.Racks) - 1
+		var leaves []string
+		servers := 0
+		for i := first; i < min(first+opts.LeavesPerRack, plan.Leaves); i++ {
+			leaves = append(leaves, "leaf"+strconv.Itoa(i+1))
+			rack.UnitsUsed += leafUnits
+			// a leaf pair's servers are counted once, on its first leaf
+			if plan.LeafPairs == 0 || i%2 == 0 {
+				slot := i
+				if plan.LeafPairs > 0 {
+					slot = i / 2
+				}
+				servers += min(max(plan.Request.Endpoints-slot*plan.EndpointsPerLeaf, 0), plan.EndpointsPerLeaf)
+			}
+		}
+		rack.Switches = leaves
+		for servers > 0 && opts.ServerUnits > 0 {
+			fit := (opts.RackUnits - rack.UnitsUsed) / opts.ServerUnits
+			if fit == 0 {
+				rack = next()
+				continue
+			}
+			fit = min(fit, servers)
+			rack.Servers += fit
+			rack.UnitsUsed += fit * opts.ServerUnits
+			rack.ServerLeaves = leaves
+			rack.ServerLength = l.lengthBetween(*rack, l.Racks[home])
+			servers -= fit
+		}
+	}
+	return l, nil
+}
+
+func withDefaults(opts Options, plan fabricplan.Plan) Options {
+	if opts.RackUnits == 0 {
+		opts.RackUnits = DefaultRackUnits
+	}
+	if opts.LeavesPerRack == 0 {
+		opts.LeavesPerRack = 1
+		if plan.LeafPairs > 0 {
+			opts.LeavesPerRack = 2
+		}
+	}
+	if opts.RacksPerPod == 0 {
+		opts.RacksPerPod = DefaultRacksPerPod
+	}
+	if opts.ServerUnits == 0 {
+		opts.ServerUnits = DefaultServerUnits
+	}
+	if opts.RackWidthM == 0 {
+		opts.RackWidthM = DefaultRackWidthM
+	}
+	if opts.PodPitchM == 0 {
+		opts.PodPitchM = DefaultPodPitchM
+	}
+	if opts.DropM == 0 {
+		opts.DropM = DefaultDropM
+	}
+	return opts
+}
+
+func height(p profiles.SwitchProfile) int {
+	if p.Physical != nil && p.Physical.RackUnits > 0 {
+		return p.Physical.RackUnits
+	}
+	return 1
+}
+
+// Find is the rack holding a switch
+func (l Layout) Find(name string) (Rack, bool) {
+	for _, r := range l.Racks {
+		for _, sw := range r.Switches {
+			if sw == name {
+				return r, true
+			}
+		}
+	}
+	return Rack{}, false
+}
+
+// Distance is the cable run between two racks in meters: along the row
+// within a pod, or to the head of the row, across the aisles and back
+// along the other row between pods, plus a drop at each end
+func (l Layout) Distance(a, b Rack) float64 {
+	run := math.Abs(float64(a.Position-b.Position)) * l.Options.RackWidthM
+	if a.Pod != b.Pod {
+		run = float64(a.Position+b.Position-2)*l.Options.RackWidthM + math.Abs(float64(a.Pod-b.Pod))*l.Options.PodPitchM
+	}
+	return run + 2*l.Options.DropM
+}
+
+// LengthClasses are the stock cable lengths in meters, shortest first
+var LengthClasses = []float64{1, 2, 3, 5, 7, 10, 15, 20, 30, 50, 100}
+
+// LengthClass is the shortest stock length that covers meters, as a
+// length class such as 3m; past the longest, the next 50m
+func LengthClass(meters float64) string {
+	for _, class := range LengthClasses {
+		if class >= meters {
+			return strconv.FormatFloat(class, 'f', -1, 64) + "m"
+		}
+	}
+	return strconv.FormatFloat(math.Ceil(meters/50)*50, 'f', -1, 64) + "m"
+}
+
+func (l Layout) lengthBetween(a, b Rack) string {
+	return LengthClass(l.Distance(a, b))
+}
+
+// Cable is the length class of a cable between two switches
+func (l Layout) Cable(a, b string) (string, error) {
+	ra, ok := l.Find(a)
+	if !ok {
+		return "", fmt.Errorf("%s is not in the layout", a)
+	}
+	rb, ok := l.Find(b)
+	if !ok {
+		return "", fmt.Errorf("%s is not in the layout", b)
+	}
+	return l.lengthBetween(ra, rb), nil
+}
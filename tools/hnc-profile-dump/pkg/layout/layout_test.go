@@ -0,0 +1,92 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func racks(l Layout) string {
+	var out []string
+	for _, r := range l.Racks {
+		out = append(out, r.Name+"="+strings.Join(r.Switches, ",")+"+"+strings.Repeat("s", min(r.Servers, 1))+r.ServerLength)
+	}
+	return strings.Join(out, " ")
+}
+
+func compute(t *testing.T, req fabricplan.Request) fabricplan.Plan {
+	t.Helper()
+	p, err := fabricplan.Compute(req, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPlanSpillsServers(t *testing.T) {
+	// 2 leaves of 48 servers: 41 fit beside each leaf, the other 7 go next door
+	p := compute(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3})
+	l, err := Plan(p, profiles.DS2000(), profiles.DS3000(), Options{RacksPerPod: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := racks(l), "pod1-rack1=spine1,spine2+ pod1-rack2=leaf1+s3m pod1-rack3=+s5m pod2-rack1=leaf2+s3m pod2-rack2=+s5m"; got != want {
+		t.Errorf("racks = %s\nwant %s", got, want)
+	}
+	servers := 0
+	for _, r := range l.Racks {
+		servers += r.Servers
+		if r.UnitsUsed > DefaultRackUnits {
+			t.Errorf("%s uses %dU", r.Name, r.UnitsUsed)
+		}
+	}
+	if servers != 96 || l.Racks[1].Servers != 41 || l.Racks[2].ServerLeaves[0] != "leaf1" {
+		t.Errorf("servers = %d, racks %+v", servers, l.Racks)
+	}
+}
+
+func TestPlanKeepsLeafPairsTogether(t *testing.T) {
+	p := compute(t, fabricplan.Request{Endpoints: 96, Oversubscription: 3, Redundancy: fabricplan.MCLAG})
+	l, err := Plan(p, profiles.DS2000(), profiles.DS3000(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a pair's 48 dual-homed servers, 40 beside the pair
+	if r, ok := l.Find("leaf2"); !ok || r.Name != "pod1-rack2" || r.Servers != 40 || l.Racks[2].Servers != 8 {
+		t.Errorf("leaf2 in %+v, racks %+v", r, l.Racks)
+	}
+	for name, opts := range map[string]Options{
+		"split pair":   {LeavesPerRack: 3},
+		"short rack":   {RackUnits: 1},
+		"negative pod": {RacksPerPod: -1},
+	} {
+		if _, err := Plan(p, profiles.DS2000(), profiles.DS3000(), opts); err == nil {
+			t.Errorf("%s: Plan() = nil error", name)
+		}
+	}
+}
+
+func TestCableLengths(t *testing.T) {
+	l := Layout{Options: withDefaults(Options{}, fabricplan.Plan{}), Racks: []Rack{
+		{Name: "pod1-rack1", Pod: 1, Position: 1, Switches: []string{"spine1"}},
+		{Name: "pod1-rack4", Pod: 1, Position: 4, Switches: []string{"leaf1", "leaf2"}},
+		{Name: "pod3-rack2", Pod: 3, Position: 2, Switches: []string{"leaf3"}},
+	}}
+	for _, tc := range []struct{ a, b, want string }{
+		{"leaf1", "leaf2", "3m"},   // 3m of drops
+		{"spine1", "leaf1", "5m"},  // 1.8m along the row
+		{"spine1", "leaf3", "10m"}, // 0.6m to the row, 6m across two aisles
+	} {
+		if got, err := l.Cable(tc.a, tc.b); err != nil || got != tc.want {
+			t.Errorf("Cable(%s, %s) = %s, %v, want %s", tc.a, tc.b, got, err, tc.want)
+		}
+	}
+	if _, err := l.Cable("spine1", "leaf9"); err == nil {
+		t.Error("Cable() to a switch outside the layout succeeded")
+	}
+	if got := LengthClass(120); got != "150m" {
+		t.Errorf("LengthClass(120) = %s, want 150m", got)
+	}
+}
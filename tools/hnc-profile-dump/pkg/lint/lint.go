@@ -0,0 +1,219 @@
+// Package lint runs a set of named rules over switch profiles and reports
+// structured findings. Rules are independent, so each can be enabled or
+// disabled on its own, and new ones only need adding to Rules.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/ports"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Severities; only errors fail a lint run
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+// Finding is one problem a rule found in one profile
+type Finding struct {
+	Rule     string `json:"rule"`
+	ModelID  string `json:"modelId"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Rule checks one property of a profile and returns a message per problem
+type Rule struct {
+	Name        string
+	Description string
+	Severity    string
+	Check       func(profiles.SwitchProfile) []string
+}
+
+// EthernetSpeedsGbps are the port speeds a profile may declare
+var EthernetSpeedsGbps = []int{1, 10, 25, 40, 50, 100, 200, 400, 800}
+
+// ModelIDPattern is the default naming convention: lower-case vendor and
+// model joined by hyphens, e.g. celestica-ds2000
+var ModelIDPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)+$`)
+
+// Rules is every rule, in the order findings are reported
+var Rules = []Rule{
+	{"port-overlap", "endpoint ports must not overlap fabric ports", Error, portOverlap},
+	{"leaf-port-profiles", "leaf profiles must name both endpoint and uplink port profiles", Error, leafPortProfiles},
+	{"spine-endpoint-ports", "spine profiles must have no endpoint-assignable ports", Error, spineEndpointPorts},
+	{"ethernet-speeds", "port and breakout speeds must be standard Ethernet speeds", Error, ethernetSpeeds},
+	{"capabilities", "capabilities must be consistent with the ports and with each other", Warning, capabilities},
+	ModelIDRule(ModelIDPattern),
+}
+
+// ModelIDRule checks modelId against a naming convention
+func ModelIDRule(pattern *regexp.Regexp) Rule {
+	return Rule{"model-id", "modelId must follow the naming convention", Warning, func(p profiles.SwitchProfile) []string {
+		if pattern.MatchString(p.ModelID) {
+			return nil
+		}
+		return []string{fmt.Sprintf("modelId %q does not match %s", p.ModelID, pattern)}
+	}}
+}
+
+// Select returns the rules to run: only the enabled ones when enable is
+// non-empty, otherwise all, less the disabled ones. Unknown names are an
+// error so a typo cannot silently skip a rule.
+func Select(rules []Rule, enable, disable []string) ([]Rule, error) {
+	known := map[string]bool{}
+	for _, r := range rules {
+		known[r.Name] = true
+	}
+	for _, name := range append(append([]string{}, enable...), disable...) {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown rule %q (known: %s)", name, strings.Join(Names(rules), ", "))
+		}
+	}
+	var selected []Rule
+	for _, r := range rules {
+		if (len(enable) == 0 || contains(enable, r.Name)) && !contains(disable, r.Name) {
+			selected = append(selected, r)
+		}
+	}
+	return selected, nil
+}
+
+// Names lists rule names in order
+func Names(rules []Rule) []string {
+	names := make([]string, len(rules))
+	for i, r := range rules {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// Run applies every rule to every profile, returning findings by profile,
+// then rule
+func Run(ps []profiles.SwitchProfile, rules []Rule) []Finding {
+	findings := []Finding{}
+	for _, p := range ps {
+		for _, r := range rules {
+			for _, msg := range r.Check(p) {
+				findings = append(findings, Finding{Rule: r.Name, ModelID: p.ModelID, Severity: r.Severity, Message: msg})
+			}
+		}
+	}
+	return findings
+}
+
+// Errors counts the findings that fail a lint run
+func Errors(findings []Finding) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == Error {
+			n++
+		}
+	}
+	return n
+}
+
+// RenderText renders findings one per line, as model: severity [rule] message
+func RenderText(findings []Finding) string {
+	var b strings.Builder
+	for _, f := range findings {
+		fmt.Fprintf(&b, "%s: %s [%s] %s\n", f.ModelID, f.Severity, f.Rule, f.Message)
+	}
+	return b.String()
+}
+
+func portOverlap(p profiles.SwitchProfile) []string {
+	both, err := ports.Overlap(p.Ports.EndpointAssignable, p.Ports.FabricAssignable)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if len(both) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("ports %s are both endpoint and fabric assignable", strings.Join(both, ", "))}
+}
+
+func leafPortProfiles(p profiles.SwitchProfile) []string {
+	if !contains(p.Roles, "leaf") {
+		return nil
+	}
+	field := "profiles"
+	if _, ok := p.Profiles.Roles["leaf"]; ok {
+		field = "profiles.roles.leaf"
+	}
+	rp := p.ForRole("leaf")
+	var msgs []string
+	for _, side := range []struct {
+		name    string
+		profile profiles.PortProfile
+	}{{"endpoint", rp.Endpoint}, {"uplink", rp.Uplink}} {
+		if side.profile.PortProfile == nil || *side.profile.PortProfile == "" {
+			msgs = append(msgs, fmt.Sprintf("leaf profile has no %s.%s.portProfile", field, side.name))
+		}
+	}
+	return msgs
+}
+
+func spineEndpointPorts(p profiles.SwitchProfile) []string {
+	if !contains(p.Roles, "spine") || len(p.Ports.EndpointAssignable) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("spine profile has endpoint-assignable ports %s", strings.Join(p.Ports.EndpointAssignable, ", "))}
+}
+
+func ethernetSpeeds(p profiles.SwitchProfile) []string {
+	var msgs []string
+	check := func(field string, speed int) {
+		if i := sort.SearchInts(EthernetSpeedsGbps, speed); i == len(EthernetSpeedsGbps) || EthernetSpeedsGbps[i] != speed {
+			msgs = append(msgs, fmt.Sprintf("%s is %dG, not an Ethernet speed", field, speed))
+		}
+	}
+	for _, e := range p.RoleEntries() {
+		// Spines carry no endpoint speed
+		if len(p.Ports.EndpointAssignable) > 0 || e.Endpoint.SpeedGbps != 0 {
+			check(e.Field+".endpoint.speedGbps", e.Endpoint.SpeedGbps)
+		}
+		check(e.Field+".uplink.speedGbps", e.Uplink.SpeedGbps)
+		for _, side := range []struct {
+			name    string
+			profile profiles.PortProfile
+		}{{"endpoint", e.Endpoint}, {"uplink", e.Uplink}} {
+			for i, b := range side.profile.Breakouts {
+				check(fmt.Sprintf("%s.%s.breakouts[%d].speedGbps", e.Field, side.name, i), b.SpeedGbps)
+			}
+		}
+	}
+	return msgs
+}
+
+// capabilities flags table sizes a profile's own ports outgrow and
+// features its tables leave no room for; package constraints checks
+// designs against them
+func capabilities(p profiles.SwitchProfile) []string {
+	c := p.Capabilities
+	if c == nil {
+		return nil
+	}
+	var msgs []string
+	if endpoints, err := ports.Expand(p.Ports.EndpointAssignable); err == nil && c.Tables.MACs > 0 && c.Tables.MACs < len(endpoints) {
+		msgs = append(msgs, fmt.Sprintf("capabilities.tables.macs is %d, fewer than the %d endpoint ports", c.Tables.MACs, len(endpoints)))
+	}
+	if c.Features.VXLANRouting && c.Tables.VXLANTunnels == 0 {
+		msgs = append(msgs, "capabilities.features.vxlanRouting is set without capabilities.tables.vxlanTunnels")
+	}
+	return msgs
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package lint
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/profiles/profiletest"
+)
+
+func TestBuiltInsAreClean(t *testing.T) {
+	if findings := Run(profiles.Default().List(), Rules); len(findings) != 0 {
+		t.Errorf("built-in profiles have findings:\n%s", RenderText(findings))
+	}
+}
+
+func TestGeneratedProfilesAreClean(t *testing.T) {
+	g := profiletest.New(1)
+	for i := 0; i < 300; i++ {
+		leaf, spine := g.Fabric()
+		if findings := Run([]profiles.SwitchProfile{leaf, spine}, Rules); len(findings) != 0 {
+			t.Fatalf("generated profiles have findings:\n%s", RenderText(findings))
+		}
+		// Giving a fabric port to endpoints too is always caught
+		leaf.Ports.EndpointAssignable = append(leaf.Ports.EndpointAssignable, leaf.Ports.FabricAssignable[0])
+		if findings := Run([]profiles.SwitchProfile{leaf}, Rules); Errors(findings) == 0 || findings[0].Rule != "port-overlap" {
+			t.Fatalf("overlapping ports %v / %v not reported: %v", leaf.Ports.EndpointAssignable, leaf.Ports.FabricAssignable, findings)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	bad := profiles.DS2000()
+	bad.ModelID = "Celestica_DS2000"
+	bad.Roles = []string{"leaf", "spine"}
+	bad.Ports.EndpointAssignable = []string{"E1/1-49"}
+	bad.Profiles.Uplink.PortProfile = nil
+	bad.Profiles.Endpoint.SpeedGbps = 30
+	bad.Capabilities = &profiles.Capabilities{ASIC: "trident3", Tables: profiles.Tables{MACs: 32}, Features: profiles.Features{VXLANRouting: true}}
+
+	findings := Run([]profiles.SwitchProfile{bad}, Rules)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Rule+": "+f.Message)
+	}
+	want := []string{
+		"port-overlap: ports E1/49 are both endpoint and fabric assignable",
+		"leaf-port-profiles: leaf profile has no profiles.uplink.portProfile",
+		"spine-endpoint-ports: spine profile has endpoint-assignable ports E1/1-49",
+		"ethernet-speeds: profiles.endpoint.speedGbps is 30G, not an Ethernet speed",
+		"capabilities: capabilities.tables.macs is 32, fewer than the 49 endpoint ports",
+		"capabilities: capabilities.features.vxlanRouting is set without capabilities.tables.vxlanTunnels",
+		`model-id: modelId "Celestica_DS2000" does not match ^[a-z0-9]+(-[a-z0-9]+)+$`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings =\n%q\nwant\n%q", got, want)
+	}
+	if n := Errors(findings); n != 4 {
+		t.Errorf("Errors() = %d, want 4 (capabilities and model-id are warnings)", n)
+	}
+	if rule := ModelIDRule(regexp.MustCompile(`^[A-Z][a-z]+_DS\d+$`)); len(rule.Check(bad)) != 0 {
+		t.Error("custom model-id pattern not applied")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	if rules, _ := Select(Rules, nil, []string{"model-id"}); len(rules) != len(Rules)-1 {
+		t.Errorf("disable model-id left %v", Names(rules))
+	}
+	if rules, _ := Select(Rules, []string{"ethernet-speeds", "port-overlap"}, nil); !reflect.DeepEqual(Names(rules), []string{"port-overlap", "ethernet-speeds"}) {
+		t.Errorf("enable = %v, want rule order kept", Names(rules))
+	}
+	if _, err := Select(Rules, nil, []string{"speeds"}); err == nil {
+		t.Error("unknown rule accepted")
+	}
+}
@@ -0,0 +1,85 @@
+// Package logging is the log/slog setup every hnc tool shares. Progress
+// and status messages ("Generated bom.json") are Info records, problems
+// Warn and Error ones, and all of them go to stderr, so stdout carries
+// only what a command produces (a table, a report, JSON with -output -)
+// and pipes cleanly. As text a record is just its message, so output
+// reads as it always has; as JSON it is one object per line with the
+// level, message and attributes, for automation. -v adds Debug records
+// and -q keeps only warnings and errors.
+package logging
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Log formats
+const (
+	Text = "text"
+	JSON = "json"
+)
+
+// Formats lists the supported formats, default first
+var Formats = []string{Text, JSON}
+
+// Options are what the -v, -q and -log-format flags set
+type Options struct {
+	Level  slog.Level // Info unless -v or -q
+	Format string     // Text when empty
+}
+
+// Flags registers -v, -q and -log-format on fs, setting o
+func Flags(fs *flag.FlagSet, o *Options) {
+	level := func(l slog.Level) func(string) error {
+		return func(value string) error {
+			on, err := strconv.ParseBool(value)
+			if on {
+				o.Level = l
+			}
+			return err
+		}
+	}
+	fs.BoolFunc("v", "Verbose: also log debug messages", level(slog.LevelDebug))
+	fs.BoolFunc("q", "Quiet: log only warnings and errors", level(slog.LevelWarn))
+	fs.Func("log-format", "Log message `format`: "+strings.Join(Formats, " or ")+" (default: text)", func(value string) error {
+		if value != Text && value != JSON {
+			return fmt.Errorf("want %s", strings.Join(Formats, " or "))
+		}
+		o.Format = value
+		return nil
+	})
+}
+
+// New is a logger writing every record to w, which is stderr outside
+// tests
+func New(w io.Writer, o Options) *slog.Logger {
+	if o.Format == JSON {
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: o.Level}))
+	}
+	return slog.New(plain{w: w, level: o.Level})
+}
+
+// plain writes a record's message alone on a line; its attributes are
+// for JSON readers
+type plain struct {
+	w     io.Writer
+	level slog.Level
+}
+
+func (p plain) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= p.level
+}
+
+func (p plain) Handle(_ context.Context, r slog.Record) error {
+	_, err := io.WriteString(p.w, r.Message+"\n")
+	return err
+}
+
+func (p plain) WithAttrs([]slog.Attr) slog.Handler { return p }
+
+func (p plain) WithGroup(string) slog.Handler { return p }
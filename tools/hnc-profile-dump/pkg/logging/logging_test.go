@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestTextKeepsMessagesPlain(t *testing.T) {
+	var stderr strings.Builder
+	log := New(&stderr, Options{})
+	log.Debug("hidden")
+	log.Info("Generated bom.json", "file", "bom.json")
+	log.Warn("Warning: careful")
+	log.Error("Error: broken", "exitCode", 1)
+	if stderr.String() != "Generated bom.json\nWarning: careful\nError: broken\n" {
+		t.Errorf("stderr %q", stderr.String())
+	}
+}
+
+func TestJSONCarriesAttributes(t *testing.T) {
+	var stderr strings.Builder
+	New(&stderr, Options{Format: JSON}).With("command", "hnc bom").Info("Generated bom.json", "file", "bom.json")
+	var record map[string]any
+	if err := json.Unmarshal([]byte(stderr.String()), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["level"] != "INFO" || record["msg"] != "Generated bom.json" || record["file"] != "bom.json" || record["command"] != "hnc bom" {
+		t.Errorf("record = %v", record)
+	}
+}
+
+func TestFlags(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		level slog.Level
+		ok    bool
+	}{
+		{nil, slog.LevelInfo, true},
+		{[]string{"-v"}, slog.LevelDebug, true},
+		{[]string{"-q"}, slog.LevelWarn, true},
+		{[]string{"-q=false"}, slog.LevelInfo, true},
+		{[]string{"-log-format", "xml"}, slog.LevelInfo, false},
+	} {
+		var o Options
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		Flags(fs, &o)
+		if err := fs.Parse(tc.args); (err == nil) != tc.ok || o.Level != tc.level {
+			t.Errorf("%v: level %v, error %v", tc.args, o.Level, err)
+		}
+	}
+
+	var stderr strings.Builder
+	New(&stderr, Options{Level: slog.LevelWarn}).Info("hidden")
+	if stderr.Len() > 0 {
+		t.Errorf("-q logged %q", stderr.String())
+	}
+}
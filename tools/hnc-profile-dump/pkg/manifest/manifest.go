@@ -0,0 +1,164 @@
+// Package manifest records the provenance of generated files: a
+// manifest.json beside them lists each file's SHA-256 checksum and size,
+// by slash-separated path for files in subdirectories, when it was last
+// generated, and the generator and version that wrote it. Verify
+// recomputes the checksums, so a file edited by hand since generation, or
+// one the generator never wrote, is caught in review or an audit.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// File is the manifest's name in the directory it describes
+const File = "manifest.json"
+
+// Manifest is the content of manifest.json
+type Manifest struct {
+	Generator        string  `json:"generator"`        // e.g. hnc profiles dump
+	GeneratorVersion string  `json:"generatorVersion"` // module version of the binary, (devel) for local builds
+	Files            []Entry `json:"files"`            // by name
+}
+
+// Entry is one generated file
+type Entry struct {
+	Name        string    `json:"name"`
+	SHA256      string    `json:"sha256"` // hex
+	Bytes       int       `json:"bytes"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// Sum is the hex SHA-256 of data
+func Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// New lists files by name, generated at now. A file whose checksum is
+// unchanged from previous keeps its earlier time, so regenerating files
+// that did not change leaves the manifest as it was.
+func New(generator, version string, files map[string][]byte, previous Manifest, now time.Time) Manifest {
+	before := map[string]Entry{}
+	for _, e := range previous.Files {
+		before[e.Name] = e
+	}
+	m := Manifest{Generator: generator, GeneratorVersion: version, Files: []Entry{}}
+	for name, data := range files {
+		e := Entry{Name: name, SHA256: Sum(data), Bytes: len(data), GeneratedAt: now.UTC().Truncate(time.Second)}
+		if old, ok := before[name]; ok && old.SHA256 == e.SHA256 {
+			e.GeneratedAt = old.GeneratedAt
+		}
+		m.Files = append(m.Files, e)
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Name < m.Files[j].Name })
+	return m
+}
+
+// Read loads dir's manifest; a directory without one has the zero
+// Manifest and an error wrapping fs.ErrNotExist
+func Read(dir string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(filepath.Join(dir, File))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("parsing %s: %w", filepath.Join(dir, File), err)
+	}
+	return m, nil
+}
+
+// Problem is a file that does not match the manifest
+type Problem struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// Verify checks every file the manifest lists against its checksum, and
+// that dir, and each directory under it the manifest lists files in, holds
+// no other files. It returns the problems and how many files matched.
+func Verify(dir string, m Manifest) ([]Problem, int, error) {
+	var problems []Problem
+	listed := map[string]bool{File: true}
+	dirs := map[string]bool{".": true}
+	matched := 0
+	for _, e := range m.Files {
+		listed[e.Name] = true
+		dirs[path.Dir(e.Name)] = true
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(e.Name)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			problems = append(problems, Problem{e.Name, "listed in " + File + " but missing"})
+		case err != nil:
+			return nil, 0, err
+		case Sum(data) != e.SHA256:
+			problems = append(problems, Problem{e.Name, fmt.Sprintf("changed since it was generated at %s (SHA-256 %s, manifest %s)",
+				e.GeneratedAt.Format(time.RFC3339), Sum(data), e.SHA256)})
+		default:
+			matched++
+		}
+	}
+	var subdirs []string
+	for d := range dirs {
+		subdirs = append(subdirs, d)
+	}
+	sort.Strings(subdirs)
+	for _, d := range subdirs {
+		entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(d)))
+		if errors.Is(err, fs.ErrNotExist) && d != "." {
+			continue // its files are reported missing
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, entry := range entries {
+			if name := path.Join(d, entry.Name()); !entry.IsDir() && !listed[name] {
+				problems = append(problems, Problem{name, "not in " + File + "; it was not generated"})
+			}
+		}
+	}
+	return problems, matched, nil
+}
+
+// Check is Verify for files held in memory, by slash-separated name, such
+// as those read from an archive: every listed file must be there with its
+// checksum, and every other file but the manifest listed
+func Check(files map[string][]byte, m Manifest) ([]Problem, int) {
+	var problems []Problem
+	listed := map[string]bool{File: true}
+	matched := 0
+	for _, e := range m.Files {
+		listed[e.Name] = true
+		data, ok := files[e.Name]
+		switch {
+		case !ok:
+			problems = append(problems, Problem{e.Name, "listed in " + File + " but missing"})
+		case Sum(data) != e.SHA256:
+			problems = append(problems, Problem{e.Name, fmt.Sprintf("changed since it was generated at %s (SHA-256 %s, manifest %s)",
+				e.GeneratedAt.Format(time.RFC3339), Sum(data), e.SHA256)})
+		default:
+			matched++
+		}
+	}
+	var unlisted []string
+	for name := range files {
+		if !listed[name] {
+			unlisted = append(unlisted, name)
+		}
+	}
+	sort.Strings(unlisted)
+	for _, name := range unlisted {
+		problems = append(problems, Problem{name, "not in " + File + "; it was not generated"})
+	}
+	return problems, matched
+}
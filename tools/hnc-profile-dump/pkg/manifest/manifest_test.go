@@ -0,0 +1,108 @@
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewKeepsTimesOfUnchangedFiles(t *testing.T) {
+	first := time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC)
+	m := New("hnc profiles dump", "v1.0.0", map[string][]byte{"b.json": []byte("b"), "a.json": []byte("a")}, Manifest{}, first)
+	if len(m.Files) != 2 || m.Files[0].Name != "a.json" || m.Files[0].Bytes != 1 || !m.Files[0].GeneratedAt.Equal(first.Truncate(time.Second)) {
+		t.Fatalf("New() = %+v", m)
+	}
+	if m.Files[0].SHA256 != "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb" {
+		t.Errorf("sha256(a) = %s", m.Files[0].SHA256)
+	}
+
+	later := first.Add(time.Hour)
+	again := New("hnc profiles dump", "v1.0.0", map[string][]byte{"a.json": []byte("a"), "b.json": []byte("B")}, m, later)
+	if !again.Files[0].GeneratedAt.Equal(m.Files[0].GeneratedAt) || !again.Files[1].GeneratedAt.Equal(later.Truncate(time.Second)) {
+		t.Errorf("regenerated = %+v", again.Files)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{"a.json": []byte("a"), "b.json": []byte("b"), "c.json": []byte("c")}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := New("test", "(devel)", files, Manifest{}, time.Now())
+	data, _ := json.Marshal(m)
+	if err := os.WriteFile(filepath.Join(dir, File), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(dir)
+	if err != nil || !reflect.DeepEqual(read.Files, m.Files) {
+		t.Fatalf("Read() = %+v, %v", read, err)
+	}
+	if problems, matched, err := Verify(dir, read); err != nil || len(problems) != 0 || matched != 3 {
+		t.Fatalf("Verify() = %v, %d, %v", problems, matched, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte("edited"), 0644)
+	os.Remove(filepath.Join(dir, "b.json"))
+	os.WriteFile(filepath.Join(dir, "d.json"), []byte("d"), 0644)
+	problems, matched, err := Verify(dir, read)
+	if err != nil || matched != 1 {
+		t.Fatalf("Verify() = %v, %d, %v", problems, matched, err)
+	}
+	var names []string
+	for _, p := range problems {
+		names = append(names, p.Name)
+	}
+	if want := []string{"a.json", "b.json", "d.json"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("problems = %+v, want files %q", problems, want)
+	}
+}
+
+// Files in subdirectories are listed by slash-separated path, and only
+// the directories the manifest lists files in are checked for others
+func TestVerifySubdirectories(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "acme", "leaf"), 0755)
+	os.MkdirAll(filepath.Join(dir, "notes"), 0755)
+	files := map[string][]byte{"index.json": []byte("i"), "acme/leaf/x.json": []byte("x")}
+	for name, data := range files {
+		os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), data, 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "notes", "todo.txt"), []byte("todo"), 0644)
+	m := New("test", "(devel)", files, Manifest{}, time.Now())
+	if problems, matched, err := Verify(dir, m); err != nil || len(problems) != 0 || matched != 2 {
+		t.Fatalf("Verify() = %v, %d, %v", problems, matched, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "acme", "leaf", "y.json"), []byte("y"), 0644)
+	problems, _, err := Verify(dir, m)
+	if want := []Problem{{"acme/leaf/y.json", "not in manifest.json; it was not generated"}}; err != nil || !reflect.DeepEqual(problems, want) {
+		t.Errorf("Verify() = %v, %v, want %v", problems, err, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	files := map[string][]byte{"bin/hnc": []byte("elf"), "catalog/a.json": []byte("a")}
+	m := New("test", "(devel)", files, Manifest{}, time.Now())
+	files[File] = []byte("{}")
+	if problems, matched := Check(files, m); len(problems) != 0 || matched != 2 {
+		t.Fatalf("Check() = %v, %d", problems, matched)
+	}
+
+	files["bin/hnc"] = []byte("patched")
+	delete(files, "catalog/a.json")
+	files["catalog/b.json"] = []byte("b")
+	problems, matched := Check(files, m)
+	var names []string
+	for _, p := range problems {
+		names = append(names, p.Name)
+	}
+	if want := []string{"bin/hnc", "catalog/a.json", "catalog/b.json"}; matched != 0 || !reflect.DeepEqual(names, want) {
+		t.Errorf("Check() = %+v, %d; want files %q", problems, matched, want)
+	}
+}
@@ -0,0 +1,312 @@
+package netbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/progress"
+)
+
+// Client talks to the NetBox REST API
+type Client struct {
+	URL    string // e.g. https://netbox.example.com
+	Token  string // API token, sent as Authorization: Token
+	Client *http.Client
+}
+
+// Result counts what Push created and what NetBox already had
+type Result struct {
+	Roles, Devices, Interfaces, Cables int // created
+	Existing                           int // devices, interfaces and cables already there
+}
+
+// object is the part of a NetBox object Push and Fetch read
+type object struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Cable *struct {
+		ID int `json:"id"`
+	} `json:"cable"`
+	Role *struct {
+		Slug string `json:"slug"`
+	} `json:"role"`
+	DeviceType *struct {
+		Slug string `json:"slug"`
+	} `json:"device_type"`
+	Status *struct {
+		Value string `json:"value"`
+	} `json:"status"`
+	Label         string        `json:"label"`
+	ATerminations []termination `json:"a_terminations"`
+	BTerminations []termination `json:"b_terminations"`
+}
+
+type termination struct {
+	ObjectType string `json:"object_type"`
+	Object     struct {
+		Name   string `json:"name"`
+		Device struct {
+			Name string `json:"name"`
+		} `json:"device"`
+	} `json:"object"`
+}
+
+// Push creates the export's devices, interfaces and cables at its site,
+// skipping those NetBox already has: devices by name at the site,
+// interfaces by name on their device, and cables whose interfaces are
+// already cabled. The site and the device types must exist; device roles
+// are created when missing.
+func (c Client) Push(e Export) (Result, error) {
+	return c.PushContext(context.Background(), e)
+}
+
+// PushContext is Push with its requests bound to ctx, reporting each
+// device, interface and cable done as a step of the devices, interfaces
+// and cables stages. Stopped, the result counts what was done before.
+func (c Client) PushContext(ctx context.Context, e Export) (Result, error) {
+	var res Result
+	site, err := c.find(ctx, "/api/dcim/sites/", url.Values{"slug": {e.Site}})
+	if err != nil {
+		return res, err
+	}
+	if site == nil {
+		return res, fmt.Errorf("no site %q in NetBox", e.Site)
+	}
+	roles, types := map[string]int{}, map[string]int{}
+	devices, interfaces := map[string]int{}, map[string]*object{}
+	for n, d := range e.Devices {
+		if _, ok := roles[d.Role]; !ok {
+			r, err := c.find(ctx, "/api/dcim/device-roles/", url.Values{"slug": {d.Role}})
+			if err != nil {
+				return res, err
+			}
+			if r == nil {
+				r = &object{}
+				if err := c.call(ctx, http.MethodPost, "/api/dcim/device-roles/", map[string]any{"name": d.Role, "slug": d.Role, "color": "9e9e9e"}, r); err != nil {
+					return res, err
+				}
+				res.Roles++
+			}
+			roles[d.Role] = r.ID
+		}
+		if _, ok := types[d.DeviceType]; !ok {
+			t, err := c.find(ctx, "/api/dcim/device-types/", url.Values{"slug": {d.DeviceType}})
+			if err != nil {
+				return res, err
+			}
+			if t == nil {
+				return res, fmt.Errorf("no device type %q in NetBox; add it, e.g. from the NetBox device type library, before pushing", d.DeviceType)
+			}
+			types[d.DeviceType] = t.ID
+		}
+		dev, err := c.find(ctx, "/api/dcim/devices/", url.Values{"name": {d.Name}, "site_id": {strconv.Itoa(site.ID)}})
+		if err != nil {
+			return res, err
+		}
+		if dev != nil {
+			res.Existing++
+		} else {
+			dev = &object{}
+			body := map[string]any{"name": d.Name, "role": roles[d.Role], "device_type": types[d.DeviceType], "site": site.ID, "status": d.Status}
+			if err := c.call(ctx, http.MethodPost, "/api/dcim/devices/", body, dev); err != nil {
+				return res, err
+			}
+			res.Devices++
+		}
+		devices[d.Name] = dev.ID
+		progress.Report(ctx, "devices", n+1, len(e.Devices))
+	}
+	for n, i := range e.Interfaces {
+		key := i.Device + "/" + i.Name
+		if _, ok := interfaces[key]; ok {
+			progress.Report(ctx, "interfaces", n+1, len(e.Interfaces))
+			continue
+		}
+		device, ok := devices[i.Device]
+		if !ok {
+			return res, fmt.Errorf("interface %s is on no listed device", key)
+		}
+		intf, err := c.find(ctx, "/api/dcim/interfaces/", url.Values{"device_id": {strconv.Itoa(device)}, "name": {i.Name}})
+		if err != nil {
+			return res, err
+		}
+		if intf != nil {
+			res.Existing++
+		} else {
+			intf = &object{}
+			if err := c.call(ctx, http.MethodPost, "/api/dcim/interfaces/", map[string]any{"device": device, "name": i.Name, "type": i.Type}, intf); err != nil {
+				return res, err
+			}
+			res.Interfaces++
+		}
+		interfaces[key] = intf
+		progress.Report(ctx, "interfaces", n+1, len(e.Interfaces))
+	}
+	for n, cable := range e.Cables {
+		a, b := interfaces[cable.SideADevice+"/"+cable.SideAName], interfaces[cable.SideBDevice+"/"+cable.SideBName]
+		if a == nil || b == nil {
+			return res, fmt.Errorf("cable %s:%s <-> %s:%s ends on an unlisted interface", cable.SideADevice, cable.SideAName, cable.SideBDevice, cable.SideBName)
+		}
+		if a.Cable != nil || b.Cable != nil {
+			res.Existing++
+			progress.Report(ctx, "cables", n+1, len(e.Cables))
+			continue
+		}
+		body := map[string]any{
+			"a_terminations": []any{map[string]any{"object_type": InterfaceTermination, "object_id": a.ID}},
+			"b_terminations": []any{map[string]any{"object_type": InterfaceTermination, "object_id": b.ID}},
+			"status":         cable.Status,
+			"label":          cable.Label,
+		}
+		if err := c.call(ctx, http.MethodPost, "/api/dcim/cables/", body, nil); err != nil {
+			return res, err
+		}
+		res.Cables++
+		progress.Report(ctx, "cables", n+1, len(e.Cables))
+	}
+	return res, nil
+}
+
+// Fetch reads the devices of a site and the cables between their
+// interfaces as an export. Interfaces are not read: the cables name them.
+func (c Client) Fetch(site string) (Export, error) {
+	return c.FetchContext(context.Background(), site)
+}
+
+// FetchContext is Fetch with its requests bound to ctx, reporting each
+// page of devices and cables read as steps of the devices and cables
+// stages, out of the count NetBox gives
+func (c Client) FetchContext(ctx context.Context, site string) (Export, error) {
+	e := Export{Site: site}
+	devices, err := c.list(ctx, "devices", "/api/dcim/devices/", url.Values{"site": {site}})
+	if err != nil {
+		return e, err
+	}
+	if len(devices) == 0 {
+		return e, fmt.Errorf("no devices at site %q in NetBox", site)
+	}
+	for _, d := range devices {
+		dev := Device{Name: d.Name, Site: site}
+		if d.Role != nil {
+			dev.Role = d.Role.Slug
+		}
+		if d.DeviceType != nil {
+			dev.DeviceType = d.DeviceType.Slug
+		}
+		if d.Status != nil {
+			dev.Status = d.Status.Value
+		}
+		e.Devices = append(e.Devices, dev)
+	}
+	cables, err := c.list(ctx, "cables", "/api/dcim/cables/", url.Values{"site": {site}})
+	if err != nil {
+		return e, err
+	}
+	for _, cable := range cables {
+		if len(cable.ATerminations) != 1 || len(cable.BTerminations) != 1 {
+			continue
+		}
+		a, b := cable.ATerminations[0], cable.BTerminations[0]
+		out := Cable{
+			SideADevice: a.Object.Device.Name, SideAType: a.ObjectType, SideAName: a.Object.Name,
+			SideBDevice: b.Object.Device.Name, SideBType: b.ObjectType, SideBName: b.Object.Name,
+			Label: cable.Label,
+		}
+		if cable.Status != nil {
+			out.Status = cable.Status.Value
+		}
+		e.Cables = append(e.Cables, out)
+	}
+	return e, nil
+}
+
+// find is the one object of a filtered list, or nil when there is none
+func (c Client) find(ctx context.Context, path string, query url.Values) (*object, error) {
+	objects, err := c.list(ctx, "", path, query)
+	if err != nil || len(objects) == 0 {
+		return nil, err
+	}
+	if len(objects) > 1 {
+		return nil, fmt.Errorf("netbox: %s?%s matches %d objects, want one", path, query.Encode(), len(objects))
+	}
+	return &objects[0], nil
+}
+
+// list reads every page of a filtered list, reporting each as a step of
+// stage, if one is given
+func (c Client) list(ctx context.Context, stage, path string, query url.Values) ([]object, error) {
+	var out []object
+	query.Set("limit", "1000")
+	next := path + "?" + query.Encode()
+	for next != "" {
+		var page struct {
+			Count   int      `json:"count"`
+			Next    string   `json:"next"`
+			Results []object `json:"results"`
+		}
+		if err := c.call(ctx, http.MethodGet, next, nil, &page); err != nil {
+			return nil, err
+		}
+		out = append(out, page.Results...)
+		if stage != "" {
+			progress.Report(ctx, stage, len(out), page.Count)
+		}
+		next = ""
+		if page.Next != "" {
+			u, err := url.Parse(page.Next)
+			if err != nil {
+				return nil, fmt.Errorf("netbox: next page %q: %w", page.Next, err)
+			}
+			next = u.RequestURI()
+		}
+	}
+	return out, nil
+}
+
+// call sends one API request with a JSON body, if there is one, and
+// decodes the JSON response into out, if it is given
+func (c Client) call(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.URL, "/")+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Token "+c.Token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("netbox: %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
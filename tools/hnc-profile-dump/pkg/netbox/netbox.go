@@ -0,0 +1,319 @@
+// Package netbox moves HNC designs in and out of NetBox, the DCIM source
+// of truth many operators keep: a plan and its cabling map become the
+// devices, interfaces and cables NetBox records, written as the YAML its
+// bulk import pages take or created through its REST API, and the devices
+// and cables of a NetBox site are read back as the wiring they amount to,
+// for wiringyaml to reconstruct a plan from.
+//
+// Devices are named as HNC names switches, with the switch's model ID as
+// the device type slug and its Hedgehog role (spine, server-leaf) as the
+// device role slug. NetBox has no notion of leaf redundancy, so reading a
+// site back pairs leaves by their cabling: leaves joined by peer links
+// are an MCLAG pair, and leaves sharing a server without them an ESLAG
+// pair.
+package netbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/capacity"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/wiringyaml"
+	"github.com/hnc/profile-dump/pkg/yamlenc"
+)
+
+// Device roles, as NetBox device role slugs
+const (
+	RoleSpine      = "spine"
+	RoleSuperSpine = "super-spine"
+	RoleLeaf       = "server-leaf"
+	RoleServer     = "server"
+)
+
+// ServerDeviceType is the device type slug of inventoried servers, whose
+// models HNC does not know
+const ServerDeviceType = "server"
+
+// Status every exported device and cable is created with, as nothing is
+// installed yet
+const Status = "planned"
+
+// InterfaceTermination is the termination type of a cable end on an
+// interface
+const InterfaceTermination = "dcim.interface"
+
+// Device is one NetBox device, under the bulk import field names
+type Device struct {
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	DeviceType string `json:"device_type"` // slug
+	Site       string `json:"site"`        // slug
+	Status     string `json:"status"`
+}
+
+// Interface is one cabled port of a device
+type Interface struct {
+	Device string `json:"device"`
+	Name   string `json:"name"`
+	Type   string `json:"type"` // NetBox interface type, e.g. 100gbase-x-qsfp28
+}
+
+// Cable joins two interfaces
+type Cable struct {
+	SideADevice string `json:"side_a_device"`
+	SideAType   string `json:"side_a_type"`
+	SideAName   string `json:"side_a_name"`
+	SideBDevice string `json:"side_b_device"`
+	SideBType   string `json:"side_b_type"`
+	SideBName   string `json:"side_b_name"`
+	Status      string `json:"status"`
+	Label       string `json:"label,omitempty"`
+}
+
+// Export is the devices, interfaces and cables of a design at a site,
+// each list in the order NetBox must create them
+type Export struct {
+	Site       string      `json:"site"`
+	Devices    []Device    `json:"devices"`
+	Interfaces []Interface `json:"interfaces"`
+	Cables     []Cable     `json:"cables"`
+}
+
+// interfaceTypes are the NetBox interface types of port speeds, in Gbps
+var interfaceTypes = map[int]string{
+	1:   "1000base-t",
+	10:  "10gbase-x-sfpp",
+	25:  "25gbase-x-sfp28",
+	40:  "40gbase-x-qsfpp",
+	50:  "50gbase-x-sfp56",
+	100: "100gbase-x-qsfp28",
+	200: "200gbase-x-qsfp56",
+	400: "400gbase-x-qsfpdd",
+	800: "800gbase-x-osfp",
+}
+
+// InterfaceType is the NetBox interface type of a port of a speed, other
+// for speeds it has no type for
+func InterfaceType(speedGbps int) string {
+	if t, ok := interfaceTypes[speedGbps]; ok {
+		return t
+	}
+	return "other"
+}
+
+// Build lists the devices, interfaces and cables of a plan cabled as m at
+// site: super-spines, spines, leaves and the servers of the map's server
+// links, then the cables in map order, leaf-spine, peer, spine-super-spine
+// and server, each with its two interfaces. External links are left out,
+// as their peers are not HNC's to name. superSpine is ignored for plans
+// without pods.
+func Build(plan fabricplan.Plan, m cabling.Map, leaf, spine, superSpine profiles.SwitchProfile, site string) (Export, error) {
+	out := Export{Site: site}
+	for _, tier := range []struct {
+		role, name, model string
+		count             int
+	}{
+		{RoleSuperSpine, "superspine", plan.SuperSpineModel, plan.SuperSpines},
+		{RoleSpine, "spine", plan.SpineModel, plan.Spines},
+		{RoleLeaf, "leaf", plan.LeafModel, plan.Leaves},
+	} {
+		for i := 0; i < tier.count; i++ {
+			out.Devices = append(out.Devices, Device{Name: tier.name + strconv.Itoa(i+1), Role: tier.role, DeviceType: tier.model, Site: site, Status: Status})
+		}
+	}
+	seen := map[string]bool{}
+	for _, l := range m.ServerLinks {
+		if !seen[l.Server] {
+			seen[l.Server] = true
+			out.Devices = append(out.Devices, Device{Name: l.Server, Role: RoleServer, DeviceType: ServerDeviceType, Site: site, Status: Status})
+		}
+	}
+
+	_, fabricSpeed, err := capacity.FabricPorts(leaf, plan.Request.Breakout)
+	if err != nil {
+		return Export{}, fmt.Errorf("leaf %w", err)
+	}
+	spineSpeed := 0
+	if plan.Pods > 1 {
+		if _, spineSpeed, err = capacity.FabricPorts(superSpine, ""); err != nil {
+			return Export{}, fmt.Errorf("super-spine %w", err)
+		}
+	}
+	endpointSpeed := leaf.Profiles.Endpoint.SpeedGbps
+	cable := func(a, aPort, b, bPort string, speed int, label string) {
+		out.Interfaces = append(out.Interfaces,
+			Interface{Device: a, Name: aPort, Type: InterfaceType(speed)},
+			Interface{Device: b, Name: bPort, Type: InterfaceType(speed)})
+		out.Cables = append(out.Cables, Cable{
+			SideADevice: a, SideAType: InterfaceTermination, SideAName: aPort,
+			SideBDevice: b, SideBType: InterfaceTermination, SideBName: bPort,
+			Status: Status, Label: label,
+		})
+	}
+	for _, c := range m.Cables {
+		cable(c.Leaf, c.LeafPort, c.Spine, c.SpinePort, fabricSpeed, c.Length)
+	}
+	for _, p := range m.PeerLinks {
+		cable(p.Leaf, p.LeafPort, p.Peer, p.PeerPort, fabricSpeed, p.Length)
+	}
+	for _, l := range m.SpineLinks {
+		cable(l.Spine, l.SpinePort, l.SuperSpine, l.SuperSpinePort, spineSpeed, "")
+	}
+	for _, l := range m.ServerLinks {
+		cable(l.Leaf, l.LeafPort, l.Server, l.NIC, endpointSpeed, "")
+	}
+	return out, nil
+}
+
+// Render writes the export as YAML, a list for each NetBox bulk import
+// page, to be imported devices first, then interfaces, then cables
+func Render(e Export) (string, error) {
+	data, err := yamlenc.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	return "# Generated by hnc export netbox; bulk import the devices, then the interfaces, then the cables\n" + string(data), nil
+}
+
+// Parse reads an export written by Render
+func Parse(data []byte) (Export, error) {
+	docs, err := profiles.ParseYAMLDocuments(data)
+	if err != nil {
+		return Export{}, err
+	}
+	if len(docs) != 1 {
+		return Export{}, fmt.Errorf("want one YAML document, got %d", len(docs))
+	}
+	raw, err := json.Marshal(docs[0])
+	if err != nil {
+		return Export{}, err
+	}
+	var e Export
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return Export{}, err
+	}
+	return e, nil
+}
+
+// Wiring is the wiring the devices and cables amount to: switches for
+// the spine and leaf devices, servers for the server devices, and a
+// connection per spine-leaf pair, per leaf pair's peer links and per
+// server. Devices of other roles, and cables to them, are skipped.
+func (e Export) Wiring() (wiringyaml.Wiring, error) {
+	var w wiringyaml.Wiring
+	role := map[string]string{}
+	for _, d := range e.Devices {
+		role[d.Name] = d.Role
+	}
+	fabric := map[string]*wiringyaml.Connection{}
+	peers := map[string]*wiringyaml.Connection{}
+	servers := map[string]*wiringyaml.Connection{}
+	var fabricOrder, peerOrder, serverOrder []string
+	add := func(conns map[string]*wiringyaml.Connection, order *[]string, name, kind string, l wiringyaml.Link) {
+		c, ok := conns[name]
+		if !ok {
+			c = &wiringyaml.Connection{Name: name, Type: kind}
+			conns[name] = c
+			*order = append(*order, name)
+		}
+		c.Links = append(c.Links, l)
+	}
+	for _, c := range e.Cables {
+		if c.SideAType != InterfaceTermination || c.SideBType != InterfaceTermination {
+			continue
+		}
+		a, b := c.SideADevice+"/"+c.SideAName, c.SideBDevice+"/"+c.SideBName
+		ra, rb := role[c.SideADevice], role[c.SideBDevice]
+		switch {
+		case ra == RoleLeaf && rb == RoleSpine, ra == RoleSpine && rb == RoleLeaf:
+			if ra == RoleLeaf {
+				a, b = b, a
+			}
+			spine, _, _ := strings.Cut(a, "/")
+			leaf, _, _ := strings.Cut(b, "/")
+			add(fabric, &fabricOrder, spine+"--fabric--"+leaf, wiringyaml.Fabric, wiringyaml.Link{A: a, B: b})
+		case ra == RoleLeaf && rb == RoleLeaf:
+			pair := []string{c.SideADevice, c.SideBDevice}
+			sort.Strings(pair)
+			add(peers, &peerOrder, pair[0]+"--mclag-domain--"+pair[1], wiringyaml.MCLAGDomain, wiringyaml.Link{A: a, B: b})
+		case ra == RoleLeaf && rb == RoleServer, ra == RoleServer && rb == RoleLeaf:
+			if ra == RoleLeaf {
+				a, b = b, a
+			}
+			server, _, _ := strings.Cut(a, "/")
+			add(servers, &serverOrder, server, wiringyaml.Unbundled, wiringyaml.Link{A: a, B: b})
+		}
+	}
+
+	// Pair leaves by their peer links, else by the servers they share
+	group := map[string]string{}
+	kind := wiringyaml.MCLAG
+	pair := func(a, b string) {
+		if group[a] == "" && group[b] == "" && a != b {
+			name := kind + "-" + strconv.Itoa(len(group)/2+1)
+			group[a], group[b] = name, name
+		}
+	}
+	for _, name := range peerOrder {
+		l := peers[name].Links[0]
+		a, _, _ := strings.Cut(l.A, "/")
+		b, _, _ := strings.Cut(l.B, "/")
+		pair(a, b)
+	}
+	if len(group) == 0 {
+		kind = wiringyaml.ESLAG
+	}
+	for _, name := range serverOrder {
+		c := servers[name]
+		leaves := map[string]bool{}
+		for _, l := range c.Links {
+			leaf, _, _ := strings.Cut(l.B, "/")
+			leaves[leaf] = true
+		}
+		if len(leaves) != 2 {
+			continue
+		}
+		var ab []string
+		for leaf := range leaves {
+			ab = append(ab, leaf)
+		}
+		sort.Strings(ab)
+		if kind == wiringyaml.ESLAG {
+			pair(ab[0], ab[1])
+		}
+		if group[ab[0]] != "" && group[ab[0]] == group[ab[1]] {
+			c.Type = kind
+		}
+	}
+
+	for _, d := range e.Devices {
+		switch d.Role {
+		case RoleSpine, RoleLeaf:
+			sw := wiringyaml.Switch{Name: d.Name, Role: d.Role, Profile: d.DeviceType, Group: group[d.Name]}
+			if sw.Group != "" {
+				sw.Redundancy = kind
+			}
+			w.Switches = append(w.Switches, sw)
+		case RoleServer:
+			w.Servers = append(w.Servers, d.Name)
+		}
+	}
+	for _, order := range []struct {
+		conns map[string]*wiringyaml.Connection
+		names []string
+	}{{fabric, fabricOrder}, {peers, peerOrder}, {servers, serverOrder}} {
+		for _, name := range order.names {
+			w.Connections = append(w.Connections, *order.conns[name])
+		}
+	}
+	if len(w.Switches) == 0 {
+		return w, fmt.Errorf("no %s or %s devices", RoleSpine, RoleLeaf)
+	}
+	return w, nil
+}
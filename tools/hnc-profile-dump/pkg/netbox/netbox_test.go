@@ -0,0 +1,259 @@
+package netbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/progress"
+	"github.com/hnc/profile-dump/pkg/wiringyaml"
+)
+
+func testExport(t *testing.T, redundancy string) (fabricplan.Plan, Export) {
+	t.Helper()
+	leaf, spine := profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine)
+	plan, err := fabricplan.Compute(fabricplan.Request{Endpoints: 96, Oversubscription: 3, Redundancy: redundancy}, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := cabling.Assign(plan, leaf, spine, cabling.RoundRobin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.ServerLinks = []cabling.ServerLink{
+		{Server: "web01", NIC: "eth0", Leaf: "leaf1", LeafPort: "E1/1"},
+		{Server: "web01", NIC: "eth1", Leaf: "leaf2", LeafPort: "E1/1"},
+	}
+	e, err := Build(plan, m, leaf, spine, profiles.SwitchProfile{}, "dc1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan, e
+}
+
+func TestBuild(t *testing.T) {
+	plan, e := testExport(t, fabricplan.MCLAG)
+	if want := plan.Spines + plan.Leaves + 1; len(e.Devices) != want {
+		t.Errorf("%d devices, want %d", len(e.Devices), want)
+	}
+	if d := e.Devices[0]; d != (Device{Name: "spine1", Role: RoleSpine, DeviceType: plan.SpineModel, Site: "dc1", Status: Status}) {
+		t.Errorf("first device = %+v", d)
+	}
+	if d := e.Devices[len(e.Devices)-1]; d.Name != "web01" || d.Role != RoleServer || d.DeviceType != ServerDeviceType {
+		t.Errorf("server device = %+v", d)
+	}
+	if len(e.Interfaces) != 2*len(e.Cables) || e.Interfaces[0].Type != "100gbase-x-qsfp28" || e.Interfaces[len(e.Interfaces)-1].Type != "25gbase-x-sfp28" {
+		t.Errorf("interfaces = %+v", e.Interfaces)
+	}
+	c := e.Cables[0]
+	if c.SideADevice != "leaf1" || c.SideBDevice != "spine1" || c.SideAType != InterfaceTermination || c.Status != Status {
+		t.Errorf("first cable = %+v", c)
+	}
+
+	yaml, err := Render(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"devices:\n", "    name: \"leaf1\"\n    role: \"server-leaf\"\n", "side_a_type: \"dcim.interface\""} {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("YAML missing %q:\n%s", want, yaml)
+		}
+	}
+	back, err := Parse([]byte(yaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, e) {
+		t.Errorf("Parse(Render) differs:\n%+v\n%+v", back, e)
+	}
+}
+
+// A site reads back as the wiring the design was built as, leaf pairs
+// recovered from the cabling
+func TestWiring(t *testing.T) {
+	for _, redundancy := range []string{fabricplan.MCLAG, fabricplan.ESLAG} {
+		plan, e := testExport(t, redundancy)
+		w, err := e.Wiring()
+		if err != nil {
+			t.Fatal(err)
+		}
+		imported, err := wiringyaml.Reconstruct(w, profiles.Default())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Without peer links only the leaves sharing web01 pair up
+		pairs := plan.LeafPairs
+		if redundancy == fabricplan.ESLAG {
+			pairs = 1
+		}
+		got := imported.Plan
+		if got.Leaves != plan.Leaves || got.Spines != plan.Spines || got.UplinksPerLeaf != plan.UplinksPerLeaf ||
+			got.Request.Redundancy != redundancy || got.LeafPairs != pairs || got.Request.Endpoints != 1 {
+			t.Errorf("%s: reconstructed %+v", redundancy, got)
+		}
+		if len(imported.Cabling.Cables) != plan.Leaves*plan.UplinksPerLeaf {
+			t.Errorf("%s: %d cables", redundancy, len(imported.Cabling.Cables))
+		}
+	}
+
+	if _, err := (Export{Devices: []Device{{Name: "fw1", Role: "firewall"}}}).Wiring(); err == nil {
+		t.Error("Wiring of a site without switches succeeded")
+	}
+}
+
+// fakeNetBox serves the list and create endpoints Push and Fetch use,
+// keeping what is created in memory
+type fakeNetBox struct {
+	mu      sync.Mutex
+	objects map[string][]map[string]any // by endpoint, e.g. dcim/devices
+	posts   int
+}
+
+func (f *fakeNetBox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Token secret" {
+		http.Error(w, `{"detail": "Invalid token"}`, http.StatusForbidden)
+		return
+	}
+	endpoint := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/")
+	switch r.Method {
+	case http.MethodGet:
+		var results []map[string]any
+		for _, o := range f.objects[endpoint] {
+			if matches(o, r.URL.Query()) {
+				results = append(results, o)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"count": len(results), "next": nil, "results": results})
+	case http.MethodPost:
+		var o map[string]any
+		json.NewDecoder(r.Body).Decode(&o)
+		f.posts++
+		o["id"] = float64(len(f.objects[endpoint]) + 1)
+		switch endpoint {
+		case "dcim/devices":
+			o["site_slug"] = "dc1"
+			o["role"] = map[string]any{"slug": f.slug("dcim/device-roles", o["role"])}
+			o["device_type"] = map[string]any{"slug": f.slug("dcim/device-types", o["device_type"])}
+			o["status"] = map[string]any{"value": o["status"]}
+		case "dcim/interfaces":
+			o["device_id"] = o["device"]
+			o["device"] = map[string]any{"name": f.name("dcim/devices", o["device"])}
+		case "dcim/cables":
+			o["site_slug"] = "dc1"
+			o["status"] = map[string]any{"value": o["status"]}
+			for _, side := range []string{"a_terminations", "b_terminations"} {
+				term := o[side].([]any)[0].(map[string]any)
+				intf := f.objects["dcim/interfaces"][int(term["object_id"].(float64))-1]
+				intf["cable"] = map[string]any{"id": o["id"]}
+				term["object"] = map[string]any{"name": intf["name"], "device": intf["device"]}
+			}
+		}
+		f.objects[endpoint] = append(f.objects[endpoint], o)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(o)
+	}
+}
+
+// matches filters on the query parameters Push and Fetch send
+func matches(o map[string]any, q map[string][]string) bool {
+	for k, v := range q {
+		var got any
+		switch k {
+		case "limit":
+			continue
+		case "site":
+			got = o["site_slug"]
+		case "site_id":
+			got = "1"
+		case "device_id":
+			got = strconv.Itoa(int(o["device_id"].(float64)))
+		default:
+			got = o[k]
+		}
+		if got != v[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakeNetBox) slug(endpoint string, id any) any {
+	return f.objects[endpoint][int(id.(float64))-1]["slug"]
+}
+
+func (f *fakeNetBox) name(endpoint string, id any) any {
+	return f.objects[endpoint][int(id.(float64))-1]["name"]
+}
+
+func TestPushFetch(t *testing.T) {
+	plan, e := testExport(t, fabricplan.MCLAG)
+	fake := &fakeNetBox{objects: map[string][]map[string]any{
+		"dcim/sites":        {{"id": float64(1), "slug": "dc1"}},
+		"dcim/device-roles": {{"id": float64(1), "slug": RoleSpine}},
+		"dcim/device-types": {{"id": float64(1), "slug": plan.SpineModel}, {"id": float64(2), "slug": plan.LeafModel}},
+	}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	client := Client{URL: srv.URL, Token: "secret"}
+
+	if _, err := client.Push(e); err == nil || !strings.Contains(err.Error(), `no device type "server"`) {
+		t.Fatalf("Push without a server device type = %v", err)
+	}
+	fake.objects["dcim/device-types"] = append(fake.objects["dcim/device-types"], map[string]any{"id": float64(3), "slug": ServerDeviceType})
+	last := map[string]progress.Event{}
+	ctx := progress.WithReporter(context.Background(), progress.Func(func(ev progress.Event) { last[ev.Stage] = ev }))
+	res, err := client.PushContext(ctx, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev := last["cables"]; len(last) != 3 || ev.Done != len(e.Cables) || ev.Total != len(e.Cables) {
+		t.Errorf("progress = %+v", last)
+	}
+	// The first push created the leaf and server roles and the switches
+	// before it stopped at the server
+	want := Result{Devices: 1, Interfaces: len(e.Interfaces), Cables: len(e.Cables), Existing: plan.Spines + plan.Leaves}
+	if res != want {
+		t.Errorf("Push = %+v, want %+v", res, want)
+	}
+	// A second push finds everything there
+	posts := fake.posts
+	if res, err = client.Push(e); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Result{Existing: len(e.Devices) + len(e.Interfaces) + len(e.Cables)}); res != want || fake.posts != posts {
+		t.Errorf("second Push = %+v, want %+v; %d new objects", res, want, fake.posts-posts)
+	}
+
+	clear(last)
+	back, err := client.FetchContext(ctx, "dc1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev := last["devices"]; ev.Done != len(e.Devices) || ev.Total != len(e.Devices) {
+		t.Errorf("Fetch progress = %+v", last)
+	}
+	if len(back.Devices) != len(e.Devices) || !reflect.DeepEqual(back.Cables, e.Cables) {
+		t.Errorf("Fetch = %+v", back)
+	}
+
+	if _, err := (Client{URL: srv.URL, Token: "wrong"}).Fetch("dc1"); err == nil || !strings.Contains(err.Error(), "403 Forbidden") {
+		t.Errorf("Fetch with a bad token = %v", err)
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.PushContext(cancelled, e); !errors.Is(err, context.Canceled) {
+		t.Errorf("Push cancelled = %v", err)
+	}
+}
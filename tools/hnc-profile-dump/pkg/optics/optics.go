@@ -0,0 +1,181 @@
+// Package optics is the transceiver compatibility matrix: for each
+// Hedgehog port profile, the pluggable optics, DAC and AOC cables that fit
+// its cages and how far each reaches. SKUs follow the frontend's generic
+// catalog (src/catalog/sku.json), so both sides order the same parts.
+package optics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Media of an option
+const (
+	// Transceiver is a pluggable optic, one per cage, used with a
+	// separate fiber cable
+	Transceiver = "transceiver"
+	// DAC is a passive copper cable with its connectors built in
+	DAC = "dac"
+	// AOC is an active optical cable with its optics built in
+	AOC = "aoc"
+)
+
+// Option is one part that lights a cage of a port profile
+type Option struct {
+	SKU         string  `json:"sku"`
+	PortProfile string  `json:"portProfile"`
+	Media       string  `json:"media"`
+	Standard    string  `json:"standard,omitempty"` // e.g. 100GBASE-SR4, for transceivers
+	Fiber       string  `json:"fiber,omitempty"`    // MMF or SMF, for transceivers
+	MaxLengthM  float64 `json:"maxLengthMeters"`    // reach, or the cable length of a DAC or AOC
+	Breakout    string  `json:"breakout,omitempty"` // e.g. 4x25G, for breakout cables
+	Description string  `json:"description"`
+}
+
+// Assembly reports whether the option is a cable with both ends built in,
+// so a run needs no separate optics or fiber
+func (o Option) Assembly() bool {
+	return o.Media == DAC || o.Media == AOC
+}
+
+// StockLengthsM are the lengths DAC and AOC cables are sold in, up to
+// each SKU's own length
+var StockLengthsM = map[string][]float64{DAC: {1, 2, 3}, AOC: {3, 5, 7, 10, 15, 20, 30}}
+
+// Cut is a DAC or AOC in the shortest stock length that covers lengthM,
+// its SKU and description naming the length, e.g. GEN-QSFP28-100G-DAC-1M.
+// Transceivers, and cables sold in no shorter length, come back as they
+// are.
+func (o Option) Cut(lengthM float64) Option {
+	for _, stock := range StockLengthsM[o.Media] {
+		if stock >= lengthM && stock < o.MaxLengthM {
+			cut := o
+			cut.MaxLengthM = stock
+			cut.SKU = o.SKU + "-" + strings.ToUpper(cut.Reach())
+			cut.Description = strings.TrimSuffix(o.Description, "("+o.Reach()+")") + "(" + cut.Reach() + ")"
+			return cut
+		}
+	}
+	return o
+}
+
+// Reach is MaxLengthM as a length class: 3m, 10km
+func (o Option) Reach() string {
+	if o.MaxLengthM >= 1000 {
+		return strconv.FormatFloat(o.MaxLengthM/1000, 'f', -1, 64) + "km"
+	}
+	return strconv.FormatFloat(o.MaxLengthM, 'f', -1, 64) + "m"
+}
+
+// options is listed per port profile from the shortest reach to the
+// longest, the order Pick prefers them in
+var options = []Option{
+	{SKU: "GEN-SFP28-25G-DAC", PortProfile: "SFP28-25G", Media: DAC, MaxLengthM: 3, Description: "25G SFP28 DAC cable (3m)"},
+	{SKU: "GEN-SFP28-25G-AOC", PortProfile: "SFP28-25G", Media: AOC, MaxLengthM: 30, Description: "25G SFP28 AOC cable (30m)"},
+	{SKU: "GEN-SFP28-25G-SR", PortProfile: "SFP28-25G", Media: Transceiver, Standard: "25GBASE-SR", Fiber: "MMF", MaxLengthM: 100, Description: "25G SFP28 SR transceiver (850nm, 100m)"},
+	{SKU: "GEN-SFP28-25G-LR", PortProfile: "SFP28-25G", Media: Transceiver, Standard: "25GBASE-LR", Fiber: "SMF", MaxLengthM: 10000, Description: "25G SFP28 LR transceiver (1310nm, 10km)"},
+
+	{SKU: "GEN-QSFP28-100G-DAC", PortProfile: "QSFP28-100G", Media: DAC, MaxLengthM: 3, Description: "100G QSFP28 DAC cable (3m)"},
+	{SKU: "GEN-QSFP28-4X25G-DAC", PortProfile: "QSFP28-100G", Media: DAC, MaxLengthM: 3, Breakout: "4x25G", Description: "100G to 4x25G breakout DAC cable (3m)"},
+	{SKU: "GEN-QSFP28-4X25G-AOC", PortProfile: "QSFP28-100G", Media: AOC, MaxLengthM: 10, Breakout: "4x25G", Description: "100G to 4x25G breakout AOC cable (10m)"},
+	{SKU: "GEN-QSFP28-100G-AOC", PortProfile: "QSFP28-100G", Media: AOC, MaxLengthM: 30, Description: "100G QSFP28 AOC cable (30m)"},
+	{SKU: "GEN-QSFP28-100G-SR4", PortProfile: "QSFP28-100G", Media: Transceiver, Standard: "100GBASE-SR4", Fiber: "MMF", MaxLengthM: 100, Description: "100G QSFP28 SR4 transceiver (850nm, 100m)"},
+	{SKU: "GEN-QSFP28-100G-LR4", PortProfile: "QSFP28-100G", Media: Transceiver, Standard: "100GBASE-LR4", Fiber: "SMF", MaxLengthM: 10000, Description: "100G QSFP28 LR4 transceiver (1310nm, 10km)"},
+
+	{SKU: "GEN-QSFP-DD-400G-DAC", PortProfile: "QSFP-DD-400G", Media: DAC, MaxLengthM: 3, Description: "400G QSFP-DD DAC cable (3m)"},
+	{SKU: "GEN-QSFP-DD-4X100G-DAC", PortProfile: "QSFP-DD-400G", Media: DAC, MaxLengthM: 3, Breakout: "4x100G", Description: "400G to 4x100G breakout DAC cable (3m)"},
+	{SKU: "GEN-QSFP-DD-400G-AOC", PortProfile: "QSFP-DD-400G", Media: AOC, MaxLengthM: 30, Description: "400G QSFP-DD AOC cable (30m)"},
+	{SKU: "GEN-QSFP-DD-400G-SR8", PortProfile: "QSFP-DD-400G", Media: Transceiver, Standard: "400GBASE-SR8", Fiber: "MMF", MaxLengthM: 100, Description: "400G QSFP-DD SR8 transceiver (850nm, 100m)"},
+	{SKU: "GEN-QSFP-DD-400G-LR8", PortProfile: "QSFP-DD-400G", Media: Transceiver, Standard: "400GBASE-LR8", Fiber: "SMF", MaxLengthM: 10000, Description: "400G QSFP-DD LR8 transceiver (1310nm, 10km)"},
+
+	{SKU: "GEN-OSFP-800G-DAC", PortProfile: "OSFP-800G", Media: DAC, MaxLengthM: 2, Description: "800G OSFP DAC cable (2m)"},
+	{SKU: "GEN-OSFP-800G-AOC", PortProfile: "OSFP-800G", Media: AOC, MaxLengthM: 30, Description: "800G OSFP AOC cable (30m)"},
+	{SKU: "GEN-OSFP-800G-SR8", PortProfile: "OSFP-800G", Media: Transceiver, Standard: "800GBASE-SR8", Fiber: "MMF", MaxLengthM: 50, Description: "800G OSFP SR8 transceiver (850nm, 50m)"},
+	{SKU: "GEN-OSFP-800G-DR8", PortProfile: "OSFP-800G", Media: Transceiver, Standard: "800GBASE-DR8", Fiber: "SMF", MaxLengthM: 500, Description: "800G OSFP DR8 transceiver (1310nm, 500m)"},
+}
+
+// All returns every option, grouped by port profile
+func All() []Option {
+	return append([]Option(nil), options...)
+}
+
+// PortProfiles lists the port profiles with options, sorted
+func PortProfiles() []string {
+	var names []string
+	for _, o := range options {
+		if len(names) == 0 || names[len(names)-1] != o.PortProfile {
+			names = append(names, o.PortProfile)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Known reports whether the matrix lists options for the port profile
+func Known(portProfile string) bool {
+	return len(For(portProfile)) > 0
+}
+
+// For returns the options for a port profile, shortest reach first
+func For(portProfile string) []Option {
+	var out []Option
+	for _, o := range options {
+		if o.PortProfile == portProfile {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// BySKU looks up an option by its SKU
+func BySKU(sku string) (Option, bool) {
+	for _, o := range options {
+		if o.SKU == sku {
+			return o, true
+		}
+	}
+	return Option{}, false
+}
+
+// Pick chooses what lights runs of a port profile up to lengthM meters
+// long: the shortest-reach transceiver that covers them or, with
+// directAttach, a DAC or AOC where one is long enough. Breakout cables
+// are never picked, as a breakout run's ends are not alike.
+func Pick(portProfile string, lengthM float64, directAttach bool) (Option, error) {
+	candidates := For(portProfile)
+	if len(candidates) == 0 {
+		return Option{}, fmt.Errorf("no optics for port profile %s", portProfile)
+	}
+	for _, assembly := range []bool{true, false} {
+		if assembly && !directAttach {
+			continue
+		}
+		for _, o := range candidates {
+			if o.Breakout == "" && o.Assembly() == assembly && o.MaxLengthM >= lengthM {
+				return o, nil
+			}
+		}
+	}
+	return Option{}, fmt.Errorf("no %s optic reaches %gm", portProfile, lengthM)
+}
+
+// ParseLength reads a cable length class such as 3m, 0.5m or 2km as
+// meters
+func ParseLength(class string) (float64, error) {
+	number, scale := class, 1.0
+	switch {
+	case strings.HasSuffix(class, "km"):
+		number, scale = strings.TrimSuffix(class, "km"), 1000
+	case strings.HasSuffix(class, "m"):
+		number = strings.TrimSuffix(class, "m")
+	default:
+		return 0, fmt.Errorf("cable length %q is not a length like 3m or 2km", class)
+	}
+	meters, err := strconv.ParseFloat(number, 64)
+	if err != nil || meters <= 0 {
+		return 0, fmt.Errorf("cable length %q is not a length like 3m or 2km", class)
+	}
+	return meters * scale, nil
+}
@@ -0,0 +1,116 @@
+package optics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func TestPick(t *testing.T) {
+	tests := []struct {
+		portProfile  string
+		lengthM      float64
+		directAttach bool
+		want         string
+	}{
+		{"SFP28-25G", 3, false, "GEN-SFP28-25G-SR"},
+		{"SFP28-25G", 3, true, "GEN-SFP28-25G-DAC"},
+		{"SFP28-25G", 10, true, "GEN-SFP28-25G-AOC"},
+		{"SFP28-25G", 300, true, "GEN-SFP28-25G-LR"},
+		{"QSFP28-100G", 10, true, "GEN-QSFP28-100G-AOC"}, // not the 4x25G breakout AOC
+		{"QSFP28-100G", 100, false, "GEN-QSFP28-100G-SR4"},
+		{"OSFP-800G", 200, false, "GEN-OSFP-800G-DR8"},
+	}
+	for _, tt := range tests {
+		got, err := Pick(tt.portProfile, tt.lengthM, tt.directAttach)
+		if err != nil {
+			t.Errorf("Pick(%s, %g, %t): %v", tt.portProfile, tt.lengthM, tt.directAttach, err)
+			continue
+		}
+		if got.SKU != tt.want {
+			t.Errorf("Pick(%s, %g, %t) = %s, want %s", tt.portProfile, tt.lengthM, tt.directAttach, got.SKU, tt.want)
+		}
+	}
+
+	if _, err := Pick("OSFP-800G", 1000, true); err == nil || !strings.Contains(err.Error(), "no OSFP-800G optic reaches 1000m") {
+		t.Errorf("error = %v, want no optic in reach", err)
+	}
+	if _, err := Pick("CFP2-100G", 3, false); err == nil || !strings.Contains(err.Error(), "no optics for port profile CFP2-100G") {
+		t.Errorf("error = %v, want an unknown port profile", err)
+	}
+}
+
+func TestCut(t *testing.T) {
+	dac, _ := BySKU("GEN-QSFP28-100G-DAC")
+	aoc, _ := BySKU("GEN-QSFP28-100G-AOC")
+	sr4, _ := BySKU("GEN-QSFP28-100G-SR4")
+	for _, tc := range []struct {
+		o       Option
+		lengthM float64
+		sku     string
+	}{
+		{dac, 0.8, "GEN-QSFP28-100G-DAC-1M"},
+		{dac, 3, "GEN-QSFP28-100G-DAC"}, // sold at its own length
+		{aoc, 4.2, "GEN-QSFP28-100G-AOC-5M"},
+		{sr4, 5, "GEN-QSFP28-100G-SR4"},
+	} {
+		if got := tc.o.Cut(tc.lengthM); got.SKU != tc.sku {
+			t.Errorf("%s.Cut(%g) = %s, want %s", tc.o.SKU, tc.lengthM, got.SKU, tc.sku)
+		}
+	}
+	if got := aoc.Cut(5); got.Description != "100G QSFP28 AOC cable (5m)" || got.MaxLengthM != 5 {
+		t.Errorf("Cut(5) = %+v", got)
+	}
+}
+
+func TestMatrixCoversBuiltInProfiles(t *testing.T) {
+	for _, p := range profiles.Default().List() {
+		for _, pp := range []profiles.PortProfile{p.Profiles.Endpoint, p.Profiles.Uplink} {
+			if pp.PortProfile != nil && !Known(*pp.PortProfile) {
+				t.Errorf("%s: no optics for port profile %s", p.ModelID, *pp.PortProfile)
+			}
+		}
+	}
+	if got, want := PortProfiles(), []string{"OSFP-800G", "QSFP-DD-400G", "QSFP28-100G", "SFP28-25G"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PortProfiles() = %v, want %v", got, want)
+	}
+}
+
+func TestMatrixIsOrderedByReach(t *testing.T) {
+	seen := map[string]bool{}
+	for _, name := range PortProfiles() {
+		list := For(name)
+		for i, o := range list {
+			if seen[o.SKU] {
+				t.Errorf("%s listed twice", o.SKU)
+			}
+			seen[o.SKU] = true
+			if i > 0 && o.MaxLengthM < list[i-1].MaxLengthM {
+				t.Errorf("%s: %s reaches less than %s before it", name, o.SKU, list[i-1].SKU)
+			}
+			if (o.Media == Transceiver) != (o.Fiber != "") {
+				t.Errorf("%s: only transceivers name a fiber", o.SKU)
+			}
+		}
+	}
+}
+
+func TestParseLength(t *testing.T) {
+	for class, want := range map[string]float64{"3m": 3, "0.5m": 0.5, "2km": 2000} {
+		if got, err := ParseLength(class); err != nil || got != want {
+			t.Errorf("ParseLength(%s) = %g, %v; want %g", class, got, err, want)
+		}
+	}
+	for _, o := range For("SFP28-25G") {
+		if got, err := ParseLength(o.Reach()); err != nil || got != o.MaxLengthM {
+			t.Errorf("ParseLength(%s.Reach()) = %g, %v; want %g", o.SKU, got, err, o.MaxLengthM)
+		}
+	}
+	for _, class := range []string{"", "3", "m", "-1m", "3ft"} {
+		if _, err := ParseLength(class); err == nil {
+			t.Errorf("ParseLength(%q) succeeded, want an error", class)
+		}
+	}
+}
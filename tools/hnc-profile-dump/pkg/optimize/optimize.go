@@ -0,0 +1,214 @@
+// Package optimize chooses the leaf and spine models of a fabric plan.
+// Every leaf and spine pairing the registry offers is sized with
+// fabricplan.Compute, which already meets the endpoint, oversubscription
+// and redundancy constraints with the fewest switches of those models,
+// and the pairing that scores lowest on an objective wins. Objectives are
+// entries of Objectives, so a new one is a score function away. Pairings
+// whose profiles' capabilities cannot carry the plan are skipped, as are
+// those whose profiles lack the data the objective scores on; a catalog
+// where no leaf or no spine has that data is an error of its own.
+// PlanContext stops between pairings once its context is done, and
+// reports each pairing sized to the context's progress.Reporter.
+package optimize
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/capacity"
+	"github.com/hnc/profile-dump/pkg/constraints"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/progress"
+)
+
+// Objective is something to minimize over candidate plans
+type Objective struct {
+	Name    string
+	Summary string
+	// Field is the profile field the objective scores on, "" for none
+	Field string
+	// has reports whether a profile sets Field
+	has func(p profiles.SwitchProfile) bool
+	// score is the candidate's value, or an error when its profiles lack
+	// the data the objective needs
+	score func(c Candidate) (float64, error)
+}
+
+// Objectives lists the supported objectives
+var Objectives = []Objective{
+	{Name: "cost", Summary: "list price of the switches", Field: "cost.listPriceUsd", score: cost,
+		has: func(p profiles.SwitchProfile) bool { return p.Cost != nil }},
+	{Name: "power", Summary: "typical power draw of the switches", Field: "physical.typicalPowerWatts", score: power,
+		has: func(p profiles.SwitchProfile) bool { return p.Physical != nil }},
+	{Name: "ports", Summary: "unused endpoint and fabric ports", score: unusedPorts},
+}
+
+// Names lists the objective names
+func Names() []string {
+	names := make([]string, len(Objectives))
+	for i, o := range Objectives {
+		names[i] = o.Name
+	}
+	return names
+}
+
+// Find looks up an objective by name
+func Find(name string) (Objective, bool) {
+	for _, o := range Objectives {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return Objective{}, false
+}
+
+// Candidate is one leaf and spine pairing, sized for the request
+type Candidate struct {
+	Plan  fabricplan.Plan        `json:"plan"`
+	Leaf  profiles.SwitchProfile `json:"-"`
+	Spine profiles.SwitchProfile `json:"-"`
+	Score float64                `json:"score"`
+}
+
+// Result is the best candidate and why the others lost or were left out
+type Result struct {
+	Objective  string      `json:"objective"`
+	Best       Candidate   `json:"best"`
+	Candidates []Candidate `json:"candidates"`        // every scored pairing, best first
+	Skipped    []string    `json:"skipped,omitempty"` // pairings that do not fit or lack data, with the reason
+}
+
+// Plan sizes every pairing of leaves and spines, each in its role, and
+// returns them ranked on the objective. Ties go to the plan with fewer
+// switches, then to model IDs in order, so the choice is stable.
+func Plan(req fabricplan.Request, leaves, spines []profiles.SwitchProfile, objective string) (Result, error) {
+	return PlanContext(context.Background(), req, leaves, spines, objective)
+}
+
+// PlanContext is Plan that stops once ctx is done, reporting each pairing
+// sized as a step of the pairings stage. Stopped, it returns the pairings
+// ranked so far with an error wrapping ctx's.
+func PlanContext(ctx context.Context, req fabricplan.Request, leaves, spines []profiles.SwitchProfile, objective string) (Result, error) {
+	o, ok := Find(objective)
+	if !ok {
+		return Result{}, fmt.Errorf("unknown objective %q (want %s)", objective, strings.Join(Names(), ", "))
+	}
+	if len(leaves) == 0 || len(spines) == 0 {
+		return Result{}, fmt.Errorf("no leaf or spine models to choose from")
+	}
+	if o.has != nil {
+		for _, tier := range []struct {
+			role   string
+			models []profiles.SwitchProfile
+		}{{"leaf", leaves}, {"spine", spines}} {
+			if !slices.ContainsFunc(tier.models, o.has) {
+				return Result{Objective: o.Name}, fmt.Errorf("the catalog has no %s data: none of its %d %s model(s) sets %s", o.Name, len(tier.models), tier.role, o.Field)
+			}
+		}
+	}
+	res := Result{Objective: o.Name}
+	done, total := 0, len(leaves)*len(spines)
+	var stopped error
+pairings:
+	for _, leaf := range leaves {
+		for _, spine := range spines {
+			if err := ctx.Err(); err != nil {
+				stopped = fmt.Errorf("optimizing stopped after %d of %d pairing(s): %w", done, total, err)
+				break pairings
+			}
+			done++
+			progress.Report(ctx, "pairings", done, total)
+			leaf, spine := leaf.InRole(profiles.RoleLeaf), spine.InRole(profiles.RoleSpine)
+			pair := leaf.ModelID + " + " + spine.ModelID
+			plan, err := fabricplan.Compute(req, leaf, spine)
+			if err != nil {
+				res.Skipped = append(res.Skipped, fmt.Sprintf("%s: %v", pair, err))
+				continue
+			}
+			if problems := constraints.Check(plan, nil, leaf, spine); len(problems) > 0 {
+				res.Skipped = append(res.Skipped, fmt.Sprintf("%s: %s", pair, strings.Join(problems, "; ")))
+				continue
+			}
+			c := Candidate{Plan: plan, Leaf: leaf, Spine: spine}
+			if c.Score, err = o.score(c); err != nil {
+				res.Skipped = append(res.Skipped, fmt.Sprintf("%s: %v", pair, err))
+				continue
+			}
+			res.Candidates = append(res.Candidates, c)
+		}
+	}
+	if len(res.Candidates) == 0 && stopped == nil {
+		return res, fmt.Errorf("none of %d leaf and spine pairing(s) fits the request and can be scored on %s", len(res.Skipped), o.Name)
+	}
+	sort.SliceStable(res.Candidates, func(i, j int) bool {
+		a, b := res.Candidates[i], res.Candidates[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if sa, sb := a.Plan.Leaves+a.Plan.Spines, b.Plan.Leaves+b.Plan.Spines; sa != sb {
+			return sa < sb
+		}
+		return a.Plan.LeafModel+" "+a.Plan.SpineModel < b.Plan.LeafModel+" "+b.Plan.SpineModel
+	})
+	if len(res.Candidates) > 0 {
+		res.Best = res.Candidates[0]
+	}
+	return res, stopped
+}
+
+// Choices splits a registry into the models that can lead each tier:
+// profiles listing the leaf role, and those listing the spine role
+func Choices(ps []profiles.SwitchProfile) (leaves, spines []profiles.SwitchProfile) {
+	for _, p := range ps {
+		if slices.Contains(p.Roles, profiles.RoleLeaf) {
+			leaves = append(leaves, p)
+		}
+		if slices.Contains(p.Roles, profiles.RoleSpine) {
+			spines = append(spines, p)
+		}
+	}
+	return leaves, spines
+}
+
+func cost(c Candidate) (float64, error) {
+	for _, p := range []profiles.SwitchProfile{c.Leaf, c.Spine} {
+		if p.Cost == nil {
+			return 0, fmt.Errorf("%s has no cost.listPriceUsd", p.ModelID)
+		}
+	}
+	return float64(c.Plan.Leaves)*c.Leaf.Cost.ListPriceUSD + float64(c.Plan.Spines)*c.Spine.Cost.ListPriceUSD, nil
+}
+
+func power(c Candidate) (float64, error) {
+	for _, p := range []profiles.SwitchProfile{c.Leaf, c.Spine} {
+		if p.Physical == nil {
+			return 0, fmt.Errorf("%s has no physical.typicalPowerWatts", p.ModelID)
+		}
+	}
+	return float64(c.Plan.Leaves)*c.Leaf.Physical.TypicalPowerWatts + float64(c.Plan.Spines)*c.Spine.Physical.TypicalPowerWatts, nil
+}
+
+// unusedPorts counts the endpoint ports no endpoint or external uplink
+// takes and the fabric ports no uplink or peer link takes, on every
+// switch of the plan
+func unusedPorts(c Candidate) (float64, error) {
+	p := c.Plan
+	endpoints, err := capacity.MaxEndpoints(c.Leaf, "")
+	if err != nil {
+		return 0, err
+	}
+	leafFabric, _, err := capacity.FabricPorts(c.Leaf, p.Request.Breakout)
+	if err != nil {
+		return 0, err
+	}
+	free := p.Leaves*endpoints - p.EndpointPorts() - p.ExternalPorts() + p.Leaves*(leafFabric-p.UplinksPerLeaf) + p.Spines*p.SpinePortsFree
+	if p.PeerLinksPerLeaf > 0 {
+		cages, _, _ := capacity.FabricPorts(c.Leaf, "")
+		free -= p.Leaves * p.PeerLinksPerLeaf * leafFabric / cages
+	}
+	return float64(free), nil
+}
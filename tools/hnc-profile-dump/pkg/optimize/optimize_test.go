@@ -0,0 +1,148 @@
+package optimize
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/progress"
+)
+
+// priced gives a copy of p its own model ID, list price and power draw
+func priced(p profiles.SwitchProfile, modelID string, usd, watts float64) profiles.SwitchProfile {
+	p.ModelID = modelID
+	p.Cost = &profiles.Cost{ListPriceUSD: usd}
+	p.Physical = &profiles.Physical{TypicalPowerWatts: watts}
+	return p
+}
+
+// unpriced gives a copy of p its own model ID and neither list price nor
+// power draw
+func unpriced(p profiles.SwitchProfile, modelID string) profiles.SwitchProfile {
+	p.ModelID, p.Cost, p.Physical = modelID, nil, nil
+	return p
+}
+
+func TestPlanPicksLowestScore(t *testing.T) {
+	req := fabricplan.Request{Endpoints: 200, Oversubscription: 3}
+	leaves := []profiles.SwitchProfile{
+		priced(profiles.DS2000(), "leaf-dear", 20000, 300),
+		priced(profiles.DS2000(), "leaf-cheap", 12000, 450),
+	}
+	spines := []profiles.SwitchProfile{priced(profiles.DS3000(), "spine", 30000, 600)}
+
+	for objective, want := range map[string]string{"cost": "leaf-cheap", "power": "leaf-dear"} {
+		res, err := Plan(req, leaves, spines, objective)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Best.Plan.LeafModel != want || len(res.Candidates) != 2 || res.Candidates[1].Score < res.Best.Score {
+			t.Errorf("%s: best %s, candidates %+v", objective, res.Best.Plan.LeafModel, res.Candidates)
+		}
+	}
+	// 5 leaves and 2 spines
+	res, _ := Plan(req, leaves[1:], spines, "cost")
+	if res.Best.Score != 5*12000+2*30000 {
+		t.Errorf("cost score = %g, want %d", res.Best.Score, 5*12000+2*30000)
+	}
+}
+
+func TestPlanTiesGoToModelOrder(t *testing.T) {
+	req := fabricplan.Request{Endpoints: 96, Oversubscription: 3}
+	leaves := []profiles.SwitchProfile{
+		priced(profiles.DS2000(), "leaf-b", 1, 1),
+		priced(profiles.DS2000(), "leaf-a", 1, 1),
+	}
+	res, err := Plan(req, leaves, []profiles.SwitchProfile{profiles.DS3000()}, "ports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Best.Plan.LeafModel != "leaf-a" || res.Best.Score <= 0 {
+		t.Errorf("best = %s scoring %g, want leaf-a", res.Best.Plan.LeafModel, res.Best.Score)
+	}
+}
+
+func TestPlanSkipsPairingsWithoutData(t *testing.T) {
+	req := fabricplan.Request{Endpoints: 96, Oversubscription: 3}
+	leaves := []profiles.SwitchProfile{unpriced(profiles.DS2000(), "leaf-unpriced"), priced(profiles.DS2000(), "leaf-priced", 10000, 300)}
+	spines := []profiles.SwitchProfile{priced(profiles.DS3000(), "spine", 30000, 600)}
+	res, err := Plan(req, leaves, spines, "cost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Best.Plan.LeafModel != "leaf-priced" || len(res.Skipped) != 1 || !strings.Contains(res.Skipped[0], "has no cost.listPriceUsd") {
+		t.Errorf("best %s, skipped %v", res.Best.Plan.LeafModel, res.Skipped)
+	}
+
+	_, err = Plan(req, leaves[:1], spines, "cost")
+	if err == nil || !strings.Contains(err.Error(), "the catalog has no cost data: none of its 1 leaf model(s) sets cost.listPriceUsd") {
+		t.Errorf("Plan() without prices = %v", err)
+	}
+	_, err = Plan(req, leaves, []profiles.SwitchProfile{unpriced(profiles.DS3000(), "spine")}, "power")
+	if err == nil || !strings.Contains(err.Error(), "the catalog has no power data: none of its 1 spine model(s) sets physical.typicalPowerWatts") {
+		t.Errorf("Plan() without power figures = %v", err)
+	}
+	if _, err := Plan(req, leaves, spines, "speed"); err == nil || !strings.Contains(err.Error(), "unknown objective") {
+		t.Errorf("Plan(speed) = %v", err)
+	}
+}
+
+func TestPlanSkipsPairingsBeyondCapabilities(t *testing.T) {
+	req := fabricplan.Request{Endpoints: 96, Oversubscription: 3}
+	small := priced(profiles.DS2000(), "leaf-small", 10000, 300)
+	small.Capabilities = &profiles.Capabilities{ASIC: "trident3", Tables: profiles.Tables{MACs: 64}}
+	leaves := []profiles.SwitchProfile{small, priced(profiles.DS2000(), "leaf-dear", 20000, 300)}
+	spines := []profiles.SwitchProfile{priced(profiles.DS3000(), "spine", 30000, 600)}
+	res, err := Plan(req, leaves, spines, "cost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Best.Plan.LeafModel != "leaf-dear" || len(res.Skipped) != 1 || !strings.Contains(res.Skipped[0], "leaf-small (trident3) holds 64 MAC addresses, the design needs 96") {
+		t.Errorf("best %s, skipped %v", res.Best.Plan.LeafModel, res.Skipped)
+	}
+}
+
+// Cancelled partway, the pairings sized so far come back ranked
+func TestPlanContextStops(t *testing.T) {
+	req := fabricplan.Request{Endpoints: 200, Oversubscription: 3}
+	leaves := []profiles.SwitchProfile{
+		priced(profiles.DS2000(), "leaf-a", 20000, 300),
+		priced(profiles.DS2000(), "leaf-b", 12000, 450),
+		priced(profiles.DS2000(), "leaf-c", 10000, 450),
+	}
+	spines := []profiles.SwitchProfile{priced(profiles.DS3000(), "spine", 30000, 600)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []progress.Event
+	ctx = progress.WithReporter(ctx, progress.Func(func(e progress.Event) {
+		events = append(events, e)
+		if e.Done == 2 {
+			cancel()
+		}
+	}))
+	res, err := PlanContext(ctx, req, leaves, spines, "cost")
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "stopped after 2 of 3 pairing(s)") {
+		t.Fatalf("PlanContext cancelled = %v", err)
+	}
+	if len(res.Candidates) != 2 || res.Best.Plan.LeafModel != "leaf-b" {
+		t.Errorf("partial result = %+v", res)
+	}
+	if want := (progress.Event{Stage: "pairings", Done: 2, Total: 3}); len(events) != 2 || events[1] != want {
+		t.Errorf("events = %+v, want the last %+v", events, want)
+	}
+}
+
+func TestChoices(t *testing.T) {
+	leaves, spines := Choices(profiles.Default().List())
+	if len(leaves) == 0 || len(spines) == 0 {
+		t.Fatalf("leaves %d, spines %d", len(leaves), len(spines))
+	}
+	for _, p := range leaves {
+		if !strings.Contains(strings.Join(p.Roles, ","), profiles.RoleLeaf) {
+			t.Errorf("%s listed as a leaf, roles %v", p.ModelID, p.Roles)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Package plandiff compares two versions of a fabric design for change
+// review: the switches the new plan adds, removes or swaps for another
+// model, the leaf-spine cables and peer links that appear, disappear or
+// move to another port, how the plan's capacity shifts, and, given both
+// VPC plans, the VPCs, subnets and attachments whose VLANs or VNIs are
+// reassigned, and, given both cost estimates, what the BOM costs by
+// category. Changes are listed in plan order, so an expansion reads as
+// the new switches and cables at the end.
+package plandiff
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/bom"
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/pricing"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+// Kinds of change, from the old design to the new
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Design is one side of the comparison: a plan, its cabling and, when
+// VLANs and VNIs are compared, its VPC plan, and when costs are, the cost
+// estimate of its BOM
+type Design struct {
+	Plan    fabricplan.Plan
+	Cabling cabling.Map
+	VPCs    *vpcplan.Plan
+	Cost    *pricing.Estimate
+}
+
+// Change is one object added, removed, or with a field changed
+type Change struct {
+	Kind   string `json:"kind"`
+	Object string `json:"object"`          // e.g. switch/leaf5, cable/leaf1:E1/49, subnet/vpc-1/subnet-1
+	Field  string `json:"field,omitempty"` // for changed objects, e.g. model, vlan
+	Old    any    `json:"old,omitempty"`
+	New    any    `json:"new,omitempty"`
+}
+
+// Delta is a capacity figure that differs between the plans
+type Delta struct {
+	Metric string  `json:"metric"`
+	Old    float64 `json:"old"`
+	New    float64 `json:"new"`
+	Change float64 `json:"change"` // new - old
+}
+
+// Diff is everything that differs, by section
+type Diff struct {
+	Switches []Change `json:"switches"`
+	Links    []Change `json:"links"`
+	Capacity []Delta  `json:"capacity"`
+	VPCs     []Change `json:"vpcs,omitempty"` // only when both designs have a VPC plan
+	Cost     []Delta  `json:"cost,omitempty"` // only when both designs have a cost estimate
+}
+
+// Empty reports whether the designs are the same
+func (d Diff) Empty() bool {
+	return len(d.Switches)+len(d.Links)+len(d.Capacity)+len(d.VPCs)+len(d.Cost) == 0
+}
+
+// Compare diffs old against new
+func Compare(old, new Design) Diff {
+	d := Diff{
+		Switches: switches(old.Plan, new.Plan),
+		Links:    links(old.Cabling, new.Cabling),
+		Capacity: capacity(old, new),
+	}
+	if old.VPCs != nil && new.VPCs != nil {
+		d.VPCs = vpcs(*old.VPCs, *new.VPCs)
+	}
+	if old.Cost != nil && new.Cost != nil {
+		d.Cost = cost(*old.Cost, *new.Cost)
+	}
+	if d.Switches == nil {
+		d.Switches = []Change{}
+	}
+	if d.Links == nil {
+		d.Links = []Change{}
+	}
+	if d.Capacity == nil {
+		d.Capacity = []Delta{}
+	}
+	return d
+}
+
+// switches compares the super-spines, spines, then the leaves, by name
+func switches(old, new fabricplan.Plan) []Change {
+	var changes []Change
+	for _, tier := range []struct {
+		role               string
+		oldCount, newCount int
+		oldModel, newModel string
+	}{
+		{"superspine", old.SuperSpines, new.SuperSpines, old.SuperSpineModel, new.SuperSpineModel},
+		{"spine", old.Spines, new.Spines, old.SpineModel, new.SpineModel},
+		{"leaf", old.Leaves, new.Leaves, old.LeafModel, new.LeafModel},
+	} {
+		for i := 1; i <= max(tier.oldCount, tier.newCount); i++ {
+			object := "switch/" + tier.role + strconv.Itoa(i)
+			switch {
+			case i > tier.newCount:
+				changes = append(changes, Change{Kind: Removed, Object: object, Old: tier.oldModel})
+			case i > tier.oldCount:
+				changes = append(changes, Change{Kind: Added, Object: object, New: tier.newModel})
+			case tier.oldModel != tier.newModel:
+				changes = append(changes, Change{Kind: Changed, Object: object, Field: "model", Old: tier.oldModel, New: tier.newModel})
+			}
+		}
+	}
+	return changes
+}
+
+// links compares cables, peer links, external and server links by their
+// leaf end, and spine links by their spine end: a port cabled to another
+// far end is changed
+func links(old, new cabling.Map) []Change {
+	type link struct{ near, far string }
+	ends := func(m cabling.Map) []link {
+		var out []link
+		for _, c := range m.Cables {
+			out = append(out, link{c.Leaf + ":" + c.LeafPort, c.Spine + ":" + c.SpinePort})
+		}
+		for _, p := range m.PeerLinks {
+			out = append(out, link{p.Leaf + ":" + p.LeafPort, p.Peer + ":" + p.PeerPort})
+		}
+		for _, l := range m.SpineLinks {
+			out = append(out, link{l.Spine + ":" + l.SpinePort, l.SuperSpine + ":" + l.SuperSpinePort})
+		}
+		for _, e := range m.ExternalLinks {
+			out = append(out, link{e.Leaf + ":" + e.LeafPort, e.Peer})
+		}
+		for _, s := range m.ServerLinks {
+			out = append(out, link{s.Leaf + ":" + s.LeafPort, s.Server + ":" + s.NIC})
+		}
+		return out
+	}
+	oldLinks, newLinks := ends(old), ends(new)
+	newFar := map[string]string{}
+	for _, l := range newLinks {
+		newFar[l.near] = l.far
+	}
+	var changes []Change
+	seen := map[string]bool{}
+	for _, l := range oldLinks {
+		seen[l.near] = true
+		object := "cable/" + l.near
+		far, ok := newFar[l.near]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Removed, Object: object, Old: l.near + " <-> " + l.far})
+		case far != l.far:
+			changes = append(changes, Change{Kind: Changed, Object: object, Field: "peer", Old: l.far, New: far})
+		}
+	}
+	for _, l := range newLinks {
+		if !seen[l.near] {
+			changes = append(changes, Change{Kind: Added, Object: "cable/" + l.near, New: l.near + " <-> " + l.far})
+		}
+	}
+	return changes
+}
+
+// capacity lists the plan figures that differ
+func capacity(old, new Design) []Delta {
+	figures := func(d Design) []float64 {
+		p := d.Plan
+		return []float64{
+			float64(p.Request.Endpoints),
+			float64(p.Leaves),
+			float64(p.Spines),
+			float64(p.EndpointsPerLeaf),
+			float64(p.UplinksPerLeaf),
+			float64(p.Leaves * p.UplinkGbpsPerLeaf),
+			p.AchievedOversubscription,
+			float64(p.SpinePortsFree),
+			float64(len(d.Cabling.Cables)),
+			float64(len(d.Cabling.PeerLinks)),
+			float64(p.Pods),
+			float64(p.SuperSpines),
+			float64(p.SpineUplinks),
+			p.AchievedPodOversubscription,
+			float64(len(d.Cabling.SpineLinks)),
+			float64(p.BorderLeaves),
+			float64(p.ExternalPorts()),
+		}
+	}
+	metrics := []string{"endpoints", "leaves", "spines", "endpointsPerLeaf", "uplinksPerLeaf", "uplinkGbps",
+		"oversubscription", "spinePortsFree", "cables", "peerLinks",
+		"pods", "superSpines", "spineUplinks", "podOversubscription", "spineLinks",
+		"borderLeaves", "externalPorts"}
+	a, b := figures(old), figures(new)
+	var deltas []Delta
+	for i, metric := range metrics {
+		if a[i] != b[i] {
+			deltas = append(deltas, Delta{Metric: metric, Old: a[i], New: b[i], Change: b[i] - a[i]})
+		}
+	}
+	return deltas
+}
+
+// cost lists the BOM categories whose cost differs, then the total
+func cost(old, new pricing.Estimate) []Delta {
+	var deltas []Delta
+	for _, category := range []string{bom.Switch, bom.Optic, bom.Cable} {
+		if a, b := old.ByCategory[category], new.ByCategory[category]; a != b {
+			deltas = append(deltas, Delta{Metric: category, Old: a, New: b, Change: cents(b - a)})
+		}
+	}
+	if old.Total != new.Total {
+		deltas = append(deltas, Delta{Metric: "total", Old: old.Total, New: new.Total, Change: cents(new.Total - old.Total)})
+	}
+	return deltas
+}
+
+// cents rounds a cost change to two decimals, as estimates are
+func cents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// vpcs compares VPCs and their subnets by name, and the attachments of
+// the endpoints both plans attach
+func vpcs(old, new vpcplan.Plan) []Change {
+	var changes []Change
+	field := func(object, name string, a, b any) {
+		if a != b {
+			changes = append(changes, Change{Kind: Changed, Object: object, Field: name, Old: a, New: b})
+		}
+	}
+
+	newVPCs := map[string]vpcplan.VPC{}
+	for _, v := range new.VPCs {
+		newVPCs[v.Name] = v
+	}
+	oldVPCs := map[string]bool{}
+	for _, v := range old.VPCs {
+		oldVPCs[v.Name] = true
+		n, ok := newVPCs[v.Name]
+		if !ok {
+			changes = append(changes, Change{Kind: Removed, Object: "vpc/" + v.Name, Old: v.VNI})
+			continue
+		}
+		field("vpc/"+v.Name, "vni", v.VNI, n.VNI)
+		newSubnets := map[string]vpcplan.Subnet{}
+		for _, s := range n.Subnets {
+			newSubnets[s.Name] = s
+		}
+		oldSubnets := map[string]bool{}
+		for _, s := range v.Subnets {
+			oldSubnets[s.Name] = true
+			object := "subnet/" + v.Name + "/" + s.Name
+			ns, ok := newSubnets[s.Name]
+			if !ok {
+				changes = append(changes, Change{Kind: Removed, Object: object, Old: s.CIDR})
+				continue
+			}
+			field(object, "vlan", s.VLAN, ns.VLAN)
+			field(object, "vni", s.VNI, ns.VNI)
+			field(object, "cidr", s.CIDR, ns.CIDR)
+		}
+		for _, s := range n.Subnets {
+			if !oldSubnets[s.Name] {
+				changes = append(changes, Change{Kind: Added, Object: "subnet/" + v.Name + "/" + s.Name, New: s.CIDR})
+			}
+		}
+	}
+	for _, v := range new.VPCs {
+		if !oldVPCs[v.Name] {
+			changes = append(changes, Change{Kind: Added, Object: "vpc/" + v.Name, New: v.VNI})
+		}
+	}
+
+	// an attachment is named for its subnet, so endpoints it moves are
+	// matched by endpoint
+	newAttachments := map[string]vpcplan.Attachment{}
+	for _, a := range new.Attachments {
+		newAttachments[a.Endpoint] = a
+	}
+	for _, a := range old.Attachments {
+		if n, ok := newAttachments[a.Endpoint]; ok {
+			object := "attachment/" + a.Endpoint
+			field(object, "leaves", strings.Join(a.Leaves, ","), strings.Join(n.Leaves, ","))
+			field(object, "subnet", a.Subnet, n.Subnet)
+			field(object, "vlan", a.VLAN, n.VLAN)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,107 @@
+package plandiff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/bom"
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/pricing"
+	"github.com/hnc/profile-dump/pkg/vpcplan"
+)
+
+func TestCompareExpansion(t *testing.T) {
+	old := Design{
+		Plan: fabricplan.Plan{Request: fabricplan.Request{Endpoints: 48}, LeafModel: "ds2000", SpineModel: "ds3000", Leaves: 1, Spines: 2, UplinksPerLeaf: 2},
+		Cabling: cabling.Map{Cables: []cabling.Cable{
+			{Leaf: "leaf1", LeafPort: "E1/49", Spine: "spine1", SpinePort: "E1/1"},
+			{Leaf: "leaf1", LeafPort: "E1/50", Spine: "spine2", SpinePort: "E1/1"},
+		}},
+	}
+	new := Design{
+		Plan: fabricplan.Plan{Request: fabricplan.Request{Endpoints: 96}, LeafModel: "ds2000", SpineModel: "ds4000", Leaves: 2, Spines: 1, UplinksPerLeaf: 2},
+		Cabling: cabling.Map{Cables: []cabling.Cable{
+			{Leaf: "leaf1", LeafPort: "E1/49", Spine: "spine1", SpinePort: "E1/1"},
+			{Leaf: "leaf1", LeafPort: "E1/50", Spine: "spine1", SpinePort: "E1/2"},
+			{Leaf: "leaf2", LeafPort: "E1/49", Spine: "spine1", SpinePort: "E1/3"},
+		}},
+	}
+	d := Compare(old, new)
+
+	wantSwitches := []Change{
+		{Kind: Changed, Object: "switch/spine1", Field: "model", Old: "ds3000", New: "ds4000"},
+		{Kind: Removed, Object: "switch/spine2", Old: "ds3000"},
+		{Kind: Added, Object: "switch/leaf2", New: "ds2000"},
+	}
+	if !reflect.DeepEqual(d.Switches, wantSwitches) {
+		t.Errorf("switches = %+v\nwant %+v", d.Switches, wantSwitches)
+	}
+	wantLinks := []Change{
+		{Kind: Changed, Object: "cable/leaf1:E1/50", Field: "peer", Old: "spine2:E1/1", New: "spine1:E1/2"},
+		{Kind: Added, Object: "cable/leaf2:E1/49", New: "leaf2:E1/49 <-> spine1:E1/3"},
+	}
+	if !reflect.DeepEqual(d.Links, wantLinks) {
+		t.Errorf("links = %+v\nwant %+v", d.Links, wantLinks)
+	}
+	wantCapacity := []Delta{
+		{Metric: "endpoints", Old: 48, New: 96, Change: 48},
+		{Metric: "leaves", Old: 1, New: 2, Change: 1},
+		{Metric: "spines", Old: 2, New: 1, Change: -1},
+		{Metric: "cables", Old: 2, New: 3, Change: 1},
+	}
+	if !reflect.DeepEqual(d.Capacity, wantCapacity) {
+		t.Errorf("capacity = %+v\nwant %+v", d.Capacity, wantCapacity)
+	}
+	if d.VPCs != nil || d.Empty() {
+		t.Errorf("VPCs = %+v without VPC plans, empty = %v", d.VPCs, d.Empty())
+	}
+	if same := Compare(old, old); !same.Empty() {
+		t.Errorf("a design differs from itself: %+v", same)
+	}
+}
+
+func TestCompareVPCs(t *testing.T) {
+	old := &vpcplan.Plan{
+		VPCs: []vpcplan.VPC{{Name: "vpc-1", VNI: 100000, Subnets: []vpcplan.Subnet{{Name: "subnet-1", CIDR: "10.0.0.0/24", VLAN: 1000, VNI: 100001}}}},
+		Attachments: []vpcplan.Attachment{
+			{Name: "server1--vpc-1--subnet-1", Endpoint: "server1", Leaves: []string{"leaf1"}, Subnet: "vpc-1/subnet-1", VLAN: 1000},
+		},
+	}
+	new := &vpcplan.Plan{
+		VPCs: []vpcplan.VPC{
+			{Name: "vpc-1", VNI: 100000, Subnets: []vpcplan.Subnet{{Name: "subnet-1", CIDR: "10.0.0.0/24", VLAN: 1001, VNI: 100001}}},
+			{Name: "vpc-2", VNI: 100002},
+		},
+		Attachments: []vpcplan.Attachment{
+			{Name: "server1--vpc-2--subnet-1", Endpoint: "server1", Leaves: []string{"leaf1"}, Subnet: "vpc-2/subnet-1", VLAN: 1002},
+		},
+	}
+	d := Compare(Design{VPCs: old}, Design{VPCs: new})
+	want := []Change{
+		{Kind: Changed, Object: "subnet/vpc-1/subnet-1", Field: "vlan", Old: 1000, New: 1001},
+		{Kind: Added, Object: "vpc/vpc-2", New: 100002},
+		{Kind: Changed, Object: "attachment/server1", Field: "subnet", Old: "vpc-1/subnet-1", New: "vpc-2/subnet-1"},
+		{Kind: Changed, Object: "attachment/server1", Field: "vlan", Old: 1000, New: 1002},
+	}
+	if !reflect.DeepEqual(d.VPCs, want) {
+		t.Errorf("VPCs = %+v\nwant %+v", d.VPCs, want)
+	}
+}
+
+func TestCompareCost(t *testing.T) {
+	old := &pricing.Estimate{ByCategory: map[string]float64{bom.Switch: 40000, bom.Optic: 1520.1}, Total: 41520.1}
+	new := &pricing.Estimate{ByCategory: map[string]float64{bom.Switch: 60000, bom.Optic: 1520.1, bom.Cable: 100}, Total: 61620.1}
+	d := Compare(Design{Cost: old}, Design{Cost: new})
+	want := []Delta{
+		{Metric: bom.Switch, Old: 40000, New: 60000, Change: 20000},
+		{Metric: bom.Cable, Old: 0, New: 100, Change: 100},
+		{Metric: "total", Old: 41520.1, New: 61620.1, Change: 20100},
+	}
+	if !reflect.DeepEqual(d.Cost, want) {
+		t.Errorf("cost = %+v\nwant %+v", d.Cost, want)
+	}
+	if d := Compare(Design{Cost: old}, Design{}); d.Cost != nil {
+		t.Errorf("cost = %+v with one estimate", d.Cost)
+	}
+}
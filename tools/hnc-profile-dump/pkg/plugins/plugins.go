@@ -0,0 +1,370 @@
+// Package plugins runs the lint rules, BOM pricing and exporters teams
+// ship as separate executables, so hnc needs no fork to take them. A
+// plugin is any executable named hnc-plugin-<name> in a directory of
+// $HNC_PLUGIN_PATH, searched in order like $PATH, the first of a name
+// winning.
+//
+// The protocol is one JSON request on the plugin's stdin, one line,
+// answered by one JSON response on its stdout, per run. Every request
+// carries "protocol" (Protocol) and a "method"; every response carries
+// the protocol it speaks and, when the plugin failed, an "error". A
+// plugin exiting non-zero has failed, with its stderr as the reason.
+//
+// The handshake is the describe method, which hnc sends before anything
+// else and which must not do any work:
+//
+//	-> {"method":"describe","protocol":1}
+//	<- {"protocol":1,"version":"1.2.0","description":"Acme house rules",
+//	    "capabilities":[{"kind":"lint-rule","name":"hostname","description":"...","severity":"warning"}]}
+//
+// Each capability is then called with its kind's method and name:
+//
+//   - lint-rule: method lint, with the "profile" to check; the response's
+//     "messages" are its problems, none when it passes. The rule runs as
+//     <plugin>/<name> beside the built-in ones.
+//   - pricing: method price, with the "bom" hnc bom lists; the response's
+//     "estimate" is what it costs, in the form hnc bom -pricing writes.
+//   - exporter: method export, with the "plan" and its "cabling"; the
+//     response's "output" is the exported text.
+//
+// The allocation policies are called by hnc cabling once it has
+// allocated the map, each with the "plan" and the "cabling" so far, and
+// answer with their own part of it, which hnc checks before taking:
+//
+//   - placement-policy: method place; the response's "serverLinks" cable
+//     the same server NICs to leaf endpoint ports of the policy's choice.
+//   - naming-policy: method name; the response's "hostnames" name the
+//     switches at the site, keyed by the names hnc gave them.
+//   - addressing-policy: method address; the response's "addressing"
+//     renumbers the same switches and links.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/addressing"
+	"github.com/hnc/profile-dump/pkg/bom"
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/lint"
+	"github.com/hnc/profile-dump/pkg/pricing"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Protocol is the version of the protocol this hnc speaks
+const Protocol = 1
+
+// Prefix starts the file name of every plugin
+const Prefix = "hnc-plugin-"
+
+// PathEnv is the environment variable listing the plugin directories
+const PathEnv = "HNC_PLUGIN_PATH"
+
+// Kinds of capability, and the method that calls each
+const (
+	LintRule = "lint-rule"
+	Pricing  = "pricing"
+	Exporter = "exporter"
+	// Allocation policies, which revise a cabling map hnc allocated
+	Placement  = "placement-policy"
+	Naming     = "naming-policy"
+	Addressing = "addressing-policy"
+)
+
+// Kinds are every kind of capability
+var Kinds = []string{LintRule, Pricing, Exporter, Placement, Naming, Addressing}
+
+var methods = map[string]string{LintRule: "lint", Pricing: "price", Exporter: "export",
+	Placement: "place", Naming: "name", Addressing: "address"}
+
+// Capability is one thing a plugin does
+type Capability struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Severity    string `json:"severity,omitempty"` // of a lint rule: error or warning, the default
+}
+
+// Plugin is one plugin executable and what it described itself as
+type Plugin struct {
+	Name         string       `json:"name"` // the file name after Prefix
+	Path         string       `json:"path"`
+	Version      string       `json:"version,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Capabilities []Capability `json:"capabilities"`
+}
+
+// Request is what hnc sends a plugin; each method fills its own fields
+type Request struct {
+	Protocol   int                     `json:"protocol"`
+	Method     string                  `json:"method"`
+	Capability string                  `json:"capability,omitempty"`
+	Profile    *profiles.SwitchProfile `json:"profile,omitempty"`
+	BOM        *bom.BOM                `json:"bom,omitempty"`
+	Plan       *fabricplan.Plan        `json:"plan,omitempty"`
+	Cabling    *cabling.Map            `json:"cabling,omitempty"`
+}
+
+// Response is what a plugin answers; each method fills its own fields
+type Response struct {
+	Protocol     int                  `json:"protocol"`
+	Error        string               `json:"error,omitempty"`
+	Version      string               `json:"version,omitempty"`
+	Description  string               `json:"description,omitempty"`
+	Capabilities []Capability         `json:"capabilities,omitempty"`
+	Messages     []string             `json:"messages,omitempty"`
+	Estimate     *pricing.Estimate    `json:"estimate,omitempty"`
+	Output       *string              `json:"output,omitempty"`
+	ServerLinks  []cabling.ServerLink `json:"serverLinks,omitempty"`
+	Hostnames    map[string]string    `json:"hostnames,omitempty"`
+	Addressing   *addressing.Plan     `json:"addressing,omitempty"`
+}
+
+// Find lists the plugin executables in dirs, by name, without running
+// them; a directory that does not exist has none
+func Find(dirs []string) ([]Plugin, error) {
+	seen := map[string]bool{}
+	var found []Plugin
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), Prefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, ".exe")
+			}
+			if !ok || name == "" || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() || runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+				continue
+			}
+			seen[name] = true
+			found = append(found, Plugin{Name: name, Path: path})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found, nil
+}
+
+// Load finds the plugins in the directories of path, a list like $PATH,
+// and describes each. The plugins that fail the handshake are left out,
+// and the error names every one of them.
+func Load(ctx context.Context, path string) ([]Plugin, error) {
+	found, err := Find(filepath.SplitList(path))
+	if err != nil {
+		return nil, err
+	}
+	var loaded []Plugin
+	var errs []error
+	for _, p := range found {
+		if p, err = Describe(ctx, p); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaded = append(loaded, p)
+	}
+	return loaded, errors.Join(errs...)
+}
+
+// Describe runs the handshake, filling in what the plugin says it is
+func Describe(ctx context.Context, p Plugin) (Plugin, error) {
+	resp, err := p.Call(ctx, Request{Method: "describe"})
+	if err != nil {
+		return p, err
+	}
+	p.Version, p.Description, p.Capabilities = resp.Version, resp.Description, resp.Capabilities
+	for _, c := range p.Capabilities {
+		if _, ok := methods[c.Kind]; !ok || c.Name == "" {
+			return p, fmt.Errorf("plugin %s: capability %q of unknown kind %q (want %s)", p.Name, c.Name, c.Kind, strings.Join(Kinds, ", "))
+		}
+		if c.Kind == LintRule && c.Severity != "" && c.Severity != lint.Error && c.Severity != lint.Warning {
+			return p, fmt.Errorf("plugin %s: lint rule %s has severity %q (want %s or %s)", p.Name, c.Name, c.Severity, lint.Error, lint.Warning)
+		}
+	}
+	return p, nil
+}
+
+// Call runs the plugin on one request and returns its response
+func (p Plugin) Call(ctx context.Context, req Request) (Response, error) {
+	req.Protocol = Protocol
+	in, err := canonjson.Compact(req)
+	if err != nil {
+		return Response{}, err
+	}
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(in)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return Response{}, fmt.Errorf("plugin %s %s: %s", p.Name, req.Method, msg)
+		}
+		return Response{}, fmt.Errorf("plugin %s %s: %w", p.Name, req.Method, err)
+	}
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return Response{}, fmt.Errorf("plugin %s %s: bad response: %w", p.Name, req.Method, err)
+	}
+	switch {
+	case resp.Protocol != Protocol:
+		return resp, fmt.Errorf("plugin %s speaks protocol %d, hnc speaks %d", p.Name, resp.Protocol, Protocol)
+	case resp.Error != "":
+		return resp, fmt.Errorf("plugin %s %s: %s", p.Name, req.Method, resp.Error)
+	}
+	return resp, nil
+}
+
+// Capability returns the plugin's capability of kind named name
+func (p Plugin) Capability(kind, name string) (Capability, bool) {
+	for _, c := range p.Capabilities {
+		if c.Kind == kind && c.Name == name {
+			return c, true
+		}
+	}
+	return Capability{}, false
+}
+
+// Resolve finds the capability of kind that ref names, as PLUGIN/NAME, or
+// as PLUGIN when that plugin has only the one of the kind
+func Resolve(ps []Plugin, kind, ref string) (Plugin, Capability, error) {
+	name, capability, _ := strings.Cut(ref, "/")
+	for _, p := range ps {
+		if p.Name != name {
+			continue
+		}
+		var of []Capability
+		for _, c := range p.Capabilities {
+			if c.Kind == kind && (capability == "" || c.Name == capability) {
+				of = append(of, c)
+			}
+		}
+		switch {
+		case len(of) == 1:
+			return p, of[0], nil
+		case len(of) > 1:
+			return p, Capability{}, fmt.Errorf("plugin %s has %d %s capabilities; name one as %s/NAME", name, len(of), kind, name)
+		}
+		return p, Capability{}, fmt.Errorf("plugin %s has no %s %q", name, kind, capability)
+	}
+	return Plugin{}, Capability{}, fmt.Errorf("no plugin %q in $%s", name, PathEnv)
+}
+
+// LintRules are the plugins' lint rules, each named <plugin>/<name>. A
+// rule whose plugin fails reports the failure as the profile's problem.
+func LintRules(ps []Plugin) []lint.Rule {
+	var rules []lint.Rule
+	for _, p := range ps {
+		for _, c := range p.Capabilities {
+			if c.Kind != LintRule {
+				continue
+			}
+			p, c := p, c
+			severity := c.Severity
+			if severity == "" {
+				severity = lint.Warning
+			}
+			rules = append(rules, lint.Rule{Name: p.Name + "/" + c.Name, Description: c.Description, Severity: severity,
+				Check: func(profile profiles.SwitchProfile) []string {
+					resp, err := p.call(context.Background(), c, Request{Profile: &profile})
+					if err != nil {
+						return []string{err.Error()}
+					}
+					return resp.Messages
+				}})
+		}
+	}
+	return rules
+}
+
+// Price has a pricing capability estimate what b costs
+func (p Plugin) Price(ctx context.Context, c Capability, b bom.BOM) (pricing.Estimate, error) {
+	resp, err := p.call(ctx, c, Request{BOM: &b})
+	if err != nil {
+		return pricing.Estimate{}, err
+	}
+	if resp.Estimate == nil {
+		return pricing.Estimate{}, fmt.Errorf("plugin %s price: no estimate", p.Name)
+	}
+	return *resp.Estimate, nil
+}
+
+// Export has an exporter capability render a plan and its cabling
+func (p Plugin) Export(ctx context.Context, c Capability, plan fabricplan.Plan, m cabling.Map) (string, error) {
+	resp, err := p.call(ctx, c, Request{Plan: &plan, Cabling: &m})
+	if err != nil {
+		return "", err
+	}
+	if resp.Output == nil {
+		return "", fmt.Errorf("plugin %s export: no output", p.Name)
+	}
+	return *resp.Output, nil
+}
+
+// Place has a placement policy cable the map's server NICs, and takes
+// the links if cabling.Map.Place passes them
+func (p Plugin) Place(ctx context.Context, c Capability, plan fabricplan.Plan, leaf profiles.SwitchProfile, m *cabling.Map) error {
+	resp, err := p.call(ctx, c, Request{Plan: &plan, Cabling: m})
+	if err != nil {
+		return err
+	}
+	if err := m.Place(resp.ServerLinks, plan, leaf); err != nil {
+		return fmt.Errorf("plugin %s place: %w", p.Name, err)
+	}
+	return nil
+}
+
+// Hostnames has a naming policy name the map's switches at the site, and
+// takes the hostnames if cabling.Map.Name passes them
+func (p Plugin) Hostnames(ctx context.Context, c Capability, plan fabricplan.Plan, m *cabling.Map) error {
+	resp, err := p.call(ctx, c, Request{Plan: &plan, Cabling: m})
+	if err != nil {
+		return err
+	}
+	if len(resp.Hostnames) == 0 {
+		return fmt.Errorf("plugin %s name: no hostnames", p.Name)
+	}
+	if err := m.Name(resp.Hostnames, plan); err != nil {
+		return fmt.Errorf("plugin %s name: %w", p.Name, err)
+	}
+	return nil
+}
+
+// Address has an addressing policy renumber the map, and takes the
+// numbering if cabling.Map.Renumber passes it
+func (p Plugin) Address(ctx context.Context, c Capability, plan fabricplan.Plan, m *cabling.Map) error {
+	resp, err := p.call(ctx, c, Request{Plan: &plan, Cabling: m})
+	if err != nil {
+		return err
+	}
+	if resp.Addressing == nil {
+		return fmt.Errorf("plugin %s address: no addressing", p.Name)
+	}
+	if err := m.Renumber(*resp.Addressing); err != nil {
+		return fmt.Errorf("plugin %s address: %w", p.Name, err)
+	}
+	return nil
+}
+
+// call sends req to capability c by its kind's method
+func (p Plugin) call(ctx context.Context, c Capability, req Request) (Response, error) {
+	req.Method, req.Capability = methods[c.Kind], c.Name
+	return p.Call(ctx, req)
+}
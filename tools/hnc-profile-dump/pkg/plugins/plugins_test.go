@@ -0,0 +1,116 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/bom"
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/lint"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// acme answers every method, passing only acme- models
+const acme = `#!/bin/sh
+req=$(cat)
+case "$req" in
+*'"method":"describe"'*)
+	echo '{"protocol":1,"version":"1.0.0","description":"Acme house rules","capabilities":[
+		{"kind":"lint-rule","name":"vendor","description":"models are Acme models","severity":"error"},
+		{"kind":"pricing","name":"list"},
+		{"kind":"exporter","name":"cmdb"},
+		{"kind":"exporter","name":"dcim"}]}' ;;
+*'"method":"lint"'*'"modelId":"acme-'*) echo '{"protocol":1}' ;;
+*'"method":"lint"'*) echo '{"protocol":1,"messages":["not an Acme model"]}' ;;
+*'"method":"price"'*) echo '{"protocol":1,"estimate":{"currency":"USD","lines":[],"byCategory":{},"total":42}}' ;;
+*'"capability":"cmdb"'*'"method":"export"'*) printf '%s\n' '{"protocol":1,"output":"leaves: 2\n"}' ;;
+*) echo '{"protocol":1,"error":"no such export"}' ;;
+esac
+`
+
+func writePlugin(t *testing.T, dir, name, script string, mode os.FileMode) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins in these tests are shell scripts")
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writePlugin(t, first, Prefix+"acme", acme, 0755)
+	writePlugin(t, first, Prefix+"notes", "not executable", 0644)
+	writePlugin(t, first, "acme", acme, 0755)
+	writePlugin(t, second, Prefix+"acme", "#!/bin/sh\nexit 1\n", 0755)
+	writePlugin(t, second, Prefix+"old", "#!/bin/sh\necho '{\"protocol\":0}'\n", 0755)
+	writePlugin(t, second, Prefix+"broken", "#!/bin/sh\necho 'no handshake' >&2\nexit 2\n", 0755)
+
+	ps, err := Load(context.Background(), strings.Join([]string{first, filepath.Join(first, "none"), second}, string(os.PathListSeparator)))
+	if err == nil || !strings.Contains(err.Error(), "plugin broken describe: no handshake") ||
+		!strings.Contains(err.Error(), "plugin old speaks protocol 0, hnc speaks 1") {
+		t.Errorf("Load() error = %v", err)
+	}
+	if len(ps) != 1 {
+		t.Fatalf("Load() = %+v, want the first acme alone", ps)
+	}
+	if p := ps[0]; p.Name != "acme" || p.Path != filepath.Join(first, Prefix+"acme") || p.Version != "1.0.0" || len(p.Capabilities) != 4 {
+		t.Errorf("acme = %+v", p)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, Prefix+"acme", acme, 0755)
+	ps, err := Load(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	rules := LintRules(ps)
+	if len(rules) != 1 || rules[0].Name != "acme/vendor" || rules[0].Severity != lint.Error {
+		t.Fatalf("LintRules() = %+v", rules)
+	}
+	own := profiles.DS2000()
+	own.ModelID = "acme-x1"
+	got := lint.Run([]profiles.SwitchProfile{profiles.DS2000(), own}, rules)
+	if want := []lint.Finding{{Rule: "acme/vendor", ModelID: "celestica-ds2000", Severity: lint.Error, Message: "not an Acme model"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lint = %+v, want %+v", got, want)
+	}
+
+	p, c, err := Resolve(ps, Pricing, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := p.Price(ctx, c, bom.BOM{}); err != nil || e.Total != 42 || e.Format(e.Total) != "USD 42.00" {
+		t.Errorf("Price() = %+v, %v", e, err)
+	}
+
+	if _, _, err := Resolve(ps, Exporter, "acme"); err == nil || !strings.Contains(err.Error(), "has 2 exporter capabilities; name one as acme/NAME") {
+		t.Errorf("Resolve(acme) = %v", err)
+	}
+	p, c, err = Resolve(ps, Exporter, "acme/cmdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := p.Export(ctx, c, fabricplan.Plan{Leaves: 2}, cabling.Map{}); err != nil || out != "leaves: 2\n" {
+		t.Errorf("Export(cmdb) = %q, %v", out, err)
+	}
+	_, c, _ = Resolve(ps, Exporter, "acme/dcim")
+	if _, err := p.Export(ctx, c, fabricplan.Plan{}, cabling.Map{}); err == nil || err.Error() != "plugin acme export: no such export" {
+		t.Errorf("Export(dcim) = %v", err)
+	}
+	for ref, want := range map[string]string{"acme/cmdb2": `no exporter "cmdb2"`, "other": `no plugin "other" in $HNC_PLUGIN_PATH`} {
+		if _, _, err := Resolve(ps, Exporter, ref); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Resolve(%s) = %v, want %q", ref, err, want)
+		}
+	}
+}
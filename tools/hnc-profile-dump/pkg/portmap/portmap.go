@@ -0,0 +1,211 @@
+// Package portmap renders per-switch faceplate SVGs showing how each port
+// is allocated (endpoint, uplink, reserved, free, breakout children), and
+// the matching commissioning sheets.
+package portmap
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// State is the allocation state of a port
+type State string
+
+const (
+	StateEndpoint State = "endpoint"
+	StateUplink   State = "uplink"
+	StateReserved State = "reserved"
+	StateFree     State = "free"
+)
+
+var stateColors = map[State]string{
+	StateEndpoint: "#2f80ed",
+	StateUplink:   "#27ae60",
+	StateReserved: "#f2994a",
+	StateFree:     "#e0e0e0",
+}
+
+// Usage is how a single port (or breakout child) is used
+type Usage struct {
+	State State
+	Peer  string // connected device:port, if any
+}
+
+// Port is one faceplate port; Children is set for broken-out ports
+type Port struct {
+	Name     string
+	State    State
+	Peer     string
+	Children []Port
+}
+
+// Faceplate is the rendered view of one switch
+type Faceplate struct {
+	Switch string
+	Model  string
+	Ports  []Port
+}
+
+// Build assembles a faceplate from the switch's physical port names and the
+// usage of each port. Usage keys that are not physical ports but extend one
+// with a trailing "/N" (E1/1/2 under E1/1) are treated as breakout children.
+// Reserved ports are only marked when they have no other usage.
+func Build(switchName, model string, portNames []string, usage map[string]Usage, reserved []string) Faceplate {
+	physical := make(map[string]bool, len(portNames))
+	for _, name := range portNames {
+		physical[name] = true
+	}
+	isReserved := make(map[string]bool, len(reserved))
+	for _, name := range reserved {
+		isReserved[name] = true
+	}
+
+	children := map[string][]Port{}
+	for name, u := range usage {
+		if physical[name] {
+			continue
+		}
+		if i := strings.LastIndex(name, "/"); i > 0 && physical[name[:i]] {
+			parent := name[:i]
+			children[parent] = append(children[parent], Port{Name: name, State: u.State, Peer: u.Peer})
+		}
+	}
+
+	fp := Faceplate{Switch: switchName, Model: model}
+	for _, name := range portNames {
+		port := Port{Name: name, State: StateFree}
+		if u, ok := usage[name]; ok {
+			port.State, port.Peer = u.State, u.Peer
+		} else if isReserved[name] {
+			port.State = StateReserved
+		}
+		if kids := children[name]; len(kids) > 0 {
+			sort.Slice(kids, func(a, b int) bool { return lastIndex(kids[a].Name) < lastIndex(kids[b].Name) })
+			port.Children = kids
+		}
+		fp.Ports = append(fp.Ports, port)
+	}
+	return fp
+}
+
+const (
+	cell    = 28
+	gap     = 4
+	margin  = 16
+	header  = 28
+	legendH = 28
+)
+
+// RenderSVG draws the faceplate with ports in two rows (odd ports on top,
+// as on most front panels) followed by a legend.
+func RenderSVG(fp Faceplate) string {
+	columns := (len(fp.Ports) + 1) / 2
+	width := margin*2 + columns*(cell+gap)
+	if width < 360 {
+		width = 360
+	}
+	height := margin*2 + header + 2*(cell+gap) + legendH
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&b, `<rect x="1" y="1" width="%d" height="%d" rx="6" fill="#333" stroke="#111"/>`+"\n", width-2, height-2)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#fff" font-size="14">%s (%s)</text>`+"\n",
+		margin, margin+14, esc(fp.Switch), esc(fp.Model))
+
+	for i, port := range fp.Ports {
+		x := margin + (i/2)*(cell+gap)
+		y := margin + header + (i%2)*(cell+gap)
+		writePort(&b, port, x, y)
+	}
+
+	legendY := height - margin - 12
+	x := margin
+	for _, state := range []State{StateEndpoint, StateUplink, StateReserved, StateFree} {
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/>`, x, legendY, stateColors[state])
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#fff" font-size="11">%s</text>`+"\n", x+16, legendY+10, state)
+		x += 80
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// SheetHeader is the column row of a commissioning sheet; verified is left
+// blank for the tech to tick during bring-up
+var SheetHeader = []string{"switch", "model", "port", "block", "row", "column", "state", "peer", "verified"}
+
+// RenderSheet lists every port as CSV in faceplate order, column by column
+// from the left, with breakout children under their cage. Ports the
+// layout does not place follow with blank positions.
+func RenderSheet(fp Faceplate, positions map[string]profiles.Position) string {
+	ordered := append([]Port{}, fp.Ports...)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		pa, okA := positions[ordered[a].Name]
+		pb, okB := positions[ordered[b].Name]
+		if okA != okB {
+			return okA
+		}
+		if pa.Column != pb.Column {
+			return pa.Column < pb.Column
+		}
+		return pa.Row < pb.Row
+	})
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(SheetHeader)
+	for _, port := range ordered {
+		var block, row, column string
+		if pos, ok := positions[port.Name]; ok {
+			block, row, column = strconv.Itoa(pos.Block), strconv.Itoa(pos.Row), strconv.Itoa(pos.Column)
+		}
+		lanes := port.Children
+		if len(lanes) == 0 {
+			lanes = []Port{port}
+		}
+		for _, p := range lanes {
+			w.Write([]string{fp.Switch, fp.Model, p.Name, block, row, column, string(p.State), p.Peer, ""})
+		}
+	}
+	w.Flush()
+	return b.String()
+}
+
+func writePort(b *strings.Builder, port Port, x, y int) {
+	fmt.Fprintf(b, `<g class="port %s">`, port.State)
+	if len(port.Children) == 0 {
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="#111"><title>%s</title></rect>`,
+			x, y, cell, cell, stateColors[port.State], esc(tooltip(port)))
+	} else {
+		// Breakout: split the cage into one slice per child port
+		slice := float64(cell) / float64(len(port.Children))
+		for i, child := range port.Children {
+			fmt.Fprintf(b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" stroke="#111"><title>%s</title></rect>`,
+				float64(x)+float64(i)*slice, y, slice, cell, stateColors[child.State], esc(tooltip(child)))
+		}
+	}
+	fmt.Fprintf(b, `<text x="%d" y="%d" fill="#111" font-size="9" text-anchor="middle">%d</text></g>`+"\n",
+		x+cell/2, y+cell/2+3, lastIndex(port.Name))
+}
+
+func tooltip(p Port) string {
+	if p.Peer == "" {
+		return fmt.Sprintf("%s: %s", p.Name, p.State)
+	}
+	return fmt.Sprintf("%s: %s -> %s", p.Name, p.State, p.Peer)
+}
+
+func lastIndex(name string) int {
+	n, _ := strconv.Atoi(name[strings.LastIndex(name, "/")+1:])
+	return n
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
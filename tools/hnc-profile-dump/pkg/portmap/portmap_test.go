@@ -0,0 +1,72 @@
+package portmap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func TestBuildAssignsStates(t *testing.T) {
+	fp := Build("leaf-1", "DS2000", []string{"E1/1", "E1/2", "E1/3", "E1/4"}, map[string]Usage{
+		"E1/1":   {State: StateEndpoint, Peer: "srv-1:eth0"},
+		"E1/2/2": {State: StateEndpoint, Peer: "srv-3:eth0"},
+		"E1/2/1": {State: StateEndpoint, Peer: "srv-2:eth0"},
+		"E1/4":   {State: StateUplink, Peer: "spine-1:E1/1"},
+	}, []string{"E1/3", "E1/4"})
+
+	got := []State{}
+	for _, p := range fp.Ports {
+		got = append(got, p.State)
+	}
+	want := []State{StateEndpoint, StateFree, StateReserved, StateUplink}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("states = %v, want %v", got, want)
+		}
+	}
+	if kids := fp.Ports[1].Children; len(kids) != 2 || kids[0].Name != "E1/2/1" {
+		t.Fatalf("breakout children = %+v, want E1/2/1 then E1/2/2", kids)
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	svg := RenderSVG(Build("leaf-<1>", "DS2000", []string{"E1/1", "E1/2"}, map[string]Usage{
+		"E1/1/1": {State: StateEndpoint},
+		"E1/1/2": {State: StateEndpoint},
+	}, nil))
+
+	if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Fatalf("not an SVG document:\n%s", svg)
+	}
+	if !strings.Contains(svg, "leaf-&lt;1&gt;") {
+		t.Errorf("switch name not escaped:\n%s", svg)
+	}
+	if n := strings.Count(svg, `<title>E1/1/`); n != 2 {
+		t.Errorf("breakout slices = %d, want 2", n)
+	}
+}
+
+func TestRenderSheet(t *testing.T) {
+	fp := Build("leaf-1", "DS2000", []string{"E1/1", "E1/2", "E1/3", "E1/4", "E1/5"}, map[string]Usage{
+		"E1/2":   {State: StateUplink, Peer: "spine-1:E1/1"},
+		"E1/3/1": {State: StateEndpoint, Peer: "srv-1:eth0"},
+	}, nil)
+	positions := map[string]profiles.Position{
+		"E1/1": {Block: 1, Row: 1, Column: 1},
+		"E1/2": {Block: 1, Row: 2, Column: 1},
+		"E1/3": {Block: 2, Row: 2, Column: 2},
+		"E1/4": {Block: 2, Row: 1, Column: 2},
+	}
+
+	want := `switch,model,port,block,row,column,state,peer,verified
+leaf-1,DS2000,E1/1,1,1,1,free,,
+leaf-1,DS2000,E1/2,1,2,1,uplink,spine-1:E1/1,
+leaf-1,DS2000,E1/4,2,1,2,free,,
+leaf-1,DS2000,E1/3/1,2,2,2,endpoint,srv-1:eth0,
+leaf-1,DS2000,E1/5,,,,free,,
+`
+	if got := RenderSheet(fp, positions); got != want {
+		t.Errorf("RenderSheet() =\n%s\nwant\n%s", got, want)
+	}
+}
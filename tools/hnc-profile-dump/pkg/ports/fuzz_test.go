@@ -0,0 +1,89 @@
+package ports
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzExpand(f *testing.F) {
+	for _, s := range []string{"E1/1-48", "E1/49-56\nE1/55", "E1/1-48,E1/55", "E1/5-1", "E1/1-99999999999999999999", "Ethernet1/1/1-4", "-"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		exprs := strings.Split(input, "\n")
+		names, err := Expand(exprs)
+		if err != nil {
+			return
+		}
+		// Accepted input expands within bounds, and Count agrees
+		n := 0
+		for _, expr := range exprs {
+			c, err := Count(expr)
+			if err != nil {
+				t.Fatalf("Count(%q) failed after Expand succeeded: %v", expr, err)
+			}
+			n += c
+		}
+		if n != len(names) {
+			t.Fatalf("Count = %d, Expand produced %d names", n, len(names))
+		}
+		if n > (strings.Count(input, ",")+len(exprs))*MaxRangePorts {
+			t.Fatalf("%q expanded to %d ports", input, n)
+		}
+	})
+}
+
+func FuzzExpandRange(f *testing.F) {
+	for _, s := range []string{"E1/1-4", "E1/1-4,E1/7", " E1/2 , E1/3-3", "Ethernet1/1/1-4", "swp0-2", "E1/01", "E1/1-4,", "E1/1-0x4", "E1/é1"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, expr string) {
+		names, err := ExpandRange(expr)
+		if err != nil {
+			if _, countErr := Count(expr); countErr == nil {
+				t.Fatalf("Count(%q) succeeded where ExpandRange failed: %v", expr, err)
+			}
+			return
+		}
+		// Every name is itself a single port that expands to exactly itself
+		for _, name := range names {
+			again, err := ExpandRange(name)
+			if err != nil || len(again) != 1 || again[0] != name {
+				t.Fatalf("%q expanded to %q, which expands to %q, %v", expr, name, again, err)
+			}
+		}
+	})
+}
+
+func FuzzOverlap(f *testing.F) {
+	f.Add("E1/1-48", "E1/49-56")
+	f.Add("E1/1-10,E1/20", "E1/5-25")
+	f.Add("Eth1-3", "E1/1-3")
+	f.Fuzz(func(t *testing.T, a, b string) {
+		left, right := strings.Split(a, "\n"), strings.Split(b, "\n")
+		ab, err := Overlap(left, right)
+		if err != nil {
+			return
+		}
+		ba, _ := Overlap(right, left)
+		if strings.Join(ab, ",") != strings.Join(ba, ",") {
+			t.Fatalf("Overlap is not symmetric: %q vs %q", ab, ba)
+		}
+		inLeft, _ := Expand(left)
+		inRight, _ := Expand(right)
+		for _, name := range ab {
+			if !containsName(inLeft, name) || !containsName(inRight, name) {
+				t.Fatalf("%q is in the overlap but not in both lists", name)
+			}
+		}
+	})
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
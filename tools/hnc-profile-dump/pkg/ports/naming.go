@@ -0,0 +1,130 @@
+package ports
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Port is a front-panel port by number: the module and port of E1/49, 1
+// and 49, and for a breakout lane its Lane from 1; Lane 0 is the whole
+// port
+type Port struct {
+	Module int
+	Number int
+	Lane   int
+}
+
+// Scheme is how a network OS spells port names. Profiles, wiring and
+// cabling maps always use the sonic spelling; a profile's namingScheme
+// says how its switch's own OS spells them, so names read from that OS
+// can be mapped back.
+type Scheme struct {
+	Name    string
+	Summary string
+	format  func(Port) string
+	re      *regexp.Regexp // module, number and lane; module may be absent
+	lane0   bool           // lanes are numbered from 0, as swp1s0
+}
+
+// Naming schemes, by Scheme.Name
+const (
+	SONiC   = "sonic"
+	Eth     = "eth"
+	OS10    = "os10"
+	Cumulus = "cumulus"
+)
+
+// Schemes lists the naming schemes, the default first
+var Schemes = []Scheme{
+	{Name: SONiC, Summary: "E1/1, lanes E1/1/1: Hedgehog SONiC, and the names HNC writes",
+		format: func(p Port) string { return withLane(fmt.Sprintf("E%d/%d", p.Module, p.Number), "/", p.Lane) },
+		re:     regexp.MustCompile(`^E(\d+)/(\d+)(?:/(\d+))?$`)},
+	{Name: Eth, Summary: "Eth1/1, lanes Eth1/1/1: Cisco NX-OS and Arista EOS short names",
+		format: func(p Port) string { return withLane(fmt.Sprintf("Eth%d/%d", p.Module, p.Number), "/", p.Lane) },
+		re:     regexp.MustCompile(`^(?i:eth)(\d+)/(\d+)(?:/(\d+))?$`)},
+	{Name: OS10, Summary: "ethernet1/1/1, lanes ethernet1/1/1:1: Dell OS10",
+		format: func(p Port) string { return withLane(fmt.Sprintf("ethernet%d/1/%d", p.Module, p.Number), ":", p.Lane) },
+		re:     regexp.MustCompile(`^(?i:ethernet)(\d+)/1/(\d+)(?::(\d+))?$`)},
+	{Name: Cumulus, Summary: "swp1, lanes swp1s0: NVIDIA Cumulus Linux, module 1 only",
+		format: func(p Port) string {
+			if p.Lane > 0 {
+				return fmt.Sprintf("swp%ds%d", p.Number, p.Lane-1)
+			}
+			return fmt.Sprintf("swp%d", p.Number)
+		},
+		re: regexp.MustCompile(`^swp()(\d+)(?:s(\d+))?$`), lane0: true},
+}
+
+// SchemeNames lists the scheme names
+func SchemeNames() []string {
+	names := make([]string, len(Schemes))
+	for i, s := range Schemes {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// LookupScheme finds a naming scheme by name; "" is sonic
+func LookupScheme(name string) (Scheme, error) {
+	if name == "" {
+		return Schemes[0], nil
+	}
+	for _, s := range Schemes {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Scheme{}, fmt.Errorf("unknown port naming scheme %q (want %s)", name, strings.Join(SchemeNames(), ", "))
+}
+
+// Format spells a port in the scheme
+func (s Scheme) Format(p Port) string {
+	return s.format(p)
+}
+
+// Parse reads a port name spelled in the scheme
+func (s Scheme) Parse(name string) (Port, error) {
+	m := s.re.FindStringSubmatch(name)
+	if m == nil {
+		return Port{}, fmt.Errorf("port %q is not a %s port name, e.g. %s", name, s.Name, s.Format(Port{Module: 1, Number: 1}))
+	}
+	p := Port{Module: 1}
+	var err error
+	if m[1] != "" {
+		p.Module, err = strconv.Atoi(m[1])
+	}
+	if err == nil {
+		p.Number, err = strconv.Atoi(m[2])
+	}
+	if err == nil && m[3] != "" {
+		p.Lane, err = strconv.Atoi(m[3])
+		if s.lane0 {
+			p.Lane++
+		}
+	}
+	if err != nil || p.Module < 1 || p.Number < 1 || (m[3] != "" && p.Lane < 1) {
+		return Port{}, fmt.Errorf("port %q is not a %s port name, e.g. %s", name, s.Name, s.Format(Port{Module: 1, Number: 1}))
+	}
+	return p, nil
+}
+
+// Rename respells a port name from one scheme in another
+func Rename(name string, from, to Scheme) (string, error) {
+	p, err := from.Parse(name)
+	if err != nil {
+		return "", err
+	}
+	if to.Name == Cumulus && p.Module != 1 {
+		return "", fmt.Errorf("port %s is on module %d, and %s names only module 1 ports", name, p.Module, to.Name)
+	}
+	return to.Format(p), nil
+}
+
+func withLane(name, sep string, lane int) string {
+	if lane > 0 {
+		return name + sep + strconv.Itoa(lane)
+	}
+	return name
+}
@@ -0,0 +1,69 @@
+package ports
+
+import "testing"
+
+func TestSchemes(t *testing.T) {
+	for _, tc := range []struct {
+		scheme string
+		port   Port
+		name   string
+	}{
+		{SONiC, Port{1, 49, 0}, "E1/49"},
+		{SONiC, Port{1, 1, 2}, "E1/1/2"},
+		{Eth, Port{1, 49, 0}, "Eth1/49"},
+		{Eth, Port{2, 3, 4}, "Eth2/3/4"},
+		{OS10, Port{1, 49, 0}, "ethernet1/1/49"},
+		{OS10, Port{1, 1, 2}, "ethernet1/1/1:2"},
+		{Cumulus, Port{1, 49, 0}, "swp49"},
+		{Cumulus, Port{1, 1, 1}, "swp1s0"},
+	} {
+		s, err := LookupScheme(tc.scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Format(tc.port); got != tc.name {
+			t.Errorf("%s Format(%+v) = %s, want %s", tc.scheme, tc.port, got, tc.name)
+		}
+		if got, err := s.Parse(tc.name); err != nil || got != tc.port {
+			t.Errorf("%s Parse(%s) = %+v, %v, want %+v", tc.scheme, tc.name, got, err, tc.port)
+		}
+	}
+	sonic, _ := LookupScheme("")
+	if sonic.Name != SONiC {
+		t.Errorf("default scheme = %s, want %s", sonic.Name, SONiC)
+	}
+	if _, err := LookupScheme("junos"); err == nil {
+		t.Error("LookupScheme(junos) succeeded")
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for scheme, names := range map[string][]string{
+		SONiC:   {"", "E1", "E0/1", "E1/0", "Eth1/1", "E1/1-48"},
+		Eth:     {"E1/1", "Eth1", "ethernet1/1/1"},
+		OS10:    {"ethernet1/2/1", "ethernet1/1/1/1", "swp1"},
+		Cumulus: {"swp0", "swp1s", "Eth1/1"},
+	} {
+		s, _ := LookupScheme(scheme)
+		for _, name := range names {
+			if p, err := s.Parse(name); err == nil {
+				t.Errorf("%s Parse(%q) = %+v, want error", scheme, name, p)
+			}
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	sonic, _ := LookupScheme(SONiC)
+	cumulus, _ := LookupScheme(Cumulus)
+	os10, _ := LookupScheme(OS10)
+	if got, err := Rename("swp49s3", cumulus, sonic); err != nil || got != "E1/49/4" {
+		t.Errorf("Rename(swp49s3) = %s, %v, want E1/49/4", got, err)
+	}
+	if got, err := Rename("E1/49", sonic, os10); err != nil || got != "ethernet1/1/49" {
+		t.Errorf("Rename(E1/49) = %s, %v, want ethernet1/1/49", got, err)
+	}
+	if _, err := Rename("E2/1", sonic, cumulus); err == nil {
+		t.Error("Rename of a module 2 port to cumulus succeeded")
+	}
+}
@@ -0,0 +1,128 @@
+// Package ports expands and validates the port range expressions switch
+// profiles use, such as "E1/1-48", "E1/55" or "E1/1-48,E1/55", and
+// respells port names between the naming schemes of network OSes.
+package ports
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxRangePorts bounds a single range so a malformed profile cannot make
+// expansion allocate without limit
+const MaxRangePorts = 4096
+
+// rangeRe splits a range into the name prefix, the first port number and
+// the optional last one: E1/1-48 is "E1/", 1, 48; Ethernet1/1/1-4 is
+// "Ethernet1/1/", 1, 4
+var rangeRe = regexp.MustCompile(`^([A-Za-z]+(?:\d+/)*)(\d+)(?:-(\d+))?$`)
+
+// ExpandRange turns one expression, possibly several comma-separated
+// ranges, into individual port names in order
+func ExpandRange(expr string) ([]string, error) {
+	var names []string
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		prefix, start, end, err := parse(part)
+		if err != nil {
+			return nil, err
+		}
+		if start == end && !strings.Contains(part, "-") {
+			names = append(names, part) // a single port keeps its spelling
+			continue
+		}
+		for i := start; i <= end; i++ {
+			names = append(names, prefix+strconv.Itoa(i))
+		}
+	}
+	return names, nil
+}
+
+// Expand expands every expression in a profile port list
+func Expand(exprs []string) ([]string, error) {
+	var names []string
+	for _, expr := range exprs {
+		expanded, err := ExpandRange(expr)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, expanded...)
+	}
+	return names, nil
+}
+
+// Count is the number of ports an expression covers, without expanding it
+func Count(expr string) (int, error) {
+	n := 0
+	for _, part := range strings.Split(expr, ",") {
+		_, start, end, err := parse(strings.TrimSpace(part))
+		if err != nil {
+			return 0, err
+		}
+		n += end - start + 1
+	}
+	return n, nil
+}
+
+// Overlap returns the ports, sorted by name, that both port lists cover,
+// e.g. a profile's endpointAssignable and fabricAssignable
+func Overlap(a, b []string) ([]string, error) {
+	left, err := Expand(a)
+	if err != nil {
+		return nil, err
+	}
+	right, err := Expand(b)
+	if err != nil {
+		return nil, err
+	}
+	inLeft := make(map[string]bool, len(left))
+	for _, name := range left {
+		inLeft[name] = true
+	}
+	var common []string
+	seen := map[string]bool{}
+	for _, name := range right {
+		if inLeft[name] && !seen[name] {
+			seen[name] = true
+			common = append(common, name)
+		}
+	}
+	sort.Slice(common, func(i, j int) bool { return less(common[i], common[j]) })
+	return common, nil
+}
+
+// less orders port names by prefix, then numerically: E1/2 before E1/10
+func less(a, b string) bool {
+	ma, mb := rangeRe.FindStringSubmatch(a), rangeRe.FindStringSubmatch(b)
+	if ma == nil || mb == nil || ma[3] != "" || mb[3] != "" || ma[1] != mb[1] {
+		return a < b
+	}
+	na, _ := strconv.Atoi(ma[2])
+	nb, _ := strconv.Atoi(mb[2])
+	if na != nb {
+		return na < nb
+	}
+	return a < b
+}
+
+func parse(r string) (prefix string, start, end int, err error) {
+	m := rangeRe.FindStringSubmatch(r)
+	if m == nil {
+		return "", 0, 0, fmt.Errorf("invalid port range %q", r)
+	}
+	start, errStart := strconv.Atoi(m[2])
+	end, errEnd := start, error(nil)
+	if m[3] != "" {
+		end, errEnd = strconv.Atoi(m[3])
+	}
+	if errStart != nil || errEnd != nil || start > end {
+		return "", 0, 0, fmt.Errorf("invalid port range %q", r)
+	}
+	if end-start >= MaxRangePorts {
+		return "", 0, 0, fmt.Errorf("port range %q has more than %d ports", r, MaxRangePorts)
+	}
+	return m[1], start, end, nil
+}
@@ -0,0 +1,56 @@
+package ports
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandRange(t *testing.T) {
+	cases := map[string][]string{
+		"E1/1-3":          {"E1/1", "E1/2", "E1/3"},
+		"E1/55":           {"E1/55"},
+		"E1/1-2, E1/55":   {"E1/1", "E1/2", "E1/55"},
+		"Ethernet1/1/1-2": {"Ethernet1/1/1", "Ethernet1/1/2"},
+		"E1/7-7":          {"E1/7"},
+	}
+	for expr, want := range cases {
+		got, err := ExpandRange(expr)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ExpandRange(%q) = %v, %v; want %v", expr, got, err, want)
+		}
+	}
+}
+
+func TestExpandRangeRejectsMalformed(t *testing.T) {
+	for _, expr := range []string{"", "E1/", "1-48", "E1/5-1", "E1/1-48,", "E1/1-2-3", "eth 0", "E1/1-99999999999999999999", "E1/1-5000"} {
+		if got, err := ExpandRange(expr); err == nil {
+			t.Errorf("ExpandRange(%q) = %v, want error", expr, got)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	if n, err := Count("E1/1-48,E1/55"); n != 49 || err != nil {
+		t.Fatalf("Count = %d, %v; want 49", n, err)
+	}
+	if _, err := Count("E1/9-1"); err == nil || !strings.Contains(err.Error(), `"E1/9-1"`) {
+		t.Fatalf("Count error = %v, want it to name the range", err)
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	both, err := Overlap([]string{"E1/1-48"}, []string{"E1/49-56", "E1/10,E1/2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"E1/2", "E1/10"}; !reflect.DeepEqual(both, want) {
+		t.Fatalf("Overlap = %v, want %v", both, want)
+	}
+	if both, _ := Overlap([]string{"E1/1-48"}, []string{"E1/49-56"}); len(both) != 0 {
+		t.Fatalf("disjoint lists overlap on %v", both)
+	}
+	if _, err := Overlap([]string{"E1/x"}, nil); err == nil {
+		t.Fatal("expected malformed range error")
+	}
+}
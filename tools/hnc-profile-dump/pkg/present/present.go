@@ -0,0 +1,143 @@
+// Package present renders the human output of the report commands: a
+// table of rows under a header, aligned by the characters of each cell
+// rather than its bytes, and the summary lines that follow it. On a
+// terminal the header is bold, unless NO_COLOR is set or TERM is dumb,
+// and a table wider than the terminal is written as one block of
+// HEADER value lines per row instead, so it does not wrap.
+package present
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// padding is the space between columns
+const padding = 2
+
+// Options is how a table is presented
+type Options struct {
+	Color bool // bold the header
+	Width int  // columns the table must fit in, 0 for any
+	Quiet bool // write only the summary lines
+}
+
+// Table is a report's rows under a header, then the lines summing them up
+type Table struct {
+	Header  []string
+	Rows    [][]string
+	Summary []string
+}
+
+// Detect is how to present output written to out: colored and as wide as
+// $COLUMNS when out is a terminal, plain and any width when it is a file
+// or a pipe
+func Detect(out io.Writer, getenv func(string) string) Options {
+	if !isTerminal(out) {
+		return Options{}
+	}
+	var o Options
+	o.Color = getenv("NO_COLOR") == "" && getenv("TERM") != "dumb"
+	if n, err := strconv.Atoi(getenv("COLUMNS")); err == nil && n > 0 {
+		o.Width = n
+	}
+	return o
+}
+
+// isTerminal reports whether out is a character device
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Render writes t: its rows aligned in columns, or one block per row when
+// they do not fit o.Width, then its summary
+func (o Options) Render(w io.Writer, t Table) {
+	if !o.Quiet && len(t.Header) > 0 {
+		widths := columnWidths(t)
+		total := 0
+		for _, n := range widths {
+			total += n + padding
+		}
+		if o.Width > 0 && total-padding > o.Width {
+			o.records(w, t)
+		} else {
+			o.columns(w, t, widths)
+		}
+	}
+	for _, line := range t.Summary {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// columnWidths is the widest cell of each column, header included
+func columnWidths(t Table) []int {
+	widths := make([]int, len(t.Header))
+	for _, row := range append([][]string{t.Header}, t.Rows...) {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+			}
+		}
+	}
+	return widths
+}
+
+// columns writes the header and rows padded to widths; the last cell of
+// a line is not padded
+func (o Options) columns(w io.Writer, t Table, widths []int) {
+	fmt.Fprintln(w, o.bold(line(t.Header, widths)))
+	for _, row := range t.Rows {
+		fmt.Fprintln(w, line(row, widths))
+	}
+}
+
+// line is cells padded to widths
+func line(cells []string, widths []int) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		b.WriteString(cell)
+		if i < len(cells)-1 && i < len(widths) {
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+padding))
+		}
+	}
+	return b.String()
+}
+
+// records writes each row as a block of header and value lines, the
+// blocks separated by a blank line
+func (o Options) records(w io.Writer, t Table) {
+	key := 0
+	for _, h := range t.Header {
+		key = max(key, utf8.RuneCountInString(h))
+	}
+	for r, row := range t.Rows {
+		if r > 0 {
+			fmt.Fprintln(w)
+		}
+		for i, cell := range row {
+			if i < len(t.Header) {
+				h := t.Header[i]
+				fmt.Fprintf(w, "%s%s%s\n", o.bold(h), strings.Repeat(" ", key-utf8.RuneCountInString(h)+padding), cell)
+			}
+		}
+	}
+	if len(t.Summary) > 0 && len(t.Rows) > 0 {
+		fmt.Fprintln(w)
+	}
+}
+
+// bold is s in bold when o is colored
+func (o Options) bold(s string) string {
+	if !o.Color {
+		return s
+	}
+	return "\x1b[1m" + s + "\x1b[0m"
+}
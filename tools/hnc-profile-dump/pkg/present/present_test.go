@@ -0,0 +1,56 @@
+package present
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+var table = Table{
+	Header:  []string{"NAME", "SITE", "PORTS"},
+	Rows:    [][]string{{"leaf1", "Zürich", "48"}, {"spine1", "Genève", "32"}},
+	Summary: []string{"2 switches"},
+}
+
+func render(o Options, t Table) string {
+	var b strings.Builder
+	o.Render(&b, t)
+	return b.String()
+}
+
+func TestRender(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts Options
+		want string
+	}{
+		// Columns align by character, so Zürich pads like a six-letter name
+		{"columns", Options{}, "NAME    SITE    PORTS\nleaf1   Zürich  48\nspine1  Genève  32\n2 switches\n"},
+		{"fits", Options{Width: 21}, "NAME    SITE    PORTS\nleaf1   Zürich  48\nspine1  Genève  32\n2 switches\n"},
+		{"records", Options{Width: 20}, "NAME   leaf1\nSITE   Zürich\nPORTS  48\n\nNAME   spine1\nSITE   Genève\nPORTS  32\n\n2 switches\n"},
+		{"color", Options{Color: true}, "\x1b[1mNAME    SITE    PORTS\x1b[0m\nleaf1   Zürich  48\nspine1  Genève  32\n2 switches\n"},
+		{"quiet", Options{Quiet: true}, "2 switches\n"},
+	} {
+		if got := render(tc.opts, table); got != tc.want {
+			t.Errorf("%s:\n%q\nwant\n%q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Output that is not a terminal is plain whatever the environment says
+func TestDetect(t *testing.T) {
+	env := map[string]string{"COLUMNS": "80"}
+	getenv := func(key string) string { return env[key] }
+	var b strings.Builder
+	if o := Detect(&b, getenv); o != (Options{}) {
+		t.Errorf("Detect(builder) = %+v", o)
+	}
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if o := Detect(f, getenv); o != (Options{}) {
+		t.Errorf("Detect(file) = %+v", o)
+	}
+}
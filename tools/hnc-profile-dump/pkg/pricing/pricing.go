@@ -0,0 +1,247 @@
+// Package pricing estimates what a bill of materials costs from a price
+// list the user supplies, as JSON or CSV: a unit price per SKU and,
+// optionally, discount tiers by the quantity ordered. hnc ships no prices;
+// every figure of an estimate comes from the list it is given, and a BOM
+// line the list does not price is reported rather than guessed.
+//
+// A line is priced by its item as the BOM lists it: a switch or optic
+// SKU, a DAC or AOC SKU, or the length class of a fiber, e.g. 10m.
+package pricing
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/bom"
+)
+
+// Tier is a discount on every unit of an item once at least MinQuantity
+// of it are ordered
+type Tier struct {
+	MinQuantity     int     `json:"minQuantity"`
+	DiscountPercent float64 `json:"discountPercent"`
+}
+
+// Price is what one item costs
+type Price struct {
+	SKU       string  `json:"sku"`
+	UnitPrice float64 `json:"unitPrice"`
+	Tiers     []Tier  `json:"tiers,omitempty"`
+}
+
+// List is a price list file
+type List struct {
+	Currency string  `json:"currency,omitempty"` // e.g. USD; only labels the figures
+	Prices   []Price `json:"prices"`
+}
+
+// CSVHeader is the first row of a CSV price list. A row with a minimum
+// quantity and discount adds a tier to its SKU; the rows of one SKU must
+// agree on its unit price.
+var CSVHeader = []string{"sku", "unit_price", "min_quantity", "discount_percent"}
+
+// Parse reads a price list, as CSV when file ends in .csv and as JSON
+// otherwise
+func Parse(data []byte, file string) (List, error) {
+	var l List
+	var err error
+	if strings.HasSuffix(strings.ToLower(file), ".csv") {
+		l, err = parseCSV(data)
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&l)
+	}
+	if err != nil {
+		return List{}, err
+	}
+	return l, l.validate()
+}
+
+// parseCSV reads a CSV price list under CSVHeader, whose last two columns
+// may be left out
+func parseCSV(data []byte) (List, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return List{}, fmt.Errorf("reading header: %w", err)
+	}
+	if len(header) < 2 || len(header) > len(CSVHeader) || strings.Join(header, ",") != strings.Join(CSVHeader[:len(header)], ",") {
+		return List{}, fmt.Errorf("header is %q, want %q", strings.Join(header, ","), strings.Join(CSVHeader, ","))
+	}
+	var l List
+	index := map[string]int{}
+	for line := 2; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return List{}, err
+		}
+		if len(row) != len(header) {
+			return List{}, fmt.Errorf("line %d: %d fields, want %d", line, len(row), len(header))
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		if err != nil {
+			return List{}, fmt.Errorf("line %d: unit_price %q is not a number", line, row[1])
+		}
+		p := Price{SKU: strings.TrimSpace(row[0]), UnitPrice: price}
+		if len(row) == len(CSVHeader) && strings.TrimSpace(row[2]+row[3]) != "" {
+			var t Tier
+			if t.MinQuantity, err = strconv.Atoi(strings.TrimSpace(row[2])); err != nil {
+				return List{}, fmt.Errorf("line %d: min_quantity %q is not a whole number", line, row[2])
+			}
+			if t.DiscountPercent, err = strconv.ParseFloat(strings.TrimSpace(row[3]), 64); err != nil {
+				return List{}, fmt.Errorf("line %d: discount_percent %q is not a number", line, row[3])
+			}
+			p.Tiers = []Tier{t}
+		}
+		i, seen := index[p.SKU]
+		if !seen {
+			index[p.SKU] = len(l.Prices)
+			l.Prices = append(l.Prices, p)
+			continue
+		}
+		if l.Prices[i].UnitPrice != p.UnitPrice {
+			return List{}, fmt.Errorf("line %d: %s is priced %g here and %g before", line, p.SKU, p.UnitPrice, l.Prices[i].UnitPrice)
+		}
+		l.Prices[i].Tiers = append(l.Prices[i].Tiers, p.Tiers...)
+	}
+	return l, nil
+}
+
+// validate checks every SKU is priced once, at no negative price, and its
+// tiers are distinct discounts below 100%
+func (l List) validate() error {
+	seen := map[string]bool{}
+	for _, p := range l.Prices {
+		if p.SKU == "" {
+			return fmt.Errorf("a price has no SKU")
+		}
+		if seen[p.SKU] {
+			return fmt.Errorf("%s is priced twice", p.SKU)
+		}
+		seen[p.SKU] = true
+		if p.UnitPrice < 0 {
+			return fmt.Errorf("%s: unit price %g is negative", p.SKU, p.UnitPrice)
+		}
+		tiers := map[int]bool{}
+		for _, t := range p.Tiers {
+			if t.MinQuantity < 1 {
+				return fmt.Errorf("%s: tier minimum quantity %d is not positive", p.SKU, t.MinQuantity)
+			}
+			if tiers[t.MinQuantity] {
+				return fmt.Errorf("%s: two tiers from %d", p.SKU, t.MinQuantity)
+			}
+			tiers[t.MinQuantity] = true
+			if t.DiscountPercent < 0 || t.DiscountPercent >= 100 {
+				return fmt.Errorf("%s: tier discount %g%% is not from 0 to under 100", p.SKU, t.DiscountPercent)
+			}
+		}
+	}
+	return nil
+}
+
+// discount is the percentage off quantity units of p: that of the
+// largest tier the quantity reaches
+func (p Price) discount(quantity int) float64 {
+	best, percent := 0, 0.0
+	for _, t := range p.Tiers {
+		if quantity >= t.MinQuantity && t.MinQuantity > best {
+			best, percent = t.MinQuantity, t.DiscountPercent
+		}
+	}
+	return percent
+}
+
+// Line is a priced BOM line
+type Line struct {
+	bom.Line
+	UnitPrice       float64 `json:"unitPrice"`
+	DiscountPercent float64 `json:"discountPercent,omitempty"`
+	Total           float64 `json:"total"`
+}
+
+// Estimate is what a BOM costs
+type Estimate struct {
+	Currency   string             `json:"currency,omitempty"`
+	Lines      []Line             `json:"lines"`
+	ByCategory map[string]float64 `json:"byCategory"` // bom.Switch, bom.Optic and bom.Cable
+	Total      float64            `json:"total"`
+	// Unpriced are the items of the lines the list does not price, which
+	// the total leaves out
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+// Estimate prices every line of b. An item's tier is reached by its
+// quantity over all of b's lines, so leaf and spine optics of one SKU
+// earn a discount together. Totals are rounded to the cent.
+func (l List) Estimate(b bom.BOM) Estimate {
+	prices := map[string]Price{}
+	for _, p := range l.Prices {
+		prices[p.SKU] = p
+	}
+	ordered := map[string]int{}
+	for _, line := range b.Lines {
+		ordered[line.Item] += line.Quantity
+	}
+	e := Estimate{Currency: l.Currency, Lines: []Line{}, ByCategory: map[string]float64{}}
+	unpriced := map[string]bool{}
+	for _, line := range b.Lines {
+		p, ok := prices[line.Item]
+		if !ok {
+			unpriced[line.Item] = true
+			continue
+		}
+		priced := Line{Line: line, UnitPrice: p.UnitPrice, DiscountPercent: p.discount(ordered[line.Item])}
+		priced.Total = cents(float64(line.Quantity) * p.UnitPrice * (100 - priced.DiscountPercent) / 100)
+		e.Lines = append(e.Lines, priced)
+		e.ByCategory[line.Category] = cents(e.ByCategory[line.Category] + priced.Total)
+		e.Total = cents(e.Total + priced.Total)
+	}
+	for item := range unpriced {
+		e.Unpriced = append(e.Unpriced, item)
+	}
+	sort.Strings(e.Unpriced)
+	return e
+}
+
+// Format writes an amount with two decimals and the currency, if any:
+// USD 1234.50
+func (e Estimate) Format(amount float64) string {
+	if e.Currency == "" {
+		return strconv.FormatFloat(amount, 'f', 2, 64)
+	}
+	return e.Currency + " " + strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// cents rounds an amount to two decimals
+func cents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// EstimateCSVHeader is the first row of RenderCSV
+var EstimateCSVHeader = []string{"category", "item", "description", "quantity", "unit_price", "discount_percent", "total"}
+
+// RenderCSV writes one row per priced line, then the total
+func RenderCSV(e Estimate) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(EstimateCSVHeader)
+	number := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, l := range e.Lines {
+		w.Write([]string{l.Category, l.Item, l.Description, strconv.Itoa(l.Quantity), number(l.UnitPrice), number(l.DiscountPercent), number(l.Total)})
+	}
+	w.Write([]string{"total", "", "", "", "", "", number(e.Total)})
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package pricing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/bom"
+)
+
+var testBOM = bom.BOM{Lines: []bom.Line{
+	{Category: bom.Switch, Item: "CELESTICA-DS2000", Description: "leaf", Quantity: 4},
+	{Category: bom.Optic, Item: "QSFP28-100G-SR4", Description: "leaf uplink ports", Quantity: 8},
+	{Category: bom.Optic, Item: "QSFP28-100G-SR4", Description: "spine fabric ports", Quantity: 8},
+	{Category: bom.Cable, Item: "10m", Description: "100G leaf to spine", Quantity: 8},
+	{Category: bom.Cable, Item: "3m", Description: "25G leaf to endpoint", Quantity: 96},
+}}
+
+func TestEstimate(t *testing.T) {
+	l, err := Parse([]byte(`sku,unit_price,min_quantity,discount_percent
+CELESTICA-DS2000,10000,,
+QSFP28-100G-SR4,100,10,5
+QSFP28-100G-SR4,100,100,20
+10m,12.5,,
+`), "prices.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := l.Estimate(testBOM)
+	// 16 optics in all reach the 10-unit tier
+	if len(e.Lines) != 4 || e.Lines[1].DiscountPercent != 5 || e.Lines[1].Total != 760 {
+		t.Errorf("lines = %+v", e.Lines)
+	}
+	want := map[string]float64{bom.Switch: 40000, bom.Optic: 1520, bom.Cable: 100}
+	if !reflect.DeepEqual(e.ByCategory, want) || e.Total != 41620 {
+		t.Errorf("by category %v, total %g; want %v, 41620", e.ByCategory, e.Total, want)
+	}
+	if !reflect.DeepEqual(e.Unpriced, []string{"3m"}) {
+		t.Errorf("unpriced = %v", e.Unpriced)
+	}
+	if got := e.Format(e.Total); got != "41620.00" {
+		t.Errorf("Format = %s", got)
+	}
+	if csv := RenderCSV(e); !strings.HasSuffix(csv, "total,,,,,,41620\n") {
+		t.Errorf("CSV:\n%s", csv)
+	}
+
+	// The JSON list prices the same
+	j, err := Parse([]byte(`{"currency": "USD", "prices": [
+		{"sku": "CELESTICA-DS2000", "unitPrice": 10000},
+		{"sku": "QSFP28-100G-SR4", "unitPrice": 100, "tiers": [{"minQuantity": 10, "discountPercent": 5}, {"minQuantity": 100, "discountPercent": 20}]},
+		{"sku": "10m", "unitPrice": 12.5}]}`), "prices.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if je := j.Estimate(testBOM); je.Total != e.Total || je.Format(je.Total) != "USD 41620.00" {
+		t.Errorf("JSON estimate = %+v", je)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, tc := range []struct {
+		file, data, want string
+	}{
+		{"p.csv", "sku,price\nA,1\n", "header is"},
+		{"p.csv", "sku,unit_price\nA,x\n", "line 2: unit_price"},
+		{"p.csv", "sku,unit_price\nA,1\nA,2\n", "line 3: A is priced 2 here and 1 before"},
+		{"p.csv", "sku,unit_price,min_quantity,discount_percent\nA,1,10,100\n", "not from 0 to under 100"},
+		{"p.json", `{"prices": [{"sku": "A", "unitPrice": -1}]}`, "negative"},
+		{"p.json", `{"prices": [{"sku": "A", "unitPrice": 1}, {"sku": "A", "unitPrice": 1}]}`, "A is priced twice"},
+		{"p.json", `{"prices": [], "discounts": []}`, "unknown field"},
+	} {
+		if _, err := Parse([]byte(tc.data), tc.file); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Parse(%s %q) = %v, want %q", tc.file, tc.data, err, tc.want)
+		}
+	}
+}
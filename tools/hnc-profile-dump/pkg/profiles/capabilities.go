@@ -0,0 +1,47 @@
+package profiles
+
+import "fmt"
+
+// Capabilities is what the switch's ASIC can hold and do, from its
+// datasheet, for checking designs against. A profile without them is not
+// checked; one with them is taken to lack the features it leaves out.
+type Capabilities struct {
+	ASIC     string   `json:"asic" doc:"ASIC family, e.g. trident3 or tomahawk4"`
+	Tables   Tables   `json:"tables" doc:"Forwarding table sizes; 0 or absent where the datasheet gives none"`
+	Features Features `json:"features" doc:"Forwarding features the ASIC and NOS support; absent means unsupported"`
+}
+
+// Tables are forwarding table sizes
+type Tables struct {
+	Routes       int `json:"routes,omitempty" doc:"IPv4 routes the switch holds"`
+	MACs         int `json:"macs,omitempty" doc:"MAC addresses the switch learns"`
+	VXLANTunnels int `json:"vxlanTunnels,omitempty" doc:"Remote VTEPs the switch keeps VXLAN tunnels to"`
+}
+
+// Features are forwarding features a design may need
+type Features struct {
+	VXLANRouting bool `json:"vxlanRouting,omitempty" doc:"Routes between VXLAN segments, which leaves need to carry VPCs"`
+	RoCE         bool `json:"roce,omitempty" doc:"Lossless RoCE transport: PFC and ECN"`
+}
+
+// validateCapabilities checks the capabilities name an ASIC and that no
+// table is negative
+func validateCapabilities(p SwitchProfile) []string {
+	c := p.Capabilities
+	if c == nil {
+		return nil
+	}
+	var errs []string
+	if c.ASIC == "" {
+		errs = append(errs, "capabilities.asic is required")
+	}
+	for _, t := range []struct {
+		name string
+		size int
+	}{{"routes", c.Tables.Routes}, {"macs", c.Tables.MACs}, {"vxlanTunnels", c.Tables.VXLANTunnels}} {
+		if t.size < 0 {
+			errs = append(errs, fmt.Sprintf("capabilities.tables.%s must not be negative, got %d", t.name, t.size))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,21 @@
+package profiles
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCapabilities(t *testing.T) {
+	p := DS2000()
+	p.Capabilities = &Capabilities{ASIC: "trident3", Tables: Tables{Routes: 16000, MACs: 32000}, Features: Features{VXLANRouting: true}}
+	if errs := Validate(p); len(errs) > 0 {
+		t.Fatalf("Validate() = %v", errs)
+	}
+	p.Capabilities = &Capabilities{Tables: Tables{VXLANTunnels: -1}}
+	errs := strings.Join(Validate(p), "; ")
+	for _, want := range []string{"capabilities.asic is required", "capabilities.tables.vxlanTunnels must not be negative, got -1"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("Validate() = %s, want %q", errs, want)
+		}
+	}
+}
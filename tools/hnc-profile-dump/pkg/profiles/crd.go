@@ -0,0 +1,488 @@
+package profiles
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/ports"
+)
+
+// CRD identity of the Hedgehog fabric SwitchProfile resource
+const (
+	CRDAPIVersion = "wiring.githedgehog.com/v1beta1"
+	CRDKind       = "SwitchProfile"
+)
+
+// Annotations that carry the HNC-only parts of a profile on the CRD
+const (
+	RolesAnnotation    = "hnc.githedgehog.com/roles"
+	SourceAnnotation   = "hnc.githedgehog.com/source"
+	VersionAnnotation  = "hnc.githedgehog.com/profile-version"
+	EndpointAnnotation = "hnc.githedgehog.com/endpoint-assignable"
+	FabricAnnotation   = "hnc.githedgehog.com/fabric-assignable"
+)
+
+// SwitchProfileCRD is a SwitchProfile custom resource
+type SwitchProfileCRD struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Metadata   CRDMetadata `json:"metadata"`
+	Spec       CRDSpec     `json:"spec"`
+}
+
+type CRDMetadata struct {
+	Name            string            `json:"name"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"` // set on objects read from a cluster
+}
+
+type CRDSpec struct {
+	DisplayName  string                    `json:"displayName"`
+	Ports        map[string]CRDPort        `json:"ports"`
+	PortGroups   map[string]CRDPortGroup   `json:"portGroups,omitempty"`
+	PortProfiles map[string]CRDPortProfile `json:"portProfiles,omitempty"`
+}
+
+// CRDPort is one front-panel port. NOS interface names are platform
+// specific and not part of the profile source, so they are left to the
+// controller's built-in profile for the platform. Group and Management
+// only appear on profiles read back from a controller.
+type CRDPort struct {
+	Label      string `json:"label"`
+	Profile    string `json:"profile,omitempty"`
+	Group      string `json:"group,omitempty"`
+	Management bool   `json:"management,omitempty"`
+}
+
+// CRDPortGroup is a set of ports whose speed is set together; member
+// ports name it instead of a profile
+type CRDPortGroup struct {
+	Profile string `json:"profile"`
+}
+
+// CRDPortProfile sets either a fixed speed or, for ports that break out,
+// the split modes
+type CRDPortProfile struct {
+	Speed    *CRDSpeed    `json:"speed,omitempty"`
+	Breakout *CRDBreakout `json:"breakout,omitempty"`
+}
+
+type CRDSpeed struct {
+	Default   string   `json:"default"`
+	Supported []string `json:"supported"`
+}
+
+// CRDBreakout lists modes such as "1x100G" and "4x25G", each with the lane
+// offsets of its resulting ports
+type CRDBreakout struct {
+	Default   string                     `json:"default"`
+	Supported map[string]CRDBreakoutMode `json:"supported"`
+}
+
+type CRDBreakoutMode struct {
+	Offsets []string `json:"offsets"`
+}
+
+// ToCRD renders a profile as a SwitchProfile resource: every assignable
+// port with its port profile, and roles and ranges as annotations
+func ToCRD(p SwitchProfile) (SwitchProfileCRD, error) {
+	crd := SwitchProfileCRD{
+		APIVersion: CRDAPIVersion,
+		Kind:       CRDKind,
+		Metadata: CRDMetadata{
+			Name: p.ModelID,
+			Annotations: map[string]string{
+				RolesAnnotation:    strings.Join(p.Roles, ","),
+				SourceAnnotation:   p.Meta.Source,
+				VersionAnnotation:  p.Meta.Version,
+				EndpointAnnotation: strings.Join(p.Ports.EndpointAssignable, ","),
+				FabricAnnotation:   strings.Join(p.Ports.FabricAssignable, ","),
+			},
+		},
+		Spec: CRDSpec{DisplayName: displayName(p.ModelID), Ports: map[string]CRDPort{}},
+	}
+
+	for _, group := range []struct {
+		ranges  []string
+		profile PortProfile
+	}{{p.Ports.EndpointAssignable, p.Profiles.Endpoint}, {p.Ports.FabricAssignable, p.Profiles.Uplink}} {
+		names, err := ports.Expand(group.ranges)
+		if err != nil {
+			return SwitchProfileCRD{}, fmt.Errorf("profile %s: %w", p.ModelID, err)
+		}
+		var profileName string
+		if group.profile.PortProfile != nil {
+			profileName = *group.profile.PortProfile
+			if crd.Spec.PortProfiles == nil {
+				crd.Spec.PortProfiles = map[string]CRDPortProfile{}
+			}
+			crd.Spec.PortProfiles[profileName] = portProfileCRD(group.profile)
+		}
+		for _, name := range names {
+			if _, dup := crd.Spec.Ports[name]; dup {
+				return SwitchProfileCRD{}, fmt.Errorf("profile %s: port %s is both endpoint and fabric assignable", p.ModelID, name)
+			}
+			crd.Spec.Ports[name] = CRDPort{Label: name[strings.LastIndex(name, "/")+1:], Profile: profileName}
+		}
+	}
+	return crd, nil
+}
+
+// portProfileCRD describes a port profile's speed, or its breakout modes
+// with the unsplit port as the default
+func portProfileCRD(pp PortProfile) CRDPortProfile {
+	speed := strconv.Itoa(pp.SpeedGbps) + "G"
+	if len(pp.Breakouts) == 0 {
+		return CRDPortProfile{Speed: &CRDSpeed{Default: speed, Supported: []string{speed}}}
+	}
+	native := "1x" + speed
+	breakout := &CRDBreakout{Default: native, Supported: map[string]CRDBreakoutMode{native: {Offsets: []string{"0"}}}}
+	for _, b := range pp.Breakouts {
+		offsets := make([]string, b.Lanes)
+		for lane := range offsets {
+			offsets[lane] = strconv.Itoa(lane)
+		}
+		breakout.Supported[b.Mode] = CRDBreakoutMode{Offsets: offsets}
+	}
+	return CRDPortProfile{Breakout: breakout}
+}
+
+// FromCRD turns a SwitchProfile resource back into a profile with source
+// as Meta.Source. Roles and port ranges come from the HNC annotations when
+// ToCRD wrote them. A controller's own profiles have none, so the fastest
+// ports are taken as fabric ports and the rest as endpoint ports, and a
+// switch with only fabric ports as a spine. The resource has no faceplate
+// and none of HNC's planning data, so none is set.
+func FromCRD(crd SwitchProfileCRD, source string) (SwitchProfile, error) {
+	p := SwitchProfile{ModelID: crd.Metadata.Name, Meta: Meta{Source: source, Version: SchemaVersion}}
+	if p.ModelID == "" {
+		return SwitchProfile{}, fmt.Errorf("SwitchProfile has no metadata.name")
+	}
+	annotations := crd.Metadata.Annotations
+	if v := annotations[VersionAnnotation]; v != "" {
+		p.Meta.Version = v
+	}
+
+	profileOf := map[string]string{}
+	speedOf := map[string]int{}
+	for name, port := range crd.Spec.Ports {
+		profileName := port.Profile
+		if port.Group != "" {
+			group, ok := crd.Spec.PortGroups[port.Group]
+			if !ok {
+				return SwitchProfile{}, fmt.Errorf("profile %s: port %s is in undefined port group %s", p.ModelID, name, port.Group)
+			}
+			profileName = group.Profile
+		}
+		if port.Management || profileName == "" {
+			continue
+		}
+		pp, ok := crd.Spec.PortProfiles[profileName]
+		if !ok {
+			return SwitchProfile{}, fmt.Errorf("profile %s: port %s uses undefined port profile %s", p.ModelID, name, profileName)
+		}
+		speed, err := nativeSpeed(pp)
+		if err != nil {
+			return SwitchProfile{}, fmt.Errorf("profile %s: port profile %s: %w", p.ModelID, profileName, err)
+		}
+		profileOf[name], speedOf[name] = profileName, speed
+	}
+
+	var endpoint, fabric []string
+	if annotations[EndpointAnnotation] != "" || annotations[FabricAnnotation] != "" {
+		p.Ports.EndpointAssignable = splitAnnotation(annotations[EndpointAnnotation])
+		p.Ports.FabricAssignable = splitAnnotation(annotations[FabricAnnotation])
+		var err error
+		if endpoint, err = ports.Expand(p.Ports.EndpointAssignable); err == nil {
+			fabric, err = ports.Expand(p.Ports.FabricAssignable)
+		}
+		if err != nil {
+			return SwitchProfile{}, fmt.Errorf("profile %s: %w", p.ModelID, err)
+		}
+	} else {
+		fastest := 0
+		for _, speed := range speedOf {
+			fastest = max(fastest, speed)
+		}
+		for name, speed := range speedOf {
+			if speed == fastest {
+				fabric = append(fabric, name)
+			} else {
+				endpoint = append(endpoint, name)
+			}
+		}
+		p.Ports.EndpointAssignable, p.Ports.FabricAssignable = portRanges(endpoint), portRanges(fabric)
+	}
+
+	switch {
+	case annotations[RolesAnnotation] != "":
+		p.Roles = splitAnnotation(annotations[RolesAnnotation])
+	case len(endpoint) == 0:
+		p.Roles = []string{"spine"}
+	default:
+		p.Roles = []string{"leaf"}
+	}
+
+	var err error
+	if p.Profiles.Endpoint, err = groupProfile(crd, endpoint, profileOf); err == nil {
+		p.Profiles.Uplink, err = groupProfile(crd, fabric, profileOf)
+	}
+	if err != nil {
+		return SwitchProfile{}, fmt.Errorf("profile %s: %w", p.ModelID, err)
+	}
+
+	// Leaves advertise the first breakout of their ports to the wiring
+	// builder, as the built-in profiles do
+	p.Profiles.Breakout = &BreakoutCapability{}
+	if slices.Contains(p.Roles, "leaf") {
+		for _, pp := range []PortProfile{p.Profiles.Endpoint, p.Profiles.Uplink} {
+			if len(pp.Breakouts) > 0 {
+				b := pp.Breakouts[0]
+				p.Profiles.Breakout = &BreakoutCapability{SupportsBreakout: true, BreakoutType: b.Mode, CapacityMultiplier: b.Lanes}
+				break
+			}
+		}
+	}
+	return p, nil
+}
+
+// groupProfile is the port profile most of names use, with its speed and
+// breakouts; an empty group has none
+func groupProfile(crd SwitchProfileCRD, names []string, profileOf map[string]string) (PortProfile, error) {
+	if len(names) == 0 {
+		return PortProfile{}, nil
+	}
+	counts := map[string]int{}
+	for _, name := range names {
+		profileName, ok := profileOf[name]
+		if !ok {
+			return PortProfile{}, fmt.Errorf("port %s has no port profile", name)
+		}
+		counts[profileName]++
+	}
+	var profileName string
+	for _, name := range sortedKeys(counts) {
+		if counts[name] > counts[profileName] {
+			profileName = name
+		}
+	}
+	pp := crd.Spec.PortProfiles[profileName]
+	speed, _ := nativeSpeed(pp)
+	result := PortProfile{PortProfile: &profileName, SpeedGbps: speed}
+	if pp.Breakout != nil {
+		for _, mode := range sortedKeys(pp.Breakout.Supported) {
+			lanes, laneSpeed, err := parseMode(mode)
+			if err != nil {
+				return PortProfile{}, fmt.Errorf("port profile %s: %w", profileName, err)
+			}
+			if lanes > 1 {
+				result.Breakouts = append(result.Breakouts, BreakoutOption{Mode: mode, Lanes: lanes, SpeedGbps: laneSpeed, PortPattern: "{port}/{lane}"})
+			}
+		}
+		sort.SliceStable(result.Breakouts, func(i, j int) bool { return result.Breakouts[i].Lanes < result.Breakouts[j].Lanes })
+	}
+	return result, nil
+}
+
+// nativeSpeed is a port profile's default speed, or the speed of its
+// unsplit breakout mode
+func nativeSpeed(pp CRDPortProfile) (int, error) {
+	switch {
+	case pp.Speed != nil:
+		return parseSpeed(pp.Speed.Default)
+	case pp.Breakout != nil:
+		lanes, speed, err := parseMode(pp.Breakout.Default)
+		return lanes * speed, err
+	}
+	return 0, fmt.Errorf("neither speed nor breakout is set")
+}
+
+// parseMode splits a breakout mode such as "4x25G" into lanes and speed
+func parseMode(mode string) (lanes, speedGbps int, err error) {
+	n, speed, ok := strings.Cut(mode, "x")
+	if lanes, err = strconv.Atoi(n); !ok || err != nil || lanes < 1 {
+		return 0, 0, fmt.Errorf("malformed breakout mode %q", mode)
+	}
+	speedGbps, err = parseSpeed(speed)
+	return lanes, speedGbps, err
+}
+
+// parseSpeed reads a speed such as "25G" in Gbps
+func parseSpeed(speed string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSuffix(speed, "G"))
+	if err != nil || n < 1 || !strings.HasSuffix(speed, "G") {
+		return 0, fmt.Errorf("malformed speed %q", speed)
+	}
+	return n, nil
+}
+
+// portRanges collapses port names into ranges: E1/1 through E1/48 and
+// E1/50 become E1/1-48 and E1/50
+func portRanges(names []string) []string {
+	sort.Slice(names, func(i, j int) bool { return portLess(names[i], names[j]) })
+	ranges := []string{}
+	for i := 0; i < len(names); {
+		prefix := names[i][:strings.LastIndex(names[i], "/")+1]
+		start, err := strconv.Atoi(names[i][len(prefix):])
+		j := i + 1
+		for err == nil && j < len(names) && names[j] == prefix+strconv.Itoa(start+j-i) {
+			j++
+		}
+		if j-i > 1 {
+			ranges = append(ranges, fmt.Sprintf("%s%d-%d", prefix, start, start+j-i-1))
+		} else {
+			ranges = append(ranges, names[i])
+		}
+		i = j
+	}
+	return ranges
+}
+
+// splitAnnotation reads a comma-joined annotation, empty as no values
+func splitAnnotation(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+// CRDFileName is the manifest file for a model, e.g. celestica-ds2000 ->
+// ds2000.yaml
+func CRDFileName(modelID string) string {
+	return YAMLFileName(modelID)
+}
+
+// WriteAllCRDs writes every profile to dir as a SwitchProfile manifest
+// ready for kubectl apply, and returns the paths written, in List order
+func (r *Registry) WriteAllCRDs(dir string) ([]string, error) {
+	files, err := r.RenderCRDs()
+	if err != nil {
+		return nil, err
+	}
+	return writeOutput(dir, files)
+}
+
+// writeOutput writes rendered files to dir and returns the paths written
+func writeOutput(dir string, files []File) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	var paths []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name)
+		if err := os.WriteFile(path, f.Data, 0644); err != nil {
+			return paths, fmt.Errorf("failed to write file %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// RenderCRDs returns the manifests WriteAllCRDs would write, in List order
+func (r *Registry) RenderCRDs() ([]File, error) {
+	return r.RenderWith(CRDFile, 1)
+}
+
+// CRDFile is a profile's SwitchProfile manifest, as RenderCRDs writes it
+func CRDFile(p SwitchProfile) (File, error) {
+	crd, err := ToCRD(p)
+	if err != nil {
+		return File{}, err
+	}
+	return File{Name: CRDFileName(p.ModelID), Data: []byte(crd.YAML())}, nil
+}
+
+// YAML renders the resource with sorted keys and ports in panel order, so
+// regenerated manifests diff cleanly
+func (c SwitchProfileCRD) YAML() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: %s\nkind: %s\nmetadata:\n  name: %s\n", c.APIVersion, c.Kind, yamlString(c.Metadata.Name))
+	if len(c.Metadata.Annotations) > 0 {
+		b.WriteString("  annotations:\n")
+		for _, k := range sortedKeys(c.Metadata.Annotations) {
+			fmt.Fprintf(&b, "    %s: %s\n", k, yamlString(c.Metadata.Annotations[k]))
+		}
+	}
+	fmt.Fprintf(&b, "spec:\n  displayName: %s\n  ports:\n", yamlString(c.Spec.DisplayName))
+	names := make([]string, 0, len(c.Spec.Ports))
+	for name := range c.Spec.Ports {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return portLess(names[i], names[j]) })
+	for _, name := range names {
+		port := c.Spec.Ports[name]
+		fmt.Fprintf(&b, "    %s:\n      label: %s\n", yamlString(name), yamlString(port.Label))
+		if port.Profile != "" {
+			fmt.Fprintf(&b, "      profile: %s\n", yamlString(port.Profile))
+		}
+	}
+	if len(c.Spec.PortProfiles) > 0 {
+		b.WriteString("  portProfiles:\n")
+		for _, name := range sortedKeys(c.Spec.PortProfiles) {
+			fmt.Fprintf(&b, "    %s:\n", yamlString(name))
+			pp := c.Spec.PortProfiles[name]
+			if pp.Speed != nil {
+				fmt.Fprintf(&b, "      speed:\n        default: %s\n        supported: %s\n",
+					yamlString(pp.Speed.Default), yamlList(pp.Speed.Supported))
+			}
+			if pp.Breakout != nil {
+				fmt.Fprintf(&b, "      breakout:\n        default: %s\n        supported:\n", yamlString(pp.Breakout.Default))
+				for _, mode := range sortedKeys(pp.Breakout.Supported) {
+					fmt.Fprintf(&b, "          %s:\n            offsets: %s\n", yamlString(mode), yamlList(pp.Breakout.Supported[mode].Offsets))
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// displayName turns celestica-ds2000 into "Celestica DS2000"
+func displayName(modelID string) string {
+	parts := strings.Split(modelID, "-")
+	for i, part := range parts {
+		if i == 0 && len(part) > 0 {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		} else {
+			parts[i] = strings.ToUpper(part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// yamlString quotes every value, so port labels like "1" stay strings
+func yamlString(s string) string {
+	return strconv.Quote(s)
+}
+
+func yamlList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = yamlString(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// portLess orders E1/2 before E1/10
+func portLess(a, b string) bool {
+	pa, pb := a[:strings.LastIndex(a, "/")+1], b[:strings.LastIndex(b, "/")+1]
+	na, errA := strconv.Atoi(a[len(pa):])
+	nb, errB := strconv.Atoi(b[len(pb):])
+	if pa != pb || errA != nil || errB != nil {
+		return a < b
+	}
+	return na < nb
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,177 @@
+package profiles
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToCRD(t *testing.T) {
+	crd, err := ToCRD(DS2000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crd.APIVersion != CRDAPIVersion || crd.Kind != CRDKind || crd.Metadata.Name != "celestica-ds2000" {
+		t.Fatalf("identity = %s %s %s", crd.APIVersion, crd.Kind, crd.Metadata.Name)
+	}
+	if crd.Spec.DisplayName != "Celestica DS2000" {
+		t.Errorf("displayName = %q", crd.Spec.DisplayName)
+	}
+	if got := crd.Metadata.Annotations[RolesAnnotation]; got != "leaf" {
+		t.Errorf("roles annotation = %q", got)
+	}
+	if len(crd.Spec.Ports) != 56 {
+		t.Fatalf("got %d ports, want 56", len(crd.Spec.Ports))
+	}
+	if got := crd.Spec.Ports["E1/10"]; got != (CRDPort{Label: "10", Profile: "SFP28-25G"}) {
+		t.Errorf("E1/10 = %+v", got)
+	}
+	if got := crd.Spec.Ports["E1/49"]; got != (CRDPort{Label: "49", Profile: "QSFP28-100G"}) {
+		t.Errorf("E1/49 = %+v", got)
+	}
+	if got := crd.Spec.PortProfiles["SFP28-25G"].Speed; got == nil || got.Default != "25G" || !reflect.DeepEqual(got.Supported, []string{"25G"}) {
+		t.Errorf("SFP28-25G speed = %+v", got)
+	}
+	want := &CRDBreakout{Default: "1x100G", Supported: map[string]CRDBreakoutMode{
+		"1x100G": {Offsets: []string{"0"}},
+		"4x25G":  {Offsets: []string{"0", "1", "2", "3"}},
+	}}
+	if got := crd.Spec.PortProfiles["QSFP28-100G"]; got.Speed != nil || !reflect.DeepEqual(got.Breakout, want) {
+		t.Errorf("QSFP28-100G = %+v, want breakout %+v", got, want)
+	}
+
+	// DS3000 has no endpoint port profile, so only the uplink one is emitted
+	spine, err := ToCRD(DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spine.Spec.PortProfiles) != 1 {
+		t.Errorf("DS3000 portProfiles = %+v", spine.Spec.PortProfiles)
+	}
+}
+
+func TestToCRDRejectsOverlappingPorts(t *testing.T) {
+	p := DS2000()
+	p.Ports.FabricAssignable = []string{"E1/48-49"}
+	if _, err := ToCRD(p); err == nil || !strings.Contains(err.Error(), "E1/48") {
+		t.Fatalf("ToCRD() error = %v, want overlap on E1/48", err)
+	}
+}
+
+func TestCRDYAMLRoundTrips(t *testing.T) {
+	for _, p := range Default().List() {
+		crd, err := ToCRD(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := crd.YAML()
+		if !strings.Contains(out, "    \"E1/2\":\n") || strings.Index(out, "\"E1/2\":") > strings.Index(out, "\"E1/10\":") {
+			t.Errorf("%s: ports are not in panel order", p.ModelID)
+		}
+
+		parsed, err := parseYAML(out)
+		if err != nil {
+			t.Fatalf("%s: emitted YAML does not parse: %v\n%s", p.ModelID, err, out)
+		}
+		var want any
+		data, _ := json.Marshal(crd)
+		if err := json.Unmarshal(data, &want); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(parsed, want) {
+			t.Errorf("%s: YAML round trip differs from JSON encoding\n%s", p.ModelID, out)
+		}
+	}
+}
+
+func TestWriteAllCRDs(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := Default().WriteAllCRDs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "ds2000.yaml"), filepath.Join(dir, "ds3000.yaml")}; len(paths) != 6 || !reflect.DeepEqual(paths[:2], want) {
+		t.Fatalf("WriteAllCRDs() = %v, want %v", paths, want)
+	}
+	data, err := os.ReadFile(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "apiVersion: "+CRDAPIVersion+"\nkind: SwitchProfile\n") {
+		t.Errorf("unexpected manifest header:\n%s", data)
+	}
+}
+
+func TestFromCRDRoundTrips(t *testing.T) {
+	for _, p := range Default().List() {
+		crd, err := ToCRD(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := FromCRD(crd, "cluster")
+		if err != nil {
+			t.Fatalf("%s: %v", p.ModelID, err)
+		}
+		want := p
+		want.Faceplate, want.Meta.Source = nil, "cluster"
+		want.Physical, want.Cost, want.Capabilities, want.Lifecycle = nil, nil, nil, nil
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: round trip differs\n got  %+v\n want %+v", p.ModelID, got, want)
+		}
+	}
+}
+
+func TestFromCRDInfersControllerProfiles(t *testing.T) {
+	// A controller's own profile: no HNC annotations, a management port
+	// and uplinks whose speed is set by port group
+	crd := SwitchProfileCRD{
+		Metadata: CRDMetadata{Name: "celestica-ds2000", ResourceVersion: "48213"},
+		Spec: CRDSpec{
+			Ports: map[string]CRDPort{
+				"M1":   {Label: "M1", Management: true},
+				"E1/1": {Label: "1", Group: "1"}, "E1/2": {Label: "2", Group: "1"},
+				"E1/3": {Label: "3", Group: "1"}, "E1/5": {Label: "5", Group: "1"},
+				"E1/6": {Label: "6", Profile: "QSFP28-100G"}, "E1/7": {Label: "7", Profile: "QSFP28-100G"},
+			},
+			PortGroups: map[string]CRDPortGroup{"1": {Profile: "SFP28-25G"}},
+			PortProfiles: map[string]CRDPortProfile{
+				"SFP28-25G": {Speed: &CRDSpeed{Default: "25G", Supported: []string{"10G", "25G"}}},
+				"QSFP28-100G": {Breakout: &CRDBreakout{Default: "1x100G", Supported: map[string]CRDBreakoutMode{
+					"1x100G": {Offsets: []string{"0"}},
+					"4x25G":  {Offsets: []string{"0", "1", "2", "3"}},
+					"2x50G":  {Offsets: []string{"0", "2"}},
+				}}},
+			},
+		},
+	}
+	p, err := FromCRD(crd, "fabric-api:lab@48213")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Roles, []string{"leaf"}) {
+		t.Errorf("roles = %v", p.Roles)
+	}
+	if want := (Ports{EndpointAssignable: []string{"E1/1-3", "E1/5"}, FabricAssignable: []string{"E1/6-7"}}); !reflect.DeepEqual(p.Ports, want) {
+		t.Errorf("ports = %+v, want %+v", p.Ports, want)
+	}
+	if *p.Profiles.Endpoint.PortProfile != "SFP28-25G" || p.Profiles.Endpoint.SpeedGbps != 25 || *p.Profiles.Uplink.PortProfile != "QSFP28-100G" {
+		t.Errorf("profiles = %+v", p.Profiles)
+	}
+	if got := p.Profiles.Uplink.Breakouts; len(got) != 2 || got[0].Mode != "2x50G" || got[1] != (BreakoutOption{Mode: "4x25G", Lanes: 4, SpeedGbps: 25, PortPattern: "{port}/{lane}"}) {
+		t.Errorf("uplink breakouts = %+v", got)
+	}
+	if want := (BreakoutCapability{SupportsBreakout: true, BreakoutType: "2x50G", CapacityMultiplier: 2}); *p.Profiles.Breakout != want {
+		t.Errorf("breakout = %+v", *p.Profiles.Breakout)
+	}
+	if errs := Validate(p); len(errs) > 0 {
+		t.Errorf("Validate() = %v", errs)
+	}
+
+	crd.Spec.Ports["E1/8"] = CRDPort{Label: "8", Profile: "OSFP-800G"}
+	if _, err := FromCRD(crd, ""); err == nil || !strings.Contains(err.Error(), "undefined port profile OSFP-800G") {
+		t.Errorf("FromCRD() error = %v, want undefined port profile", err)
+	}
+}
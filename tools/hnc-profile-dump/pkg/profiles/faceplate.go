@@ -0,0 +1,51 @@
+package profiles
+
+import (
+	"fmt"
+
+	"github.com/hnc/profile-dump/pkg/ports"
+)
+
+// Faceplate is the physical layout of the front panel, left to right, so
+// commissioning sheets can say where on the switch each port sits
+type Faceplate struct {
+	Blocks []PortBlock `json:"blocks" doc:"Cage blocks, left to right"`
+}
+
+// PortBlock is a group of same-sized cages filled column by column: with
+// 2 rows, E1/1 is top left, E1/2 below it and E1/3 top of the next column
+type PortBlock struct {
+	Ports []string `json:"ports" doc:"Port ranges in the block, e.g. E1/1-48"`
+	Rows  int      `json:"rows" doc:"Cage rows; ports fill each column top to bottom"`
+}
+
+// Position is where a port sits on the faceplate, counted from 1 at the
+// top left; columns run on across blocks
+type Position struct {
+	Block  int `json:"block"`
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+// Positions locates every port the faceplate lists
+func (f Faceplate) Positions() (map[string]Position, error) {
+	positions := map[string]Position{}
+	column := 0
+	for b, block := range f.Blocks {
+		if block.Rows < 1 {
+			return nil, fmt.Errorf("faceplate.blocks[%d].rows must be at least 1", b)
+		}
+		names, err := ports.Expand(block.Ports)
+		if err != nil {
+			return nil, fmt.Errorf("faceplate.blocks[%d]: %w", b, err)
+		}
+		for i, name := range names {
+			if _, ok := positions[name]; ok {
+				return nil, fmt.Errorf("faceplate.blocks[%d]: port %s is placed twice", b, name)
+			}
+			positions[name] = Position{Block: b + 1, Row: i%block.Rows + 1, Column: column + i/block.Rows + 1}
+		}
+		column += (len(names) + block.Rows - 1) / block.Rows
+	}
+	return positions, nil
+}
@@ -0,0 +1,45 @@
+package profiles
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFaceplatePositions(t *testing.T) {
+	positions, err := DS2000().Faceplate.Positions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 56 {
+		t.Fatalf("placed %d ports, want 56", len(positions))
+	}
+	for port, want := range map[string]Position{
+		"E1/1":  {Block: 1, Row: 1, Column: 1},
+		"E1/2":  {Block: 1, Row: 2, Column: 1},
+		"E1/47": {Block: 1, Row: 1, Column: 24},
+		"E1/49": {Block: 2, Row: 1, Column: 25},
+		"E1/56": {Block: 2, Row: 2, Column: 28},
+	} {
+		if got := positions[port]; got != want {
+			t.Errorf("%s at %+v, want %+v", port, got, want)
+		}
+	}
+}
+
+func TestValidateFaceplate(t *testing.T) {
+	cases := map[string]struct {
+		blocks []PortBlock
+		want   string
+	}{
+		"no rows":      {[]PortBlock{{Ports: []string{"E1/1-56"}}}, "rows must be at least 1"},
+		"placed twice": {[]PortBlock{{Ports: []string{"E1/1-48"}, Rows: 2}, {Ports: []string{"E1/48-56"}, Rows: 2}}, "E1/48 is placed twice"},
+		"unknown port": {[]PortBlock{{Ports: []string{"E1/1-58"}, Rows: 2}}, "ports E1/57, E1/58 that are not"},
+	}
+	for name, tc := range cases {
+		p := DS2000()
+		p.Faceplate = &Faceplate{Blocks: tc.blocks}
+		if errs := Validate(p); !strings.Contains(strings.Join(errs, "; "), tc.want) {
+			t.Errorf("%s: Validate() = %v, want %q", name, errs, tc.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package profiles
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// A model family defines switch models that differ only in parameters,
+// such as port counts and speeds, once. Its file, named *.family.yaml,
+// holds a header document listing the models with their parameters, a
+// --- line, then the family's profile as a text/template that each
+// model's parameters fill in:
+//
+//	family: celestica-400g-800g-spines
+//	models:
+//	  - modelId: celestica-ds4000
+//	    ports: 32
+//	    speedGbps: 400
+//	---
+//	modelId: {{.modelId}}
+//	ports:
+//	  fabricAssignable: ['E1/1-{{.ports}}']
+//	...
+//
+// Templates may call add, sub, mul and div on integer parameters, e.g.
+// {{div .speedGbps 4}}, and range over list, e.g. {{range list 2 4 8}};
+// a parameter a model does not set is an error.
+
+// FamilySuffix ends the name of a model family definition
+const FamilySuffix = ".family.yaml"
+
+// IsFamily reports whether a file holds a model family rather than one
+// profile
+func IsFamily(name string) bool {
+	name = strings.ToLower(filepath.Base(name))
+	return strings.HasSuffix(name, FamilySuffix) || strings.HasSuffix(name, ".family.yml")
+}
+
+var familyFuncs = template.FuncMap{
+	"add": func(a, b int64) int64 { return a + b },
+	"sub": func(a, b int64) int64 { return a - b },
+	"mul": func(a, b int64) int64 { return a * b },
+	"div": func(a, b int64) (int64, error) {
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	},
+	"list": func(values ...int64) []int64 { return values },
+}
+
+// ParseFamily instantiates every model of a family definition, in header
+// order, without Validate
+func ParseFamily(data []byte) ([]SwitchProfile, error) {
+	src := strings.ReplaceAll(string(data), "\r\n", "\n")
+	header, body, ok := strings.Cut(src, "\n---\n")
+	if !ok {
+		return nil, fmt.Errorf("model family needs a header, a --- line and a profile template")
+	}
+	value, err := parseYAML(header)
+	if err != nil {
+		return nil, fmt.Errorf("family header: %w", err)
+	}
+	fields, _ := value.(map[string]any)
+	name, _ := fields["family"].(string)
+	models, _ := fields["models"].([]any)
+	if name == "" || len(models) == 0 || len(fields) != 2 {
+		return nil, fmt.Errorf("family header needs a family name and a list of models, and nothing else")
+	}
+	tmpl, err := template.New(name).Funcs(familyFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("family %s: %w", name, err)
+	}
+
+	var ps []SwitchProfile
+	for i, m := range models {
+		params, _ := m.(map[string]any)
+		id, _ := params["modelId"].(string)
+		if id == "" {
+			return nil, fmt.Errorf("family %s: model %d has no modelId", name, i+1)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, params); err != nil {
+			return nil, fmt.Errorf("family %s: %s: %w", name, id, err)
+		}
+		p, err := Parse(out.Bytes(), ".yaml")
+		if err != nil {
+			return nil, fmt.Errorf("family %s: %s: %w", name, id, err)
+		}
+		if p.ModelID != id {
+			return nil, fmt.Errorf("family %s: model %s instantiates modelId %q", name, id, p.ModelID)
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// DecodeFamily is ParseFamily, then Validate on every model
+func DecodeFamily(data []byte) ([]SwitchProfile, error) {
+	ps, err := ParseFamily(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range ps {
+		if errs := Validate(p); len(errs) > 0 {
+			return nil, fmt.Errorf("%s: %s", p.ModelID, strings.Join(errs, "; "))
+		}
+	}
+	return ps, nil
+}
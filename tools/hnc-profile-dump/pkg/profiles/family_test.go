@@ -0,0 +1,98 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const spineFamily = `family: test-spines
+models:
+  - modelId: test-spine32
+    ports: 32
+    speedGbps: 400
+  - modelId: test-spine64
+    ports: 64
+    speedGbps: 800
+---
+modelId: {{.modelId}}
+roles: [spine]
+ports:
+  endpointAssignable: []
+  fabricAssignable: ['E1/1-{{.ports}}', 'E1/{{add .ports 1}}']
+profiles:
+  endpoint:
+    portProfile: null
+    speedGbps: 0
+  uplink:
+    portProfile: QSFP-DD-{{.speedGbps}}G
+    speedGbps: {{.speedGbps}}
+    breakouts:
+{{- range $lanes := list 2 4}}
+      - mode: {{$lanes}}x{{div $.speedGbps $lanes}}G
+        lanes: {{$lanes}}
+        speedGbps: {{div $.speedGbps $lanes}}
+        portPattern: '{port}/{lane}'
+{{- end}}
+meta:
+  source: family_test.go
+`
+
+func TestParseFamily(t *testing.T) {
+	ps, err := DecodeFamily([]byte(spineFamily))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 2 || ps[0].ModelID != "test-spine32" || ps[1].ModelID != "test-spine64" {
+		t.Fatalf("DecodeFamily() = %+v", ps)
+	}
+	uplink := ps[1].Profiles.Uplink
+	if uplink.SpeedGbps != 800 || *uplink.PortProfile != "QSFP-DD-800G" || len(uplink.Breakouts) != 2 || uplink.Breakouts[1].Mode != "4x200G" || uplink.Breakouts[1].SpeedGbps != 200 {
+		t.Errorf("test-spine64 uplinks = %+v", uplink)
+	}
+	if want := []string{"E1/1-32", "E1/33"}; !reflect.DeepEqual(ps[0].Ports.FabricAssignable, want) {
+		t.Errorf("test-spine32 fabric ports = %v, want %v", ps[0].Ports.FabricAssignable, want)
+	}
+
+	for doc, want := range map[string]string{
+		strings.Replace(spineFamily, "    speedGbps: 800\n", "", 1):            `test-spine64: template: test-spines`,
+		strings.Replace(spineFamily, "modelId: {{.modelId}}", "modelId: x", 1): `model test-spine32 instantiates modelId "x"`,
+		strings.Replace(spineFamily, "\n---\n", "\n", 1):                       "needs a header",
+		"family: x\nmodels: []\n---\nmodelId: x\n":                             "needs a family name and a list of models",
+	} {
+		if _, err := ParseFamily([]byte(doc)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseFamily() error = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestLoadDirFamily(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "spines"+FamilySuffix), []byte(spineFamily), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ds2000.json"), mustMarshal(t, DS2000()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(r.List()); got != 3 {
+		t.Fatalf("LoadDir() has %d profiles, want 3", got)
+	}
+	if _, ok := r.Get("test-spine64"); !ok {
+		t.Error("LoadDir() did not instantiate test-spine64")
+	}
+}
+
+func mustMarshal(t *testing.T, p SwitchProfile) []byte {
+	t.Helper()
+	data, err := Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
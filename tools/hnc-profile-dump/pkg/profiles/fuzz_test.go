@@ -0,0 +1,60 @@
+package profiles
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Seeds beyond testdata/fuzz, which hnc-fuzz-corpus fills from the golden
+// profile fixtures
+var yamlSeeds = []string{
+	"modelId: acme-x1\nroles: [leaf]\nports:\n  endpointAssignable: [E1/1-48]\n  fabricAssignable:\n    - E1/49-52\n",
+	"- a: 1\n  b: [x, 'y', \"z\"]\n-\n  - ~\n",
+	"\"quoted key\": {}\nkey: # comment\n",
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, p := range Default().List() {
+		data, _ := json.Marshal(p)
+		f.Add(data, false)
+	}
+	for _, s := range yamlSeeds {
+		f.Add([]byte(s), true)
+	}
+	f.Fuzz(func(t *testing.T, data []byte, isYAML bool) {
+		ext := ".json"
+		if isYAML {
+			ext = ".yaml"
+		}
+		p, err := Decode(data, ext)
+		if err != nil {
+			return
+		}
+		// Anything accepted must be valid and survive a JSON round trip
+		if errs := Validate(p); len(errs) > 0 {
+			t.Fatalf("Decode accepted an invalid profile: %v", errs)
+		}
+		out, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decode(out, ".json"); err != nil {
+			t.Fatalf("decoded profile does not round-trip: %v\n%s", err, out)
+		}
+	})
+}
+
+func FuzzParseYAML(f *testing.F) {
+	for _, s := range yamlSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		value, err := parseYAML(src)
+		if err != nil {
+			return
+		}
+		if _, err := json.Marshal(value); err != nil {
+			t.Fatalf("parsed value is not JSON-encodable: %v", err)
+		}
+	})
+}
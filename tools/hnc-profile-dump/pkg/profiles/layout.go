@@ -0,0 +1,159 @@
+package profiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/manifest"
+)
+
+// Layouts of a fixtures directory: flat holds every profile's file at the
+// top, tree partitions them as <vendor>/<role>/<file> and lists them in an
+// index.json at the top, for consumers that would rather not glob
+const (
+	LayoutFlat = "flat"
+	LayoutTree = "tree"
+)
+
+// Layouts are the layouts Partition writes
+var Layouts = []string{LayoutFlat, LayoutTree}
+
+// IndexFile is the index's name at the top of a tree layout directory
+const IndexFile = "index.json"
+
+// Index is the content of a tree layout's index.json
+type Index struct {
+	Layout   string       `json:"layout"`
+	Profiles []IndexEntry `json:"profiles"` // in model ID order
+}
+
+// IndexEntry is one profile of a tree layout directory
+type IndexEntry struct {
+	ModelID string `json:"modelId"`
+	Vendor  string `json:"vendor"`
+	Role    string `json:"role"`
+	Path    string `json:"path"` // slash-separated, relative to the index
+}
+
+// Vendor is the vendor part of a model ID, e.g. celestica for
+// celestica-ds2000, or other for an ID without one
+func Vendor(modelID string) string {
+	if vendor, _, ok := strings.Cut(modelID, "-"); ok && vendor != "" {
+		return vendor
+	}
+	return "other"
+}
+
+// Partition lays out files rendered from ps, one per profile in the same
+// order, as a fixtures directory of layout: unchanged for flat; for tree,
+// each under its profile's vendor and first role, followed by the index
+func Partition(layout string, ps []SwitchProfile, files []File) ([]File, error) {
+	switch layout {
+	case LayoutFlat:
+		return files, nil
+	case LayoutTree:
+	default:
+		return nil, fmt.Errorf("unknown layout %q (want %s)", layout, strings.Join(Layouts, " or "))
+	}
+	if len(ps) != len(files) {
+		return nil, fmt.Errorf("%d file(s) for %d profile(s)", len(files), len(ps))
+	}
+	index := Index{Layout: LayoutTree, Profiles: []IndexEntry{}}
+	out := make([]File, 0, len(files)+1)
+	for i, p := range ps {
+		role := "none"
+		if len(p.Roles) > 0 {
+			role = p.Roles[0]
+		}
+		e := IndexEntry{ModelID: p.ModelID, Vendor: Vendor(p.ModelID), Role: role}
+		e.Path = path.Join(e.Vendor, e.Role, files[i].Name)
+		index.Profiles = append(index.Profiles, e)
+		out = append(out, File{Name: e.Path, Data: files[i].Data})
+	}
+	sort.Slice(index.Profiles, func(i, j int) bool { return index.Profiles[i].ModelID < index.Profiles[j].ModelID })
+	data, err := canonjson.Marshal(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal index: %w", err)
+	}
+	return append(out, File{Name: IndexFile, Data: data}), nil
+}
+
+// ReadIndex loads a tree layout directory's index; a flat directory has
+// none, and the error wraps fs.ErrNotExist
+func ReadIndex(dir string) (Index, error) {
+	var index Index
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil {
+		return index, err
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return index, fmt.Errorf("parsing %s: %w", filepath.Join(dir, IndexFile), err)
+	}
+	for _, e := range index.Profiles {
+		if !filepath.IsLocal(filepath.FromSlash(e.Path)) {
+			return index, fmt.Errorf("%s: %s is outside %s", filepath.Join(dir, IndexFile), e.Path, dir)
+		}
+	}
+	return index, nil
+}
+
+// DirLayout is the layout of a fixtures directory: tree when it has an
+// index, else flat, which is also what a directory not yet written gets
+func DirLayout(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, IndexFile)); err == nil {
+		return LayoutTree
+	}
+	return LayoutFlat
+}
+
+// FixtureFiles are the profile files of a fixtures directory in either
+// layout, as paths under dir, whose base names match any of patterns (all
+// of them for none): those the index lists, in path order, or the files at
+// the top of a flat directory, in name order, leaving out its manifest
+func FixtureFiles(dir string, patterns ...string) ([]string, error) {
+	var names []string
+	index, err := ReadIndex(dir)
+	switch {
+	case err == nil:
+		for _, e := range index.Profiles {
+			names = append(names, filepath.FromSlash(e.Path))
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	default:
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && e.Name() != manifest.File {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+	var files []string
+	for _, name := range names {
+		if matchAny(filepath.Base(name), patterns) {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	return files, nil
+}
+
+func matchAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return len(patterns) == 0
+}
@@ -0,0 +1,87 @@
+package profiles
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Lifecycle statuses: an active model is sold and supported, a deprecated
+// one is still sold but should not go into new designs, and an
+// end-of-sale one can no longer be bought
+const (
+	LifecycleActive     = "active"
+	LifecycleDeprecated = "deprecated"
+	LifecycleEndOfSale  = "end-of-sale"
+)
+
+// LifecycleStatuses are every lifecycle status
+var LifecycleStatuses = []string{LifecycleActive, LifecycleDeprecated, LifecycleEndOfSale}
+
+// DateLayout is how lifecycle dates are written
+const DateLayout = "2006-01-02"
+
+// Lifecycle is where the model is in its vendor's product life. A profile
+// without one is taken as active.
+type Lifecycle struct {
+	Status      string `json:"status" doc:"active, deprecated (still sold, kept out of new designs) or end-of-sale"`
+	Replacement string `json:"replacement,omitempty" doc:"Model ID to design with instead, e.g. celestica-ds3000"`
+	EndOfLife   string `json:"endOfLife,omitempty" doc:"Date support ends, as YYYY-MM-DD"`
+}
+
+// Status is the profile's lifecycle status, active when it has none
+func (p SwitchProfile) Status() string {
+	if p.Lifecycle == nil || p.Lifecycle.Status == "" {
+		return LifecycleActive
+	}
+	return p.Lifecycle.Status
+}
+
+// Retired reports whether the model should be kept out of new designs:
+// deprecated or end-of-sale
+func (p SwitchProfile) Retired() bool {
+	return p.Status() != LifecycleActive
+}
+
+// Retirement describes a retired model for a warning, e.g. "celestica-ds1000
+// is end-of-sale, end of life 2027-06-30; use celestica-ds2000 instead", or
+// is empty for an active one
+func (p SwitchProfile) Retirement() string {
+	if !p.Retired() {
+		return ""
+	}
+	msg := p.ModelID + " is " + p.Status()
+	if l := p.Lifecycle; l.EndOfLife != "" {
+		msg += ", end of life " + l.EndOfLife
+	}
+	if l := p.Lifecycle; l.Replacement != "" {
+		msg += "; use " + l.Replacement + " instead"
+	}
+	return msg
+}
+
+// validateLifecycle checks the status is a known one, the end-of-life date
+// parses and the model does not replace itself
+func validateLifecycle(p SwitchProfile) []string {
+	l := p.Lifecycle
+	if l == nil {
+		return nil
+	}
+	var errs []string
+	known := false
+	for _, s := range LifecycleStatuses {
+		known = known || l.Status == s
+	}
+	if !known {
+		errs = append(errs, fmt.Sprintf("lifecycle.status %q is not one of %s", l.Status, strings.Join(LifecycleStatuses, ", ")))
+	}
+	if l.EndOfLife != "" {
+		if _, err := time.Parse(DateLayout, l.EndOfLife); err != nil {
+			errs = append(errs, fmt.Sprintf("lifecycle.endOfLife %q is not a YYYY-MM-DD date", l.EndOfLife))
+		}
+	}
+	if l.Replacement != "" && l.Replacement == p.ModelID {
+		errs = append(errs, "lifecycle.replacement names the model itself")
+	}
+	return errs
+}
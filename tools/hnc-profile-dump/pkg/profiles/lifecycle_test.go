@@ -0,0 +1,27 @@
+package profiles
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLifecycle(t *testing.T) {
+	p := DS2000()
+	if p.Retired() || p.Retirement() != "" {
+		t.Errorf("profile without a lifecycle is retired: %q", p.Retirement())
+	}
+	p.Lifecycle = &Lifecycle{Status: LifecycleDeprecated, Replacement: "celestica-ds3000", EndOfLife: "2027-06-30"}
+	if errs := Validate(p); len(errs) > 0 {
+		t.Fatalf("Validate() = %v", errs)
+	}
+	if want := "celestica-ds2000 is deprecated, end of life 2027-06-30; use celestica-ds3000 instead"; !p.Retired() || p.Retirement() != want {
+		t.Errorf("Retirement() = %q, want %q", p.Retirement(), want)
+	}
+	p.Lifecycle = &Lifecycle{Status: "retired", Replacement: p.ModelID, EndOfLife: "30/06/2027"}
+	errs := strings.Join(Validate(p), "; ")
+	for _, want := range []string{`lifecycle.status "retired" is not one of active, deprecated, end-of-sale`, `lifecycle.endOfLife "30/06/2027" is not a YYYY-MM-DD date`, "lifecycle.replacement names the model itself"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("Validate() = %s, want %q", errs, want)
+		}
+	}
+}
@@ -0,0 +1,632 @@
+package profiles
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/batch"
+	"github.com/hnc/profile-dump/pkg/ports"
+)
+
+// LoadDir reads every .json, .yaml and .yml profile definition in dir, in
+// file name order, into a new registry, with every model of each model
+// family file (see IsFamily); a manifest.json is not a profile. A tree
+// layout directory (see Partition) is read as its index lists it.
+func LoadDir(dir string) (*Registry, error) {
+	return LoadDirJobs(dir, 0)
+}
+
+// LoadDirJobs is LoadDir parsing jobs files at a time, one per CPU for 0.
+// The error names every file that does not load, in file name order.
+func LoadDirJobs(dir string, jobs int) (*Registry, error) {
+	return LoadDirContext(context.Background(), dir, jobs)
+}
+
+// LoadDirContext is LoadDirJobs that stops parsing once ctx is done,
+// reporting each file parsed as a step of the files stage
+func LoadDirContext(ctx context.Context, dir string, jobs int) (*Registry, error) {
+	files, err := FixtureFiles(dir, "*.json", "*.yaml", "*.yml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input directory: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .json, .yaml or .yml profiles in %s", dir)
+	}
+
+	loaded, err := batch.MapContext(ctx, "files", jobs, files, func(file string) ([]SwitchProfile, error) {
+		if IsFamily(file) {
+			return LoadFamilyFile(file)
+		}
+		p, err := LoadFile(file)
+		return []SwitchProfile{p}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	r, _ := NewRegistry()
+	for i, ps := range loaded {
+		for _, p := range ps {
+			if err := r.Register(p); err != nil {
+				rel, _ := filepath.Rel(dir, files[i])
+				return nil, fmt.Errorf("%s: %w", rel, err)
+			}
+		}
+	}
+	return r, nil
+}
+
+// Load reads profiles from a directory, or from stdin when path is "-"
+func Load(path string, stdin io.Reader) (*Registry, error) {
+	return LoadJobs(path, stdin, 0)
+}
+
+// LoadJobs is Load parsing a directory's files jobs at a time
+func LoadJobs(path string, stdin io.Reader, jobs int) (*Registry, error) {
+	if path == "-" {
+		return ReadStream(stdin)
+	}
+	return LoadDirJobs(path, jobs)
+}
+
+// ReadStream reads profiles as a JSON array or as NDJSON (one profile per
+// line, or any sequence of JSON objects), as written by -output -, and
+// validates each as Decode does
+func ReadStream(rd io.Reader) (*Registry, error) {
+	ps, err := ParseStream(rd)
+	if err != nil {
+		return nil, err
+	}
+	r, _ := NewRegistry()
+	for i, p := range ps {
+		if errs := Validate(p); len(errs) > 0 {
+			return nil, fmt.Errorf("profile %d in stream: %s", i+1, strings.Join(errs, "; "))
+		}
+		if err := r.Register(p); err != nil {
+			return nil, fmt.Errorf("profile %d in stream: %w", i+1, err)
+		}
+	}
+	return r, nil
+}
+
+// ParseStream is ReadStream without Validate, returning the profiles in
+// stream order
+func ParseStream(rd io.Reader) ([]SwitchProfile, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	var raws []json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, fmt.Errorf("profile stream: %w", err)
+		}
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("profile stream: %w", err)
+			}
+			raws = append(raws, raw)
+		}
+	}
+	if len(raws) == 0 {
+		return nil, fmt.Errorf("no profiles in stream")
+	}
+
+	var ps []SwitchProfile
+	for i, raw := range raws {
+		p, err := Parse(raw, ".json")
+		if err != nil {
+			return nil, fmt.Errorf("profile %d in stream: %w", i+1, err)
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// LoadFile decodes, validates and normalizes one profile definition
+func LoadFile(path string) (SwitchProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SwitchProfile{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	p, err := Decode(data, filepath.Ext(path))
+	if err != nil {
+		return SwitchProfile{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// LoadFamilyFile instantiates and validates every model of a model
+// family definition
+func LoadFamilyFile(path string) ([]SwitchProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	ps, err := DecodeFamily(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ps, nil
+}
+
+// Decode parses a profile definition as YAML when ext is .yaml or .yml and
+// as JSON otherwise, then normalizes and validates it. Malformed input is
+// always an error, never a panic.
+func Decode(data []byte, ext string) (SwitchProfile, error) {
+	p, err := Parse(data, ext)
+	if err != nil {
+		return SwitchProfile{}, err
+	}
+	if errs := Validate(p); len(errs) > 0 {
+		return SwitchProfile{}, errors.New(strings.Join(errs, "; "))
+	}
+	return p, nil
+}
+
+// Parse is Decode without Validate, for tools such as lint that report a
+// profile's problems themselves
+func Parse(data []byte, ext string) (SwitchProfile, error) {
+	if ext := strings.ToLower(ext); ext == ".yaml" || ext == ".yml" {
+		value, err := parseYAML(string(data))
+		if err != nil {
+			return SwitchProfile{}, err
+		}
+		if data, err = json.Marshal(value); err != nil {
+			return SwitchProfile{}, err
+		}
+	}
+
+	// Check the schema first, so an old layout is reported as such rather
+	// than as unknown fields
+	var header struct {
+		Meta struct {
+			Version string `json:"version"`
+		} `json:"meta"`
+	}
+	if json.Unmarshal(data, &header) == nil {
+		if err := checkReadable(header.Meta.Version); err != nil {
+			return SwitchProfile{}, err
+		}
+	}
+
+	var p SwitchProfile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return SwitchProfile{}, err
+	}
+	return Normalize(p), nil
+}
+
+// Normalize trims names, drops duplicate roles and ports, replaces nil
+// lists with empty ones so fixtures always carry [] rather than null, and
+// stamps SchemaVersion on definitions that leave the version out
+func Normalize(p SwitchProfile) SwitchProfile {
+	p.ModelID = strings.TrimSpace(p.ModelID)
+	if p.Meta.Version == "" {
+		p.Meta.Version = SchemaVersion
+	}
+	p.Roles = unique(p.Roles)
+	p.Ports.EndpointAssignable = unique(p.Ports.EndpointAssignable)
+	p.Ports.FabricAssignable = unique(p.Ports.FabricAssignable)
+	p.Profiles.RoleProfiles = normalizeRole(p.Profiles.RoleProfiles)
+	roles := p.Profiles.Roles
+	p.Profiles.Roles = nil
+	for role, rp := range roles {
+		if p.Profiles.Roles == nil {
+			p.Profiles.Roles = map[string]RoleProfiles{}
+		}
+		p.Profiles.Roles[strings.TrimSpace(role)] = normalizeRole(rp)
+	}
+	return p
+}
+
+func normalizeRole(rp RoleProfiles) RoleProfiles {
+	for _, pp := range []*PortProfile{&rp.Endpoint, &rp.Uplink} {
+		if pp.PortProfile != nil && strings.TrimSpace(*pp.PortProfile) == "" {
+			pp.PortProfile = nil
+		}
+	}
+	return rp
+}
+
+// Validate reports every problem with a profile; nil means it is usable
+func Validate(p SwitchProfile) []string {
+	var errs []string
+	if p.ModelID == "" {
+		errs = append(errs, "modelId is required")
+	}
+	if len(p.Roles) == 0 {
+		errs = append(errs, "at least one role is required")
+	}
+	if _, err := ports.LookupScheme(p.NamingScheme); err != nil {
+		errs = append(errs, "namingScheme: "+err.Error())
+	}
+	valid := true
+	for _, expr := range append(append([]string{}, p.Ports.EndpointAssignable...), p.Ports.FabricAssignable...) {
+		if _, err := ports.ExpandRange(expr); err != nil {
+			errs = append(errs, err.Error())
+			valid = false
+		}
+	}
+	if valid {
+		if both, _ := ports.Overlap(p.Ports.EndpointAssignable, p.Ports.FabricAssignable); len(both) > 0 {
+			errs = append(errs, fmt.Sprintf("ports %s are both endpoint and fabric assignable", strings.Join(both, ", ")))
+		}
+	}
+	if valid && p.Faceplate != nil {
+		errs = append(errs, validateFaceplate(p)...)
+	}
+	errs = append(errs, validatePlanningData(p)...)
+	errs = append(errs, validateCapabilities(p)...)
+	errs = append(errs, validateLifecycle(p)...)
+	if len(p.Ports.FabricAssignable) == 0 {
+		errs = append(errs, "ports.fabricAssignable must list at least one port")
+	}
+	for _, e := range p.RoleEntries() {
+		if e.Field != "profiles" && (!slices.Contains(p.Roles, e.Role) || e.Role == p.Roles[0]) {
+			errs = append(errs, fmt.Sprintf("%s: %s is not one of roles after the first, whose profiles are inline", e.Field, e.Role))
+			continue
+		}
+		errs = append(errs, validateRole(e.Field, len(p.Ports.EndpointAssignable) > 0, e.RoleProfiles)...)
+	}
+	return errs
+}
+
+// validateRole checks the speeds and breakouts of one role's port profiles
+func validateRole(field string, endpointPorts bool, rp RoleProfiles) []string {
+	var errs []string
+	if endpointPorts && rp.Endpoint.SpeedGbps <= 0 {
+		errs = append(errs, field+".endpoint.speedGbps must be positive when endpoint ports are assignable")
+	}
+	if rp.Uplink.SpeedGbps <= 0 {
+		errs = append(errs, field+".uplink.speedGbps must be positive")
+	}
+	if rp.Endpoint.SpeedGbps < 0 {
+		errs = append(errs, field+".endpoint.speedGbps must not be negative")
+	}
+	for _, side := range []struct {
+		name    string
+		profile PortProfile
+	}{{"endpoint", rp.Endpoint}, {"uplink", rp.Uplink}} {
+		errs = append(errs, validateBreakouts(field+"."+side.name, side.profile)...)
+	}
+	return errs
+}
+
+// validateFaceplate checks that the layout places only the profile's own
+// ports, each once; ports it leaves out simply have no position
+func validateFaceplate(p SwitchProfile) []string {
+	positions, err := p.Faceplate.Positions()
+	if err != nil {
+		return []string{err.Error()}
+	}
+	names, _ := ports.Expand(append(append([]string{}, p.Ports.EndpointAssignable...), p.Ports.FabricAssignable...))
+	known := map[string]bool{}
+	for _, name := range names {
+		known[name] = true
+	}
+	var unknown []string
+	for name := range positions {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Slice(unknown, func(i, j int) bool { return portLess(unknown[i], unknown[j]) })
+	return []string{fmt.Sprintf("faceplate places ports %s that are not endpoint or fabric assignable", strings.Join(unknown, ", "))}
+}
+
+// validateBreakouts checks that every mode is named once, splits into at
+// least two ports that fit the parent speed, and names each port uniquely
+func validateBreakouts(field string, pp PortProfile) []string {
+	var errs []string
+	seen := map[string]bool{}
+	for i, b := range pp.Breakouts {
+		at := fmt.Sprintf("%s.breakouts[%d]", field, i)
+		switch {
+		case b.Mode == "":
+			errs = append(errs, at+".mode is required")
+		case seen[strings.ToLower(b.Mode)]:
+			errs = append(errs, fmt.Sprintf("%s: duplicate breakout mode %q", at, b.Mode))
+		}
+		seen[strings.ToLower(b.Mode)] = true
+		if b.Lanes < 2 || b.SpeedGbps <= 0 {
+			errs = append(errs, at+" must split into at least 2 lanes of positive speed")
+		} else if b.Lanes*b.SpeedGbps > pp.SpeedGbps {
+			errs = append(errs, fmt.Sprintf("%s: %d x %dG exceeds the %dG port speed", at, b.Lanes, b.SpeedGbps, pp.SpeedGbps))
+		}
+		if !strings.Contains(b.PortPattern, "{port}") || !strings.Contains(b.PortPattern, "{lane}") {
+			errs = append(errs, at+".portPattern must contain {port} and {lane}")
+		}
+	}
+	return errs
+}
+
+func unique(values []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// ParseYAMLDocuments decodes a stream of YAML documents separated by ---
+// lines, such as a Hedgehog wiring.yaml, with the subset profile
+// definitions use. Documents holding only comments are skipped.
+func ParseYAMLDocuments(data []byte) ([]any, error) {
+	var docs []any
+	var doc []string
+	first, n := 1, 0
+	flush := func() error {
+		lines := doc
+		doc = nil
+		empty := true
+		for _, line := range lines {
+			empty = empty && strings.TrimSpace(stripComment(line)) == ""
+		}
+		if empty {
+			return nil
+		}
+		n++
+		value, err := parseYAML(strings.Join(lines, "\n"))
+		if err != nil {
+			return fmt.Errorf("document %d (line %d): %w", n, first, err)
+		}
+		docs = append(docs, value)
+		return nil
+	}
+	for i, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if strings.TrimRight(line, " \t") == "---" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			first = i + 2
+			continue
+		}
+		doc = append(doc, line)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// yamlLine is one significant line of a YAML document
+type yamlLine struct {
+	indent int
+	text   string
+	num    int
+}
+
+// parseYAML decodes the YAML subset profile definitions use: block
+// mappings and sequences, flow sequences of scalars, quoted and plain
+// scalars, null and comments. Anchors, multi-line strings and flow
+// mappings other than {} are rejected.
+func parseYAML(src string) (any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		text := strings.TrimRight(stripComment(raw), " \t")
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(text, " "), "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{indent: len(text) - len(strings.TrimLeft(text, " ")), text: trimmed, num: i + 1})
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty document")
+	}
+	value, next, err := parseBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].num)
+	}
+	return value, nil
+}
+
+func parseBlock(lines []yamlLine, i, indent int) (any, int, error) {
+	if isSequenceItem(lines[i].text) {
+		return parseSequence(lines, i, indent)
+	}
+	return parseMapping(lines, i, indent)
+}
+
+func parseSequence(lines []yamlLine, i, indent int) (any, int, error) {
+	items := []any{}
+	for i < len(lines) && lines[i].indent == indent && isSequenceItem(lines[i].text) {
+		rest := strings.TrimSpace(strings.TrimPrefix(lines[i].text, "-"))
+		switch {
+		case rest == "":
+			if i+1 >= len(lines) || lines[i+1].indent <= indent {
+				items = append(items, nil)
+				i++
+				continue
+			}
+			value, next, err := parseBlock(lines, i+1, lines[i+1].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			items, i = append(items, value), next
+		case mappingKey(rest) != "":
+			// "- key: value" opens a mapping indented past the dash
+			inner := indent + len(lines[i].text) - len(rest)
+			nested := append([]yamlLine{{indent: inner, text: rest, num: lines[i].num}}, lines[i+1:]...)
+			value, next, err := parseMapping(nested, 0, inner)
+			if err != nil {
+				return nil, 0, err
+			}
+			items, i = append(items, value), i+next
+		default:
+			value, err := parseScalar(rest, lines[i].num)
+			if err != nil {
+				return nil, 0, err
+			}
+			items, i = append(items, value), i+1
+		}
+	}
+	return items, i, nil
+}
+
+func parseMapping(lines []yamlLine, i, indent int) (any, int, error) {
+	m := map[string]any{}
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		key := mappingKey(line.text)
+		if key == "" {
+			return nil, 0, fmt.Errorf("line %d: expected 'key: value'", line.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, 0, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		rest := strings.TrimSpace(line.text[len(rawKey(line.text))+1:])
+		i++
+		switch {
+		case rest != "":
+			value, err := parseScalar(rest, line.num)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = value
+		case i < len(lines) && (lines[i].indent > indent || (lines[i].indent == indent && isSequenceItem(lines[i].text))):
+			value, next, err := parseBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key], i = value, next
+		default:
+			m[key] = nil
+		}
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("line %d: unexpected indentation", lines[i].num)
+	}
+	return m, i, nil
+}
+
+func parseScalar(text string, num int) (any, error) {
+	switch {
+	case text == "{}":
+		return map[string]any{}, nil
+	case strings.HasPrefix(text, "{"), strings.HasPrefix(text, "&"), strings.HasPrefix(text, "*"),
+		strings.HasPrefix(text, "|"), strings.HasPrefix(text, ">"):
+		return nil, fmt.Errorf("line %d: unsupported YAML syntax %q", num, text)
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("line %d: unterminated flow sequence", num)
+		}
+		items := []any{}
+		body := strings.TrimSpace(text[1 : len(text)-1])
+		if body == "" {
+			return items, nil
+		}
+		for _, part := range strings.Split(body, ",") {
+			value, err := parseScalar(strings.TrimSpace(part), num)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	case strings.HasPrefix(text, `"`):
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad quoted string %s", num, text)
+		}
+		return s, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("line %d: bad quoted string %s", num, text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case text == "null" || text == "~":
+		return nil, nil
+	case text == "true" || text == "false":
+		return text == "true", nil
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, nil
+	}
+	return text, nil
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// rawKey is the text before the first ": " (or a trailing ":"), quotes included
+func rawKey(text string) string {
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+		if end := strings.Index(text[1:], text[:1]); end != -1 && strings.HasPrefix(text[end+2:], ":") {
+			return text[:end+2]
+		}
+		return ""
+	}
+	if i := strings.Index(text, ": "); i != -1 {
+		return text[:i]
+	}
+	if strings.HasSuffix(text, ":") {
+		return text[:len(text)-1]
+	}
+	return ""
+}
+
+func mappingKey(text string) string {
+	key := rawKey(text)
+	if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') {
+		return key[1 : len(key)-1]
+	}
+	if strings.ContainsAny(key, "[]{}") {
+		return "" // a flow value, not a key
+	}
+	return key
+}
+
+// stripComment drops a '#' comment that is outside quotes and starts the
+// line or follows whitespace
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
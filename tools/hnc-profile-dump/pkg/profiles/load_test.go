@@ -0,0 +1,226 @@
+package profiles
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ds2000YAML = `# Celestica DS2000 leaf
+modelId: celestica-ds2000
+roles: [leaf]
+ports:
+  endpointAssignable:
+    - E1/1-48
+  fabricAssignable: ["E1/49-56"]
+profiles:
+  endpoint:
+    portProfile: SFP28-25G
+    speedGbps: 25
+  uplink: {}
+meta:
+  source: switch_profile.go
+  version: v0.4.0
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadDirMatchesBuiltIns(t *testing.T) {
+	uplink := strings.Replace(ds2000YAML, "  uplink: {}", "  uplink:\n    portProfile: 'QSFP28-100G'\n    speedGbps: 100\n"+
+		"    breakouts:\n      - mode: 4x25G\n        lanes: 4\n        speedGbps: 25\n        portPattern: '{port}/{lane}'\n"+
+		"  breakout:\n    supportsBreakout: true\n    breakoutType: 4x25G\n    capacityMultiplier: 4", 1)
+	dir := writeFiles(t, map[string]string{
+		"ds2000.yaml": uplink,
+		"ds3000.json": `{"modelId":"celestica-ds3000","roles":["spine"],"ports":{"fabricAssignable":["E1/1-32"]},
+			"profiles":{"endpoint":{"portProfile":null,"speedGbps":0},"uplink":{"portProfile":"QSFP28-100G","speedGbps":100,
+			"breakouts":[{"mode":"4x25G","lanes":4,"speedGbps":25,"portPattern":"{port}/{lane}"}]},
+			"breakout":{"supportsBreakout":false}},
+			"meta":{"source":"switch_profile.go","version":"v0.4.0"}}`,
+		"README.md": "ignored",
+	})
+
+	r, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SwitchProfile{DS2000(), DS3000()}
+	for i := range want {
+		// The definitions above leave out the layout and the planning data,
+		// and are v0.4.0 files, which load as written
+		want[i].Faceplate = nil
+		want[i].Physical, want[i].Cost, want[i].Capabilities, want[i].Lifecycle = nil, nil, nil, nil
+		want[i].Meta.Version = "v0.4.0"
+	}
+	if !reflect.DeepEqual(r.List(), want) {
+		t.Fatalf("loaded profiles differ from built-ins:\n got  %+v\n want %+v", r.List(), want)
+	}
+}
+
+func TestLoadDirReadsTreeLayout(t *testing.T) {
+	r := Default()
+	rendered, err := r.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := Partition(LayoutTree, r.List(), rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := files[0]; f.Name != "celestica/leaf/ds2000.json" || string(f.Data) != string(rendered[0].Data) {
+		t.Errorf("first file = %s", f.Name)
+	}
+	if last := files[len(files)-1]; last.Name != IndexFile || !strings.Contains(string(last.Data), `"path": "edgecore/spine/dcs501.json"`) {
+		t.Errorf("last file = %s:\n%s", last.Name, last.Data)
+	}
+	dir := t.TempDir()
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, f.Data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := DirLayout(dir); got != LayoutTree {
+		t.Errorf("DirLayout() = %s", got)
+	}
+	loaded, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.List(), r.List()) {
+		t.Error("tree layout loads differently from the profiles written")
+	}
+
+	if _, err := Partition("nested", r.List(), rendered); err == nil || !strings.Contains(err.Error(), `unknown layout "nested"`) {
+		t.Errorf("Partition(nested) = %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, IndexFile), []byte(`{"profiles": [{"path": "../ds2000.json"}]}`), 0644)
+	if _, err := LoadDir(dir); err == nil || !strings.Contains(err.Error(), "is outside") {
+		t.Errorf("LoadDir of an index escaping its directory = %v", err)
+	}
+}
+
+func TestLoadFileRejectsInvalidProfiles(t *testing.T) {
+	cases := map[string]string{
+		"unknown field": strings.Replace(ds2000YAML, "roles:", "role: spine\nroles:", 1),
+		"uplink speed":  ds2000YAML,
+		"port range":    strings.Replace(ds2000YAML, "E1/1-48", "ports 1 to 48", 1),
+		"overlap":       strings.Replace(ds2000YAML, "E1/1-48", "E1/1-50", 1),
+		"missing model": strings.Replace(ds2000YAML, "modelId: celestica-ds2000", "modelId: ''", 1),
+		"bad yaml":      strings.Replace(ds2000YAML, "meta:", "meta: &anchor", 1),
+		"breakout": strings.Replace(ds2000YAML, "speedGbps: 25\n",
+			"speedGbps: 25\n    breakouts:\n      - mode: 4x10G\n        lanes: 4\n        speedGbps: 10\n        portPattern: '{port}/{lane}'\n", 1),
+	}
+	want := map[string]string{
+		"unknown field": `unknown field "role"`,
+		"uplink speed":  "profiles.uplink.speedGbps must be positive",
+		"port range":    `invalid port range "ports 1 to 48"`,
+		"overlap":       "ports E1/49, E1/50 are both endpoint and fabric assignable",
+		"missing model": "modelId is required",
+		"bad yaml":      "unsupported YAML syntax",
+		"breakout":      "profiles.endpoint.breakouts[0]: 4 x 10G exceeds the 25G port speed",
+	}
+	for name, content := range cases {
+		dir := writeFiles(t, map[string]string{"p.yaml": content})
+		_, err := LoadFile(filepath.Join(dir, "p.yaml"))
+		if err == nil || !strings.Contains(err.Error(), want[name]) {
+			t.Errorf("%s: error = %v, want it to contain %q", name, err, want[name])
+		}
+	}
+}
+
+func TestLoadDirRejectsDuplicateModels(t *testing.T) {
+	valid := strings.Replace(ds2000YAML, "  uplink: {}", "  uplink:\n    speedGbps: 100", 1)
+	dir := writeFiles(t, map[string]string{"a.yaml": valid, "b.yml": valid})
+	if _, err := LoadDir(dir); err == nil || !strings.Contains(err.Error(), "b.yml: duplicate profile") {
+		t.Fatalf("error = %v, want duplicate profile in b.yml", err)
+	}
+}
+
+func TestLoadDirJobsReportsEveryBadFile(t *testing.T) {
+	valid := strings.Replace(ds2000YAML, "  uplink: {}", "  uplink:\n    speedGbps: 100", 1)
+	dir := writeFiles(t, map[string]string{"a.yaml": valid, "b.json": "{", "c.yaml": "modelId: [", "d.json": "{}"})
+	_, err := LoadDirJobs(dir, 4)
+	if err == nil {
+		t.Fatal("LoadDirJobs() of broken files succeeded")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], "b.json") || !strings.Contains(lines[1], "c.yaml") || !strings.Contains(lines[2], "d.json") {
+		t.Errorf("error = %v, want b.json, c.yaml and d.json in order", err)
+	}
+}
+
+func TestParseYAMLSequencesOfMappings(t *testing.T) {
+	got, err := parseYAML("items:\n- name: a\n  ports: [E1/1, E1/2]\n- name: 'b'\n  empty:\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"items": []any{
+		map[string]any{"name": "a", "ports": []any{"E1/1", "E1/2"}},
+		map[string]any{"name": "b", "empty": nil},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseYAML = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseYAMLDocuments(t *testing.T) {
+	got, err := ParseYAMLDocuments([]byte("# header\n---\na: y1\n---\n# only a comment\n---\nb: [x]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{map[string]any{"a": "y1"}, map[string]any{"b": []any{"x"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseYAMLDocuments = %#v, want %#v", got, want)
+	}
+	if _, err := ParseYAMLDocuments([]byte("a: 1\n---\nb: &x 1\n")); err == nil || !strings.Contains(err.Error(), "document 2 (line 3)") {
+		t.Errorf("error = %v, want document 2 at line 3", err)
+	}
+}
+
+func TestStreamRoundTrips(t *testing.T) {
+	for _, ndjson := range []bool{false, true} {
+		var b strings.Builder
+		if err := Default().Stream(&b, ndjson); err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Count(b.String(), "\n"); ndjson && lines != 6 {
+			t.Errorf("NDJSON has %d lines, want one per profile", lines)
+		}
+		r, err := ReadStream(strings.NewReader(b.String()))
+		if err != nil {
+			t.Fatalf("ndjson=%v: %v", ndjson, err)
+		}
+		if !reflect.DeepEqual(r.List(), Default().List()) {
+			t.Errorf("ndjson=%v: stream does not round-trip", ndjson)
+		}
+	}
+}
+
+func TestReadStreamRejectsBadInput(t *testing.T) {
+	ds2000, _ := json.Marshal(DS2000())
+	for name, in := range map[string]string{
+		"empty":     "  \n",
+		"truncated": `[{"modelId":`,
+		"invalid":   `{"modelId":"x","roles":[],"ports":{"endpointAssignable":[],"fabricAssignable":[]},"profiles":{"endpoint":{"portProfile":null,"speedGbps":0},"uplink":{"portProfile":null,"speedGbps":100}},"meta":{"source":"t","version":"v0.4.0"}}`,
+		"duplicate": string(ds2000) + "\n" + string(ds2000) + "\n",
+	} {
+		if _, err := ReadStream(strings.NewReader(in)); err == nil {
+			t.Errorf("%s: ReadStream succeeded, want an error", name)
+		}
+	}
+}
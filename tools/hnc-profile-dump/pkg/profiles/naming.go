@@ -0,0 +1,41 @@
+package profiles
+
+import (
+	"fmt"
+
+	"github.com/hnc/profile-dump/pkg/ports"
+)
+
+// PortNaming is the naming scheme of the switch's OS: the profile's
+// namingScheme, sonic when it names none or one this build does not know
+func (p SwitchProfile) PortNaming() ports.Scheme {
+	s, err := ports.LookupScheme(p.NamingScheme)
+	if err != nil {
+		return ports.Schemes[0]
+	}
+	return s
+}
+
+// HNCPort respells a port name as the switch's OS writes it, e.g. swp49,
+// in the sonic spelling of the profile's port ranges, E1/49. A name that
+// is already spelled that way is kept, so data from either side reads.
+func (p SwitchProfile) HNCPort(name string) (string, error) {
+	sonic := ports.Schemes[0]
+	if _, err := sonic.Parse(name); err == nil {
+		return name, nil
+	}
+	hnc, err := ports.Rename(name, p.PortNaming(), sonic)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", p.ModelID, err)
+	}
+	return hnc, nil
+}
+
+// NOSPort spells one of the profile's ports as the switch's OS does
+func (p SwitchProfile) NOSPort(name string) (string, error) {
+	nos, err := ports.Rename(name, ports.Schemes[0], p.PortNaming())
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", p.ModelID, err)
+	}
+	return nos, nil
+}
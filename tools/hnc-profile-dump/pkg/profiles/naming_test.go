@@ -0,0 +1,30 @@
+package profiles
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHNCPort(t *testing.T) {
+	p := DS2000()
+	p.NamingScheme = "cumulus"
+	for name, want := range map[string]string{"swp49": "E1/49", "swp1s2": "E1/1/3", "E1/49": "E1/49"} {
+		if got, err := p.HNCPort(name); err != nil || got != want {
+			t.Errorf("HNCPort(%s) = %s, %v, want %s", name, got, err, want)
+		}
+	}
+	if got, err := p.NOSPort("E1/55"); err != nil || got != "swp55" {
+		t.Errorf("NOSPort(E1/55) = %s, %v, want swp55", got, err)
+	}
+	if _, err := p.HNCPort("Eth1/1"); err == nil || !strings.HasPrefix(err.Error(), p.ModelID+": ") {
+		t.Errorf("HNCPort(Eth1/1) = %v, want an error naming %s", err, p.ModelID)
+	}
+	if got, _ := DS2000().NOSPort("E1/1"); got != "E1/1" {
+		t.Errorf("sonic NOSPort(E1/1) = %s", got)
+	}
+
+	p.NamingScheme = "junos"
+	if errs := Validate(p); len(errs) != 1 || !strings.Contains(errs[0], "namingScheme") {
+		t.Errorf("Validate(namingScheme junos) = %q", errs)
+	}
+}
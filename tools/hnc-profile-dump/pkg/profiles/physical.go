@@ -0,0 +1,56 @@
+package profiles
+
+import "fmt"
+
+// Physical is what the switch takes from the facility, as its vendor's
+// datasheet gives it
+type Physical struct {
+	TypicalPowerWatts float64 `json:"typicalPowerWatts" doc:"Typical power draw in watts, with optics fitted"`
+	MaxPowerWatts     float64 `json:"maxPowerWatts,omitempty" doc:"Maximum power draw in watts, for sizing feeds"`
+	HeatBTUPerHour    float64 `json:"heatBtuPerHour,omitempty" doc:"Maximum heat output in BTU/h (default: maximum, else typical, power x 3.412)"`
+	RackUnits         int     `json:"rackUnits,omitempty" doc:"Height in rack units"`
+	WeightKg          float64 `json:"weightKg,omitempty" doc:"Weight in kilograms, with power supplies and fans fitted"`
+}
+
+// WattsToBTUPerHour converts a power draw to the heat it gives off
+const WattsToBTUPerHour = 3.412
+
+// Heat is the switch's heat output in BTU/h: the datasheet figure, or
+// its maximum (else typical) power draw converted
+func (p Physical) Heat() float64 {
+	if p.HeatBTUPerHour > 0 {
+		return p.HeatBTUPerHour
+	}
+	return max(p.MaxPowerWatts, p.TypicalPowerWatts) * WattsToBTUPerHour
+}
+
+// Cost is what the switch costs to buy
+type Cost struct {
+	ListPriceUSD float64 `json:"listPriceUsd" doc:"List price in US dollars, for comparing plans rather than quoting"`
+}
+
+// validatePlanningData checks the optional physical and cost figures are
+// positive, since plan optimization and power reports multiply them out
+func validatePlanningData(p SwitchProfile) []string {
+	var errs []string
+	if ph := p.Physical; ph != nil {
+		if ph.TypicalPowerWatts <= 0 {
+			errs = append(errs, fmt.Sprintf("physical.typicalPowerWatts must be positive, got %g", ph.TypicalPowerWatts))
+		}
+		if ph.MaxPowerWatts != 0 && ph.MaxPowerWatts < ph.TypicalPowerWatts {
+			errs = append(errs, fmt.Sprintf("physical.maxPowerWatts %g is below typicalPowerWatts %g", ph.MaxPowerWatts, ph.TypicalPowerWatts))
+		}
+		for _, f := range []struct {
+			name  string
+			value float64
+		}{{"heatBtuPerHour", ph.HeatBTUPerHour}, {"rackUnits", float64(ph.RackUnits)}, {"weightKg", ph.WeightKg}} {
+			if f.value < 0 {
+				errs = append(errs, fmt.Sprintf("physical.%s must be positive, got %g", f.name, f.value))
+			}
+		}
+	}
+	if p.Cost != nil && p.Cost.ListPriceUSD <= 0 {
+		errs = append(errs, fmt.Sprintf("cost.listPriceUsd must be positive, got %g", p.Cost.ListPriceUSD))
+	}
+	return errs
+}
@@ -0,0 +1,42 @@
+package profiles
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePlanningData(t *testing.T) {
+	p := DS2000()
+	p.Physical = &Physical{TypicalPowerWatts: 350}
+	p.Cost = &Cost{ListPriceUSD: 15000}
+	if errs := Validate(p); len(errs) > 0 {
+		t.Fatalf("Validate() = %v", errs)
+	}
+	p.Physical.MaxPowerWatts = 300
+	if errs := Validate(p); len(errs) != 1 || !strings.Contains(errs[0], "maxPowerWatts 300 is below typicalPowerWatts 350") {
+		t.Errorf("Validate() = %v", errs)
+	}
+	p.Physical = &Physical{TypicalPowerWatts: 0, MaxPowerWatts: 200, RackUnits: -1}
+	p.Cost.ListPriceUSD = -1
+	errs := strings.Join(Validate(p), "; ")
+	for _, want := range []string{"physical.typicalPowerWatts must be positive", "physical.rackUnits must be positive, got -1", "cost.listPriceUsd must be positive, got -1"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("Validate() = %s, want %q", errs, want)
+		}
+	}
+}
+
+func TestPhysicalHeat(t *testing.T) {
+	for _, tc := range []struct {
+		p    Physical
+		want float64
+	}{
+		{Physical{TypicalPowerWatts: 100}, 341.2},
+		{Physical{TypicalPowerWatts: 100, MaxPowerWatts: 200}, 682.4},
+		{Physical{TypicalPowerWatts: 100, MaxPowerWatts: 200, HeatBTUPerHour: 500}, 500},
+	} {
+		if got := tc.p.Heat(); got != tc.want {
+			t.Errorf("%+v Heat() = %g, want %g", tc.p, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,428 @@
+// Package profiles holds the canonical switch profile definitions shared by
+// the HNC Go tools, and a registry to look them up and write them as the
+// JSON fixtures the frontend loads.
+package profiles
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/batch"
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/yamlenc"
+)
+
+// SwitchProfile represents the JSON structure for switch profiles
+type SwitchProfile struct {
+	ModelID      string        `json:"modelId" doc:"Hedgehog model ID, vendor and model joined by a hyphen, e.g. celestica-ds2000"`
+	Roles        []string      `json:"roles" doc:"Fabric roles the switch can take: leaf, spine or both"`
+	Ports        Ports         `json:"ports" doc:"Which front-panel ports may carry endpoint and fabric links"`
+	NamingScheme string        `json:"namingScheme,omitempty" doc:"How the switch's network OS spells port names, so names imported from it map to the E1/1 ones used here: sonic (the default), eth, os10 or cumulus"`
+	Faceplate    *Faceplate    `json:"faceplate,omitempty" doc:"Physical front-panel layout, for commissioning sheets"`
+	Physical     *Physical     `json:"physical,omitempty" doc:"Power, heat, height and weight from the datasheet, for plan optimization and power reports"`
+	Cost         *Cost         `json:"cost,omitempty" doc:"List price, for plan optimization"`
+	Capabilities *Capabilities `json:"capabilities,omitempty" doc:"ASIC family, table sizes and features, for checking designs against"`
+	Lifecycle    *Lifecycle    `json:"lifecycle,omitempty" doc:"Whether the model is still sold, and what replaces it, for keeping retired models out of new designs"`
+	Profiles     Profiles      `json:"profiles" doc:"Port profile and speed of endpoint and uplink ports, per role"`
+	Meta         Meta          `json:"meta" doc:"Where the profile came from and the schema version it follows"`
+}
+
+type Ports struct {
+	EndpointAssignable []string `json:"endpointAssignable" doc:"Port ranges servers may connect to, e.g. E1/1-48"`
+	FabricAssignable   []string `json:"fabricAssignable" doc:"Port ranges for leaf-spine links, e.g. E1/49-56"`
+}
+
+// Roles a switch profile can take
+const (
+	RoleLeaf  = "leaf"
+	RoleSpine = "spine"
+)
+
+// Profiles holds the port profiles of each role the switch can take. The
+// first role's are inline, where every schema version has them, so readers
+// that predate per-role profiles still find them; from v0.5.0, Roles holds
+// those of later roles that differ, e.g. a border leaf's spine uplinks.
+type Profiles struct {
+	RoleProfiles
+	Roles map[string]RoleProfiles `json:"roles,omitempty" doc:"Port profiles of roles after the first, keyed by role, where they differ from the inline ones"`
+}
+
+// RoleProfiles is how a switch's ports run in one role
+type RoleProfiles struct {
+	Endpoint PortProfile         `json:"endpoint" doc:"Endpoint-facing ports"`
+	Uplink   PortProfile         `json:"uplink" doc:"Fabric-facing ports"`
+	Breakout *BreakoutCapability `json:"breakout,omitempty" doc:"Switch-level breakout summary for the wiring builder"`
+}
+
+// ForRole returns the port profiles the switch uses in role: its entry in
+// Profiles.Roles, or the inline ones
+func (p SwitchProfile) ForRole(role string) RoleProfiles {
+	if rp, ok := p.Profiles.Roles[role]; ok {
+		return rp
+	}
+	return p.Profiles.RoleProfiles
+}
+
+// InRole returns the profile as a switch in role sees it, with the role's
+// port profiles inline, so code sizing a leaf or spine need not look them up
+func (p SwitchProfile) InRole(role string) SwitchProfile {
+	p.Profiles = Profiles{RoleProfiles: p.ForRole(role)}
+	return p
+}
+
+// RoleEntry is one set of role profiles and the field it is written at
+type RoleEntry struct {
+	Field string // profiles, or profiles.roles.<role>
+	Role  string
+	RoleProfiles
+}
+
+// RoleEntries returns the inline profiles, as the first role's, then each
+// per-role entry in role name order
+func (p SwitchProfile) RoleEntries() []RoleEntry {
+	first := ""
+	if len(p.Roles) > 0 {
+		first = p.Roles[0]
+	}
+	entries := []RoleEntry{{Field: "profiles", Role: first, RoleProfiles: p.Profiles.RoleProfiles}}
+	roles := make([]string, 0, len(p.Profiles.Roles))
+	for role := range p.Profiles.Roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		entries = append(entries, RoleEntry{Field: "profiles.roles." + role, Role: role, RoleProfiles: p.Profiles.Roles[role]})
+	}
+	return entries
+}
+
+// BreakoutCapability is the switch-level breakout summary the frontend
+// wiring builder reads to multiply endpoint capacity
+type BreakoutCapability struct {
+	SupportsBreakout   bool   `json:"supportsBreakout" doc:"Whether endpoint ports can break out"`
+	BreakoutType       string `json:"breakoutType,omitempty" doc:"Default breakout mode, e.g. 4x25G"`
+	CapacityMultiplier int    `json:"capacityMultiplier,omitempty" doc:"Endpoint ports per physical port when broken out"`
+}
+
+type PortProfile struct {
+	PortProfile *string          `json:"portProfile" doc:"Hedgehog port profile name, e.g. SFP28-25G; null for ports with none"`
+	SpeedGbps   int              `json:"speedGbps" doc:"Port speed in Gbps; 0 for ports with none"`
+	Breakouts   []BreakoutOption `json:"breakouts,omitempty" doc:"Ways the port can split into lower-speed ports"`
+}
+
+// BreakoutOption is one way to split a port into lower-speed ports, e.g. a
+// 100G QSFP28 port into 4x25G
+type BreakoutOption struct {
+	Mode        string `json:"mode" doc:"Breakout mode, e.g. 4x25G"`
+	Lanes       int    `json:"lanes" doc:"Resulting ports per physical port"`
+	SpeedGbps   int    `json:"speedGbps" doc:"Speed of each resulting port in Gbps"`
+	PortPattern string `json:"portPattern" doc:"Resulting port names from {port} and {lane}, e.g. {port}/{lane}"`
+}
+
+// Breakout returns the option for mode, e.g. "4x25G"
+func (pp PortProfile) Breakout(mode string) (BreakoutOption, bool) {
+	for _, b := range pp.Breakouts {
+		if strings.EqualFold(b.Mode, mode) {
+			return b, true
+		}
+	}
+	return BreakoutOption{}, false
+}
+
+// PortNames names the ports a physical port breaks out into: E1/1 in
+// 4x25G with "{port}/{lane}" is E1/1/1 through E1/1/4
+func (b BreakoutOption) PortNames(port string) []string {
+	names := make([]string, b.Lanes)
+	for lane := range names {
+		names[lane] = strings.NewReplacer("{port}", port, "{lane}", strconv.Itoa(lane+1)).Replace(b.PortPattern)
+	}
+	return names
+}
+
+type Meta struct {
+	Source  string `json:"source" doc:"File or generator the profile was written from"`
+	Version string `json:"version" doc:"Profile schema version, e.g. v0.4.0"`
+}
+
+// models holds the built-in profile definitions, one YAML file per model
+// or model family in the same format LoadDir reads
+//
+//go:embed models/*.yaml
+var models embed.FS
+
+// DS2000 returns the Celestica DS2000 leaf switch profile
+func DS2000() SwitchProfile { return builtin("celestica-ds2000") }
+
+// DS3000 returns the Celestica DS3000 spine switch profile
+func DS3000() SwitchProfile { return builtin("celestica-ds3000") }
+
+// builtins decodes every built-in profile afresh, so callers may modify
+// the results
+func builtins() []SwitchProfile {
+	names, _ := fs.Glob(models, "models/*.yaml")
+	var ps []SwitchProfile
+	for _, name := range names {
+		data, err := models.ReadFile(name)
+		if err != nil {
+			panic(err)
+		}
+		if IsFamily(name) {
+			family, err := DecodeFamily(data)
+			if err != nil {
+				panic(fmt.Sprintf("built-in model family %s: %v", name, err))
+			}
+			ps = append(ps, family...)
+			continue
+		}
+		p, err := Decode(data, ".yaml")
+		if err != nil {
+			panic(fmt.Sprintf("built-in profile %s: %v", name, err))
+		}
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// builtin is the built-in profile of a model
+func builtin(modelID string) SwitchProfile {
+	for _, p := range builtins() {
+		if p.ModelID == modelID {
+			return p
+		}
+	}
+	panic("no built-in profile " + modelID)
+}
+
+// Registry is a set of switch profiles keyed by model ID
+type Registry struct {
+	profiles map[string]SwitchProfile
+}
+
+// NewRegistry returns a registry holding the given profiles
+func NewRegistry(profiles ...SwitchProfile) (*Registry, error) {
+	r := &Registry{profiles: map[string]SwitchProfile{}}
+	for _, p := range profiles {
+		if err := r.Register(p); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Default returns a registry with every built-in profile: the Celestica
+// DS2000 leaf, the DS3000, DS4000 and DS5000 spines, and the Edgecore
+// DCS204 and DCS501 spines
+func Default() *Registry {
+	r, err := NewRegistry(builtins()...)
+	if err != nil {
+		panic(err) // built-in model IDs are unique
+	}
+	return r
+}
+
+// Register adds a profile; model IDs must be non-empty and unique
+func (r *Registry) Register(p SwitchProfile) error {
+	if p.ModelID == "" {
+		return fmt.Errorf("profile has no modelId")
+	}
+	if _, ok := r.profiles[p.ModelID]; ok {
+		return fmt.Errorf("duplicate profile for model %s", p.ModelID)
+	}
+	r.profiles[p.ModelID] = p
+	return nil
+}
+
+// Get returns the profile for a model ID
+func (r *Registry) Get(modelID string) (SwitchProfile, bool) {
+	p, ok := r.profiles[modelID]
+	return p, ok
+}
+
+// Find returns the profile for a model ID or its short name without the
+// vendor prefix, case-insensitively (DS2000 finds celestica-ds2000)
+func (r *Registry) Find(name string) (SwitchProfile, bool) {
+	if p, ok := r.profiles[name]; ok {
+		return p, true
+	}
+	for _, p := range r.List() {
+		if strings.EqualFold(p.ModelID, name) || strings.EqualFold(strings.TrimSuffix(FileName(p.ModelID), ".json"), name) {
+			return p, true
+		}
+	}
+	return SwitchProfile{}, false
+}
+
+// List returns every profile, sorted by model ID
+func (r *Registry) List() []SwitchProfile {
+	list := make([]SwitchProfile, 0, len(r.profiles))
+	for _, p := range r.profiles {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ModelID < list[j].ModelID })
+	return list
+}
+
+// WriteAll writes every profile to dir as FileName(modelID) and returns
+// the paths written, in List order
+func (r *Registry) WriteAll(dir string) ([]string, error) {
+	var paths []string
+	for _, p := range r.List() {
+		path, err := WriteFile(p, dir, FileName(p.ModelID))
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// FileName is the fixture file for a model: the model ID without its
+// vendor prefix, e.g. celestica-ds2000 -> ds2000.json
+func FileName(modelID string) string {
+	if i := strings.Index(modelID, "-"); i != -1 {
+		modelID = modelID[i+1:]
+	}
+	return modelID + ".json"
+}
+
+// Marshal renders a profile as its fixture file is written, as canonical
+// JSON
+func Marshal(profile SwitchProfile) ([]byte, error) {
+	data, err := canonjson.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	return data, nil
+}
+
+// File is a generated file held in memory, named relative to its output
+// directory
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Render returns the files WriteAll would write, in List order, without
+// touching the filesystem
+func (r *Registry) Render() ([]File, error) {
+	return r.RenderWith(JSONFile, 1)
+}
+
+// A Renderer writes one profile as its generated file
+type Renderer func(SwitchProfile) (File, error)
+
+// JSONFile is a profile's fixture file, as Render writes it
+func JSONFile(p SwitchProfile) (File, error) {
+	data, err := Marshal(p)
+	return File{Name: FileName(p.ModelID), Data: data}, err
+}
+
+// YAMLFile is a profile's YAML file, as RenderYAML writes it
+func YAMLFile(p SwitchProfile) (File, error) {
+	data, err := MarshalYAML(p)
+	return File{Name: YAMLFileName(p.ModelID), Data: data}, err
+}
+
+// RenderWith renders every profile with render, jobs at a time (one per
+// CPU for 0), and returns the files in List order. The error names every
+// profile that failed, not only the first.
+func (r *Registry) RenderWith(render Renderer, jobs int) ([]File, error) {
+	return r.RenderContext(context.Background(), render, jobs)
+}
+
+// RenderContext is RenderWith that stops rendering once ctx is done,
+// reporting each profile rendered as a step of the profiles stage
+func (r *Registry) RenderContext(ctx context.Context, render Renderer, jobs int) ([]File, error) {
+	files, err := batch.MapContext(ctx, "profiles", jobs, r.List(), func(p SwitchProfile) (File, error) {
+		f, err := render(p)
+		if err != nil {
+			return f, fmt.Errorf("%s: %w", p.ModelID, err)
+		}
+		return f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// YAMLFileName is the YAML file for a model, e.g. celestica-ds2000 ->
+// ds2000.yaml
+func YAMLFileName(modelID string) string {
+	return strings.TrimSuffix(FileName(modelID), ".json") + ".yaml"
+}
+
+// MarshalYAML renders a profile as YAML with the same keys, in the same
+// order, as Marshal
+func MarshalYAML(profile SwitchProfile) ([]byte, error) {
+	data, err := yamlenc.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	return data, nil
+}
+
+// RenderYAML returns the files WriteAllYAML would write, in List order
+func (r *Registry) RenderYAML() ([]File, error) {
+	return r.RenderWith(YAMLFile, 1)
+}
+
+// WriteAllYAML writes every profile to dir as YAML, for review and for
+// pipelines that consume YAML, and returns the paths written, in List order
+func (r *Registry) WriteAllYAML(dir string) ([]string, error) {
+	files, err := r.RenderYAML()
+	if err != nil {
+		return nil, err
+	}
+	return writeOutput(dir, files)
+}
+
+// Stream writes every profile to w, in List order, as one indented JSON
+// array or, with ndjson, as one compact JSON object per line
+func (r *Registry) Stream(w io.Writer, ndjson bool) error {
+	list := r.List()
+	if !ndjson {
+		data, err := canonjson.Marshal(list)
+		if err != nil {
+			return fmt.Errorf("failed to marshal profiles: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	for _, p := range list {
+		data, err := canonjson.Compact(p)
+		if err != nil {
+			return fmt.Errorf("failed to marshal profile: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile writes a switch profile to a JSON file with stable ordering
+func WriteFile(profile SwitchProfile, outputDir, filename string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	data, err := Marshal(profile)
+	if err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(outputDir, filename)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+	return filePath, nil
+}
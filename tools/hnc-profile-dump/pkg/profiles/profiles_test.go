@@ -0,0 +1,262 @@
+package profiles
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	r := Default()
+
+	var ids []string
+	for _, p := range r.List() {
+		ids = append(ids, p.ModelID)
+	}
+	want := []string{"celestica-ds2000", "celestica-ds3000", "celestica-ds4000", "celestica-ds5000", "edgecore-dcs204", "edgecore-dcs501"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("List() = %v, want %v", ids, want)
+	}
+	if p, ok := r.Get("celestica-ds3000"); !ok || p.Roles[0] != "spine" {
+		t.Fatalf("Get(celestica-ds3000) = %+v, %v", p, ok)
+	}
+	if _, ok := r.Get("ds9000"); ok {
+		t.Fatal("Get(ds9000) found a profile")
+	}
+}
+
+func TestBuiltinModels(t *testing.T) {
+	names, err := fs.Glob(models, "models/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if IsFamily(name) {
+			continue
+		}
+		// Model files are named as their fixtures, so -output stays in step
+		data, _ := models.ReadFile(name)
+		p, err := Decode(data, ".yaml")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := strings.TrimSuffix(FileName(p.ModelID), ".json") + ".yaml"; got != path.Base(name) {
+			t.Errorf("%s defines %s, whose fixture is %s", name, p.ModelID, got)
+		}
+	}
+
+	r := Default()
+	for _, tt := range []struct {
+		name      string
+		roles     []string
+		fabric    string
+		speedGbps int
+		breakouts int
+	}{
+		{"DS4000", []string{"spine"}, "E1/1-32", 400, 3},
+		{"DS5000", []string{"spine"}, "E1/1-64", 800, 3},
+		{"DCS204", []string{"spine"}, "E1/1-32", 100, 1},
+		{"DCS501", []string{"spine"}, "E1/1-32", 100, 1},
+	} {
+		p, ok := r.Find(tt.name)
+		if !ok {
+			t.Errorf("Find(%s) found no profile", tt.name)
+			continue
+		}
+		if !reflect.DeepEqual(p.Roles, tt.roles) || p.Ports.FabricAssignable[0] != tt.fabric ||
+			p.Profiles.Uplink.SpeedGbps != tt.speedGbps || len(p.Profiles.Uplink.Breakouts) != tt.breakouts {
+			t.Errorf("%s = %+v", tt.name, p)
+		}
+	}
+}
+
+// The default leaf and spine carry every kind of planning data, so plans
+// of the built-in models can be optimized, checked and reported on in full
+func TestBuiltinPlanningData(t *testing.T) {
+	for _, p := range []SwitchProfile{DS2000(), DS3000()} {
+		ph, c := p.Physical, p.Capabilities
+		switch {
+		case ph == nil || ph.TypicalPowerWatts == 0 || ph.MaxPowerWatts == 0 || ph.RackUnits == 0 || ph.WeightKg == 0:
+			t.Errorf("%s physical = %+v, want every figure", p.ModelID, ph)
+		case p.Cost == nil:
+			t.Errorf("%s has no cost", p.ModelID)
+		case c == nil || c.Tables.Routes == 0 || c.Tables.MACs == 0 || c.Tables.VXLANTunnels == 0 || !c.Features.VXLANRouting || !c.Features.RoCE:
+			t.Errorf("%s capabilities = %+v, want every table and feature", p.ModelID, c)
+		case p.Lifecycle == nil || p.Lifecycle.Status != LifecycleActive:
+			t.Errorf("%s lifecycle = %+v, want active", p.ModelID, p.Lifecycle)
+		}
+	}
+}
+
+func TestFindByShortName(t *testing.T) {
+	r := Default()
+	for _, name := range []string{"celestica-ds2000", "DS2000", "ds2000"} {
+		if p, ok := r.Find(name); !ok || p.ModelID != "celestica-ds2000" {
+			t.Errorf("Find(%q) = %q, %v", name, p.ModelID, ok)
+		}
+	}
+	if _, ok := r.Find("celestica"); ok {
+		t.Error("Find(celestica) matched a vendor prefix")
+	}
+}
+
+func TestBreakoutPortNames(t *testing.T) {
+	b, ok := DS3000().Profiles.Uplink.Breakout("4X25G")
+	if !ok {
+		t.Fatal("DS3000 uplinks have no 4x25G breakout")
+	}
+	if got, want := b.PortNames("E1/7"), []string{"E1/7/1", "E1/7/2", "E1/7/3", "E1/7/4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PortNames(E1/7) = %v, want %v", got, want)
+	}
+	if _, ok := DS2000().Profiles.Endpoint.Breakout("4x25G"); ok {
+		t.Error("DS2000 SFP28 endpoint ports report a breakout")
+	}
+}
+
+// borderLeafYAML is a switch that serves endpoints as a leaf and, in
+// smaller fabrics, runs its uplinks at 400G as a spine
+const borderLeafYAML = `modelId: acme-bl100
+roles: [leaf, spine]
+ports:
+  endpointAssignable: ['E1/1-32']
+  fabricAssignable: ['E1/33-40']
+profiles:
+  endpoint:
+    portProfile: QSFP28-100G
+    speedGbps: 100
+  uplink:
+    portProfile: QSFP-DD-400G
+    speedGbps: 400
+  roles:
+    spine:
+      endpoint:
+        portProfile: QSFP28-100G
+        speedGbps: 100
+      uplink:
+        portProfile: QSFP28-100G
+        speedGbps: 100
+meta:
+  source: test
+`
+
+func TestRoleProfiles(t *testing.T) {
+	p, err := Decode([]byte(borderLeafYAML), ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.ForRole(RoleSpine).Uplink.SpeedGbps; got != 100 {
+		t.Errorf("spine uplink = %dG, want 100G", got)
+	}
+	if got := p.ForRole(RoleLeaf).Uplink.SpeedGbps; got != 400 {
+		t.Errorf("leaf uplink = %dG, want the inline 400G", got)
+	}
+	if spine := p.InRole(RoleSpine); spine.Profiles.Uplink.SpeedGbps != 100 || spine.Profiles.Roles != nil {
+		t.Errorf("InRole(spine) = %+v", spine.Profiles)
+	}
+
+	// v0.4 readers keep finding the first role's profiles inline
+	data, _ := Marshal(p)
+	if errs := CheckSchema(data); errs != nil {
+		t.Errorf("CheckSchema = %v", errs)
+	}
+	var legacy struct {
+		Profiles struct {
+			Uplink PortProfile `json:"uplink"`
+		} `json:"profiles"`
+	}
+	if json.Unmarshal(data, &legacy); legacy.Profiles.Uplink.SpeedGbps != 400 {
+		t.Errorf("inline uplink = %+v, want 400G", legacy.Profiles.Uplink)
+	}
+	if old, _ := AtVersion(p, "v0.4.0"); old.Profiles.Roles != nil || old.Profiles.Uplink.SpeedGbps != 400 {
+		t.Errorf("AtVersion(v0.4.0) = %+v", old.Profiles)
+	}
+
+	for role, want := range map[string]string{
+		"leaf":   "profiles.roles.leaf: leaf is not one of roles after the first",
+		"border": "profiles.roles.border: border is not one of roles after the first",
+	} {
+		doc := strings.Replace(borderLeafYAML, "    spine:\n", "    "+role+":\n", 1)
+		if _, err := Decode([]byte(doc), ".yaml"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Decode(roles.%s) error = %v, want %q", role, err, want)
+		}
+	}
+	doc := strings.Replace(borderLeafYAML, "        speedGbps: 100\nmeta", "        speedGbps: 0\nmeta", 1)
+	if _, err := Decode([]byte(doc), ".yaml"); err == nil || !strings.Contains(err.Error(), "profiles.roles.spine.uplink.speedGbps must be positive") {
+		t.Errorf("Decode(0G spine uplink) error = %v", err)
+	}
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	if _, err := NewRegistry(DS2000(), DS2000()); err == nil {
+		t.Fatal("expected duplicate model error")
+	}
+	if err := Default().Register(SwitchProfile{}); err == nil {
+		t.Fatal("expected missing modelId error")
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := Default().WriteAll(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "ds2000.json"), filepath.Join(dir, "ds3000.json")}; len(paths) != 6 || !reflect.DeepEqual(paths[:2], want) {
+		t.Fatalf("WriteAll() = %v, want %v", paths, want)
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written SwitchProfile
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, DS2000()) {
+		t.Fatalf("ds2000.json = %+v, want %+v", written, DS2000())
+	}
+}
+
+func TestWriteAllYAML(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := Default().WriteAllYAML(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 6 || paths[0] != filepath.Join(dir, "ds2000.yaml") {
+		t.Fatalf("WriteAllYAML() = %v", paths)
+	}
+
+	jsonKey := regexp.MustCompile(`(?m)^\s*"(\w+)":`)
+	yamlKey := regexp.MustCompile(`(?m)^[\s-]*(\w+):`)
+	keys := func(re *regexp.Regexp, s string) (out []string) {
+		for _, m := range re.FindAllStringSubmatch(s, -1) {
+			out = append(out, m[1])
+		}
+		return out
+	}
+	for i, p := range Default().List() {
+		data, err := os.ReadFile(paths[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := Decode(data, ".yaml")
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", paths[i], err, data)
+		}
+		if !reflect.DeepEqual(decoded, p) {
+			t.Errorf("%s decodes to %+v, want %+v", paths[i], decoded, p)
+		}
+		fixture, _ := Marshal(p)
+		if got, want := keys(yamlKey, string(data)), keys(jsonKey, string(fixture)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: key order %v, want the JSON order %v", paths[i], got, want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package profiletest generates random switch profiles that pass
+// profiles.Validate, for property tests of the planner, the capacity math
+// and the validators: port ranges of any shape and prefix, endpoint and
+// uplink speed combinations, and the breakouts those speeds allow. A
+// generator seeded the same way yields the same profiles, so a failing
+// case can be replayed from its seed.
+package profiletest
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// portType is a port profile and the breakouts its cages support
+type portType struct {
+	profile   string
+	speedGbps int
+	breakouts []profiles.BreakoutOption
+}
+
+// portTypes are ordered by speed, so an uplink type can be drawn no slower
+// than the endpoint type
+var portTypes = []portType{
+	{"SFP28-10G", 10, nil},
+	{"SFP28-25G", 25, nil},
+	{"QSFP28-100G", 100, []profiles.BreakoutOption{
+		{Mode: "4x25G", Lanes: 4, SpeedGbps: 25},
+		{Mode: "2x50G", Lanes: 2, SpeedGbps: 50},
+	}},
+	{"QSFP-DD-400G", 400, []profiles.BreakoutOption{
+		{Mode: "4x100G", Lanes: 4, SpeedGbps: 100},
+		{Mode: "2x200G", Lanes: 2, SpeedGbps: 200},
+		{Mode: "8x50G", Lanes: 8, SpeedGbps: 50},
+	}},
+}
+
+// prefixes are the port name styles profiles use
+var prefixes = []string{"E1/", "Ethernet1/1/", "Eth", "swp"}
+
+// patterns are breakout port name patterns
+var patterns = []string{"{port}/{lane}", "{port}.{lane}", "{port}:{lane}"}
+
+// Generator draws profiles from a seeded random source
+type Generator struct {
+	r *rand.Rand
+	n int // profiles drawn, for unique model IDs
+}
+
+// New returns a generator seeded with seed
+func New(seed int64) *Generator {
+	return &Generator{r: rand.New(rand.NewSource(seed))}
+}
+
+// Profile draws a valid profile for role (profiles.RoleLeaf or
+// profiles.RoleSpine). Leaves get 1 to 96 endpoint ports and 1 to 16
+// fabric ports; spines have no endpoint ports and 2 to 128 fabric ports.
+func (g *Generator) Profile(role string) profiles.SwitchProfile {
+	g.n++
+	endpoints, fabric := 0, 2+g.r.Intn(127)
+	if role == profiles.RoleLeaf {
+		endpoints, fabric = 1+g.r.Intn(96), 1+g.r.Intn(16)
+	}
+	prefix := prefixes[g.r.Intn(len(prefixes))]
+	first := g.r.Intn(2) // ports number from 0 or 1
+
+	endpointType := g.r.Intn(len(portTypes))
+	uplinkType := endpointType + g.r.Intn(len(portTypes)-endpointType)
+	p := profiles.SwitchProfile{
+		ModelID: fmt.Sprintf("profiletest-%s%d", role, g.n),
+		Roles:   []string{role},
+		Ports: profiles.Ports{
+			EndpointAssignable: g.ranges(prefix, first, endpoints),
+			FabricAssignable:   g.ranges(prefix, first+endpoints, fabric),
+		},
+		Meta: profiles.Meta{Source: "profiletest", Version: profiles.SchemaVersion},
+	}
+	p.Profiles.Uplink = g.portProfile(portTypes[uplinkType])
+	if endpoints > 0 {
+		p.Profiles.Endpoint = g.portProfile(portTypes[endpointType])
+		if b := p.Profiles.Endpoint.Breakouts; len(b) > 0 {
+			p.Profiles.Breakout = &profiles.BreakoutCapability{SupportsBreakout: true, BreakoutType: b[0].Mode, CapacityMultiplier: b[0].Lanes}
+		}
+	}
+	return p
+}
+
+// Fabric draws a leaf and a spine. Half the time the spine's fabric ports
+// are the leaf's uplink type, so breakout plans can match on both sides.
+func (g *Generator) Fabric() (leaf, spine profiles.SwitchProfile) {
+	leaf, spine = g.Profile(profiles.RoleLeaf), g.Profile(profiles.RoleSpine)
+	if g.r.Intn(2) == 0 {
+		for _, t := range portTypes {
+			if t.speedGbps == leaf.Profiles.Uplink.SpeedGbps {
+				spine.Profiles.Uplink = g.portProfile(t)
+			}
+		}
+	}
+	return leaf, spine
+}
+
+// Breakout is a breakout mode both profiles' uplinks support, or "" when
+// they share none
+func Breakout(leaf, spine profiles.SwitchProfile) string {
+	for _, b := range leaf.Profiles.Uplink.Breakouts {
+		if s, ok := spine.Profiles.Uplink.Breakout(b.Mode); ok && s.SpeedGbps == b.SpeedGbps {
+			return b.Mode
+		}
+	}
+	return ""
+}
+
+// ranges covers n ports numbered from first in a random mix of ranges,
+// single ports and comma-joined lists
+func (g *Generator) ranges(prefix string, first, n int) []string {
+	exprs := []string{}
+	for n > 0 {
+		var parts []string
+		for joined := 1 + g.r.Intn(3); joined > 0 && n > 0; joined-- {
+			size := 1 + g.r.Intn(n)
+			if size == 1 {
+				parts = append(parts, prefix+strconv.Itoa(first))
+			} else {
+				parts = append(parts, fmt.Sprintf("%s%d-%d", prefix, first, first+size-1))
+			}
+			first += size
+			n -= size
+		}
+		exprs = append(exprs, strings.Join(parts, ","))
+	}
+	return exprs
+}
+
+// portProfile is t with a random subset of its breakouts, in their order
+func (g *Generator) portProfile(t portType) profiles.PortProfile {
+	name := t.profile
+	pp := profiles.PortProfile{PortProfile: &name, SpeedGbps: t.speedGbps}
+	for _, b := range t.breakouts {
+		if g.r.Intn(2) == 0 {
+			b.PortPattern = patterns[g.r.Intn(len(patterns))]
+			pp.Breakouts = append(pp.Breakouts, b)
+		}
+	}
+	return pp
+}
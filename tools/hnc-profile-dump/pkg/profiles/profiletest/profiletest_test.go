@@ -0,0 +1,65 @@
+package profiletest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func TestProfilesAreValid(t *testing.T) {
+	g := New(1)
+	for i := 0; i < 500; i++ {
+		leaf, spine := g.Fabric()
+		for _, p := range []profiles.SwitchProfile{leaf, spine} {
+			if errs := profiles.Validate(p); len(errs) > 0 {
+				t.Fatalf("profile %d is invalid: %v\n%+v", i, errs, p)
+			}
+			// Both file formats read back to the same profile
+			for _, format := range []struct {
+				ext     string
+				marshal func(profiles.SwitchProfile) ([]byte, error)
+			}{{".json", profiles.Marshal}, {".yaml", profiles.MarshalYAML}} {
+				data, err := format.marshal(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				back, err := profiles.Decode(data, format.ext)
+				if err != nil {
+					t.Fatalf("%s does not decode: %v\n%s", format.ext, err, data)
+				}
+				if !reflect.DeepEqual(back, profiles.Normalize(p)) {
+					t.Fatalf("%s round trip changed the profile:\n%+v\n%+v", format.ext, back, p)
+				}
+			}
+		}
+	}
+}
+
+func TestSeedReplays(t *testing.T) {
+	a, b := New(42), New(42)
+	for i := 0; i < 20; i++ {
+		if p, q := a.Profile(profiles.RoleLeaf), b.Profile(profiles.RoleLeaf); !reflect.DeepEqual(p, q) {
+			t.Fatalf("draw %d differs for the same seed:\n%+v\n%+v", i, p, q)
+		}
+	}
+}
+
+func TestFabricSharesBreakouts(t *testing.T) {
+	g := New(7)
+	shared := 0
+	for i := 0; i < 200; i++ {
+		leaf, spine := g.Fabric()
+		if mode := Breakout(leaf, spine); mode != "" {
+			shared++
+			l, _ := leaf.Profiles.Uplink.Breakout(mode)
+			s, _ := spine.Profiles.Uplink.Breakout(mode)
+			if l.SpeedGbps != s.SpeedGbps {
+				t.Fatalf("breakout %s runs at %dG on the leaf, %dG on the spine", mode, l.SpeedGbps, s.SpeedGbps)
+			}
+		}
+	}
+	if shared == 0 {
+		t.Fatal("no fabric shared a breakout mode")
+	}
+}
@@ -0,0 +1,174 @@
+package profiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaDialect is the JSON Schema draft the generated schema declares
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema derives a JSON Schema from the SwitchProfile struct: every field
+// without omitempty is required, pointers may also be null, and unknown
+// keys are rejected, matching what Decode accepts
+func Schema() map[string]any {
+	s := schemaFor(reflect.TypeOf(SwitchProfile{}))
+	s["$schema"] = SchemaDialect
+	s["title"] = "SwitchProfile"
+	return s
+}
+
+func schemaFor(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := schemaFor(t.Elem())
+		s["type"] = []any{s["type"], "null"}
+		return s
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []any{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if f.Anonymous && name == "" {
+				// Embedded structs are encoded inline
+				embedded := schemaFor(f.Type)
+				for k, v := range embedded["properties"].(map[string]any) {
+					properties[k] = v
+				}
+				required = append(required, embedded["required"].([]any)...)
+				continue
+			}
+			if name == "" || name == "-" {
+				continue
+			}
+			properties[name] = schemaFor(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required, "additionalProperties": false}
+	}
+	panic(fmt.Sprintf("profiles: no JSON Schema mapping for %s", t))
+}
+
+// CheckSchema reports every place a JSON profile document departs from
+// Schema(), e.g. "$.profiles.uplink.speedGbps: expected integer, got
+// string"; nil means it conforms
+func CheckSchema(data []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return []string{fmt.Sprintf("$: invalid JSON: %v", err)}
+	}
+	var errs []string
+	checkValue(Schema(), doc, "$", &errs)
+	return errs
+}
+
+// checkValue validates the subset of JSON Schema that Schema() emits:
+// type, properties, required, additionalProperties (false or a schema)
+// and items
+func checkValue(schema map[string]any, value any, path string, errs *[]string) {
+	if got := jsonType(value); !typeAllowed(schema["type"], got) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", path, typeNames(schema["type"]), got))
+		return
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub, known := properties[k].(map[string]any)
+			if !known {
+				sub, known = schema["additionalProperties"].(map[string]any)
+			}
+			if !known {
+				if schema["additionalProperties"] == false {
+					*errs = append(*errs, fmt.Sprintf("%s: unknown property %q", path, k))
+				}
+				continue
+			}
+			checkValue(sub, v[k], path+"."+k, errs)
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				checkValue(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func typeAllowed(want any, got string) bool {
+	switch w := want.(type) {
+	case string:
+		return w == got || (w == "number" && got == "integer")
+	case []any:
+		for _, t := range w {
+			if typeAllowed(t, got) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func typeNames(want any) string {
+	if list, ok := want.([]any); ok {
+		names := make([]string, len(list))
+		for i, t := range list {
+			names[i] = fmt.Sprint(t)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(want)
+}
@@ -0,0 +1,57 @@
+package profiles
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaDescribesSwitchProfile(t *testing.T) {
+	s := Schema()
+	if s["$schema"] != SchemaDialect || s["additionalProperties"] != false {
+		t.Fatalf("schema header = %v", s)
+	}
+	if want := []any{"modelId", "roles", "ports", "profiles", "meta"}; !reflect.DeepEqual(s["required"], want) {
+		t.Errorf("required = %v, want %v", s["required"], want)
+	}
+	uplink := s["properties"].(map[string]any)["profiles"].(map[string]any)["properties"].(map[string]any)["uplink"].(map[string]any)
+	if got := uplink["required"]; !reflect.DeepEqual(got, []any{"portProfile", "speedGbps"}) {
+		t.Errorf("uplink required = %v; breakouts is optional", got)
+	}
+	portProfile := uplink["properties"].(map[string]any)["portProfile"].(map[string]any)
+	if !reflect.DeepEqual(portProfile["type"], []any{"string", "null"}) {
+		t.Errorf("portProfile type = %v", portProfile["type"])
+	}
+}
+
+func TestBuiltInsMatchSchema(t *testing.T) {
+	for _, p := range Default().List() {
+		data, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errs := CheckSchema(data); len(errs) > 0 {
+			t.Errorf("%s: %v", p.ModelID, errs)
+		}
+	}
+}
+
+func TestCheckSchemaReportsEveryProblem(t *testing.T) {
+	doc := `{"modelId":"x","roles":"leaf","ports":{"endpointAssignable":[1],"fabricAssignable":[]},
+		"profiles":{"endpoint":{"portProfile":null,"speedGbps":2.5},"uplink":{"portProfile":7,"speedGbps":100,"extra":true}},
+		"meta":{"source":"s"}}`
+	want := []string{
+		`$.meta: missing required property "version"`,
+		"$.ports.endpointAssignable[0]: expected string, got integer",
+		"$.profiles.endpoint.speedGbps: expected integer, got number",
+		`$.profiles.uplink: unknown property "extra"`,
+		"$.profiles.uplink.portProfile: expected string or null, got integer",
+		"$.roles: expected array, got string",
+	}
+	if got := CheckSchema([]byte(doc)); !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckSchema() =\n%q\nwant\n%q", got, want)
+	}
+	if got := CheckSchema([]byte("{")); len(got) != 1 {
+		t.Errorf("CheckSchema(truncated) = %q", got)
+	}
+}
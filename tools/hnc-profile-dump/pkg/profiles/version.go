@@ -0,0 +1,145 @@
+package profiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the fixture layout this package writes, recorded in
+// Meta.Version so readers know which fields to expect
+const SchemaVersion = "v0.5.0"
+
+// Schema versions this package can write. Each minor version is a layout;
+// patch releases never change fields.
+//
+//	v0.2.x  role (one string), ports.endpoint and ports.fabric
+//	v0.3.0  roles, ports.endpointAssignable and ports.fabricAssignable
+//	v0.4.0  adds profiles.*.breakouts, profiles.breakout and faceplate
+//	v0.5.0  adds profiles.roles, port profiles per role
+var SchemaVersions = []string{"v0.3.0", "v0.4.0", SchemaVersion}
+
+// oldestReadable is the first minor version Decode accepts without
+// migration; earlier files must go through Migrate
+const oldestReadable = 3
+
+// schemaMinor returns the minor version of "v0.N.P"
+func schemaMinor(version string) (int, error) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) != 3 || parts[0] != "0" {
+		return 0, fmt.Errorf("unknown schema version %q (want v0.N.P)", version)
+	}
+	for _, p := range parts[1:] {
+		if _, err := strconv.Atoi(p); err != nil {
+			return 0, fmt.Errorf("unknown schema version %q (want v0.N.P)", version)
+		}
+	}
+	minor, _ := strconv.Atoi(parts[1])
+	return minor, nil
+}
+
+// checkReadable reports whether Decode can read a file of this version
+// as is; an empty version is taken as current
+func checkReadable(version string) error {
+	if version == "" {
+		return nil
+	}
+	minor, err := schemaMinor(version)
+	if err != nil {
+		return err
+	}
+	current, _ := schemaMinor(SchemaVersion)
+	switch {
+	case minor < oldestReadable:
+		return fmt.Errorf("schema %s predates v0.%d.0; run `hnc-profile-dump migrate` to upgrade it to %s", version, oldestReadable, SchemaVersion)
+	case minor > current:
+		return fmt.Errorf("schema %s is newer than %s, the latest this tool reads", version, SchemaVersion)
+	}
+	return nil
+}
+
+// AtVersion returns the profile as the given schema version writes it,
+// dropping fields that version does not have
+func AtVersion(p SwitchProfile, version string) (SwitchProfile, error) {
+	supported := false
+	for _, v := range SchemaVersions {
+		supported = supported || v == version
+	}
+	if !supported {
+		return SwitchProfile{}, fmt.Errorf("cannot write schema %s (supported: %s)", version, strings.Join(SchemaVersions, ", "))
+	}
+	minor, _ := schemaMinor(version)
+	if minor < 5 {
+		p.Profiles.Roles = nil
+	}
+	if minor < 4 {
+		p.Faceplate = nil
+		p.Profiles.Breakout = nil
+		p.Profiles.Endpoint.Breakouts = nil
+		p.Profiles.Uplink.Breakouts = nil
+	}
+	p.Meta.Version = version
+	return p, nil
+}
+
+// AtVersion returns a registry of every profile at the given schema version
+func (r *Registry) AtVersion(version string) (*Registry, error) {
+	out, _ := NewRegistry()
+	for _, p := range r.List() {
+		converted, err := AtVersion(p, version)
+		if err != nil {
+			return nil, err
+		}
+		out.profiles[p.ModelID] = converted
+	}
+	return out, nil
+}
+
+// Migrate upgrades a JSON profile of any known schema to SchemaVersion
+// and returns it along with the version it was written at
+func Migrate(data []byte) (SwitchProfile, string, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return SwitchProfile{}, "", err
+	}
+	meta, _ := doc["meta"].(map[string]any)
+	from, _ := meta["version"].(string)
+	if from == "" {
+		return SwitchProfile{}, "", fmt.Errorf("meta.version is missing, so the schema is unknown")
+	}
+	minor, err := schemaMinor(from)
+	if err != nil {
+		return SwitchProfile{}, from, err
+	}
+	if minor < 2 {
+		return SwitchProfile{}, from, fmt.Errorf("schema %s is older than v0.2.0 and cannot be migrated", from)
+	}
+
+	if minor == 2 {
+		// v0.3.0 made role a list and spelled out the port list names
+		if role, ok := doc["role"]; ok {
+			doc["roles"] = []any{role}
+			delete(doc, "role")
+		}
+		if ports, ok := doc["ports"].(map[string]any); ok {
+			rename(ports, "endpoint", "endpointAssignable")
+			rename(ports, "fabric", "fabricAssignable")
+		}
+	}
+	// v0.4.0 and v0.5.0 only added optional fields
+
+	meta["version"] = SchemaVersion
+	if data, err = json.Marshal(doc); err != nil {
+		return SwitchProfile{}, from, err
+	}
+	p, err := Decode(data, ".json")
+	return p, from, err
+}
+
+func rename(m map[string]any, from, to string) {
+	if v, ok := m[from]; ok {
+		m[to] = v
+		delete(m, from)
+	}
+}
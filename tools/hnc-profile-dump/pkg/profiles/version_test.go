@@ -0,0 +1,71 @@
+package profiles
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// v02DS3000 is the DS3000 fixture as v0.2.x wrote it
+const v02DS3000 = `{"modelId":"celestica-ds3000","role":"spine",
+	"ports":{"endpoint":[],"fabric":["E1/1-32"]},
+	"profiles":{"endpoint":{"portProfile":null,"speedGbps":0},"uplink":{"portProfile":"QSFP28-100G","speedGbps":100}},
+	"meta":{"source":"switch_profile.go","version":"v0.2.1"}}`
+
+func TestMigrateFromV02(t *testing.T) {
+	p, from, err := Migrate([]byte(v02DS3000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != "v0.2.1" || p.Meta.Version != SchemaVersion {
+		t.Errorf("migrated %s -> %s, want v0.2.1 -> %s", from, p.Meta.Version, SchemaVersion)
+	}
+	if !reflect.DeepEqual(p.Roles, []string{"spine"}) || !reflect.DeepEqual(p.Ports.FabricAssignable, []string{"E1/1-32"}) {
+		t.Errorf("migrated profile = %+v", p)
+	}
+
+	// Current files pass through unchanged
+	data, _ := Marshal(DS2000())
+	if p, _, err := Migrate(data); err != nil || !reflect.DeepEqual(p, DS2000()) {
+		t.Errorf("Migrate(current DS2000) = %+v, %v", p, err)
+	}
+}
+
+func TestMigrateRejectsUnknownSchemas(t *testing.T) {
+	for version, want := range map[string]string{
+		"":       "meta.version is missing",
+		"v0.1.0": "cannot be migrated",
+		"1.0":    "unknown schema version",
+	} {
+		doc := strings.Replace(v02DS3000, `"version":"v0.2.1"`, `"version":"`+version+`"`, 1)
+		if _, _, err := Migrate([]byte(doc)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Migrate(%q) error = %v, want %q", version, err, want)
+		}
+	}
+}
+
+func TestDecodeChecksSchemaVersion(t *testing.T) {
+	if _, err := Decode([]byte(v02DS3000), ".json"); err == nil || !strings.Contains(err.Error(), "run `hnc-profile-dump migrate`") {
+		t.Errorf("Decode(v0.2.1) error = %v, want a pointer to migrate", err)
+	}
+	newer := strings.Replace(v02DS3000, "v0.2.1", "v0.9.0", 1)
+	if _, err := Decode([]byte(newer), ".json"); err == nil || !strings.Contains(err.Error(), "newer than") {
+		t.Errorf("Decode(v0.9.0) error = %v, want newer-than error", err)
+	}
+}
+
+func TestAtVersion(t *testing.T) {
+	old, err := AtVersion(DS2000(), "v0.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.Meta.Version != "v0.3.0" || old.Faceplate != nil || old.Profiles.Breakout != nil || old.Profiles.Uplink.Breakouts != nil {
+		t.Errorf("AtVersion(v0.3.0) kept later fields: %+v", old)
+	}
+	if p, _ := AtVersion(DS2000(), SchemaVersion); !reflect.DeepEqual(p, DS2000()) {
+		t.Errorf("AtVersion(%s) changed the profile", SchemaVersion)
+	}
+	if _, err := AtVersion(DS2000(), "v0.2.0"); err == nil {
+		t.Error("AtVersion(v0.2.0) wrote an unsupported schema")
+	}
+}
@@ -0,0 +1,42 @@
+// Package progress lets long-running package APIs report how far they
+// have got. A caller attaches a Reporter to the context it passes in, as
+// net/http/httptrace attaches a trace, so the APIs between it and the
+// work need no extra parameter; a context without one drops the reports.
+// Reports come from whichever goroutine did the work, so a Reporter
+// shared by concurrent calls must be safe for concurrent use.
+package progress
+
+import "context"
+
+// Event is one step of a long-running call
+type Event struct {
+	Stage string // what is being worked through, e.g. pairings or cables
+	Done  int    // items of the stage finished so far
+	Total int    // items in the stage; 0 when not known up front
+}
+
+// Reporter is told of every step a call takes
+type Reporter interface {
+	Progress(Event)
+}
+
+// Func adapts a function to a Reporter
+type Func func(Event)
+
+// Progress calls f
+func (f Func) Progress(e Event) { f(e) }
+
+type key struct{}
+
+// WithReporter is ctx carrying r, replacing any Reporter it carried
+func WithReporter(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, key{}, r)
+}
+
+// Report tells ctx's Reporter, if it has one, that done of total items of
+// stage are finished
+func Report(ctx context.Context, stage string, done, total int) {
+	if r, ok := ctx.Value(key{}).(Reporter); ok && r != nil {
+		r.Progress(Event{Stage: stage, Done: done, Total: total})
+	}
+}
@@ -0,0 +1,28 @@
+package progress
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	// Without a Reporter reports go nowhere
+	Report(context.Background(), "items", 1, 2)
+
+	var got []Event
+	ctx := WithReporter(context.Background(), Func(func(e Event) { got = append(got, e) }))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	Report(ctx, "items", 1, 2)
+	Report(ctx, "items", 2, 2)
+	if want := []Event{{"items", 1, 2}, {"items", 2, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v, want %+v", got, want)
+	}
+
+	// A nested Reporter replaces the outer one
+	Report(WithReporter(ctx, Func(func(Event) {})), "items", 3, 3)
+	if len(got) != 2 {
+		t.Errorf("outer Reporter got %d events, want 2", len(got))
+	}
+}
@@ -0,0 +1,39 @@
+// Package provenance makes generated artifacts reproducible. Generator
+// records which hnc wrote a file; the seed of its assignment choices is
+// recorded with the inputs it was generated from. Seeded choices come
+// from Perm, whose sequence for a seed and stream is fixed, so the same
+// inputs and seed at the same generator version give the same bytes.
+package provenance
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// Generator is what wrote an artifact
+type Generator struct {
+	Name    string `json:"name"`    // e.g. hnc cabling
+	Version string `json:"version"` // module version of the binary, (devel) for local builds
+}
+
+// New is the generator name at version
+func New(name, version string) *Generator {
+	return &Generator{Name: name, Version: version}
+}
+
+// Perm is the order of n choices for stream, e.g. a switch name, under
+// seed. Seed 0 keeps the canonical order 0, 1, ... n-1; any other seed
+// shuffles it, the same way every time, and each stream independently of
+// the others, so adding a stream leaves the rest as they were.
+func Perm(seed int64, stream string, n int) []int {
+	if seed == 0 {
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+		return order
+	}
+	h := fnv.New64a()
+	h.Write([]byte(stream))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64()))).Perm(n)
+}
@@ -0,0 +1,26 @@
+package provenance
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPerm(t *testing.T) {
+	if got := Perm(0, "leaf1", 4); !reflect.DeepEqual(got, []int{0, 1, 2, 3}) {
+		t.Errorf("Perm(0) = %v, want the canonical order", got)
+	}
+	a, again := Perm(42, "leaf1", 8), Perm(42, "leaf1", 8)
+	if !reflect.DeepEqual(a, again) {
+		t.Errorf("Perm(42) = %v, then %v", a, again)
+	}
+	sorted := append([]int(nil), a...)
+	sort.Ints(sorted)
+	if !reflect.DeepEqual(sorted, []int{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("Perm(42) = %v, not a permutation", a)
+	}
+	// A different seed or stream orders the choices differently
+	if reflect.DeepEqual(a, Perm(43, "leaf1", 8)) || reflect.DeepEqual(a, Perm(42, "leaf2", 8)) {
+		t.Errorf("Perm(42, leaf1) = %v, the same as another seed or stream", a)
+	}
+}
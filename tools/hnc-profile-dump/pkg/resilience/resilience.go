@@ -0,0 +1,262 @@
+// Package resilience reports the blast radius of single failures in a
+// cabled fabric plan: for every spine, leaf and leaf-spine link, and in a
+// multi-pod plan every super-spine, what losing it does to the endpoints
+// and to the fabric's bandwidth. Designs of the same cost can differ a lot
+// here, e.g. two spines against four.
+//
+// Bandwidth is counted as the plan counts it: a leaf carries the
+// bandwidth of every endpoint on it, both leaves of a pair the whole
+// pair's, so losing one leaf of a pair leaves its partner's
+// oversubscription as it was and only takes away the redundancy.
+package resilience
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/capacity"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/progress"
+	"github.com/hnc/profile-dump/pkg/utilization"
+)
+
+// Kinds of failure, in report order
+const (
+	Spine      = "spine"
+	Leaf       = "leaf"
+	Link       = "link"
+	SuperSpine = utilization.RoleSuperSpine
+)
+
+// Failure is what one failed component costs
+type Failure struct {
+	Kind      string `json:"kind"`
+	Component string `json:"component"` // a switch name, or a link as leaf1:E1/49 <-> spine1:E1/1
+	// Components is, in a worst-case report, how many of the kind were
+	// failed in turn to find this one
+	Components        int     `json:"components,omitempty"`
+	EndpointsDown     int     `json:"endpointsDown"`     // cut off from the fabric
+	EndpointsDegraded int     `json:"endpointsDegraded"` // dual-homed, left on one leaf
+	FabricGbps        int     `json:"fabricGbps"`        // leaf uplink bandwidth left
+	FabricPercent     float64 `json:"fabricPercent"`     // of the fabric's, rounded to 0.1
+	// Oversubscription is the most loaded leaf's that still has uplinks,
+	// rounded to 0.01; PodOversubscription the most loaded spine's toward
+	// the super-spines
+	Oversubscription    float64 `json:"oversubscription"`
+	PodOversubscription float64 `json:"podOversubscription,omitempty"`
+}
+
+// Report is a fabric's resilience: its bandwidth whole, and the failures
+type Report struct {
+	Endpoints           int       `json:"endpoints"`
+	FabricGbps          int       `json:"fabricGbps"`
+	Oversubscription    float64   `json:"oversubscription"`
+	PodOversubscription float64   `json:"podOversubscription,omitempty"`
+	Failures            []Failure `json:"failures"`
+}
+
+// fabric is the plan and map as Analyze fails them
+type fabric struct {
+	plan      fabricplan.Plan
+	m         cabling.Map
+	leaves    []string
+	endpoints map[string]int // on each leaf; a pair's on both
+	downGbps  map[string]int
+	laneGbps  int // of a leaf uplink
+	spineGbps int // of a spine uplink to a super-spine
+}
+
+// Analyze fails every component of the plan cabled as m in turn; spine is
+// the spine profile, for the speed of a multi-pod plan's spine uplinks
+func Analyze(plan fabricplan.Plan, m cabling.Map, spine profiles.SwitchProfile) (Report, error) {
+	return AnalyzeContext(context.Background(), plan, m, spine)
+}
+
+// AnalyzeContext is Analyze that stops once ctx is done, reporting each
+// component failed as a step of the failures stage. Stopped, it returns
+// the failures found so far with an error wrapping ctx's.
+func AnalyzeContext(ctx context.Context, plan fabricplan.Plan, m cabling.Map, spine profiles.SwitchProfile) (Report, error) {
+	if plan.Leaves <= 0 {
+		return Report{}, fmt.Errorf("the plan has no leaves")
+	}
+	f := fabric{plan: plan, m: m, endpoints: map[string]int{}, downGbps: map[string]int{}}
+	if plan.UplinksPerLeaf > 0 {
+		f.laneGbps = plan.UplinkGbpsPerLeaf / plan.UplinksPerLeaf
+	}
+	if plan.SuperSpines > 0 {
+		var err error
+		if _, f.spineGbps, err = capacity.FabricPorts(spine, ""); err != nil {
+			return Report{}, fmt.Errorf("spine %w", err)
+		}
+	}
+	for i := 0; i < plan.Leaves; i++ {
+		name := "leaf" + strconv.Itoa(i+1)
+		f.leaves = append(f.leaves, name)
+		slot, slots := i, plan.Leaves
+		if plan.LeafPairs > 0 {
+			slot, slots = i/2, plan.LeafPairs
+		}
+		n := min(max(plan.Request.Endpoints-slot*plan.EndpointsPerLeaf, 0), plan.EndpointsPerLeaf)
+		gbps := n * plan.Request.EndpointSpeedGbps
+		if len(plan.Placement) > 0 {
+			n, gbps = 0, 0
+			for _, p := range plan.Placement {
+				on, _ := p.OnLeaf(slot, slots)
+				n += on
+				gbps += on * p.SpeedGbps
+			}
+		}
+		f.endpoints[name], f.downGbps[name] = n, gbps
+	}
+
+	whole := f.fail(func(string) bool { return false })
+	r := Report{Endpoints: plan.Request.Endpoints, FabricGbps: whole.FabricGbps,
+		Oversubscription: whole.Oversubscription, PodOversubscription: whole.PodOversubscription}
+	var components [][2]string // kind, component
+	for i := 0; i < plan.Spines; i++ {
+		components = append(components, [2]string{Spine, "spine" + strconv.Itoa(i+1)})
+	}
+	for _, name := range f.leaves {
+		components = append(components, [2]string{Leaf, name})
+	}
+	for _, c := range m.Cables {
+		components = append(components, [2]string{Link, c.String()})
+	}
+	for i := 0; i < plan.SuperSpines; i++ {
+		components = append(components, [2]string{SuperSpine, "superspine" + strconv.Itoa(i+1)})
+	}
+	for i, c := range components {
+		if err := ctx.Err(); err != nil {
+			return r, fmt.Errorf("analysis stopped after %d of %d component(s): %w", i, len(components), err)
+		}
+		failure := f.fail(func(s string) bool { return s == c[1] })
+		failure.Kind, failure.Component = c[0], c[1]
+		r.Failures = append(r.Failures, failure)
+		progress.Report(ctx, "failures", i+1, len(components))
+	}
+	return r, nil
+}
+
+// fail is the fabric with the switches and links down reports down,
+// by name or as Cable.String. A leaf is cut off when it is down or, in a
+// fabric with spines, has no uplink left; a pair's endpoints are down
+// when both its leaves are, and degraded when one is.
+func (f fabric) fail(down func(string) bool) Failure {
+	uplinks := map[string]int{}
+	spineDown := map[string]int{}
+	for _, c := range f.m.Cables {
+		if down(c.Leaf) || down(c.Spine) || down(c.String()) {
+			continue
+		}
+		uplinks[c.Leaf]++
+		spineDown[c.Spine] += f.laneGbps
+	}
+	spineUp := map[string]int{}
+	for _, l := range f.m.SpineLinks {
+		if !down(l.Spine) && !down(l.SuperSpine) {
+			spineUp[l.Spine] += f.spineGbps
+		}
+	}
+
+	var out Failure
+	up := func(leaf string) bool {
+		return !down(leaf) && (uplinks[leaf] > 0 || f.plan.Spines == 0)
+	}
+	for i, name := range f.leaves {
+		if up(name) && uplinks[name] > 0 {
+			ratio := float64(f.downGbps[name]) / float64(uplinks[name]*f.laneGbps)
+			out.Oversubscription = max(out.Oversubscription, math.Round(ratio*100)/100)
+		}
+		out.FabricGbps += uplinks[name] * f.laneGbps
+		switch {
+		case f.plan.LeafPairs == 0:
+			if !up(name) {
+				out.EndpointsDown += f.endpoints[name]
+			}
+		case i%2 == 0:
+			// Count each pair's endpoints once, on its first leaf
+			switch a, b := up(name), up(f.leaves[i+1]); {
+			case !a && !b:
+				out.EndpointsDown += f.endpoints[name]
+			case !a || !b:
+				out.EndpointsDegraded += f.endpoints[name]
+			}
+		}
+	}
+	if total := f.plan.Leaves * f.plan.UplinkGbpsPerLeaf; total > 0 {
+		out.FabricPercent = math.Round(float64(out.FabricGbps)*1000/float64(total)) / 10
+	}
+	for spine, gbps := range spineDown {
+		if up := spineUp[spine]; up > 0 {
+			out.PodOversubscription = max(out.PodOversubscription, math.Round(float64(gbps)/float64(up)*100)/100)
+		}
+	}
+	return out
+}
+
+// Worst is the worst failure of each kind, in kind order, with how many
+// of the kind there were: the one that takes down the most endpoints,
+// then degrades the most, then leaves the least bandwidth and the highest
+// oversubscription; the first such in the plan's order on a tie
+func Worst(failures []Failure) []Failure {
+	var out []Failure
+	index := map[string]int{}
+	for _, f := range failures {
+		i, ok := index[f.Kind]
+		if !ok {
+			index[f.Kind] = len(out)
+			f.Components = 1
+			out = append(out, f)
+			continue
+		}
+		w := out[i]
+		if worse(f, w) {
+			f.Components = w.Components
+			w = f
+		}
+		w.Components++
+		out[i] = w
+	}
+	return out
+}
+
+// worse reports whether a costs more than b
+func worse(a, b Failure) bool {
+	if a.EndpointsDown != b.EndpointsDown {
+		return a.EndpointsDown > b.EndpointsDown
+	}
+	if a.EndpointsDegraded != b.EndpointsDegraded {
+		return a.EndpointsDegraded > b.EndpointsDegraded
+	}
+	if a.FabricGbps != b.FabricGbps {
+		return a.FabricGbps < b.FabricGbps
+	}
+	if a.Oversubscription != b.Oversubscription {
+		return a.Oversubscription > b.Oversubscription
+	}
+	return a.PodOversubscription > b.PodOversubscription
+}
+
+// CSVHeader is the first row of RenderCSV
+var CSVHeader = []string{"kind", "component", "components", "endpoints_down", "endpoints_degraded",
+	"fabric_gbps", "fabric_percent", "oversubscription", "pod_oversubscription"}
+
+// RenderCSV writes one row per failure
+func RenderCSV(r Report) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(CSVHeader)
+	for _, f := range r.Failures {
+		w.Write([]string{f.Kind, f.Component, strconv.Itoa(f.Components), strconv.Itoa(f.EndpointsDown), strconv.Itoa(f.EndpointsDegraded),
+			strconv.Itoa(f.FabricGbps), strconv.FormatFloat(f.FabricPercent, 'f', 1, 64),
+			strconv.FormatFloat(f.Oversubscription, 'f', 2, 64), strconv.FormatFloat(f.PodOversubscription, 'f', 2, 64)})
+	}
+	w.Flush()
+	return b.String()
+}
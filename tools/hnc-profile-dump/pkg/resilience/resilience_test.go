@@ -0,0 +1,100 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/progress"
+)
+
+func analyze(t *testing.T, req fabricplan.Request) Report {
+	t.Helper()
+	leaf, spine := profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine)
+	plan, err := fabricplan.Compute(req, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := cabling.Assign(plan, leaf, spine, cabling.RoundRobin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Analyze(plan, m, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestAnalyze(t *testing.T) {
+	// 5 leaves of 40 x 25G, each with 4 x 100G uplinks over 2 spines
+	r := analyze(t, fabricplan.Request{Endpoints: 200, Oversubscription: 3})
+	if r.FabricGbps != 2000 || r.Oversubscription != 2.5 || len(r.Failures) != 2+5+20 {
+		t.Fatalf("report = %+v", r)
+	}
+	want := []Failure{
+		{Kind: Spine, Component: "spine1", Components: 2, FabricGbps: 1000, FabricPercent: 50, Oversubscription: 5},
+		{Kind: Leaf, Component: "leaf1", Components: 5, EndpointsDown: 40, FabricGbps: 1600, FabricPercent: 80, Oversubscription: 2.5},
+		{Kind: Link, Component: "leaf1:E1/49 <-> spine1:E1/1", Components: 20, FabricGbps: 1900, FabricPercent: 95, Oversubscription: 3.33},
+	}
+	if got := Worst(r.Failures); !reflect.DeepEqual(got, want) {
+		t.Errorf("Worst() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	csv := RenderCSV(Report{Failures: want[:1]})
+	if line := strings.Join(CSVHeader, ",") + "\nspine,spine1,2,0,0,1000,50.0,5.00,0.00\n"; csv != line {
+		t.Errorf("CSV =\n%s", csv)
+	}
+}
+
+func TestAnalyzePairs(t *testing.T) {
+	// A leaf of a pair leaves its endpoints on the other
+	r := analyze(t, fabricplan.Request{Endpoints: 200, Oversubscription: 3, Redundancy: fabricplan.MCLAG})
+	worst := Worst(r.Failures)
+	if leaf := worst[1]; leaf.EndpointsDown != 0 || leaf.EndpointsDegraded != 40 || leaf.Oversubscription != r.Oversubscription {
+		t.Errorf("leaf failure = %+v", leaf)
+	}
+
+	single, err := fabricplan.Compute(fabricplan.Request{Endpoints: 30, Topology: fabricplan.SingleSwitch}, profiles.DS2000(), profiles.SwitchProfile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Analyze(single, cabling.Map{}, profiles.SwitchProfile{})
+	if err != nil || len(s.Failures) != 1 || s.Failures[0].EndpointsDown != 30 {
+		t.Errorf("single switch = %+v, %v", s, err)
+	}
+}
+
+func TestAnalyzeContextStops(t *testing.T) {
+	leaf, spine := profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine)
+	plan, err := fabricplan.Compute(fabricplan.Request{Endpoints: 200, Oversubscription: 3}, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := cabling.Assign(plan, leaf, spine, cabling.RoundRobin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = progress.WithReporter(ctx, progress.Func(func(e progress.Event) {
+		if e.Stage != "failures" || e.Total != 2+5+20 {
+			t.Errorf("event %+v", e)
+		}
+		if e.Done == 3 {
+			cancel()
+		}
+	}))
+	r, err := AnalyzeContext(ctx, plan, m, spine)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "stopped after 3 of 27 component(s)") {
+		t.Fatalf("AnalyzeContext cancelled = %v", err)
+	}
+	if len(r.Failures) != 3 || r.Failures[2].Component != "leaf1" {
+		t.Errorf("partial failures = %+v", r.Failures)
+	}
+}
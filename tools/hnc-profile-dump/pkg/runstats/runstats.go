@@ -0,0 +1,140 @@
+// Package runstats summarizes one hnc run for tracking planner
+// performance across versions: what it generated (switches, the switch
+// ports they use, cables and files), how long each stage took and what
+// memory it allocated, as JSON a benchmark harness such as tools/hnc-bench
+// compares between builds. Nothing is collected unless a run asks for it,
+// and nothing leaves the machine: the summary is only written to a file.
+package runstats
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+)
+
+// Version is the version of the Stats format; it changes when a field
+// changes meaning, so harnesses do not compare unlike numbers
+const Version = 1
+
+// Stage is the time spent in one stage of a run, e.g. load or write
+type Stage struct {
+	Name   string  `json:"name"`
+	Millis float64 `json:"ms"`
+	Count  int     `json:"count"` // times the stage ran
+}
+
+// Memory is the Go runtime's view of the run's memory when it ended
+type Memory struct {
+	TotalAllocBytes uint64 `json:"totalAllocBytes"` // allocated over the run
+	HeapAllocBytes  uint64 `json:"heapAllocBytes"`  // live at the end
+	SysBytes        uint64 `json:"sysBytes"`        // obtained from the OS
+	Mallocs         uint64 `json:"mallocs"`
+	GCs             uint32 `json:"gcs"`
+}
+
+// Stats is the summary of one run
+type Stats struct {
+	StatsVersion int    `json:"statsVersion"`
+	Command      string `json:"command"`
+	Version      string `json:"version"` // of hnc
+	GoVersion    string `json:"goVersion"`
+	ExitCode     int    `json:"exitCode"`
+	// Switches and Ports count the fabric of the last plan the run made
+	// or read: its switches, and the switch ports its endpoints, external
+	// uplinks and fabric links take, both ends of each fabric link
+	Switches  int     `json:"switches"`
+	Ports     int     `json:"ports"`
+	Cables    int     `json:"cables"` // of the last cabling map
+	Files     int     `json:"files"`  // generated, written or not
+	Bytes     int     `json:"bytes"`
+	ElapsedMs float64 `json:"elapsedMs"`
+	Stages    []Stage `json:"stages"` // in the order they first ran
+	Memory    Memory  `json:"memory"`
+}
+
+// Recorder collects the stats of a run as it goes. A nil Recorder
+// records nothing, so callers need not check whether stats were asked
+// for. It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	start time.Time
+	stats Stats
+}
+
+// New starts recording a run of command, built as version
+func New(command, version string) *Recorder {
+	return &Recorder{start: time.Now(), stats: Stats{StatsVersion: Version, Command: command, Version: version, GoVersion: runtime.Version()}}
+}
+
+// Stage starts timing a stage; calling the function it returns ends it.
+// A stage run again adds to its time.
+func (r *Recorder) Stage(name string) (done func()) {
+	if r == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		elapsed := float64(time.Since(start).Microseconds()) / 1000
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i := range r.stats.Stages {
+			if s := &r.stats.Stages[i]; s.Name == name {
+				s.Millis += elapsed
+				s.Count++
+				return
+			}
+		}
+		r.stats.Stages = append(r.stats.Stages, Stage{Name: name, Millis: elapsed, Count: 1})
+	}
+}
+
+// Plan counts the switches of a plan and the ports they use
+func (r *Recorder) Plan(p fabricplan.Plan) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Switches = p.Leaves + p.Spines + p.SuperSpines
+	// A peer link's two ends are both among the leaves' peer ports
+	uplinks := p.Leaves*p.UplinksPerLeaf + p.Spines*p.SpineUplinks
+	r.stats.Ports = p.EndpointPorts() + p.ExternalPorts() + 2*uplinks + p.Leaves*p.PeerLinksPerLeaf
+}
+
+// Cabling counts the cables of a map, of every kind
+func (r *Recorder) Cabling(m cabling.Map) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Cables = len(m.Cables) + len(m.PeerLinks) + len(m.SpineLinks) + len(m.ExternalLinks) + len(m.ServerLinks)
+}
+
+// File counts a generated file of n bytes
+func (r *Recorder) File(n int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Files++
+	r.stats.Bytes += n
+}
+
+// Finish is the stats of the run, ended now with exitCode
+func (r *Recorder) Finish(exitCode int) Stats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats
+	s.Stages = append([]Stage{}, s.Stages...)
+	s.ExitCode = exitCode
+	s.ElapsedMs = float64(time.Since(r.start).Microseconds()) / 1000
+	s.Memory = Memory{TotalAllocBytes: m.TotalAlloc, HeapAllocBytes: m.HeapAlloc, SysBytes: m.Sys, Mallocs: m.Mallocs, GCs: m.NumGC}
+	return s
+}
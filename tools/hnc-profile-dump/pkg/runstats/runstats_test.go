@@ -0,0 +1,51 @@
+package runstats
+
+import (
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func TestRecorder(t *testing.T) {
+	leaf, spine := profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine)
+	plan, err := fabricplan.Compute(fabricplan.Request{Endpoints: 96, Oversubscription: 3, Redundancy: fabricplan.MCLAG}, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := cabling.Assign(plan, leaf, spine, cabling.RoundRobin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New("hnc plan", "v1.0.0")
+	for _, stage := range []string{"load", "plan", "load"} {
+		r.Stage(stage)()
+	}
+	r.Plan(plan)
+	r.Cabling(m)
+	r.File(100)
+	r.File(20)
+	s := r.Finish(0)
+	if s.StatsVersion != Version || s.Command != "hnc plan" || s.Files != 2 || s.Bytes != 120 || s.Memory.TotalAllocBytes == 0 {
+		t.Errorf("stats = %+v", s)
+	}
+	if len(s.Stages) != 2 || s.Stages[0].Name != "load" || s.Stages[0].Count != 2 || s.Stages[1].Name != "plan" {
+		t.Errorf("stages = %+v", s.Stages)
+	}
+	if s.Switches != plan.Leaves+plan.Spines || s.Cables != len(m.Cables)+len(m.PeerLinks) {
+		t.Errorf("%d switches and %d cables, want %d and %d", s.Switches, s.Cables, plan.Leaves+plan.Spines, len(m.Cables)+len(m.PeerLinks))
+	}
+	// Every cabled port is counted once, and so is every endpoint port
+	if want := 2*(len(m.Cables)+len(m.PeerLinks)) + plan.EndpointPorts(); s.Ports != want {
+		t.Errorf("%d ports, want %d", s.Ports, want)
+	}
+
+	// A nil Recorder records nothing
+	var none *Recorder
+	none.Stage("load")()
+	none.Plan(plan)
+	none.Cabling(m)
+	none.File(1)
+}
@@ -0,0 +1,199 @@
+// Package scenario keeps named fabric plans, with the files they were
+// planned from, in a directory, so what-if designs can be saved, listed,
+// restored and compared with hnc plan diff. Each scenario is a directory
+// of its own, holding scenario.json and the saved files under their own
+// names, so the plan files in the store are the same files hnc writes
+// and every other command reads them as they are.
+//
+// The store is a plain directory rather than a database file: the module
+// takes no dependencies, and a directory diffs and backs up like any
+// other.
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+)
+
+// Files a scenario holds under these names
+const (
+	MetaFile    = "scenario.json"
+	PlanFile    = "fabric-plan.json"
+	CablingFile = "cabling.json"
+	VPCsFile    = "vpc-plan.json"
+)
+
+// Scenario is a saved design's scenario.json
+type Scenario struct {
+	Name    string    `json:"name"`
+	Note    string    `json:"note,omitempty"`
+	SavedAt time.Time `json:"savedAt"`
+	Files   []string  `json:"files"` // saved beside scenario.json, fabric-plan.json first
+}
+
+// Has reports whether the scenario saved a file
+func (s Scenario) Has(file string) bool {
+	for _, f := range s.Files {
+		if f == file {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNotFound is returned for a scenario the store does not have
+var ErrNotFound = errors.New("no such scenario")
+
+// validName is what a scenario may be named, so the name is a directory
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// CheckName reports whether name can name a scenario
+func CheckName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("scenario name %q is not 1 to 64 letters, digits, dots, hyphens and underscores, starting with a letter or digit", name)
+	}
+	return nil
+}
+
+// Store is a directory of scenarios
+type Store struct {
+	Dir string
+}
+
+// Path is where a scenario keeps file
+func (st Store) Path(name, file string) string {
+	return filepath.Join(st.Dir, name, file)
+}
+
+// Save stores files, which must include the plan, as scenario s; its
+// SavedAt is set and its Files listed from them. A scenario already of
+// that name is an error, unless replace. The files appear all at once: a
+// failed save leaves the store as it was.
+func (st Store) Save(s Scenario, files map[string][]byte, replace bool) (Scenario, error) {
+	if err := CheckName(s.Name); err != nil {
+		return s, err
+	}
+	if _, ok := files[PlanFile]; !ok {
+		return s, fmt.Errorf("scenario %s has no %s", s.Name, PlanFile)
+	}
+	s.Files = []string{PlanFile}
+	for name := range files {
+		if name == MetaFile || !filepath.IsLocal(name) || filepath.Base(name) != name {
+			return s, fmt.Errorf("scenario %s cannot save a file named %s", s.Name, name)
+		}
+		if name != PlanFile {
+			s.Files = append(s.Files, name)
+		}
+	}
+	sort.Strings(s.Files[1:])
+	s.SavedAt = s.SavedAt.UTC().Truncate(time.Second)
+
+	target := filepath.Join(st.Dir, s.Name)
+	if _, err := os.Stat(target); err == nil && !replace {
+		return s, fmt.Errorf("scenario %s already exists", s.Name)
+	}
+	if err := os.MkdirAll(st.Dir, 0755); err != nil {
+		return s, err
+	}
+	tmp, err := os.MkdirTemp(st.Dir, "."+s.Name+"-")
+	if err != nil {
+		return s, err
+	}
+	defer os.RemoveAll(tmp)
+	meta, err := canonjson.Marshal(s)
+	if err != nil {
+		return s, err
+	}
+	files[MetaFile] = meta
+	defer delete(files, MetaFile)
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tmp, name), data, 0644); err != nil {
+			return s, err
+		}
+	}
+	if err := os.Chmod(tmp, 0755); err != nil {
+		return s, err
+	}
+	if replace {
+		if err := os.RemoveAll(target); err != nil {
+			return s, err
+		}
+	}
+	return s, os.Rename(tmp, target)
+}
+
+// Get reads a scenario's scenario.json
+func (st Store) Get(name string) (Scenario, error) {
+	var s Scenario
+	if err := CheckName(name); err != nil {
+		return s, err
+	}
+	data, err := os.ReadFile(st.Path(name, MetaFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, fmt.Errorf("%w %s in %s", ErrNotFound, name, st.Dir)
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("parsing %s: %w", st.Path(name, MetaFile), err)
+	}
+	return s, nil
+}
+
+// List is every scenario in the store, by name; a store not yet created
+// has none
+func (st Store) List() ([]Scenario, error) {
+	entries, err := os.ReadDir(st.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []Scenario
+	for _, e := range entries {
+		// Dot directories are saves in progress
+		if !e.IsDir() || CheckName(e.Name()) != nil {
+			continue
+		}
+		s, err := st.Get(e.Name())
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// Read returns a file a scenario saved
+func (st Store) Read(name, file string) ([]byte, error) {
+	s, err := st.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Has(file) {
+		return nil, fmt.Errorf("scenario %s has no %s", name, file)
+	}
+	return os.ReadFile(st.Path(name, file))
+}
+
+// Delete removes a scenario and its files
+func (st Store) Delete(name string) error {
+	if _, err := st.Get(name); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(st.Dir, name))
+}
@@ -0,0 +1,82 @@
+package scenario
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	st := Store{Dir: filepath.Join(t.TempDir(), "scenarios")}
+	if list, err := st.List(); err != nil || len(list) != 0 {
+		t.Fatalf("List() of a store not yet created = %v, %v", list, err)
+	}
+	saved := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+	files := map[string][]byte{PlanFile: []byte(`{"leaves":4}`), CablingFile: []byte(`{}`), "layout.json": []byte(`{}`)}
+	s, err := st.Save(Scenario{Name: "dual-spine", Note: "two spines", SavedAt: saved}, files, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{PlanFile, CablingFile, "layout.json"}; !reflect.DeepEqual(s.Files, want) {
+		t.Errorf("Save() files = %v, want %v", s.Files, want)
+	}
+	if _, err := st.Save(Scenario{Name: "dual-spine"}, files, false); err == nil {
+		t.Error("Save() over an existing scenario succeeded")
+	}
+	if _, err := st.Save(Scenario{Name: "b"}, map[string][]byte{CablingFile: nil}, false); err == nil {
+		t.Error("Save() without a plan succeeded")
+	}
+	if _, err := st.Save(Scenario{Name: "c"}, map[string][]byte{PlanFile: nil, "../x": nil}, false); err == nil {
+		t.Error("Save() of a file outside the scenario succeeded")
+	}
+	if _, err := st.Save(Scenario{Name: "quad-spine", SavedAt: saved}, map[string][]byte{PlanFile: []byte(`{"leaves":8}`)}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.Get("dual-spine")
+	if err != nil || !reflect.DeepEqual(got, s) {
+		t.Errorf("Get() = %+v, %v, want %+v", got, err, s)
+	}
+	if data, err := st.Read("dual-spine", PlanFile); err != nil || string(data) != `{"leaves":4}` {
+		t.Errorf("Read() = %s, %v", data, err)
+	}
+	if _, err := st.Read("quad-spine", CablingFile); err == nil {
+		t.Error("Read() of a file the scenario did not save succeeded")
+	}
+	list, err := st.List()
+	if err != nil || len(list) != 2 || list[0].Name != "dual-spine" || list[1].Name != "quad-spine" {
+		t.Errorf("List() = %+v, %v", list, err)
+	}
+
+	// Replacing drops the files the new save does not have
+	if _, err := st.Save(Scenario{Name: "dual-spine"}, map[string][]byte{PlanFile: []byte(`{"leaves":6}`)}, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(st.Path("dual-spine", CablingFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("replaced scenario kept %s: %v", CablingFile, err)
+	}
+	if err := st.Delete("dual-spine"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Get("dual-spine"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a deleted scenario = %v, want ErrNotFound", err)
+	}
+	if err := st.Delete("dual-spine"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of a deleted scenario = %v, want ErrNotFound", err)
+	}
+	entries, _ := os.ReadDir(st.Dir)
+	if len(entries) != 1 {
+		t.Errorf("store holds %d entries, want quad-spine alone", len(entries))
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	for name, ok := range map[string]bool{"a": true, "v2.1_what-if": true, "": false, ".hidden": false, "a/b": false, "..": false, "a b": false} {
+		if err := CheckName(name); (err == nil) != ok {
+			t.Errorf("CheckName(%q) = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package schemadoc renders a reference for JSON and YAML formats from the
+// Go types that define them: field names and optionality come from json
+// tags, descriptions from doc tags, so the reference cannot drift from
+// what the tools read and write.
+package schemadoc
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+	"strings"
+)
+
+// Doc is the reference for one document format
+type Doc struct {
+	Title   string
+	Summary string
+	Fields  []Field
+}
+
+// Field is one property, flattened to its path from the document root
+type Field struct {
+	Path        string // e.g. profiles.endpoint.breakouts[].mode; map keys are *
+	Type        string // e.g. "string", "array of string", "object"
+	Required    bool
+	Nullable    bool
+	Description string
+}
+
+// Describe documents the type of v
+func Describe(title, summary string, v any) Doc {
+	doc := Doc{Title: title, Summary: summary}
+	walk(reflect.TypeOf(v), "", &doc.Fields)
+	return doc
+}
+
+func walk(t reflect.Type, prefix string, fields *[]Field) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && name == "" {
+			walk(f.Type, prefix, fields)
+			continue
+		}
+		if name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+		ft, nullable := f.Type, false
+		if ft.Kind() == reflect.Pointer {
+			ft, nullable = ft.Elem(), true
+		}
+		path := prefix + name
+		*fields = append(*fields, Field{
+			Path:        path,
+			Type:        typeName(ft),
+			Required:    !strings.Contains(opts, "omitempty"),
+			Nullable:    nullable,
+			Description: f.Tag.Get("doc"),
+		})
+		for ft.Kind() == reflect.Slice || ft.Kind() == reflect.Map {
+			if ft.Kind() == reflect.Map {
+				ft, path = ft.Elem(), path+".*"
+			} else {
+				ft, path = ft.Elem(), path+"[]"
+			}
+		}
+		if ft.Kind() == reflect.Struct {
+			walk(ft, path+".", fields)
+		}
+	}
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeName(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		return "array of " + typeName(t.Elem())
+	case reflect.Map:
+		return "map of " + typeName(t.Key()) + " to " + typeName(t.Elem())
+	case reflect.Struct:
+		return "object"
+	}
+	panic(fmt.Sprintf("schemadoc: no type name for %s", t))
+}
+
+// Markdown renders the docs as one Markdown page, a table per document
+func Markdown(title, intro string, docs []Doc) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%s\n", title, intro)
+	for _, d := range docs {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n\n", d.Title, d.Summary)
+		b.WriteString("| Field | Type | Required | Description |\n|---|---|---|---|\n")
+		for _, f := range d.Fields {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", f.Path, f.typeLabel(), yesNo(f.Required), strings.ReplaceAll(f.Description, "|", `\|`))
+		}
+	}
+	return b.String()
+}
+
+// HTML renders the docs as one standalone HTML page with a table of
+// contents, for publishing alongside release artifacts
+func HTML(title, intro string, docs []Doc) string {
+	var b strings.Builder
+	e := html.EscapeString
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", e(title))
+	b.WriteString("<style>body{font-family:sans-serif;max-width:60em;margin:2em auto;padding:0 1em}" +
+		"table{border-collapse:collapse;width:100%}th,td{border:1px solid #ccc;padding:.3em .5em;text-align:left;vertical-align:top}" +
+		"code{white-space:nowrap}</style>\n</head>\n<body>\n")
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<p>%s</p>\n<ul>\n", e(title), e(intro))
+	for _, d := range docs {
+		fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a></li>\n", anchor(d.Title), e(d.Title))
+	}
+	b.WriteString("</ul>\n")
+	for _, d := range docs {
+		fmt.Fprintf(&b, "<h2 id=\"%s\">%s</h2>\n<p>%s</p>\n", anchor(d.Title), e(d.Title), e(d.Summary))
+		b.WriteString("<table>\n<tr><th>Field</th><th>Type</th><th>Required</th><th>Description</th></tr>\n")
+		for _, f := range d.Fields {
+			fmt.Fprintf(&b, "<tr><td><code>%s</code></td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				e(f.Path), e(f.typeLabel()), yesNo(f.Required), e(f.Description))
+		}
+		b.WriteString("</table>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+func (f Field) typeLabel() string {
+	if f.Nullable {
+		return f.Type + " or null"
+	}
+	return f.Type
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// anchor is a fragment id for a heading: lower case, runs of anything
+// else than letters and digits as one hyphen
+func anchor(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
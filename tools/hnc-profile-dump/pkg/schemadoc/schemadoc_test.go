@@ -0,0 +1,58 @@
+package schemadoc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type labeled struct {
+	Labels map[string]string `json:"labels,omitempty" doc:"Free-form labels"`
+}
+
+type port struct {
+	Name  string `json:"name" doc:"Port name"`
+	Speed *int   `json:"speed" doc:"Speed | Gbps"`
+}
+
+type device struct {
+	ID       string `json:"id" doc:"Device id"`
+	Ports    []port `json:"ports,omitempty"`
+	internal string
+	Skipped  string   `json:"-"`
+	Tags     []string `json:"tags"`
+	labeled
+}
+
+func TestDescribe(t *testing.T) {
+	got := Describe("Device", "A device", device{}).Fields
+	want := []Field{
+		{Path: "id", Type: "string", Required: true, Description: "Device id"},
+		{Path: "ports", Type: "array of object"},
+		{Path: "ports[].name", Type: "string", Required: true, Description: "Port name"},
+		{Path: "ports[].speed", Type: "integer", Required: true, Nullable: true, Description: "Speed | Gbps"},
+		{Path: "tags", Type: "array of string", Required: true},
+		{Path: "labels", Type: "map of string to string", Description: "Free-form labels"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Describe() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	docs := []Doc{Describe("Device <v1>", "A device", device{})}
+
+	md := Markdown("Reference", "Intro", docs)
+	for _, want := range []string{"# Reference\n", "## Device <v1>\n", "| `ports[].speed` | integer or null | yes | Speed \\| Gbps |\n"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	page := HTML("Reference", "Intro", docs)
+	for _, want := range []string{`<a href="#device-v1">Device &lt;v1&gt;</a>`, `<h2 id="device-v1">`, "<td><code>ports[].speed</code></td><td>integer or null</td>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML missing %q:\n%s", want, page)
+		}
+	}
+}
@@ -0,0 +1,479 @@
+// Package server serves the profile registry and fabric planning over a
+// small HTTP+JSON API, so the frontend can call the Go logic live instead
+// of bundling fixtures that go stale:
+//
+//	GET  /version             the API version and the oldest client
+//	                          version served, as Version
+//	GET  /profiles            every profile, as the JSON array the
+//	                          frontend's registry mode reads
+//	GET  /profiles/{modelId}  one profile, by model ID or short name
+//	POST /plan                a fabricplan.Request plus leafModel and
+//	                          spineModel, answered with the plan
+//	POST /admin/reload        reload the catalog, when Options.Reload is
+//	                          set, answered with what changed and the
+//	                          designs it breaks
+//
+// Responses are canonical JSON. Errors are {"error": "..."} with 400 for a
+// malformed request, 401 for an admin request without Options.AdminToken,
+// 403 for one from another host when there is no token, 404 for an
+// unknown path or model, 405 for a wrong method, 413 for a body over the size limit, 422 when the design is over
+// the endpoint limit, no fabric fits the request or a reloaded catalog does
+// not load, and 429, with Retry-After, when the client already has as many
+// plans computing as it may.
+//
+// Every response carries the API version in the HNC-API-Version header,
+// and clients send theirs in the same header. The version goes up when a
+// request or response changes incompatibly. A client that sends a version
+// older than MinClientVersion is still answered, with a Warning header
+// telling it to upgrade; one that sends none is taken to be current.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+	"github.com/hnc/profile-dump/pkg/constraints"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Default limits, for Options that set none. MaxRequestBytes is far above
+// any real plan request, and MaxEndpoints above any fabric HNC plans.
+const (
+	MaxRequestBytes = 1 << 20
+	MaxEndpoints    = 50000
+	MaxConcurrent   = 2
+)
+
+// API versioning; see the package documentation
+const (
+	APIVersion       = 1
+	MinClientVersion = 1
+	VersionHeader    = "HNC-API-Version"
+)
+
+// Version is the body of GET /version
+type Version struct {
+	APIVersion       int    `json:"apiVersion"`
+	MinClientVersion int    `json:"minClientVersion"`
+	SchemaVersion    string `json:"schemaVersion"` // of the profiles served
+	HNC              string `json:"hnc,omitempty"` // build of hnc serving, from Options.Build
+}
+
+// PlanRequest is the body of POST /plan. Models default to DS2000 and
+// DS3000, as hnc plan's do.
+type PlanRequest struct {
+	fabricplan.Request
+	LeafModel  string `json:"leafModel,omitempty"`
+	SpineModel string `json:"spineModel,omitempty"`
+}
+
+// Options configure the handler
+type Options struct {
+	// Build is the hnc build GET /version reports (default: none)
+	Build string
+	// AllowOrigin is sent as Access-Control-Allow-Origin so a frontend
+	// served from another origin (e.g. the Vite dev server) may call the
+	// API; empty sends none
+	AllowOrigin string
+	// Reload loads and validates the catalog afresh, for Server.Reload;
+	// nil when there is nothing to reload, e.g. a stream read from stdin
+	Reload func() (*profiles.Registry, error)
+	// Designs loads, by name, the designs a reload checks again when it
+	// removes or changes one of their models; nil for none
+	Designs func() (map[string]fabricplan.Plan, error)
+	// AdminToken is the bearer token POST /admin/reload must carry; empty
+	// takes the request from a loopback address only
+	AdminToken string
+	// MaxRequestBytes bounds a POST body (default MaxRequestBytes)
+	MaxRequestBytes int64
+	// MaxEndpoints bounds the endpoints of a planned design (default
+	// MaxEndpoints)
+	MaxEndpoints int
+	// MaxConcurrent bounds the plans one client may have computing at once
+	// (default MaxConcurrent). The API has no users, so a client is a
+	// remote IP address; behind a proxy every client is the proxy.
+	MaxConcurrent int
+}
+
+// Server is the API handler. Its catalog can be swapped while it serves:
+// each request sees one catalog throughout.
+type Server struct {
+	registry atomic.Pointer[profiles.Registry]
+	reload   func() (*profiles.Registry, error)
+	reloads  sync.Mutex
+	handler  http.Handler
+	limits   Options
+	// computing counts the plans each client has in flight
+	computing   map[string]int
+	computingMu sync.Mutex
+}
+
+// Reloaded is what a reload changed, by model ID, and the designs of
+// Options.Designs it checked again for using a removed or changed model
+type Reloaded struct {
+	Profiles int       `json:"profiles"`
+	Added    []string  `json:"added"`
+	Removed  []string  `json:"removed"`
+	Changed  []string  `json:"changed"`
+	Designs  []Recheck `json:"designs"`
+}
+
+// Recheck is a design checked again after a reload: the removed or
+// changed models it uses, and the problems the new catalog finds in it
+// that the old one did not, none when it still fits
+type Recheck struct {
+	Design   string   `json:"design"`
+	Models   []string `json:"models"`
+	Problems []string `json:"problems"`
+}
+
+// Handler serves the API over registry
+func Handler(registry *profiles.Registry, opts Options) http.Handler {
+	return New(registry, opts)
+}
+
+// New is the API over registry
+func New(registry *profiles.Registry, opts Options) *Server {
+	if opts.MaxRequestBytes <= 0 {
+		opts.MaxRequestBytes = MaxRequestBytes
+	}
+	if opts.MaxEndpoints <= 0 {
+		opts.MaxEndpoints = MaxEndpoints
+	}
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = MaxConcurrent
+	}
+	s := &Server{reload: opts.Reload, limits: opts, computing: map[string]int{}}
+	s.registry.Store(registry)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", s.method(http.MethodGet, s.version))
+	mux.HandleFunc("/profiles", s.method(http.MethodGet, s.listProfiles))
+	mux.HandleFunc("/profiles/", s.method(http.MethodGet, s.getProfile))
+	mux.HandleFunc("/plan", s.method(http.MethodPost, s.plan))
+	if s.reload != nil {
+		mux.HandleFunc("/admin/reload", s.method(http.MethodPost, s.reloadCatalog))
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint %s", r.URL.Path))
+	})
+	s.handler = mux
+	if opts.AllowOrigin == "" {
+		return s
+	}
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", opts.AllowOrigin)
+		w.Header().Set("Access-Control-Expose-Headers", VersionHeader+", Warning")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+VersionHeader)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(VersionHeader, strconv.Itoa(APIVersion))
+	if sent := r.Header.Get(VersionHeader); sent != "" {
+		client, err := strconv.Atoi(sent)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%s %q is not a version number", VersionHeader, sent))
+			return
+		}
+		if client < MinClientVersion {
+			w.Header().Add("Warning", fmt.Sprintf(`299 - "API version %d is deprecated; this server speaks %d to %d, so upgrade the client"`, client, MinClientVersion, APIVersion))
+		}
+	}
+	s.handler.ServeHTTP(w, r)
+}
+
+// Reload loads the catalog with Options.Reload and, once it has loaded
+// and validated in full, swaps it in for the requests that follow, then
+// checks the designs of Options.Designs that use a model it removed or
+// changed. A catalog or design that fails to load leaves the catalog
+// being served in place.
+func (s *Server) Reload() (Reloaded, error) {
+	if s.reload == nil {
+		return Reloaded{}, errors.New("the catalog cannot be reloaded")
+	}
+	s.reloads.Lock()
+	defer s.reloads.Unlock()
+	next, err := s.reload()
+	if err != nil {
+		return Reloaded{}, fmt.Errorf("catalog not reloaded: %w", err)
+	}
+	var designs map[string]fabricplan.Plan
+	if s.limits.Designs != nil {
+		if designs, err = s.limits.Designs(); err != nil {
+			return Reloaded{}, fmt.Errorf("catalog not reloaded: %w", err)
+		}
+	}
+	prev := s.registry.Swap(next)
+	r, err := diff(prev, next)
+	if err != nil {
+		return r, err
+	}
+	r.Designs = recheck(designs, prev, next, append(slices.Clone(r.Removed), r.Changed...))
+	return r, nil
+}
+
+// recheck checks, in name order, each design using one of models in prev
+// against next
+func recheck(designs map[string]fabricplan.Plan, prev, next *profiles.Registry, models []string) []Recheck {
+	names := make([]string, 0, len(designs))
+	for name := range designs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	checks := []Recheck{}
+	for _, name := range names {
+		plan := designs[name]
+		check := Recheck{Design: name, Models: []string{}, Problems: []string{}}
+		// Plans may name a model by its short name
+		for _, model := range []string{plan.LeafModel, plan.SpineModel} {
+			if p, ok := prev.Find(model); ok && slices.Contains(models, p.ModelID) && !slices.Contains(check.Models, p.ModelID) {
+				check.Models = append(check.Models, p.ModelID)
+			}
+		}
+		if len(check.Models) == 0 {
+			continue
+		}
+		before := problems(prev, plan)
+		for _, p := range problems(next, plan) {
+			if !slices.Contains(before, p) {
+				check.Problems = append(check.Problems, p)
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// problems is every way the models of plan in registry fall short of it,
+// as hnc check -plan reports them
+func problems(registry *profiles.Registry, plan fabricplan.Plan) []string {
+	leaf, ok := registry.Find(plan.LeafModel)
+	if !ok {
+		return []string{fmt.Sprintf("no profile for leaf model %s", plan.LeafModel)}
+	}
+	var spine profiles.SwitchProfile
+	if plan.SpineModel != "" {
+		if spine, ok = registry.Find(plan.SpineModel); !ok {
+			return []string{fmt.Sprintf("no profile for spine model %s", plan.SpineModel)}
+		}
+		spine = spine.InRole(profiles.RoleSpine)
+	}
+	return constraints.Check(plan, nil, leaf.InRole(profiles.RoleLeaf), spine)
+}
+
+// diff lists the models next adds to, removes from and changes in prev
+func diff(prev, next *profiles.Registry) (Reloaded, error) {
+	r := Reloaded{Profiles: len(next.List()), Added: []string{}, Removed: []string{}, Changed: []string{}}
+	before := map[string][]byte{}
+	for _, p := range prev.List() {
+		data, err := profiles.Marshal(p)
+		if err != nil {
+			return r, err
+		}
+		before[p.ModelID] = data
+	}
+	for _, p := range next.List() {
+		data, err := profiles.Marshal(p)
+		if err != nil {
+			return r, err
+		}
+		old, ok := before[p.ModelID]
+		switch {
+		case !ok:
+			r.Added = append(r.Added, p.ModelID)
+		case string(old) != string(data):
+			r.Changed = append(r.Changed, p.ModelID)
+		}
+		delete(before, p.ModelID)
+	}
+	for model := range before {
+		r.Removed = append(r.Removed, model)
+	}
+	slices.Sort(r.Removed)
+	return r, nil
+}
+
+// method restricts h to one HTTP method (HEAD goes with GET)
+func (s *Server) method(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method && !(method == http.MethodGet && r.Method == http.MethodHead) {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s %s is not supported; use %s", r.Method, r.URL.Path, method))
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (s *Server) version(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Version{APIVersion: APIVersion, MinClientVersion: MinClientVersion, SchemaVersion: profiles.SchemaVersion, HNC: s.limits.Build})
+}
+
+func (s *Server) listProfiles(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.registry.Load().List())
+}
+
+func (s *Server) getProfile(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/profiles/")
+	p, ok := s.registry.Load().Find(name)
+	if !ok || strings.Contains(name, "/") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no profile for model %q", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) plan(w http.ResponseWriter, r *http.Request) {
+	req := PlanRequest{LeafModel: "DS2000", SpineModel: "DS3000"}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.limits.MaxRequestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds the limit of %d bytes", s.limits.MaxRequestBytes))
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("parsing plan request: %w", err))
+		return
+	}
+	if n := endpoints(req.Request); n > s.limits.MaxEndpoints {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("%d endpoints exceed the limit of %d per design", n, s.limits.MaxEndpoints))
+		return
+	}
+
+	registry := s.registry.Load()
+	leaf, ok := registry.Find(req.LeafModel)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no profile for leaf model %s", req.LeafModel))
+		return
+	}
+	spine, ok := registry.Find(req.SpineModel)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no profile for spine model %s", req.SpineModel))
+		return
+	}
+	client := clientAddr(r)
+	if !s.admit(client) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, fmt.Errorf("%s already has %d plans computing, the limit per client; retry when one finishes", client, s.limits.MaxConcurrent))
+		return
+	}
+	defer s.release(client)
+	// A client that goes away stops its computation, and frees its slot
+	plan, err := fabricplan.ComputeContext(r.Context(), req.Request, leaf.InRole(profiles.RoleLeaf), spine.InRole(profiles.RoleSpine))
+	if r.Context().Err() != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// endpoints is how many endpoints req plans for: its own count, or its
+// classes' when that is more, so neither can slip past the limit. The sum
+// stops at math.MaxInt rather than wrap.
+func endpoints(req fabricplan.Request) int {
+	total := 0
+	for _, c := range req.Classes {
+		if c.Count > 0 {
+			total += min(c.Count, math.MaxInt-total)
+		}
+	}
+	return max(req.Endpoints, total)
+}
+
+// clientAddr is the remote IP address a request came from
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// admit starts a computation for client unless it is at its limit
+func (s *Server) admit(client string) bool {
+	s.computingMu.Lock()
+	defer s.computingMu.Unlock()
+	if s.computing[client] >= s.limits.MaxConcurrent {
+		return false
+	}
+	s.computing[client]++
+	return true
+}
+
+// release ends a computation admit started
+func (s *Server) release(client string) {
+	s.computingMu.Lock()
+	defer s.computingMu.Unlock()
+	if s.computing[client]--; s.computing[client] == 0 {
+		delete(s.computing, client)
+	}
+}
+
+// admin says whether r may use an admin endpoint, answering it when not:
+// it must carry the AdminToken or, with none set, come from this host
+func (s *Server) admin(w http.ResponseWriter, r *http.Request) bool {
+	if s.limits.AdminToken == "" {
+		if ip := net.ParseIP(clientAddr(r)); ip == nil || !ip.IsLoopback() {
+			writeError(w, http.StatusForbidden, fmt.Errorf("%s is only served to this host without an admin token", r.URL.Path))
+			return false
+		}
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.limits.AdminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("%s needs the admin token as a bearer token", r.URL.Path))
+		return false
+	}
+	return true
+}
+
+func (s *Server) reloadCatalog(w http.ResponseWriter, r *http.Request) {
+	if !s.admin(w, r) {
+		return
+	}
+	reloaded, err := s.Reload()
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reloaded)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := canonjson.Marshal(v)
+	if err != nil {
+		status, data = http.StatusInternalServerError, []byte(`{"error":"encoding response"}`+"\n")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func do(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec
+}
+
+func TestProfiles(t *testing.T) {
+	h := Handler(profiles.Default(), Options{})
+
+	rec := do(t, h, http.MethodGet, "/profiles", "")
+	var list []profiles.SwitchProfile
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); rec.Code != http.StatusOK || err != nil || len(list) != len(profiles.Default().List()) {
+		t.Fatalf("GET /profiles = %d, %d profiles, %v", rec.Code, len(list), err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	rec = do(t, h, http.MethodGet, "/profiles/DS2000", "")
+	var p profiles.SwitchProfile
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); rec.Code != http.StatusOK || err != nil || p.ModelID != "celestica-ds2000" {
+		t.Fatalf("GET /profiles/DS2000 = %d %s", rec.Code, rec.Body)
+	}
+
+	for _, path := range []string{"/profiles/nope", "/profiles/", "/profiles/DS2000/x", "/nowhere"} {
+		if rec := do(t, h, http.MethodGet, path, ""); rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `"error"`) {
+			t.Errorf("GET %s = %d %s, want 404", path, rec.Code, rec.Body)
+		}
+	}
+	if rec := do(t, h, http.MethodDelete, "/profiles", ""); rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodGet {
+		t.Errorf("DELETE /profiles = %d, Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestPlan(t *testing.T) {
+	h := Handler(profiles.Default(), Options{})
+
+	rec := do(t, h, http.MethodPost, "/plan", `{"endpoints": 96, "oversubscription": 3}`)
+	var plan fabricplan.Plan
+	if err := json.Unmarshal(rec.Body.Bytes(), &plan); rec.Code != http.StatusOK || err != nil {
+		t.Fatalf("POST /plan = %d %s", rec.Code, rec.Body)
+	}
+	want, _ := fabricplan.Compute(fabricplan.Request{Endpoints: 96, Oversubscription: 3},
+		profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine))
+	if plan.Leaves != want.Leaves || plan.Spines != want.Spines || plan.LeafModel != "celestica-ds2000" {
+		t.Fatalf("plan = %+v, want %+v", plan, want)
+	}
+
+	for _, tc := range []struct {
+		body string
+		code int
+	}{
+		{`{"endpoints": 96`, http.StatusBadRequest},
+		{`{"endpoints": 96, "oversubscription": 3, "leafs": 2}`, http.StatusBadRequest},
+		{`{"endpoints": 96, "oversubscription": 3, "leafModel": "nope"}`, http.StatusNotFound},
+		{`{"endpoints": 0, "oversubscription": 3}`, http.StatusUnprocessableEntity},
+		{`{"endpoints": -5, "oversubscription": 3, "endpointClasses": [{"count": 8, "speedGbps": 25}]}`, http.StatusUnprocessableEntity},
+		{`{"endpoints": 96, "oversubscription": 3, "minSpines": -1}`, http.StatusUnprocessableEntity},
+		{`{"endpoints": 96, "oversubscription": 3, "breakout": "` + strings.Repeat("x", MaxRequestBytes) + `"}`, http.StatusRequestEntityTooLarge},
+	} {
+		if rec := do(t, h, http.MethodPost, "/plan", tc.body); rec.Code != tc.code {
+			t.Errorf("POST /plan %.40s = %d %s, want %d", tc.body, rec.Code, rec.Body, tc.code)
+		}
+	}
+	if rec := do(t, h, http.MethodGet, "/plan", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /plan = %d, want 405", rec.Code)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	h := Handler(profiles.Default(), Options{AllowOrigin: "http://localhost:5173"})
+	rec := do(t, h, http.MethodOptions, "/plan", "")
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "http://localhost:5173" ||
+		!strings.Contains(rec.Header().Get("Access-Control-Allow-Methods"), "POST") {
+		t.Fatalf("preflight = %d %v", rec.Code, rec.Header())
+	}
+	if rec := do(t, h, http.MethodGet, "/profiles", ""); rec.Header().Get("Access-Control-Allow-Origin") == "" {
+		t.Error("GET /profiles sent no CORS header")
+	}
+	if rec := do(t, Handler(profiles.Default(), Options{}), http.MethodGet, "/profiles", ""); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("CORS header sent without AllowOrigin")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, VersionHeader) {
+		t.Errorf("preflight allows headers %q, want %s", got, VersionHeader)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	h := Handler(profiles.Default(), Options{Build: "v1.4.0"})
+	rec := do(t, h, http.MethodGet, "/version", "")
+	var v Version
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); rec.Code != http.StatusOK || err != nil {
+		t.Fatalf("GET /version = %d %s", rec.Code, rec.Body)
+	}
+	if want := (Version{APIVersion: APIVersion, MinClientVersion: MinClientVersion, SchemaVersion: profiles.SchemaVersion, HNC: "v1.4.0"}); v != want {
+		t.Errorf("version = %+v, want %+v", v, want)
+	}
+	for _, path := range []string{"/version", "/profiles", "/nowhere"} {
+		if got := do(t, h, http.MethodGet, path, "").Header().Get(VersionHeader); got != "1" {
+			t.Errorf("GET %s %s = %q, want 1", path, VersionHeader, got)
+		}
+	}
+
+	for _, tc := range []struct {
+		sent    string
+		code    int
+		warning string
+	}{
+		{"1", http.StatusOK, ""},
+		{"2", http.StatusOK, ""},
+		{"0", http.StatusOK, `299 - "API version 0 is deprecated; this server speaks 1 to 1, so upgrade the client"`},
+		{"v1", http.StatusBadRequest, ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/profiles", nil)
+		req.Header.Set(VersionHeader, tc.sent)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.code || rec.Header().Get("Warning") != tc.warning {
+			t.Errorf("GET /profiles from client version %s = %d, Warning %q, want %d %q", tc.sent, rec.Code, rec.Header().Get("Warning"), tc.code, tc.warning)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	if rec := do(t, Handler(profiles.Default(), Options{}), http.MethodPost, "/admin/reload", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("POST /admin/reload without Reload = %d, want 404", rec.Code)
+	}
+
+	prev, err := profiles.NewRegistry(profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds2000 := profiles.DS2000()
+	ds2000.Lifecycle = &profiles.Lifecycle{Status: profiles.LifecycleDeprecated}
+	next, err := profiles.NewRegistry(ds2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := fabricplan.Compute(fabricplan.Request{Endpoints: 96, Oversubscription: 3}, profiles.DS2000(), profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	single := plan
+	single.SpineModel = ""
+	designs := map[string]fabricplan.Plan{"rack-a": plan, "lab": single}
+	var loadErr, designsErr error
+	s := New(prev, Options{
+		AdminToken: "s3cret",
+		Reload:     func() (*profiles.Registry, error) { return next, loadErr },
+		Designs:    func() (map[string]fabricplan.Plan, error) { return designs, designsErr },
+	})
+	reload := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	loadErr = errors.New("bad catalog")
+	if rec := reload(); rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "bad catalog") {
+		t.Fatalf("failed reload = %d %s", rec.Code, rec.Body)
+	}
+	loadErr, designsErr = nil, errors.New("bad design")
+	if rec := reload(); rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "bad design") {
+		t.Fatalf("reload with a bad design = %d %s", rec.Code, rec.Body)
+	}
+	if rec := do(t, s, http.MethodGet, "/profiles/DS3000", ""); rec.Code != http.StatusOK {
+		t.Fatalf("failed reload dropped the catalog: GET /profiles/DS3000 = %d", rec.Code)
+	}
+
+	designsErr = nil
+	rec := reload()
+	var reloaded Reloaded
+	if err := json.Unmarshal(rec.Body.Bytes(), &reloaded); rec.Code != http.StatusOK || err != nil {
+		t.Fatalf("POST /admin/reload = %d %s", rec.Code, rec.Body)
+	}
+	want := Reloaded{Profiles: 1, Added: []string{}, Removed: []string{"celestica-ds3000"}, Changed: []string{"celestica-ds2000"}, Designs: []Recheck{
+		{Design: "lab", Models: []string{"celestica-ds2000"}, Problems: []string{}},
+		{Design: "rack-a", Models: []string{"celestica-ds2000", "celestica-ds3000"}, Problems: []string{"no profile for spine model celestica-ds3000"}},
+	}}
+	if !reflect.DeepEqual(reloaded, want) {
+		t.Errorf("reloaded = %+v, want %+v", reloaded, want)
+	}
+	if rec := do(t, s, http.MethodGet, "/profiles/DS3000", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /profiles/DS3000 after reload = %d, want 404", rec.Code)
+	}
+	if rec := do(t, s, http.MethodGet, "/admin/reload", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /admin/reload = %d, want 405", rec.Code)
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	reload := func() (*profiles.Registry, error) { return profiles.Default(), nil }
+	for _, tc := range []struct {
+		token, remote, auth string
+		code                int
+	}{
+		{"", "127.0.0.1:4000", "", http.StatusOK},
+		{"", "[::1]:4000", "", http.StatusOK},
+		{"", "192.0.2.1:4000", "", http.StatusForbidden},
+		{"s3cret", "192.0.2.1:4000", "Bearer s3cret", http.StatusOK},
+		{"s3cret", "127.0.0.1:4000", "", http.StatusUnauthorized},
+		{"s3cret", "192.0.2.1:4000", "Bearer guess", http.StatusUnauthorized},
+		{"s3cret", "192.0.2.1:4000", "s3cret", http.StatusUnauthorized},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+		req.RemoteAddr = tc.remote
+		if tc.auth != "" {
+			req.Header.Set("Authorization", tc.auth)
+		}
+		New(profiles.Default(), Options{Reload: reload, AdminToken: tc.token}).ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Errorf("POST /admin/reload from %s with token %q, Authorization %q = %d %s, want %d", tc.remote, tc.token, tc.auth, rec.Code, rec.Body, tc.code)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	s := New(profiles.Default(), Options{MaxRequestBytes: 200, MaxEndpoints: 1000, MaxConcurrent: 1})
+	for _, tc := range []struct {
+		body string
+		code int
+		msg  string
+	}{
+		{`{"endpoints": 1000, "oversubscription": 3}`, http.StatusOK, ""},
+		{`{"endpoints": 1001, "oversubscription": 3}`, http.StatusUnprocessableEntity, "limit of 1000 per design"},
+		{`{"endpointClasses": [{"count": 900, "speedGbps": 25}, {"count": 900, "speedGbps": 100}], "oversubscription": 3}`, http.StatusUnprocessableEntity, "1800 endpoints"},
+		{`{"endpointClasses": [{"count": 9223372036854775807, "speedGbps": 25}, {"count": 9, "speedGbps": 25}], "oversubscription": 3}`, http.StatusUnprocessableEntity, "limit of 1000"},
+		{`{"endpoints": 96, "oversubscription": 3, "breakout": "` + strings.Repeat("x", 200) + `"}`, http.StatusRequestEntityTooLarge, "limit of 200 bytes"},
+	} {
+		if rec := do(t, s, http.MethodPost, "/plan", tc.body); rec.Code != tc.code || !strings.Contains(rec.Body.String(), tc.msg) {
+			t.Errorf("POST /plan %.60s = %d %s, want %d %q", tc.body, rec.Code, rec.Body, tc.code, tc.msg)
+		}
+	}
+
+	// httptest requests come from 192.0.2.1
+	s.computing["192.0.2.1"] = 1
+	rec := do(t, s, http.MethodPost, "/plan", `{"endpoints": 96, "oversubscription": 3}`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("POST /plan at the limit = %d %s, Retry-After %q", rec.Code, rec.Body, rec.Header().Get("Retry-After"))
+	}
+	s.release("192.0.2.1")
+	if rec := do(t, s, http.MethodPost, "/plan", `{"endpoints": 96, "oversubscription": 3}`); rec.Code != http.StatusOK {
+		t.Fatalf("POST /plan after release = %d %s", rec.Code, rec.Body)
+	}
+	if len(s.computing) != 0 {
+		t.Errorf("computing = %v after every plan finished", s.computing)
+	}
+
+	// A client that went away gets no plan, and keeps no slot
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/plan", strings.NewReader(`{"endpoints": 96, "oversubscription": 3}`)).WithContext(ctx))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "planning stopped") || len(s.computing) != 0 {
+		t.Errorf("POST /plan from a client gone = %d %s, computing %v", rec.Code, rec.Body, s.computing)
+	}
+}
@@ -0,0 +1,173 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitHub commits the files to a new branch of a repository, all at once
+// when the run publishes, and opens a pull request from it, through the
+// REST API
+type GitHub struct {
+	API    string // e.g. https://api.github.com
+	Owner  string
+	Repo   string
+	Prefix string // directory of the files in the repository
+	Base   string // branch the pull request merges into, default: the repository's default branch
+	Branch string // created for the commit
+	Token  string
+	Client *http.Client
+
+	files []githubFile
+}
+
+type githubFile struct {
+	path string
+	data []byte
+}
+
+// Write holds the file for the commit
+func (g *GitHub) Write(_ context.Context, name string, data []byte) error {
+	k, err := key(g.Prefix, name)
+	if err != nil {
+		return err
+	}
+	for i, f := range g.files {
+		if f.path == k {
+			g.files[i].data = data
+			return nil
+		}
+	}
+	g.files = append(g.files, githubFile{k, data})
+	return nil
+}
+
+// Location is the file's path on the branch
+func (g *GitHub) Location(name string) string {
+	k, err := key(g.Prefix, name)
+	if err != nil {
+		return name
+	}
+	return fmt.Sprintf("github:%s/%s@%s:%s", g.Owner, g.Repo, g.Branch, k)
+}
+
+// Publish commits the files written, on Branch from the head of Base,
+// and opens a pull request titled summary. It returns the pull request's
+// web link, or nothing when no file was written.
+func (g *GitHub) Publish(ctx context.Context, summary string) (string, error) {
+	if len(g.files) == 0 {
+		return "", nil
+	}
+	repo := "/repos/" + url.PathEscape(g.Owner) + "/" + url.PathEscape(g.Repo)
+	if g.Base == "" {
+		var r struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := g.call(ctx, http.MethodGet, repo, nil, &r); err != nil {
+			return "", err
+		}
+		g.Base = r.DefaultBranch
+	}
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if err := g.call(ctx, http.MethodGet, repo+"/git/ref/heads/"+g.Base, nil, &ref); err != nil {
+		return "", err
+	}
+	var parent struct {
+		Tree struct {
+			SHA string `json:"sha"`
+		} `json:"tree"`
+	}
+	if err := g.call(ctx, http.MethodGet, repo+"/git/commits/"+ref.Object.SHA, nil, &parent); err != nil {
+		return "", err
+	}
+
+	type entry struct {
+		Path string `json:"path"`
+		Mode string `json:"mode"`
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+	}
+	var entries []entry
+	var list strings.Builder
+	for _, f := range g.files {
+		var blob struct {
+			SHA string `json:"sha"`
+		}
+		body := map[string]string{"content": base64.StdEncoding.EncodeToString(f.data), "encoding": "base64"}
+		if err := g.call(ctx, http.MethodPost, repo+"/git/blobs", body, &blob); err != nil {
+			return "", err
+		}
+		entries = append(entries, entry{f.path, "100644", "blob", blob.SHA})
+		fmt.Fprintf(&list, "- %s\n", f.path)
+	}
+	var tree, commit struct {
+		SHA string `json:"sha"`
+	}
+	if err := g.call(ctx, http.MethodPost, repo+"/git/trees", map[string]any{"base_tree": parent.Tree.SHA, "tree": entries}, &tree); err != nil {
+		return "", err
+	}
+	message := summary + "\n\n" + list.String()
+	if err := g.call(ctx, http.MethodPost, repo+"/git/commits", map[string]any{"message": message, "tree": tree.SHA, "parents": []string{ref.Object.SHA}}, &commit); err != nil {
+		return "", err
+	}
+	if err := g.call(ctx, http.MethodPost, repo+"/git/refs", map[string]string{"ref": "refs/heads/" + g.Branch, "sha": commit.SHA}, nil); err != nil {
+		return "", err
+	}
+	var pull struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := g.call(ctx, http.MethodPost, repo+"/pulls", map[string]string{"title": summary, "head": g.Branch, "base": g.Base, "body": list.String()}, &pull); err != nil {
+		return "", err
+	}
+	return pull.HTMLURL, nil
+}
+
+// call sends one API request with a JSON body, if there is one, and
+// decodes the JSON response into out, if it is given
+func (g *GitHub) call(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(g.API, "/")+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.Token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client(g.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&e)
+		return fmt.Errorf("github: %s %s: %s: %s", method, path, resp.Status, e.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,144 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 puts each file as an object of a bucket on S3 or a store that speaks
+// its API, such as MinIO, addressed path-style as ENDPOINT/BUCKET/KEY and
+// signed with AWS Signature Version 4
+type S3 struct {
+	Endpoint     string // e.g. https://s3.us-east-1.amazonaws.com
+	Region       string
+	Bucket       string
+	Prefix       string // of every key
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // for temporary credentials
+	Client       *http.Client
+}
+
+// Write puts the object at once
+func (s *S3) Write(ctx context.Context, name string, data []byte) error {
+	k, err := key(s.Prefix, name)
+	if err != nil {
+		return err
+	}
+	target, err := url.JoinPath(s.Endpoint, s.Bucket, k)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if t := mime.TypeByExtension(path.Ext(k)); t != "" {
+		req.Header.Set("Content-Type", t)
+	}
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	s.sign(req, data, time.Now())
+	resp, err := client(s.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3: PUT %s/%s: %s: %s", s.Bucket, k, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Location is the object's s3:// URL
+func (s *S3) Location(name string) string {
+	k, err := key(s.Prefix, name)
+	if err != nil {
+		return name
+	}
+	return "s3://" + s.Bucket + "/" + k
+}
+
+// Publish has nothing left to do: every object is in place once written
+func (s *S3) Publish(context.Context, string) (string, error) { return "", nil }
+
+// sign adds the Signature Version 4 headers for req, whose body is
+// payload, at t. Every header already set is signed, with Host.
+func (s *S3) sign(req *http.Request, payload []byte, t time.Time) {
+	t = t.UTC()
+	stamp, day := t.Format("20060102T150405Z"), t.Format("20060102")
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.Region + "/s3/aws4_request"
+	canonicalSum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(canonicalSum[:])
+
+	signingKey := []byte("AWS4" + s.SecretKey)
+	for _, part := range []string{day, s.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signed, signature))
+}
+
+// uriEncode escapes every byte of a path but the unreserved characters
+// and slashes, as Signature Version 4 canonicalizes it
+func uriEncode(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
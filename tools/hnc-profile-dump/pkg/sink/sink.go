@@ -0,0 +1,157 @@
+// Package sink publishes the files a command generates: to the local
+// filesystem, to a bucket of S3-compatible object storage, or as a commit
+// on a new branch of a GitHub repository with a pull request, so CI runs
+// can publish plans and fixtures directly. A sink is named by URL:
+//
+//	file:                                      files where they are named (the default)
+//	s3://BUCKET/PREFIX?endpoint=URL&region=R   objects BUCKET/PREFIX/NAME
+//	github://OWNER/REPO/PREFIX?base=B&branch=N  files PREFIX/NAME on branch N, in a pull request to B
+//
+// Credentials come from the environment: AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN for S3, GITHUB_TOKEN (or
+// GH_TOKEN) for GitHub. AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL,
+// AWS_REGION and GITHUB_API_URL stand in for the query parameters.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A Sink stores the files one command run generates
+type Sink interface {
+	// Write stores a generated file, named by its path, giving up once
+	// ctx is done
+	Write(ctx context.Context, name string, data []byte) error
+	// Location is where Write stores name, for status messages
+	Location(name string) string
+	// Publish finishes the run, under a one-line summary of it, and
+	// returns a link to what it published, if there is one: a GitHub
+	// sink commits the files and opens its pull request
+	Publish(ctx context.Context, summary string) (string, error)
+}
+
+// Schemes lists the sink URL schemes, default first
+var Schemes = []string{"file", "s3", "github"}
+
+// Open parses a sink URL, reading credentials and defaults with getenv.
+// An empty URL is the file sink.
+func Open(rawURL string, getenv func(string) string) (Sink, error) {
+	if getenv == nil {
+		getenv = func(string) string { return "" }
+	}
+	if rawURL == "" || rawURL == "file:" {
+		return File{}, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	param := func(name string, env ...string) string {
+		if v := query.Get(name); v != "" {
+			return v
+		}
+		for _, key := range env {
+			if v := getenv(key); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "s3":
+		s := &S3{
+			Endpoint:     param("endpoint", "AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+			Region:       param("region", "AWS_REGION", "AWS_DEFAULT_REGION"),
+			Bucket:       u.Host,
+			Prefix:       prefix,
+			AccessKey:    getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: getenv("AWS_SESSION_TOKEN"),
+		}
+		if s.Bucket == "" {
+			return nil, fmt.Errorf("s3 sink %q names no bucket (want s3://BUCKET/PREFIX)", rawURL)
+		}
+		if s.AccessKey == "" || s.SecretKey == "" {
+			return nil, fmt.Errorf("s3 sink needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		if s.Region == "" {
+			s.Region = "us-east-1"
+		}
+		if s.Endpoint == "" {
+			s.Endpoint = "https://s3." + s.Region + ".amazonaws.com"
+		}
+		return s, nil
+	case "github":
+		repo, rest, _ := strings.Cut(prefix, "/")
+		g := &GitHub{
+			API:    param("api", "GITHUB_API_URL"),
+			Owner:  u.Host,
+			Repo:   repo,
+			Prefix: rest,
+			Base:   query.Get("base"),
+			Branch: query.Get("branch"),
+			Token:  param("token", "GITHUB_TOKEN", "GH_TOKEN"),
+		}
+		if g.Owner == "" || g.Repo == "" {
+			return nil, fmt.Errorf("github sink %q names no repository (want github://OWNER/REPO/PREFIX)", rawURL)
+		}
+		if g.Token == "" {
+			return nil, fmt.Errorf("github sink needs GITHUB_TOKEN")
+		}
+		if g.API == "" {
+			g.API = "https://api.github.com"
+		}
+		if g.Branch == "" {
+			g.Branch = "hnc/" + time.Now().UTC().Format("20060102-150405")
+		}
+		return g, nil
+	}
+	return nil, fmt.Errorf("unknown sink %q (want %s)", rawURL, strings.Join(Schemes, ", "))
+}
+
+// File writes files to the local filesystem, where they are named
+type File struct{}
+
+// Write writes the file as a command without a sink would
+func (File) Write(_ context.Context, name string, data []byte) error {
+	return os.WriteFile(name, data, 0644)
+}
+
+// Location is the file's own path
+func (File) Location(name string) string { return name }
+
+// Publish has nothing left to do
+func (File) Publish(context.Context, string) (string, error) { return "", nil }
+
+// key is the remote path of a generated file under prefix. Remote sinks
+// mirror the working directory, so a file outside it has no key.
+func key(prefix, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("%s is outside the working directory, which the sink mirrors", name)
+	}
+	return path.Join(prefix, filepath.ToSlash(filepath.Clean(name))), nil
+}
+
+// Timeout bounds each request of a sink whose Client is not set
+const Timeout = time.Minute
+
+// defaultClient is the HTTP client of a sink whose Client is not set
+var defaultClient = &http.Client{Timeout: Timeout}
+
+// client is the HTTP client of a sink, defaultClient unless set
+func client(c *http.Client) *http.Client {
+	if c == nil {
+		return defaultClient
+	}
+	return c
+}
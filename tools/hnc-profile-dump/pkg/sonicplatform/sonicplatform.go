@@ -0,0 +1,315 @@
+// Package sonicplatform converts the port maps SONiC ships for a switch
+// platform, platform.json and optionally an hwsku.json, into HNC switch
+// profiles. Front-panel ports are numbered by their platform index and
+// named E1/N; each port's native speed is its widest unbroken mode, and
+// its other breakout modes become the profile's breakouts.
+package sonicplatform
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Platform is the part of a platform.json HNC reads
+type Platform struct {
+	Interfaces map[string]Interface `json:"interfaces"`
+}
+
+// Interface is one front-panel port of a platform.json, e.g. Ethernet0
+type Interface struct {
+	Index         string              `json:"index"` // physical port number of each lane, e.g. 1,1,1,1
+	Lanes         string              `json:"lanes"`
+	BreakoutModes map[string][]string `json:"breakout_modes"` // e.g. 4x25G[10G] -> the port's names in that mode
+}
+
+// HWSKU is the part of an hwsku.json HNC reads: the breakout mode each
+// port comes up in
+type HWSKU struct {
+	Interfaces map[string]struct {
+		DefaultBreakoutMode string `json:"default_brkout_mode"`
+	} `json:"interfaces"`
+}
+
+// ParsePlatform reads a platform.json
+func ParsePlatform(data []byte) (Platform, error) {
+	var p Platform
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, err
+	}
+	if len(p.Interfaces) == 0 {
+		return p, fmt.Errorf("no interfaces")
+	}
+	return p, nil
+}
+
+// ParseHWSKU reads an hwsku.json
+func ParseHWSKU(data []byte) (HWSKU, error) {
+	var h HWSKU
+	err := json.Unmarshal(data, &h)
+	return h, err
+}
+
+// Options is what the platform files do not say
+type Options struct {
+	ModelID string // Hedgehog model ID, e.g. celestica-ds2000
+	Source  string // file the profile is converted from, for its meta
+	// FabricPorts makes the last FabricPorts ports the fabric ports of a
+	// leaf. 0 takes the fastest ports when the platform mixes speeds, as a
+	// leaf, and every port when it does not, as a spine.
+	FabricPorts int
+}
+
+// Result is a converted profile and what the converter had to guess
+type Result struct {
+	Profile  profiles.SwitchProfile
+	Warnings []string
+}
+
+// port is one physical port
+type port struct {
+	iface     string
+	number    int
+	speedGbps int
+	breakouts []profiles.BreakoutOption
+}
+
+// modePattern is a uniform breakout mode, e.g. 4x25G[10G]; SONiC's mixed
+// modes such as 2x50G(2)+1x100G(2) do not match
+var modePattern = regexp.MustCompile(`^(\d+)x(\d+)G(\[[\dG,]*\])?$`)
+
+// Convert builds a profile from a platform and, when hwsku is not nil, the
+// breakout modes its ports come up in
+func Convert(platform Platform, hwsku *HWSKU, opts Options) (Result, error) {
+	var r Result
+	if opts.ModelID == "" {
+		return r, fmt.Errorf("a model ID is required")
+	}
+	ports, defaults, err := physicalPorts(platform, hwsku, &r.Warnings)
+	if err != nil {
+		return r, err
+	}
+	if opts.FabricPorts < 0 || opts.FabricPorts > len(ports) {
+		return r, fmt.Errorf("%d fabric ports asked for, the platform has %d ports", opts.FabricPorts, len(ports))
+	}
+
+	split := len(ports) - opts.FabricPorts
+	if opts.FabricPorts == 0 {
+		top := 0
+		for _, p := range ports {
+			top = max(top, p.speedGbps)
+		}
+		split = 0
+		for i, p := range ports {
+			if p.speedGbps < top {
+				split = i + 1
+			}
+		}
+		for _, p := range ports[:split] {
+			if p.speedGbps == top {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("%s (E1/%d) runs at %dG like the fabric ports but sits among the endpoint ports; it is an endpoint port",
+					p.iface, p.number, p.speedGbps))
+			}
+		}
+	}
+	endpoint, fabric := ports[:split], ports[split:]
+
+	p := profiles.SwitchProfile{
+		ModelID: opts.ModelID,
+		Roles:   []string{profiles.RoleSpine},
+		Ports:   profiles.Ports{EndpointAssignable: ranges(endpoint), FabricAssignable: ranges(fabric)},
+		Meta:    profiles.Meta{Source: opts.Source, Version: profiles.SchemaVersion},
+	}
+	p.Profiles.Uplink = portProfile(fabric, &r.Warnings)
+	if len(endpoint) > 0 {
+		p.Roles = []string{profiles.RoleLeaf}
+		p.Profiles.Endpoint = portProfile(endpoint, &r.Warnings)
+	}
+	p.Faceplate = &profiles.Faceplate{}
+	for _, group := range [][]port{endpoint, fabric} {
+		if len(group) > 0 {
+			rows := 1
+			if len(group)%2 == 0 {
+				rows = 2
+			}
+			p.Faceplate.Blocks = append(p.Faceplate.Blocks, profiles.PortBlock{Ports: ranges(group), Rows: rows})
+		}
+	}
+	p.Profiles.Breakout = breakoutSummary(endpoint, fabric, defaults)
+
+	if errs := profiles.Validate(p); len(errs) > 0 {
+		return r, fmt.Errorf("converted profile is invalid: %s", strings.Join(errs, "; "))
+	}
+	r.Profile = p
+	return r, nil
+}
+
+// physicalPorts lists the platform's ports by number, and the hwsku's
+// default mode of each port that comes up broken out
+func physicalPorts(platform Platform, hwsku *HWSKU, warnings *[]string) ([]port, map[string]string, error) {
+	names := make([]string, 0, len(platform.Interfaces))
+	for name := range platform.Interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var ports []port
+	seen := map[int]string{}
+	skipped := map[string]int{}
+	for _, name := range names {
+		iface := platform.Interfaces[name]
+		first, _, _ := strings.Cut(iface.Index, ",")
+		n, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil || n <= 0 {
+			return nil, nil, fmt.Errorf("%s: bad index %q", name, iface.Index)
+		}
+		if other, ok := seen[n]; ok {
+			*warnings = append(*warnings, fmt.Sprintf("%s has the index %d of %s; skipped", name, n, other))
+			continue
+		}
+		seen[n] = name
+		p := port{iface: name, number: n}
+		modes := make([]string, 0, len(iface.BreakoutModes))
+		for mode := range iface.BreakoutModes {
+			modes = append(modes, mode)
+		}
+		sort.Strings(modes)
+		for _, mode := range modes {
+			m := modePattern.FindStringSubmatch(mode)
+			if m == nil {
+				skipped[mode]++
+				continue
+			}
+			lanes, _ := strconv.Atoi(m[1])
+			speed, _ := strconv.Atoi(m[2])
+			if lanes == 1 {
+				p.speedGbps = max(p.speedGbps, speed)
+				continue
+			}
+			p.breakouts = append(p.breakouts, profiles.BreakoutOption{
+				Mode: fmt.Sprintf("%dx%dG", lanes, speed), Lanes: lanes, SpeedGbps: speed, PortPattern: "{port}/{lane}"})
+		}
+		if p.speedGbps == 0 {
+			return nil, nil, fmt.Errorf("%s has no 1xNG breakout mode to take its speed from", name)
+		}
+		sort.SliceStable(p.breakouts, func(i, j int) bool { return p.breakouts[i].Lanes < p.breakouts[j].Lanes })
+		ports = append(ports, p)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].number < ports[j].number })
+	modes := make([]string, 0, len(skipped))
+	for mode := range skipped {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	for _, mode := range modes {
+		*warnings = append(*warnings, fmt.Sprintf("skipped breakout mode %s of %d port(s), which mixes speeds", mode, skipped[mode]))
+	}
+
+	defaults := map[string]string{}
+	if hwsku != nil {
+		names = names[:0]
+		for name := range hwsku.Interfaces {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, ok := platform.Interfaces[name]; !ok {
+				*warnings = append(*warnings, fmt.Sprintf("hwsku interface %s is not in the platform; skipped", name))
+				continue
+			}
+			if m := modePattern.FindStringSubmatch(hwsku.Interfaces[name].DefaultBreakoutMode); m != nil && m[1] != "1" {
+				defaults[name] = fmt.Sprintf("%sx%sG", m[1], m[2])
+			}
+		}
+	}
+	return ports, defaults, nil
+}
+
+// portProfile is the port profile of a group of ports: the speed and
+// breakouts of the fastest, named as Hedgehog names the usual cage at
+// that speed
+func portProfile(group []port, warnings *[]string) profiles.PortProfile {
+	var fastest port
+	for _, p := range group {
+		if p.speedGbps > fastest.speedGbps {
+			fastest = p
+		}
+	}
+	for _, p := range group {
+		if p.speedGbps != fastest.speedGbps {
+			*warnings = append(*warnings, fmt.Sprintf("%s (E1/%d) runs at %dG; its profile says %dG, like %s", p.iface, p.number, p.speedGbps, fastest.speedGbps, fastest.iface))
+		}
+	}
+	pp := profiles.PortProfile{SpeedGbps: fastest.speedGbps, Breakouts: fastest.breakouts}
+	if name, ok := cages[fastest.speedGbps]; ok {
+		pp.PortProfile = &name
+	} else {
+		*warnings = append(*warnings, fmt.Sprintf("no Hedgehog port profile for %dG ports; portProfile is null", fastest.speedGbps))
+	}
+	return pp
+}
+
+// cages names the Hedgehog port profile of the usual cage at each speed
+var cages = map[int]string{
+	10:  "SFP+-10G",
+	25:  "SFP28-25G",
+	40:  "QSFP+-40G",
+	100: "QSFP28-100G",
+	200: "QSFP56-200G",
+	400: "QSFP-DD-400G",
+	800: "OSFP-800G",
+}
+
+// breakoutSummary is the wiring builder's breakout summary of a leaf: the
+// breakouts of its endpoint ports or, when they have none, of its fabric
+// ports, in the mode the hwsku brings them up in or else the first. A
+// spine's says it has none, as it has no endpoint ports.
+func breakoutSummary(endpoint, fabric []port, defaults map[string]string) *profiles.BreakoutCapability {
+	if len(endpoint) == 0 {
+		return &profiles.BreakoutCapability{}
+	}
+	for _, group := range [][]port{endpoint, fabric} {
+		var options []profiles.BreakoutOption
+		for _, p := range group {
+			if len(p.breakouts) > 0 {
+				options = p.breakouts
+				break
+			}
+		}
+		if len(options) == 0 {
+			continue
+		}
+		chosen := options[0]
+		for _, p := range group {
+			for _, o := range options {
+				if defaults[p.iface] == o.Mode {
+					chosen = o
+				}
+			}
+		}
+		return &profiles.BreakoutCapability{SupportsBreakout: true, BreakoutType: chosen.Mode, CapacityMultiplier: chosen.Lanes}
+	}
+	return &profiles.BreakoutCapability{}
+}
+
+// ranges writes the ports' numbers as E1/N ranges of consecutive runs
+func ranges(group []port) []string {
+	out := []string{}
+	for i := 0; i < len(group); {
+		j := i
+		for j+1 < len(group) && group[j+1].number == group[j].number+1 {
+			j++
+		}
+		if i == j {
+			out = append(out, fmt.Sprintf("E1/%d", group[i].number))
+		} else {
+			out = append(out, fmt.Sprintf("E1/%d-%d", group[i].number, group[j].number))
+		}
+		i = j + 1
+	}
+	return out
+}
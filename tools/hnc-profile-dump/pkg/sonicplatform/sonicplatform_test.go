@@ -0,0 +1,117 @@
+package sonicplatform
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func read(t *testing.T) (Platform, HWSKU) {
+	t.Helper()
+	data, err := os.ReadFile("testdata/platform.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	platform, err := ParsePlatform(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = os.ReadFile("testdata/hwsku.json"); err != nil {
+		t.Fatal(err)
+	}
+	hwsku, err := ParseHWSKU(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return platform, hwsku
+}
+
+// The DS2000's platform converts to the ports, faceplate and port
+// profiles of its built-in profile
+func TestConvert(t *testing.T) {
+	platform, hwsku := read(t)
+	r, err := Convert(platform, &hwsku, Options{ModelID: "celestica-ds2000", Source: "platform.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, want := r.Profile, profiles.DS2000()
+	if !reflect.DeepEqual(p.Roles, want.Roles) || !reflect.DeepEqual(p.Ports, want.Ports) || !reflect.DeepEqual(p.Faceplate, want.Faceplate) {
+		t.Errorf("roles, ports and faceplate = %v %+v %+v, want %v %+v %+v", p.Roles, p.Ports, p.Faceplate, want.Roles, want.Ports, want.Faceplate)
+	}
+	if !reflect.DeepEqual(p.Profiles.Endpoint, want.Profiles.Endpoint) || !reflect.DeepEqual(p.Profiles.Breakout, want.Profiles.Breakout) {
+		t.Errorf("endpoint and breakout = %+v %+v, want %+v %+v", p.Profiles.Endpoint, p.Profiles.Breakout, want.Profiles.Endpoint, want.Profiles.Breakout)
+	}
+	uplink := p.Profiles.Uplink
+	if *uplink.PortProfile != "QSFP28-100G" || uplink.SpeedGbps != 100 || len(uplink.Breakouts) != 2 ||
+		uplink.Breakouts[0] != (profiles.BreakoutOption{Mode: "2x50G", Lanes: 2, SpeedGbps: 50, PortPattern: "{port}/{lane}"}) ||
+		uplink.Breakouts[1] != want.Profiles.Uplink.Breakouts[0] {
+		t.Errorf("uplink = %+v", uplink)
+	}
+	if p.Meta != (profiles.Meta{Source: "platform.json", Version: profiles.SchemaVersion}) {
+		t.Errorf("meta = %+v", p.Meta)
+	}
+	if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "skipped breakout mode 1x50G(2)+2x25G(2) of 8 port(s)") {
+		t.Errorf("warnings = %q", r.Warnings)
+	}
+}
+
+func TestConvertFabricPorts(t *testing.T) {
+	platform, _ := read(t)
+	// Without the 25G ports, every port runs at 100G: a spine
+	for name, iface := range platform.Interfaces {
+		if _, ok := iface.BreakoutModes["1x25G[10G]"]; ok {
+			delete(platform.Interfaces, name)
+		}
+	}
+	r, err := Convert(platform, nil, Options{ModelID: "example-spine8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := r.Profile; !reflect.DeepEqual(p.Roles, []string{"spine"}) || len(p.Ports.EndpointAssignable) != 0 ||
+		!reflect.DeepEqual(p.Ports.FabricAssignable, []string{"E1/49-56"}) || p.Profiles.Breakout.SupportsBreakout {
+		t.Errorf("spine = %+v", p)
+	}
+	// -fabric-ports makes it a leaf
+	r, err = Convert(platform, nil, Options{ModelID: "example-leaf8", FabricPorts: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := r.Profile; !reflect.DeepEqual(p.Roles, []string{"leaf"}) || !reflect.DeepEqual(p.Ports.EndpointAssignable, []string{"E1/49-54"}) ||
+		!reflect.DeepEqual(p.Ports.FabricAssignable, []string{"E1/55-56"}) || p.Profiles.Endpoint.SpeedGbps != 100 ||
+		p.Profiles.Breakout.BreakoutType != "2x50G" {
+		t.Errorf("leaf = %+v", p)
+	}
+}
+
+func TestConvertRejects(t *testing.T) {
+	platform, _ := read(t)
+	for _, tc := range []struct {
+		opts Options
+		edit func(Platform)
+		want string
+	}{
+		{Options{}, nil, "a model ID is required"},
+		{Options{ModelID: "x-y", FabricPorts: 57}, nil, "57 fabric ports asked for, the platform has 56"},
+		{Options{ModelID: "x-y"}, func(p Platform) { p.Interfaces["Ethernet0"] = Interface{Index: "one"} }, `Ethernet0: bad index "one"`},
+		{Options{ModelID: "x-y"}, func(p Platform) {
+			p.Interfaces["Ethernet0"] = Interface{Index: "1", BreakoutModes: map[string][]string{"2x10G": nil}}
+		}, "Ethernet0 has no 1xNG breakout mode"},
+	} {
+		p := Platform{Interfaces: map[string]Interface{}}
+		for name, iface := range platform.Interfaces {
+			p.Interfaces[name] = iface
+		}
+		if tc.edit != nil {
+			tc.edit(p)
+		}
+		if _, err := Convert(p, nil, tc.opts); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Convert(%+v) = %v, want %q", tc.opts, err, tc.want)
+		}
+	}
+	if _, err := ParsePlatform([]byte(`{"interfaces": {}}`)); err == nil {
+		t.Error("ParsePlatform accepted a platform without interfaces")
+	}
+}
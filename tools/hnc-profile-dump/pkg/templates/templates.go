@@ -0,0 +1,70 @@
+// Package templates renders user-supplied Go text/template files against
+// a design, for export formats hnc has no built-in for: an internal CMDB
+// CSV, a vendor config. A template sees the design as .Plan and .Cabling,
+// the fabricplan.Plan and cabling.Map with their Go field names, e.g.
+//
+//	{{range .Cabling.Cables}}{{.Leaf}},{{.LeafPort}},{{.Spine}},{{.SpinePort}}
+//	{{end}}
+//
+// Only the text/template builtins (len, index, printf, and so on) are
+// defined, so a template renders the same under any Go tool.
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+)
+
+// Dir is where templates named without a directory are looked up
+const Dir = "templates"
+
+// DirEnv is the environment variable naming another templates directory
+const DirEnv = "HNC_TEMPLATES"
+
+// Context is what a template is executed with
+type Context struct {
+	Plan    fabricplan.Plan
+	Cabling cabling.Map
+}
+
+// Find resolves a template name to its file: the name itself when it is
+// a file, else the name in dir
+func Find(name, dir string) (string, error) {
+	if _, err := os.Stat(name); err == nil || filepath.Base(name) != name {
+		return name, err
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return path, fmt.Errorf("no template %s, nor %s in %s", name, name, dir)
+		}
+		return path, err
+	}
+	return path, nil
+}
+
+// Render executes the template file at path with ctx. Errors name the
+// file and line, as text/template reports them.
+func Render(path string, ctx Context) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	t, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := t.Execute(&out, ctx); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,68 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+)
+
+func write(t *testing.T, dir, name, text string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	path := write(t, dir, "cmdb.csv.tmpl", "")
+	if got, err := Find("cmdb.csv.tmpl", dir); err != nil || got != path {
+		t.Errorf("Find by name = %s, %v; want %s", got, err, path)
+	}
+	if got, err := Find(path, "elsewhere"); err != nil || got != path {
+		t.Errorf("Find by path = %s, %v; want %s", got, err, path)
+	}
+	if _, err := Find("missing.tmpl", dir); err == nil || !strings.Contains(err.Error(), "no template missing.tmpl") {
+		t.Errorf("Find missing = %v", err)
+	}
+	if _, err := Find(filepath.Join(dir, "missing.tmpl"), dir); err == nil {
+		t.Error("Find of a missing path succeeded")
+	}
+}
+
+// Render is text/template's: its builtins only, piped values as the last
+// argument, a | inside a string and else if
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	ctx := Context{
+		Plan: fabricplan.Plan{LeafModel: "celestica-ds2000", Leaves: 2, Spines: 1},
+		Cabling: cabling.Map{Cables: []cabling.Cable{
+			{Leaf: "leaf1", LeafPort: "E1/49", Spine: "spine1", SpinePort: "E1/1"},
+			{Leaf: "leaf2", LeafPort: "E1/49", Spine: "spine1", SpinePort: "E1/2"},
+		}},
+	}
+	for _, tc := range []struct{ text, want string }{
+		{"{{range .Cabling.Cables}}{{.Leaf}},{{.LeafPort}},{{.Spine}},{{.SpinePort}}\n{{end}}", "leaf1,E1/49,spine1,E1/1\nleaf2,E1/49,spine1,E1/2\n"},
+		{`{{.Plan.LeafModel | printf "%s:%s" "leaf"}}`, "leaf:celestica-ds2000"},
+		{`{{"a|b" | len}} {{len .Cabling.Cables}}`, "3 2"},
+		{`{{if eq .Plan.Spines 2}}two{{else if eq .Plan.Spines 1}}one{{else}}many{{end}}`, "one"},
+	} {
+		got, err := Render(write(t, dir, "t.tmpl", tc.text), ctx)
+		if err != nil || got != tc.want {
+			t.Errorf("Render(%q) = %q, %v; want %q", tc.text, got, err, tc.want)
+		}
+	}
+
+	if _, err := Render(write(t, dir, "bad.tmpl", "{{upper .Plan.LeafModel}}"), ctx); err == nil || !strings.Contains(err.Error(), `"upper" not defined`) {
+		t.Errorf("Render with an unknown function = %v", err)
+	}
+	if _, err := Render(write(t, dir, "missing.tmpl", "{{.Plan.Nope}}"), ctx); err == nil {
+		t.Error("Render of a missing field succeeded")
+	}
+}
@@ -0,0 +1,173 @@
+// Package textdiff renders line-based unified diffs, as `diff -u` does,
+// for reporting generated files that drifted from their checked-in copies.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines shown around each change
+const Context = 3
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified returns the unified diff from a to b, or "" when they are equal.
+// Lines are compared without their newline; a missing final newline is
+// marked the way diff does.
+func Unified(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for start := 0; start < len(ops); {
+		// Find the next change, then extend the hunk while changes are
+		// within 2*Context unchanged lines of each other
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*Context {
+				break
+			}
+		}
+		from, to := max(first-Context, start), min(last+Context+1, len(ops))
+		writeHunk(&out, ops, from, to)
+		start = to
+	}
+	return out.String()
+}
+
+// Stat counts the lines added and removed from a to b
+func Stat(a, b string) (added, removed int) {
+	if a == b {
+		return 0, 0
+	}
+	for _, o := range diffLines(splitLines(a), splitLines(b)) {
+		switch o.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func writeHunk(out *strings.Builder, ops []op, from, to int) {
+	// Line numbers of the hunk's first line on each side
+	aLine, bLine := 1, 1
+	for _, o := range ops[:from] {
+		if o.kind != '+' {
+			aLine++
+		}
+		if o.kind != '-' {
+			bLine++
+		}
+	}
+	aCount, bCount := 0, 0
+	for _, o := range ops[from:to] {
+		if o.kind != '+' {
+			aCount++
+		}
+		if o.kind != '-' {
+			bCount++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", span(aLine, aCount), span(bLine, bCount))
+	for _, o := range ops[from:to] {
+		text, noEOL := strings.CutSuffix(o.line, noNewline)
+		out.WriteByte(o.kind)
+		out.WriteString(text)
+		out.WriteByte('\n')
+		if noEOL {
+			out.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
+
+func span(line, count int) string {
+	if count == 0 {
+		line-- // diff names the line before an empty range
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// noNewline tags a final line that has no trailing newline, so "x" and
+// "x\n" compare as different lines
+const noNewline = "\x00"
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i, l := range lines {
+		if trimmed, ok := strings.CutSuffix(l, "\n"); ok {
+			lines[i] = trimmed
+		} else {
+			lines[i] = l + noNewline
+		}
+	}
+	return lines
+}
+
+// diffLines is a longest-common-subsequence diff, fine for fixture-sized
+// files; deletions are listed before insertions within a change
+func diffLines(a, b []string) []op {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+	return ops
+}
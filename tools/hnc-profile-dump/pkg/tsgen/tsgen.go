@@ -0,0 +1,391 @@
+// Package tsgen declares Go types for TypeScript, as interfaces or as zod
+// schemas with the types inferred from them, so a frontend reading what
+// the tools write cannot drift from the Go structs. Property names and
+// optionality come from json tags, descriptions from doc tags, as
+// schemadoc reads them: omitempty makes a property optional, a pointer
+// makes it nullable, and an embedded struct is a base interface.
+package tsgen
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Type is a type to declare, with every named struct type it refers to
+type Type struct {
+	Name  string // TypeScript name, e.g. SwitchProfile; default: the Go type's name
+	Value any    // a value of the type
+}
+
+// Options says what to generate
+type Options struct {
+	Header string // leading comment, one line per line
+	Zod    bool   // zod schemas NameSchema and the types inferred from them, instead of interfaces
+	Strict bool   // zod objects reject unknown keys, as a decoder with DisallowUnknownFields does
+}
+
+// Generate declares types and the struct types they refer to, each
+// after those it refers to, as TypeScript source
+func Generate(opts Options, types ...Type) string {
+	g := &generator{names: map[reflect.Type]string{}, taken: map[string]reflect.Type{}, done: map[reflect.Type]bool{}}
+	for _, t := range types {
+		rt := reflect.TypeOf(t.Value)
+		if t.Name != "" {
+			g.name(rt, t.Name)
+		}
+	}
+	for _, t := range types {
+		g.visit(reflect.TypeOf(t.Value), opts.Zod)
+	}
+
+	var b strings.Builder
+	for _, line := range strings.Split(opts.Header, "\n") {
+		if line != "" {
+			fmt.Fprintf(&b, "// %s\n", line)
+		}
+	}
+	if opts.Zod {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("import { z } from 'zod';\n")
+	}
+	for _, t := range g.order {
+		b.WriteByte('\n')
+		if opts.Zod {
+			g.zodSchema(&b, t, opts.Strict)
+		} else {
+			g.iface(&b, t)
+		}
+	}
+	return b.String()
+}
+
+type generator struct {
+	names map[reflect.Type]string
+	taken map[string]reflect.Type
+	done  map[reflect.Type]bool
+	order []reflect.Type // dependencies first
+}
+
+// property is a struct field as JSON encodes it
+type property struct {
+	name     string
+	t        reflect.Type
+	optional bool
+	nullable bool
+	doc      string
+}
+
+// fields splits a struct into the named struct types it embeds and its
+// own properties
+func fields(t reflect.Type) (bases []reflect.Type, props []property) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				bases = append(bases, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		p := property{name: name, t: ft, optional: hasOpt(opts, "omitempty"), doc: f.Tag.Get("doc")}
+		if ft.Kind() == reflect.Pointer {
+			p.t, p.nullable = ft.Elem(), true
+		}
+		if hasOpt(opts, "string") {
+			p.t = reflect.TypeOf("")
+		}
+		props = append(props, p)
+	}
+	return bases, props
+}
+
+func hasOpt(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// marshals reports how t encodes itself, if it does: as a string for
+// time.Time and text marshalers, as anything for JSON marshalers
+func marshals(t reflect.Type) (string, bool) {
+	implements := func(i reflect.Type) bool { return t.Implements(i) || reflect.PointerTo(t).Implements(i) }
+	switch {
+	case t == timeType:
+		return "string", true
+	case implements(jsonMarshaler):
+		return "unknown", true
+	case implements(textMarshaler):
+		return "string", true
+	}
+	return "", false
+}
+
+// named reports whether t is declared on its own rather than inline
+func named(t reflect.Type) bool {
+	if _, ok := marshals(t); ok {
+		return false
+	}
+	return t.Kind() == reflect.Struct && t.Name() != ""
+}
+
+// name gives t its TypeScript name: the one asked for, else its Go name,
+// prefixed with its package's where another type has that already
+func (g *generator) name(t reflect.Type, want string) string {
+	if n, ok := g.names[t]; ok {
+		return n
+	}
+	n := want
+	if n == "" {
+		n = t.Name()
+	}
+	if other, ok := g.taken[n]; ok && other != t {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		n = strings.ToUpper(pkg[:1]) + pkg[1:] + n
+	}
+	for i := 2; g.taken[n] != nil; i++ {
+		n = fmt.Sprintf("%s%d", strings.TrimRight(n, "0123456789"), i)
+	}
+	g.names[t], g.taken[n] = n, t
+	return n
+}
+
+// visit adds t and the named struct types it refers to, those first
+func (g *generator) visit(t reflect.Type, zod bool) {
+	g.walk(t, zod, map[reflect.Type]bool{})
+}
+
+func (g *generator) walk(t reflect.Type, zod bool, path map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	if _, ok := marshals(t); ok {
+		return
+	}
+	if g.done[t] {
+		return
+	}
+	if path[t] {
+		if zod {
+			panic(fmt.Sprintf("tsgen: %s refers to itself, which a zod schema cannot without z.lazy", t))
+		}
+		return
+	}
+	path[t] = true
+	bases, props := fields(t)
+	for _, b := range bases {
+		g.walk(b, zod, path)
+	}
+	for _, p := range props {
+		g.walk(p.t, zod, path)
+	}
+	delete(path, t)
+	if named(t) && !g.done[t] {
+		g.name(t, "")
+		g.done[t] = true
+		g.order = append(g.order, t)
+	}
+}
+
+// iface declares t as an interface
+func (g *generator) iface(b *strings.Builder, t reflect.Type) {
+	bases, props := fields(t)
+	fmt.Fprintf(b, "export interface %s", g.names[t])
+	if len(bases) > 0 {
+		names := make([]string, len(bases))
+		for i, base := range bases {
+			names[i] = g.names[base]
+		}
+		fmt.Fprintf(b, " extends %s", strings.Join(names, ", "))
+	}
+	b.WriteString(" ")
+	g.object(b, props, "")
+	b.WriteString("\n")
+}
+
+func (g *generator) object(b *strings.Builder, props []property, indent string) {
+	b.WriteString("{\n")
+	for _, p := range props {
+		docComment(b, p.doc, indent+"  ")
+		ts := g.tsType(p.t, indent+"  ")
+		if p.nullable {
+			ts += " | null"
+		}
+		optional := ""
+		if p.optional {
+			optional = "?"
+		}
+		fmt.Fprintf(b, "%s  %s%s: %s;\n", indent, propName(p.name), optional, ts)
+	}
+	b.WriteString(indent + "}")
+}
+
+// tsType is the TypeScript type of the values JSON encodes t as
+func (g *generator) tsType(t reflect.Type, indent string) string {
+	if ts, ok := marshals(t); ok {
+		return ts
+	}
+	if named(t) {
+		return g.names[t]
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Pointer:
+		return "(" + g.tsType(t.Elem(), indent) + " | null)"
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return "string" // base64
+		}
+		elem := g.tsType(t.Elem(), indent)
+		if strings.Contains(elem, " ") && !strings.HasPrefix(elem, "(") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + g.tsType(t.Elem(), indent) + ">"
+	case reflect.Struct:
+		var b strings.Builder
+		_, props := fields(t)
+		g.object(&b, props, indent)
+		return b.String()
+	case reflect.Interface:
+		return "unknown"
+	}
+	panic(fmt.Sprintf("tsgen: no TypeScript type for %s", t))
+}
+
+// zodSchema declares t as a zod schema and the type inferred from it
+func (g *generator) zodSchema(b *strings.Builder, t reflect.Type, strict bool) {
+	bases, props := fields(t)
+	name := g.names[t]
+	fmt.Fprintf(b, "export const %sSchema = ", name)
+	if len(bases) == 0 {
+		b.WriteString("z.object(")
+	} else {
+		b.WriteString(g.names[bases[0]] + "Schema")
+		for _, base := range bases[1:] {
+			fmt.Fprintf(b, ".merge(%sSchema)", g.names[base])
+		}
+		b.WriteString(".extend(")
+	}
+	g.zodShape(b, props, "", strict)
+	b.WriteString(")")
+	if strict && len(bases) == 0 {
+		b.WriteString(".strict()")
+	}
+	fmt.Fprintf(b, ";\nexport type %s = z.infer<typeof %sSchema>;\n", name, name)
+}
+
+func (g *generator) zodShape(b *strings.Builder, props []property, indent string, strict bool) {
+	b.WriteString("{\n")
+	for _, p := range props {
+		docComment(b, p.doc, indent+"  ")
+		z := g.zodType(p.t, indent+"  ", strict)
+		if p.nullable {
+			z += ".nullable()"
+		}
+		if p.optional {
+			z += ".optional()"
+		}
+		fmt.Fprintf(b, "%s  %s: %s,\n", indent, propName(p.name), z)
+	}
+	b.WriteString(indent + "}")
+}
+
+// zodType is the zod schema of the values JSON encodes t as
+func (g *generator) zodType(t reflect.Type, indent string, strict bool) string {
+	if ts, ok := marshals(t); ok {
+		return "z." + ts + "()"
+	}
+	if named(t) {
+		return g.names[t] + "Schema"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "z.boolean()"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "z.number().int()"
+	case reflect.Float32, reflect.Float64:
+		return "z.number()"
+	case reflect.String:
+		return "z.string()"
+	case reflect.Pointer:
+		return g.zodType(t.Elem(), indent, strict) + ".nullable()"
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return "z.string()" // base64
+		}
+		return "z.array(" + g.zodType(t.Elem(), indent, strict) + ")"
+	case reflect.Map:
+		return "z.record(z.string(), " + g.zodType(t.Elem(), indent, strict) + ")"
+	case reflect.Struct:
+		var b strings.Builder
+		_, props := fields(t)
+		b.WriteString("z.object(")
+		g.zodShape(&b, props, indent, strict)
+		b.WriteString(")")
+		if strict {
+			b.WriteString(".strict()")
+		}
+		return b.String()
+	case reflect.Interface:
+		return "z.unknown()"
+	}
+	panic(fmt.Sprintf("tsgen: no zod schema for %s", t))
+}
+
+func docComment(b *strings.Builder, doc, indent string) {
+	if doc != "" {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.ReplaceAll(doc, "*/", "*\\/"))
+	}
+}
+
+// propName quotes a property name that is not an identifier
+func propName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return fmt.Sprintf("%q", name)
+		}
+	}
+	return name
+}
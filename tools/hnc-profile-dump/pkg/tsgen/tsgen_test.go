@@ -0,0 +1,80 @@
+package tsgen
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type base struct {
+	Kind string `json:"kind" doc:"Device kind"`
+}
+
+type port struct {
+	Name  string `json:"name" doc:"Port name"`
+	Speed *int   `json:"speed,omitempty" doc:"Speed in Gbps"`
+}
+
+type device struct {
+	base
+	ID       string            `json:"id"`
+	Ports    []port            `json:"ports"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Weight   float64           `json:"weight"`
+	Seen     time.Time         `json:"seen"`
+	Hyphened bool              `json:"hot-swap"`
+	Extra    any               `json:"extra,omitempty"`
+	Skipped  string            `json:"-"`
+	internal string
+}
+
+func TestGenerate(t *testing.T) {
+	got := Generate(Options{Header: "Generated; do not edit."}, Type{Name: "Device", Value: device{}})
+	want := `// Generated; do not edit.
+
+export interface base {
+  /** Device kind */
+  kind: string;
+}
+
+export interface port {
+  /** Port name */
+  name: string;
+  /** Speed in Gbps */
+  speed?: number | null;
+}
+
+export interface Device extends base {
+  id: string;
+  ports: port[];
+  labels?: Record<string, string>;
+  weight: number;
+  seen: string;
+  "hot-swap": boolean;
+  extra?: unknown;
+}
+`
+	if got != want {
+		t.Errorf("Generate() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGenerateZod(t *testing.T) {
+	got := Generate(Options{Zod: true, Strict: true}, Type{Name: "Device", Value: device{}})
+	for _, want := range []string{
+		"import { z } from 'zod';\n",
+		"export const portSchema = z.object({\n  /** Port name */\n  name: z.string(),\n  /** Speed in Gbps */\n  speed: z.number().int().nullable().optional(),\n}).strict();\n",
+		"export type port = z.infer<typeof portSchema>;\n",
+		"export const DeviceSchema = baseSchema.extend({\n",
+		"  ports: z.array(portSchema),\n",
+		"  labels: z.record(z.string(), z.string()).optional(),\n",
+		"  weight: z.number(),\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(Zod) missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "baseSchema =") > strings.Index(got, "DeviceSchema =") {
+		t.Errorf("Generate(Zod) declares DeviceSchema before the schema it extends:\n%s", got)
+	}
+}
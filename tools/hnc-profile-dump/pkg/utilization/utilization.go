@@ -0,0 +1,191 @@
+// Package utilization reports how much of each switch's port capacity a
+// fabric uses: endpoint ports taken by servers and fabric ports taken by
+// uplinks and peer links, against what the switch's profile offers.
+package utilization
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/capacity"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+// Ports is the use of one class of a switch's ports
+type Ports struct {
+	Used    int     `json:"used"`
+	Free    int     `json:"free"`
+	Total   int     `json:"total"`
+	Percent float64 `json:"percent"` // used / total, rounded to 0.1; 0 when the switch has none
+}
+
+// NewPorts counts used of total ports; Free goes negative when a switch
+// is cabled past its profile
+func NewPorts(used, total int) Ports {
+	p := Ports{Used: used, Free: total - used, Total: total}
+	if total > 0 {
+		p.Percent = float64(int(float64(used)*1000/float64(total)+0.5)) / 10
+	}
+	return p
+}
+
+// RoleSuperSpine is the role of a multi-pod plan's super-spines, whose
+// switches are named superspine1, superspine2, ...
+const RoleSuperSpine = "super-spine"
+
+// Switch is one switch's port use
+type Switch struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"` // super-spine, spine or leaf
+	Model     string `json:"model"`
+	Endpoints Ports  `json:"endpointPorts"`
+	Fabric    Ports  `json:"fabricPorts"`
+	// Classes are a leaf's endpoints by class, when the plan has them;
+	// Endpoints then counts the physical ports they take
+	Classes []Class `json:"endpointClasses,omitempty"`
+}
+
+// Class is one endpoint class on a leaf
+type Class struct {
+	SpeedGbps int    `json:"speedGbps"`
+	Breakout  string `json:"breakout,omitempty"` // e.g. 4x25G; "" for whole ports
+	Endpoints int    `json:"endpoints"`
+	Ports     int    `json:"ports"` // physical endpoint ports
+}
+
+// String is the class as installers read it: 20x25G on 5 ports (4x25G)
+func (c Class) String() string {
+	s := fmt.Sprintf("%dx%dG on %d ports", c.Endpoints, c.SpeedGbps, c.Ports)
+	if c.Breakout != "" {
+		s += " (" + c.Breakout + ")"
+	}
+	return s
+}
+
+// FromPlan reports the switches of a plan cabled as m: super-spines, for
+// a multi-pod plan, then spines, then leaves. Endpoints fill the leaves
+// (or leaf pairs, one port on each leaf) in order, EndpointsPerLeaf at a
+// time, as hnc vpcs attaches them; fabric ports are the map's cables and
+// peer links on each switch, and a border leaf's external links take
+// endpoint ports too. Fabric totals count breakout lanes when the plan
+// splits its ports. A plan with endpoint classes spreads each class
+// evenly instead, as it places them, and counts the ports they take.
+// superSpine is ignored for plans without pods.
+func FromPlan(plan fabricplan.Plan, m cabling.Map, leaf, spine, superSpine profiles.SwitchProfile) ([]Switch, error) {
+	fabric := map[string]int{}
+	for _, l := range m.SpineLinks {
+		fabric[l.Spine]++
+		fabric[l.SuperSpine]++
+	}
+	for _, c := range m.Cables {
+		fabric[c.Leaf]++
+		fabric[c.Spine]++
+	}
+	for _, p := range m.PeerLinks {
+		fabric[p.Leaf]++
+		fabric[p.Peer]++
+	}
+	external := map[string]int{}
+	for _, e := range m.ExternalLinks {
+		external[e.Leaf]++
+	}
+
+	var out []Switch
+	for _, tier := range []struct {
+		role    string
+		count   int
+		profile profiles.SwitchProfile
+	}{{RoleSuperSpine, plan.SuperSpines, superSpine}, {profiles.RoleSpine, plan.Spines, spine}, {profiles.RoleLeaf, plan.Leaves, leaf}} {
+		if tier.count == 0 {
+			continue
+		}
+		fabricTotal, _, err := capacity.FabricPorts(tier.profile, plan.Request.Breakout)
+		if err != nil {
+			return nil, fmt.Errorf("%s %w", tier.role, err)
+		}
+		endpointTotal := 0
+		if tier.role == profiles.RoleLeaf {
+			if endpointTotal, err = capacity.MaxEndpoints(tier.profile, ""); err != nil {
+				return nil, fmt.Errorf("leaf %w", err)
+			}
+		}
+		for i := 0; i < tier.count; i++ {
+			name := strings.ReplaceAll(tier.role, "-", "") + strconv.Itoa(i+1)
+			endpoints := 0
+			var classes []Class
+			if tier.role == profiles.RoleLeaf {
+				slot, slots := i, plan.Leaves
+				if plan.LeafPairs > 0 {
+					slot, slots = i/2, plan.LeafPairs
+				}
+				endpoints = min(max(plan.Request.Endpoints-slot*plan.EndpointsPerLeaf, 0), plan.EndpointsPerLeaf)
+				if len(plan.Placement) > 0 {
+					endpoints = 0
+					for _, p := range plan.Placement {
+						n, ports := p.OnLeaf(slot, slots)
+						classes = append(classes, Class{SpeedGbps: p.SpeedGbps, Breakout: p.Breakout, Endpoints: n, Ports: ports})
+						endpoints += ports
+					}
+				}
+				endpoints += external[name]
+			}
+			out = append(out, Switch{
+				Name:      name,
+				Role:      tier.role,
+				Model:     tier.profile.ModelID,
+				Endpoints: NewPorts(endpoints, endpointTotal),
+				Fabric:    NewPorts(fabric[name], fabricTotal),
+				Classes:   classes,
+			})
+		}
+	}
+	return out, nil
+}
+
+// CSVHeader is the first row of RenderCSV
+var CSVHeader = []string{"switch", "role", "model", "endpoint_used", "endpoint_free", "endpoint_total", "endpoint_percent",
+	"fabric_used", "fabric_free", "fabric_total", "fabric_percent"}
+
+// RenderCSV writes one row per switch. With endpoint classes, a last
+// endpoint_classes column lists a leaf's, separated by semicolons.
+func RenderCSV(switches []Switch) string {
+	classes := false
+	for _, s := range switches {
+		classes = classes || len(s.Classes) > 0
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if classes {
+		w.Write(append(CSVHeader[:len(CSVHeader):len(CSVHeader)], "endpoint_classes"))
+	} else {
+		w.Write(CSVHeader)
+	}
+	for _, s := range switches {
+		row := []string{s.Name, s.Role, s.Model}
+		for _, p := range []Ports{s.Endpoints, s.Fabric} {
+			row = append(row, strconv.Itoa(p.Used), strconv.Itoa(p.Free), strconv.Itoa(p.Total), strconv.FormatFloat(p.Percent, 'f', 1, 64))
+		}
+		if classes {
+			row = append(row, s.ClassList("; "))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// ClassList joins the switch's classes, - for none
+func (s Switch) ClassList(sep string) string {
+	if len(s.Classes) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(s.Classes))
+	for i, c := range s.Classes {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, sep)
+}
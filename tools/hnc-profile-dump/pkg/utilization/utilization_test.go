@@ -0,0 +1,83 @@
+package utilization
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func TestNewPorts(t *testing.T) {
+	if p := NewPorts(1, 3); p != (Ports{Used: 1, Free: 2, Total: 3, Percent: 33.3}) {
+		t.Errorf("NewPorts(1, 3) = %+v", p)
+	}
+	if p := NewPorts(10, 8); p.Free != -2 || p.Percent != 125 {
+		t.Errorf("NewPorts(10, 8) = %+v", p)
+	}
+	if p := NewPorts(0, 0); p.Percent != 0 {
+		t.Errorf("NewPorts(0, 0) = %+v", p)
+	}
+}
+
+func TestFromPlan(t *testing.T) {
+	leaf, spine := profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine)
+	plan, err := fabricplan.Compute(fabricplan.Request{Endpoints: 100, Oversubscription: 3, Redundancy: fabricplan.MCLAG}, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := cabling.Assign(plan, leaf, spine, cabling.RoundRobin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switches, err := FromPlan(plan, m, leaf, spine, profiles.SwitchProfile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(switches) != plan.Spines+plan.Leaves {
+		t.Fatalf("%d switches, want %d", len(switches), plan.Spines+plan.Leaves)
+	}
+	spine1 := switches[0]
+	if spine1.Name != "spine1" || spine1.Endpoints.Total != 0 || spine1.Fabric.Used != plan.SpinePortsUsed {
+		t.Errorf("spine1 = %+v, want %d fabric ports used", spine1, plan.SpinePortsUsed)
+	}
+	// Both leaves of a pair carry a port of each of the pair's endpoints
+	first, last := switches[plan.Spines], switches[len(switches)-1]
+	if first.Endpoints.Used != plan.EndpointsPerLeaf || first.Fabric.Used != plan.UplinksPerLeaf+plan.PeerLinksPerLeaf {
+		t.Errorf("leaf1 = %+v", first)
+	}
+	placed := (plan.LeafPairs - 1) * plan.EndpointsPerLeaf
+	if last.Endpoints.Used != plan.Request.Endpoints-placed {
+		t.Errorf("last leaf = %+v, want the %d endpoints left", last, plan.Request.Endpoints-placed)
+	}
+
+	csv := RenderCSV(switches[:1])
+	if want := strings.Join(CSVHeader, ",") + "\nspine1,spine,celestica-ds3000,0,0,0,0.0,"; !strings.HasPrefix(csv, want) {
+		t.Errorf("CSV =\n%s", csv)
+	}
+}
+
+func TestFromPlanEndpointClasses(t *testing.T) {
+	leaf, spine := profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine)
+	// 10G endpoints run slower on whole SFP28 ports: 2 leaves of 30+10
+	classes := []fabricplan.EndpointClass{{Count: 60, SpeedGbps: 25}, {Count: 19, SpeedGbps: 10}}
+	plan, err := fabricplan.Compute(fabricplan.Request{Oversubscription: 3, Classes: classes}, leaf, spine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ := cabling.Assign(plan, leaf, spine, cabling.RoundRobin, 0)
+	switches, err := FromPlan(plan, m, leaf, spine, profiles.SwitchProfile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf2 := switches[len(switches)-1]
+	if leaf2.Endpoints.Used != 39 || len(leaf2.Classes) != 2 || leaf2.Classes[1] != (Class{SpeedGbps: 10, Endpoints: 9, Ports: 9}) {
+		t.Errorf("leaf2 = %+v", leaf2)
+	}
+	csv := RenderCSV(switches)
+	if !strings.HasPrefix(csv, strings.Join(CSVHeader, ",")+",endpoint_classes\n") || !strings.Contains(csv, "\nspine1,spine,celestica-ds3000,0,0,0,0.0,2,30,32,6.3,-\n") ||
+		!strings.Contains(csv, "\nleaf1,leaf,celestica-ds2000,40,8,48,83.3,3,5,8,37.5,30x25G on 30 ports; 10x10G on 10 ports\n") {
+		t.Errorf("CSV =\n%s", csv)
+	}
+}
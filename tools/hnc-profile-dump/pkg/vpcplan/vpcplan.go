@@ -0,0 +1,258 @@
+// Package vpcplan allocates the tenant side of a fabric plan: VLAN IDs,
+// VNIs and IPv4 subnets for a number of VPCs, drawn from configurable
+// pools, and the VPC attachment of every endpoint. Allocation walks VPCs,
+// then subnets, in order, or for a seeded request in an order shuffled by
+// the seed, and takes the lowest free value of each pool, so the same plan
+// and request always produce the same vpc-plan.json.
+package vpcplan
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/provenance"
+)
+
+// Range is an inclusive range of integers, e.g. VLAN IDs 1000-2999
+type Range struct {
+	First int `json:"first"`
+	Last  int `json:"last"`
+}
+
+// Size is how many values the range holds
+func (r Range) Size() int {
+	return r.Last - r.First + 1
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// ParseRange reads FIRST-LAST
+func ParseRange(s string) (Range, error) {
+	first, last, ok := strings.Cut(s, "-")
+	a, errA := strconv.Atoi(strings.TrimSpace(first))
+	b, errB := strconv.Atoi(strings.TrimSpace(last))
+	if !ok || errA != nil || errB != nil || a > b {
+		return Range{}, fmt.Errorf("bad range %q (want FIRST-LAST, e.g. 1000-2999)", s)
+	}
+	return Range{First: a, Last: b}, nil
+}
+
+// Default pools, matching the Hedgehog fabric's default VLAN and IPv4
+// namespaces
+var (
+	DefaultVLANs    = Range{First: 1000, Last: 2999}
+	DefaultVNIs     = Range{First: 100000, Last: 199999}
+	DefaultIPv4Pool = "10.0.0.0/16"
+)
+
+// Request is the tenant layout to allocate
+type Request struct {
+	VPCs           int    `json:"vpcs"`
+	SubnetPrefixes []int  `json:"subnetPrefixes"` // prefix length of each subnet every VPC gets, e.g. [24, 26]; default [24]
+	VLANs          Range  `json:"vlans"`          // one per subnet
+	VNIs           Range  `json:"vnis"`           // one per VPC and one per subnet
+	IPv4Pool       string `json:"ipv4Pool"`       // CIDR the subnets are carved from
+	// Seed, when not 0, shuffles the order the VPCs take their VLANs, VNIs
+	// and subnets from the pools
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// Plan is the allocation, written as vpc-plan.json
+type Plan struct {
+	Request     Request      `json:"request"`
+	VPCs        []VPC        `json:"vpcs"`
+	Attachments []Attachment `json:"attachments"`
+	// Generator wrote the plan, when it was written by hnc
+	Generator *provenance.Generator `json:"generator,omitempty"`
+}
+
+// VPC is one tenant VPC
+type VPC struct {
+	Name    string   `json:"name"`
+	VNI     int      `json:"vni"`
+	Subnets []Subnet `json:"subnets"`
+}
+
+// Subnet is one VPC subnet and the endpoints attached to it
+type Subnet struct {
+	Name      string `json:"name"`
+	CIDR      string `json:"cidr"`
+	Gateway   string `json:"gateway"`
+	VLAN      int    `json:"vlan"`
+	VNI       int    `json:"vni"`
+	Endpoints int    `json:"endpoints"`
+}
+
+// Attachment puts one endpoint's server connection on a VPC subnet, as a
+// Hedgehog VPCAttachment does
+type Attachment struct {
+	Name       string   `json:"name"`
+	Endpoint   string   `json:"endpoint"`
+	Connection string   `json:"connection"`
+	Leaves     []string `json:"leaves"`
+	Subnet     string   `json:"subnet"` // vpc/subnet
+	VLAN       int      `json:"vlan"`
+}
+
+// Endpoint is a server connection to attach: the server, and the leaves
+// it is cabled to
+type Endpoint struct {
+	Server string
+	Leaves []string
+}
+
+// Compute allocates the VPCs and attaches the fabric's endpoints to them.
+// Endpoints are numbered as the plan places them, filling leaves (or leaf
+// pairs) in order, and split into contiguous, even blocks over the VPCs
+// and within each VPC over its subnets. Each subnet keeps its network,
+// gateway and broadcast addresses, and fails the plan if its endpoints do
+// not fit in the rest.
+func Compute(req Request, fabric fabricplan.Plan) (Plan, error) {
+	n := fabric.Request.Endpoints
+	if n > 0 && fabric.EndpointsPerLeaf <= 0 {
+		return Plan{}, fmt.Errorf("fabric plan places no endpoints on its leaves")
+	}
+	endpoints := make([]Endpoint, n)
+	for e := range endpoints {
+		endpoints[e] = numbered(fabric, e)
+	}
+	return ComputeEndpoints(req, fabric, endpoints)
+}
+
+// ComputeEndpoints is Compute for the given endpoints, such as the servers
+// of an inventory, in place of the plan's numbered ones
+func ComputeEndpoints(req Request, fabric fabricplan.Plan, endpoints []Endpoint) (Plan, error) {
+	if req.VPCs <= 0 {
+		return Plan{}, fmt.Errorf("vpcs must be positive, got %d", req.VPCs)
+	}
+	if len(req.SubnetPrefixes) == 0 {
+		req.SubnetPrefixes = []int{24}
+	}
+	if req.VLANs == (Range{}) {
+		req.VLANs = DefaultVLANs
+	}
+	if req.VNIs == (Range{}) {
+		req.VNIs = DefaultVNIs
+	}
+	if req.IPv4Pool == "" {
+		req.IPv4Pool = DefaultIPv4Pool
+	}
+	if req.VLANs.First < 1 || req.VLANs.Last > 4094 || req.VLANs.Size() < 1 {
+		return Plan{}, fmt.Errorf("VLAN range %s is outside 1-4094", req.VLANs)
+	}
+	if req.VNIs.First < 1 || req.VNIs.Last > 1<<24-1 || req.VNIs.Size() < 1 {
+		return Plan{}, fmt.Errorf("VNI range %s is outside 1-%d", req.VNIs, 1<<24-1)
+	}
+	pool, err := netip.ParsePrefix(req.IPv4Pool)
+	if err != nil || !pool.Addr().Is4() || pool.Masked() != pool {
+		return Plan{}, fmt.Errorf("IPv4 pool %q is not an IPv4 network address, e.g. 10.0.0.0/16", req.IPv4Pool)
+	}
+	for _, p := range req.SubnetPrefixes {
+		if p < pool.Bits() || p > 30 {
+			return Plan{}, fmt.Errorf("subnet prefix /%d must be between the pool's /%d and /30", p, pool.Bits())
+		}
+	}
+
+	subnets := req.VPCs * len(req.SubnetPrefixes)
+	if subnets > req.VLANs.Size() {
+		return Plan{}, fmt.Errorf("%d subnets need %d VLANs, range %s has %d", subnets, subnets, req.VLANs, req.VLANs.Size())
+	}
+	if vnis := req.VPCs + subnets; vnis > req.VNIs.Size() {
+		return Plan{}, fmt.Errorf("%d VPCs and %d subnets need %d VNIs, range %s has %d", req.VPCs, subnets, vnis, req.VNIs, req.VNIs.Size())
+	}
+
+	out := Plan{Request: req, VPCs: make([]VPC, req.VPCs)}
+	attachments := make([][]Attachment, req.VPCs)
+	vlan, vni := req.VLANs.First, req.VNIs.First
+	next := addrValue(pool.Addr())
+	poolEnd := next + 1<<(32-pool.Bits())
+	for _, v := range provenance.Perm(req.Seed, "vpcs", req.VPCs) {
+		vpc := VPC{Name: fmt.Sprintf("vpc-%d", v+1), VNI: vni}
+		vni++
+		first, last := split(len(endpoints), req.VPCs, v)
+		for s, prefix := range req.SubnetPrefixes {
+			size := uint64(1) << (32 - prefix)
+			next = (next + size - 1) / size * size // align to the subnet size
+			if next+size > poolEnd {
+				return Plan{}, fmt.Errorf("IPv4 pool %s is exhausted at %s subnet-%d (/%d)", req.IPv4Pool, vpc.Name, s+1, prefix)
+			}
+			subnet := Subnet{
+				Name:    fmt.Sprintf("subnet-%d", s+1),
+				CIDR:    netip.PrefixFrom(valueAddr(next), prefix).String(),
+				Gateway: valueAddr(next + 1).String(),
+				VLAN:    vlan,
+				VNI:     vni,
+			}
+			next += size
+			vlan++
+			vni++
+
+			a, b := split(last-first, len(req.SubnetPrefixes), s)
+			subnet.Endpoints = b - a
+			if hosts := int(size) - 3; subnet.Endpoints > hosts {
+				return Plan{}, fmt.Errorf("%s/%s (/%d) has room for %d endpoints, not %d; use a larger subnet or more VPCs",
+					vpc.Name, subnet.Name, prefix, hosts, subnet.Endpoints)
+			}
+			for e := first + a; e < first+b; e++ {
+				attachments[v] = append(attachments[v], attach(fabric, endpoints[e], vpc.Name+"/"+subnet.Name, subnet.VLAN))
+			}
+			vpc.Subnets = append(vpc.Subnets, subnet)
+		}
+		out.VPCs[v] = vpc
+	}
+	for _, a := range attachments {
+		out.Attachments = append(out.Attachments, a...)
+	}
+	return out, nil
+}
+
+// split returns block i of n contiguous, even blocks of count items, as
+// [first, last)
+func split(count, n, i int) (first, last int) {
+	return i * count / n, (i + 1) * count / n
+}
+
+// numbered is the plan's endpoint e, server e+1 on the leaf (or leaf
+// pair) it fills
+func numbered(fabric fabricplan.Plan, e int) Endpoint {
+	slot := e / fabric.EndpointsPerLeaf
+	end := Endpoint{Server: "server" + strconv.Itoa(e+1)}
+	if fabric.LeafPairs > 0 {
+		end.Leaves = []string{"leaf" + strconv.Itoa(2*slot+1), "leaf" + strconv.Itoa(2*slot+2)}
+	} else {
+		end.Leaves = []string{"leaf" + strconv.Itoa(slot+1)}
+	}
+	return end
+}
+
+// attach names an endpoint's server connection the way Hedgehog names
+// server connections, e.g. server3--mclag--leaf1--leaf2
+func attach(fabric fabricplan.Plan, end Endpoint, subnet string, vlan int) Attachment {
+	connection := end.Server + "--unbundled--"
+	if len(end.Leaves) > 1 {
+		connection = end.Server + "--" + fabric.Request.Redundancy + "--"
+	}
+	connection += strings.Join(end.Leaves, "--")
+	return Attachment{
+		Name:       connection + "--" + strings.ReplaceAll(subnet, "/", "--"),
+		Endpoint:   end.Server,
+		Connection: connection,
+		Leaves:     end.Leaves,
+		Subnet:     subnet,
+		VLAN:       vlan,
+	}
+}
+
+func addrValue(a netip.Addr) uint64 {
+	b := a.As4()
+	return uint64(b[0])<<24 | uint64(b[1])<<16 | uint64(b[2])<<8 | uint64(b[3])
+}
+
+func valueAddr(v uint64) netip.Addr {
+	return netip.AddrFrom4([4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+}
@@ -0,0 +1,135 @@
+package vpcplan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+)
+
+func fabric(t *testing.T, req fabricplan.Request) fabricplan.Plan {
+	t.Helper()
+	plan, err := fabricplan.Compute(req, profiles.DS2000().InRole(profiles.RoleLeaf), profiles.DS3000().InRole(profiles.RoleSpine))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestCompute(t *testing.T) {
+	plan, err := Compute(Request{VPCs: 2, SubnetPrefixes: []int{24, 27}}, fabric(t, fabricplan.Request{Endpoints: 100, Oversubscription: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VPC{
+		{Name: "vpc-1", VNI: 100000, Subnets: []Subnet{
+			{Name: "subnet-1", CIDR: "10.0.0.0/24", Gateway: "10.0.0.1", VLAN: 1000, VNI: 100001, Endpoints: 25},
+			{Name: "subnet-2", CIDR: "10.0.1.0/27", Gateway: "10.0.1.1", VLAN: 1001, VNI: 100002, Endpoints: 25},
+		}},
+		{Name: "vpc-2", VNI: 100003, Subnets: []Subnet{
+			// Aligned to the /24 boundary past vpc-1's /27
+			{Name: "subnet-1", CIDR: "10.0.2.0/24", Gateway: "10.0.2.1", VLAN: 1002, VNI: 100004, Endpoints: 25},
+			{Name: "subnet-2", CIDR: "10.0.3.0/27", Gateway: "10.0.3.1", VLAN: 1003, VNI: 100005, Endpoints: 25},
+		}},
+	}
+	if !reflect.DeepEqual(plan.VPCs, want) {
+		t.Errorf("VPCs =\n%+v\nwant\n%+v", plan.VPCs, want)
+	}
+	if len(plan.Attachments) != 100 {
+		t.Fatalf("%d attachments, want 100", len(plan.Attachments))
+	}
+	// 100 endpoints on 3 leaves: 34 per leaf
+	a := plan.Attachments[34]
+	if a.Endpoint != "server35" || a.Connection != "server35--unbundled--leaf2" || a.Subnet != "vpc-1/subnet-2" || a.VLAN != 1001 {
+		t.Errorf("attachment 35 = %+v", a)
+	}
+	if a.Name != "server35--unbundled--leaf2--vpc-1--subnet-2" {
+		t.Errorf("name = %s", a.Name)
+	}
+
+	again, _ := Compute(Request{VPCs: 2, SubnetPrefixes: []int{24, 27}}, fabric(t, fabricplan.Request{Endpoints: 100, Oversubscription: 3}))
+	if !reflect.DeepEqual(plan, again) {
+		t.Error("same inputs allocated differently")
+	}
+}
+
+func TestComputeSeed(t *testing.T) {
+	f := fabric(t, fabricplan.Request{Endpoints: 100, Oversubscription: 3})
+	canonical, _ := Compute(Request{VPCs: 4}, f)
+	plan, err := Compute(Request{VPCs: 4, Seed: 7}, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := Compute(Request{VPCs: 4, Seed: 7}, f)
+	if !reflect.DeepEqual(plan, again) {
+		t.Error("same inputs and seed allocated differently")
+	}
+	// The VPCs keep their names, endpoints and attachment order and take
+	// the same pool values between them, in another order
+	if reflect.DeepEqual(plan.VPCs, canonical.VPCs) {
+		t.Fatal("seed 7 allocated as unseeded")
+	}
+	vlans := map[int]bool{}
+	for i, vpc := range plan.VPCs {
+		c := canonical.VPCs[i]
+		if vpc.Name != c.Name || vpc.Subnets[0].Endpoints != c.Subnets[0].Endpoints {
+			t.Errorf("VPC %d = %+v, unseeded %+v", i, vpc, c)
+		}
+		vlans[vpc.Subnets[0].VLAN] = true
+	}
+	for _, vpc := range canonical.VPCs {
+		if !vlans[vpc.Subnets[0].VLAN] {
+			t.Errorf("seeded VPCs do not use VLAN %d", vpc.Subnets[0].VLAN)
+		}
+	}
+	for i, a := range plan.Attachments {
+		if a.Endpoint != canonical.Attachments[i].Endpoint || a.Subnet != canonical.Attachments[i].Subnet {
+			t.Fatalf("attachment %d = %+v, unseeded %+v", i, a, canonical.Attachments[i])
+		}
+	}
+}
+
+func TestDualHomed(t *testing.T) {
+	plan, err := Compute(Request{VPCs: 1}, fabric(t, fabricplan.Request{Endpoints: 60, Oversubscription: 3, Redundancy: fabricplan.ESLAG}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := plan.Attachments[len(plan.Attachments)-1]
+	if last.Connection != "server60--eslag--leaf3--leaf4" || !reflect.DeepEqual(last.Leaves, []string{"leaf3", "leaf4"}) {
+		t.Errorf("last attachment = %+v", last)
+	}
+}
+
+func TestComputeErrors(t *testing.T) {
+	small := fabric(t, fabricplan.Request{Endpoints: 10, Oversubscription: 3})
+	for _, tc := range []struct {
+		req  Request
+		want string
+	}{
+		{Request{}, "vpcs must be positive"},
+		{Request{VPCs: 1, SubnetPrefixes: []int{29}}, "room for 5 endpoints, not 10"},
+		{Request{VPCs: 1, SubnetPrefixes: []int{8}}, "between the pool's /16 and /30"},
+		{Request{VPCs: 3, VLANs: Range{100, 101}}, "3 subnets need 3 VLANs"},
+		{Request{VPCs: 2, VNIs: Range{1, 3}}, "need 4 VNIs"},
+		{Request{VPCs: 2, IPv4Pool: "10.0.0.0/24"}, "exhausted at vpc-2 subnet-1"},
+		{Request{VPCs: 1, IPv4Pool: "10.0.0.1/24"}, "not an IPv4 network address"},
+		{Request{VPCs: 1, VLANs: Range{4000, 4095}}, "outside 1-4094"},
+	} {
+		if _, err := Compute(tc.req, small); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Compute(%+v) = %v, want %q", tc.req, err, tc.want)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	if r, err := ParseRange("1000-2999"); err != nil || r != DefaultVLANs || r.Size() != 2000 {
+		t.Errorf("ParseRange = %v, %v", r, err)
+	}
+	for _, s := range []string{"1000", "a-b", "20-10"} {
+		if _, err := ParseRange(s); err == nil {
+			t.Errorf("ParseRange(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+// Package wiringyaml reads Hedgehog wiring documents (the Switch, Server and
+// Connection objects of a wiring.yaml) and reconstructs the HNC design
+// they amount to: a fabric plan, its cabling map and the port
+// utilization of every switch, so a brownfield fabric can be loaded into
+// the designer. Objects of other kinds, and connections other than
+// fabric, MCLAG domain and server ones, are skipped.
+package wiringyaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/cabling"
+	"github.com/hnc/profile-dump/pkg/capacity"
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/utilization"
+)
+
+// Strategy marks cabling maps read from a wiring rather than assigned
+const Strategy = "imported"
+
+// Connection types read from a Connection's spec
+const (
+	Fabric      = "fabric"
+	MCLAGDomain = "mclag-domain"
+	Unbundled   = "unbundled"
+	Bundled     = "bundled"
+	MCLAG       = "mclag"
+	ESLAG       = "eslag"
+)
+
+// Switch is one wiring Switch object
+type Switch struct {
+	Name       string
+	Role       string // spine, server-leaf, border-leaf or mixed-leaf
+	Profile    string // SwitchProfile name, e.g. celestica-ds2000
+	Group      string // redundancy group, e.g. mclag-1
+	Redundancy string // mclag or eslag; "" when the switch is in no group
+}
+
+// Link joins two ports, each written device/port: spine to leaf in a
+// fabric connection, switch1 to switch2 in an MCLAG domain, server to
+// switch in a server connection
+type Link struct {
+	A, B string
+}
+
+// Connection is one wiring Connection object
+type Connection struct {
+	Name     string
+	Type     string // one of the connection types, or the spec key of another
+	Links    []Link
+	Sessions []Link // MCLAG session links
+}
+
+// Wiring is the objects of a wiring.yaml HNC understands, in document order
+type Wiring struct {
+	Switches    []Switch
+	Servers     []string
+	Connections []Connection
+}
+
+type object struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec json.RawMessage `json:"spec"`
+}
+
+type port struct {
+	Port string `json:"port"`
+}
+
+type serverLink struct {
+	Server port `json:"server"`
+	Switch port `json:"switch"`
+}
+
+// Parse reads the wiring objects of a multi-document YAML stream
+func Parse(data []byte) (Wiring, error) {
+	docs, err := profiles.ParseYAMLDocuments(data)
+	if err != nil {
+		return Wiring{}, err
+	}
+	var w Wiring
+	for i, doc := range docs {
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return Wiring{}, err
+		}
+		var o object
+		if err := json.Unmarshal(raw, &o); err != nil {
+			return Wiring{}, fmt.Errorf("document %d: %w", i+1, err)
+		}
+		if !strings.HasPrefix(o.APIVersion, "wiring.githedgehog.com/") {
+			continue
+		}
+		if o.Metadata.Name == "" {
+			return Wiring{}, fmt.Errorf("document %d: %s has no metadata.name", i+1, o.Kind)
+		}
+		switch o.Kind {
+		case "Switch":
+			var spec struct {
+				Role       string `json:"role"`
+				Profile    string `json:"profile"`
+				Redundancy struct {
+					Group string `json:"group"`
+					Type  string `json:"type"`
+				} `json:"redundancy"`
+			}
+			if err := unmarshalSpec(o, &spec); err != nil {
+				return Wiring{}, err
+			}
+			w.Switches = append(w.Switches, Switch{Name: o.Metadata.Name, Role: spec.Role, Profile: spec.Profile,
+				Group: spec.Redundancy.Group, Redundancy: spec.Redundancy.Type})
+		case "Server":
+			w.Servers = append(w.Servers, o.Metadata.Name)
+		case "Connection":
+			c, err := parseConnection(o)
+			if err != nil {
+				return Wiring{}, err
+			}
+			w.Connections = append(w.Connections, c)
+		}
+	}
+	return w, nil
+}
+
+func unmarshalSpec(o object, v any) error {
+	if len(o.Spec) == 0 || string(o.Spec) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(o.Spec, v); err != nil {
+		return fmt.Errorf("%s %s: %w", o.Kind, o.Metadata.Name, err)
+	}
+	return nil
+}
+
+func parseConnection(o object) (Connection, error) {
+	var spec struct {
+		Fabric *struct {
+			Links []struct {
+				Spine port `json:"spine"`
+				Leaf  port `json:"leaf"`
+			} `json:"links"`
+		} `json:"fabric"`
+		MCLAGDomain *struct {
+			PeerLinks    []struct{ Switch1, Switch2 port } `json:"peerLinks"`
+			SessionLinks []struct{ Switch1, Switch2 port } `json:"sessionLinks"`
+		} `json:"mclagDomain"`
+		Unbundled *struct {
+			Link serverLink `json:"link"`
+		} `json:"unbundled"`
+		Bundled *struct {
+			Links []serverLink `json:"links"`
+		} `json:"bundled"`
+		MCLAG *struct {
+			Links []serverLink `json:"links"`
+		} `json:"mclag"`
+		ESLAG *struct {
+			Links []serverLink `json:"links"`
+		} `json:"eslag"`
+	}
+	if err := unmarshalSpec(o, &spec); err != nil {
+		return Connection{}, err
+	}
+	c := Connection{Name: o.Metadata.Name}
+	servers := func(kind string, links []serverLink) {
+		c.Type = kind
+		for _, l := range links {
+			c.Links = append(c.Links, Link{A: l.Server.Port, B: l.Switch.Port})
+		}
+	}
+	switch {
+	case spec.Fabric != nil:
+		c.Type = Fabric
+		for _, l := range spec.Fabric.Links {
+			c.Links = append(c.Links, Link{A: l.Spine.Port, B: l.Leaf.Port})
+		}
+	case spec.MCLAGDomain != nil:
+		c.Type = MCLAGDomain
+		for _, l := range spec.MCLAGDomain.PeerLinks {
+			c.Links = append(c.Links, Link{A: l.Switch1.Port, B: l.Switch2.Port})
+		}
+		for _, l := range spec.MCLAGDomain.SessionLinks {
+			c.Sessions = append(c.Sessions, Link{A: l.Switch1.Port, B: l.Switch2.Port})
+		}
+	case spec.Unbundled != nil:
+		servers(Unbundled, []serverLink{spec.Unbundled.Link})
+	case spec.Bundled != nil:
+		servers(Bundled, spec.Bundled.Links)
+	case spec.MCLAG != nil:
+		servers(MCLAG, spec.MCLAG.Links)
+	case spec.ESLAG != nil:
+		servers(ESLAG, spec.ESLAG.Links)
+	default:
+		var keys map[string]json.RawMessage
+		json.Unmarshal(o.Spec, &keys)
+		for k := range keys {
+			if c.Type == "" || k < c.Type {
+				c.Type = k
+			}
+		}
+	}
+	for _, l := range append(append([]Link(nil), c.Links...), c.Sessions...) {
+		for _, p := range []string{l.A, l.B} {
+			if _, _, ok := splitPort(p); !ok {
+				return Connection{}, fmt.Errorf("Connection %s: port %q is not device/port", c.Name, p)
+			}
+		}
+	}
+	return c, nil
+}
+
+// splitPort splits leaf1/E1/49 into leaf1 and E1/49
+func splitPort(p string) (device, name string, ok bool) {
+	device, name, ok = strings.Cut(p, "/")
+	return device, name, ok && device != "" && name != ""
+}
+
+// Import is the HNC design a wiring amounts to
+type Import struct {
+	Plan        fabricplan.Plan      `json:"plan"`
+	Cabling     cabling.Map          `json:"cabling"`
+	Utilization []utilization.Switch `json:"utilization"`
+	Warnings    []string             `json:"warnings,omitempty"`
+}
+
+// Reconstruct rebuilds the plan a wiring would have been designed from.
+// Every spine must run one profile and every leaf another, both in the
+// registry; fabric ports named as the profile's namingScheme spells them
+// are renamed to the profile's own names. Counts that a plan holds once but a wiring can vary
+// (uplinks per leaf, ports per spine, endpoints per leaf) take the
+// largest value, with a warning when switches differ; the request's
+// oversubscription is the one achieved.
+func Reconstruct(w Wiring, registry *profiles.Registry) (Import, error) {
+	var out Import
+	warn := func(format string, args ...any) {
+		out.Warnings = append(out.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	var spines, leaves []Switch
+	role := map[string]string{}
+	for _, sw := range w.Switches {
+		switch {
+		case sw.Role == profiles.RoleSpine:
+			spines = append(spines, sw)
+			role[sw.Name] = profiles.RoleSpine
+		case strings.HasSuffix(sw.Role, profiles.RoleLeaf):
+			leaves = append(leaves, sw)
+			role[sw.Name] = profiles.RoleLeaf
+		default:
+			warn("switch %s has role %q and is not part of the plan", sw.Name, sw.Role)
+		}
+	}
+	if len(spines) == 0 || len(leaves) == 0 {
+		return Import{}, fmt.Errorf("wiring has %d spine(s) and %d leaf(s); HNC plans need both", len(spines), len(leaves))
+	}
+	spine, err := tierProfile(registry, "spine", spines)
+	if err != nil {
+		return Import{}, err
+	}
+	leaf, err := tierProfile(registry, "leaf", leaves)
+	if err != nil {
+		return Import{}, err
+	}
+
+	endpointPorts, fabricPorts := map[string]int{}, map[string]int{}
+	uplinks, spinePorts, peerLinks := map[string]int{}, map[string]int{}, 0
+	m := cabling.Map{Strategy: Strategy, LeafModel: leaf.ModelID, SpineModel: spine.ModelID}
+	endpoints, lanes := 0, false
+	for _, c := range w.Connections {
+		switch c.Type {
+		case Fabric:
+			for _, l := range c.Links {
+				s, sp, _ := splitPort(l.A)
+				lf, lp, _ := splitPort(l.B)
+				if role[s] != profiles.RoleSpine || role[lf] != profiles.RoleLeaf {
+					return Import{}, fmt.Errorf("Connection %s: %s <-> %s is not a spine-leaf link", c.Name, l.A, l.B)
+				}
+				if sp, err = spine.HNCPort(sp); err == nil {
+					lp, err = leaf.HNCPort(lp)
+				}
+				if err != nil {
+					return Import{}, fmt.Errorf("Connection %s: %w", c.Name, err)
+				}
+				cable := cabling.Cable{Leaf: lf, LeafPort: lp, Spine: s, SpinePort: sp}
+				cable.Link = cable.String()
+				m.Cables = append(m.Cables, cable)
+				uplinks[lf]++
+				spinePorts[s]++
+				fabricPorts[lf]++
+				fabricPorts[s]++
+				lanes = lanes || strings.Count(lp, "/") > 1 || strings.Count(sp, "/") > 1
+			}
+		case MCLAGDomain:
+			for _, l := range c.Links {
+				a, ap, _ := splitPort(l.A)
+				b, bp, _ := splitPort(l.B)
+				if ap, err = leaf.HNCPort(ap); err == nil {
+					bp, err = leaf.HNCPort(bp)
+				}
+				if err != nil {
+					return Import{}, fmt.Errorf("Connection %s: %w", c.Name, err)
+				}
+				m.PeerLinks = append(m.PeerLinks, cabling.PeerLink{Leaf: a, LeafPort: ap, Peer: b, PeerPort: bp})
+				fabricPorts[a]++
+				fabricPorts[b]++
+			}
+			for _, l := range c.Sessions {
+				a, _, _ := splitPort(l.A)
+				b, _, _ := splitPort(l.B)
+				fabricPorts[a]++
+				fabricPorts[b]++
+			}
+			peerLinks = max(peerLinks, len(c.Links))
+		case Unbundled, Bundled, MCLAG, ESLAG:
+			endpoints++
+			for _, l := range c.Links {
+				sw, _, _ := splitPort(l.B)
+				endpointPorts[sw]++
+			}
+		default:
+			warn("connection %s (%s) is not part of the plan", c.Name, c.Type)
+		}
+	}
+	if lanes {
+		warn("fabric links use breakout lanes; each lane is counted as a port and the plan records no breakout")
+	}
+
+	perLeaf, uplinksPerLeaf := maxOf(leaves, endpointPorts), maxOf(leaves, uplinks)
+	if minOf(leaves, uplinks) != uplinksPerLeaf {
+		warn("leaves have %d to %d uplinks; the plan records %d", minOf(leaves, uplinks), uplinksPerLeaf, uplinksPerLeaf)
+	}
+	spineUsed := maxOf(spines, spinePorts)
+	if minOf(spines, spinePorts) != spineUsed {
+		warn("spines terminate %d to %d fabric links; the plan records %d", minOf(spines, spinePorts), spineUsed, spineUsed)
+	}
+
+	redundancy, groups := "", map[string]bool{}
+	for _, sw := range leaves {
+		if sw.Redundancy == "" {
+			continue
+		}
+		if redundancy != "" && sw.Redundancy != redundancy {
+			return Import{}, fmt.Errorf("leaves mix %s and %s redundancy groups; HNC plans one", redundancy, sw.Redundancy)
+		}
+		redundancy = sw.Redundancy
+		groups[sw.Group] = true
+	}
+	if redundancy != "" && redundancy != fabricplan.MCLAG && redundancy != fabricplan.ESLAG {
+		return Import{}, fmt.Errorf("unknown leaf redundancy %q (want %s or %s)", redundancy, fabricplan.MCLAG, fabricplan.ESLAG)
+	}
+	if redundancy != "" && 2*len(groups) != len(leaves) {
+		warn("%d leaves form %d %s group(s); the plan assumes every leaf is paired", len(leaves), len(groups), redundancy)
+	}
+
+	endpointSpeed := leaf.Profiles.Endpoint.SpeedGbps
+	leafFabric, uplinkSpeed, err := capacity.FabricPorts(leaf, "")
+	if err != nil {
+		return Import{}, fmt.Errorf("leaf %w", err)
+	}
+	spineFabric, _, err := capacity.FabricPorts(spine, "")
+	if err != nil {
+		return Import{}, fmt.Errorf("spine %w", err)
+	}
+	down, up := perLeaf*endpointSpeed, uplinksPerLeaf*uplinkSpeed
+	ratio := 0.0
+	if up > 0 {
+		ratio, _ = capacity.Oversubscription(down, up)
+		ratio = math.Round(ratio*100) / 100
+	} else {
+		warn("leaves have no uplinks")
+	}
+	if uplinksPerLeaf+peerLinks > leafFabric {
+		warn("leaves use %d fabric ports, profile %s has %d", uplinksPerLeaf+peerLinks, leaf.ModelID, leafFabric)
+	}
+
+	out.Plan = fabricplan.Plan{
+		Request: fabricplan.Request{
+			Endpoints:         endpoints,
+			Oversubscription:  ratio,
+			MinSpines:         len(spines),
+			EndpointSpeedGbps: endpointSpeed,
+			Redundancy:        redundancy,
+			PeerLinks:         peerLinks,
+		},
+		LeafModel:                leaf.ModelID,
+		SpineModel:               spine.ModelID,
+		Leaves:                   len(leaves),
+		Spines:                   len(spines),
+		UplinksPerLeaf:           uplinksPerLeaf,
+		EndpointsPerLeaf:         perLeaf,
+		DownlinkGbpsPerLeaf:      down,
+		UplinkGbpsPerLeaf:        up,
+		AchievedOversubscription: ratio,
+		SpinePortsUsed:           spineUsed,
+		SpinePortsFree:           spineFabric - spineUsed,
+		LeafPairs:                len(groups),
+		PeerLinksPerLeaf:         peerLinks,
+	}
+	out.Cabling = m
+
+	for _, tier := range []struct {
+		switches []Switch
+		role     string
+		profile  profiles.SwitchProfile
+	}{{spines, profiles.RoleSpine, spine}, {leaves, profiles.RoleLeaf, leaf}} {
+		endpointTotal, _ := capacity.MaxEndpoints(tier.profile, "")
+		fabricTotal, _, _ := capacity.FabricPorts(tier.profile, "")
+		for _, sw := range tier.switches {
+			out.Utilization = append(out.Utilization, utilization.Switch{
+				Name:      sw.Name,
+				Role:      tier.role,
+				Model:     tier.profile.ModelID,
+				Endpoints: utilization.NewPorts(endpointPorts[sw.Name], endpointTotal),
+				Fabric:    utilization.NewPorts(fabricPorts[sw.Name], fabricTotal),
+			})
+		}
+	}
+	return out, nil
+}
+
+// tierProfile is the one profile every switch of a tier runs, as that
+// tier sees it
+func tierProfile(registry *profiles.Registry, tier string, switches []Switch) (profiles.SwitchProfile, error) {
+	seen := map[string]bool{}
+	for _, sw := range switches {
+		seen[sw.Profile] = true
+	}
+	if len(seen) > 1 {
+		names := make([]string, 0, len(seen))
+		for name := range seen {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return profiles.SwitchProfile{}, fmt.Errorf("%ss run %d profiles (%s); HNC plans one %s model", tier, len(names), strings.Join(names, ", "), tier)
+	}
+	name := switches[0].Profile
+	p, ok := registry.Find(name)
+	if !ok {
+		return profiles.SwitchProfile{}, fmt.Errorf("no profile for %s model %q (switch %s); load it with -profiles", tier, name, switches[0].Name)
+	}
+	return p.InRole(tier), nil
+}
+
+func maxOf(switches []Switch, counts map[string]int) int {
+	n := 0
+	for _, sw := range switches {
+		n = max(n, counts[sw.Name])
+	}
+	return n
+}
+
+func minOf(switches []Switch, counts map[string]int) int {
+	n := math.MaxInt
+	for _, sw := range switches {
+		n = min(n, counts[sw.Name])
+	}
+	return n
+}
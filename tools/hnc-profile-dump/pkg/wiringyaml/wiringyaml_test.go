@@ -0,0 +1,129 @@
+package wiringyaml
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hnc/profile-dump/pkg/fabricplan"
+	"github.com/hnc/profile-dump/pkg/profiles"
+	"github.com/hnc/profile-dump/pkg/utilization"
+)
+
+func parse(t *testing.T) Wiring {
+	t.Helper()
+	data, err := os.ReadFile("testdata/wiring.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestParse(t *testing.T) {
+	w := parse(t)
+	if len(w.Switches) != 4 || len(w.Servers) != 2 || len(w.Connections) != 8 {
+		t.Fatalf("%d switches, %d servers, %d connections; want 4, 2, 8", len(w.Switches), len(w.Servers), len(w.Connections))
+	}
+	if sw := w.Switches[2]; sw != (Switch{Name: "leaf-01", Role: "server-leaf", Profile: "celestica-ds2000", Group: "mclag-1", Redundancy: "mclag"}) {
+		t.Errorf("leaf-01 = %+v", sw)
+	}
+	domain := w.Connections[4]
+	if domain.Type != MCLAGDomain || len(domain.Links) != 2 || len(domain.Sessions) != 1 || domain.Links[0] != (Link{A: "leaf-01/E1/55", B: "leaf-02/E1/55"}) {
+		t.Errorf("mclag domain = %+v", domain)
+	}
+	if c := w.Connections[7]; c.Type != "external" || len(c.Links) != 0 {
+		t.Errorf("external connection = %+v", c)
+	}
+
+	if _, err := Parse([]byte("apiVersion: wiring.githedgehog.com/v1beta1\nkind: Connection\nmetadata:\n  name: x\nspec:\n  unbundled:\n    link:\n      server:\n        port: server-01\n      switch:\n        port: leaf-01/E1/1\n")); err == nil ||
+		!strings.Contains(err.Error(), `port "server-01" is not device/port`) {
+		t.Errorf("bare port error = %v", err)
+	}
+}
+
+func TestReconstruct(t *testing.T) {
+	out, err := Reconstruct(parse(t), profiles.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := out.Plan
+	if p.LeafModel != "celestica-ds2000" || p.Leaves != 2 || p.Spines != 2 || p.UplinksPerLeaf != 4 || p.LeafPairs != 1 ||
+		p.PeerLinksPerLeaf != 2 || p.Request.Redundancy != fabricplan.MCLAG || p.Request.Endpoints != 2 || p.EndpointsPerLeaf != 2 {
+		t.Errorf("plan = %+v", p)
+	}
+	if p.SpinePortsUsed != 3 || p.SpinePortsFree != 29 {
+		t.Errorf("spine ports = %d used, %d free; want 3, 29", p.SpinePortsUsed, p.SpinePortsFree)
+	}
+	if len(out.Cabling.Cables) != 6 || out.Cabling.Cables[0].Link != "leaf-01:E1/49 <-> spine-01:E1/1" || len(out.Cabling.PeerLinks) != 2 {
+		t.Errorf("cabling = %+v", out.Cabling)
+	}
+	if len(out.Warnings) != 2 || !strings.Contains(out.Warnings[0], "external") || !strings.Contains(out.Warnings[1], "2 to 4 uplinks") {
+		t.Errorf("warnings = %q", out.Warnings)
+	}
+	// 4 uplinks, 2 peer links and a session link on leaf-01
+	if u := out.Utilization[2]; u.Name != "leaf-01" || u.Endpoints != utilization.NewPorts(2, 48) || u.Fabric != utilization.NewPorts(7, 8) {
+		t.Errorf("leaf-01 utilization = %+v", u)
+	}
+}
+
+func TestReconstructErrors(t *testing.T) {
+	for _, tc := range []struct {
+		edit func(*Wiring)
+		want string
+	}{
+		{func(w *Wiring) { w.Switches = w.Switches[2:] }, "0 spine(s) and 2 leaf(s)"},
+		{func(w *Wiring) { w.Switches[3].Profile = "dell-s5248f-on" }, "leafs run 2 profiles"},
+		{func(w *Wiring) { w.Switches[0].Profile, w.Switches[1].Profile = "acme-x1", "acme-x1" }, `no profile for spine model "acme-x1"`},
+		{func(w *Wiring) { w.Switches[3].Redundancy = "eslag" }, "mix mclag and eslag"},
+		{func(w *Wiring) { w.Connections[0].Links[0].A = "leaf-02/E1/60" }, "is not a spine-leaf link"},
+	} {
+		w := parse(t)
+		tc.edit(&w)
+		if _, err := Reconstruct(w, profiles.Default()); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Reconstruct = %v, want %q", err, tc.want)
+		}
+	}
+}
+
+// Leaf ports spelled as a Cumulus leaf names them map to the profile's
+func TestReconstructNamingScheme(t *testing.T) {
+	leaf := profiles.DS2000()
+	leaf.NamingScheme = "cumulus"
+	registry, err := profiles.NewRegistry(leaf, profiles.DS3000())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := parse(t)
+	for i, c := range w.Connections {
+		if c.Type != Fabric && c.Type != MCLAGDomain {
+			continue
+		}
+		for j, l := range c.Links {
+			for _, end := range []*string{&l.A, &l.B} {
+				if strings.HasPrefix(*end, "leaf-") {
+					*end = strings.Replace(*end, "/E1/", "/swp", 1)
+				}
+			}
+			w.Connections[i].Links[j] = l
+		}
+	}
+	out, err := Reconstruct(w, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Cabling.Cables[0].Link; got != "leaf-01:E1/49 <-> spine-01:E1/1" {
+		t.Errorf("first cable = %s", got)
+	}
+	if p := out.Cabling.PeerLinks[0]; p.LeafPort != "E1/55" || p.PeerPort != "E1/55" {
+		t.Errorf("first peer link = %+v", p)
+	}
+
+	w.Connections[0].Links[0].B = "leaf-01/Eth1/49"
+	if _, err := Reconstruct(w, registry); err == nil || !strings.Contains(err.Error(), "not a cumulus port name") {
+		t.Errorf("Reconstruct of an Eth port on a cumulus leaf = %v", err)
+	}
+}
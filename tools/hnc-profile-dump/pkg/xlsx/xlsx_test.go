@@ -0,0 +1,81 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	bom, err := FromCSV("BOM", "category,item,quantity\nswitch,DS2000 <leaf>,4\noptic,007,2.5\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Write(Sheet{Name: "Plan", Rows: [][]string{{"field", "value"}, {"leaves", "4"}}}, bom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := Write(Sheet{Name: "Plan", Rows: [][]string{{"field", "value"}, {"leaves", "4"}}}, bom)
+	if !bytes.Equal(data, again) {
+		t.Error("Write is not deterministic")
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(r)
+		parts[f.Name] = string(content)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook has no %s", name)
+		}
+	}
+	if want := `<sheet name="BOM" sheetId="2" r:id="rId2"/>`; !strings.Contains(parts["xl/workbook.xml"], want) {
+		t.Errorf("workbook.xml missing %s:\n%s", want, parts["xl/workbook.xml"])
+	}
+	sheet := parts["xl/worksheets/sheet2.xml"]
+	for _, want := range []string{
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`,
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">category</t></is></c>`,
+		`<t xml:space="preserve">DS2000 &lt;leaf&gt;</t>`,
+		`<c r="C2"><v>4</v></c>`,
+		`<c r="B3" t="inlineStr"><is><t xml:space="preserve">007</t></is></c>`,
+		`<c r="C3"><v>2.5</v></c>`,
+		`<col min="2" max="2" width="15" customWidth="1"/>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet2.xml missing %s:\n%s", want, sheet)
+		}
+	}
+}
+
+func TestWriteRejectsSheetNames(t *testing.T) {
+	for _, sheets := range [][]Sheet{
+		nil,
+		{{Name: "a/b"}},
+		{{Name: strings.Repeat("x", 32)}},
+		{{Name: "BOM"}, {Name: "bom"}},
+	} {
+		if _, err := Write(sheets...); err == nil {
+			t.Errorf("Write(%v) succeeded", sheets)
+		}
+	}
+}
+
+func TestColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := column(i); got != want {
+			t.Errorf("column(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
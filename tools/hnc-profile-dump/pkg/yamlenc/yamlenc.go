@@ -0,0 +1,175 @@
+// Package yamlenc renders JSON documents as block YAML, keeping object
+// keys in the order they appear in the JSON, so a value marshalled with
+// canonjson produces YAML with the same stable field ordering.
+package yamlenc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hnc/profile-dump/pkg/canonjson"
+)
+
+// Marshal renders v as it would be marshalled to JSON, in YAML
+func Marshal(v any) ([]byte, error) {
+	data, err := canonjson.Compact(v)
+	if err != nil {
+		return nil, err
+	}
+	return FromJSON(data)
+}
+
+// FromJSON converts a JSON document to YAML. Strings are always quoted, so
+// values like "1" or "true" keep their type; empty objects and arrays are
+// written as {} and [].
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := decode(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, fmt.Errorf("unexpected data after JSON document")
+	}
+
+	var b strings.Builder
+	switch v := root.(type) {
+	case object:
+		if len(v) == 0 {
+			b.WriteString("{}\n")
+		}
+		writeObject(&b, v, 0)
+	case []any:
+		if len(v) == 0 {
+			b.WriteString("[]\n")
+		}
+		writeArray(&b, v, 0)
+	default:
+		b.WriteString(scalar(v) + "\n")
+	}
+	return []byte(b.String()), nil
+}
+
+type member struct {
+	key   string
+	value any
+}
+
+// object is a JSON object with its keys in document order
+type object []member
+
+func decode(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.(string), value: value})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			value, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+func writeObject(b *strings.Builder, obj object, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, m := range obj {
+		b.WriteString(pad + key(m.key) + ":")
+		writeValue(b, m.value, indent)
+	}
+}
+
+func writeArray(b *strings.Builder, arr []any, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, item := range arr {
+		if obj, ok := item.(object); ok && len(obj) > 0 {
+			// "- key: value" with the rest of the object aligned past the dash
+			var inner strings.Builder
+			writeObject(&inner, obj, indent+2)
+			b.WriteString(pad + "- " + strings.TrimPrefix(inner.String(), pad+"  "))
+			continue
+		}
+		b.WriteString(pad + "-")
+		writeValue(b, item, indent)
+	}
+}
+
+// writeValue finishes a "key:" or "-" line with a scalar, or nests a
+// non-empty object or array below it
+func writeValue(b *strings.Builder, value any, indent int) {
+	switch v := value.(type) {
+	case object:
+		if len(v) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		writeObject(b, v, indent+2)
+	case []any:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeArray(b, v, indent+2)
+	default:
+		b.WriteString(" " + scalar(v) + "\n")
+	}
+}
+
+func scalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return strconv.Quote(v)
+	}
+	return fmt.Sprint(v)
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_./-]*$`)
+
+// key leaves identifier-like keys (and port names such as E1/1) plain
+// and quotes the rest, including words YAML 1.1 reads as booleans or null
+func key(k string) string {
+	switch strings.ToLower(k) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return strconv.Quote(k)
+	}
+	if plainKey.MatchString(k) {
+		return k
+	}
+	return strconv.Quote(k)
+}
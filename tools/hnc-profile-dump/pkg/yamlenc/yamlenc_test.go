@@ -0,0 +1,37 @@
+package yamlenc
+
+import "testing"
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"keys keep document order", `{"z":1,"a":{"x":true,"b":null}}`, "z: 1\na:\n  x: true\n  b: null\n"},
+		{"strings stay quoted", `{"label":"1","flag":"true"}`, "label: \"1\"\nflag: \"true\"\n"},
+		{"odd keys are quoted", `{"E1/1":1,"a b":2,"on":3,"":4}`, "E1/1: 1\n\"a b\": 2\n\"on\": 3\n\"\": 4\n"},
+		{"empty collections", `{"a":[],"b":{}}`, "a: []\nb: {}\n"},
+		{"sequences of mappings", `{"items":[{"k":"v","m":[1,2]},[],"x"]}`,
+			"items:\n  - k: \"v\"\n    m:\n      - 1\n      - 2\n  - []\n  - \"x\"\n"},
+		{"top-level array", `[1,{"a":2}]`, "- 1\n- a: 2\n"},
+		{"number text is preserved", `{"f":1.50,"big":12345678901234567890}`, "f: 1.50\nbig: 12345678901234567890\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromJSON([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("FromJSON(%s) =\n%s\nwant\n%s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromJSONRejectsMalformedInput(t *testing.T) {
+	for _, in := range []string{``, `{"a":`, `{"a":1} {"b":2}`} {
+		if _, err := FromJSON([]byte(in)); err == nil {
+			t.Errorf("FromJSON(%q) succeeded, want an error", in)
+		}
+	}
+}
@@ -2,12 +2,21 @@
 
 /**
  * Profile Verification Tool - HNC v0.3
- * Validates switch profiles against schema and checks key ordering
+ * Validates switch profiles against schema, checks they are canonical JSON,
+ * and checks them against the manifest.json the generator writes beside them.
+ * Reads a fixtures directory of either layout hnc profiles dump writes.
+ * Usage: node tools/verify-profiles.js [DIR] (default: src/fixtures/switch-profiles)
  */
 
+import { createHash } from 'crypto';
 import { readFile, readdir } from 'fs/promises';
 import { join } from 'path';
 
+// The generator's provenance record (pkg/manifest) and a tree layout's
+// index of its profiles (pkg/profiles/layout.go), neither a profile
+const MANIFEST = 'manifest.json';
+const INDEX = 'index.json';
+
 // Expected schema structure with key ordering
 const EXPECTED_SCHEMA = {
   modelId: 'string',
@@ -32,13 +41,6 @@ const EXPECTED_SCHEMA = {
   }
 };
 
-// Expected key order for deterministic output
-const EXPECTED_KEY_ORDER = ['modelId', 'roles', 'ports', 'profiles', 'meta'];
-const EXPECTED_PORTS_ORDER = ['endpointAssignable', 'fabricAssignable'];
-const EXPECTED_PROFILES_ORDER = ['endpoint', 'uplink'];
-const EXPECTED_PROFILE_ORDER = ['portProfile', 'speedGbps'];
-const EXPECTED_META_ORDER = ['source', 'version'];
-
 /**
  * Validates the structure of a switch profile
  */
@@ -101,93 +103,133 @@ function validateProfileStructure(profile, filename) {
 }
 
 /**
- * Validates key ordering for deterministic output
+ * Profile JSON as the Go tools write it (pkg/canonjson): keys sorted
+ * recursively, two-space indentation and a trailing newline
  */
-function validateKeyOrdering(profile, filename) {
-  const errors = [];
-
-  // Check top-level key order
-  const topKeys = Object.keys(profile);
-  const expectedKeys = EXPECTED_KEY_ORDER.filter(key => key in profile);
-  if (JSON.stringify(topKeys) !== JSON.stringify(expectedKeys)) {
-    errors.push(`${filename}: top-level keys not in expected order. Expected: ${expectedKeys.join(', ')}, Got: ${topKeys.join(', ')}`);
-  }
-
-  // Check ports key order
-  if (profile.ports) {
-    const portsKeys = Object.keys(profile.ports);
-    const expectedPortsKeys = EXPECTED_PORTS_ORDER.filter(key => key in profile.ports);
-    if (JSON.stringify(portsKeys) !== JSON.stringify(expectedPortsKeys)) {
-      errors.push(`${filename}: ports keys not in expected order. Expected: ${expectedPortsKeys.join(', ')}, Got: ${portsKeys.join(', ')}`);
-    }
-  }
-
-  // Check profiles key order
-  if (profile.profiles) {
-    const profilesKeys = Object.keys(profile.profiles);
-    const expectedProfilesKeys = EXPECTED_PROFILES_ORDER.filter(key => key in profile.profiles);
-    if (JSON.stringify(profilesKeys) !== JSON.stringify(expectedProfilesKeys)) {
-      errors.push(`${filename}: profiles keys not in expected order. Expected: ${expectedProfilesKeys.join(', ')}, Got: ${profilesKeys.join(', ')}`);
-    }
+function canonicalJson(value) {
+  return JSON.stringify(sortKeys(value), null, 2) + '\n';
+}
 
-    // Check individual profile key order
-    ['endpoint', 'uplink'].forEach(profileType => {
-      if (profile.profiles[profileType]) {
-        const profileKeys = Object.keys(profile.profiles[profileType]);
-        const expectedProfileKeys = EXPECTED_PROFILE_ORDER.filter(key => key in profile.profiles[profileType]);
-        if (JSON.stringify(profileKeys) !== JSON.stringify(expectedProfileKeys)) {
-          errors.push(`${filename}: profiles.${profileType} keys not in expected order. Expected: ${expectedProfileKeys.join(', ')}, Got: ${profileKeys.join(', ')}`);
-        }
-      }
-    });
+function sortKeys(value) {
+  if (Array.isArray(value)) return value.map(sortKeys);
+  if (value && typeof value === 'object') {
+    return Object.fromEntries(
+      Object.keys(value).sort().map(key => [key, sortKeys(value[key])])
+    );
   }
+  return value;
+}
 
-  // Check meta key order
-  if (profile.meta) {
-    const metaKeys = Object.keys(profile.meta);
-    const expectedMetaKeys = EXPECTED_META_ORDER.filter(key => key in profile.meta);
-    if (JSON.stringify(metaKeys) !== JSON.stringify(expectedMetaKeys)) {
-      errors.push(`${filename}: meta keys not in expected order. Expected: ${expectedMetaKeys.join(', ')}, Got: ${metaKeys.join(', ')}`);
-    }
+/**
+ * Checks the file is byte-identical to its canonical form, so regenerating
+ * it would not change it
+ */
+function validateCanonicalForm(content, profile, filename) {
+  if (content === canonicalJson(profile)) {
+    return [];
   }
-
-  return errors;
+  return [`${filename}: not canonical JSON (sorted keys, two-space indent, trailing newline); regenerate it with hnc profiles dump`];
 }
 
 /**
- * Validates a single profile file
+ * Validates a single profile file, named by its path in the fixtures
+ * directory
  */
-async function validateProfileFile(filePath) {
+async function validateProfileFile(filePath, filename) {
   try {
     const content = await readFile(filePath, 'utf-8');
     const profile = JSON.parse(content);
-    const filename = filePath.split('/').pop();
 
     const structureErrors = validateProfileStructure(profile, filename);
-    const orderingErrors = validateKeyOrdering(profile, filename);
+    const canonicalErrors = validateCanonicalForm(content, profile, filename);
 
-    return [...structureErrors, ...orderingErrors];
+    return [...structureErrors, ...canonicalErrors];
   } catch (error) {
     return [`Failed to parse ${filePath}: ${error.message}`];
   }
 }
 
+/**
+ * The profile files of a fixtures directory in either layout, by path in
+ * it: those a tree's index lists, or the JSON files at the top of a flat
+ * directory
+ */
+async function profileFiles(fixturesDir) {
+  let index;
+  try {
+    index = JSON.parse(await readFile(join(fixturesDir, INDEX), 'utf-8'));
+  } catch (error) {
+    if (error.code !== 'ENOENT') throw new Error(`${INDEX}: ${error.message}`);
+    const files = await readdir(fixturesDir);
+    return files.filter(f => f.endsWith('.json') && f !== MANIFEST).sort();
+  }
+  if (!Array.isArray(index.profiles)) {
+    throw new Error(`${INDEX}: profiles must be an array`);
+  }
+  return index.profiles.map(({ path }) => {
+    if (typeof path !== 'string' || path.startsWith('/') || path.split('/').some(part => part === '' || part === '..')) {
+      throw new Error(`${INDEX}: ${path} is outside ${fixturesDir}`);
+    }
+    return path;
+  }).sort();
+}
+
+/**
+ * Checks every file the manifest lists against its SHA-256 checksum and
+ * size, and that every profile is listed, as hnc profiles verify does
+ */
+async function verifyManifest(fixturesDir, profileFiles) {
+  let manifest;
+  try {
+    manifest = JSON.parse(await readFile(join(fixturesDir, MANIFEST), 'utf-8'));
+  } catch (error) {
+    if (error.code === 'ENOENT') return [];
+    return [`${MANIFEST}: ${error.message}`];
+  }
+  if (!Array.isArray(manifest.files)) {
+    return [`${MANIFEST}: files must be an array`];
+  }
+
+  const errors = [];
+  for (const entry of manifest.files) {
+    let data;
+    try {
+      data = await readFile(join(fixturesDir, entry.name));
+    } catch (error) {
+      if (error.code !== 'ENOENT') throw error;
+      errors.push(`${entry.name}: listed in ${MANIFEST} but missing`);
+      continue;
+    }
+    const sha256 = createHash('sha256').update(data).digest('hex');
+    if (sha256 !== entry.sha256 || data.length !== entry.bytes) {
+      errors.push(`${entry.name}: changed since it was generated at ${entry.generatedAt} ` +
+        `(SHA-256 ${sha256}, ${data.length} bytes; manifest ${entry.sha256}, ${entry.bytes} bytes)`);
+    }
+  }
+  const listed = new Set(manifest.files.map(entry => entry.name));
+  for (const file of profileFiles) {
+    if (!listed.has(file)) {
+      errors.push(`${file}: not in ${MANIFEST}; it was not generated`);
+    }
+  }
+  return errors;
+}
+
 /**
  * Main verification function
  */
 async function verifyProfiles() {
-  const fixturesDir = 'src/fixtures/switch-profiles';
+  const fixturesDir = process.argv[2] || 'src/fixtures/switch-profiles';
   let allErrors = [];
 
   try {
-    const files = await readdir(fixturesDir);
-    const jsonFiles = files.filter(f => f.endsWith('.json'));
+    const jsonFiles = await profileFiles(fixturesDir);
 
     console.log(`🔍 Verifying ${jsonFiles.length} profile files in ${fixturesDir}/`);
 
     for (const file of jsonFiles) {
       const filePath = join(fixturesDir, file);
-      const errors = await validateProfileFile(filePath);
+      const errors = await validateProfileFile(filePath, file);
       
       if (errors.length === 0) {
         console.log(`✅ ${file}: Valid`);
@@ -198,6 +240,15 @@ async function verifyProfiles() {
       }
     }
 
+    const manifestErrors = await verifyManifest(fixturesDir, jsonFiles);
+    if (manifestErrors.length === 0) {
+      console.log(`✅ ${MANIFEST}: checksums match`);
+    } else {
+      console.log(`❌ ${MANIFEST}: ${manifestErrors.length} error(s)`);
+      manifestErrors.forEach(error => console.log(`   ${error}`));
+      allErrors.push(...manifestErrors);
+    }
+
     if (allErrors.length === 0) {
       console.log('\n🎉 All profile files are valid!');
       process.exit(0);